@@ -10,6 +10,9 @@ var (
 	ErrRepoBadVersion                 = errors.New("repository: unsupported layout version")
 	ErrManifestNotFound               = errors.New("manifest: not found")
 	ErrBadManifest                    = errors.New("manifest: invalid contents")
+	ErrManifestTooLarge               = errors.New("manifest: decompressed manifest is too large")
+	ErrUnsupportedContentEncoding     = errors.New("manifest: unsupported content encoding")
+	ErrSchema1ManifestRejected        = errors.New("manifest: docker schema1 manifests are deprecated and not accepted by this registry, push the image with a client producing docker schema2 or oci manifests") //nolint:lll
 	ErrBadIndex                       = errors.New("index: invalid contents")
 	ErrUploadNotFound                 = errors.New("uploads: not found")
 	ErrBadUploadRange                 = errors.New("uploads: bad range")
@@ -32,6 +35,7 @@ var (
 	ErrInvalidArgs                    = errors.New("cli: Invalid Arguments")
 	ErrInvalidFlagsCombination        = errors.New("cli: Invalid combination of flags")
 	ErrInvalidURL                     = errors.New("cli: invalid URL format")
+	ErrInvalidFilter                  = errors.New("cli: invalid filter expression")
 	ErrExtensionNotEnabled            = errors.New("cli: functionality is not built in current version")
 	ErrUnauthorizedAccess             = errors.New("auth: unauthorized access. check credentials")
 	ErrCannotResetConfigKey           = errors.New("cli: cannot reset given config key")
@@ -88,16 +92,90 @@ var (
 	ErrCouldNotMarshalBookmarkedRepos = errors.New("repodb: could not repack entry for user bookmarked repos")
 	ErrUserDataNotFound               = errors.New("repodb: user data not found for given user identifier")
 	ErrUserDataNotAllowed             = errors.New("repodb: user data operations are not allowed")
+	ErrInvalidOldUserActivity         = errors.New("repodb: invalid old entry for user activity")
+	ErrCouldNotMarshalUserActivity    = errors.New("repodb: could not repack entry for user activity")
 	ErrCouldNotPersistData            = errors.New("repodb: could not persist to db")
 	ErrDedupeRebuild                  = errors.New("dedupe: couldn't rebuild dedupe index")
+	ErrReflinkNotSupported            = errors.New("dedupe: reflinks are not supported by the filesystem")
 	ErrSignConfigDirNotSet            = errors.New("signatures: signature config dir not set")
 	ErrBadManifestDigest              = errors.New("signatures: bad manifest digest")
 	ErrInvalidSignatureType           = errors.New("signatures: invalid signature type")
 	ErrSyncPingRegistry               = errors.New("sync: unable to ping any registry URLs")
 	ErrSyncImageNotSigned             = errors.New("sync: image is not signed")
 	ErrSyncImageFilteredOut           = errors.New("sync: image is filtered out by sync config")
+	ErrSyncSubjectNotFound            = errors.New("sync: image is not present locally, only its referrers are synced")
+	ErrSyncTagConflict                = errors.New("sync: tag points to different digests on local and peer registry")
 	ErrCallerInfo                     = errors.New("runtime: failed to get info regarding the current runtime")
 	ErrInvalidTruststoreType          = errors.New("signatures: invalid truststore type")
 	ErrInvalidTruststoreName          = errors.New("signatures: invalid truststore name")
 	ErrInvalidCertificateContent      = errors.New("signatures: invalid certificate content")
+	ErrBadDigestPrefix                = errors.New("digest: prefix is not a valid abbreviated digest")
+	ErrDigestPrefixTooShort           = errors.New("digest: prefix is shorter than the minimum length")
+	ErrAmbiguousDigestPrefix          = errors.New("digest: prefix matches more than one manifest")
+	ErrPinNotFound                    = errors.New("repodb: pin not found for given reference")
+	ErrBadRegistryAnnotation          = errors.New("repodb: annotation keys can't be empty or contain '='")
+	ErrImageEncrypted                 = errors.New("cveinfo: image layers are encrypted and can't be scanned")
+	ErrCacheMaintenanceUnsupported    = errors.New("cache: driver can't be checked or compacted")
+	ErrGCCheckpointUnsupported        = errors.New("cache: driver can't record gc checkpoints")
+	ErrCacheCorrupted                 = errors.New("cache: integrity check found issues")
+	ErrTagLimitReached                = errors.New("quota: repository tag limit reached")
+	ErrRepoLimitReached               = errors.New("quota: namespace repository limit reached")
+	ErrRepoStorageQuotaExceeded       = errors.New("quota: repository storage quota exceeded")
+	ErrStorageQuotaExceeded           = errors.New("quota: registry storage quota exceeded")
+	ErrDigestBlocked                  = errors.New("blocklist: digest is blocked")
+	ErrBlocklistEntryNotFound         = errors.New("blocklist: digest is not blocked")
+	ErrBlocklistEntryFromConfig       = errors.New("blocklist: digest is blocked by the config file")
+	ErrScanCanceled                   = errors.New("cve: scan was canceled")
+	ErrCVEAcknowledgementNotFound     = errors.New("cve: CVE is not acknowledged for this image")
+	ErrBadCVEAcknowledgement          = errors.New("cve: invalid CVE acknowledgement")
+	ErrCVEReportNotGenerated          = errors.New("cve: no vulnerability report was generated yet")
+	ErrCVEReportWebhookFailed         = errors.New("cve: vulnerability report webhook returned an error status")
+	ErrBadSBOM                        = errors.New("repodb: invalid SBOM")
+	ErrNFSExclusiveCreate             = errors.New("storage: exclusive file creation doesn't work on filesystem")
+	ErrLeaseNotFound                  = errors.New("lease: lease not found or expired")
+	ErrBadLeaseDuration               = errors.New("lease: duration must be positive and not exceed the max duration")
+	ErrUnknownRole                    = errors.New("authz: unknown role")
+	ErrRoleBindingNotFound            = errors.New("authz: role binding not found")
+	ErrTelemetryEndpointFailed        = errors.New("telemetry: endpoint returned an error status")
+	ErrPluginAlreadyLoaded            = errors.New("plugins: a plugin with the same name is already loaded")
+	ErrHTPasswdUnavailable            = errors.New("auth: htpasswd file is unavailable")
+	ErrBadPassphraseHash              = errors.New("auth: unsupported or malformed passphrase hash")
+	ErrPassphraseMismatch             = errors.New("auth: passphrase doesn't match")
+	ErrBadTagAliasRule                = errors.New("config: invalid tag alias rule")
+	ErrTagAliasDenied                 = errors.New("tagalias: not allowed to update an existing tag alias")
+	ErrBadRetentionPolicy             = errors.New("config: invalid retention policy")
+	ErrBadDownloadsConfig             = errors.New("config: invalid download counts config")
+	ErrStorageProbeFailed             = errors.New("storage: root directory can't be reached")
+	ErrStorageProbeTimeout            = errors.New("storage: probe timed out")
+	ErrCircuitOpen                    = errors.New("breaker: circuit is open, dependency keeps failing")
+	ErrBadTenant                      = errors.New("tenancy: invalid tenant")
+	ErrTenantNotFound                 = errors.New("tenancy: tenant not found")
+	ErrTenantFromConfig               = errors.New("tenancy: tenant is defined in the config file")
+	ErrBadPromotionGate               = errors.New("config: invalid promotion gate")
+	ErrTagProtected                   = errors.New("promotion: tag can only be created through the promotion API")
+	ErrImageNotSigned                 = errors.New("promotion: image is not signed")
+	ErrImageNotScanned                = errors.New("promotion: image can't be scanned")
+	ErrImageVulnerable                = errors.New("promotion: image has vulnerabilities at or above the threshold")
+	ErrNoProvenance                   = errors.New("promotion: image has no provenance attestation")
+	ErrBadPullTokensConfig            = errors.New("config: invalid pull tokens config")
+	ErrBadPullToken                   = errors.New("pulltoken: invalid pull token")
+	ErrPullTokenTTL                   = errors.New("pulltoken: validity exceeds the maximum allowed")
+	ErrDirectIONotSupported           = errors.New("storage: direct IO is not supported on this platform")
+	ErrLayoutVersionNotSupported      = errors.New("storage: layout version is newer than supported")
+	ErrBadLayoutVersion               = errors.New("storage: invalid layout version file")
+	ErrGCSRequestFailed               = errors.New("gcs: request failed")
+	ErrBadUploadSession               = errors.New("gcs: invalid upload session")
+	ErrFileWriterDone                 = errors.New("storage: file writer is already closed, committed or cancelled")
+	ErrBadPreReceiveHook              = errors.New("config: invalid pre-receive hook")
+	ErrManifestRejected               = errors.New("hooks: manifest rejected by pre-receive hook")
+	ErrPreReceiveHookFailed           = errors.New("hooks: pre-receive hook is unreachable or returned an error")
+	ErrBadBlobPolicy                  = errors.New("config: invalid blob policy")
+	ErrBlobTooLarge                   = errors.New("blobpolicy: blob is larger than allowed in the repository")
+	ErrLayerMediaTypeNotAllowed       = errors.New("blobpolicy: layer media type is not allowed in the repository")
+	ErrBadLayerRecompression          = errors.New("config: invalid layer recompression")
+	ErrBadReferrersTags               = errors.New("config: invalid referrers tags config")
+	ErrBadImmutableTagsRule           = errors.New("config: invalid immutable tags rule")
+	ErrTagImmutable                   = errors.New("immutabletags: immutable tags can't be moved to another manifest or deleted")
+	ErrBadAuditSnapshots              = errors.New("config: invalid audit snapshots config")
+	ErrBadAuditSnapshotSignature      = errors.New("audit: snapshot signature doesn't match")
 )
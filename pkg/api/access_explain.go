@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/plugins"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+// ExplainAccess godoc
+// @Summary Explain an access control decision
+// @Description Returns whether an identity can do an action on a repo and the rules of the access control config
+// @Description evaluated to decide it, to debug denied requests. Only admins can explain decisions.
+// @Router 	/v2/_zot/ext/access/explain [get]
+// @Produce json
+// @Param   username query   string     false       "user name, anonymous if not set"
+// @Param   groups   query   string     false       "comma separated groups of the user, added to its config groups"
+// @Param   repo     query   string     true        "repository name"
+// @Param   action   query   string     true        "create, read, update, delete or detectManifestCollision"
+// @Success 200 {object} 	api.AccessDecision
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 500 {string} 	string 				"internal server error".
+func (rh *RouteHandler) ExplainAccess(response http.ResponseWriter, request *http.Request) {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if acCtx == nil || !acCtx.IsAdmin {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	query := request.URL.Query()
+
+	username, repo, action := query.Get("username"), query.Get("repo"), query.Get("action")
+	if repo == "" || !zcommon.Contains([]string{Create, Read, Update, Delete, DetectManifestCollision}, action) {
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	acCtrlr := NewAccessController(rh.c.Config, rh.c.RoleBindings)
+
+	// the groups of the config are the ones the authentication adds to the groups of the user
+	groups := acCtrlr.getUserGroups(username)
+
+	if groupsStr := query.Get("groups"); groupsStr != "" {
+		for _, group := range strings.Split(groupsStr, ",") {
+			if !zcommon.Contains(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	decision := acCtrlr.explain(username, groups, action, repo)
+
+	// the authorization plugins can override the config
+	allowed := rh.c.Plugins.Authorize(request.Context(), plugins.AuthzRequest{
+		Username: username,
+		Groups:   groups,
+		Action:   action,
+		Repo:     repo,
+	}, decision.Allowed)
+	if allowed != decision.Allowed {
+		decision.Rules = append(decision.Rules, AccessRule{
+			Kind:    "plugins",
+			Actions: []string{action},
+			Matched: true,
+			Allowed: allowed,
+		})
+		decision.Allowed = allowed
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, decision)
+}
@@ -5,22 +5,25 @@ import (
 	"context"
 	"crypto/x509"
 	"encoding/base64"
+	goerrors "errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/chartmuseum/auth"
 	"github.com/gorilla/mux"
-	"golang.org/x/crypto/bcrypt"
 
 	"zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/api/config"
 	"zotregistry.io/zot/pkg/api/constants"
 	apiErr "zotregistry.io/zot/pkg/api/errors"
+	"zotregistry.io/zot/pkg/api/htpasswd"
 	"zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/log"
 	localCtx "zotregistry.io/zot/pkg/requestcontext"
 )
 
@@ -29,23 +32,39 @@ const (
 )
 
 func AuthHandler(c *Controller) mux.MiddlewareFunc {
-	if isBearerAuthEnabled(c.Config) {
-		return bearerAuthHandler(c)
+	return ListenerAuthHandler(c, c.Config.HTTP.Auth)
+}
+
+// ListenerAuthHandler authenticates the requests with authConfig, the auth settings of the listener serving them.
+func ListenerAuthHandler(c *Controller, authConfig *config.AuthConfig) mux.MiddlewareFunc {
+	if isBearerAuthEnabled(authConfig) {
+		return bearerAuthHandler(c, authConfig)
 	}
 
-	return basicAuthHandler(c)
+	return basicAuthHandler(c, authConfig)
 }
 
-func bearerAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
-	authorizer, err := auth.NewAuthorizer(&auth.AuthorizerOptions{
-		Realm:                 ctlr.Config.HTTP.Auth.Bearer.Realm,
-		Service:               ctlr.Config.HTTP.Auth.Bearer.Service,
-		PublicKeyPath:         ctlr.Config.HTTP.Auth.Bearer.Cert,
+func newBearerAuthorizer(authConfig *config.AuthConfig) (*auth.Authorizer, error) {
+	return auth.NewAuthorizer(&auth.AuthorizerOptions{
+		Realm:                 authConfig.Bearer.Realm,
+		Service:               authConfig.Bearer.Service,
+		PublicKeyPath:         authConfig.Bearer.Cert,
 		AccessEntryType:       bearerAuthDefaultAccessEntryType,
 		EmptyDefaultNamespace: true,
 	})
+}
+
+func bearerAuthHandler(ctlr *Controller, authConfig *config.AuthConfig) mux.MiddlewareFunc {
+	authorizer, err := newBearerAuthorizer(authConfig)
 	if err != nil {
-		ctlr.Log.Panic().Err(err).Msg("error creating bearer authorizer")
+		// already checked by Controller.Init, fail closed if the cert became unreadable since then
+		ctlr.Log.Error().Err(err).Msg("error creating bearer authorizer")
+
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				response.WriteHeader(http.StatusInternalServerError)
+			})
+		}
 	}
 
 	return func(next http.Handler) http.Handler {
@@ -70,6 +89,13 @@ func bearerAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 				return
 			}
 
+			// pull tokens minted by zot are checked before the tokens of the auth server
+			if ctlr.authorizePullToken(request) {
+				next.ServeHTTP(response, request)
+
+				return
+			}
+
 			action := auth.PullAction
 			if m := request.Method; m != http.MethodGet && m != http.MethodHead {
 				action = auth.PushAction
@@ -112,7 +138,7 @@ func noPasswdAuth(realm string, config *config.Config) mux.MiddlewareFunc {
 }
 
 //nolint:gocyclo  // we use closure making this a complex subroutine
-func basicAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
+func basicAuthHandler(ctlr *Controller, authConfig *config.AuthConfig) mux.MiddlewareFunc {
 	realm := ctlr.Config.HTTP.Realm
 	if realm == "" {
 		realm = "Authorization Required"
@@ -121,23 +147,26 @@ func basicAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 	realm = "Basic realm=" + strconv.Quote(realm)
 
 	// no password based authN, if neither LDAP nor HTTP BASIC is enabled
-	if ctlr.Config.HTTP.Auth == nil ||
-		(ctlr.Config.HTTP.Auth.HTPasswd.Path == "" && ctlr.Config.HTTP.Auth.LDAP == nil) {
+	if !isAuthnEnabled(authConfig) {
 		return noPasswdAuth(realm, ctlr.Config)
 	}
 
-	credMap := make(map[string]string)
-
-	delay := ctlr.Config.HTTP.Auth.FailDelay
+	delay := authConfig.FailDelay
 
 	var ldapClient *LDAPClient
 
-	if ctlr.Config.HTTP.Auth != nil {
-		if ctlr.Config.HTTP.Auth.LDAP != nil {
-			ldapConfig := ctlr.Config.HTTP.Auth.LDAP
+	var htpasswdCreds *htpasswdCredentials
+
+	if authConfig != nil {
+		if authConfig.LDAP != nil {
+			ldapConfig := authConfig.LDAP
 			ldapClient = &LDAPClient{
 				Host:               ldapConfig.Address,
 				Port:               ldapConfig.Port,
+				Addresses:          ldapConfig.Addresses,
+				DialTimeout:        ldapConfig.DialTimeout,
+				FailureThreshold:   ldapConfig.FailureThreshold,
+				BreakerTimeout:     ldapConfig.BreakerTimeout,
 				UseSSL:             !ldapConfig.Insecure,
 				SkipTLS:            !ldapConfig.StartTLS,
 				Base:               ldapConfig.BaseDN,
@@ -151,46 +180,19 @@ func basicAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 				SubtreeSearch:      ldapConfig.SubtreeSearch,
 			}
 
-			if ctlr.Config.HTTP.Auth.LDAP.CACert != "" {
-				caCert, err := os.ReadFile(ctlr.Config.HTTP.Auth.LDAP.CACert)
-				if err != nil {
-					panic(err)
-				}
-
-				caCertPool := x509.NewCertPool()
-
-				if !caCertPool.AppendCertsFromPEM(caCert) {
-					panic(errors.ErrBadCACert)
-				}
-
-				ldapClient.ClientCAs = caCertPool
-			} else {
-				// default to system cert pool
-				caCertPool, err := x509.SystemCertPool()
-				if err != nil {
-					panic(errors.ErrBadCACert)
-				}
-
-				ldapClient.ClientCAs = caCertPool
-			}
-		}
-
-		if ctlr.Config.HTTP.Auth.HTPasswd.Path != "" {
-			credsFile, err := os.Open(ctlr.Config.HTTP.Auth.HTPasswd.Path)
+			caCertPool, err := getLDAPCACertPool(ldapConfig)
 			if err != nil {
-				panic(err)
+				// already checked by Controller.Init, fail closed if the cert became unreadable since then
+				ctlr.Log.Error().Err(err).Msg("unable to load ldap CA cert")
+
+				return failAuth
 			}
-			defer credsFile.Close()
 
-			scanner := bufio.NewScanner(credsFile)
+			ldapClient.ClientCAs = caCertPool
+		}
 
-			for scanner.Scan() {
-				line := scanner.Text()
-				if strings.Contains(line, ":") {
-					tokens := strings.Split(scanner.Text(), ":")
-					credMap[tokens[0]] = tokens[1]
-				}
-			}
+		if authConfig.HTPasswd.Path != "" {
+			htpasswdCreds = newHTPasswdCredentials(authConfig.HTPasswd.Path, ctlr.Log)
 		}
 	}
 
@@ -236,15 +238,30 @@ func basicAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 				}
 			}
 
+			// set if a backend couldn't tell whether the credentials are valid
+			var backendErr error
+
 			// first, HTTPPassword authN (which is local)
-			passphraseHash, ok := credMap[username]
+			var passphraseHash string
+
+			ok := false
+
+			if htpasswdCreds != nil {
+				passphraseHash, ok, backendErr = htpasswdCreds.lookup(username)
+			}
+
 			if ok {
-				if err := bcrypt.CompareHashAndPassword([]byte(passphraseHash), []byte(passphrase)); err == nil {
+				err := htpasswd.CompareHashAndPassword(passphraseHash, passphrase)
+				if goerrors.Is(err, errors.ErrBadPassphraseHash) {
+					ctlr.Log.Error().Err(err).Str("username", username).Msg("unable to check htpasswd passphrase")
+				}
+
+				if err == nil {
 					// Process request
 					var userGroups []string
 
 					if ctlr.Config.HTTP.AccessControl != nil {
-						ac := NewAccessController(ctlr.Config)
+						ac := NewAccessController(ctlr.Config, ctlr.RoleBindings)
 						userGroups = ac.getUserGroups(username)
 					}
 
@@ -256,14 +273,18 @@ func basicAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 			}
 
 			// next, LDAP if configured (network-based which can lose connectivity)
-			if ctlr.Config.HTTP.Auth != nil && ctlr.Config.HTTP.Auth.LDAP != nil {
+			if ldapClient != nil {
 				ok, _, ldapgroups, err := ldapClient.Authenticate(username, passphrase)
+				if goerrors.Is(err, errors.ErrLDAPBadConn) {
+					backendErr = err
+				}
+
 				if ok && err == nil {
 					// Process request
 					var userGroups []string
 
 					if ctlr.Config.HTTP.AccessControl != nil {
-						ac := NewAccessController(ctlr.Config)
+						ac := NewAccessController(ctlr.Config, ctlr.RoleBindings)
 						userGroups = ac.getUserGroups(username)
 					}
 
@@ -276,11 +297,167 @@ func basicAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 				}
 			}
 
+			// the anonymous policy still applies, the fallback only prevents outages of reads
+			if backendErr != nil && authConfig.FallbackToAnonymousRead &&
+				(request.Method == http.MethodGet || request.Method == http.MethodHead) {
+				ctlr.Log.Warn().Err(backendErr).Str("username", username).
+					Msg("auth backend unavailable, serving read request as anonymous")
+
+				ctx := getReqContextWithAuthorization("", []string{}, request)
+				next.ServeHTTP(response, request.WithContext(ctx)) //nolint:contextcheck
+
+				return
+			}
+
 			authFail(response, realm, delay)
 		})
 	}
 }
 
+// failAuth is used instead of an authn handler which couldn't be set up, every request is failed.
+func failAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(http.StatusInternalServerError)
+	})
+}
+
+// checkAuthBackends returns an error explaining how to fix the auth config if an auth backend can't be loaded,
+// an unreadable htpasswd file is only logged if reads can fall back to anonymous requests.
+func checkAuthBackends(authConfig *config.AuthConfig, log log.Logger) error {
+	if authConfig == nil {
+		return nil
+	}
+
+	if authConfig.LDAP != nil {
+		if _, err := getLDAPCACertPool(authConfig.LDAP); err != nil {
+			return err
+		}
+	}
+
+	if authConfig.HTPasswd.Path != "" {
+		if _, err := loadHTPasswd(authConfig.HTPasswd.Path); err != nil {
+			if !authConfig.FallbackToAnonymousRead {
+				return fmt.Errorf("%w, or set fallbackToAnonymousRead to serve anonymous reads meanwhile", err)
+			}
+
+			log.Warn().Err(err).Msg("htpasswd unavailable, serving reads as anonymous requests until it can be read")
+		}
+	}
+
+	return nil
+}
+
+func getLDAPCACertPool(ldapConfig *config.LDAPConfig) (*x509.CertPool, error) {
+	if ldapConfig.CACert == "" {
+		// default to system cert pool
+		caCertPool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to load the system cert pool, set the ldap CA cert to use instead: %w",
+				errors.ErrBadCACert, err)
+		}
+
+		return caCertPool, nil
+	}
+
+	caCert, err := os.ReadFile(ldapConfig.CACert)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read ldap CA cert %s, check that it exists and is readable "+
+			"by the user running zot: %w", errors.ErrBadCACert, ldapConfig.CACert, err)
+	}
+
+	caCertPool := x509.NewCertPool()
+
+	if !caCertPool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("%w: ldap CA cert %s doesn't contain any PEM encoded certificate",
+			errors.ErrBadCACert, ldapConfig.CACert)
+	}
+
+	return caCertPool, nil
+}
+
+func loadHTPasswd(path string) (map[string]string, error) {
+	credsFile, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read htpasswd file %s, check that it exists and is readable "+
+			"by the user running zot: %w", errors.ErrHTPasswdUnavailable, path, err)
+	}
+	defer credsFile.Close()
+
+	credMap := make(map[string]string)
+	scanner := bufio.NewScanner(credsFile)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, ":") {
+			tokens := strings.Split(scanner.Text(), ":")
+			credMap[tokens[0]] = tokens[1]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: unable to read htpasswd file %s: %w", errors.ErrHTPasswdUnavailable, path, err)
+	}
+
+	return credMap, nil
+}
+
+// htpasswdCredentials holds the users of the htpasswd file, if it couldn't be read it is read again by
+// the next requests, at most once per htpasswdRetryInterval, so zot recovers once it is available.
+type htpasswdCredentials struct {
+	path     string
+	credMap  map[string]string
+	err      error
+	loadedAt time.Time
+	lock     *sync.Mutex
+	log      log.Logger
+}
+
+const htpasswdRetryInterval = 10 * time.Second
+
+func newHTPasswdCredentials(path string, log log.Logger) *htpasswdCredentials {
+	creds := &htpasswdCredentials{path: path, lock: &sync.Mutex{}, log: log}
+	creds.load()
+
+	return creds
+}
+
+func (creds *htpasswdCredentials) load() {
+	creds.credMap, creds.err = loadHTPasswd(creds.path)
+	creds.loadedAt = time.Now()
+
+	if creds.err != nil {
+		creds.log.Error().Err(creds.err).Msg("unable to load htpasswd")
+
+		return
+	}
+
+	for username, passphraseHash := range creds.credMap {
+		if htpasswd.GetFormat(passphraseHash) == "" {
+			creds.log.Warn().Str("username", username).Str("path", creds.path).
+				Msg("unsupported htpasswd passphrase hash, the user can't log in, " +
+					"use bcrypt, argon2id, scrypt or sha512-crypt")
+		}
+	}
+}
+
+// lookup returns the passphrase hash of a user, an error is returned if the htpasswd file couldn't be read.
+func (creds *htpasswdCredentials) lookup(username string) (string, bool, error) {
+	creds.lock.Lock()
+	defer creds.lock.Unlock()
+
+	if creds.err != nil && time.Since(creds.loadedAt) >= htpasswdRetryInterval {
+		creds.load()
+	}
+
+	if creds.err != nil {
+		return "", false, creds.err
+	}
+
+	passphraseHash, ok := creds.credMap[username]
+
+	return passphraseHash, ok, nil
+}
+
 func getReqContextWithAuthorization(username string, groups []string, request *http.Request) context.Context {
 	acCtx := localCtx.AccessControlContext{
 		Username: username,
@@ -293,21 +470,21 @@ func getReqContextWithAuthorization(username string, groups []string, request *h
 	return ctx
 }
 
-func isAuthnEnabled(config *config.Config) bool {
-	if config.HTTP.Auth != nil &&
-		(config.HTTP.Auth.HTPasswd.Path != "" || config.HTTP.Auth.LDAP != nil) {
+func isAuthnEnabled(authConfig *config.AuthConfig) bool {
+	if authConfig != nil &&
+		(authConfig.HTPasswd.Path != "" || authConfig.LDAP != nil) {
 		return true
 	}
 
 	return false
 }
 
-func isBearerAuthEnabled(config *config.Config) bool {
-	if config.HTTP.Auth != nil &&
-		config.HTTP.Auth.Bearer != nil &&
-		config.HTTP.Auth.Bearer.Cert != "" &&
-		config.HTTP.Auth.Bearer.Realm != "" &&
-		config.HTTP.Auth.Bearer.Service != "" {
+func isBearerAuthEnabled(authConfig *config.AuthConfig) bool {
+	if authConfig != nil &&
+		authConfig.Bearer != nil &&
+		authConfig.Bearer.Cert != "" &&
+		authConfig.Bearer.Realm != "" &&
+		authConfig.Bearer.Service != "" {
 		return true
 	}
 
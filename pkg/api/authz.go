@@ -8,7 +8,9 @@ import (
 	"github.com/gorilla/mux"
 
 	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/roles"
 	"zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/plugins"
 	"zotregistry.io/zot/pkg/log"
 	localCtx "zotregistry.io/zot/pkg/requestcontext"
 )
@@ -29,9 +31,11 @@ type AccessController struct {
 	Log    log.Logger
 }
 
-func NewAccessController(config *config.Config) *AccessController {
+// NewAccessController returns an access controller enforcing the access control config along with the roles
+// assigned through the API.
+func NewAccessController(config *config.Config, bindings *roles.Bindings) *AccessController {
 	return &AccessController{
-		Config: config.HTTP.AccessControl,
+		Config: bindings.Apply(config.HTTP.AccessControl),
 		Log:    log.NewLogger(config.Log.Level, config.Log.Output),
 	}
 }
@@ -56,7 +60,7 @@ func (ac *AccessController) getGlobPatterns(username string, groups []string, ac
 
 		// check user based policy
 		for _, p := range policyGroup.Policies {
-			if common.Contains(p.Users, username) && common.Contains(p.Actions, action) {
+			if common.Contains(p.Users, username) && common.Contains(p.GetActions(), action) {
 				globPatterns[pattern] = true
 			}
 		}
@@ -64,7 +68,7 @@ func (ac *AccessController) getGlobPatterns(username string, groups []string, ac
 		// check group based policy
 		for _, group := range groups {
 			for _, p := range policyGroup.Policies {
-				if common.Contains(p.Groups, group) && common.Contains(p.Actions, action) {
+				if common.Contains(p.Groups, group) && common.Contains(p.GetActions(), action) {
 					globPatterns[pattern] = true
 				}
 			}
@@ -79,46 +83,121 @@ func (ac *AccessController) getGlobPatterns(username string, groups []string, ac
 	return globPatterns
 }
 
-// can verifies if a user can do action on repository.
-func (ac *AccessController) can(ctx context.Context, username, action, repository string) bool {
-	can := false
+// AccessRule is a rule of the access control config evaluated to authorize an action.
+type AccessRule struct {
+	// policy, defaultPolicy, anonymousPolicy or adminPolicy
+	Kind    string   `json:"kind"`
+	Pattern string   `json:"pattern,omitempty"`
+	Users   []string `json:"users,omitempty"`
+	Groups  []string `json:"groups,omitempty"`
+	Actions []string `json:"actions"`
+	// whether the rule applies to the identity
+	Matched bool `json:"matched"`
+	// whether the rule allows the action
+	Allowed bool `json:"allowed"`
+}
+
+// AccessDecision explains whether an identity can do an action on a repo.
+type AccessDecision struct {
+	Username   string   `json:"username"`
+	Groups     []string `json:"groups"`
+	Action     string   `json:"action"`
+	Repository string   `json:"repository"`
+	// the longest pattern matching the repo, only its policies apply to the repo
+	MatchedPattern string `json:"matchedPattern"`
+	// the rules evaluated, in order, the first one allowing the action ends the evaluation
+	Rules   []AccessRule `json:"rules"`
+	Allowed bool         `json:"allowed"`
+}
 
-	var longestMatchedPattern string
+// explain evaluates the rules of the access control config applying to an action of a user on a repository.
+func (ac *AccessController) explain(username string, userGroups []string, action, repository string,
+) AccessDecision {
+	decision := AccessDecision{
+		Username:   username,
+		Groups:     userGroups,
+		Action:     action,
+		Repository: repository,
+		Rules:      []AccessRule{},
+	}
 
 	for pattern := range ac.Config.Repositories {
 		matched, err := glob.Match(pattern, repository)
 		if err == nil {
-			if matched && len(pattern) > len(longestMatchedPattern) {
-				longestMatchedPattern = pattern
+			if matched && len(pattern) > len(decision.MatchedPattern) {
+				decision.MatchedPattern = pattern
 			}
 		}
 	}
 
-	acCtx, err := localCtx.GetAccessControlContext(ctx)
-	if err != nil {
-		return false
+	// check matched repo based policy
+	if pg, ok := ac.Config.Repositories[decision.MatchedPattern]; ok {
+		decision.Allowed = ac.explainPolicyGroup(&decision, pg)
 	}
 
-	userGroups := acCtx.Groups
+	// check admins based policy
+	if !decision.Allowed {
+		rule := AccessRule{
+			Kind:    "adminPolicy",
+			Users:   ac.Config.AdminPolicy.Users,
+			Groups:  ac.Config.AdminPolicy.Groups,
+			Actions: ac.Config.AdminPolicy.GetActions(),
+			Matched: ac.isAdmin(username) || ac.isAnyGroupInAdminPolicy(userGroups),
+		}
+		rule.Allowed = rule.Matched && common.Contains(rule.Actions, action)
 
-	// check matched repo based policy
-	pg, ok := ac.Config.Repositories[longestMatchedPattern]
-	if ok {
-		can = ac.isPermitted(userGroups, username, action, pg)
+		decision.Rules = append(decision.Rules, rule)
+		decision.Allowed = rule.Allowed
 	}
 
-	// check admins based policy
-	if !can {
-		if ac.isAdmin(username) && common.Contains(ac.Config.AdminPolicy.Actions, action) {
-			can = true
+	return decision
+}
+
+// explainPolicyGroup adds the rules of the policy group evaluated to the decision and returns whether they allow
+// the action.
+func (ac *AccessController) explainPolicyGroup(decision *AccessDecision, policyGroup config.PolicyGroup) bool {
+	// check repo/system based policies
+	for _, policy := range policyGroup.Policies {
+		rule := AccessRule{
+			Kind:    "policy",
+			Pattern: decision.MatchedPattern,
+			Users:   policy.Users,
+			Groups:  policy.Groups,
+			Actions: policy.GetActions(),
+			Matched: common.Contains(policy.Users, decision.Username),
+		}
+
+		for _, group := range policy.Groups {
+			if common.Contains(decision.Groups, group) {
+				rule.Matched = true
+			}
 		}
 
-		if ac.isAnyGroupInAdminPolicy(userGroups) && common.Contains(ac.Config.AdminPolicy.Actions, action) {
-			can = true
+		rule.Allowed = rule.Matched && common.Contains(rule.Actions, decision.Action)
+
+		decision.Rules = append(decision.Rules, rule)
+
+		if rule.Allowed {
+			return true
+		}
+	}
+
+	// check defaultPolicy, then anonymousPolicy
+	for _, rule := range []AccessRule{
+		{Kind: "defaultPolicy", Actions: policyGroup.DefaultPolicy, Matched: decision.Username != ""},
+		{Kind: "anonymousPolicy", Actions: policyGroup.AnonymousPolicy, Matched: decision.Username == ""},
+	} {
+		rule.Pattern = decision.MatchedPattern
+		rule.Allowed = rule.Matched && common.Contains(rule.Actions, decision.Action)
+
+		decision.Rules = append(decision.Rules, rule)
+
+		if rule.Allowed {
+			return true
 		}
 	}
 
-	return can
+	return false
 }
 
 // isAdmin .
@@ -154,9 +233,11 @@ func (ac *AccessController) getUserGroups(username string) []string {
 // getContext updates an AccessControlContext for a user/anonymous and returns a context.Context containing it.
 func (ac *AccessController) getContext(acCtx *localCtx.AccessControlContext, request *http.Request) context.Context {
 	readGlobPatterns := ac.getGlobPatterns(acCtx.Username, acCtx.Groups, Read)
+	updateGlobPatterns := ac.getGlobPatterns(acCtx.Username, acCtx.Groups, Update)
 	dmcGlobPatterns := ac.getGlobPatterns(acCtx.Username, acCtx.Groups, DetectManifestCollision)
 
 	acCtx.ReadGlobPatterns = readGlobPatterns
+	acCtx.UpdateGlobPatterns = updateGlobPatterns
 	acCtx.DmcGlobPatterns = dmcGlobPatterns
 
 	if ac.isAdmin(acCtx.Username) {
@@ -171,53 +252,7 @@ func (ac *AccessController) getContext(acCtx *localCtx.AccessControlContext, req
 	return ctx
 }
 
-// isPermitted returns true if username can do action on a repository policy.
-func (ac *AccessController) isPermitted(userGroups []string, username, action string,
-	policyGroup config.PolicyGroup,
-) bool {
-	var result bool
-
-	// check repo/system based policies
-	for _, p := range policyGroup.Policies {
-		if common.Contains(p.Users, username) && common.Contains(p.Actions, action) {
-			result = true
-
-			return result
-		}
-	}
-
-	if userGroups != nil {
-		for _, p := range policyGroup.Policies {
-			if common.Contains(p.Actions, action) {
-				for _, group := range p.Groups {
-					if common.Contains(userGroups, group) {
-						result = true
-
-						return result
-					}
-				}
-			}
-		}
-	}
-
-	// check defaultPolicy
-	if !result {
-		if common.Contains(policyGroup.DefaultPolicy, action) && username != "" {
-			result = true
-		}
-	}
-
-	// check anonymousPolicy
-	if !result {
-		if common.Contains(policyGroup.AnonymousPolicy, action) && username == "" {
-			result = true
-		}
-	}
-
-	return result
-}
-
-func BaseAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
+func BaseAuthzHandler(ctlr *Controller, authConfig *config.AuthConfig) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
 			/* NOTE:
@@ -238,7 +273,7 @@ func BaseAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 				return
 			}
 
-			acCtrlr := NewAccessController(ctlr.Config)
+			acCtrlr := NewAccessController(ctlr.Config, ctlr.RoleBindings)
 
 			var identity string
 
@@ -248,11 +283,11 @@ func BaseAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 			acCtx := &localCtx.AccessControlContext{}
 
 			// get username from context made in authn.go
-			if isAuthnEnabled(ctlr.Config) {
+			if isAuthnEnabled(authConfig) {
 				// get access control context made in authn.go if authn is enabled
 				acCtx, err = localCtx.GetAccessControlContext(request.Context())
 				if err != nil { // should never happen
-					authFail(response, ctlr.Config.HTTP.Realm, ctlr.Config.HTTP.Auth.FailDelay)
+					authFail(response, ctlr.Config.HTTP.Realm, authConfig.FailDelay)
 
 					return
 				}
@@ -272,7 +307,7 @@ func BaseAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 					// if we still don't have an identity
 					if identity == "" {
 						acCtrlr.Log.Info().Msg("couldn't get identity from TLS certificate")
-						authFail(response, ctlr.Config.HTTP.Realm, ctlr.Config.HTTP.Auth.FailDelay)
+						authFail(response, ctlr.Config.HTTP.Realm, authConfig.FailDelay)
 
 						return
 					}
@@ -282,6 +317,18 @@ func BaseAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 				}
 			}
 
+			if ctlr.Plugins != nil {
+				// extensions check the actions on each repo they serve through the context
+				acCtx.Authorize = func(action, repository string, allowed bool) bool {
+					return ctlr.Plugins.Authorize(request.Context(), plugins.AuthzRequest{ //nolint:contextcheck
+						Username: acCtx.Username,
+						Groups:   acCtx.Groups,
+						Action:   action,
+						Repo:     repository,
+					}, allowed)
+				}
+			}
+
 			ctx := acCtrlr.getContext(acCtx, request)
 
 			next.ServeHTTP(response, request.WithContext(ctx)) //nolint:contextcheck
@@ -289,7 +336,7 @@ func BaseAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 	}
 }
 
-func DistSpecAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
+func DistSpecAuthzHandler(ctlr *Controller, authConfig *config.AuthConfig) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
 			if request.Method == http.MethodOptions {
@@ -302,7 +349,7 @@ func DistSpecAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 			resource := vars["name"]
 			reference, ok := vars["reference"]
 
-			acCtrlr := NewAccessController(ctlr.Config)
+			acCtrlr := NewAccessController(ctlr.Config, ctlr.RoleBindings)
 
 			var identity string
 
@@ -311,7 +358,7 @@ func DistSpecAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 			// get acCtx built in authn and previous authz middlewares
 			acCtx, err := localCtx.GetAccessControlContext(request.Context())
 			if err != nil { // should never happen
-				authFail(response, ctlr.Config.HTTP.Realm, ctlr.Config.HTTP.Auth.FailDelay)
+				authFail(response, ctlr.Config.HTTP.Realm, authConfig.FailDelay)
 
 				return
 			}
@@ -342,12 +389,32 @@ func DistSpecAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 				action = Delete
 			}
 
-			can := acCtrlr.can(request.Context(), identity, action, resource) //nolint:contextcheck
+			decision := acCtrlr.explain(identity, acCtx.Groups, action, resource)
+
+			can := ctlr.Plugins.Authorize(request.Context(), plugins.AuthzRequest{ //nolint:contextcheck
+				Username: identity,
+				Groups:   acCtx.Groups,
+				Action:   action,
+				Repo:     resource,
+			}, decision.Allowed)
 			if !can {
-				common.AuthzFail(response, ctlr.Config.HTTP.Realm, ctlr.Config.HTTP.Auth.FailDelay)
+				if acCtrlr.Config.LogDenied {
+					logAccessDenied(ctlr.Log, decision)
+				}
+
+				common.AuthzFail(response, ctlr.Config.HTTP.Realm, authConfig.FailDelay)
 			} else {
 				next.ServeHTTP(response, request) //nolint:contextcheck
 			}
 		})
 	}
 }
+
+// logAccessDenied logs the rules evaluated to deny an action, the action is allowed by the config but denied by an
+// authorization plugin if none of the rules denies it.
+func logAccessDenied(logger log.Logger, decision AccessDecision) {
+	logger.Info().Str("username", decision.Username).Strs("groups", decision.Groups).
+		Str("action", decision.Action).Str("repository", decision.Repository).
+		Str("matchedPattern", decision.MatchedPattern).Interface("rules", decision.Rules).
+		Bool("allowedByConfig", decision.Allowed).Msg("access denied")
+}
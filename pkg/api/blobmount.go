@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zreg "zotregistry.io/zot/pkg/regexp"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+/*
+mountBlob mounts a blob in a repo and returns whether it's mounted. With the "from" parameter the blob is linked
+from that repo, if the user can read it and it's in the same image store. Without it the blob is looked up in the
+dedupe cache, as clients don't always know which repo has it.
+*/
+func (rh *RouteHandler) mountBlob(request *http.Request, imgStore storageTypes.ImageStore, name string,
+	digest godigest.Digest,
+) bool {
+	fromRepos, ok := request.URL.Query()["from"]
+	if !ok {
+		// check blob looks for the blob in the repo then in the cache, and links it if found in the cache
+		_, _, err := imgStore.CheckBlob(name, digest)
+
+		return err == nil
+	}
+
+	if len(fromRepos) != 1 || !zreg.FullNameRegexp.MatchString(fromRepos[0]) {
+		return false
+	}
+
+	from := rh.getRouteRepoName(request, fromRepos[0])
+
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		return false
+	}
+
+	// the blob isn't mounted rather than denied, so that the repos the user can't read aren't disclosed
+	if acCtx != nil && !acCtx.IsAdmin && !acCtx.CanReadRepo(from) {
+		rh.c.Log.Info().Str("repository", name).Str("from", from).Str("digest", digest.String()).
+			Str("user", localCtx.GetUsernameFromContext(acCtx)).Msg("blob mount denied, no read access to the source")
+
+		return false
+	}
+
+	// blobs can only be linked within an image store
+	if rh.getImageStore(request, from).RootDir() != imgStore.RootDir() {
+		return false
+	}
+
+	if _, err := imgStore.MountBlob(name, from, digest); err != nil {
+		rh.c.Log.Debug().Err(err).Str("repository", name).Str("from", from).Str("digest", digest.String()).
+			Msg("unable to mount blob")
+
+		return false
+	}
+
+	return true
+}
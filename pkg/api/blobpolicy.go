@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/blobpolicy"
+	apiErr "zotregistry.io/zot/pkg/api/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// checkBlobSize enforces the max layer size of the repo on a blob whose size is known before it's uploaded,
+// e.g. from Content-Length. If it's too large it writes the error response and returns false.
+func (rh *RouteHandler) checkBlobSize(response http.ResponseWriter, name string, size int64) bool {
+	maxLayerSize := rh.c.BlobPolicies.GetMaxLayerSize(name)
+	if maxLayerSize == 0 || size <= maxLayerSize {
+		return true
+	}
+
+	rh.c.Log.Info().Err(zerr.ErrBlobTooLarge).Str("repository", name).Int64("size", size).
+		Int64("maxLayerSize", maxLayerSize).Msg("push denied")
+
+	writeBlobTooLargeError(response, zerr.ErrBlobTooLarge, name, maxLayerSize)
+
+	return false
+}
+
+// limitBlobUpload returns the body of a streamed upload failing once the blob exceeds the max layer size of the
+// repo, counting the bytes already uploaded.
+func (rh *RouteHandler) limitBlobUpload(imgStore storageTypes.ImageStore, name, sessionID string,
+	body io.Reader,
+) io.Reader {
+	maxLayerSize := rh.c.BlobPolicies.GetMaxLayerSize(name)
+	if maxLayerSize == 0 {
+		return body
+	}
+
+	uploaded, err := imgStore.GetBlobUpload(name, sessionID)
+	if err != nil {
+		// the upload itself reports the error
+		return body
+	}
+
+	return blobpolicy.LimitReader(body, maxLayerSize-uploaded)
+}
+
+// checkManifestBlobPolicy enforces the blob policy of the repo on the layers of a pushed manifest.
+// If one of them isn't allowed it writes the error response and returns false.
+func (rh *RouteHandler) checkManifestBlobPolicy(response http.ResponseWriter, name, reference string,
+	body []byte,
+) bool {
+	err := rh.c.BlobPolicies.CheckManifest(name, body)
+	if err == nil {
+		return true
+	}
+
+	rh.c.Log.Info().Err(err).Str("repository", name).Str("reference", reference).Msg("push denied")
+
+	if errors.Is(err, zerr.ErrBlobTooLarge) {
+		writeBlobTooLargeError(response, err, name, rh.c.BlobPolicies.GetMaxLayerSize(name))
+
+		return false
+	}
+
+	zcommon.WriteJSON(response, http.StatusBadRequest,
+		apiErr.NewErrorList(apiErr.NewError(apiErr.MANIFEST_INVALID, map[string]string{"reference": reference}).
+			WithMessage(err.Error())))
+
+	return false
+}
+
+func writeBlobTooLargeError(response http.ResponseWriter, err error, name string, maxLayerSize int64) {
+	zcommon.WriteJSON(response, http.StatusRequestEntityTooLarge,
+		apiErr.NewErrorList(apiErr.NewError(apiErr.SIZE_INVALID, map[string]string{
+			"name":         name,
+			"maxLayerSize": strconv.FormatInt(maxLayerSize, 10),
+		}).WithMessage(err.Error())))
+}
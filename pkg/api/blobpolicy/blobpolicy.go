@@ -0,0 +1,192 @@
+package blobpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+)
+
+type policy struct {
+	repositories      []string
+	maxLayerSize      int64
+	allowedMediaTypes []string
+}
+
+// Policies gives the limits on the blobs pushed to a repo, according to the blob policies of the config.
+type Policies struct {
+	policies []policy
+	lock     sync.RWMutex
+}
+
+// New validates the blob policies of the config.
+func New(configs []config.BlobPolicyConfig) (*Policies, error) {
+	policies := &Policies{}
+
+	if err := policies.Set(configs); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// Set replaces the policies, e.g. when the config is reloaded, they are left unchanged if one of them is invalid.
+func (policies *Policies) Set(configs []config.BlobPolicyConfig) error {
+	validated := []policy{}
+
+	for idx, policyConfig := range configs {
+		if policyConfig.MaxLayerSize < 0 {
+			return fmt.Errorf("%w: policy %d has a negative max layer size", zerr.ErrBadBlobPolicy, idx)
+		}
+
+		for _, pattern := range policyConfig.Repositories {
+			if !glob.ValidatePattern(pattern) {
+				return fmt.Errorf("%w: policy %d has an invalid repository pattern %s", zerr.ErrBadBlobPolicy,
+					idx, pattern)
+			}
+		}
+
+		for _, pattern := range policyConfig.AllowedMediaTypes {
+			if !glob.ValidatePattern(pattern) {
+				return fmt.Errorf("%w: policy %d has an invalid media type pattern %s", zerr.ErrBadBlobPolicy,
+					idx, pattern)
+			}
+		}
+
+		validated = append(validated, policy{
+			repositories:      policyConfig.Repositories,
+			maxLayerSize:      policyConfig.MaxLayerSize,
+			allowedMediaTypes: policyConfig.AllowedMediaTypes,
+		})
+	}
+
+	policies.lock.Lock()
+	defer policies.lock.Unlock()
+
+	policies.policies = validated
+
+	return nil
+}
+
+// getPolicy returns the first policy applying to the repo.
+func (policies *Policies) getPolicy(repo string) (policy, bool) {
+	if policies == nil {
+		return policy{}, false
+	}
+
+	policies.lock.RLock()
+	defer policies.lock.RUnlock()
+
+	for _, policy := range policies.policies {
+		if policy.matchesRepo(repo) {
+			return policy, true
+		}
+	}
+
+	return policy{}, false
+}
+
+// GetMaxLayerSize returns the maximum size of the blobs pushed to the repo, 0 if there's no limit.
+// Blobs aren't known to be layers before a manifest references them, so the limit applies to all of them.
+func (policies *Policies) GetMaxLayerSize(repo string) int64 {
+	policy, _ := policies.getPolicy(repo)
+
+	return policy.maxLayerSize
+}
+
+// CheckManifest checks the layers of a manifest pushed to the repo, e.g. the ones mounted from other repos
+// which weren't uploaded. Manifests which can't be parsed are left to the storage to reject.
+func (policies *Policies) CheckManifest(repo string, body []byte) error {
+	policy, ok := policies.getPolicy(repo)
+	if !ok {
+		return nil
+	}
+
+	var manifest struct {
+		Layers []ispec.Descriptor `json:"layers"`
+	}
+
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil //nolint:nilerr // invalid manifests are rejected by the storage
+	}
+
+	for _, layer := range manifest.Layers {
+		if policy.maxLayerSize > 0 && layer.Size > policy.maxLayerSize {
+			return fmt.Errorf("%w: layer %s has %d bytes, the limit is %d", zerr.ErrBlobTooLarge, layer.Digest,
+				layer.Size, policy.maxLayerSize)
+		}
+
+		if !policy.allowsMediaType(layer.MediaType) {
+			return fmt.Errorf("%w: layer %s has media type %s", zerr.ErrLayerMediaTypeNotAllowed, layer.Digest,
+				layer.MediaType)
+		}
+	}
+
+	return nil
+}
+
+func (policy policy) matchesRepo(repo string) bool {
+	if len(policy.repositories) == 0 {
+		return true
+	}
+
+	for _, pattern := range policy.repositories {
+		// patterns are validated by New
+		if matched, _ := glob.Match(pattern, repo); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (policy policy) allowsMediaType(mediaType string) bool {
+	if len(policy.allowedMediaTypes) == 0 {
+		return true
+	}
+
+	for _, pattern := range policy.allowedMediaTypes {
+		if matched, _ := glob.Match(pattern, mediaType); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LimitReader returns a reader of the body of an upload failing with ErrBlobTooLarge as soon as more than remaining
+// bytes are read, so that oversized blobs are rejected while they're streamed instead of once fully uploaded.
+func LimitReader(body io.Reader, remaining int64) io.Reader {
+	return &limitedReader{body: body, remaining: remaining}
+}
+
+type limitedReader struct {
+	body      io.Reader
+	remaining int64
+}
+
+func (reader *limitedReader) Read(buf []byte) (int, error) {
+	if reader.remaining < 0 {
+		return 0, zerr.ErrBlobTooLarge
+	}
+
+	// read one byte over the limit at most, enough to know it's exceeded
+	if int64(len(buf)) > reader.remaining+1 {
+		buf = buf[:reader.remaining+1]
+	}
+
+	n, err := reader.body.Read(buf)
+
+	reader.remaining -= int64(n)
+	if reader.remaining < 0 {
+		return 0, zerr.ErrBlobTooLarge
+	}
+
+	return n, err
+}
@@ -0,0 +1,102 @@
+package blobpolicy_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/blobpolicy"
+	"zotregistry.io/zot/pkg/api/config"
+)
+
+func TestBlobPolicies(t *testing.T) {
+	Convey("Apply the first policy matching a repo", t, func() {
+		policies, err := blobpolicy.New([]config.BlobPolicyConfig{
+			{
+				Repositories:      []string{"apps/**"},
+				MaxLayerSize:      100,
+				AllowedMediaTypes: []string{"application/vnd.oci.image.layer.v1.tar*"},
+			},
+			{
+				MaxLayerSize: 1000,
+			},
+		})
+		So(err, ShouldBeNil)
+
+		So(policies.GetMaxLayerSize("apps/frontend"), ShouldEqual, 100)
+		So(policies.GetMaxLayerSize("infra/db"), ShouldEqual, 1000)
+
+		manifest := func(mediaType string, size int64) []byte {
+			blob, err := json.Marshal(ispec.Manifest{
+				Layers: []ispec.Descriptor{
+					{MediaType: mediaType, Size: size, Digest: godigest.FromString("layer")},
+				},
+			})
+			So(err, ShouldBeNil)
+
+			return blob
+		}
+
+		err = policies.CheckManifest("apps/frontend", manifest(ispec.MediaTypeImageLayerGzip, 100))
+		So(err, ShouldBeNil)
+
+		err = policies.CheckManifest("apps/frontend", manifest(ispec.MediaTypeImageLayerGzip, 101))
+		So(err, ShouldWrap, zerr.ErrBlobTooLarge)
+
+		err = policies.CheckManifest("apps/frontend", manifest("application/vnd.example.wasm", 10))
+		So(err, ShouldWrap, zerr.ErrLayerMediaTypeNotAllowed)
+
+		// the second policy allows all media types
+		err = policies.CheckManifest("infra/db", manifest("application/vnd.example.wasm", 1000))
+		So(err, ShouldBeNil)
+
+		// invalid manifests are left to the storage
+		err = policies.CheckManifest("apps/frontend", []byte("not json"))
+		So(err, ShouldBeNil)
+
+		Convey("Reloading invalid policies keeps the previous ones", func() {
+			err := policies.Set([]config.BlobPolicyConfig{{Repositories: []string{"apps/["}}})
+			So(err, ShouldWrap, zerr.ErrBadBlobPolicy)
+			So(policies.GetMaxLayerSize("apps/frontend"), ShouldEqual, 100)
+
+			err = policies.Set([]config.BlobPolicyConfig{})
+			So(err, ShouldBeNil)
+			So(policies.GetMaxLayerSize("apps/frontend"), ShouldEqual, 0)
+		})
+
+		var nilPolicies *blobpolicy.Policies
+		So(nilPolicies.GetMaxLayerSize("apps/frontend"), ShouldEqual, 0)
+		So(nilPolicies.CheckManifest("apps/frontend", manifest("application/vnd.example.wasm", 1000)), ShouldBeNil)
+	})
+
+	Convey("Reject invalid policies", t, func() {
+		for _, policyConfig := range []config.BlobPolicyConfig{
+			{MaxLayerSize: -1},
+			{Repositories: []string{"apps/["}},
+			{AllowedMediaTypes: []string{"application/["}},
+		} {
+			_, err := blobpolicy.New([]config.BlobPolicyConfig{policyConfig})
+			So(err, ShouldWrap, zerr.ErrBadBlobPolicy)
+		}
+	})
+
+	Convey("Stop reading uploads exceeding the limit", t, func() {
+		content := bytes.Repeat([]byte("a"), 100)
+
+		read, err := io.ReadAll(blobpolicy.LimitReader(bytes.NewReader(content), 100))
+		So(err, ShouldBeNil)
+		So(read, ShouldResemble, content)
+
+		_, err = io.ReadAll(blobpolicy.LimitReader(bytes.NewReader(content), 99))
+		So(err, ShouldEqual, zerr.ErrBlobTooLarge)
+
+		_, err = io.Copy(io.Discard, blobpolicy.LimitReader(bytes.NewReader(content), 10))
+		So(err, ShouldEqual, zerr.ErrBlobTooLarge)
+	})
+}
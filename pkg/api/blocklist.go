@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	storageCommon "zotregistry.io/zot/pkg/storage/common"
+)
+
+// checkBlocklist denies pushing or pulling blocked digests, the denied requests are logged and audited.
+// If one of the digests is blocked it writes the error response and returns false.
+func (rh *RouteHandler) checkBlocklist(response http.ResponseWriter, request *http.Request, name string,
+	digests ...godigest.Digest,
+) bool {
+	blocked, ok := rh.c.Blocklist.IsBlocked(digests...)
+	if !ok {
+		return true
+	}
+
+	var username string
+
+	if acCtx, err := localCtx.GetAccessControlContext(request.Context()); err == nil {
+		username = localCtx.GetUsernameFromContext(acCtx)
+	}
+
+	rh.c.Log.Warn().Err(zerr.ErrDigestBlocked).Str("repository", name).Str("digest", blocked.String()).
+		Str("user", username).Str("method", request.Method).Str("path", request.URL.Path).Msg("request denied")
+
+	// denied requests are not recorded by the audit middleware, blocklist matches are always audited
+	if rh.c.Audit != nil {
+		rh.c.Audit.Warn().
+			Str("clientIP", request.RemoteAddr).
+			Str("subject", username).
+			Str("action", request.Method).
+			Str("object", request.URL.Path).
+			Str("digest", blocked.String()).
+			Int("status", http.StatusForbidden).
+			Msg("blocked digest")
+	}
+
+	writeDeniedError(response, zerr.ErrDigestBlocked, map[string]string{
+		"name":   name,
+		"digest": blocked.String(),
+	})
+
+	return false
+}
+
+// checkManifestBlocklist denies pushing or pulling a manifest if it or one of the digests it references
+// is blocked, see checkBlocklist.
+func (rh *RouteHandler) checkManifestBlocklist(response http.ResponseWriter, request *http.Request, name string,
+	digest godigest.Digest, content []byte,
+) bool {
+	// skip parsing the manifest if there's nothing to look for
+	if rh.c.Blocklist.IsEmpty() {
+		return true
+	}
+
+	digests := []godigest.Digest{digest}
+
+	// invalid manifests are reported by the storage
+	if blobs, manifests, err := storageCommon.GetManifestReferences(content); err == nil {
+		digests = append(digests, blobs...)
+		digests = append(digests, manifests...)
+	}
+
+	return rh.checkBlocklist(response, request, name, digests...)
+}
@@ -0,0 +1,260 @@
+package blocklist
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/log"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+// FileName is the file, under the root directory of the default store, where the digests blocked
+// through the API are kept.
+const FileName = "blocklist.json"
+
+// Entry is a blocked digest.
+type Entry struct {
+	Digest  godigest.Digest `json:"digest"`
+	Reason  string          `json:"reason,omitempty"`
+	AddedBy string          `json:"addedBy,omitempty"`
+	AddedAt time.Time       `json:"addedAt,omitempty"`
+	// repos holding a copy of the digest when it was blocked, the copies are kept for investigation
+	// but can't be pulled anymore
+	Quarantined []string `json:"quarantined,omitempty"`
+	// digests blocked in the config file can only be unblocked by changing the config file
+	FromConfig bool `json:"fromConfig"`
+}
+
+type EntryList struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Blocklist holds the digests which can't be pushed or pulled, either set in the config file or
+// added through the API, the latter are persisted so they survive restarts.
+type Blocklist struct {
+	filePath   string
+	configured map[godigest.Digest]bool
+	entries    map[godigest.Digest]Entry
+	lock       *sync.RWMutex
+	log        log.Logger
+}
+
+// New creates a blocklist from the digests set in the config file and the ones previously added
+// through the API and saved under rootDir.
+func New(rootDir string, digests []string, log log.Logger) (*Blocklist, error) {
+	blocklist := &Blocklist{
+		filePath: path.Join(rootDir, FileName),
+		entries:  map[godigest.Digest]Entry{},
+		lock:     &sync.RWMutex{},
+		log:      log,
+	}
+
+	if err := blocklist.SetConfigDigests(digests); err != nil {
+		return nil, err
+	}
+
+	buf, err := os.ReadFile(blocklist.filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return blocklist, nil
+		}
+
+		log.Error().Err(err).Str("file", blocklist.filePath).Msg("blocklist: unable to read blocklist")
+
+		return nil, err
+	}
+
+	var entryList EntryList
+
+	if err := json.Unmarshal(buf, &entryList); err != nil {
+		log.Error().Err(err).Str("file", blocklist.filePath).Msg("blocklist: invalid JSON")
+
+		return nil, err
+	}
+
+	for _, entry := range entryList.Entries {
+		blocklist.entries[entry.Digest] = entry
+	}
+
+	return blocklist, nil
+}
+
+// SetConfigDigests replaces the digests blocked in the config file, e.g. when the config is reloaded.
+func (bl *Blocklist) SetConfigDigests(digests []string) error {
+	configured := map[godigest.Digest]bool{}
+
+	for _, digestStr := range digests {
+		digest, err := godigest.Parse(digestStr)
+		if err != nil {
+			bl.log.Error().Err(err).Str("digest", digestStr).Msg("blocklist: invalid digest")
+
+			return err
+		}
+
+		configured[digest] = true
+	}
+
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	bl.configured = configured
+
+	return nil
+}
+
+// IsEmpty returns true if no digest is blocked.
+func (bl *Blocklist) IsEmpty() bool {
+	if bl == nil {
+		return true
+	}
+
+	bl.lock.RLock()
+	defer bl.lock.RUnlock()
+
+	return len(bl.configured) == 0 && len(bl.entries) == 0
+}
+
+// IsBlocked returns the first of the given digests which is blocked, if any.
+func (bl *Blocklist) IsBlocked(digests ...godigest.Digest) (godigest.Digest, bool) {
+	if bl == nil {
+		return "", false
+	}
+
+	bl.lock.RLock()
+	defer bl.lock.RUnlock()
+
+	for _, digest := range digests {
+		if _, ok := bl.entries[digest]; ok || bl.configured[digest] {
+			return digest, true
+		}
+	}
+
+	return "", false
+}
+
+// List returns all the blocked digests sorted by digest.
+func (bl *Blocklist) List() []Entry {
+	bl.lock.RLock()
+	defer bl.lock.RUnlock()
+
+	entries := make([]Entry, 0, len(bl.configured)+len(bl.entries))
+
+	for digest := range bl.configured {
+		entry, ok := bl.entries[digest]
+		if !ok {
+			entry = Entry{Digest: digest}
+		}
+
+		entry.FromConfig = true
+		entries = append(entries, entry)
+	}
+
+	for digest, entry := range bl.entries {
+		if !bl.configured[digest] {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Digest < entries[j].Digest
+	})
+
+	return entries
+}
+
+// Add blocks a digest, or updates the entry if it is already blocked, and saves the blocklist.
+func (bl *Blocklist) Add(entry Entry) error {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	previous, existed := bl.entries[entry.Digest]
+
+	entry.FromConfig = false
+	bl.entries[entry.Digest] = entry
+
+	if err := bl.save(); err != nil {
+		if existed {
+			bl.entries[entry.Digest] = previous
+		} else {
+			delete(bl.entries, entry.Digest)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Remove unblocks a digest added through the API and saves the blocklist.
+func (bl *Blocklist) Remove(digest godigest.Digest) error {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	if bl.configured[digest] {
+		return zerr.ErrBlocklistEntryFromConfig
+	}
+
+	entry, ok := bl.entries[digest]
+	if !ok {
+		return zerr.ErrBlocklistEntryNotFound
+	}
+
+	delete(bl.entries, digest)
+
+	if err := bl.save(); err != nil {
+		bl.entries[digest] = entry
+
+		return err
+	}
+
+	return nil
+}
+
+func (bl *Blocklist) save() error {
+	entryList := EntryList{Entries: make([]Entry, 0, len(bl.entries))}
+
+	for _, entry := range bl.entries {
+		entryList.Entries = append(entryList.Entries, entry)
+	}
+
+	sort.Slice(entryList.Entries, func(i, j int) bool {
+		return entryList.Entries[i].Digest < entryList.Entries[j].Digest
+	})
+
+	buf, err := json.MarshalIndent(entryList, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	// with remote storage drivers the root directory may not exist locally
+	if err := os.MkdirAll(path.Dir(bl.filePath), storageConstants.DefaultDirPerms); err != nil {
+		bl.log.Error().Err(err).Str("file", bl.filePath).Msg("blocklist: unable to create blocklist dir")
+
+		return err
+	}
+
+	// write to a temporary file first so a crash doesn't leave a truncated blocklist behind
+	tmpFile := bl.filePath + ".tmp"
+
+	if err := os.WriteFile(tmpFile, buf, storageConstants.DefaultFilePerms); err != nil {
+		bl.log.Error().Err(err).Str("file", tmpFile).Msg("blocklist: unable to write blocklist")
+
+		return err
+	}
+
+	if err := os.Rename(tmpFile, bl.filePath); err != nil {
+		bl.log.Error().Err(err).Str("file", bl.filePath).Msg("blocklist: unable to write blocklist")
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package blocklist_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/blocklist"
+	"zotregistry.io/zot/pkg/log"
+)
+
+func TestBlocklist(t *testing.T) {
+	log := log.NewLogger("debug", "")
+
+	Convey("Block digests from the config file and the API", t, func() {
+		rootDir := t.TempDir()
+		configDigest := godigest.FromString("config")
+		apiDigest := godigest.FromString("api")
+
+		digestBlocklist, err := blocklist.New(rootDir, []string{configDigest.String()}, log)
+		So(err, ShouldBeNil)
+		So(digestBlocklist.IsEmpty(), ShouldBeFalse)
+
+		blocked, ok := digestBlocklist.IsBlocked(apiDigest, configDigest)
+		So(ok, ShouldBeTrue)
+		So(blocked, ShouldEqual, configDigest)
+
+		_, ok = digestBlocklist.IsBlocked(apiDigest)
+		So(ok, ShouldBeFalse)
+
+		err = digestBlocklist.Add(blocklist.Entry{
+			Digest:      apiDigest,
+			Reason:      "malware",
+			AddedBy:     "admin",
+			AddedAt:     time.Now(),
+			Quarantined: []string{"repo"},
+		})
+		So(err, ShouldBeNil)
+
+		_, ok = digestBlocklist.IsBlocked(apiDigest)
+		So(ok, ShouldBeTrue)
+
+		entries := digestBlocklist.List()
+		So(len(entries), ShouldEqual, 2)
+
+		for _, entry := range entries {
+			So(entry.FromConfig, ShouldEqual, entry.Digest == configDigest)
+		}
+
+		// only the digests added through the API are saved
+		digestBlocklist, err = blocklist.New(rootDir, nil, log)
+		So(err, ShouldBeNil)
+
+		entries = digestBlocklist.List()
+		So(len(entries), ShouldEqual, 1)
+		So(entries[0].Digest, ShouldEqual, apiDigest)
+		So(entries[0].Reason, ShouldEqual, "malware")
+		So(entries[0].Quarantined, ShouldResemble, []string{"repo"})
+
+		err = digestBlocklist.SetConfigDigests([]string{configDigest.String()})
+		So(err, ShouldBeNil)
+
+		err = digestBlocklist.Remove(configDigest)
+		So(err, ShouldEqual, zerr.ErrBlocklistEntryFromConfig)
+
+		err = digestBlocklist.Remove(apiDigest)
+		So(err, ShouldBeNil)
+
+		err = digestBlocklist.Remove(apiDigest)
+		So(err, ShouldEqual, zerr.ErrBlocklistEntryNotFound)
+
+		err = digestBlocklist.SetConfigDigests(nil)
+		So(err, ShouldBeNil)
+		So(digestBlocklist.IsEmpty(), ShouldBeTrue)
+
+		var nilBlocklist *blocklist.Blocklist
+
+		So(nilBlocklist.IsEmpty(), ShouldBeTrue)
+
+		_, ok = nilBlocklist.IsBlocked(apiDigest)
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Blocklist errors", t, func() {
+		rootDir := t.TempDir()
+
+		_, err := blocklist.New(rootDir, []string{"bogus"}, log)
+		So(err, ShouldNotBeNil)
+
+		err = os.WriteFile(path.Join(rootDir, blocklist.FileName), []byte("{"), 0o600)
+		So(err, ShouldBeNil)
+
+		_, err = blocklist.New(rootDir, nil, log)
+		So(err, ShouldNotBeNil)
+
+		err = os.Remove(path.Join(rootDir, blocklist.FileName))
+		So(err, ShouldBeNil)
+
+		digestBlocklist, err := blocklist.New(rootDir, nil, log)
+		So(err, ShouldBeNil)
+
+		// a dir in place of the blocklist file makes saving it fail
+		err = os.MkdirAll(path.Join(rootDir, blocklist.FileName, "dir"), 0o700)
+		So(err, ShouldBeNil)
+
+		digest := godigest.FromString("digest")
+
+		// nothing is blocked if the blocklist can't be saved
+		err = digestBlocklist.Add(blocklist.Entry{Digest: digest})
+		So(err, ShouldNotBeNil)
+		So(digestBlocklist.IsEmpty(), ShouldBeTrue)
+	})
+}
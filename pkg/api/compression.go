@@ -0,0 +1,258 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/klauspost/compress/zstd"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+)
+
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+
+	defaultCompressionMinSize = 1024
+)
+
+// the catalog, tag lists, GraphQL and extension responses are JSON, the referrers are an OCI index.
+var defaultCompressionMediaTypes = []string{constants.DefaultMediaType, ispec.MediaTypeImageIndex}
+
+var (
+	gzipWriters = sync.Pool{New: func() any {
+		return gzip.NewWriter(io.Discard)
+	}}
+	zstdWriters = sync.Pool{New: func() any {
+		// the options are valid, NewWriter can't fail
+		writer, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+
+		return writer
+	}}
+)
+
+// CompressionHandler compresses the responses having one of the configured media types with gzip or zstd,
+// for the clients accepting either in their Accept-Encoding header. Responses smaller than the configured
+// size, partial responses and errors are sent as they are.
+func CompressionHandler(compressionConfig config.CompressionConfig) mux.MiddlewareFunc {
+	minSize := compressionConfig.MinSize
+	if minSize == 0 {
+		minSize = defaultCompressionMinSize
+	}
+
+	mediaTypes := map[string]bool{}
+
+	for _, mediaType := range compressionConfig.MediaTypes {
+		mediaTypes[mediaType] = true
+	}
+
+	if len(mediaTypes) == 0 {
+		for _, mediaType := range defaultCompressionMediaTypes {
+			mediaTypes[mediaType] = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			encoding := negotiateEncoding(request.Header.Get("Accept-Encoding"))
+			if encoding == "" || request.Method == http.MethodHead {
+				next.ServeHTTP(response, request)
+
+				return
+			}
+
+			compressWr := &compressWriter{
+				ResponseWriter: response,
+				encoding:       encoding,
+				minSize:        minSize,
+				mediaTypes:     mediaTypes,
+			}
+			defer compressWr.close()
+
+			next.ServeHTTP(compressWr, request)
+		})
+	}
+}
+
+// negotiateEncoding returns the accepted encoding with the highest quality, zstd if both have the same,
+// or an empty string if neither is accepted.
+func negotiateEncoding(acceptEncoding string) string {
+	var gzipQuality, zstdQuality float64
+
+	for _, accepted := range strings.Split(acceptEncoding, ",") {
+		encoding, params, _ := strings.Cut(strings.TrimSpace(accepted), ";")
+
+		quality := 1.0
+
+		if _, value, found := strings.Cut(params, "q="); found {
+			var err error
+
+			if quality, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+				continue
+			}
+		}
+
+		switch strings.ToLower(strings.TrimSpace(encoding)) {
+		case encodingGzip:
+			gzipQuality = quality
+		case encodingZstd:
+			zstdQuality = quality
+		}
+	}
+
+	switch {
+	case zstdQuality > 0 && zstdQuality >= gzipQuality:
+		return encodingZstd
+	case gzipQuality > 0:
+		return encodingGzip
+	default:
+		return ""
+	}
+}
+
+// compressWriter holds back the beginning of a response until it's large enough to be compressed.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding   string
+	minSize    int
+	mediaTypes map[string]bool
+	status     int
+	// the response is sent as it is
+	passthrough bool
+	buffer      bytes.Buffer
+	encoder     io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+
+	w.status = status
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+
+	if status != http.StatusOK || !w.mediaTypes[mediaType] || w.Header().Get("Content-Encoding") != "" {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+
+	w.buffer.Write(b)
+
+	if w.buffer.Len() < w.minSize {
+		return len(b), nil
+	}
+
+	w.startEncoding()
+
+	if _, err := w.encoder.Write(w.buffer.Bytes()); err != nil {
+		return 0, err
+	}
+
+	w.buffer.Reset()
+
+	return len(b), nil
+}
+
+// ReadFrom lets the underlying writer send files with sendfile if the response isn't compressed.
+func (w *compressWriter) ReadFrom(reader io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.passthrough {
+		return io.Copy(w.ResponseWriter, reader)
+	}
+
+	// only the Write method, io.Copy would call ReadFrom again otherwise
+	return io.Copy(struct{ io.Writer }{w}, reader)
+}
+
+// Flush sends what was written so far, compressed if the response was large enough, so streamed responses
+// aren't held back.
+func (w *compressWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.passthrough && w.encoder == nil {
+		w.sendBuffer()
+	}
+
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets handlers use the features of the underlying writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) startEncoding() {
+	w.Header().Set("Content-Encoding", w.encoding)
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if w.encoding == encodingZstd {
+		encoder, _ := zstdWriters.Get().(*zstd.Encoder)
+		encoder.Reset(w.ResponseWriter)
+		w.encoder = encoder
+	} else {
+		encoder, _ := gzipWriters.Get().(*gzip.Writer)
+		encoder.Reset(w.ResponseWriter)
+		w.encoder = encoder
+	}
+}
+
+// sendBuffer sends the response held back as it is, it was too small to be compressed.
+func (w *compressWriter) sendBuffer() {
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(w.buffer.Bytes())
+	w.buffer.Reset()
+}
+
+func (w *compressWriter) close() {
+	switch {
+	case w.encoder != nil:
+		_ = w.encoder.Close()
+
+		switch encoder := w.encoder.(type) {
+		case *zstd.Encoder:
+			encoder.Reset(nil)
+			zstdWriters.Put(encoder)
+		case *gzip.Writer:
+			encoder.Reset(io.Discard)
+			gzipWriters.Put(encoder)
+		}
+	case w.status != 0 && !w.passthrough:
+		w.sendBuffer()
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+const (
+	ReaderRole     = "reader"
+	PublisherRole  = "publisher"
+	MaintainerRole = "maintainer"
+	AdminRole      = "admin"
+)
+
+// GetRoleActions returns the actions a role expands to, the second value is false if the role is unknown.
+func GetRoleActions(role string) ([]string, bool) {
+	switch role {
+	case ReaderRole:
+		return []string{"read"}, true
+	case PublisherRole:
+		return []string{"read", "create"}, true
+	case MaintainerRole:
+		return []string{"read", "create", "update", "delete"}, true
+	case AdminRole:
+		return []string{"read", "create", "update", "delete", "detectManifestCollision"}, true
+	default:
+		return nil, false
+	}
+}
+
+// GetActions returns the actions of the policy along with the ones its roles expand to.
+func (policy Policy) GetActions() []string {
+	if len(policy.Roles) == 0 {
+		return policy.Actions
+	}
+
+	actions := append([]string{}, policy.Actions...)
+
+	for _, role := range policy.Roles {
+		roleActions, _ := GetRoleActions(role)
+		actions = append(actions, roleActions...)
+	}
+
+	return actions
+}
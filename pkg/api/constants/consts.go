@@ -10,6 +10,15 @@ const (
 	SubjectDigestKey             = "OCI-Subject"
 	BlobUploadUUID               = "Blob-Upload-UUID"
 	DefaultMediaType             = "application/json"
+	ProblemMediaType             = "application/problem+json"
+	EventStreamMediaType         = "text/event-stream"
+	RequestIDHeader              = "X-Request-Id"
+	ImageCreatedHeader           = "Zot-Image-Created"
+	ImageAgeDaysHeader           = "Zot-Image-Age-Days"
+	LastScannedHeader            = "Zot-Last-Scanned"
+	DaysSinceBaseUpdateHeader    = "Zot-Days-Since-Base-Update"
 	BinaryMediaType              = "application/octet-stream"
 	DefaultMetricsExtensionRoute = "/metrics"
+	// compressed manifests are rejected if they are larger than this once decompressed
+	MaxDecompressedManifestSize = 4 * 1024 * 1024
 )
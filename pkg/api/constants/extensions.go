@@ -18,4 +18,76 @@ const (
 	ExtUserPreferences        = "/userprefs"
 	ExtUserPreferencesPrefix  = ExtPrefix + ExtUserPreferences
 	FullUserPreferencesPrefix = RoutePrefix + ExtUserPreferencesPrefix
+
+	ExtUserActivity        = "/useractivity"
+	ExtUserActivityPrefix  = ExtPrefix + ExtUserActivity
+	FullUserActivityPrefix = RoutePrefix + ExtUserActivityPrefix
+
+	ExtPeering        = "/peering"
+	ExtPeeringPrefix  = ExtPrefix + ExtPeering
+	FullPeeringPrefix = RoutePrefix + ExtPeeringPrefix
+
+	ExtDigests        = "/digests"
+	ExtDigestsPrefix  = ExtPrefix + ExtDigests
+	FullDigestsPrefix = RoutePrefix + ExtDigestsPrefix
+
+	ExtPins        = "/pins"
+	ExtPinsPrefix  = ExtPrefix + ExtPins
+	FullPinsPrefix = RoutePrefix + ExtPinsPrefix
+
+	ExtAnnotations        = "/annotations"
+	ExtAnnotationsPrefix  = ExtPrefix + ExtAnnotations
+	FullAnnotationsPrefix = RoutePrefix + ExtAnnotationsPrefix
+
+	ExtCVEScans        = "/cve/scans"
+	ExtCVEScansPrefix  = ExtPrefix + ExtCVEScans
+	FullCVEScansPrefix = RoutePrefix + ExtCVEScansPrefix
+
+	ExtCVEAcknowledgements        = "/cve/acknowledgements"
+	ExtCVEAcknowledgementsPrefix  = ExtPrefix + ExtCVEAcknowledgements
+	FullCVEAcknowledgementsPrefix = RoutePrefix + ExtCVEAcknowledgementsPrefix
+
+	ExtCVEReport        = "/cve/report"
+	ExtCVEReportPrefix  = ExtPrefix + ExtCVEReport
+	FullCVEReportPrefix = RoutePrefix + ExtCVEReportPrefix
+
+	ExtCVEExport        = "/cve/export"
+	ExtCVEExportPrefix  = ExtPrefix + ExtCVEExport
+	FullCVEExportPrefix = RoutePrefix + ExtCVEExportPrefix
+
+	ExtPromotion        = "/promotion"
+	ExtPromotionPrefix  = ExtPrefix + ExtPromotion
+	FullPromotionPrefix = RoutePrefix + ExtPromotionPrefix
+
+	ExtAuditSnapshots        = "/audit/snapshots"
+	ExtAuditSnapshotsPrefix  = ExtPrefix + ExtAuditSnapshots
+	FullAuditSnapshotsPrefix = RoutePrefix + ExtAuditSnapshotsPrefix
+
+	ExtPullTokens        = "/pulltokens"
+	ExtPullTokensPrefix  = ExtPrefix + ExtPullTokens
+	FullPullTokensPrefix = RoutePrefix + ExtPullTokensPrefix
+
+	ExtStats        = "/stats"
+	ExtStatsPrefix  = ExtPrefix + ExtStats
+	FullStatsPrefix = RoutePrefix + ExtStatsPrefix
+
+	ExtEvents        = "/events"
+	ExtEventsPrefix  = ExtPrefix + ExtEvents
+	FullEventsPrefix = RoutePrefix + ExtEventsPrefix
+
+	ExtActivity        = "/activity"
+	ExtActivityPrefix  = ExtPrefix + ExtActivity
+	FullActivityPrefix = RoutePrefix + ExtActivityPrefix
+
+	ExtAccessExplain        = "/access/explain"
+	ExtAccessExplainPrefix  = ExtPrefix + ExtAccessExplain
+	FullAccessExplainPrefix = RoutePrefix + ExtAccessExplainPrefix
+
+	ExtQuota        = "/quota"
+	ExtQuotaPrefix  = ExtPrefix + ExtQuota
+	FullQuotaPrefix = RoutePrefix + ExtQuotaPrefix
+
+	ExtTelemetry        = "/telemetry"
+	ExtTelemetryPrefix  = ExtPrefix + ExtTelemetry
+	FullTelemetryPrefix = RoutePrefix + ExtTelemetryPrefix
 )
@@ -4,28 +4,49 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"runtime"
 	"strconv"
 	"strings"
+	goSync "sync"
 	"syscall"
 	"time"
 
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 
 	"zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/blobpolicy"
+	"zotregistry.io/zot/pkg/api/blocklist"
 	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/downloads"
+	"zotregistry.io/zot/pkg/api/hooks"
+	"zotregistry.io/zot/pkg/api/immutabletags"
+	"zotregistry.io/zot/pkg/api/loadshed"
+	"zotregistry.io/zot/pkg/api/promotion"
+	"zotregistry.io/zot/pkg/api/pulltoken"
+	"zotregistry.io/zot/pkg/api/roles"
+	"zotregistry.io/zot/pkg/api/tagalias"
+	"zotregistry.io/zot/pkg/api/tenancy"
+	"zotregistry.io/zot/pkg/breaker"
 	ext "zotregistry.io/zot/pkg/extensions"
+	"zotregistry.io/zot/pkg/extensions/lint"
 	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/extensions/plugins"
+	"zotregistry.io/zot/pkg/extensions/sync"
 	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/events"
 	"zotregistry.io/zot/pkg/meta/repodb"
 	"zotregistry.io/zot/pkg/meta/repodb/repodbfactory"
 	"zotregistry.io/zot/pkg/scheduler"
 	"zotregistry.io/zot/pkg/storage"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/inflight"
+	"zotregistry.io/zot/pkg/storage/lease"
+	"zotregistry.io/zot/pkg/storage/quota"
+	"zotregistry.io/zot/pkg/storage/recompress"
+	"zotregistry.io/zot/pkg/storage/referrerstags"
 )
 
 const (
@@ -41,11 +62,37 @@ type Controller struct {
 	Log             log.Logger
 	Audit           *log.Logger
 	Server          *http.Server
+	Servers         []*http.Server
 	Metrics         monitoring.MetricServer
 	CveInfo         ext.CveInfo
+	CVEReporter     ext.CVEReporter
+	AuditSnapshots  ext.AuditSnapshotter
 	SyncOnDemand    SyncOnDemand
+	SyncConflicts   *sync.ConflictStore
+	Blocklist       *blocklist.Blocklist
+	Leases          *lease.Leases
+	StorageQuotas   *quota.Quotas
+	RoleBindings    *roles.Bindings
+	Plugins         *plugins.Registry
+	TagAliases      *tagalias.Rules
+	ImmutableTags   *immutabletags.Rules
+	PreReceiveHooks *hooks.Hooks
+	BlobPolicies    *blobpolicy.Policies
+	Linter          *lint.Linter
+	Downloads       *downloads.Filter
+	MetaEvents      *events.Queue
+	RepoDBIndexing  *repodb.IndexingStatus
+	LoadShedder     *loadshed.Shedder
+	RepoDBBreaker   *breaker.Breaker
+	Tenants         *tenancy.Tenants
+	PromotionGates  *promotion.Gates
+	PullTokens      *pulltoken.Signer
 	// runtime params
-	chosenPort int // kernel-chosen port
+	chosenPort     int // kernel-chosen port
+	cancelIndexing context.CancelFunc
+	// repos changed while the repodb circuit was open
+	staleRepos     map[string]struct{}
+	staleReposLock goSync.Mutex
 }
 
 func NewController(config *config.Config) *Controller {
@@ -54,6 +101,7 @@ func NewController(config *config.Config) *Controller {
 	logger := log.NewLogger(config.Log.Level, config.Log.Output)
 	controller.Config = config
 	controller.Log = logger
+	controller.SyncConflicts = sync.NewConflictStore()
 
 	if config.Log.Audit != "" {
 		audit := log.NewAuditLogger(config.Log.Level, config.Log.Audit)
@@ -91,57 +139,153 @@ func (c *Controller) GetPort() int {
 func (c *Controller) Run(reloadCtx context.Context) error {
 	c.StartBackgroundTasks(reloadCtx)
 
-	// setup HTTP API router
-	engine := mux.NewRouter()
+	monitoring.SetServerInfo(c.Metrics, c.Config.Commit, c.Config.BinaryType, c.Config.GoVersion,
+		c.Config.DistSpecVersion)
+
+	listenerConfigs := c.Config.HTTP.GetListeners()
+	servers := make([]*http.Server, 0, len(listenerConfigs))
+	bindings := []listenerBinding{}
+
+	closeListeners := func() {
+		for _, binding := range bindings {
+			_ = binding.listener.Close()
+		}
+	}
+
+	middlewares := c.middlewares()
+
+	for index, listenerConfig := range listenerConfigs {
+		// each listener has its own router, authenticating its requests with the auth settings of the listener
+		router := mux.NewRouter()
+		router.Use(middlewares...)
+		router.UseEncodedPath()
+
+		//nolint: contextcheck
+		_ = NewListenerRouteHandler(c, router, listenerConfig.Auth)
+
+		// the router of the first listener is the one exposed to the tests
+		if index == 0 {
+			c.Router = router
+		}
+
+		server := &http.Server{
+			Addr:              net.JoinHostPort(listenerConfig.Address, listenerConfig.Port),
+			Handler:           TenancyHandler(c, router),
+			IdleTimeout:       idleTimeout,
+			ReadHeaderTimeout: readHeaderTimeout,
+		}
+
+		if isTLSEnabled(listenerConfig.TLS) {
+			server.TLSConfig = c.getTLSConfig(listenerConfig.TLS, listenerConfig.Auth)
+		}
+
+		servers = append(servers, server)
+
+		// the same server serves all the addresses of the listener
+		for _, address := range listenerConfig.GetAddresses() {
+			addr := net.JoinHostPort(address, listenerConfig.Port)
+
+			// Create the listener
+			listener, err := net.Listen(listenerConfig.GetNetwork(), addr)
+			if err != nil {
+				closeListeners()
+
+				return err
+			}
+
+			// the port of the first listener is the one reported to the tests
+			if len(bindings) == 0 {
+				if err := c.setChosenPort(listener, listenerConfig.Port); err != nil {
+					_ = listener.Close()
+
+					return err
+				}
+			}
+
+			bindings = append(bindings, listenerBinding{server: server, listener: listener, tls: listenerConfig.TLS})
+
+			c.Log.Info().Str("address", listener.Addr().String()).Str("network", listenerConfig.GetNetwork()).
+				Bool("tls", isTLSEnabled(listenerConfig.TLS)).Msg("listening")
+		}
+	}
+
+	c.Server = servers[0]
+	c.Servers = servers
+
+	// when a server stops, e.g. it's shut down, the others are stopped too
+	errCh := make(chan error, len(bindings))
+
+	for _, binding := range bindings {
+		go func(binding listenerBinding) {
+			if isTLSEnabled(binding.tls) {
+				errCh <- binding.server.ServeTLS(binding.listener, binding.tls.Cert, binding.tls.Key)
+
+				return
+			}
+
+			errCh <- binding.server.Serve(binding.listener)
+		}(binding)
+	}
+
+	err := <-errCh
+
+	if len(bindings) > 1 {
+		c.Shutdown()
+	}
+
+	return err
+}
+
+// middlewares returns the middlewares applied to all the requests, whatever listener serves them, they are
+// shared by the routers of the listeners so that e.g. the rate limits apply to all of them.
+func (c *Controller) middlewares() []mux.MiddlewareFunc {
+	middlewares := []mux.MiddlewareFunc{}
 
 	// rate-limit HTTP requests if enabled
 	if c.Config.HTTP.Ratelimit != nil {
 		if c.Config.HTTP.Ratelimit.Rate != nil {
-			engine.Use(RateLimiter(c, *c.Config.HTTP.Ratelimit.Rate))
+			middlewares = append(middlewares, RateLimiter(c, *c.Config.HTTP.Ratelimit.Rate))
 		}
 
 		for _, mrlim := range c.Config.HTTP.Ratelimit.Methods {
-			engine.Use(MethodRateLimiter(c, mrlim.Method, mrlim.Rate))
+			middlewares = append(middlewares, MethodRateLimiter(c, mrlim.Method, mrlim.Rate))
 		}
 	}
 
-	engine.Use(
+	middlewares = append(middlewares,
+		RequestIDHandler(),
 		SessionLogger(c),
-		handlers.RecoveryHandler(handlers.RecoveryLogger(c.Log),
-			handlers.PrintRecoveryStack(false)))
+		RecoveryHandler(c))
 
-	if c.Audit != nil {
-		engine.Use(SessionAuditLogger(c.Audit))
+	if c.LoadShedder != nil {
+		middlewares = append(middlewares, LoadShedder(c))
 	}
 
-	c.Router = engine
-	c.Router.UseEncodedPath()
-
-	monitoring.SetServerInfo(c.Metrics, c.Config.Commit, c.Config.BinaryType, c.Config.GoVersion,
-		c.Config.DistSpecVersion)
+	if c.Config.HTTP.Compression != nil {
+		middlewares = append(middlewares, CompressionHandler(*c.Config.HTTP.Compression))
+	}
 
-	//nolint: contextcheck
-	_ = NewRouteHandler(c)
+	middlewares = append(middlewares, ProblemDetailsHandler(c))
 
-	addr := fmt.Sprintf("%s:%s", c.Config.HTTP.Address, c.Config.HTTP.Port)
-	server := &http.Server{
-		Addr:              addr,
-		Handler:           c.Router,
-		IdleTimeout:       idleTimeout,
-		ReadHeaderTimeout: readHeaderTimeout,
+	if c.Audit != nil {
+		middlewares = append(middlewares, SessionAuditLogger(c.Audit))
 	}
-	c.Server = server
 
-	// Create the listener
-	listener, err := net.Listen("tcp", addr)
-	if err != nil {
-		return err
-	}
+	return middlewares
+}
 
-	if c.Config.HTTP.Port == "0" || c.Config.HTTP.Port == "" {
+// listenerBinding is one of the addresses a server listens on.
+type listenerBinding struct {
+	server   *http.Server
+	listener net.Listener
+	tls      *config.TLSConfig
+}
+
+func (c *Controller) setChosenPort(listener net.Listener, port string) error {
+	if port == "0" || port == "" {
 		chosenAddr, ok := listener.Addr().(*net.TCPAddr)
 		if !ok {
-			c.Log.Error().Str("port", c.Config.HTTP.Port).Msg("invalid addr type")
+			c.Log.Error().Str("port", port).Msg("invalid addr type")
 
 			return errors.ErrBadType
 		}
@@ -152,55 +296,59 @@ func (c *Controller) Run(reloadCtx context.Context) error {
 			"port is unspecified, listening on kernel chosen port",
 		)
 	} else {
-		chosenPort, _ := strconv.ParseInt(c.Config.HTTP.Port, 10, 64)
+		chosenPort, _ := strconv.ParseInt(port, 10, 64)
 
 		c.chosenPort = int(chosenPort)
 	}
 
-	if c.Config.HTTP.TLS != nil && c.Config.HTTP.TLS.Key != "" && c.Config.HTTP.TLS.Cert != "" {
-		server.TLSConfig = &tls.Config{
-			CipherSuites: []uint16{
-				tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
-				tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
-				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-			},
-			CurvePreferences: []tls.CurveID{
-				tls.CurveP256,
-				tls.X25519,
-			},
-			PreferServerCipherSuites: true,
-			MinVersion:               tls.VersionTLS12,
-		}
+	return nil
+}
 
-		if c.Config.HTTP.TLS.CACert != "" {
-			clientAuth := tls.VerifyClientCertIfGiven
-			if (c.Config.HTTP.Auth == nil || c.Config.HTTP.Auth.HTPasswd.Path == "") &&
-				!c.Config.HTTP.AccessControl.AnonymousPolicyExists() {
-				clientAuth = tls.RequireAndVerifyClientCert
-			}
+func isTLSEnabled(tlsConfig *config.TLSConfig) bool {
+	return tlsConfig != nil && tlsConfig.Key != "" && tlsConfig.Cert != ""
+}
 
-			caCert, err := os.ReadFile(c.Config.HTTP.TLS.CACert)
-			if err != nil {
-				panic(err)
-			}
+func (c *Controller) getTLSConfig(tlsConfig *config.TLSConfig, authConfig *config.AuthConfig) *tls.Config {
+	serverTLSConfig := &tls.Config{
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		},
+		CurvePreferences: []tls.CurveID{
+			tls.CurveP256,
+			tls.X25519,
+		},
+		PreferServerCipherSuites: true,
+		MinVersion:               tls.VersionTLS12,
+	}
 
-			caCertPool := x509.NewCertPool()
+	if tlsConfig.CACert != "" {
+		clientAuth := tls.VerifyClientCertIfGiven
+		if (authConfig == nil || authConfig.HTPasswd.Path == "") &&
+			!c.Config.HTTP.AccessControl.AnonymousPolicyExists() {
+			clientAuth = tls.RequireAndVerifyClientCert
+		}
 
-			if !caCertPool.AppendCertsFromPEM(caCert) {
-				panic(errors.ErrBadCACert)
-			}
+		caCert, err := os.ReadFile(tlsConfig.CACert)
+		if err != nil {
+			panic(err)
+		}
 
-			server.TLSConfig.ClientAuth = clientAuth
-			server.TLSConfig.ClientCAs = caCertPool
+		caCertPool := x509.NewCertPool()
+
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			panic(errors.ErrBadCACert)
 		}
 
-		return server.ServeTLS(listener, c.Config.HTTP.TLS.Cert, c.Config.HTTP.TLS.Key)
+		serverTLSConfig.ClientAuth = clientAuth
+		serverTLSConfig.ClientCAs = caCertPool
 	}
 
-	return server.Serve(listener)
+	return serverTLSConfig
 }
 
 func (c *Controller) Init(reloadCtx context.Context) error {
@@ -220,30 +368,270 @@ func (c *Controller) Init(reloadCtx context.Context) error {
 
 	c.Metrics = monitoring.NewMetricsServer(enabled, c.Log)
 
+	// fail early instead of serving requests which can't be authenticated
+	for _, listenerConfig := range c.Config.HTTP.GetListeners() {
+		if err := checkAuthBackends(listenerConfig.Auth, c.Log); err != nil {
+			c.Log.Error().Err(err).Msg("unable to set up authentication")
+
+			return err
+		}
+
+		if isBearerAuthEnabled(listenerConfig.Auth) {
+			if _, err := newBearerAuthorizer(listenerConfig.Auth); err != nil {
+				c.Log.Error().Err(err).Str("cert", listenerConfig.Auth.Bearer.Cert).Msg("error creating bearer authorizer")
+
+				return err
+			}
+		}
+	}
+
 	if err := c.InitImageStore(); err != nil { //nolint:contextcheck
 		return err
 	}
 
+	if err := c.InitBlocklist(); err != nil {
+		return err
+	}
+
+	if err := c.InitTagAliases(); err != nil {
+		return err
+	}
+
+	if err := c.InitImmutableTags(); err != nil {
+		return err
+	}
+
+	if err := c.InitPreReceiveHooks(); err != nil {
+		return err
+	}
+
+	if err := c.InitBlobPolicies(); err != nil {
+		return err
+	}
+
+	if err := c.InitDownloads(); err != nil {
+		return err
+	}
+
+	if err := c.InitPromotionGates(); err != nil {
+		return err
+	}
+
+	if err := c.InitPullTokens(); err != nil {
+		return err
+	}
+
+	c.InitLeases()
+
+	c.InitInFlight()
+
+	c.InitStorageQuotas()
+
+	c.InitLoadShedder()
+
+	if err := c.InitRoleBindings(); err != nil {
+		return err
+	}
+
+	if err := c.InitTenants(); err != nil {
+		return err
+	}
+
+	if err := c.InitPlugins(); err != nil {
+		return err
+	}
+
+	// the stores are migrated to the current layout before anything reads them
+	if _, err := storage.MigrateLayout(c.StoreController, storage.Migrations, false, c.Log); err != nil {
+		return err
+	}
+
+	// repos have to be consistent before they are parsed into repodb
+	if err := storage.CheckConsistency(c.Config, c.StoreController, c.Log); err != nil {
+		return err
+	}
+
 	if err := c.InitRepoDB(reloadCtx); err != nil {
 		return err
 	}
 
+	c.InitRepoDBBreaker()
+
 	c.InitCVEInfo()
 
+	c.InitAuditSnapshots()
+
+	return nil
+}
+
+func (c *Controller) InitBlocklist() error {
+	digestBlocklist, err := blocklist.New(c.Config.Storage.RootDirectory, c.Config.Storage.Blocklist, c.Log)
+	if err != nil {
+		return err
+	}
+
+	c.Blocklist = digestBlocklist
+
+	return nil
+}
+
+func (c *Controller) InitTagAliases() error {
+	rules, err := tagalias.New(c.Config.Storage.TagAliases)
+	if err != nil {
+		return err
+	}
+
+	c.TagAliases = rules
+
+	return nil
+}
+
+func (c *Controller) InitImmutableTags() error {
+	rules, err := immutabletags.New(c.Config.Storage.ImmutableTags)
+	if err != nil {
+		return err
+	}
+
+	c.ImmutableTags = rules
+
+	// the stores reject the pushes and deletes of immutable tags under their lock
+	c.StoreController.SetImmutableTags(rules)
+
+	return nil
+}
+
+func (c *Controller) InitPreReceiveHooks() error {
+	preReceiveHooks, err := hooks.New(c.Config.Storage.PreReceiveHooks, c.Log)
+	if err != nil {
+		return err
+	}
+
+	c.PreReceiveHooks = preReceiveHooks
+
 	return nil
 }
 
+func (c *Controller) InitBlobPolicies() error {
+	policies, err := blobpolicy.New(c.Config.Storage.BlobPolicies)
+	if err != nil {
+		return err
+	}
+
+	c.BlobPolicies = policies
+
+	return nil
+}
+
+func (c *Controller) InitRoleBindings() error {
+	roleBindings, err := roles.New(c.Config.Storage.RootDirectory, c.Log)
+	if err != nil {
+		return err
+	}
+
+	c.RoleBindings = roleBindings
+
+	return nil
+}
+
+func (c *Controller) InitPlugins() error {
+	if c.Config.Extensions == nil {
+		return nil
+	}
+
+	// events are kept so that consumers can replay the ones they missed, by default if notifiers are loaded
+	eventsConfig := c.Config.Extensions.Events
+	keepEvents := len(c.Config.Extensions.Plugins) > 0
+
+	if eventsConfig != nil && eventsConfig.Enable != nil {
+		keepEvents = *eventsConfig.Enable
+	}
+
+	if len(c.Config.Extensions.Plugins) == 0 && !keepEvents {
+		return nil
+	}
+
+	registry, err := plugins.Load(c.Config.Extensions.Plugins, c.Log)
+	if err != nil {
+		return err
+	}
+
+	if keepEvents {
+		eventLog, err := plugins.NewEventLog(c.Config.Storage.RootDirectory, c.Log)
+		if err != nil {
+			// the notifiers are already running
+			_ = registry.Close()
+
+			return err
+		}
+
+		if eventsConfig != nil {
+			if eventsConfig.Retention > 0 {
+				eventLog.MaxAge = eventsConfig.Retention
+			}
+
+			if eventsConfig.MaxEventsPerRepo > 0 {
+				eventLog.MaxEventsPerRepo = uint64(eventsConfig.MaxEventsPerRepo)
+			}
+		}
+
+		registry.SetEventLog(eventLog)
+	}
+
+	c.Plugins = registry
+
+	return nil
+}
+
+func (c *Controller) InitLeases() {
+	c.Leases = lease.New(c.Config.Storage.MaxLeaseDuration, c.Metrics, c.Log)
+
+	// gc is paused while external readers hold a lease
+	c.StoreController.SetLeases(c.Leases)
+}
+
+// InitInFlight keeps the content pushed or synced before the manifests referencing it from gc.
+func (c *Controller) InitInFlight() {
+	c.StoreController.InFlight = inflight.New(c.Config.Storage.InFlightUploadTimeout)
+
+	c.StoreController.SetInFlight(c.StoreController.InFlight)
+}
+
+// InitStorageQuotas enforces the byte limits of the storage quota config, if any.
+func (c *Controller) InitStorageQuotas() {
+	if c.Config.Storage.Quota == nil {
+		c.StorageQuotas = nil
+
+		return
+	}
+
+	c.StorageQuotas = quota.New(*c.Config.Storage.Quota, c.StoreController, c.Metrics, c.Log)
+}
+
 func (c *Controller) InitCVEInfo() {
 	// Enable CVE extension if extension config is provided
 	if c.Config != nil && c.Config.Extensions != nil {
-		c.CveInfo = ext.GetCVEInfo(c.Config, c.StoreController, c.RepoDB, c.Log)
+		c.CveInfo = ext.GetCVEInfo(c.Config, c.StoreController, c.RepoDB, c.Metrics, c.Log)
+		c.CVEReporter = ext.GetCVEReporter(c.Config, c.CveInfo, c.RepoDB, c.Log)
+
+		// the completed scans are part of the activity of the repos
+		ext.NotifyImageScans(c.CveInfo, c.Plugins)
+	}
+}
+
+// InitAuditSnapshots loads the signing key of the audit snapshots, if they're enabled.
+func (c *Controller) InitAuditSnapshots() {
+	if c.Config != nil && c.Config.Extensions != nil {
+		c.AuditSnapshots = ext.GetAuditSnapshotter(c.Config, c.StoreController, c.RepoDB, c.CveInfo, c.Log)
 	}
 }
 
 func (c *Controller) InitImageStore() error {
-	linter := ext.GetLinter(c.Config, c.Log)
+	c.Linter = ext.GetLinter(c.Config, c.Log)
 
-	storeController, err := storage.New(c.Config, linter, c.Metrics, c.Log)
+	// the tenants with a separate store are served by a substore
+	c.Config.AddTenantSubPaths()
+
+	storeController, err := storage.New(c.Config, c.Linter, c.Metrics, c.Log)
 	if err != nil {
 		return err
 	}
@@ -265,12 +653,30 @@ func (c *Controller) InitRepoDB(reloadCtx context.Context) error {
 			return err
 		}
 
-		err = repodb.ParseStorage(driver, c.StoreController, c.Log)
-		if err != nil {
-			return err
+		if c.Config.Storage.LazyRepoDBPopulation {
+			// the repos are parsed in the background while requests are served
+			ctx, cancel := context.WithCancel(context.Background())
+
+			c.RepoDBIndexing = repodb.ParseStorageInBackground(ctx, driver, c.StoreController, c.Log)
+			c.cancelIndexing = cancel
+		} else {
+			err = repodb.ParseStorage(driver, c.StoreController, c.Log)
+			if err != nil {
+				return err
+			}
 		}
 
 		c.RepoDB = driver
+
+		// images pinned in repoDB are not garbage collected
+		c.StoreController.SetPinnedImages(driver)
+
+		// tags expired by retention are removed from repoDB too
+		c.StoreController.SetTagRemovals(repoDBTagRemovals{ctlr: c})
+
+		if err := c.InitMetaEvents(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -283,6 +689,49 @@ func (c *Controller) LoadNewConfig(reloadCtx context.Context, config *config.Con
 	// reload periodical gc interval
 	c.Config.Storage.GCInterval = config.Storage.GCInterval
 
+	// reload blocked digests
+	if c.Blocklist != nil {
+		if err := c.Blocklist.SetConfigDigests(config.Storage.Blocklist); err == nil {
+			c.Config.Storage.Blocklist = config.Storage.Blocklist
+		}
+	}
+
+	// reload tag alias rules
+	if c.TagAliases != nil {
+		if err := c.TagAliases.Set(config.Storage.TagAliases); err == nil {
+			c.Config.Storage.TagAliases = config.Storage.TagAliases
+		} else {
+			c.Log.Error().Err(err).Msg("unable to reload tag alias rules, keeping the previous ones")
+		}
+	}
+
+	// reload immutable tags rules
+	if c.ImmutableTags != nil {
+		if err := c.ImmutableTags.Set(config.Storage.ImmutableTags); err == nil {
+			c.Config.Storage.ImmutableTags = config.Storage.ImmutableTags
+		} else {
+			c.Log.Error().Err(err).Msg("unable to reload immutable tags rules, keeping the previous ones")
+		}
+	}
+
+	// reload pre-receive hooks
+	if c.PreReceiveHooks != nil {
+		if err := c.PreReceiveHooks.Set(config.Storage.PreReceiveHooks); err == nil {
+			c.Config.Storage.PreReceiveHooks = config.Storage.PreReceiveHooks
+		} else {
+			c.Log.Error().Err(err).Msg("unable to reload pre-receive hooks, keeping the previous ones")
+		}
+	}
+
+	// reload blob policies
+	if c.BlobPolicies != nil {
+		if err := c.BlobPolicies.Set(config.Storage.BlobPolicies); err == nil {
+			c.Config.Storage.BlobPolicies = config.Storage.BlobPolicies
+		} else {
+			c.Log.Error().Err(err).Msg("unable to reload blob policies, keeping the previous ones")
+		}
+	}
+
 	// reload background tasks
 	if config.Extensions != nil {
 		// reload sync extension
@@ -292,6 +741,24 @@ func (c *Controller) LoadNewConfig(reloadCtx context.Context, config *config.Con
 			// reload only if search is enabled and reloaded config has search extension
 			if *c.Config.Extensions.Search.Enable && config.Extensions.Search != nil {
 				c.Config.Extensions.Search.CVE = config.Extensions.Search.CVE
+
+				// reload download counts filters
+				if c.Downloads != nil {
+					if err := c.Downloads.Set(config.Extensions.Search.Downloads); err == nil {
+						c.Config.Extensions.Search.Downloads = config.Extensions.Search.Downloads
+					} else {
+						c.Log.Error().Err(err).Msg("unable to reload download counts config, keeping the previous one")
+					}
+				}
+
+				// reload promotion gates
+				if c.PromotionGates != nil {
+					if err := c.PromotionGates.Set(config.Extensions.Search.PromotionGates); err == nil {
+						c.Config.Extensions.Search.PromotionGates = config.Extensions.Search.PromotionGates
+					} else {
+						c.Log.Error().Err(err).Msg("unable to reload promotion gates, keeping the previous ones")
+					}
+				}
 			}
 		}
 		// reload scrub extension
@@ -310,25 +777,89 @@ func (c *Controller) LoadNewConfig(reloadCtx context.Context, config *config.Con
 
 func (c *Controller) Shutdown() {
 	ctx := context.Background()
-	_ = c.Server.Shutdown(ctx)
+
+	for _, server := range c.Servers {
+		_ = server.Shutdown(ctx)
+	}
+
+	if c.cancelIndexing != nil {
+		c.cancelIndexing()
+		c.RepoDBIndexing.Wait()
+	}
+
+	// events left in the queue are applied after a restart
+	if c.MetaEvents != nil {
+		_ = c.MetaEvents.Close()
+	}
+
+	_ = c.Plugins.Close()
 }
 
 func (c *Controller) StartBackgroundTasks(reloadCtx context.Context) {
 	taskScheduler := scheduler.NewScheduler(c.Config, c.Log)
 	taskScheduler.RunScheduler(reloadCtx)
 
+	// probe the storage health to shed requests while it's unhealthy
+	c.runLoadShedder(reloadCtx)
+
+	// export the health of the background subsystems, e.g. sync and the CVE DB updates, through the metrics
+	monitoring.RunHealthChecksPeriodically(c.Metrics, monitoring.HealthCheckInterval, taskScheduler)
+
 	// Enable running garbage-collect periodically for DefaultStore
 	if c.Config.Storage.GC && c.Config.Storage.GCInterval != 0 {
 		c.StoreController.DefaultStore.RunGCPeriodically(c.Config.Storage.GCInterval, taskScheduler)
 	}
 
+	// Enable flushing written files to disk periodically for DefaultStore
+	if c.Config.Storage.GetCommitPolicy() == storageConstants.CommitPolicyPeriodic {
+		c.StoreController.DefaultStore.RunCommitPeriodically(c.Config.Storage.GetCommitInterval(), taskScheduler)
+	}
+
 	// Enable running dedupe blobs both ways (dedupe or restore deduped blobs)
 	c.StoreController.DefaultStore.RunDedupeBlobs(time.Duration(0), taskScheduler)
 
+	// Enable dropping the expired events of the event log periodically
+	if eventLog := c.Plugins.EventLog(); eventLog != nil {
+		eventLog.RunCompactionPeriodically(plugins.EventLogCompactionInterval, taskScheduler)
+	}
+
+	// Enable counting the storage used by the repos periodically, dropping the content removed by gc
+	if c.StorageQuotas != nil {
+		c.StorageQuotas.RunUsageRefreshPeriodically(quota.DefaultUsageMaxAge, taskScheduler)
+	}
+
+	// Enable recompressing the gzip layers of the stored images to zstd periodically
+	if c.Config.Storage.LayerRecompression != nil {
+		recompressor, err := recompress.New(*c.Config.Storage.LayerRecompression, c.StoreController, c.RepoDB, c.Log)
+		if err != nil {
+			c.Log.Error().Err(err).Msg("unable to start layer recompression")
+		} else {
+			recompressor.RunPeriodically(taskScheduler)
+		}
+	}
+
+	// Enable reconciling the referrers tags with the referrers periodically
+	if c.Config.Storage.ReferrersTags != nil {
+		reconciler, err := referrerstags.New(*c.Config.Storage.ReferrersTags, c.StoreController, c.RepoDB, c.Log)
+		if err != nil {
+			c.Log.Error().Err(err).Msg("unable to start referrers tags reconciliation")
+		} else {
+			reconciler.RunPeriodically(taskScheduler)
+		}
+	}
+
+	// Enable checking and compacting the dedupe cache db periodically for DefaultStore
+	if c.Config.Storage.CacheMaintenanceInterval != 0 {
+		c.StoreController.DefaultStore.RunCacheMaintenancePeriodically(c.Config.Storage.CacheMaintenanceInterval,
+			taskScheduler)
+	}
+
 	// Enable extensions if extension config is provided for DefaultStore
 	if c.Config != nil && c.Config.Extensions != nil {
 		ext.EnableMetricsExtension(c.Config, c.Log, c.Config.Storage.RootDirectory)
-		ext.EnableSearchExtension(c.Config, c.StoreController, c.RepoDB, taskScheduler, c.CveInfo, c.Log)
+		ext.EnableSearchExtension(c.Config, c.StoreController, c.RepoDB, taskScheduler, c.CveInfo, c.Metrics, c.Log)
+		ext.EnableCVEReports(c.Config, taskScheduler, c.CVEReporter, c.Log)
+		ext.EnableAuditSnapshots(c.Config, taskScheduler, c.AuditSnapshots, c.Log)
 	}
 
 	if c.Config.Storage.SubPaths != nil {
@@ -338,6 +869,11 @@ func (c *Controller) StartBackgroundTasks(reloadCtx context.Context) {
 				c.StoreController.SubStore[route].RunGCPeriodically(storageConfig.GCInterval, taskScheduler)
 			}
 
+			// Enable flushing written files to disk periodically for subImageStore
+			if storageConfig.GetCommitPolicy() == storageConstants.CommitPolicyPeriodic {
+				c.StoreController.SubStore[route].RunCommitPeriodically(storageConfig.GetCommitInterval(), taskScheduler)
+			}
+
 			// Enable extensions if extension config is provided for subImageStore
 			if c.Config != nil && c.Config.Extensions != nil {
 				ext.EnableMetricsExtension(c.Config, c.Log, storageConfig.RootDirectory)
@@ -347,19 +883,26 @@ func (c *Controller) StartBackgroundTasks(reloadCtx context.Context) {
 			substore := c.StoreController.SubStore[route]
 			if substore != nil {
 				substore.RunDedupeBlobs(time.Duration(0), taskScheduler)
+
+				if storageConfig.CacheMaintenanceInterval != 0 {
+					substore.RunCacheMaintenancePeriodically(storageConfig.CacheMaintenanceInterval, taskScheduler)
+				}
 			}
 		}
 	}
 
 	if c.Config.Extensions != nil {
-		ext.EnableScrubExtension(c.Config, c.Log, c.StoreController, taskScheduler)
+		ext.EnableScrubExtension(c.Config, c.Log, c.StoreController, c.Metrics, taskScheduler)
 
-		syncOnDemand, err := ext.EnableSyncExtension(c.Config, c.RepoDB, c.StoreController, taskScheduler, c.Log)
+		syncOnDemand, err := ext.EnableSyncExtension(c.Config, c.RepoDB, c.StoreController, taskScheduler,
+			c.SyncConflicts, c.Metrics, c.Log)
 		if err != nil {
 			c.Log.Error().Err(err).Msg("unable to start sync extension")
 		}
 
 		c.SyncOnDemand = syncOnDemand
+
+		ext.EnableTelemetryExtension(c.Config, c.StoreController, taskScheduler, c.Log)
 	}
 
 	if c.Config.Extensions != nil {
@@ -370,6 +913,6 @@ func (c *Controller) StartBackgroundTasks(reloadCtx context.Context) {
 }
 
 type SyncOnDemand interface {
-	SyncImage(repo, reference string) error
-	SyncReference(repo string, subjectDigestStr string, referenceType string) error
+	SyncImage(ctx context.Context, repo, reference string) error
+	SyncReference(ctx context.Context, repo string, subjectDigestStr string, referenceType string) error
 }
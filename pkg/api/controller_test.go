@@ -6,6 +6,7 @@ package api_test
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -25,6 +26,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/klauspost/compress/zstd"
 	vldap "github.com/nmcclain/ldap"
 	notreg "github.com/notaryproject/notation-go/registry"
 	distext "github.com/opencontainers/distribution-spec/specs-go/v1/extensions"
@@ -44,15 +46,22 @@ import (
 
 	"zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/api"
+	"zotregistry.io/zot/pkg/api/blocklist"
 	"zotregistry.io/zot/pkg/api/config"
 	"zotregistry.io/zot/pkg/api/constants"
 	apiErr "zotregistry.io/zot/pkg/api/errors"
+	"zotregistry.io/zot/pkg/api/hooks"
+	"zotregistry.io/zot/pkg/api/roles"
 	"zotregistry.io/zot/pkg/common"
 	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/extensions/plugins"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/repodb/repodbfactory"
 	"zotregistry.io/zot/pkg/storage"
 	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/local"
+	"zotregistry.io/zot/pkg/storage/quota"
 	"zotregistry.io/zot/pkg/test"
 	"zotregistry.io/zot/pkg/test/inject"
 )
@@ -572,6 +581,51 @@ func TestAllowMethodsHeader(t *testing.T) {
 	})
 }
 
+func TestHtpasswdHashFormats(t *testing.T) {
+	Convey("Users with passphrases hashed in different formats", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		// sha512-crypt and scrypt hashes of "Hello world!" and "test", the format is detected for each user
+		content := getCredString(ALICE, ALICE) + "\n" +
+			"carol:$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1\n" +
+			"dave:$scrypt$ln=14,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg$Nb+CrdPpamPozpkiW4HbOaeq726iWjhjKj0njlfCwjA\n" +
+			"eve:{SHA}qUqP5cyxm6YcTAhz05Hph5gvu9M=\n"
+
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		htpasswdPath := test.MakeHtpasswdFileFromString(content)
+		defer os.Remove(htpasswdPath)
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		for user, password := range map[string]string{ALICE: ALICE, "carol": "Hello world!", "dave": "test"} {
+			resp, _ := resty.R().SetBasicAuth(user, password).Get(baseURL + "/v2/")
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			resp, _ = resty.R().SetBasicAuth(user, password+"x").Get(baseURL + "/v2/")
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+		}
+
+		// unsupported formats are rejected
+		resp, _ := resty.R().SetBasicAuth("eve", "test").Get(baseURL + "/v2/")
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+	})
+}
+
 func TestHtpasswdTwoCreds(t *testing.T) {
 	Convey("Two creds", t, func() {
 		twoCredTests := []string{}
@@ -1522,6 +1576,201 @@ func TestTLSMutualAuth(t *testing.T) {
 	})
 }
 
+func TestMultipleListeners(t *testing.T) {
+	Convey("Serve plain HTTP and HTTPS with client certificates on separate listeners", t, func() {
+		caCert, err := os.ReadFile(CACert)
+		So(err, ShouldBeNil)
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(caCert)
+
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		securePort := test.GetFreePort()
+		secureBaseURL := test.GetSecureBaseURL(securePort)
+
+		resty.SetTLSClientConfig(&tls.Config{RootCAs: caCertPool, MinVersion: tls.VersionTLS12})
+		defer func() { resty.SetTLSClientConfig(nil) }()
+		conf := config.New()
+		conf.HTTP.Listeners = []config.ListenerConfig{
+			{Address: "127.0.0.1", Port: port},
+			{
+				Address: "127.0.0.1",
+				Port:    securePort,
+				TLS: &config.TLSConfig{
+					Cert:   ServerCert,
+					Key:    ServerKey,
+					CACert: CACert,
+				},
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+
+		expectedPort, err := strconv.Atoi(port)
+		So(err, ShouldBeNil)
+		So(ctlr.GetPort(), ShouldEqual, expectedPort)
+
+		resp, err := resty.R().Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// client certificates are required on the TLS listener
+		_, err = resty.R().Get(secureBaseURL + "/v2/")
+		So(err, ShouldNotBeNil)
+
+		cert, err := tls.LoadX509KeyPair("../../test/data/client.cert", "../../test/data/client.key")
+		So(err, ShouldBeNil)
+
+		resty.SetCertificates(cert)
+		defer func() { resty.SetCertificates(tls.Certificate{}) }()
+
+		resp, err = resty.R().Get(secureBaseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// plain HTTP isn't served on the TLS listener
+		resp, err = resty.R().Get(test.GetBaseURL(securePort) + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		// both listeners are stopped
+		cm.StopServer()
+
+		_, err = resty.R().Get(baseURL + "/v2/")
+		So(err, ShouldNotBeNil)
+
+		_, err = resty.R().Get(secureBaseURL + "/v2/")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Fail to start if one of the listeners can't listen", t, func() {
+		port := test.GetFreePort()
+
+		conf := config.New()
+		conf.HTTP.Listeners = []config.ListenerConfig{
+			{Address: "127.0.0.1", Port: port},
+			{Address: "127.0.0.1", Port: port},
+		}
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err := ctlr.Init(ctx)
+		So(err, ShouldBeNil)
+
+		err = ctlr.Run(ctx)
+		So(err, ShouldNotBeNil)
+
+		// the first listener was closed
+		_, err = resty.R().Get(test.GetBaseURL(port) + "/v2/")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Authenticate the requests with the auth settings of their listener", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		authPort := test.GetFreePort()
+		authBaseURL := test.GetBaseURL(authPort)
+
+		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(ALICE, ALICE))
+		defer os.Remove(htpasswdPath)
+
+		conf := config.New()
+		conf.HTTP.Listeners = []config.ListenerConfig{
+			{Address: "127.0.0.1", Port: port},
+			{
+				Address: "127.0.0.1",
+				Port:    authPort,
+				Auth: &config.AuthConfig{
+					HTPasswd: config.AuthHTPasswd{
+						Path: htpasswdPath,
+					},
+				},
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		resp, err := resty.R().Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Get(authBaseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+		resp, err = resty.R().SetBasicAuth(ALICE, ALICE).Get(authBaseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetBasicAuth(ALICE, "wrong").Get(authBaseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+	})
+}
+
+func TestDualStackListeners(t *testing.T) {
+	// some environments, like containers, have no IPv6 loopback
+	ipv6Listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 is not available")
+	}
+
+	ipv6Listener.Close()
+
+	Convey("Listen on IPv4 and IPv6 addresses", t, func() {
+		port := test.GetFreePort()
+
+		conf := config.New()
+		conf.HTTP.Address = "127.0.0.1"
+		conf.HTTP.Addresses = []string{"::1"}
+		conf.HTTP.Port = port
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		resp, err := resty.R().Get(fmt.Sprintf("http://[::1]:%s/v2/", port))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Post(fmt.Sprintf("http://[::1]:%s/v2/repo/blobs/uploads/", port))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(resp.Header().Get("Location"), ShouldStartWith, "/v2/repo/blobs/uploads/")
+	})
+
+	Convey("Listen on IPv4 only", t, func() {
+		port := test.GetFreePort()
+		disabled := false
+
+		conf := config.New()
+		conf.HTTP.Address = ""
+		conf.HTTP.Port = port
+		conf.HTTP.IPv6 = &disabled
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		_, err := resty.R().Get(fmt.Sprintf("http://[::1]:%s/v2/", port))
+		So(err, ShouldNotBeNil)
+	})
+}
+
 func TestTLSMutualAuthAllowReadAccess(t *testing.T) {
 	Convey("Make a new controller", t, func() {
 		caCert, err := os.ReadFile(CACert)
@@ -2016,25 +2265,33 @@ func TestLDAPFailures(t *testing.T) {
 	})
 }
 
-func TestBearerAuth(t *testing.T) {
-	Convey("Make a new controller", t, func() {
-		authTestServer := test.MakeAuthTestServer(ServerKey, UnauthorizedNamespace)
-		defer authTestServer.Close()
-
+func TestLDAPFailover(t *testing.T) {
+	Convey("Make a new controller with an unreachable LDAP server", t, func() {
+		l := newTestLDAPServer()
 		port := test.GetFreePort()
+		ldapPort, err := strconv.Atoi(port)
+		So(err, ShouldBeNil)
+		l.Start(ldapPort)
+		defer l.Stop()
+
+		deadPort, err := strconv.Atoi(test.GetFreePort())
+		So(err, ShouldBeNil)
+
+		port = test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
 
 		conf := config.New()
 		conf.HTTP.Port = port
-
-		aurl, err := url.Parse(authTestServer.URL)
-		So(err, ShouldBeNil)
-
 		conf.HTTP.Auth = &config.AuthConfig{
-			Bearer: &config.BearerConfig{
-				Cert:    ServerCert,
-				Realm:   authTestServer.URL + "/auth/token",
-				Service: aurl.Host,
+			LDAP: &config.LDAPConfig{
+				Insecure:      true,
+				Address:       LDAPAddress,
+				Port:          deadPort,
+				Addresses:     []string{fmt.Sprintf("%s:%d", LDAPAddress, ldapPort)},
+				BindDN:        LDAPBindDN,
+				BindPassword:  LDAPBindPassword,
+				BaseDN:        LDAPBaseDN,
+				UserAttribute: "uid",
 			},
 		}
 		ctlr := makeController(conf, t.TempDir(), "")
@@ -2043,494 +2300,632 @@ func TestBearerAuth(t *testing.T) {
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		blob := []byte("hello, blob!")
-		digest := godigest.FromBytes(blob).String()
+		// the failover server is used
+		resp, _ := resty.R().SetBasicAuth(username, passphrase).Get(baseURL + "/v2/")
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		resp, err := resty.R().Get(baseURL + "/v2/")
-		So(err, ShouldBeNil)
+		resp, _ = resty.R().SetBasicAuth(username, "wrong").Get(baseURL + "/v2/")
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+	})
 
-		authorizationHeader := test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
-		resp, err = resty.R().
-			SetQueryParam("service", authorizationHeader.Service).
-			SetQueryParam("scope", authorizationHeader.Scope).
-			Get(authorizationHeader.Realm)
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		var goodToken test.AccessTokenResponse
-		err = json.Unmarshal(resp.Body(), &goodToken)
-		So(err, ShouldBeNil)
+	Convey("Skip unreachable LDAP servers", t, func() {
+		deadPort, err := strconv.Atoi(test.GetFreePort())
+		So(err, ShouldBeNil)
+
+		lc := &api.LDAPClient{
+			Host:             LDAPAddress,
+			Port:             deadPort,
+			SkipTLS:          true,
+			BindDN:           LDAPBindDN,
+			BindPassword:     LDAPBindPassword,
+			Base:             LDAPBaseDN,
+			UserFilter:       "(uid=%s)",
+			FailureThreshold: 2,
+			BreakerTimeout:   100 * time.Millisecond,
+			Log:              log.NewLogger("debug", ""),
+		}
 
-		resp, err = resty.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
-			Get(baseURL + "/v2/")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		// the server is skipped after 2 failed connections instead of retrying for all the retries
+		start := time.Now()
+		_, _, _, err = lc.Authenticate(username, passphrase)
+		So(goerrors.Is(err, errors.ErrLDAPBadConn), ShouldBeTrue)
+		So(time.Since(start), ShouldBeLessThan, 5*time.Second)
 
-		resp, err = resty.R().SetHeader("Authorization",
-			fmt.Sprintf("Bearer %s", goodToken.AccessToken)).Options(baseURL + "/v2/")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
+		// then logins fail right away
+		start = time.Now()
+		_, _, _, err = lc.Authenticate(username, passphrase)
+		So(goerrors.Is(err, errors.ErrLDAPBadConn), ShouldBeTrue)
+		So(time.Since(start), ShouldBeLessThan, time.Second)
 
-		resp, err = resty.R().Post(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/uploads/")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+		// the server is used again once the background check finds it reachable
+		l := newTestLDAPServer()
+		l.Start(deadPort)
+		defer l.Stop()
 
-		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
-		resp, err = resty.R().
-			SetQueryParam("service", authorizationHeader.Service).
-			SetQueryParam("scope", authorizationHeader.Scope).
-			Get(authorizationHeader.Realm)
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		err = json.Unmarshal(resp.Body(), &goodToken)
-		So(err, ShouldBeNil)
+		time.Sleep(200 * time.Millisecond)
 
-		resp, err = resty.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
-			Post(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/uploads/")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc := resp.Header().Get("Location")
+		So(func() error {
+			_, _, _, err := lc.Authenticate(username, passphrase)
 
-		resp, err = resty.R().
-			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetQueryParam("digest", digest).
-			SetBody(blob).
-			Put(baseURL + loc)
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+			return err
+		}(), ShouldNotBeNil)
 
-		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
-		resp, err = resty.R().
-			SetQueryParam("service", authorizationHeader.Service).
-			SetQueryParam("scope", authorizationHeader.Scope).
-			Get(authorizationHeader.Realm)
+		ok := false
+
+		for i := 0; i < 50 && !ok; i++ {
+			time.Sleep(100 * time.Millisecond)
+
+			ok, _, _, _ = lc.Authenticate(username, passphrase)
+		}
+
+		So(ok, ShouldBeTrue)
+	})
+}
+
+func TestTagAliases(t *testing.T) {
+	Convey("Update tag aliases when pushing tags", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.TagAliases = []config.TagAliasRule{
+			{
+				Repositories: []string{"apps/**"},
+				Tag:          `(\d+)\.(\d+)\.(\d+)`,
+				Aliases:      []string{"$1.$2", "$1", "latest"},
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		img, err := test.GetRandomImage("1.2.3")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		err = json.Unmarshal(resp.Body(), &goodToken)
+
+		digest, err := img.Digest()
 		So(err, ShouldBeNil)
 
-		resp, err = resty.R().
-			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
-			SetQueryParam("digest", digest).
-			SetBody(blob).
-			Put(baseURL + loc)
+		err = test.UploadImage(img, baseURL, "apps/frontend")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		resp, err = resty.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
-			Get(baseURL + "/v2/" + AuthorizedNamespace + "/tags/list")
+		for _, tag := range []string{"1.2.3", "1.2", "1", "latest"} {
+			resp, err := resty.R().Head(baseURL + "/v2/apps/frontend/manifests/" + tag)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, digest.String())
+		}
+
+		// newer versions move the aliases
+		newImg, err := test.GetRandomImage("1.3.0")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
-		resp, err = resty.R().
-			SetQueryParam("service", authorizationHeader.Service).
-			SetQueryParam("scope", authorizationHeader.Scope).
-			Get(authorizationHeader.Realm)
+		newDigest, err := newImg.Digest()
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		err = json.Unmarshal(resp.Body(), &goodToken)
+
+		err = test.UploadImage(newImg, baseURL, "apps/frontend")
 		So(err, ShouldBeNil)
 
-		resp, err = resty.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
-			Get(baseURL + "/v2/" + AuthorizedNamespace + "/tags/list")
+		for tag, expectedDigest := range map[string]godigest.Digest{
+			"1.2": digest, "1.3": newDigest, "1": newDigest, "latest": newDigest,
+		} {
+			resp, err := resty.R().Head(baseURL + "/v2/apps/frontend/manifests/" + tag)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, expectedDigest.String())
+		}
+
+		// other repos and tags are left alone
+		img, err = test.GetRandomImage("1.2.3")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		resp, err = resty.R().
-			Post(baseURL + "/v2/" + UnauthorizedNamespace + "/blobs/uploads/")
+		err = test.UploadImage(img, baseURL, "infra/db")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
-		resp, err = resty.R().
-			SetQueryParam("service", authorizationHeader.Service).
-			SetQueryParam("scope", authorizationHeader.Scope).
-			Get(authorizationHeader.Realm)
+		resp, err := resty.R().Get(baseURL + "/v2/infra/db/tags/list")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		var badToken test.AccessTokenResponse
-		err = json.Unmarshal(resp.Body(), &badToken)
-		So(err, ShouldBeNil)
 
-		resp, err = resty.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", badToken.AccessToken)).
-			Post(baseURL + "/v2/" + UnauthorizedNamespace + "/blobs/uploads/")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+		var tags api.ImageTags
+		So(json.Unmarshal(resp.Body(), &tags), ShouldBeNil)
+		So(tags.Tags, ShouldResemble, []string{"1.2.3"})
 	})
-}
 
-func TestBearerAuthWithAllowReadAccess(t *testing.T) {
-	Convey("Make a new controller", t, func() {
-		authTestServer := test.MakeAuthTestServer(ServerKey, UnauthorizedNamespace)
-		defer authTestServer.Close()
+	Convey("Moving existing tag aliases needs update permission", t, func() {
+		htpasswdPath := test.MakeHtpasswdFile()
+		defer os.Remove(htpasswdPath)
 
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
 
 		conf := config.New()
 		conf.HTTP.Port = port
-
-		aurl, err := url.Parse(authTestServer.URL)
-		So(err, ShouldBeNil)
-
 		conf.HTTP.Auth = &config.AuthConfig{
-			Bearer: &config.BearerConfig{
-				Cert:    ServerCert,
-				Realm:   authTestServer.URL + "/auth/token",
-				Service: aurl.Host,
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
 			},
 		}
-		ctlr := makeController(conf, t.TempDir(), "")
-
 		conf.HTTP.AccessControl = &config.AccessControlConfig{
 			Repositories: config.Repositories{
 				AuthorizationAllRepos: config.PolicyGroup{
-					AnonymousPolicy: []string{"read"},
+					DefaultPolicy: []string{"read", "create"},
 				},
 			},
 		}
+		conf.Storage.TagAliases = []config.TagAliasRule{
+			{
+				Tag:     `(\d+)\.(\d+)\.(\d+)`,
+				Aliases: []string{"latest"},
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir(), "")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		blob := []byte("hello, blob!")
-		digest := godigest.FromBytes(blob).String()
+		img, err := test.GetRandomImage("1.2.3")
+		So(err, ShouldBeNil)
 
-		resp, err := resty.R().Get(baseURL + "/v2/")
+		digest, err := img.Digest()
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		authorizationHeader := test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
-		resp, err = resty.R().
-			SetQueryParam("service", authorizationHeader.Service).
-			SetQueryParam("scope", authorizationHeader.Scope).
-			Get(authorizationHeader.Realm)
+		// creating the alias only needs create permission
+		err = test.UploadImageWithBasicAuth(img, baseURL, "app", username, passphrase)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		var goodToken test.AccessTokenResponse
-		err = json.Unmarshal(resp.Body(), &goodToken)
+
+		newImg, err := test.GetRandomImage("1.3.0")
 		So(err, ShouldBeNil)
 
-		resp, err = resty.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
-			Get(baseURL + "/v2/")
+		err = test.UploadImageWithBasicAuth(newImg, baseURL, "app", username, passphrase)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		resp, err = resty.R().Post(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/uploads/")
+		manifestBlob, err := json.Marshal(newImg.Manifest)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
-		resp, err = resty.R().
-			SetQueryParam("service", authorizationHeader.Service).
-			SetQueryParam("scope", authorizationHeader.Scope).
-			Get(authorizationHeader.Realm)
+		resp, err := resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/app/manifests/1.3.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		// neither the tag nor its alias were written
+		resp, err = resty.R().SetBasicAuth(username, passphrase).Get(baseURL + "/v2/app/tags/list")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		err = json.Unmarshal(resp.Body(), &goodToken)
+
+		var tags api.ImageTags
+		So(json.Unmarshal(resp.Body(), &tags), ShouldBeNil)
+		So(tags.Tags, ShouldResemble, []string{"1.2.3", "latest"})
+
+		resp, err = resty.R().SetBasicAuth(username, passphrase).Head(baseURL + "/v2/app/manifests/latest")
 		So(err, ShouldBeNil)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, digest.String())
+	})
+}
 
-		resp, err = resty.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
-			Post(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/uploads/")
+func TestImmutableTags(t *testing.T) {
+	Convey("Reject pushing immutable tags with another manifest", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.ImmutableTags = []config.ImmutableTagsRule{
+			{
+				Repositories: []string{"releases/**"},
+				Tags:         []string{`\d+\.\d+\.\d+`, `\d+\.\d+`},
+			},
+		}
+		conf.Storage.TagAliases = []config.TagAliasRule{
+			{
+				Tag:     `(\d+)\.(\d+)\.(\d+)`,
+				Aliases: []string{"$1.$2", "latest"},
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		img, err := test.GetRandomImage("1.2.3")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc := resp.Header().Get("Location")
 
-		resp, err = resty.R().
-			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetQueryParam("digest", digest).
-			SetBody(blob).
-			Put(baseURL + loc)
+		digest, err := img.Digest()
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
-		resp, err = resty.R().
-			SetQueryParam("service", authorizationHeader.Service).
-			SetQueryParam("scope", authorizationHeader.Scope).
-			Get(authorizationHeader.Realm)
+		err = test.UploadImage(img, baseURL, "releases/app")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		err = json.Unmarshal(resp.Body(), &goodToken)
+
+		// pushing the same manifest again is allowed
+		err = test.UploadImage(img, baseURL, "releases/app")
 		So(err, ShouldBeNil)
 
-		resp, err = resty.R().
-			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
-			SetQueryParam("digest", digest).
-			SetBody(blob).
-			Put(baseURL + loc)
+		newImg, err := test.GetRandomImage("1.2.4")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		resp, err = resty.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
-			Get(baseURL + "/v2/" + AuthorizedNamespace + "/tags/list")
+		newDigest, err := newImg.Digest()
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
-		resp, err = resty.R().
-			SetQueryParam("service", authorizationHeader.Service).
-			SetQueryParam("scope", authorizationHeader.Scope).
-			Get(authorizationHeader.Realm)
+		err = test.UploadImage(newImg, baseURL, "releases/app")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		err = json.Unmarshal(resp.Body(), &goodToken)
+
+		// immutable aliases aren't moved, the others are
+		for tag, expectedDigest := range map[string]godigest.Digest{
+			"1.2.3": digest, "1.2": digest, "1.2.4": newDigest, "latest": newDigest,
+		} {
+			resp, err := resty.R().Head(baseURL + "/v2/releases/app/manifests/" + tag)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, expectedDigest.String())
+		}
+
+		manifestBlob, err := json.Marshal(newImg.Manifest)
 		So(err, ShouldBeNil)
 
-		resp, err = resty.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
-			Get(baseURL + "/v2/" + AuthorizedNamespace + "/tags/list")
+		resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/releases/app/manifests/1.2.3")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.StatusCode(), ShouldEqual, http.StatusConflict)
 
-		resp, err = resty.R().
-			Post(baseURL + "/v2/" + UnauthorizedNamespace + "/blobs/uploads/")
+		var errList apiErr.ErrorList
+		So(json.Unmarshal(resp.Body(), &errList), ShouldBeNil)
+		So(errList.Errors, ShouldHaveLength, 1)
+		So(errList.Errors[0].Code, ShouldEqual, "TAG_IMMUTABLE")
+
+		resp, err = resty.R().Head(baseURL + "/v2/releases/app/manifests/1.2.3")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, digest.String())
 
-		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
-		resp, err = resty.R().
-			SetQueryParam("service", authorizationHeader.Service).
-			SetQueryParam("scope", authorizationHeader.Scope).
-			Get(authorizationHeader.Realm)
+		// mutable tags and other repos are left alone
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/releases/app/manifests/latest-1.2")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		var badToken test.AccessTokenResponse
-		err = json.Unmarshal(resp.Body(), &badToken)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		err = test.UploadImage(img, baseURL, "apps/app")
 		So(err, ShouldBeNil)
 
-		resp, err = resty.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", badToken.AccessToken)).
-			Post(baseURL + "/v2/" + UnauthorizedNamespace + "/blobs/uploads/")
+		newImg.Reference = "1.2.3"
+
+		err = test.UploadImage(newImg, baseURL, "apps/app")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
-	})
-}
 
-func TestAuthorizationWithBasicAuth(t *testing.T) {
-	Convey("Make a new controller", t, func() {
-		port := test.GetFreePort()
-		baseURL := test.GetBaseURL(port)
+		// the image store checks the tags again under its lock
+		imgStore := ctlr.StoreController.DefaultStore
 
-		conf := config.New()
-		conf.HTTP.Port = port
-		htpasswdPath := test.MakeHtpasswdFile()
-		defer os.Remove(htpasswdPath)
+		_, _, err = imgStore.PutImageManifest("releases/app", "1.2.3", ispec.MediaTypeImageManifest, manifestBlob)
+		So(err, ShouldEqual, errors.ErrTagImmutable)
 
-		conf.HTTP.Auth = &config.AuthConfig{
-			HTPasswd: config.AuthHTPasswd{
-				Path: htpasswdPath,
-			},
+		err = imgStore.TagImageManifest("releases/app", newDigest, []string{"1.2"})
+		So(err, ShouldEqual, errors.ErrTagImmutable)
+
+		// immutable tags can't be deleted, neither by tag nor by the digest they point to
+		for _, reference := range []string{"1.2", digest.String()} {
+			resp, err = resty.R().Delete(baseURL + "/v2/releases/app/manifests/" + reference)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusConflict)
+			So(json.Unmarshal(resp.Body(), &errList), ShouldBeNil)
+			So(errList.Errors, ShouldHaveLength, 1)
+			So(errList.Errors[0].Code, ShouldEqual, "TAG_IMMUTABLE")
 		}
-		conf.HTTP.AccessControl = &config.AccessControlConfig{
-			Repositories: config.Repositories{
-				AuthorizationAllRepos: config.PolicyGroup{
-					Policies: []config.Policy{
-						{
-							Users:   []string{},
-							Actions: []string{},
-						},
-					},
-					DefaultPolicy: []string{},
-				},
-			},
-			AdminPolicy: config.Policy{
-				Users:   []string{},
-				Actions: []string{},
-			},
+
+		resp, err = resty.R().Head(baseURL + "/v2/releases/app/manifests/1.2")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// mutable tags can
+		resp, err = resty.R().Delete(baseURL + "/v2/releases/app/manifests/latest-1.2")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+	})
+}
+
+func TestPreReceiveHooks(t *testing.T) {
+	Convey("Validate pushed manifests with pre-receive hooks", t, func() {
+		hookServer := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			var hookRequest hooks.Request
+
+			if err := json.NewDecoder(request.Body).Decode(&hookRequest); err != nil {
+				response.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			if hookRequest.Reference == "latest" {
+				_ = json.NewEncoder(response).Encode(hooks.Response{Message: "pushing latest is not allowed"})
+
+				return
+			}
+
+			_ = json.NewEncoder(response).Encode(hooks.Response{Allowed: true})
+		}))
+		defer hookServer.Close()
+
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.PreReceiveHooks = []config.PreReceiveHookConfig{
+			{URL: hookServer.URL, Repositories: []string{"apps/**"}},
+			// unreachable, only applies to the infra repos
+			{URL: "http://127.0.0.1:1", Repositories: []string{"infra/**"}, Timeout: time.Second},
 		}
 
-		ctlr := makeController(conf, t.TempDir(), "../../test/data")
+		ctlr := makeController(conf, t.TempDir(), "")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		blob := []byte("hello, blob!")
-		digest := godigest.FromBytes(blob).String()
-
-		// unauthenticated clients should not have access to /v2/
-		resp, err := resty.R().Get(baseURL + "/v2/")
+		img, err := test.GetRandomImage("1.0")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, 401)
 
-		// everybody should have access to /v2/
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/")
+		err = test.UploadImage(img, baseURL, "apps/frontend")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// everybody should have access to /v2/_catalog
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + constants.RoutePrefix + constants.ExtCatalogPrefix)
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		var apiErr apiErr.Error
-		err = json.Unmarshal(resp.Body(), &apiErr)
+		content, err := json.Marshal(img.Manifest)
 		So(err, ShouldBeNil)
 
-		// should get 403 without create
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(content).
+			Put(baseURL + "/v2/apps/frontend/manifests/latest")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(string(resp.Body()), ShouldContainSubstring, "pushing latest is not allowed")
 
-		// first let's use global based policies
-		// add test user to global policy with create perm
-		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Users = append(conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Users, "test") //nolint:lll // gofumpt conflicts with lll
+		resp, err = resty.R().Head(baseURL + "/v2/apps/frontend/manifests/latest")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
 
-		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions, "create") //nolint:lll // gofumpt conflicts with lll
+		// the unreachable hook is fail closed
+		err = test.UploadImage(img, baseURL, "infra/db")
+		So(err, ShouldNotBeNil)
 
-		// now it should get 202
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(content).
+			Put(baseURL + "/v2/infra/db/manifests/1.0")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc := resp.Header().Get("Location")
+		So(resp.StatusCode(), ShouldEqual, http.StatusServiceUnavailable)
 
-		// uploading blob should get 201
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetQueryParam("digest", digest).
-			SetBody(blob).
-			Put(baseURL + loc)
+		// repos without hooks are left alone
+		err = test.UploadImage(img, baseURL, "other")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		// head blob should get 403 without read perm
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		Convey("Fail open hooks accept the manifests when they fail", func() {
+			ctlr.Config.Storage.PreReceiveHooks[1].FailOpen = true
+			err := ctlr.PreReceiveHooks.Set(ctlr.Config.Storage.PreReceiveHooks)
+			So(err, ShouldBeNil)
 
-		// get tags without read access should get 403
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+			err = test.UploadImage(img, baseURL, "infra/db")
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestBlobPolicies(t *testing.T) {
+	Convey("Enforce the blob policies of the repos", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.BlobPolicies = []config.BlobPolicyConfig{
+			{
+				Repositories:      []string{"limited/**"},
+				MaxLayerSize:      1024,
+				AllowedMediaTypes: []string{"application/vnd.oci.image.layer.v1.tar+zstd"},
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		content := make([]byte, 2048)
+		digest := godigest.FromBytes(content)
+
+		Convey("Oversized streamed uploads are rejected", func() {
+			resp, err := resty.R().Post(baseURL + "/v2/limited/app/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+			loc := test.Location(baseURL, resp)
+
+			resp, err = resty.R().SetHeader("Content-Type", "application/octet-stream").SetBody(content).Patch(loc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestEntityTooLarge)
+			So(string(resp.Body()), ShouldContainSubstring, "SIZE_INVALID")
+
+			// the upload is discarded
+			resp, err = resty.R().Get(loc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Oversized monolithic uploads are rejected", func() {
+			resp, err := resty.R().Post(baseURL + "/v2/limited/app/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+			loc := test.Location(baseURL, resp)
+
+			resp, err = resty.R().SetHeader("Content-Type", "application/octet-stream").
+				SetQueryParam("digest", digest.String()).SetBody(content).Put(loc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestEntityTooLarge)
+		})
+
+		Convey("Blobs of other repos aren't limited", func() {
+			resp, err := resty.R().Post(baseURL + "/v2/other/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+			loc := test.Location(baseURL, resp)
+
+			resp, err = resty.R().SetHeader("Content-Type", "application/octet-stream").SetBody(content).Patch(loc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		})
+
+		Convey("Manifests with layers of other media types are rejected", func() {
+			img, err := test.GetRandomImage("1.0")
+			So(err, ShouldBeNil)
+
+			err = test.UploadImage(img, baseURL, "limited/app")
+			So(err, ShouldNotBeNil)
+
+			manifestBlob, err := json.Marshal(img.Manifest)
+			So(err, ShouldBeNil)
+
+			resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+				Put(baseURL + "/v2/limited/app/manifests/1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+			So(string(resp.Body()), ShouldContainSubstring, "MANIFEST_INVALID")
+
+			ctlr.Config.Storage.BlobPolicies[0].AllowedMediaTypes = []string{"application/vnd.oci.image.layer.v1.*"}
+			err = ctlr.BlobPolicies.Set(ctlr.Config.Storage.BlobPolicies)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImage(img, baseURL, "limited/app")
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestZstdLayers(t *testing.T) {
+	Convey("Push and pull images with zstd layers", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		img, err := test.GetRandomImage("1.0")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		// get tags with read access should get 200
-		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions, "read") //nolint:lll // gofumpt conflicts with lll
+		img.Manifest.Layers[0].MediaType = ispec.MediaTypeImageLayerZstd
 
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		err = test.UploadImage(img, baseURL, "zstd")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// head blob should get 200 now
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		resp, err := resty.R().SetHeader("Accept", ispec.MediaTypeImageManifest).
+			Get(baseURL + "/v2/zstd/manifests/1.0")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// get blob should get 200 now
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		var manifest ispec.Manifest
+		err = json.Unmarshal(resp.Body(), &manifest)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(manifest.Layers[0].MediaType, ShouldEqual, ispec.MediaTypeImageLayerZstd)
 
-		// delete blob should get 403 without delete perm
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		resp, err = resty.R().SetHeader("Accept", ispec.MediaTypeImageLayerZstd).
+			Get(baseURL + "/v2/zstd/blobs/" + manifest.Layers[0].Digest.String())
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Body(), ShouldResemble, img.Layers[0])
+		So(resp.Header().Get("Content-Type"), ShouldEqual, ispec.MediaTypeImageLayerZstd)
+	})
+}
 
-		// add delete perm on repo
-		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions, "delete") //nolint:lll // gofumpt conflicts with lll
+func TestBearerAuth(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		authTestServer := test.MakeAuthTestServer(ServerKey, UnauthorizedNamespace)
+		defer authTestServer.Close()
 
-		// delete blob should get 202
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		aurl, err := url.Parse(authTestServer.URL)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-		// now let's use only repository based policies
-		// add test user to repo's policy with create perm
-		// longest path matching should match the repo and not **/*
-		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace] = config.PolicyGroup{
-			Policies: []config.Policy{
-				{
-					Users:   []string{},
-					Actions: []string{},
-				},
+		conf.HTTP.Auth = &config.AuthConfig{
+			Bearer: &config.BearerConfig{
+				Cert:    ServerCert,
+				Realm:   authTestServer.URL + "/auth/token",
+				Service: aurl.Host,
 			},
-			DefaultPolicy: []string{},
 		}
+		ctlr := makeController(conf, t.TempDir(), "")
 
-		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Users = append(conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Users, "test")       //nolint:lll // gofumpt conflicts with lll
-		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions, "create") //nolint:lll // gofumpt conflicts with lll
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-		// now it should get 202
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		blob := []byte("hello, blob!")
+		digest := godigest.FromBytes(blob).String()
+
+		resp, err := resty.R().Get(baseURL + "/v2/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc = resp.Header().Get("Location")
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		// uploading blob should get 201
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
+		authorizationHeader := test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
+		resp, err = resty.R().
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		var goodToken test.AccessTokenResponse
+		err = json.Unmarshal(resp.Body(), &goodToken)
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
+			Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetHeader("Authorization",
+			fmt.Sprintf("Bearer %s", goodToken.AccessToken)).Options(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
+
+		resp, err = resty.R().Post(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
+		resp, err = resty.R().
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &goodToken)
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
+			Post(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc := resp.Header().Get("Location")
+
+		resp, err = resty.R().
 			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
 			SetHeader("Content-Type", "application/octet-stream").
 			SetQueryParam("digest", digest).
@@ -2538,452 +2933,365 @@ func TestAuthorizationWithBasicAuth(t *testing.T) {
 			Put(baseURL + loc)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		// head blob should get 403 without read perm
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
+		resp, err = resty.R().
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &goodToken)
+		So(err, ShouldBeNil)
 
-		// get tags without read access should get 403
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		resp, err = resty.R().
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
+			SetQueryParam("digest", digest).
+			SetBody(blob).
+			Put(baseURL + loc)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		// get tags with read access should get 200
-		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions, "read") //nolint:lll // gofumpt conflicts with lll
+		resp, err = resty.R().
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
+			Get(baseURL + "/v2/" + AuthorizedNamespace + "/tags/list")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
+		resp, err = resty.R().
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &goodToken)
+		So(err, ShouldBeNil)
 
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		resp, err = resty.R().
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
+			Get(baseURL + "/v2/" + AuthorizedNamespace + "/tags/list")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// head blob should get 200 now
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		resp, err = resty.R().
+			Post(baseURL + "/v2/" + UnauthorizedNamespace + "/blobs/uploads/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		// get blob should get 200 now
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
+		resp, err = resty.R().
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		var badToken test.AccessTokenResponse
+		err = json.Unmarshal(resp.Body(), &badToken)
+		So(err, ShouldBeNil)
 
-		// delete blob should get 403 without delete perm
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		resp, err = resty.R().
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", badToken.AccessToken)).
+			Post(baseURL + "/v2/" + UnauthorizedNamespace + "/blobs/uploads/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+	})
+}
 
-		// add delete perm on repo
-		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions, "delete") //nolint:lll // gofumpt conflicts with lll
+func TestBearerPullTokens(t *testing.T) {
+	Convey("Share images with pull tokens", t, func() {
+		authTestServer := test.MakeAuthTestServer(ServerKey, UnauthorizedNamespace)
+		defer authTestServer.Close()
 
-		// delete blob should get 202
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		dir := t.TempDir()
+
+		keyFile := path.Join(t.TempDir(), "pulltokens.key")
+		err := os.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef"), 0o600)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-		// remove permissions on **/* so it will not interfere with zot-test namespace
-		repoPolicy := conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos]
-		repoPolicy.Policies = []config.Policy{}
-		repoPolicy.DefaultPolicy = []string{}
-		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos] = repoPolicy
+		conf := config.New()
+		conf.HTTP.Port = port
 
-		// get manifest should get 403, we don't have perm at all on this repo
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/zot-test/manifests/0.0.1")
+		aurl, err := url.Parse(authTestServer.URL)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		// add read perm on repo
-		conf.HTTP.AccessControl.Repositories["zot-test"] = config.PolicyGroup{Policies: []config.Policy{
-			{
-				Users:   []string{"test"},
-				Actions: []string{"read"},
+		conf.HTTP.Auth = &config.AuthConfig{
+			Bearer: &config.BearerConfig{
+				Cert:       ServerCert,
+				Realm:      authTestServer.URL + "/auth/token",
+				Service:    aurl.Host,
+				PullTokens: &config.PullTokensConfig{KeyFile: keyFile, MaxTTL: time.Hour},
 			},
-		}, DefaultPolicy: []string{}}
+		}
 
-		/* we have 4 images(authz/image, golang, zot-test, zot-cve-test) in storage,
-		but because at this point we only have read access
-		in authz/image and zot-test, we should get only that when listing repositories*/
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + constants.RoutePrefix + constants.ExtCatalogPrefix)
+		logger := log.NewLogger("debug", "")
+		imgStore := local.NewImageStore(dir, false, storageConstants.DefaultGCDelay, false, false, logger,
+			monitoring.NewMetricsServer(false, logger), nil, nil)
+
+		image, err := test.GetRandomImage("1.0")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		err = json.Unmarshal(resp.Body(), &apiErr)
+
+		err = test.WriteImageToFileSystem(image, AuthorizedNamespace, storage.StoreController{DefaultStore: imgStore})
 		So(err, ShouldBeNil)
 
-		catalog := struct {
-			Repositories []string `json:"repositories"`
-		}{}
+		digest, err := image.Digest()
+		So(err, ShouldBeNil)
 
-		err = json.Unmarshal(resp.Body(), &catalog)
+		ctlr := makeController(conf, dir, "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		pullTokensURL := baseURL + constants.FullPullTokensPrefix + "/" + AuthorizedNamespace
+
+		resp, err := resty.R().Post(pullTokensURL)
 		So(err, ShouldBeNil)
-		So(len(catalog.Repositories), ShouldEqual, 2)
-		So(catalog.Repositories, ShouldContain, "zot-test")
-		So(catalog.Repositories, ShouldContain, AuthorizationNamespace)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		// get manifest should get 200 now
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/zot-test/manifests/0.0.1")
+		// minting pull tokens needs push access
+		authorizationHeader := test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
+		So(authorizationHeader.Scope, ShouldEqual, "repository:"+AuthorizedNamespace+":push")
+
+		resp, err = resty.R().
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		manifestBlob := resp.Body()
-		var manifest ispec.Manifest
+		var pushToken test.AccessTokenResponse
+		err = json.Unmarshal(resp.Body(), &pushToken)
+		So(err, ShouldBeNil)
 
-		err = json.Unmarshal(manifestBlob, &manifest)
+		resp, err = resty.R().SetAuthToken(pushToken.AccessToken).SetQueryParam("digest", digest.String()).
+			SetQueryParam("ttl", "30m").Post(pullTokensURL)
 		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		// put manifest should get 403 without create perm
-		resp, err = resty.R().SetBasicAuth(username, passphrase).SetBody(manifestBlob).
-			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
+		var imageToken api.PullTokenResponse
+		err = json.Unmarshal(resp.Body(), &imageToken)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(imageToken.Repository, ShouldEqual, AuthorizedNamespace)
+		So(imageToken.Digest, ShouldEqual, digest.String())
+		So(imageToken.ExpiresAt, ShouldHappenWithin, 31*time.Minute, time.Now())
 
-		// add create perm on repo
-		conf.HTTP.AccessControl.Repositories["zot-test"].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories["zot-test"].Policies[0].Actions, "create") //nolint:lll // gofumpt conflicts with lll
+		// the shareable URL
+		resp, err = resty.R().Get(baseURL + imageToken.URL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, digest.String())
 
-		// should get 201 with create perm
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
-			SetBody(manifestBlob).
-			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
+		resp, err = resty.R().SetAuthToken(imageToken.Token).Get(baseURL + "/v2/")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// create update config and post it.
-		cblob, cdigest := test.GetRandomImageConfig()
+		resp, err = resty.R().SetAuthToken(imageToken.Token).
+			Get(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/" + image.Manifest.Layers[0].Digest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Body(), ShouldResemble, image.Layers[0])
 
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Post(baseURL + "/v2/zot-test/blobs/uploads/")
+		// tokens scoped to an image don't allow other requests
+		for _, route := range []string{"/tags/list", "/manifests/1.0", "/blobs/" + godigest.FromString("other").String()} {
+			resp, err = resty.R().SetAuthToken(imageToken.Token).Get(baseURL + "/v2/" + AuthorizedNamespace + route)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+		}
+
+		resp, err = resty.R().SetAuthToken(imageToken.Token).Delete(baseURL + "/v2/" + AuthorizedNamespace +
+			"/manifests/" + digest.String())
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc = test.Location(baseURL, resp)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		// uploading blob should get 201
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			SetHeader("Content-Length", fmt.Sprintf("%d", len(cblob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetQueryParam("digest", cdigest.String()).
-			SetBody(cblob).
-			Put(loc)
+		resp, err = resty.R().SetAuthToken(imageToken.Token).Post(pullTokensURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+		// tokens scoped to the repo
+		resp, err = resty.R().SetAuthToken(pushToken.AccessToken).Post(pullTokensURL)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		// create updated layer and post it
-		updateBlob := []byte("Hello, blob update!")
+		var repoToken api.PullTokenResponse
+		err = json.Unmarshal(resp.Body(), &repoToken)
+		So(err, ShouldBeNil)
+		So(repoToken.Digest, ShouldBeEmpty)
 
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Post(baseURL + "/v2/zot-test/blobs/uploads/")
+		resp, err = resty.R().SetQueryParam(api.PullTokenQueryParam, repoToken.Token).
+			Get(baseURL + "/v2/" + AuthorizedNamespace + "/manifests/1.0")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc = test.Location(baseURL, resp)
-		// uploading blob should get 201
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			SetHeader("Content-Length", fmt.Sprintf("%d", len(updateBlob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetQueryParam("digest", string(godigest.FromBytes(updateBlob))).
-			SetBody(updateBlob).
-			Put(loc)
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-
-		updatedManifest := ispec.Manifest{
-			Config: ispec.Descriptor{
-				MediaType: "application/vnd.oci.image.config.v1+json",
-				Digest:    cdigest,
-				Size:      int64(len(cblob)),
-			},
-			Layers: []ispec.Descriptor{
-				{
-					MediaType: "application/vnd.oci.image.layer.v1.tar",
-					Digest:    godigest.FromBytes(updateBlob),
-					Size:      int64(len(updateBlob)),
-				},
-			},
-		}
-		updatedManifest.SchemaVersion = 2
-		updatedManifestBlob, err := json.Marshal(updatedManifest)
-		So(err, ShouldBeNil)
-
-		// update manifest should get 403 without update perm
-		resp, err = resty.R().SetBasicAuth(username, passphrase).SetBody(updatedManifestBlob).
-			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
-
-		// get the manifest and check if it's the old one
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/zot-test/manifests/0.0.2")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		So(resp.Body(), ShouldResemble, manifestBlob)
-
-		// add update perm on repo
-		conf.HTTP.AccessControl.Repositories["zot-test"].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories["zot-test"].Policies[0].Actions, "update") //nolint:lll // gofumpt conflicts with lll
-
-		// update manifest should get 201 with update perm
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
-			SetBody(updatedManifestBlob).
-			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		// get the manifest and check if it's the new updated one
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/zot-test/manifests/0.0.2")
+		resp, err = resty.R().SetAuthToken(repoToken.Token).Get(baseURL + "/v2/" + AuthorizedNamespace + "/tags/list")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		So(resp.Body(), ShouldResemble, updatedManifestBlob)
-
-		// now use default repo policy
-		conf.HTTP.AccessControl.Repositories["zot-test"].Policies[0].Actions = []string{}
-		repoPolicy = conf.HTTP.AccessControl.Repositories["zot-test"]
-		repoPolicy.DefaultPolicy = []string{"update"}
-		conf.HTTP.AccessControl.Repositories["zot-test"] = repoPolicy
 
-		// update manifest should get 201 with update perm on repo's default policy
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
-			SetBody(manifestBlob).
-			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
+		resp, err = resty.R().SetAuthToken(repoToken.Token).Get(baseURL + "/v2/other/repo/tags/list")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-
-		// with default read on repo should still get 200
-		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions = []string{}
-		repoPolicy = conf.HTTP.AccessControl.Repositories[AuthorizationNamespace]
-		repoPolicy.DefaultPolicy = []string{"read"}
-		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace] = repoPolicy
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		resp, err = resty.R().SetAuthToken(repoToken.Token).Get(baseURL + "/v2/_catalog")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-
-		// upload blob without user create but with default create should get 200
-		repoPolicy.DefaultPolicy = append(repoPolicy.DefaultPolicy, "create")
-		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace] = repoPolicy
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		// invalid requests
+		resp, err = resty.R().SetAuthToken(pushToken.AccessToken).SetQueryParam("ttl", "2h").Post(pullTokensURL)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-
-		// remove per repo policy
-		repoPolicy = conf.HTTP.AccessControl.Repositories[AuthorizationNamespace]
-		repoPolicy.Policies = []config.Policy{}
-		repoPolicy.DefaultPolicy = []string{}
-		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace] = repoPolicy
-
-		repoPolicy = conf.HTTP.AccessControl.Repositories["zot-test"]
-		repoPolicy.Policies = []config.Policy{}
-		repoPolicy.DefaultPolicy = []string{}
-		conf.HTTP.AccessControl.Repositories["zot-test"] = repoPolicy
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
 
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		resp, err = resty.R().SetAuthToken(pushToken.AccessToken).SetQueryParam("ttl", "forever").Post(pullTokensURL)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
 
-		// whithout any perm should get 403
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		resp, err = resty.R().SetAuthToken(pushToken.AccessToken).SetQueryParam("digest", "sha256:bad").
+			Post(pullTokensURL)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
 
-		// add read perm
-		conf.HTTP.AccessControl.AdminPolicy.Users = append(conf.HTTP.AccessControl.AdminPolicy.Users, "test")
-		conf.HTTP.AccessControl.AdminPolicy.Actions = append(conf.HTTP.AccessControl.AdminPolicy.Actions, "read")
-		// with read perm should get 200
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		resp, err = resty.R().SetAuthToken(pushToken.AccessToken).
+			SetQueryParam("digest", godigest.FromString("missing").String()).Post(pullTokensURL)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+}
 
-		// without create perm should 403
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+func TestBearerAuthWithBadCert(t *testing.T) {
+	Convey("A bearer cert which can't be loaded stops the controller from starting", t, func() {
+		conf := config.New()
+		conf.HTTP.Auth = &config.AuthConfig{
+			Bearer: &config.BearerConfig{
+				Cert:    path.Join(t.TempDir(), "missing.cert"),
+				Realm:   "https://auth.example.com/auth/token",
+				Service: "auth.example.com",
+			},
+		}
 
-		// add create perm
-		conf.HTTP.AccessControl.AdminPolicy.Actions = append(conf.HTTP.AccessControl.AdminPolicy.Actions, "create")
-		// with create perm should get 202
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc = resp.Header().Get("Location")
+		ctlr := makeController(conf, t.TempDir(), "")
 
-		// uploading blob should get 201
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetQueryParam("digest", digest).
-			SetBody(blob).
-			Put(baseURL + loc)
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		err := ctlr.Init(context.Background())
+		So(err, ShouldNotBeNil)
+	})
+}
 
-		// without delete perm should 403
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+func TestAuthBackendUnavailable(t *testing.T) {
+	Convey("An htpasswd file which can't be read stops the controller from starting", t, func() {
+		conf := config.New()
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: path.Join(t.TempDir(), "missing.htpasswd"),
+			},
+		}
 
-		// add delete perm
-		conf.HTTP.AccessControl.AdminPolicy.Actions = append(conf.HTTP.AccessControl.AdminPolicy.Actions, "delete")
-		// with delete perm should get http.StatusAccepted
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		ctlr := makeController(conf, t.TempDir(), "")
 
-		// without update perm should 403
-		resp, err = resty.R().SetBasicAuth(username, passphrase).SetBody(manifestBlob).
-			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		err := ctlr.Init(context.Background())
+		So(goerrors.Is(err, errors.ErrHTPasswdUnavailable), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "fallbackToAnonymousRead")
+	})
 
-		// add update perm
-		conf.HTTP.AccessControl.AdminPolicy.Actions = append(conf.HTTP.AccessControl.AdminPolicy.Actions, "update")
-		// update manifest should get 201 with update perm
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
-			SetBody(manifestBlob).
-			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
+	Convey("An invalid ldap CA cert stops the controller from starting", t, func() {
+		caCert := path.Join(t.TempDir(), "ca.crt")
+		err := os.WriteFile(caCert, []byte("not a cert"), 0o600)
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		conf.HTTP.AccessControl = &config.AccessControlConfig{}
+		conf := config.New()
+		conf.HTTP.Auth = &config.AuthConfig{
+			LDAP: &config.LDAPConfig{
+				Address: "127.0.0.1",
+				Port:    389,
+				BaseDN:  "ou=test",
+				CACert:  caCert,
+			},
+		}
 
-		resp, err = resty.R().SetBasicAuth(username, passphrase).
-			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
-			SetBody(manifestBlob).
-			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		err = ctlr.Init(context.Background())
+		So(goerrors.Is(err, errors.ErrBadCACert), ShouldBeTrue)
 	})
-}
 
-func TestGetUsername(t *testing.T) {
-	Convey("Make a new controller", t, func() {
+	Convey("Reads are served as anonymous requests while htpasswd is unavailable", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
 
-		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
-		defer os.Remove(htpasswdPath)
-
 		conf := config.New()
 		conf.HTTP.Port = port
 		conf.HTTP.Auth = &config.AuthConfig{
 			HTPasswd: config.AuthHTPasswd{
-				Path: htpasswdPath,
+				Path: path.Join(t.TempDir(), "missing.htpasswd"),
+			},
+			FallbackToAnonymousRead: true,
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				AuthorizationAllRepos: config.PolicyGroup{
+					AnonymousPolicy: []string{"read"},
+				},
 			},
 		}
 
-		dir := t.TempDir()
-		ctlr := makeController(conf, dir, "")
+		ctlr := makeController(conf, t.TempDir(), "")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		resp, err := resty.R().Get(baseURL + "/v2/")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
-
-		// test base64 encode
-		resp, err = resty.R().SetHeader("Authorization", "Basic should fail").Get(baseURL + "/v2/_catalog")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
-
-		// test "username:password" encoding
-		resp, err = resty.R().SetHeader("Authorization", "Basic dGVzdA==").Get(baseURL + "/v2/_catalog")
+		resp, err := resty.R().SetBasicAuth(username, passphrase).Get(baseURL + "/v2/_catalog")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// failed parsing authorization header
-		resp, err = resty.R().SetHeader("Authorization", "Basic ").Get(baseURL + "/v2/_catalog")
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
-
-		var e apiErr.Error
-		err = json.Unmarshal(resp.Body(), &e)
-		So(err, ShouldBeNil)
 	})
 }
 
-func TestAuthorizationWithOnlyAnonymousPolicy(t *testing.T) {
+func TestBearerAuthWithAllowReadAccess(t *testing.T) {
 	Convey("Make a new controller", t, func() {
-		const TestRepo = "my-repos/repo"
+		authTestServer := test.MakeAuthTestServer(ServerKey, UnauthorizedNamespace)
+		defer authTestServer.Close()
+
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
 
 		conf := config.New()
 		conf.HTTP.Port = port
-		conf.HTTP.Auth = &config.AuthConfig{}
+
+		aurl, err := url.Parse(authTestServer.URL)
+		So(err, ShouldBeNil)
+
+		conf.HTTP.Auth = &config.AuthConfig{
+			Bearer: &config.BearerConfig{
+				Cert:    ServerCert,
+				Realm:   authTestServer.URL + "/auth/token",
+				Service: aurl.Host,
+			},
+		}
+		ctlr := makeController(conf, t.TempDir(), "")
+
 		conf.HTTP.AccessControl = &config.AccessControlConfig{
 			Repositories: config.Repositories{
-				TestRepo: config.PolicyGroup{
-					AnonymousPolicy: []string{},
+				AuthorizationAllRepos: config.PolicyGroup{
+					AnonymousPolicy: []string{"read"},
 				},
 			},
 		}
 
-		dir := t.TempDir()
-		ctlr := makeController(conf, dir, "")
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
@@ -2994,175 +3302,143 @@ func TestAuthorizationWithOnlyAnonymousPolicy(t *testing.T) {
 		resp, err := resty.R().Get(baseURL + "/v2/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		resp, err = resty.R().Get(baseURL + "/v2/_catalog")
+		authorizationHeader := test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
+		resp, err = resty.R().
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		var e apiErr.Error
-		err = json.Unmarshal(resp.Body(), &e)
+		var goodToken test.AccessTokenResponse
+		err = json.Unmarshal(resp.Body(), &goodToken)
 		So(err, ShouldBeNil)
 
-		// should get 403 without create
-		resp, err = resty.R().Post(baseURL + "/v2/" + TestRepo + "/blobs/uploads/")
+		resp, err = resty.R().
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
+			Get(baseURL + "/v2/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		if entry, ok := conf.HTTP.AccessControl.Repositories[TestRepo]; ok {
-			entry.AnonymousPolicy = []string{"create", "read"}
-			conf.HTTP.AccessControl.Repositories[TestRepo] = entry
-		}
+		resp, err = resty.R().Post(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		// now it should get 202
-		resp, err = resty.R().Post(baseURL + "/v2/" + TestRepo + "/blobs/uploads/")
+		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
+		resp, err = resty.R().
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &goodToken)
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
+			Post(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/uploads/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 		loc := resp.Header().Get("Location")
 
-		// uploading blob should get 201
-		resp, err = resty.R().SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
+		resp, err = resty.R().
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
 			SetHeader("Content-Type", "application/octet-stream").
 			SetQueryParam("digest", digest).
 			SetBody(blob).
 			Put(baseURL + loc)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-
-		cblob, cdigest := test.GetRandomImageConfig()
-
-		resp, err = resty.R().Post(baseURL + "/v2/" + TestRepo + "/blobs/uploads/")
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc = test.Location(baseURL, resp)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		// uploading blob should get 201
-		resp, err = resty.R().SetHeader("Content-Length", fmt.Sprintf("%d", len(cblob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetQueryParam("digest", cdigest.String()).
-			SetBody(cblob).
-			Put(loc)
+		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
+		resp, err = resty.R().
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-
-		manifest := ispec.Manifest{
-			Config: ispec.Descriptor{
-				MediaType: "application/vnd.oci.image.config.v1+json",
-				Digest:    cdigest,
-				Size:      int64(len(cblob)),
-			},
-			Layers: []ispec.Descriptor{
-				{
-					MediaType: "application/vnd.oci.image.layer.v1.tar",
-					Digest:    godigest.FromBytes(blob),
-					Size:      int64(len(blob)),
-				},
-			},
-		}
-		manifest.SchemaVersion = 2
-		manifestBlob, err := json.Marshal(manifest)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &goodToken)
 		So(err, ShouldBeNil)
 
 		resp, err = resty.R().
-			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
-			SetBody(manifestBlob).
-			Put(baseURL + "/v2/" + TestRepo + "/manifests/0.0.2")
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
+			SetQueryParam("digest", digest).
+			SetBody(blob).
+			Put(baseURL + loc)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		updateBlob := []byte("Hello, blob update!")
-
 		resp, err = resty.R().
-			Post(baseURL + "/v2/" + TestRepo + "/blobs/uploads/")
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
+			Get(baseURL + "/v2/" + AuthorizedNamespace + "/tags/list")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc = test.Location(baseURL, resp)
-		// uploading blob should get 201
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
 		resp, err = resty.R().
-			SetHeader("Content-Length", fmt.Sprintf("%d", len(updateBlob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetQueryParam("digest", string(godigest.FromBytes(updateBlob))).
-			SetBody(updateBlob).
-			Put(loc)
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-
-		updatedManifest := ispec.Manifest{
-			Config: ispec.Descriptor{
-				MediaType: "application/vnd.oci.image.config.v1+json",
-				Digest:    cdigest,
-				Size:      int64(len(cblob)),
-			},
-			Layers: []ispec.Descriptor{
-				{
-					MediaType: "application/vnd.oci.image.layer.v1.tar",
-					Digest:    godigest.FromBytes(updateBlob),
-					Size:      int64(len(updateBlob)),
-				},
-			},
-		}
-		updatedManifest.SchemaVersion = 2
-		updatedManifestBlob, err := json.Marshal(updatedManifest)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &goodToken)
 		So(err, ShouldBeNil)
 
-		// update manifest should get 403 without update perm
-		resp, err = resty.R().SetBody(updatedManifestBlob).
-			Put(baseURL + "/v2/" + TestRepo + "/manifests/0.0.2")
+		resp, err = resty.R().
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", goodToken.AccessToken)).
+			Get(baseURL + "/v2/" + AuthorizedNamespace + "/tags/list")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// get the manifest and check if it's the old one
 		resp, err = resty.R().
-			Get(baseURL + "/v2/" + TestRepo + "/manifests/0.0.2")
+			Post(baseURL + "/v2/" + UnauthorizedNamespace + "/blobs/uploads/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		So(resp.Body(), ShouldResemble, manifestBlob)
-
-		// add update perm on repo
-		if entry, ok := conf.HTTP.AccessControl.Repositories[TestRepo]; ok {
-			entry.AnonymousPolicy = []string{"create", "read", "update"}
-			conf.HTTP.AccessControl.Repositories[TestRepo] = entry
-		}
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-		// update manifest should get 201 with update perm
+		authorizationHeader = test.ParseBearerAuthHeader(resp.Header().Get("Www-Authenticate"))
 		resp, err = resty.R().
-			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
-			SetBody(updatedManifestBlob).
-			Put(baseURL + "/v2/" + TestRepo + "/manifests/0.0.2")
+			SetQueryParam("service", authorizationHeader.Service).
+			SetQueryParam("scope", authorizationHeader.Scope).
+			Get(authorizationHeader.Realm)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		var badToken test.AccessTokenResponse
+		err = json.Unmarshal(resp.Body(), &badToken)
+		So(err, ShouldBeNil)
 
-		// get the manifest and check if it's the new updated one
 		resp, err = resty.R().
-			Get(baseURL + "/v2/" + TestRepo + "/manifests/0.0.2")
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", badToken.AccessToken)).
+			Post(baseURL + "/v2/" + UnauthorizedNamespace + "/blobs/uploads/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		So(resp.Body(), ShouldResemble, updatedManifestBlob)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 	})
 }
 
-func TestAuthorizationWithMultiplePolicies(t *testing.T) {
+func TestAuthorizationWithBasicAuth(t *testing.T) {
 	Convey("Make a new controller", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
 
 		conf := config.New()
 		conf.HTTP.Port = port
-		// have two users: "test" user for  user Policy, and "bob" for default policy
-		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase) +
-			"\n" + getCredString("bob", passphrase))
+		htpasswdPath := test.MakeHtpasswdFile()
 		defer os.Remove(htpasswdPath)
 
 		conf.HTTP.Auth = &config.AuthConfig{
@@ -3170,7 +3446,6 @@ func TestAuthorizationWithMultiplePolicies(t *testing.T) {
 				Path: htpasswdPath,
 			},
 		}
-		// config with all policy types, to test that the correct one is applied in each case
 		conf.HTTP.AccessControl = &config.AccessControlConfig{
 			Repositories: config.Repositories{
 				AuthorizationAllRepos: config.PolicyGroup{
@@ -3180,8 +3455,7 @@ func TestAuthorizationWithMultiplePolicies(t *testing.T) {
 							Actions: []string{},
 						},
 					},
-					DefaultPolicy:   []string{},
-					AnonymousPolicy: []string{},
+					DefaultPolicy: []string{},
 				},
 			},
 			AdminPolicy: config.Policy{
@@ -3190,8 +3464,7 @@ func TestAuthorizationWithMultiplePolicies(t *testing.T) {
 			},
 		}
 
-		dir := t.TempDir()
-		ctlr := makeController(conf, dir, "../../test/data")
+		ctlr := makeController(conf, t.TempDir(), "../../test/data")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
@@ -3200,33 +3473,43 @@ func TestAuthorizationWithMultiplePolicies(t *testing.T) {
 		blob := []byte("hello, blob!")
 		digest := godigest.FromBytes(blob).String()
 
-		// unauthenticated clients should not have access to /v2/, no policy is applied since none exists
+		// unauthenticated clients should not have access to /v2/
 		resp, err := resty.R().Get(baseURL + "/v2/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, 401)
 
-		repoPolicy := conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos]
-		repoPolicy.AnonymousPolicy = append(repoPolicy.AnonymousPolicy, "read")
-		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos] = repoPolicy
-
-		// should have access to /v2/, anonymous policy is applied, "read" allowed
-		resp, err = resty.R().Get(baseURL + "/v2/")
+		// everybody should have access to /v2/
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// with empty username:password
-		resp, err = resty.R().SetHeader("Authorization", "Basic Og==").Get(baseURL + "/v2/")
+		// everybody should have access to /v2/_catalog
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + constants.RoutePrefix + constants.ExtCatalogPrefix)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		var apiErr apiErr.Error
+		err = json.Unmarshal(resp.Body(), &apiErr)
+		So(err, ShouldBeNil)
 
-		// add "test" user to global policy with create permission
-		repoPolicy.Policies[0].Users = append(repoPolicy.Policies[0].Users, "test")
-		repoPolicy.Policies[0].Actions = append(repoPolicy.Policies[0].Actions, "create")
+		// should get 403 without create
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		// now it should get 202, user has the permission set on "create"
+		// first let's use global based policies
+		// add test user to global policy with create perm
+		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Users = append(conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Users, "test") //nolint:lll // gofumpt conflicts with lll
+
+		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions, "create") //nolint:lll // gofumpt conflicts with lll
+
+		// now it should get 202
 		resp, err = resty.R().SetBasicAuth(username, passphrase).
 			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
 		So(err, ShouldBeNil)
@@ -3259,374 +3542,464 @@ func TestAuthorizationWithMultiplePolicies(t *testing.T) {
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		repoPolicy.DefaultPolicy = append(repoPolicy.DefaultPolicy, "read")
-		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos] = repoPolicy
+		// get tags with read access should get 200
+		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions, "read") //nolint:lll // gofumpt conflicts with lll
 
-		// with read permission should get 200, because default policy allows reading now
 		resp, err = resty.R().SetBasicAuth(username, passphrase).
 			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// get tags with default read access should be ok, since the user is now "bob" and default policy is applied
-		resp, err = resty.R().SetBasicAuth("bob", passphrase).
-			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		// head blob should get 200 now
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// get tags with default policy read access
-		resp, err = resty.R().Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		// get blob should get 200 now
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		// get tags with anonymous read access should be ok
-		resp, err = resty.R().Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		// delete blob should get 403 without delete perm
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		// without create permission should get 403, since "bob" can only read(default policy applied)
-		resp, err = resty.R().SetBasicAuth("bob", passphrase).
-			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		// add delete perm on repo
+		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos].Policies[0].Actions, "delete") //nolint:lll // gofumpt conflicts with lll
+
+		// delete blob should get 202
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-		// add read permission to user "bob"
-		conf.HTTP.AccessControl.AdminPolicy.Users = append(conf.HTTP.AccessControl.AdminPolicy.Users, "bob")
-		conf.HTTP.AccessControl.AdminPolicy.Actions = append(conf.HTTP.AccessControl.AdminPolicy.Actions, "create")
+		// now let's use only repository based policies
+		// add test user to repo's policy with create perm
+		// longest path matching should match the repo and not **/*
+		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace] = config.PolicyGroup{
+			Policies: []config.Policy{
+				{
+					Users:   []string{},
+					Actions: []string{},
+				},
+			},
+			DefaultPolicy: []string{},
+		}
 
-		// added create permission to user "bob", should be allowed now
-		resp, err = resty.R().SetBasicAuth("bob", passphrase).
+		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Users = append(conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Users, "test")       //nolint:lll // gofumpt conflicts with lll
+		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions, "create") //nolint:lll // gofumpt conflicts with lll
+
+		// now it should get 202
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
 			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-	})
-}
-
-func TestInvalidCases(t *testing.T) {
-	Convey("Invalid repo dir", t, func() {
-		port := test.GetFreePort()
-		baseURL := test.GetBaseURL(port)
+		loc = resp.Header().Get("Location")
 
-		conf := config.New()
-		conf.HTTP.Port = port
-		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
+		// uploading blob should get 201
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", digest).
+			SetBody(blob).
+			Put(baseURL + loc)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		defer os.Remove(htpasswdPath)
+		// head blob should get 403 without read perm
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		conf.HTTP.Auth = &config.AuthConfig{
-			HTPasswd: config.AuthHTPasswd{
-				Path: htpasswdPath,
-			},
-		}
+		// get tags without read access should get 403
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		ctlr := makeController(conf, "oci-repo-test", "")
+		// get tags with read access should get 200
+		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions, "read") //nolint:lll // gofumpt conflicts with lll
 
-		err := os.Mkdir("oci-repo-test", 0o000)
-		if err != nil {
-			panic(err)
-		}
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		cm := test.NewControllerManager(ctlr)
-		cm.StartAndWait(port)
-		defer func(ctrl *api.Controller) {
-			err := ctrl.Server.Shutdown(context.Background())
-			if err != nil {
-				panic(err)
-			}
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			err = os.RemoveAll(ctrl.Config.Storage.RootDirectory)
-			if err != nil {
-				panic(err)
-			}
-		}(ctlr)
+		// head blob should get 200 now
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		digest := test.GetTestBlobDigest("zot-cve-test", "config").String()
-		name := "zot-c-test"
+		// get blob should get 200 now
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		client := resty.New()
+		// delete blob should get 403 without delete perm
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		params := make(map[string]string)
-		params["from"] = "zot-cveid-test"
-		params["mount"] = digest
+		// add delete perm on repo
+		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions, "delete") //nolint:lll // gofumpt conflicts with lll
 
-		postResponse, err := client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).
-			Post(fmt.Sprintf("%s/v2/%s/blobs/uploads/", baseURL, name))
+		// delete blob should get 202
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusInternalServerError)
-	})
-}
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-func TestHTTPReadOnly(t *testing.T) {
-	Convey("Single cred", t, func() {
-		singleCredtests := []string{}
-		user := ALICE
-		password := ALICE
-		singleCredtests = append(singleCredtests, getCredString(user, password))
-		singleCredtests = append(singleCredtests, getCredString(user, password)+"\n")
+		// remove permissions on **/* so it will not interfere with zot-test namespace
+		repoPolicy := conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos]
+		repoPolicy.Policies = []config.Policy{}
+		repoPolicy.DefaultPolicy = []string{}
+		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos] = repoPolicy
 
-		port := test.GetFreePort()
-		baseURL := test.GetBaseURL(port)
+		// get manifest should get 403, we don't have perm at all on this repo
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/zot-test/manifests/0.0.1")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		for _, testString := range singleCredtests {
-			func() {
-				conf := config.New()
-				conf.HTTP.Port = port
-				// enable read-only mode
-				conf.HTTP.AccessControl = &config.AccessControlConfig{
-					Repositories: config.Repositories{
-						AuthorizationAllRepos: config.PolicyGroup{
-							DefaultPolicy: []string{"read"},
-						},
-					},
-				}
+		// add read perm on repo
+		conf.HTTP.AccessControl.Repositories["zot-test"] = config.PolicyGroup{Policies: []config.Policy{
+			{
+				Users:   []string{"test"},
+				Actions: []string{"read"},
+			},
+		}, DefaultPolicy: []string{}}
 
-				htpasswdPath := test.MakeHtpasswdFileFromString(testString)
-				defer os.Remove(htpasswdPath)
-				conf.HTTP.Auth = &config.AuthConfig{
-					HTPasswd: config.AuthHTPasswd{
-						Path: htpasswdPath,
-					},
-				}
-				ctlr := makeController(conf, t.TempDir(), "")
+		/* we have 4 images(authz/image, golang, zot-test, zot-cve-test) in storage,
+		but because at this point we only have read access
+		in authz/image and zot-test, we should get only that when listing repositories*/
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + constants.RoutePrefix + constants.ExtCatalogPrefix)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &apiErr)
+		So(err, ShouldBeNil)
 
-				cm := test.NewControllerManager(ctlr)
-				cm.StartAndWait(port)
-				defer cm.StopServer()
+		catalog := struct {
+			Repositories []string `json:"repositories"`
+		}{}
 
-				// with creds, should get expected status code
-				resp, _ := resty.R().SetBasicAuth(user, password).Get(baseURL + "/v2/")
-				So(resp, ShouldNotBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &catalog)
+		So(err, ShouldBeNil)
+		So(len(catalog.Repositories), ShouldEqual, 2)
+		So(catalog.Repositories, ShouldContain, "zot-test")
+		So(catalog.Repositories, ShouldContain, AuthorizationNamespace)
 
-				// with creds, any modifications should still fail on read-only mode
-				resp, err := resty.R().SetBasicAuth(user, password).
-					Post(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/uploads/")
-				So(err, ShouldBeNil)
-				So(resp, ShouldNotBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		// get manifest should get 200 now
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/zot-test/manifests/0.0.1")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-				// with invalid creds, it should fail
-				resp, _ = resty.R().SetBasicAuth("chuck", "chuck").Get(baseURL + "/v2/")
-				So(resp, ShouldNotBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
-			}()
-		}
-	})
-}
+		manifestBlob := resp.Body()
+		var manifest ispec.Manifest
 
-func TestCrossRepoMount(t *testing.T) {
-	Convey("Cross Repo Mount", t, func() {
-		port := test.GetFreePort()
-		baseURL := test.GetBaseURL(port)
+		err = json.Unmarshal(manifestBlob, &manifest)
+		So(err, ShouldBeNil)
 
-		conf := config.New()
-		conf.HTTP.Port = port
-		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
-
-		defer os.Remove(htpasswdPath)
-
-		conf.HTTP.Auth = &config.AuthConfig{
-			HTPasswd: config.AuthHTPasswd{
-				Path: htpasswdPath,
-			},
-		}
-
-		dir := t.TempDir()
-		ctlr := makeController(conf, dir, "../../test/data")
-		ctlr.Config.Storage.RemoteCache = false
-		ctlr.Config.Storage.Dedupe = false
-
-		cm := test.NewControllerManager(ctlr) //nolint: varnamelen
-		cm.StartAndWait(port)
-
-		params := make(map[string]string)
-
-		var manifestDigest godigest.Digest
-		manifestDigest, _, _ = test.GetOciLayoutDigests("../../test/data/zot-cve-test")
-
-		dgst := manifestDigest
-		name := "zot-cve-test"
-		params["mount"] = string(manifestDigest)
-		params["from"] = name
-
-		client := resty.New()
-		headResponse, err := client.R().SetBasicAuth(username, passphrase).
-			Head(fmt.Sprintf("%s/v2/%s/blobs/%s", baseURL, name, manifestDigest))
+		// put manifest should get 403 without create perm
+		resp, err = resty.R().SetBasicAuth(username, passphrase).SetBody(manifestBlob).
+			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
 		So(err, ShouldBeNil)
-		So(headResponse.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		// All invalid request of mount should return 202.
-		params["mount"] = "sha:"
+		// add create perm on repo
+		conf.HTTP.AccessControl.Repositories["zot-test"].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories["zot-test"].Policies[0].Actions, "create") //nolint:lll // gofumpt conflicts with lll
 
-		postResponse, err := client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).
-			Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
-		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
-		location, err := postResponse.RawResponse.Location()
+		// should get 201 with create perm
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
+			SetBody(manifestBlob).
+			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
 		So(err, ShouldBeNil)
-		So(location.String(), ShouldStartWith, fmt.Sprintf("%s%s/zot-c-test/%s/%s",
-			baseURL, constants.RoutePrefix, constants.Blobs, constants.Uploads))
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		incorrectParams := make(map[string]string)
-		incorrectParams["mount"] = test.GetTestBlobDigest("zot-cve-test", "manifest").String()
-		incorrectParams["from"] = "zot-x-test"
+		// create update config and post it.
+		cblob, cdigest := test.GetRandomImageConfig()
 
-		postResponse, err = client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(incorrectParams).
-			Post(baseURL + "/v2/zot-y-test/blobs/uploads/")
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Post(baseURL + "/v2/zot-test/blobs/uploads/")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
-		So(test.Location(baseURL, postResponse), ShouldStartWith, fmt.Sprintf("%s%s/zot-y-test/%s/%s",
-			baseURL, constants.RoutePrefix, constants.Blobs, constants.Uploads))
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc = test.Location(baseURL, resp)
 
-		// Use correct request
-		// This is correct request but it will return 202 because blob is not present in cache.
-		params["mount"] = string(manifestDigest)
-		postResponse, err = client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).
-			Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
+		// uploading blob should get 201
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(cblob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", cdigest.String()).
+			SetBody(cblob).
+			Put(loc)
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
-		So(test.Location(baseURL, postResponse), ShouldStartWith, fmt.Sprintf("%s%s/zot-c-test/%s/%s",
-			baseURL, constants.RoutePrefix, constants.Blobs, constants.Uploads))
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		// Send same request again
-		postResponse, err = client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).
-			Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
-		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
+		// create updated layer and post it
+		updateBlob := []byte("Hello, blob update!")
 
-		// Valid requests
-		postResponse, err = client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).
-			Post(baseURL + "/v2/zot-d-test/blobs/uploads/")
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Post(baseURL + "/v2/zot-test/blobs/uploads/")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
-
-		headResponse, err = client.R().SetBasicAuth(username, passphrase).
-			Head(fmt.Sprintf("%s/v2/zot-cv-test/blobs/%s", baseURL, manifestDigest))
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc = test.Location(baseURL, resp)
+		// uploading blob should get 201
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(updateBlob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", string(godigest.FromBytes(updateBlob))).
+			SetBody(updateBlob).
+			Put(loc)
 		So(err, ShouldBeNil)
-		So(headResponse.StatusCode(), ShouldEqual, http.StatusNotFound)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		postResponse, err = client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
+		updatedManifest := ispec.Manifest{
+			Config: ispec.Descriptor{
+				MediaType: "application/vnd.oci.image.config.v1+json",
+				Digest:    cdigest,
+				Size:      int64(len(cblob)),
+			},
+			Layers: []ispec.Descriptor{
+				{
+					MediaType: "application/vnd.oci.image.layer.v1.tar",
+					Digest:    godigest.FromBytes(updateBlob),
+					Size:      int64(len(updateBlob)),
+				},
+			},
+		}
+		updatedManifest.SchemaVersion = 2
+		updatedManifestBlob, err := json.Marshal(updatedManifest)
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-		postResponse, err = client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).
-			Post(baseURL + "/v2/ /blobs/uploads/")
+		// update manifest should get 403 without update perm
+		resp, err = resty.R().SetBasicAuth(username, passphrase).SetBody(updatedManifestBlob).
+			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusNotFound)
-
-		blob := manifestDigest.Encoded()
-
-		buf, err := os.ReadFile(path.Join(ctlr.Config.Storage.RootDirectory, "zot-cve-test/blobs/sha256/"+blob))
-		if err != nil {
-			panic(err)
-		}
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		postResponse, err = client.R().SetHeader("Content-type", "application/octet-stream").
-			SetBasicAuth(username, passphrase).SetQueryParam("digest", "sha256:"+blob).
-			SetBody(buf).Post(baseURL + "/v2/zot-d-test/blobs/uploads/")
+		// get the manifest and check if it's the old one
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/zot-test/manifests/0.0.2")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
-
-		// We have uploaded a blob and since we have provided digest it should be full blob upload and there should be entry
-		// in cache, now try mount blob request status and it should be 201 because now blob is present in cache
-		// and it should do hard link.
-
-		// restart server with dedupe enabled
-		cm.StopServer()
-		ctlr.Config.Storage.Dedupe = true
-		cm.StartAndWait(port)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Body(), ShouldResemble, manifestBlob)
 
-		// wait for dedupe task to run
-		time.Sleep(15 * time.Second)
+		// add update perm on repo
+		conf.HTTP.AccessControl.Repositories["zot-test"].Policies[0].Actions = append(conf.HTTP.AccessControl.Repositories["zot-test"].Policies[0].Actions, "update") //nolint:lll // gofumpt conflicts with lll
 
-		params["mount"] = string(manifestDigest)
-		postResponse, err = client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).
-			Post(baseURL + "/v2/zot-mount-test/blobs/uploads/")
+		// update manifest should get 201 with update perm
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
+			SetBody(updatedManifestBlob).
+			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
-		So(test.Location(baseURL, postResponse), ShouldEqual, fmt.Sprintf("%s%s/zot-mount-test/%s/%s:%s",
-			baseURL, constants.RoutePrefix, constants.Blobs, godigest.SHA256, blob))
-
-		// Check os.SameFile here
-		cachePath := path.Join(ctlr.Config.Storage.RootDirectory, "zot-d-test", "blobs/sha256", dgst.Encoded())
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		cacheFi, err := os.Stat(cachePath)
+		// get the manifest and check if it's the new updated one
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/zot-test/manifests/0.0.2")
 		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Body(), ShouldResemble, updatedManifestBlob)
 
-		linkPath := path.Join(ctlr.Config.Storage.RootDirectory, "zot-mount-test", "blobs/sha256", dgst.Encoded())
+		// now use default repo policy
+		conf.HTTP.AccessControl.Repositories["zot-test"].Policies[0].Actions = []string{}
+		repoPolicy = conf.HTTP.AccessControl.Repositories["zot-test"]
+		repoPolicy.DefaultPolicy = []string{"update"}
+		conf.HTTP.AccessControl.Repositories["zot-test"] = repoPolicy
 
-		linkFi, err := os.Stat(linkPath)
+		// update manifest should get 201 with update perm on repo's default policy
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
+			SetBody(manifestBlob).
+			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
 		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		So(os.SameFile(cacheFi, linkFi), ShouldEqual, true)
+		// with default read on repo should still get 200
+		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace].Policies[0].Actions = []string{}
+		repoPolicy = conf.HTTP.AccessControl.Repositories[AuthorizationNamespace]
+		repoPolicy.DefaultPolicy = []string{"read"}
+		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace] = repoPolicy
 
-		// Now try another mount request and this time it should be from above uploaded repo i.e zot-mount-test
-		// mount request should pass and should return 201.
-		params["mount"] = string(manifestDigest)
-		params["from"] = "zot-mount-test"
-		postResponse, err = client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).
-			Post(baseURL + "/v2/zot-mount1-test/blobs/uploads/")
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
-		So(test.Location(baseURL, postResponse), ShouldEqual, fmt.Sprintf("%s%s/zot-mount1-test/%s/%s:%s",
-			baseURL, constants.RoutePrefix, constants.Blobs, godigest.SHA256, blob))
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		linkPath = path.Join(ctlr.Config.Storage.RootDirectory, "zot-mount1-test", "blobs/sha256", dgst.Encoded())
+		// upload blob without user create but with default create should get 200
+		repoPolicy.DefaultPolicy = append(repoPolicy.DefaultPolicy, "create")
+		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace] = repoPolicy
 
-		linkFi, err = os.Stat(linkPath)
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
 		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-		So(os.SameFile(cacheFi, linkFi), ShouldEqual, true)
+		// remove per repo policy
+		repoPolicy = conf.HTTP.AccessControl.Repositories[AuthorizationNamespace]
+		repoPolicy.Policies = []config.Policy{}
+		repoPolicy.DefaultPolicy = []string{}
+		conf.HTTP.AccessControl.Repositories[AuthorizationNamespace] = repoPolicy
 
-		headResponse, err = client.R().SetBasicAuth(username, passphrase).
-			Head(fmt.Sprintf("%s/v2/zot-cv-test/blobs/%s", baseURL, manifestDigest))
-		So(err, ShouldBeNil)
-		So(headResponse.StatusCode(), ShouldEqual, http.StatusOK)
+		repoPolicy = conf.HTTP.AccessControl.Repositories["zot-test"]
+		repoPolicy.Policies = []config.Policy{}
+		repoPolicy.DefaultPolicy = []string{}
+		conf.HTTP.AccessControl.Repositories["zot-test"] = repoPolicy
 
-		// Invalid request
-		params = make(map[string]string)
-		params["mount"] = "sha256:"
-		postResponse, err = client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).
-			Post(baseURL + "/v2/zot-mount-test/blobs/uploads/")
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		params = make(map[string]string)
-		params["from"] = "zot-cve-test"
-		postResponse, err = client.R().
-			SetBasicAuth(username, passphrase).SetQueryParams(params).
-			Post(baseURL + "/v2/zot-mount-test/blobs/uploads/")
+		// whithout any perm should get 403
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusMethodNotAllowed)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		// add read perm
+		conf.HTTP.AccessControl.AdminPolicy.Users = append(conf.HTTP.AccessControl.AdminPolicy.Users, "test")
+		conf.HTTP.AccessControl.AdminPolicy.Actions = append(conf.HTTP.AccessControl.AdminPolicy.Actions, "read")
+		// with read perm should get 200
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// without create perm should 403
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		// add create perm
+		conf.HTTP.AccessControl.AdminPolicy.Actions = append(conf.HTTP.AccessControl.AdminPolicy.Actions, "create")
+		// with create perm should get 202
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc = resp.Header().Get("Location")
+
+		// uploading blob should get 201
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", digest).
+			SetBody(blob).
+			Put(baseURL + loc)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		// without delete perm should 403
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		// add delete perm
+		conf.HTTP.AccessControl.AdminPolicy.Actions = append(conf.HTTP.AccessControl.AdminPolicy.Actions, "delete")
+		// with delete perm should get http.StatusAccepted
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		// without update perm should 403
+		resp, err = resty.R().SetBasicAuth(username, passphrase).SetBody(manifestBlob).
+			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		// add update perm
+		conf.HTTP.AccessControl.AdminPolicy.Actions = append(conf.HTTP.AccessControl.AdminPolicy.Actions, "update")
+		// update manifest should get 201 with update perm
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
+			SetBody(manifestBlob).
+			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		conf.HTTP.AccessControl = &config.AccessControlConfig{}
+
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
+			SetBody(manifestBlob).
+			Put(baseURL + "/v2/zot-test/manifests/0.0.2")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 	})
+}
 
-	Convey("Disable dedupe and cache", t, func() {
+func TestGetUsername(t *testing.T) {
+	Convey("Make a new controller", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
 
-		conf := config.New()
-		conf.HTTP.Port = port
 		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
-
 		defer os.Remove(htpasswdPath)
 
+		conf := config.New()
+		conf.HTTP.Port = port
 		conf.HTTP.Auth = &config.AuthConfig{
 			HTPasswd: config.AuthHTPasswd{
 				Path: htpasswdPath,
@@ -3634,392 +4007,447 @@ func TestCrossRepoMount(t *testing.T) {
 		}
 
 		dir := t.TempDir()
-		ctlr := makeController(conf, dir, "../../test/data")
-		ctlr.Config.Storage.Dedupe = false
-		ctlr.Config.Storage.GC = false
+		ctlr := makeController(conf, dir, "")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		digest := test.GetTestBlobDigest("zot-cve-test", "layer").String()
-		name := "zot-c-test"
-		client := resty.New()
-		headResponse, err := client.R().SetBasicAuth(username, passphrase).
-			Head(fmt.Sprintf("%s/v2/%s/blobs/%s", baseURL, name, digest))
+		resp, err := resty.R().Get(baseURL + "/v2/")
 		So(err, ShouldBeNil)
-		So(headResponse.StatusCode(), ShouldEqual, http.StatusNotFound)
-	})
-}
-
-func TestParallelRequests(t *testing.T) {
-	t.Parallel()
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-	testCases := []struct {
-		srcImageName  string
-		srcImageTag   string
-		destImageName string
-		destImageTag  string
-		testCaseName  string
-	}{
-		{
-			srcImageName:  "zot-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "zot-1-test",
-			destImageTag:  "0.0.1",
-			testCaseName:  "Request-1",
-		},
-		{
-			srcImageName:  "zot-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "zot-2-test",
-			testCaseName:  "Request-2",
-		},
-		{
-			srcImageName:  "zot-cve-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "a/zot-3-test",
-			testCaseName:  "Request-3",
-		},
-		{
-			srcImageName:  "zot-cve-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "b/zot-4-test",
-			testCaseName:  "Request-4",
-		},
-		{
-			srcImageName:  "zot-cve-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "zot-5-test",
-			testCaseName:  "Request-5",
-		},
-		{
-			srcImageName:  "zot-cve-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "zot-1-test",
-			testCaseName:  "Request-6",
-		},
-		{
-			srcImageName:  "zot-cve-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "zot-2-test",
-			testCaseName:  "Request-7",
-		},
-		{
-			srcImageName:  "zot-cve-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "zot-3-test",
-			testCaseName:  "Request-8",
-		},
-		{
-			srcImageName:  "zot-cve-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "zot-4-test",
-			testCaseName:  "Request-9",
-		},
-		{
-			srcImageName:  "zot-cve-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "zot-5-test",
-			testCaseName:  "Request-10",
-		},
-		{
-			srcImageName:  "zot-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "zot-1-test",
-			destImageTag:  "0.0.1",
-			testCaseName:  "Request-11",
-		},
-		{
-			srcImageName:  "zot-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "zot-2-test",
-			testCaseName:  "Request-12",
-		},
-		{
-			srcImageName:  "zot-cve-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "a/zot-3-test",
-			testCaseName:  "Request-13",
-		},
-		{
-			srcImageName:  "zot-cve-test",
-			srcImageTag:   "0.0.1",
-			destImageName: "b/zot-4-test",
-			testCaseName:  "Request-14",
-		},
-	}
+		// test base64 encode
+		resp, err = resty.R().SetHeader("Authorization", "Basic should fail").Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-	port := test.GetFreePort()
-	baseURL := test.GetBaseURL(port)
+		// test "username:password" encoding
+		resp, err = resty.R().SetHeader("Authorization", "Basic dGVzdA==").Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-	conf := config.New()
-	conf.HTTP.Port = port
-	htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
+		// failed parsing authorization header
+		resp, err = resty.R().SetHeader("Authorization", "Basic ").Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
 
-	conf.HTTP.Auth = &config.AuthConfig{
-		HTPasswd: config.AuthHTPasswd{
-			Path: htpasswdPath,
-		},
-	}
+		var e apiErr.Error
+		err = json.Unmarshal(resp.Body(), &e)
+		So(err, ShouldBeNil)
+	})
+}
 
-	dir := t.TempDir()
-	firstSubDir := t.TempDir()
-	secondSubDir := t.TempDir()
+func TestAuthorizationWithOnlyAnonymousPolicy(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		const TestRepo = "my-repos/repo"
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-	subPaths := make(map[string]config.StorageConfig)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Auth = &config.AuthConfig{}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				TestRepo: config.PolicyGroup{
+					AnonymousPolicy: []string{},
+				},
+			},
+		}
 
-	subPaths["/a"] = config.StorageConfig{RootDirectory: firstSubDir}
-	subPaths["/b"] = config.StorageConfig{RootDirectory: secondSubDir}
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "")
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-	ctlr := makeController(conf, dir, "")
-	ctlr.Config.Storage.SubPaths = subPaths
+		blob := []byte("hello, blob!")
+		digest := godigest.FromBytes(blob).String()
 
-	cm := test.NewControllerManager(ctlr)
-	cm.StartAndWait(port)
+		resp, err := resty.R().Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-	// without creds, should get access error
-	for i, testcase := range testCases {
-		testcase := testcase
-		run := i
+		resp, err = resty.R().Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		var e apiErr.Error
+		err = json.Unmarshal(resp.Body(), &e)
+		So(err, ShouldBeNil)
 
-		t.Run(testcase.testCaseName, func(t *testing.T) {
-			t.Parallel()
-			client := resty.New()
+		// should get 403 without create
+		resp, err = resty.R().Post(baseURL + "/v2/" + TestRepo + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			tagResponse, err := client.R().SetBasicAuth(username, passphrase).
-				Get(baseURL + "/v2/" + testcase.destImageName + "/tags/list")
-			assert.Equal(t, err, nil, "Error should be nil")
-			assert.NotEqual(t, tagResponse.StatusCode(), http.StatusBadRequest, "bad request")
+		if entry, ok := conf.HTTP.AccessControl.Repositories[TestRepo]; ok {
+			entry.AnonymousPolicy = []string{"create", "read"}
+			conf.HTTP.AccessControl.Repositories[TestRepo] = entry
+		}
 
-			manifestList := getAllManifests(path.Join("../../test/data", testcase.srcImageName))
+		// now it should get 202
+		resp, err = resty.R().Post(baseURL + "/v2/" + TestRepo + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc := resp.Header().Get("Location")
 
-			for _, manifest := range manifestList {
-				headResponse, err := client.R().SetBasicAuth(username, passphrase).
-					Head(baseURL + "/v2/" + testcase.destImageName + "/manifests/" + manifest)
-				assert.Equal(t, err, nil, "Error should be nil")
-				assert.Equal(t, headResponse.StatusCode(), http.StatusNotFound, "response status code should return 404")
+		// uploading blob should get 201
+		resp, err = resty.R().SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", digest).
+			SetBody(blob).
+			Put(baseURL + loc)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-				getResponse, err := client.R().SetBasicAuth(username, passphrase).
-					Get(baseURL + "/v2/" + testcase.destImageName + "/manifests/" + manifest)
-				assert.Equal(t, err, nil, "Error should be nil")
-				assert.Equal(t, getResponse.StatusCode(), http.StatusNotFound, "response status code should return 404")
-			}
+		cblob, cdigest := test.GetRandomImageConfig()
 
-			blobList := getAllBlobs(path.Join("../../test/data", testcase.srcImageName))
+		resp, err = resty.R().Post(baseURL + "/v2/" + TestRepo + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc = test.Location(baseURL, resp)
 
-			for _, blob := range blobList {
-				// Get request of blob
-				headResponse, err := client.R().
-					SetBasicAuth(username, passphrase).
-					Head(baseURL + "/v2/" + testcase.destImageName + "/blobs/sha256:" + blob)
+		// uploading blob should get 201
+		resp, err = resty.R().SetHeader("Content-Length", fmt.Sprintf("%d", len(cblob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", cdigest.String()).
+			SetBody(cblob).
+			Put(loc)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-				assert.Equal(t, err, nil, "Should not be nil")
-				assert.NotEqual(t, headResponse.StatusCode(), http.StatusInternalServerError,
-					"internal server error should not occurred")
+		manifest := ispec.Manifest{
+			Config: ispec.Descriptor{
+				MediaType: "application/vnd.oci.image.config.v1+json",
+				Digest:    cdigest,
+				Size:      int64(len(cblob)),
+			},
+			Layers: []ispec.Descriptor{
+				{
+					MediaType: "application/vnd.oci.image.layer.v1.tar",
+					Digest:    godigest.FromBytes(blob),
+					Size:      int64(len(blob)),
+				},
+			},
+		}
+		manifest.SchemaVersion = 2
+		manifestBlob, err := json.Marshal(manifest)
+		So(err, ShouldBeNil)
 
-				getResponse, err := client.R().
-					SetBasicAuth(username, passphrase).
-					Get(baseURL + "/v2/" + testcase.destImageName + "/blobs/sha256:" + blob)
+		resp, err = resty.R().
+			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
+			SetBody(manifestBlob).
+			Put(baseURL + "/v2/" + TestRepo + "/manifests/0.0.2")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-				assert.Equal(t, err, nil, "Should not be nil")
-				assert.NotEqual(t, getResponse.StatusCode(), http.StatusInternalServerError,
-					"internal server error should not occurred")
+		updateBlob := []byte("Hello, blob update!")
 
-				blobPath := path.Join("../../test/data", testcase.srcImageName, "blobs/sha256", blob)
+		resp, err = resty.R().
+			Post(baseURL + "/v2/" + TestRepo + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc = test.Location(baseURL, resp)
+		// uploading blob should get 201
+		resp, err = resty.R().
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(updateBlob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", string(godigest.FromBytes(updateBlob))).
+			SetBody(updateBlob).
+			Put(loc)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-				buf, err := os.ReadFile(blobPath)
-				if err != nil {
-					panic(err)
-				}
+		updatedManifest := ispec.Manifest{
+			Config: ispec.Descriptor{
+				MediaType: "application/vnd.oci.image.config.v1+json",
+				Digest:    cdigest,
+				Size:      int64(len(cblob)),
+			},
+			Layers: []ispec.Descriptor{
+				{
+					MediaType: "application/vnd.oci.image.layer.v1.tar",
+					Digest:    godigest.FromBytes(updateBlob),
+					Size:      int64(len(updateBlob)),
+				},
+			},
+		}
+		updatedManifest.SchemaVersion = 2
+		updatedManifestBlob, err := json.Marshal(updatedManifest)
+		So(err, ShouldBeNil)
 
-				// Post request of blob
-				postResponse, err := client.R().
-					SetHeader("Content-type", "application/octet-stream").
-					SetBasicAuth(username, passphrase).
-					SetBody(buf).Post(baseURL + "/v2/" + testcase.destImageName + "/blobs/uploads/")
+		// update manifest should get 403 without update perm
+		resp, err = resty.R().SetBody(updatedManifestBlob).
+			Put(baseURL + "/v2/" + TestRepo + "/manifests/0.0.2")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-				assert.Equal(t, err, nil, "Error should be nil")
-				assert.NotEqual(t, postResponse.StatusCode(), http.StatusInternalServerError,
-					"response status code should not return 500")
+		// get the manifest and check if it's the old one
+		resp, err = resty.R().
+			Get(baseURL + "/v2/" + TestRepo + "/manifests/0.0.2")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Body(), ShouldResemble, manifestBlob)
 
-				// Post request with query parameter
-				if run%2 == 0 {
-					postResponse, err = client.R().
-						SetHeader("Content-type", "application/octet-stream").
-						SetBasicAuth(username, passphrase).
-						SetBody(buf).
-						Post(baseURL + "/v2/" + testcase.destImageName + "/blobs/uploads/")
+		// add update perm on repo
+		if entry, ok := conf.HTTP.AccessControl.Repositories[TestRepo]; ok {
+			entry.AnonymousPolicy = []string{"create", "read", "update"}
+			conf.HTTP.AccessControl.Repositories[TestRepo] = entry
+		}
 
-					assert.Equal(t, err, nil, "Error should be nil")
-					assert.NotEqual(t, postResponse.StatusCode(), http.StatusInternalServerError,
-						"response status code should not return 500")
+		// update manifest should get 201 with update perm
+		resp, err = resty.R().
+			SetHeader("Content-type", "application/vnd.oci.image.manifest.v1+json").
+			SetBody(updatedManifestBlob).
+			Put(baseURL + "/v2/" + TestRepo + "/manifests/0.0.2")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-					var sessionID string
-					sessionIDList := postResponse.Header().Values("Blob-Upload-UUID")
-					if len(sessionIDList) == 0 {
-						location := postResponse.Header().Values("Location")
-						firstLocation := location[0]
-						splitLocation := strings.Split(firstLocation, "/")
-						sessionID = splitLocation[len(splitLocation)-1]
-					} else {
-						sessionID = sessionIDList[0]
-					}
+		// get the manifest and check if it's the new updated one
+		resp, err = resty.R().
+			Get(baseURL + "/v2/" + TestRepo + "/manifests/0.0.2")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Body(), ShouldResemble, updatedManifestBlob)
+	})
+}
 
-					file, err := os.Open(blobPath)
-					if err != nil {
-						panic(err)
-					}
+func TestAuthorizationWithRoles(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-					defer file.Close()
+		conf := config.New()
+		conf.HTTP.Port = port
+		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase) +
+			"\n" + getCredString("bob", passphrase))
+		defer os.Remove(htpasswdPath)
 
-					reader := bufio.NewReader(file)
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				AuthorizationAllRepos: config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Users: []string{username},
+							Roles: []string{config.PublisherRole},
+						},
+					},
+				},
+			},
+		}
 
-					buf := make([]byte, 5*1024*1024)
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "../../test/data")
 
-					if run%4 == 0 {
-						readContent := 0
-						for {
-							nbytes, err := reader.Read(buf)
-							if err != nil {
-								if goerrors.Is(err, io.EOF) {
-									break
-								}
-								panic(err)
-							}
-							// Patch request of blob
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-							patchResponse, err := client.R().
-								SetBody(buf[0:nbytes]).
-								SetHeader("Content-Type", "application/octet-stream").
-								SetHeader("Content-Length", fmt.Sprintf("%d", nbytes)).
-								SetHeader("Content-Range", fmt.Sprintf("%d", readContent)+"-"+fmt.Sprintf("%d", readContent+nbytes-1)).
-								SetBasicAuth(username, passphrase).
-								Patch(baseURL + "/v2/" + testcase.destImageName + "/blobs/uploads/" + sessionID)
+		blob := []byte("hello, blob!")
+		digest := godigest.FromBytes(blob).String()
 
-							assert.Equal(t, err, nil, "Error should be nil")
-							assert.NotEqual(t, patchResponse.StatusCode(), http.StatusInternalServerError,
-								"response status code should not return 500")
+		// the publisher role allows creating and reading
+		resp, err := resty.R().SetBasicAuth(username, passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc := resp.Header().Get("Location")
 
-							readContent += nbytes
-						}
-					} else {
-						for {
-							nbytes, err := reader.Read(buf)
-							if err != nil {
-								if goerrors.Is(err, io.EOF) {
-									break
-								}
-								panic(err)
-							}
-							// Patch request of blob
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", digest).
+			SetBody(blob).
+			Put(baseURL + loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-							patchResponse, err := client.R().SetBody(buf[0:nbytes]).SetHeader("Content-type", "application/octet-stream").
-								SetBasicAuth(username, passphrase).
-								Patch(baseURL + "/v2/" + testcase.destImageName + "/blobs/uploads/" + sessionID)
-							if err != nil {
-								panic(err)
-							}
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-							assert.Equal(t, err, nil, "Error should be nil")
-							assert.NotEqual(t, patchResponse.StatusCode(), http.StatusInternalServerError,
-								"response status code should not return 500")
-						}
-					}
-				} else {
-					postResponse, err = client.R().
-						SetHeader("Content-type", "application/octet-stream").
-						SetBasicAuth(username, passphrase).
-						SetBody(buf).SetQueryParam("digest", "sha256:"+blob).
-						Post(baseURL + "/v2/" + testcase.destImageName + "/blobs/uploads/")
+		// but not deleting
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-					assert.Equal(t, err, nil, "Error should be nil")
-					assert.NotEqual(t, postResponse.StatusCode(), http.StatusInternalServerError,
-						"response status code should not return 500")
-				}
+		resp, err = resty.R().SetBasicAuth("bob", passphrase).
+			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-				headResponse, err = client.R().
-					SetBasicAuth(username, passphrase).
-					Head(baseURL + "/v2/" + testcase.destImageName + "/blobs/sha256:" + blob)
+		// roles assigned at runtime are enforced along with the config
+		err = ctlr.RoleBindings.Add(roles.Binding{
+			Pattern: "authz/**",
+			Role:    config.ReaderRole,
+			Users:   []string{"bob"},
+		})
+		So(err, ShouldBeNil)
 
-				assert.Equal(t, err, nil, "Should not be nil")
-				assert.NotEqual(t, headResponse.StatusCode(), http.StatusInternalServerError, "response should return success code")
+		resp, err = resty.R().SetBasicAuth("bob", passphrase).
+			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-				getResponse, err = client.R().
-					SetBasicAuth(username, passphrase).
-					Get(baseURL + "/v2/" + testcase.destImageName + "/blobs/sha256:" + blob)
+		resp, err = resty.R().SetBasicAuth("bob", passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-				assert.Equal(t, err, nil, "Should not be nil")
-				assert.NotEqual(t, getResponse.StatusCode(), http.StatusInternalServerError, "response should return success code")
-			}
+		// the longest matching pattern applies, the publisher role on "**" doesn't apply to authz/ repos anymore
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			tagResponse, err = client.R().SetBasicAuth(username, passphrase).
-				Get(baseURL + "/v2/" + testcase.destImageName + "/tags/list")
-			assert.Equal(t, err, nil, "Error should be nil")
-			assert.Equal(t, tagResponse.StatusCode(), http.StatusOK, "response status code should return success code")
+		So(ctlr.RoleBindings.Remove("authz/**", config.ReaderRole), ShouldBeNil)
 
-			repoResponse, err := client.R().SetBasicAuth(username, passphrase).
-				Get(baseURL + constants.RoutePrefix + constants.ExtCatalogPrefix)
-			assert.Equal(t, err, nil, "Error should be nil")
-			assert.Equal(t, repoResponse.StatusCode(), http.StatusOK, "response status code should return success code")
-		})
+		resp, err = resty.R().SetBasicAuth("bob", passphrase).
+			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+	})
+}
+
+type testPlugin struct {
+	events chan plugins.Event
+}
+
+func (plugin *testPlugin) Init(options map[string]interface{}, log log.Logger) error {
+	return nil
+}
+
+func (plugin *testPlugin) Notify(ctx context.Context, event plugins.Event) error {
+	plugin.events <- event
+
+	return nil
+}
+
+func (plugin *testPlugin) Authorize(ctx context.Context, request plugins.AuthzRequest) (plugins.Decision, error) {
+	if request.Action == api.Delete {
+		return plugins.Deny, nil
+	}
+
+	if request.Username == "bob" && request.Action == api.Read {
+		return plugins.Allow, nil
 	}
+
+	return plugins.Abstain, nil
 }
 
-func TestHardLink(t *testing.T) {
-	Convey("Validate hard link", t, func() {
+func TestAuthorizationWithPlugins(t *testing.T) {
+	Convey("Make a new controller", t, func() {
 		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
 		conf := config.New()
 		conf.HTTP.Port = port
-		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
+		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase) +
+			"\n" + getCredString("bob", passphrase))
+		defer os.Remove(htpasswdPath)
 
 		conf.HTTP.Auth = &config.AuthConfig{
 			HTPasswd: config.AuthHTPasswd{
 				Path: htpasswdPath,
 			},
 		}
-
-		dir := t.TempDir()
-
-		err := os.Chmod(dir, 0o400)
-		if err != nil {
-			panic(err)
-		}
-
-		subDir := t.TempDir()
-
-		err = os.Chmod(subDir, 0o400)
-		if err != nil {
-			panic(err)
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				AuthorizationAllRepos: config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Users: []string{username},
+							Roles: []string{config.AdminRole},
+						},
+					},
+				},
+			},
 		}
 
+		dir := t.TempDir()
 		ctlr := makeController(conf, dir, "")
-		subPaths := make(map[string]config.StorageConfig)
 
-		subPaths["/a"] = config.StorageConfig{RootDirectory: subDir, Dedupe: true}
-		ctlr.Config.Storage.SubPaths = subPaths
+		plugin := &testPlugin{events: make(chan plugins.Event, 1)}
+		ctlr.Plugins = plugins.NewRegistry(ctlr.Log)
+		So(ctlr.Plugins.Register("policy", plugin, nil), ShouldBeNil)
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		err = os.Chmod(dir, 0o644)
-		if err != nil {
-			panic(err)
-		}
+		cfg, layers, manifest, err := test.GetImageComponents(2)
+		So(err, ShouldBeNil)
 
-		err = os.Chmod(subDir, 0o644)
-		if err != nil {
-			panic(err)
+		img := test.Image{Config: cfg, Layers: layers, Manifest: manifest, Reference: "1.0"}
+
+		err = test.UploadImageWithBasicAuth(img, baseURL, AuthorizationNamespace, username, passphrase)
+		So(err, ShouldBeNil)
+
+		// notifiers are told about pushes
+		event := <-plugin.events
+		So(event.Type, ShouldEqual, plugins.EventManifestPushed)
+		So(event.Repo, ShouldEqual, AuthorizationNamespace)
+		So(event.Username, ShouldEqual, username)
+
+		// authorizers can allow requests the config doesn't
+		resp, err := resty.R().SetBasicAuth("bob", passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/manifests/" + img.Reference)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// also on the routes checking each repo they list, e.g. the catalog
+		resp, err = resty.R().SetBasicAuth("bob", passphrase).Get(baseURL + constants.RoutePrefix +
+			constants.ExtCatalogPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var catalog struct {
+			Repositories []string `json:"repositories"`
 		}
 
-		So(ctlr.Config.Storage.Dedupe, ShouldEqual, false)
+		So(json.Unmarshal(resp.Body(), &catalog), ShouldBeNil)
+		So(catalog.Repositories, ShouldContain, AuthorizationNamespace)
+
+		resp, err = resty.R().SetBasicAuth("bob", passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		// and deny requests the config allows
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Delete(baseURL + "/v2/" + AuthorizationNamespace + "/manifests/" + img.Reference)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 	})
 }
 
-func TestImageSignatures(t *testing.T) {
-	Convey("Validate signatures", t, func() {
-		// start a new server
+func TestPluginEvents(t *testing.T) {
+	Convey("Make a new controller", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
 
@@ -4028,1589 +4456,1487 @@ func TestImageSignatures(t *testing.T) {
 
 		dir := t.TempDir()
 		ctlr := makeController(conf, dir, "")
+
+		eventLog, err := plugins.NewEventLog(dir, ctlr.Log)
+		So(err, ShouldBeNil)
+
+		plugin := &testPlugin{events: make(chan plugins.Event, 10)}
+		ctlr.Plugins = plugins.NewRegistry(ctlr.Log)
+		ctlr.Plugins.SetEventLog(eventLog)
+		So(ctlr.Plugins.Register("notifier", plugin, nil), ShouldBeNil)
+
 		cm := test.NewControllerManager(ctlr)
-		// this blocks
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		repoName := "signed-repo"
 		cfg, layers, manifest, err := test.GetImageComponents(2)
 		So(err, ShouldBeNil)
 
-		err = test.UploadImage(
-			test.Image{
-				Config:    cfg,
-				Layers:    layers,
-				Manifest:  manifest,
-				Reference: "1.0",
-			}, baseURL, repoName)
+		for _, tag := range []string{"1.0", "latest"} {
+			img := test.Image{Config: cfg, Layers: layers, Manifest: manifest, Reference: tag}
+
+			So(test.UploadImage(img, baseURL, "alpine"), ShouldBeNil)
+		}
+
+		resp, err := resty.R().Delete(baseURL + "/v2/alpine/manifests/1.0")
 		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-		content, err := json.Marshal(manifest)
+		// notifiers receive the events in order
+		for seq := uint64(1); seq <= 3; seq++ {
+			So((<-plugin.events).Sequence, ShouldEqual, seq)
+		}
+
+		// and missed events can be replayed
+		resp, err = resty.R().Get(baseURL + constants.FullEventsPrefix + "/alpine?from=2")
 		So(err, ShouldBeNil)
-		digest := godigest.FromBytes(content)
-		So(digest, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		Convey("Validate cosign signatures", func() {
-			cwd, err := os.Getwd()
-			So(err, ShouldBeNil)
-			defer func() { _ = os.Chdir(cwd) }()
-			tdir := t.TempDir()
-			_ = os.Chdir(tdir)
+		var eventList api.EventList
 
-			// generate a keypair
-			os.Setenv("COSIGN_PASSWORD", "")
-			err = generate.GenerateKeyPairCmd(context.TODO(), "", "cosign", nil)
-			So(err, ShouldBeNil)
+		So(json.Unmarshal(resp.Body(), &eventList), ShouldBeNil)
+		So(eventList.OldestSequence, ShouldEqual, 1)
+		So(eventList.LatestSequence, ShouldEqual, 3)
+		So(len(eventList.Events), ShouldEqual, 2)
+		So(eventList.Events[0].Reference, ShouldEqual, "latest")
+		So(eventList.Events[1].Type, ShouldEqual, plugins.EventManifestDeleted)
+		So(eventList.Events[1].Sequence, ShouldEqual, 3)
 
-			annotations := []string{"tag=1.0"}
+		resp, err = resty.R().Get(baseURL + constants.FullEventsPrefix + "/alpine?limit=1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			// sign the image
-			err = sign.SignCmd(&options.RootOptions{Verbose: true, Timeout: 1 * time.Minute},
-				options.KeyOpts{KeyRef: path.Join(tdir, "cosign.key"), PassFunc: generate.GetPass},
-				options.SignOptions{
-					Registry:          options.RegistryOptions{AllowInsecure: true},
-					AnnotationOptions: options.AnnotationOptions{Annotations: annotations},
-					Upload:            true,
-				},
-				[]string{fmt.Sprintf("localhost:%s/%s@%s", port, repoName, digest.String())})
-			So(err, ShouldBeNil)
+		So(json.Unmarshal(resp.Body(), &eventList), ShouldBeNil)
+		So(len(eventList.Events), ShouldEqual, 1)
+		So(eventList.Events[0].Sequence, ShouldEqual, 1)
 
-			// verify the image
-			aopts := &options.AnnotationOptions{Annotations: annotations}
-			amap, err := aopts.AnnotationsMap()
-			So(err, ShouldBeNil)
-			vrfy := verify.VerifyCommand{
-				RegistryOptions: options.RegistryOptions{AllowInsecure: true},
-				CheckClaims:     true,
-				KeyRef:          path.Join(tdir, "cosign.pub"),
-				Annotations:     amap,
-				IgnoreTlog:      true,
-			}
-			err = vrfy.Exec(context.TODO(), []string{fmt.Sprintf("localhost:%s/%s:%s", port, repoName, "1.0")})
-			So(err, ShouldBeNil)
+		resp, err = resty.R().Get(baseURL + constants.FullEventsPrefix + "/busybox")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			// verify the image with incorrect tag
-			aopts = &options.AnnotationOptions{Annotations: []string{"tag=2.0"}}
-			amap, err = aopts.AnnotationsMap()
-			So(err, ShouldBeNil)
-			vrfy = verify.VerifyCommand{
-				RegistryOptions: options.RegistryOptions{AllowInsecure: true},
-				CheckClaims:     true,
-				KeyRef:          path.Join(tdir, "cosign.pub"),
-				Annotations:     amap,
-				IgnoreTlog:      true,
-			}
-			err = vrfy.Exec(context.TODO(), []string{fmt.Sprintf("localhost:%s/%s:%s", port, repoName, "1.0")})
-			So(err, ShouldNotBeNil)
+		So(json.Unmarshal(resp.Body(), &eventList), ShouldBeNil)
+		So(eventList.Events, ShouldBeEmpty)
+		So(eventList.LatestSequence, ShouldEqual, 0)
 
-			// verify the image with incorrect key
-			aopts = &options.AnnotationOptions{Annotations: []string{"tag=1.0"}}
-			amap, err = aopts.AnnotationsMap()
+		for _, query := range []string{"?from=first", "?limit=0", "?limit=many"} {
+			resp, err = resty.R().Get(baseURL + constants.FullEventsPrefix + "/alpine" + query)
 			So(err, ShouldBeNil)
-			vrfy = verify.VerifyCommand{
-				CheckClaims:     true,
-				RegistryOptions: options.RegistryOptions{AllowInsecure: true},
-				KeyRef:          path.Join(tdir, "cosign.key"),
-				Annotations:     amap,
-				IgnoreTlog:      true,
-			}
-			err = vrfy.Exec(context.TODO(), []string{fmt.Sprintf("localhost:%s/%s:%s", port, repoName, "1.0")})
-			So(err, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		}
+	})
 
-			// generate another keypair
-			err = os.Remove(path.Join(tdir, "cosign.pub"))
-			So(err, ShouldBeNil)
-			err = os.Remove(path.Join(tdir, "cosign.key"))
-			So(err, ShouldBeNil)
+	Convey("Make a new controller keeping the events without plugins", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			os.Setenv("COSIGN_PASSWORD", "")
-			err = generate.GenerateKeyPairCmd(context.TODO(), "", "cosign", nil)
-			So(err, ShouldBeNil)
+		enable := true
 
-			// verify the image with incorrect key
-			aopts = &options.AnnotationOptions{Annotations: []string{"tag=1.0"}}
-			amap, err = aopts.AnnotationsMap()
-			So(err, ShouldBeNil)
-			vrfy = verify.VerifyCommand{
-				CheckClaims:     true,
-				RegistryOptions: options.RegistryOptions{AllowInsecure: true},
-				KeyRef:          path.Join(tdir, "cosign.pub"),
-				Annotations:     amap,
-				IgnoreTlog:      true,
-			}
-			err = vrfy.Exec(context.TODO(), []string{fmt.Sprintf("localhost:%s/%s:%s", port, repoName, "1.0")})
-			So(err, ShouldNotBeNil)
-		})
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Extensions = &extconf.ExtensionConfig{
+			Events: &extconf.EventsConfig{BaseConfig: extconf.BaseConfig{Enable: &enable}, Retention: time.Hour},
+		}
 
-		Convey("Validate notation signatures", func() {
-			cwd, err := os.Getwd()
-			So(err, ShouldBeNil)
-			defer func() { _ = os.Chdir(cwd) }()
-			tdir := t.TempDir()
-			_ = os.Chdir(tdir)
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "")
 
-			test.NotationPathLock.Lock()
-			defer test.NotationPathLock.Unlock()
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			test.LoadNotationPath(tdir)
+		So(ctlr.Plugins.EventLog(), ShouldNotBeNil)
+		So(ctlr.Plugins.EventLog().MaxAge, ShouldEqual, time.Hour)
 
-			err = test.GenerateNotationCerts(tdir, "good")
-			So(err, ShouldBeNil)
+		cfg, layers, manifest, err := test.GetImageComponents(2)
+		So(err, ShouldBeNil)
 
-			err = test.GenerateNotationCerts(tdir, "bad")
-			So(err, ShouldBeNil)
+		start := time.Now().UTC().Format(time.RFC3339)
 
-			image := fmt.Sprintf("localhost:%s/%s:%s", port, repoName, "1.0")
-			err = test.SignWithNotation("good", image, tdir)
-			So(err, ShouldBeNil)
+		for _, repo := range []string{"alpine", "busybox"} {
+			img := test.Image{Config: cfg, Layers: layers, Manifest: manifest, Reference: "1.0"}
 
-			err = test.VerifyWithNotation(image, tdir)
-			So(err, ShouldBeNil)
+			So(test.UploadImage(img, baseURL, repo), ShouldBeNil)
+		}
 
-			// check list
-			sigs, err := test.ListNotarySignatures(image, tdir)
-			So(len(sigs), ShouldEqual, 1)
-			So(err, ShouldBeNil)
+		resp, err := resty.R().Delete(baseURL + "/v2/alpine/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-			// check unsupported manifest media type
-			resp, err := resty.R().SetHeader("Content-Type", "application/vnd.unsupported.image.manifest.v1+json").
-				SetBody(content).Put(baseURL + fmt.Sprintf("/v2/%s/manifests/1.0", repoName))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusUnsupportedMediaType)
+		var eventPage api.EventPage
 
-			// check invalid content with artifact media type
-			resp, err = resty.R().SetHeader("Content-Type", artifactspec.MediaTypeArtifactManifest).
-				SetBody([]byte("bogus")).Put(baseURL + fmt.Sprintf("/v2/%s/manifests/1.0", repoName))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		resp, err = resty.R().Get(baseURL + constants.FullEventsPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			Convey("Validate corrupted signature", func() {
-				// verify with corrupted signature
-				resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
-					fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-				var refs ispec.Index
-				err = json.Unmarshal(resp.Body(), &refs)
-				So(err, ShouldBeNil)
-				So(len(refs.Manifests), ShouldEqual, 1)
-				err = os.WriteFile(path.Join(dir, repoName, "blobs",
-					strings.ReplaceAll(refs.Manifests[0].Digest.String(), ":", "/")), []byte("corrupt"), 0o600)
-				So(err, ShouldBeNil)
-				resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
-					fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusInternalServerError)
+		So(json.Unmarshal(resp.Body(), &eventPage), ShouldBeNil)
+		So(len(eventPage.Events), ShouldEqual, 3)
+		So(eventPage.Events[1].ID, ShouldEqual, 2)
+		So(eventPage.Events[1].Repo, ShouldEqual, "busybox")
+		So(eventPage.Next, ShouldEqual, 4)
+		So(eventPage.LatestID, ShouldEqual, 3)
 
-				err = test.VerifyWithNotation(image, tdir)
-				So(err, ShouldNotBeNil)
-			})
+		// pages
+		resp, err = resty.R().Get(baseURL + constants.FullEventsPrefix + "?limit=2")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			Convey("Validate deleted signature", func() {
-				// verify with corrupted signature
-				resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
-					fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-				var refs ispec.Index
-				err = json.Unmarshal(resp.Body(), &refs)
-				So(err, ShouldBeNil)
-				So(len(refs.Manifests), ShouldEqual, 1)
-				err = os.Remove(path.Join(dir, repoName, "blobs",
-					strings.ReplaceAll(refs.Manifests[0].Digest.String(), ":", "/")))
-				So(err, ShouldBeNil)
-				resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
-					fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		So(json.Unmarshal(resp.Body(), &eventPage), ShouldBeNil)
+		So(len(eventPage.Events), ShouldEqual, 2)
+		So(eventPage.Next, ShouldEqual, 3)
 
-				err = test.VerifyWithNotation(image, tdir)
-				So(err, ShouldNotBeNil)
-			})
-		})
+		resp, err = resty.R().Get(fmt.Sprintf("%s%s?from=%d", baseURL, constants.FullEventsPrefix, eventPage.Next))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		Convey("GetOrasReferrers", func() {
-			// cover error paths
-			resp, err := resty.R().Get(
-				fmt.Sprintf("%s/oras/artifacts/v1/%s/manifests/%s/referrers", baseURL, "badRepo", "badDigest"))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		So(json.Unmarshal(resp.Body(), &eventPage), ShouldBeNil)
+		So(len(eventPage.Events), ShouldEqual, 1)
+		So(eventPage.Events[0].Type, ShouldEqual, plugins.EventManifestDeleted)
 
-			resp, err = resty.R().Get(
-				fmt.Sprintf("%s/oras/artifacts/v1/%s/manifests/%s/referrers", baseURL, repoName, "badDigest"))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		// filters
+		resp, err = resty.R().SetQueryParams(map[string]string{
+			"repo": "alpine", "type": plugins.EventManifestPushed, "since": start,
+		}).Get(baseURL + constants.FullEventsPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			resp, err = resty.R().Get(
-				fmt.Sprintf("%s/oras/artifacts/v1/%s/manifests/%s/referrers", baseURL, repoName, digest.String()))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		So(json.Unmarshal(resp.Body(), &eventPage), ShouldBeNil)
+		So(len(eventPage.Events), ShouldEqual, 1)
+		So(eventPage.Events[0].Repo, ShouldEqual, "alpine")
 
-			resp, err = resty.R().SetQueryParam("artifactType", "badArtifact").Get(
-				fmt.Sprintf("%s/oras/artifacts/v1/%s/manifests/%s/referrers", baseURL, repoName, digest.String()))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		resp, err = resty.R().SetQueryParam("until", start).Get(baseURL + constants.FullEventsPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
-				fmt.Sprintf("%s/oras/artifacts/v1/%s/manifests/%s/referrers", baseURL, "badRepo", digest.String()))
+		So(json.Unmarshal(resp.Body(), &eventPage), ShouldBeNil)
+		So(eventPage.Events, ShouldBeEmpty)
+
+		for _, query := range []string{"?from=first", "?limit=0", "?since=yesterday", "?until=1"} {
+			resp, err = resty.R().Get(baseURL + constants.FullEventsPrefix + query)
 			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-		})
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		}
 	})
-}
 
-func TestArtifactReferences(t *testing.T) {
-	Convey("Validate Artifact References", t, func() {
-		// start a new server
+	Convey("Make a new controller serving the activity of the repos", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
 
+		enable := true
+
 		conf := config.New()
 		conf.HTTP.Port = port
+		conf.Extensions = &extconf.ExtensionConfig{
+			Events: &extconf.EventsConfig{BaseConfig: extconf.BaseConfig{Enable: &enable}},
+		}
 
 		dir := t.TempDir()
 		ctlr := makeController(conf, dir, "")
+
 		cm := test.NewControllerManager(ctlr)
-		// this blocks
-		cm.StartServer()
-		time.Sleep(1000 * time.Millisecond)
+		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		repoName := "artifact-repo"
-		content := []byte("this is a blob")
-		digest := godigest.FromBytes(content)
-		So(digest, ShouldNotBeNil)
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+		So(test.UploadImage(img, baseURL, "alpine"), ShouldBeNil)
 
-		cfg, layers, manifest, err := test.GetImageComponents(2)
+		signedDigest, err := img.Digest()
 		So(err, ShouldBeNil)
 
-		err = test.UploadImage(
-			test.Image{
-				Config:    cfg,
-				Layers:    layers,
-				Manifest:  manifest,
-				Reference: "1.0",
-			}, baseURL, repoName)
+		signature, err := test.GetRandomImage("")
 		So(err, ShouldBeNil)
 
-		content, err = json.Marshal(manifest)
+		signature.Reference, err = test.GetCosignSignatureTagForManifest(img.Manifest)
 		So(err, ShouldBeNil)
-		digest = godigest.FromBytes(content)
-		So(digest, ShouldNotBeNil)
+		So(test.UploadImage(signature, baseURL, "alpine"), ShouldBeNil)
 
-		artifactType := "application/vnd.example.icecream.v1"
+		// scans are notified by the scanner
+		ctlr.Plugins.Notify(plugins.Event{Type: plugins.EventScanCompleted, Repo: "alpine", Digest: signedDigest.String()})
 
-		Convey("Validate Image Manifest Reference", func() {
-			resp, err := resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		var activityPage api.ActivityPage
 
-			var referrers ispec.Index
-			err = json.Unmarshal(resp.Body(), &referrers)
-			So(err, ShouldBeNil)
-			So(referrers.Manifests, ShouldBeEmpty)
+		resp, err := resty.R().Get(baseURL + constants.FullActivityPrefix + "/alpine")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			// now upload a reference
+		// newest first, signature pushes are notified along with their signatures
+		So(json.Unmarshal(resp.Body(), &activityPage), ShouldBeNil)
+		So(len(activityPage.Events), ShouldEqual, 4)
+		So(activityPage.Events[0].Type, ShouldEqual, plugins.EventScanCompleted)
+		So(activityPage.Events[1].Type, ShouldEqual, plugins.EventSignatureAdded)
+		So(activityPage.Events[1].Reference, ShouldEqual, signature.Reference)
+		So(activityPage.Events[1].Details[plugins.EventDetailSignatureType], ShouldEqual, "cosign")
+		So(activityPage.Events[1].Details[plugins.EventDetailSignedDigest], ShouldEqual, signedDigest.String())
+		So(activityPage.Events[2].Type, ShouldEqual, plugins.EventManifestPushed)
+		So(activityPage.Events[2].Reference, ShouldEqual, signature.Reference)
+		So(activityPage.Events[3].Reference, ShouldEqual, "1.0")
+		So(activityPage.Next, ShouldEqual, 0)
+
+		// pages
+		resp, err = resty.R().Get(baseURL + constants.FullActivityPrefix + "/alpine?limit=3")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			// upload image config blob
-			resp, err = resty.R().Post(baseURL + fmt.Sprintf("/v2/%s/blobs/uploads/", repoName))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-			loc := test.Location(baseURL, resp)
-			cblob, cdigest := test.GetEmptyImageConfig()
+		So(json.Unmarshal(resp.Body(), &activityPage), ShouldBeNil)
+		So(len(activityPage.Events), ShouldEqual, 3)
+		So(activityPage.Next, ShouldEqual, 1)
 
-			resp, err = resty.R().
-				SetContentLength(true).
-				SetHeader("Content-Length", fmt.Sprintf("%d", len(cblob))).
-				SetHeader("Content-Type", "application/octet-stream").
-				SetQueryParam("digest", cdigest.String()).
-				SetBody(cblob).
-				Put(loc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-
-			// create a manifest
-			manifest := ispec.Manifest{
-				Config: ispec.Descriptor{
-					MediaType: artifactType,
-					Digest:    cdigest,
-					Size:      int64(len(cblob)),
-				},
-				Layers: []ispec.Descriptor{
-					{
-						MediaType: "application/vnd.oci.image.layer.v1.tar",
-						Digest:    digest,
-						Size:      int64(len(content)),
-					},
-				},
-				Subject: &ispec.Descriptor{
-					MediaType: ispec.MediaTypeImageManifest,
-					Digest:    digest,
-					Size:      int64(len(content)),
-				},
-				Annotations: map[string]string{
-					"key": "val",
-				},
-			}
-			manifest.SchemaVersion = 2
-
-			Convey("Using invalid content", func() {
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
-					SetBody([]byte("invalid data")).Put(baseURL + fmt.Sprintf("/v2/%s/manifests/1.0", repoName))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
-
-				// unknown repo will return status not found
-				resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", "unknown", digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-
-				resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		resp, err = resty.R().Get(fmt.Sprintf("%s%s/alpine?from=%d", baseURL, constants.FullActivityPrefix,
+			activityPage.Next))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-				resp, err = resty.R().SetQueryParams(map[string]string{"artifactType": artifactType}).
-					Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			})
-			Convey("Using valid content", func() {
-				content, err = json.Marshal(manifest)
-				So(err, ShouldBeNil)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
-					SetBody(content).Put(baseURL + fmt.Sprintf("/v2/%s/manifests/1.0", repoName))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(json.Unmarshal(resp.Body(), &activityPage), ShouldBeNil)
+		So(len(activityPage.Events), ShouldEqual, 1)
+		So(activityPage.Events[0].Sequence, ShouldEqual, 1)
 
-				resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		// filters
+		resp, err = resty.R().SetMultiValueQueryParams(url.Values{
+			"type": {plugins.EventSignatureAdded, plugins.EventScanCompleted},
+		}).Get(baseURL + constants.FullActivityPrefix + "/alpine")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-				resp, err = resty.R().SetQueryParams(map[string]string{"artifact": "invalid"}).
-					Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(json.Unmarshal(resp.Body(), &activityPage), ShouldBeNil)
+		So(len(activityPage.Events), ShouldEqual, 2)
 
-				resp, err = resty.R().SetQueryParams(map[string]string{"artifactType": "invalid"}).
-					Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		resp, err = resty.R().Get(baseURL + constants.FullActivityPrefix + "/busybox")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-				resp, err = resty.R().SetQueryParams(map[string]string{"artifactType": artifactType}).
-					Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-				So(resp.Header().Get("Content-Type"), ShouldEqual, ispec.MediaTypeImageIndex)
-				So(resp.Header().Get("OCI-Filters-Applied"), ShouldEqual, artifactType)
+		So(json.Unmarshal(resp.Body(), &activityPage), ShouldBeNil)
+		So(activityPage.Events, ShouldBeEmpty)
 
-				resp, err = resty.R().SetQueryParams(map[string]string{"artifactType": artifactType +
-					",otherArtType"}).Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName,
-					digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-				So(resp.Header().Get("Content-Type"), ShouldEqual, ispec.MediaTypeImageIndex)
-				So(resp.Header().Get("OCI-Filters-Applied"), ShouldEqual, artifactType+",otherArtType")
-			})
-		})
+		for _, query := range []string{"?from=latest", "?limit=-1"} {
+			resp, err = resty.R().Get(baseURL + constants.FullActivityPrefix + "/alpine" + query)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		}
 	})
 }
 
-//nolint:dupl // duplicated test code
-func TestRouteFailures(t *testing.T) {
+func TestAuthorizationWithMultiplePolicies(t *testing.T) {
 	Convey("Make a new controller", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
+
 		conf := config.New()
 		conf.HTTP.Port = port
+		// have two users: "test" user for  user Policy, and "bob" for default policy
+		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase) +
+			"\n" + getCredString("bob", passphrase))
+		defer os.Remove(htpasswdPath)
 
-		ctlr := makeController(conf, t.TempDir(), "")
-		ctlr.Config.Storage.Commit = true
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		// config with all policy types, to test that the correct one is applied in each case
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				AuthorizationAllRepos: config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Users:   []string{},
+							Actions: []string{},
+						},
+					},
+					DefaultPolicy:   []string{},
+					AnonymousPolicy: []string{},
+				},
+			},
+			AdminPolicy: config.Policy{
+				Users:   []string{},
+				Actions: []string{},
+			},
+		}
+
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "../../test/data")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		rthdlr := api.NewRouteHandler(ctlr)
-
-		// NOTE: the url or method itself doesn't matter below since we are calling the handlers directly,
-		// so path routing is bypassed
+		blob := []byte("hello, blob!")
+		digest := godigest.FromBytes(blob).String()
 
-		Convey("List tags", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+		// unauthenticated clients should not have access to /v2/, no policy is applied since none exists
+		resp, err := resty.R().Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, 401)
 
-			rthdlr.ListTags(response, request)
+		repoPolicy := conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos]
+		repoPolicy.AnonymousPolicy = append(repoPolicy.AnonymousPolicy, "read")
+		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos] = repoPolicy
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		// should have access to /v2/, anonymous policy is applied, "read" allowed
+		resp, err = resty.R().Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+		// with empty username:password
+		resp, err = resty.R().SetHeader("Authorization", "Basic Og==").Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			rthdlr.ListTags(response, request)
+		// add "test" user to global policy with create permission
+		repoPolicy.Policies[0].Users = append(repoPolicy.Policies[0].Users, "test")
+		repoPolicy.Policies[0].Actions = append(repoPolicy.Policies[0].Actions, "create")
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		// now it should get 202, user has the permission set on "create"
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc := resp.Header().Get("Location")
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			qparm := request.URL.Query()
-			qparm.Add("n", "a")
-			request.URL.RawQuery = qparm.Encode()
-			response = httptest.NewRecorder()
+		// uploading blob should get 201
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(blob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", digest).
+			SetBody(blob).
+			Put(baseURL + loc)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-			rthdlr.ListTags(response, request)
+		// head blob should get 403 without read perm
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Head(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/" + digest)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+		// get tags without read access should get 403
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			qparm = request.URL.Query()
-			qparm.Add("n", "abc")
-			request.URL.RawQuery = qparm.Encode()
-			response = httptest.NewRecorder()
+		repoPolicy.DefaultPolicy = append(repoPolicy.DefaultPolicy, "read")
+		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos] = repoPolicy
 
-			rthdlr.ListTags(response, request)
+		// with read permission should get 200, because default policy allows reading now
+		resp, err = resty.R().SetBasicAuth(username, passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+		// get tags with default read access should be ok, since the user is now "bob" and default policy is applied
+		resp, err = resty.R().SetBasicAuth("bob", passphrase).
+			Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			qparm = request.URL.Query()
-			qparm.Add("n", "a")
-			qparm.Add("n", "abc")
-			request.URL.RawQuery = qparm.Encode()
-			response = httptest.NewRecorder()
+		// get tags with default policy read access
+		resp, err = resty.R().Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			rthdlr.ListTags(response, request)
+		// get tags with anonymous read access should be ok
+		resp, err = resty.R().Get(baseURL + "/v2/" + AuthorizationNamespace + "/tags/list")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+		// without create permission should get 403, since "bob" can only read(default policy applied)
+		resp, err = resty.R().SetBasicAuth("bob", passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			qparm = request.URL.Query()
-			qparm.Add("n", "0")
-			request.URL.RawQuery = qparm.Encode()
-			response = httptest.NewRecorder()
+		// add read permission to user "bob"
+		conf.HTTP.AccessControl.AdminPolicy.Users = append(conf.HTTP.AccessControl.AdminPolicy.Users, "bob")
+		conf.HTTP.AccessControl.AdminPolicy.Actions = append(conf.HTTP.AccessControl.AdminPolicy.Actions, "create")
 
-			rthdlr.ListTags(response, request)
+		// added create permission to user "bob", should be allowed now
+		resp, err = resty.R().SetBasicAuth("bob", passphrase).
+			Post(baseURL + "/v2/" + AuthorizationNamespace + "/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+	})
+}
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+func TestInvalidCases(t *testing.T) {
+	Convey("Invalid repo dir", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			qparm = request.URL.Query()
-			qparm.Add("n", "1")
-			qparm.Add("last", "")
-			request.URL.RawQuery = qparm.Encode()
-			response = httptest.NewRecorder()
+		conf := config.New()
+		conf.HTTP.Port = port
+		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
 
-			rthdlr.ListTags(response, request)
+		defer os.Remove(htpasswdPath)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			qparm = request.URL.Query()
-			qparm.Add("n", "1")
-			qparm.Add("last", "a")
-			request.URL.RawQuery = qparm.Encode()
-			response = httptest.NewRecorder()
+		ctlr := makeController(conf, "oci-repo-test", "")
 
-			rthdlr.ListTags(response, request)
+		err := os.Mkdir("oci-repo-test", 0o000)
+		if err != nil {
+			panic(err)
+		}
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer func(ctrl *api.Controller) {
+			err := ctrl.Server.Shutdown(context.Background())
+			if err != nil {
+				panic(err)
+			}
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			qparm = request.URL.Query()
-			qparm.Add("n", "1")
-			qparm.Add("last", "a")
-			qparm.Add("last", "abc")
-			request.URL.RawQuery = qparm.Encode()
-			response = httptest.NewRecorder()
+			err = os.RemoveAll(ctrl.Config.Storage.RootDirectory)
+			if err != nil {
+				panic(err)
+			}
+		}(ctlr)
 
-			rthdlr.ListTags(response, request)
+		digest := test.GetTestBlobDigest("zot-cve-test", "config").String()
+		name := "zot-c-test"
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
-		})
+		client := resty.New()
 
-		Convey("Check manifest", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+		params := make(map[string]string)
+		params["from"] = "zot-cveid-test"
+		params["mount"] = digest
 
-			rthdlr.CheckManifest(response, request)
+		postResponse, err := client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(params).
+			Post(fmt.Sprintf("%s/v2/%s/blobs/uploads/", baseURL, name))
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusInternalServerError)
+	})
+}
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+func TestHTTPReadOnly(t *testing.T) {
+	Convey("Single cred", t, func() {
+		singleCredtests := []string{}
+		user := ALICE
+		password := ALICE
+		singleCredtests = append(singleCredtests, getCredString(user, password))
+		singleCredtests = append(singleCredtests, getCredString(user, password)+"\n")
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			rthdlr.CheckManifest(response, request)
+		for _, testString := range singleCredtests {
+			func() {
+				conf := config.New()
+				conf.HTTP.Port = port
+				// enable read-only mode
+				conf.HTTP.AccessControl = &config.AccessControlConfig{
+					Repositories: config.Repositories{
+						AuthorizationAllRepos: config.PolicyGroup{
+							DefaultPolicy: []string{"read"},
+						},
+					},
+				}
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+				htpasswdPath := test.MakeHtpasswdFileFromString(testString)
+				defer os.Remove(htpasswdPath)
+				conf.HTTP.Auth = &config.AuthConfig{
+					HTPasswd: config.AuthHTPasswd{
+						Path: htpasswdPath,
+					},
+				}
+				ctlr := makeController(conf, t.TempDir(), "")
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo", "reference": ""})
-			response = httptest.NewRecorder()
+				cm := test.NewControllerManager(ctlr)
+				cm.StartAndWait(port)
+				defer cm.StopServer()
 
-			rthdlr.CheckManifest(response, request)
+				// with creds, should get expected status code
+				resp, _ := resty.R().SetBasicAuth(user, password).Get(baseURL + "/v2/")
+				So(resp, ShouldNotBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
-		})
+				// with creds, any modifications should still fail on read-only mode
+				resp, err := resty.R().SetBasicAuth(user, password).
+					Post(baseURL + "/v2/" + AuthorizedNamespace + "/blobs/uploads/")
+				So(err, ShouldBeNil)
+				So(resp, ShouldNotBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		Convey("Get manifest", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+				// with invalid creds, it should fail
+				resp, _ = resty.R().SetBasicAuth("chuck", "chuck").Get(baseURL + "/v2/")
+				So(resp, ShouldNotBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+			}()
+		}
+	})
+}
 
-			rthdlr.GetManifest(response, request)
+func TestCrossRepoMount(t *testing.T) {
+	Convey("Cross Repo Mount", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		conf := config.New()
+		conf.HTTP.Port = port
+		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+		defer os.Remove(htpasswdPath)
 
-			rthdlr.GetManifest(response, request)
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "../../test/data")
+		ctlr.Config.Storage.RemoteCache = false
+		ctlr.Config.Storage.Dedupe = false
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo", "reference": ""})
-			response = httptest.NewRecorder()
+		cm := test.NewControllerManager(ctlr) //nolint: varnamelen
+		cm.StartAndWait(port)
 
-			rthdlr.GetManifest(response, request)
+		params := make(map[string]string)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
-		})
+		var manifestDigest godigest.Digest
+		manifestDigest, _, _ = test.GetOciLayoutDigests("../../test/data/zot-cve-test")
 
-		Convey("Update manifest", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+		dgst := manifestDigest
+		name := "zot-cve-test"
+		params["mount"] = string(manifestDigest)
+		params["from"] = name
 
-			rthdlr.UpdateManifest(response, request)
+		client := resty.New()
+		headResponse, err := client.R().SetBasicAuth(username, passphrase).
+			Head(fmt.Sprintf("%s/v2/%s/blobs/%s", baseURL, name, manifestDigest))
+		So(err, ShouldBeNil)
+		So(headResponse.StatusCode(), ShouldEqual, http.StatusOK)
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		// All invalid request of mount should return 202.
+		params["mount"] = "sha:"
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+		postResponse, err := client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(params).
+			Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
+		location, err := postResponse.RawResponse.Location()
+		So(err, ShouldBeNil)
+		So(location.String(), ShouldStartWith, fmt.Sprintf("%s%s/zot-c-test/%s/%s",
+			baseURL, constants.RoutePrefix, constants.Blobs, constants.Uploads))
 
-			rthdlr.UpdateManifest(response, request)
+		incorrectParams := make(map[string]string)
+		incorrectParams["mount"] = test.GetTestBlobDigest("zot-cve-test", "manifest").String()
+		incorrectParams["from"] = "zot-x-test"
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		postResponse, err = client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(incorrectParams).
+			Post(baseURL + "/v2/zot-y-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(test.Location(baseURL, postResponse), ShouldStartWith, fmt.Sprintf("%s%s/zot-y-test/%s/%s",
+			baseURL, constants.RoutePrefix, constants.Blobs, constants.Uploads))
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo", "reference": ""})
-			response = httptest.NewRecorder()
+		// Use correct request
+		// The blob is mounted from the repo given by "from" even if it's not in the cache.
+		params["mount"] = string(manifestDigest)
+		postResponse, err = client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(params).
+			Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(test.Location(baseURL, postResponse), ShouldEqual, fmt.Sprintf("%s%s/zot-c-test/%s/%s",
+			baseURL, constants.RoutePrefix, constants.Blobs, manifestDigest))
 
-			rthdlr.UpdateManifest(response, request)
+		headResponse, err = client.R().SetBasicAuth(username, passphrase).
+			Head(fmt.Sprintf("%s/v2/zot-c-test/blobs/%s", baseURL, manifestDigest))
+		So(err, ShouldBeNil)
+		So(headResponse.StatusCode(), ShouldEqual, http.StatusOK)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
-		})
+		// Send same request again
+		postResponse, err = client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(params).
+			Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		Convey("Delete manifest", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+		// Without "from" the blob is only looked up in the cache, which is disabled
+		postResponse, err = client.R().
+			SetBasicAuth(username, passphrase).SetQueryParam("mount", string(manifestDigest)).
+			Post(baseURL + "/v2/zot-d-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-			rthdlr.DeleteManifest(response, request)
+		headResponse, err = client.R().SetBasicAuth(username, passphrase).
+			Head(fmt.Sprintf("%s/v2/zot-cv-test/blobs/%s", baseURL, manifestDigest))
+		So(err, ShouldBeNil)
+		So(headResponse.StatusCode(), ShouldEqual, http.StatusNotFound)
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		postResponse, err = client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(params).Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+		postResponse, err = client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(params).
+			Post(baseURL + "/v2/ /blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusNotFound)
 
-			rthdlr.DeleteManifest(response, request)
+		blob := manifestDigest.Encoded()
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		buf, err := os.ReadFile(path.Join(ctlr.Config.Storage.RootDirectory, "zot-cve-test/blobs/sha256/"+blob))
+		if err != nil {
+			panic(err)
+		}
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo", "reference": ""})
-			response = httptest.NewRecorder()
+		postResponse, err = client.R().SetHeader("Content-type", "application/octet-stream").
+			SetBasicAuth(username, passphrase).SetQueryParam("digest", "sha256:"+blob).
+			SetBody(buf).Post(baseURL + "/v2/zot-d-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
 
-			rthdlr.DeleteManifest(response, request)
+		// We have uploaded a blob and since we have provided digest it should be full blob upload and there should be entry
+		// in cache, now try mount blob request status and it should be 201 because now blob is present in cache
+		// and it should do hard link.
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
-		})
+		// restart server with dedupe enabled
+		cm.StopServer()
+		ctlr.Config.Storage.Dedupe = true
+		cm.StartAndWait(port)
 
-		Convey("Check blob", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+		// wait for dedupe task to run
+		time.Sleep(15 * time.Second)
 
-			rthdlr.CheckBlob(response, request)
+		params["mount"] = string(manifestDigest)
+		postResponse, err = client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(params).
+			Post(baseURL + "/v2/zot-mount-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(test.Location(baseURL, postResponse), ShouldEqual, fmt.Sprintf("%s%s/zot-mount-test/%s/%s:%s",
+			baseURL, constants.RoutePrefix, constants.Blobs, godigest.SHA256, blob))
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		// Check os.SameFile here, the blob is linked from the repo given by "from"
+		cachePath := path.Join(ctlr.Config.Storage.RootDirectory, "zot-cve-test", "blobs/sha256", dgst.Encoded())
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+		cacheFi, err := os.Stat(cachePath)
+		So(err, ShouldBeNil)
 
-			rthdlr.CheckBlob(response, request)
+		linkPath := path.Join(ctlr.Config.Storage.RootDirectory, "zot-mount-test", "blobs/sha256", dgst.Encoded())
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		linkFi, err := os.Stat(linkPath)
+		So(err, ShouldBeNil)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo", "digest": ""})
-			response = httptest.NewRecorder()
+		So(os.SameFile(cacheFi, linkFi), ShouldEqual, true)
 
-			rthdlr.CheckBlob(response, request)
+		// Now try another mount request and this time it should be from above uploaded repo i.e zot-mount-test
+		// mount request should pass and should return 201.
+		params["mount"] = string(manifestDigest)
+		params["from"] = "zot-mount-test"
+		postResponse, err = client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(params).
+			Post(baseURL + "/v2/zot-mount1-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(test.Location(baseURL, postResponse), ShouldEqual, fmt.Sprintf("%s%s/zot-mount1-test/%s/%s:%s",
+			baseURL, constants.RoutePrefix, constants.Blobs, godigest.SHA256, blob))
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
-		})
+		linkPath = path.Join(ctlr.Config.Storage.RootDirectory, "zot-mount1-test", "blobs/sha256", dgst.Encoded())
 
-		Convey("Get blob", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+		linkFi, err = os.Stat(linkPath)
+		So(err, ShouldBeNil)
 
-			rthdlr.GetBlob(response, request)
+		So(os.SameFile(cacheFi, linkFi), ShouldEqual, true)
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		headResponse, err = client.R().SetBasicAuth(username, passphrase).
+			Head(fmt.Sprintf("%s/v2/zot-cv-test/blobs/%s", baseURL, manifestDigest))
+		So(err, ShouldBeNil)
+		So(headResponse.StatusCode(), ShouldEqual, http.StatusOK)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+		// Invalid request
+		params = make(map[string]string)
+		params["mount"] = "sha256:"
+		postResponse, err = client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(params).
+			Post(baseURL + "/v2/zot-mount-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-			rthdlr.GetBlob(response, request)
+		params = make(map[string]string)
+		params["from"] = "zot-cve-test"
+		postResponse, err = client.R().
+			SetBasicAuth(username, passphrase).SetQueryParams(params).
+			Post(baseURL + "/v2/zot-mount-test/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusMethodNotAllowed)
+	})
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+	Convey("Cross Repo Mount requires read access to the source repo", t, func() {
+		bob := "bob"
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo", "digest": ""})
-			response = httptest.NewRecorder()
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		conf.Storage.Dedupe = false
 
-			rthdlr.GetBlob(response, request)
+		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(ALICE, ALICE) + "\n" +
+			getCredString(bob, bob))
+		defer os.Remove(htpasswdPath)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
-		})
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				AuthorizationAllRepos: config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Users:   []string{ALICE, bob},
+							Actions: []string{"read", "create"},
+						},
+					},
+				},
+				"private": config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Users:   []string{ALICE},
+							Actions: []string{"read", "create"},
+						},
+					},
+				},
+			},
+		}
 
-		Convey("Delete blob", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+		ctlr := api.NewController(conf)
 
-			rthdlr.DeleteBlob(response, request)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
-
-			rthdlr.DeleteBlob(response, request)
+		err = test.UploadImageWithBasicAuth(img, baseURL, "private", ALICE, ALICE)
+		So(err, ShouldBeNil)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		layerDigest := img.Manifest.Layers[0].Digest
+		params := map[string]string{"mount": layerDigest.String(), "from": "private"}
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo", "digest": ""})
-			response = httptest.NewRecorder()
+		// the blob isn't mounted but a new upload is started
+		resp, err := resty.R().SetBasicAuth(bob, bob).SetQueryParams(params).
+			Post(baseURL + "/v2/bob/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(test.Location(baseURL, resp), ShouldStartWith, fmt.Sprintf("%s%s/bob/%s/%s",
+			baseURL, constants.RoutePrefix, constants.Blobs, constants.Uploads))
 
-			rthdlr.DeleteBlob(response, request)
+		resp, err = resty.R().SetBasicAuth(bob, bob).Head(baseURL + "/v2/bob/blobs/" + layerDigest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
-		})
+		resp, err = resty.R().SetBasicAuth(ALICE, ALICE).SetQueryParams(params).
+			Post(baseURL + "/v2/alice/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(test.Location(baseURL, resp), ShouldEqual, fmt.Sprintf("%s%s/alice/%s/%s",
+			baseURL, constants.RoutePrefix, constants.Blobs, layerDigest))
 
-		Convey("Create blob upload", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPost, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+		resp, err = resty.R().SetBasicAuth(ALICE, ALICE).Get(baseURL + "/v2/alice/blobs/" + layerDigest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Body(), ShouldResemble, img.Layers[0])
 
-			rthdlr.CreateBlobUpload(response, request)
+		// invalid source repos aren't mounted from
+		params["from"] = "../private"
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		resp, err = resty.R().SetBasicAuth(ALICE, ALICE).SetQueryParams(params).
+			Post(baseURL + "/v2/alice2/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+	})
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPost, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			qparm := request.URL.Query()
-			qparm.Add("mount", "a")
-			qparm.Add("mount", "abc")
-			request.URL.RawQuery = qparm.Encode()
-			response = httptest.NewRecorder()
+	Convey("Disable dedupe and cache", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			rthdlr.CreateBlobUpload(response, request)
+		conf := config.New()
+		conf.HTTP.Port = port
+		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+		defer os.Remove(htpasswdPath)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPost, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			qparm = request.URL.Query()
-			qparm.Add("mount", "a")
-			request.URL.RawQuery = qparm.Encode()
-			response = httptest.NewRecorder()
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
 
-			rthdlr.CreateBlobUpload(response, request)
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "../../test/data")
+		ctlr.Config.Storage.Dedupe = false
+		ctlr.Config.Storage.GC = false
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusAccepted)
-		})
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-		Convey("Get blob upload", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+		digest := test.GetTestBlobDigest("zot-cve-test", "layer").String()
+		name := "zot-c-test"
+		client := resty.New()
+		headResponse, err := client.R().SetBasicAuth(username, passphrase).
+			Head(fmt.Sprintf("%s/v2/%s/blobs/%s", baseURL, name, digest))
+		So(err, ShouldBeNil)
+		So(headResponse.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+}
 
-			rthdlr.GetBlobUpload(response, request)
+func TestParallelRequests(t *testing.T) {
+	t.Parallel()
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+	testCases := []struct {
+		srcImageName  string
+		srcImageTag   string
+		destImageName string
+		destImageTag  string
+		testCaseName  string
+	}{
+		{
+			srcImageName:  "zot-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "zot-1-test",
+			destImageTag:  "0.0.1",
+			testCaseName:  "Request-1",
+		},
+		{
+			srcImageName:  "zot-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "zot-2-test",
+			testCaseName:  "Request-2",
+		},
+		{
+			srcImageName:  "zot-cve-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "a/zot-3-test",
+			testCaseName:  "Request-3",
+		},
+		{
+			srcImageName:  "zot-cve-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "b/zot-4-test",
+			testCaseName:  "Request-4",
+		},
+		{
+			srcImageName:  "zot-cve-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "zot-5-test",
+			testCaseName:  "Request-5",
+		},
+		{
+			srcImageName:  "zot-cve-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "zot-1-test",
+			testCaseName:  "Request-6",
+		},
+		{
+			srcImageName:  "zot-cve-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "zot-2-test",
+			testCaseName:  "Request-7",
+		},
+		{
+			srcImageName:  "zot-cve-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "zot-3-test",
+			testCaseName:  "Request-8",
+		},
+		{
+			srcImageName:  "zot-cve-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "zot-4-test",
+			testCaseName:  "Request-9",
+		},
+		{
+			srcImageName:  "zot-cve-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "zot-5-test",
+			testCaseName:  "Request-10",
+		},
+		{
+			srcImageName:  "zot-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "zot-1-test",
+			destImageTag:  "0.0.1",
+			testCaseName:  "Request-11",
+		},
+		{
+			srcImageName:  "zot-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "zot-2-test",
+			testCaseName:  "Request-12",
+		},
+		{
+			srcImageName:  "zot-cve-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "a/zot-3-test",
+			testCaseName:  "Request-13",
+		},
+		{
+			srcImageName:  "zot-cve-test",
+			srcImageTag:   "0.0.1",
+			destImageName: "b/zot-4-test",
+			testCaseName:  "Request-14",
+		},
+	}
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
 
-			rthdlr.GetBlobUpload(response, request)
+	conf := config.New()
+	conf.HTTP.Port = port
+	htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
-		})
+	conf.HTTP.Auth = &config.AuthConfig{
+		HTPasswd: config.AuthHTPasswd{
+			Path: htpasswdPath,
+		},
+	}
 
-		Convey("Patch blob upload", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPatch, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+	dir := t.TempDir()
+	firstSubDir := t.TempDir()
+	secondSubDir := t.TempDir()
 
-			rthdlr.PatchBlobUpload(response, request)
+	subPaths := make(map[string]config.StorageConfig)
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+	subPaths["/a"] = config.StorageConfig{RootDirectory: firstSubDir}
+	subPaths["/b"] = config.StorageConfig{RootDirectory: secondSubDir}
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+	ctlr := makeController(conf, dir, "")
+	ctlr.Config.Storage.SubPaths = subPaths
 
-			rthdlr.PatchBlobUpload(response, request)
+	cm := test.NewControllerManager(ctlr)
+	cm.StartAndWait(port)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
-		})
+	// without creds, should get access error
+	for i, testcase := range testCases {
+		testcase := testcase
+		run := i
 
-		Convey("Update blob upload", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			t.Parallel()
+			client := resty.New()
 
-			rthdlr.UpdateBlobUpload(response, request)
+			tagResponse, err := client.R().SetBasicAuth(username, passphrase).
+				Get(baseURL + "/v2/" + testcase.destImageName + "/tags/list")
+			assert.Equal(t, err, nil, "Error should be nil")
+			assert.NotEqual(t, tagResponse.StatusCode(), http.StatusBadRequest, "bad request")
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+			manifestList := getAllManifests(path.Join("../../test/data", testcase.srcImageName))
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+			for _, manifest := range manifestList {
+				headResponse, err := client.R().SetBasicAuth(username, passphrase).
+					Head(baseURL + "/v2/" + testcase.destImageName + "/manifests/" + manifest)
+				assert.Equal(t, err, nil, "Error should be nil")
+				assert.Equal(t, headResponse.StatusCode(), http.StatusNotFound, "response status code should return 404")
 
-			rthdlr.UpdateBlobUpload(response, request)
+				getResponse, err := client.R().SetBasicAuth(username, passphrase).
+					Get(baseURL + "/v2/" + testcase.destImageName + "/manifests/" + manifest)
+				assert.Equal(t, err, nil, "Error should be nil")
+				assert.Equal(t, getResponse.StatusCode(), http.StatusNotFound, "response status code should return 404")
+			}
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+			blobList := getAllBlobs(path.Join("../../test/data", testcase.srcImageName))
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo", "session_id": "bar"})
-			response = httptest.NewRecorder()
+			for _, blob := range blobList {
+				// Get request of blob
+				headResponse, err := client.R().
+					SetBasicAuth(username, passphrase).
+					Head(baseURL + "/v2/" + testcase.destImageName + "/blobs/sha256:" + blob)
 
-			rthdlr.UpdateBlobUpload(response, request)
+				assert.Equal(t, err, nil, "Should not be nil")
+				assert.NotEqual(t, headResponse.StatusCode(), http.StatusInternalServerError,
+					"internal server error should not occurred")
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+				getResponse, err := client.R().
+					SetBasicAuth(username, passphrase).
+					Get(baseURL + "/v2/" + testcase.destImageName + "/blobs/sha256:" + blob)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo", "session_id": "bar"})
-			qparm := request.URL.Query()
-			qparm.Add("digest", "a")
-			qparm.Add("digest", "abc")
-			request.URL.RawQuery = qparm.Encode()
-			response = httptest.NewRecorder()
+				assert.Equal(t, err, nil, "Should not be nil")
+				assert.NotEqual(t, getResponse.StatusCode(), http.StatusInternalServerError,
+					"internal server error should not occurred")
 
-			rthdlr.UpdateBlobUpload(response, request)
+				blobPath := path.Join("../../test/data", testcase.srcImageName, "blobs/sha256", blob)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
-		})
+				buf, err := os.ReadFile(blobPath)
+				if err != nil {
+					panic(err)
+				}
 
-		Convey("Delete blob upload", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+				// Post request of blob
+				postResponse, err := client.R().
+					SetHeader("Content-type", "application/octet-stream").
+					SetBasicAuth(username, passphrase).
+					SetBody(buf).Post(baseURL + "/v2/" + testcase.destImageName + "/blobs/uploads/")
 
-			rthdlr.DeleteBlobUpload(response, request)
+				assert.Equal(t, err, nil, "Error should be nil")
+				assert.NotEqual(t, postResponse.StatusCode(), http.StatusInternalServerError,
+					"response status code should not return 500")
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+				// Post request with query parameter
+				if run%2 == 0 {
+					postResponse, err = client.R().
+						SetHeader("Content-type", "application/octet-stream").
+						SetBasicAuth(username, passphrase).
+						SetBody(buf).
+						Post(baseURL + "/v2/" + testcase.destImageName + "/blobs/uploads/")
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+					assert.Equal(t, err, nil, "Error should be nil")
+					assert.NotEqual(t, postResponse.StatusCode(), http.StatusInternalServerError,
+						"response status code should not return 500")
 
-			rthdlr.DeleteBlobUpload(response, request)
+					var sessionID string
+					sessionIDList := postResponse.Header().Values("Blob-Upload-UUID")
+					if len(sessionIDList) == 0 {
+						location := postResponse.Header().Values("Location")
+						firstLocation := location[0]
+						splitLocation := strings.Split(firstLocation, "/")
+						sessionID = splitLocation[len(splitLocation)-1]
+					} else {
+						sessionID = sessionIDList[0]
+					}
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
-		})
+					file, err := os.Open(blobPath)
+					if err != nil {
+						panic(err)
+					}
 
-		Convey("Get referrers", func() {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{})
-			response := httptest.NewRecorder()
+					defer file.Close()
 
-			rthdlr.GetOrasReferrers(response, request)
+					reader := bufio.NewReader(file)
 
-			resp := response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+					buf := make([]byte, 5*1024*1024)
 
-			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
-			response = httptest.NewRecorder()
+					if run%4 == 0 {
+						readContent := 0
+						for {
+							nbytes, err := reader.Read(buf)
+							if err != nil {
+								if goerrors.Is(err, io.EOF) {
+									break
+								}
+								panic(err)
+							}
+							// Patch request of blob
 
-			rthdlr.GetOrasReferrers(response, request)
+							patchResponse, err := client.R().
+								SetBody(buf[0:nbytes]).
+								SetHeader("Content-Type", "application/octet-stream").
+								SetHeader("Content-Length", fmt.Sprintf("%d", nbytes)).
+								SetHeader("Content-Range", fmt.Sprintf("%d", readContent)+"-"+fmt.Sprintf("%d", readContent+nbytes-1)).
+								SetBasicAuth(username, passphrase).
+								Patch(baseURL + "/v2/" + testcase.destImageName + "/blobs/uploads/" + sessionID)
 
-			resp = response.Result()
-			defer resp.Body.Close()
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+							assert.Equal(t, err, nil, "Error should be nil")
+							assert.NotEqual(t, patchResponse.StatusCode(), http.StatusInternalServerError,
+								"response status code should not return 500")
+
+							readContent += nbytes
+						}
+					} else {
+						for {
+							nbytes, err := reader.Read(buf)
+							if err != nil {
+								if goerrors.Is(err, io.EOF) {
+									break
+								}
+								panic(err)
+							}
+							// Patch request of blob
+
+							patchResponse, err := client.R().SetBody(buf[0:nbytes]).SetHeader("Content-type", "application/octet-stream").
+								SetBasicAuth(username, passphrase).
+								Patch(baseURL + "/v2/" + testcase.destImageName + "/blobs/uploads/" + sessionID)
+							if err != nil {
+								panic(err)
+							}
+
+							assert.Equal(t, err, nil, "Error should be nil")
+							assert.NotEqual(t, patchResponse.StatusCode(), http.StatusInternalServerError,
+								"response status code should not return 500")
+						}
+					}
+				} else {
+					postResponse, err = client.R().
+						SetHeader("Content-type", "application/octet-stream").
+						SetBasicAuth(username, passphrase).
+						SetBody(buf).SetQueryParam("digest", "sha256:"+blob).
+						Post(baseURL + "/v2/" + testcase.destImageName + "/blobs/uploads/")
+
+					assert.Equal(t, err, nil, "Error should be nil")
+					assert.NotEqual(t, postResponse.StatusCode(), http.StatusInternalServerError,
+						"response status code should not return 500")
+				}
+
+				headResponse, err = client.R().
+					SetBasicAuth(username, passphrase).
+					Head(baseURL + "/v2/" + testcase.destImageName + "/blobs/sha256:" + blob)
+
+				assert.Equal(t, err, nil, "Should not be nil")
+				assert.NotEqual(t, headResponse.StatusCode(), http.StatusInternalServerError, "response should return success code")
+
+				getResponse, err = client.R().
+					SetBasicAuth(username, passphrase).
+					Get(baseURL + "/v2/" + testcase.destImageName + "/blobs/sha256:" + blob)
+
+				assert.Equal(t, err, nil, "Should not be nil")
+				assert.NotEqual(t, getResponse.StatusCode(), http.StatusInternalServerError, "response should return success code")
+			}
+
+			tagResponse, err = client.R().SetBasicAuth(username, passphrase).
+				Get(baseURL + "/v2/" + testcase.destImageName + "/tags/list")
+			assert.Equal(t, err, nil, "Error should be nil")
+			assert.Equal(t, tagResponse.StatusCode(), http.StatusOK, "response status code should return success code")
+
+			repoResponse, err := client.R().SetBasicAuth(username, passphrase).
+				Get(baseURL + constants.RoutePrefix + constants.ExtCatalogPrefix)
+			assert.Equal(t, err, nil, "Error should be nil")
+			assert.Equal(t, repoResponse.StatusCode(), http.StatusOK, "response status code should return success code")
 		})
-	})
+	}
 }
 
-func TestStorageCommit(t *testing.T) {
-	Convey("Make a new controller", t, func() {
+func TestHardLink(t *testing.T) {
+	Convey("Validate hard link", t, func() {
 		port := test.GetFreePort()
-		baseURL := test.GetBaseURL(port)
 		conf := config.New()
 		conf.HTTP.Port = port
+		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString(username, passphrase))
+
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
 
 		dir := t.TempDir()
+
+		err := os.Chmod(dir, 0o400)
+		if err != nil {
+			panic(err)
+		}
+
+		subDir := t.TempDir()
+
+		err = os.Chmod(subDir, 0o400)
+		if err != nil {
+			panic(err)
+		}
+
 		ctlr := makeController(conf, dir, "")
-		ctlr.Config.Storage.Commit = true
+		subPaths := make(map[string]config.StorageConfig)
+
+		subPaths["/a"] = config.StorageConfig{RootDirectory: subDir, Dedupe: true}
+		ctlr.Config.Storage.SubPaths = subPaths
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		Convey("Manifests", func() {
-			_, _ = Print("\nManifests")
-
-			cfg, layers, manifest, err := test.GetImageComponents(2)
-			So(err, ShouldBeNil)
-
-			content := []byte("this is a blob5")
-			digest := godigest.FromBytes(content)
-			So(digest, ShouldNotBeNil)
-			// check a non-existent manifest
-			resp, err := resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
-				SetBody(content).Head(baseURL + "/v2/unknown/manifests/test:1.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-
-			repoName := "repo7"
-			err = test.UploadImage(
-				test.Image{
-					Config:    cfg,
-					Layers:    layers,
-					Manifest:  manifest,
-					Reference: "test:1.0",
-				}, baseURL, repoName)
-			So(err, ShouldBeNil)
-
-			_, err = os.Stat(path.Join(dir, "repo7"))
-			So(err, ShouldBeNil)
-
-			content, err = json.Marshal(manifest)
-			So(err, ShouldBeNil)
-			digest = godigest.FromBytes(content)
-			So(digest, ShouldNotBeNil)
-			resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
-				SetBody(content).Put(baseURL + "/v2/repo7/manifests/test:1.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-			digestHdr := resp.Header().Get(constants.DistContentDigestKey)
-			So(digestHdr, ShouldNotBeEmpty)
-			So(digestHdr, ShouldEqual, digest.String())
-
-			resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
-				SetBody(content).Put(baseURL + "/v2/repo7/manifests/test:1.0.1")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-			digestHdr = resp.Header().Get(constants.DistContentDigestKey)
-			So(digestHdr, ShouldNotBeEmpty)
-			So(digestHdr, ShouldEqual, digest.String())
-
-			content, err = json.Marshal(manifest)
-			So(err, ShouldBeNil)
-
-			err = test.UploadImage(
-				test.Image{
-					Config:    cfg,
-					Layers:    layers,
-					Manifest:  manifest,
-					Reference: "test:1.0.1",
-				}, baseURL, repoName)
-			So(err, ShouldBeNil)
-
-			cfg, layers, manifest, err = test.GetImageComponents(1)
-			So(err, ShouldBeNil)
-
-			err = test.UploadImage(
-				test.Image{
-					Config:    cfg,
-					Layers:    layers,
-					Manifest:  manifest,
-					Reference: "test:2.0",
-				}, baseURL, repoName)
-			So(err, ShouldBeNil)
-
-			resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
-				SetBody(content).Put(baseURL + "/v2/repo7/manifests/test:2.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-			digestHdr = resp.Header().Get(constants.DistContentDigestKey)
-			So(digestHdr, ShouldNotBeEmpty)
-			So(digestHdr, ShouldEqual, digest.String())
-
-			// check/get by tag
-			resp, err = resty.R().Head(baseURL + "/v2/repo7/manifests/test:1.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
-			resp, err = resty.R().Get(baseURL + "/v2/repo7/manifests/test:1.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			So(resp.Body(), ShouldNotBeEmpty)
-			// check/get by reference
-			resp, err = resty.R().Head(baseURL + "/v2/repo7/manifests/" + digest.String())
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
-			resp, err = resty.R().Get(baseURL + "/v2/repo7/manifests/" + digest.String())
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			So(resp.Body(), ShouldNotBeEmpty)
+		err = os.Chmod(dir, 0o644)
+		if err != nil {
+			panic(err)
+		}
 
-			// delete manifest by tag should pass
-			resp, err = resty.R().Delete(baseURL + "/v2/repo7/manifests/test:1.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-			// delete manifest by digest (1.0 deleted but 1.0.1 has same reference)
-			resp, err = resty.R().Delete(baseURL + "/v2/repo7/manifests/" + digest.String())
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-			// delete manifest by digest
-			resp, err = resty.R().Delete(baseURL + "/v2/repo7/manifests/" + digest.String())
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-			// delete again should fail
-			resp, err = resty.R().Delete(baseURL + "/v2/repo7/manifests/" + digest.String())
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		err = os.Chmod(subDir, 0o644)
+		if err != nil {
+			panic(err)
+		}
 
-			// check/get by tag
-			resp, err = resty.R().Head(baseURL + "/v2/repo7/manifests/test:1.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-			resp, err = resty.R().Get(baseURL + "/v2/repo7/manifests/test:1.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-			So(resp.Body(), ShouldNotBeEmpty)
-			resp, err = resty.R().Head(baseURL + "/v2/repo7/manifests/test:2.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-			resp, err = resty.R().Get(baseURL + "/v2/repo7/manifests/test:2.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-			So(resp.Body(), ShouldNotBeEmpty)
-			// check/get by reference
-			resp, err = resty.R().Head(baseURL + "/v2/repo7/manifests/" + digest.String())
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-			resp, err = resty.R().Get(baseURL + "/v2/repo7/manifests/" + digest.String())
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-			So(resp.Body(), ShouldNotBeEmpty)
-		})
+		So(ctlr.Config.Storage.Dedupe, ShouldEqual, false)
 	})
 }
 
-func TestManifestImageIndex(t *testing.T) {
-	Convey("Make a new controller", t, func() {
+func TestImageSignatures(t *testing.T) {
+	Convey("Validate signatures", t, func() {
+		// start a new server
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
+
 		conf := config.New()
 		conf.HTTP.Port = port
 
 		dir := t.TempDir()
 		ctlr := makeController(conf, dir, "")
-
 		cm := test.NewControllerManager(ctlr)
+		// this blocks
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		rthdlr := api.NewRouteHandler(ctlr)
-
+		repoName := "signed-repo"
 		cfg, layers, manifest, err := test.GetImageComponents(2)
 		So(err, ShouldBeNil)
 
-		content := []byte("this is a blob1")
-		digest := godigest.FromBytes(content)
-		So(digest, ShouldNotBeNil)
-
-		// check a non-existent manifest
-		resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
-			SetBody(content).Head(baseURL + "/v2/unknown/manifests/test:1.0")
-		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-
-		repoName := "index"
 		err = test.UploadImage(
 			test.Image{
 				Config:    cfg,
 				Layers:    layers,
 				Manifest:  manifest,
-				Reference: "test:1.0",
+				Reference: "1.0",
 			}, baseURL, repoName)
 		So(err, ShouldBeNil)
 
-		_, err = os.Stat(path.Join(dir, "index"))
-		So(err, ShouldBeNil)
-
-		content, err = json.Marshal(manifest)
+		content, err := json.Marshal(manifest)
 		So(err, ShouldBeNil)
-		digest = godigest.FromBytes(content)
+		digest := godigest.FromBytes(content)
 		So(digest, ShouldNotBeNil)
-		m1content := content
-		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
-			SetBody(content).Put(baseURL + "/v2/index/manifests/test:1.0")
-		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-		digestHdr := resp.Header().Get(constants.DistContentDigestKey)
-		So(digestHdr, ShouldNotBeEmpty)
-		So(digestHdr, ShouldEqual, digest.String())
 
-		// create another manifest but upload using its sha256 reference
+		Convey("Validate cosign signatures", func() {
+			cwd, err := os.Getwd()
+			So(err, ShouldBeNil)
+			defer func() { _ = os.Chdir(cwd) }()
+			tdir := t.TempDir()
+			_ = os.Chdir(tdir)
 
-		// upload image config blob
-		resp, err = resty.R().Post(baseURL + "/v2/index/blobs/uploads/")
-		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		cfg, layers, manifest, err = test.GetImageComponents(1)
-		So(err, ShouldBeNil)
+			// generate a keypair
+			os.Setenv("COSIGN_PASSWORD", "")
+			err = generate.GenerateKeyPairCmd(context.TODO(), "", "cosign", nil)
+			So(err, ShouldBeNil)
 
-		err = test.UploadImage(
-			test.Image{
-				Config:   cfg,
-				Layers:   layers,
-				Manifest: manifest,
-			}, baseURL, repoName)
-		So(err, ShouldBeNil)
+			annotations := []string{"tag=1.0"}
 
-		content, err = json.Marshal(manifest)
-		So(err, ShouldBeNil)
-		digest = godigest.FromBytes(content)
-		So(digest, ShouldNotBeNil)
-		m2dgst := digest
-		m2size := len(content)
-		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
-			SetBody(content).Put(baseURL + fmt.Sprintf("/v2/index/manifests/%s", digest))
-		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-		digestHdr = resp.Header().Get(constants.DistContentDigestKey)
-		So(digestHdr, ShouldNotBeEmpty)
-		So(digestHdr, ShouldEqual, digest.String())
+			// sign the image
+			err = sign.SignCmd(&options.RootOptions{Verbose: true, Timeout: 1 * time.Minute},
+				options.KeyOpts{KeyRef: path.Join(tdir, "cosign.key"), PassFunc: generate.GetPass},
+				options.SignOptions{
+					Registry:          options.RegistryOptions{AllowInsecure: true},
+					AnnotationOptions: options.AnnotationOptions{Annotations: annotations},
+					Upload:            true,
+				},
+				[]string{fmt.Sprintf("localhost:%s/%s@%s", port, repoName, digest.String())})
+			So(err, ShouldBeNil)
 
-		Convey("Image index", func() {
-			cfg, layers, manifest, err = test.GetImageComponents(3)
+			// verify the image
+			aopts := &options.AnnotationOptions{Annotations: annotations}
+			amap, err := aopts.AnnotationsMap()
 			So(err, ShouldBeNil)
-			err = test.UploadImage(
-				test.Image{
-					Config:   cfg,
-					Layers:   layers,
-					Manifest: manifest,
-				}, baseURL, repoName)
+			vrfy := verify.VerifyCommand{
+				RegistryOptions: options.RegistryOptions{AllowInsecure: true},
+				CheckClaims:     true,
+				KeyRef:          path.Join(tdir, "cosign.pub"),
+				Annotations:     amap,
+				IgnoreTlog:      true,
+			}
+			err = vrfy.Exec(context.TODO(), []string{fmt.Sprintf("localhost:%s/%s:%s", port, repoName, "1.0")})
 			So(err, ShouldBeNil)
 
-			content, err = json.Marshal(manifest)
+			// verify the image with incorrect tag
+			aopts = &options.AnnotationOptions{Annotations: []string{"tag=2.0"}}
+			amap, err = aopts.AnnotationsMap()
 			So(err, ShouldBeNil)
-			digest = godigest.FromBytes(content)
-			So(digest, ShouldNotBeNil)
-			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
-				SetBody(content).Put(baseURL + fmt.Sprintf("/v2/index/manifests/%s", digest.String()))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-			digestHdr := resp.Header().Get(constants.DistContentDigestKey)
-			So(digestHdr, ShouldNotBeEmpty)
-			So(digestHdr, ShouldEqual, digest.String())
-
-			var index ispec.Index
-			index.SchemaVersion = 2
-			index.Manifests = []ispec.Descriptor{
-				{
-					MediaType: ispec.MediaTypeImageManifest,
-					Digest:    digest,
-					Size:      int64(len(content)),
-				},
-				{
-					MediaType: ispec.MediaTypeImageManifest,
-					Digest:    m2dgst,
-					Size:      int64(m2size),
-				},
+			vrfy = verify.VerifyCommand{
+				RegistryOptions: options.RegistryOptions{AllowInsecure: true},
+				CheckClaims:     true,
+				KeyRef:          path.Join(tdir, "cosign.pub"),
+				Annotations:     amap,
+				IgnoreTlog:      true,
 			}
+			err = vrfy.Exec(context.TODO(), []string{fmt.Sprintf("localhost:%s/%s:%s", port, repoName, "1.0")})
+			So(err, ShouldNotBeNil)
 
-			content, err = json.Marshal(index)
-			So(err, ShouldBeNil)
-			digest = godigest.FromBytes(content)
-			So(digest, ShouldNotBeNil)
-			index1dgst := digest
-			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-				SetBody(content).Put(baseURL + "/v2/index/manifests/test:index1")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-			digestHdr = resp.Header().Get(constants.DistContentDigestKey)
-			So(digestHdr, ShouldNotBeEmpty)
-			So(digestHdr, ShouldEqual, digest.String())
-			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-				Get(baseURL + "/v2/index/manifests/test:index1")
+			// verify the image with incorrect key
+			aopts = &options.AnnotationOptions{Annotations: []string{"tag=1.0"}}
+			amap, err = aopts.AnnotationsMap()
 			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			So(resp.Body(), ShouldNotBeEmpty)
-			So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
+			vrfy = verify.VerifyCommand{
+				CheckClaims:     true,
+				RegistryOptions: options.RegistryOptions{AllowInsecure: true},
+				KeyRef:          path.Join(tdir, "cosign.key"),
+				Annotations:     amap,
+				IgnoreTlog:      true,
+			}
+			err = vrfy.Exec(context.TODO(), []string{fmt.Sprintf("localhost:%s/%s:%s", port, repoName, "1.0")})
+			So(err, ShouldNotBeNil)
 
-			cfg, layers, manifest, err = test.GetImageComponents(4)
-			So(err, ShouldBeNil)
-			err = test.UploadImage(
-				test.Image{
-					Config:   cfg,
-					Layers:   layers,
-					Manifest: manifest,
-				}, baseURL, repoName)
+			// generate another keypair
+			err = os.Remove(path.Join(tdir, "cosign.pub"))
 			So(err, ShouldBeNil)
-			content, err = json.Marshal(manifest)
+			err = os.Remove(path.Join(tdir, "cosign.key"))
 			So(err, ShouldBeNil)
-			digest = godigest.FromBytes(content)
-			So(digest, ShouldNotBeNil)
-			m4dgst := digest
-			m4size := len(content)
-			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
-				SetBody(content).Put(baseURL + fmt.Sprintf("/v2/index/manifests/%s", digest.String()))
+
+			os.Setenv("COSIGN_PASSWORD", "")
+			err = generate.GenerateKeyPairCmd(context.TODO(), "", "cosign", nil)
 			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-			digestHdr = resp.Header().Get(constants.DistContentDigestKey)
-			So(digestHdr, ShouldNotBeEmpty)
-			So(digestHdr, ShouldEqual, digest.String())
 
-			index.SchemaVersion = 2
-			index.Manifests = []ispec.Descriptor{
-				{
-					MediaType: ispec.MediaTypeImageManifest,
-					Digest:    digest,
-					Size:      int64(len(content)),
-				},
-				{
-					MediaType: ispec.MediaTypeImageManifest,
-					Digest:    m2dgst,
-					Size:      int64(m2size),
-				},
+			// verify the image with incorrect key
+			aopts = &options.AnnotationOptions{Annotations: []string{"tag=1.0"}}
+			amap, err = aopts.AnnotationsMap()
+			So(err, ShouldBeNil)
+			vrfy = verify.VerifyCommand{
+				CheckClaims:     true,
+				RegistryOptions: options.RegistryOptions{AllowInsecure: true},
+				KeyRef:          path.Join(tdir, "cosign.pub"),
+				Annotations:     amap,
+				IgnoreTlog:      true,
 			}
+			err = vrfy.Exec(context.TODO(), []string{fmt.Sprintf("localhost:%s/%s:%s", port, repoName, "1.0")})
+			So(err, ShouldNotBeNil)
+		})
 
-			content, err = json.Marshal(index)
+		Convey("Validate notation signatures", func() {
+			cwd, err := os.Getwd()
 			So(err, ShouldBeNil)
-			digest = godigest.FromBytes(content)
-			So(digest, ShouldNotBeNil)
-			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-				SetBody(content).Put(baseURL + "/v2/index/manifests/test:index2")
+			defer func() { _ = os.Chdir(cwd) }()
+			tdir := t.TempDir()
+			_ = os.Chdir(tdir)
+
+			test.NotationPathLock.Lock()
+			defer test.NotationPathLock.Unlock()
+
+			test.LoadNotationPath(tdir)
+
+			err = test.GenerateNotationCerts(tdir, "good")
 			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-			digestHdr = resp.Header().Get(constants.DistContentDigestKey)
-			So(digestHdr, ShouldNotBeEmpty)
-			So(digestHdr, ShouldEqual, digest.String())
-			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-				Get(baseURL + "/v2/index/manifests/test:index2")
+
+			err = test.GenerateNotationCerts(tdir, "bad")
 			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			So(resp.Body(), ShouldNotBeEmpty)
-			So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
 
-			Convey("List tags", func() {
-				request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
-				request = mux.SetURLVars(request, map[string]string{"name": "index"})
-				response := httptest.NewRecorder()
+			image := fmt.Sprintf("localhost:%s/%s:%s", port, repoName, "1.0")
+			err = test.SignWithNotation("good", image, tdir)
+			So(err, ShouldBeNil)
 
-				rthdlr.ListTags(response, request)
+			err = test.VerifyWithNotation(image, tdir)
+			So(err, ShouldBeNil)
 
-				resp := response.Result()
-				defer resp.Body.Close()
-				So(resp, ShouldNotBeNil)
-				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			// check list
+			sigs, err := test.ListNotarySignatures(image, tdir)
+			So(len(sigs), ShouldEqual, 1)
+			So(err, ShouldBeNil)
 
-				var tags api.ImageTags
-				err = json.NewDecoder(resp.Body).Decode(&tags)
-				So(err, ShouldBeNil)
-				So(len(tags.Tags), ShouldEqual, 3)
-				So(tags.Tags, ShouldContain, "test:1.0")
-				So(tags.Tags, ShouldContain, "test:index1")
-				So(tags.Tags, ShouldContain, "test:index2")
-			})
+			// check unsupported manifest media type
+			resp, err := resty.R().SetHeader("Content-Type", "application/vnd.unsupported.image.manifest.v1+json").
+				SetBody(content).Put(baseURL + fmt.Sprintf("/v2/%s/manifests/1.0", repoName))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusUnsupportedMediaType)
 
-			Convey("Another index with same manifest", func() {
-				var index ispec.Index
-				index.SchemaVersion = 2
-				index.Manifests = []ispec.Descriptor{
-					{
-						MediaType: ispec.MediaTypeImageManifest,
-						Digest:    m4dgst,
-						Size:      int64(m4size),
-					},
-				}
+			// check invalid content with artifact media type
+			resp, err = resty.R().SetHeader("Content-Type", artifactspec.MediaTypeArtifactManifest).
+				SetBody([]byte("bogus")).Put(baseURL + fmt.Sprintf("/v2/%s/manifests/1.0", repoName))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
 
-				content, err = json.Marshal(index)
+			Convey("Validate corrupted signature", func() {
+				// verify with corrupted signature
+				resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
+					fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
 				So(err, ShouldBeNil)
-				digest = godigest.FromBytes(content)
-				So(digest, ShouldNotBeNil)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					SetBody(content).Put(baseURL + "/v2/index/manifests/test:index3")
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+				var refs ispec.Index
+				err = json.Unmarshal(resp.Body(), &refs)
 				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-				digestHdr = resp.Header().Get(constants.DistContentDigestKey)
-				So(digestHdr, ShouldNotBeEmpty)
-				So(digestHdr, ShouldEqual, digest.String())
-			})
-
-			Convey("Another index using digest with same manifest", func() {
-				var index ispec.Index
-				index.SchemaVersion = 2
-				index.Manifests = []ispec.Descriptor{
-					{
-						MediaType: ispec.MediaTypeImageManifest,
-						Digest:    m4dgst,
-						Size:      int64(m4size),
-					},
-				}
-
-				content, err = json.Marshal(index)
+				So(len(refs.Manifests), ShouldEqual, 1)
+				err = os.WriteFile(path.Join(dir, repoName, "blobs",
+					strings.ReplaceAll(refs.Manifests[0].Digest.String(), ":", "/")), []byte("corrupt"), 0o600)
 				So(err, ShouldBeNil)
-				digest = godigest.FromBytes(content)
-				So(digest, ShouldNotBeNil)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					SetBody(content).Put(baseURL + fmt.Sprintf("/v2/index/manifests/%s", digest))
+				resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
+					fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
 				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-				digestHdr = resp.Header().Get(constants.DistContentDigestKey)
-				So(digestHdr, ShouldNotBeEmpty)
-				So(digestHdr, ShouldEqual, digest.String())
+				So(resp.StatusCode(), ShouldEqual, http.StatusInternalServerError)
+
+				err = test.VerifyWithNotation(image, tdir)
+				So(err, ShouldNotBeNil)
 			})
 
-			Convey("Deleting an image index", func() {
-				// delete manifest by tag should pass
-				resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/test:index3")
+			Convey("Validate deleted signature", func() {
+				// verify with corrupted signature
+				resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
+					fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
 				So(err, ShouldBeNil)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					Get(baseURL + "/v2/index/manifests/test:index3")
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+				var refs ispec.Index
+				err = json.Unmarshal(resp.Body(), &refs)
 				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-				So(resp.Body(), ShouldNotBeEmpty)
-				resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/test:index1")
+				So(len(refs.Manifests), ShouldEqual, 1)
+				err = os.Remove(path.Join(dir, repoName, "blobs",
+					strings.ReplaceAll(refs.Manifests[0].Digest.String(), ":", "/")))
 				So(err, ShouldBeNil)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					Get(baseURL + "/v2/index/manifests/test:index1")
+				resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
+					fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
 				So(err, ShouldBeNil)
 				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-				So(resp.Body(), ShouldNotBeEmpty)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					Get(baseURL + "/v2/index/manifests/test:index2")
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-				So(resp.Body(), ShouldNotBeEmpty)
-				So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
-			})
-
-			Convey("Deleting an image index by digest", func() {
-				// delete manifest by tag should pass
-				resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/test:index3")
-				So(err, ShouldBeNil)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					Get(baseURL + "/v2/index/manifests/test:index3")
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-				So(resp.Body(), ShouldNotBeEmpty)
-				resp, err = resty.R().Delete(baseURL + fmt.Sprintf("/v2/index/manifests/%s", index1dgst))
-				So(err, ShouldBeNil)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					Get(baseURL + "/v2/index/manifests/test:index1")
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-				So(resp.Body(), ShouldNotBeEmpty)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					Get(baseURL + "/v2/index/manifests/test:index2")
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-				So(resp.Body(), ShouldNotBeEmpty)
-				So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
-			})
-
-			Convey("Update an index tag with different manifest", func() {
-				cfg, layers, manifest, err = test.GetImageComponents(5)
-				So(err, ShouldBeNil)
-				err = test.UploadImage(
-					test.Image{
-						Config:   cfg,
-						Layers:   layers,
-						Manifest: manifest,
-					}, baseURL, repoName)
-				So(err, ShouldBeNil)
-				content, err = json.Marshal(manifest)
-				So(err, ShouldBeNil)
-				digest = godigest.FromBytes(content)
-				So(digest, ShouldNotBeNil)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
-					SetBody(content).Put(baseURL + fmt.Sprintf("/v2/index/manifests/%s", digest))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-				digestHdr = resp.Header().Get(constants.DistContentDigestKey)
-				So(digestHdr, ShouldNotBeEmpty)
-				So(digestHdr, ShouldEqual, digest.String())
-
-				index.SchemaVersion = 2
-				index.Manifests = []ispec.Descriptor{
-					{
-						MediaType: ispec.MediaTypeImageManifest,
-						Digest:    digest,
-						Size:      int64(len(content)),
-					},
-				}
-
-				content, err = json.Marshal(index)
-				So(err, ShouldBeNil)
-				digest = godigest.FromBytes(content)
-				So(digest, ShouldNotBeNil)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					SetBody(content).Put(baseURL + "/v2/index/manifests/test:index1")
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-				digestHdr = resp.Header().Get(constants.DistContentDigestKey)
-				So(digestHdr, ShouldNotBeEmpty)
-				So(digestHdr, ShouldEqual, digest.String())
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					Get(baseURL + "/v2/index/manifests/test:index1")
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-				So(resp.Body(), ShouldNotBeEmpty)
-				So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
 
-				// delete manifest by tag should pass
-				resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/test:index1")
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-					Get(baseURL + "/v2/index/manifests/test:index1")
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-				So(resp.Body(), ShouldNotBeEmpty)
+				err = test.VerifyWithNotation(image, tdir)
+				So(err, ShouldNotBeNil)
 			})
+		})
 
-			Convey("Negative test cases", func() {
-				Convey("Delete index", func() {
-					err = os.Remove(path.Join(dir, "index", "blobs", index1dgst.Algorithm().String(), index1dgst.Encoded()))
-					So(err, ShouldBeNil)
-					resp, err = resty.R().Delete(baseURL + fmt.Sprintf("/v2/index/manifests/%s", index1dgst))
-					So(err, ShouldBeNil)
-					resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-						Get(baseURL + "/v2/index/manifests/test:index1")
-					So(err, ShouldBeNil)
-					So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-					So(resp.Body(), ShouldNotBeEmpty)
-				})
+		Convey("GetOrasReferrers", func() {
+			// cover error paths
+			resp, err := resty.R().Get(
+				fmt.Sprintf("%s/oras/artifacts/v1/%s/manifests/%s/referrers", baseURL, "badRepo", "badDigest"))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
 
-				Convey("Corrupt index", func() {
-					err = os.WriteFile(path.Join(dir, "index", "blobs", index1dgst.Algorithm().String(), index1dgst.Encoded()),
-						[]byte("deadbeef"), storageConstants.DefaultFilePerms)
-					So(err, ShouldBeNil)
-					resp, err = resty.R().Delete(baseURL + fmt.Sprintf("/v2/index/manifests/%s", index1dgst))
-					So(err, ShouldBeNil)
-					resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-						Get(baseURL + "/v2/index/manifests/test:index1")
-					So(err, ShouldBeNil)
-					So(resp.StatusCode(), ShouldEqual, http.StatusInternalServerError)
-					So(resp.Body(), ShouldBeEmpty)
-				})
+			resp, err = resty.R().Get(
+				fmt.Sprintf("%s/oras/artifacts/v1/%s/manifests/%s/referrers", baseURL, repoName, "badDigest"))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
 
-				Convey("Change media-type", func() {
-					// previously a manifest, try writing an image index
-					var index ispec.Index
-					index.SchemaVersion = 2
-					index.Manifests = []ispec.Descriptor{
-						{
-							MediaType: ispec.MediaTypeImageIndex,
-							Digest:    m4dgst,
-							Size:      int64(m4size),
-						},
-					}
+			resp, err = resty.R().Get(
+				fmt.Sprintf("%s/oras/artifacts/v1/%s/manifests/%s/referrers", baseURL, repoName, digest.String()))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
 
-					content, err = json.Marshal(index)
-					So(err, ShouldBeNil)
-					digest = godigest.FromBytes(content)
-					So(digest, ShouldNotBeNil)
-					resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-						SetBody(content).Put(baseURL + "/v2/index/manifests/test:1.0")
-					So(err, ShouldBeNil)
-					So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+			resp, err = resty.R().SetQueryParam("artifactType", "badArtifact").Get(
+				fmt.Sprintf("%s/oras/artifacts/v1/%s/manifests/%s/referrers", baseURL, repoName, digest.String()))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
 
-					// previously an image index, try writing a manifest
-					resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
-						SetBody(m1content).Put(baseURL + "/v2/index/manifests/test:index1")
-					So(err, ShouldBeNil)
-					So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
-				})
-			})
+			resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
+				fmt.Sprintf("%s/oras/artifacts/v1/%s/manifests/%s/referrers", baseURL, "badRepo", digest.String()))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
 		})
 	})
 }
 
-func TestManifestCollision(t *testing.T) {
-	Convey("Make a new controller", t, func() {
+func TestArtifactReferences(t *testing.T) {
+	Convey("Validate Artifact References", t, func() {
+		// start a new server
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
+
 		conf := config.New()
 		conf.HTTP.Port = port
 
 		dir := t.TempDir()
 		ctlr := makeController(conf, dir, "")
-
-		conf.HTTP.AccessControl = &config.AccessControlConfig{
-			Repositories: config.Repositories{
-				AuthorizationAllRepos: config.PolicyGroup{
-					AnonymousPolicy: []string{api.Read, api.Create, api.Delete, api.DetectManifestCollision},
-				},
-			},
-		}
-
 		cm := test.NewControllerManager(ctlr)
-		cm.StartAndWait(port)
+		// this blocks
+		cm.StartServer()
+		time.Sleep(1000 * time.Millisecond)
 		defer cm.StopServer()
 
+		repoName := "artifact-repo"
+		content := []byte("this is a blob")
+		digest := godigest.FromBytes(content)
+		So(digest, ShouldNotBeNil)
+
 		cfg, layers, manifest, err := test.GetImageComponents(2)
 		So(err, ShouldBeNil)
 
@@ -5619,1629 +5945,4406 @@ func TestManifestCollision(t *testing.T) {
 				Config:    cfg,
 				Layers:    layers,
 				Manifest:  manifest,
-				Reference: "test:1.0",
-			}, baseURL, "index")
-		So(err, ShouldBeNil)
-
-		_, err = os.Stat(path.Join(dir, "index"))
-		So(err, ShouldBeNil)
-
-		content := []byte("this is a blob1")
-		digest := godigest.FromBytes(content)
-		So(digest, ShouldNotBeNil)
-
-		// check a non-existent manifest
-		resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
-			SetBody(content).Head(baseURL + "/v2/unknown/manifests/test:1.0")
+				Reference: "1.0",
+			}, baseURL, repoName)
 		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
 
 		content, err = json.Marshal(manifest)
 		So(err, ShouldBeNil)
 		digest = godigest.FromBytes(content)
 		So(digest, ShouldNotBeNil)
 
-		err = test.UploadImage(
-			test.Image{
-				Config:    cfg,
-				Layers:    layers,
-				Manifest:  manifest,
-				Reference: "test:2.0",
-			}, baseURL, "index")
-		So(err, ShouldBeNil)
+		artifactType := "application/vnd.example.icecream.v1"
+
+		Convey("Validate Image Manifest Reference", func() {
+			resp, err := resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			var referrers ispec.Index
+			err = json.Unmarshal(resp.Body(), &referrers)
+			So(err, ShouldBeNil)
+			So(referrers.Manifests, ShouldBeEmpty)
+
+			// now upload a reference
+
+			// upload image config blob
+			resp, err = resty.R().Post(baseURL + fmt.Sprintf("/v2/%s/blobs/uploads/", repoName))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+			loc := test.Location(baseURL, resp)
+			cblob, cdigest := test.GetEmptyImageConfig()
+
+			resp, err = resty.R().
+				SetContentLength(true).
+				SetHeader("Content-Length", fmt.Sprintf("%d", len(cblob))).
+				SetHeader("Content-Type", "application/octet-stream").
+				SetQueryParam("digest", cdigest.String()).
+				SetBody(cblob).
+				Put(loc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+			// create a manifest
+			manifest := ispec.Manifest{
+				Config: ispec.Descriptor{
+					MediaType: artifactType,
+					Digest:    cdigest,
+					Size:      int64(len(cblob)),
+				},
+				Layers: []ispec.Descriptor{
+					{
+						MediaType: "application/vnd.oci.image.layer.v1.tar",
+						Digest:    digest,
+						Size:      int64(len(content)),
+					},
+				},
+				Subject: &ispec.Descriptor{
+					MediaType: ispec.MediaTypeImageManifest,
+					Digest:    digest,
+					Size:      int64(len(content)),
+				},
+				Annotations: map[string]string{
+					"key": "val",
+				},
+			}
+			manifest.SchemaVersion = 2
+
+			Convey("Using invalid content", func() {
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+					SetBody([]byte("invalid data")).Put(baseURL + fmt.Sprintf("/v2/%s/manifests/1.0", repoName))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+				// unknown repo will return status not found
+				resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", "unknown", digest.String()))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+				resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+				resp, err = resty.R().SetQueryParams(map[string]string{"artifactType": artifactType}).
+					Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			})
+			Convey("Using valid content", func() {
+				content, err = json.Marshal(manifest)
+				So(err, ShouldBeNil)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+					SetBody(content).Put(baseURL + fmt.Sprintf("/v2/%s/manifests/1.0", repoName))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+				resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+				resp, err = resty.R().SetQueryParams(map[string]string{"artifact": "invalid"}).
+					Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+				resp, err = resty.R().SetQueryParams(map[string]string{"artifactType": "invalid"}).
+					Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+				resp, err = resty.R().SetQueryParams(map[string]string{"artifactType": artifactType}).
+					Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest.String()))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+				So(resp.Header().Get("Content-Type"), ShouldEqual, ispec.MediaTypeImageIndex)
+				So(resp.Header().Get("OCI-Filters-Applied"), ShouldEqual, artifactType)
+
+				resp, err = resty.R().SetQueryParams(map[string]string{"artifactType": artifactType +
+					",otherArtType"}).Get(baseURL + fmt.Sprintf("/v2/%s/referrers/%s", repoName,
+					digest.String()))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+				So(resp.Header().Get("Content-Type"), ShouldEqual, ispec.MediaTypeImageIndex)
+				So(resp.Header().Get("OCI-Filters-Applied"), ShouldEqual, artifactType+",otherArtType")
+			})
+		})
+	})
+}
+
+//nolint:dupl // duplicated test code
+func TestRouteFailures(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := makeController(conf, t.TempDir(), "")
+		ctlr.Config.Storage.Commit = true
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		rthdlr := api.NewRouteHandler(ctlr)
+
+		// NOTE: the url or method itself doesn't matter below since we are calling the handlers directly,
+		// so path routing is bypassed
+
+		Convey("List tags", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.ListTags(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.ListTags(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			qparm := request.URL.Query()
+			qparm.Add("n", "a")
+			request.URL.RawQuery = qparm.Encode()
+			response = httptest.NewRecorder()
+
+			rthdlr.ListTags(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			qparm = request.URL.Query()
+			qparm.Add("n", "abc")
+			request.URL.RawQuery = qparm.Encode()
+			response = httptest.NewRecorder()
+
+			rthdlr.ListTags(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			qparm = request.URL.Query()
+			qparm.Add("n", "a")
+			qparm.Add("n", "abc")
+			request.URL.RawQuery = qparm.Encode()
+			response = httptest.NewRecorder()
+
+			rthdlr.ListTags(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			qparm = request.URL.Query()
+			qparm.Add("n", "0")
+			request.URL.RawQuery = qparm.Encode()
+			response = httptest.NewRecorder()
+
+			rthdlr.ListTags(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			qparm = request.URL.Query()
+			qparm.Add("n", "1")
+			qparm.Add("last", "")
+			request.URL.RawQuery = qparm.Encode()
+			response = httptest.NewRecorder()
+
+			rthdlr.ListTags(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			qparm = request.URL.Query()
+			qparm.Add("n", "1")
+			qparm.Add("last", "a")
+			request.URL.RawQuery = qparm.Encode()
+			response = httptest.NewRecorder()
+
+			rthdlr.ListTags(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			qparm = request.URL.Query()
+			qparm.Add("n", "1")
+			qparm.Add("last", "a")
+			qparm.Add("last", "abc")
+			request.URL.RawQuery = qparm.Encode()
+			response = httptest.NewRecorder()
+
+			rthdlr.ListTags(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Check manifest", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.CheckManifest(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.CheckManifest(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo", "reference": ""})
+			response = httptest.NewRecorder()
+
+			rthdlr.CheckManifest(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Get manifest", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.GetManifest(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.GetManifest(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo", "reference": ""})
+			response = httptest.NewRecorder()
+
+			rthdlr.GetManifest(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Update manifest", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.UpdateManifest(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.UpdateManifest(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo", "reference": ""})
+			response = httptest.NewRecorder()
+
+			rthdlr.UpdateManifest(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Delete manifest", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.DeleteManifest(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.DeleteManifest(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo", "reference": ""})
+			response = httptest.NewRecorder()
+
+			rthdlr.DeleteManifest(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Check blob", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.CheckBlob(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.CheckBlob(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodHead, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo", "digest": ""})
+			response = httptest.NewRecorder()
+
+			rthdlr.CheckBlob(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Get blob", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.GetBlob(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.GetBlob(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo", "digest": ""})
+			response = httptest.NewRecorder()
+
+			rthdlr.GetBlob(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Delete blob", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.DeleteBlob(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.DeleteBlob(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo", "digest": ""})
+			response = httptest.NewRecorder()
+
+			rthdlr.DeleteBlob(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Create blob upload", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPost, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.CreateBlobUpload(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPost, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			qparm := request.URL.Query()
+			qparm.Add("mount", "a")
+			qparm.Add("mount", "abc")
+			request.URL.RawQuery = qparm.Encode()
+			response = httptest.NewRecorder()
+
+			rthdlr.CreateBlobUpload(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPost, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			qparm = request.URL.Query()
+			qparm.Add("mount", "a")
+			request.URL.RawQuery = qparm.Encode()
+			response = httptest.NewRecorder()
+
+			rthdlr.CreateBlobUpload(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusAccepted)
+		})
+
+		Convey("Get blob upload", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.GetBlobUpload(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.GetBlobUpload(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Patch blob upload", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPatch, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.PatchBlobUpload(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.PatchBlobUpload(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Update blob upload", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.UpdateBlobUpload(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.UpdateBlobUpload(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo", "session_id": "bar"})
+			response = httptest.NewRecorder()
+
+			rthdlr.UpdateBlobUpload(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo", "session_id": "bar"})
+			qparm := request.URL.Query()
+			qparm.Add("digest", "a")
+			qparm.Add("digest", "abc")
+			request.URL.RawQuery = qparm.Encode()
+			response = httptest.NewRecorder()
+
+			rthdlr.UpdateBlobUpload(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("Delete blob upload", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.DeleteBlobUpload(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodDelete, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.DeleteBlobUpload(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Get referrers", func() {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{})
+			response := httptest.NewRecorder()
+
+			rthdlr.GetOrasReferrers(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+
+			request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "foo"})
+			response = httptest.NewRecorder()
+
+			rthdlr.GetOrasReferrers(response, request)
+
+			resp = response.Result()
+			defer resp.Body.Close()
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+func TestStorageCommit(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "")
+		ctlr.Config.Storage.Commit = true
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		Convey("Manifests", func() {
+			_, _ = Print("\nManifests")
+
+			cfg, layers, manifest, err := test.GetImageComponents(2)
+			So(err, ShouldBeNil)
+
+			content := []byte("this is a blob5")
+			digest := godigest.FromBytes(content)
+			So(digest, ShouldNotBeNil)
+			// check a non-existent manifest
+			resp, err := resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
+				SetBody(content).Head(baseURL + "/v2/unknown/manifests/test:1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+			repoName := "repo7"
+			err = test.UploadImage(
+				test.Image{
+					Config:    cfg,
+					Layers:    layers,
+					Manifest:  manifest,
+					Reference: "test:1.0",
+				}, baseURL, repoName)
+			So(err, ShouldBeNil)
+
+			_, err = os.Stat(path.Join(dir, "repo7"))
+			So(err, ShouldBeNil)
+
+			content, err = json.Marshal(manifest)
+			So(err, ShouldBeNil)
+			digest = godigest.FromBytes(content)
+			So(digest, ShouldNotBeNil)
+			resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
+				SetBody(content).Put(baseURL + "/v2/repo7/manifests/test:1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+			digestHdr := resp.Header().Get(constants.DistContentDigestKey)
+			So(digestHdr, ShouldNotBeEmpty)
+			So(digestHdr, ShouldEqual, digest.String())
+
+			resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
+				SetBody(content).Put(baseURL + "/v2/repo7/manifests/test:1.0.1")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+			digestHdr = resp.Header().Get(constants.DistContentDigestKey)
+			So(digestHdr, ShouldNotBeEmpty)
+			So(digestHdr, ShouldEqual, digest.String())
+
+			content, err = json.Marshal(manifest)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImage(
+				test.Image{
+					Config:    cfg,
+					Layers:    layers,
+					Manifest:  manifest,
+					Reference: "test:1.0.1",
+				}, baseURL, repoName)
+			So(err, ShouldBeNil)
+
+			cfg, layers, manifest, err = test.GetImageComponents(1)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImage(
+				test.Image{
+					Config:    cfg,
+					Layers:    layers,
+					Manifest:  manifest,
+					Reference: "test:2.0",
+				}, baseURL, repoName)
+			So(err, ShouldBeNil)
+
+			resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
+				SetBody(content).Put(baseURL + "/v2/repo7/manifests/test:2.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+			digestHdr = resp.Header().Get(constants.DistContentDigestKey)
+			So(digestHdr, ShouldNotBeEmpty)
+			So(digestHdr, ShouldEqual, digest.String())
+
+			// check/get by tag
+			resp, err = resty.R().Head(baseURL + "/v2/repo7/manifests/test:1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
+			resp, err = resty.R().Get(baseURL + "/v2/repo7/manifests/test:1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Body(), ShouldNotBeEmpty)
+			// check/get by reference
+			resp, err = resty.R().Head(baseURL + "/v2/repo7/manifests/" + digest.String())
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
+			resp, err = resty.R().Get(baseURL + "/v2/repo7/manifests/" + digest.String())
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Body(), ShouldNotBeEmpty)
+
+			// delete manifest by tag should pass
+			resp, err = resty.R().Delete(baseURL + "/v2/repo7/manifests/test:1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+			// delete manifest by digest (1.0 deleted but 1.0.1 has same reference)
+			resp, err = resty.R().Delete(baseURL + "/v2/repo7/manifests/" + digest.String())
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+			// delete manifest by digest
+			resp, err = resty.R().Delete(baseURL + "/v2/repo7/manifests/" + digest.String())
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+			// delete again should fail
+			resp, err = resty.R().Delete(baseURL + "/v2/repo7/manifests/" + digest.String())
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+			// check/get by tag
+			resp, err = resty.R().Head(baseURL + "/v2/repo7/manifests/test:1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+			resp, err = resty.R().Get(baseURL + "/v2/repo7/manifests/test:1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+			So(resp.Body(), ShouldNotBeEmpty)
+			resp, err = resty.R().Head(baseURL + "/v2/repo7/manifests/test:2.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+			resp, err = resty.R().Get(baseURL + "/v2/repo7/manifests/test:2.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+			So(resp.Body(), ShouldNotBeEmpty)
+			// check/get by reference
+			resp, err = resty.R().Head(baseURL + "/v2/repo7/manifests/" + digest.String())
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+			resp, err = resty.R().Get(baseURL + "/v2/repo7/manifests/" + digest.String())
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+			So(resp.Body(), ShouldNotBeEmpty)
+		})
+	})
+}
+
+func TestManifestImageIndex(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		rthdlr := api.NewRouteHandler(ctlr)
+
+		cfg, layers, manifest, err := test.GetImageComponents(2)
+		So(err, ShouldBeNil)
+
+		content := []byte("this is a blob1")
+		digest := godigest.FromBytes(content)
+		So(digest, ShouldNotBeNil)
+
+		// check a non-existent manifest
+		resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+			SetBody(content).Head(baseURL + "/v2/unknown/manifests/test:1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		repoName := "index"
+		err = test.UploadImage(
+			test.Image{
+				Config:    cfg,
+				Layers:    layers,
+				Manifest:  manifest,
+				Reference: "test:1.0",
+			}, baseURL, repoName)
+		So(err, ShouldBeNil)
+
+		_, err = os.Stat(path.Join(dir, "index"))
+		So(err, ShouldBeNil)
+
+		content, err = json.Marshal(manifest)
+		So(err, ShouldBeNil)
+		digest = godigest.FromBytes(content)
+		So(digest, ShouldNotBeNil)
+		m1content := content
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+			SetBody(content).Put(baseURL + "/v2/index/manifests/test:1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		digestHdr := resp.Header().Get(constants.DistContentDigestKey)
+		So(digestHdr, ShouldNotBeEmpty)
+		So(digestHdr, ShouldEqual, digest.String())
+
+		// create another manifest but upload using its sha256 reference
+
+		// upload image config blob
+		resp, err = resty.R().Post(baseURL + "/v2/index/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		cfg, layers, manifest, err = test.GetImageComponents(1)
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(
+			test.Image{
+				Config:   cfg,
+				Layers:   layers,
+				Manifest: manifest,
+			}, baseURL, repoName)
+		So(err, ShouldBeNil)
+
+		content, err = json.Marshal(manifest)
+		So(err, ShouldBeNil)
+		digest = godigest.FromBytes(content)
+		So(digest, ShouldNotBeNil)
+		m2dgst := digest
+		m2size := len(content)
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+			SetBody(content).Put(baseURL + fmt.Sprintf("/v2/index/manifests/%s", digest))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		digestHdr = resp.Header().Get(constants.DistContentDigestKey)
+		So(digestHdr, ShouldNotBeEmpty)
+		So(digestHdr, ShouldEqual, digest.String())
+
+		Convey("Image index", func() {
+			cfg, layers, manifest, err = test.GetImageComponents(3)
+			So(err, ShouldBeNil)
+			err = test.UploadImage(
+				test.Image{
+					Config:   cfg,
+					Layers:   layers,
+					Manifest: manifest,
+				}, baseURL, repoName)
+			So(err, ShouldBeNil)
+
+			content, err = json.Marshal(manifest)
+			So(err, ShouldBeNil)
+			digest = godigest.FromBytes(content)
+			So(digest, ShouldNotBeNil)
+			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+				SetBody(content).Put(baseURL + fmt.Sprintf("/v2/index/manifests/%s", digest.String()))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+			digestHdr := resp.Header().Get(constants.DistContentDigestKey)
+			So(digestHdr, ShouldNotBeEmpty)
+			So(digestHdr, ShouldEqual, digest.String())
+
+			var index ispec.Index
+			index.SchemaVersion = 2
+			index.Manifests = []ispec.Descriptor{
+				{
+					MediaType: ispec.MediaTypeImageManifest,
+					Digest:    digest,
+					Size:      int64(len(content)),
+				},
+				{
+					MediaType: ispec.MediaTypeImageManifest,
+					Digest:    m2dgst,
+					Size:      int64(m2size),
+				},
+			}
+
+			content, err = json.Marshal(index)
+			So(err, ShouldBeNil)
+			digest = godigest.FromBytes(content)
+			So(digest, ShouldNotBeNil)
+			index1dgst := digest
+			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+				SetBody(content).Put(baseURL + "/v2/index/manifests/test:index1")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+			digestHdr = resp.Header().Get(constants.DistContentDigestKey)
+			So(digestHdr, ShouldNotBeEmpty)
+			So(digestHdr, ShouldEqual, digest.String())
+			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+				Get(baseURL + "/v2/index/manifests/test:index1")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Body(), ShouldNotBeEmpty)
+			So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
+
+			cfg, layers, manifest, err = test.GetImageComponents(4)
+			So(err, ShouldBeNil)
+			err = test.UploadImage(
+				test.Image{
+					Config:   cfg,
+					Layers:   layers,
+					Manifest: manifest,
+				}, baseURL, repoName)
+			So(err, ShouldBeNil)
+			content, err = json.Marshal(manifest)
+			So(err, ShouldBeNil)
+			digest = godigest.FromBytes(content)
+			So(digest, ShouldNotBeNil)
+			m4dgst := digest
+			m4size := len(content)
+			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+				SetBody(content).Put(baseURL + fmt.Sprintf("/v2/index/manifests/%s", digest.String()))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+			digestHdr = resp.Header().Get(constants.DistContentDigestKey)
+			So(digestHdr, ShouldNotBeEmpty)
+			So(digestHdr, ShouldEqual, digest.String())
+
+			index.SchemaVersion = 2
+			index.Manifests = []ispec.Descriptor{
+				{
+					MediaType: ispec.MediaTypeImageManifest,
+					Digest:    digest,
+					Size:      int64(len(content)),
+				},
+				{
+					MediaType: ispec.MediaTypeImageManifest,
+					Digest:    m2dgst,
+					Size:      int64(m2size),
+				},
+			}
+
+			content, err = json.Marshal(index)
+			So(err, ShouldBeNil)
+			digest = godigest.FromBytes(content)
+			So(digest, ShouldNotBeNil)
+			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+				SetBody(content).Put(baseURL + "/v2/index/manifests/test:index2")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+			digestHdr = resp.Header().Get(constants.DistContentDigestKey)
+			So(digestHdr, ShouldNotBeEmpty)
+			So(digestHdr, ShouldEqual, digest.String())
+			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+				Get(baseURL + "/v2/index/manifests/test:index2")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Body(), ShouldNotBeEmpty)
+			So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
+
+			Convey("List tags", func() {
+				request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+				request = mux.SetURLVars(request, map[string]string{"name": "index"})
+				response := httptest.NewRecorder()
+
+				rthdlr.ListTags(response, request)
+
+				resp := response.Result()
+				defer resp.Body.Close()
+				So(resp, ShouldNotBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+
+				var tags api.ImageTags
+				err = json.NewDecoder(resp.Body).Decode(&tags)
+				So(err, ShouldBeNil)
+				So(len(tags.Tags), ShouldEqual, 3)
+				So(tags.Tags, ShouldContain, "test:1.0")
+				So(tags.Tags, ShouldContain, "test:index1")
+				So(tags.Tags, ShouldContain, "test:index2")
+			})
+
+			Convey("Another index with same manifest", func() {
+				var index ispec.Index
+				index.SchemaVersion = 2
+				index.Manifests = []ispec.Descriptor{
+					{
+						MediaType: ispec.MediaTypeImageManifest,
+						Digest:    m4dgst,
+						Size:      int64(m4size),
+					},
+				}
+
+				content, err = json.Marshal(index)
+				So(err, ShouldBeNil)
+				digest = godigest.FromBytes(content)
+				So(digest, ShouldNotBeNil)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					SetBody(content).Put(baseURL + "/v2/index/manifests/test:index3")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+				digestHdr = resp.Header().Get(constants.DistContentDigestKey)
+				So(digestHdr, ShouldNotBeEmpty)
+				So(digestHdr, ShouldEqual, digest.String())
+			})
+
+			Convey("Another index using digest with same manifest", func() {
+				var index ispec.Index
+				index.SchemaVersion = 2
+				index.Manifests = []ispec.Descriptor{
+					{
+						MediaType: ispec.MediaTypeImageManifest,
+						Digest:    m4dgst,
+						Size:      int64(m4size),
+					},
+				}
+
+				content, err = json.Marshal(index)
+				So(err, ShouldBeNil)
+				digest = godigest.FromBytes(content)
+				So(digest, ShouldNotBeNil)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					SetBody(content).Put(baseURL + fmt.Sprintf("/v2/index/manifests/%s", digest))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+				digestHdr = resp.Header().Get(constants.DistContentDigestKey)
+				So(digestHdr, ShouldNotBeEmpty)
+				So(digestHdr, ShouldEqual, digest.String())
+			})
+
+			Convey("Deleting an image index", func() {
+				// delete manifest by tag should pass
+				resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/test:index3")
+				So(err, ShouldBeNil)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					Get(baseURL + "/v2/index/manifests/test:index3")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+				So(resp.Body(), ShouldNotBeEmpty)
+				resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/test:index1")
+				So(err, ShouldBeNil)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					Get(baseURL + "/v2/index/manifests/test:index1")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+				So(resp.Body(), ShouldNotBeEmpty)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					Get(baseURL + "/v2/index/manifests/test:index2")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+				So(resp.Body(), ShouldNotBeEmpty)
+				So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
+			})
+
+			Convey("Deleting an image index by digest", func() {
+				// delete manifest by tag should pass
+				resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/test:index3")
+				So(err, ShouldBeNil)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					Get(baseURL + "/v2/index/manifests/test:index3")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+				So(resp.Body(), ShouldNotBeEmpty)
+				resp, err = resty.R().Delete(baseURL + fmt.Sprintf("/v2/index/manifests/%s", index1dgst))
+				So(err, ShouldBeNil)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					Get(baseURL + "/v2/index/manifests/test:index1")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+				So(resp.Body(), ShouldNotBeEmpty)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					Get(baseURL + "/v2/index/manifests/test:index2")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+				So(resp.Body(), ShouldNotBeEmpty)
+				So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
+			})
+
+			Convey("Update an index tag with different manifest", func() {
+				cfg, layers, manifest, err = test.GetImageComponents(5)
+				So(err, ShouldBeNil)
+				err = test.UploadImage(
+					test.Image{
+						Config:   cfg,
+						Layers:   layers,
+						Manifest: manifest,
+					}, baseURL, repoName)
+				So(err, ShouldBeNil)
+				content, err = json.Marshal(manifest)
+				So(err, ShouldBeNil)
+				digest = godigest.FromBytes(content)
+				So(digest, ShouldNotBeNil)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+					SetBody(content).Put(baseURL + fmt.Sprintf("/v2/index/manifests/%s", digest))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+				digestHdr = resp.Header().Get(constants.DistContentDigestKey)
+				So(digestHdr, ShouldNotBeEmpty)
+				So(digestHdr, ShouldEqual, digest.String())
+
+				index.SchemaVersion = 2
+				index.Manifests = []ispec.Descriptor{
+					{
+						MediaType: ispec.MediaTypeImageManifest,
+						Digest:    digest,
+						Size:      int64(len(content)),
+					},
+				}
+
+				content, err = json.Marshal(index)
+				So(err, ShouldBeNil)
+				digest = godigest.FromBytes(content)
+				So(digest, ShouldNotBeNil)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					SetBody(content).Put(baseURL + "/v2/index/manifests/test:index1")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+				digestHdr = resp.Header().Get(constants.DistContentDigestKey)
+				So(digestHdr, ShouldNotBeEmpty)
+				So(digestHdr, ShouldEqual, digest.String())
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					Get(baseURL + "/v2/index/manifests/test:index1")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+				So(resp.Body(), ShouldNotBeEmpty)
+				So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
+
+				// delete manifest by tag should pass
+				resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/test:index1")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+				resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+					Get(baseURL + "/v2/index/manifests/test:index1")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+				So(resp.Body(), ShouldNotBeEmpty)
+			})
+
+			Convey("Negative test cases", func() {
+				Convey("Delete index", func() {
+					err = os.Remove(path.Join(dir, "index", "blobs", index1dgst.Algorithm().String(), index1dgst.Encoded()))
+					So(err, ShouldBeNil)
+					resp, err = resty.R().Delete(baseURL + fmt.Sprintf("/v2/index/manifests/%s", index1dgst))
+					So(err, ShouldBeNil)
+					resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+						Get(baseURL + "/v2/index/manifests/test:index1")
+					So(err, ShouldBeNil)
+					So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+					So(resp.Body(), ShouldNotBeEmpty)
+				})
+
+				Convey("Corrupt index", func() {
+					err = os.WriteFile(path.Join(dir, "index", "blobs", index1dgst.Algorithm().String(), index1dgst.Encoded()),
+						[]byte("deadbeef"), storageConstants.DefaultFilePerms)
+					So(err, ShouldBeNil)
+					resp, err = resty.R().Delete(baseURL + fmt.Sprintf("/v2/index/manifests/%s", index1dgst))
+					So(err, ShouldBeNil)
+					resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+						Get(baseURL + "/v2/index/manifests/test:index1")
+					So(err, ShouldBeNil)
+					So(resp.StatusCode(), ShouldEqual, http.StatusInternalServerError)
+					So(resp.Body(), ShouldBeEmpty)
+				})
+
+				Convey("Change media-type", func() {
+					// previously a manifest, try writing an image index
+					var index ispec.Index
+					index.SchemaVersion = 2
+					index.Manifests = []ispec.Descriptor{
+						{
+							MediaType: ispec.MediaTypeImageIndex,
+							Digest:    m4dgst,
+							Size:      int64(m4size),
+						},
+					}
+
+					content, err = json.Marshal(index)
+					So(err, ShouldBeNil)
+					digest = godigest.FromBytes(content)
+					So(digest, ShouldNotBeNil)
+					resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+						SetBody(content).Put(baseURL + "/v2/index/manifests/test:1.0")
+					So(err, ShouldBeNil)
+					So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+					// previously an image index, try writing a manifest
+					resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+						SetBody(m1content).Put(baseURL + "/v2/index/manifests/test:index1")
+					So(err, ShouldBeNil)
+					So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+				})
+			})
+		})
+	})
+}
+
+func TestManifestCollision(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "")
+
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				AuthorizationAllRepos: config.PolicyGroup{
+					AnonymousPolicy: []string{api.Read, api.Create, api.Delete, api.DetectManifestCollision},
+				},
+			},
+		}
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		cfg, layers, manifest, err := test.GetImageComponents(2)
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(
+			test.Image{
+				Config:    cfg,
+				Layers:    layers,
+				Manifest:  manifest,
+				Reference: "test:1.0",
+			}, baseURL, "index")
+		So(err, ShouldBeNil)
+
+		_, err = os.Stat(path.Join(dir, "index"))
+		So(err, ShouldBeNil)
+
+		content := []byte("this is a blob1")
+		digest := godigest.FromBytes(content)
+		So(digest, ShouldNotBeNil)
+
+		// check a non-existent manifest
+		resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+			SetBody(content).Head(baseURL + "/v2/unknown/manifests/test:1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		content, err = json.Marshal(manifest)
+		So(err, ShouldBeNil)
+		digest = godigest.FromBytes(content)
+		So(digest, ShouldNotBeNil)
+
+		err = test.UploadImage(
+			test.Image{
+				Config:    cfg,
+				Layers:    layers,
+				Manifest:  manifest,
+				Reference: "test:2.0",
+			}, baseURL, "index")
+		So(err, ShouldBeNil)
+
+		// Deletion should fail if using digest
+		resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/" + digest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusConflict)
+
+		// remove detectManifestCollision action from ** (all repos)
+		repoPolicy := conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos]
+		repoPolicy.AnonymousPolicy = []string{"read", "delete"}
+		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos] = repoPolicy
+
+		resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/" + digest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		resp, err = resty.R().Get(baseURL + "/v2/index/manifests/test:1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().Get(baseURL + "/v2/index/manifests/test:2.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+}
+
+func TestPullRange(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		// create a blob/layer
+		resp, err := resty.R().Post(baseURL + "/v2/index/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc := test.Location(baseURL, resp)
+		So(loc, ShouldNotBeEmpty)
+
+		// since we are not specifying any prefix i.e provided in config while starting server,
+		// so it should store index1 to global root dir
+		_, err = os.Stat(path.Join(dir, "index"))
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().Get(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
+		content := []byte("0123456789")
+		digest := godigest.FromBytes(content)
+		So(digest, ShouldNotBeNil)
+		// monolithic blob upload: success
+		resp, err = resty.R().SetQueryParam("digest", digest.String()).
+			SetHeader("Content-Type", "application/octet-stream").SetBody(content).Put(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		blobLoc := resp.Header().Get("Location")
+		So(blobLoc, ShouldNotBeEmpty)
+		So(resp.Header().Get("Content-Length"), ShouldEqual, "0")
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldNotBeEmpty)
+		blobLoc = baseURL + blobLoc
+
+		Convey("Range is supported using 'bytes'", func() {
+			resp, err = resty.R().Head(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.Header().Get("Accept-Ranges"), ShouldEqual, "bytes")
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Get a range of bytes", func() {
+			resp, err = resty.R().SetHeader("Range", "bytes=0-").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
+			So(resp.Header().Get("Content-Length"), ShouldEqual, fmt.Sprintf("%d", len(content)))
+			So(resp.Body(), ShouldResemble, content)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=0-100").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
+			So(resp.Header().Get("Content-Length"), ShouldEqual, fmt.Sprintf("%d", len(content)))
+			So(resp.Body(), ShouldResemble, content)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=0-10").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
+			So(resp.Header().Get("Content-Length"), ShouldEqual, fmt.Sprintf("%d", len(content)))
+			So(resp.Body(), ShouldResemble, content)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=0-0").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
+			So(resp.Header().Get("Content-Length"), ShouldEqual, "1")
+			So(resp.Body(), ShouldResemble, content[0:1])
+
+			resp, err = resty.R().SetHeader("Range", "bytes=0-1").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
+			So(resp.Header().Get("Content-Length"), ShouldEqual, "2")
+			So(resp.Body(), ShouldResemble, content[0:2])
+
+			resp, err = resty.R().SetHeader("Range", "bytes=2-3").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
+			So(resp.Header().Get("Content-Length"), ShouldEqual, "2")
+			So(resp.Body(), ShouldResemble, content[2:4])
+		})
+
+		Convey("Get the whole blob", func() {
+			resp, err = resty.R().Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Header().Get("Content-Length"), ShouldEqual, fmt.Sprintf("%d", len(content)))
+			So(resp.Header().Get("Accept-Ranges"), ShouldEqual, "bytes")
+			So(resp.Header().Get("ETag"), ShouldEqual, fmt.Sprintf("%q", digest))
+			So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, digest.String())
+			So(resp.Body(), ShouldResemble, content)
+
+			resp, err = resty.R().SetHeader("If-None-Match", fmt.Sprintf("%q", digest)).Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotModified)
+			So(resp.Body(), ShouldBeEmpty)
+		})
+
+		Convey("Get a range of bytes of the same blob with If-Range", func() {
+			resp, err = resty.R().SetHeader("Range", "bytes=2-3").
+				SetHeader("If-Range", fmt.Sprintf("%q", digest)).Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
+			So(resp.Header().Get("Content-Range"), ShouldEqual, fmt.Sprintf("bytes 2-3/%d", len(content)))
+			So(resp.Body(), ShouldResemble, content[2:4])
+
+			// a different blob, the whole blob is sent
+			resp, err = resty.R().SetHeader("Range", "bytes=2-3").
+				SetHeader("If-Range", fmt.Sprintf("%q", godigest.FromString("other"))).Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Header().Get("Content-Range"), ShouldBeEmpty)
+			So(resp.Body(), ShouldResemble, content)
+
+			// blobs have no modification date to compare with
+			resp, err = resty.R().SetHeader("Range", "bytes=2-3").
+				SetHeader("If-Range", "Wed, 21 Oct 2015 07:28:00 GMT").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Body(), ShouldResemble, content)
+		})
+
+		Convey("Negative cases", func() {
+			resp, err = resty.R().SetHeader("Range", "=0").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "=a").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "=").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "byte=").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "byte=-0").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "byte=0").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "octet=-0").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=-0").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=1-0").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=-1-0").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=-1--0").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=1--2").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=0-a").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=a-10").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=a-b").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		})
+	})
+}
+
+func TestInjectInterruptedImageManifest(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		rthdlr := api.NewRouteHandler(ctlr)
+
+		Convey("Upload a blob & a config blob; Create an image manifest", func() {
+			// create a blob/layer
+			resp, err := resty.R().Post(baseURL + "/v2/repotest/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+			loc := test.Location(baseURL, resp)
+			So(loc, ShouldNotBeEmpty)
+
+			// since we are not specifying any prefix i.e provided in config while starting server,
+			// so it should store repotest to global root dir
+			_, err = os.Stat(path.Join(dir, "repotest"))
+			So(err, ShouldBeNil)
+
+			resp, err = resty.R().Get(loc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
+			content := []byte("this is a dummy blob")
+			digest := godigest.FromBytes(content)
+			So(digest, ShouldNotBeNil)
+			// monolithic blob upload: success
+			resp, err = resty.R().SetQueryParam("digest", digest.String()).
+				SetHeader("Content-Type", "application/octet-stream").SetBody(content).Put(loc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+			blobLoc := resp.Header().Get("Location")
+			So(blobLoc, ShouldNotBeEmpty)
+			So(resp.Header().Get("Content-Length"), ShouldEqual, "0")
+			So(resp.Header().Get(constants.DistContentDigestKey), ShouldNotBeEmpty)
+
+			// upload image config blob
+			resp, err = resty.R().Post(baseURL + "/v2/repotest/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+			loc = test.Location(baseURL, resp)
+			cblob, cdigest := test.GetRandomImageConfig()
+
+			resp, err = resty.R().
+				SetContentLength(true).
+				SetHeader("Content-Length", fmt.Sprintf("%d", len(cblob))).
+				SetHeader("Content-Type", "application/octet-stream").
+				SetQueryParam("digest", cdigest.String()).
+				SetBody(cblob).
+				Put(loc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+			// create a manifest
+			manifest := ispec.Manifest{
+				Config: ispec.Descriptor{
+					MediaType: "application/vnd.oci.image.config.v1+json",
+					Digest:    cdigest,
+					Size:      int64(len(cblob)),
+				},
+				Layers: []ispec.Descriptor{
+					{
+						MediaType: "application/vnd.oci.image.layer.v1.tar",
+						Digest:    digest,
+						Size:      int64(len(content)),
+					},
+				},
+			}
+			manifest.SchemaVersion = 2
+			content, err = json.Marshal(manifest)
+			So(err, ShouldBeNil)
+			digest = godigest.FromBytes(content)
+			So(digest, ShouldNotBeNil)
+
+			// Testing router path:  @Router /v2/{name}/manifests/{reference} [put]
+			Convey("Uploading an image manifest blob (when injected simulates an interrupted image manifest upload)", func() {
+				injected := inject.InjectFailure(0)
+
+				request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
+				request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
+				request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+				response := httptest.NewRecorder()
+
+				rthdlr.UpdateManifest(response, request)
+
+				resp := response.Result()
+				defer resp.Body.Close()
+
+				So(resp, ShouldNotBeNil)
+
+				if injected {
+					So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+				} else {
+					So(resp.StatusCode, ShouldEqual, http.StatusCreated)
+				}
+			})
+		})
+	})
+}
+
+func TestInjectTooManyOpenFiles(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "")
+		conf.Storage.RemoteCache = false
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		rthdlr := api.NewRouteHandler(ctlr)
+
+		// create a blob/layer
+		resp, err := resty.R().Post(baseURL + "/v2/repotest/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc := test.Location(baseURL, resp)
+		So(loc, ShouldNotBeEmpty)
+
+		// since we are not specifying any prefix i.e provided in config while starting server,
+		// so it should store repotest to global root dir
+		_, err = os.Stat(path.Join(dir, "repotest"))
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().Get(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
+		content := []byte("this is a dummy blob")
+		digest := godigest.FromBytes(content)
+		So(digest, ShouldNotBeNil)
+
+		// monolithic blob upload
+		injected := inject.InjectFailure(0)
+		if injected {
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, loc, bytes.NewReader(content))
+			tokens := strings.Split(loc, "/")
+			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "session_id": tokens[len(tokens)-1]})
+			q := request.URL.Query()
+			q.Add("digest", digest.String())
+			request.URL.RawQuery = q.Encode()
+			request.Header.Set("Content-Type", "application/octet-stream")
+			request.Header.Set("Content-Length", fmt.Sprintf("%d", len(content)))
+			response := httptest.NewRecorder()
+
+			rthdlr.UpdateBlobUpload(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+		} else {
+			resp, err = resty.R().SetQueryParam("digest", digest.String()).
+				SetHeader("Content-Type", "application/octet-stream").
+				SetBody(content).Put(loc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+			blobLoc := resp.Header().Get("Location")
+			So(blobLoc, ShouldNotBeEmpty)
+			So(resp.Header().Get("Content-Length"), ShouldEqual, "0")
+			So(resp.Header().Get(constants.DistContentDigestKey), ShouldNotBeEmpty)
+		}
+
+		// upload image config blob
+		resp, err = resty.R().Post(baseURL + "/v2/repotest/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc = test.Location(baseURL, resp)
+		cblob, cdigest := test.GetRandomImageConfig()
+
+		resp, err = resty.R().
+			SetContentLength(true).
+			SetHeader("Content-Length", fmt.Sprintf("%d", len(cblob))).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", cdigest.String()).
+			SetBody(cblob).
+			Put(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		// create a manifest
+		manifest := ispec.Manifest{
+			Config: ispec.Descriptor{
+				MediaType: "application/vnd.oci.image.config.v1+json",
+				Digest:    cdigest,
+				Size:      int64(len(cblob)),
+			},
+			Layers: []ispec.Descriptor{
+				{
+					MediaType: "application/vnd.oci.image.layer.v1.tar",
+					Digest:    digest,
+					Size:      int64(len(content)),
+				},
+			},
+		}
+		manifest.SchemaVersion = 2
+		content, err = json.Marshal(manifest)
+		So(err, ShouldBeNil)
+		digest = godigest.FromBytes(content)
+		So(digest, ShouldNotBeNil)
+
+		// Testing router path:  @Router /v2/{name}/manifests/{reference} [put]
+		//nolint:lll // gofumpt conflicts with lll
+		Convey("Uploading an image manifest blob (when injected simulates that PutImageManifest failed due to 'too many open files' error)", func() {
+			injected := inject.InjectFailure(1)
+
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
+			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
+			request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			response := httptest.NewRecorder()
+
+			rthdlr.UpdateManifest(response, request)
+
+			resp := response.Result()
+			So(resp, ShouldNotBeNil)
+			defer resp.Body.Close()
+
+			if injected {
+				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+			} else {
+				So(resp.StatusCode, ShouldEqual, http.StatusCreated)
+			}
+		})
+		Convey("when injected simulates a `too many open files` error inside PutImageManifest method of img store", func() {
+			injected := inject.InjectFailure(2)
+
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
+			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
+			request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			response := httptest.NewRecorder()
+
+			rthdlr.UpdateManifest(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+
+			So(resp, ShouldNotBeNil)
+
+			if injected {
+				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+			} else {
+				So(resp.StatusCode, ShouldEqual, http.StatusCreated)
+			}
+		})
+		Convey("code coverage: error inside PutImageManifest method of img store (unable to marshal JSON)", func() {
+			injected := inject.InjectFailure(1)
+
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
+			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
+			request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			response := httptest.NewRecorder()
+
+			rthdlr.UpdateManifest(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+
+			So(resp, ShouldNotBeNil)
+
+			if injected {
+				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+			} else {
+				So(resp.StatusCode, ShouldEqual, http.StatusCreated)
+			}
+		})
+		Convey("code coverage: error inside PutImageManifest method of img store (umoci.OpenLayout error)", func() {
+			injected := inject.InjectFailure(3)
+
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
+			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
+			request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			response := httptest.NewRecorder()
+
+			rthdlr.UpdateManifest(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+
+			So(resp, ShouldNotBeNil)
+
+			if injected {
+				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+			} else {
+				So(resp.StatusCode, ShouldEqual, http.StatusCreated)
+			}
+		})
+		Convey("code coverage: error inside PutImageManifest method of img store (oci.GC)", func() {
+			injected := inject.InjectFailure(4)
+
+			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
+			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
+			request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			response := httptest.NewRecorder()
+
+			rthdlr.UpdateManifest(response, request)
+
+			resp := response.Result()
+			defer resp.Body.Close()
+
+			So(resp, ShouldNotBeNil)
+
+			if injected {
+				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+			} else {
+				So(resp.StatusCode, ShouldEqual, http.StatusCreated)
+			}
+		})
+		Convey("when index.json is not in json format", func() {
+			resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
+				SetBody(content).Put(baseURL + "/v2/repotest/manifests/v1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+			digestHdr := resp.Header().Get(constants.DistContentDigestKey)
+			So(digestHdr, ShouldNotBeEmpty)
+			So(digestHdr, ShouldEqual, digest.String())
+
+			indexFile := path.Join(dir, "repotest", "index.json")
+			_, err = os.Stat(indexFile)
+			So(err, ShouldBeNil)
+			indexContent := []byte(`not a JSON content`)
+			err = os.WriteFile(indexFile, indexContent, 0o600)
+			So(err, ShouldBeNil)
+
+			resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
+				SetBody(content).Put(baseURL + "/v2/repotest/manifests/v1.1")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusInternalServerError)
+		})
+	})
+}
+
+func TestGCSignaturesAndUntaggedManifests(t *testing.T) {
+	Convey("Make controller", t, func() {
+		repoName := "testrepo" //nolint:goconst
+		tag := "0.0.1"
+
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		Convey("Garbage collect signatures without subject and manifests without tags", func(c C) {
+			dir := t.TempDir()
+			ctlr.Config.Storage.RootDirectory = dir
+			ctlr.Config.Storage.GC = true
+			ctlr.Config.Storage.GCDelay = 1 * time.Millisecond
+
+			test.CopyTestFiles("../../test/data/zot-test", path.Join(dir, repoName))
+
+			cm := test.NewControllerManager(ctlr)
+			cm.StartServer()
+			cm.WaitServerToBeReady(port)
+			defer cm.StopServer()
+
+			resp, err := resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, tag))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			digest := godigest.FromBytes(resp.Body())
+			So(digest, ShouldNotBeEmpty)
+
+			cwd, err := os.Getwd()
+			So(err, ShouldBeNil)
+			defer func() { _ = os.Chdir(cwd) }()
+			tdir := t.TempDir()
+			_ = os.Chdir(tdir)
+
+			// generate a keypair
+			os.Setenv("COSIGN_PASSWORD", "")
+			err = generate.GenerateKeyPairCmd(context.TODO(), "", "cosign", nil)
+			So(err, ShouldBeNil)
+
+			image := fmt.Sprintf("localhost:%s/%s@%s", port, repoName, digest.String())
+
+			annotations := []string{fmt.Sprintf("tag=%s", tag)}
+
+			// sign the image
+			err = sign.SignCmd(&options.RootOptions{Verbose: true, Timeout: 1 * time.Minute},
+				options.KeyOpts{KeyRef: path.Join(tdir, "cosign.key"), PassFunc: generate.GetPass},
+				options.SignOptions{
+					Registry:          options.RegistryOptions{AllowInsecure: true},
+					AnnotationOptions: options.AnnotationOptions{Annotations: annotations},
+					Upload:            true,
+				},
+				[]string{image})
+
+			So(err, ShouldBeNil)
+
+			test.NotationPathLock.Lock()
+			defer test.NotationPathLock.Unlock()
+
+			test.LoadNotationPath(tdir)
+
+			// generate a keypair
+			err = test.GenerateNotationCerts(tdir, "good")
+			So(err, ShouldBeNil)
+
+			// sign the image
+			err = test.SignWithNotation("good", image, tdir)
+			So(err, ShouldBeNil)
+
+			// get cosign signature manifest
+			cosignTag := strings.Replace(digest.String(), ":", "-", 1) + "." + remote.SignatureTagSuffix
+
+			resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, cosignTag))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			// get notation signature manifest
+			resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
+				fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			var index ispec.Index
+
+			err = json.Unmarshal(resp.Body(), &index)
+			So(err, ShouldBeNil)
+			So(len(index.Manifests), ShouldEqual, 1)
+
+			Convey("Trigger gcNotationSignatures() error", func() {
+				var refs ispec.Index
+				err = json.Unmarshal(resp.Body(), &refs)
+
+				err := os.Chmod(path.Join(dir, repoName, "blobs", "sha256", refs.Manifests[0].Digest.Encoded()), 0o000)
+				So(err, ShouldBeNil)
+
+				// trigger gc
+				cfg, layers, manifest, err := test.GetImageComponents(3)
+				So(err, ShouldBeNil)
+
+				err = test.UploadImage(
+					test.Image{
+						Config:    cfg,
+						Layers:    layers,
+						Manifest:  manifest,
+						Reference: tag,
+					}, baseURL, repoName)
+				So(err, ShouldNotBeNil)
+
+				err = os.Chmod(path.Join(dir, repoName, "blobs", "sha256", refs.Manifests[0].Digest.Encoded()), 0o755)
+				So(err, ShouldBeNil)
+
+				content, err := os.ReadFile(path.Join(dir, repoName, "blobs", "sha256", refs.Manifests[0].Digest.Encoded()))
+				So(err, ShouldBeNil)
+				err = os.WriteFile(path.Join(dir, repoName, "blobs", "sha256", refs.Manifests[0].Digest.Encoded()), []byte("corrupt"), 0o600) //nolint:lll
+				So(err, ShouldBeNil)
+
+				err = test.UploadImage(
+					test.Image{
+						Config:    cfg,
+						Layers:    layers,
+						Manifest:  manifest,
+						Reference: tag,
+					}, baseURL, repoName)
+				So(err, ShouldNotBeNil)
+
+				err = os.WriteFile(path.Join(dir, repoName, "blobs", "sha256", refs.Manifests[0].Digest.Encoded()), content, 0o600)
+				So(err, ShouldBeNil)
+			})
+
+			// push an image without tag
+			cfg, layers, manifest, err := test.GetImageComponents(2)
+			So(err, ShouldBeNil)
+
+			manifestBuf, err := json.Marshal(manifest)
+			So(err, ShouldBeNil)
+			untaggedManifestDigest := godigest.FromBytes(manifestBuf)
+
+			err = test.UploadImage(
+				test.Image{
+					Config:    cfg,
+					Layers:    layers,
+					Manifest:  manifest,
+					Reference: untaggedManifestDigest.String(),
+				}, baseURL, repoName)
+			So(err, ShouldBeNil)
+
+			// overwrite image so that signatures will get invalidated and gc'ed
+			cfg, layers, manifest, err = test.GetImageComponents(3)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImage(
+				test.Image{
+					Config:    cfg,
+					Layers:    layers,
+					Manifest:  manifest,
+					Reference: tag,
+				}, baseURL, repoName)
+			So(err, ShouldBeNil)
+
+			manifestBuf, err = json.Marshal(manifest)
+			So(err, ShouldBeNil)
+			newManifestDigest := godigest.FromBytes(manifestBuf)
+
+			// both signatures should be gc'ed
+			resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, cosignTag))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+			resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
+				fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			err = json.Unmarshal(resp.Body(), &index)
+			So(err, ShouldBeNil)
+			So(len(index.Manifests), ShouldEqual, 0)
+
+			resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
+				fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, newManifestDigest.String()))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			err = json.Unmarshal(resp.Body(), &index)
+			So(err, ShouldBeNil)
+			So(len(index.Manifests), ShouldEqual, 0)
+
+			// untagged image should also be gc'ed
+			resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, untaggedManifestDigest))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Do not gc manifests which are part of a multiarch image", func(c C) {
+			dir := t.TempDir()
+			ctlr.Config.Storage.RootDirectory = dir
+			ctlr.Config.Storage.GC = true
+			ctlr.Config.Storage.GCDelay = 500 * time.Millisecond
+
+			test.CopyTestFiles("../../test/data/zot-test", path.Join(dir, repoName))
+
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(port)
+			defer cm.StopServer()
+
+			resp, err := resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, tag))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			digest := godigest.FromBytes(resp.Body())
+			So(digest, ShouldNotBeEmpty)
+
+			// push an image index and make sure manifests contained by it are not gc'ed
+			// create an image index on upstream
+			var index ispec.Index
+			index.SchemaVersion = 2
+			index.MediaType = ispec.MediaTypeImageIndex
+
+			// upload multiple manifests
+			for i := 0; i < 4; i++ {
+				config, layers, manifest, err := test.GetImageComponents(1000 + i)
+				So(err, ShouldBeNil)
+
+				manifestContent, err := json.Marshal(manifest)
+				So(err, ShouldBeNil)
+
+				manifestDigest := godigest.FromBytes(manifestContent)
+
+				err = test.UploadImage(
+					test.Image{
+						Manifest:  manifest,
+						Config:    config,
+						Layers:    layers,
+						Reference: manifestDigest.String(),
+					},
+					baseURL,
+					repoName)
+				So(err, ShouldBeNil)
+
+				index.Manifests = append(index.Manifests, ispec.Descriptor{
+					Digest:    manifestDigest,
+					MediaType: ispec.MediaTypeImageManifest,
+					Size:      int64(len(manifestContent)),
+				})
+			}
+
+			content, err := json.Marshal(index)
+			So(err, ShouldBeNil)
+			indexDigest := godigest.FromBytes(content)
+			So(indexDigest, ShouldNotBeNil)
+
+			time.Sleep(1 * time.Second)
+			// upload image index
+			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+				SetBody(content).Put(baseURL + fmt.Sprintf("/v2/%s/manifests/latest", repoName))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
+				Get(baseURL + fmt.Sprintf("/v2/%s/manifests/latest", repoName))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Body(), ShouldNotBeEmpty)
+			So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
+
+			// make sure manifests which are part of image index are not gc'ed
+			for _, manifest := range index.Manifests {
+				resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, manifest.Digest.String()))
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			}
+		})
+	})
+}
+
+func TestPeriodicGC(t *testing.T) {
+	Convey("Periodic gc enabled for default store", t, func() {
+		repoName := "testrepo" //nolint:goconst
+
+		port := test.GetFreePort()
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RemoteCache = false
+
+		logFile, err := os.CreateTemp("", "zot-log*.txt")
+		So(err, ShouldBeNil)
+		conf.Log.Output = logFile.Name()
+		defer os.Remove(logFile.Name()) // clean up
+
+		ctlr := api.NewController(conf)
+		dir := t.TempDir()
+		ctlr.Config.Storage.RootDirectory = dir
+		ctlr.Config.Storage.Dedupe = false
+		ctlr.Config.Storage.GC = true
+		ctlr.Config.Storage.GCInterval = 1 * time.Hour
+		ctlr.Config.Storage.GCDelay = 1 * time.Second
+
+		test.CopyTestFiles("../../test/data/zot-test", path.Join(dir, repoName))
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		time.Sleep(5000 * time.Millisecond)
+
+		data, err := os.ReadFile(logFile.Name())
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring,
+			"\"GC\":true,\"Commit\":false,\"GCDelay\":1000000000,\"GCInterval\":3600000000000")
+		So(string(data), ShouldContainSubstring,
+			fmt.Sprintf("executing GC of orphaned blobs for %s", path.Join(ctlr.StoreController.DefaultStore.RootDir(), repoName))) //nolint:lll
+		So(string(data), ShouldContainSubstring,
+			fmt.Sprintf("GC successfully completed for %s", path.Join(ctlr.StoreController.DefaultStore.RootDir(), repoName))) //nolint:lll
+	})
+
+	Convey("Periodic GC enabled for substore", t, func() {
+		port := test.GetFreePort()
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		logFile, err := os.CreateTemp("", "zot-log*.txt")
+		So(err, ShouldBeNil)
+		conf.Log.Output = logFile.Name()
+		defer os.Remove(logFile.Name()) // clean up
+
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "")
+		subDir := t.TempDir()
+
+		subPaths := make(map[string]config.StorageConfig)
+
+		subPaths["/a"] = config.StorageConfig{RootDirectory: subDir, GC: true, GCDelay: 1 * time.Second, GCInterval: 24 * time.Hour, RemoteCache: false, Dedupe: false} //nolint:lll // gofumpt conflicts with lll
+		ctlr.Config.Storage.Dedupe = false
+		ctlr.Config.Storage.SubPaths = subPaths
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		data, err := os.ReadFile(logFile.Name())
+		So(err, ShouldBeNil)
+		// periodic GC is not enabled for default store
+		So(string(data), ShouldContainSubstring,
+			"\"GCDelay\":3600000000000,\"GCInterval\":0,\"")
+		// periodic GC is enabled for sub store
+		So(string(data), ShouldContainSubstring,
+			fmt.Sprintf("\"SubPaths\":{\"/a\":{\"RootDirectory\":\"%s\",\"Dedupe\":false,\"RemoteCache\":false,\"GC\":true,\"Commit\":false,\"GCDelay\":1000000000,\"GCInterval\":86400000000000", subDir)) //nolint:lll // gofumpt conflicts with lll
+	})
+
+	Convey("Periodic gc error", t, func() {
+		repoName := "testrepo" //nolint:goconst
+
+		port := test.GetFreePort()
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RemoteCache = false
+
+		logFile, err := os.CreateTemp("", "zot-log*.txt")
+		So(err, ShouldBeNil)
+		conf.Log.Output = logFile.Name()
+		defer os.Remove(logFile.Name()) // clean up
+
+		ctlr := api.NewController(conf)
+		dir := t.TempDir()
+		ctlr.Config.Storage.RootDirectory = dir
+		ctlr.Config.Storage.Dedupe = false
+
+		ctlr.Config.Storage.GC = true
+		ctlr.Config.Storage.GCInterval = 1 * time.Hour
+		ctlr.Config.Storage.GCDelay = 1 * time.Second
+
+		test.CopyTestFiles("../../test/data/zot-test", path.Join(dir, repoName))
+
+		So(os.Chmod(dir, 0o000), ShouldBeNil)
+
+		defer func() {
+			So(os.Chmod(dir, 0o755), ShouldBeNil)
+		}()
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		time.Sleep(5000 * time.Millisecond)
+
+		data, err := os.ReadFile(logFile.Name())
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring,
+			"\"GC\":true,\"Commit\":false,\"GCDelay\":1000000000,\"GCInterval\":3600000000000")
+		So(string(data), ShouldContainSubstring, "failure walking storage root-dir") //nolint:lll
+	})
+}
+
+func TestSearchRoutes(t *testing.T) {
+	Convey("Upload image for test", t, func(c C) {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		tempDir := t.TempDir()
+
+		ctlr := makeController(conf, tempDir, "")
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		repoName := "testrepo" //nolint:goconst
+		inaccessibleRepo := "inaccessible"
+
+		cfg, layers, manifest, err := test.GetImageComponents(10000)
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(
+			test.Image{
+				Config:    cfg,
+				Layers:    layers,
+				Manifest:  manifest,
+				Reference: "latest",
+			}, baseURL, repoName)
+
+		So(err, ShouldBeNil)
+
+		// data for the inaccessible repo
+		cfg, layers, manifest, err = test.GetImageComponents(10000)
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(
+			test.Image{
+				Config:    cfg,
+				Layers:    layers,
+				Manifest:  manifest,
+				Reference: "latest",
+			}, baseURL, inaccessibleRepo)
+
+		So(err, ShouldBeNil)
+
+		Convey("GlobalSearch with authz enabled", func(c C) {
+			conf := config.New()
+			port := test.GetFreePort()
+			baseURL := test.GetBaseURL(port)
+
+			user1 := "test"
+			password1 := "test"
+			testString1 := getCredString(user1, password1)
+			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
+			defer os.Remove(htpasswdPath)
+			conf.HTTP.Auth = &config.AuthConfig{
+				HTPasswd: config.AuthHTPasswd{
+					Path: htpasswdPath,
+				},
+			}
+
+			conf.HTTP.Port = port
+
+			defaultVal := true
+
+			searchConfig := &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+			}
+
+			conf.Extensions = &extconf.ExtensionConfig{
+				Search: searchConfig,
+			}
+
+			conf.HTTP.AccessControl = &config.AccessControlConfig{
+				Repositories: config.Repositories{
+					repoName: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Users:   []string{user1},
+								Actions: []string{"read", "create"},
+							},
+						},
+						DefaultPolicy: []string{},
+					},
+					inaccessibleRepo: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Users:   []string{user1},
+								Actions: []string{"create"},
+							},
+						},
+						DefaultPolicy: []string{},
+					},
+				},
+				AdminPolicy: config.Policy{
+					Users:   []string{},
+					Actions: []string{},
+				},
+			}
+
+			ctlr := makeController(conf, tempDir, "")
+
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(port)
+			defer cm.StopServer()
+
+			cfg, layers, manifest, err := test.GetImageComponents(10000)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImageWithBasicAuth(
+				test.Image{
+					Config:    cfg,
+					Layers:    layers,
+					Manifest:  manifest,
+					Reference: "latest",
+				}, baseURL, repoName,
+				user1, password1)
+
+			So(err, ShouldBeNil)
+
+			// data for the inaccessible repo
+			cfg, layers, manifest, err = test.GetImageComponents(10000)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImageWithBasicAuth(
+				test.Image{
+					Config:    cfg,
+					Layers:    layers,
+					Manifest:  manifest,
+					Reference: "latest",
+				}, baseURL, inaccessibleRepo,
+				user1, password1)
+
+			So(err, ShouldBeNil)
+
+			query := `
+				{
+					GlobalSearch(query:"testrepo"){
+						Repos {
+							Name
+							NewestImage {
+								RepoName
+								Tag
+							}
+						}
+					}
+				}`
+			resp, err := resty.R().SetBasicAuth(user1, password1).Get(baseURL + constants.FullSearchPrefix +
+				"?query=" + url.QueryEscape(query))
+			So(err, ShouldBeNil)
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, 200)
+			So(string(resp.Body()), ShouldContainSubstring, repoName)
+			So(string(resp.Body()), ShouldNotContainSubstring, inaccessibleRepo)
+
+			resp, err = resty.R().Get(baseURL + constants.FullSearchPrefix + "?query=" + url.QueryEscape(query))
+			So(err, ShouldBeNil)
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+			conf.HTTP.AccessControl = &config.AccessControlConfig{
+				Repositories: config.Repositories{
+					repoName: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Users:   []string{user1},
+								Actions: []string{},
+							},
+						},
+						DefaultPolicy: []string{},
+					},
+					inaccessibleRepo: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Users:   []string{},
+								Actions: []string{},
+							},
+						},
+						DefaultPolicy: []string{},
+					},
+				},
+				AdminPolicy: config.Policy{
+					Users:   []string{},
+					Actions: []string{},
+				},
+			}
+
+			// authenticated, but no access to resource
+			resp, err = resty.R().SetBasicAuth(user1, password1).Get(baseURL + constants.FullSearchPrefix +
+				"?query=" + url.QueryEscape(query))
+			So(err, ShouldBeNil)
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(string(resp.Body()), ShouldNotContainSubstring, repoName)
+			So(string(resp.Body()), ShouldNotContainSubstring, inaccessibleRepo)
+		})
+
+		Convey("Testing group permissions", func(c C) {
+			conf := config.New()
+			port := test.GetFreePort()
+			baseURL := test.GetBaseURL(port)
+
+			user1 := "test1"
+			password1 := "test1"
+			group1 := "testgroup3"
+			testString1 := getCredString(user1, password1)
+			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
+			defer os.Remove(htpasswdPath)
+			conf.HTTP.Auth = &config.AuthConfig{
+				HTPasswd: config.AuthHTPasswd{
+					Path: htpasswdPath,
+				},
+			}
+
+			conf.HTTP.Port = port
+
+			defaultVal := true
+
+			searchConfig := &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+			}
+
+			conf.Extensions = &extconf.ExtensionConfig{
+				Search: searchConfig,
+			}
+
+			conf.HTTP.AccessControl = &config.AccessControlConfig{
+				Groups: config.Groups{
+					group1: {
+						Users: []string{user1},
+					},
+				},
+				Repositories: config.Repositories{
+					repoName: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Groups:  []string{group1},
+								Actions: []string{"read", "create"},
+							},
+						},
+						DefaultPolicy: []string{},
+					},
+				},
+				AdminPolicy: config.Policy{
+					Users:   []string{},
+					Actions: []string{},
+				},
+			}
+
+			ctlr := makeController(conf, tempDir, "")
+
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(port)
+			defer cm.StopServer()
+
+			cfg, layers, manifest, err := test.GetImageComponents(10000)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImageWithBasicAuth(
+				test.Image{
+					Config:   cfg,
+					Layers:   layers,
+					Manifest: manifest,
+				}, baseURL, repoName,
+				user1, password1)
+
+			So(err, ShouldBeNil)
+
+			query := `
+						{
+							GlobalSearch(query:"testrepo"){
+								Repos {
+									Name
+									NewestImage {
+										RepoName
+										Tag
+									}
+								}
+							}
+						}`
+			resp, err := resty.R().SetBasicAuth(user1, password1).Get(baseURL + constants.FullSearchPrefix +
+				"?query=" + url.QueryEscape(query))
+			So(err, ShouldBeNil)
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, 200)
+		})
+
+		Convey("Testing group permissions when the user is part of more groups with different permissions", func(c C) {
+			conf := config.New()
+			port := test.GetFreePort()
+			baseURL := test.GetBaseURL(port)
+
+			user1 := "test2"
+			password1 := "test2"
+			group1 := "testgroup1"
+			group2 := "secondtestgroup"
+			testString1 := getCredString(user1, password1)
+			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
+			defer os.Remove(htpasswdPath)
+			conf.HTTP.Auth = &config.AuthConfig{
+				HTPasswd: config.AuthHTPasswd{
+					Path: htpasswdPath,
+				},
+			}
+
+			conf.HTTP.Port = port
+
+			defaultVal := true
+
+			searchConfig := &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+			}
+
+			conf.Extensions = &extconf.ExtensionConfig{
+				Search: searchConfig,
+			}
+
+			conf.HTTP.AccessControl = &config.AccessControlConfig{
+				Groups: config.Groups{
+					group1: {
+						Users: []string{user1},
+					},
+				},
+				Repositories: config.Repositories{
+					repoName: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Groups:  []string{group1},
+								Actions: []string{"delete"},
+							},
+							{
+								Groups:  []string{group2},
+								Actions: []string{"read", "create"},
+							},
+						},
+						DefaultPolicy: []string{},
+					},
+				},
+				AdminPolicy: config.Policy{
+					Users:   []string{},
+					Actions: []string{},
+				},
+			}
+
+			ctlr := makeController(conf, tempDir, "")
+
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(port)
+			defer cm.StopServer()
+
+			cfg, layers, manifest, err := test.GetImageComponents(10000)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImageWithBasicAuth(
+				test.Image{
+					Config:   cfg,
+					Layers:   layers,
+					Manifest: manifest,
+				}, baseURL, repoName,
+				user1, password1)
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Testing group permissions when group has less permissions than user", func(c C) {
+			conf := config.New()
+			port := test.GetFreePort()
+			baseURL := test.GetBaseURL(port)
+
+			user1 := "test3"
+			password1 := "test3"
+			group1 := "testgroup"
+			testString1 := getCredString(user1, password1)
+			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
+			defer os.Remove(htpasswdPath)
+			conf.HTTP.Auth = &config.AuthConfig{
+				HTPasswd: config.AuthHTPasswd{
+					Path: htpasswdPath,
+				},
+			}
+
+			conf.HTTP.Port = port
+
+			defaultVal := true
+
+			searchConfig := &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+			}
+
+			conf.Extensions = &extconf.ExtensionConfig{
+				Search: searchConfig,
+			}
+
+			conf.HTTP.AccessControl = &config.AccessControlConfig{
+				Groups: config.Groups{
+					group1: {
+						Users: []string{user1},
+					},
+				},
+				Repositories: config.Repositories{
+					repoName: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Groups:  []string{group1},
+								Actions: []string{"delete"},
+							},
+							{
+								Users:   []string{user1},
+								Actions: []string{"read", "create", "delete"},
+							},
+						},
+						DefaultPolicy: []string{},
+					},
+				},
+				AdminPolicy: config.Policy{
+					Users:   []string{},
+					Actions: []string{},
+				},
+			}
+
+			ctlr := makeController(conf, tempDir, "")
+
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(port)
+			defer cm.StopServer()
+
+			cfg, layers, manifest, err := test.GetImageComponents(10000)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImageWithBasicAuth(
+				test.Image{
+					Config:   cfg,
+					Layers:   layers,
+					Manifest: manifest,
+				}, baseURL, repoName,
+				user1, password1)
+
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Testing group permissions when user has less permissions than group", func(c C) {
+			conf := config.New()
+			port := test.GetFreePort()
+			baseURL := test.GetBaseURL(port)
+
+			user1 := "test4"
+			password1 := "test4"
+			group1 := "testgroup1"
+			testString1 := getCredString(user1, password1)
+			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
+			defer os.Remove(htpasswdPath)
+			conf.HTTP.Auth = &config.AuthConfig{
+				HTPasswd: config.AuthHTPasswd{
+					Path: htpasswdPath,
+				},
+			}
+
+			conf.HTTP.Port = port
+
+			defaultVal := true
+
+			searchConfig := &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+			}
+
+			conf.Extensions = &extconf.ExtensionConfig{
+				Search: searchConfig,
+			}
+
+			conf.HTTP.AccessControl = &config.AccessControlConfig{
+				Groups: config.Groups{
+					group1: {
+						Users: []string{user1},
+					},
+				},
+				Repositories: config.Repositories{
+					repoName: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Groups:  []string{group1},
+								Actions: []string{"read", "create", "delete"},
+							},
+							{
+								Users:   []string{user1},
+								Actions: []string{"delete"},
+							},
+						},
+						DefaultPolicy: []string{},
+					},
+				},
+				AdminPolicy: config.Policy{
+					Users:   []string{},
+					Actions: []string{},
+				},
+			}
+
+			ctlr := makeController(conf, tempDir, "")
+
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(port)
+			defer cm.StopServer()
+
+			cfg, layers, manifest, err := test.GetImageComponents(10000)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImageWithBasicAuth(
+				test.Image{
+					Config:   cfg,
+					Layers:   layers,
+					Manifest: manifest,
+				}, baseURL, repoName,
+				user1, password1)
+
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Testing group permissions on admin policy", func(c C) {
+			conf := config.New()
+			port := test.GetFreePort()
+			baseURL := test.GetBaseURL(port)
+
+			user1 := "test5"
+			password1 := "test5"
+			group1 := "testgroup2"
+			testString1 := getCredString(user1, password1)
+			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
+			defer os.Remove(htpasswdPath)
+			conf.HTTP.Auth = &config.AuthConfig{
+				HTPasswd: config.AuthHTPasswd{
+					Path: htpasswdPath,
+				},
+			}
+
+			conf.HTTP.Port = port
+
+			defaultVal := true
+
+			searchConfig := &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+			}
+
+			conf.Extensions = &extconf.ExtensionConfig{
+				Search: searchConfig,
+			}
+
+			conf.HTTP.AccessControl = &config.AccessControlConfig{
+				Groups: config.Groups{
+					group1: {
+						Users: []string{user1},
+					},
+				},
+				Repositories: config.Repositories{},
+				AdminPolicy: config.Policy{
+					Groups:  []string{group1},
+					Actions: []string{"read", "create"},
+				},
+			}
+
+			ctlr := makeController(conf, tempDir, "")
+
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(port)
+			defer cm.StopServer()
+
+			cfg, layers, manifest, err := test.GetImageComponents(10000)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImageWithBasicAuth(
+				test.Image{
+					Config:   cfg,
+					Layers:   layers,
+					Manifest: manifest,
+				}, baseURL, repoName,
+				user1, password1)
+
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Testing group permissions on anonymous policy", func(c C) {
+			conf := config.New()
+			port := test.GetFreePort()
+			baseURL := test.GetBaseURL(port)
+
+			conf.HTTP.Port = port
+
+			defaultVal := true
+			group1 := group
+			user1 := username
+			password1 := passphrase
+
+			testString1 := getCredString(user1, password1)
+			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
+			defer os.Remove(htpasswdPath)
+			conf.HTTP.Auth = &config.AuthConfig{
+				HTPasswd: config.AuthHTPasswd{
+					Path: htpasswdPath,
+				},
+			}
+
+			searchConfig := &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+			}
+
+			conf.Extensions = &extconf.ExtensionConfig{
+				Search: searchConfig,
+			}
+			conf.HTTP.AccessControl = &config.AccessControlConfig{
+				Groups: config.Groups{
+					group1: {
+						Users: []string{user1},
+					},
+				},
+				Repositories: config.Repositories{
+					repoName: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Groups:  []string{group1},
+								Actions: []string{"read", "create", "delete"},
+							},
+							{
+								Users:   []string{user1},
+								Actions: []string{"delete"},
+							},
+						},
+						DefaultPolicy:   []string{},
+						AnonymousPolicy: []string{"read", "create"},
+					},
+				},
+			}
+
+			ctlr := makeController(conf, tempDir, "")
+
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(port)
+			defer cm.StopServer()
+
+			cfg, layers, manifest, err := test.GetImageComponents(10000)
+			So(err, ShouldBeNil)
+
+			err = test.UploadImageWithBasicAuth(
+				test.Image{
+					Config:   cfg,
+					Layers:   layers,
+					Manifest: manifest,
+				}, baseURL, repoName,
+				"", "")
+
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestDistSpecExtensions(t *testing.T) {
+	Convey("start zot server with search extension", t, func(c C) {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf.HTTP.Port = port
+
+		defaultVal := true
+
+		searchConfig := &extconf.SearchConfig{
+			BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+		}
+
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: searchConfig,
+		}
 
-		// Deletion should fail if using digest
-		resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/" + digest.String())
+		logFile, err := os.CreateTemp("", "zot-log*.txt")
 		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusConflict)
+		conf.Log.Output = logFile.Name()
+		defer os.Remove(logFile.Name()) // clean up
 
-		// remove detectManifestCollision action from ** (all repos)
-		repoPolicy := conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos]
-		repoPolicy.AnonymousPolicy = []string{"read", "delete"}
-		conf.HTTP.AccessControl.Repositories[AuthorizationAllRepos] = repoPolicy
+		ctlr := makeController(conf, t.TempDir(), "")
 
-		resp, err = resty.R().Delete(baseURL + "/v2/index/manifests/" + digest.String())
-		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-		resp, err = resty.R().Get(baseURL + "/v2/index/manifests/test:1.0")
-		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		var extensionList distext.ExtensionList
 
-		resp, err = resty.R().Get(baseURL + "/v2/index/manifests/test:2.0")
+		resp, err := resty.R().Get(baseURL + constants.RoutePrefix + constants.ExtOciDiscoverPrefix)
 		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, 200)
+		err = json.Unmarshal(resp.Body(), &extensionList)
+		So(err, ShouldBeNil)
+		So(len(extensionList.Extensions), ShouldEqual, 1)
+		So(len(extensionList.Extensions[0].Endpoints), ShouldEqual, 5)
+		So(extensionList.Extensions[0].Name, ShouldEqual, "_zot")
+		So(extensionList.Extensions[0].URL, ShouldContainSubstring, "_zot.md")
+		So(extensionList.Extensions[0].Description, ShouldNotBeEmpty)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullSearchPrefix)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullDigestsPrefix)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullPinsPrefix)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullAnnotationsPrefix)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullUserPreferencesPrefix)
 	})
-}
 
-func TestPullRange(t *testing.T) {
-	Convey("Make a new controller", t, func() {
+	Convey("start zot server with search and mgmt extensions", t, func(c C) {
+		conf := config.New()
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
-		conf := config.New()
+
 		conf.HTTP.Port = port
 
-		dir := t.TempDir()
-		ctlr := makeController(conf, dir, "")
+		defaultVal := true
+
+		searchConfig := &extconf.SearchConfig{
+			BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+		}
+
+		mgmtConfg := &extconf.MgmtConfig{
+			BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+		}
+
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: searchConfig,
+			Mgmt:   mgmtConfg,
+		}
+
+		logFile, err := os.CreateTemp("", "zot-log*.txt")
+		So(err, ShouldBeNil)
+		conf.Log.Output = logFile.Name()
+		defer os.Remove(logFile.Name()) // clean up
+
+		ctlr := makeController(conf, t.TempDir(), "")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		// create a blob/layer
-		resp, err := resty.R().Post(baseURL + "/v2/index/blobs/uploads/")
-		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc := test.Location(baseURL, resp)
-		So(loc, ShouldNotBeEmpty)
-
-		// since we are not specifying any prefix i.e provided in config while starting server,
-		// so it should store index1 to global root dir
-		_, err = os.Stat(path.Join(dir, "index"))
-		So(err, ShouldBeNil)
+		var extensionList distext.ExtensionList
 
-		resp, err = resty.R().Get(loc)
+		resp, err := resty.R().Get(baseURL + constants.RoutePrefix + constants.ExtOciDiscoverPrefix)
 		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
-		content := []byte("0123456789")
-		digest := godigest.FromBytes(content)
-		So(digest, ShouldNotBeNil)
-		// monolithic blob upload: success
-		resp, err = resty.R().SetQueryParam("digest", digest.String()).
-			SetHeader("Content-Type", "application/octet-stream").SetBody(content).Put(loc)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, 200)
+		err = json.Unmarshal(resp.Body(), &extensionList)
 		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-		blobLoc := resp.Header().Get("Location")
-		So(blobLoc, ShouldNotBeEmpty)
-		So(resp.Header().Get("Content-Length"), ShouldEqual, "0")
-		So(resp.Header().Get(constants.DistContentDigestKey), ShouldNotBeEmpty)
-		blobLoc = baseURL + blobLoc
-
-		Convey("Range is supported using 'bytes'", func() {
-			resp, err = resty.R().Head(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.Header().Get("Accept-Ranges"), ShouldEqual, "bytes")
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-		})
-
-		Convey("Get a range of bytes", func() {
-			resp, err = resty.R().SetHeader("Range", "bytes=0-").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
-			So(resp.Header().Get("Content-Length"), ShouldEqual, fmt.Sprintf("%d", len(content)))
-			So(resp.Body(), ShouldResemble, content)
+		So(len(extensionList.Extensions), ShouldEqual, 1)
+		So(len(extensionList.Extensions[0].Endpoints), ShouldEqual, 6)
+		So(extensionList.Extensions[0].Name, ShouldEqual, "_zot")
+		So(extensionList.Extensions[0].URL, ShouldContainSubstring, "_zot.md")
+		So(extensionList.Extensions[0].Description, ShouldNotBeEmpty)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullSearchPrefix)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullUserPreferencesPrefix)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullMgmtPrefix)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullDigestsPrefix)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullPinsPrefix)
+		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullAnnotationsPrefix)
+	})
 
-			resp, err = resty.R().SetHeader("Range", "bytes=0-100").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
-			So(resp.Header().Get("Content-Length"), ShouldEqual, fmt.Sprintf("%d", len(content)))
-			So(resp.Body(), ShouldResemble, content)
+	Convey("start minimal zot server", t, func(c C) {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			resp, err = resty.R().SetHeader("Range", "bytes=0-10").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
-			So(resp.Header().Get("Content-Length"), ShouldEqual, fmt.Sprintf("%d", len(content)))
-			So(resp.Body(), ShouldResemble, content)
+		conf.HTTP.Port = port
 
-			resp, err = resty.R().SetHeader("Range", "bytes=0-0").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
-			So(resp.Header().Get("Content-Length"), ShouldEqual, "1")
-			So(resp.Body(), ShouldResemble, content[0:1])
+		logFile, err := os.CreateTemp("", "zot-log*.txt")
+		So(err, ShouldBeNil)
+		conf.Log.Output = logFile.Name()
+		defer os.Remove(logFile.Name()) // clean up
 
-			resp, err = resty.R().SetHeader("Range", "bytes=0-1").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
-			So(resp.Header().Get("Content-Length"), ShouldEqual, "2")
-			So(resp.Body(), ShouldResemble, content[0:2])
+		ctlr := makeController(conf, t.TempDir(), "")
 
-			resp, err = resty.R().SetHeader("Range", "bytes=2-3").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
-			So(resp.Header().Get("Content-Length"), ShouldEqual, "2")
-			So(resp.Body(), ShouldResemble, content[2:4])
-		})
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-		Convey("Negative cases", func() {
-			resp, err = resty.R().SetHeader("Range", "=0").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		var extensionList distext.ExtensionList
+		resp, err := resty.R().Get(baseURL + constants.RoutePrefix + constants.ExtOciDiscoverPrefix)
+		So(err, ShouldBeNil)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, 200)
 
-			resp, err = resty.R().SetHeader("Range", "=a").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		err = json.Unmarshal(resp.Body(), &extensionList)
+		So(err, ShouldBeNil)
+		So(len(extensionList.Extensions), ShouldEqual, 0)
+	})
+}
 
-			resp, err = resty.R().SetHeader("Range", "").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+func TestHTTPOptionsResponse(t *testing.T) {
+	Convey("Test http options response", t, func() {
+		conf := config.New()
+		port := test.GetFreePort()
+		conf.HTTP.Port = port
+		baseURL := test.GetBaseURL(port)
 
-			resp, err = resty.R().SetHeader("Range", "=").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		ctlr := api.NewController(conf)
 
-			resp, err = resty.R().SetHeader("Range", "byte=").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		firstDir := t.TempDir()
 
-			resp, err = resty.R().SetHeader("Range", "bytes=").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		secondDir := t.TempDir()
+		defer os.RemoveAll(firstDir)
+		defer os.RemoveAll(secondDir)
 
-			resp, err = resty.R().SetHeader("Range", "byte=-0").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		ctlr.Config.Storage.RootDirectory = firstDir
+		subPaths := make(map[string]config.StorageConfig)
+		subPaths["/a"] = config.StorageConfig{
+			RootDirectory: secondDir,
+		}
 
-			resp, err = resty.R().SetHeader("Range", "byte=0").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		ctlr.Config.Storage.SubPaths = subPaths
+		ctrlManager := test.NewControllerManager(ctlr)
 
-			resp, err = resty.R().SetHeader("Range", "octet=-0").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		ctrlManager.StartAndWait(port)
 
-			resp, err = resty.R().SetHeader("Range", "bytes=-0").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		resp, _ := resty.R().Options(baseURL + constants.RoutePrefix + constants.ExtCatalogPrefix)
+		So(resp, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
 
-			resp, err = resty.R().SetHeader("Range", "bytes=1-0").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+		defer ctrlManager.StopServer()
+	})
+}
 
-			resp, err = resty.R().SetHeader("Range", "bytes=-1-0").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+func getAllBlobs(imagePath string) []string {
+	blobList := make([]string, 0)
 
-			resp, err = resty.R().SetHeader("Range", "bytes=-1--0").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+	if !common.DirExists(imagePath) {
+		return []string{}
+	}
 
-			resp, err = resty.R().SetHeader("Range", "bytes=1--2").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+	buf, err := os.ReadFile(path.Join(imagePath, "index.json"))
+	if err != nil {
+		panic(err)
+	}
 
-			resp, err = resty.R().SetHeader("Range", "bytes=0-a").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+	var index ispec.Index
+	if err := json.Unmarshal(buf, &index); err != nil {
+		panic(err)
+	}
 
-			resp, err = resty.R().SetHeader("Range", "bytes=a-10").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+	var digest godigest.Digest
 
-			resp, err = resty.R().SetHeader("Range", "bytes=a-b").Get(blobLoc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
-		})
-	})
-}
+	for _, m := range index.Manifests {
+		digest = m.Digest
+		blobList = append(blobList, digest.Encoded())
+		p := path.Join(imagePath, "blobs", digest.Algorithm().String(), digest.Encoded())
 
-func TestInjectInterruptedImageManifest(t *testing.T) {
-	Convey("Make a new controller", t, func() {
-		port := test.GetFreePort()
-		baseURL := test.GetBaseURL(port)
-		conf := config.New()
-		conf.HTTP.Port = port
+		buf, err = os.ReadFile(p)
 
-		dir := t.TempDir()
-		ctlr := makeController(conf, dir, "")
+		if err != nil {
+			panic(err)
+		}
 
-		cm := test.NewControllerManager(ctlr)
-		cm.StartAndWait(port)
-		defer cm.StopServer()
+		var manifest ispec.Manifest
+		if err := json.Unmarshal(buf, &manifest); err != nil {
+			panic(err)
+		}
 
-		rthdlr := api.NewRouteHandler(ctlr)
+		blobList = append(blobList, manifest.Config.Digest.Encoded())
 
-		Convey("Upload a blob & a config blob; Create an image manifest", func() {
-			// create a blob/layer
-			resp, err := resty.R().Post(baseURL + "/v2/repotest/blobs/uploads/")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-			loc := test.Location(baseURL, resp)
-			So(loc, ShouldNotBeEmpty)
+		for _, layer := range manifest.Layers {
+			blobList = append(blobList, layer.Digest.Encoded())
+		}
+	}
 
-			// since we are not specifying any prefix i.e provided in config while starting server,
-			// so it should store repotest to global root dir
-			_, err = os.Stat(path.Join(dir, "repotest"))
-			So(err, ShouldBeNil)
+	return blobList
+}
 
-			resp, err = resty.R().Get(loc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
-			content := []byte("this is a dummy blob")
-			digest := godigest.FromBytes(content)
-			So(digest, ShouldNotBeNil)
-			// monolithic blob upload: success
-			resp, err = resty.R().SetQueryParam("digest", digest.String()).
-				SetHeader("Content-Type", "application/octet-stream").SetBody(content).Put(loc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-			blobLoc := resp.Header().Get("Location")
-			So(blobLoc, ShouldNotBeEmpty)
-			So(resp.Header().Get("Content-Length"), ShouldEqual, "0")
-			So(resp.Header().Get(constants.DistContentDigestKey), ShouldNotBeEmpty)
+func getAllManifests(imagePath string) []string {
+	manifestList := make([]string, 0)
 
-			// upload image config blob
-			resp, err = resty.R().Post(baseURL + "/v2/repotest/blobs/uploads/")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-			loc = test.Location(baseURL, resp)
-			cblob, cdigest := test.GetRandomImageConfig()
+	if !common.DirExists(imagePath) {
+		return []string{}
+	}
 
-			resp, err = resty.R().
-				SetContentLength(true).
-				SetHeader("Content-Length", fmt.Sprintf("%d", len(cblob))).
-				SetHeader("Content-Type", "application/octet-stream").
-				SetQueryParam("digest", cdigest.String()).
-				SetBody(cblob).
-				Put(loc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+	buf, err := os.ReadFile(path.Join(imagePath, "index.json"))
+	if err != nil {
+		panic(err)
+	}
 
-			// create a manifest
-			manifest := ispec.Manifest{
-				Config: ispec.Descriptor{
-					MediaType: "application/vnd.oci.image.config.v1+json",
-					Digest:    cdigest,
-					Size:      int64(len(cblob)),
-				},
-				Layers: []ispec.Descriptor{
-					{
-						MediaType: "application/vnd.oci.image.layer.v1.tar",
-						Digest:    digest,
-						Size:      int64(len(content)),
-					},
-				},
-			}
-			manifest.SchemaVersion = 2
-			content, err = json.Marshal(manifest)
-			So(err, ShouldBeNil)
-			digest = godigest.FromBytes(content)
-			So(digest, ShouldNotBeNil)
+	var index ispec.Index
+	if err := json.Unmarshal(buf, &index); err != nil {
+		panic(err)
+	}
 
-			// Testing router path:  @Router /v2/{name}/manifests/{reference} [put]
-			Convey("Uploading an image manifest blob (when injected simulates an interrupted image manifest upload)", func() {
-				injected := inject.InjectFailure(0)
+	var digest godigest.Digest
 
-				request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
-				request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
-				request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
-				response := httptest.NewRecorder()
+	for _, m := range index.Manifests {
+		digest = m.Digest
+		manifestList = append(manifestList, digest.Encoded())
+	}
 
-				rthdlr.UpdateManifest(response, request)
+	return manifestList
+}
 
-				resp := response.Result()
-				defer resp.Body.Close()
+func makeController(conf *config.Config, dir string, copyTestDataDest string) *api.Controller {
+	ctlr := api.NewController(conf)
 
-				So(resp, ShouldNotBeNil)
+	if copyTestDataDest != "" {
+		test.CopyTestFiles(copyTestDataDest, dir)
+	}
+	ctlr.Config.Storage.RootDirectory = dir
 
-				if injected {
-					So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
-				} else {
-					So(resp.StatusCode, ShouldEqual, http.StatusCreated)
-				}
-			})
-		})
-	})
+	return ctlr
 }
 
-func TestInjectTooManyOpenFiles(t *testing.T) {
-	Convey("Make a new controller", t, func() {
+func TestQuota(t *testing.T) {
+	Convey("Enforce tag and repository count limits", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
+
 		conf := config.New()
 		conf.HTTP.Port = port
+		conf.HTTP.Quota = &config.QuotaConfig{
+			Default: config.QuotaLimits{MaxTags: 1, MaxRepos: 1},
+		}
 
-		dir := t.TempDir()
-		ctlr := makeController(conf, dir, "")
-		conf.Storage.RemoteCache = false
+		ctlr := makeController(conf, t.TempDir(), "")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		rthdlr := api.NewRouteHandler(ctlr)
-
-		// create a blob/layer
-		resp, err := resty.R().Post(baseURL + "/v2/repotest/blobs/uploads/")
+		img, err := test.GetRandomImage("1.0")
 		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc := test.Location(baseURL, resp)
-		So(loc, ShouldNotBeEmpty)
 
-		// since we are not specifying any prefix i.e provided in config while starting server,
-		// so it should store repotest to global root dir
-		_, err = os.Stat(path.Join(dir, "repotest"))
+		err = test.UploadImage(img, baseURL, "ns/repo1")
 		So(err, ShouldBeNil)
 
-		resp, err = resty.R().Get(loc)
+		// existing tags can be overwritten
+		err = test.UploadImage(img, baseURL, "ns/repo1")
 		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
-		content := []byte("this is a dummy blob")
-		digest := godigest.FromBytes(content)
-		So(digest, ShouldNotBeNil)
-
-		// monolithic blob upload
-		injected := inject.InjectFailure(0)
-		if injected {
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, loc, bytes.NewReader(content))
-			tokens := strings.Split(loc, "/")
-			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "session_id": tokens[len(tokens)-1]})
-			q := request.URL.Query()
-			q.Add("digest", digest.String())
-			request.URL.RawQuery = q.Encode()
-			request.Header.Set("Content-Type", "application/octet-stream")
-			request.Header.Set("Content-Length", fmt.Sprintf("%d", len(content)))
-			response := httptest.NewRecorder()
 
-			rthdlr.UpdateBlobUpload(response, request)
-
-			resp := response.Result()
-			defer resp.Body.Close()
+		manifestBlob, err := json.Marshal(img.Manifest)
+		So(err, ShouldBeNil)
 
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
-		} else {
-			resp, err = resty.R().SetQueryParam("digest", digest.String()).
-				SetHeader("Content-Type", "application/octet-stream").
-				SetBody(content).Put(loc)
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/ns/repo1/manifests/2.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			blobLoc := resp.Header().Get("Location")
-			So(blobLoc, ShouldNotBeEmpty)
-			So(resp.Header().Get("Content-Length"), ShouldEqual, "0")
-			So(resp.Header().Get(constants.DistContentDigestKey), ShouldNotBeEmpty)
-		}
+		var errList apiErr.ErrorList
 
-		// upload image config blob
-		resp, err = resty.R().Post(baseURL + "/v2/repotest/blobs/uploads/")
+		err = json.Unmarshal(resp.Body(), &errList)
 		So(err, ShouldBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
-		loc = test.Location(baseURL, resp)
-		cblob, cdigest := test.GetRandomImageConfig()
+		So(len(errList.Errors), ShouldEqual, 1)
+		So(errList.Errors[0].Code, ShouldEqual, apiErr.DENIED.String())
+		So(string(resp.Body()), ShouldContainSubstring, "maxTags")
 
-		resp, err = resty.R().
-			SetContentLength(true).
-			SetHeader("Content-Length", fmt.Sprintf("%d", len(cblob))).
-			SetHeader("Content-Type", "application/octet-stream").
-			SetQueryParam("digest", cdigest.String()).
-			SetBody(cblob).
-			Put(loc)
+		// pushing by digest doesn't add a tag
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/ns/repo1/manifests/" + godigest.FromBytes(manifestBlob).String())
 		So(err, ShouldBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-		// create a manifest
-		manifest := ispec.Manifest{
-			Config: ispec.Descriptor{
-				MediaType: "application/vnd.oci.image.config.v1+json",
-				Digest:    cdigest,
-				Size:      int64(len(cblob)),
-			},
-			Layers: []ispec.Descriptor{
-				{
-					MediaType: "application/vnd.oci.image.layer.v1.tar",
-					Digest:    digest,
-					Size:      int64(len(content)),
-				},
-			},
-		}
-		manifest.SchemaVersion = 2
-		content, err = json.Marshal(manifest)
+		resp, err = resty.R().Post(baseURL + "/v2/ns/repo2/blobs/uploads/")
 		So(err, ShouldBeNil)
-		digest = godigest.FromBytes(content)
-		So(digest, ShouldNotBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(string(resp.Body()), ShouldContainSubstring, "maxRepos")
 
-		// Testing router path:  @Router /v2/{name}/manifests/{reference} [put]
-		//nolint:lll // gofumpt conflicts with lll
-		Convey("Uploading an image manifest blob (when injected simulates that PutImageManifest failed due to 'too many open files' error)", func() {
-			injected := inject.InjectFailure(1)
+		resp, err = resty.R().Post(baseURL + "/v2/other/repo/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+	})
+}
 
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
-			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
-			request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
-			response := httptest.NewRecorder()
+func TestStorageQuota(t *testing.T) {
+	Convey("Enforce storage byte limits", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
 
-			rthdlr.UpdateManifest(response, request)
+		manifestBlob, err := json.Marshal(img.Manifest)
+		So(err, ShouldBeNil)
 
-			resp := response.Result()
-			So(resp, ShouldNotBeNil)
-			defer resp.Body.Close()
+		imageSize := int64(len(manifestBlob)) + img.Manifest.Config.Size
+		for _, layer := range img.Manifest.Layers {
+			imageSize += layer.Size
+		}
 
-			if injected {
-				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
-			} else {
-				So(resp.StatusCode, ShouldEqual, http.StatusCreated)
-			}
-		})
-		Convey("when injected simulates a `too many open files` error inside PutImageManifest method of img store", func() {
-			injected := inject.InjectFailure(2)
+		// the random images only differ by their layer, their config counts once in a repo
+		totalSize := 3*imageSize - img.Manifest.Config.Size
 
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
-			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
-			request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
-			response := httptest.NewRecorder()
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.Quota = &config.StorageQuotaConfig{
+			MaxBytes:     totalSize,
+			MaxRepoBytes: imageSize,
+			Repositories: map[string]int64{"large/**": 2 * imageSize},
+		}
 
-			rthdlr.UpdateManifest(response, request)
+		ctlr := makeController(conf, t.TempDir(), "")
 
-			resp := response.Result()
-			defer resp.Body.Close()
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			So(resp, ShouldNotBeNil)
+		err = test.UploadImage(img, baseURL, "repo")
+		So(err, ShouldBeNil)
 
-			if injected {
-				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
-			} else {
-				So(resp.StatusCode, ShouldEqual, http.StatusCreated)
-			}
-		})
-		Convey("code coverage: error inside PutImageManifest method of img store (unable to marshal JSON)", func() {
-			injected := inject.InjectFailure(1)
+		// the repo is full, the same image can still be tagged again
+		resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/repo/manifests/latest")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
-			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
-			request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
-			response := httptest.NewRecorder()
+		resp, err = resty.R().Post(baseURL + "/v2/repo/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusRequestEntityTooLarge)
 
-			rthdlr.UpdateManifest(response, request)
+		var errList apiErr.ErrorList
 
-			resp := response.Result()
-			defer resp.Body.Close()
+		err = json.Unmarshal(resp.Body(), &errList)
+		So(err, ShouldBeNil)
+		So(len(errList.Errors), ShouldEqual, 1)
+		So(errList.Errors[0].Code, ShouldEqual, apiErr.DENIED.String())
+		So(errList.Errors[0].Message, ShouldEqual, errors.ErrRepoStorageQuotaExceeded.Error())
 
-			So(resp, ShouldNotBeNil)
+		other, err := test.GetRandomImage("2.0")
+		So(err, ShouldBeNil)
 
-			if injected {
-				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
-			} else {
-				So(resp.StatusCode, ShouldEqual, http.StatusCreated)
-			}
-		})
-		Convey("code coverage: error inside PutImageManifest method of img store (umoci.OpenLayout error)", func() {
-			injected := inject.InjectFailure(3)
+		otherBlob, err := json.Marshal(other.Manifest)
+		So(err, ShouldBeNil)
 
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
-			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
-			request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
-			response := httptest.NewRecorder()
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(otherBlob).
+			Put(baseURL + "/v2/repo/manifests/2.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusRequestEntityTooLarge)
 
-			rthdlr.UpdateManifest(response, request)
+		// the repos matching a pattern have their own limit, the registry limit applies to all the repos
+		err = test.UploadImage(img, baseURL, "large/repo")
+		So(err, ShouldBeNil)
 
-			resp := response.Result()
-			defer resp.Body.Close()
+		err = test.UploadImage(other, baseURL, "large/repo")
+		So(err, ShouldBeNil)
 
-			So(resp, ShouldNotBeNil)
+		resp, err = resty.R().Post(baseURL + "/v2/new/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusRequestEntityTooLarge)
+		So(string(resp.Body()), ShouldContainSubstring, errors.ErrStorageQuotaExceeded.Error())
 
-			if injected {
-				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
-			} else {
-				So(resp.StatusCode, ShouldEqual, http.StatusCreated)
-			}
-		})
-		Convey("code coverage: error inside PutImageManifest method of img store (oci.GC)", func() {
-			injected := inject.InjectFailure(4)
+		// the usage is listed by the admin api
+		resp, err = resty.R().Get(baseURL + constants.FullQuotaPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPut, baseURL, bytes.NewReader(content))
-			request = mux.SetURLVars(request, map[string]string{"name": "repotest", "reference": "1.0"})
-			request.Header.Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
-			response := httptest.NewRecorder()
+		var list quota.UsageList
 
-			rthdlr.UpdateManifest(response, request)
+		err = json.Unmarshal(resp.Body(), &list)
+		So(err, ShouldBeNil)
+		So(list.Total, ShouldResemble, quota.Usage{Bytes: totalSize, MaxBytes: totalSize})
+		So(list.Repos, ShouldHaveLength, 2)
+		So(list.Repos[1], ShouldResemble, quota.Usage{Repo: "repo", Bytes: imageSize, MaxBytes: imageSize})
 
-			resp := response.Result()
-			defer resp.Body.Close()
+		resp, err = resty.R().Get(baseURL + constants.FullQuotaPrefix + "?repo=large/repo")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			So(resp, ShouldNotBeNil)
+		var usage quota.Usage
 
-			if injected {
-				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
-			} else {
-				So(resp.StatusCode, ShouldEqual, http.StatusCreated)
-			}
+		err = json.Unmarshal(resp.Body(), &usage)
+		So(err, ShouldBeNil)
+		So(usage, ShouldResemble, quota.Usage{
+			Repo:     "large/repo",
+			Bytes:    totalSize - imageSize,
+			MaxBytes: 2 * imageSize,
 		})
-		Convey("when index.json is not in json format", func() {
-			resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
-				SetBody(content).Put(baseURL + "/v2/repotest/manifests/v1.0")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
-			digestHdr := resp.Header().Get(constants.DistContentDigestKey)
-			So(digestHdr, ShouldNotBeEmpty)
-			So(digestHdr, ShouldEqual, digest.String())
 
-			indexFile := path.Join(dir, "repotest", "index.json")
-			_, err = os.Stat(indexFile)
-			So(err, ShouldBeNil)
-			indexContent := []byte(`not a JSON content`)
-			err = os.WriteFile(indexFile, indexContent, 0o600)
-			So(err, ShouldBeNil)
+		// deleting an image frees its bytes
+		resp, err = resty.R().Delete(baseURL + "/v2/large/repo/manifests/2.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-			resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
-				SetBody(content).Put(baseURL + "/v2/repotest/manifests/v1.1")
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusInternalServerError)
-		})
+		resp, err = resty.R().Post(baseURL + "/v2/new/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 	})
 }
 
-func TestGCSignaturesAndUntaggedManifests(t *testing.T) {
-	Convey("Make controller", t, func() {
-		repoName := "testrepo" //nolint:goconst
-		tag := "0.0.1"
-
+func TestAccessExplain(t *testing.T) {
+	Convey("Explain access control decisions", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
-		conf := config.New()
-		conf.HTTP.Port = port
-
-		ctlr := makeController(conf, t.TempDir(), "")
-
-		Convey("Garbage collect signatures without subject and manifests without tags", func(c C) {
-			dir := t.TempDir()
-			ctlr.Config.Storage.RootDirectory = dir
-			ctlr.Config.Storage.GC = true
-			ctlr.Config.Storage.GCDelay = 1 * time.Millisecond
 
-			test.CopyTestFiles("../../test/data/zot-test", path.Join(dir, repoName))
+		htpasswdPath := test.MakeHtpasswdFileFromString(getCredString("admin", "admin") + "\n" +
+			getCredString("alice", "alice") + "\n" + getCredString("bob", "bob"))
+		defer os.Remove(htpasswdPath)
 
-			cm := test.NewControllerManager(ctlr)
-			cm.StartServer()
-			cm.WaitServerToBeReady(port)
-			defer cm.StopServer()
+		logFile, err := os.CreateTemp("", "zot-log*.txt")
+		So(err, ShouldBeNil)
+		defer os.Remove(logFile.Name()) // clean up
 
-			resp, err := resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, tag))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			digest := godigest.FromBytes(resp.Body())
-			So(digest, ShouldNotBeEmpty)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Log.Output = logFile.Name()
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				"**": config.PolicyGroup{
+					DefaultPolicy: []string{"read"},
+				},
+				"apps/**": config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Groups:  []string{"dev"},
+							Actions: []string{"read", "create"},
+						},
+					},
+				},
+			},
+			AdminPolicy: config.Policy{
+				Users:   []string{"admin"},
+				Actions: []string{"read", "create", "update", "delete"},
+			},
+			Groups: config.Groups{
+				"dev": config.Group{Users: []string{"alice"}},
+			},
+			LogDenied: true,
+		}
 
-			cwd, err := os.Getwd()
-			So(err, ShouldBeNil)
-			defer func() { _ = os.Chdir(cwd) }()
-			tdir := t.TempDir()
-			_ = os.Chdir(tdir)
+		ctlr := makeController(conf, t.TempDir(), "")
 
-			// generate a keypair
-			os.Setenv("COSIGN_PASSWORD", "")
-			err = generate.GenerateKeyPairCmd(context.TODO(), "", "cosign", nil)
-			So(err, ShouldBeNil)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			image := fmt.Sprintf("localhost:%s/%s@%s", port, repoName, digest.String())
+		explainURL := baseURL + constants.FullAccessExplainPrefix
 
-			annotations := []string{fmt.Sprintf("tag=%s", tag)}
+		// only admins can explain decisions
+		resp, err := resty.R().SetBasicAuth("alice", "alice").
+			Get(explainURL + "?username=alice&repo=apps/app&action=create")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			// sign the image
-			err = sign.SignCmd(&options.RootOptions{Verbose: true, Timeout: 1 * time.Minute},
-				options.KeyOpts{KeyRef: path.Join(tdir, "cosign.key"), PassFunc: generate.GetPass},
-				options.SignOptions{
-					Registry:          options.RegistryOptions{AllowInsecure: true},
-					AnnotationOptions: options.AnnotationOptions{Annotations: annotations},
-					Upload:            true,
-				},
-				[]string{image})
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Get(explainURL + "?repo=apps/app&action=push")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
 
-			So(err, ShouldBeNil)
+		var decision api.AccessDecision
 
-			test.NotationPathLock.Lock()
-			defer test.NotationPathLock.Unlock()
+		// alice is in the dev group of the config
+		resp, err = resty.R().SetBasicAuth("admin", "admin").
+			Get(explainURL + "?username=alice&repo=apps/app&action=create")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			test.LoadNotationPath(tdir)
+		err = json.Unmarshal(resp.Body(), &decision)
+		So(err, ShouldBeNil)
+		So(decision.Allowed, ShouldBeTrue)
+		So(decision.Groups, ShouldResemble, []string{"dev"})
+		So(decision.MatchedPattern, ShouldEqual, "apps/**")
+		So(decision.Rules, ShouldHaveLength, 1)
+		So(decision.Rules[0].Kind, ShouldEqual, "policy")
+		So(decision.Rules[0].Matched, ShouldBeTrue)
 
-			// generate a keypair
-			err = test.GenerateNotationCerts(tdir, "good")
-			So(err, ShouldBeNil)
+		// the default policy of ** doesn't apply to the repos matched by a longer pattern
+		resp, err = resty.R().SetBasicAuth("admin", "admin").
+			Get(explainURL + "?username=bob&repo=apps/app&action=read")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			// sign the image
-			err = test.SignWithNotation("good", image, tdir)
-			So(err, ShouldBeNil)
+		decision = api.AccessDecision{}
 
-			// get cosign signature manifest
-			cosignTag := strings.Replace(digest.String(), ":", "-", 1) + "." + remote.SignatureTagSuffix
+		err = json.Unmarshal(resp.Body(), &decision)
+		So(err, ShouldBeNil)
+		So(decision.Allowed, ShouldBeFalse)
+		So(decision.MatchedPattern, ShouldEqual, "apps/**")
+		So(decision.Rules, ShouldHaveLength, 4)
 
-			resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, cosignTag))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		kinds := []string{}
+		for _, rule := range decision.Rules {
+			So(rule.Allowed, ShouldBeFalse)
 
-			// get notation signature manifest
-			resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
-				fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			kinds = append(kinds, rule.Kind)
+		}
 
-			var index ispec.Index
+		So(kinds, ShouldResemble, []string{"policy", "defaultPolicy", "anonymousPolicy", "adminPolicy"})
+		So(decision.Rules[1].Matched, ShouldBeTrue)
 
-			err = json.Unmarshal(resp.Body(), &index)
-			So(err, ShouldBeNil)
-			So(len(index.Manifests), ShouldEqual, 1)
+		// groups can be added to the ones of the config, e.g. the ldap groups of the user
+		resp, err = resty.R().SetBasicAuth("admin", "admin").
+			Get(explainURL + "?username=bob&groups=qa,dev&repo=apps/app&action=read")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			Convey("Trigger gcNotationSignatures() error", func() {
-				var refs ispec.Index
-				err = json.Unmarshal(resp.Body(), &refs)
+		decision = api.AccessDecision{}
 
-				err := os.Chmod(path.Join(dir, repoName, "blobs", "sha256", refs.Manifests[0].Digest.Encoded()), 0o000)
-				So(err, ShouldBeNil)
+		err = json.Unmarshal(resp.Body(), &decision)
+		So(err, ShouldBeNil)
+		So(decision.Allowed, ShouldBeTrue)
+		So(decision.Groups, ShouldResemble, []string{"qa", "dev"})
 
-				// trigger gc
-				cfg, layers, manifest, err := test.GetImageComponents(3)
-				So(err, ShouldBeNil)
+		// denied requests are logged with the rules evaluated
+		resp, err = resty.R().SetBasicAuth("bob", "bob").Get(baseURL + "/v2/apps/app/tags/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-				err = test.UploadImage(
-					test.Image{
-						Config:    cfg,
-						Layers:    layers,
-						Manifest:  manifest,
-						Reference: tag,
-					}, baseURL, repoName)
-				So(err, ShouldNotBeNil)
+		found, err := test.ReadLogFileAndSearchString(logFile.Name(), "access denied", 10*time.Second)
+		So(err, ShouldBeNil)
+		So(found, ShouldBeTrue)
 
-				err = os.Chmod(path.Join(dir, repoName, "blobs", "sha256", refs.Manifests[0].Digest.Encoded()), 0o755)
-				So(err, ShouldBeNil)
+		data, err := os.ReadFile(logFile.Name())
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, `"matchedPattern":"apps/**"`)
+		So(string(data), ShouldContainSubstring, `"kind":"defaultPolicy"`)
+	})
+}
 
-				content, err := os.ReadFile(path.Join(dir, repoName, "blobs", "sha256", refs.Manifests[0].Digest.Encoded()))
-				So(err, ShouldBeNil)
-				err = os.WriteFile(path.Join(dir, repoName, "blobs", "sha256", refs.Manifests[0].Digest.Encoded()), []byte("corrupt"), 0o600) //nolint:lll
-				So(err, ShouldBeNil)
+func TestBlocklist(t *testing.T) {
+	Convey("Deny pushing and pulling blocked digests", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-				err = test.UploadImage(
-					test.Image{
-						Config:    cfg,
-						Layers:    layers,
-						Manifest:  manifest,
-						Reference: tag,
-					}, baseURL, repoName)
-				So(err, ShouldNotBeNil)
+		auditPath := path.Join(t.TempDir(), "audit.log")
 
-				err = os.WriteFile(path.Join(dir, repoName, "blobs", "sha256", refs.Manifests[0].Digest.Encoded()), content, 0o600)
-				So(err, ShouldBeNil)
-			})
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Log.Audit = auditPath
 
-			// push an image without tag
-			cfg, layers, manifest, err := test.GetImageComponents(2)
-			So(err, ShouldBeNil)
+		ctlr := makeController(conf, t.TempDir(), "")
 
-			manifestBuf, err := json.Marshal(manifest)
-			So(err, ShouldBeNil)
-			untaggedManifestDigest := godigest.FromBytes(manifestBuf)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			err = test.UploadImage(
-				test.Image{
-					Config:    cfg,
-					Layers:    layers,
-					Manifest:  manifest,
-					Reference: untaggedManifestDigest.String(),
-				}, baseURL, repoName)
-			So(err, ShouldBeNil)
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
 
-			// overwrite image so that signatures will get invalidated and gc'ed
-			cfg, layers, manifest, err = test.GetImageComponents(3)
-			So(err, ShouldBeNil)
+		err = test.UploadImage(img, baseURL, "repo")
+		So(err, ShouldBeNil)
 
-			err = test.UploadImage(
-				test.Image{
-					Config:    cfg,
-					Layers:    layers,
-					Manifest:  manifest,
-					Reference: tag,
-				}, baseURL, repoName)
-			So(err, ShouldBeNil)
+		manifestBlob, err := json.Marshal(img.Manifest)
+		So(err, ShouldBeNil)
 
-			manifestBuf, err = json.Marshal(manifest)
-			So(err, ShouldBeNil)
-			newManifestDigest := godigest.FromBytes(manifestBuf)
+		layerDigest := img.Manifest.Layers[0].Digest
 
-			// both signatures should be gc'ed
-			resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, cosignTag))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		err = ctlr.Blocklist.SetConfigDigests([]string{layerDigest.String()})
+		So(err, ShouldBeNil)
 
-			resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
-				fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, digest.String()))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		// the image is quarantined, it can't be pulled anymore
+		resp, err := resty.R().Get(baseURL + "/v2/repo/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			err = json.Unmarshal(resp.Body(), &index)
-			So(err, ShouldBeNil)
-			So(len(index.Manifests), ShouldEqual, 0)
+		var errList apiErr.ErrorList
 
-			resp, err = resty.R().SetQueryParam("artifactType", notreg.ArtifactTypeNotation).Get(
-				fmt.Sprintf("%s/v2/%s/referrers/%s", baseURL, repoName, newManifestDigest.String()))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &errList)
+		So(err, ShouldBeNil)
+		So(len(errList.Errors), ShouldEqual, 1)
+		So(errList.Errors[0].Code, ShouldEqual, apiErr.DENIED.String())
+		So(errList.Errors[0].Message, ShouldEqual, errors.ErrDigestBlocked.Error())
+		So(string(resp.Body()), ShouldContainSubstring, layerDigest.String())
 
-			err = json.Unmarshal(resp.Body(), &index)
-			So(err, ShouldBeNil)
-			So(len(index.Manifests), ShouldEqual, 0)
+		resp, err = resty.R().Head(baseURL + "/v2/repo/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			// untagged image should also be gc'ed
-			resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, untaggedManifestDigest))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
-		})
+		resp, err = resty.R().Get(baseURL + "/v2/repo/blobs/" + layerDigest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-		Convey("Do not gc manifests which are part of a multiarch image", func(c C) {
-			dir := t.TempDir()
-			ctlr.Config.Storage.RootDirectory = dir
-			ctlr.Config.Storage.GC = true
-			ctlr.Config.Storage.GCDelay = 500 * time.Millisecond
+		resp, err = resty.R().Head(baseURL + "/v2/repo/blobs/" + layerDigest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			test.CopyTestFiles("../../test/data/zot-test", path.Join(dir, repoName))
+		// the config blob is not blocked
+		resp, err = resty.R().Head(baseURL + "/v2/repo/blobs/" + img.Manifest.Config.Digest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			cm := test.NewControllerManager(ctlr)
-			cm.StartAndWait(port)
-			defer cm.StopServer()
+		// pushes of the blocked digest or manifests referencing it are denied
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/other/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			resp, err := resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, tag))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			digest := godigest.FromBytes(resp.Body())
-			So(digest, ShouldNotBeEmpty)
+		resp, err = resty.R().SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", layerDigest.String()).SetBody(img.Layers[0]).
+			Post(baseURL + "/v2/other/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			// push an image index and make sure manifests contained by it are not gc'ed
-			// create an image index on upstream
-			var index ispec.Index
-			index.SchemaVersion = 2
-			index.MediaType = ispec.MediaTypeImageIndex
+		resp, err = resty.R().SetQueryParams(map[string]string{"mount": layerDigest.String(), "from": "repo"}).
+			Post(baseURL + "/v2/other/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-			// upload multiple manifests
-			for i := 0; i < 4; i++ {
-				config, layers, manifest, err := test.GetImageComponents(1000 + i)
-				So(err, ShouldBeNil)
+		resp, err = resty.R().Post(baseURL + "/v2/other/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-				manifestContent, err := json.Marshal(manifest)
-				So(err, ShouldBeNil)
+		resp, err = resty.R().SetHeader("Content-Type", "application/octet-stream").
+			SetQueryParam("digest", layerDigest.String()).SetBody(img.Layers[0]).
+			Put(baseURL + resp.Header().Get("Location"))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 
-				manifestDigest := godigest.FromBytes(manifestContent)
+		auditLog, err := os.ReadFile(auditPath)
+		So(err, ShouldBeNil)
+		So(string(auditLog), ShouldContainSubstring, "blocked digest")
+		So(string(auditLog), ShouldContainSubstring, layerDigest.String())
 
-				err = test.UploadImage(
-					test.Image{
-						Manifest:  manifest,
-						Config:    config,
-						Layers:    layers,
-						Reference: manifestDigest.String(),
-					},
-					baseURL,
-					repoName)
-				So(err, ShouldBeNil)
+		// once unblocked the image can be pulled again
+		err = ctlr.Blocklist.SetConfigDigests(nil)
+		So(err, ShouldBeNil)
 
-				index.Manifests = append(index.Manifests, ispec.Descriptor{
-					Digest:    manifestDigest,
-					MediaType: ispec.MediaTypeImageManifest,
-					Size:      int64(len(manifestContent)),
-				})
-			}
+		resp, err = resty.R().Get(baseURL + "/v2/repo/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+	})
 
-			content, err := json.Marshal(index)
-			So(err, ShouldBeNil)
-			indexDigest := godigest.FromBytes(content)
-			So(indexDigest, ShouldNotBeNil)
+	Convey("Invalid blocklist", t, func() {
+		conf := config.New()
+		conf.Storage.Blocklist = []string{"bogus"}
 
-			time.Sleep(1 * time.Second)
-			// upload image index
-			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-				SetBody(content).Put(baseURL + fmt.Sprintf("/v2/%s/manifests/latest", repoName))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		ctlr := makeController(conf, t.TempDir(), "")
 
-			resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageIndex).
-				Get(baseURL + fmt.Sprintf("/v2/%s/manifests/latest", repoName))
-			So(err, ShouldBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			So(resp.Body(), ShouldNotBeEmpty)
-			So(resp.Header().Get("Content-Type"), ShouldNotBeEmpty)
+		err := ctlr.InitBlocklist()
+		So(err, ShouldNotBeNil)
 
-			// make sure manifests which are part of image index are not gc'ed
-			for _, manifest := range index.Manifests {
-				resp, err = resty.R().Get(baseURL + fmt.Sprintf("/v2/%s/manifests/%s", repoName, manifest.Digest.String()))
-				So(err, ShouldBeNil)
-				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			}
-		})
+		conf.Storage.Blocklist = nil
+
+		err = os.WriteFile(path.Join(ctlr.Config.Storage.RootDirectory, blocklist.FileName), []byte("{"), 0o600)
+		So(err, ShouldBeNil)
+
+		err = ctlr.InitBlocklist()
+		So(err, ShouldNotBeNil)
 	})
 }
 
-func TestPeriodicGC(t *testing.T) {
-	Convey("Periodic gc enabled for default store", t, func() {
-		repoName := "testrepo" //nolint:goconst
-
+func TestExternalURL(t *testing.T) {
+	Convey("Location headers use the configured external URL", t, func() {
 		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
 		conf := config.New()
 		conf.HTTP.Port = port
-		conf.Storage.RemoteCache = false
-
-		logFile, err := os.CreateTemp("", "zot-log*.txt")
-		So(err, ShouldBeNil)
-		conf.Log.Output = logFile.Name()
-		defer os.Remove(logFile.Name()) // clean up
-
-		ctlr := api.NewController(conf)
-		dir := t.TempDir()
-		ctlr.Config.Storage.RootDirectory = dir
-		ctlr.Config.Storage.Dedupe = false
-		ctlr.Config.Storage.GC = true
-		ctlr.Config.Storage.GCInterval = 1 * time.Hour
-		ctlr.Config.Storage.GCDelay = 1 * time.Second
+		conf.HTTP.ExternalURL = "https://example.com/registry/"
 
-		test.CopyTestFiles("../../test/data/zot-test", path.Join(dir, repoName))
+		ctlr := makeController(conf, t.TempDir(), "")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		time.Sleep(5000 * time.Millisecond)
+		resp, err := resty.R().Post(baseURL + "/v2/repo/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(resp.Header().Get("Location"), ShouldStartWith, "https://example.com/registry/v2/repo/blobs/uploads/")
 
-		data, err := os.ReadFile(logFile.Name())
+		// the proxy headers are ignored
+		resp, err = resty.R().SetHeader("X-Forwarded-Host", "other.com").Post(baseURL + "/v2/repo/blobs/uploads/")
 		So(err, ShouldBeNil)
-		So(string(data), ShouldContainSubstring,
-			"\"GC\":true,\"Commit\":false,\"GCDelay\":1000000000,\"GCInterval\":3600000000000")
-		So(string(data), ShouldContainSubstring,
-			fmt.Sprintf("executing GC of orphaned blobs for %s", path.Join(ctlr.StoreController.DefaultStore.RootDir(), repoName))) //nolint:lll
-		So(string(data), ShouldContainSubstring,
-			fmt.Sprintf("GC successfully completed for %s", path.Join(ctlr.StoreController.DefaultStore.RootDir(), repoName))) //nolint:lll
+		So(resp.Header().Get("Location"), ShouldStartWith, "https://example.com/registry/v2/repo/blobs/uploads/")
 	})
 
-	Convey("Periodic GC enabled for substore", t, func() {
+	Convey("Location and Link headers honor the X-Forwarded headers of trusted proxies", t, func() {
 		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
 		conf := config.New()
 		conf.HTTP.Port = port
+		conf.HTTP.TrustedProxies = []string{"10.0.0.1", "127.0.0.0/8"}
 
-		logFile, err := os.CreateTemp("", "zot-log*.txt")
+		ctlr := makeController(conf, t.TempDir(), "")
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		img, err := test.GetRandomImage("1.0")
 		So(err, ShouldBeNil)
-		conf.Log.Output = logFile.Name()
-		defer os.Remove(logFile.Name()) // clean up
 
-		dir := t.TempDir()
-		ctlr := makeController(conf, dir, "")
-		subDir := t.TempDir()
+		err = test.UploadImage(img, baseURL, "repo")
+		So(err, ShouldBeNil)
 
-		subPaths := make(map[string]config.StorageConfig)
+		img.Reference = "2.0"
 
-		subPaths["/a"] = config.StorageConfig{RootDirectory: subDir, GC: true, GCDelay: 1 * time.Second, GCInterval: 24 * time.Hour, RemoteCache: false, Dedupe: false} //nolint:lll // gofumpt conflicts with lll
-		ctlr.Config.Storage.Dedupe = false
-		ctlr.Config.Storage.SubPaths = subPaths
+		err = test.UploadImage(img, baseURL, "repo")
+		So(err, ShouldBeNil)
+
+		proxyHeaders := map[string]string{
+			"X-Forwarded-Proto":  "https",
+			"X-Forwarded-Host":   "example.com",
+			"X-Forwarded-Prefix": "/registry",
+		}
+
+		resp, err := resty.R().SetHeaders(proxyHeaders).Post(baseURL + "/v2/repo/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(resp.Header().Get("Location"), ShouldStartWith, "https://example.com/registry/v2/repo/blobs/uploads/")
+
+		resp, err = resty.R().SetHeaders(proxyHeaders).Get(baseURL + "/v2/repo/tags/list?n=1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Link"), ShouldStartWith, "https://example.com/registry/v2/repo/tags/list?n=1&last=1.0")
+
+		// IPv6 literals are enclosed in brackets
+		resp, err = resty.R().SetHeaders(map[string]string{
+			"X-Forwarded-Proto": "https",
+			"X-Forwarded-Host":  "fd00::1",
+		}).Post(baseURL + "/v2/repo/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.Header().Get("Location"), ShouldStartWith, "https://[fd00::1]/v2/repo/blobs/uploads/")
+
+		// invalid values are ignored
+		resp, err = resty.R().SetHeaders(map[string]string{
+			"X-Forwarded-Proto":  "javascript",
+			"X-Forwarded-Host":   "example.com/other",
+			"X-Forwarded-Prefix": "../registry",
+		}).Post(baseURL + "/v2/repo/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.Header().Get("Location"), ShouldStartWith, "http://127.0.0.1:"+port+"/v2/repo/blobs/uploads/")
+
+		// without proxy headers the locations are relative
+		resp, err = resty.R().Post(baseURL + "/v2/repo/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.Header().Get("Location"), ShouldStartWith, "/v2/repo/blobs/uploads/")
+	})
+
+	Convey("The X-Forwarded headers of untrusted peers are ignored", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.TrustedProxies = []string{"10.0.0.0/8"}
+
+		ctlr := makeController(conf, t.TempDir(), "")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		data, err := os.ReadFile(logFile.Name())
+		resp, err := resty.R().SetHeader("X-Forwarded-Host", "example.com").Post(baseURL + "/v2/repo/blobs/uploads/")
 		So(err, ShouldBeNil)
-		// periodic GC is not enabled for default store
-		So(string(data), ShouldContainSubstring,
-			"\"GCDelay\":3600000000000,\"GCInterval\":0,\"")
-		// periodic GC is enabled for sub store
-		So(string(data), ShouldContainSubstring,
-			fmt.Sprintf("\"SubPaths\":{\"/a\":{\"RootDirectory\":\"%s\",\"Dedupe\":false,\"RemoteCache\":false,\"GC\":true,\"Commit\":false,\"GCDelay\":1000000000,\"GCInterval\":86400000000000", subDir)) //nolint:lll // gofumpt conflicts with lll
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(resp.Header().Get("Location"), ShouldStartWith, "/v2/repo/blobs/uploads/")
 	})
+}
 
-	Convey("Periodic gc error", t, func() {
-		repoName := "testrepo" //nolint:goconst
-
+func TestProblemDetails(t *testing.T) {
+	Convey("Errors are returned as problem details to the clients preferring them", t, func() {
 		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
 		conf := config.New()
 		conf.HTTP.Port = port
-		conf.Storage.RemoteCache = false
 
-		logFile, err := os.CreateTemp("", "zot-log*.txt")
-		So(err, ShouldBeNil)
-		conf.Log.Output = logFile.Name()
-		defer os.Remove(logFile.Name()) // clean up
+		ctlr := makeController(conf, t.TempDir(), "")
 
-		ctlr := api.NewController(conf)
-		dir := t.TempDir()
-		ctlr.Config.Storage.RootDirectory = dir
-		ctlr.Config.Storage.Dedupe = false
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-		ctlr.Config.Storage.GC = true
-		ctlr.Config.Storage.GCInterval = 1 * time.Hour
-		ctlr.Config.Storage.GCDelay = 1 * time.Second
+		// the OCI error body is returned by default
+		resp, err := resty.R().SetHeader("Accept", "*/*").Get(baseURL + "/v2/repo/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, constants.DefaultMediaType)
+		So(resp.Header().Get(constants.RequestIDHeader), ShouldNotBeEmpty)
 
-		test.CopyTestFiles("../../test/data/zot-test", path.Join(dir, repoName))
+		var errList apiErr.ErrorList
+		err = json.Unmarshal(resp.Body(), &errList)
+		So(err, ShouldBeNil)
+		So(errList.Errors, ShouldHaveLength, 1)
 
-		So(os.Chmod(dir, 0o000), ShouldBeNil)
+		resp, err = resty.R().SetHeader("Accept", "application/json, application/problem+json;q=0.5").
+			Get(baseURL + "/v2/repo/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, constants.DefaultMediaType)
 
-		defer func() {
-			So(os.Chmod(dir, 0o755), ShouldBeNil)
-		}()
+		resp, err = resty.R().SetHeader("Accept", "application/problem+json, application/json;q=0.9").
+			SetHeader(constants.RequestIDHeader, "client-request-1").
+			Get(baseURL + "/v2/repo/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, constants.ProblemMediaType)
+		So(resp.Header().Get(constants.RequestIDHeader), ShouldEqual, "client-request-1")
 
-		cm := test.NewControllerManager(ctlr)
-		cm.StartAndWait(port)
-		defer cm.StopServer()
+		var problem apiErr.Problem
+		err = json.Unmarshal(resp.Body(), &problem)
+		So(err, ShouldBeNil)
+		So(problem.Status, ShouldEqual, http.StatusNotFound)
+		So(problem.Title, ShouldEqual, http.StatusText(http.StatusNotFound))
+		So(problem.Code, ShouldEqual, "NAME_UNKNOWN")
+		So(problem.Instance, ShouldEqual, "/v2/repo/manifests/1.0")
+		So(problem.RequestID, ShouldEqual, "client-request-1")
+		So(problem.Docs, ShouldEqual, apiErr.DefaultDocsURL)
+		So(problem.Errors, ShouldHaveLength, 1)
+		So(problem.Errors[0].Code, ShouldEqual, "NAME_UNKNOWN")
 
-		time.Sleep(5000 * time.Millisecond)
+		// successful responses are unchanged
+		resp, err = resty.R().SetHeader("Accept", constants.ProblemMediaType).Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, constants.DefaultMediaType)
 
-		data, err := os.ReadFile(logFile.Name())
+		// malformed request IDs are replaced
+		resp, err = resty.R().SetHeader(constants.RequestIDHeader, "bad id").Get(baseURL + "/v2/")
 		So(err, ShouldBeNil)
-		So(string(data), ShouldContainSubstring,
-			"\"GC\":true,\"Commit\":false,\"GCDelay\":1000000000,\"GCInterval\":3600000000000")
-		So(string(data), ShouldContainSubstring, "failure walking storage root-dir") //nolint:lll
+		So(resp.Header().Get(constants.RequestIDHeader), ShouldNotEqual, "bad id")
+		So(resp.Header().Get(constants.RequestIDHeader), ShouldNotBeEmpty)
 	})
 }
 
-func TestSearchRoutes(t *testing.T) {
-	Convey("Upload image for test", t, func(c C) {
+func TestBlobUploadProgress(t *testing.T) {
+	Convey("Upload progress is returned to clients asking for it", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
+
 		conf := config.New()
 		conf.HTTP.Port = port
-		tempDir := t.TempDir()
 
-		ctlr := makeController(conf, tempDir, "")
-		cm := test.NewControllerManager(ctlr)
+		ctlr := makeController(conf, t.TempDir(), "")
 
+		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		repoName := "testrepo" //nolint:goconst
-		inaccessibleRepo := "inaccessible"
+		resp, err := resty.R().Post(baseURL + "/v2/repo/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-		cfg, layers, manifest, err := test.GetImageComponents(10000)
+		loc := test.Location(baseURL, resp)
+		content := []byte("this is a blob")
+
+		resp, err = resty.R().SetHeader("Content-Length", strconv.Itoa(len(content))).
+			SetHeader("Content-Range", fmt.Sprintf("0-%d", len(content)-1)).
+			SetHeader("Content-Type", "application/octet-stream").SetBody(content).Patch(loc)
 		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-		err = test.UploadImage(
-			test.Image{
-				Config:    cfg,
-				Layers:    layers,
-				Manifest:  manifest,
-				Reference: "latest",
-			}, baseURL, repoName)
+		// the response defined by the spec is returned by default
+		resp, err = resty.R().SetHeader("Accept", "*/*").Get(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
+		So(resp.Header().Get("Range"), ShouldEqual, fmt.Sprintf("0-%d", len(content)-1))
 
+		resp, err = resty.R().SetHeader("Accept", constants.DefaultMediaType).Get(loc)
 		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Range"), ShouldEqual, fmt.Sprintf("0-%d", len(content)-1))
 
-		// data for the inaccessible repo
-		cfg, layers, manifest, err = test.GetImageComponents(10000)
+		var progress api.BlobUploadProgress
+		err = json.Unmarshal(resp.Body(), &progress)
 		So(err, ShouldBeNil)
+		So(progress.Repository, ShouldEqual, "repo")
+		So(progress.SessionID, ShouldNotBeEmpty)
+		So(progress.BytesReceived, ShouldEqual, len(content))
 
-		err = test.UploadImage(
-			test.Image{
-				Config:    cfg,
-				Layers:    layers,
-				Manifest:  manifest,
-				Reference: "latest",
-			}, baseURL, inaccessibleRepo)
+		// the progress is streamed until the upload is completed
+		request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, loc, nil)
+		So(err, ShouldBeNil)
+		request.Header.Set("Accept", constants.EventStreamMediaType)
 
+		stream, err := http.DefaultClient.Do(request)
 		So(err, ShouldBeNil)
+		defer stream.Body.Close()
 
-		Convey("GlobalSearch with authz enabled", func(c C) {
-			conf := config.New()
-			port := test.GetFreePort()
-			baseURL := test.GetBaseURL(port)
+		So(stream.StatusCode, ShouldEqual, http.StatusOK)
+		So(stream.Header.Get("Content-Type"), ShouldEqual, constants.EventStreamMediaType)
 
-			user1 := "test"
-			password1 := "test"
-			testString1 := getCredString(user1, password1)
-			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
-			defer os.Remove(htpasswdPath)
-			conf.HTTP.Auth = &config.AuthConfig{
-				HTPasswd: config.AuthHTPasswd{
-					Path: htpasswdPath,
-				},
-			}
+		reader := bufio.NewReader(stream.Body)
 
-			conf.HTTP.Port = port
+		line, err := reader.ReadString('\n')
+		So(err, ShouldBeNil)
+		So(line, ShouldEqual, "event: progress\n")
 
-			defaultVal := true
+		line, err = reader.ReadString('\n')
+		So(err, ShouldBeNil)
+		So(line, ShouldContainSubstring, fmt.Sprintf(`"bytesReceived":%d`, len(content)))
 
-			searchConfig := &extconf.SearchConfig{
-				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-			}
+		resp, err = resty.R().SetQueryParam("digest", godigest.FromBytes(content).String()).Put(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-			conf.Extensions = &extconf.ExtensionConfig{
-				Search: searchConfig,
-			}
+		body, err := io.ReadAll(reader)
+		So(err, ShouldBeNil)
+		So(string(body), ShouldContainSubstring, "event: done\n")
+	})
+}
 
-			conf.HTTP.AccessControl = &config.AccessControlConfig{
-				Repositories: config.Repositories{
-					repoName: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Users:   []string{user1},
-								Actions: []string{"read", "create"},
-							},
-						},
-						DefaultPolicy: []string{},
-					},
-					inaccessibleRepo: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Users:   []string{user1},
-								Actions: []string{"create"},
-							},
-						},
-						DefaultPolicy: []string{},
-					},
-				},
-				AdminPolicy: config.Policy{
-					Users:   []string{},
-					Actions: []string{},
-				},
-			}
+func TestCompression(t *testing.T) {
+	Convey("JSON responses are compressed for the clients accepting it", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			ctlr := makeController(conf, tempDir, "")
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Compression = &config.CompressionConfig{MinSize: 16}
 
-			cm := test.NewControllerManager(ctlr)
-			cm.StartAndWait(port)
-			defer cm.StopServer()
+		ctlr := makeController(conf, t.TempDir(), "")
 
-			cfg, layers, manifest, err := test.GetImageComponents(10000)
-			So(err, ShouldBeNil)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			err = test.UploadImageWithBasicAuth(
-				test.Image{
-					Config:    cfg,
-					Layers:    layers,
-					Manifest:  manifest,
-					Reference: "latest",
-				}, baseURL, repoName,
-				user1, password1)
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
 
-			So(err, ShouldBeNil)
+		err = test.UploadImage(img, baseURL, "repo")
+		So(err, ShouldBeNil)
 
-			// data for the inaccessible repo
-			cfg, layers, manifest, err = test.GetImageComponents(10000)
+		getTags := func(acceptEncoding string) (*http.Response, []byte) {
+			request, err := http.NewRequestWithContext(context.Background(), http.MethodGet,
+				baseURL+"/v2/repo/tags/list", nil)
 			So(err, ShouldBeNil)
 
-			err = test.UploadImageWithBasicAuth(
-				test.Image{
-					Config:    cfg,
-					Layers:    layers,
-					Manifest:  manifest,
-					Reference: "latest",
-				}, baseURL, inaccessibleRepo,
-				user1, password1)
+			if acceptEncoding != "" {
+				request.Header.Set("Accept-Encoding", acceptEncoding)
+			}
 
+			response, err := http.DefaultClient.Do(request)
 			So(err, ShouldBeNil)
+			defer response.Body.Close()
 
-			query := `
-				{
-					GlobalSearch(query:"testrepo"){
-						Repos {
-							Name
-							NewestImage {
-								RepoName
-								Tag
-							}
-						}
-					}
-				}`
-			resp, err := resty.R().SetBasicAuth(user1, password1).Get(baseURL + constants.FullSearchPrefix +
-				"?query=" + url.QueryEscape(query))
+			body, err := io.ReadAll(response.Body)
 			So(err, ShouldBeNil)
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode(), ShouldEqual, 200)
-			So(string(resp.Body()), ShouldContainSubstring, repoName)
-			So(string(resp.Body()), ShouldNotContainSubstring, inaccessibleRepo)
 
-			resp, err = resty.R().Get(baseURL + constants.FullSearchPrefix + "?query=" + url.QueryEscape(query))
-			So(err, ShouldBeNil)
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+			return response, body
+		}
 
-			conf.HTTP.AccessControl = &config.AccessControlConfig{
-				Repositories: config.Repositories{
-					repoName: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Users:   []string{user1},
-								Actions: []string{},
-							},
-						},
-						DefaultPolicy: []string{},
-					},
-					inaccessibleRepo: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Users:   []string{},
-								Actions: []string{},
-							},
-						},
-						DefaultPolicy: []string{},
-					},
-				},
-				AdminPolicy: config.Policy{
-					Users:   []string{},
-					Actions: []string{},
-				},
-			}
+		response, body := getTags("gzip;q=0.5, zstd")
+		So(response.StatusCode, ShouldEqual, http.StatusOK)
+		So(response.Header.Get("Content-Encoding"), ShouldEqual, "zstd")
+		So(response.Header.Get("Vary"), ShouldContainSubstring, "Accept-Encoding")
 
-			// authenticated, but no access to resource
-			resp, err = resty.R().SetBasicAuth(user1, password1).Get(baseURL + constants.FullSearchPrefix +
-				"?query=" + url.QueryEscape(query))
-			So(err, ShouldBeNil)
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
-			So(string(resp.Body()), ShouldNotContainSubstring, repoName)
-			So(string(resp.Body()), ShouldNotContainSubstring, inaccessibleRepo)
-		})
+		decoder, err := zstd.NewReader(bytes.NewReader(body))
+		So(err, ShouldBeNil)
 
-		Convey("Testing group permissions", func(c C) {
-			conf := config.New()
-			port := test.GetFreePort()
-			baseURL := test.GetBaseURL(port)
+		decoded, err := io.ReadAll(decoder)
+		So(err, ShouldBeNil)
+		decoder.Close()
+		So(string(decoded), ShouldContainSubstring, `"tags":["1.0"]`)
+
+		response, body = getTags("gzip")
+		So(response.Header.Get("Content-Encoding"), ShouldEqual, "gzip")
+
+		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		So(err, ShouldBeNil)
+
+		decoded, err = io.ReadAll(gzipReader)
+		So(err, ShouldBeNil)
+		So(string(decoded), ShouldContainSubstring, `"tags":["1.0"]`)
+
+		response, body = getTags("identity")
+		So(response.Header.Get("Content-Encoding"), ShouldBeEmpty)
+		So(string(body), ShouldContainSubstring, `"tags":["1.0"]`)
+
+		// errors aren't compressed
+		request, err := http.NewRequestWithContext(context.Background(), http.MethodGet,
+			baseURL+"/v2/missing/tags/list", nil)
+		So(err, ShouldBeNil)
+		request.Header.Set("Accept-Encoding", "gzip")
+
+		response, err = http.DefaultClient.Do(request)
+		So(err, ShouldBeNil)
+		So(response.StatusCode, ShouldEqual, http.StatusNotFound)
+		So(response.Header.Get("Content-Encoding"), ShouldBeEmpty)
+		response.Body.Close()
+	})
+}
 
-			user1 := "test1"
-			password1 := "test1"
-			group1 := "testgroup3"
-			testString1 := getCredString(user1, password1)
-			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
-			defer os.Remove(htpasswdPath)
-			conf.HTTP.Auth = &config.AuthConfig{
-				HTPasswd: config.AuthHTPasswd{
-					Path: htpasswdPath,
-				},
-			}
+func TestCompressedManifestUpload(t *testing.T) {
+	Convey("Manifests uploaded with Content-Encoding: gzip are decompressed", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			conf.HTTP.Port = port
+		conf := config.New()
+		conf.HTTP.Port = port
 
-			defaultVal := true
+		ctlr := makeController(conf, t.TempDir(), "")
 
-			searchConfig := &extconf.SearchConfig{
-				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-			}
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			conf.Extensions = &extconf.ExtensionConfig{
-				Search: searchConfig,
-			}
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
 
-			conf.HTTP.AccessControl = &config.AccessControlConfig{
-				Groups: config.Groups{
-					group1: {
-						Users: []string{user1},
-					},
-				},
-				Repositories: config.Repositories{
-					repoName: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Groups:  []string{group1},
-								Actions: []string{"read", "create"},
-							},
-						},
-						DefaultPolicy: []string{},
-					},
-				},
-				AdminPolicy: config.Policy{
-					Users:   []string{},
-					Actions: []string{},
-				},
-			}
+		err = test.UploadImage(img, baseURL, "repo")
+		So(err, ShouldBeNil)
 
-			ctlr := makeController(conf, tempDir, "")
+		resp, err := resty.R().SetHeader("Accept", ispec.MediaTypeImageManifest).
+			Get(baseURL + "/v2/repo/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			cm := test.NewControllerManager(ctlr)
-			cm.StartAndWait(port)
-			defer cm.StopServer()
+		manifestBlob := resp.Body()
 
-			cfg, layers, manifest, err := test.GetImageComponents(10000)
+		compress := func(content []byte) []byte {
+			var buf bytes.Buffer
+
+			writer := gzip.NewWriter(&buf)
+			_, err := writer.Write(content)
 			So(err, ShouldBeNil)
+			So(writer.Close(), ShouldBeNil)
 
-			err = test.UploadImageWithBasicAuth(
-				test.Image{
-					Config:   cfg,
-					Layers:   layers,
-					Manifest: manifest,
-				}, baseURL, repoName,
-				user1, password1)
+			return buf.Bytes()
+		}
 
-			So(err, ShouldBeNil)
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+			SetHeader("Content-Encoding", "gzip").SetBody(compress(manifestBlob)).
+			Put(baseURL + "/v2/repo/manifests/2.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, godigest.FromBytes(manifestBlob).String())
 
-			query := `
-						{
-							GlobalSearch(query:"testrepo"){
-								Repos {
-									Name
-									NewestImage {
-										RepoName
-										Tag
-									}
-								}
-							}
-						}`
-			resp, err := resty.R().SetBasicAuth(user1, password1).Get(baseURL + constants.FullSearchPrefix +
-				"?query=" + url.QueryEscape(query))
-			So(err, ShouldBeNil)
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode(), ShouldEqual, 200)
-		})
+		resp, err = resty.R().SetHeader("Accept", ispec.MediaTypeImageManifest).
+			Get(baseURL + "/v2/repo/manifests/2.0")
+		So(err, ShouldBeNil)
+		So(resp.Body(), ShouldResemble, manifestBlob)
 
-		Convey("Testing group permissions when the user is part of more groups with different permissions", func(c C) {
-			conf := config.New()
-			port := test.GetFreePort()
-			baseURL := test.GetBaseURL(port)
+		// invalid gzip content
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+			SetHeader("Content-Encoding", "gzip").SetBody(manifestBlob).
+			Put(baseURL + "/v2/repo/manifests/3.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
 
-			user1 := "test2"
-			password1 := "test2"
-			group1 := "testgroup1"
-			group2 := "secondtestgroup"
-			testString1 := getCredString(user1, password1)
-			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
-			defer os.Remove(htpasswdPath)
-			conf.HTTP.Auth = &config.AuthConfig{
-				HTPasswd: config.AuthHTPasswd{
-					Path: htpasswdPath,
-				},
-			}
+		// too large once decompressed
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+			SetHeader("Content-Encoding", "gzip").
+			SetBody(compress(bytes.Repeat([]byte(" "), constants.MaxDecompressedManifestSize+1))).
+			Put(baseURL + "/v2/repo/manifests/3.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusRequestEntityTooLarge)
 
-			conf.HTTP.Port = port
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).
+			SetHeader("Content-Encoding", "br").SetBody(manifestBlob).
+			Put(baseURL + "/v2/repo/manifests/3.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnsupportedMediaType)
 
-			defaultVal := true
+		resp, err = resty.R().Get(baseURL + "/v2/repo/manifests/3.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+}
 
-			searchConfig := &extconf.SearchConfig{
-				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-			}
+func TestDockerManifestUpload(t *testing.T) {
+	Convey("Docker manifests are accepted and schema1 ones can be rejected", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			conf.Extensions = &extconf.ExtensionConfig{
-				Search: searchConfig,
-			}
+		conf := config.New()
+		conf.HTTP.Port = port
 
-			conf.HTTP.AccessControl = &config.AccessControlConfig{
-				Groups: config.Groups{
-					group1: {
-						Users: []string{user1},
-					},
-				},
-				Repositories: config.Repositories{
-					repoName: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Groups:  []string{group1},
-								Actions: []string{"delete"},
-							},
-							{
-								Groups:  []string{group2},
-								Actions: []string{"read", "create"},
-							},
-						},
-						DefaultPolicy: []string{},
-					},
-				},
-				AdminPolicy: config.Policy{
-					Users:   []string{},
-					Actions: []string{},
-				},
-			}
+		ctlr := makeController(conf, t.TempDir(), "")
 
-			ctlr := makeController(conf, tempDir, "")
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			cm := test.NewControllerManager(ctlr)
-			cm.StartAndWait(port)
-			defer cm.StopServer()
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
 
-			cfg, layers, manifest, err := test.GetImageComponents(10000)
-			So(err, ShouldBeNil)
+		err = test.UploadImage(img, baseURL, "repo")
+		So(err, ShouldBeNil)
 
-			err = test.UploadImageWithBasicAuth(
-				test.Image{
-					Config:   cfg,
-					Layers:   layers,
-					Manifest: manifest,
-				}, baseURL, repoName,
-				user1, password1)
+		manifest := img.Manifest
+		manifest.MediaType = common.MediaTypeDockerManifest
+		manifest.Config.MediaType = common.MediaTypeDockerImageConfig
 
-			So(err, ShouldNotBeNil)
-		})
+		manifestBlob, err := json.Marshal(manifest)
+		So(err, ShouldBeNil)
 
-		Convey("Testing group permissions when group has less permissions than user", func(c C) {
-			conf := config.New()
-			port := test.GetFreePort()
-			baseURL := test.GetBaseURL(port)
+		resp, err := resty.R().SetHeader("Content-Type", common.MediaTypeDockerManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/repo/manifests/docker")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-			user1 := "test3"
-			password1 := "test3"
-			group1 := "testgroup"
-			testString1 := getCredString(user1, password1)
-			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
-			defer os.Remove(htpasswdPath)
-			conf.HTTP.Auth = &config.AuthConfig{
-				HTPasswd: config.AuthHTPasswd{
-					Path: htpasswdPath,
+		manifestList := ispec.Index{
+			MediaType: common.MediaTypeDockerManifestList,
+			Manifests: []ispec.Descriptor{
+				{
+					MediaType: common.MediaTypeDockerManifest,
+					Digest:    godigest.FromBytes(manifestBlob),
+					Size:      int64(len(manifestBlob)),
 				},
-			}
+			},
+		}
+		manifestList.SchemaVersion = 2
 
-			conf.HTTP.Port = port
+		manifestListBlob, err := json.Marshal(manifestList)
+		So(err, ShouldBeNil)
 
-			defaultVal := true
+		resp, err = resty.R().SetHeader("Content-Type", common.MediaTypeDockerManifestList).
+			SetBody(manifestListBlob).Put(baseURL + "/v2/repo/manifests/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-			searchConfig := &extconf.SearchConfig{
-				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-			}
+		resp, err = resty.R().Get(baseURL + "/v2/repo/manifests/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, common.MediaTypeDockerManifestList)
 
-			conf.Extensions = &extconf.ExtensionConfig{
-				Search: searchConfig,
-			}
+		schema1Blob, err := json.Marshal(common.Schema1Manifest{
+			SchemaVersion: 1,
+			Name:          "repo",
+			Tag:           "schema1",
+			FSLayers:      []common.Schema1FSLayer{{BlobSum: godigest.FromBytes(img.Layers[0])}},
+		})
+		So(err, ShouldBeNil)
 
-			conf.HTTP.AccessControl = &config.AccessControlConfig{
-				Groups: config.Groups{
-					group1: {
-						Users: []string{user1},
-					},
-				},
-				Repositories: config.Repositories{
-					repoName: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Groups:  []string{group1},
-								Actions: []string{"delete"},
-							},
-							{
-								Users:   []string{user1},
-								Actions: []string{"read", "create", "delete"},
-							},
-						},
-						DefaultPolicy: []string{},
-					},
-				},
-				AdminPolicy: config.Policy{
-					Users:   []string{},
-					Actions: []string{},
-				},
-			}
+		resp, err = resty.R().SetHeader("Content-Type", common.MediaTypeDockerSchema1SignedManifest).
+			SetBody(schema1Blob).Put(baseURL + "/v2/repo/manifests/schema1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		resp, err = resty.R().Get(baseURL + "/v2/repo/manifests/schema1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, common.MediaTypeDockerSchema1SignedManifest)
+
+		ctlr.Config.Storage.RejectSchema1 = true
+
+		resp, err = resty.R().SetHeader("Content-Type", common.MediaTypeDockerSchema1SignedManifest).
+			SetBody(schema1Blob).Put(baseURL + "/v2/repo/manifests/rejected")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		So(string(resp.Body()), ShouldContainSubstring, "schema1 manifests are deprecated")
+
+		// docker schema2 manifests are still accepted
+		resp, err = resty.R().SetHeader("Content-Type", common.MediaTypeDockerManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/repo/manifests/docker2")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+	})
+}
 
-			ctlr := makeController(conf, tempDir, "")
+func TestConvertDockerManifestUpload(t *testing.T) {
+	Convey("Docker manifests are converted to oci when pushed", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			cm := test.NewControllerManager(ctlr)
-			cm.StartAndWait(port)
-			defer cm.StopServer()
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.ConvertDockerToOCI = true
 
-			cfg, layers, manifest, err := test.GetImageComponents(10000)
-			So(err, ShouldBeNil)
+		ctlr := makeController(conf, t.TempDir(), "")
 
-			err = test.UploadImageWithBasicAuth(
-				test.Image{
-					Config:   cfg,
-					Layers:   layers,
-					Manifest: manifest,
-				}, baseURL, repoName,
-				user1, password1)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			So(err, ShouldBeNil)
-		})
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
 
-		Convey("Testing group permissions when user has less permissions than group", func(c C) {
-			conf := config.New()
-			port := test.GetFreePort()
-			baseURL := test.GetBaseURL(port)
+		err = test.UploadImage(img, baseURL, "repo")
+		So(err, ShouldBeNil)
 
-			user1 := "test4"
-			password1 := "test4"
-			group1 := "testgroup1"
-			testString1 := getCredString(user1, password1)
-			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
-			defer os.Remove(htpasswdPath)
-			conf.HTTP.Auth = &config.AuthConfig{
-				HTPasswd: config.AuthHTPasswd{
-					Path: htpasswdPath,
-				},
-			}
+		manifest := img.Manifest
+		manifest.MediaType = common.MediaTypeDockerManifest
+		manifest.Config.MediaType = common.MediaTypeDockerImageConfig
 
-			conf.HTTP.Port = port
+		for idx := range manifest.Layers {
+			manifest.Layers[idx].MediaType = common.MediaTypeDockerLayerGzip
+		}
 
-			defaultVal := true
+		manifestBlob, err := json.Marshal(manifest)
+		So(err, ShouldBeNil)
 
-			searchConfig := &extconf.SearchConfig{
-				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-			}
+		manifestDigest := godigest.FromBytes(manifestBlob)
 
-			conf.Extensions = &extconf.ExtensionConfig{
-				Search: searchConfig,
-			}
+		resp, err := resty.R().SetHeader("Content-Type", common.MediaTypeDockerManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/repo/manifests/" + manifestDigest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-			conf.HTTP.AccessControl = &config.AccessControlConfig{
-				Groups: config.Groups{
-					group1: {
-						Users: []string{user1},
-					},
-				},
-				Repositories: config.Repositories{
-					repoName: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Groups:  []string{group1},
-								Actions: []string{"read", "create", "delete"},
-							},
-							{
-								Users:   []string{user1},
-								Actions: []string{"delete"},
-							},
-						},
-						DefaultPolicy: []string{},
-					},
-				},
-				AdminPolicy: config.Policy{
-					Users:   []string{},
-					Actions: []string{},
+		convertedDigest := resp.Header().Get(constants.DistContentDigestKey)
+		So(convertedDigest, ShouldNotEqual, manifestDigest.String())
+
+		// the digest it was pushed with resolves to the converted manifest
+		resp, err = resty.R().Get(baseURL + "/v2/repo/manifests/" + manifestDigest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, ispec.MediaTypeImageManifest)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, convertedDigest)
+
+		var convertedManifest ispec.Manifest
+		err = json.Unmarshal(resp.Body(), &convertedManifest)
+		So(err, ShouldBeNil)
+		So(convertedManifest.Config.MediaType, ShouldEqual, ispec.MediaTypeImageConfig)
+		So(convertedManifest.Layers[0].MediaType, ShouldEqual, ispec.MediaTypeImageLayerGzip)
+		So(convertedManifest.Layers[0].Digest, ShouldEqual, img.Manifest.Layers[0].Digest)
+		So(convertedManifest.Annotations[common.AnnotationOriginalDigest], ShouldEqual, manifestDigest.String())
+
+		manifestList := ispec.Index{
+			MediaType: common.MediaTypeDockerManifestList,
+			Manifests: []ispec.Descriptor{
+				{
+					MediaType: common.MediaTypeDockerManifest,
+					Digest:    manifestDigest,
+					Size:      int64(len(manifestBlob)),
 				},
-			}
+			},
+		}
+		manifestList.SchemaVersion = 2
 
-			ctlr := makeController(conf, tempDir, "")
+		manifestListBlob, err := json.Marshal(manifestList)
+		So(err, ShouldBeNil)
 
-			cm := test.NewControllerManager(ctlr)
-			cm.StartAndWait(port)
-			defer cm.StopServer()
+		resp, err = resty.R().SetHeader("Content-Type", common.MediaTypeDockerManifestList).
+			SetBody(manifestListBlob).Put(baseURL + "/v2/repo/manifests/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 
-			cfg, layers, manifest, err := test.GetImageComponents(10000)
-			So(err, ShouldBeNil)
+		resp, err = resty.R().Get(baseURL + "/v2/repo/manifests/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, ispec.MediaTypeImageIndex)
 
-			err = test.UploadImageWithBasicAuth(
-				test.Image{
-					Config:   cfg,
-					Layers:   layers,
-					Manifest: manifest,
-				}, baseURL, repoName,
-				user1, password1)
+		var convertedIndex ispec.Index
+		err = json.Unmarshal(resp.Body(), &convertedIndex)
+		So(err, ShouldBeNil)
+		So(convertedIndex.Manifests[0].Digest.String(), ShouldEqual, convertedDigest)
+		So(convertedIndex.Manifests[0].MediaType, ShouldEqual, ispec.MediaTypeImageManifest)
 
-			So(err, ShouldBeNil)
-		})
+		// a digest which doesn't match the pushed manifest is rejected
+		resp, err = resty.R().SetHeader("Content-Type", common.MediaTypeDockerManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/repo/manifests/" + godigest.FromString("other").String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
 
-		Convey("Testing group permissions on admin policy", func(c C) {
-			conf := config.New()
-			port := test.GetFreePort()
-			baseURL := test.GetBaseURL(port)
+		resp, err = resty.R().SetHeader("Content-Type", common.MediaTypeDockerManifest).SetBody([]byte("invalid")).
+			Put(baseURL + "/v2/repo/manifests/invalid")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+	})
+}
 
-			user1 := "test5"
-			password1 := "test5"
-			group1 := "testgroup2"
-			testString1 := getCredString(user1, password1)
-			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
-			defer os.Remove(htpasswdPath)
-			conf.HTTP.Auth = &config.AuthConfig{
-				HTPasswd: config.AuthHTPasswd{
-					Path: htpasswdPath,
-				},
-			}
+func TestTenancy(t *testing.T) {
+	Convey("Serve the repos of the tenants as virtual registries", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-			conf.HTTP.Port = port
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Realm = "zot"
+		conf.Tenants = []config.TenantConfig{{
+			Name:        "acme",
+			Hosts:       []string{"acme.example.com"},
+			PathPrefix:  "/acme",
+			Realm:       "ACME registry",
+			QuotaLimits: config.QuotaLimits{MaxRepos: 2},
+		}}
 
-			defaultVal := true
+		ctlr := makeController(conf, t.TempDir(), "")
 
-			searchConfig := &extconf.SearchConfig{
-				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-			}
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			conf.Extensions = &extconf.ExtensionConfig{
-				Search: searchConfig,
-			}
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
 
-			conf.HTTP.AccessControl = &config.AccessControlConfig{
-				Groups: config.Groups{
-					group1: {
-						Users: []string{user1},
-					},
-				},
-				Repositories: config.Repositories{},
-				AdminPolicy: config.Policy{
-					Groups:  []string{group1},
-					Actions: []string{"read", "create"},
-				},
-			}
+		// the tenant's repos are in its namespace
+		err = test.UploadImage(img, baseURL, "acme/app")
+		So(err, ShouldBeNil)
 
-			ctlr := makeController(conf, tempDir, "")
+		err = test.UploadImage(img, baseURL, "other")
+		So(err, ShouldBeNil)
 
-			cm := test.NewControllerManager(ctlr)
-			cm.StartAndWait(port)
-			defer cm.StopServer()
+		resp, err := resty.R().Get(baseURL + "/acme/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			cfg, layers, manifest, err := test.GetImageComponents(10000)
-			So(err, ShouldBeNil)
+		var repoList api.RepositoryList
 
-			err = test.UploadImageWithBasicAuth(
-				test.Image{
-					Config:   cfg,
-					Layers:   layers,
-					Manifest: manifest,
-				}, baseURL, repoName,
-				user1, password1)
+		err = json.Unmarshal(resp.Body(), &repoList)
+		So(err, ShouldBeNil)
+		So(repoList.Repositories, ShouldResemble, []string{"app"})
 
-			So(err, ShouldBeNil)
-		})
+		resp, err = resty.R().Get(baseURL + "/acme/v2/app/tags/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		Convey("Testing group permissions on anonymous policy", func(c C) {
-			conf := config.New()
-			port := test.GetFreePort()
-			baseURL := test.GetBaseURL(port)
+		var tags api.ImageTags
 
-			conf.HTTP.Port = port
+		err = json.Unmarshal(resp.Body(), &tags)
+		So(err, ShouldBeNil)
+		So(tags.Name, ShouldEqual, "app")
+		So(tags.Tags, ShouldResemble, []string{"1.0"})
 
-			defaultVal := true
-			group1 := group
-			user1 := username
-			password1 := passphrase
+		resp, err = resty.R().Get(baseURL + "/acme/v2/other/tags/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
 
-			testString1 := getCredString(user1, password1)
-			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
-			defer os.Remove(htpasswdPath)
-			conf.HTTP.Auth = &config.AuthConfig{
-				HTPasswd: config.AuthHTPasswd{
-					Path: htpasswdPath,
-				},
-			}
+		// the locations are relative to the virtual registry
+		resp, err = resty.R().Post(baseURL + "/acme/v2/app/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(resp.Header().Get("Location"), ShouldStartWith, "/acme/v2/app/blobs/uploads/")
 
-			searchConfig := &extconf.SearchConfig{
-				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-			}
+		resp, err = resty.R().Post(baseURL + "/acme/v2/app2/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-			conf.Extensions = &extconf.ExtensionConfig{
-				Search: searchConfig,
-			}
-			conf.HTTP.AccessControl = &config.AccessControlConfig{
-				Groups: config.Groups{
-					group1: {
-						Users: []string{user1},
-					},
-				},
-				Repositories: config.Repositories{
-					repoName: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Groups:  []string{group1},
-								Actions: []string{"read", "create", "delete"},
-							},
-							{
-								Users:   []string{user1},
-								Actions: []string{"delete"},
-							},
-						},
-						DefaultPolicy:   []string{},
-						AnonymousPolicy: []string{"read", "create"},
-					},
-				},
-			}
+		// the limits of the tenant apply to all its repos
+		err = test.UploadImage(img, baseURL, "acme/app2")
+		So(err, ShouldBeNil)
+
+		// the catalog pages are relative to the virtual registry too
+		resp, err = resty.R().Get(baseURL + "/acme/v2/_catalog?n=1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Link"), ShouldEqual, `/acme/v2/_catalog?n=1&last=app; rel="next"`)
+
+		err = json.Unmarshal(resp.Body(), &repoList)
+		So(err, ShouldBeNil)
+		So(repoList.Repositories, ShouldResemble, []string{"app"})
+
+		resp, err = resty.R().Get(baseURL + "/acme/v2/_catalog?n=1&last=app")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Link"), ShouldBeEmpty)
+
+		err = json.Unmarshal(resp.Body(), &repoList)
+		So(err, ShouldBeNil)
+		So(repoList.Repositories, ShouldResemble, []string{"app2"})
+
+		resp, err = resty.R().Post(baseURL + "/acme/v2/app3/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+		So(string(resp.Body()), ShouldContainSubstring, "maxRepos")
+
+		resp, err = resty.R().Get(baseURL + "/acme/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("WWW-Authenticate"), ShouldEqual, `Basic realm="ACME registry"`)
+
+		// the extensions aren't served on the virtual registries
+		resp, err = resty.R().Get(baseURL + "/acme" + constants.FullSearchPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		// virtual hosts
+		request, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL+"/v2/app/tags/list", nil)
+		So(err, ShouldBeNil)
+		request.Host = "ACME.example.com:" + port
 
-			ctlr := makeController(conf, tempDir, "")
+		response, err := http.DefaultClient.Do(request)
+		So(err, ShouldBeNil)
+		defer response.Body.Close()
+		So(response.StatusCode, ShouldEqual, http.StatusOK)
 
-			cm := test.NewControllerManager(ctlr)
-			cm.StartAndWait(port)
-			defer cm.StopServer()
+		request, err = http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL+"/v2/other/tags/list", nil)
+		So(err, ShouldBeNil)
+		request.Host = "acme.example.com"
 
-			cfg, layers, manifest, err := test.GetImageComponents(10000)
-			So(err, ShouldBeNil)
+		response, err = http.DefaultClient.Do(request)
+		So(err, ShouldBeNil)
+		defer response.Body.Close()
+		So(response.StatusCode, ShouldEqual, http.StatusNotFound)
 
-			err = test.UploadImageWithBasicAuth(
-				test.Image{
-					Config:   cfg,
-					Layers:   layers,
-					Manifest: manifest,
-				}, baseURL, repoName,
-				"", "")
+		// the main host serves all the repos
+		resp, err = resty.R().Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-			So(err, ShouldBeNil)
-		})
+		err = json.Unmarshal(resp.Body(), &repoList)
+		So(err, ShouldBeNil)
+		So(repoList.Repositories, ShouldContain, "acme/app")
+		So(repoList.Repositories, ShouldContain, "other")
 	})
 }
 
-func TestDistSpecExtensions(t *testing.T) {
-	Convey("start zot server with search extension", t, func(c C) {
-		conf := config.New()
+func TestPanicRecovery(t *testing.T) {
+	Convey("Panicking handlers are turned into 500 responses", t, func() {
 		port := test.GetFreePort()
-		baseURL := test.GetBaseURL(port)
 
+		conf := config.New()
 		conf.HTTP.Port = port
 
-		defaultVal := true
-
-		searchConfig := &extconf.SearchConfig{
-			BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-		}
-
-		conf.Extensions = &extconf.ExtensionConfig{
-			Search: searchConfig,
-		}
-
 		logFile, err := os.CreateTemp("", "zot-log*.txt")
 		So(err, ShouldBeNil)
 		conf.Log.Output = logFile.Name()
@@ -7253,217 +10356,255 @@ func TestDistSpecExtensions(t *testing.T) {
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		var extensionList distext.ExtensionList
-
-		resp, err := resty.R().Get(baseURL + constants.RoutePrefix + constants.ExtOciDiscoverPrefix)
-		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, 200)
-		err = json.Unmarshal(resp.Body(), &extensionList)
-		So(err, ShouldBeNil)
-		So(len(extensionList.Extensions), ShouldEqual, 1)
-		So(len(extensionList.Extensions[0].Endpoints), ShouldEqual, 2)
-		So(extensionList.Extensions[0].Name, ShouldEqual, "_zot")
-		So(extensionList.Extensions[0].URL, ShouldContainSubstring, "_zot.md")
-		So(extensionList.Extensions[0].Description, ShouldNotBeEmpty)
-		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullSearchPrefix)
-		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullUserPreferencesPrefix)
-	})
+		router := mux.NewRouter()
+		router.Use(api.RequestIDHandler(), api.RecoveryHandler(ctlr))
+		router.HandleFunc("/v2/{name}/panic", func(response http.ResponseWriter, request *http.Request) {
+			response.Header().Set("Content-Type", "application/json")
+			response.Header().Set("Docker-Content-Digest", "sha256:bad")
 
-	Convey("start zot server with search and mgmt extensions", t, func(c C) {
-		conf := config.New()
-		port := test.GetFreePort()
-		baseURL := test.GetBaseURL(port)
+			panic("bad request")
+		})
+		router.HandleFunc("/v2/{name}/partial", func(response http.ResponseWriter, request *http.Request) {
+			response.WriteHeader(http.StatusOK)
+			_, _ = response.Write([]byte("partial"))
 
-		conf.HTTP.Port = port
+			panic("bad request")
+		})
 
-		defaultVal := true
+		request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, "/v2/repo/panic", nil)
+		request.Header.Set(constants.RequestIDHeader, "panic-request-id")
+		response := httptest.NewRecorder()
 
-		searchConfig := &extconf.SearchConfig{
-			BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-		}
+		So(func() { router.ServeHTTP(response, request) }, ShouldNotPanic)
 
-		mgmtConfg := &extconf.MgmtConfig{
-			BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-		}
+		resp := response.Result()
+		defer resp.Body.Close()
+		So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+		So(resp.Header.Get(constants.RequestIDHeader), ShouldEqual, "panic-request-id")
+		So(resp.Header.Get("Docker-Content-Digest"), ShouldBeEmpty)
+		So(resp.Header.Get("Content-Type"), ShouldBeEmpty)
 
-		conf.Extensions = &extconf.ExtensionConfig{
-			Search: searchConfig,
-			Mgmt:   mgmtConfg,
-		}
+		// the response can't be changed once it's started, the connection is aborted instead
+		request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, "/v2/repo/partial", nil)
+		response = httptest.NewRecorder()
 
-		logFile, err := os.CreateTemp("", "zot-log*.txt")
-		So(err, ShouldBeNil)
-		conf.Log.Output = logFile.Name()
-		defer os.Remove(logFile.Name()) // clean up
+		So(func() { router.ServeHTTP(response, request) }, ShouldPanicWith, http.ErrAbortHandler)
 
-		ctlr := makeController(conf, t.TempDir(), "")
+		server := httptest.NewServer(router)
+		defer server.Close()
 
-		cm := test.NewControllerManager(ctlr)
-		cm.StartAndWait(port)
-		defer cm.StopServer()
+		request, _ = http.NewRequestWithContext(context.TODO(), http.MethodGet, server.URL+"/v2/repo/partial", nil)
 
-		var extensionList distext.ExtensionList
+		// the client doesn't get the partial response as a complete one
+		resp, err = http.DefaultClient.Do(request) //nolint:bodyclose // there's no response
+		So(err, ShouldNotBeNil)
+		So(resp, ShouldBeNil)
 
-		resp, err := resty.R().Get(baseURL + constants.RoutePrefix + constants.ExtOciDiscoverPrefix)
+		data, err := os.ReadFile(logFile.Name())
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, 200)
-		err = json.Unmarshal(resp.Body(), &extensionList)
+		So(string(data), ShouldContainSubstring, "recovered from a panic while handling a request")
+		So(string(data), ShouldContainSubstring, "panic-request-id")
+		So(string(data), ShouldContainSubstring, "/v2/{name}/panic")
+		So(string(data), ShouldContainSubstring, "runtime/debug.Stack")
+
+		// the server keeps serving requests
+		resp2, err := resty.R().Get(test.GetBaseURL(port) + "/v2/")
 		So(err, ShouldBeNil)
-		So(len(extensionList.Extensions), ShouldEqual, 1)
-		So(len(extensionList.Extensions[0].Endpoints), ShouldEqual, 3)
-		So(extensionList.Extensions[0].Name, ShouldEqual, "_zot")
-		So(extensionList.Extensions[0].URL, ShouldContainSubstring, "_zot.md")
-		So(extensionList.Extensions[0].Description, ShouldNotBeEmpty)
-		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullSearchPrefix)
-		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullUserPreferencesPrefix)
-		So(extensionList.Extensions[0].Endpoints, ShouldContain, constants.FullMgmtPrefix)
+		So(resp2.StatusCode(), ShouldEqual, http.StatusOK)
 	})
+}
 
-	Convey("start minimal zot server", t, func(c C) {
-		conf := config.New()
+func TestLoadShedding(t *testing.T) {
+	Convey("Requests are shed while the storage is unhealthy", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
 
+		conf := config.New()
 		conf.HTTP.Port = port
+		conf.HTTP.LoadShedding = &config.LoadSheddingConfig{
+			Fraction:      1,
+			ProbeInterval: 100 * time.Millisecond,
+		}
 
-		logFile, err := os.CreateTemp("", "zot-log*.txt")
-		So(err, ShouldBeNil)
-		conf.Log.Output = logFile.Name()
-		defer os.Remove(logFile.Name()) // clean up
-
-		ctlr := makeController(conf, t.TempDir(), "")
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir, "")
 
 		cm := test.NewControllerManager(ctlr)
 		cm.StartAndWait(port)
 		defer cm.StopServer()
 
-		var extensionList distext.ExtensionList
-		resp, err := resty.R().Get(baseURL + constants.RoutePrefix + constants.ExtOciDiscoverPrefix)
+		resp, err := resty.R().Get(baseURL + "/v2/_catalog")
 		So(err, ShouldBeNil)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, 200)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		err = json.Unmarshal(resp.Body(), &extensionList)
+		// the storage root can't be reached anymore
+		So(os.RemoveAll(dir), ShouldBeNil)
+
+		So(func() bool {
+			for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(50 * time.Millisecond) {
+				if !ctlr.LoadShedder.Healthy() {
+					return true
+				}
+			}
+
+			return false
+		}(), ShouldBeTrue)
+
+		resp, err = resty.R().Get(baseURL + "/v2/_catalog")
 		So(err, ShouldBeNil)
-		So(len(extensionList.Extensions), ShouldEqual, 0)
+		So(resp.StatusCode(), ShouldEqual, http.StatusServiceUnavailable)
+		So(resp.Header().Get("Retry-After"), ShouldEqual, "1")
+
+		resp, err = resty.R().Get(baseURL + "/v2/repo/tags/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusServiceUnavailable)
+
+		// the base route is still served
+		resp, err = resty.R().Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		So(os.MkdirAll(dir, 0o700), ShouldBeNil)
+
+		So(func() bool {
+			for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(50 * time.Millisecond) {
+				if ctlr.LoadShedder.Healthy() {
+					return true
+				}
+			}
+
+			return false
+		}(), ShouldBeTrue)
+
+		resp, err = resty.R().Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 	})
 }
 
-func TestHTTPOptionsResponse(t *testing.T) {
-	Convey("Test http options response", t, func() {
-		conf := config.New()
+func TestCatalogPagination(t *testing.T) {
+	Convey("The catalog is served in pages", t, func() {
 		port := test.GetFreePort()
-		conf.HTTP.Port = port
 		baseURL := test.GetBaseURL(port)
 
-		ctlr := api.NewController(conf)
+		conf := config.New()
+		conf.HTTP.Port = port
 
-		firstDir := t.TempDir()
+		ctlr := makeController(conf, t.TempDir(), "")
+		ctlr.Config.Storage.SubPaths = map[string]config.StorageConfig{
+			"/b": {RootDirectory: t.TempDir()},
+		}
 
-		secondDir := t.TempDir()
-		defer os.RemoveAll(firstDir)
-		defer os.RemoveAll(secondDir)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-		ctlr.Config.Storage.RootDirectory = firstDir
-		subPaths := make(map[string]config.StorageConfig)
-		subPaths["/a"] = config.StorageConfig{
-			RootDirectory: secondDir,
-		}
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
 
-		ctlr.Config.Storage.SubPaths = subPaths
-		ctrlManager := test.NewControllerManager(ctlr)
+		// b/app is in the substore, the other repos in the default store
+		for _, repo := range []string{"c", "a-c", "b/app", "a/b", "a"} {
+			err = test.UploadImage(img, baseURL, repo)
+			So(err, ShouldBeNil)
+		}
 
-		ctrlManager.StartAndWait(port)
+		getCatalog := func(query string) ([]string, string) {
+			resp, err := resty.R().Get(baseURL + "/v2/_catalog" + query)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
 
-		resp, _ := resty.R().Options(baseURL + constants.RoutePrefix + constants.ExtCatalogPrefix)
-		So(resp, ShouldNotBeNil)
-		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
+			var repoList api.RepositoryList
 
-		defer ctrlManager.StopServer()
-	})
-}
+			err = json.Unmarshal(resp.Body(), &repoList)
+			So(err, ShouldBeNil)
 
-func getAllBlobs(imagePath string) []string {
-	blobList := make([]string, 0)
+			return repoList.Repositories, resp.Header().Get("Link")
+		}
 
-	if !common.DirExists(imagePath) {
-		return []string{}
-	}
+		repos, link := getCatalog("")
+		So(repos, ShouldResemble, []string{"a", "a/b", "a-c", "b/app", "c"})
+		So(link, ShouldBeEmpty)
 
-	buf, err := os.ReadFile(path.Join(imagePath, "index.json"))
-	if err != nil {
-		panic(err)
-	}
+		repos, link = getCatalog("?n=2")
+		So(repos, ShouldResemble, []string{"a", "a/b"})
+		So(link, ShouldEqual, `/v2/_catalog?n=2&last=a/b; rel="next"`)
 
-	var index ispec.Index
-	if err := json.Unmarshal(buf, &index); err != nil {
-		panic(err)
-	}
+		repos, link = getCatalog("?n=2&last=a/b")
+		So(repos, ShouldResemble, []string{"a-c", "b/app"})
+		So(link, ShouldEqual, `/v2/_catalog?n=2&last=b/app; rel="next"`)
 
-	var digest godigest.Digest
+		repos, link = getCatalog("?n=2&last=b/app")
+		So(repos, ShouldResemble, []string{"c"})
+		So(link, ShouldBeEmpty)
 
-	for _, m := range index.Manifests {
-		digest = m.Digest
-		blobList = append(blobList, digest.Encoded())
-		p := path.Join(imagePath, "blobs", digest.Algorithm().String(), digest.Encoded())
+		// the repos after last are listed even if last doesn't exist
+		repos, link = getCatalog("?last=a-b")
+		So(repos, ShouldResemble, []string{"a-c", "b/app", "c"})
+		So(link, ShouldBeEmpty)
 
-		buf, err = os.ReadFile(p)
+		repos, link = getCatalog("?n=0")
+		So(repos, ShouldBeEmpty)
+		So(link, ShouldBeEmpty)
 
-		if err != nil {
-			panic(err)
+		for _, query := range []string{"?n=-1", "?n=a", "?n=1&n=2", "?last=a&last=b"} {
+			resp, err := resty.R().Get(baseURL + "/v2/_catalog" + query)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
 		}
+	})
+}
 
-		var manifest ispec.Manifest
-		if err := json.Unmarshal(buf, &manifest); err != nil {
-			panic(err)
-		}
+func TestRequestLogFields(t *testing.T) {
+	Convey("The storage events carry the fields of the request", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
 
-		blobList = append(blobList, manifest.Config.Digest.Encoded())
+		logFile, err := os.CreateTemp(t.TempDir(), "zot-log*.txt")
+		So(err, ShouldBeNil)
 
-		for _, layer := range manifest.Layers {
-			blobList = append(blobList, layer.Digest.Encoded())
-		}
-	}
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Log.Level = "debug"
+		conf.Log.Output = logFile.Name()
 
-	return blobList
-}
+		ctlr := makeController(conf, t.TempDir(), "")
 
-func getAllManifests(imagePath string) []string {
-	manifestList := make([]string, 0)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-	if !common.DirExists(imagePath) {
-		return []string{}
-	}
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
 
-	buf, err := os.ReadFile(path.Join(imagePath, "index.json"))
-	if err != nil {
-		panic(err)
-	}
+		err = test.UploadImage(img, baseURL, "repo")
+		So(err, ShouldBeNil)
 
-	var index ispec.Index
-	if err := json.Unmarshal(buf, &index); err != nil {
-		panic(err)
-	}
+		missingDigest := godigest.FromString("missing")
 
-	var digest godigest.Digest
+		resp, err := resty.R().SetHeader(constants.RequestIDHeader, "storage-request-1").
+			Get(baseURL + "/v2/repo/blobs/" + missingDigest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
 
-	for _, m := range index.Manifests {
-		digest = m.Digest
-		manifestList = append(manifestList, digest.Encoded())
-	}
+		data, err := os.ReadFile(logFile.Name())
+		So(err, ShouldBeNil)
 
-	return manifestList
-}
+		var storageEvent map[string]interface{}
 
-func makeController(conf *config.Config, dir string, copyTestDataDest string) *api.Controller {
-	ctlr := api.NewController(conf)
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.Contains(line, "storage-request-1") && strings.Contains(line, "failed to stat blob") {
+				err = json.Unmarshal([]byte(line), &storageEvent)
+				So(err, ShouldBeNil)
 
-	if copyTestDataDest != "" {
-		test.CopyTestFiles(copyTestDataDest, dir)
-	}
-	ctlr.Config.Storage.RootDirectory = dir
+				break
+			}
+		}
 
-	return ctlr
+		So(storageEvent, ShouldNotBeNil)
+		So(storageEvent["request"], ShouldResemble, map[string]interface{}{
+			"id":         "storage-request-1",
+			"repository": "repo",
+			"digest":     missingDigest.String(),
+		})
+	})
 }
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	apiErr "zotregistry.io/zot/pkg/api/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
+	storageCommon "zotregistry.io/zot/pkg/storage/common"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+/*
+convertDockerManifest converts a pushed docker manifest or manifest list to oci and returns the reference,
+media type and body to store instead. A manifest pushed by digest is stored by the digest of the converted
+manifest, the digest it was pushed with still resolves to it.
+If the manifest can't be converted it writes the error response and returns false.
+*/
+func (rh *RouteHandler) convertDockerManifest(response http.ResponseWriter, imgStore storageTypes.ImageStore,
+	name, reference, mediaType string, body []byte,
+) (string, string, []byte, bool) {
+	converted, convertedMediaType, err := storageCommon.ConvertDockerManifest(imgStore, name, mediaType, body,
+		rh.c.Log.Logger)
+	if err != nil {
+		if errors.Is(err, zerr.ErrBadManifest) {
+			zcommon.WriteJSON(response, http.StatusBadRequest,
+				apiErr.NewErrorList(apiErr.NewError(apiErr.MANIFEST_INVALID, map[string]string{"reference": reference})))
+		} else {
+			rh.c.Log.Error().Err(err).Str("repository", name).Str("reference", reference).
+				Msg("unable to convert docker manifest")
+			response.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return "", "", nil, false
+	}
+
+	if convertedMediaType == mediaType {
+		return reference, mediaType, body, true
+	}
+
+	// a digest which doesn't match the pushed manifest is still rejected by the storage
+	if reference == godigest.FromBytes(body).String() {
+		reference = godigest.FromBytes(converted).String()
+	}
+
+	rh.c.Log.Debug().Str("repository", name).Str("reference", reference).Str("mediaType", mediaType).
+		Str("originalDigest", godigest.FromBytes(body).String()).
+		Msg("converted docker manifest to oci")
+
+	return reference, convertedMediaType, converted, true
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	"zotregistry.io/zot/pkg/api/downloads"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+func (c *Controller) InitDownloads() error {
+	var config *extconf.DownloadsConfig
+
+	if c.Config.Extensions != nil && c.Config.Extensions.Search != nil {
+		config = c.Config.Extensions.Search.Downloads
+	}
+
+	filter, err := downloads.New(config)
+	if err != nil {
+		return err
+	}
+
+	c.Downloads = filter
+
+	return nil
+}
+
+// isDownloadCounted returns whether a pull of a manifest is counted in its download count, pulls are told
+// apart by username, or by client IP for anonymous pulls.
+func (rh *RouteHandler) isDownloadCounted(request *http.Request, name string, digest godigest.Digest) bool {
+	identity := clientIP(request, rh.c.Config.HTTP.TrustedProxies)
+
+	if acCtx, err := localCtx.GetAccessControlContext(request.Context()); err == nil && acCtx != nil &&
+		acCtx.Username != "" {
+		identity = acCtx.Username
+	}
+
+	return rh.c.Downloads.Count(name, digest, identity, request.UserAgent())
+}
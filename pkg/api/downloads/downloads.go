@@ -0,0 +1,124 @@
+package downloads
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobwas/glob"
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+)
+
+// maxTrackedPulls bounds the memory used to deduplicate pulls, pulls beyond it are counted.
+const maxTrackedPulls = 100000
+
+// Filter decides which pulls are counted in the download counts of the images, according to the config.
+type Filter struct {
+	dedupWindow time.Duration
+	userAgents  []glob.Glob
+	pulls       map[string]time.Time
+	lastSweep   time.Time
+	lock        sync.Mutex
+}
+
+// New compiles the download counts config, every pull is counted if it's nil.
+func New(config *extconf.DownloadsConfig) (*Filter, error) {
+	filter := &Filter{pulls: map[string]time.Time{}}
+
+	if err := filter.Set(config); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// Set replaces the config, e.g. when it's reloaded, it's left unchanged if it's invalid.
+func (filter *Filter) Set(config *extconf.DownloadsConfig) error {
+	var (
+		dedupWindow time.Duration
+		userAgents  []glob.Glob
+	)
+
+	if config != nil {
+		if config.DedupWindow < 0 {
+			return fmt.Errorf("%w: negative dedup window %s", zerr.ErrBadDownloadsConfig, config.DedupWindow)
+		}
+
+		for _, pattern := range config.IgnoredUserAgents {
+			userAgent, err := glob.Compile(strings.ToLower(pattern))
+			if err != nil {
+				return fmt.Errorf("%w: invalid user agent pattern %s: %w", zerr.ErrBadDownloadsConfig, pattern, err)
+			}
+
+			userAgents = append(userAgents, userAgent)
+		}
+
+		dedupWindow = config.DedupWindow
+	}
+
+	filter.lock.Lock()
+	defer filter.lock.Unlock()
+
+	filter.dedupWindow = dedupWindow
+	filter.userAgents = userAgents
+
+	return nil
+}
+
+// Count returns whether a pull of an image is counted: it isn't if the user agent is ignored, or if the
+// same identity, a username or an IP, already pulled the image during the dedup window.
+func (filter *Filter) Count(repo string, digest godigest.Digest, identity, userAgent string) bool {
+	if filter == nil {
+		return true
+	}
+
+	filter.lock.Lock()
+	defer filter.lock.Unlock()
+
+	userAgent = strings.ToLower(userAgent)
+
+	for _, ignored := range filter.userAgents {
+		if ignored.Match(userAgent) {
+			return false
+		}
+	}
+
+	if filter.dedupWindow == 0 {
+		return true
+	}
+
+	now := time.Now()
+	filter.sweep(now)
+
+	// a pull by tag and a pull by digest of the same image are the same pull
+	key := repo + "@" + digest.String() + " " + identity
+
+	if pulledAt, found := filter.pulls[key]; found && now.Sub(pulledAt) < filter.dedupWindow {
+		return false
+	}
+
+	if len(filter.pulls) < maxTrackedPulls {
+		filter.pulls[key] = now
+	}
+
+	return true
+}
+
+// sweep forgets the pulls older than the dedup window, at most once per window.
+func (filter *Filter) sweep(now time.Time) {
+	if now.Sub(filter.lastSweep) < filter.dedupWindow {
+		return
+	}
+
+	for key, pulledAt := range filter.pulls {
+		if now.Sub(pulledAt) >= filter.dedupWindow {
+			delete(filter.pulls, key)
+		}
+	}
+
+	filter.lastSweep = now
+}
@@ -0,0 +1,64 @@
+package downloads_test
+
+import (
+	"testing"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/downloads"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+)
+
+func TestDownloadsFilter(t *testing.T) {
+	digest := godigest.FromString("manifest")
+
+	Convey("Every pull is counted without config", t, func() {
+		filter, err := downloads.New(nil)
+		So(err, ShouldBeNil)
+
+		So(filter.Count("repo", digest, "alice", "docker/24.0.2"), ShouldBeTrue)
+		So(filter.Count("repo", digest, "alice", "docker/24.0.2"), ShouldBeTrue)
+
+		var nilFilter *downloads.Filter
+		So(nilFilter.Count("repo", digest, "alice", "docker/24.0.2"), ShouldBeTrue)
+	})
+
+	Convey("Pulls are filtered", t, func() {
+		filter, err := downloads.New(&extconf.DownloadsConfig{
+			DedupWindow:       50 * time.Millisecond,
+			IgnoredUserAgents: []string{"kube-probe/*", "*HealthChecker*"},
+		})
+		So(err, ShouldBeNil)
+
+		So(filter.Count("repo", digest, "10.0.0.1", "kube-probe/1.27"), ShouldBeFalse)
+		So(filter.Count("repo", digest, "10.0.0.1", "ELB-healthchecker/2.0"), ShouldBeFalse)
+
+		So(filter.Count("repo", digest, "alice", "docker/24.0.2"), ShouldBeTrue)
+		So(filter.Count("repo", digest, "alice", "containerd/1.7.2"), ShouldBeFalse)
+		So(filter.Count("repo", digest, "bob", "docker/24.0.2"), ShouldBeTrue)
+		So(filter.Count("other", digest, "alice", "docker/24.0.2"), ShouldBeTrue)
+		So(filter.Count("repo", godigest.FromString("other"), "alice", "docker/24.0.2"), ShouldBeTrue)
+
+		time.Sleep(60 * time.Millisecond)
+
+		So(filter.Count("repo", digest, "alice", "docker/24.0.2"), ShouldBeTrue)
+
+		Convey("Reload the config", func() {
+			err := filter.Set(&extconf.DownloadsConfig{IgnoredUserAgents: []string{"["}})
+			So(err, ShouldWrap, zerr.ErrBadDownloadsConfig)
+			So(filter.Count("repo", digest, "10.0.0.1", "kube-probe/1.27"), ShouldBeFalse)
+
+			err = filter.Set(&extconf.DownloadsConfig{DedupWindow: -time.Second})
+			So(err, ShouldWrap, zerr.ErrBadDownloadsConfig)
+
+			err = filter.Set(nil)
+			So(err, ShouldBeNil)
+			So(filter.Count("repo", digest, "10.0.0.1", "kube-probe/1.27"), ShouldBeTrue)
+			So(filter.Count("repo", digest, "alice", "docker/24.0.2"), ShouldBeTrue)
+			So(filter.Count("repo", digest, "alice", "docker/24.0.2"), ShouldBeTrue)
+		})
+	})
+}
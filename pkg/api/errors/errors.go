@@ -1,9 +1,15 @@
 package errors
 
 import (
+	"net/http"
+	"strings"
+
 	"zotregistry.io/zot/errors"
 )
 
+// DefaultDocsURL documents the error codes returned in the body of the responses.
+const DefaultDocsURL = "https://github.com/opencontainers/distribution-spec/blob/main/spec.md#error-codes"
+
 type Error struct {
 	Code        string      `json:"code"`
 	Message     string      `json:"message"`
@@ -21,6 +27,46 @@ type ErrorList struct {
 	Errors []*Error `json:"errors"`
 }
 
+// Problem is an error response in the application/problem+json format of RFC 7807, for the clients which
+// prefer it to the body defined by the distribution spec, whose errors are kept in the errors field.
+type Problem struct {
+	Type      string   `json:"type"`
+	Title     string   `json:"title"`
+	Status    int      `json:"status"`
+	Detail    string   `json:"detail,omitempty"`
+	Instance  string   `json:"instance,omitempty"`
+	Code      string   `json:"code,omitempty"`
+	RequestID string   `json:"requestId,omitempty"`
+	Docs      string   `json:"docs,omitempty"`
+	Errors    []*Error `json:"errors"`
+}
+
+// NewProblem converts the errors of a response to a problem, the detail and code being the ones of the first
+// error. The docs URL may contain a {code} placeholder, replaced by the code of the first error.
+func NewProblem(status int, errList ErrorList, instance, requestID, docsURL string) Problem {
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  instance,
+		RequestID: requestID,
+		Errors:    errList.Errors,
+	}
+
+	if len(errList.Errors) > 0 {
+		problem.Detail = errList.Errors[0].Message
+		problem.Code = errList.Errors[0].Code
+	}
+
+	if docsURL == "" {
+		docsURL = DefaultDocsURL
+	}
+
+	problem.Docs = strings.ReplaceAll(docsURL, "{code}", strings.ToLower(problem.Code))
+
+	return problem
+}
+
 type ErrorCode int
 
 //nolint:golint,stylecheck,revive
@@ -41,6 +87,7 @@ const (
 	DENIED
 	UNSUPPORTED
 	INVALID_INDEX
+	TAG_IMMUTABLE
 )
 
 func (e ErrorCode) String() string {
@@ -61,6 +108,7 @@ func (e ErrorCode) String() string {
 		DENIED:                "DENIED",
 		UNSUPPORTED:           "UNSUPPORTED",
 		INVALID_INDEX:         "INVALID_INDEX",
+		TAG_IMMUTABLE:         "TAG_IMMUTABLE",
 	}
 
 	return errMap[e]
@@ -165,6 +213,12 @@ func NewError(code ErrorCode, detail ...interface{}) Error {
 			Message:     "Invalid format of index.json file of the repo",
 			Description: "index.json file does not contain data in json format",
 		},
+
+		TAG_IMMUTABLE: {
+			Message: "tag is immutable",
+			Description: `The tag is immutable in the repository and already points to
+			another manifest, it can't be pushed again with a different manifest.`,
+		},
 	}
 
 	err, ok := errMap[code]
@@ -1,6 +1,7 @@
 package errors_test
 
 import (
+	"net/http"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -13,3 +14,21 @@ func TestUnknownCodeError(t *testing.T) {
 		So(func() { _ = apiErr.NewError(123456789, nil) }, ShouldPanic)
 	})
 }
+
+func TestNewProblem(t *testing.T) {
+	Convey("Convert errors to a problem", t, func() {
+		errList := apiErr.NewErrorList(apiErr.NewError(apiErr.MANIFEST_UNKNOWN))
+
+		problem := apiErr.NewProblem(http.StatusNotFound, errList, "/v2/repo/manifests/1.0", "id", "")
+		So(problem.Type, ShouldEqual, "about:blank")
+		So(problem.Title, ShouldEqual, "Not Found")
+		So(problem.Detail, ShouldEqual, "manifest unknown")
+		So(problem.Code, ShouldEqual, "MANIFEST_UNKNOWN")
+		So(problem.RequestID, ShouldEqual, "id")
+		So(problem.Docs, ShouldEqual, apiErr.DefaultDocsURL)
+		So(problem.Errors, ShouldHaveLength, 1)
+
+		problem = apiErr.NewProblem(http.StatusNotFound, errList, "", "", "https://example.com/errors#{code}")
+		So(problem.Docs, ShouldEqual, "https://example.com/errors#manifest_unknown")
+	})
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"zotregistry.io/zot/pkg/api/constants"
+)
+
+const hoursPerDay = 24
+
+func isFreshnessHeadersEnabled(ctlr *Controller) bool {
+	extensions := ctlr.Config.Extensions
+
+	return extensions != nil && extensions.Search != nil && extensions.Search.Enable != nil &&
+		*extensions.Search.Enable && extensions.Search.FreshnessHeaders && ctlr.RepoDB != nil
+}
+
+// setFreshnessHeaders adds the headers telling how fresh an image is, so admission controllers can enforce
+// freshness policies without querying the registry again. They are read from repodb, each header is
+// omitted if the metadata it's made of is missing, e.g. if the image was never scanned.
+func (rh *RouteHandler) setFreshnessHeaders(response http.ResponseWriter, name string, digest godigest.Digest,
+	mediaType string, content []byte,
+) {
+	if !isFreshnessHeadersEnabled(rh.c) || mediaType != ispec.MediaTypeImageManifest {
+		return
+	}
+
+	if created, found := rh.getImageCreated(digest); found {
+		response.Header().Set(constants.ImageCreatedHeader, created.UTC().Format(time.RFC3339))
+		response.Header().Set(constants.ImageAgeDaysHeader, strconv.Itoa(daysSince(created)))
+	}
+
+	if repoMeta, err := rh.c.RepoDB.GetRepoMeta(name); err == nil {
+		if summary, found := repoMeta.VulnerabilitySummaries[digest.String()]; found && !summary.UpdatedAt.IsZero() {
+			response.Header().Set(constants.LastScannedHeader, summary.UpdatedAt.UTC().Format(time.RFC3339))
+		}
+	}
+
+	var manifest ispec.Manifest
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return
+	}
+
+	if days, found := rh.getDaysSinceBaseUpdate(manifest.Annotations); found {
+		response.Header().Set(constants.DaysSinceBaseUpdateHeader, strconv.Itoa(days))
+	}
+}
+
+// getDaysSinceBaseUpdate returns for how many days the base image tag, named in the annotations of an image,
+// has pointed to another image than the one the image was built on, 0 if the image is built on the
+// current base image. The base image has to be stored in this registry.
+func (rh *RouteHandler) getDaysSinceBaseUpdate(annotations map[string]string) (int, bool) {
+	baseDigest := annotations[ispec.AnnotationBaseImageDigest]
+
+	baseRepo, baseTag, ok := parseBaseImageName(annotations[ispec.AnnotationBaseImageName])
+	if !ok || baseDigest == "" {
+		return 0, false
+	}
+
+	baseRepoMeta, err := rh.c.RepoDB.GetRepoMeta(baseRepo)
+	if err != nil {
+		return 0, false
+	}
+
+	currentBase, found := baseRepoMeta.Tags[baseTag]
+	if !found {
+		return 0, false
+	}
+
+	if currentBase.Digest == baseDigest {
+		return 0, true
+	}
+
+	updated, found := rh.getImageCreated(godigest.Digest(currentBase.Digest))
+	if !found {
+		return 0, false
+	}
+
+	return daysSince(updated), true
+}
+
+// getImageCreated returns the creation time found in the config of an image.
+func (rh *RouteHandler) getImageCreated(digest godigest.Digest) (time.Time, bool) {
+	manifestData, err := rh.c.RepoDB.GetManifestData(digest)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var imageConfig ispec.Image
+
+	if err := json.Unmarshal(manifestData.ConfigBlob, &imageConfig); err != nil || imageConfig.Created == nil {
+		return time.Time{}, false
+	}
+
+	return *imageConfig.Created, true
+}
+
+// parseBaseImageName returns the repo and tag of a base image name like registry.example.com/repo:tag,
+// the registry host is dropped and the tag defaults to latest.
+func parseBaseImageName(name string) (string, string, bool) {
+	name, _, _ = strings.Cut(name, "@")
+	if name == "" {
+		return "", "", false
+	}
+
+	repo, tag := name, "latest"
+
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		repo, tag = name[:colon], name[colon+1:]
+	}
+
+	if host, path, found := strings.Cut(repo, "/"); found && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		repo = path
+	}
+
+	return repo, tag, repo != "" && tag != ""
+}
+
+func daysSince(date time.Time) int {
+	days := int(time.Since(date).Hours() / hoursPerDay)
+	if days < 0 {
+		return 0
+	}
+
+	return days
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	apiErr "zotregistry.io/zot/pkg/api/errors"
+	"zotregistry.io/zot/pkg/api/hooks"
+	zcommon "zotregistry.io/zot/pkg/common"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+// checkPreReceiveHooks posts a pushed manifest to the pre-receive hooks before it's accepted.
+// If it's rejected, or a fail closed hook failed, it writes the error response and returns false.
+func (rh *RouteHandler) checkPreReceiveHooks(response http.ResponseWriter, request *http.Request,
+	name, reference, mediaType string, body []byte,
+) bool {
+	// invalid manifests are rejected by the storage
+	if rh.c.PreReceiveHooks == nil || !json.Valid(body) {
+		return true
+	}
+
+	var username string
+
+	if acCtx, err := localCtx.GetAccessControlContext(request.Context()); err == nil {
+		username = localCtx.GetUsernameFromContext(acCtx)
+	}
+
+	err := rh.c.PreReceiveHooks.Check(request.Context(), hooks.Request{
+		Repository: name,
+		Reference:  reference,
+		Digest:     godigest.FromBytes(body).String(),
+		MediaType:  mediaType,
+		Size:       len(body),
+		Manifest:   body,
+		User:       username,
+	})
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, zerr.ErrManifestRejected) {
+		rh.c.Log.Info().Err(err).Str("repository", name).Str("reference", reference).Str("user", username).
+			Msg("push denied by pre-receive hook")
+
+		writeDeniedError(response, err, map[string]string{
+			"name":      name,
+			"reference": reference,
+		})
+
+		return false
+	}
+
+	if errors.Is(err, zerr.ErrPreReceiveHookFailed) {
+		zcommon.WriteJSON(response, http.StatusServiceUnavailable,
+			apiErr.NewErrorList(apiErr.NewError(apiErr.DENIED, map[string]string{"name": name, "reference": reference}).
+				WithMessage(err.Error())))
+
+		return false
+	}
+
+	rh.c.Log.Error().Err(err).Str("repository", name).Msg("unable to check pre-receive hooks")
+	response.WriteHeader(http.StatusInternalServerError)
+
+	return false
+}
@@ -0,0 +1,228 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+)
+
+const (
+	// time to wait for the answer of a hook without a timeout.
+	DefaultTimeout = 10 * time.Second
+
+	// answers are small, larger ones are truncated and rejected as invalid JSON.
+	maxResponseSize = 64 * 1024
+)
+
+// Request is posted as JSON to the pre-receive hooks for each manifest pushed to a repo they apply to.
+type Request struct {
+	Repository string `json:"repository"`
+	// tag or digest the manifest is pushed with
+	Reference string          `json:"reference"`
+	Digest    string          `json:"digest"`
+	MediaType string          `json:"mediaType"`
+	Size      int             `json:"size"`
+	Manifest  json.RawMessage `json:"manifest"`
+	// pushing user, empty for anonymous pushes
+	User string `json:"user,omitempty"`
+}
+
+// Response is the answer of a pre-receive hook, the manifest is rejected with the message if it's not allowed.
+type Response struct {
+	Allowed bool   `json:"allowed"`
+	Message string `json:"message,omitempty"`
+}
+
+type hook struct {
+	url          string
+	repositories []string
+	timeout      time.Duration
+	failOpen     bool
+	headers      map[string]string
+}
+
+// Hooks posts the pushed manifests to the pre-receive hooks of the config before they're accepted.
+type Hooks struct {
+	hooks  []hook
+	client *http.Client
+	lock   sync.RWMutex
+	log    log.Logger
+}
+
+// New validates the pre-receive hooks of the config.
+func New(configs []config.PreReceiveHookConfig, log log.Logger) (*Hooks, error) {
+	hooks := &Hooks{
+		// each request is bounded by the timeout of its hook
+		client: &http.Client{},
+		log:    log,
+	}
+
+	if err := hooks.Set(configs); err != nil {
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+// Set replaces the hooks, e.g. when the config is reloaded, they are left unchanged if one of them is invalid.
+func (hooks *Hooks) Set(configs []config.PreReceiveHookConfig) error {
+	validated := []hook{}
+
+	for idx, hookConfig := range configs {
+		hookURL, err := url.Parse(hookConfig.URL)
+		if err != nil || (hookURL.Scheme != "http" && hookURL.Scheme != "https") || hookURL.Host == "" {
+			return fmt.Errorf("%w: hook %d needs an http or https url", zerr.ErrBadPreReceiveHook, idx)
+		}
+
+		for _, pattern := range hookConfig.Repositories {
+			if !glob.ValidatePattern(pattern) {
+				return fmt.Errorf("%w: hook %d has an invalid repository pattern %s", zerr.ErrBadPreReceiveHook,
+					idx, pattern)
+			}
+		}
+
+		if hookConfig.Timeout < 0 {
+			return fmt.Errorf("%w: hook %d has a negative timeout", zerr.ErrBadPreReceiveHook, idx)
+		}
+
+		timeout := hookConfig.Timeout
+		if timeout == 0 {
+			timeout = DefaultTimeout
+		}
+
+		validated = append(validated, hook{
+			url:          hookConfig.URL,
+			repositories: hookConfig.Repositories,
+			timeout:      timeout,
+			failOpen:     hookConfig.FailOpen,
+			headers:      hookConfig.Headers,
+		})
+	}
+
+	hooks.lock.Lock()
+	defer hooks.lock.Unlock()
+
+	hooks.hooks = validated
+
+	return nil
+}
+
+/*
+Check posts a pushed manifest to the hooks applying to its repo, in order, until one of them rejects it.
+It returns ErrManifestRejected with the message of the hook if the manifest is rejected, or ErrPreReceiveHookFailed
+if a fail closed hook is unreachable, times out, answers with an error status or an invalid response. The
+failures of fail open hooks are logged and the manifest is accepted by them.
+*/
+func (hooks *Hooks) Check(ctx context.Context, request Request) error {
+	if hooks == nil {
+		return nil
+	}
+
+	hooks.lock.RLock()
+	matching := []hook{}
+
+	for _, hook := range hooks.hooks {
+		if hook.matchesRepo(request.Repository) {
+			matching = append(matching, hook)
+		}
+	}
+	hooks.lock.RUnlock()
+
+	if len(matching) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range matching {
+		response, err := hooks.call(ctx, hook, body)
+		if err != nil {
+			if hook.failOpen {
+				hooks.log.Warn().Err(err).Str("url", hook.url).Str("repository", request.Repository).
+					Str("reference", request.Reference).Msg("pre-receive hook failed, accepting the manifest")
+
+				continue
+			}
+
+			hooks.log.Error().Err(err).Str("url", hook.url).Str("repository", request.Repository).
+				Str("reference", request.Reference).Msg("pre-receive hook failed, rejecting the manifest")
+
+			return err
+		}
+
+		if !response.Allowed {
+			if response.Message == "" {
+				return zerr.ErrManifestRejected
+			}
+
+			return fmt.Errorf("%w: %s", zerr.ErrManifestRejected, response.Message)
+		}
+	}
+
+	return nil
+}
+
+func (hooks *Hooks) call(ctx context.Context, hook hook, body []byte) (Response, error) {
+	var response Response
+
+	ctx, cancel := context.WithTimeout(ctx, hook.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.url, bytes.NewReader(body))
+	if err != nil {
+		return response, fmt.Errorf("%w: %w", zerr.ErrPreReceiveHookFailed, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for name, value := range hook.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := hooks.client.Do(req)
+	if err != nil {
+		return response, fmt.Errorf("%w: %w", zerr.ErrPreReceiveHookFailed, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return response, fmt.Errorf("%w: status %d", zerr.ErrPreReceiveHookFailed, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&response); err != nil {
+		return response, fmt.Errorf("%w: invalid response: %w", zerr.ErrPreReceiveHookFailed, err)
+	}
+
+	return response, nil
+}
+
+func (hook hook) matchesRepo(repo string) bool {
+	if len(hook.repositories) == 0 {
+		return true
+	}
+
+	for _, pattern := range hook.repositories {
+		// patterns are validated by New
+		if matched, _ := glob.Match(pattern, repo); matched {
+			return true
+		}
+	}
+
+	return false
+}
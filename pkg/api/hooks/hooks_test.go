@@ -0,0 +1,145 @@
+package hooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/hooks"
+	"zotregistry.io/zot/pkg/log"
+)
+
+func TestPreReceiveHooks(t *testing.T) {
+	Convey("Post the pushed manifests to the hooks", t, func() {
+		received := []hooks.Request{}
+
+		server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			var hookRequest hooks.Request
+
+			if err := json.NewDecoder(request.Body).Decode(&hookRequest); err != nil {
+				response.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			received = append(received, hookRequest)
+
+			if request.Header.Get("Authorization") != "Bearer secret" {
+				response.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			if hookRequest.Reference == "latest" {
+				_ = json.NewEncoder(response).Encode(hooks.Response{Allowed: false, Message: "latest is not allowed"})
+
+				return
+			}
+
+			_ = json.NewEncoder(response).Encode(hooks.Response{Allowed: true})
+		}))
+		defer server.Close()
+
+		preReceiveHooks, err := hooks.New([]config.PreReceiveHookConfig{
+			{
+				URL:          server.URL,
+				Repositories: []string{"apps/**"},
+				Headers:      map[string]string{"Authorization": "Bearer secret"},
+			},
+		}, log.NewLogger("debug", ""))
+		So(err, ShouldBeNil)
+
+		request := hooks.Request{
+			Repository: "apps/frontend",
+			Reference:  "1.0",
+			Digest:     "sha256:8a4e2d4b0b7a2bc1cb6d0fe5d5a5c1e8d0b6a1d8ecf3e1d7d1b5c2a4c9e6f7a0",
+			MediaType:  "application/vnd.oci.image.manifest.v1+json",
+			Manifest:   json.RawMessage(`{"schemaVersion":2}`),
+			User:       "alice",
+		}
+
+		err = preReceiveHooks.Check(context.Background(), request)
+		So(err, ShouldBeNil)
+		So(received, ShouldHaveLength, 1)
+		So(received[0].Repository, ShouldEqual, "apps/frontend")
+		So(received[0].User, ShouldEqual, "alice")
+		So(string(received[0].Manifest), ShouldEqual, `{"schemaVersion":2}`)
+
+		request.Reference = "latest"
+		err = preReceiveHooks.Check(context.Background(), request)
+		So(err, ShouldWrap, zerr.ErrManifestRejected)
+		So(err.Error(), ShouldContainSubstring, "latest is not allowed")
+
+		// other repos aren't checked
+		request.Repository = "infra/db"
+		err = preReceiveHooks.Check(context.Background(), request)
+		So(err, ShouldBeNil)
+		So(received, ShouldHaveLength, 2)
+
+		Convey("Failing hooks reject the manifests unless they fail open", func() {
+			err := preReceiveHooks.Set([]config.PreReceiveHookConfig{{URL: server.URL}})
+			So(err, ShouldBeNil)
+
+			err = preReceiveHooks.Check(context.Background(), request)
+			So(err, ShouldWrap, zerr.ErrPreReceiveHookFailed)
+
+			err = preReceiveHooks.Set([]config.PreReceiveHookConfig{{URL: server.URL, FailOpen: true}})
+			So(err, ShouldBeNil)
+
+			err = preReceiveHooks.Check(context.Background(), request)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Hooks answering too late fail", func() {
+			slowServer := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				time.Sleep(500 * time.Millisecond)
+
+				_ = json.NewEncoder(response).Encode(hooks.Response{Allowed: true})
+			}))
+			defer slowServer.Close()
+
+			err := preReceiveHooks.Set([]config.PreReceiveHookConfig{
+				{URL: slowServer.URL, Timeout: 100 * time.Millisecond},
+			})
+			So(err, ShouldBeNil)
+
+			err = preReceiveHooks.Check(context.Background(), request)
+			So(err, ShouldWrap, zerr.ErrPreReceiveHookFailed)
+		})
+
+		Convey("Invalid responses fail", func() {
+			invalidServer := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				_, _ = response.Write([]byte("not json"))
+			}))
+			defer invalidServer.Close()
+
+			err := preReceiveHooks.Set([]config.PreReceiveHookConfig{{URL: invalidServer.URL}})
+			So(err, ShouldBeNil)
+
+			err = preReceiveHooks.Check(context.Background(), request)
+			So(err, ShouldWrap, zerr.ErrPreReceiveHookFailed)
+		})
+
+		var nilHooks *hooks.Hooks
+		So(nilHooks.Check(context.Background(), request), ShouldBeNil)
+	})
+
+	Convey("Reject invalid hooks", t, func() {
+		for _, hookConfig := range []config.PreReceiveHookConfig{
+			{URL: ""},
+			{URL: "ftp://hooks.example.com"},
+			{URL: "http://hooks.example.com", Repositories: []string{"apps/["}},
+			{URL: "http://hooks.example.com", Timeout: -time.Second},
+		} {
+			_, err := hooks.New([]config.PreReceiveHookConfig{hookConfig}, log.NewLogger("debug", ""))
+			So(err, ShouldWrap, zerr.ErrBadPreReceiveHook)
+		}
+	})
+}
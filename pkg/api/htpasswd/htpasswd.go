@@ -0,0 +1,351 @@
+package htpasswd
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+
+	zerr "zotregistry.io/zot/errors"
+)
+
+// Formats of the passphrase hashes of an htpasswd file, detected from the prefix of each entry.
+const (
+	FormatBcrypt      = "bcrypt"       // $2a$, $2b$ or $2y$, e.g. htpasswd -B
+	FormatArgon2id    = "argon2id"     // $argon2id$v=19$m=65536,t=3,p=4$salt$hash, e.g. argon2 -id -e
+	FormatScrypt      = "scrypt"       // $scrypt$ln=16,r=8,p=1$salt$hash
+	FormatSHA512Crypt = "sha512-crypt" // $6$rounds=5000$salt$hash, e.g. mkpasswd -m sha-512
+)
+
+const (
+	sha512CryptDefaultRounds = 5000
+	sha512CryptMinRounds     = 1000
+	sha512CryptMaxRounds     = 999999999
+	sha512CryptMaxSaltLen    = 16
+	cryptAlphabet            = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// GetFormat returns the format of a passphrase hash, or an empty string if it isn't supported.
+func GetFormat(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return FormatBcrypt
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return FormatArgon2id
+	case strings.HasPrefix(hash, "$scrypt$"):
+		return FormatScrypt
+	case strings.HasPrefix(hash, "$6$"):
+		return FormatSHA512Crypt
+	default:
+		return ""
+	}
+}
+
+// CompareHashAndPassword returns nil if the passphrase matches the hash, ErrPassphraseMismatch if it doesn't
+// and ErrBadPassphraseHash if the hash can't be parsed.
+func CompareHashAndPassword(hash, passphrase string) error {
+	switch GetFormat(hash) {
+	case FormatBcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(passphrase))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return zerr.ErrPassphraseMismatch
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w: %w", zerr.ErrBadPassphraseHash, err)
+		}
+
+		return nil
+	case FormatArgon2id:
+		return compareArgon2id(hash, passphrase)
+	case FormatScrypt:
+		return compareScrypt(hash, passphrase)
+	case FormatSHA512Crypt:
+		return compareSHA512Crypt(hash, passphrase)
+	default:
+		return fmt.Errorf("%w: unknown format, supported formats are %s, %s, %s and %s", zerr.ErrBadPassphraseHash,
+			FormatBcrypt, FormatArgon2id, FormatScrypt, FormatSHA512Crypt)
+	}
+}
+
+func compare(expected, actual []byte) error {
+	if subtle.ConstantTimeCompare(expected, actual) != 1 {
+		return zerr.ErrPassphraseMismatch
+	}
+
+	return nil
+}
+
+// splitPHC splits a hash in the PHC string format: $<id>$[v=<version>$]<params>$<salt>$<hash>,
+// the salt and hash being base64 encoded.
+func splitPHC(hash string) (string, string, []byte, []byte, error) {
+	fields := strings.Split(hash, "$")
+
+	var version string
+
+	switch len(fields) {
+	case 5: //nolint: gomnd
+	case 6: //nolint: gomnd
+		version = fields[2]
+		fields = append(fields[:2], fields[3:]...)
+	default:
+		return "", "", nil, nil, fmt.Errorf("%w: expected $%s$[v=<version>$]<params>$<salt>$<hash>",
+			zerr.ErrBadPassphraseHash, fields[1])
+	}
+
+	salt, err := decodePHCBase64(fields[3])
+	if err != nil {
+		return "", "", nil, nil, fmt.Errorf("%w: invalid salt: %w", zerr.ErrBadPassphraseHash, err)
+	}
+
+	key, err := decodePHCBase64(fields[4])
+	if err != nil || len(key) == 0 {
+		return "", "", nil, nil, fmt.Errorf("%w: invalid hash", zerr.ErrBadPassphraseHash)
+	}
+
+	return version, fields[2], salt, key, nil
+}
+
+// decodePHCBase64 decodes the unpadded base64 of PHC strings, also accepting the "." used instead
+// of "+" by passlib.
+func decodePHCBase64(value string) ([]byte, error) {
+	value = strings.TrimRight(strings.ReplaceAll(value, ".", "+"), "=")
+
+	return base64.RawStdEncoding.DecodeString(value)
+}
+
+// parseParams parses comma separated <name>=<number> parameters, all the expected ones are mandatory.
+func parseParams(params string, names ...string) (map[string]uint64, error) {
+	values := map[string]uint64{}
+
+	for _, param := range strings.Split(params, ",") {
+		name, value, _ := strings.Cut(param, "=")
+
+		number, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid parameter %s", zerr.ErrBadPassphraseHash, param)
+		}
+
+		values[name] = number
+	}
+
+	for _, name := range names {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("%w: missing parameter %s", zerr.ErrBadPassphraseHash, name)
+		}
+	}
+
+	return values, nil
+}
+
+func compareArgon2id(hash, passphrase string) error {
+	version, params, salt, key, err := splitPHC(hash)
+	if err != nil {
+		return err
+	}
+
+	if version != fmt.Sprintf("v=%d", argon2.Version) {
+		return fmt.Errorf("%w: unsupported argon2 version %s", zerr.ErrBadPassphraseHash, version)
+	}
+
+	values, err := parseParams(params, "m", "t", "p")
+	if err != nil {
+		return err
+	}
+
+	if values["t"] == 0 || values["p"] == 0 || values["p"] > 255 {
+		return fmt.Errorf("%w: invalid argon2 parameters %s", zerr.ErrBadPassphraseHash, params)
+	}
+
+	actual := argon2.IDKey([]byte(passphrase), salt, uint32(values["t"]), uint32(values["m"]),
+		uint8(values["p"]), uint32(len(key)))
+
+	return compare(key, actual)
+}
+
+func compareScrypt(hash, passphrase string) error {
+	version, params, salt, key, err := splitPHC(hash)
+	if err != nil {
+		return err
+	}
+
+	if version != "" {
+		return fmt.Errorf("%w: unexpected scrypt version %s", zerr.ErrBadPassphraseHash, version)
+	}
+
+	// ln is the log2 of the cost parameter N
+	values, err := parseParams(params, "ln", "r", "p")
+	if err != nil {
+		return err
+	}
+
+	if values["ln"] == 0 || values["ln"] >= 32 {
+		return fmt.Errorf("%w: invalid scrypt parameters %s", zerr.ErrBadPassphraseHash, params)
+	}
+
+	actual, err := scrypt.Key([]byte(passphrase), salt, 1<<values["ln"], int(values["r"]), int(values["p"]), len(key))
+	if err != nil {
+		return fmt.Errorf("%w: %w", zerr.ErrBadPassphraseHash, err)
+	}
+
+	return compare(key, actual)
+}
+
+func compareSHA512Crypt(hash, passphrase string) error {
+	settings := strings.TrimPrefix(hash, "$6$")
+	rounds := sha512CryptDefaultRounds
+	roundsParam := ""
+
+	if strings.HasPrefix(settings, "rounds=") {
+		var value string
+
+		value, settings, _ = strings.Cut(strings.TrimPrefix(settings, "rounds="), "$")
+
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: invalid sha512-crypt rounds %s", zerr.ErrBadPassphraseHash, value)
+		}
+
+		rounds = number
+
+		if rounds < sha512CryptMinRounds {
+			rounds = sha512CryptMinRounds
+		}
+
+		if rounds > sha512CryptMaxRounds {
+			rounds = sha512CryptMaxRounds
+		}
+		roundsParam = "rounds=" + value + "$"
+	}
+
+	sepIndex := strings.LastIndex(settings, "$")
+	if sepIndex < 0 {
+		return fmt.Errorf("%w: expected $6$[rounds=<rounds>$]<salt>$<hash>", zerr.ErrBadPassphraseHash)
+	}
+
+	salt := settings[:sepIndex]
+	if len(salt) > sha512CryptMaxSaltLen {
+		salt = salt[:sha512CryptMaxSaltLen]
+	}
+
+	expected := "$6$" + roundsParam + salt + "$" + sha512Crypt([]byte(passphrase), []byte(salt), rounds)
+
+	return compare([]byte(hash), []byte(expected))
+}
+
+// sha512Crypt returns the encoded hash of a passphrase as specified by https://akkadia.org/drepper/SHA-crypt.txt.
+func sha512Crypt(passphrase, salt []byte, rounds int) string {
+	digest := sha512.New()
+	digest.Write(passphrase)
+	digest.Write(salt)
+	digest.Write(passphrase)
+	sumB := digest.Sum(nil)
+
+	digest.Reset()
+	digest.Write(passphrase)
+	digest.Write(salt)
+
+	length := len(passphrase)
+	for ; length > sha512.Size; length -= sha512.Size {
+		digest.Write(sumB)
+	}
+
+	digest.Write(sumB[:length])
+
+	for length = len(passphrase); length > 0; length >>= 1 {
+		if length&1 != 0 {
+			digest.Write(sumB)
+		} else {
+			digest.Write(passphrase)
+		}
+	}
+
+	sumA := digest.Sum(nil)
+
+	digest.Reset()
+
+	for i := 0; i < len(passphrase); i++ {
+		digest.Write(passphrase)
+	}
+
+	passphraseSeq := repeat(digest.Sum(nil), len(passphrase))
+
+	digest.Reset()
+
+	for i := 0; i < 16+int(sumA[0]); i++ {
+		digest.Write(salt)
+	}
+
+	saltSeq := repeat(digest.Sum(nil), len(salt))
+
+	sumC := sumA
+
+	for round := 0; round < rounds; round++ {
+		digest.Reset()
+
+		if round&1 != 0 {
+			digest.Write(passphraseSeq)
+		} else {
+			digest.Write(sumC)
+		}
+
+		if round%3 != 0 {
+			digest.Write(saltSeq)
+		}
+
+		if round%7 != 0 {
+			digest.Write(passphraseSeq)
+		}
+
+		if round&1 != 0 {
+			digest.Write(sumC)
+		} else {
+			digest.Write(passphraseSeq)
+		}
+
+		sumC = digest.Sum(nil)
+	}
+
+	return encodeSHA512Crypt(sumC)
+}
+
+// repeat returns the first length bytes of sum repeated.
+func repeat(sum []byte, length int) []byte {
+	seq := make([]byte, 0, length)
+
+	for len(seq)+len(sum) <= length {
+		seq = append(seq, sum...)
+	}
+
+	return append(seq, sum[:length-len(seq)]...)
+}
+
+// encodeSHA512Crypt encodes a sha512-crypt hash with the crypt alphabet, the bytes being shuffled in groups of 3.
+func encodeSHA512Crypt(sum []byte) string {
+	var encoded strings.Builder
+
+	for i := 0; i < 21; i++ {
+		first := (i * 22) % 63
+		encode24Bits(&encoded, sum[first], sum[(first+21)%63], sum[(first+42)%63], 4) //nolint: gomnd
+	}
+
+	encode24Bits(&encoded, 0, 0, sum[63], 2) //nolint: gomnd
+
+	return encoded.String()
+}
+
+func encode24Bits(encoded *strings.Builder, byte2, byte1, byte0 byte, length int) {
+	bits := uint(byte2)<<16 | uint(byte1)<<8 | uint(byte0)
+
+	for i := 0; i < length; i++ {
+		encoded.WriteByte(cryptAlphabet[bits&0x3f])
+		bits >>= 6
+	}
+}
@@ -0,0 +1,84 @@
+package htpasswd_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/htpasswd"
+)
+
+func TestCompareHashAndPassword(t *testing.T) {
+	Convey("Check passphrases against each supported format", t, func() {
+		bcryptHash, err := bcrypt.GenerateFromPassword([]byte("test"), bcrypt.MinCost)
+		So(err, ShouldBeNil)
+
+		salt := []byte("0123456789abcdef")
+		argon2Hash := fmt.Sprintf("$argon2id$v=19$m=1024,t=2,p=1$%s$%s", base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte("test"), salt, 2, 1024, 1, 32)))
+
+		hashes := map[string]struct {
+			hash       string
+			passphrase string
+		}{
+			htpasswd.FormatBcrypt:   {string(bcryptHash), "test"},
+			htpasswd.FormatArgon2id: {argon2Hash, "test"},
+			// python3 -c 'import hashlib; hashlib.scrypt(b"test", salt=b"0123456789abcdef", n=1<<14, r=8, p=1, dklen=32)'
+			htpasswd.FormatScrypt: {
+				"$scrypt$ln=14,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg$Nb+CrdPpamPozpkiW4HbOaeq726iWjhjKj0njlfCwjA", "test",
+			},
+			// test vectors of https://akkadia.org/drepper/SHA-crypt.txt
+			htpasswd.FormatSHA512Crypt: {
+				"$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+				"Hello world!",
+			},
+			htpasswd.FormatSHA512Crypt + " with rounds": {
+				"$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/" +
+					"YTBmSK6H9qs/y3RnOaw5v.",
+				"Hello world!",
+			},
+			// passphrases longer than a sha512 sum
+			htpasswd.FormatSHA512Crypt + " with a long passphrase": {
+				"$6$abc$o275sw7z9W/Pb4A20YyemI4cFT7LWaG.VTjpEyy1cRkMFtFo4JULbmygGSDNApYLeZkJvVJuyVolV7zaAc8uM1",
+				strings.Repeat("test", 40),
+			},
+		}
+
+		for format, hash := range hashes {
+			So(htpasswd.GetFormat(hash.hash), ShouldEqual, strings.Split(format, " ")[0])
+			So(htpasswd.CompareHashAndPassword(hash.hash, hash.passphrase), ShouldBeNil)
+			So(htpasswd.CompareHashAndPassword(hash.hash, hash.passphrase+"x"), ShouldEqual, zerr.ErrPassphraseMismatch)
+		}
+	})
+
+	Convey("Reject malformed and unsupported hashes", t, func() {
+		for _, hash := range []string{
+			"plaintext",
+			"{SHA}qUqP5cyxm6YcTAhz05Hph5gvu9M=",
+			"$1$salt$hash",
+			"$2y$05$short",
+			"$argon2id$m=1024,t=2,p=1$c2FsdA$aGFzaA",
+			"$argon2id$v=16$m=1024,t=2,p=1$c2FsdA$aGFzaA",
+			"$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$aGFzaA",
+			"$argon2id$v=19$m=1024,p=1$c2FsdA$aGFzaA",
+			"$argon2id$v=19$m=1024,t=2,p=1$c2FsdA$",
+			"$scrypt$ln=14,r=8,p=1$!$aGFzaA",
+			"$scrypt$ln=0,r=8,p=1$c2FsdA$aGFzaA",
+			"$scrypt$ln=14,r=8,p=x$c2FsdA$aGFzaA",
+			"$scrypt$v=1$ln=14,r=8,p=1$c2FsdA$aGFzaA",
+			"$6$rounds=x$salt$hash",
+			"$6$salt",
+		} {
+			err := htpasswd.CompareHashAndPassword(hash, "test")
+			So(err, ShouldWrap, zerr.ErrBadPassphraseHash)
+		}
+
+		So(htpasswd.GetFormat("plaintext"), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	apiErr "zotregistry.io/zot/pkg/api/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// checkImmutableTag rejects the push of an immutable tag which already points to another manifest, pushing the
+// same manifest again is allowed. If it's rejected it writes the error response and returns false.
+// It only rejects the push early, the image store checks the tag again under its lock.
+func (rh *RouteHandler) checkImmutableTag(response http.ResponseWriter, imgStore storageTypes.ImageStore,
+	name, reference string, body []byte,
+) bool {
+	// pushes by digest don't move tags
+	if _, err := godigest.Parse(reference); err == nil {
+		return true
+	}
+
+	tagDigest, moved := rh.isImmutableTagMoved(imgStore, name, reference, godigest.FromBytes(body))
+	if !moved {
+		return true
+	}
+
+	rh.c.Log.Info().Err(zerr.ErrTagImmutable).Str("repository", name).Str("tag", reference).
+		Str("digest", tagDigest.String()).Msg("push denied")
+
+	zcommon.WriteJSON(response, http.StatusConflict,
+		apiErr.NewErrorList(apiErr.NewError(apiErr.TAG_IMMUTABLE, map[string]string{
+			"name":   name,
+			"tag":    reference,
+			"digest": tagDigest.String(),
+		}).WithMessage(zerr.ErrTagImmutable.Error())))
+
+	return false
+}
+
+// isImmutableTagMoved returns true, with the digest the tag points to, if the tag is immutable and already points
+// to another manifest than digest. New tags aren't moved, and the errors reading the tag are left to the storage.
+func (rh *RouteHandler) isImmutableTagMoved(imgStore storageTypes.ImageStore, name, tag string,
+	digest godigest.Digest,
+) (godigest.Digest, bool) {
+	if !rh.c.ImmutableTags.IsImmutable(name, tag) {
+		return "", false
+	}
+
+	_, tagDigest, _, err := imgStore.GetImageManifest(name, tag)
+	if err != nil {
+		return "", false
+	}
+
+	return tagDigest, tagDigest != digest
+}
@@ -0,0 +1,124 @@
+package immutabletags
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+)
+
+type rule struct {
+	repositories []string
+	tags         []*regexp.Regexp
+}
+
+// Rules tells which tags can't be moved to another manifest once pushed, according to the immutable tags config.
+type Rules struct {
+	rules []rule
+	lock  sync.RWMutex
+}
+
+// New compiles the enabled rules of the config.
+func New(configs []config.ImmutableTagsRule) (*Rules, error) {
+	rules := &Rules{}
+
+	if err := rules.Set(configs); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Set replaces the rules, e.g. when the config is reloaded, they are left unchanged if one of them is invalid.
+func (rules *Rules) Set(configs []config.ImmutableTagsRule) error {
+	compiled := []rule{}
+
+	for idx, ruleConfig := range configs {
+		if ruleConfig.Enable != nil && !*ruleConfig.Enable {
+			continue
+		}
+
+		for _, pattern := range ruleConfig.Repositories {
+			if !glob.ValidatePattern(pattern) {
+				return fmt.Errorf("%w: rule %d has an invalid repository pattern %s", zerr.ErrBadImmutableTagsRule,
+					idx, pattern)
+			}
+		}
+
+		tags := make([]*regexp.Regexp, 0, len(ruleConfig.Tags))
+
+		for _, tag := range ruleConfig.Tags {
+			// the whole tag has to match
+			tagRegexp, err := regexp.Compile("^(?:" + tag + ")$")
+			if err != nil {
+				return fmt.Errorf("%w: rule %d has an invalid tag regular expression: %w",
+					zerr.ErrBadImmutableTagsRule, idx, err)
+			}
+
+			tags = append(tags, tagRegexp)
+		}
+
+		compiled = append(compiled, rule{
+			repositories: ruleConfig.Repositories,
+			tags:         tags,
+		})
+	}
+
+	rules.lock.Lock()
+	defer rules.lock.Unlock()
+
+	rules.rules = compiled
+
+	return nil
+}
+
+// IsImmutable returns true if one of the rules makes the tag of the repo immutable.
+func (rules *Rules) IsImmutable(repo, tag string) bool {
+	if rules == nil {
+		return false
+	}
+
+	rules.lock.RLock()
+	defer rules.lock.RUnlock()
+
+	for _, rule := range rules.rules {
+		if rule.matchesRepo(repo) && rule.matchesTag(tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rule rule) matchesRepo(repo string) bool {
+	if len(rule.repositories) == 0 {
+		return true
+	}
+
+	for _, pattern := range rule.repositories {
+		// patterns are validated by New
+		if matched, _ := glob.Match(pattern, repo); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rule rule) matchesTag(tag string) bool {
+	if len(rule.tags) == 0 {
+		return true
+	}
+
+	for _, tagRegexp := range rule.tags {
+		if tagRegexp.MatchString(tag) {
+			return true
+		}
+	}
+
+	return false
+}
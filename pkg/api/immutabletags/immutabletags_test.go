@@ -0,0 +1,64 @@
+package immutabletags_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/immutabletags"
+)
+
+func TestImmutableTags(t *testing.T) {
+	Convey("Tell which tags are immutable", t, func() {
+		disabled := false
+
+		rules, err := immutabletags.New([]config.ImmutableTagsRule{
+			{
+				Repositories: []string{"releases/**"},
+			},
+			{
+				Repositories: []string{"apps/**"},
+				Tags:         []string{`v?\d+\.\d+\.\d+`, "stable"},
+			},
+			{
+				Tags:   []string{".*"},
+				Enable: &disabled,
+			},
+		})
+		So(err, ShouldBeNil)
+
+		So(rules.IsImmutable("releases/frontend", "latest"), ShouldBeTrue)
+		So(rules.IsImmutable("apps/frontend", "v1.2.3"), ShouldBeTrue)
+		So(rules.IsImmutable("apps/frontend", "stable"), ShouldBeTrue)
+		// the whole tag has to match
+		So(rules.IsImmutable("apps/frontend", "1.2.3-rc1"), ShouldBeFalse)
+		So(rules.IsImmutable("apps/frontend", "latest"), ShouldBeFalse)
+		So(rules.IsImmutable("infra/db", "1.2.3"), ShouldBeFalse)
+
+		Convey("Reload the rules", func() {
+			err := rules.Set([]config.ImmutableTagsRule{{Repositories: []string{"infra/*"}}})
+			So(err, ShouldBeNil)
+			So(rules.IsImmutable("infra/db", "1.2.3"), ShouldBeTrue)
+			So(rules.IsImmutable("releases/frontend", "latest"), ShouldBeFalse)
+
+			err = rules.Set([]config.ImmutableTagsRule{{Tags: []string{"("}}})
+			So(err, ShouldWrap, zerr.ErrBadImmutableTagsRule)
+			So(rules.IsImmutable("infra/db", "1.2.3"), ShouldBeTrue)
+		})
+
+		var nilRules *immutabletags.Rules
+		So(nilRules.IsImmutable("releases/frontend", "latest"), ShouldBeFalse)
+	})
+
+	Convey("Reject invalid rules", t, func() {
+		for _, rule := range []config.ImmutableTagsRule{
+			{Repositories: []string{"apps/["}},
+			{Tags: []string{"v[0-9"}},
+		} {
+			_, err := immutabletags.New([]config.ImmutableTagsRule{rule})
+			So(err, ShouldWrap, zerr.ErrBadImmutableTagsRule)
+		}
+	})
+}
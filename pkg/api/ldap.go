@@ -5,7 +5,10 @@ package api
 import (
 	"crypto/tls"
 	"crypto/x509"
+	goerrors "errors"
 	"fmt"
+	"net"
+	"strconv"
 	"sync"
 	"time"
 
@@ -15,6 +18,12 @@ import (
 	"zotregistry.io/zot/pkg/log"
 )
 
+const (
+	defaultLDAPDialTimeout      = 10 * time.Second
+	defaultLDAPFailureThreshold = 3
+	defaultLDAPBreakerTimeout   = 30 * time.Second
+)
+
 type LDAPClient struct {
 	InsecureSkipVerify bool
 	UseSSL             bool
@@ -28,73 +37,270 @@ type LDAPClient struct {
 	GroupFilter        string // e.g. "(memberUid=%s)"
 	UserGroupAttribute string // e.g. "memberOf"
 	Host               string
+	Addresses          []string // failover servers, e.g. "ldap2.example.org" or "ldap2.example.org:636"
 	ServerName         string
 	UserFilter         string // e.g. "(uid=%s)"
 	Conn               *ldap.Conn
 	ClientCertificates []tls.Certificate // Adding client certificates
 	ClientCAs          *x509.CertPool
+	DialTimeout        time.Duration
+	FailureThreshold   int
+	BreakerTimeout     time.Duration
 	Log                log.Logger
 	lock               sync.Mutex
+	servers            []*ldapServer
+	server             *ldapServer // server of Conn
+}
+
+// ldapServer is one of the servers of an LDAPClient, after FailureThreshold consecutive failed connections
+// its circuit is opened: the server is skipped so that logins don't wait for it to time out, and it's
+// checked again in the background every BreakerTimeout until it's reachable.
+type ldapServer struct {
+	host     string
+	port     int
+	failures int
+	openedAt time.Time
+	checking bool
+	lock     sync.Mutex
 }
 
-// Connect connects to the ldap backend.
+func (server *ldapServer) address() string {
+	return net.JoinHostPort(server.host, strconv.Itoa(server.port))
+}
+
+// SplitLDAPAddress returns the host and port of an LDAP server given as "host" or "host:port".
+func SplitLDAPAddress(address string, defaultPort int) (string, int, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		// no port
+		return address, defaultPort, nil //nolint: nilerr
+	}
+
+	portNumber, err := strconv.Atoi(port)
+	if err != nil || host == "" {
+		return "", 0, fmt.Errorf("%w: invalid address %s", errors.ErrLDAPConfig, address)
+	}
+
+	return host, portNumber, nil
+}
+
+// Connect connects to the first available ldap server, the main one given by Host and Port, then
+// the failover ones in order.
 func (lc *LDAPClient) Connect() error {
-	if lc.Conn == nil {
-		var l *ldap.Conn
+	if lc.Conn != nil {
+		return nil
+	}
 
-		var err error
+	var err error
 
-		address := fmt.Sprintf("%s:%d", lc.Host, lc.Port)
+	available := false
 
-		if !lc.UseSSL {
-			l, err = ldap.Dial("tcp", address)
-			if err != nil {
-				lc.Log.Error().Err(err).Str("address", address).Msg("non-TLS connection failed")
+	for _, server := range lc.getServers() {
+		if !lc.isAvailable(server) {
+			continue
+		}
 
-				return err
-			}
+		available = true
 
-			// Reconnect with TLS
-			if !lc.SkipTLS {
-				config := &tls.Config{
-					InsecureSkipVerify: lc.InsecureSkipVerify, //nolint: gosec // InsecureSkipVerify is not true by default
-					RootCAs:            lc.ClientCAs,
-				}
+		var conn *ldap.Conn
 
-				if lc.ClientCertificates != nil && len(lc.ClientCertificates) > 0 {
-					config.Certificates = lc.ClientCertificates
-				}
+		conn, err = lc.dial(server)
+		if err != nil {
+			lc.recordFailure(server)
 
-				err = l.StartTLS(config)
+			continue
+		}
 
-				if err != nil {
-					lc.Log.Error().Err(err).Str("address", address).Msg("TLS connection failed")
+		lc.recordSuccess(server)
 
-					return err
-				}
-			}
-		} else {
+		if lc.server != nil && lc.server != server {
+			lc.Log.Warn().Str("address", server.address()).Str("previous", lc.server.address()).
+				Msg("failed over to another ldap server")
+		}
+
+		lc.Conn = conn
+		lc.server = server
+
+		return nil
+	}
+
+	if !available {
+		err = fmt.Errorf("%w: all ldap servers are unavailable, they are checked again in the background",
+			errors.ErrLDAPBadConn)
+		lc.Log.Error().Err(err).Msg("unable to connect to ldap")
+	}
+
+	return err
+}
+
+func (lc *LDAPClient) getServers() []*ldapServer {
+	if lc.servers != nil {
+		return lc.servers
+	}
+
+	lc.servers = []*ldapServer{{host: lc.Host, port: lc.Port}}
+
+	for _, address := range lc.Addresses {
+		host, port, err := SplitLDAPAddress(address, lc.Port)
+		if err != nil {
+			lc.Log.Error().Err(err).Msg("skipping failover ldap server")
+
+			continue
+		}
+
+		lc.servers = append(lc.servers, &ldapServer{host: host, port: port})
+	}
+
+	return lc.servers
+}
+
+// isAvailable returns false if the circuit of the server is open, starting a background check
+// of the server if it has been skipped for long enough.
+func (lc *LDAPClient) isAvailable(server *ldapServer) bool {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if server.failures < lc.getFailureThreshold() {
+		return true
+	}
+
+	if !server.checking && time.Since(server.openedAt) >= lc.getBreakerTimeout() {
+		server.checking = true
+
+		go lc.checkServer(server)
+	}
+
+	return false
+}
+
+func (lc *LDAPClient) checkServer(server *ldapServer) {
+	conn, err := lc.dial(server)
+	if err == nil {
+		conn.Close()
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.checking = false
+
+	if err != nil {
+		server.openedAt = time.Now()
+
+		return
+	}
+
+	server.failures = 0
+
+	lc.Log.Info().Str("address", server.address()).Msg("ldap server is reachable again")
+}
+
+func (lc *LDAPClient) recordFailure(server *ldapServer) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.failures++
+
+	if server.failures == lc.getFailureThreshold() {
+		server.openedAt = time.Now()
+
+		lc.Log.Error().Str("address", server.address()).Int("failures", server.failures).
+			Str("checkedEvery", lc.getBreakerTimeout().String()).
+			Msg("too many failed connections to ldap server, skipping it until it's reachable again")
+	}
+}
+
+func (lc *LDAPClient) recordSuccess(server *ldapServer) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.failures = 0
+}
+
+func (lc *LDAPClient) getDialTimeout() time.Duration {
+	if lc.DialTimeout > 0 {
+		return lc.DialTimeout
+	}
+
+	return defaultLDAPDialTimeout
+}
+
+func (lc *LDAPClient) getFailureThreshold() int {
+	if lc.FailureThreshold > 0 {
+		return lc.FailureThreshold
+	}
+
+	return defaultLDAPFailureThreshold
+}
+
+func (lc *LDAPClient) getBreakerTimeout() time.Duration {
+	if lc.BreakerTimeout > 0 {
+		return lc.BreakerTimeout
+	}
+
+	return defaultLDAPBreakerTimeout
+}
+
+func (lc *LDAPClient) dial(server *ldapServer) (*ldap.Conn, error) {
+	address := server.address()
+	dialer := &net.Dialer{Timeout: lc.getDialTimeout()}
+
+	if !lc.UseSSL {
+		l, err := ldap.DialURL("ldap://"+address, ldap.DialWithDialer(dialer))
+		if err != nil {
+			lc.Log.Error().Err(err).Str("address", address).Msg("non-TLS connection failed")
+
+			return nil, err
+		}
+
+		// Reconnect with TLS
+		if !lc.SkipTLS {
 			config := &tls.Config{
 				InsecureSkipVerify: lc.InsecureSkipVerify, //nolint: gosec // InsecureSkipVerify is not true by default
-				ServerName:         lc.ServerName,
 				RootCAs:            lc.ClientCAs,
 			}
+
 			if lc.ClientCertificates != nil && len(lc.ClientCertificates) > 0 {
 				config.Certificates = lc.ClientCertificates
-				// config.BuildNameToCertificate()
 			}
-			l, err = ldap.DialTLS("tcp", address, config)
+
+			err = l.StartTLS(config)
+
 			if err != nil {
 				lc.Log.Error().Err(err).Str("address", address).Msg("TLS connection failed")
+				l.Close()
 
-				return err
+				return nil, err
 			}
 		}
 
-		lc.Conn = l
+		return l, nil
 	}
 
-	return nil
+	// ServerName is the name of the main server
+	serverName := lc.ServerName
+	if server.host != lc.Host {
+		serverName = server.host
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: lc.InsecureSkipVerify, //nolint: gosec // InsecureSkipVerify is not true by default
+		ServerName:         serverName,
+		RootCAs:            lc.ClientCAs,
+	}
+	if lc.ClientCertificates != nil && len(lc.ClientCertificates) > 0 {
+		config.Certificates = lc.ClientCertificates
+		// config.BuildNameToCertificate()
+	}
+
+	l, err := ldap.DialURL("ldaps://"+address, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(config))
+	if err != nil {
+		lc.Log.Error().Err(err).Str("address", address).Msg("TLS connection failed")
+
+		return nil, err
+	}
+
+	return l, nil
 }
 
 // Close closes the ldap backend connection.
@@ -132,10 +338,34 @@ func (lc *LDAPClient) Authenticate(username, password string) (bool, map[string]
 		return false, nil, nil, errors.ErrLDAPEmptyPassphrase
 	}
 
+	// a server going down is only noticed when using its connection, then retry once with the other servers
+	for reconnects := 0; ; reconnects++ {
+		if err := lc.bind(); err != nil {
+			return false, nil, nil, err
+		}
+
+		ok, user, groups, err := lc.authenticate(username, password)
+		if err == nil || reconnects > 0 || !ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+			return ok, user, groups, err
+		}
+
+		lc.Log.Warn().Err(err).Str("address", lc.server.address()).Msg("lost connection to ldap server, reconnecting")
+		lc.recordFailure(lc.server)
+		lc.Close()
+	}
+}
+
+// bind connects to the ldap backend and binds with the read only user, if any.
+func (lc *LDAPClient) bind() error {
 	connected := false
 	for retries := 0; !connected && sleepAndRetry(retries, maxRetries); retries++ {
 		err := lc.Connect()
 		if err != nil {
+			if goerrors.Is(err, errors.ErrLDAPBadConn) {
+				// every server is skipped, don't wait for them
+				return err
+			}
+
 			continue
 		}
 
@@ -159,9 +389,14 @@ func (lc *LDAPClient) Authenticate(username, password string) (bool, map[string]
 	if !connected {
 		lc.Log.Error().Err(errors.ErrLDAPBadConn).Msg("exhausted all retries")
 
-		return false, nil, nil, errors.ErrLDAPBadConn
+		return errors.ErrLDAPBadConn
 	}
 
+	return nil
+}
+
+// authenticate searches for the user then binds as the user to check the password.
+func (lc *LDAPClient) authenticate(username, password string) (bool, map[string]string, []string, error) {
 	attributes := lc.Attributes
 	attributes = append(attributes, "dn")
 	attributes = append(attributes, lc.UserGroupAttribute)
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zcommon "zotregistry.io/zot/pkg/common"
+	storageCommon "zotregistry.io/zot/pkg/storage/common"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// warningCode is the code of the Warning headers defined by the distribution spec.
+const warningCode = "299"
+
+// setLintWarnings adds a Warning header to the response for each lint rule violated by an image which was
+// accepted because the rules are configured to warn, and records the violations in repodb.
+func (rh *RouteHandler) setLintWarnings(response http.ResponseWriter, imgStore storageTypes.ImageStore,
+	name, reference, mediaType string, digest godigest.Digest, body []byte,
+) {
+	// the linter is only applied on images, not signatures or indexes
+	if mediaType != ispec.MediaTypeImageManifest {
+		return
+	}
+
+	var manifest ispec.Manifest
+
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return
+	}
+
+	descriptor := ispec.Descriptor{
+		MediaType:    mediaType,
+		Digest:       digest,
+		ArtifactType: zcommon.GetManifestArtifactType(manifest),
+		Annotations:  map[string]string{ispec.AnnotationRefName: reference},
+	}
+
+	if storageCommon.IsSignature(descriptor) {
+		return
+	}
+
+	warnings, err := rh.c.Linter.Warnings(name, digest, imgStore)
+	if err != nil {
+		rh.c.Log.Error().Err(err).Str("repository", name).Str("reference", reference).
+			Msg("linter: unable to check the image for warnings")
+
+		return
+	}
+
+	if warnings == nil {
+		return
+	}
+
+	for _, warning := range warnings {
+		response.Header().Add("Warning", warningCode+` - "`+strings.ReplaceAll(warning, `"`, `'`)+`"`)
+	}
+
+	if len(warnings) > 0 {
+		rh.c.Log.Warn().Str("repository", name).Str("reference", reference).Strs("violations", warnings).
+			Msg("linter: image accepted in spite of the lint rules it violates")
+	}
+
+	if rh.c.RepoDB != nil {
+		if err := rh.c.RepoDB.SetLintViolations(name, digest, warnings); err != nil {
+			rh.c.Log.Error().Err(err).Str("repository", name).Str("reference", reference).
+				Msg("linter: unable to record the lint violations in repodb")
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/api/loadshed"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+)
+
+func (c *Controller) InitLoadShedder() {
+	if c.Config.HTTP.LoadShedding == nil {
+		return
+	}
+
+	c.LoadShedder = loadshed.New(*c.Config.HTTP.LoadShedding, c.Metrics, c.Log)
+}
+
+// probeStorage checks the root directory of every image store can be reached.
+func (c *Controller) probeStorage() error {
+	for _, imgStore := range c.StoreController.UniqueImageStores() {
+		if !imgStore.DirExists(imgStore.RootDir()) {
+			return fmt.Errorf("%w: %s", zerr.ErrStorageProbeFailed, imgStore.RootDir())
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) runLoadShedder(reloadCtx context.Context) {
+	if c.LoadShedder == nil {
+		return
+	}
+
+	go c.LoadShedder.Run(reloadCtx, c.probeStorage)
+}
+
+// LoadShedder rejects part of the requests reaching the storage with 503 while it's unhealthy, so clients
+// retry later instead of waiting for requests queued on a slow or failing storage. The base /v2/ route and
+// the extensions are always served, so clients can still check the registry and operators monitor it.
+func LoadShedder(ctlr *Controller) mux.MiddlewareFunc {
+	// Retry-After is in whole seconds
+	retryAfter := strconv.Itoa(int(math.Max(1, math.Ceil(ctlr.LoadShedder.RetryAfter().Seconds()))))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if !isStorageRequest(request.URL.Path) || !ctlr.LoadShedder.Shed() {
+				next.ServeHTTP(response, request)
+
+				return
+			}
+
+			monitoring.IncHTTPShedRequests(ctlr.Metrics, request.Method)
+
+			response.Header().Set("Retry-After", retryAfter)
+			response.WriteHeader(http.StatusServiceUnavailable)
+		})
+	}
+}
+
+// isStorageRequest returns whether a request is served from the storage, i.e. a distribution or ORAS
+// artifacts API request other than the base route.
+func isStorageRequest(path string) bool {
+	if strings.HasPrefix(path, constants.ArtifactSpecRoutePrefix+"/") {
+		return true
+	}
+
+	if !strings.HasPrefix(path, constants.RoutePrefix+"/") {
+		return false
+	}
+
+	return path != constants.RoutePrefix+"/" && !strings.HasPrefix(path, constants.RoutePrefix+constants.ExtPrefix)
+}
@@ -0,0 +1,167 @@
+package loadshed
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+)
+
+const (
+	DefaultFraction       = 0.5
+	DefaultMinHealthScore = 0.5
+	DefaultProbeInterval  = 5 * time.Second
+	DefaultProbeTimeout   = 2 * time.Second
+
+	// weight of the last probe in the health score, starting healthy the score drops below 0.5 after two
+	// failed probes, and at most two successful probes get it back above 0.5
+	probeWeight = 0.3
+)
+
+// Probe checks the storage is reachable, it returns an error if it isn't.
+type Probe func() error
+
+// Shedder tracks the health of the storage from periodic probes, and rejects part of the requests while
+// it's unhealthy so they fail fast instead of queuing until they time out.
+type Shedder struct {
+	fraction       float64
+	minHealthScore float64
+	probeInterval  time.Duration
+	probeTimeout   time.Duration
+	// float64 bits of the health score, between 0 (every recent probe failed) and 1 (healthy)
+	score atomic.Uint64
+	// a probe is still running, e.g. stuck on an unresponsive network storage
+	probing atomic.Bool
+	metrics monitoring.MetricServer
+	log     log.Logger
+}
+
+// New creates a shedder for the storage, the storage is healthy until it's probed.
+func New(config config.LoadSheddingConfig, metrics monitoring.MetricServer, log log.Logger) *Shedder {
+	shedder := &Shedder{
+		fraction:       config.Fraction,
+		minHealthScore: config.MinHealthScore,
+		probeInterval:  config.ProbeInterval,
+		probeTimeout:   config.ProbeTimeout,
+		metrics:        metrics,
+		log:            log,
+	}
+
+	if shedder.fraction == 0 {
+		shedder.fraction = DefaultFraction
+	}
+
+	if shedder.minHealthScore == 0 {
+		shedder.minHealthScore = DefaultMinHealthScore
+	}
+
+	if shedder.probeInterval == 0 {
+		shedder.probeInterval = DefaultProbeInterval
+	}
+
+	if shedder.probeTimeout == 0 {
+		shedder.probeTimeout = DefaultProbeTimeout
+	}
+
+	shedder.score.Store(math.Float64bits(1))
+
+	return shedder
+}
+
+// Score returns the health score of the storage, between 0 and 1.
+func (shedder *Shedder) Score() float64 {
+	return math.Float64frombits(shedder.score.Load())
+}
+
+// Healthy returns whether the health score is above the configured minimum.
+func (shedder *Shedder) Healthy() bool {
+	return shedder.Score() >= shedder.minHealthScore
+}
+
+// Shed returns whether a request should be rejected, the configured fraction of the requests is while the
+// storage is unhealthy.
+func (shedder *Shedder) Shed() bool {
+	return !shedder.Healthy() && rand.Float64() < shedder.fraction //nolint:gosec // not used for security
+}
+
+// RetryAfter is how long rejected clients should wait, the health score is updated after that.
+func (shedder *Shedder) RetryAfter() time.Duration {
+	return shedder.probeInterval
+}
+
+// Run probes the storage periodically until the context is canceled.
+func (shedder *Shedder) Run(ctx context.Context, probe Probe) {
+	ticker := time.NewTicker(shedder.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			shedder.Observe(shedder.runProbe(probe))
+		}
+	}
+}
+
+// Observe updates the health score with the result of a probe.
+func (shedder *Shedder) Observe(err error) {
+	result := 1.0
+	if err != nil {
+		result = 0
+	}
+
+	for {
+		old := shedder.score.Load()
+		score := (1-probeWeight)*math.Float64frombits(old) + probeWeight*result
+
+		if shedder.score.CompareAndSwap(old, math.Float64bits(score)) {
+			wasHealthy := math.Float64frombits(old) >= shedder.minHealthScore
+			isHealthy := score >= shedder.minHealthScore
+
+			switch {
+			case wasHealthy && !isHealthy:
+				shedder.log.Warn().Err(err).Float64("score", score).Float64("fraction", shedder.fraction).
+					Msg("storage is unhealthy, shedding requests")
+			case !wasHealthy && isHealthy:
+				shedder.log.Info().Float64("score", score).Msg("storage recovered, no longer shedding requests")
+			}
+
+			monitoring.SetStorageHealthScore(shedder.metrics, score)
+
+			return
+		}
+	}
+}
+
+// runProbe runs a probe, a probe which doesn't return before the timeout fails, and so do the next ones
+// until it returns, without piling up more probes on an unresponsive storage.
+func (shedder *Shedder) runProbe(probe Probe) error {
+	if !shedder.probing.CompareAndSwap(false, true) {
+		return zerr.ErrStorageProbeTimeout
+	}
+
+	result := make(chan error, 1)
+
+	go func() {
+		defer shedder.probing.Store(false)
+
+		result <- probe()
+	}()
+
+	timer := time.NewTimer(shedder.probeTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return zerr.ErrStorageProbeTimeout
+	}
+}
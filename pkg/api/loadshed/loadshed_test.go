@@ -0,0 +1,111 @@
+package loadshed_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/loadshed"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+)
+
+func TestShedder(t *testing.T) {
+	log := log.NewLogger("debug", "")
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	Convey("The defaults are used for the settings not configured", t, func() {
+		shedder := loadshed.New(config.LoadSheddingConfig{}, metrics, log)
+		So(shedder.Score(), ShouldEqual, 1)
+		So(shedder.Healthy(), ShouldBeTrue)
+		So(shedder.Shed(), ShouldBeFalse)
+		So(shedder.RetryAfter(), ShouldEqual, loadshed.DefaultProbeInterval)
+	})
+
+	Convey("Requests are shed while the health score is below the minimum", t, func() {
+		shedder := loadshed.New(config.LoadSheddingConfig{Fraction: 1}, metrics, log)
+
+		shedder.Observe(zerr.ErrStorageProbeFailed)
+		So(shedder.Score(), ShouldBeLessThan, 1)
+		So(shedder.Healthy(), ShouldBeTrue)
+		So(shedder.Shed(), ShouldBeFalse)
+
+		shedder.Observe(zerr.ErrStorageProbeFailed)
+		So(shedder.Healthy(), ShouldBeFalse)
+		So(shedder.Shed(), ShouldBeTrue)
+
+		shedder.Observe(nil)
+		shedder.Observe(nil)
+		So(shedder.Healthy(), ShouldBeTrue)
+		So(shedder.Shed(), ShouldBeFalse)
+	})
+
+	Convey("Only the configured fraction of the requests is shed", t, func() {
+		shedder := loadshed.New(config.LoadSheddingConfig{Fraction: 0.5}, metrics, log)
+
+		for i := 0; i < 10; i++ {
+			shedder.Observe(zerr.ErrStorageProbeFailed)
+		}
+
+		shed := 0
+
+		for i := 0; i < 1000; i++ {
+			if shedder.Shed() {
+				shed++
+			}
+		}
+
+		So(shed, ShouldBeBetween, 350, 650)
+	})
+
+	Convey("Slow probes count as failed", t, func() {
+		shedder := loadshed.New(config.LoadSheddingConfig{
+			ProbeInterval: 10 * time.Millisecond,
+			ProbeTimeout:  5 * time.Millisecond,
+		}, metrics, log)
+
+		var probes atomic.Int32
+
+		unblock := make(chan struct{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go shedder.Run(ctx, func() error {
+			probes.Add(1)
+			<-unblock
+
+			return nil
+		})
+
+		So(func() bool {
+			for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+				if !shedder.Healthy() {
+					return true
+				}
+			}
+
+			return false
+		}(), ShouldBeTrue)
+
+		// no probe is started while the previous one is stuck
+		So(probes.Load(), ShouldEqual, 1)
+
+		close(unblock)
+
+		So(func() bool {
+			for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+				if shedder.Healthy() {
+					return true
+				}
+			}
+
+			return false
+		}(), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/constants"
+)
+
+// readManifestBody reads the body of a manifest upload, decompressing it if it's sent with
+// Content-Encoding: gzip, so the digest is computed and the manifest validated on its decompressed content.
+// Decompressed manifests larger than constants.MaxDecompressedManifestSize are rejected, so a small
+// compressed body can't be inflated into a huge one.
+func readManifestBody(request *http.Request) ([]byte, error) {
+	encoding := strings.ToLower(strings.TrimSpace(request.Header.Get("Content-Encoding")))
+
+	switch encoding {
+	case "", "identity":
+		return io.ReadAll(request.Body)
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", zerr.ErrBadManifest, err)
+		}
+
+		defer reader.Close()
+
+		body, err := io.ReadAll(io.LimitReader(reader, constants.MaxDecompressedManifestSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", zerr.ErrBadManifest, err)
+		}
+
+		if len(body) > constants.MaxDecompressedManifestSize {
+			return nil, zerr.ErrManifestTooLarge
+		}
+
+		return body, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", zerr.ErrUnsupportedContentEncoding, encoding)
+	}
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/plugins"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = plugins.DefaultEventRetention
+)
+
+// EventList is a page of the events of a repo.
+type EventList struct {
+	Events []plugins.Event `json:"events"`
+	// sequence of the oldest event which can still be replayed, a consumer which last saw an older event
+	// missed some of them
+	OldestSequence uint64 `json:"oldestSequence"`
+	LatestSequence uint64 `json:"latestSequence"`
+}
+
+// EventPage is a page of the events of the registry.
+type EventPage struct {
+	Events []plugins.Event `json:"events"`
+	// id to list the next events from, the events following the returned ones are not listed yet
+	Next     uint64 `json:"next"`
+	LatestID uint64 `json:"latestId"`
+}
+
+// ActivityPage is a page of the timeline of a repo.
+type ActivityPage struct {
+	Events []plugins.Event `json:"events"`
+	// sequence to list the older events from, 0 once the oldest event is listed
+	Next uint64 `json:"next"`
+}
+
+// ListEvents godoc
+// @Summary List the registry events
+// @Description Returns the events of the repos the user can read, oldest first, so that consumers can catch up
+// @Description on the events they missed, e.g. after a downtime. Pass the next id of a page as the from parameter
+// @Description of the following request to get the next page, or the events logged since.
+// @Router 	/v2/_zot/ext/events [get]
+// @Produce json
+// @Param   from     query   integer    false       "id of the first event to return"
+// @Param   limit    query   integer    false       "max number of events to return, 100 by default"
+// @Param   repo     query   string     false       "only return the events of this repo"
+// @Param   type     query   string     false       "only return the events of this type, e.g. manifestPushed"
+// @Param   since    query   string     false       "only return the events at or after this RFC 3339 time"
+// @Param   until    query   string     false       "only return the events before this RFC 3339 time"
+// @Success 200 {object} 	api.EventPage
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 500 {string} 	string 				"internal server error".
+func (rh *RouteHandler) ListEvents(response http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+
+	from, limit, ok := getEventsPage(request)
+	if !ok {
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	var since, until time.Time
+
+	for param, value := range map[string]*time.Time{"since": &since, "until": &until} {
+		if valueStr := query.Get(param); valueStr != "" {
+			parsed, err := time.Parse(time.RFC3339, valueStr)
+			if err != nil {
+				response.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			*value = parsed
+		}
+	}
+
+	repo, eventType := query.Get("repo"), query.Get("type")
+
+	events, next, latest, err := rh.c.Plugins.EventLog().List(from, limit, func(event plugins.Event) (bool, error) {
+		if (repo != "" && event.Repo != repo) || (eventType != "" && event.Type != eventType) ||
+			(!since.IsZero() && event.Timestamp.Before(since)) || (!until.IsZero() && !event.Timestamp.Before(until)) {
+			return false, nil
+		}
+
+		return localCtx.RepoIsUserAvailable(request.Context(), event.Repo)
+	})
+	if err != nil {
+		rh.c.Log.Error().Err(err).Msg("unable to list events")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, EventPage{Events: events, Next: next, LatestID: latest})
+}
+
+// ListPluginEvents godoc
+// @Summary Replay the events of a repo
+// @Description Returns the events of a repo sent to the notifier plugins, oldest first, so that consumers can
+// @Description order the events they received and fetch the ones they missed.
+// @Router 	/v2/_zot/ext/events/{name} [get]
+// @Produce json
+// @Param   name     path    string     true        "repository name"
+// @Param   from     query   integer    false       "sequence of the first event to return"
+// @Param   limit    query   integer    false       "max number of events to return, 100 by default"
+// @Success 200 {object} 	api.EventList
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 500 {string} 	string 				"internal server error".
+func (rh *RouteHandler) ListPluginEvents(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["name"]
+
+	from, limit, ok := getEventsPage(request)
+	if !ok {
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	available, err := localCtx.RepoIsUserAvailable(request.Context(), name)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if !available {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	events, oldest, latest, err := rh.c.Plugins.EventLog().Since(name, from, limit)
+	if err != nil {
+		rh.c.Log.Error().Err(err).Str("repository", name).Msg("unable to list plugin events")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, EventList{
+		Events:         events,
+		OldestSequence: oldest,
+		LatestSequence: latest,
+	})
+}
+
+// ListRepoActivity godoc
+// @Summary Get the activity of a repo
+// @Description Returns the timeline of a repo, newest first: the pushes and deletes of its images, the signatures
+// @Description added to them and the completed vulnerability scans. Pass the next sequence of a page as the from
+// @Description parameter of the following request to get the older events.
+// @Router 	/v2/_zot/ext/activity/{name} [get]
+// @Produce json
+// @Param   name     path    string     true        "repository name"
+// @Param   from     query   integer    false       "sequence of the newest event to return, the latest by default"
+// @Param   limit    query   integer    false       "max number of events to return, 100 by default"
+// @Param   type     query   []string   false       "only return the events of these types, e.g. scanCompleted"
+// @Success 200 {object} 	api.ActivityPage
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 500 {string} 	string 				"internal server error".
+func (rh *RouteHandler) ListRepoActivity(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["name"]
+
+	from, limit, ok := getEventsPage(request)
+	if !ok {
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	available, err := localCtx.RepoIsUserAvailable(request.Context(), name)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if !available {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	eventTypes := request.URL.Query()["type"]
+
+	events, next, err := rh.c.Plugins.EventLog().Before(name, from, limit, func(event plugins.Event) (bool, error) {
+		return len(eventTypes) == 0 || zcommon.Contains(eventTypes, event.Type), nil
+	})
+	if err != nil {
+		rh.c.Log.Error().Err(err).Str("repository", name).Msg("unable to list repo activity")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, ActivityPage{Events: events, Next: next})
+}
+
+// getEventsPage returns the from and limit query params, false if they are invalid.
+func getEventsPage(request *http.Request) (uint64, int, bool) {
+	var (
+		from  uint64
+		limit = defaultEventsLimit
+		err   error
+	)
+
+	if fromStr := request.URL.Query().Get("from"); fromStr != "" {
+		from, err = strconv.ParseUint(fromStr, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+
+	if limitStr := request.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return 0, 0, false
+		}
+
+		if limit > maxEventsLimit {
+			limit = maxEventsLimit
+		}
+	}
+
+	return from, limit, true
+}
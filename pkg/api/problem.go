@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	guuid "github.com/gofrs/uuid"
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/constants"
+	apiErr "zotregistry.io/zot/pkg/api/errors"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+// request IDs sent by clients or proxies are reused if they are made of these characters.
+var requestIDRegexp = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIDHandler gives each request an ID, returned in the X-Request-Id header and logged, so a failing
+// request can be found in the logs. The ID sent by the client or a proxy in the same header is kept.
+func RequestIDHandler() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			requestID := request.Header.Get(constants.RequestIDHeader)
+
+			if !requestIDRegexp.MatchString(requestID) {
+				uuid, err := guuid.NewV4()
+				if err == nil {
+					requestID = uuid.String()
+				} else {
+					requestID = ""
+				}
+			}
+
+			if requestID != "" {
+				response.Header().Set(constants.RequestIDHeader, requestID)
+				request = request.WithContext(localCtx.WithRequestID(request.Context(), requestID))
+			}
+
+			next.ServeHTTP(response, request)
+		})
+	}
+}
+
+// ProblemDetailsHandler returns the error responses in the application/problem+json format to the clients
+// preferring it to application/json in their Accept header, other clients get the body defined by
+// the distribution spec.
+func ProblemDetailsHandler(ctlr *Controller) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if !prefersProblemDetails(request.Header.Get("Accept")) {
+				next.ServeHTTP(response, request)
+
+				return
+			}
+
+			problemWr := &problemWriter{ResponseWriter: response}
+
+			next.ServeHTTP(problemWr, request)
+
+			problemWr.flush(request, ctlr.Config.HTTP.ErrorDocsURL)
+		})
+	}
+}
+
+// prefersProblemDetails checks if application/problem+json is accepted with a quality at least as high as
+// application/json's, wildcards only match application/json.
+func prefersProblemDetails(accept string) bool {
+	var problemQuality, jsonQuality float64
+
+	for _, accepted := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+
+		if value, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case constants.ProblemMediaType:
+			problemQuality = quality
+		case constants.DefaultMediaType, "application/*", "*/*":
+			if quality > jsonQuality {
+				jsonQuality = quality
+			}
+		}
+	}
+
+	return problemQuality > 0 && problemQuality >= jsonQuality
+}
+
+// problemWriter holds back the JSON error responses, so they are converted once the handler is done.
+type problemWriter struct {
+	http.ResponseWriter
+	status int
+	body   *bytes.Buffer
+}
+
+func (w *problemWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+
+	w.status = status
+
+	contentType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if status >= http.StatusBadRequest && contentType == constants.DefaultMediaType {
+		w.body = &bytes.Buffer{}
+
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *problemWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.body != nil {
+		return w.body.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// ReadFrom lets the underlying writer send files with sendfile, unless the response is held back.
+func (w *problemWriter) ReadFrom(reader io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.body != nil {
+		return w.body.ReadFrom(reader)
+	}
+
+	return io.Copy(w.ResponseWriter, reader)
+}
+
+// Unwrap lets handlers flush streamed responses through the writer, they aren't held back.
+func (w *problemWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// flush writes the held back response, converted if it's a list of errors.
+func (w *problemWriter) flush(request *http.Request, docsURL string) {
+	if w.body == nil {
+		return
+	}
+
+	body := w.body.Bytes()
+
+	var errList apiErr.ErrorList
+
+	if err := json.Unmarshal(body, &errList); err == nil && len(errList.Errors) > 0 {
+		problem := apiErr.NewProblem(w.status, errList, request.URL.Path,
+			localCtx.GetRequestID(request.Context()), docsURL)
+
+		if problemBody, err := json.Marshal(problem); err == nil {
+			w.Header().Set("Content-Type", constants.ProblemMediaType)
+			body = problemBody
+		}
+	}
+
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(body)
+}
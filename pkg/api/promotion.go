@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/api/promotion"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+func (c *Controller) InitPromotionGates() error {
+	var configs []extconf.PromotionGateConfig
+
+	// the checks of the gates need the metadata in repodb
+	if c.Config.Extensions != nil && c.Config.Extensions.Search != nil && c.Config.Extensions.Search.Enable != nil &&
+		*c.Config.Extensions.Search.Enable {
+		configs = c.Config.Extensions.Search.PromotionGates
+	}
+
+	gates, err := promotion.New(configs)
+	if err != nil {
+		return err
+	}
+
+	c.PromotionGates = gates
+
+	return nil
+}
+
+// checkPromotionGates denies pushing a tag protected by a promotion gate, such tags are only created by
+// promoting an image through the promotion API. Pushing by digest is always allowed, images are pushed
+// that way before being promoted.
+func (rh *RouteHandler) checkPromotionGates(response http.ResponseWriter, request *http.Request,
+	name, reference string,
+) bool {
+	if _, err := godigest.Parse(reference); err == nil {
+		return true
+	}
+
+	gates := rh.c.PromotionGates.Match(name, reference)
+	if len(gates) == 0 {
+		return true
+	}
+
+	var username string
+
+	if acCtx, err := localCtx.GetAccessControlContext(request.Context()); err == nil {
+		username = localCtx.GetUsernameFromContext(acCtx)
+	}
+
+	rh.c.Log.Info().Str("repository", name).Str("tag", reference).Str("environment", gates[0].Environment).
+		Str("user", username).Msg("denied pushing a tag protected by a promotion gate")
+
+	writeDeniedError(response, zerr.ErrTagProtected, map[string]string{
+		"name":        name,
+		"tag":         reference,
+		"environment": gates[0].Environment,
+		"promotion":   constants.FullPromotionPrefix + "/" + name + "/" + reference,
+	})
+
+	return false
+}
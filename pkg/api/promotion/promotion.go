@@ -0,0 +1,283 @@
+package promotion
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/meta/repodb"
+)
+
+const (
+	CheckSignature       = "signature"
+	CheckVulnerabilities = "vulnerabilities"
+	CheckProvenance      = "provenance"
+
+	// artifact type of the in-toto attestations, e.g. SLSA provenance, attached as referrers.
+	InTotoArtifactType = "application/vnd.in-toto+json"
+)
+
+// Gate is a compiled promotion gate, see extconf.PromotionGateConfig.
+type Gate struct {
+	Environment             string
+	RequireSignature        bool
+	SeverityThreshold       string
+	RequireProvenance       bool
+	ProvenanceArtifactTypes []string
+
+	repositories []string
+	tag          *regexp.Regexp
+}
+
+// Gates gives the gates protecting a tag, according to the promotion gates of the config.
+type Gates struct {
+	gates []Gate
+	lock  sync.RWMutex
+}
+
+// Report is the result of checking an image against the gates protecting the tag it's promoted to.
+type Report struct {
+	Repository string  `json:"repository"`
+	Tag        string  `json:"tag"`
+	Digest     string  `json:"digest"`
+	OK         bool    `json:"ok"`
+	Checks     []Check `json:"checks"`
+}
+
+// Check is a single check of a gate.
+type Check struct {
+	Environment string `json:"environment"`
+	Name        string `json:"name"`
+	OK          bool   `json:"ok"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ScanFunc returns the highest severity of the vulnerabilities of an image, NONE if it has none.
+type ScanFunc func(repo string, digest godigest.Digest) (string, error)
+
+// New compiles the promotion gates of the config.
+func New(configs []extconf.PromotionGateConfig) (*Gates, error) {
+	gates := &Gates{}
+
+	if err := gates.Set(configs); err != nil {
+		return nil, err
+	}
+
+	return gates, nil
+}
+
+// Set replaces the gates, e.g. when the config is reloaded, they are left unchanged if one of them is invalid.
+func (gates *Gates) Set(configs []extconf.PromotionGateConfig) error {
+	compiled := []Gate{}
+
+	for idx, gateConfig := range configs {
+		if gateConfig.Environment == "" || gateConfig.Tag == "" {
+			return fmt.Errorf("%w: gate %d needs an environment and a tag", zerr.ErrBadPromotionGate, idx)
+		}
+
+		for _, pattern := range gateConfig.Repositories {
+			if !glob.ValidatePattern(pattern) {
+				return fmt.Errorf("%w: gate %s has an invalid repository pattern %s", zerr.ErrBadPromotionGate,
+					gateConfig.Environment, pattern)
+			}
+		}
+
+		// the whole tag has to match
+		tagRegexp, err := regexp.Compile("^(?:" + gateConfig.Tag + ")$")
+		if err != nil {
+			return fmt.Errorf("%w: gate %s has an invalid tag regular expression: %w", zerr.ErrBadPromotionGate,
+				gateConfig.Environment, err)
+		}
+
+		severityThreshold := gateConfig.SeverityThreshold
+		if severityThreshold != "" && cvemodel.SeverityValue(severityThreshold) == cvemodel.None {
+			return fmt.Errorf("%w: gate %s has an invalid severity threshold %s, it has to be LOW, MEDIUM, HIGH "+
+				"or CRITICAL", zerr.ErrBadPromotionGate, gateConfig.Environment, severityThreshold)
+		}
+
+		artifactTypes := gateConfig.ProvenanceArtifactTypes
+		if len(artifactTypes) == 0 {
+			artifactTypes = []string{InTotoArtifactType}
+		}
+
+		compiled = append(compiled, Gate{
+			Environment:             gateConfig.Environment,
+			RequireSignature:        gateConfig.RequireSignature,
+			SeverityThreshold:       severityThreshold,
+			RequireProvenance:       gateConfig.RequireProvenance,
+			ProvenanceArtifactTypes: artifactTypes,
+			repositories:            gateConfig.Repositories,
+			tag:                     tagRegexp,
+		})
+	}
+
+	gates.lock.Lock()
+	defer gates.lock.Unlock()
+
+	gates.gates = compiled
+
+	return nil
+}
+
+// Match returns the gates protecting the tag of the repo, in the order of the config.
+func (gates *Gates) Match(repo, tag string) []Gate {
+	if gates == nil {
+		return nil
+	}
+
+	gates.lock.RLock()
+	defer gates.lock.RUnlock()
+
+	matched := []Gate{}
+
+	for _, gate := range gates.gates {
+		if gate.matches(repo, tag) {
+			matched = append(matched, gate)
+		}
+	}
+
+	return matched
+}
+
+// IsProtected returns whether the tag of the repo can only be created through the promotion API.
+func (gates *Gates) IsProtected(repo, tag string) bool {
+	return len(gates.Match(repo, tag)) > 0
+}
+
+func (gate Gate) matches(repo, tag string) bool {
+	if !gate.tag.MatchString(tag) {
+		return false
+	}
+
+	if len(gate.repositories) == 0 {
+		return true
+	}
+
+	for _, pattern := range gate.repositories {
+		if matched, err := glob.Match(pattern, repo); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Evaluate checks the image with the digest against the gates protecting the tag it's promoted to: it has to be
+signed, referred by a provenance attestation and scanned without vulnerabilities at or above the threshold,
+as each gate requires. scan is only called if a gate has a severity threshold, it may be nil if vulnerability
+scanning is disabled, in which case the check fails.
+*/
+func Evaluate(gates []Gate, repoMeta repodb.RepoMetadata, tag string, digest godigest.Digest, scan ScanFunc,
+) Report {
+	report := Report{
+		Repository: repoMeta.Name,
+		Tag:        tag,
+		Digest:     digest.String(),
+		OK:         true,
+		Checks:     []Check{},
+	}
+
+	// the image is scanned once, whatever the number of gates
+	var (
+		maxSeverity string
+		scanErr     error
+		scanned     bool
+	)
+
+	for _, gate := range gates {
+		if gate.RequireSignature {
+			report.add(gate, CheckSignature, checkSignature(repoMeta, digest))
+		}
+
+		if gate.SeverityThreshold != "" {
+			if !scanned {
+				maxSeverity, scanErr = runScan(scan, repoMeta.Name, digest)
+				scanned = true
+			}
+
+			report.add(gate, CheckVulnerabilities, checkVulnerabilities(gate, maxSeverity, scanErr))
+		}
+
+		if gate.RequireProvenance {
+			report.add(gate, CheckProvenance, checkProvenance(gate, repoMeta, digest))
+		}
+	}
+
+	return report
+}
+
+func (report *Report) add(gate Gate, name string, err error) {
+	check := Check{Environment: gate.Environment, Name: name, OK: err == nil}
+
+	if err != nil {
+		check.Error = err.Error()
+		report.OK = false
+	}
+
+	report.Checks = append(report.Checks, check)
+}
+
+func checkSignature(repoMeta repodb.RepoMetadata, digest godigest.Digest) error {
+	for _, signatures := range repoMeta.Signatures[digest.String()] {
+		if len(signatures) > 0 {
+			return nil
+		}
+	}
+
+	return zerr.ErrImageNotSigned
+}
+
+func runScan(scan ScanFunc, repo string, digest godigest.Digest) (string, error) {
+	if scan == nil {
+		return "", fmt.Errorf("%w: vulnerability scanning is disabled", zerr.ErrImageNotScanned)
+	}
+
+	maxSeverity, err := scan(repo, digest)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", zerr.ErrImageNotScanned, err)
+	}
+
+	// the scanner returns no severity for the images it can't scan
+	if maxSeverity == "" {
+		return "", zerr.ErrImageNotScanned
+	}
+
+	return maxSeverity, nil
+}
+
+func checkVulnerabilities(gate Gate, maxSeverity string, scanErr error) error {
+	if scanErr != nil {
+		return scanErr
+	}
+
+	if cvemodel.SeverityValue(maxSeverity) >= cvemodel.SeverityValue(gate.SeverityThreshold) {
+		return fmt.Errorf("%w: the highest severity is %s, the threshold is %s", zerr.ErrImageVulnerable,
+			maxSeverity, gate.SeverityThreshold)
+	}
+
+	return nil
+}
+
+func checkProvenance(gate Gate, repoMeta repodb.RepoMetadata, digest godigest.Digest) error {
+	for _, referrer := range repoMeta.Referrers[digest.String()] {
+		for _, artifactType := range gate.ProvenanceArtifactTypes {
+			if referrer.ArtifactType == artifactType {
+				return nil
+			}
+		}
+	}
+
+	// cosign attaches its attestations with a tag named after the digest, recorded apart from the tags
+	if len(repoMeta.Attestations[digest.String()]) > 0 {
+		return nil
+	}
+
+	return zerr.ErrNoProvenance
+}
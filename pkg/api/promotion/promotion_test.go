@@ -0,0 +1,144 @@
+package promotion_test
+
+import (
+	"errors"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/promotion"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/meta/repodb"
+)
+
+var ErrTestScan = errors.New("test: scan error")
+
+func TestPromotionGates(t *testing.T) {
+	Convey("Match the gates protecting a tag", t, func() {
+		gates, err := promotion.New([]extconf.PromotionGateConfig{
+			{Environment: "prod", Tag: "prod", RequireSignature: true},
+			{Environment: "release", Repositories: []string{"apps/**"}, Tag: `release-.*`, RequireProvenance: true},
+		})
+		So(err, ShouldBeNil)
+
+		matched := gates.Match("infra/db", "prod")
+		So(len(matched), ShouldEqual, 1)
+		So(matched[0].Environment, ShouldEqual, "prod")
+
+		So(gates.IsProtected("apps/frontend", "release-1.0"), ShouldBeTrue)
+		So(gates.IsProtected("infra/db", "release-1.0"), ShouldBeFalse)
+		// the whole tag has to match
+		So(gates.IsProtected("infra/db", "prod-1"), ShouldBeFalse)
+
+		Convey("Reload the gates", func() {
+			err := gates.Set([]extconf.PromotionGateConfig{{Environment: "prod", Tag: "("}})
+			So(err, ShouldWrap, zerr.ErrBadPromotionGate)
+			So(gates.IsProtected("infra/db", "prod"), ShouldBeTrue)
+
+			So(gates.Set(nil), ShouldBeNil)
+			So(gates.IsProtected("infra/db", "prod"), ShouldBeFalse)
+		})
+
+		var nilGates *promotion.Gates
+		So(nilGates.IsProtected("infra/db", "prod"), ShouldBeFalse)
+	})
+
+	Convey("Reject invalid gates", t, func() {
+		for _, gate := range []extconf.PromotionGateConfig{
+			{Tag: "prod"},
+			{Environment: "prod"},
+			{Environment: "prod", Tag: "prod", Repositories: []string{"[apps"}},
+			{Environment: "prod", Tag: "prod", SeverityThreshold: "NONE"},
+		} {
+			_, err := promotion.New([]extconf.PromotionGateConfig{gate})
+			So(err, ShouldWrap, zerr.ErrBadPromotionGate)
+		}
+	})
+
+	Convey("Check images against the gates", t, func() {
+		digest := godigest.FromString("image")
+
+		gates, err := promotion.New([]extconf.PromotionGateConfig{
+			{Environment: "staging", Tag: "prod", SeverityThreshold: "CRITICAL"},
+			{
+				Environment: "prod", Tag: "prod", RequireSignature: true, SeverityThreshold: "HIGH",
+				RequireProvenance: true,
+			},
+		})
+		So(err, ShouldBeNil)
+
+		scans := 0
+		scan := func(repo string, digest godigest.Digest) (string, error) {
+			scans++
+
+			return "HIGH", nil
+		}
+
+		repoMeta := repodb.RepoMetadata{Name: "infra/db"}
+
+		report := promotion.Evaluate(gates.Match("infra/db", "prod"), repoMeta, "prod", digest, scan)
+		So(report.OK, ShouldBeFalse)
+		So(report.Repository, ShouldEqual, "infra/db")
+		So(report.Digest, ShouldEqual, digest.String())
+		So(scans, ShouldEqual, 1)
+		So(report.Checks, ShouldResemble, []promotion.Check{
+			{Environment: "staging", Name: promotion.CheckVulnerabilities, OK: true},
+			{
+				Environment: "prod", Name: promotion.CheckSignature, OK: false,
+				Error: zerr.ErrImageNotSigned.Error(),
+			},
+			{
+				Environment: "prod", Name: promotion.CheckVulnerabilities, OK: false,
+				Error: zerr.ErrImageVulnerable.Error() + ": the highest severity is HIGH, the threshold is HIGH",
+			},
+			{
+				Environment: "prod", Name: promotion.CheckProvenance, OK: false,
+				Error: zerr.ErrNoProvenance.Error(),
+			},
+		})
+
+		repoMeta.Signatures = map[string]repodb.ManifestSignatures{
+			digest.String(): {"cosign": []repodb.SignatureInfo{{SignatureManifestDigest: "sha256:sig"}}},
+		}
+		repoMeta.Referrers = map[string][]repodb.ReferrerInfo{
+			digest.String(): {{Digest: "sha256:att", ArtifactType: promotion.InTotoArtifactType}},
+		}
+
+		report = promotion.Evaluate(gates.Match("infra/db", "prod"), repoMeta, "prod", digest,
+			func(repo string, digest godigest.Digest) (string, error) {
+				return "NONE", nil
+			})
+		So(report.OK, ShouldBeTrue)
+		So(len(report.Checks), ShouldEqual, 4)
+
+		// cosign attestations are tagged after the digest and recorded apart from the tags
+		repoMeta.Referrers = nil
+		repoMeta.Attestations = map[string][]repodb.AttestationInfo{
+			digest.String(): {{ManifestDigest: "sha256:att", Tool: repodb.CosignAttestation}},
+		}
+
+		report = promotion.Evaluate(gates.Match("infra/db", "prod"), repoMeta, "prod", digest,
+			func(repo string, digest godigest.Digest) (string, error) {
+				return "MEDIUM", nil
+			})
+		So(report.OK, ShouldBeTrue)
+
+		// images which can't be scanned don't pass
+		for _, scan := range []promotion.ScanFunc{
+			nil,
+			func(repo string, digest godigest.Digest) (string, error) { return "", nil },
+			func(repo string, digest godigest.Digest) (string, error) { return "", ErrTestScan },
+		} {
+			report = promotion.Evaluate(gates.Match("infra/db", "prod"), repoMeta, "prod", digest, scan)
+			So(report.OK, ShouldBeFalse)
+			So(report.Checks[0].Error, ShouldStartWith, zerr.ErrImageNotScanned.Error())
+		}
+
+		// tags not protected by a gate can be promoted
+		report = promotion.Evaluate(gates.Match("infra/db", "dev"), repoMeta, "dev", digest, nil)
+		So(report.OK, ShouldBeTrue)
+		So(report.Checks, ShouldBeEmpty)
+	})
+}
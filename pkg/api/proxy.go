@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// externalLocation prefixes a location generated by zot, e.g. /v2/<name>/blobs/uploads/<session_id>,
+// with the URL clients reach zot at.
+// The URL is the externalURL setting if given, else it's built from the X-Forwarded-Proto, X-Forwarded-Host
+// and X-Forwarded-Prefix headers of the requests coming from trusted proxies.
+// Without both the location is returned as is, relative to the host of the request.
+func (rh *RouteHandler) externalLocation(request *http.Request, location string) string {
+	httpConfig := rh.c.Config.HTTP
+
+	if httpConfig.ExternalURL != "" {
+		return strings.TrimSuffix(httpConfig.ExternalURL, "/") + location
+	}
+
+	if len(httpConfig.TrustedProxies) == 0 || !isTrustedProxy(request.RemoteAddr, httpConfig.TrustedProxies) {
+		return location
+	}
+
+	proto := getForwardedHeader(request, "X-Forwarded-Proto")
+	host := getForwardedHeader(request, "X-Forwarded-Host")
+	prefix := getForwardedHeader(request, "X-Forwarded-Prefix")
+
+	if proto == "" && host == "" && prefix == "" {
+		return location
+	}
+
+	// ignore the values which would make the clients go somewhere else than the proxy
+	if proto != "http" && proto != "https" {
+		proto = "http"
+
+		if request.TLS != nil {
+			proto = "https"
+		}
+	}
+
+	// IPv6 literals have to be enclosed in brackets
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		host = "[" + host + "]"
+	}
+
+	if host == "" || strings.ContainsAny(host, "/?#@\\ ") {
+		host = request.Host
+	}
+
+	if !strings.HasPrefix(prefix, "/") || path.Clean(prefix) != strings.TrimSuffix(prefix, "/") {
+		prefix = ""
+	}
+
+	return proto + "://" + host + strings.TrimSuffix(prefix, "/") + location
+}
+
+// getForwardedHeader returns the first value of a X-Forwarded-* header, the one set by the proxy nearest
+// to the client.
+func getForwardedHeader(request *http.Request, header string) string {
+	value, _, _ := strings.Cut(request.Header.Get(header), ",")
+
+	return strings.TrimSpace(value)
+}
+
+// isTrustedProxy checks if the address of the peer of a request is one of the trusted IPs or CIDRs.
+func isTrustedProxy(remoteAddr string, trustedProxies []string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	peerIP := net.ParseIP(host)
+	if peerIP == nil {
+		return false
+	}
+
+	for _, trustedProxy := range trustedProxies {
+		if _, ipNet, err := net.ParseCIDR(trustedProxy); err == nil {
+			if ipNet.Contains(peerIP) {
+				return true
+			}
+
+			continue
+		}
+
+		if trustedIP := net.ParseIP(trustedProxy); trustedIP != nil && trustedIP.Equal(peerIP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientIP returns the IP of the client of a request. If the request comes from a trusted proxy, the hops of
+// the X-Forwarded-For header are walked from the right, the proxy nearest to zot, and the first one which isn't
+// a trusted proxy is the client: the hops on its left are set by the client itself and can't be trusted.
+func clientIP(request *http.Request, trustedProxies []string) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+
+	if len(trustedProxies) == 0 || !isTrustedProxy(request.RemoteAddr, trustedProxies) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(request.Header.Values("X-Forwarded-For"), ","), ",")
+
+	for idx := len(hops) - 1; idx >= 0; idx-- {
+		hop := strings.TrimSpace(hops[idx])
+
+		if net.ParseIP(hop) == nil {
+			// stop at the hops which aren't IPs, e.g. when the header is missing
+			break
+		}
+
+		host = hop
+
+		if !isTrustedProxy(hop, trustedProxies) {
+			break
+		}
+	}
+
+	return host
+}
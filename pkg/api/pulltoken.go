@@ -0,0 +1,280 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	apiErr "zotregistry.io/zot/pkg/api/errors"
+	"zotregistry.io/zot/pkg/api/pulltoken"
+	zcommon "zotregistry.io/zot/pkg/common"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// PullTokenQueryParam carries the pull token in shareable URLs, for clients which can't set the
+// Authorization header.
+const PullTokenQueryParam = "token"
+
+// PullTokenResponse is returned when a pull token is minted.
+type PullTokenResponse struct {
+	Token      string    `json:"token"`
+	Repository string    `json:"repository"`
+	Digest     string    `json:"digest,omitempty"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	// path of the manifest of the image, or of the tags of the repo, carrying the token
+	URL string `json:"url"`
+}
+
+func (c *Controller) InitPullTokens() error {
+	var pullTokensConfig *config.PullTokensConfig
+
+	// pull tokens are checked along the tokens of the bearer auth server
+	if isBearerAuthEnabled(c.Config.HTTP.Auth) {
+		pullTokensConfig = c.Config.HTTP.Auth.Bearer.PullTokens
+	}
+
+	signer, err := pulltoken.New(pullTokensConfig)
+	if err != nil {
+		c.Log.Error().Err(err).Msg("unable to set up pull tokens")
+
+		return err
+	}
+
+	c.PullTokens = signer
+
+	return nil
+}
+
+func getPullToken(request *http.Request) string {
+	if header := request.Header.Get("Authorization"); header != "" {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return ""
+		}
+
+		return token
+	}
+
+	return request.URL.Query().Get(PullTokenQueryParam)
+}
+
+// authorizePullToken returns whether the request carries a valid pull token allowing it. Pull tokens only
+// allow reading the manifests, blobs, tags and referrers of their repo, or of their image if they're
+// scoped to a digest. Other tokens are left to the bearer auth server.
+func (c *Controller) authorizePullToken(request *http.Request) bool {
+	if c.PullTokens == nil || (request.Method != http.MethodGet && request.Method != http.MethodHead) {
+		return false
+	}
+
+	token := getPullToken(request)
+	if token == "" {
+		return false
+	}
+
+	claims, err := c.PullTokens.Parse(token)
+	if err != nil {
+		return false
+	}
+
+	vars := mux.Vars(request)
+	name := vars["name"]
+
+	// clients check the API version before pulling
+	if name == "" {
+		return request.URL.Path == constants.RoutePrefix+"/"
+	}
+
+	if name != claims.Repository {
+		return false
+	}
+
+	route, _ := strings.CutPrefix(request.URL.Path, constants.RoutePrefix+"/"+name+"/")
+
+	switch {
+	case strings.HasPrefix(route, "manifests/"):
+		if claims.Digest == "" {
+			return true
+		}
+
+		return c.isPartOfImage(name, claims.Digest, vars["reference"])
+	case strings.HasPrefix(route, "blobs/") && !strings.HasPrefix(route, "blobs/uploads/"):
+		if claims.Digest == "" {
+			return true
+		}
+
+		return c.isPartOfImage(name, claims.Digest, vars["digest"])
+	case route == "tags/list", strings.HasPrefix(route, "referrers/"):
+		// tags and referrers aren't part of the image
+		return claims.Digest == ""
+	}
+
+	return false
+}
+
+// isPartOfImage returns whether digest is the digest of the image, of one of its manifests or of one of its blobs.
+func (c *Controller) isPartOfImage(repo, imageDigest, digest string) bool {
+	if digest == imageDigest {
+		return true
+	}
+
+	return isPartOfManifest(c.StoreController.GetImageStore(repo), repo, imageDigest, digest)
+}
+
+func isPartOfManifest(imgStore storageTypes.ImageStore, repo, manifestDigest, digest string) bool {
+	manifestBlob, _, mediaType, err := imgStore.GetImageManifest(repo, manifestDigest)
+	if err != nil {
+		return false
+	}
+
+	if zcommon.IsImageIndex(mediaType) {
+		var index ispec.Index
+
+		if err := json.Unmarshal(manifestBlob, &index); err != nil {
+			return false
+		}
+
+		for _, manifest := range index.Manifests {
+			if manifest.Digest.String() == digest ||
+				isPartOfManifest(imgStore, repo, manifest.Digest.String(), digest) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var manifest ispec.Manifest
+
+	if err := json.Unmarshal(manifestBlob, &manifest); err != nil {
+		return false
+	}
+
+	if manifest.Config.Digest.String() == digest {
+		return true
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.Digest.String() == digest {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CreatePullToken godoc
+// @Summary Mint a pull token
+// @Description Mint a time limited, read only token, scoped to a repo or to a single image of the repo, to share
+// @Description images with users without accounts. The token can be sent as a bearer token or in the token query
+// @Description parameter. Minting a token requires push access to the repo.
+// @Router 	/v2/_zot/ext/pulltokens/{name} [post]
+// @Produce json
+// @Param   name     path    string     true        "repository name"
+// @Param   digest   query   string     false       "digest of the image the token is scoped to"
+// @Param   ttl      query   string     false       "validity of the token, e.g. 30m, 1h by default"
+// @Success 201 {object} 	api.PullTokenResponse
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func (rh *RouteHandler) CreatePullToken(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["name"]
+
+	var (
+		digest godigest.Digest
+		ttl    time.Duration
+		err    error
+	)
+
+	if digestStr := request.URL.Query().Get("digest"); digestStr != "" {
+		digest, err = godigest.Parse(digestStr)
+		if err != nil {
+			zcommon.WriteJSON(response, http.StatusBadRequest,
+				apiErr.NewErrorList(apiErr.NewError(apiErr.DIGEST_INVALID, map[string]string{"digest": digestStr})))
+
+			return
+		}
+	}
+
+	if ttlStr := request.URL.Query().Get("ttl"); ttlStr != "" {
+		ttl, err = time.ParseDuration(ttlStr)
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	imgStore := rh.getImageStore(request, name)
+
+	if digest != "" {
+		_, _, _, err = imgStore.GetImageManifest(name, digest.String())
+	} else {
+		_, err = imgStore.GetImageTags(name)
+	}
+
+	if err != nil {
+		if errors.Is(err, zerr.ErrRepoNotFound) {
+			zcommon.WriteJSON(response, http.StatusNotFound,
+				apiErr.NewErrorList(apiErr.NewError(apiErr.NAME_UNKNOWN, map[string]string{"name": name})))
+		} else if errors.Is(err, zerr.ErrManifestNotFound) {
+			zcommon.WriteJSON(response, http.StatusNotFound, apiErr.NewErrorList(
+				apiErr.NewError(apiErr.MANIFEST_UNKNOWN, map[string]string{"digest": digest.String()})))
+		} else {
+			rh.c.Log.Error().Err(err).Str("repository", name).Msg("unable to check the image to share")
+			response.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	// the token of the bearer auth server was already checked, it's only decoded to log who minted the token
+	var minter jwt.RegisteredClaims
+
+	if header := request.Header.Get("Authorization"); header != "" {
+		if bearerToken, ok := strings.CutPrefix(header, "Bearer "); ok {
+			_, _, _ = jwt.NewParser().ParseUnverified(bearerToken, &minter)
+		}
+	}
+
+	token, claims, err := rh.c.PullTokens.Mint(name, digest, ttl, minter.Subject)
+	if err != nil {
+		if errors.Is(err, zerr.ErrPullTokenTTL) {
+			zcommon.WriteJSON(response, http.StatusBadRequest,
+				apiErr.NewErrorList(apiErr.NewError(apiErr.UNSUPPORTED, map[string]string{"ttl": ttl.String()}).
+					WithMessage(err.Error())))
+
+			return
+		}
+
+		rh.c.Log.Error().Err(err).Str("repository", name).Msg("unable to mint pull token")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	rh.c.Log.Info().Str("repository", name).Str("digest", digest.String()).Str("subject", minter.Subject).
+		Time("expiresAt", claims.ExpiresAt.Time).Msg("pull token minted")
+
+	url := constants.RoutePrefix + "/" + name + "/tags/list"
+	if digest != "" {
+		url = constants.RoutePrefix + "/" + name + "/manifests/" + digest.String()
+	}
+
+	zcommon.WriteJSON(response, http.StatusCreated, PullTokenResponse{
+		Token:      token,
+		Repository: name,
+		Digest:     digest.String(),
+		ExpiresAt:  claims.ExpiresAt.Time,
+		URL:        url + "?" + PullTokenQueryParam + "=" + token,
+	})
+}
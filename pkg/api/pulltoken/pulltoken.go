@@ -0,0 +1,131 @@
+package pulltoken
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+)
+
+const (
+	// Issuer tells pull tokens apart from the tokens of the bearer auth server.
+	Issuer = "zot-pull-token"
+
+	DefaultTTL    = time.Hour
+	DefaultMaxTTL = 24 * time.Hour
+
+	// minKeySize is the minimum size of the secret, as recommended for HMAC-SHA256.
+	minKeySize = 32
+)
+
+// Claims scope a pull token to a repo, and to a single image of the repo if Digest is set.
+type Claims struct {
+	jwt.RegisteredClaims
+	Repository string `json:"repository"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// Signer mints pull tokens and checks the pull tokens it minted.
+type Signer struct {
+	key    []byte
+	maxTTL time.Duration
+}
+
+// New reads the secret the pull tokens are signed with, it returns nil if pull tokens are not configured.
+func New(pullTokensConfig *config.PullTokensConfig) (*Signer, error) {
+	if pullTokensConfig == nil {
+		return nil, nil //nolint: nilnil
+	}
+
+	if pullTokensConfig.MaxTTL < 0 {
+		return nil, fmt.Errorf("%w: maxTTL %s is negative", zerr.ErrBadPullTokensConfig, pullTokensConfig.MaxTTL)
+	}
+
+	key, err := os.ReadFile(pullTokensConfig.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", zerr.ErrBadPullTokensConfig, err)
+	}
+
+	key = bytes.TrimSpace(key)
+	if len(key) < minKeySize {
+		return nil, fmt.Errorf("%w: the key in %s is shorter than %d bytes", zerr.ErrBadPullTokensConfig,
+			pullTokensConfig.KeyFile, minKeySize)
+	}
+
+	maxTTL := pullTokensConfig.MaxTTL
+	if maxTTL == 0 {
+		maxTTL = DefaultMaxTTL
+	}
+
+	return &Signer{key: key, maxTTL: maxTTL}, nil
+}
+
+// MaxTTL returns the maximum validity of the tokens.
+func (signer *Signer) MaxTTL() time.Duration {
+	return signer.maxTTL
+}
+
+// Mint returns a token granting pull access to repo, or only to the image with the given digest if it's set,
+// until ttl elapses. A zero ttl mints a token valid for DefaultTTL, or MaxTTL if shorter.
+func (signer *Signer) Mint(repo string, digest godigest.Digest, ttl time.Duration, subject string,
+) (string, Claims, error) {
+	if ttl > signer.maxTTL || ttl < 0 {
+		return "", Claims{}, fmt.Errorf("%w: %s, the maximum is %s", zerr.ErrPullTokenTTL, ttl, signer.maxTTL)
+	}
+
+	if ttl == 0 {
+		ttl = DefaultTTL
+		if ttl > signer.maxTTL {
+			ttl = signer.maxTTL
+		}
+	}
+
+	now := time.Now()
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Repository: repo,
+		Digest:     digest.String(),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signer.key)
+	if err != nil {
+		return "", Claims{}, err
+	}
+
+	return token, claims, nil
+}
+
+// Parse checks the signature and the validity of a pull token and returns its claims.
+func (signer *Signer) Parse(token string) (Claims, error) {
+	var claims Claims
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		// the tokens of the bearer auth server are signed with asymmetric keys
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("%w: unexpected signing method %s", zerr.ErrBadPullToken, token.Method.Alg())
+		}
+
+		return signer.key, nil
+	})
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %w", zerr.ErrBadPullToken, err)
+	}
+
+	if claims.Issuer != Issuer || claims.Repository == "" || claims.ExpiresAt == nil {
+		return Claims{}, zerr.ErrBadPullToken
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,125 @@
+package pulltoken_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/pulltoken"
+)
+
+func TestPullTokens(t *testing.T) {
+	Convey("Mint and parse pull tokens", t, func() {
+		keyFile := path.Join(t.TempDir(), "key")
+		err := os.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef\n"), 0o600)
+		So(err, ShouldBeNil)
+
+		signer, err := pulltoken.New(&config.PullTokensConfig{KeyFile: keyFile})
+		So(err, ShouldBeNil)
+		So(signer.MaxTTL(), ShouldEqual, pulltoken.DefaultMaxTTL)
+
+		digest := godigest.FromString("image")
+
+		token, claims, err := signer.Mint("infra/db", digest, 0, "alice")
+		So(err, ShouldBeNil)
+		So(claims.ExpiresAt.Sub(claims.IssuedAt.Time), ShouldEqual, pulltoken.DefaultTTL)
+
+		parsed, err := signer.Parse(token)
+		So(err, ShouldBeNil)
+		So(parsed.Repository, ShouldEqual, "infra/db")
+		So(parsed.Digest, ShouldEqual, digest.String())
+		So(parsed.Subject, ShouldEqual, "alice")
+
+		// repo scoped tokens
+		token, _, err = signer.Mint("infra/db", "", 2*time.Hour, "")
+		So(err, ShouldBeNil)
+
+		parsed, err = signer.Parse(token)
+		So(err, ShouldBeNil)
+		So(parsed.Digest, ShouldBeEmpty)
+
+		_, _, err = signer.Mint("infra/db", "", 25*time.Hour, "")
+		So(err, ShouldWrap, zerr.ErrPullTokenTTL)
+
+		Convey("Reject tokens not minted by the signer", func() {
+			_, err := signer.Parse("not a token")
+			So(err, ShouldWrap, zerr.ErrBadPullToken)
+
+			other, err := pulltoken.New(&config.PullTokensConfig{KeyFile: keyFile, MaxTTL: time.Minute})
+			So(err, ShouldBeNil)
+
+			// the default validity is capped
+			token, claims, err := other.Mint("infra/db", "", 0, "")
+			So(err, ShouldBeNil)
+			So(claims.ExpiresAt.Sub(claims.IssuedAt.Time), ShouldEqual, time.Minute)
+
+			_, err = signer.Parse(token)
+			So(err, ShouldBeNil)
+
+			// expired tokens
+			expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, pulltoken.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    pulltoken.Issuer,
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+				},
+				Repository: "infra/db",
+			}).SignedString([]byte("0123456789abcdef0123456789abcdef"))
+			So(err, ShouldBeNil)
+
+			_, err = signer.Parse(expired)
+			So(err, ShouldWrap, zerr.ErrBadPullToken)
+
+			// tokens signed with another key
+			forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, pulltoken.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    pulltoken.Issuer,
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+				},
+				Repository: "infra/db",
+			}).SignedString([]byte("another key, another key, another"))
+			So(err, ShouldBeNil)
+
+			_, err = signer.Parse(forged)
+			So(err, ShouldWrap, zerr.ErrBadPullToken)
+
+			// tokens signed with the key for other purposes
+			other, err = pulltoken.New(&config.PullTokensConfig{KeyFile: keyFile})
+			So(err, ShouldBeNil)
+
+			unscoped, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			}).SignedString([]byte("0123456789abcdef0123456789abcdef"))
+			So(err, ShouldBeNil)
+
+			_, err = other.Parse(unscoped)
+			So(err, ShouldWrap, zerr.ErrBadPullToken)
+		})
+	})
+
+	Convey("Pull tokens config", t, func() {
+		signer, err := pulltoken.New(nil)
+		So(err, ShouldBeNil)
+		So(signer, ShouldBeNil)
+
+		keyFile := path.Join(t.TempDir(), "key")
+
+		_, err = pulltoken.New(&config.PullTokensConfig{KeyFile: keyFile})
+		So(err, ShouldWrap, zerr.ErrBadPullTokensConfig)
+
+		err = os.WriteFile(keyFile, []byte("short"), 0o600)
+		So(err, ShouldBeNil)
+
+		_, err = pulltoken.New(&config.PullTokensConfig{KeyFile: keyFile})
+		So(err, ShouldWrap, zerr.ErrBadPullTokensConfig)
+
+		_, err = pulltoken.New(&config.PullTokensConfig{KeyFile: keyFile, MaxTTL: -time.Hour})
+		So(err, ShouldWrap, zerr.ErrBadPullTokensConfig)
+	})
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	apiErr "zotregistry.io/zot/pkg/api/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// checkQuota enforces the count limits of the identity making the request before a repo is created or a
+// new tag is pushed. If a limit is reached it writes the error response and returns false.
+func (rh *RouteHandler) checkQuota(response http.ResponseWriter, request *http.Request,
+	imgStore storageTypes.ImageStore, name, reference string,
+) bool {
+	var limits config.QuotaLimits
+
+	if tenant, ok := rh.c.Tenants.OfRepo(name); ok && tenant.HasQuota() {
+		// the limits of a tenant replace the quota config, its repos are in the namespace named after it
+		limits = config.QuotaLimits{MaxTags: tenant.MaxTags, MaxRepos: tenant.MaxRepos}
+	} else {
+		quota := rh.c.Config.HTTP.Quota
+		if quota == nil {
+			return true
+		}
+
+		acCtx, err := localCtx.GetAccessControlContext(request.Context())
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+
+			return false
+		}
+
+		var groups []string
+		if acCtx != nil {
+			groups = acCtx.Groups
+		}
+
+		limits = quota.GetLimits(localCtx.GetUsernameFromContext(acCtx), groups)
+	}
+
+	tags, err := imgStore.GetImageTags(name)
+	if err != nil {
+		if !errors.Is(err, zerr.ErrRepoNotFound) {
+			// let the storage operation report the error
+			return true
+		}
+
+		return rh.checkRepoQuota(response, name, limits.MaxRepos)
+	}
+
+	// only new tags count, digests and already existing tags can be pushed
+	if limits.MaxTags == 0 || reference == "" || zcommon.Contains(tags, reference) {
+		return true
+	}
+
+	if _, err := godigest.Parse(reference); err == nil {
+		return true
+	}
+
+	if len(tags) >= limits.MaxTags {
+		rh.c.Log.Info().Err(zerr.ErrTagLimitReached).Str("repository", name).Str("reference", reference).
+			Int("maxTags", limits.MaxTags).Msg("push denied")
+
+		writeDeniedError(response, zerr.ErrTagLimitReached, map[string]string{
+			"name":    name,
+			"maxTags": strconv.Itoa(limits.MaxTags),
+		})
+
+		return false
+	}
+
+	return true
+}
+
+func (rh *RouteHandler) checkRepoQuota(response http.ResponseWriter, name string, maxRepos int) bool {
+	if maxRepos == 0 {
+		return true
+	}
+
+	namespace := zcommon.GetRepoNamespace(name)
+
+	count, err := rh.countNamespaceRepos(namespace)
+	if err != nil {
+		rh.c.Log.Error().Err(err).Str("namespace", namespace).Msg("unable to count namespace repositories")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return false
+	}
+
+	if count >= maxRepos {
+		rh.c.Log.Info().Err(zerr.ErrRepoLimitReached).Str("repository", name).Str("namespace", namespace).
+			Int("maxRepos", maxRepos).Msg("push denied")
+
+		writeDeniedError(response, zerr.ErrRepoLimitReached, map[string]string{
+			"name":      name,
+			"namespace": namespace,
+			"maxRepos":  strconv.Itoa(maxRepos),
+		})
+
+		return false
+	}
+
+	return true
+}
+
+// countNamespaceRepos counts the repositories of a namespace across all the stores.
+func (rh *RouteHandler) countNamespaceRepos(namespace string) (int, error) {
+	count := 0
+
+	for _, imgStore := range rh.c.StoreController.UniqueImageStores() {
+		repos, err := imgStore.GetRepositories()
+		if err != nil {
+			return 0, err
+		}
+
+		for _, repo := range repos {
+			if zcommon.GetRepoNamespace(repo) == namespace {
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// checkStorageQuota enforces the byte limits of the storage quota config before a blob upload is started, body is
+// nil, or before a manifest is pushed. If a limit is exceeded it writes the error response and returns false.
+func (rh *RouteHandler) checkStorageQuota(response http.ResponseWriter, name string, body []byte) bool {
+	if rh.c.StorageQuotas == nil {
+		return true
+	}
+
+	var err error
+
+	if body == nil {
+		err = rh.c.StorageQuotas.CheckUpload(name)
+	} else {
+		err = rh.c.StorageQuotas.CheckManifest(name, body)
+	}
+
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, zerr.ErrRepoStorageQuotaExceeded) || errors.Is(err, zerr.ErrStorageQuotaExceeded) {
+		rh.c.Log.Info().Err(err).Str("repository", name).Msg("push denied")
+
+		zcommon.WriteJSON(response, http.StatusRequestEntityTooLarge,
+			apiErr.NewErrorList(apiErr.NewError(apiErr.DENIED, map[string]string{"name": name}).
+				WithMessage(err.Error())))
+
+		return false
+	}
+
+	rh.c.Log.Error().Err(err).Str("repository", name).Msg("unable to check storage quota")
+	response.WriteHeader(http.StatusInternalServerError)
+
+	return false
+}
+
+// invalidateStorageUsage makes the storage quotas count the usage of a repo again after it changed.
+func (rh *RouteHandler) invalidateStorageUsage(name string) {
+	if rh.c.StorageQuotas != nil {
+		rh.c.StorageQuotas.Invalidate(name)
+	}
+}
+
+// ListStorageUsage godoc
+// @Summary List the storage usage of the repos
+// @Description Returns the bytes used by each repo and by the registry, with their storage quota limits.
+// @Description Only admins can list them.
+// @Router 	/v2/_zot/ext/quota [get]
+// @Produce json
+// @Param   repo     query   string     false       "return the quota.Usage of this repo instead"
+// @Success 200 {object} 	quota.UsageList
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 500 {string} 	string 				"internal server error".
+func (rh *RouteHandler) ListStorageUsage(response http.ResponseWriter, request *http.Request) {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if rh.c.Config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	if repo := request.URL.Query().Get("repo"); repo != "" {
+		usage, err := rh.c.StorageQuotas.RepoUsage(repo)
+		if err != nil {
+			rh.c.Log.Error().Err(err).Str("repository", repo).Msg("unable to get storage usage")
+			response.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		zcommon.WriteJSON(response, http.StatusOK, usage)
+
+		return
+	}
+
+	list, err := rh.c.StorageQuotas.List()
+	if err != nil {
+		rh.c.Log.Error().Err(err).Msg("unable to list storage usage")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, list)
+}
+
+func writeDeniedError(response http.ResponseWriter, err error, detail map[string]string) {
+	zcommon.WriteJSON(response, http.StatusForbidden,
+		apiErr.NewErrorList(apiErr.NewError(apiErr.DENIED, detail).WithMessage(err.Error())))
+}
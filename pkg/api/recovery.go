@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+// RecoveryHandler turns a panicking handler into a 500 response, so a single bad request can't crash zot.
+// The panic is logged with its stack trace and the request ID, returned to the client in the X-Request-Id
+// header, and counted by the route it happened on.
+func RecoveryHandler(ctlr *Controller) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			recoveryWr := &recoveryWriter{ResponseWriter: response}
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				// the handler aborted the response on purpose, net/http closes the connection silently
+				if recovered == http.ErrAbortHandler { //nolint:errorlint,goerr113 // sentinel panic value
+					panic(recovered)
+				}
+
+				route := "unknown"
+				if currentRoute := mux.CurrentRoute(request); currentRoute != nil {
+					if template, err := currentRoute.GetPathTemplate(); err == nil {
+						route = template
+					}
+				}
+
+				requestID := localCtx.GetRequestID(request.Context())
+
+				ctlr.Log.Error().Str("panic", fmt.Sprint(recovered)).Str("requestID", requestID).
+					Str("method", request.Method).Str("path", request.URL.Path).Str("route", route).
+					Str("stack", string(debug.Stack())).Msg("recovered from a panic while handling a request")
+
+				monitoring.IncHTTPPanics(ctlr.Metrics, request.Method, route)
+
+				if recoveryWr.wroteHeader {
+					// part of the response was sent already, the connection is aborted so that the client
+					// doesn't take it for a complete one
+					panic(http.ErrAbortHandler)
+				}
+
+				// drop the headers the handler set for the response it didn't send
+				for key := range response.Header() {
+					response.Header().Del(key)
+				}
+
+				if requestID != "" {
+					response.Header().Set(constants.RequestIDHeader, requestID)
+				}
+
+				response.WriteHeader(http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(recoveryWr, request)
+		})
+	}
+}
+
+// recoveryWriter tracks if the response was started, the status can't be changed after that.
+type recoveryWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *recoveryWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recoveryWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+
+	return w.ResponseWriter.Write(b)
+}
+
+// ReadFrom lets the underlying writer send files with sendfile.
+func (w *recoveryWriter) ReadFrom(reader io.Reader) (int64, error) {
+	w.wroteHeader = true
+
+	return io.Copy(w.ResponseWriter, reader)
+}
+
+// Unwrap lets handlers flush streamed responses through the writer.
+func (w *recoveryWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"errors"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/breaker"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/meta"
+	"zotregistry.io/zot/pkg/meta/events"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// InitMetaEvents opens the queue repodb is updated from, if repodb updates are asynchronous, events queued
+// before a restart are applied once the queue is started.
+func (c *Controller) InitMetaEvents() error {
+	if c.RepoDB == nil || !c.Config.Storage.AsyncRepoDBUpdates {
+		return nil
+	}
+
+	queue, err := events.New(c.Config.Storage.RootDirectory, func(event events.Event) error {
+		err := c.applyMetaEvent(event)
+
+		monitoring.RecordRepoDBUpdate(c.Metrics, err)
+
+		return err
+	}, c.Log)
+	if err != nil {
+		return err
+	}
+
+	c.MetaEvents = queue
+	c.MetaEvents.Start()
+
+	return nil
+}
+
+// applyMetaEvent updates repodb with a queued event, a failing event is retried by the queue so the image
+// store isn't rolled back like it is when repodb is updated synchronously.
+func (c *Controller) applyMetaEvent(event events.Event) error {
+	switch event.Type {
+	case events.EventManifestPushed:
+		imgStore := c.StoreController.GetImageStore(event.Repo)
+
+		// the reference was deleted or overwritten since, the event of that change updates repodb
+		_, digest, _, err := imgStore.GetImageManifest(event.Repo, event.Reference)
+		if err != nil || digest != event.Digest {
+			c.Log.Debug().Str("repository", event.Repo).Str("reference", event.Reference).
+				Msg("events: manifest changed since it was pushed, skipping event")
+
+			return nil
+		}
+
+		return meta.UpdateManifestMeta(event.Repo, event.Reference, event.MediaType, event.Digest, event.Body,
+			c.StoreController, c.RepoDB, c.Log)
+	case events.EventManifestDeleted:
+		return meta.DeleteManifestMeta(event.Repo, event.Reference, event.MediaType, event.Digest, event.Body,
+			c.StoreController, c.RepoDB, c.Log)
+	case events.EventManifestPulled:
+		return c.countDownload(event.Repo, event.Reference, event.Body)
+	default:
+		c.Log.Error().Str("event", event.Type).Msg("events: unknown event, skipping it")
+
+		return nil
+	}
+}
+
+// updateRepoDB updates repodb after a manifest is pushed, deleted or pulled. If updates are asynchronous
+// the event is queued and applied in the background, otherwise, or if it couldn't be queued, repodb is
+// updated right away and the image store is rolled back if it fails.
+func (rh *RouteHandler) updateRepoDB(eventType, name, reference, mediaType string, digest godigest.Digest,
+	body []byte,
+) error {
+	// docker schema1 manifests aren't described in repodb, there's nothing to update
+	if rh.c.RepoDB == nil || zcommon.IsSchema1Manifest(mediaType) {
+		return nil
+	}
+
+	if rh.c.MetaEvents != nil {
+		err := rh.c.MetaEvents.Enqueue(events.Event{
+			Type:      eventType,
+			Repo:      name,
+			Reference: reference,
+			Digest:    digest,
+			MediaType: mediaType,
+			Body:      body,
+		})
+		if err == nil {
+			return nil
+		}
+
+		rh.c.Log.Warn().Err(err).Str("repository", name).Str("reference", reference).
+			Msg("events: unable to queue repodb update, updating it synchronously")
+	}
+
+	err := rh.c.RepoDBBreaker.Do(func() error {
+		switch eventType {
+		case events.EventManifestPushed:
+			return meta.OnUpdateManifest(name, reference, mediaType, digest, body, rh.c.StoreController,
+				rh.c.RepoDB, rh.c.Log)
+		case events.EventManifestDeleted:
+			return meta.OnDeleteManifest(name, reference, mediaType, digest, body, rh.c.StoreController,
+				rh.c.RepoDB, rh.c.Log)
+		default:
+			return rh.c.countDownload(name, reference, body)
+		}
+	})
+
+	monitoring.RecordRepoDBUpdate(rh.c.Metrics, err)
+
+	if errors.Is(err, zerr.ErrCircuitOpen) {
+		// repodb keeps failing, the request succeeds without updating it and the repo is parsed again once
+		// repodb recovers, pulls just aren't counted
+		rh.c.Log.Warn().Err(err).Str("repository", name).Str("reference", reference).Str("event", eventType).
+			Msg("repodb is unavailable, skipping its update")
+
+		if eventType != events.EventManifestPulled {
+			rh.c.markRepoStale(name)
+		}
+
+		return nil
+	}
+
+	if err == nil {
+		rh.c.parseStaleRepos()
+	}
+
+	return err
+}
+
+// repoDBTagRemovals removes from repodb the tags removed by the retention policies of gc.
+type repoDBTagRemovals struct {
+	ctlr *Controller
+}
+
+// OnTagsRemoved is called by gc while it holds the lock of the repo, so the image store isn't read, if repodb
+// fails the repo is parsed into it again once the store is unlocked.
+func (removals repoDBTagRemovals) OnTagsRemoved(repo string, tags []storageTypes.TagInfo) {
+	ctlr := removals.ctlr
+
+	for _, tag := range tags {
+		err := ctlr.RepoDBBreaker.Do(func() error {
+			return ctlr.RepoDB.DeleteRepoTag(repo, tag.Tag)
+		})
+
+		monitoring.RecordRepoDBUpdate(ctlr.Metrics, err)
+
+		if err != nil {
+			ctlr.Log.Error().Err(err).Str("repository", repo).Str("tag", tag.Tag).
+				Msg("unable to remove tag expired by retention from repodb, parsing the repo again later")
+
+			ctlr.markRepoStale(repo)
+
+			return
+		}
+	}
+}
+
+func (c *Controller) InitRepoDBBreaker() {
+	if c.RepoDB == nil || c.Config.CircuitBreaker == nil {
+		return
+	}
+
+	c.RepoDBBreaker = breaker.New("repodb", *c.Config.CircuitBreaker, c.Metrics, c.Log,
+		zerr.ErrManifestMetaNotFound, zerr.ErrRepoMetaNotFound, zerr.ErrRepoNotFound)
+}
+
+// markRepoStale records a repo whose changes weren't applied to repodb while its circuit was open.
+func (c *Controller) markRepoStale(repo string) {
+	c.staleReposLock.Lock()
+	defer c.staleReposLock.Unlock()
+
+	if c.staleRepos == nil {
+		c.staleRepos = map[string]struct{}{}
+	}
+
+	c.staleRepos[repo] = struct{}{}
+}
+
+// parseStaleRepos parses the stale repos into repodb again in the background, once it's available again.
+func (c *Controller) parseStaleRepos() {
+	c.staleReposLock.Lock()
+	repos := c.staleRepos
+	c.staleRepos = nil
+	c.staleReposLock.Unlock()
+
+	if len(repos) == 0 {
+		return
+	}
+
+	go func() {
+		for repo := range repos {
+			err := c.RepoDBBreaker.Do(func() error {
+				return repodb.ParseRepo(repo, c.RepoDB, c.StoreController, c.Log)
+			})
+
+			switch {
+			case err == nil:
+				c.Log.Info().Str("repository", repo).Msg("repo parsed into repodb after it recovered")
+			case errors.Is(err, zerr.ErrRepoNotFound):
+				// the repo was deleted in the meantime
+			default:
+				c.Log.Error().Err(err).Str("repository", repo).Msg("unable to parse repo into repodb, retrying later")
+				c.markRepoStale(repo)
+			}
+		}
+	}()
+}
+
+// countDownload increments the download counter of a manifest, unless its repo wasn't parsed into repodb yet.
+func (c *Controller) countDownload(name, reference string, body []byte) error {
+	err := meta.OnGetManifest(name, reference, body, c.StoreController, c.RepoDB, c.Log)
+	if errors.Is(err, zerr.ErrManifestMetaNotFound) && c.RepoDBIndexing.InProgress() {
+		c.Log.Debug().Str("repository", name).Str("reference", reference).
+			Msg("repodb is being populated, download not counted")
+
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+// route variables logged with the events of the requests, and the keys they're logged with.
+var requestLogVars = []struct{ name, key string }{ //nolint: gochecknoglobals
+	{"name", "repository"},
+	{"reference", "reference"},
+	{"digest", "digest"},
+	{"session_id", "session"},
+}
+
+// getRequestLogger returns a child of logger adding to its events a "request" dict with the ID of the request,
+// the user making it and the repo, reference, digest and upload session it's for, so that a grep on the request
+// ID shows the events of all the layers serving it, e.g. the storage, along with the HTTP API log.
+// The fields are grouped so that they don't clash with the fields of the events.
+func getRequestLogger(request *http.Request, logger zerolog.Logger) zerolog.Logger {
+	fields := zerolog.Dict()
+
+	if requestID := localCtx.GetRequestID(request.Context()); requestID != "" {
+		fields = fields.Str("id", requestID)
+	}
+
+	// the access control context isn't there without authn nor authz
+	acCtx, _ := localCtx.GetAccessControlContext(request.Context())
+	if username := localCtx.GetUsernameFromContext(acCtx); username != "" {
+		fields = fields.Str("user", username)
+	} else if identity, ok := localCtx.GetIdentity(request.Context()); ok && identity.Username != "" {
+		fields = fields.Str("user", identity.Username)
+	}
+
+	vars := mux.Vars(request)
+
+	for _, logVar := range requestLogVars {
+		if value := vars[logVar.name]; value != "" {
+			fields = fields.Str(logVar.key, value)
+		}
+	}
+
+	return logger.With().Dict("request", fields).Logger()
+}
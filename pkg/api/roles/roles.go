@@ -0,0 +1,236 @@
+package roles
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+// FileName is the file, under the root directory of the default store, where the roles assigned
+// through the API are kept.
+const FileName = "rolebindings.json"
+
+// Binding assigns a role to users and groups on the repos matching a pattern.
+type Binding struct {
+	Pattern string    `json:"pattern"`
+	Role    string    `json:"role"`
+	Users   []string  `json:"users,omitempty"`
+	Groups  []string  `json:"groups,omitempty"`
+	AddedBy string    `json:"addedBy,omitempty"`
+	AddedAt time.Time `json:"addedAt,omitempty"`
+}
+
+type BindingList struct {
+	Bindings []Binding `json:"bindings"`
+}
+
+type bindingKey struct {
+	pattern string
+	role    string
+}
+
+// Bindings holds the roles assigned through the API, in addition to the policies of the access control config,
+// they are persisted so they survive restarts.
+type Bindings struct {
+	filePath string
+	bindings map[bindingKey]Binding
+	lock     *sync.RWMutex
+	log      log.Logger
+}
+
+// New loads the role bindings previously added through the API and saved under rootDir.
+func New(rootDir string, log log.Logger) (*Bindings, error) {
+	bindings := &Bindings{
+		filePath: path.Join(rootDir, FileName),
+		bindings: map[bindingKey]Binding{},
+		lock:     &sync.RWMutex{},
+		log:      log,
+	}
+
+	buf, err := os.ReadFile(bindings.filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return bindings, nil
+		}
+
+		log.Error().Err(err).Str("file", bindings.filePath).Msg("roles: unable to read role bindings")
+
+		return nil, err
+	}
+
+	var bindingList BindingList
+
+	if err := json.Unmarshal(buf, &bindingList); err != nil {
+		log.Error().Err(err).Str("file", bindings.filePath).Msg("roles: invalid JSON")
+
+		return nil, err
+	}
+
+	for _, binding := range bindingList.Bindings {
+		bindings.bindings[bindingKey{binding.Pattern, binding.Role}] = binding
+	}
+
+	return bindings, nil
+}
+
+// List returns all the role bindings sorted by pattern and role.
+func (rb *Bindings) List() []Binding {
+	rb.lock.RLock()
+	defer rb.lock.RUnlock()
+
+	return rb.list()
+}
+
+// Add assigns a role on a repo pattern, or replaces the users and groups it is assigned to, and saves the bindings.
+func (rb *Bindings) Add(binding Binding) error {
+	if _, ok := config.GetRoleActions(binding.Role); !ok {
+		return zerr.ErrUnknownRole
+	}
+
+	if !glob.ValidatePattern(binding.Pattern) {
+		return glob.ErrBadPattern
+	}
+
+	key := bindingKey{binding.Pattern, binding.Role}
+
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+
+	previous, existed := rb.bindings[key]
+
+	rb.bindings[key] = binding
+
+	if err := rb.save(); err != nil {
+		if existed {
+			rb.bindings[key] = previous
+		} else {
+			delete(rb.bindings, key)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Remove unassigns a role on a repo pattern and saves the bindings.
+func (rb *Bindings) Remove(pattern, role string) error {
+	key := bindingKey{pattern, role}
+
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+
+	binding, ok := rb.bindings[key]
+	if !ok {
+		return zerr.ErrRoleBindingNotFound
+	}
+
+	delete(rb.bindings, key)
+
+	if err := rb.save(); err != nil {
+		rb.bindings[key] = binding
+
+		return err
+	}
+
+	return nil
+}
+
+// Apply returns a copy of the access control config with a policy added to the matching repo pattern
+// for each role binding, acConfig is returned as is if there are no bindings.
+func (rb *Bindings) Apply(acConfig *config.AccessControlConfig) *config.AccessControlConfig {
+	if rb == nil || acConfig == nil {
+		return acConfig
+	}
+
+	rb.lock.RLock()
+	defer rb.lock.RUnlock()
+
+	if len(rb.bindings) == 0 {
+		return acConfig
+	}
+
+	applied := *acConfig
+	applied.Repositories = make(config.Repositories, len(acConfig.Repositories)+len(rb.bindings))
+
+	for pattern, policyGroup := range acConfig.Repositories {
+		applied.Repositories[pattern] = policyGroup
+	}
+
+	for _, binding := range rb.list() {
+		policyGroup := applied.Repositories[binding.Pattern]
+
+		// don't append to the slice shared with the config
+		policies := make([]config.Policy, 0, len(policyGroup.Policies)+1)
+		policies = append(policies, policyGroup.Policies...)
+		policyGroup.Policies = append(policies, config.Policy{
+			Users:  binding.Users,
+			Groups: binding.Groups,
+			Roles:  []string{binding.Role},
+		})
+
+		applied.Repositories[binding.Pattern] = policyGroup
+	}
+
+	return &applied
+}
+
+func (rb *Bindings) list() []Binding {
+	bindings := make([]Binding, 0, len(rb.bindings))
+
+	for _, binding := range rb.bindings {
+		bindings = append(bindings, binding)
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		if bindings[i].Pattern == bindings[j].Pattern {
+			return bindings[i].Role < bindings[j].Role
+		}
+
+		return bindings[i].Pattern < bindings[j].Pattern
+	})
+
+	return bindings
+}
+
+func (rb *Bindings) save() error {
+	buf, err := json.MarshalIndent(BindingList{Bindings: rb.list()}, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	// with remote storage drivers the root directory may not exist locally
+	if err := os.MkdirAll(path.Dir(rb.filePath), storageConstants.DefaultDirPerms); err != nil {
+		rb.log.Error().Err(err).Str("file", rb.filePath).Msg("roles: unable to create role bindings dir")
+
+		return err
+	}
+
+	// write to a temporary file first so a crash doesn't leave truncated role bindings behind
+	tmpFile := rb.filePath + ".tmp"
+
+	if err := os.WriteFile(tmpFile, buf, storageConstants.DefaultFilePerms); err != nil {
+		rb.log.Error().Err(err).Str("file", tmpFile).Msg("roles: unable to write role bindings")
+
+		return err
+	}
+
+	if err := os.Rename(tmpFile, rb.filePath); err != nil {
+		rb.log.Error().Err(err).Str("file", rb.filePath).Msg("roles: unable to write role bindings")
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package roles_test
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/roles"
+	"zotregistry.io/zot/pkg/log"
+)
+
+func TestRoleBindings(t *testing.T) {
+	log := log.NewLogger("debug", "")
+
+	Convey("Assign and unassign roles", t, func() {
+		rootDir := t.TempDir()
+
+		bindings, err := roles.New(rootDir, log)
+		So(err, ShouldBeNil)
+		So(bindings.List(), ShouldBeEmpty)
+
+		err = bindings.Add(roles.Binding{Pattern: "team/**", Role: config.PublisherRole, Users: []string{"alice"}})
+		So(err, ShouldBeNil)
+
+		err = bindings.Add(roles.Binding{Pattern: "**", Role: config.ReaderRole, Groups: []string{"dev"}})
+		So(err, ShouldBeNil)
+
+		err = bindings.Add(roles.Binding{Pattern: "**", Role: "owner"})
+		So(errors.Is(err, zerr.ErrUnknownRole), ShouldBeTrue)
+
+		err = bindings.Add(roles.Binding{Pattern: "[", Role: config.ReaderRole})
+		So(errors.Is(err, glob.ErrBadPattern), ShouldBeTrue)
+
+		// assigning the same role on the same pattern replaces the binding
+		err = bindings.Add(roles.Binding{Pattern: "team/**", Role: config.PublisherRole, Users: []string{"bob"}})
+		So(err, ShouldBeNil)
+
+		list := bindings.List()
+		So(len(list), ShouldEqual, 2)
+		So(list[0].Pattern, ShouldEqual, "**")
+		So(list[1].Users, ShouldResemble, []string{"bob"})
+
+		// bindings are saved
+		bindings, err = roles.New(rootDir, log)
+		So(err, ShouldBeNil)
+		So(len(bindings.List()), ShouldEqual, 2)
+
+		So(bindings.Remove("**", config.ReaderRole), ShouldBeNil)
+		So(errors.Is(bindings.Remove("**", config.ReaderRole), zerr.ErrRoleBindingNotFound), ShouldBeTrue)
+		So(len(bindings.List()), ShouldEqual, 1)
+	})
+
+	Convey("Apply role bindings to the access control config", t, func() {
+		bindings, err := roles.New(t.TempDir(), log)
+		So(err, ShouldBeNil)
+
+		acConfig := &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				"**": config.PolicyGroup{
+					Policies: []config.Policy{{Users: []string{"admin"}, Actions: []string{"read"}}},
+				},
+			},
+		}
+
+		So(bindings.Apply(acConfig), ShouldEqual, acConfig)
+		So(bindings.Apply(nil), ShouldBeNil)
+
+		var nilBindings *roles.Bindings
+		So(nilBindings.Apply(acConfig), ShouldEqual, acConfig)
+
+		err = bindings.Add(roles.Binding{Pattern: "**", Role: config.MaintainerRole, Users: []string{"alice"}})
+		So(err, ShouldBeNil)
+
+		err = bindings.Add(roles.Binding{Pattern: "team/**", Role: config.ReaderRole, Groups: []string{"dev"}})
+		So(err, ShouldBeNil)
+
+		applied := bindings.Apply(acConfig)
+		So(len(applied.Repositories), ShouldEqual, 2)
+		So(len(applied.Repositories["**"].Policies), ShouldEqual, 2)
+		So(applied.Repositories["**"].Policies[1].GetActions(), ShouldContain, "delete")
+		So(applied.Repositories["team/**"].Policies[0].Groups, ShouldResemble, []string{"dev"})
+
+		// the config itself is left untouched
+		So(len(acConfig.Repositories), ShouldEqual, 1)
+		So(len(acConfig.Repositories["**"].Policies), ShouldEqual, 1)
+	})
+
+	Convey("Invalid role bindings file", t, func() {
+		rootDir := t.TempDir()
+
+		err := os.WriteFile(path.Join(rootDir, roles.FileName), []byte("invalid JSON"), 0o600)
+		So(err, ShouldBeNil)
+
+		_, err = roles.New(rootDir, log)
+		So(err, ShouldNotBeNil)
+	})
+}
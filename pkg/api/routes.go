@@ -8,6 +8,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,6 +20,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/opencontainers/distribution-spec/specs-go/v1/extensions"
@@ -27,17 +29,20 @@ import (
 	artifactspec "github.com/oras-project/artifacts-spec/specs-go/v1"
 
 	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
 	"zotregistry.io/zot/pkg/api/constants"
 	apiErr "zotregistry.io/zot/pkg/api/errors"
 	zcommon "zotregistry.io/zot/pkg/common"
 	gqlPlayground "zotregistry.io/zot/pkg/debug/gqlplayground"
 	debug "zotregistry.io/zot/pkg/debug/swagger"
 	ext "zotregistry.io/zot/pkg/extensions"
+	"zotregistry.io/zot/pkg/extensions/plugins"
 	syncConstants "zotregistry.io/zot/pkg/extensions/sync/constants"
 	"zotregistry.io/zot/pkg/log"
-	"zotregistry.io/zot/pkg/meta"
+	"zotregistry.io/zot/pkg/meta/events"
 	zreg "zotregistry.io/zot/pkg/regexp"
 	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/storage"
 	storageCommon "zotregistry.io/zot/pkg/storage/common"
 	storageTypes "zotregistry.io/zot/pkg/storage/types"
 	"zotregistry.io/zot/pkg/test/inject"
@@ -45,31 +50,42 @@ import (
 
 type RouteHandler struct {
 	c *Controller
+	// router the routes are set up on and auth settings of the listener it serves
+	router *mux.Router
+	auth   *config.AuthConfig
 }
 
 func NewRouteHandler(c *Controller) *RouteHandler {
-	rh := &RouteHandler{c: c}
+	return NewListenerRouteHandler(c, c.Router, c.Config.HTTP.Auth)
+}
+
+// NewListenerRouteHandler sets up the routes on the router of a listener, authenticating its requests with authConfig.
+func NewListenerRouteHandler(c *Controller, router *mux.Router, authConfig *config.AuthConfig) *RouteHandler {
+	rh := &RouteHandler{c: c, router: router, auth: authConfig}
 	rh.SetupRoutes()
 
 	return rh
 }
 
 func (rh *RouteHandler) SetupRoutes() {
-	prefixedRouter := rh.c.Router.PathPrefix(constants.RoutePrefix).Subrouter()
-	prefixedRouter.Use(AuthHandler(rh.c))
+	prefixedRouter := rh.router.PathPrefix(constants.RoutePrefix).Subrouter()
+	prefixedRouter.Use(ListenerAuthHandler(rh.c, rh.auth))
 
 	prefixedDistSpecRouter := prefixedRouter.NewRoute().Subrouter()
 	// authz is being enabled if AccessControl is specified
 	// if Authn is not present AccessControl will have only default policies
-	if rh.c.Config.HTTP.AccessControl != nil && !isBearerAuthEnabled(rh.c.Config) {
-		if isAuthnEnabled(rh.c.Config) {
+	if rh.c.Config.HTTP.AccessControl != nil && !isBearerAuthEnabled(rh.auth) {
+		if isAuthnEnabled(rh.auth) {
 			rh.c.Log.Info().Msg("access control is being enabled")
 		} else {
 			rh.c.Log.Info().Msg("default policy only access control is being enabled")
 		}
 
-		prefixedRouter.Use(BaseAuthzHandler(rh.c))
-		prefixedDistSpecRouter.Use(DistSpecAuthzHandler(rh.c))
+		prefixedRouter.Use(BaseAuthzHandler(rh.c, rh.auth))
+		prefixedDistSpecRouter.Use(DistSpecAuthzHandler(rh.c, rh.auth))
+
+		prefixedRouter.HandleFunc(constants.ExtAccessExplainPrefix,
+			rh.ExplainAccess).Methods(http.MethodGet)
 	}
 
 	applyCORSHeaders := getCORSHeadersHandler(rh.c.Config.HTTP.AllowOrigin)
@@ -113,12 +129,31 @@ func (rh *RouteHandler) SetupRoutes() {
 			applyCORSHeaders(rh.CheckVersionSupport)).Methods(zcommon.AllowedMethods("GET")...)
 	}
 
+	if rh.c.PullTokens != nil {
+		prefixedRouter.HandleFunc(fmt.Sprintf("%s/{name:%s}", constants.ExtPullTokensPrefix, zreg.NameRegexp.String()),
+			rh.CreatePullToken).Methods(http.MethodPost)
+	}
+
+	if rh.c.StorageQuotas != nil {
+		prefixedRouter.HandleFunc(constants.ExtQuotaPrefix,
+			applyCORSHeaders(rh.ListStorageUsage)).Methods(zcommon.AllowedMethods("GET")...)
+	}
+
+	if rh.c.Plugins.EventLog() != nil {
+		prefixedRouter.HandleFunc(constants.ExtEventsPrefix,
+			applyCORSHeaders(rh.ListEvents)).Methods(zcommon.AllowedMethods("GET")...)
+		prefixedRouter.HandleFunc(fmt.Sprintf("%s/{name:%s}", constants.ExtEventsPrefix, zreg.NameRegexp.String()),
+			applyCORSHeaders(rh.ListPluginEvents)).Methods(zcommon.AllowedMethods("GET")...)
+		prefixedRouter.HandleFunc(fmt.Sprintf("%s/{name:%s}", constants.ExtActivityPrefix, zreg.NameRegexp.String()),
+			applyCORSHeaders(rh.ListRepoActivity)).Methods(zcommon.AllowedMethods("GET")...)
+	}
+
 	// support for ORAS artifact reference types (alpha 1) - image signature use case
-	rh.c.Router.HandleFunc(fmt.Sprintf("%s/{name:%s}/manifests/{digest}/referrers",
+	rh.router.HandleFunc(fmt.Sprintf("%s/{name:%s}/manifests/{digest}/referrers",
 		constants.ArtifactSpecRoutePrefix, zreg.NameRegexp.String()), rh.GetOrasReferrers).Methods("GET")
 
 	// swagger
-	debug.SetupSwaggerRoutes(rh.c.Config, rh.c.Router, AuthHandler(rh.c), rh.c.Log)
+	debug.SetupSwaggerRoutes(rh.c.Config, rh.router, ListenerAuthHandler(rh.c, rh.auth), rh.c.Log)
 
 	// Setup Extensions Routes
 	if rh.c.Config != nil {
@@ -130,18 +165,34 @@ func (rh *RouteHandler) SetupRoutes() {
 			prefixedExtensionsRouter := prefixedRouter.PathPrefix(constants.ExtPrefix).Subrouter()
 			prefixedExtensionsRouter.Use(CORSHeadersMiddleware(rh.c.Config.HTTP.AllowOrigin))
 
-			ext.SetupMgmtRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.Log)
-			ext.SetupSearchRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.StoreController, rh.c.RepoDB, rh.c.CveInfo,
-				rh.c.Log)
+			ext.SetupMgmtRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.StoreController, rh.c.RepoDB,
+				rh.c.Blocklist, rh.c.Leases, rh.c.RoleBindings, rh.c.Tenants, rh.c.Log)
+			ext.SetupSearchRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.StoreController, rh.c.RepoDB,
+				rh.c.RepoDBIndexing, rh.c.CveInfo, rh.c.Log)
 			ext.SetupUserPreferencesRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.StoreController, rh.c.RepoDB,
 				rh.c.CveInfo, rh.c.Log)
+			ext.SetupUserActivityRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.RepoDB, rh.c.Log)
+			ext.SetupPinRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.RepoDB, rh.c.Log)
+			ext.SetupPromotionRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.StoreController, rh.c.RepoDB,
+				rh.c.CveInfo, rh.c.PromotionGates, rh.c.Log)
+			ext.SetupAnnotationsRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.RepoDB, rh.c.Log)
+			ext.SetupStatsRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.RepoDB, rh.c.Log)
+			ext.SetupCVEAcknowledgementsRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.RepoDB, rh.c.Log)
+			ext.SetupCVEReportRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.CVEReporter, rh.c.Log)
+			ext.SetupAuditSnapshotRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.AuditSnapshots, rh.c.Log)
+			ext.SetupCVEExportRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.StoreController, rh.c.RepoDB,
+				rh.c.CveInfo, rh.c.Log)
+			ext.SetupPeeringRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.StoreController, rh.c.SyncConflicts,
+				rh.c.Log)
+			ext.SetupTelemetryRoutes(rh.c.Config, prefixedExtensionsRouter, rh.c.StoreController, rh.c.Log)
 
-			ext.SetupMetricsRoutes(rh.c.Config, rh.c.Router, rh.c.StoreController, AuthHandler(rh.c), rh.c.Log)
+			ext.SetupMetricsRoutes(rh.c.Config, rh.router, rh.c.StoreController, ListenerAuthHandler(rh.c, rh.auth),
+				rh.c.Log)
 
 			gqlPlayground.SetupGQLPlaygroundRoutes(rh.c.Config, prefixedRouter, rh.c.StoreController, rh.c.Log)
 
 			// last should always be UI because it will setup a http.FileServer and paths will be resolved by this FileServer.
-			ext.SetupUIRoutes(rh.c.Config, rh.c.Router, rh.c.StoreController, rh.c.Log)
+			ext.SetupUIRoutes(rh.c.Config, rh.router, rh.c.StoreController, rh.c.Log)
 		}
 	}
 }
@@ -194,9 +245,9 @@ func (rh *RouteHandler) CheckVersionSupport(response http.ResponseWriter, reques
 	response.Header().Set(constants.DistAPIVersion, "registry/2.0")
 	// NOTE: compatibility workaround - return this header in "allowed-read" mode to allow for clients to
 	// work correctly
-	if rh.c.Config.HTTP.Auth != nil {
-		if rh.c.Config.HTTP.Auth.Bearer != nil {
-			response.Header().Set("WWW-Authenticate", fmt.Sprintf("bearer realm=%s", rh.c.Config.HTTP.Auth.Bearer.Realm))
+	if rh.auth != nil {
+		if rh.auth.Bearer != nil {
+			response.Header().Set("WWW-Authenticate", fmt.Sprintf("bearer realm=%s", rh.auth.Bearer.Realm))
 		} else {
 			response.Header().Set("WWW-Authenticate", fmt.Sprintf("basic realm=%s", rh.c.Config.HTTP.Realm))
 		}
@@ -240,7 +291,7 @@ func (rh *RouteHandler) ListTags(response http.ResponseWriter, request *http.Req
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	paginate := false
 	numTags := -1
@@ -292,7 +343,7 @@ func (rh *RouteHandler) ListTags(response http.ResponseWriter, request *http.Req
 	if paginate && (numTags < len(tags)) {
 		sort.Strings(tags)
 
-		pTags := ImageTags{Name: name}
+		pTags := ImageTags{Name: getTenantRepoName(request, name)}
 
 		if last == "" {
 			// first
@@ -332,13 +383,14 @@ func (rh *RouteHandler) ListTags(response http.ResponseWriter, request *http.Req
 			last = pTags.Tags[len(pTags.Tags)-1]
 		}
 
-		response.Header().Set("Link", fmt.Sprintf("/v2/%s/tags/list?n=%d&last=%s; rel=\"next\"", name, numTags, last))
+		response.Header().Set("Link", rh.externalLocation(request,
+			fmt.Sprintf("/v2/%s/tags/list?n=%d&last=%s; rel=\"next\"", name, numTags, last)))
 		zcommon.WriteJSON(response, http.StatusOK, pTags)
 
 		return
 	}
 
-	zcommon.WriteJSON(response, http.StatusOK, ImageTags{Name: name, Tags: tags})
+	zcommon.WriteJSON(response, http.StatusOK, ImageTags{Name: getTenantRepoName(request, name), Tags: tags})
 }
 
 // CheckManifest godoc
@@ -370,7 +422,7 @@ func (rh *RouteHandler) CheckManifest(response http.ResponseWriter, request *htt
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	reference, ok := vars["reference"]
 	if !ok || reference == "" {
@@ -381,7 +433,7 @@ func (rh *RouteHandler) CheckManifest(response http.ResponseWriter, request *htt
 		return
 	}
 
-	content, digest, mediaType, err := getImageManifest(rh, imgStore, name, reference) //nolint:contextcheck
+	content, digest, mediaType, err := getImageManifest(getSyncContext(request), rh, imgStore, name, reference)
 	if err != nil {
 		if errors.Is(err, zerr.ErrRepoNotFound) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
 			zcommon.WriteJSON(response, http.StatusNotFound,
@@ -398,6 +450,10 @@ func (rh *RouteHandler) CheckManifest(response http.ResponseWriter, request *htt
 		return
 	}
 
+	if !rh.checkManifestBlocklist(response, request, name, digest, content) {
+		return
+	}
+
 	response.Header().Set(constants.DistContentDigestKey, digest.String())
 	response.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
 	response.Header().Set("Content-Type", mediaType)
@@ -442,7 +498,7 @@ func (rh *RouteHandler) GetManifest(response http.ResponseWriter, request *http.
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	reference, ok := vars["reference"]
 	if !ok || reference == "" {
@@ -453,7 +509,7 @@ func (rh *RouteHandler) GetManifest(response http.ResponseWriter, request *http.
 		return
 	}
 
-	content, digest, mediaType, err := getImageManifest(rh, imgStore, name, reference) //nolint: contextcheck
+	content, digest, mediaType, err := getImageManifest(getSyncContext(request), rh, imgStore, name, reference)
 	if err != nil {
 		if errors.Is(err, zerr.ErrRepoNotFound) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
 			zcommon.WriteJSON(response, http.StatusNotFound,
@@ -472,15 +528,22 @@ func (rh *RouteHandler) GetManifest(response http.ResponseWriter, request *http.
 		return
 	}
 
-	if rh.c.RepoDB != nil {
-		err := meta.OnGetManifest(name, reference, content, rh.c.StoreController, rh.c.RepoDB, rh.c.Log)
-		if err != nil {
+	if !rh.checkManifestBlocklist(response, request, name, digest, content) {
+		return
+	}
+
+	if rh.isDownloadCounted(request, name, digest) {
+		if err := rh.updateRepoDB(events.EventManifestPulled, name, reference, mediaType, digest, content); err != nil {
 			response.WriteHeader(http.StatusInternalServerError)
 
 			return
 		}
 	}
 
+	ext.RecordUserActivity(rh.c.Config, rh.c.RepoDB, request, ext.UserActivityPull, name, reference, rh.c.Log)
+
+	rh.setFreshnessHeaders(response, name, digest, mediaType, content)
+
 	response.Header().Set(constants.DistContentDigestKey, digest.String())
 	response.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
 	response.Header().Set("Content-Type", mediaType)
@@ -491,17 +554,18 @@ type ImageIndex struct {
 	ispec.Index
 }
 
-func getReferrers(routeHandler *RouteHandler,
+func getReferrers(ctx context.Context, routeHandler *RouteHandler,
 	imgStore storageTypes.ImageStore, name string, digest godigest.Digest,
 	artifactTypes []string,
 ) (ispec.Index, error) {
 	refs, err := imgStore.GetReferrers(name, digest, artifactTypes)
 	if err != nil || len(refs.Manifests) == 0 {
-		if isSyncOnDemandEnabled(*routeHandler.c) {
+		if isSyncOnDemandEnabled(routeHandler.c) {
 			routeHandler.c.Log.Info().Str("repository", name).Str("reference", digest.String()).
 				Msg("referrers not found, trying to get reference by syncing on demand")
 
-			if errSync := routeHandler.c.SyncOnDemand.SyncReference(name, digest.String(), syncConstants.OCI); errSync != nil {
+			if errSync := routeHandler.c.SyncOnDemand.SyncReference(ctx, name, digest.String(),
+				syncConstants.OCI); errSync != nil {
 				routeHandler.c.Log.Err(errSync).Str("repository", name).Str("reference", digest.String()).
 					Msg("error encounter while syncing OCI reference for image")
 			}
@@ -556,9 +620,9 @@ func (rh *RouteHandler) GetReferrers(response http.ResponseWriter, request *http
 
 	rh.c.Log.Info().Str("digest", digest.String()).Interface("artifactType", artifactTypes).Msg("getting manifest")
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
-	referrers, err := getReferrers(rh, imgStore, name, digest, artifactTypes)
+	referrers, err := getReferrers(getSyncContext(request), rh, imgStore, name, digest, artifactTypes)
 	if err != nil {
 		if errors.Is(err, zerr.ErrManifestNotFound) || errors.Is(err, zerr.ErrRepoNotFound) {
 			rh.c.Log.Error().Err(err).Str("name", name).Str("digest", digest.String()).Msg("manifest not found")
@@ -609,7 +673,7 @@ func (rh *RouteHandler) UpdateManifest(response http.ResponseWriter, request *ht
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	reference, ok := vars["reference"]
 	if !ok || reference == "" {
@@ -629,7 +693,39 @@ func (rh *RouteHandler) UpdateManifest(response http.ResponseWriter, request *ht
 		return
 	}
 
-	body, err := io.ReadAll(request.Body)
+	if zcommon.IsSchema1Manifest(mediaType) && rh.c.Config.Storage.RejectSchema1 {
+		rh.c.Log.Info().Str("repository", name).Str("reference", reference).Str("mediaType", mediaType).
+			Msg("rejecting docker schema1 manifest")
+
+		zcommon.WriteJSON(response, http.StatusBadRequest,
+			apiErr.NewErrorList(apiErr.NewError(apiErr.MANIFEST_INVALID, map[string]string{"mediaType": mediaType}).
+				WithMessage(zerr.ErrSchema1ManifestRejected.Error())))
+
+		return
+	}
+
+	body, err := readManifestBody(request)
+	if errors.Is(err, zerr.ErrUnsupportedContentEncoding) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
+		zcommon.WriteJSON(response, http.StatusUnsupportedMediaType,
+			apiErr.NewErrorList(apiErr.NewError(apiErr.UNSUPPORTED, map[string]string{
+				"contentEncoding": request.Header.Get("Content-Encoding"),
+			}).WithMessage(err.Error())))
+
+		return
+	} else if errors.Is(err, zerr.ErrManifestTooLarge) {
+		zcommon.WriteJSON(response, http.StatusRequestEntityTooLarge,
+			apiErr.NewErrorList(apiErr.NewError(apiErr.SIZE_INVALID, map[string]string{"reference": reference}).
+				WithMessage(err.Error())))
+
+		return
+	} else if errors.Is(err, zerr.ErrBadManifest) {
+		zcommon.WriteJSON(response, http.StatusBadRequest,
+			apiErr.NewErrorList(apiErr.NewError(apiErr.MANIFEST_INVALID, map[string]string{"reference": reference}).
+				WithMessage(err.Error())))
+
+		return
+	}
+
 	// hard to reach test case, injected error (simulates an interrupted image manifest upload)
 	// err could be io.ErrUnexpectedEOF
 	if err := inject.Error(err); err != nil {
@@ -639,6 +735,51 @@ func (rh *RouteHandler) UpdateManifest(response http.ResponseWriter, request *ht
 		return
 	}
 
+	if !rh.checkManifestBlocklist(response, request, name, godigest.FromBytes(body), body) {
+		return
+	}
+
+	if rh.c.Config.Storage.ConvertDockerToOCI {
+		var ok bool
+
+		if reference, mediaType, body, ok = rh.convertDockerManifest(response, imgStore, name, reference, mediaType,
+			body); !ok {
+			return
+		}
+	}
+
+	if !rh.checkImmutableTag(response, imgStore, name, reference, body) {
+		return
+	}
+
+	if !rh.checkPromotionGates(response, request, name, reference) {
+		return
+	}
+
+	aliases, ok := rh.checkTagAliases(response, request, imgStore, name, reference, body)
+	if !ok {
+		return
+	}
+
+	if !rh.checkQuota(response, request, imgStore, name, reference) {
+		return
+	}
+
+	if !rh.checkStorageQuota(response, name, body) {
+		return
+	}
+
+	if !rh.checkManifestBlobPolicy(response, name, reference, body) {
+		return
+	}
+
+	if !rh.checkPreReceiveHooks(response, request, name, reference, mediaType, body) {
+		return
+	}
+
+	// the events of concurrent pushes are sequenced in the order the manifests are written
+	defer rh.c.Plugins.LockRepo(name)()
+
 	digest, subjectDigest, err := imgStore.PutImageManifest(name, reference, mediaType, body)
 	if err != nil {
 		if errors.Is(err, zerr.ErrRepoNotFound) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
@@ -660,6 +801,11 @@ func (rh *RouteHandler) UpdateManifest(response http.ResponseWriter, request *ht
 			zcommon.WriteJSON(response, http.StatusBadRequest,
 				apiErr.NewErrorList(apiErr.NewError(
 					apiErr.MANIFEST_INVALID, map[string]string{"reference": reference}).WithMessage(err.Error())))
+		} else if errors.Is(err, zerr.ErrTagImmutable) {
+			zcommon.WriteJSON(response, http.StatusConflict,
+				apiErr.NewErrorList(apiErr.NewError(apiErr.TAG_IMMUTABLE, map[string]string{
+					"name": name, "tag": reference,
+				}).WithMessage(err.Error())))
 		} else {
 			// could be syscall.EMFILE (Err:0x18 too many opened files), etc
 			rh.c.Log.Error().Err(err).Msg("unexpected error: performing cleanup")
@@ -678,21 +824,30 @@ func (rh *RouteHandler) UpdateManifest(response http.ResponseWriter, request *ht
 		return
 	}
 
-	if rh.c.RepoDB != nil {
-		err := meta.OnUpdateManifest(name, reference, mediaType, digest, body, rh.c.StoreController, rh.c.RepoDB,
-			rh.c.Log)
-		if err != nil {
-			response.WriteHeader(http.StatusInternalServerError)
+	rh.invalidateStorageUsage(name)
 
-			return
-		}
+	if err := rh.updateRepoDB(events.EventManifestPushed, name, reference, mediaType, digest, body); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
 	}
 
+	ext.RecordUserActivity(rh.c.Config, rh.c.RepoDB, request, ext.UserActivityPush, name, reference, rh.c.Log)
+	rh.notifyPlugins(request, plugins.EventManifestPushed, name, reference, digest, mediaType)
+	rh.notifySignature(request, name, reference, digest, mediaType, body)
+
+	if !rh.applyTagAliases(response, request, imgStore, name, reference, aliases, digest, mediaType, body) {
+		return
+	}
+
+	rh.setLintWarnings(response, imgStore, name, reference, mediaType, digest, body)
+
 	if subjectDigest.String() != "" {
 		response.Header().Set(constants.SubjectDigestKey, subjectDigest.String())
 	}
 
-	response.Header().Set("Location", fmt.Sprintf("/v2/%s/manifests/%s", name, digest))
+	response.Header().Set("Location",
+		rh.externalLocation(request, fmt.Sprintf("/v2/%s/manifests/%s", name, digest)))
 	response.Header().Set(constants.DistContentDigestKey, digest.String())
 	response.WriteHeader(http.StatusCreated)
 }
@@ -716,7 +871,7 @@ func (rh *RouteHandler) DeleteManifest(response http.ResponseWriter, request *ht
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	reference, ok := vars["reference"]
 	if !ok || reference == "" {
@@ -738,6 +893,8 @@ func (rh *RouteHandler) DeleteManifest(response http.ResponseWriter, request *ht
 		detectCollision = acCtx.CanDetectManifestCollision(name)
 	}
 
+	defer rh.c.Plugins.LockRepo(name)()
+
 	manifestBlob, manifestDigest, mediaType, err := imgStore.GetImageManifest(name, reference)
 	if err != nil {
 		if errors.Is(err, zerr.ErrRepoNotFound) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
@@ -768,6 +925,11 @@ func (rh *RouteHandler) DeleteManifest(response http.ResponseWriter, request *ht
 		} else if errors.Is(err, zerr.ErrManifestConflict) {
 			zcommon.WriteJSON(response, http.StatusConflict,
 				apiErr.NewErrorList(apiErr.NewError(apiErr.MANIFEST_INVALID, map[string]string{"reference": reference})))
+		} else if errors.Is(err, zerr.ErrTagImmutable) {
+			zcommon.WriteJSON(response, http.StatusConflict,
+				apiErr.NewErrorList(apiErr.NewError(apiErr.TAG_IMMUTABLE, map[string]string{
+					"name": name, "reference": reference,
+				}).WithMessage(err.Error())))
 		} else if errors.Is(err, zerr.ErrBadManifest) {
 			zcommon.WriteJSON(response, http.StatusBadRequest,
 				apiErr.NewErrorList(apiErr.NewError(apiErr.UNSUPPORTED, map[string]string{"reference": reference})))
@@ -779,16 +941,25 @@ func (rh *RouteHandler) DeleteManifest(response http.ResponseWriter, request *ht
 		return
 	}
 
-	if rh.c.RepoDB != nil {
-		err := meta.OnDeleteManifest(name, reference, mediaType, manifestDigest, manifestBlob,
-			rh.c.StoreController, rh.c.RepoDB, rh.c.Log)
-		if err != nil {
-			response.WriteHeader(http.StatusInternalServerError)
+	rh.invalidateStorageUsage(name)
 
-			return
+	err = rh.updateRepoDB(events.EventManifestDeleted, name, reference, mediaType, manifestDigest, manifestBlob)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	// the manifest is still there if only one of its tags was deleted
+	if rh.c.CveInfo != nil {
+		if _, _, _, err := imgStore.GetImageManifest(name, manifestDigest.String()); err != nil {
+			ext.CancelImageScans(rh.c.CveInfo, name, manifestDigest.String())
 		}
 	}
 
+	ext.RecordUserActivity(rh.c.Config, rh.c.RepoDB, request, ext.UserActivityDelete, name, reference, rh.c.Log)
+	rh.notifyPlugins(request, plugins.EventManifestDeleted, name, reference, manifestDigest, mediaType)
+
 	response.WriteHeader(http.StatusAccepted)
 }
 
@@ -812,7 +983,7 @@ func (rh *RouteHandler) CheckBlob(response http.ResponseWriter, request *http.Re
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	digestStr, ok := vars["digest"]
 
@@ -824,6 +995,10 @@ func (rh *RouteHandler) CheckBlob(response http.ResponseWriter, request *http.Re
 
 	digest := godigest.Digest(digestStr)
 
+	if !rh.checkBlocklist(response, request, name, digest) {
+		return
+	}
+
 	ok, blen, err := imgStore.CheckBlob(name, digest)
 	if err != nil {
 		if errors.Is(err, zerr.ErrBadBlobDigest) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
@@ -909,6 +1084,7 @@ func parseRangeHeader(contentRange string) (int64, int64, error) {
 // @Description Get an image's blob/layer given a digest
 // @Accept  json
 // @Produce application/vnd.oci.image.layer.v1.tar+gzip
+// @Produce application/vnd.oci.image.layer.v1.tar+zstd
 // @Param   name				path    string     true        "repository name"
 // @Param   digest     	path    string     true        "blob/layer digest"
 // @Header  200 {object} constants.DistContentDigestKey
@@ -924,7 +1100,7 @@ func (rh *RouteHandler) GetBlob(response http.ResponseWriter, request *http.Requ
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	digestStr, ok := vars["digest"]
 
@@ -936,6 +1112,10 @@ func (rh *RouteHandler) GetBlob(response http.ResponseWriter, request *http.Requ
 
 	digest := godigest.Digest(digestStr)
 
+	if !rh.checkBlocklist(response, request, name, digest) {
+		return
+	}
+
 	mediaType := request.Header.Get("Accept")
 
 	/* content range is supported for resumbale pulls */
@@ -962,7 +1142,8 @@ func (rh *RouteHandler) GetBlob(response http.ResponseWriter, request *http.Requ
 			return
 		}
 
-		partial = true
+		// the whole blob is sent if the client has part of a different one
+		partial = ifRangeMatches(request, digest)
 	}
 
 	var repo io.ReadCloser
@@ -997,6 +1178,25 @@ func (rh *RouteHandler) GetBlob(response http.ResponseWriter, request *http.Requ
 	}
 	defer repo.Close()
 
+	response.Header().Set("ETag", blobETag(digest))
+
+	if !partial {
+		response.Header().Set(constants.DistContentDigestKey, digest.String())
+
+		// blobs of the local storage are files, they are sent with sendfile instead of being copied through
+		// userspace
+		if blob, ok := repo.(io.ReadSeeker); ok {
+			response.Header().Set("Content-Type", mediaType)
+
+			request = request.Clone(request.Context())
+			request.Header.Del("Range")
+
+			http.ServeContent(response, request, "", time.Time{}, blob)
+
+			return
+		}
+	}
+
 	response.Header().Set("Content-Length", fmt.Sprintf("%d", blen))
 
 	status := http.StatusOK
@@ -1005,14 +1205,29 @@ func (rh *RouteHandler) GetBlob(response http.ResponseWriter, request *http.Requ
 		status = http.StatusPartialContent
 
 		response.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", from, from+blen-1, bsize))
-	} else {
-		response.Header().Set(constants.DistContentDigestKey, digest.String())
 	}
 
 	// return the blob data
 	WriteDataFromReader(response, status, blen, mediaType, repo, rh.c.Log)
 }
 
+// blobETag returns the entity tag of a blob, its content never changes so its digest is a strong validator.
+func blobETag(digest godigest.Digest) string {
+	return fmt.Sprintf("%q", digest.String())
+}
+
+// ifRangeMatches tells if the range requested for a blob can be sent, If-Range makes it conditional on the
+// blob being the one the client already has part of.
+func ifRangeMatches(request *http.Request, digest godigest.Digest) bool {
+	ifRange := request.Header.Get("If-Range")
+	if ifRange == "" {
+		return true
+	}
+
+	// blobs have no Last-Modified date to compare an HTTP date with, in which case the whole blob is sent
+	return ifRange == blobETag(digest)
+}
+
 // DeleteBlob godoc
 // @Summary Delete image blob/layer
 // @Description Delete an image's blob/layer given a digest
@@ -1041,7 +1256,7 @@ func (rh *RouteHandler) DeleteBlob(response http.ResponseWriter, request *http.R
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	err = imgStore.DeleteBlob(name, digest)
 	if err != nil {
@@ -1070,10 +1285,14 @@ func (rh *RouteHandler) DeleteBlob(response http.ResponseWriter, request *http.R
 
 // CreateBlobUpload godoc
 // @Summary Create image blob/layer upload
-// @Description Create a new image blob/layer upload
+// @Description Create a new image blob/layer upload, or mount a blob from another repository
 // @Accept  json
 // @Produce json
 // @Param   name				path    string     true        "repository name"
+// @Param   mount				query   string     false       "digest of the blob to mount"
+// @Param   from				query   string     false       "repository to mount the blob from"
+// @Success 201 {string} string	"created"
+// @Header  201 {string} Location "/v2/{name}/blobs/{digest}"
 // @Success 202 {string} string	"accepted"
 // @Header  202 {string} Location "/v2/{name}/blobs/uploads/{session_id}"
 // @Header  202 {string} Range "0-0"
@@ -1090,9 +1309,19 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
-	// currently zot does not support cross-repository mounting, following dist-spec and returning 202
+	// all the uploads below create the repo if it doesn't exist
+	if !rh.checkQuota(response, request, imgStore, name, "") {
+		return
+	}
+
+	if !rh.checkStorageQuota(response, name, nil) {
+		return
+	}
+
+	// a cross repository mount if "mount" is present, following dist-spec a new upload is started and 202
+	// returned if the blob can't be mounted
 	if mountDigests, ok := request.URL.Query()["mount"]; ok {
 		if len(mountDigests) != 1 {
 			response.WriteHeader(http.StatusBadRequest)
@@ -1101,11 +1330,12 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 		}
 
 		mountDigest := godigest.Digest(mountDigests[0])
-		// zot does not support cross mounting directly and do a workaround creating using hard link.
-		// check blob looks for actual path (name+mountDigests[0]) first then look for cache and
-		// if found in cache, will do hard link and if fails we will start new upload.
-		_, _, err := imgStore.CheckBlob(name, mountDigest)
-		if err != nil {
+
+		if !rh.checkBlocklist(response, request, name, mountDigest) {
+			return
+		}
+
+		if !rh.mountBlob(request, imgStore, name, mountDigest) {
 			upload, err := imgStore.NewBlobUpload(name)
 			if err != nil {
 				if errors.Is(err, zerr.ErrRepoNotFound) {
@@ -1119,14 +1349,16 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 				return
 			}
 
-			response.Header().Set("Location", getBlobUploadSessionLocation(request.URL, upload))
+			response.Header().Set("Location",
+				rh.externalLocation(request, getBlobUploadSessionLocation(request.URL, upload)))
 			response.Header().Set("Range", "0-0")
 			response.WriteHeader(http.StatusAccepted)
 
 			return
 		}
 
-		response.Header().Set("Location", getBlobUploadLocation(request.URL, name, mountDigest))
+		response.Header().Set("Location",
+			rh.externalLocation(request, getBlobUploadLocation(request.URL, name, mountDigest)))
 		response.WriteHeader(http.StatusCreated)
 
 		return
@@ -1160,6 +1392,10 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 
 		digest := godigest.Digest(digestStr)
 
+		if !rh.checkBlocklist(response, request, name, digest) {
+			return
+		}
+
 		var contentLength int64
 
 		contentLength, err := strconv.ParseInt(request.Header.Get("Content-Length"), 10, 64)
@@ -1171,6 +1407,10 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 			return
 		}
 
+		if !rh.checkBlobSize(response, name, contentLength) {
+			return
+		}
+
 		sessionID, size, err := imgStore.FullBlobUpload(name, request.Body, digest)
 		if err != nil {
 			rh.c.Log.Error().Err(err).Int64("actual", size).Int64("expected", contentLength).Msg("failed full upload")
@@ -1186,7 +1426,8 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 			return
 		}
 
-		response.Header().Set("Location", getBlobUploadLocation(request.URL, name, digest))
+		response.Header().Set("Location",
+			rh.externalLocation(request, getBlobUploadLocation(request.URL, name, digest)))
 		response.Header().Set(constants.BlobUploadUUID, sessionID)
 		response.WriteHeader(http.StatusCreated)
 
@@ -1206,19 +1447,23 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 		return
 	}
 
-	response.Header().Set("Location", getBlobUploadSessionLocation(request.URL, upload))
+	response.Header().Set("Location",
+		rh.externalLocation(request, getBlobUploadSessionLocation(request.URL, upload)))
 	response.Header().Set("Range", "0-0")
 	response.WriteHeader(http.StatusAccepted)
 }
 
 // GetBlobUpload godoc
 // @Summary Get image blob/layer upload
-// @Description Get an image's blob/layer upload given a session_id
+// @Description Get an image's blob/layer upload given a session_id.
+// @Description Clients accepting application/json get the bytes received so far in the response body,
+// @Description clients accepting text/event-stream get them streamed as server-sent events until the upload ends.
 // @Accept  json
 // @Produce json
 // @Param   name     path    string     true        "repository name"
 // @Param   session_id     path    string     true        "upload session_id"
 // @Success 204 {string} string "no content"
+// @Success 200 {object} api.BlobUploadProgress
 // @Header  202 {string} Location "/v2/{name}/blobs/uploads/{session_id}"
 // @Header  202 {string} Range "0-128"
 // @Failure 404 {string} string "not found"
@@ -1234,7 +1479,7 @@ func (rh *RouteHandler) GetBlobUpload(response http.ResponseWriter, request *htt
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	sessionID, ok := vars["session_id"]
 	if !ok || sessionID == "" {
@@ -1265,9 +1510,20 @@ func (rh *RouteHandler) GetBlobUpload(response http.ResponseWriter, request *htt
 		return
 	}
 
-	response.Header().Set("Location", getBlobUploadSessionLocation(request.URL, sessionID))
+	response.Header().Set("Location",
+		rh.externalLocation(request, getBlobUploadSessionLocation(request.URL, sessionID)))
 	response.Header().Set("Range", fmt.Sprintf("0-%d", size-1))
-	response.WriteHeader(http.StatusNoContent)
+
+	progress := BlobUploadProgress{SessionID: sessionID, Repository: name, BytesReceived: size}
+
+	switch accept := request.Header.Get("Accept"); {
+	case acceptsMediaType(accept, constants.EventStreamMediaType):
+		rh.streamBlobUploadProgress(response, request, imgStore, progress)
+	case acceptsMediaType(accept, constants.DefaultMediaType):
+		zcommon.WriteJSON(response, http.StatusOK, progress)
+	default:
+		response.WriteHeader(http.StatusNoContent)
+	}
 }
 
 // PatchBlobUpload godoc
@@ -1296,7 +1552,7 @@ func (rh *RouteHandler) PatchBlobUpload(response http.ResponseWriter, request *h
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	sessionID, ok := vars["session_id"]
 	if !ok || sessionID == "" {
@@ -1310,8 +1566,9 @@ func (rh *RouteHandler) PatchBlobUpload(response http.ResponseWriter, request *h
 	var err error
 
 	if request.Header.Get("Content-Length") == "" || request.Header.Get("Content-Range") == "" {
-		// streamed blob upload
-		clen, err = imgStore.PutBlobChunkStreamed(name, sessionID, request.Body)
+		// streamed blob upload, oversized blobs are rejected as soon as they exceed the limit
+		clen, err = imgStore.PutBlobChunkStreamed(name, sessionID,
+			rh.limitBlobUpload(imgStore, name, sessionID, request.Body))
 	} else {
 		// chunked blob upload
 
@@ -1331,6 +1588,10 @@ func (rh *RouteHandler) PatchBlobUpload(response http.ResponseWriter, request *h
 			return
 		}
 
+		if !rh.checkBlobSize(response, name, to+1) {
+			return
+		}
+
 		clen, err = imgStore.PutBlobChunk(name, sessionID, from, to, request.Body)
 	}
 
@@ -1338,6 +1599,16 @@ func (rh *RouteHandler) PatchBlobUpload(response http.ResponseWriter, request *h
 		if errors.Is(err, zerr.ErrBadUploadRange) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
 			zcommon.WriteJSON(response, http.StatusRequestedRangeNotSatisfiable,
 				apiErr.NewErrorList(apiErr.NewError(apiErr.BLOB_UPLOAD_INVALID, map[string]string{"session_id": sessionID})))
+		} else if errors.Is(err, zerr.ErrBlobTooLarge) {
+			rh.c.Log.Info().Err(err).Str("repository", name).Str("session_id", sessionID).Msg("push denied")
+
+			// the upload can't be resumed
+			if err = imgStore.DeleteBlobUpload(name, sessionID); err != nil {
+				rh.c.Log.Error().Err(err).Str("blobUpload", sessionID).Str("repository", name).
+					Msg("couldn't remove blobUpload in repo")
+			}
+
+			writeBlobTooLargeError(response, zerr.ErrBlobTooLarge, name, rh.c.BlobPolicies.GetMaxLayerSize(name))
 		} else if errors.Is(err, zerr.ErrRepoNotFound) {
 			zcommon.WriteJSON(response, http.StatusNotFound,
 				apiErr.NewErrorList(apiErr.NewError(apiErr.NAME_UNKNOWN, map[string]string{"name": name})))
@@ -1358,7 +1629,8 @@ func (rh *RouteHandler) PatchBlobUpload(response http.ResponseWriter, request *h
 		return
 	}
 
-	response.Header().Set("Location", getBlobUploadSessionLocation(request.URL, sessionID))
+	response.Header().Set("Location",
+		rh.externalLocation(request, getBlobUploadSessionLocation(request.URL, sessionID)))
 	response.Header().Set("Range", fmt.Sprintf("0-%d", clen-1))
 	response.Header().Set("Content-Length", "0")
 	response.Header().Set(constants.BlobUploadUUID, sessionID)
@@ -1389,7 +1661,7 @@ func (rh *RouteHandler) UpdateBlobUpload(response http.ResponseWriter, request *
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	sessionID, ok := vars["session_id"]
 	if !ok || sessionID == "" {
@@ -1412,6 +1684,10 @@ func (rh *RouteHandler) UpdateBlobUpload(response http.ResponseWriter, request *
 		return
 	}
 
+	if !rh.checkBlocklist(response, request, name, digest) {
+		return
+	}
+
 	rh.c.Log.Info().Int64("r.ContentLength", request.ContentLength).Msg("DEBUG")
 
 	contentPresent := true
@@ -1453,6 +1729,10 @@ func (rh *RouteHandler) UpdateBlobUpload(response http.ResponseWriter, request *
 			return
 		}
 
+		if !rh.checkBlobSize(response, name, to+1) {
+			return
+		}
+
 		_, err = imgStore.PutBlobChunk(name, sessionID, from, to, request.Body)
 		if err != nil {
 			if errors.Is(err, zerr.ErrBadUploadRange) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
@@ -1508,7 +1788,8 @@ finish:
 		return
 	}
 
-	response.Header().Set("Location", getBlobUploadLocation(request.URL, name, digest))
+	response.Header().Set("Location",
+		rh.externalLocation(request, getBlobUploadLocation(request.URL, name, digest)))
 	response.Header().Set("Content-Length", "0")
 	response.Header().Set(constants.DistContentDigestKey, digest.String())
 	response.WriteHeader(http.StatusCreated)
@@ -1535,7 +1816,7 @@ func (rh *RouteHandler) DeleteBlobUpload(response http.ResponseWriter, request *
 		return
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	sessionID, ok := vars["session_id"]
 	if !ok || sessionID == "" {
@@ -1571,7 +1852,10 @@ type RepositoryList struct {
 // @Description List all image repositories
 // @Accept  json
 // @Produce json
+// @Param 	n	 			 query 	 integer 		false				"limit entries for pagination"
+// @Param 	last	 	 query 	 string 		false				"last repository value for pagination"
 // @Success 200 {object} 	api.RepositoryList
+// @Failure 400 {string} string "bad request"
 // @Failure 500 {string} string "internal server error"
 // @Router /v2/_catalog [get].
 func (rh *RouteHandler) ListRepositories(response http.ResponseWriter, request *http.Request) {
@@ -1582,50 +1866,54 @@ func (rh *RouteHandler) ListRepositories(response http.ResponseWriter, request *
 		return
 	}
 
-	combineRepoList := make([]string, 0)
+	numRepos := -1
 
-	subStore := rh.c.StoreController.SubStore
+	nQuery, ok := request.URL.Query()["n"]
 
-	for _, imgStore := range subStore {
-		repos, err := imgStore.GetRepositories()
-		if err != nil {
-			response.WriteHeader(http.StatusInternalServerError)
+	if ok {
+		if len(nQuery) != 1 {
+			response.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		nQuery1, err := strconv.ParseInt(nQuery[0], 10, 0)
+		if err != nil || nQuery1 < 0 {
+			response.WriteHeader(http.StatusBadRequest)
 
 			return
 		}
 
-		combineRepoList = append(combineRepoList, repos...)
+		numRepos = int(nQuery1)
 	}
 
-	singleStore := rh.c.StoreController.DefaultStore
-	if singleStore != nil {
-		repos, err := singleStore.GetRepositories()
-		if err != nil {
-			response.WriteHeader(http.StatusInternalServerError)
+	last := ""
+	lastQuery, ok := request.URL.Query()["last"]
+
+	if ok {
+		if len(lastQuery) != 1 {
+			response.WriteHeader(http.StatusBadRequest)
 
 			return
 		}
 
-		combineRepoList = append(combineRepoList, repos...)
+		last = lastQuery[0]
 	}
 
-	repos := make([]string, 0)
-	// authz context
-	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	repos, err := rh.getCatalog(request, last, numRepos)
 	if err != nil {
 		response.WriteHeader(http.StatusInternalServerError)
 
 		return
 	}
 
-	if acCtx != nil {
-		for _, r := range combineRepoList {
-			if acCtx.IsAdmin || acCtx.CanReadRepo(r) {
-				repos = append(repos, r)
-			}
+	if numRepos >= 0 && len(repos) > numRepos {
+		repos = repos[:numRepos]
+
+		if numRepos > 0 {
+			response.Header().Set("Link", rh.externalLocation(request,
+				fmt.Sprintf("/v2/_catalog?n=%d&last=%s; rel=\"next\"", numRepos, repos[numRepos-1])))
 		}
-	} else {
-		repos = combineRepoList
 	}
 
 	is := RepositoryList{Repositories: repos}
@@ -1633,6 +1921,71 @@ func (rh *RouteHandler) ListRepositories(response http.ResponseWriter, request *
 	zcommon.WriteJSON(response, http.StatusOK, is)
 }
 
+// getCatalog returns the repos the user can read coming after last, in the order given by
+// storageCommon.CompareRepoNames. With a limit, at most limit+1 repos are returned, the image stores being
+// walked until then only, so that the pages of big catalogs don't need all the repos to be listed.
+func (rh *RouteHandler) getCatalog(request *http.Request, last string, limit int) ([]string, error) {
+	// authz context
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	// the catalog of a virtual registry lists the repos of its tenant only, which are walked first when starting
+	// from the tenant's namespace
+	tenant := localCtx.GetTenant(request.Context())
+	if tenant != "" {
+		if last != "" {
+			last = tenant + "/" + last
+		} else {
+			last = tenant
+		}
+	}
+
+	repos := make([]string, 0)
+
+	for _, imgStore := range rh.c.StoreController.UniqueImageStores() {
+		storeRepos := 0
+
+		err := imgStore.WalkRepositories(last, func(repo string) error {
+			name := repo
+
+			if tenant != "" {
+				var found bool
+				if name, found = strings.CutPrefix(repo, tenant+"/"); !found {
+					return io.EOF
+				}
+			}
+
+			if acCtx != nil && !acCtx.IsAdmin && !acCtx.CanReadRepo(repo) {
+				return nil
+			}
+
+			repos = append(repos, name)
+			storeRepos++
+
+			if limit >= 0 && storeRepos > limit {
+				return io.EOF
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	}
+
+	sort.Slice(repos, func(i, j int) bool {
+		return storageCommon.CompareRepoNames(repos[i], repos[j]) < 0
+	})
+
+	if limit >= 0 && len(repos) > limit+1 {
+		repos = repos[:limit+1]
+	}
+
+	return repos, nil
+}
+
 // ListExtensions godoc
 // @Summary List Registry level extensions
 // @Description List all extensions present on registry
@@ -1703,21 +2056,30 @@ func WriteDataFromReader(response http.ResponseWriter, status int, length int64,
 	}
 }
 
-// will return image storage corresponding to subpath provided in config.
-func (rh *RouteHandler) getImageStore(name string) storageTypes.ImageStore {
-	return rh.c.StoreController.GetImageStore(name)
+// will return image storage corresponding to subpath provided in config, logging its events with the fields of
+// the request, see getRequestLogger.
+func (rh *RouteHandler) getImageStore(request *http.Request, name string) storageTypes.ImageStore {
+	return rh.c.StoreController.GetImageStore(name).WithLogger(getRequestLogger(request, rh.c.Log.Logger))
 }
 
 // will sync on demand if an image is not found, in case sync extensions is enabled.
-func getImageManifest(routeHandler *RouteHandler, imgStore storageTypes.ImageStore, name,
+func getImageManifest(ctx context.Context, routeHandler *RouteHandler, imgStore storageTypes.ImageStore, name,
 	reference string,
 ) ([]byte, godigest.Digest, string, error) {
-	syncEnabled := isSyncOnDemandEnabled(*routeHandler.c)
+	syncEnabled := isSyncOnDemandEnabled(routeHandler.c)
 
 	_, digestErr := godigest.Parse(reference)
 	if digestErr == nil {
 		// if it's a digest then return local cached image, if not found and sync enabled, then try to sync
 		content, digest, mediaType, err := imgStore.GetImageManifest(name, reference)
+		if errors.Is(err, zerr.ErrManifestNotFound) && routeHandler.c.RepoDB != nil {
+			// the manifest may have been rewritten when it was pushed, repodb knows the digest it's stored with
+			aliased, aliasErr := routeHandler.c.RepoDB.ResolveDigestAlias(name, godigest.Digest(reference))
+			if aliasErr == nil {
+				content, digest, mediaType, err = imgStore.GetImageManifest(name, aliased.String())
+			}
+		}
+
 		if err == nil || !syncEnabled {
 			return content, digest, mediaType, err
 		}
@@ -1727,7 +2089,7 @@ func getImageManifest(routeHandler *RouteHandler, imgStore storageTypes.ImageSto
 		routeHandler.c.Log.Info().Str("repository", name).Str("reference", reference).
 			Msg("trying to get updated image by syncing on demand")
 
-		if errSync := routeHandler.c.SyncOnDemand.SyncImage(name, reference); errSync != nil {
+		if errSync := routeHandler.c.SyncOnDemand.SyncImage(ctx, name, reference); errSync != nil {
 			routeHandler.c.Log.Err(errSync).Str("repository", name).Str("reference", reference).
 				Msg("error encounter while syncing image")
 		}
@@ -1737,17 +2099,18 @@ func getImageManifest(routeHandler *RouteHandler, imgStore storageTypes.ImageSto
 }
 
 // will sync referrers on demand if they are not found, in case sync extensions is enabled.
-func getOrasReferrers(routeHandler *RouteHandler,
+func getOrasReferrers(ctx context.Context, routeHandler *RouteHandler,
 	imgStore storageTypes.ImageStore, name string, digest godigest.Digest,
 	artifactType string,
 ) ([]artifactspec.Descriptor, error) {
 	refs, err := imgStore.GetOrasReferrers(name, digest, artifactType)
 	if err != nil {
-		if isSyncOnDemandEnabled(*routeHandler.c) {
+		if isSyncOnDemandEnabled(routeHandler.c) {
 			routeHandler.c.Log.Info().Str("repository", name).Str("reference", digest.String()).
 				Msg("artifact not found, trying to get artifact by syncing on demand")
 
-			if errSync := routeHandler.c.SyncOnDemand.SyncReference(name, digest.String(), syncConstants.Oras); errSync != nil {
+			if errSync := routeHandler.c.SyncOnDemand.SyncReference(ctx, name, digest.String(),
+				syncConstants.Oras); errSync != nil {
 				routeHandler.c.Log.Error().Err(err).Str("name", name).Str("digest", digest.String()).
 					Msg("unable to get references")
 			}
@@ -1809,11 +2172,11 @@ func (rh *RouteHandler) GetOrasReferrers(response http.ResponseWriter, request *
 		artifactType = artifactTypes[0]
 	}
 
-	imgStore := rh.getImageStore(name)
+	imgStore := rh.getImageStore(request, name)
 
 	rh.c.Log.Info().Str("digest", digest.String()).Str("artifactType", artifactType).Msg("getting manifest")
 
-	refs, err := getOrasReferrers(rh, imgStore, name, digest, artifactType) //nolint:contextcheck
+	refs, err := getOrasReferrers(getSyncContext(request), rh, imgStore, name, digest, artifactType)
 	if err != nil {
 		if errors.Is(err, zerr.ErrManifestNotFound) || errors.Is(err, zerr.ErrRepoNotFound) {
 			rh.c.Log.Error().Err(err).Str("name", name).Str("digest", digest.String()).Msg("manifest not found")
@@ -1859,7 +2222,7 @@ func getBlobUploadLocation(url *url.URL, name string, digest godigest.Digest) st
 	return url.String()
 }
 
-func isSyncOnDemandEnabled(ctlr Controller) bool {
+func isSyncOnDemandEnabled(ctlr *Controller) bool {
 	if ctlr.Config.Extensions != nil &&
 		ctlr.Config.Extensions.Sync != nil &&
 		*ctlr.Config.Extensions.Sync.Enable &&
@@ -1869,3 +2232,74 @@ func isSyncOnDemandEnabled(ctlr Controller) bool {
 
 	return false
 }
+
+/*
+getSyncContext returns the request context carrying the credentials the user authenticated with,
+so that sync on demand can forward them to the upstream registries configured to do so.
+*/
+func getSyncContext(request *http.Request) context.Context {
+	ctx := request.Context()
+
+	identity := localCtx.Identity{}
+
+	if username, password, ok := request.BasicAuth(); ok {
+		identity.Username = username
+		identity.Password = password
+	} else if token, found := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer "); found {
+		identity.BearerToken = token
+	}
+
+	if acCtx, err := localCtx.GetAccessControlContext(ctx); err == nil && acCtx != nil && acCtx.Username != "" {
+		identity.Username = acCtx.Username
+	}
+
+	return localCtx.WithIdentity(ctx, identity)
+}
+
+// notifyPlugins sends an image event to the notifier plugins, if any.
+func (rh *RouteHandler) notifyPlugins(request *http.Request, eventType, repo, reference string,
+	digest godigest.Digest, mediaType string,
+) {
+	rh.notifyPluginsEvent(request, plugins.Event{
+		Type:      eventType,
+		Repo:      repo,
+		Reference: reference,
+		Digest:    digest.String(),
+		MediaType: mediaType,
+	})
+}
+
+// notifySignature sends a signatureAdded event to the notifier plugins, if the pushed manifest is a signature.
+func (rh *RouteHandler) notifySignature(request *http.Request, repo, reference string, digest godigest.Digest,
+	mediaType string, body []byte,
+) {
+	if rh.c.Plugins == nil || mediaType != ispec.MediaTypeImageManifest {
+		return
+	}
+
+	isSignature, signatureType, signedDigest, err := storage.CheckIsImageSignature(repo, body, reference)
+	if err != nil || !isSignature {
+		return
+	}
+
+	rh.notifyPluginsEvent(request, plugins.Event{
+		Type:      plugins.EventSignatureAdded,
+		Repo:      repo,
+		Reference: reference,
+		Digest:    digest.String(),
+		MediaType: mediaType,
+		Details: map[string]string{
+			plugins.EventDetailSignatureType: signatureType,
+			plugins.EventDetailSignedDigest:  signedDigest.String(),
+		},
+	})
+}
+
+// notifyPluginsEvent sends an event done by the user who made the request to the notifier plugins, if any.
+func (rh *RouteHandler) notifyPluginsEvent(request *http.Request, event plugins.Event) {
+	if acCtx, err := localCtx.GetAccessControlContext(request.Context()); err == nil && acCtx != nil {
+		event.Username = acCtx.Username
+	}
+
+	rh.c.Plugins.Notify(event)
+}
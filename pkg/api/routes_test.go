@@ -1197,8 +1197,11 @@ func TestRoutes(t *testing.T) {
 				ctlr.StoreController.DefaultStore = ism
 				ctlr.StoreController.SubStore = map[string]storageTypes.ImageStore{
 					"test": &mocks.MockedImageStore{
-						GetRepositoriesFn: func() ([]string, error) {
-							return []string{}, ErrUnexpectedError
+						RootDirFn: func() string {
+							return "/test"
+						},
+						WalkRepositoriesFn: func(last string, walkFn func(repo string) error) error {
+							return ErrUnexpectedError
 						},
 					},
 				}
@@ -1265,11 +1268,7 @@ func TestRoutes(t *testing.T) {
 					"name":       "repo",
 					"session_id": "test",
 				},
-				&mocks.MockedImageStore{
-					GetRepositoriesFn: func() ([]string, error) {
-						return []string{}, ErrUnexpectedError
-					},
-				},
+				&mocks.MockedImageStore{},
 			)
 			So(status, ShouldEqual, http.StatusInternalServerError)
 
@@ -1281,8 +1280,8 @@ func TestRoutes(t *testing.T) {
 					"session_id": "test",
 				},
 				&mocks.MockedImageStore{
-					GetRepositoriesFn: func() ([]string, error) {
-						return []string{}, ErrUnexpectedError
+					WalkRepositoriesFn: func(last string, walkFn func(repo string) error) error {
+						return ErrUnexpectedError
 					},
 				},
 			)
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/base64"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,6 +13,7 @@ import (
 
 	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
 )
 
 type statusWriter struct {
@@ -25,6 +27,11 @@ func (w *statusWriter) WriteHeader(status int) {
 	w.ResponseWriter.WriteHeader(status)
 }
 
+// Unwrap lets handlers flush streamed responses through the writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (w *statusWriter) Write(b []byte) (int, error) {
 	if w.status == 0 {
 		w.status = http.StatusOK
@@ -36,6 +43,18 @@ func (w *statusWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// ReadFrom lets the underlying writer send files with sendfile.
+func (w *statusWriter) ReadFrom(reader io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := io.Copy(w.ResponseWriter, reader)
+	w.length += int(n)
+
+	return n, err
+}
+
 // RateLimiter limits handling of incoming requests.
 func RateLimiter(ctlr *Controller, rate int) mux.MiddlewareFunc {
 	ctlr.Log.Info().Int("rate", rate).Msg("ratelimiter enabled")
@@ -121,6 +140,10 @@ func SessionLogger(ctlr *Controller) mux.MiddlewareFunc {
 				monitoring.ObserveHTTPMethodLatency(ctlr.Metrics, method, latency) // histogram
 			}
 
+			if requestID := localCtx.GetRequestID(request.Context()); requestID != "" {
+				log = log.Str("requestID", requestID)
+			}
+
 			log.Str("clientIP", clientIP).
 				Str("method", method).
 				Str("path", path).
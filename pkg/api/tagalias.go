@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	apiErr "zotregistry.io/zot/pkg/api/errors"
+	"zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/plugins"
+	"zotregistry.io/zot/pkg/meta/events"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// checkTagAliases returns the aliases a pushed tag updates, it's called before the tag is stored so that a push
+// is either rejected or applied along with its aliases. Immutable aliases pointing to another manifest are
+// skipped, moving an existing alias needs update permission on the repo. If the push is rejected it writes the
+// error response and returns false.
+func (rh *RouteHandler) checkTagAliases(response http.ResponseWriter, request *http.Request,
+	imgStore storageTypes.ImageStore, name, reference string, body []byte,
+) ([]string, bool) {
+	// aliases are only updated when pushing tags
+	if _, err := godigest.Parse(reference); err == nil {
+		return nil, true
+	}
+
+	digest := godigest.FromBytes(body)
+	aliases := []string{}
+
+	var tags []string
+
+	for _, alias := range rh.c.TagAliases.GetAliases(name, reference) {
+		if tagDigest, moved := rh.isImmutableTagMoved(imgStore, name, alias, digest); moved {
+			rh.c.Log.Info().Str("repository", name).Str("tag", reference).Str("alias", alias).
+				Str("digest", tagDigest.String()).Msg("skipping immutable tag alias")
+
+			continue
+		}
+
+		if tags == nil {
+			// a new repo has no tags to update
+			tags, _ = imgStore.GetImageTags(name)
+			if tags == nil {
+				tags = []string{}
+			}
+		}
+
+		if common.Contains(tags, alias) && !rh.canUpdateRepo(request, name) {
+			rh.c.Log.Info().Err(zerr.ErrTagAliasDenied).Str("repository", name).Str("tag", reference).
+				Str("alias", alias).Msg("push denied")
+
+			writeDeniedError(response, zerr.ErrTagAliasDenied, map[string]string{
+				"name":  name,
+				"tag":   reference,
+				"alias": alias,
+			})
+
+			return nil, false
+		}
+
+		// aliases can't create the tags protected by promotion gates either
+		if !rh.checkPromotionGates(response, request, name, alias) {
+			return nil, false
+		}
+
+		aliases = append(aliases, alias)
+	}
+
+	return aliases, true
+}
+
+// canUpdateRepo returns whether the user who made the request has update permission on the repo, the access
+// control middleware only checks the tag being pushed.
+func (rh *RouteHandler) canUpdateRepo(request *http.Request, name string) bool {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil || acCtx == nil {
+		return rh.c.Config.HTTP.AccessControl == nil
+	}
+
+	return acCtx.IsAdmin || acCtx.CanUpdateRepo(name)
+}
+
+// applyTagAliases makes the aliases of a pushed tag point to the pushed manifest, all at once, the updated
+// aliases are logged and audited. If the aliases couldn't be updated it writes the error response and
+// returns false, pushing the tag again retries.
+func (rh *RouteHandler) applyTagAliases(response http.ResponseWriter, request *http.Request,
+	imgStore storageTypes.ImageStore, name, reference string, aliases []string, digest godigest.Digest,
+	mediaType string, body []byte,
+) bool {
+	if len(aliases) == 0 {
+		return true
+	}
+
+	var username string
+
+	if acCtx, err := localCtx.GetAccessControlContext(request.Context()); err == nil {
+		username = localCtx.GetUsernameFromContext(acCtx)
+	}
+
+	if err := imgStore.TagImageManifest(name, digest, aliases); err != nil {
+		rh.c.Log.Error().Err(err).Str("repository", name).Str("tag", reference).Strs("aliases", aliases).
+			Msg("unable to update tag aliases")
+
+		// an alias was made immutable by a concurrent push since it was checked
+		if errors.Is(err, zerr.ErrTagImmutable) {
+			common.WriteJSON(response, http.StatusConflict,
+				apiErr.NewErrorList(apiErr.NewError(apiErr.TAG_IMMUTABLE, map[string]string{
+					"name": name, "tag": reference,
+				}).WithMessage(err.Error())))
+
+			return false
+		}
+
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return false
+	}
+
+	for _, alias := range aliases {
+		if err := rh.updateRepoDB(events.EventManifestPushed, name, alias, mediaType, digest, body); err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+
+			return false
+		}
+
+		rh.notifyPlugins(request, plugins.EventManifestPushed, name, alias, digest, mediaType)
+	}
+
+	rh.c.Log.Info().Str("repository", name).Str("tag", reference).Strs("aliases", aliases).
+		Str("digest", digest.String()).Str("user", username).Msg("updated tag aliases")
+
+	// the audit middleware only records the pushed tag
+	if rh.c.Audit != nil {
+		rh.c.Audit.Info().
+			Str("clientIP", request.RemoteAddr).
+			Str("subject", username).
+			Str("action", request.Method).
+			Str("object", request.URL.Path).
+			Strs("aliases", aliases).
+			Str("digest", digest.String()).
+			Int("status", http.StatusCreated).
+			Msg("tag aliases updated")
+	}
+
+	return true
+}
@@ -0,0 +1,133 @@
+package tagalias
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	zreg "zotregistry.io/zot/pkg/regexp"
+)
+
+type rule struct {
+	repositories []string
+	tag          *regexp.Regexp
+	aliases      []string
+}
+
+// Rules gives the tags to update when a tag is pushed, according to the tag alias rules of the config.
+type Rules struct {
+	rules []rule
+	lock  sync.RWMutex
+}
+
+// New compiles the enabled rules of the config.
+func New(configs []config.TagAliasRule) (*Rules, error) {
+	rules := &Rules{}
+
+	if err := rules.Set(configs); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Set replaces the rules, e.g. when the config is reloaded, they are left unchanged if one of them is invalid.
+func (rules *Rules) Set(configs []config.TagAliasRule) error {
+	compiled := []rule{}
+
+	for idx, ruleConfig := range configs {
+		if ruleConfig.Enable != nil && !*ruleConfig.Enable {
+			continue
+		}
+
+		if ruleConfig.Tag == "" || len(ruleConfig.Aliases) == 0 {
+			return fmt.Errorf("%w: rule %d needs a tag and aliases", zerr.ErrBadTagAliasRule, idx)
+		}
+
+		for _, pattern := range ruleConfig.Repositories {
+			if !glob.ValidatePattern(pattern) {
+				return fmt.Errorf("%w: rule %d has an invalid repository pattern %s", zerr.ErrBadTagAliasRule,
+					idx, pattern)
+			}
+		}
+
+		// the whole tag has to match
+		tagRegexp, err := regexp.Compile("^(?:" + ruleConfig.Tag + ")$")
+		if err != nil {
+			return fmt.Errorf("%w: rule %d has an invalid tag regular expression: %w", zerr.ErrBadTagAliasRule,
+				idx, err)
+		}
+
+		compiled = append(compiled, rule{
+			repositories: ruleConfig.Repositories,
+			tag:          tagRegexp,
+			aliases:      ruleConfig.Aliases,
+		})
+	}
+
+	rules.lock.Lock()
+	defer rules.lock.Unlock()
+
+	rules.rules = compiled
+
+	return nil
+}
+
+// GetAliases returns the tags to update when the tag is pushed to the repo, in the order of the rules,
+// aliases which aren't valid tags once expanded are skipped.
+func (rules *Rules) GetAliases(repo, tag string) []string {
+	aliases := []string{}
+
+	if rules == nil {
+		return aliases
+	}
+
+	rules.lock.RLock()
+	defer rules.lock.RUnlock()
+
+	seen := map[string]bool{tag: true}
+
+	for _, rule := range rules.rules {
+		if !rule.matchesRepo(repo) {
+			continue
+		}
+
+		match := rule.tag.FindStringSubmatchIndex(tag)
+		if match == nil {
+			continue
+		}
+
+		for _, template := range rule.aliases {
+			alias := string(rule.tag.ExpandString(nil, template, tag, match))
+
+			if seen[alias] || !zreg.FullTagRegexp.MatchString(alias) {
+				continue
+			}
+
+			seen[alias] = true
+
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return aliases
+}
+
+func (rule rule) matchesRepo(repo string) bool {
+	if len(rule.repositories) == 0 {
+		return true
+	}
+
+	for _, pattern := range rule.repositories {
+		// patterns are validated by New
+		if matched, _ := glob.Match(pattern, repo); matched {
+			return true
+		}
+	}
+
+	return false
+}
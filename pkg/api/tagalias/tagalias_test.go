@@ -0,0 +1,69 @@
+package tagalias_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/tagalias"
+)
+
+func TestTagAliases(t *testing.T) {
+	Convey("Get the aliases of pushed tags", t, func() {
+		disabled := false
+
+		rules, err := tagalias.New([]config.TagAliasRule{
+			{
+				Repositories: []string{"apps/**"},
+				Tag:          `(\d+)\.(\d+)\.(\d+)`,
+				Aliases:      []string{"$1.$2", "$1", "latest"},
+			},
+			{
+				Tag:     `(?P<version>.+)-(?P<arch>amd64|arm64)`,
+				Aliases: []string{"${version}", "latest-${arch}", "$invalid:tag"},
+			},
+			{
+				Tag:     `.*`,
+				Aliases: []string{"disabled"},
+				Enable:  &disabled,
+			},
+		})
+		So(err, ShouldBeNil)
+
+		So(rules.GetAliases("apps/frontend", "1.2.3"), ShouldResemble, []string{"1.2", "1", "latest"})
+		// the whole tag has to match
+		So(rules.GetAliases("apps/frontend", "1.2.3-rc1"), ShouldBeEmpty)
+		So(rules.GetAliases("infra/db", "1.2.3"), ShouldBeEmpty)
+		So(rules.GetAliases("infra/db", "1.2.3-arm64"), ShouldResemble, []string{"1.2.3", "latest-arm64"})
+		// an alias is never the pushed tag itself
+		So(rules.GetAliases("apps/frontend", "latest-amd64"), ShouldResemble, []string{"latest"})
+
+		Convey("Reload the rules", func() {
+			err := rules.Set([]config.TagAliasRule{{Tag: "(.*)", Aliases: []string{"[bad"}}})
+			So(err, ShouldBeNil)
+			// invalid tags are skipped
+			So(rules.GetAliases("apps/frontend", "1.2.3"), ShouldBeEmpty)
+
+			err = rules.Set([]config.TagAliasRule{{Tag: "(", Aliases: []string{"latest"}}})
+			So(err, ShouldWrap, zerr.ErrBadTagAliasRule)
+			So(rules.GetAliases("apps/frontend", "1.2.3"), ShouldBeEmpty)
+		})
+
+		var nilRules *tagalias.Rules
+		So(nilRules.GetAliases("apps/frontend", "1.2.3"), ShouldBeEmpty)
+	})
+
+	Convey("Reject invalid rules", t, func() {
+		for _, rule := range []config.TagAliasRule{
+			{Tag: "", Aliases: []string{"latest"}},
+			{Tag: ".*"},
+			{Tag: "(", Aliases: []string{"latest"}},
+			{Repositories: []string{"[a-"}, Tag: ".*", Aliases: []string{"latest"}},
+		} {
+			_, err := tagalias.New([]config.TagAliasRule{rule})
+			So(err, ShouldWrap, zerr.ErrBadTagAliasRule)
+		}
+	})
+}
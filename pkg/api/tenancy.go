@@ -0,0 +1,178 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/api/tenancy"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+func (c *Controller) InitTenants() error {
+	tenants, err := tenancy.New(c.Config.Storage.RootDirectory, c.Config.Tenants, c.Log)
+	if err != nil {
+		return err
+	}
+
+	c.Tenants = tenants
+
+	return nil
+}
+
+// TenancyHandler serves the virtual registries of the tenants. The requests reaching a tenant on one of its hosts
+// or under its path prefix are routed to its namespace, e.g. /v2/app/manifests/1.0 to /v2/acme/app/manifests/1.0,
+// and the namespace is removed from the Location and Link headers of the responses. It wraps the router since the
+// routes are matched on the rewritten path.
+func TenancyHandler(ctlr *Controller, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		tenant, tenantPath, ok := ctlr.Tenants.Resolve(request.Host, request.URL.Path)
+		if !ok {
+			next.ServeHTTP(response, request)
+
+			return
+		}
+
+		// only the distribution API is scoped to the tenants, the UI and the extensions are served on
+		// the main host only
+		if (tenantPath != constants.RoutePrefix && !strings.HasPrefix(tenantPath, constants.RoutePrefix+"/")) ||
+			strings.HasPrefix(tenantPath, constants.RoutePrefix+constants.ExtPrefix) {
+			response.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		tenantRequest := request.Clone(localCtx.WithTenant(request.Context(), tenant.Name))
+		tenantRequest.URL.Path = getTenantRoutePath(tenant, tenantPath)
+		tenantRequest.URL.RawPath = ""
+
+		tenantWr := &tenantWriter{
+			ResponseWriter: response,
+			tenant:         tenant,
+			prefix:         strings.TrimSuffix(request.URL.Path, tenantPath),
+		}
+
+		next.ServeHTTP(tenantWr, tenantRequest)
+
+		if tenantWr.status == 0 {
+			tenantWr.status = http.StatusOK
+		}
+
+		monitoring.IncTenantHTTPRequests(ctlr.Metrics, tenant.Name, request.Method, strconv.Itoa(tenantWr.status))
+	})
+}
+
+// getTenantRoutePath returns the path of a request for the virtual registry of a tenant, as routed by zot.
+func getTenantRoutePath(tenant tenancy.Tenant, tenantPath string) string {
+	rest, _ := strings.CutPrefix(tenantPath, constants.RoutePrefix+"/")
+
+	// the routes which don't name a repo
+	if rest == "" || tenantPath == constants.RoutePrefix || strings.HasPrefix(rest, "_") {
+		return tenantPath
+	}
+
+	return constants.RoutePrefix + "/" + tenant.Namespace() + rest
+}
+
+// getTenantRepoName returns the name of a repo as seen by the clients of the virtual registry the request is for.
+func getTenantRepoName(request *http.Request, name string) string {
+	tenant := localCtx.GetTenant(request.Context())
+	if tenant == "" {
+		return name
+	}
+
+	return strings.TrimPrefix(name, tenant+"/")
+}
+
+// getRouteRepoName returns the name zot stores a repo under, given its name as seen by the clients of the virtual
+// registry the request is for, e.g. in a query parameter.
+func (rh *RouteHandler) getRouteRepoName(request *http.Request, name string) string {
+	tenant, ok := rh.c.Tenants.Get(localCtx.GetTenant(request.Context()))
+	if !ok {
+		return name
+	}
+
+	return tenant.Namespace() + name
+}
+
+// tenantWriter makes the locations and links of the responses relative to the virtual registry of a tenant,
+// and sets the realm of the tenant in the basic auth challenges.
+type tenantWriter struct {
+	http.ResponseWriter
+	tenant tenancy.Tenant
+	// path prefix the virtual registry was reached under, empty if it was reached on one of its hosts
+	prefix string
+	status int
+}
+
+func (w *tenantWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.rewriteHeaders()
+	}
+
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *tenantWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.rewriteHeaders()
+
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// ReadFrom lets the underlying writer send files with sendfile.
+func (w *tenantWriter) ReadFrom(reader io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.rewriteHeaders()
+
+		w.status = http.StatusOK
+	}
+
+	return io.Copy(w.ResponseWriter, reader)
+}
+
+// Unwrap lets handlers flush streamed responses through the writer.
+func (w *tenantWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *tenantWriter) rewriteHeaders() {
+	header := w.Header()
+
+	for _, key := range []string{"Location", "Link"} {
+		if value := header.Get(key); value != "" {
+			header.Set(key, w.location(value))
+		}
+	}
+
+	challenge := header.Get("WWW-Authenticate")
+	if w.tenant.Realm != "" && strings.HasPrefix(strings.ToLower(challenge), "basic") {
+		header.Set("WWW-Authenticate", "Basic realm="+strconv.Quote(w.tenant.Realm))
+	}
+}
+
+// location returns a location generated by zot, e.g. https://registry.example.com/v2/acme/app/blobs/uploads/<id>,
+// relative to the virtual registry, e.g. /acme/v2/app/blobs/uploads/<id>, zot's external URL being the one of
+// the main host.
+func (w *tenantWriter) location(location string) string {
+	// the catalog links already list the repos relative to the tenant's namespace
+	if idx := strings.Index(location, constants.RoutePrefix+"/_catalog"); idx >= 0 {
+		return w.prefix + location[idx:]
+	}
+
+	repoPrefix := constants.RoutePrefix + "/" + w.tenant.Namespace()
+
+	idx := strings.Index(location, repoPrefix)
+	if idx < 0 {
+		return location
+	}
+
+	return w.prefix + constants.RoutePrefix + "/" + location[idx+len(repoPrefix):]
+}
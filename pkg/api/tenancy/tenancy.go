@@ -0,0 +1,396 @@
+package tenancy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/log"
+	zreg "zotregistry.io/zot/pkg/regexp"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+// FileName is the file, under the root directory of the default store, where the tenants added
+// through the API are kept.
+const FileName = "tenants.json"
+
+// Tenant is a virtual registry, see config.TenantConfig.
+type Tenant struct {
+	Name       string   `json:"name"`
+	Hosts      []string `json:"hosts,omitempty"`
+	PathPrefix string   `json:"pathPrefix,omitempty"`
+	Realm      string   `json:"realm,omitempty"`
+	MaxTags    int      `json:"maxTags,omitempty"`
+	MaxRepos   int      `json:"maxRepos,omitempty"`
+	// the tenant is defined in the config file, it can't be changed through the API
+	FromConfig bool      `json:"fromConfig,omitempty"`
+	AddedBy    string    `json:"addedBy,omitempty"`
+	AddedAt    time.Time `json:"addedAt,omitempty"`
+}
+
+type TenantList struct {
+	Tenants []Tenant `json:"tenants"`
+}
+
+// Namespace returns the prefix of the names of the tenant's repos.
+func (tenant Tenant) Namespace() string {
+	return tenant.Name + "/"
+}
+
+// HasQuota returns whether the limits of the tenant replace the quota config for its repos.
+func (tenant Tenant) HasQuota() bool {
+	return tenant.MaxTags != 0 || tenant.MaxRepos != 0
+}
+
+// FromConfig returns the tenant defined by a tenant config.
+func FromConfig(tenantConfig config.TenantConfig) Tenant {
+	return Tenant{
+		Name:       tenantConfig.Name,
+		Hosts:      tenantConfig.Hosts,
+		PathPrefix: tenantConfig.PathPrefix,
+		Realm:      tenantConfig.Realm,
+		MaxTags:    tenantConfig.MaxTags,
+		MaxRepos:   tenantConfig.MaxRepos,
+		FromConfig: true,
+	}
+}
+
+// Tenants holds the tenants of the config and the ones added through the API, the latter are persisted
+// so they survive restarts.
+type Tenants struct {
+	filePath string
+	tenants  map[string]Tenant
+	lock     *sync.RWMutex
+	log      log.Logger
+}
+
+// New loads the tenants of the config and the ones previously added through the API and saved under rootDir,
+// a saved tenant conflicting with the config is skipped.
+func New(rootDir string, configs []config.TenantConfig, log log.Logger) (*Tenants, error) {
+	tenants := &Tenants{
+		filePath: path.Join(rootDir, FileName),
+		tenants:  map[string]Tenant{},
+		lock:     &sync.RWMutex{},
+		log:      log,
+	}
+
+	if err := tenants.addConfigTenants(configs); err != nil {
+		return nil, err
+	}
+
+	buf, err := os.ReadFile(tenants.filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return tenants, nil
+		}
+
+		log.Error().Err(err).Str("file", tenants.filePath).Msg("tenancy: unable to read tenants")
+
+		return nil, err
+	}
+
+	var tenantList TenantList
+
+	if err := json.Unmarshal(buf, &tenantList); err != nil {
+		log.Error().Err(err).Str("file", tenants.filePath).Msg("tenancy: invalid JSON")
+
+		return nil, err
+	}
+
+	for _, tenant := range tenantList.Tenants {
+		tenant.FromConfig = false
+
+		if _, ok := tenants.tenants[tenant.Name]; ok {
+			log.Warn().Str("tenant", tenant.Name).
+				Msg("tenancy: tenant is defined in the config file, skipping the saved one")
+
+			continue
+		}
+
+		if err := tenants.validate(tenant); err != nil {
+			log.Warn().Err(err).Str("tenant", tenant.Name).Msg("tenancy: skipping saved tenant")
+
+			continue
+		}
+
+		tenants.tenants[tenant.Name] = tenant
+	}
+
+	return tenants, nil
+}
+
+// Validate checks the tenants of the config, e.g. before it's loaded.
+func Validate(configs []config.TenantConfig) error {
+	tenants := &Tenants{tenants: map[string]Tenant{}}
+
+	return tenants.addConfigTenants(configs)
+}
+
+// List returns all the tenants sorted by name.
+func (tenants *Tenants) List() []Tenant {
+	if tenants == nil {
+		return []Tenant{}
+	}
+
+	tenants.lock.RLock()
+	defer tenants.lock.RUnlock()
+
+	return tenants.list()
+}
+
+// Get returns a tenant by name.
+func (tenants *Tenants) Get(name string) (Tenant, bool) {
+	if tenants == nil {
+		return Tenant{}, false
+	}
+
+	tenants.lock.RLock()
+	defer tenants.lock.RUnlock()
+
+	tenant, ok := tenants.tenants[name]
+
+	return tenant, ok
+}
+
+// Add adds a tenant, or replaces the one with the same name, and saves the tenants added through the API.
+// The tenants of the config file can't be replaced.
+func (tenants *Tenants) Add(tenant Tenant) error {
+	tenant.FromConfig = false
+
+	tenants.lock.Lock()
+	defer tenants.lock.Unlock()
+
+	previous, existed := tenants.tenants[tenant.Name]
+	if existed && previous.FromConfig {
+		return zerr.ErrTenantFromConfig
+	}
+
+	if err := tenants.validate(tenant); err != nil {
+		return err
+	}
+
+	tenants.tenants[tenant.Name] = tenant
+
+	if err := tenants.save(); err != nil {
+		if existed {
+			tenants.tenants[tenant.Name] = previous
+		} else {
+			delete(tenants.tenants, tenant.Name)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Remove removes a tenant added through the API and saves the tenants, its repos are left in the storage.
+func (tenants *Tenants) Remove(name string) error {
+	tenants.lock.Lock()
+	defer tenants.lock.Unlock()
+
+	tenant, ok := tenants.tenants[name]
+	if !ok {
+		return zerr.ErrTenantNotFound
+	}
+
+	if tenant.FromConfig {
+		return zerr.ErrTenantFromConfig
+	}
+
+	delete(tenants.tenants, name)
+
+	if err := tenants.save(); err != nil {
+		tenants.tenants[name] = tenant
+
+		return err
+	}
+
+	return nil
+}
+
+// Resolve returns the tenant whose virtual registry a request is for, and the path of the request relative
+// to it, e.g. /v2/app/manifests/1.0 for a request to /acme/v2/app/manifests/1.0 if /acme is the path prefix
+// of the tenant. The host of the request is matched first.
+func (tenants *Tenants) Resolve(host, urlPath string) (Tenant, string, bool) {
+	if tenants == nil {
+		return Tenant{}, "", false
+	}
+
+	host = normalizeHost(host)
+
+	tenants.lock.RLock()
+	defer tenants.lock.RUnlock()
+
+	for _, tenant := range tenants.tenants {
+		for _, tenantHost := range tenant.Hosts {
+			if normalizeHost(tenantHost) == host {
+				return tenant, urlPath, true
+			}
+		}
+	}
+
+	for _, tenant := range tenants.tenants {
+		if tenant.PathPrefix == "" {
+			continue
+		}
+
+		tenantPath, found := strings.CutPrefix(urlPath, tenant.PathPrefix)
+		if found && (tenantPath == "/v2" || strings.HasPrefix(tenantPath, "/v2/")) {
+			return tenant, tenantPath, true
+		}
+	}
+
+	return Tenant{}, "", false
+}
+
+// OfRepo returns the tenant a repo belongs to, i.e. the tenant named like the namespace of the repo.
+func (tenants *Tenants) OfRepo(repo string) (Tenant, bool) {
+	namespace := zcommon.GetRepoNamespace(repo)
+	if namespace == "" {
+		return Tenant{}, false
+	}
+
+	return tenants.Get(namespace)
+}
+
+func (tenants *Tenants) addConfigTenants(configs []config.TenantConfig) error {
+	for _, tenantConfig := range configs {
+		tenant := FromConfig(tenantConfig)
+
+		if _, ok := tenants.tenants[tenant.Name]; ok {
+			return fmt.Errorf("%w: tenant %s is defined more than once", zerr.ErrBadTenant, tenant.Name)
+		}
+
+		if err := tenants.validate(tenant); err != nil {
+			return err
+		}
+
+		tenants.tenants[tenant.Name] = tenant
+	}
+
+	return nil
+}
+
+func (tenants *Tenants) validate(tenant Tenant) error {
+	if strings.Contains(tenant.Name, "/") || !zreg.FullNameRegexp.MatchString(tenant.Name) {
+		return fmt.Errorf("%w: name %q has to be a valid repository name component", zerr.ErrBadTenant, tenant.Name)
+	}
+
+	if len(tenant.Hosts) == 0 && tenant.PathPrefix == "" {
+		return fmt.Errorf("%w: tenant %s needs hosts or a path prefix", zerr.ErrBadTenant, tenant.Name)
+	}
+
+	if tenant.PathPrefix != "" && (!strings.HasPrefix(tenant.PathPrefix, "/") || tenant.PathPrefix == "/" ||
+		path.Clean(tenant.PathPrefix) != tenant.PathPrefix || tenant.PathPrefix == "/v2" ||
+		strings.HasPrefix(tenant.PathPrefix, "/v2/")) {
+		return fmt.Errorf("%w: tenant %s has an invalid path prefix %q", zerr.ErrBadTenant, tenant.Name,
+			tenant.PathPrefix)
+	}
+
+	for _, host := range tenant.Hosts {
+		if host == "" || strings.ContainsAny(host, "/?#@\\ ") {
+			return fmt.Errorf("%w: tenant %s has an invalid host %q", zerr.ErrBadTenant, tenant.Name, host)
+		}
+	}
+
+	if tenant.MaxTags < 0 || tenant.MaxRepos < 0 {
+		return fmt.Errorf("%w: tenant %s limits can not be negative", zerr.ErrBadTenant, tenant.Name)
+	}
+
+	// requests have to reach a single tenant
+	for _, other := range tenants.tenants {
+		if other.Name == tenant.Name {
+			continue
+		}
+
+		if tenant.PathPrefix != "" && tenant.PathPrefix == other.PathPrefix {
+			return fmt.Errorf("%w: tenants %s and %s have the same path prefix", zerr.ErrBadTenant, tenant.Name,
+				other.Name)
+		}
+
+		for _, host := range tenant.Hosts {
+			for _, otherHost := range other.Hosts {
+				if normalizeHost(host) == normalizeHost(otherHost) {
+					return fmt.Errorf("%w: tenants %s and %s have the same host %s", zerr.ErrBadTenant, tenant.Name,
+						other.Name, host)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (tenants *Tenants) list() []Tenant {
+	list := make([]Tenant, 0, len(tenants.tenants))
+
+	for _, tenant := range tenants.tenants {
+		list = append(list, tenant)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+func (tenants *Tenants) save() error {
+	// the tenants of the config file aren't saved
+	saved := []Tenant{}
+
+	for _, tenant := range tenants.list() {
+		if !tenant.FromConfig {
+			saved = append(saved, tenant)
+		}
+	}
+
+	buf, err := json.MarshalIndent(TenantList{Tenants: saved}, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	// with remote storage drivers the root directory may not exist locally
+	if err := os.MkdirAll(path.Dir(tenants.filePath), storageConstants.DefaultDirPerms); err != nil {
+		tenants.log.Error().Err(err).Str("file", tenants.filePath).Msg("tenancy: unable to create tenants dir")
+
+		return err
+	}
+
+	// write to a temporary file first so a crash doesn't leave truncated tenants behind
+	tmpFile := tenants.filePath + ".tmp"
+
+	if err := os.WriteFile(tmpFile, buf, storageConstants.DefaultFilePerms); err != nil {
+		tenants.log.Error().Err(err).Str("file", tmpFile).Msg("tenancy: unable to write tenants")
+
+		return err
+	}
+
+	if err := os.Rename(tmpFile, tenants.filePath); err != nil {
+		tenants.log.Error().Err(err).Str("file", tenants.filePath).Msg("tenancy: unable to write tenants")
+
+		return err
+	}
+
+	return nil
+}
+
+// normalizeHost returns the lowercase host name without the port and the trailing dot.
+func normalizeHost(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
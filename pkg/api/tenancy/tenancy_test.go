@@ -0,0 +1,161 @@
+package tenancy_test
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/tenancy"
+	"zotregistry.io/zot/pkg/log"
+)
+
+func TestTenants(t *testing.T) {
+	log := log.NewLogger("debug", "")
+
+	Convey("Add and remove tenants", t, func() {
+		rootDir := t.TempDir()
+
+		tenants, err := tenancy.New(rootDir, []config.TenantConfig{
+			{Name: "acme", Hosts: []string{"acme.example.com"}, QuotaLimits: config.QuotaLimits{MaxRepos: 2}},
+		}, log)
+		So(err, ShouldBeNil)
+
+		list := tenants.List()
+		So(len(list), ShouldEqual, 1)
+		So(list[0].FromConfig, ShouldBeTrue)
+		So(list[0].HasQuota(), ShouldBeTrue)
+
+		err = tenants.Add(tenancy.Tenant{Name: "globex", PathPrefix: "/globex"})
+		So(err, ShouldBeNil)
+
+		// the tenants of the config can't be changed
+		err = tenants.Add(tenancy.Tenant{Name: "acme", PathPrefix: "/acme"})
+		So(errors.Is(err, zerr.ErrTenantFromConfig), ShouldBeTrue)
+		So(errors.Is(tenants.Remove("acme"), zerr.ErrTenantFromConfig), ShouldBeTrue)
+
+		for _, tenant := range []tenancy.Tenant{
+			{Name: "Initech", PathPrefix: "/initech"},
+			{Name: "initech/dev", PathPrefix: "/initech"},
+			{Name: "initech"},
+			{Name: "initech", PathPrefix: "initech"},
+			{Name: "initech", PathPrefix: "/initech/"},
+			{Name: "initech", PathPrefix: "/v2"},
+			{Name: "initech", Hosts: []string{"initech.example.com/v2"}},
+			{Name: "initech", Hosts: []string{"ACME.example.com"}},
+			{Name: "initech", PathPrefix: "/globex"},
+			{Name: "initech", PathPrefix: "/initech", MaxTags: -1},
+		} {
+			err = tenants.Add(tenant)
+			So(errors.Is(err, zerr.ErrBadTenant), ShouldBeTrue)
+		}
+
+		// tenants are saved
+		tenants, err = tenancy.New(rootDir, nil, log)
+		So(err, ShouldBeNil)
+
+		list = tenants.List()
+		So(len(list), ShouldEqual, 1)
+		So(list[0].Name, ShouldEqual, "globex")
+		So(list[0].FromConfig, ShouldBeFalse)
+
+		So(tenants.Remove("globex"), ShouldBeNil)
+		So(errors.Is(tenants.Remove("globex"), zerr.ErrTenantNotFound), ShouldBeTrue)
+		So(tenants.List(), ShouldBeEmpty)
+	})
+
+	Convey("Saved tenants conflicting with the config are skipped", t, func() {
+		rootDir := t.TempDir()
+
+		tenants, err := tenancy.New(rootDir, nil, log)
+		So(err, ShouldBeNil)
+
+		So(tenants.Add(tenancy.Tenant{Name: "acme", PathPrefix: "/acme"}), ShouldBeNil)
+		So(tenants.Add(tenancy.Tenant{Name: "globex", Hosts: []string{"globex.example.com"}}), ShouldBeNil)
+
+		tenants, err = tenancy.New(rootDir, []config.TenantConfig{
+			{Name: "acme", Hosts: []string{"acme.example.com"}},
+			{Name: "initech", Hosts: []string{"globex.example.com"}},
+		}, log)
+		So(err, ShouldBeNil)
+
+		list := tenants.List()
+		So(len(list), ShouldEqual, 2)
+		So(list[0].Name, ShouldEqual, "acme")
+		So(list[0].FromConfig, ShouldBeTrue)
+		So(list[1].Name, ShouldEqual, "initech")
+	})
+
+	Convey("Invalid config tenants", t, func() {
+		_, err := tenancy.New(t.TempDir(), []config.TenantConfig{
+			{Name: "acme", Hosts: []string{"acme.example.com"}},
+			{Name: "acme", PathPrefix: "/acme"},
+		}, log)
+		So(errors.Is(err, zerr.ErrBadTenant), ShouldBeTrue)
+
+		_, err = tenancy.New(t.TempDir(), []config.TenantConfig{{Name: "acme"}}, log)
+		So(errors.Is(err, zerr.ErrBadTenant), ShouldBeTrue)
+	})
+
+	Convey("Invalid saved tenants", t, func() {
+		rootDir := t.TempDir()
+
+		err := os.WriteFile(path.Join(rootDir, tenancy.FileName), []byte("{"), 0o600)
+		So(err, ShouldBeNil)
+
+		_, err = tenancy.New(rootDir, nil, log)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Resolve the tenant of a request", t, func() {
+		tenants, err := tenancy.New(t.TempDir(), []config.TenantConfig{
+			{Name: "acme", Hosts: []string{"acme.example.com"}, PathPrefix: "/acme"},
+			{Name: "globex", PathPrefix: "/tenants/globex"},
+		}, log)
+		So(err, ShouldBeNil)
+
+		tenant, tenantPath, ok := tenants.Resolve("ACME.example.com:8080", "/v2/app/tags/list")
+		So(ok, ShouldBeTrue)
+		So(tenant.Name, ShouldEqual, "acme")
+		So(tenantPath, ShouldEqual, "/v2/app/tags/list")
+
+		tenant, tenantPath, ok = tenants.Resolve("registry.example.com", "/tenants/globex/v2/app/tags/list")
+		So(ok, ShouldBeTrue)
+		So(tenant.Name, ShouldEqual, "globex")
+		So(tenantPath, ShouldEqual, "/v2/app/tags/list")
+
+		tenant, tenantPath, ok = tenants.Resolve("registry.example.com", "/acme/v2")
+		So(ok, ShouldBeTrue)
+		So(tenant.Name, ShouldEqual, "acme")
+		So(tenantPath, ShouldEqual, "/v2")
+
+		_, _, ok = tenants.Resolve("registry.example.com", "/acmeco/v2/")
+		So(ok, ShouldBeFalse)
+
+		_, _, ok = tenants.Resolve("registry.example.com", "/v2/acme/app/tags/list")
+		So(ok, ShouldBeFalse)
+
+		tenant, ok = tenants.OfRepo("acme/app")
+		So(ok, ShouldBeTrue)
+		So(tenant.Namespace(), ShouldEqual, "acme/")
+
+		_, ok = tenants.OfRepo("acme")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Nil tenants", t, func() {
+		var tenants *tenancy.Tenants
+
+		So(tenants.List(), ShouldBeEmpty)
+
+		_, _, ok := tenants.Resolve("acme.example.com", "/v2/")
+		So(ok, ShouldBeFalse)
+
+		_, ok = tenants.OfRepo("acme/app")
+		So(ok, ShouldBeFalse)
+	})
+}
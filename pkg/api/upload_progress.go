@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/constants"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+const (
+	// how often the size of an upload is checked while its progress is streamed
+	uploadProgressInterval = time.Second
+	// a comment is sent when the size didn't change for this long, so proxies don't close the stream
+	uploadProgressKeepAlive = 15 * time.Second
+)
+
+// BlobUploadProgress is how many bytes of a blob upload session the registry received so far.
+type BlobUploadProgress struct {
+	SessionID     string `json:"sessionID"`
+	Repository    string `json:"repository"`
+	BytesReceived int64  `json:"bytesReceived"`
+}
+
+// acceptsMediaType checks if the Accept header lists a media type with a non zero quality, wildcards are
+// ignored so clients which didn't ask for it explicitly keep getting the response defined by the spec.
+func acceptsMediaType(accept, mediaType string) bool {
+	for _, accepted := range strings.Split(accept, ",") {
+		acceptedType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || acceptedType != mediaType {
+			continue
+		}
+
+		if value, ok := params["q"]; ok {
+			quality, err := strconv.ParseFloat(value, 64)
+
+			return err == nil && quality > 0
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// streamBlobUploadProgress sends the progress of an upload session as server-sent events: a progress
+// event each time the number of bytes received changes, then a done event once the session is gone,
+// i.e. the upload was completed or canceled. The stream ends with the done event or when the client
+// disconnects.
+func (rh *RouteHandler) streamBlobUploadProgress(response http.ResponseWriter, request *http.Request,
+	imgStore storageTypes.ImageStore, progress BlobUploadProgress,
+) {
+	controller := http.NewResponseController(response)
+
+	response.Header().Set("Content-Type", constants.EventStreamMediaType)
+	response.Header().Set("Cache-Control", "no-cache")
+	response.WriteHeader(http.StatusOK)
+
+	if !rh.writeUploadEvent(response, controller, "progress", progress) {
+		return
+	}
+
+	ticker := time.NewTicker(uploadProgressInterval)
+	defer ticker.Stop()
+
+	lastEvent := time.Now()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		size, err := imgStore.GetBlobUpload(progress.Repository, progress.SessionID)
+		if err != nil {
+			if !errors.Is(err, zerr.ErrUploadNotFound) {
+				rh.c.Log.Error().Err(err).Str("repository", progress.Repository).
+					Str("session_id", progress.SessionID).Msg("failed to get blob upload progress")
+			}
+
+			rh.writeUploadEvent(response, controller, "done", progress)
+
+			return
+		}
+
+		if size != progress.BytesReceived {
+			progress.BytesReceived = size
+			lastEvent = time.Now()
+
+			if !rh.writeUploadEvent(response, controller, "progress", progress) {
+				return
+			}
+
+			continue
+		}
+
+		if time.Since(lastEvent) >= uploadProgressKeepAlive {
+			lastEvent = time.Now()
+
+			if _, err := fmt.Fprint(response, ": keep-alive\n\n"); err != nil || controller.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeUploadEvent sends an event right away, it returns false if the client is gone.
+func (rh *RouteHandler) writeUploadEvent(response http.ResponseWriter, controller *http.ResponseController,
+	event string, progress BlobUploadProgress,
+) bool {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		rh.c.Log.Error().Err(err).Msg("failed to marshal blob upload progress")
+
+		return false
+	}
+
+	if _, err := fmt.Fprintf(response, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return false
+	}
+
+	if err := controller.Flush(); err != nil {
+		rh.c.Log.Debug().Err(err).Msg("failed to flush blob upload progress")
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,214 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+)
+
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenDuration     = 30 * time.Second
+)
+
+// State of a circuit, its value is the one reported by the circuit breaker state metric.
+type State int
+
+const (
+	// calls go through
+	StateClosed State = iota
+	// a single call goes through, its result closes or opens the circuit again
+	StateHalfOpen
+	// calls are rejected
+	StateOpen
+)
+
+func (state State) String() string {
+	switch state {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+/*
+Breaker stops calling a dependency, e.g. repodb or an upstream registry, once it failed several times in a
+row, so the callers degrade right away instead of waiting on it: calls are rejected with zerr.ErrCircuitOpen
+while the circuit is open, then a single call is let through to check if the dependency recovered.
+
+A nil Breaker lets every call through.
+*/
+type Breaker struct {
+	name             string
+	failureThreshold int
+	openDuration     time.Duration
+	// errors which don't tell anything about the health of the dependency, e.g. not found errors
+	ignored  []error
+	state    State
+	failures int
+	openedAt time.Time
+	lock     sync.Mutex
+	metrics  monitoring.MetricServer
+	log      log.Logger
+}
+
+// New creates a closed circuit breaker, errors matching one of ignored aren't counted as failures.
+func New(name string, config config.CircuitBreakerConfig, metrics monitoring.MetricServer, log log.Logger,
+	ignored ...error,
+) *Breaker {
+	breaker := &Breaker{
+		name:             name,
+		failureThreshold: config.FailureThreshold,
+		openDuration:     config.OpenDuration,
+		ignored:          ignored,
+		metrics:          metrics,
+		log:              log,
+	}
+
+	if breaker.failureThreshold <= 0 {
+		breaker.failureThreshold = DefaultFailureThreshold
+	}
+
+	if breaker.openDuration <= 0 {
+		breaker.openDuration = DefaultOpenDuration
+	}
+
+	monitoring.SetCircuitBreakerState(metrics, name, int(StateClosed))
+
+	return breaker
+}
+
+// Name returns the name of the breaker, i.e. of the dependency it protects.
+func (breaker *Breaker) Name() string {
+	if breaker == nil {
+		return ""
+	}
+
+	return breaker.name
+}
+
+// State returns the state of the circuit.
+func (breaker *Breaker) State() State {
+	if breaker == nil {
+		return StateClosed
+	}
+
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	return breaker.state
+}
+
+// Do calls fn unless the circuit is open, and records its result.
+func (breaker *Breaker) Do(fn func() error) error {
+	done, err := breaker.Allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+
+	done(err)
+
+	return err
+}
+
+// Allow returns zerr.ErrCircuitOpen if the call has to be rejected, otherwise the caller makes the call and
+// passes its result to done.
+func (breaker *Breaker) Allow() (func(error), error) {
+	if breaker == nil {
+		return func(error) {}, nil
+	}
+
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	switch breaker.state {
+	case StateOpen:
+		if time.Since(breaker.openedAt) < breaker.openDuration {
+			return nil, breaker.reject()
+		}
+
+		// let this call check if the dependency recovered
+		breaker.setState(StateHalfOpen)
+	case StateHalfOpen:
+		// a call is already checking if the dependency recovered
+		return nil, breaker.reject()
+	case StateClosed:
+	}
+
+	return breaker.done, nil
+}
+
+func (breaker *Breaker) done(err error) {
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	if !breaker.isFailure(err) {
+		breaker.failures = 0
+
+		if breaker.state != StateClosed {
+			breaker.log.Info().Str("breaker", breaker.name).Msg("dependency recovered, closing the circuit")
+
+			breaker.setState(StateClosed)
+		}
+
+		return
+	}
+
+	switch breaker.state {
+	case StateHalfOpen:
+		breaker.open(err)
+	case StateClosed:
+		breaker.failures++
+
+		if breaker.failures >= breaker.failureThreshold {
+			breaker.open(err)
+		}
+	case StateOpen:
+		// a call made before the circuit opened
+	}
+}
+
+func (breaker *Breaker) open(err error) {
+	breaker.log.Warn().Err(err).Str("breaker", breaker.name).Int("failures", breaker.failures).
+		Dur("openDuration", breaker.openDuration).Msg("dependency keeps failing, opening the circuit")
+
+	breaker.openedAt = time.Now()
+	breaker.setState(StateOpen)
+}
+
+func (breaker *Breaker) setState(state State) {
+	breaker.state = state
+
+	monitoring.SetCircuitBreakerState(breaker.metrics, breaker.name, int(state))
+}
+
+func (breaker *Breaker) reject() error {
+	monitoring.IncCircuitBreakerRejections(breaker.metrics, breaker.name)
+
+	return fmt.Errorf("%w: %s", zerr.ErrCircuitOpen, breaker.name)
+}
+
+func (breaker *Breaker) isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, ignored := range breaker.ignored {
+		if errors.Is(err, ignored) {
+			return false
+		}
+	}
+
+	return true
+}
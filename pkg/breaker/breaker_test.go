@@ -0,0 +1,105 @@
+package breaker_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/breaker"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+)
+
+var errDependency = errors.New("dependency failed")
+
+func TestBreaker(t *testing.T) {
+	log := log.NewLogger("debug", "")
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	Convey("The circuit opens after consecutive failures and closes once the dependency recovers", t, func() {
+		circuit := breaker.New("test", config.CircuitBreakerConfig{
+			FailureThreshold: 2,
+			OpenDuration:     50 * time.Millisecond,
+		}, metrics, log)
+		So(circuit.Name(), ShouldEqual, "test")
+		So(circuit.State(), ShouldEqual, breaker.StateClosed)
+
+		failing := func() error { return errDependency }
+		calls := 0
+		succeeding := func() error {
+			calls++
+
+			return nil
+		}
+
+		So(circuit.Do(failing), ShouldEqual, errDependency)
+		So(circuit.State(), ShouldEqual, breaker.StateClosed)
+
+		// a success resets the failures
+		So(circuit.Do(succeeding), ShouldBeNil)
+		So(circuit.Do(failing), ShouldEqual, errDependency)
+		So(circuit.State(), ShouldEqual, breaker.StateClosed)
+
+		So(circuit.Do(failing), ShouldEqual, errDependency)
+		So(circuit.State(), ShouldEqual, breaker.StateOpen)
+		So(circuit.State().String(), ShouldEqual, "open")
+
+		err := circuit.Do(succeeding)
+		So(errors.Is(err, zerr.ErrCircuitOpen), ShouldBeTrue)
+		So(calls, ShouldEqual, 1)
+
+		time.Sleep(60 * time.Millisecond)
+
+		// a single call checks if the dependency recovered
+		done, err := circuit.Allow()
+		So(err, ShouldBeNil)
+		So(circuit.State(), ShouldEqual, breaker.StateHalfOpen)
+
+		_, err = circuit.Allow()
+		So(errors.Is(err, zerr.ErrCircuitOpen), ShouldBeTrue)
+
+		Convey("The circuit opens again if the dependency still fails", func() {
+			done(errDependency)
+			So(circuit.State(), ShouldEqual, breaker.StateOpen)
+
+			err := circuit.Do(succeeding)
+			So(errors.Is(err, zerr.ErrCircuitOpen), ShouldBeTrue)
+		})
+
+		Convey("The circuit closes if the dependency recovered", func() {
+			done(nil)
+			So(circuit.State(), ShouldEqual, breaker.StateClosed)
+			So(circuit.State().String(), ShouldEqual, "closed")
+
+			So(circuit.Do(succeeding), ShouldBeNil)
+			So(calls, ShouldEqual, 2)
+		})
+	})
+
+	Convey("Ignored errors aren't failures", t, func() {
+		circuit := breaker.New("test", config.CircuitBreakerConfig{FailureThreshold: 1}, metrics, log,
+			zerr.ErrManifestNotFound)
+
+		err := circuit.Do(func() error { return zerr.ErrManifestNotFound })
+		So(errors.Is(err, zerr.ErrManifestNotFound), ShouldBeTrue)
+		So(circuit.State(), ShouldEqual, breaker.StateClosed)
+
+		So(circuit.Do(func() error { return errDependency }), ShouldEqual, errDependency)
+		So(circuit.State(), ShouldEqual, breaker.StateOpen)
+	})
+
+	Convey("A nil breaker lets every call through", t, func() {
+		var circuit *breaker.Breaker
+
+		for i := 0; i < 10; i++ {
+			So(circuit.Do(func() error { return errDependency }), ShouldEqual, errDependency)
+		}
+
+		So(circuit.State(), ShouldEqual, breaker.StateClosed)
+		So(circuit.Name(), ShouldBeEmpty)
+	})
+}
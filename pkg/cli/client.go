@@ -214,22 +214,30 @@ func (p *requestsPool) doJob(ctx context.Context, job *httpJob) {
 		if isContextDone(ctx) {
 			return
 		}
-		p.outputCh <- stringResult{"", err}
+		p.outputCh <- stringResult{Err: err}
 	}
 
 	verbose := *job.config.verbose
 
 	switch header.Get("Content-Type") {
-	case ispec.MediaTypeImageManifest:
+	case ispec.MediaTypeImageManifest, common.MediaTypeDockerManifest:
 		image, err := fetchImageManifestStruct(ctx, job)
 		if err != nil {
 			if isContextDone(ctx) {
 				return
 			}
-			p.outputCh <- stringResult{"", err}
+			p.outputCh <- stringResult{Err: err}
 
 			return
 		}
+		if collectsResults(job.config) {
+			if !isContextDone(ctx) {
+				p.outputCh <- stringResult{Value: *image}
+			}
+
+			return
+		}
+
 		platformStr := getPlatformStr(image.Manifests[0].Platform)
 
 		str, err := image.string(*job.config.outputFormat, len(job.imageName), len(job.tagName), len(platformStr), verbose)
@@ -237,7 +245,7 @@ func (p *requestsPool) doJob(ctx context.Context, job *httpJob) {
 			if isContextDone(ctx) {
 				return
 			}
-			p.outputCh <- stringResult{"", err}
+			p.outputCh <- stringResult{Err: err}
 
 			return
 		}
@@ -246,14 +254,22 @@ func (p *requestsPool) doJob(ctx context.Context, job *httpJob) {
 			return
 		}
 
-		p.outputCh <- stringResult{str, nil}
-	case ispec.MediaTypeImageIndex:
+		p.outputCh <- stringResult{StrValue: str}
+	case ispec.MediaTypeImageIndex, common.MediaTypeDockerManifestList:
 		image, err := fetchImageIndexStruct(ctx, job)
 		if err != nil {
 			if isContextDone(ctx) {
 				return
 			}
-			p.outputCh <- stringResult{"", err}
+			p.outputCh <- stringResult{Err: err}
+
+			return
+		}
+
+		if collectsResults(job.config) {
+			if !isContextDone(ctx) {
+				p.outputCh <- stringResult{Value: *image}
+			}
 
 			return
 		}
@@ -265,7 +281,7 @@ func (p *requestsPool) doJob(ctx context.Context, job *httpJob) {
 			if isContextDone(ctx) {
 				return
 			}
-			p.outputCh <- stringResult{"", err}
+			p.outputCh <- stringResult{Err: err}
 
 			return
 		}
@@ -274,7 +290,7 @@ func (p *requestsPool) doJob(ctx context.Context, job *httpJob) {
 			return
 		}
 
-		p.outputCh <- stringResult{str, nil}
+		p.outputCh <- stringResult{StrValue: str}
 	default:
 		return
 	}
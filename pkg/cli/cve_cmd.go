@@ -23,7 +23,7 @@ const (
 func NewCveCommand(searchService SearchService) *cobra.Command {
 	searchCveParams := make(map[string]*string)
 
-	var servURL, user, outputFormat string
+	var servURL, user, outputFormat, filter, columns string
 
 	var isSpinner, verifyTLS, fixedFlag, verbose, debug bool
 
@@ -90,6 +90,8 @@ func NewCveCommand(searchService SearchService) *cobra.Command {
 				verifyTLS:     &verifyTLS,
 				verbose:       &verbose,
 				debug:         &debug,
+				filter:        &filter,
+				columns:       &columns,
 				resultWriter:  cmd.OutOrStdout(),
 				spinner:       spinnerState{spin, isSpinner},
 			}
@@ -116,6 +118,7 @@ func NewCveCommand(searchService SearchService) *cobra.Command {
 	}
 
 	setupCveFlags(cveCmd, vars)
+	setupOutputFilterFlags(cveCmd, &filter, &columns)
 
 	return cveCmd
 }
@@ -128,7 +131,7 @@ func setupCveFlags(cveCmd *cobra.Command, variables cveFlagVariables) {
 	cveCmd.Flags().StringVar(variables.servURL, "url", "", "Specify zot server URL if config-name is not mentioned")
 	cveCmd.Flags().StringVarP(variables.user, "user", "u", "", `User Credentials of `+
 		`zot server in USERNAME:PASSWORD format`)
-	cveCmd.Flags().StringVarP(variables.outputFormat, "output", "o", "", "Specify output format [text/json/yaml]."+
+	cveCmd.Flags().StringVarP(variables.outputFormat, "output", "o", "", "Specify output format [text/table/json/yaml]."+
 		" JSON and YAML format return all info for CVEs")
 
 	cveCmd.Flags().BoolVar(variables.fixedFlag, "fixed", false, "List tags which have fixed a CVE")
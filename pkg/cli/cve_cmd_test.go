@@ -258,6 +258,28 @@ func TestSearchCVECmd(t *testing.T) {
 			`- name: packagename installedversion: installedver fixedversion: fixedver`)
 		So(err, ShouldBeNil)
 	})
+	Convey("Test CVE by image name - table, columns and filter", t, func() {
+		runCveCmd := func(args ...string) string {
+			configPath := makeConfigFile(`{"configs":[{"_name":"cvetest","showspinner":false}]}`)
+			defer os.Remove(configPath)
+			cveCmd := NewCveCommand(new(mockService))
+			buff := bytes.NewBufferString("")
+			cveCmd.SetOut(buff)
+			cveCmd.SetErr(buff)
+			cveCmd.SetArgs(append([]string{"cvetest", "--image", "dummyImageName:tag", "--url", "someURL"}, args...))
+			So(cveCmd.Execute(), ShouldBeNil)
+			space := regexp.MustCompile(`\s+`)
+
+			return strings.TrimSpace(space.ReplaceAllString(buff.String(), " "))
+		}
+
+		So(runCveCmd("-o", "table"), ShouldEqual, "ID SEVERITY TITLE dummyCVEID HIGH Title of that CVE")
+		So(runCveCmd("--columns", "Id,PackageList.Name"), ShouldEqual, "ID PACKAGELIST.NAME dummyCVEID packagename")
+		So(runCveCmd("--filter", `.Severity == "HIGH"`), ShouldEqual,
+			"ID SEVERITY TITLE dummyCVEID HIGH Title of that CVE")
+		So(runCveCmd("--filter", `.Severity == "LOW"`), ShouldEqual, "No CVEs found for image")
+	})
+
 	Convey("Test CVE by image name - invalid format", t, func() {
 		args := []string{"cvetest", "--image", "dummyImageName:tag", "--url", "someURL", "-o", "random"}
 		configPath := makeConfigFile(`{"configs":[{"_name":"cvetest","showspinner":false}]}`)
@@ -1289,7 +1311,7 @@ func (service *mockServiceForRetry) getImagesByCveID(ctx context.Context, config
 	service.retryCounter += 1
 
 	if service.retryCounter < service.succeedOn || service.succeedOn < 0 {
-		rch <- stringResult{"", zotErrors.ErrCVEDBNotFound}
+		rch <- stringResult{Err: zotErrors.ErrCVEDBNotFound}
 		close(rch)
 
 		wtgrp.Done()
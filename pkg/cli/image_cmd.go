@@ -19,7 +19,7 @@ import (
 func NewImageCommand(searchService SearchService) *cobra.Command {
 	searchImageParams := make(map[string]*string)
 
-	var servURL, user, outputFormat string
+	var servURL, user, outputFormat, filter, columns string
 
 	var isSpinner, verifyTLS, verbose, debug bool
 
@@ -81,6 +81,8 @@ func NewImageCommand(searchService SearchService) *cobra.Command {
 				outputFormat:  &outputFormat,
 				verbose:       &verbose,
 				debug:         &debug,
+				filter:        &filter,
+				columns:       &columns,
 				spinner:       spinnerState{spin, isSpinner},
 				verifyTLS:     &verifyTLS,
 				resultWriter:  cmd.OutOrStdout(),
@@ -99,6 +101,7 @@ func NewImageCommand(searchService SearchService) *cobra.Command {
 	}
 
 	setupImageFlags(imageCmd, searchImageParams, &servURL, &user, &outputFormat, &verbose, &debug)
+	setupOutputFilterFlags(imageCmd, &filter, &columns)
 	imageCmd.SetUsageTemplate(imageCmd.UsageTemplate() + usageFooter)
 
 	return imageCmd
@@ -131,7 +134,7 @@ func setupImageFlags(imageCmd *cobra.Command, searchImageParams map[string]*stri
 
 	imageCmd.Flags().StringVar(servURL, "url", "", "Specify zot server URL if config-name is not mentioned")
 	imageCmd.Flags().StringVarP(user, "user", "u", "", `User Credentials of zot server in "username:password" format`)
-	imageCmd.Flags().StringVarP(outputFormat, "output", "o", "", "Specify output format [text/json/yaml]")
+	imageCmd.Flags().StringVarP(outputFormat, "output", "o", "", "Specify output format [text/table/json/yaml]")
 	imageCmd.Flags().BoolVar(verbose, "verbose", false, "Show verbose output")
 	imageCmd.Flags().BoolVar(debug, "debug", false, "Show debug output")
 }
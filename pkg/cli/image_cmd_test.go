@@ -739,6 +739,31 @@ func TestOutputFormat(t *testing.T) {
 		So(err, ShouldBeNil)
 	})
 
+	Convey("Test table, columns and filter", t, func() {
+		runImageCmd := func(args ...string) string {
+			configPath := makeConfigFile(`{"configs":[{"_name":"imagetest","url":"https://test-url.com","showspinner":false}]}`)
+			defer os.Remove(configPath)
+			cmd := NewImageCommand(new(mockService))
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(append([]string{"imagetest", "--name", "dummyImageName"}, args...))
+			So(cmd.Execute(), ShouldBeNil)
+			space := regexp.MustCompile(`\s+`)
+
+			return strings.TrimSpace(space.ReplaceAllString(buff.String(), " "))
+		}
+
+		So(runImageCmd("-o", "table"), ShouldEqual,
+			"REPONAME TAG DIGEST SIZE ISSIGNED dummyImageName tag "+
+				"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08 123445 false")
+		So(runImageCmd("--columns", "RepoName,Tag"), ShouldEqual, "REPONAME TAG dummyImageName tag")
+		So(runImageCmd("-o", "json", "--columns", "Tag"), ShouldEqual, `{ "Tag": "tag" }`)
+		So(runImageCmd("--filter", `.Tag == "tag"`), ShouldEqual,
+			"IMAGE NAME TAG OS/ARCH DIGEST SIGNED SIZE dummyImageName tag os/arch 6e2f80bf false 123kB")
+		So(runImageCmd("--filter", `.Tag == "other"`), ShouldBeEmpty)
+	})
+
 	//  get image config functia
 
 	Convey("Test json", t, func() {
@@ -1707,7 +1732,7 @@ func (service mockService) getRepos(ctx context.Context, config searchConfig, us
 	catalog[1] = "busybox"
 	catalog[2] = "hello-world"
 
-	channel <- stringResult{"", nil}
+	channel <- stringResult{}
 }
 
 func (service mockService) getReferrers(ctx context.Context, config searchConfig, username, password string,
@@ -1975,14 +2000,20 @@ func (service mockService) getAllImages(ctx context.Context, config searchConfig
 	}
 	image.Size = "123445"
 
+	if collectsResults(config) {
+		channel <- stringResult{Value: *image}
+
+		return
+	}
+
 	str, err := image.string(*config.outputFormat, len(image.RepoName), len(image.Tag), len("os/Arch"), *config.verbose)
 	if err != nil {
-		channel <- stringResult{"", err}
+		channel <- stringResult{Err: err}
 
 		return
 	}
 
-	channel <- stringResult{str, nil}
+	channel <- stringResult{StrValue: str}
 }
 
 func (service mockService) getImageByName(ctx context.Context, config searchConfig,
@@ -2007,14 +2038,20 @@ func (service mockService) getImageByName(ctx context.Context, config searchConf
 	}
 	image.Size = "123445"
 
+	if collectsResults(config) {
+		channel <- stringResult{Value: *image}
+
+		return
+	}
+
 	str, err := image.string(*config.outputFormat, len(image.RepoName), len(image.Tag), len("os/Arch"), *config.verbose)
 	if err != nil {
-		channel <- stringResult{"", err}
+		channel <- stringResult{Err: err}
 
 		return
 	}
 
-	channel <- stringResult{str, nil}
+	channel <- stringResult{StrValue: str}
 }
 
 func (service mockService) getCveByImage(ctx context.Context, config searchConfig, username, password,
@@ -2045,14 +2082,20 @@ func (service mockService) getCveByImage(ctx context.Context, config searchConfi
 		},
 	}
 
+	if collectsResults(config) {
+		rch <- stringResult{Value: *cveRes}
+
+		return
+	}
+
 	str, err := cveRes.string(*config.outputFormat)
 	if err != nil {
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
 
-	rch <- stringResult{str, nil}
+	rch <- stringResult{StrValue: str}
 }
 
 func (service mockService) getFixedTagsForCVE(ctx context.Context, config searchConfig,
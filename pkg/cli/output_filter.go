@@ -0,0 +1,579 @@
+//go:build search
+// +build search
+
+package cli
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	zotErrors "zotregistry.io/zot/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
+)
+
+const (
+	tableFormat = "table"
+
+	filterAnd = "and"
+	filterOr  = "or"
+)
+
+var (
+	defaultImageColumns    = []string{"RepoName", "Tag", "Digest", "Size", "IsSigned"} //nolint:gochecknoglobals
+	defaultRepoColumns     = []string{"Name", "Size", "LastUpdated", "DownloadCount"}  //nolint:gochecknoglobals
+	defaultReferrerColumns = []string{"ArtifactType", "Digest", "Size"}                //nolint:gochecknoglobals
+	defaultCVEColumns      = []string{"Id", "Severity", "Title"}                       //nolint:gochecknoglobals
+	filterOperators        = []string{"==", "!=", ">=", "<=", "=~", ">", "<"}          //nolint:gochecknoglobals
+)
+
+// resultFilter is a client-side predicate evaluated on the JSON representation of a search result.
+// The syntax is a small jq-like subset: `.Path op value` conditions joined by `and`/`or`,
+// e.g. `.Tag =~ "^v1" and .IsSigned == true`. A path traversing a list matches if any element matches.
+type resultFilter struct {
+	// disjunction of conjunctions: the filter matches if all conditions of any group match
+	groups [][]filterCondition
+}
+
+type filterCondition struct {
+	path     []string
+	operator string
+	value    string
+	regex    *regexp.Regexp
+}
+
+func setupOutputFilterFlags(cmd *cobra.Command, filter, columns *string) {
+	cmd.Flags().StringVar(filter, "filter", "",
+		`Only show results matching the expression, e.g. '.Tag =~ "^v1" and .IsSigned == true'`)
+	cmd.Flags().StringVar(columns, "columns", "",
+		"Comma separated list of fields to show, e.g. 'RepoName,Tag,Manifests.Platform.Os'")
+}
+
+func parseResultFilter(expression string) (*resultFilter, error) {
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil //nolint:nilnil // an empty expression means no filtering
+	}
+
+	filter := &resultFilter{groups: [][]filterCondition{{}}}
+
+	for idx := 0; idx < len(tokens); {
+		token := tokens[idx]
+
+		if !strings.HasPrefix(token, ".") {
+			return nil, fmt.Errorf("%w: expected a path starting with '.', got %q", zotErrors.ErrInvalidFilter, token)
+		}
+
+		condition := filterCondition{path: splitFilterPath(token)}
+		idx++
+
+		if idx+1 < len(tokens) && zcommon.Contains(filterOperators, tokens[idx]) {
+			condition.operator = tokens[idx]
+			condition.value = tokens[idx+1]
+			idx += 2
+
+			if condition.operator == "=~" {
+				condition.regex, err = regexp.Compile(condition.value)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s", zotErrors.ErrInvalidFilter, err.Error())
+				}
+			}
+		} else if idx < len(tokens) && zcommon.Contains(filterOperators, tokens[idx]) {
+			return nil, fmt.Errorf("%w: missing value after %q", zotErrors.ErrInvalidFilter, tokens[idx])
+		}
+
+		lastGroup := len(filter.groups) - 1
+		filter.groups[lastGroup] = append(filter.groups[lastGroup], condition)
+
+		if idx == len(tokens) {
+			break
+		}
+
+		switch strings.ToLower(tokens[idx]) {
+		case filterAnd:
+		case filterOr:
+			filter.groups = append(filter.groups, []filterCondition{})
+		default:
+			return nil, fmt.Errorf("%w: expected 'and' or 'or', got %q", zotErrors.ErrInvalidFilter, tokens[idx])
+		}
+
+		idx++
+
+		if idx == len(tokens) {
+			return nil, fmt.Errorf("%w: dangling %q", zotErrors.ErrInvalidFilter, tokens[idx-1])
+		}
+	}
+
+	return filter, nil
+}
+
+func tokenizeFilter(expression string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(expression)
+
+	for idx := 0; idx < len(runes); {
+		char := runes[idx]
+
+		switch {
+		case char == ' ' || char == '\t':
+			idx++
+		case char == '"':
+			end := idx + 1
+			for ; end < len(runes) && runes[end] != '"'; end++ {
+				if runes[end] == '\\' {
+					end++
+				}
+			}
+
+			if end >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated string", zotErrors.ErrInvalidFilter)
+			}
+
+			value, err := strconv.Unquote(string(runes[idx : end+1]))
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", zotErrors.ErrInvalidFilter, err.Error())
+			}
+
+			tokens = append(tokens, value)
+			idx = end + 1
+		case strings.ContainsRune("=!<>~", char):
+			end := idx
+			for end < len(runes) && strings.ContainsRune("=!<>~", runes[end]) {
+				end++
+			}
+
+			tokens = append(tokens, string(runes[idx:end]))
+			idx = end
+		default:
+			end := idx
+			for end < len(runes) && !strings.ContainsRune(" \t\"=!<>~", runes[end]) {
+				end++
+			}
+
+			tokens = append(tokens, string(runes[idx:end]))
+			idx = end
+		}
+	}
+
+	return tokens, nil
+}
+
+func splitFilterPath(path string) []string {
+	segments := []string{}
+
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		segment = strings.TrimSuffix(segment, "[]")
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return segments
+}
+
+func (filter *resultFilter) matches(result interface{}) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+
+	generic, err := toGenericValue(result)
+	if err != nil {
+		return false, err
+	}
+
+	for _, group := range filter.groups {
+		groupMatches := true
+
+		for _, condition := range group {
+			if !condition.matches(resolveFilterPath(generic, condition.path)) {
+				groupMatches = false
+
+				break
+			}
+		}
+
+		if groupMatches {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (condition filterCondition) matches(values []interface{}) bool {
+	if condition.operator == "!=" {
+		return !filterCondition{path: condition.path, operator: "==", value: condition.value}.matches(values)
+	}
+
+	for _, value := range values {
+		if condition.matchesValue(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (condition filterCondition) matchesValue(value interface{}) bool {
+	if condition.operator == "" {
+		return isTruthy(value)
+	}
+
+	str := valueToString(value)
+
+	if condition.operator == "=~" {
+		return condition.regex.MatchString(str)
+	}
+
+	left, leftErr := strconv.ParseFloat(str, 64)
+	right, rightErr := strconv.ParseFloat(condition.value, 64)
+	numeric := leftErr == nil && rightErr == nil
+
+	switch condition.operator {
+	case "==":
+		if numeric {
+			return left == right
+		}
+
+		return str == condition.value
+	case ">":
+		if numeric {
+			return left > right
+		}
+
+		return str > condition.value
+	case ">=":
+		if numeric {
+			return left >= right
+		}
+
+		return str >= condition.value
+	case "<":
+		if numeric {
+			return left < right
+		}
+
+		return str < condition.value
+	case "<=":
+		if numeric {
+			return left <= right
+		}
+
+		return str <= condition.value
+	}
+
+	return false
+}
+
+func isTruthy(value interface{}) bool {
+	switch typed := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return typed
+	case float64:
+		return typed != 0
+	case string:
+		return typed != ""
+	case []interface{}:
+		return len(typed) > 0
+	case map[string]interface{}:
+		return len(typed) > 0
+	}
+
+	return true
+}
+
+func valueToString(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case []interface{}, map[string]interface{}:
+		json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+		body, err := json.Marshal(typed)
+		if err != nil {
+			return ""
+		}
+
+		return string(body)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// toGenericValue converts a result into the structure obtained by decoding its JSON representation.
+func toGenericValue(result interface{}) (interface{}, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+
+	if err := json.Unmarshal(body, &generic); err != nil {
+		return nil, err
+	}
+
+	return generic, nil
+}
+
+// resolveFilterPath returns all values found at the given path, fanning out over lists.
+// Keys are matched case-insensitively so that both `.RepoName` and `.repoName` work.
+func resolveFilterPath(value interface{}, path []string) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		results := []interface{}{}
+
+		for _, elem := range list {
+			results = append(results, resolveFilterPath(elem, path)...)
+		}
+
+		return results
+	}
+
+	if len(path) == 0 {
+		return []interface{}{value}
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return []interface{}{}
+	}
+
+	for key, child := range object {
+		if strings.EqualFold(key, path[0]) {
+			return resolveFilterPath(child, path[1:])
+		}
+	}
+
+	return []interface{}{}
+}
+
+func parseColumns(columns string) []string {
+	result := []string{}
+
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimPrefix(strings.TrimSpace(column), ".")
+		if column != "" {
+			result = append(result, column)
+		}
+	}
+
+	return result
+}
+
+func columnValue(generic interface{}, column string) string {
+	values := resolveFilterPath(generic, splitFilterPath(column))
+
+	strValues := make([]string, 0, len(values))
+	for _, value := range values {
+		strValues = append(strValues, valueToString(value))
+	}
+
+	return strings.Join(strValues, ",")
+}
+
+// selectColumns keeps only the requested columns of a result, keyed by the column name as given.
+func selectColumns(result interface{}, columns []string) (yaml.MapSlice, error) {
+	generic, err := toGenericValue(result)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := yaml.MapSlice{}
+
+	for _, column := range columns {
+		values := resolveFilterPath(generic, splitFilterPath(column))
+
+		var value interface{}
+
+		switch len(values) {
+		case 0:
+			value = nil
+		case 1:
+			value = values[0]
+		default:
+			value = values
+		}
+
+		selected = append(selected, yaml.MapItem{Key: column, Value: value})
+	}
+
+	return selected, nil
+}
+
+func getResultFilter(config searchConfig) (*resultFilter, error) {
+	if config.filter == nil {
+		return nil, nil //nolint:nilnil // no filter was requested
+	}
+
+	return parseResultFilter(*config.filter)
+}
+
+func getColumns(config searchConfig, defaultColumns []string) []string {
+	if config.columns == nil || *config.columns == "" {
+		return defaultColumns
+	}
+
+	return parseColumns(*config.columns)
+}
+
+// useSelectedColumns is true if the output is restricted to a set of columns,
+// either explicitly or by requesting the generic table format.
+func useSelectedColumns(config searchConfig) bool {
+	return (config.columns != nil && *config.columns != "") ||
+		strings.ToLower(*config.outputFormat) == tableFormat
+}
+
+// collectsResults returns whether the results of the searchers streaming them must all be received
+// before being printed, to filter them or to lay out their columns.
+func collectsResults(config searchConfig) bool {
+	return useSelectedColumns(config) || (config.filter != nil && *config.filter != "")
+}
+
+// filterResults returns the results matching the filter expression given on the command line, if any.
+func filterResults[T any](config searchConfig, results []T) ([]T, error) {
+	filter, err := getResultFilter(config)
+	if err != nil || filter == nil {
+		return results, err
+	}
+
+	filtered := make([]T, 0, len(results))
+
+	for _, result := range results {
+		ok, err := filter.matches(result)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			filtered = append(filtered, result)
+		}
+	}
+
+	return filtered, nil
+}
+
+// printSelectedColumns prints the results as a table, or as json/yaml objects,
+// containing only the selected columns.
+func printSelectedColumns[T any](config searchConfig, results []T, defaultColumns []string) error {
+	columns := getColumns(config, defaultColumns)
+
+	switch strings.ToLower(*config.outputFormat) {
+	case "", defaultOutoutFormat, tableFormat:
+		return printColumnsTable(config.resultWriter, results, columns)
+	}
+
+	for _, result := range results {
+		selected, err := selectColumns(result, columns)
+		if err != nil {
+			return err
+		}
+
+		var out string
+
+		switch strings.ToLower(*config.outputFormat) {
+		case jsonFormat:
+			out, err = mapSliceToJSON(selected)
+		case ymlFormat, yamlFormat:
+			var body []byte
+
+			body, err = yaml.Marshal(selected)
+			out = string(body)
+		default:
+			return ErrInvalidOutputFormat
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(config.resultWriter, out)
+	}
+
+	return nil
+}
+
+// mapSliceToJSON encodes the selected columns as a JSON object, preserving the column order.
+func mapSliceToJSON(selected yaml.MapSlice) (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	compact := bytes.NewBufferString("{")
+
+	for idx, item := range selected {
+		if idx > 0 {
+			compact.WriteString(",")
+		}
+
+		key, err := json.Marshal(item.Key)
+		if err != nil {
+			return "", err
+		}
+
+		value, err := json.Marshal(item.Value)
+		if err != nil {
+			return "", err
+		}
+
+		compact.Write(key)
+		compact.WriteString(":")
+		compact.Write(value)
+	}
+
+	compact.WriteString("}")
+
+	var indented bytes.Buffer
+
+	if err := stdjson.Indent(&indented, compact.Bytes(), "", "  "); err != nil {
+		return "", err
+	}
+
+	return indented.String(), nil
+}
+
+func printColumnsTable[T any](writer io.Writer, results []T, columns []string) error {
+	table := getImageTableWriter(writer)
+
+	header := make([]string, len(columns))
+	for idx, column := range columns {
+		header[idx] = strings.ToUpper(column)
+	}
+
+	table.Append(header)
+
+	for _, result := range results {
+		generic, err := toGenericValue(result)
+		if err != nil {
+			return err
+		}
+
+		row := make([]string, len(columns))
+		for idx, column := range columns {
+			row[idx] = columnValue(generic, column)
+		}
+
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
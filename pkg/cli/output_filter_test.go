@@ -0,0 +1,135 @@
+//go:build search
+// +build search
+
+package cli //nolint:testpackage
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zotErrors "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/common"
+)
+
+func TestOutputFilter(t *testing.T) {
+	images := []imageStruct{
+		{
+			RepoName: "repo1", Tag: "v1.0", Digest: "sha256:1111", Size: "1200", IsSigned: true,
+			Manifests: []common.ManifestSummary{{Platform: common.Platform{Os: "linux", Arch: "amd64"}}},
+		},
+		{
+			RepoName: "repo1", Tag: "latest", Digest: "sha256:2222", Size: "900", IsSigned: false,
+			Manifests: []common.ManifestSummary{
+				{Platform: common.Platform{Os: "linux", Arch: "arm64"}},
+				{Platform: common.Platform{Os: "windows", Arch: "amd64"}},
+			},
+		},
+		{
+			RepoName: "repo2", Tag: "v2.0", Digest: "sha256:3333", Size: "30", IsSigned: false,
+			Manifests: []common.ManifestSummary{{Platform: common.Platform{Os: "linux", Arch: "amd64"}}},
+		},
+	}
+
+	getConfig := func(output, filter, columns string) (searchConfig, *bytes.Buffer) {
+		buff := &bytes.Buffer{}
+
+		return searchConfig{
+			outputFormat: ref(output),
+			verbose:      ref(false),
+			filter:       ref(filter),
+			columns:      ref(columns),
+			resultWriter: buff,
+		}, buff
+	}
+
+	Convey("Filter expressions", t, func() {
+		testCases := []struct {
+			expression string
+			tags       []string
+		}{
+			{``, []string{"v1.0", "latest", "v2.0"}},
+			{`.IsSigned`, []string{"v1.0"}},
+			{`.isSigned == false`, []string{"latest", "v2.0"}},
+			{`.Tag =~ "^v"`, []string{"v1.0", "v2.0"}},
+			{`.RepoName == repo1 and .Size > 1000`, []string{"v1.0"}},
+			{`.Size<100 or .Tag=="latest"`, []string{"latest", "v2.0"}},
+			{`.Manifests[].Platform.Os == "windows"`, []string{"latest"}},
+			{`.Manifests.Platform.Os != "windows"`, []string{"v1.0", "v2.0"}},
+		}
+
+		for _, testCase := range testCases {
+			config, _ := getConfig("", testCase.expression, "")
+
+			filtered, err := filterResults(config, images)
+			So(err, ShouldBeNil)
+
+			tags := []string{}
+			for _, image := range filtered {
+				tags = append(tags, image.Tag)
+			}
+
+			So(tags, ShouldResemble, testCase.tags)
+		}
+	})
+
+	Convey("Invalid filter expressions", t, func() {
+		for _, expression := range []string{
+			`Tag == "v1"`,
+			`.Tag ==`,
+			`.Tag == "v1`,
+			`.Tag == v1 and`,
+			`.Tag == v1 xor .Size > 1`,
+			`.Tag =~ "(("`,
+		} {
+			config, _ := getConfig("", expression, "")
+
+			_, err := filterResults(config, images)
+			So(errors.Is(err, zotErrors.ErrInvalidFilter), ShouldBeTrue)
+		}
+	})
+
+	Convey("Table output with selected columns", t, func() {
+		config, buff := getConfig("table", `.Tag == "latest"`, "RepoName,Tag,Manifests.Platform.Arch")
+
+		err := printImageResult(config, images)
+		So(err, ShouldBeNil)
+
+		lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+		So(len(lines), ShouldEqual, 2)
+		So(strings.Fields(lines[0]), ShouldResemble, []string{"REPONAME", "TAG", "MANIFESTS.PLATFORM.ARCH"})
+		So(strings.Fields(lines[1]), ShouldResemble, []string{"repo1", "latest", "arm64,amd64"})
+	})
+
+	Convey("Table output with default columns", t, func() {
+		config, buff := getConfig("table", "", "")
+
+		err := printRepoResults(config, []repoStruct{{Name: "repo1", Size: "100", DownloadCount: 3}})
+		So(err, ShouldBeNil)
+		So(buff.String(), ShouldContainSubstring, "NAME")
+		So(buff.String(), ShouldContainSubstring, "DOWNLOADCOUNT")
+		So(buff.String(), ShouldContainSubstring, "repo1")
+	})
+
+	Convey("JSON and YAML output with selected columns", t, func() {
+		config, buff := getConfig("json", `.RepoName == repo2`, "RepoName,Size")
+
+		err := printImageResult(config, images)
+		So(err, ShouldBeNil)
+		So(buff.String(), ShouldEqual, "{\n  \"RepoName\": \"repo2\",\n  \"Size\": \"30\"\n}")
+
+		config, buff = getConfig("yaml", `.RepoName == repo2`, "RepoName,Size")
+
+		err = printImageResult(config, images)
+		So(err, ShouldBeNil)
+		So(buff.String(), ShouldEqual, "RepoName: repo2\nSize: \"30\"\n")
+
+		config, _ = getConfig("xml", "", "RepoName")
+
+		err = printImageResult(config, images)
+		So(err, ShouldEqual, ErrInvalidOutputFormat)
+	})
+}
@@ -2,11 +2,15 @@ package cli
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
+	"mime"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,17 +18,32 @@ import (
 	glob "github.com/bmatcuk/doublestar/v4"
 	"github.com/mitchellh/mapstructure"
 	distspec "github.com/opencontainers/distribution-spec/specs-go"
+	godigest "github.com/opencontainers/go-digest"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/api"
+	"zotregistry.io/zot/pkg/api/blobpolicy"
 	"zotregistry.io/zot/pkg/api/config"
 	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/api/downloads"
+	"zotregistry.io/zot/pkg/api/hooks"
+	"zotregistry.io/zot/pkg/api/immutabletags"
+	"zotregistry.io/zot/pkg/api/promotion"
+	"zotregistry.io/zot/pkg/api/pulltoken"
+	"zotregistry.io/zot/pkg/api/tagalias"
+	"zotregistry.io/zot/pkg/api/tenancy"
+	"zotregistry.io/zot/pkg/extensions/audit"
 	extconf "zotregistry.io/zot/pkg/extensions/config"
 	"zotregistry.io/zot/pkg/extensions/monitoring"
+	zlog "zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage"
 	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/recompress"
+	"zotregistry.io/zot/pkg/storage/referrerstags"
+	"zotregistry.io/zot/pkg/storage/retention"
 	"zotregistry.io/zot/pkg/storage/s3"
 )
 
@@ -95,19 +114,7 @@ func newScrubCmd(conf *config.Config) *cobra.Command {
 				return
 			}
 
-			// checking if the server is  already running
-			req, err := http.NewRequestWithContext(context.Background(),
-				http.MethodGet,
-				fmt.Sprintf("http://%s/v2", net.JoinHostPort(conf.HTTP.Address, conf.HTTP.Port)),
-				nil)
-			if err != nil {
-				log.Error().Err(err).Msg("unable to create a new http request")
-				panic(err)
-			}
-
-			response, err := http.DefaultClient.Do(req)
-			if err == nil {
-				response.Body.Close()
+			if isServerRunning(conf) {
 				log.Warn().Msg("The server is running, in order to perform the scrub command the server should be shut down")
 				panic("Error: server is running")
 			} else {
@@ -132,6 +139,80 @@ func newScrubCmd(conf *config.Config) *cobra.Command {
 	return scrubCmd
 }
 
+func newMigrateCmd(conf *config.Config) *cobra.Command {
+	dryRun := false
+
+	// "migrate"
+	migrateCmd := &cobra.Command{
+		Use:     "migrate <config>",
+		Aliases: []string{"migrate"},
+		Short:   "`migrate` migrates the storage layout to the current version",
+		Long: "`migrate` migrates the storage layout to the current version, as done when the server starts, " +
+			"with --dry-run the pending migrations are listed and nothing is changed",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				if err := LoadConfiguration(conf, args[0]); err != nil {
+					panic(err)
+				}
+			} else {
+				if err := cmd.Usage(); err != nil {
+					panic(err)
+				}
+
+				return
+			}
+
+			// a dry run only reads the layout versions, the server can be running
+			if !dryRun && isServerRunning(conf) {
+				log.Warn().Msg("The server is running, in order to perform the migrate command the server should be shut down")
+				panic("Error: server is running")
+			}
+
+			ctlr := api.NewController(conf)
+			ctlr.Metrics = monitoring.NewMetricsServer(false, ctlr.Log)
+
+			if err := ctlr.InitImageStore(); err != nil {
+				panic(err)
+			}
+
+			reports, err := storage.MigrateLayout(ctlr.StoreController, storage.Migrations, dryRun, ctlr.Log)
+
+			storage.PrintMigrationReports(reports, dryRun, cmd.OutOrStdout())
+
+			if err != nil {
+				panic(err)
+			}
+		},
+	}
+
+	migrateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the pending migrations without applying them")
+
+	return migrateCmd
+}
+
+// isServerRunning checks if a zot server is listening on the address of the config.
+func isServerRunning(conf *config.Config) bool {
+	listener := conf.HTTP.GetListeners()[0]
+
+	req, err := http.NewRequestWithContext(context.Background(),
+		http.MethodGet,
+		fmt.Sprintf("http://%s/v2", net.JoinHostPort(listener.Address, listener.Port)),
+		nil)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to create a new http request")
+		panic(err)
+	}
+
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+
+	response.Body.Close()
+
+	return true
+}
+
 func newVerifyCmd(conf *config.Config) *cobra.Command {
 	// verify
 	verifyCmd := &cobra.Command{
@@ -143,6 +224,14 @@ func newVerifyCmd(conf *config.Config) *cobra.Command {
 			if len(args) > 0 {
 				if err := LoadConfiguration(conf, args[0]); err != nil {
 					log.Error().Str("config", args[0]).Msg("Config file is invalid")
+
+					// list every problem found, one per line
+					fmt.Fprintf(cmd.ErrOrStderr(), "config %s is invalid:\n", args[0])
+
+					for _, problem := range strings.Split(err.Error(), "\n") {
+						fmt.Fprintf(cmd.ErrOrStderr(), "  - %s\n", problem)
+					}
+
 					panic(err)
 				}
 
@@ -180,6 +269,8 @@ func NewServerRootCmd() *cobra.Command {
 	rootCmd.AddCommand(newVerifyCmd(conf))
 	// "scrub"
 	rootCmd.AddCommand(newScrubCmd(conf))
+	// "migrate"
+	rootCmd.AddCommand(newMigrateCmd(conf))
 	// "version"
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "show the version and exit")
 
@@ -222,7 +313,7 @@ func validateStorageConfig(cfg *config.Config) error {
 		if strings.EqualFold(defaultRootDir, storageConfig.RootDirectory) {
 			log.Error().Err(errors.ErrBadConfig).Msg("storage subpaths cannot use default storage root directory")
 
-			return errors.ErrBadConfig
+			return fmt.Errorf("%w: storage subpaths cannot use default storage root directory", errors.ErrBadConfig)
 		}
 
 		expConfig, ok := expConfigMap[storageConfig.RootDirectory]
@@ -231,7 +322,8 @@ func validateStorageConfig(cfg *config.Config) error {
 			if !equal {
 				log.Error().Err(errors.ErrBadConfig).Msg("storage config with same root directory should have same parameters")
 
-				return errors.ErrBadConfig
+				return fmt.Errorf("%w: storage config with same root directory should have same "+
+					"parameters", errors.ErrBadConfig)
 			}
 		} else {
 			expConfigMap[storageConfig.RootDirectory] = storageConfig
@@ -242,70 +334,103 @@ func validateStorageConfig(cfg *config.Config) error {
 }
 
 func validateCacheConfig(cfg *config.Config) error {
-	// global
-	// dedupe true, remote storage, remoteCache true, but no cacheDriver (remote)
-	//nolint: lll
-	if cfg.Storage.Dedupe && cfg.Storage.StorageDriver != nil && cfg.Storage.RemoteCache && cfg.Storage.CacheDriver == nil {
-		log.Error().Err(errors.ErrBadConfig).Msg(
-			"dedupe set to true with remote storage and caching, but no remote cache configured!")
+	if err := validateStorageCache(cfg.Storage.StorageConfig); err != nil {
+		return err
+	}
+
+	for _, subPath := range cfg.Storage.SubPaths {
+		if err := validateStorageCache(subPath); err != nil {
+			return err
+		}
+	}
+
+	return validateCacheMaintenance(cfg)
+}
 
-		return errors.ErrBadConfig
+// validateStorageCache checks the cache config of the default store or of a substore.
+func validateStorageCache(storageConfig config.StorageConfig) error {
+	// dedupe true, remote storage, remoteCache true, but no cacheDriver (remote)
+	if storageConfig.Dedupe && storageConfig.StorageDriver != nil && storageConfig.RemoteCache &&
+		storageConfig.CacheDriver == nil {
+		log.Error().Err(errors.ErrBadConfig).Str("directory", storageConfig.RootDirectory).
+			Msg("dedupe with remote storage and remoteCache requires a remote cacheDriver, configure one or set " +
+				"remoteCache to false")
+
+		return fmt.Errorf("%w: dedupe with remote storage and remoteCache requires a remote cacheDriver, "+
+			"configure one or set remoteCache to false", errors.ErrBadConfig)
 	}
 
-	if cfg.Storage.CacheDriver != nil && cfg.Storage.RemoteCache {
+	if storageConfig.CacheDriver != nil && storageConfig.RemoteCache {
 		// local storage with remote caching
-		if cfg.Storage.StorageDriver == nil {
-			log.Error().Err(errors.ErrBadConfig).Msg("cannot have local storage driver with remote caching!")
+		if storageConfig.StorageDriver == nil {
+			log.Error().Err(errors.ErrBadConfig).Str("directory", storageConfig.RootDirectory).
+				Msg("remote caching requires a remote storage driver, set remoteCache to false")
 
-			return errors.ErrBadConfig
+			return fmt.Errorf("%w: remote caching requires a remote storage driver, set remoteCache to false",
+				errors.ErrBadConfig)
 		}
 
 		// unsupported cache driver
-		if cfg.Storage.CacheDriver["name"] != storageConstants.DynamoDBDriverName {
-			log.Error().Err(errors.ErrBadConfig).
-				Interface("cacheDriver", cfg.Storage.CacheDriver["name"]).Msg("unsupported cache driver")
+		if !isRemoteCacheDriver(storageConfig.CacheDriver["name"]) {
+			log.Error().Err(errors.ErrBadConfig).Str("directory", storageConfig.RootDirectory).
+				Interface("cacheDriver", storageConfig.CacheDriver["name"]).Msg("unsupported cache driver")
 
-			return errors.ErrBadConfig
+			return fmt.Errorf("%w: unsupported cache driver %v", errors.ErrBadConfig, storageConfig.CacheDriver["name"])
 		}
 	}
 
-	if !cfg.Storage.RemoteCache && cfg.Storage.CacheDriver != nil {
-		log.Warn().Err(errors.ErrBadConfig).Str("directory", cfg.Storage.RootDirectory).
-			Msg("remoteCache set to false but cacheDriver config (remote caching) provided for directory" +
+	if !storageConfig.RemoteCache && storageConfig.CacheDriver != nil {
+		log.Warn().Err(errors.ErrBadConfig).Str("directory", storageConfig.RootDirectory).
+			Msg("remoteCache set to false but cacheDriver config (remote caching) provided for directory, " +
 				"will ignore and use local caching")
 	}
 
-	// subpaths
-	for _, subPath := range cfg.Storage.SubPaths {
-		// dedupe true, remote storage, remoteCache true, but no cacheDriver (remote)
-		//nolint: lll
-		if subPath.Dedupe && subPath.StorageDriver != nil && subPath.RemoteCache && subPath.CacheDriver == nil {
-			log.Error().Err(errors.ErrBadConfig).Msg("dedupe set to true with remote storage and caching, but no remote cache configured!")
+	return nil
+}
+
+func validateCacheMaintenance(cfg *config.Config) error {
+	storageConfigs := map[string]config.StorageConfig{"": cfg.Storage.StorageConfig}
+	for route, subPath := range cfg.Storage.SubPaths {
+		storageConfigs[route] = subPath
+	}
+
+	for route, storageConfig := range storageConfigs {
+		if storageConfig.CacheMaintenanceInterval < 0 {
+			log.Error().Err(errors.ErrBadConfig).Str("subPath", route).
+				Dur("interval", storageConfig.CacheMaintenanceInterval).
+				Msg("invalid cache maintenance interval specified")
 
-			return errors.ErrBadConfig
+			return fmt.Errorf("%w: invalid cache maintenance interval specified", errors.ErrBadConfig)
 		}
 
-		if subPath.CacheDriver != nil && subPath.RemoteCache {
-			// local storage with remote caching
-			if subPath.StorageDriver == nil {
-				log.Error().Err(errors.ErrBadConfig).Msg("cannot have local storage driver with remote caching!")
+		if storageConfig.CacheMaintenanceInterval != 0 && storageConfig.RemoteCache {
+			log.Warn().Err(errors.ErrBadConfig).Str("subPath", route).
+				Msg("cache maintenance interval specified with remote caching, will be ignored")
+		}
+	}
 
-				return errors.ErrBadConfig
-			}
+	return nil
+}
 
-			// unsupported cache driver
-			if subPath.CacheDriver["name"] != storageConstants.DynamoDBDriverName {
-				log.Error().Err(errors.ErrBadConfig).Interface("cacheDriver", cfg.Storage.CacheDriver["name"]).
-					Msg("unsupported cache driver")
+// validateLint checks the actions of the lint config and of its rules, and that the rules have an annotation.
+func validateLint(lintConfig *extconf.LintConfig) error {
+	actions := []string{lintConfig.Action}
 
-				return errors.ErrBadConfig
-			}
+	for idx, rule := range lintConfig.Rules {
+		if rule.MandatoryAnnotation == "" {
+			log.Warn().Err(errors.ErrBadConfig).Int("rule", idx).Msg("lint rule must have a mandatory annotation")
+
+			return fmt.Errorf("%w: lint rule %d must have a mandatory annotation", errors.ErrBadConfig, idx)
 		}
 
-		if !subPath.RemoteCache && subPath.CacheDriver != nil {
-			log.Warn().Err(errors.ErrBadConfig).Str("directory", cfg.Storage.RootDirectory).
-				Msg("remoteCache set to false but cacheDriver config (remote caching) provided for directory," +
-					"will ignore and use local caching")
+		actions = append(actions, rule.Action)
+	}
+
+	for _, action := range actions {
+		if action != "" && action != extconf.LintActionReject && action != extconf.LintActionWarn {
+			log.Warn().Err(errors.ErrBadConfig).Str("action", action).Msg("lint action must be either reject or warn")
+
+			return fmt.Errorf("%w: lint action must be either reject or warn", errors.ErrBadConfig)
 		}
 	}
 
@@ -315,134 +440,475 @@ func validateCacheConfig(cfg *config.Config) error {
 func validateExtensionsConfig(cfg *config.Config) error {
 	if cfg.Extensions != nil && cfg.Extensions.UI != nil && cfg.Extensions.UI.Enable != nil && *cfg.Extensions.UI.Enable {
 		if cfg.Extensions.Mgmt == nil || !*cfg.Extensions.Mgmt.Enable {
-			log.Warn().Err(errors.ErrBadConfig).Msg("UI functionality can't be used without mgmt extension.")
+			log.Warn().Err(errors.ErrBadConfig).Msg("UI functionality can't be used without mgmt extension")
+
+			return fmt.Errorf("%w: UI functionality can't be used without mgmt extension", errors.ErrBadConfig)
+		}
 
-			return errors.ErrBadConfig
+		if cfg.Extensions.Search == nil || !*cfg.Extensions.Search.Enable {
+			log.Warn().Err(errors.ErrBadConfig).Msg("UI functionality can't be used without search extension")
+
+			return fmt.Errorf("%w: UI functionality can't be used without search extension", errors.ErrBadConfig)
+		}
+
+		if err := validateUIConfig(cfg.Extensions.UI); err != nil {
+			return err
 		}
+	}
 
+	if cfg.Extensions != nil && cfg.Extensions.UserActivity != nil && cfg.Extensions.UserActivity.Enable != nil &&
+		*cfg.Extensions.UserActivity.Enable {
 		if cfg.Extensions.Search == nil || !*cfg.Extensions.Search.Enable {
-			log.Warn().Err(errors.ErrBadConfig).Msg("UI functionality can't be used without search extension.")
+			log.Warn().Err(errors.ErrBadConfig).Msg("user activity can't be tracked without search extension")
+
+			return fmt.Errorf("%w: user activity can't be tracked without search extension", errors.ErrBadConfig)
+		}
+
+		if cfg.Extensions.UserActivity.MaxEntries < 0 {
+			log.Warn().Err(errors.ErrBadConfig).Int("maxEntries", cfg.Extensions.UserActivity.MaxEntries).
+				Msg("user activity maxEntries can not be negative")
+
+			return fmt.Errorf("%w: user activity maxEntries can not be negative", errors.ErrBadConfig)
+		}
+	}
+
+	if cfg.Extensions != nil && cfg.Extensions.Events != nil {
+		if cfg.Extensions.Events.Retention < 0 || cfg.Extensions.Events.MaxEventsPerRepo < 0 {
+			log.Warn().Err(errors.ErrBadConfig).Dur("retention", cfg.Extensions.Events.Retention).
+				Int("maxEventsPerRepo", cfg.Extensions.Events.MaxEventsPerRepo).
+				Msg("events retention and maxEventsPerRepo can not be negative")
+
+			return fmt.Errorf("%w: events retention and maxEventsPerRepo can not be negative", errors.ErrBadConfig)
+		}
+	}
+
+	if cfg.Extensions != nil && cfg.Extensions.Search != nil && cfg.Extensions.Search.Enable != nil &&
+		*cfg.Extensions.Search.Enable && cfg.Extensions.Search.CVE != nil && hasRemoteStorage(cfg) {
+		log.Warn().Err(errors.ErrBadConfig).Msg("CVE functionality can't be used with remote storage, disable CVE")
+
+		return fmt.Errorf("%w: CVE functionality can't be used with remote storage, disable CVE", errors.ErrBadConfig)
+	}
+
+	if cfg.Extensions != nil && cfg.Extensions.Search != nil && cfg.Extensions.Search.Limits != nil {
+		limits := cfg.Extensions.Search.Limits
+
+		if limits.MaxDepth < 0 || limits.MaxComplexity < 0 || limits.MaxResultSize < 0 {
+			log.Warn().Err(errors.ErrBadConfig).Int("maxDepth", limits.MaxDepth).
+				Int("maxComplexity", limits.MaxComplexity).Int("maxResultSize", limits.MaxResultSize).
+				Msg("search limits can not be negative")
+
+			return fmt.Errorf("%w: search limits can not be negative", errors.ErrBadConfig)
+		}
+	}
+
+	if cfg.Extensions != nil && cfg.Extensions.Lint != nil {
+		if err := validateLint(cfg.Extensions.Lint); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Extensions != nil && cfg.Extensions.Search != nil && cfg.Extensions.Search.MinDigestPrefixLength < 0 {
+		log.Warn().Err(errors.ErrBadConfig).Int("minDigestPrefixLength", cfg.Extensions.Search.MinDigestPrefixLength).
+			Msg("search minDigestPrefixLength can not be negative")
+
+		return fmt.Errorf("%w: search minDigestPrefixLength can not be negative", errors.ErrBadConfig)
+	}
+
+	if cfg.Extensions != nil && cfg.Extensions.Search != nil && cfg.Extensions.Search.CVE != nil &&
+		cfg.Extensions.Search.CVE.MaxConcurrentScans < 0 {
+		log.Warn().Err(errors.ErrBadConfig).Int("maxConcurrentScans", cfg.Extensions.Search.CVE.MaxConcurrentScans).
+			Msg("CVE maxConcurrentScans can not be negative")
+
+		return fmt.Errorf("%w: CVE maxConcurrentScans can not be negative", errors.ErrBadConfig)
+	}
+
+	if cfg.Extensions != nil && cfg.Extensions.Search != nil && cfg.Extensions.Search.CVE != nil &&
+		cfg.Extensions.Search.CVE.Trivy != nil && cfg.Extensions.Search.CVE.Trivy.JavaDBPath != "" {
+		javaDBPath := cfg.Extensions.Search.CVE.Trivy.JavaDBPath
+
+		if fileInfo, err := os.Stat(javaDBPath); err != nil || !fileInfo.IsDir() {
+			log.Warn().Err(errors.ErrBadConfig).Str("javaDBPath", javaDBPath).
+				Msg("trivy javaDBPath must be an existing directory")
+
+			return fmt.Errorf("%w: trivy javaDBPath must be an existing directory", errors.ErrBadConfig)
+		}
+	}
+
+	if cfg.Extensions != nil && cfg.Extensions.Search != nil && cfg.Extensions.Search.CVE != nil &&
+		cfg.Extensions.Search.CVE.Report != nil {
+		report := cfg.Extensions.Search.CVE.Report
+
+		if report.Interval < 0 {
+			log.Warn().Err(errors.ErrBadConfig).Str("interval", report.Interval.String()).
+				Msg("CVE report interval can not be negative")
+
+			return fmt.Errorf("%w: CVE report interval can not be negative", errors.ErrBadConfig)
+		}
+
+		if report.Webhook != "" {
+			webhookURL, err := url.Parse(report.Webhook)
+			if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+				log.Warn().Err(errors.ErrBadConfig).Str("webhook", report.Webhook).
+					Msg("CVE report webhook must be an http or https URL")
+
+				return fmt.Errorf("%w: CVE report webhook must be an http or https URL", errors.ErrBadConfig)
+			}
+		}
+	}
 
-			return errors.ErrBadConfig
+	if cfg.Extensions != nil && cfg.Extensions.Telemetry != nil {
+		telemetry := cfg.Extensions.Telemetry
+
+		if telemetry.Interval < 0 {
+			log.Warn().Err(errors.ErrBadConfig).Str("interval", telemetry.Interval.String()).
+				Msg("telemetry interval can not be negative")
+
+			return fmt.Errorf("%w: telemetry interval can not be negative", errors.ErrBadConfig)
+		}
+
+		if telemetry.Enable != nil && *telemetry.Enable {
+			endpointURL, err := url.Parse(telemetry.Endpoint)
+			if err != nil || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") || endpointURL.Host == "" {
+				log.Warn().Err(errors.ErrBadConfig).Str("endpoint", telemetry.Endpoint).
+					Msg("telemetry endpoint must be an http or https URL")
+
+				return fmt.Errorf("%w: telemetry endpoint must be an http or https URL", errors.ErrBadConfig)
+			}
+		}
+	}
+
+	if cfg.Extensions != nil {
+		pluginNames := map[string]bool{}
+
+		for _, pluginConfig := range cfg.Extensions.Plugins {
+			if pluginConfig.Name == "" || pluginConfig.Path == "" {
+				log.Warn().Err(errors.ErrBadConfig).Str("plugin", pluginConfig.Name).Str("path", pluginConfig.Path).
+					Msg("plugins must have a name and a path")
+
+				return fmt.Errorf("%w: plugins must have a name and a path", errors.ErrBadConfig)
+			}
+
+			if pluginNames[pluginConfig.Name] {
+				log.Warn().Err(errors.ErrBadConfig).Str("plugin", pluginConfig.Name).Msg("plugin names must be unique")
+
+				return fmt.Errorf("%w: plugin names must be unique", errors.ErrBadConfig)
+			}
+
+			pluginNames[pluginConfig.Name] = true
 		}
 	}
 
-	//nolint:lll
-	if cfg.Storage.StorageDriver != nil && cfg.Extensions != nil && cfg.Extensions.Search != nil &&
-		cfg.Extensions.Search.Enable != nil && *cfg.Extensions.Search.Enable && cfg.Extensions.Search.CVE != nil {
-		log.Warn().Err(errors.ErrBadConfig).Msg("CVE functionality can't be used with remote storage. Please disable CVE")
+	return nil
+}
 
-		return errors.ErrBadConfig
+// hasRemoteStorage returns true if the default store or one of the substores uses a remote storage driver.
+func hasRemoteStorage(cfg *config.Config) bool {
+	if cfg.Storage.StorageDriver != nil {
+		return true
 	}
 
 	for _, subPath := range cfg.Storage.SubPaths {
-		//nolint:lll
-		if subPath.StorageDriver != nil && cfg.Extensions != nil && cfg.Extensions.Search != nil &&
-			cfg.Extensions.Search.Enable != nil && *cfg.Extensions.Search.Enable && cfg.Extensions.Search.CVE != nil {
-			log.Warn().Err(errors.ErrBadConfig).Msg("CVE functionality can't be used with remote storage. Please disable CVE")
+		if subPath.StorageDriver != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateUIConfig(uiConfig *extconf.UIConfig) error {
+	basePath := strings.TrimSuffix(uiConfig.BasePath, "/")
+
+	if basePath != "" && (!strings.HasPrefix(basePath, "/") || path.Clean(basePath) != basePath ||
+		strings.HasPrefix(basePath+"/", "/v2/")) {
+		log.Error().Err(errors.ErrBadConfig).Str("basePath", uiConfig.BasePath).
+			Msg("UI base path must be an absolute and clean URL path, outside of /v2")
 
-			return errors.ErrBadConfig
+		return fmt.Errorf("%w: UI base path must be an absolute and clean URL path, outside of "+
+			"/v2", errors.ErrBadConfig)
+	}
+
+	if uiConfig.LogoPath != "" {
+		if _, err := os.Stat(uiConfig.LogoPath); err != nil {
+			log.Error().Err(errors.ErrBadConfig).Str("logoPath", uiConfig.LogoPath).
+				Msg("UI logo file can't be read")
+
+			return fmt.Errorf("%w: UI logo file can't be read", errors.ErrBadConfig)
 		}
 	}
 
+	if uiConfig.CacheMaxAge < 0 {
+		log.Error().Err(errors.ErrBadConfig).Dur("cacheMaxAge", uiConfig.CacheMaxAge).
+			Msg("UI cache max age can't be negative")
+
+		return fmt.Errorf("%w: UI cache max age can't be negative", errors.ErrBadConfig)
+	}
+
 	return nil
 }
 
-func validateConfiguration(config *config.Config) error {
-	if err := validateHTTP(config); err != nil {
-		return err
+func validateConfiguration(cfg *config.Config) error {
+	validateConsistencyCheck(cfg)
+
+	// every check is run so all the problems of the config are reported at once, instead of one per attempt
+	validators := []func(*config.Config) error{
+		validateHTTP,
+		validateBearerAuth,
+		validateGC,
+		validateGCVerifyPercent,
+		validateGCBatchSize,
+		validateCommitPolicy,
+		validateDedupeStrategy,
+		validateSharedBlobs,
+		validateStorageIO,
+		validateLDAP,
+		validateSync,
+		validateStorageConfig,
+		validateCacheConfig,
+		validateBlocklist,
+		validateStorageQuota,
+		validateTagAliases,
+		validateImmutableTags,
+		validatePreReceiveHooks,
+		validateBlobPolicies,
+		validateLayerRecompression,
+		validateReferrersTags,
+		validateRetention,
+		validateDownloads,
+		validatePromotionGates,
+		validateAuditSnapshots,
+		validateLeases,
+		validateCircuitBreaker,
+		validateTenants,
+		validateExtensionsConfig,
+		validateAuthz,
+		validateStorageDrivers,
 	}
 
-	if err := validateGC(config); err != nil {
-		return err
+	errs := []error{}
+
+	for _, validate := range validators {
+		if err := validate(cfg); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
-	if err := validateLDAP(config); err != nil {
-		return err
+	return goerrors.Join(errs...)
+}
+
+func validateLeases(config *config.Config) error {
+	if config.Storage.MaxLeaseDuration < 0 {
+		log.Error().Err(errors.ErrBadConfig).Dur("maxLeaseDuration", config.Storage.MaxLeaseDuration).
+			Msg("max lease duration can not be negative")
+
+		return fmt.Errorf("%w: max lease duration can not be negative", errors.ErrBadConfig)
 	}
 
-	if err := validateSync(config); err != nil {
-		return err
+	return nil
+}
+
+func validateCircuitBreaker(config *config.Config) error {
+	if config.CircuitBreaker == nil {
+		return nil
 	}
 
-	if err := validateStorageConfig(config); err != nil {
-		return err
+	if config.CircuitBreaker.FailureThreshold < 0 || config.CircuitBreaker.OpenDuration < 0 {
+		log.Error().Err(errors.ErrBadConfig).Int("failureThreshold", config.CircuitBreaker.FailureThreshold).
+			Dur("openDuration", config.CircuitBreaker.OpenDuration).
+			Msg("circuit breaker failure threshold and open duration can not be negative")
+
+		return fmt.Errorf("%w: circuit breaker failure threshold and open duration can not be negative",
+			errors.ErrBadConfig)
+	}
+
+	return nil
+}
+
+func validateTenants(config *config.Config) error {
+	if err := tenancy.Validate(config.Tenants); err != nil {
+		log.Error().Err(err).Msg("invalid tenant")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	for _, tenant := range config.Tenants {
+		if tenant.Storage == nil {
+			continue
+		}
+
+		if tenant.Storage.RootDirectory == "" ||
+			strings.EqualFold(tenant.Storage.RootDirectory, config.Storage.RootDirectory) {
+			log.Error().Err(errors.ErrBadConfig).Str("tenant", tenant.Name).
+				Msg("tenant storage needs its own root directory")
+
+			return fmt.Errorf("%w: tenant %s storage needs its own root directory", errors.ErrBadConfig, tenant.Name)
+		}
+
+		if _, ok := config.Storage.SubPaths["/"+tenant.Name]; ok {
+			log.Error().Err(errors.ErrBadConfig).Str("tenant", tenant.Name).
+				Msg("tenant storage conflicts with the storage subpath of the same name")
+
+			return fmt.Errorf("%w: tenant %s storage conflicts with the storage subpath of the same name",
+				errors.ErrBadConfig, tenant.Name)
+		}
+	}
+
+	return nil
+}
+
+func validateAuthz(config *config.Config) error {
+	// check authorization config, it should have basic auth enabled or ldap
+	if config.HTTP.AccessControl == nil {
+		return nil
 	}
 
-	if err := validateCacheConfig(config); err != nil {
+	// checking for anonymous policy only authorization config: no users, no policies but anonymous policy
+	if err := validateAuthzPolicies(config); err != nil {
 		return err
 	}
 
-	if err := validateExtensionsConfig(config); err != nil {
+	if err := validateAuthzRoles(config.HTTP.AccessControl); err != nil {
 		return err
 	}
 
-	// check authorization config, it should have basic auth enabled or ldap
-	if config.HTTP.AccessControl != nil {
-		// checking for anonymous policy only authorization config: no users, no policies but anonymous policy
-		if err := validateAuthzPolicies(config); err != nil {
+	// check glob patterns in authz config are compilable
+	for pattern := range config.HTTP.AccessControl.Repositories {
+		ok := glob.ValidatePattern(pattern)
+		if !ok {
+			log.Error().Err(glob.ErrBadPattern).Str("pattern", pattern).Msg("authorization pattern could not be compiled")
+
+			return fmt.Errorf("%w: authorization pattern %q could not be compiled", glob.ErrBadPattern, pattern)
+		}
+	}
+
+	return nil
+}
+
+// validateBearerAuth checks bearer auth is fully configured, otherwise it would be silently ignored.
+func validateBearerAuth(config *config.Config) error {
+	for _, listener := range config.HTTP.GetListeners() {
+		if err := validateListenerBearerAuth(listener.Auth); err != nil {
 			return err
 		}
 	}
 
+	return nil
+}
+
+func validateListenerBearerAuth(authConfig *config.AuthConfig) error {
+	if authConfig == nil || authConfig.Bearer == nil {
+		return nil
+	}
+
+	bearer := authConfig.Bearer
+	missing := []string{}
+
+	for field, value := range map[string]string{"realm": bearer.Realm, "service": bearer.Service, "cert": bearer.Cert} {
+		if value == "" {
+			missing = append(missing, field)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+
+		log.Error().Err(errors.ErrBadConfig).Strs("missing", missing).Msg("bearer auth requires realm, service and cert")
+
+		return fmt.Errorf("%w: bearer auth requires realm, service and cert, missing %s", errors.ErrBadConfig,
+			strings.Join(missing, ", "))
+	}
+
+	if _, err := pulltoken.New(bearer.PullTokens); err != nil {
+		log.Error().Err(err).Str("keyFile", bearer.PullTokens.KeyFile).Msg("invalid pull tokens config")
+
+		return err
+	}
+
+	return nil
+}
+
+func validateStorageDrivers(config *config.Config) error {
 	if len(config.Storage.StorageDriver) != 0 {
-		// enforce s3 driver in case of using storage driver
-		if config.Storage.StorageDriver["name"] != storageConstants.S3StorageDriverName {
+		// enforce s3 or gcs driver in case of using storage driver
+		if !isObjectStorageDriver(config.Storage.StorageDriver["name"]) {
 			log.Error().Err(errors.ErrBadConfig).Interface("cacheDriver", config.Storage.StorageDriver["name"]).
 				Msg("unsupported storage driver")
 
-			return errors.ErrBadConfig
+			return fmt.Errorf("%w: unsupported storage driver %v, only s3 and gcs are supported", errors.ErrBadConfig,
+				config.Storage.StorageDriver["name"])
 		}
 
 		// enforce filesystem storage in case sync feature is enabled
 		if config.Extensions != nil && config.Extensions.Sync != nil {
 			log.Error().Err(errors.ErrBadConfig).Msg("sync supports only filesystem storage")
 
-			return errors.ErrBadConfig
+			return fmt.Errorf("%w: sync supports only filesystem storage", errors.ErrBadConfig)
 		}
 	}
 
-	// enforce s3 driver on subpaths in case of using storage driver
-	if config.Storage.SubPaths != nil {
-		if len(config.Storage.SubPaths) > 0 {
-			subPaths := config.Storage.SubPaths
+	// enforce s3 or gcs driver on subpaths in case of using storage driver
+	for route, storageConfig := range config.Storage.SubPaths {
+		if len(storageConfig.StorageDriver) != 0 {
+			if !isObjectStorageDriver(storageConfig.StorageDriver["name"]) {
+				log.Error().Err(errors.ErrBadConfig).Str("subpath", route).Interface("storageDriver",
+					storageConfig.StorageDriver["name"]).Msg("unsupported storage driver")
 
-			for route, storageConfig := range subPaths {
-				if len(storageConfig.StorageDriver) != 0 {
-					if storageConfig.StorageDriver["name"] != storageConstants.S3StorageDriverName {
-						log.Error().Err(errors.ErrBadConfig).Str("subpath", route).Interface("storageDriver",
-							storageConfig.StorageDriver["name"]).Msg("unsupported storage driver")
-
-						return errors.ErrBadConfig
-					}
-				}
+				return fmt.Errorf("%w: unsupported storage driver %v for subpath %s, only s3 and gcs are supported",
+					errors.ErrBadConfig, storageConfig.StorageDriver["name"], route)
 			}
 		}
 	}
 
-	// check glob patterns in authz config are compilable
-	if config.HTTP.AccessControl != nil {
-		for pattern := range config.HTTP.AccessControl.Repositories {
-			ok := glob.ValidatePattern(pattern)
-			if !ok {
-				log.Error().Err(glob.ErrBadPattern).Str("pattern", pattern).Msg("authorization pattern could not be compiled")
+	return nil
+}
 
-				return glob.ErrBadPattern
-			}
-		}
-	}
+func isObjectStorageDriver(name interface{}) bool {
+	return name == storageConstants.S3StorageDriverName || name == storageConstants.GCSStorageDriverName
+}
 
-	return nil
+func isRemoteCacheDriver(name interface{}) bool {
+	return name == storageConstants.DynamoDBDriverName || name == storageConstants.RedisDriverName
 }
 
 func validateAuthzPolicies(config *config.Config) error {
-	if (config.HTTP.Auth == nil || (config.HTTP.Auth.HTPasswd.Path == "" && config.HTTP.Auth.LDAP == nil)) &&
-		!authzContainsOnlyAnonymousPolicy(config) {
+	// the policies of the users apply to the listeners authenticating them
+	authnEnabled := false
+
+	for _, listener := range config.HTTP.GetListeners() {
+		if listener.Auth != nil && (listener.Auth.HTPasswd.Path != "" || listener.Auth.LDAP != nil) {
+			authnEnabled = true
+		}
+	}
+
+	if !authnEnabled && !authzContainsOnlyAnonymousPolicy(config) {
 		log.Error().Err(errors.ErrBadConfig).
 			Msg("access control config requires httpasswd, ldap authentication " +
 				"or using only 'anonymousPolicy' policies")
 
-		return errors.ErrBadConfig
+		return fmt.Errorf("%w: access control config requires httpasswd, ldap authentication "+
+			"or using only 'anonymousPolicy' policies", errors.ErrBadConfig)
+	}
+
+	return nil
+}
+
+func validateAuthzRoles(acConfig *config.AccessControlConfig) error {
+	policies := []config.Policy{acConfig.AdminPolicy}
+
+	for _, policyGroup := range acConfig.Repositories {
+		policies = append(policies, policyGroup.Policies...)
+	}
+
+	for _, policy := range policies {
+		for _, role := range policy.Roles {
+			if _, ok := config.GetRoleActions(role); !ok {
+				log.Error().Err(errors.ErrUnknownRole).Str("role", role).
+					Msg("unknown role in access control config, valid roles are reader, publisher, maintainer and admin")
+
+				return fmt.Errorf("%w: unknown role in access control config, valid roles are reader, publisher, "+
+					"maintainer and admin", errors.ErrBadConfig)
+			}
+		}
 	}
 
 	return nil
@@ -484,6 +950,20 @@ func applyDefaultValues(config *config.Config, viperInstance *viper.Viper) {
 			// Note: In case mgmt is not empty the config.Extensions will not be nil and we will not reach here
 			config.Extensions.Mgmt = &extconf.MgmtConfig{}
 		}
+
+		_, ok = extMap["useractivity"]
+		if ok {
+			// we found a config like `"extensions": {"userActivity:": {}}`
+			// Note: In case userActivity is not empty the config.Extensions will not be nil and we will not reach here
+			config.Extensions.UserActivity = &extconf.UserActivityConfig{}
+		}
+
+		_, ok = extMap["events"]
+		if ok {
+			// we found a config like `"extensions": {"events:": {}}`
+			// Note: In case events is not empty the config.Extensions will not be nil and we will not reach here
+			config.Extensions.Events = &extconf.EventsConfig{}
+		}
 	}
 
 	if config.Extensions != nil {
@@ -514,6 +994,10 @@ func applyDefaultValues(config *config.Config, viperInstance *viper.Viper) {
 						"changing update duration to 2 hours and continuing.")
 				}
 
+				if config.Extensions.Search.CVE.Report != nil && config.Extensions.Search.CVE.Report.Interval == 0 {
+					config.Extensions.Search.CVE.Report.Interval = 24 * time.Hour //nolint: gomnd
+				}
+
 				if config.Extensions.Search.CVE.Trivy == nil {
 					config.Extensions.Search.CVE.Trivy = &extconf.TrivyConfig{}
 				}
@@ -531,6 +1015,17 @@ func applyDefaultValues(config *config.Config, viperInstance *viper.Viper) {
 						Msg("Config: using default Trivy Java DB download URL.")
 					config.Extensions.Search.CVE.Trivy.JavaDBRepository = defaultJavaDBDownloadURL
 				}
+
+				if config.Extensions.Search.CVE.Trivy.JavaDBUpdateInterval == 0 {
+					config.Extensions.Search.CVE.Trivy.JavaDBUpdateInterval = config.Extensions.Search.CVE.UpdateInterval
+				}
+
+				if config.Extensions.Search.CVE.Trivy.JavaDBUpdateInterval < defaultUpdateInterval {
+					config.Extensions.Search.CVE.Trivy.JavaDBUpdateInterval = defaultUpdateInterval
+
+					log.Warn().Msg("Java DB update interval set to too-short interval < 2h, " +
+						"changing update duration to 2 hours and continuing.")
+				}
 			}
 		}
 
@@ -550,6 +1045,41 @@ func applyDefaultValues(config *config.Config, viperInstance *viper.Viper) {
 			}
 		}
 
+		if config.Extensions.UserActivity != nil {
+			if config.Extensions.UserActivity.Enable == nil {
+				config.Extensions.UserActivity.Enable = &defaultVal
+			}
+
+			if config.Extensions.UserActivity.MaxEntries == 0 {
+				config.Extensions.UserActivity.MaxEntries = 100 //nolint: gomnd
+			}
+		}
+
+		if config.Extensions.Events != nil {
+			if config.Extensions.Events.Enable == nil {
+				config.Extensions.Events.Enable = &defaultVal
+			}
+
+			if config.Extensions.Events.Retention == 0 {
+				config.Extensions.Events.Retention = 7 * 24 * time.Hour //nolint: gomnd
+			}
+
+			if config.Extensions.Events.MaxEventsPerRepo == 0 {
+				config.Extensions.Events.MaxEventsPerRepo = 1000 //nolint: gomnd
+			}
+		}
+
+		for idx := range config.Extensions.Plugins {
+			if config.Extensions.Plugins[idx].Enable == nil {
+				config.Extensions.Plugins[idx].Enable = &defaultVal
+			}
+		}
+
+		// telemetry is opt-in so enable is not defaulted to true
+		if config.Extensions.Telemetry != nil && config.Extensions.Telemetry.Interval == 0 {
+			config.Extensions.Telemetry.Interval = 24 * time.Hour //nolint: gomnd
+		}
+
 		if config.Extensions.Scrub != nil {
 			if config.Extensions.Scrub.Enable == nil {
 				config.Extensions.Scrub.Enable = &defaultVal
@@ -651,13 +1181,19 @@ func LoadConfiguration(config *config.Config, configPath string) error {
 	if len(metaData.Keys) == 0 {
 		log.Error().Err(errors.ErrBadConfig).Msg("config doesn't contain any key:value pair")
 
-		return errors.ErrBadConfig
+		return fmt.Errorf("%w: config doesn't contain any key:value pair", errors.ErrBadConfig)
 	}
 
+	errs := []error{}
+
+	// unknown keys are reported along with the other problems of the config
 	if len(metaData.Unused) > 0 {
+		sort.Strings(metaData.Unused)
+
 		log.Error().Err(errors.ErrBadConfig).Strs("keys", metaData.Unused).Msg("unknown keys")
 
-		return errors.ErrBadConfig
+		errs = append(errs, fmt.Errorf("%w: unknown keys %s, check their spelling and nesting", errors.ErrBadConfig,
+			strings.Join(metaData.Unused, ", ")))
 	}
 
 	// defaults
@@ -665,7 +1201,11 @@ func LoadConfiguration(config *config.Config, configPath string) error {
 
 	// various config checks
 	if err := validateConfiguration(config); err != nil {
-		return err
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return goerrors.Join(errs...)
 	}
 
 	// update distSpecVersion
@@ -680,7 +1220,7 @@ func authzContainsOnlyAnonymousPolicy(cfg *config.Config) bool {
 
 	log.Info().Msg("checking if anonymous authorization is the only type of authorization policy configured")
 
-	if len(adminPolicy.Actions)+len(adminPolicy.Users) > 0 {
+	if len(adminPolicy.Actions)+len(adminPolicy.Roles)+len(adminPolicy.Users) > 0 {
 		log.Info().Msg("admin policy detected, anonymous authorization is not the only authorization policy configured")
 
 		return false
@@ -701,7 +1241,7 @@ func authzContainsOnlyAnonymousPolicy(cfg *config.Config) bool {
 		}
 
 		for _, policy := range repository.Policies {
-			if len(policy.Actions)+len(policy.Users) > 0 {
+			if len(policy.Actions)+len(policy.Roles)+len(policy.Users) > 0 {
 				log.Info().Interface("repository", repository).
 					Msg("repository with non-empty policy detected, " +
 						"anonymous authorization is not the only authorization policy configured")
@@ -715,9 +1255,19 @@ func authzContainsOnlyAnonymousPolicy(cfg *config.Config) bool {
 }
 
 func validateLDAP(config *config.Config) error {
+	for _, listener := range config.HTTP.GetListeners() {
+		if err := validateListenerLDAP(listener.Auth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateListenerLDAP(authConfig *config.AuthConfig) error {
 	// LDAP mandatory configuration
-	if config.HTTP.Auth != nil && config.HTTP.Auth.LDAP != nil {
-		ldap := config.HTTP.Auth.LDAP
+	if authConfig != nil && authConfig.LDAP != nil {
+		ldap := authConfig.LDAP
 		if ldap.UserAttribute == "" {
 			log.Error().Str("userAttribute", ldap.UserAttribute).
 				Msg("invalid LDAP configuration, missing mandatory key: userAttribute")
@@ -738,18 +1288,512 @@ func validateLDAP(config *config.Config) error {
 
 			return errors.ErrLDAPConfig
 		}
+
+		for _, address := range ldap.Addresses {
+			if _, _, err := api.SplitLDAPAddress(address, ldap.Port); err != nil {
+				log.Error().Err(err).Str("address", address).
+					Msg("invalid LDAP configuration, failover addresses must be given as host or host:port")
+
+				return err
+			}
+		}
+
+		if ldap.DialTimeout < 0 || ldap.FailureThreshold < 0 || ldap.BreakerTimeout < 0 {
+			log.Error().Str("dialTimeout", ldap.DialTimeout.String()).Int("failureThreshold", ldap.FailureThreshold).
+				Str("breakerTimeout", ldap.BreakerTimeout.String()).
+				Msg("invalid LDAP configuration, dialTimeout, failureThreshold and breakerTimeout can't be negative")
+
+			return fmt.Errorf("%w: dialTimeout, failureThreshold and breakerTimeout can't be negative",
+				errors.ErrLDAPConfig)
+		}
 	}
 
 	return nil
 }
 
 func validateHTTP(config *config.Config) error {
-	if config.HTTP.Port != "" {
-		port, err := strconv.ParseInt(config.HTTP.Port, 10, 64)
-		if err != nil || (port < 0 || port > 65535) {
-			log.Error().Str("port", config.HTTP.Port).Msg("invalid port")
+	for _, listener := range config.HTTP.GetListeners() {
+		if listener.Port != "" {
+			port, err := strconv.ParseInt(listener.Port, 10, 64)
+			if err != nil || (port < 0 || port > 65535) {
+				log.Error().Str("port", listener.Port).Msg("invalid port")
+
+				return fmt.Errorf("%w: invalid port", errors.ErrBadConfig)
+			}
+		}
+
+		if err := validateIPFamilies(listener); err != nil {
+			return err
+		}
+	}
+
+	if config.HTTP.ExternalURL != "" {
+		externalURL, err := url.Parse(config.HTTP.ExternalURL)
+		if err != nil || (externalURL.Scheme != "http" && externalURL.Scheme != "https") || externalURL.Host == "" ||
+			externalURL.RawQuery != "" || externalURL.Fragment != "" {
+			log.Error().Str("externalURL", config.HTTP.ExternalURL).
+				Msg("invalid external URL, it must be an http or https URL without query")
+
+			return fmt.Errorf("%w: invalid external URL, it must be an http or https URL without "+
+				"query", errors.ErrBadConfig)
+		}
+	}
+
+	for _, trustedProxy := range config.HTTP.TrustedProxies {
+		if _, _, err := net.ParseCIDR(trustedProxy); err != nil && net.ParseIP(trustedProxy) == nil {
+			log.Error().Str("trustedProxy", trustedProxy).Msg("invalid trusted proxy, it must be an IP or a CIDR")
+
+			return fmt.Errorf("%w: invalid trusted proxy, it must be an IP or a CIDR", errors.ErrBadConfig)
+		}
+	}
+
+	if err := validateCompression(config.HTTP.Compression); err != nil {
+		return err
+	}
+
+	if err := validateLoadShedding(config.HTTP.LoadShedding); err != nil {
+		return err
+	}
+
+	return validateQuota(config)
+}
+
+func validateCompression(compression *config.CompressionConfig) error {
+	if compression == nil {
+		return nil
+	}
+
+	if compression.MinSize < 0 {
+		log.Error().Err(errors.ErrBadConfig).Int("minSize", compression.MinSize).
+			Msg("invalid compression min size, it can't be negative")
+
+		return fmt.Errorf("%w: invalid compression min size, it can't be negative", errors.ErrBadConfig)
+	}
+
+	for _, mediaType := range compression.MediaTypes {
+		if _, _, err := mime.ParseMediaType(mediaType); err != nil {
+			log.Error().Err(errors.ErrBadConfig).Str("mediaType", mediaType).Msg("invalid compressed media type")
+
+			return fmt.Errorf("%w: invalid compressed media type %s", errors.ErrBadConfig, mediaType)
+		}
+	}
+
+	return nil
+}
+
+func validateLoadShedding(loadShedding *config.LoadSheddingConfig) error {
+	if loadShedding == nil {
+		return nil
+	}
+
+	if loadShedding.Fraction < 0 || loadShedding.Fraction > 1 {
+		log.Error().Err(errors.ErrBadConfig).Float64("fraction", loadShedding.Fraction).
+			Msg("invalid load shedding fraction, it must be between 0 and 1")
+
+		return fmt.Errorf("%w: invalid load shedding fraction, it must be between 0 and 1", errors.ErrBadConfig)
+	}
+
+	if loadShedding.MinHealthScore < 0 || loadShedding.MinHealthScore > 1 {
+		log.Error().Err(errors.ErrBadConfig).Float64("minHealthScore", loadShedding.MinHealthScore).
+			Msg("invalid load shedding min health score, it must be between 0 and 1")
+
+		return fmt.Errorf("%w: invalid load shedding min health score, it must be between 0 and 1",
+			errors.ErrBadConfig)
+	}
+
+	if loadShedding.ProbeInterval < 0 || loadShedding.ProbeTimeout < 0 {
+		log.Error().Err(errors.ErrBadConfig).Dur("probeInterval", loadShedding.ProbeInterval).
+			Dur("probeTimeout", loadShedding.ProbeTimeout).
+			Msg("invalid load shedding probe interval or timeout, they can't be negative")
+
+		return fmt.Errorf("%w: invalid load shedding probe interval or timeout, they can't be negative",
+			errors.ErrBadConfig)
+	}
+
+	return nil
+}
+
+// validateIPFamilies checks at least one IP family is enabled, and that it's the one of the IP addresses listened on.
+func validateIPFamilies(listener config.ListenerConfig) error {
+	network := listener.GetNetwork()
+
+	if listener.IPv4 != nil && !*listener.IPv4 && listener.IPv6 != nil && !*listener.IPv6 {
+		log.Error().Str("address", listener.Address).Msg("invalid listener, both IPv4 and IPv6 are disabled")
+
+		return fmt.Errorf("%w: invalid listener, both IPv4 and IPv6 are disabled", errors.ErrBadConfig)
+	}
+
+	for _, address := range listener.GetAddresses() {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			continue
+		}
+
+		if (network == "tcp4" && ip.To4() == nil) || (network == "tcp6" && ip.To4() != nil) {
+			log.Error().Str("address", address).Str("network", network).
+				Msg("invalid listener, the IP family of the address is disabled")
+
+			return fmt.Errorf("%w: invalid listener, the IP family of the address is disabled", errors.ErrBadConfig)
+		}
+	}
+
+	return nil
+}
+
+func validateQuota(config *config.Config) error {
+	if config.HTTP.Quota == nil {
+		return nil
+	}
+
+	if err := validateQuotaLimits(config.HTTP.Quota.Default.MaxTags, config.HTTP.Quota.Default.MaxRepos); err != nil {
+		return err
+	}
+
+	for _, policy := range config.HTTP.Quota.Policies {
+		if err := validateQuotaLimits(policy.MaxTags, policy.MaxRepos); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateQuotaLimits(maxTags, maxRepos int) error {
+	if maxTags < 0 || maxRepos < 0 {
+		log.Error().Err(errors.ErrBadConfig).Int("maxTags", maxTags).Int("maxRepos", maxRepos).
+			Msg("invalid quota limits, they can't be negative")
+
+		return fmt.Errorf("%w: invalid quota limits, they can't be negative", errors.ErrBadConfig)
+	}
+
+	return nil
+}
+
+func validateStorageQuota(config *config.Config) error {
+	quota := config.Storage.Quota
+	if quota == nil {
+		return nil
+	}
+
+	limits := map[string]int64{"": quota.MaxRepoBytes}
+	for pattern, maxBytes := range quota.Repositories {
+		if !glob.ValidatePattern(pattern) {
+			log.Error().Err(glob.ErrBadPattern).Str("pattern", pattern).Msg("invalid storage quota repository pattern")
+
+			return fmt.Errorf("%w: invalid storage quota repository pattern %s", errors.ErrBadConfig, pattern)
+		}
+
+		limits[pattern] = maxBytes
+	}
+
+	for pattern, maxBytes := range limits {
+		if quota.MaxBytes < 0 || maxBytes < 0 {
+			log.Error().Err(errors.ErrBadConfig).Str("pattern", pattern).Int64("maxBytes", quota.MaxBytes).
+				Int64("maxRepoBytes", maxBytes).Msg("invalid storage quota limits, they can't be negative")
+
+			return fmt.Errorf("%w: invalid storage quota limits, they can't be negative", errors.ErrBadConfig)
+		}
+	}
+
+	return nil
+}
+
+func validateBlocklist(config *config.Config) error {
+	for _, digestStr := range config.Storage.Blocklist {
+		if _, err := godigest.Parse(digestStr); err != nil {
+			log.Error().Err(errors.ErrBadConfig).Str("digest", digestStr).Msg("invalid digest in blocklist")
+
+			return fmt.Errorf("%w: invalid digest in blocklist", errors.ErrBadConfig)
+		}
+	}
+
+	return nil
+}
+
+func validateTagAliases(config *config.Config) error {
+	if _, err := tagalias.New(config.Storage.TagAliases); err != nil {
+		log.Error().Err(err).Msg("invalid tag alias rule")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	return nil
+}
+
+func validateImmutableTags(config *config.Config) error {
+	if _, err := immutabletags.New(config.Storage.ImmutableTags); err != nil {
+		log.Error().Err(err).Msg("invalid immutable tags rule")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	return nil
+}
+
+func validatePreReceiveHooks(config *config.Config) error {
+	if _, err := hooks.New(config.Storage.PreReceiveHooks, zlog.Logger{Logger: log.Logger}); err != nil {
+		log.Error().Err(err).Msg("invalid pre-receive hook")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	return nil
+}
+
+func validateBlobPolicies(config *config.Config) error {
+	if _, err := blobpolicy.New(config.Storage.BlobPolicies); err != nil {
+		log.Error().Err(err).Msg("invalid blob policy")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	return nil
+}
+
+func validateLayerRecompression(config *config.Config) error {
+	if config.Storage.LayerRecompression == nil {
+		return nil
+	}
+
+	if _, err := recompress.New(*config.Storage.LayerRecompression, storage.StoreController{}, nil,
+		zlog.Logger{Logger: log.Logger}); err != nil {
+		log.Error().Err(err).Msg("invalid layer recompression")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	return nil
+}
+
+func validateReferrersTags(config *config.Config) error {
+	if config.Storage.ReferrersTags == nil {
+		return nil
+	}
 
-			return errors.ErrBadConfig
+	if _, err := referrerstags.New(*config.Storage.ReferrersTags, storage.StoreController{}, nil,
+		zlog.Logger{Logger: log.Logger}); err != nil {
+		log.Error().Err(err).Msg("invalid referrers tags config")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	return nil
+}
+
+func validateRetention(config *config.Config) error {
+	if _, err := retention.New(config.Storage.Retention); err != nil {
+		log.Error().Err(err).Msg("invalid retention policy")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	for route, subPath := range config.Storage.SubPaths {
+		if _, err := retention.New(subPath.Retention); err != nil {
+			log.Error().Err(err).Str("subPath", route).Msg("invalid retention policy")
+
+			return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+		}
+	}
+
+	return nil
+}
+
+func validateDownloads(config *config.Config) error {
+	if config.Extensions == nil || config.Extensions.Search == nil {
+		return nil
+	}
+
+	if _, err := downloads.New(config.Extensions.Search.Downloads); err != nil {
+		log.Error().Err(err).Msg("invalid download counts config")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	return nil
+}
+
+func validateAuditSnapshots(config *config.Config) error {
+	if config.Extensions == nil || config.Extensions.Search == nil || config.Extensions.Search.AuditSnapshots == nil {
+		return nil
+	}
+
+	if _, err := audit.New(*config.Extensions.Search.AuditSnapshots, storage.StoreController{}, nil,
+		zlog.Logger{Logger: log.Logger}); err != nil {
+		log.Error().Err(err).Msg("invalid audit snapshots config")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	return nil
+}
+
+func validatePromotionGates(config *config.Config) error {
+	if config.Extensions == nil || config.Extensions.Search == nil {
+		return nil
+	}
+
+	if _, err := promotion.New(config.Extensions.Search.PromotionGates); err != nil {
+		log.Error().Err(err).Msg("invalid promotion gate")
+
+		return fmt.Errorf("%w: %w", errors.ErrBadConfig, err)
+	}
+
+	// without CVE scanning the vulnerability checks would fail every promotion
+	for _, gate := range config.Extensions.Search.PromotionGates {
+		if gate.SeverityThreshold != "" && config.Extensions.Search.CVE == nil {
+			log.Error().Err(errors.ErrBadConfig).Str("environment", gate.Environment).
+				Msg("promotion gate has a severity threshold but CVE scanning is not enabled")
+
+			return fmt.Errorf("%w: promotion gate %s has a severity threshold but CVE scanning is not enabled",
+				errors.ErrBadConfig, gate.Environment)
+		}
+	}
+
+	return nil
+}
+
+func validateConsistencyCheck(config *config.Config) {
+	if config.Storage.Repair && !config.Storage.ConsistencyCheck {
+		log.Warn().Err(errors.ErrBadConfig).
+			Msg("repair specified without enabling the storage consistency check, will be ignored")
+	}
+
+	for name, subPath := range config.Storage.SubPaths {
+		if subPath.Repair && !subPath.ConsistencyCheck {
+			log.Warn().Err(errors.ErrBadConfig).Str("subPath", name).
+				Msg("repair specified without enabling the storage consistency check, will be ignored")
+		}
+	}
+}
+
+func validateCommitPolicy(cfg *config.Config) error {
+	storageConfigs := map[string]config.StorageConfig{"": cfg.Storage.StorageConfig}
+	for route, subPath := range cfg.Storage.SubPaths {
+		storageConfigs[route] = subPath
+	}
+
+	for route, storageConfig := range storageConfigs {
+		switch storageConfig.GetCommitPolicy() {
+		case storageConstants.CommitPolicyNone, storageConstants.CommitPolicyAlways,
+			storageConstants.CommitPolicyManifest, storageConstants.CommitPolicyPeriodic:
+		default:
+			log.Error().Err(errors.ErrBadConfig).Str("subPath", route).Str("commitPolicy", storageConfig.CommitPolicy).
+				Msg("invalid commit policy specified, should be one of none, always, manifest or periodic")
+
+			return fmt.Errorf("%w: invalid commit policy specified, should be one of none, always, manifest or "+
+				"periodic", errors.ErrBadConfig)
+		}
+
+		if storageConfig.CommitInterval < 0 {
+			log.Error().Err(errors.ErrBadConfig).Str("subPath", route).Dur("interval", storageConfig.CommitInterval).
+				Msg("invalid commit interval specified")
+
+			return fmt.Errorf("%w: invalid commit interval specified", errors.ErrBadConfig)
+		}
+
+		if storageConfig.CommitInterval != 0 &&
+			storageConfig.GetCommitPolicy() != storageConstants.CommitPolicyPeriodic {
+			log.Warn().Err(errors.ErrBadConfig).Str("subPath", route).
+				Msg("commit interval specified without the periodic commit policy, will be ignored")
+		}
+
+		if storageConfig.Commit && storageConfig.CommitPolicy != "" &&
+			storageConfig.CommitPolicy != storageConstants.CommitPolicyAlways {
+			log.Warn().Err(errors.ErrBadConfig).Str("subPath", route).
+				Msg("commit specified along with a different commit policy, will be ignored")
+		}
+
+		if storageConfig.StorageDriver != nil && storageConfig.CommitPolicy != "" {
+			log.Warn().Err(errors.ErrBadConfig).Str("subPath", route).
+				Msg("commit policy specified with remote storage, will be ignored")
+		}
+	}
+
+	return nil
+}
+
+func validateDedupeStrategy(cfg *config.Config) error {
+	storageConfigs := map[string]config.StorageConfig{"": cfg.Storage.StorageConfig}
+	for route, subPath := range cfg.Storage.SubPaths {
+		storageConfigs[route] = subPath
+	}
+
+	for route, storageConfig := range storageConfigs {
+		switch storageConfig.GetDedupeStrategy() {
+		case storageConstants.DedupeStrategyHardLink, storageConstants.DedupeStrategyReflink:
+		default:
+			log.Error().Err(errors.ErrBadConfig).Str("subPath", route).
+				Str("dedupeStrategy", storageConfig.DedupeStrategy).
+				Msg("invalid dedupe strategy specified, should be one of hardlink or reflink")
+
+			return fmt.Errorf("%w: invalid dedupe strategy specified, should be one of hardlink or reflink",
+				errors.ErrBadConfig)
+		}
+
+		if storageConfig.DedupeStrategy != "" && storageConfig.StorageDriver != nil {
+			log.Warn().Err(errors.ErrBadConfig).Str("subPath", route).
+				Msg("dedupe strategy specified with remote storage, will be ignored")
+		}
+	}
+
+	return nil
+}
+
+func validateSharedBlobs(cfg *config.Config) error {
+	storageConfigs := map[string]config.StorageConfig{"": cfg.Storage.StorageConfig}
+	for route, subPath := range cfg.Storage.SubPaths {
+		storageConfigs[route] = subPath
+	}
+
+	for route, storageConfig := range storageConfigs {
+		// remote storage has no directories to link the shared blobs from
+		if storageConfig.SharedBlobs && storageConfig.StorageDriver != nil {
+			log.Error().Err(errors.ErrBadConfig).Str("subPath", route).
+				Msg("shared blobs layout specified with remote storage, it's only supported by local storage")
+
+			return fmt.Errorf("%w: shared blobs layout specified with remote storage, it's only supported by local storage",
+				errors.ErrBadConfig)
+		}
+	}
+
+	return nil
+}
+
+func validateStorageIO(cfg *config.Config) error {
+	storageConfigs := map[string]config.StorageConfig{"": cfg.Storage.StorageConfig}
+	for route, subPath := range cfg.Storage.SubPaths {
+		storageConfigs[route] = subPath
+	}
+
+	for route, storageConfig := range storageConfigs {
+		if storageConfig.IO == nil {
+			continue
+		}
+
+		ioConfig := storageConfig.IO
+
+		if ioConfig.WriteBufferSize < 0 || ioConfig.LargeBlobSize < 0 {
+			log.Error().Err(errors.ErrBadConfig).Str("subPath", route).Int("writeBufferSize", ioConfig.WriteBufferSize).
+				Int64("largeBlobSize", ioConfig.LargeBlobSize).Msg("invalid storage IO sizes specified")
+
+			return fmt.Errorf("%w: invalid storage IO sizes specified, writeBufferSize and largeBlobSize can't be "+
+				"negative", errors.ErrBadConfig)
+		}
+
+		switch ioConfig.ReadAhead {
+		case "", storageConstants.ReadAheadNormal, storageConstants.ReadAheadSequential,
+			storageConstants.ReadAheadRandom:
+		default:
+			log.Error().Err(errors.ErrBadConfig).Str("subPath", route).Str("readAhead", ioConfig.ReadAhead).
+				Msg("invalid readahead hint specified, should be one of normal, sequential or random")
+
+			return fmt.Errorf("%w: invalid readahead hint specified, should be one of normal, sequential or random",
+				errors.ErrBadConfig)
+		}
+
+		if storageConfig.StorageDriver != nil {
+			log.Warn().Err(errors.ErrBadConfig).Str("subPath", route).
+				Msg("storage IO settings specified with remote storage, will be ignored")
 		}
 	}
 
@@ -762,14 +1806,14 @@ func validateGC(config *config.Config) error {
 		log.Error().Err(errors.ErrBadConfig).Dur("delay", config.Storage.GCDelay).
 			Msg("invalid garbage-collect delay specified")
 
-		return errors.ErrBadConfig
+		return fmt.Errorf("%w: invalid garbage-collect delay specified", errors.ErrBadConfig)
 	}
 
 	if config.Storage.GCInterval < 0 {
 		log.Error().Err(errors.ErrBadConfig).Dur("interval", config.Storage.GCInterval).
 			Msg("invalid garbage-collect interval specified")
 
-		return errors.ErrBadConfig
+		return fmt.Errorf("%w: invalid garbage-collect interval specified", errors.ErrBadConfig)
 	}
 
 	if !config.Storage.GC {
@@ -792,7 +1836,55 @@ func validateGC(config *config.Config) error {
 				Interface("gcDelay", subPath.GCDelay).
 				Msg("invalid GC delay configuration - cannot be negative or zero")
 
-			return errors.ErrBadConfig
+			return fmt.Errorf("%w: invalid GC delay configuration - cannot be negative or zero", errors.ErrBadConfig)
+		}
+	}
+
+	return nil
+}
+
+func validateGCVerifyPercent(cfg *config.Config) error {
+	storageConfigs := map[string]config.StorageConfig{"": cfg.Storage.StorageConfig}
+	for route, subPath := range cfg.Storage.SubPaths {
+		storageConfigs[route] = subPath
+	}
+
+	for route, storageConfig := range storageConfigs {
+		if storageConfig.GCVerifyPercent < 0 || storageConfig.GCVerifyPercent > 100 {
+			log.Error().Err(errors.ErrBadConfig).Str("subPath", route).Int("gcVerifyPercent", storageConfig.GCVerifyPercent).
+				Msg("invalid percentage of blobs verified by garbage-collect, should be between 0 and 100")
+
+			return fmt.Errorf("%w: invalid percentage of blobs verified by garbage-collect, should be between 0 "+
+				"and 100", errors.ErrBadConfig)
+		}
+
+		if !storageConfig.GC && storageConfig.GCVerifyPercent != 0 {
+			log.Warn().Err(errors.ErrBadConfig).Str("subPath", route).
+				Msg("blob verification specified without enabling garbage-collect, will be ignored")
+		}
+	}
+
+	return nil
+}
+
+func validateGCBatchSize(cfg *config.Config) error {
+	storageConfigs := map[string]config.StorageConfig{"": cfg.Storage.StorageConfig}
+	for route, subPath := range cfg.Storage.SubPaths {
+		storageConfigs[route] = subPath
+	}
+
+	for route, storageConfig := range storageConfigs {
+		if storageConfig.GCBatchSize < 0 {
+			log.Error().Err(errors.ErrBadConfig).Str("subPath", route).Int("gcBatchSize", storageConfig.GCBatchSize).
+				Msg("invalid number of blobs swept by each garbage-collect task, can not be negative")
+
+			return fmt.Errorf("%w: invalid number of blobs swept by each garbage-collect task, can not be negative",
+				errors.ErrBadConfig)
+		}
+
+		if !storageConfig.GC && storageConfig.GCBatchSize != 0 {
+			log.Warn().Err(errors.ErrBadConfig).Str("subPath", route).
+				Msg("incremental garbage-collect specified without enabling garbage-collect, will be ignored")
 		}
 	}
 
@@ -802,13 +1894,44 @@ func validateGC(config *config.Config) error {
 func validateSync(config *config.Config) error {
 	// check glob patterns in sync config are compilable
 	if config.Extensions != nil && config.Extensions.Sync != nil {
+		if config.Extensions.Sync.MaxConcurrentDownloads < 0 {
+			log.Error().Err(errors.ErrBadConfig).Int("maxConcurrentDownloads", config.Extensions.Sync.MaxConcurrentDownloads).
+				Msg("sync maxConcurrentDownloads can not be negative")
+
+			return fmt.Errorf("%w: sync maxConcurrentDownloads can not be negative", errors.ErrBadConfig)
+		}
+
 		for id, regCfg := range config.Extensions.Sync.Registries {
+			if regCfg.MaxParallelDownloads < 0 {
+				log.Error().Err(errors.ErrBadConfig).Int("id", id).Int("maxParallelDownloads", regCfg.MaxParallelDownloads).
+					Msg("sync maxParallelDownloads can not be negative")
+
+				return fmt.Errorf("%w: sync maxParallelDownloads can not be negative", errors.ErrBadConfig)
+			}
+
 			// check retry options are configured for sync
 			if regCfg.MaxRetries != nil && regCfg.RetryDelay == nil {
 				log.Error().Err(errors.ErrBadConfig).Int("id", id).Interface("extensions.sync.registries[id]",
 					config.Extensions.Sync.Registries[id]).Msg("retryDelay is required when using maxRetries")
 
-				return errors.ErrBadConfig
+				return fmt.Errorf("%w: retryDelay is required when using maxRetries", errors.ErrBadConfig)
+			}
+
+			if regCfg.OnDemandRules != nil {
+				if !regCfg.OnDemand {
+					log.Warn().Int("id", id).Msg("sync onDemandRules are ignored, onDemand is not enabled")
+				}
+
+				patterns := append([]string{}, regCfg.OnDemandRules.Allow...)
+
+				for _, pattern := range append(patterns, regCfg.OnDemandRules.Deny...) {
+					if !glob.ValidatePattern(pattern) {
+						log.Error().Err(glob.ErrBadPattern).Str("pattern", pattern).
+							Msg("sync onDemandRules pattern could not be compiled")
+
+						return glob.ErrBadPattern
+					}
+				}
 			}
 
 			if regCfg.Content != nil {
@@ -825,7 +1948,8 @@ func validateSync(config *config.Config) error {
 							Interface("sync content", content).
 							Msg("sync config: can not use stripPrefix true and destination '/' without using glob patterns in prefix")
 
-						return errors.ErrBadConfig
+						return fmt.Errorf("%w: sync config: can not use stripPrefix true and destination '/' "+
+							"without using glob patterns in prefix", errors.ErrBadConfig)
 					}
 				}
 			}
@@ -2,17 +2,21 @@ package cli_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 
+	zerr "zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/api"
 	"zotregistry.io/zot/pkg/api/config"
 	"zotregistry.io/zot/pkg/cli"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
 	storageConstants "zotregistry.io/zot/pkg/storage/constants"
 	"zotregistry.io/zot/pkg/storage/s3"
 	. "zotregistry.io/zot/pkg/test"
@@ -134,6 +138,128 @@ func TestVerify(t *testing.T) {
 		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
 	})
 
+	Convey("Test verify negative CVE maxConcurrentScans", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"search":{"enable":true,"cve":{"maxConcurrentScans":-1}}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+	})
+
+	Convey("Test verify trivy javaDBPath which doesn't exist", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"search":{"enable":true,"cve":{"trivy":{"javaDBPath":"/tmp/zot-missing-java-db"}}}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+	})
+
+	Convey("Test verify CVE report config", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"search":{"enable":true,"cve":{"report":{"webhook":"ftp://reports"}}}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+
+		content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"search":{"enable":true,"cve":{"report":{"interval":"-1h"}}}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+
+		content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"search":{"enable":true,"cve":{"report":{"webhook":"https://reports.example.com/zot"}}}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldNotPanic)
+	})
+
+	Convey("Test verify telemetry config", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"telemetry":{"enable":true}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+
+		content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"telemetry":{"enable":true,"endpoint":"https://stats.example.com","interval":"-1h"}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+
+		// the endpoint is only required if telemetry is enabled
+		content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"telemetry":{}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldNotPanic)
+
+		content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"telemetry":{"enable":true,"endpoint":"https://stats.example.com"}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldNotPanic)
+	})
+
+	Convey("Test verify plugins config", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"plugins":[{"name":"notifier"}]}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+
+		content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"plugins":[{"name":"notifier","path":"/a.so"},{"name":"notifier","path":"/b.so"}]}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+
+		content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+			"http":{"address":"127.0.0.1","port":"8080"},
+			"extensions":{"plugins":[{"name":"notifier","path":"/a.so","options":{"url":"https://example.com"}}]}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldNotPanic)
+	})
+
 	Convey("Test verify CVE warn for remote storage", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
@@ -317,6 +443,44 @@ func TestVerify(t *testing.T) {
 		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
 		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
 
+		// redis cache driver
+		content = []byte(`{
+			"storage":{
+			   "rootDirectory":"/tmp/zot",
+			   "dedupe":true,
+			   "remoteCache":true,
+			   "cacheDriver":{
+				  "name":"redis",
+				  "url":"redis://localhost:6379/0",
+				  "poolSize":20
+			   },
+			   "storageDriver":{
+				  "name":"s3",
+				  "rootdirectory":"/zot",
+				  "region":"us-east-2",
+				  "bucket":"zot-storage",
+				  "secure":true,
+				  "skipverify":false
+			   }
+			},
+			"http":{
+			   "address":"127.0.0.1",
+			   "port":"8080",
+			   "realm":"zot",
+			   "auth":{
+				  "htpasswd":{
+					 "path":"test/data/htpasswd"
+				  },
+				  "failDelay":1
+			   }
+			}
+		 }`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldNotPanic)
+
 		// remoteCache false but provided cacheDriver config, ignored
 		content = []byte(`{
 			"storage":{
@@ -567,11 +731,45 @@ func TestVerify(t *testing.T) {
 		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
 	})
 
-	Convey("Test verify storage driver different than s3", t, func(c C) {
+	Convey("Test verify negative search limits", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080"},
+							"extensions":{"search": {"enable": true, "limits": {"maxDepth": -1}}}}`)
+		_, err = tmpfile.Write(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+	})
+
+	Convey("Test verify gcs storage driver", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot", "remoteCache": false,
+							"storageDriver": {"name": "gcs", "bucket": "zot"},
+							"subPaths": {"/a": {"rootDirectory": "/zot-a", "remoteCache": false,
+							"storageDriver": {"name": "gcs", "bucket": "zot-a"}}}},
+							"http":{"address":"127.0.0.1","port":"8080","realm":"zot",
+							"auth":{"htpasswd":{"path":"test/data/htpasswd"},"failDelay":1}}}`)
+		_, err = tmpfile.Write(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		err = cli.NewServerRootCmd().Execute()
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Test verify storage driver different than s3 or gcs", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
 		defer os.Remove(tmpfile.Name()) // clean up
-		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot", "storageDriver": {"name": "gcs"}},
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot", "storageDriver": {"name": "azure"}},
 							"http":{"address":"127.0.0.1","port":"8080","realm":"zot",
 							"auth":{"htpasswd":{"path":"test/data/htpasswd"},"failDelay":1}}}`)
 		_, err = tmpfile.Write(content)
@@ -582,12 +780,12 @@ func TestVerify(t *testing.T) {
 		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
 	})
 
-	Convey("Test verify subpath storage driver different than s3", t, func(c C) {
+	Convey("Test verify subpath storage driver different than s3 or gcs", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
 		defer os.Remove(tmpfile.Name()) // clean up
 		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot", "storageDriver": {"name": "s3"},
-							"subPaths": {"/a": {"rootDirectory": "/zot-a","storageDriver": {"name": "gcs"}}}},
+							"subPaths": {"/a": {"rootDirectory": "/zot-a","storageDriver": {"name": "azure"}}}},
 							"http":{"address":"127.0.0.1","port":"8080","realm":"zot",
 							"auth":{"htpasswd":{"path":"test/data/htpasswd"},"failDelay":1}}}`)
 		_, err = tmpfile.Write(content)
@@ -707,6 +905,30 @@ func TestVerify(t *testing.T) {
 		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldNotPanic)
 	})
 
+	Convey("Test verify w/ authorization roles", t, func(c C) {
+		for role, valid := range map[string]bool{"reader": true, "maintainer": true, "owner": false} {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(fmt.Sprintf(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080","realm":"zot",
+							"auth":{"htpasswd":{"path":"test/data/htpasswd"},"failDelay":1},
+							"accessControl":{"repositories":{"**":{"policies":[{"users":["bob"],"roles":["%s"]}]}},
+							"adminPolicy":{"users":["admin"],"roles":["admin"]}}}}`, role))
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldNotPanic)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
+	})
+
 	Convey("Test verify anonymous authorization", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
@@ -937,95 +1159,390 @@ func TestVerify(t *testing.T) {
 		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
 	})
 
-	Convey("Test verify config with unknown keys", t, func(c C) {
+	Convey("Test verify sync with negative download limits", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
 		defer os.Remove(tmpfile.Name()) // clean up
-		content := []byte(`{"distSpecVersion": "1.0.0", "storage": {"rootDirectory": "/tmp/zot"},
-							"http": {"url": "127.0.0.1", "port": "8080"},
-							"log": {"level": "debug"}}`)
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080","realm":"zot",
+							"auth":{"htpasswd":{"path":"test/data/htpasswd"},"failDelay":1}},
+							"extensions":{"sync": {"registries": [{"urls":["localhost:9999"],
+							"maxParallelDownloads": -1, "content": [{"prefix":"repo**"}]}]}}}`)
 		_, err = tmpfile.Write(content)
 		So(err, ShouldBeNil)
 		err = tmpfile.Close()
 		So(err, ShouldBeNil)
 		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
 		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
-	})
 
-	Convey("Test verify config with missing basedn key", t, func(c C) {
-		tmpfile, err := os.CreateTemp("", "zot-test*.json")
-		So(err, ShouldBeNil)
-		defer os.Remove(tmpfile.Name()) // clean up
-		content := []byte(`{"distSpecVersion": "1.0.0", "storage": {"rootDirectory": "/tmp/zot"},
-							"http": {"auth": {"ldap": {"address": "ldap", "userattribute": "uid"}},
-							"address": "127.0.0.1", "port": "8080"},
-							"log": {"level": "debug"}}`)
-		_, err = tmpfile.Write(content)
-		So(err, ShouldBeNil)
-		err = tmpfile.Close()
+		content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080","realm":"zot",
+							"auth":{"htpasswd":{"path":"test/data/htpasswd"},"failDelay":1}},
+							"extensions":{"sync": {"maxConcurrentDownloads": -1, "registries": [{"urls":["localhost:9999"],
+							"maxParallelDownloads": 4, "content": [{"prefix":"repo**"}]}]}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
 		So(err, ShouldBeNil)
 		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
 		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+
+		content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080","realm":"zot",
+							"auth":{"htpasswd":{"path":"test/data/htpasswd"},"failDelay":1}},
+							"extensions":{"sync": {"maxConcurrentDownloads": 16, "registries": [{"urls":["localhost:9999"],
+							"maxParallelDownloads": 4, "content": [{"prefix":"repo**"}]}]}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		err = cli.NewServerRootCmd().Execute()
+		So(err, ShouldBeNil)
 	})
 
-	Convey("Test verify config with missing address key", t, func(c C) {
+	Convey("Test verify sync on demand rules", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
 		defer os.Remove(tmpfile.Name()) // clean up
-		content := []byte(`{"distSpecVersion": "1.0.0", "storage": {"rootDirectory": "/tmp/zot"},
-							"http": {"auth": {"ldap": {"basedn": "ou=Users,dc=example,dc=org", "userattribute": "uid"}},
-							"address": "127.0.0.1", "port": "8080"},
-							"log": {"level": "debug"}}`)
-		_, err = tmpfile.Write(content)
-		So(err, ShouldBeNil)
-		err = tmpfile.Close()
-		So(err, ShouldBeNil)
-		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
-		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+
+		for registryConfig, valid := range map[string]bool{
+			`"onDemand":true,"onDemandRules":{"allow":["library/**"],"deny":["library/tmp-*"]}`: true,
+			`"onDemand":false,"onDemandRules":{"deny":["**/test"]}`:                             true,
+			`"onDemand":true,"onDemandRules":{"allow":["library/[a"]}`:                          false,
+			`"onDemand":true,"onDemandRules":{"deny":["{a,b"]}`:                                 false,
+		} {
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080"},
+							"extensions":{"sync": {"registries": [{"urls":["localhost:9999"],` + registryConfig + `}]}}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
 	})
 
-	Convey("Test verify config with missing userattribute key", t, func(c C) {
+	Convey("Test verify storage commit policy", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
 		defer os.Remove(tmpfile.Name()) // clean up
-		content := []byte(`{"distSpecVersion": "1.0.0", "storage": {"rootDirectory": "/tmp/zot"},
-							"http": {"auth": {"ldap": {"basedn": "ou=Users,dc=example,dc=org", "address": "ldap"}},
-							"address": "127.0.0.1", "port": "8080"},
-							"log": {"level": "debug"}}`)
-		_, err = tmpfile.Write(content)
-		So(err, ShouldBeNil)
-		err = tmpfile.Close()
-		So(err, ShouldBeNil)
-		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
-		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+
+		for storageConfig, valid := range map[string]bool{
+			`{"rootDirectory":"/tmp/zot","commitPolicy":"periodic","commitInterval":"10s"}`:                       true,
+			`{"rootDirectory":"/tmp/zot","commit":true,"commitPolicy":"manifest"}`:                                true,
+			`{"rootDirectory":"/tmp/zot","commitInterval":"10s"}`:                                                 true,
+			`{"rootDirectory":"/tmp/zot","commitPolicy":"sometimes"}`:                                             false,
+			`{"rootDirectory":"/tmp/zot","commitPolicy":"periodic","commitInterval":"-1s"}`:                       false,
+			`{"rootDirectory":"/tmp/zot","subPaths":{"/a":{"rootDirectory":"/tmp/zot1","commitPolicy":"never"}}}`: false,
+		} {
+			content := []byte(`{"storage":` + storageConfig + `,
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
 	})
 
-	Convey("Test verify good config", t, func(c C) {
+	Convey("Test verify storage IO settings", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
 		defer os.Remove(tmpfile.Name()) // clean up
-		content := []byte(`{"distSpecVersion": "1.0.0", "storage": {"rootDirectory": "/tmp/zot"},
-							"http": {"address": "127.0.0.1", "port": "8080"},
-							"log": {"level": "debug"}}`)
-		_, err = tmpfile.Write(content)
-		So(err, ShouldBeNil)
-		err = tmpfile.Close()
+
+		for storageConfig, valid := range map[string]bool{
+			`{"rootDirectory":"/tmp/zot","io":{"writeBufferSize":1048576,"directIO":true,"preallocate":true}}`:       true,
+			`{"rootDirectory":"/tmp/zot","io":{"largeBlobSize":1048576,"readAhead":"sequential"}}`:                   true,
+			`{"rootDirectory":"/tmp/zot","io":{"writeBufferSize":-1}}`:                                               false,
+			`{"rootDirectory":"/tmp/zot","io":{"readAhead":"backwards"}}`:                                            false,
+			`{"rootDirectory":"/tmp/zot","subPaths":{"/a":{"rootDirectory":"/tmp/zot1","io":{"largeBlobSize":-1}}}}`: false,
+		} {
+			content := []byte(`{"storage":` + storageConfig + `,
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
+	})
+
+	Convey("Test verify events config", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
-		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
-		err = cli.NewServerRootCmd().Execute()
+		defer os.Remove(tmpfile.Name()) // clean up
+
+		for eventsConfig, valid := range map[string]bool{
+			`{}`: true,
+			`{"retention":"24h","maxEventsPerRepo":100}`: true,
+			`{"enable":false}`:                           true,
+			`{"retention":"-24h"}`:                       false,
+			`{"maxEventsPerRepo":-1}`:                    false,
+		} {
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080"},
+							"extensions":{"events":` + eventsConfig + `}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
+	})
+
+	Convey("Test verify dedupe strategy", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+
+		for storageConfig, valid := range map[string]bool{
+			`{"rootDirectory":"/tmp/zot","dedupe":true,"dedupeStrategy":"reflink"}`:                                true,
+			`{"rootDirectory":"/tmp/zot","dedupe":true,"dedupeStrategy":"hardlink"}`:                               true,
+			`{"rootDirectory":"/tmp/zot","dedupe":true,"dedupeStrategy":"copy"}`:                                   false,
+			`{"rootDirectory":"/tmp/zot","subPaths":{"/a":{"rootDirectory":"/tmp/zot1","dedupeStrategy":"link"}}}`: false,
+		} {
+			content := []byte(`{"storage":` + storageConfig + `,
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
 	})
-}
 
-func TestValidateExtensionsConfig(t *testing.T) {
-	Convey("Test missing extensions for UI to work", t, func(c C) {
-		config := config.New()
+	Convey("Test verify shared blobs", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
-		defer os.Remove(tmpfile.Name())
-		content := []byte(`{
-			"storage": {
-				"rootDirectory": "%/tmp/zot"
+		defer os.Remove(tmpfile.Name()) // clean up
+
+		for storageConfig, valid := range map[string]bool{
+			`{"rootDirectory":"/tmp/zot","sharedBlobs":true}`:                                                 true,
+			`{"rootDirectory":"/tmp/zot","subPaths":{"/a":{"rootDirectory":"/tmp/zot1","sharedBlobs":true}}}`: true,
+			`{"rootDirectory":"/tmp/zot","sharedBlobs":true,"storageDriver":{"name":"s3"}}`:                   false,
+		} {
+			content := []byte(`{"storage":` + storageConfig + `,
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
+	})
+
+	Convey("Test verify storage quota", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+
+		for quotaConfig, valid := range map[string]bool{
+			`{"maxBytes":1000000,"maxRepoBytes":1000,"repositories":{"large/**":10000}}`: true,
+			`{"maxBytes":-1}`:                     false,
+			`{"repositories":{"large/**":-1}}`:    false,
+			`{"repositories":{"large/[**":1000}}`: false,
+		} {
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot","quota":` + quotaConfig + `},
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
+	})
+
+	Convey("Test verify retention policies", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+
+		for storageConfig, valid := range map[string]bool{
+			`{"rootDirectory":"/tmp/zot","retention":{"dryRun":true,"policies":[{"repositories":["infra/**"],` +
+				`"keepTags":5,"maxAge":"720h","protectTags":["v\\d+\\.\\d+","latest"]}]}}`: true,
+			`{"rootDirectory":"/tmp/zot","retention":{"policies":[{"keepTags":-1}]}}`:            false,
+			`{"rootDirectory":"/tmp/zot","retention":{"policies":[{"repositories":["a/[**"]}]}}`: false,
+			`{"rootDirectory":"/tmp/zot","subPaths":{"/a":{"rootDirectory":"/tmp/zot1",` +
+				`"retention":{"policies":[{"protectTags":["v("]}]}}}}`: false,
+		} {
+			content := []byte(`{"storage":` + storageConfig + `,
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
+	})
+
+	Convey("Test verify gc blob verification percentage", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+
+		for storageConfig, valid := range map[string]bool{
+			`{"rootDirectory":"/tmp/zot","gc":true,"gcVerifyPercent":10}`:                                       true,
+			`{"rootDirectory":"/tmp/zot","gc":false,"gcVerifyPercent":10}`:                                      true,
+			`{"rootDirectory":"/tmp/zot","gc":true,"gcVerifyPercent":101}`:                                      false,
+			`{"rootDirectory":"/tmp/zot","subPaths":{"/a":{"rootDirectory":"/tmp/zot1","gcVerifyPercent":-1}}}`: false,
+		} {
+			content := []byte(`{"storage":` + storageConfig + `,
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
+	})
+
+	Convey("Test verify incremental gc batch size", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+
+		for storageConfig, valid := range map[string]bool{
+			`{"rootDirectory":"/tmp/zot","gc":true,"gcBatchSize":1000}`:                                     true,
+			`{"rootDirectory":"/tmp/zot","gc":false,"gcBatchSize":1000}`:                                    true,
+			`{"rootDirectory":"/tmp/zot","gc":true,"gcBatchSize":-1}`:                                       false,
+			`{"rootDirectory":"/tmp/zot","subPaths":{"/a":{"rootDirectory":"/tmp/zot1","gcBatchSize":-1}}}`: false,
+		} {
+			content := []byte(`{"storage":` + storageConfig + `,
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			if valid {
+				So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+			} else {
+				So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+			}
+		}
+	})
+
+	Convey("Test verify config with unknown keys", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"distSpecVersion": "1.0.0", "storage": {"rootDirectory": "/tmp/zot"},
+							"http": {"url": "127.0.0.1", "port": "8080"},
+							"log": {"level": "debug"}}`)
+		_, err = tmpfile.Write(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+	})
+
+	Convey("Test verify config with missing basedn key", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"distSpecVersion": "1.0.0", "storage": {"rootDirectory": "/tmp/zot"},
+							"http": {"auth": {"ldap": {"address": "ldap", "userattribute": "uid"}},
+							"address": "127.0.0.1", "port": "8080"},
+							"log": {"level": "debug"}}`)
+		_, err = tmpfile.Write(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+	})
+
+	Convey("Test verify config with missing address key", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"distSpecVersion": "1.0.0", "storage": {"rootDirectory": "/tmp/zot"},
+							"http": {"auth": {"ldap": {"basedn": "ou=Users,dc=example,dc=org", "userattribute": "uid"}},
+							"address": "127.0.0.1", "port": "8080"},
+							"log": {"level": "debug"}}`)
+		_, err = tmpfile.Write(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+	})
+
+	Convey("Test verify config with missing userattribute key", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"distSpecVersion": "1.0.0", "storage": {"rootDirectory": "/tmp/zot"},
+							"http": {"auth": {"ldap": {"basedn": "ou=Users,dc=example,dc=org", "address": "ldap"}},
+							"address": "127.0.0.1", "port": "8080"},
+							"log": {"level": "debug"}}`)
+		_, err = tmpfile.Write(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+	})
+
+	Convey("Test verify good config", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"distSpecVersion": "1.0.0", "storage": {"rootDirectory": "/tmp/zot"},
+							"http": {"address": "127.0.0.1", "port": "8080"},
+							"log": {"level": "debug"}}`)
+		_, err = tmpfile.Write(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		err = cli.NewServerRootCmd().Execute()
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestValidateExtensionsConfig(t *testing.T) {
+	Convey("Test missing extensions for UI to work", t, func(c C) {
+		config := config.New()
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name())
+		content := []byte(`{
+			"storage": {
+				"rootDirectory": "%/tmp/zot"
 			},
 			"http": {
 				"address": "127.0.0.1",
@@ -1109,6 +1626,85 @@ func TestValidateExtensionsConfig(t *testing.T) {
 		err = cli.LoadConfiguration(config, tmpfile.Name())
 		So(err, ShouldNotBeNil)
 	})
+
+	Convey("Test UI base path, logo and cache max age", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name())
+
+		logoPath := path.Join(t.TempDir(), "logo.png")
+		err = os.WriteFile(logoPath, []byte("logo"), 0o0600)
+		So(err, ShouldBeNil)
+
+		content := `{
+			"storage": {
+				"rootDirectory": "/tmp/zot"
+			},
+			"http": {
+				"address": "127.0.0.1",
+				"port": "8080"
+			},
+			"log": {
+				"level": "debug"
+			},
+			"extensions": {
+				"ui": {
+					"enable": true,
+					%s
+				},
+				"search": {
+					"enable": true
+				},
+				"mgmt": {
+					"enable": true
+				}
+			}
+		}`
+
+		for uiConfig, valid := range map[string]bool{
+			`"basePath": "/registry/", "logoPath": "` + logoPath + `", "cacheMaxAge": "2h"`: true,
+			`"basePath": "/"`:                   true,
+			`"basePath": "registry"`:            false,
+			`"basePath": "/registry/../v2"`:     false,
+			`"basePath": "/v2/ui"`:              false,
+			`"logoPath": "/does/not/exist.png"`: false,
+			`"cacheMaxAge": "-1h"`:              false,
+		} {
+			config := config.New()
+			err = os.WriteFile(tmpfile.Name(), []byte(fmt.Sprintf(content, uiConfig)), 0o0600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, tmpfile.Name())
+
+			if valid {
+				So(err, ShouldBeNil)
+			} else {
+				So(err, ShouldNotBeNil)
+			}
+		}
+	})
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	Convey("All the problems of the config are reported at once", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name())
+
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot","gcDelai":"1h","maxLeaseDuration":"-1h"},
+			"http":{"address":"127.0.0.1","port":"8080",
+			"auth":{"bearer":{"realm":"https://auth.example.com/auth/token"}}},
+			"extensions":{"telemetry":{"enable":true,"endpoint":"ftp://stats"}}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+
+		err = cli.LoadConfiguration(config.New(), tmpfile.Name())
+		So(errors.Is(err, zerr.ErrBadConfig), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "unknown keys")
+		So(err.Error(), ShouldContainSubstring, "gcdelai")
+		So(err.Error(), ShouldContainSubstring, "bearer auth requires realm, service and cert, missing cert, service")
+		So(err.Error(), ShouldContainSubstring, "max lease duration can not be negative")
+		So(err.Error(), ShouldContainSubstring, "telemetry endpoint must be an http or https URL")
+	})
 }
 
 func TestLoadConfig(t *testing.T) {
@@ -1117,6 +1713,67 @@ func TestLoadConfig(t *testing.T) {
 		err := cli.LoadConfiguration(config, "../../examples/config-policy.json")
 		So(err, ShouldBeNil)
 	})
+	Convey("Test listeners config", t, func(c C) {
+		conf := config.New()
+		err := cli.LoadConfiguration(conf, "../../examples/config-listeners.json")
+		So(err, ShouldBeNil)
+
+		listeners := conf.HTTP.GetListeners()
+		So(len(listeners), ShouldEqual, 2)
+		So(listeners[0].Port, ShouldEqual, "8081")
+		So(listeners[0].TLS, ShouldBeNil)
+		So(listeners[1].Address, ShouldEqual, "0.0.0.0")
+		So(listeners[1].TLS.Cert, ShouldEqual, "../../test/data/server.cert")
+
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"listeners":[{"address":"127.0.0.1","port":"65536"}]}}`)
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name())
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		err = cli.LoadConfiguration(conf, tmpfile.Name())
+		So(err, ShouldNotBeNil)
+
+		for listener, valid := range map[string]bool{
+			`{"address":"127.0.0.1","addresses":["::1"],"port":"8080"}`:              true,
+			`{"address":"","port":"8080","ipv6":false}`:                              true,
+			`{"address":"::","port":"8080","ipv4":false}`:                            true,
+			`{"address":"","port":"8080","ipv4":false,"ipv6":false}`:                 false,
+			`{"address":"127.0.0.1","addresses":["::1"],"port":"8080","ipv6":false}`: false,
+			`{"address":"0.0.0.0","port":"8080","ipv4":false}`:                       false,
+			// the auth settings of the listeners are checked like the http ones
+			`{"address":"127.0.0.1","port":"8080","auth":{"failDelay":1}}`:                         true,
+			`{"address":"127.0.0.1","port":"8080","auth":{"bearer":{"realm":"https://auth.io"}}}`:  false,
+			`{"address":"127.0.0.1","port":"8080","auth":{"ldap":{"address":"ldap.example.com"}}}`: false,
+		} {
+			conf := config.New()
+			content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},"http":{"listeners":[` + listener + `]}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(conf, tmpfile.Name())
+
+			if valid {
+				So(err, ShouldBeNil)
+			} else {
+				So(err, ShouldNotBeNil)
+			}
+		}
+
+		// the listeners without auth settings use the http ones
+		conf = config.New()
+		content = []byte(`{"storage":{"rootDirectory":"/tmp/zot"},"http":{"auth":{"failDelay":2},
+							"listeners":[{"address":"127.0.0.1","port":"8080"},
+							{"address":"127.0.0.1","port":"8081","auth":{"failDelay":1}}]}}`)
+		err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+		So(err, ShouldBeNil)
+		err = cli.LoadConfiguration(conf, tmpfile.Name())
+		So(err, ShouldBeNil)
+
+		listeners = conf.HTTP.GetListeners()
+		So(listeners[0].Auth.FailDelay, ShouldEqual, 2)
+		So(listeners[1].Auth.FailDelay, ShouldEqual, 1)
+	})
 	Convey("Test subpath config combination", t, func(c C) {
 		config := config.New()
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
@@ -1219,6 +1876,41 @@ func TestLoadConfig(t *testing.T) {
 		err = cli.LoadConfiguration(config, tmpfile.Name())
 		So(err, ShouldNotBeNil)
 	})
+
+	Convey("Test HTTP settings", t, func() {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name())
+
+		for httpConfig, valid := range map[string]bool{
+			`"externalURL":"https://example.com/registry/","trustedProxies":["10.0.0.1","fd00::/8"]`:          true,
+			`"externalURL":"example.com/registry"`:                                                            false,
+			`"externalURL":"ftp://example.com"`:                                                               false,
+			`"externalURL":"https://example.com/?a=b"`:                                                        false,
+			`"trustedProxies":["10.0.0.0/33"]`:                                                                false,
+			`"trustedProxies":["proxy.example.com"]`:                                                          false,
+			`"compression":{"minSize":512,"mediaTypes":["application/json"]}`:                                 true,
+			`"compression":{"minSize":-1}`:                                                                    false,
+			`"compression":{"mediaTypes":["application/"]}`:                                                   false,
+			`"loadShedding":{"fraction":0.25,"minHealthScore":0.6,"probeInterval":"10s","probeTimeout":"1s"}`: true,
+			`"loadShedding":{"fraction":1.5}`:                                                                 false,
+			`"loadShedding":{"minHealthScore":-0.1}`:                                                          false,
+			`"loadShedding":{"probeInterval":"-1s"}`:                                                          false,
+		} {
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080",` + httpConfig + `}}`)
+			err = os.WriteFile(tmpfile.Name(), content, 0o0600)
+			So(err, ShouldBeNil)
+			// each config is loaded from scratch, the settings of the previous one don't leak into it
+			err = cli.LoadConfiguration(config.New(), tmpfile.Name())
+
+			if valid {
+				So(err, ShouldBeNil)
+			} else {
+				So(err, ShouldNotBeNil)
+			}
+		}
+	})
 }
 
 func TestGC(t *testing.T) {
@@ -1329,6 +2021,373 @@ func TestGC(t *testing.T) {
 			err = cli.LoadConfiguration(config, file.Name())
 			So(err, ShouldNotBeNil)
 		})
+
+		Convey("Negative cache maintenance interval", func() {
+			config := config.New()
+			err = json.Unmarshal(contents, config)
+			config.Storage.CacheMaintenanceInterval = -1 * time.Second
+
+			file, err := os.CreateTemp("", "gc-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(config, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid digest in blocklist", func() {
+			config := config.New()
+			err = json.Unmarshal(contents, config)
+			config.Storage.Blocklist = []string{"sha256:bogus"}
+
+			file, err := os.CreateTemp("", "gc-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(config, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid tag alias rule", func() {
+			tagAliases := []config.TagAliasRule{{Tag: "(", Aliases: []string{"latest"}}}
+			config := config.New()
+			err = json.Unmarshal(contents, config)
+			config.Storage.TagAliases = tagAliases
+
+			file, err := os.CreateTemp("", "gc-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(config, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid immutable tags rule", func() {
+			immutableTags := []config.ImmutableTagsRule{{Tags: []string{"("}}}
+			config := config.New()
+			err = json.Unmarshal(contents, config)
+			config.Storage.ImmutableTags = immutableTags
+
+			file, err := os.CreateTemp("", "gc-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(config, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid pre-receive hook", func() {
+			preReceiveHooks := []config.PreReceiveHookConfig{{URL: "hooks.example.com/validate"}}
+			config := config.New()
+			err = json.Unmarshal(contents, config)
+			config.Storage.PreReceiveHooks = preReceiveHooks
+
+			file, err := os.CreateTemp("", "gc-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(config, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid blob policy", func() {
+			blobPolicies := []config.BlobPolicyConfig{{MaxLayerSize: -1}}
+			config := config.New()
+			err = json.Unmarshal(contents, config)
+			config.Storage.BlobPolicies = blobPolicies
+
+			file, err := os.CreateTemp("", "gc-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(config, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid layer recompression", func() {
+			layerRecompression := &config.LayerRecompressionConfig{Repositories: []string{"["}}
+			config := config.New()
+			err = json.Unmarshal(contents, config)
+			config.Storage.LayerRecompression = layerRecompression
+
+			file, err := os.CreateTemp("", "gc-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(config, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid referrers tags config", func() {
+			referrersTags := &config.ReferrersTagsConfig{Interval: -time.Hour}
+			config := config.New()
+			err = json.Unmarshal(contents, config)
+			config.Storage.ReferrersTags = referrersTags
+
+			file, err := os.CreateTemp("", "gc-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(config, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid ignored user agent pattern", func() {
+			enable := true
+			config := config.New()
+			err = json.Unmarshal(contents, config)
+			config.Extensions = &extconf.ExtensionConfig{
+				Search: &extconf.SearchConfig{
+					BaseConfig: extconf.BaseConfig{Enable: &enable},
+					Downloads:  &extconf.DownloadsConfig{IgnoredUserAgents: []string{"["}},
+				},
+			}
+
+			file, err := os.CreateTemp("", "gc-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(config, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid promotion gates", func() {
+			enable := true
+
+			for _, gate := range []extconf.PromotionGateConfig{
+				{Tag: "prod"},
+				{Environment: "prod", Tag: "("},
+				{Environment: "prod", Tag: "prod", SeverityThreshold: "SEVERE"},
+				// CVE scanning is not enabled
+				{Environment: "prod", Tag: "prod", SeverityThreshold: "HIGH"},
+			} {
+				config := config.New()
+				err = json.Unmarshal(contents, config)
+				config.Extensions = &extconf.ExtensionConfig{
+					Search: &extconf.SearchConfig{
+						BaseConfig:     extconf.BaseConfig{Enable: &enable},
+						PromotionGates: []extconf.PromotionGateConfig{gate},
+					},
+				}
+
+				file, err := os.CreateTemp("", "gc-config-*.json")
+				So(err, ShouldBeNil)
+				defer os.Remove(file.Name())
+
+				gateContents, err := json.MarshalIndent(config, "", " ")
+				So(err, ShouldBeNil)
+
+				err = os.WriteFile(file.Name(), gateContents, 0o600)
+				So(err, ShouldBeNil)
+				err = cli.LoadConfiguration(config, file.Name())
+				So(err, ShouldNotBeNil)
+			}
+		})
+
+		Convey("Invalid audit snapshots config", func() {
+			enable := true
+
+			for _, auditConfig := range []extconf.AuditSnapshotsConfig{
+				{},
+				{SigningKey: "/etc/zot/missing.key"},
+			} {
+				config := config.New()
+				err = json.Unmarshal(contents, config)
+				auditConfig := auditConfig
+				config.Extensions = &extconf.ExtensionConfig{
+					Search: &extconf.SearchConfig{
+						BaseConfig:     extconf.BaseConfig{Enable: &enable},
+						AuditSnapshots: &auditConfig,
+					},
+				}
+
+				file, err := os.CreateTemp("", "audit-config-*.json")
+				So(err, ShouldBeNil)
+				defer os.Remove(file.Name())
+
+				auditContents, err := json.MarshalIndent(config, "", " ")
+				So(err, ShouldBeNil)
+
+				err = os.WriteFile(file.Name(), auditContents, 0o600)
+				So(err, ShouldBeNil)
+				err = cli.LoadConfiguration(config, file.Name())
+				So(err, ShouldNotBeNil)
+			}
+		})
+
+		Convey("Invalid pull tokens config", func() {
+			cfg := config.New()
+			err = json.Unmarshal(contents, cfg)
+			cfg.HTTP.Auth = &config.AuthConfig{
+				Bearer: &config.BearerConfig{
+					Realm:      "https://auth.example.com/auth/token",
+					Service:    "auth.example.com",
+					Cert:       "/etc/zot/auth.crt",
+					PullTokens: &config.PullTokensConfig{KeyFile: "/etc/zot/missing.key"},
+				},
+			}
+
+			file, err := os.CreateTemp("", "pulltokens-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(cfg, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(cfg, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid lint action", func() {
+			enable := true
+
+			for _, lintConfig := range []*extconf.LintConfig{
+				{BaseConfig: extconf.BaseConfig{Enable: &enable}, Action: "ignore"},
+				{
+					BaseConfig: extconf.BaseConfig{Enable: &enable},
+					Rules:      []extconf.LintRule{{MandatoryAnnotation: "annotation1", Action: "ignore"}},
+				},
+				{
+					BaseConfig: extconf.BaseConfig{Enable: &enable},
+					Rules:      []extconf.LintRule{{Action: extconf.LintActionWarn}},
+				},
+			} {
+				config := config.New()
+				err = json.Unmarshal(contents, config)
+				config.Extensions = &extconf.ExtensionConfig{Lint: lintConfig}
+
+				file, err := os.CreateTemp("", "gc-config-*.json")
+				So(err, ShouldBeNil)
+				defer os.Remove(file.Name())
+
+				configContents, err := json.MarshalIndent(config, "", " ")
+				So(err, ShouldBeNil)
+
+				err = os.WriteFile(file.Name(), configContents, 0o600)
+				So(err, ShouldBeNil)
+				err = cli.LoadConfiguration(config, file.Name())
+				So(err, ShouldNotBeNil)
+			}
+		})
+
+		Convey("Negative max lease duration", func() {
+			config := config.New()
+			err = json.Unmarshal(contents, config)
+			config.Storage.MaxLeaseDuration = -1 * time.Minute
+
+			file, err := os.CreateTemp("", "gc-config-*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+
+			contents, err = json.MarshalIndent(config, "", " ")
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(file.Name(), contents, 0o600)
+			So(err, ShouldBeNil)
+			err = cli.LoadConfiguration(config, file.Name())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid tenants", func() {
+			for _, tenants := range [][]config.TenantConfig{
+				{{Name: "acme"}},
+				{{Name: "acme", PathPrefix: "/acme"}, {Name: "globex", PathPrefix: "/acme"}},
+				{{Name: "acme", PathPrefix: "/acme", Storage: &config.StorageConfig{}}},
+				{{Name: "acme", PathPrefix: "/acme", Storage: &config.StorageConfig{RootDirectory: "/tmp/zot"}}},
+			} {
+				config := config.New()
+				err = json.Unmarshal(contents, config)
+				So(err, ShouldBeNil)
+
+				config.Storage.RootDirectory = "/tmp/zot"
+				config.Tenants = tenants
+
+				file, err := os.CreateTemp("", "gc-config-*.json")
+				So(err, ShouldBeNil)
+				defer os.Remove(file.Name())
+
+				contents, err := json.MarshalIndent(config, "", " ")
+				So(err, ShouldBeNil)
+
+				err = os.WriteFile(file.Name(), contents, 0o600)
+				So(err, ShouldBeNil)
+				err = cli.LoadConfiguration(config, file.Name())
+				So(err, ShouldNotBeNil)
+			}
+		})
+
+		Convey("Negative circuit breaker settings", func() {
+			for _, breakerConfig := range []config.CircuitBreakerConfig{
+				{FailureThreshold: -1},
+				{OpenDuration: -1 * time.Second},
+			} {
+				config := config.New()
+				err = json.Unmarshal(contents, config)
+				So(err, ShouldBeNil)
+
+				breakerConfig := breakerConfig
+				config.CircuitBreaker = &breakerConfig
+
+				file, err := os.CreateTemp("", "gc-config-*.json")
+				So(err, ShouldBeNil)
+				defer os.Remove(file.Name())
+
+				contents, err := json.MarshalIndent(config, "", " ")
+				So(err, ShouldBeNil)
+
+				err = os.WriteFile(file.Name(), contents, 0o600)
+				So(err, ShouldBeNil)
+				err = cli.LoadConfiguration(config, file.Name())
+				So(err, ShouldNotBeNil)
+			}
+		})
 	})
 }
 
@@ -1492,3 +2551,84 @@ func TestScrub(t *testing.T) {
 		})
 	})
 }
+
+func TestMigrate(t *testing.T) {
+	oldArgs := os.Args
+
+	defer func() { os.Args = oldArgs }()
+
+	Convey("Test migrate help", t, func(c C) {
+		os.Args = []string{"cli_test", "migrate", "-h"}
+		err := cli.NewServerRootCmd().Execute()
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Test migrate no args", t, func(c C) {
+		os.Args = []string{"cli_test", "migrate"}
+		err := cli.NewServerRootCmd().Execute()
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Test migrate config", t, func(c C) {
+		dir := t.TempDir()
+
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(fmt.Sprintf(`{
+			"storage": {
+				"rootDirectory": "%s"
+			},
+			"http": {
+				"port": %s
+			},
+			"log": {
+				"level": "debug"
+			}
+		}
+		`, dir, GetFreePort()))
+		_, err = tmpfile.Write(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+
+		Convey("non-existent config", func(c C) {
+			os.Args = []string{"cli_test", "migrate", path.Join(os.TempDir(), "/x.yaml")}
+			So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+		})
+
+		Convey("dry run and migration", func(c C) {
+			output := &strings.Builder{}
+
+			os.Args = []string{"cli_test", "migrate", "--dry-run", tmpfile.Name()}
+			rootCmd := cli.NewServerRootCmd()
+			rootCmd.SetOut(output)
+			err := rootCmd.Execute()
+			So(err, ShouldBeNil)
+			So(output.String(), ShouldContainSubstring, "pending migration to version 1")
+
+			_, err = os.Stat(path.Join(dir, storageConstants.LayoutVersionFile))
+			So(os.IsNotExist(err), ShouldBeTrue)
+
+			output.Reset()
+
+			os.Args = []string{"cli_test", "migrate", tmpfile.Name()}
+			rootCmd = cli.NewServerRootCmd()
+			rootCmd.SetOut(output)
+			err = rootCmd.Execute()
+			So(err, ShouldBeNil)
+			So(output.String(), ShouldContainSubstring, "applied migration to version 1")
+
+			_, err = os.Stat(path.Join(dir, storageConstants.LayoutVersionFile))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("newer layout version", func(c C) {
+			err := os.WriteFile(path.Join(dir, storageConstants.LayoutVersionFile), []byte(`{"version":1000}`), 0o600)
+			So(err, ShouldBeNil)
+
+			os.Args = []string{"cli_test", "migrate", "--dry-run", tmpfile.Name()}
+			So(func() { _ = cli.NewServerRootCmd().Execute() }, ShouldPanic)
+		})
+	})
+}
@@ -17,7 +17,7 @@ import (
 func NewSearchCommand(searchService SearchService) *cobra.Command {
 	searchImageParams := make(map[string]*string)
 
-	var servURL, user, outputFormat string
+	var servURL, user, outputFormat, filter, columns string
 
 	var isSpinner, verifyTLS, verbose, debug bool
 
@@ -90,6 +90,8 @@ Example:
 				outputFormat:  &outputFormat,
 				verbose:       &verbose,
 				debug:         &debug,
+				filter:        &filter,
+				columns:       &columns,
 				spinner:       spinnerState{spin, isSpinner},
 				verifyTLS:     &verifyTLS,
 				resultWriter:  cmd.OutOrStdout(),
@@ -108,6 +110,7 @@ Example:
 	}
 
 	setupSearchFlags(imageCmd, searchImageParams, &servURL, &user, &outputFormat, &verbose, &debug)
+	setupOutputFilterFlags(imageCmd, &filter, &columns)
 	imageCmd.SetUsageTemplate(imageCmd.UsageTemplate() + usageFooter)
 
 	return imageCmd
@@ -125,7 +128,7 @@ func setupSearchFlags(imageCmd *cobra.Command, searchImageParams map[string]*str
 
 	imageCmd.Flags().StringVar(servURL, "url", "", "Specify zot server URL if config-name is not mentioned")
 	imageCmd.Flags().StringVarP(user, "user", "u", "", `User Credentials of zot server in "username:password" format`)
-	imageCmd.Flags().StringVarP(outputFormat, "output", "o", "", "Specify output format [text/json/yaml]")
+	imageCmd.Flags().StringVarP(outputFormat, "output", "o", "", "Specify output format [text/table/json/yaml]")
 	imageCmd.Flags().BoolVar(verbose, "verbose", false, "Show verbose output")
 	imageCmd.Flags().BoolVar(debug, "debug", false, "Show debug output")
 }
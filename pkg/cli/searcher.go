@@ -108,6 +108,8 @@ type searchConfig struct {
 	fixedFlag     *bool
 	verbose       *bool
 	debug         *bool
+	filter        *string
+	columns       *string
 	resultWriter  io.Writer
 	spinner       spinnerState
 }
@@ -393,8 +395,6 @@ func (search cveByImageSearcherGQL) search(config searchConfig) (bool, error) {
 		return true, errInvalidImageNameAndTag
 	}
 
-	var builder strings.Builder
-
 	username, password := getUsernameAndPassword(*config.user)
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -406,26 +406,42 @@ func (search cveByImageSearcherGQL) search(config searchConfig) (bool, error) {
 		return true, err
 	}
 
-	if len(cveList.Data.CVEListForImage.CVEList) > 0 &&
-		(*config.outputFormat == defaultOutoutFormat || *config.outputFormat == "") {
-		printCVETableHeader(&builder, *config.verbose, 0, 0, 0)
-		fmt.Fprint(config.resultWriter, builder.String())
+	return true, printCVEResult(config, *cveList)
+}
+
+func printCVEResult(config searchConfig, cveList cveResult) error {
+	var err error
+
+	cveList.Data.CVEListForImage.CVEList, err = filterResults(config, cveList.Data.CVEListForImage.CVEList)
+	if err != nil {
+		return err
+	}
+
+	if useSelectedColumns(config) {
+		return printSelectedColumns(config, cveList.Data.CVEListForImage.CVEList, defaultCVEColumns)
 	}
 
 	if len(cveList.Data.CVEListForImage.CVEList) == 0 {
 		fmt.Fprint(config.resultWriter, "No CVEs found for image\n")
 
-		return true, nil
+		return nil
+	}
+
+	if *config.outputFormat == defaultOutoutFormat || *config.outputFormat == "" {
+		var builder strings.Builder
+
+		printCVETableHeader(&builder, *config.verbose, 0, 0, 0)
+		fmt.Fprint(config.resultWriter, builder.String())
 	}
 
 	out, err := cveList.string(*config.outputFormat)
 	if err != nil {
-		return true, err
+		return err
 	}
 
 	fmt.Fprint(config.resultWriter, out)
 
-	return true, nil
+	return nil
 }
 
 type imagesByCVEIDSearcher struct{}
@@ -778,6 +794,8 @@ func collectResults(config searchConfig, wg *sync.WaitGroup, imageErr chan strin
 ) {
 	var foundResult bool
 
+	var collected []interface{}
+
 	defer wg.Done()
 	config.spinner.startSpinner()
 
@@ -789,6 +807,12 @@ func collectResults(config searchConfig, wg *sync.WaitGroup, imageErr chan strin
 			if !ok {
 				cancel()
 
+				if len(collected) > 0 {
+					if err := printCollectedResults(config, collected); err != nil {
+						errCh <- err
+					}
+				}
+
 				return
 			}
 
@@ -799,6 +823,12 @@ func collectResults(config searchConfig, wg *sync.WaitGroup, imageErr chan strin
 				return
 			}
 
+			if result.Value != nil {
+				collected = append(collected, result.Value)
+
+				continue
+			}
+
 			if !foundResult && (*config.outputFormat == defaultOutoutFormat || *config.outputFormat == "") {
 				var builder strings.Builder
 
@@ -820,6 +850,22 @@ func collectResults(config searchConfig, wg *sync.WaitGroup, imageErr chan strin
 	}
 }
 
+// printCollectedResults prints the images, or the CVE list, received by collectResults once they are all known.
+func printCollectedResults(config searchConfig, collected []interface{}) error {
+	imageList := []imageStruct{}
+
+	for _, value := range collected {
+		switch result := value.(type) {
+		case imageStruct:
+			imageList = append(imageList, result)
+		case cveResult:
+			return printCVEResult(config, result)
+		}
+	}
+
+	return printImageResult(config, imageList)
+}
+
 func getUsernameAndPassword(user string) (string, string) {
 	if strings.Contains(user, ":") {
 		split := strings.Split(user, ":")
@@ -900,6 +946,8 @@ var (
 type stringResult struct {
 	StrValue string
 	Err      error
+	// the image or CVE list sent instead of StrValue when the results are collected, see collectsResults
+	Value interface{}
 }
 
 type printHeader func(writer io.Writer, verbose bool, maxImageNameLen, maxTagLen, maxPlatformLen int)
@@ -970,7 +1018,7 @@ func printCVETableHeader(writer io.Writer, verbose bool, maxImgLen, maxTagLen, m
 }
 
 func printReferrersTableHeader(config searchConfig, writer io.Writer, maxArtifactTypeLen int) {
-	if *config.outputFormat != "" && *config.outputFormat != defaultOutoutFormat {
+	if (*config.outputFormat != "" && *config.outputFormat != defaultOutoutFormat) || useSelectedColumns(config) {
 		return
 	}
 
@@ -1046,6 +1094,15 @@ func printRepoTableHeader(writer io.Writer, repoMaxLen, maxTimeLen int, verbose
 }
 
 func printReferrersResult(config searchConfig, referrersList referrersResult, maxArtifactTypeLen int) error {
+	referrersList, err := filterResults(config, referrersList)
+	if err != nil {
+		return err
+	}
+
+	if useSelectedColumns(config) {
+		return printSelectedColumns(config, referrersList, defaultReferrerColumns)
+	}
+
 	out, err := referrersList.string(*config.outputFormat, maxArtifactTypeLen)
 	if err != nil {
 		return err
@@ -1057,6 +1114,15 @@ func printReferrersResult(config searchConfig, referrersList referrersResult, ma
 }
 
 func printImageResult(config searchConfig, imageList []imageStruct) error {
+	imageList, err := filterResults(config, imageList)
+	if err != nil {
+		return err
+	}
+
+	if useSelectedColumns(config) {
+		return printSelectedColumns(config, imageList, defaultImageColumns)
+	}
+
 	var builder strings.Builder
 	maxImgNameLen := 0
 	maxTagLen := 0
@@ -1101,6 +1167,15 @@ func printImageResult(config searchConfig, imageList []imageStruct) error {
 }
 
 func printRepoResults(config searchConfig, repoList []repoStruct) error {
+	repoList, err := filterResults(config, repoList)
+	if err != nil {
+		return err
+	}
+
+	if useSelectedColumns(config) {
+		return printSelectedColumns(config, repoList, defaultRepoColumns)
+	}
+
 	maxRepoNameLen := 0
 	maxTimeLen := 0
 
@@ -480,7 +480,7 @@ func (service searchService) getAllImages(ctx context.Context, config searchConf
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -491,7 +491,7 @@ func (service searchService) getAllImages(ctx context.Context, config searchConf
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -523,7 +523,7 @@ func getImage(ctx context.Context, config searchConfig, username, password, imag
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -536,7 +536,7 @@ func getImage(ctx context.Context, config searchConfig, username, password, imag
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -590,7 +590,7 @@ func (service searchService) getImagesByCveID(ctx context.Context, config search
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -605,7 +605,7 @@ func (service searchService) getImagesByCveID(ctx context.Context, config search
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", errors.New(errBuilder.String())} //nolint: goerr113
+		rch <- stringResult{Err: errors.New(errBuilder.String())} //nolint: goerr113
 
 		return
 	}
@@ -659,7 +659,7 @@ func (service searchService) getImagesByDigest(ctx context.Context, config searc
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -674,7 +674,7 @@ func (service searchService) getImagesByDigest(ctx context.Context, config searc
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", errors.New(errBuilder.String())} //nolint: goerr113
+		rch <- stringResult{Err: errors.New(errBuilder.String())} //nolint: goerr113
 
 		return
 	}
@@ -728,7 +728,7 @@ func (service searchService) getImageByNameAndCVEID(ctx context.Context, config
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -743,7 +743,7 @@ func (service searchService) getImageByNameAndCVEID(ctx context.Context, config
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", errors.New(errBuilder.String())} //nolint: goerr113
+		rch <- stringResult{Err: errors.New(errBuilder.String())} //nolint: goerr113
 
 		return
 	}
@@ -784,7 +784,7 @@ func (service searchService) getCveByImage(ctx context.Context, config searchCon
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -799,19 +799,27 @@ func (service searchService) getCveByImage(ctx context.Context, config searchCon
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", errors.New(errBuilder.String())} //nolint: goerr113
+		rch <- stringResult{Err: errors.New(errBuilder.String())} //nolint: goerr113
 
 		return
 	}
 
 	result.Data.CVEListForImage.CVEList = groupCVEsBySeverity(result.Data.CVEListForImage.CVEList)
 
+	if collectsResults(config) {
+		if !isContextDone(ctx) {
+			rch <- stringResult{Value: *result}
+		}
+
+		return
+	}
+
 	str, err := result.string(*config.outputFormat)
 	if err != nil {
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -819,7 +827,7 @@ func (service searchService) getCveByImage(ctx context.Context, config searchCon
 	if isContextDone(ctx) {
 		return
 	}
-	rch <- stringResult{str, nil}
+	rch <- stringResult{StrValue: str}
 }
 
 func (service searchService) getFixedTagsForCVE(ctx context.Context, config searchConfig,
@@ -854,7 +862,7 @@ func (service searchService) getFixedTagsForCVE(ctx context.Context, config sear
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -869,7 +877,7 @@ func (service searchService) getFixedTagsForCVE(ctx context.Context, config sear
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", errors.New(errBuilder.String())} //nolint: goerr113
+		rch <- stringResult{Err: errors.New(errBuilder.String())} //nolint: goerr113
 
 		return
 	}
@@ -1002,7 +1010,7 @@ func addManifestCallToPool(ctx context.Context, config searchConfig, pool *reque
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 	}
 
 	job := httpJob{
@@ -1334,9 +1342,9 @@ func addImageToTable(table *tablewriter.Table, img *imageStruct, maxPlatformLen
 	imageName, tagName string, verbose bool,
 ) error {
 	switch img.MediaType {
-	case ispec.MediaTypeImageManifest:
+	case ispec.MediaTypeImageManifest, common.MediaTypeDockerManifest:
 		return addManifestToTable(table, imageName, tagName, &img.Manifests[0], maxPlatformLen, verbose)
-	case ispec.MediaTypeImageIndex:
+	case ispec.MediaTypeImageIndex, common.MediaTypeDockerManifestList:
 		return addImageIndexToTable(table, img, maxPlatformLen, imageName, tagName, verbose)
 	}
 
@@ -1604,7 +1612,7 @@ func (service searchService) getRepos(ctx context.Context, config searchConfig,
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
@@ -1615,7 +1623,7 @@ func (service searchService) getRepos(ctx context.Context, config searchConfig,
 		if isContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		rch <- stringResult{Err: err}
 
 		return
 	}
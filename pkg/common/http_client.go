@@ -128,6 +128,30 @@ func MakeHTTPGetRequest(httpClient *http.Client, username string, password strin
 		req.SetBasicAuth(username, password)
 	}
 
+	return doHTTPGetRequest(httpClient, req, resultPtr, blobURL, log)
+}
+
+// MakeHTTPGetRequestWithToken is like MakeHTTPGetRequest, authenticating with a bearer token.
+func MakeHTTPGetRequestWithToken(httpClient *http.Client, token string, resultPtr interface{},
+	blobURL string, mediaType string, log log.Logger,
+) ([]byte, string, int, error) {
+	req, err := http.NewRequest(http.MethodGet, blobURL, nil) //nolint
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if mediaType != "" {
+		req.Header.Set("Accept", mediaType)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return doHTTPGetRequest(httpClient, req, resultPtr, blobURL, log)
+}
+
+func doHTTPGetRequest(httpClient *http.Client, req *http.Request, resultPtr interface{},
+	blobURL string, log log.Logger,
+) ([]byte, string, int, error) {
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		log.Error().Str("errorType", TypeOf(err)).
@@ -120,6 +120,17 @@ func GetFullImageName(repo, ref string) string {
 	return repo + "@" + ref
 }
 
+// GetRepoNamespace returns the namespace of a repo, the first component of its name, repos without one share
+// the empty namespace.
+func GetRepoNamespace(repo string) string {
+	namespace, _, found := strings.Cut(repo, "/")
+	if !found {
+		return ""
+	}
+
+	return namespace
+}
+
 func IsDigest(ref string) bool {
 	_, err := digest.Parse(ref)
 
@@ -129,3 +140,131 @@ func IsDigest(ref string) bool {
 func IsTag(ref string) bool {
 	return !IsDigest(ref)
 }
+
+// layer media types used by ocicrypt, see https://github.com/opencontainers/image-spec/pull/775.
+const (
+	MediaTypeImageLayerEncrypted     = ispec.MediaTypeImageLayer + "+encrypted"
+	MediaTypeImageLayerGzipEncrypted = ispec.MediaTypeImageLayerGzip + "+encrypted"
+	MediaTypeImageLayerZstdEncrypted = ispec.MediaTypeImageLayerZstd + "+encrypted"
+
+	encryptedMediaTypeSuffix = "+encrypted"
+)
+
+// IsEncryptedLayer returns true if the layer media type is an ocicrypt encrypted one.
+func IsEncryptedLayer(mediaType string) bool {
+	return strings.HasSuffix(mediaType, encryptedMediaTypeSuffix)
+}
+
+// IsImageEncrypted returns true if at least one of the layers of the image is encrypted.
+func IsImageEncrypted(manifestContent ispec.Manifest) bool {
+	for _, layer := range manifestContent.Layers {
+		if IsEncryptedLayer(layer.MediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// docker media types, pushed by clients which don't produce oci manifests.
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerImageConfig  = "application/vnd.docker.container.image.v1+json"
+
+	// deprecated docker image manifest format, which has no config and lists its layers in reverse order.
+	MediaTypeDockerSchema1Manifest       = "application/vnd.docker.distribution.manifest.v1+json"
+	MediaTypeDockerSchema1SignedManifest = "application/vnd.docker.distribution.manifest.v1+prettyjws"
+)
+
+// docker layer media types, which have oci equivalents.
+const (
+	MediaTypeDockerLayer              = "application/vnd.docker.image.rootfs.diff.tar"
+	MediaTypeDockerLayerGzip          = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	MediaTypeDockerLayerZstd          = "application/vnd.docker.image.rootfs.diff.tar.zstd"
+	MediaTypeDockerForeignLayer       = "application/vnd.docker.image.rootfs.foreign.diff.tar"
+	MediaTypeDockerForeignLayerGzip   = "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip"
+	MediaTypeDockerLayerEncrypted     = MediaTypeDockerLayer + encryptedMediaTypeSuffix
+	MediaTypeDockerLayerGzipEncrypted = MediaTypeDockerLayerGzip + encryptedMediaTypeSuffix
+	MediaTypeDockerLayerZstdEncrypted = MediaTypeDockerLayerZstd + encryptedMediaTypeSuffix
+)
+
+// annotation of the manifests converted from docker media types to oci ones, holding the digest they were pushed with.
+const AnnotationOriginalDigest = "io.zotregistry.image.original-digest"
+
+// annotation of the tag descriptors in index.json, holding when the tag was last pointed to its manifest.
+const AnnotationTagPushedAt = "io.zotregistry.tag.pushed-at"
+
+// DockerLayerToOCI returns the oci media type of a docker layer media type, other media types are returned as is.
+func DockerLayerToOCI(mediaType string) string {
+	switch mediaType {
+	case MediaTypeDockerLayer:
+		return ispec.MediaTypeImageLayer
+	case MediaTypeDockerLayerGzip:
+		return ispec.MediaTypeImageLayerGzip
+	case MediaTypeDockerLayerZstd:
+		return ispec.MediaTypeImageLayerZstd
+	case MediaTypeDockerForeignLayer:
+		return ispec.MediaTypeImageLayerNonDistributable //nolint:staticcheck
+	case MediaTypeDockerForeignLayerGzip:
+		return ispec.MediaTypeImageLayerNonDistributableGzip //nolint:staticcheck
+	case MediaTypeDockerLayerEncrypted:
+		return MediaTypeImageLayerEncrypted
+	case MediaTypeDockerLayerGzipEncrypted:
+		return MediaTypeImageLayerGzipEncrypted
+	case MediaTypeDockerLayerZstdEncrypted:
+		return MediaTypeImageLayerZstdEncrypted
+	default:
+		return mediaType
+	}
+}
+
+// IsImageManifest returns true for the oci image manifest and the docker schema2 manifest media types.
+func IsImageManifest(mediaType string) bool {
+	return mediaType == ispec.MediaTypeImageManifest || mediaType == MediaTypeDockerManifest
+}
+
+// IsImageIndex returns true for the oci image index and the docker manifest list media types.
+func IsImageIndex(mediaType string) bool {
+	return mediaType == ispec.MediaTypeImageIndex || mediaType == MediaTypeDockerManifestList
+}
+
+// IsImageConfig returns true for the oci and docker image config media types.
+func IsImageConfig(mediaType string) bool {
+	return mediaType == ispec.MediaTypeImageConfig || mediaType == MediaTypeDockerImageConfig
+}
+
+// IsSchema1Manifest returns true for the signed and unsigned docker schema1 manifest media types.
+func IsSchema1Manifest(mediaType string) bool {
+	return mediaType == MediaTypeDockerSchema1Manifest || mediaType == MediaTypeDockerSchema1SignedManifest
+}
+
+// Schema1Manifest is the part of a docker schema1 manifest zot needs to keep track of its layers.
+type Schema1Manifest struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	Name          string           `json:"name"`
+	Tag           string           `json:"tag"`
+	FSLayers      []Schema1FSLayer `json:"fsLayers"`
+}
+
+type Schema1FSLayer struct {
+	BlobSum digest.Digest `json:"blobSum"`
+}
+
+// Layers returns the distinct layer digests of a schema1 manifest, which repeats the empty layers.
+func (manifest Schema1Manifest) Layers() []digest.Digest {
+	layers := make([]digest.Digest, 0, len(manifest.FSLayers))
+	seen := map[digest.Digest]bool{}
+
+	for _, layer := range manifest.FSLayers {
+		if seen[layer.BlobSum] {
+			continue
+		}
+
+		seen[layer.BlobSum] = true
+
+		layers = append(layers, layer.BlobSum)
+	}
+
+	return layers
+}
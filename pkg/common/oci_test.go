@@ -3,6 +3,8 @@ package common_test
 import (
 	"testing"
 
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	. "github.com/smartystreets/goconvey/convey"
 
 	"zotregistry.io/zot/pkg/common"
@@ -14,4 +16,55 @@ func TestOCI(t *testing.T) {
 		So(repo, ShouldResemble, "image")
 		So(digest, ShouldResemble, "")
 	})
+
+	Convey("Test repo namespace", t, func() {
+		So(common.GetRepoNamespace("infra/tools/builder"), ShouldEqual, "infra")
+		So(common.GetRepoNamespace("team-a/app"), ShouldEqual, "team-a")
+		So(common.GetRepoNamespace("busybox"), ShouldEqual, "")
+	})
+
+	Convey("Test encrypted layers", t, func() {
+		So(common.IsEncryptedLayer(common.MediaTypeImageLayerGzipEncrypted), ShouldBeTrue)
+		So(common.IsEncryptedLayer("application/vnd.docker.image.rootfs.diff.tar.gzip+encrypted"), ShouldBeTrue)
+		So(common.IsEncryptedLayer(ispec.MediaTypeImageLayerGzip), ShouldBeFalse)
+
+		manifest := ispec.Manifest{
+			Layers: []ispec.Descriptor{
+				{MediaType: ispec.MediaTypeImageLayerGzip},
+			},
+		}
+		So(common.IsImageEncrypted(manifest), ShouldBeFalse)
+
+		manifest.Layers = append(manifest.Layers, ispec.Descriptor{MediaType: common.MediaTypeImageLayerZstdEncrypted})
+		So(common.IsImageEncrypted(manifest), ShouldBeTrue)
+	})
+	Convey("Test docker media types", t, func() {
+		So(common.IsImageManifest(ispec.MediaTypeImageManifest), ShouldBeTrue)
+		So(common.IsImageManifest(common.MediaTypeDockerManifest), ShouldBeTrue)
+		So(common.IsImageManifest(common.MediaTypeDockerManifestList), ShouldBeFalse)
+
+		So(common.IsImageIndex(ispec.MediaTypeImageIndex), ShouldBeTrue)
+		So(common.IsImageIndex(common.MediaTypeDockerManifestList), ShouldBeTrue)
+		So(common.IsImageIndex(common.MediaTypeDockerManifest), ShouldBeFalse)
+
+		So(common.IsImageConfig(ispec.MediaTypeImageConfig), ShouldBeTrue)
+		So(common.IsImageConfig(common.MediaTypeDockerImageConfig), ShouldBeTrue)
+		So(common.IsImageConfig(ispec.MediaTypeImageLayer), ShouldBeFalse)
+
+		So(common.IsSchema1Manifest(common.MediaTypeDockerSchema1Manifest), ShouldBeTrue)
+		So(common.IsSchema1Manifest(common.MediaTypeDockerSchema1SignedManifest), ShouldBeTrue)
+		So(common.IsSchema1Manifest(common.MediaTypeDockerManifest), ShouldBeFalse)
+	})
+
+	Convey("Test schema1 manifest layers", t, func() {
+		layer1 := godigest.FromString("layer1")
+		layer2 := godigest.FromString("layer2")
+
+		manifest := common.Schema1Manifest{SchemaVersion: 1}
+		for _, layer := range []godigest.Digest{layer1, layer2, layer1} {
+			manifest.FSLayers = append(manifest.FSLayers, common.Schema1FSLayer{BlobSum: layer})
+		}
+
+		So(manifest.Layers(), ShouldResemble, []godigest.Digest{layer1, layer2})
+	})
 }
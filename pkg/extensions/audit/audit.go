@@ -0,0 +1,469 @@
+package audit
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+	godigest "github.com/opencontainers/go-digest"
+	imeta "github.com/opencontainers/image-spec/specs-go"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.io/zot/errors"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+const (
+	// DefaultRepository is the repo the snapshots are pushed to when the config doesn't set one.
+	DefaultRepository = "zot-audit"
+	// SnapshotMediaType is the artifact type of the snapshots and the media type of their single layer.
+	SnapshotMediaType = "application/vnd.zot.audit.snapshot.v1+json"
+	// AnnotationRepository is the repo a snapshot was taken of.
+	AnnotationRepository = "io.zotregistry.audit.repository"
+	// AnnotationSignature is the base64 signature of the snapshot layer, by the signing key of the config.
+	AnnotationSignature = "io.zotregistry.audit.signature"
+
+	// ScanStatusScanned tells the scan summary of an image is its scan with the current vulnerability databases.
+	ScanStatusScanned = "scanned"
+	// ScanStatusUnknown tells an image wasn't scanned since the vulnerability databases were last updated.
+	ScanStatusUnknown = "unknown"
+
+	// tags are limited to 128 characters by the distribution spec
+	maxTagLength  = 128
+	tagTimeFormat = "20060102T150405Z"
+)
+
+// Snapshot lists the tags of a repo at a given time, sorted, with their signatures and scan summary.
+type Snapshot struct {
+	Repository string        `json:"repository"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	Tags       []TagSnapshot `json:"tags"`
+}
+
+type TagSnapshot struct {
+	Tag       string `json:"tag"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType"`
+	Signed    bool   `json:"signed"`
+	// types of the signatures of the image, e.g. cosign and notation, sorted
+	SignatureTypes []string `json:"signatureTypes,omitempty"`
+	// scan of the image at the time of the snapshot, not set if CVE scanning is disabled
+	Scan *ScanSummary `json:"scan,omitempty"`
+}
+
+type ScanSummary struct {
+	// ScanStatusScanned or ScanStatusUnknown, the vulnerabilities are only known for scanned images
+	Status          string     `json:"status"`
+	Vulnerabilities int        `json:"vulnerabilities,omitempty"`
+	MaxSeverity     string     `json:"maxSeverity,omitempty"`
+	ScannedAt       *time.Time `json:"scannedAt,omitempty"`
+}
+
+// ScanResults gives the summary of the images scanned with the current vulnerability databases, without
+// scanning them.
+type ScanResults interface {
+	GetCachedCVESummary(repo, digest, mediaType string) (cvemodel.ImageCVESummary, bool)
+}
+
+// Result tells where a snapshot was pushed.
+type Result struct {
+	Repository      string `json:"repository"`
+	AuditRepository string `json:"auditRepository"`
+	Tag             string `json:"tag"`
+	Digest          string `json:"digest"`
+}
+
+/*
+Snapshotter pushes signed snapshots of the repos to the audit repo. A snapshot is an artifact whose single layer is
+the Snapshot document of a repo, the same repo content at the same time always gives the same document. It's
+tagged with its creation time followed by the repo name, e.g. 20231016T120000Z-apps_frontend, and signed with the
+signing key, the base64 signature of the layer being kept in the AnnotationSignature annotation of the manifest.
+*/
+type Snapshotter struct {
+	auditRepo       string
+	repositories    []string
+	interval        time.Duration
+	signingKey      crypto.Signer
+	storeController storage.StoreController
+	repoDB          repodb.RepoDB
+	scanResults     ScanResults
+	log             log.Logger
+}
+
+// New validates the audit snapshots config and loads the signing key.
+func New(auditConfig extconf.AuditSnapshotsConfig, storeController storage.StoreController,
+	repoDB repodb.RepoDB, log log.Logger,
+) (*Snapshotter, error) {
+	for _, pattern := range auditConfig.Repositories {
+		if !glob.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("%w: invalid repository pattern %s", zerr.ErrBadAuditSnapshots, pattern)
+		}
+	}
+
+	if auditConfig.Interval < 0 {
+		return nil, fmt.Errorf("%w: negative interval", zerr.ErrBadAuditSnapshots)
+	}
+
+	signingKey, err := loadSigningKey(auditConfig.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+
+	auditRepo := auditConfig.Repository
+	if auditRepo == "" {
+		auditRepo = DefaultRepository
+	}
+
+	return &Snapshotter{
+		auditRepo:       auditRepo,
+		repositories:    auditConfig.Repositories,
+		interval:        auditConfig.Interval,
+		signingKey:      signingKey,
+		storeController: storeController,
+		repoDB:          repoDB,
+		log:             log,
+	}, nil
+}
+
+func loadSigningKey(keyPath string) (crypto.Signer, error) {
+	if keyPath == "" {
+		return nil, fmt.Errorf("%w: no signing key", zerr.ErrBadAuditSnapshots)
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", zerr.ErrBadAuditSnapshots, err)
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block in signing key %s", zerr.ErrBadAuditSnapshots, keyPath)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", zerr.ErrBadAuditSnapshots, err)
+	}
+
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	case *rsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported signing key type %T", zerr.ErrBadAuditSnapshots, key)
+	}
+}
+
+// SetScanResults sets where the scan summaries of the snapshots are taken from, snapshots have none if it's not set.
+func (snapshotter *Snapshotter) SetScanResults(scanResults ScanResults) {
+	snapshotter.scanResults = scanResults
+}
+
+// PublicKey returns the public key the snapshots can be verified with.
+func (snapshotter *Snapshotter) PublicKey() crypto.PublicKey {
+	return snapshotter.signingKey.Public()
+}
+
+// RunPeriodically snapshots the matching repos, one repo per task, every interval, if one is set.
+func (snapshotter *Snapshotter) RunPeriodically(sch *scheduler.Scheduler) {
+	if snapshotter.interval == 0 {
+		return
+	}
+
+	sch.SubmitGenerator(&taskGenerator{snapshotter: snapshotter}, snapshotter.interval, scheduler.LowPriority)
+}
+
+// GetSnapshot returns the snapshot of a repo at createdAt, as listed by repodb.
+func (snapshotter *Snapshotter) GetSnapshot(repo string, createdAt time.Time) (Snapshot, error) {
+	repoMeta, err := snapshotter.repoDB.GetRepoMeta(repo)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := Snapshot{
+		Repository: repo,
+		CreatedAt:  createdAt.UTC().Truncate(time.Second),
+		Tags:       make([]TagSnapshot, 0, len(repoMeta.Tags)),
+	}
+
+	for tag, desc := range repoMeta.Tags {
+		tagSnapshot := TagSnapshot{Tag: tag, Digest: desc.Digest, MediaType: desc.MediaType}
+
+		for signatureType, signatures := range repoMeta.Signatures[desc.Digest] {
+			if len(signatures) > 0 {
+				tagSnapshot.SignatureTypes = append(tagSnapshot.SignatureTypes, signatureType)
+			}
+		}
+
+		sort.Strings(tagSnapshot.SignatureTypes)
+		tagSnapshot.Signed = len(tagSnapshot.SignatureTypes) > 0
+
+		if snapshotter.scanResults != nil {
+			tagSnapshot.Scan = snapshotter.getScanSummary(repo, desc, repoMeta)
+		}
+
+		snapshot.Tags = append(snapshot.Tags, tagSnapshot)
+	}
+
+	sort.Slice(snapshot.Tags, func(i, j int) bool { return snapshot.Tags[i].Tag < snapshot.Tags[j].Tag })
+
+	return snapshot, nil
+}
+
+// getScanSummary summarizes the scan of an image with the current vulnerability databases, the summary recorded
+// in repodb may be from a scan with older databases, so images whose scan isn't cached have an unknown status.
+func (snapshotter *Snapshotter) getScanSummary(repo string, desc repodb.Descriptor, repoMeta repodb.RepoMetadata,
+) *ScanSummary {
+	summary, ok := snapshotter.scanResults.GetCachedCVESummary(repo, desc.Digest, desc.MediaType)
+	if !ok {
+		return &ScanSummary{Status: ScanStatusUnknown}
+	}
+
+	scanSummary := &ScanSummary{
+		Status:          ScanStatusScanned,
+		Vulnerabilities: summary.Count,
+		MaxSeverity:     summary.MaxSeverity,
+	}
+
+	if recorded, found := repoMeta.VulnerabilitySummaries[desc.Digest]; found && !recorded.UpdatedAt.IsZero() {
+		scannedAt := recorded.UpdatedAt.UTC()
+		scanSummary.ScannedAt = &scannedAt
+	}
+
+	return scanSummary
+}
+
+// SnapshotRepo pushes a signed snapshot of a repo, taken now, to the audit repo.
+func (snapshotter *Snapshotter) SnapshotRepo(repo string) (Result, error) {
+	snapshot, err := snapshotter.GetSnapshot(repo, time.Now())
+	if err != nil {
+		return Result{}, err
+	}
+
+	snapshotBlob, err := json.Marshal(snapshot)
+	if err != nil {
+		return Result{}, err
+	}
+
+	signature, err := Sign(snapshotter.signingKey, snapshotBlob)
+	if err != nil {
+		return Result{}, err
+	}
+
+	imgStore := snapshotter.storeController.GetImageStore(snapshotter.auditRepo)
+
+	snapshotDigest := godigest.FromBytes(snapshotBlob)
+
+	if _, _, err := imgStore.FullBlobUpload(snapshotter.auditRepo, bytes.NewReader(snapshotBlob),
+		snapshotDigest); err != nil {
+		return Result{}, err
+	}
+
+	emptyConfig := ispec.DescriptorEmptyJSON
+
+	if _, _, err := imgStore.FullBlobUpload(snapshotter.auditRepo, bytes.NewReader(emptyConfig.Data),
+		emptyConfig.Digest); err != nil {
+		return Result{}, err
+	}
+
+	emptyConfig.Data = nil
+
+	manifest := ispec.Manifest{
+		Versioned:    imeta.Versioned{SchemaVersion: storageConstants.SchemaVersion},
+		MediaType:    ispec.MediaTypeImageManifest,
+		ArtifactType: SnapshotMediaType,
+		Config:       emptyConfig,
+		Layers: []ispec.Descriptor{{
+			MediaType: SnapshotMediaType,
+			Digest:    snapshotDigest,
+			Size:      int64(len(snapshotBlob)),
+		}},
+		Annotations: map[string]string{
+			ispec.AnnotationCreated: snapshot.CreatedAt.Format(time.RFC3339),
+			AnnotationRepository:    repo,
+			AnnotationSignature:     base64.StdEncoding.EncodeToString(signature),
+		},
+	}
+
+	manifestBlob, err := json.Marshal(manifest)
+	if err != nil {
+		return Result{}, err
+	}
+
+	tag := GetSnapshotTag(repo, snapshot.CreatedAt)
+
+	digest, _, err := imgStore.PutImageManifest(snapshotter.auditRepo, tag, ispec.MediaTypeImageManifest,
+		manifestBlob)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := meta.OnUpdateManifest(snapshotter.auditRepo, tag, ispec.MediaTypeImageManifest, digest, manifestBlob,
+		snapshotter.storeController, snapshotter.repoDB, snapshotter.log); err != nil {
+		return Result{}, err
+	}
+
+	snapshotter.log.Info().Str("repository", repo).Str("auditRepository", snapshotter.auditRepo).Str("tag", tag).
+		Str("digest", digest.String()).Int("tags", len(snapshot.Tags)).Msg("audit: pushed repo snapshot")
+
+	return Result{
+		Repository:      repo,
+		AuditRepository: snapshotter.auditRepo,
+		Tag:             tag,
+		Digest:          digest.String(),
+	}, nil
+}
+
+// GetSnapshotTag returns the tag of the snapshot of a repo taken at createdAt, e.g.
+// 20231016T120000Z-apps_frontend, truncated to the longest tag allowed.
+func GetSnapshotTag(repo string, createdAt time.Time) string {
+	tag := createdAt.UTC().Format(tagTimeFormat) + "-" + strings.ReplaceAll(repo, "/", "_")
+
+	if len(tag) > maxTagLength {
+		tag = tag[:maxTagLength]
+	}
+
+	return tag
+}
+
+// Sign signs a snapshot layer, ed25519 keys sign it as is, ECDSA and RSA (PKCS #1 v1.5) keys its SHA-256 hash.
+func Sign(key crypto.Signer, blob []byte) ([]byte, error) {
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		return key.Sign(rand.Reader, blob, crypto.Hash(0))
+	}
+
+	hash := sha256.Sum256(blob)
+
+	return key.Sign(rand.Reader, hash[:], crypto.SHA256)
+}
+
+// Verify checks the signature of a snapshot layer, as signed by Sign with the private key of publicKey.
+func Verify(publicKey crypto.PublicKey, blob, signature []byte) error {
+	hash := sha256.Sum256(blob)
+
+	verified := false
+
+	switch publicKey := publicKey.(type) {
+	case ed25519.PublicKey:
+		verified = ed25519.Verify(publicKey, blob, signature)
+	case *ecdsa.PublicKey:
+		verified = ecdsa.VerifyASN1(publicKey, hash[:], signature)
+	case *rsa.PublicKey:
+		verified = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature) == nil
+	}
+
+	if !verified {
+		return zerr.ErrBadAuditSnapshotSignature
+	}
+
+	return nil
+}
+
+func (snapshotter *Snapshotter) matchesRepo(repo string) bool {
+	if len(snapshotter.repositories) == 0 {
+		return true
+	}
+
+	for _, pattern := range snapshotter.repositories {
+		// patterns are validated by New
+		if matched, _ := glob.Match(pattern, repo); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getRepositories lists the repos the periodic snapshots are taken of, the audit repo is never snapshotted.
+func (snapshotter *Snapshotter) getRepositories() ([]string, error) {
+	repos, err := snapshotter.storeController.GetRepositories()
+	if err != nil {
+		return nil, err
+	}
+
+	matchingRepos := []string{}
+
+	for _, repo := range repos {
+		if repo != snapshotter.auditRepo && snapshotter.matchesRepo(repo) {
+			matchingRepos = append(matchingRepos, repo)
+		}
+	}
+
+	return matchingRepos, nil
+}
+
+type taskGenerator struct {
+	snapshotter *Snapshotter
+	// repos left to snapshot in this pass, listed when it starts
+	repos  []string
+	listed bool
+	done   bool
+}
+
+func (gen *taskGenerator) Next() (scheduler.Task, error) {
+	if !gen.listed {
+		repos, err := gen.snapshotter.getRepositories()
+		if err != nil {
+			return nil, err
+		}
+
+		gen.repos = repos
+		gen.listed = true
+	}
+
+	if len(gen.repos) == 0 {
+		gen.done = true
+
+		return nil, nil
+	}
+
+	repo := gen.repos[0]
+	gen.repos = gen.repos[1:]
+
+	return &snapshotTask{snapshotter: gen.snapshotter, repo: repo}, nil
+}
+
+func (gen *taskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *taskGenerator) Reset() {
+	gen.repos = nil
+	gen.listed = false
+	gen.done = false
+}
+
+type snapshotTask struct {
+	snapshotter *Snapshotter
+	repo        string
+}
+
+func (task *snapshotTask) DoWork() error {
+	_, err := task.snapshotter.SnapshotRepo(task.repo)
+
+	return err
+}
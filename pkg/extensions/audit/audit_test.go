@@ -0,0 +1,234 @@
+package audit_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/extensions/audit"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/storage"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/local"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+// writeSigningKey writes the PKCS #8 PEM encoding of key to a file of dir and returns its path.
+func writeSigningKey(dir string, key crypto.Signer) string {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	So(err, ShouldBeNil)
+
+	keyPath := path.Join(dir, "audit.key")
+
+	err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600)
+	So(err, ShouldBeNil)
+
+	return keyPath
+}
+
+func TestSnapshotRepo(t *testing.T) {
+	log := log.NewLogger("debug", "")
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	signedDigest := godigest.FromString("signed")
+	unsignedDigest := godigest.FromString("unsigned")
+	scannedAt := time.Date(2023, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	repoDB := mocks.RepoDBMock{
+		GetRepoMetaFn: func(repo string) (repodb.RepoMetadata, error) {
+			if repo != "apps/frontend" {
+				return repodb.RepoMetadata{}, zerr.ErrRepoMetaNotFound
+			}
+
+			return repodb.RepoMetadata{
+				Name: repo,
+				Tags: map[string]repodb.Descriptor{
+					"2.0": {Digest: unsignedDigest.String(), MediaType: ispec.MediaTypeImageIndex},
+					"1.0": {Digest: signedDigest.String(), MediaType: ispec.MediaTypeImageManifest},
+				},
+				Signatures: map[string]repodb.ManifestSignatures{
+					signedDigest.String(): {
+						"notation": {{SignatureManifestDigest: godigest.FromString("notation").String()}},
+						"cosign":   {{SignatureManifestDigest: godigest.FromString("cosign").String()}},
+					},
+					unsignedDigest.String(): {"cosign": {}},
+				},
+				VulnerabilitySummaries: map[string]repodb.VulnerabilitySummary{
+					signedDigest.String(): {MaxSeverity: "HIGH", Count: 3, UpdatedAt: scannedAt},
+				},
+			}, nil
+		},
+	}
+
+	Convey("Push signed snapshots of the repos", t, func() {
+		dir := t.TempDir()
+		imgStore := local.NewImageStore(dir, false, storageConstants.DefaultGCDelay, false, false,
+			log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		So(err, ShouldBeNil)
+
+		snapshotter, err := audit.New(extconf.AuditSnapshotsConfig{SigningKey: writeSigningKey(dir, key)},
+			storeController, repoDB, log)
+		So(err, ShouldBeNil)
+
+		createdAt := time.Date(2023, 10, 16, 12, 30, 0, 500, time.UTC)
+
+		// without CVE scanning the snapshots have no scan summary
+		snapshot, err := snapshotter.GetSnapshot("apps/frontend", createdAt)
+		So(err, ShouldBeNil)
+		So(snapshot.Tags, ShouldHaveLength, 2)
+		So(snapshot.Tags[0].Scan, ShouldBeNil)
+		So(snapshot.Tags[1].Scan, ShouldBeNil)
+
+		// only the result of the scan of 1.0 with the current vulnerability databases is cached
+		snapshotter.SetScanResults(mocks.CveInfoMock{
+			GetCachedCVESummaryFn: func(repo, digest, mediaType string) (cvemodel.ImageCVESummary, bool) {
+				if digest != signedDigest.String() {
+					return cvemodel.ImageCVESummary{}, false
+				}
+
+				return cvemodel.ImageCVESummary{Count: 3, MaxSeverity: "HIGH"}, true
+			},
+		})
+
+		snapshot, err = snapshotter.GetSnapshot("apps/frontend", createdAt)
+		So(err, ShouldBeNil)
+		So(snapshot.CreatedAt, ShouldEqual, createdAt.Truncate(time.Second))
+		So(snapshot.Tags, ShouldResemble, []audit.TagSnapshot{
+			{
+				Tag:            "1.0",
+				Digest:         signedDigest.String(),
+				MediaType:      ispec.MediaTypeImageManifest,
+				Signed:         true,
+				SignatureTypes: []string{"cosign", "notation"},
+				Scan: &audit.ScanSummary{
+					Status:          audit.ScanStatusScanned,
+					Vulnerabilities: 3,
+					MaxSeverity:     "HIGH",
+					ScannedAt:       &scannedAt,
+				},
+			},
+			{
+				Tag:       "2.0",
+				Digest:    unsignedDigest.String(),
+				MediaType: ispec.MediaTypeImageIndex,
+				Scan:      &audit.ScanSummary{Status: audit.ScanStatusUnknown},
+			},
+		})
+
+		// the same repo content at the same time gives the same document
+		snapshotBlob, err := json.Marshal(snapshot)
+		So(err, ShouldBeNil)
+
+		for i := 0; i < 5; i++ {
+			snapshot, err := snapshotter.GetSnapshot("apps/frontend", createdAt)
+			So(err, ShouldBeNil)
+
+			blob, err := json.Marshal(snapshot)
+			So(err, ShouldBeNil)
+			So(blob, ShouldResemble, snapshotBlob)
+		}
+
+		result, err := snapshotter.SnapshotRepo("apps/frontend")
+		So(err, ShouldBeNil)
+		So(result.Repository, ShouldEqual, "apps/frontend")
+		So(result.AuditRepository, ShouldEqual, audit.DefaultRepository)
+		So(result.Tag, ShouldEndWith, "-apps_frontend")
+
+		manifestBlob, manifestDigest, _, err := imgStore.GetImageManifest(audit.DefaultRepository, result.Tag)
+		So(err, ShouldBeNil)
+		So(manifestDigest.String(), ShouldEqual, result.Digest)
+
+		var manifest ispec.Manifest
+		err = json.Unmarshal(manifestBlob, &manifest)
+		So(err, ShouldBeNil)
+		So(manifest.ArtifactType, ShouldEqual, audit.SnapshotMediaType)
+		So(manifest.Annotations[audit.AnnotationRepository], ShouldEqual, "apps/frontend")
+		So(manifest.Layers, ShouldHaveLength, 1)
+
+		layerBlob, err := imgStore.GetBlobContent(audit.DefaultRepository, manifest.Layers[0].Digest)
+		So(err, ShouldBeNil)
+
+		var pushed audit.Snapshot
+		err = json.Unmarshal(layerBlob, &pushed)
+		So(err, ShouldBeNil)
+		So(pushed.Tags, ShouldResemble, snapshot.Tags)
+		So(manifest.Annotations[ispec.AnnotationCreated], ShouldEqual, pushed.CreatedAt.Format(time.RFC3339))
+		So(result.Tag, ShouldEqual, audit.GetSnapshotTag("apps/frontend", pushed.CreatedAt))
+
+		signature, err := base64.StdEncoding.DecodeString(manifest.Annotations[audit.AnnotationSignature])
+		So(err, ShouldBeNil)
+		So(audit.Verify(snapshotter.PublicKey(), layerBlob, signature), ShouldBeNil)
+
+		tampered := []byte(strings.Replace(string(layerBlob), "HIGH", "LOW", 1))
+		So(audit.Verify(snapshotter.PublicKey(), tampered, signature), ShouldWrap,
+			zerr.ErrBadAuditSnapshotSignature)
+
+		_, err = snapshotter.SnapshotRepo("missing")
+		So(err, ShouldWrap, zerr.ErrRepoMetaNotFound)
+	})
+
+	Convey("Sign snapshots with ECDSA keys", t, func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		So(err, ShouldBeNil)
+
+		blob := []byte(`{"repository":"apps/frontend"}`)
+
+		signature, err := audit.Sign(key, blob)
+		So(err, ShouldBeNil)
+		So(audit.Verify(key.Public(), blob, signature), ShouldBeNil)
+		So(audit.Verify(key.Public(), []byte("{}"), signature), ShouldNotBeNil)
+	})
+
+	Convey("Truncate the tags of repos with long names", t, func() {
+		tag := audit.GetSnapshotTag(strings.Repeat("a", 200), time.Date(2023, 10, 16, 12, 0, 0, 0, time.UTC))
+		So(tag, ShouldHaveLength, 128)
+		So(tag, ShouldStartWith, "20231016T120000Z-aaa")
+	})
+
+	Convey("Reject invalid configs", t, func() {
+		dir := t.TempDir()
+
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		So(err, ShouldBeNil)
+
+		keyPath := writeSigningKey(dir, key)
+
+		notPEM := path.Join(dir, "not-pem.key")
+		err = os.WriteFile(notPEM, []byte("not a key"), 0o600)
+		So(err, ShouldBeNil)
+
+		for _, auditConfig := range []extconf.AuditSnapshotsConfig{
+			{},
+			{SigningKey: path.Join(dir, "missing.key")},
+			{SigningKey: notPEM},
+			{SigningKey: keyPath, Repositories: []string{"apps/["}},
+			{SigningKey: keyPath, Interval: -time.Hour},
+		} {
+			_, err := audit.New(auditConfig, storage.StoreController{}, nil, log)
+			So(err, ShouldWrap, zerr.ErrBadAuditSnapshots)
+		}
+	})
+}
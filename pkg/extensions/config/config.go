@@ -12,38 +12,166 @@ type BaseConfig struct {
 }
 
 type ExtensionConfig struct {
-	Search  *SearchConfig
-	Sync    *sync.Config
-	Metrics *MetricsConfig
-	Scrub   *ScrubConfig
-	Lint    *LintConfig
-	UI      *UIConfig
-	Mgmt    *MgmtConfig
+	Search       *SearchConfig
+	Sync         *sync.Config
+	Metrics      *MetricsConfig
+	Scrub        *ScrubConfig
+	Lint         *LintConfig
+	UI           *UIConfig
+	Mgmt         *MgmtConfig
+	UserActivity *UserActivityConfig
+	Telemetry    *TelemetryConfig
+	Plugins      []PluginConfig
+	Events       *EventsConfig
+}
+
+// EventsConfig keeps the registry events in a log, so consumers can catch up on the ones they missed.
+// The log is also kept when notifier plugins are loaded, unless it is disabled.
+type EventsConfig struct {
+	BaseConfig       `mapstructure:",squash"`
+	Retention        time.Duration // how long events are kept, default is 7 days
+	MaxEventsPerRepo int           // events kept for each repo, the oldest are dropped first, default is 1000
+}
+
+// PluginConfig attaches a third-party extension, running in its own process, at startup.
+type PluginConfig struct {
+	BaseConfig `mapstructure:",squash"`
+	Name       string
+	Path       string                 // executable serving the plugin with plugins.Serve
+	Options    map[string]interface{} // passed to the plugin as json
+}
+
+// TelemetryConfig is opt-in, anonymous usage statistics are only reported if enable is explicitly set to true.
+type TelemetryConfig struct {
+	BaseConfig `mapstructure:",squash"`
+	Endpoint   string        // URL the usage statistics are posted to
+	Interval   time.Duration // default is 24 hours
 }
 
 type MgmtConfig struct {
 	BaseConfig `mapstructure:",squash"`
 }
 
+type UserActivityConfig struct {
+	BaseConfig          `mapstructure:",squash"`
+	MaxEntries          int  // number of actions kept for each user, if not specified default is 100
+	RecordClientAddress bool // store the address of the client which made the request
+	RecordUserAgent     bool // store the user agent of the client which made the request
+}
+
+const (
+	LintActionReject = "reject"
+	LintActionWarn   = "warn"
+)
+
 type LintConfig struct {
 	BaseConfig           `mapstructure:",squash"`
 	MandatoryAnnotations []string
+	// reject the images violating the rules, or accept them with Warning headers, if not specified default is reject
+	Action string
+	// rules with an action of their own, checked along with the mandatory annotations
+	Rules []LintRule
+}
+
+// LintRule is a mandatory annotation with the action taken on the images missing it.
+type LintRule struct {
+	MandatoryAnnotation string
+	// if not specified default is the action of the lint config
+	Action string
 }
 
 type SearchConfig struct {
 	BaseConfig `mapstructure:",squash"`
 	// CVE search
 	CVE *CVEConfig
+	// limits applied to graphQL queries
+	Limits *QueryLimitsConfig
+	// minimum number of hex characters of the abbreviated digests resolved by the digests endpoint,
+	// if not specified default is 8
+	MinDigestPrefixLength int
+	// pulls counted in the download counts of the images, every GET of a manifest is counted if not specified
+	Downloads *DownloadsConfig
+	// add the age of the image, its last scan time and the days since its base image was updated to the
+	// responses to manifest GETs
+	FreshnessHeaders bool
+	// tags which can only be created through the promotion API, for images passing the checks of the gates
+	PromotionGates []PromotionGateConfig
+	// signed snapshots of the tags of the repos, with their signatures and scan summaries, pushed to an audit
+	// repo, not taken if not set
+	AuditSnapshots *AuditSnapshotsConfig
+}
+
+// AuditSnapshotsConfig pushes signed snapshots of the repos to a dedicated repo, as evidence of the images they
+// served, and whether these were signed and vulnerable, at a given time. Snapshots are taken on demand, and
+// periodically if an interval is set.
+type AuditSnapshotsConfig struct {
+	// repo the snapshots are pushed to, default is zot-audit
+	Repository string
+	// glob patterns of the repos the periodic snapshots are taken of, all repos if empty
+	Repositories []string
+	// time between two periodic snapshots of the repos, snapshots are only taken on demand if not specified
+	Interval time.Duration
+	// PEM file holding the PKCS #8 private key the snapshots are signed with, ed25519, ECDSA or RSA
+	SigningKey string
+}
+
+// PromotionGateConfig protects the tags of an environment, e.g. prod, pushing them is denied, they are created
+// by promoting an image already in the repo once it passes the checks of the gate.
+type PromotionGateConfig struct {
+	// name of the environment, e.g. prod, reported in the gate failures
+	Environment string
+	// glob patterns of the repos the gate applies to, all repos if empty
+	Repositories []string
+	// regular expression the whole tag has to match, e.g. prod or prod-.*
+	Tag string
+	// the image has to be signed, with cosign or notation
+	RequireSignature bool
+	// the image has to be scanned and have no vulnerabilities of this severity or a higher one, e.g. HIGH,
+	// acknowledged vulnerabilities are ignored, vulnerabilities are not checked if not specified
+	SeverityThreshold string
+	// the image has to be referred by a provenance attestation
+	RequireProvenance bool
+	// artifact types of the referrers accepted as provenance attestations, cosign attestations are always
+	// accepted, default is application/vnd.in-toto+json
+	ProvenanceArtifactTypes []string
+}
+
+type DownloadsConfig struct {
+	// pulls of an image by the same user, or from the same IP for anonymous users, are counted once per window,
+	// if not specified pulls are not deduplicated
+	DedupWindow time.Duration
+	// glob patterns of the user agents whose pulls are not counted, e.g. kube-probe/*, matched ignoring case
+	IgnoredUserAgents []string
+}
+
+type QueryLimitsConfig struct {
+	MaxDepth      int // maximum nesting of fields in a query, if not specified default is 20
+	MaxComplexity int // maximum complexity score of a query, if not specified default is 2000
+	MaxResultSize int // maximum size in bytes of a query response, if not specified responses are not limited
 }
 
 type CVEConfig struct {
 	UpdateInterval time.Duration // should be 2 hours or more, if not specified default be kept as 24 hours
 	Trivy          *TrivyConfig
+	// number of images scanned at the same time, the other scans wait in a queue, default is 1
+	MaxConcurrentScans int
+	// periodic vulnerability reports by namespace, not generated if not set
+	Report *CVEReportConfig
+}
+
+type CVEReportConfig struct {
+	Interval time.Duration // default is 24 hours
+	Webhook  string        // optional URL the reports are posted to
 }
 
 type TrivyConfig struct {
 	DBRepository     string // default is "ghcr.io/aquasecurity/trivy-db"
 	JavaDBRepository string // default is "ghcr.io/aquasecurity/trivy-java-db"
+	// the Java DB is updated on its own schedule, if not specified default is the CVE update interval
+	JavaDBUpdateInterval time.Duration
+	// directory holding a Java DB (trivy-java.db and metadata.json) loaded instead of downloading it,
+	// e.g. for air-gapped registries, it is loaded again at each update interval
+	JavaDBPath string
 }
 
 type MetricsConfig struct {
@@ -62,4 +190,12 @@ type ScrubConfig struct {
 
 type UIConfig struct {
 	BaseConfig `mapstructure:",squash"`
+	// URL path the UI is served under, e.g. /registry when a reverse proxy forwards it there
+	BasePath string
+	// replaces the title of the UI pages
+	Title string
+	// image file served as the logo of the UI
+	LogoPath string
+	// how long browsers cache the static files which aren't content addressed, e.g. the favicon
+	CacheMaxAge time.Duration
 }
@@ -15,7 +15,9 @@ type Credentials struct {
 type Config struct {
 	Enable          *bool
 	CredentialsFile string
-	Registries      []RegistryConfig
+	// max number of layers downloaded at the same time by all registries, 0 means no limit
+	MaxConcurrentDownloads int
+	Registries             []RegistryConfig
 }
 
 type RegistryConfig struct {
@@ -28,6 +30,21 @@ type RegistryConfig struct {
 	MaxRetries   *int
 	RetryDelay   *time.Duration
 	OnlySigned   *bool
+	Peering      bool
+	// on demand syncs use the credentials of the user pulling the image instead of the credentials file
+	ForwardIdentity bool
+	// max number of layers of an image downloaded in parallel
+	MaxParallelDownloads int
+	// restricts the repos which may be synced on demand, every repo matching the content may be if not set
+	OnDemandRules *OnDemandRules
+}
+
+// OnDemandRules are glob patterns matched against the local name of the repos pulled, deny rules win.
+type OnDemandRules struct {
+	// repos which may be synced on demand, every repo if empty
+	Allow []string
+	// repos which are never synced on demand, even if allowed
+	Deny []string
 }
 
 type Content struct {
@@ -35,6 +52,15 @@ type Content struct {
 	Tags        *Tags
 	Destination string `mapstructure:",omitempty"`
 	StripPrefix bool
+	// sync only the referrers (signatures, SBOMs, ...) of the images already present locally, not the images
+	ReferrersOnly bool
+	// read back the blobs of the newly synced images, so the first pull is served from the storage caches
+	Prefetch *Prefetch
+}
+
+type Prefetch struct {
+	// prefetch only the images of repos downloaded at least MinDownloads times, 0 prefetches every image
+	MinDownloads int
 }
 
 type Tags struct {
@@ -0,0 +1,233 @@
+//go:build search
+// +build search
+
+package extensions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	zreg "zotregistry.io/zot/pkg/regexp"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+type RegistryAnnotations struct {
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type RegistryAnnotationsList struct {
+	Manifests []RegistryAnnotations `json:"manifests"`
+}
+
+func SetupAnnotationsRoutes(config *config.Config, router *mux.Router, repoDB repodb.RepoDB, log log.Logger) {
+	if config.Extensions.Search != nil && *config.Extensions.Search.Enable && repoDB != nil {
+		log.Info().Msg("setting up registry annotations routes")
+
+		allowedMethods := zcommon.AllowedMethods(http.MethodGet, http.MethodPatch)
+
+		annotationsRouter := router.PathPrefix(constants.ExtAnnotations).Subrouter()
+		annotationsRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+		annotationsRouter.Use(zcommon.AddExtensionSecurityHeaders())
+		annotationsRouter.HandleFunc(fmt.Sprintf("/{name:%s}", zreg.NameRegexp.String()),
+			HandleListRegistryAnnotations(repoDB, log)).Methods(zcommon.AllowedMethods(http.MethodGet)...)
+		annotationsRouter.HandleFunc(fmt.Sprintf("/{name:%s}/{reference}", zreg.NameRegexp.String()),
+			HandleGetRegistryAnnotations(repoDB, log)).Methods(zcommon.AllowedMethods(http.MethodGet)...)
+		annotationsRouter.HandleFunc(fmt.Sprintf("/{name:%s}/{reference}", zreg.NameRegexp.String()),
+			HandlePatchRegistryAnnotations(config, repoDB, log)).Methods(zcommon.AllowedMethods(http.MethodPatch)...)
+	}
+}
+
+// HandleListRegistryAnnotations godoc
+// @Summary Export the registry annotations of a repo
+// @Description List the registry annotations of all the manifests of a repo
+// @Router 	/v2/_zot/ext/annotations/{name} [get]
+// @Produce json
+// @Param   name     path    string     true        "repository name"
+// @Success 200 {object} 	extensions.RegistryAnnotationsList
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleListRegistryAnnotations(repoDB repodb.RepoDB, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		repo := mux.Vars(req)["name"]
+
+		repoMeta, ok := getRepoMetaForRequest(rsp, req, repoDB, repo, log)
+		if !ok {
+			return
+		}
+
+		annotationsList := RegistryAnnotationsList{
+			Manifests: make([]RegistryAnnotations, 0, len(repoMeta.RegistryAnnotations)),
+		}
+
+		for digest, annotations := range repoMeta.RegistryAnnotations {
+			annotationsList.Manifests = append(annotationsList.Manifests, RegistryAnnotations{
+				Digest:      digest,
+				Annotations: annotations,
+			})
+		}
+
+		sort.Slice(annotationsList.Manifests, func(i, j int) bool {
+			return annotationsList.Manifests[i].Digest < annotationsList.Manifests[j].Digest
+		})
+
+		zcommon.WriteJSON(rsp, http.StatusOK, annotationsList)
+	}
+}
+
+// HandleGetRegistryAnnotations godoc
+// @Summary Get the registry annotations of an image
+// @Description Get the registry annotations of the manifest a tag or digest points to
+// @Router 	/v2/_zot/ext/annotations/{name}/{reference} [get]
+// @Produce json
+// @Param   name       path    string     true        "repository name"
+// @Param   reference  path    string     true        "tag or digest"
+// @Success 200 {object} 	extensions.RegistryAnnotations
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleGetRegistryAnnotations(repoDB repodb.RepoDB, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repo, reference := vars["name"], vars["reference"]
+
+		repoMeta, ok := getRepoMetaForRequest(rsp, req, repoDB, repo, log)
+		if !ok {
+			return
+		}
+
+		digest, err := repodb.GetReferenceDigest(repoMeta, reference)
+		if err != nil {
+			rsp.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		annotations := repoMeta.RegistryAnnotations[digest]
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		zcommon.WriteJSON(rsp, http.StatusOK, RegistryAnnotations{Digest: digest, Annotations: annotations})
+	}
+}
+
+// HandlePatchRegistryAnnotations godoc
+// @Summary Update the registry annotations of an image
+// @Description Add, change or remove (by setting them to null) registry annotations of the manifest a tag
+// @Description or digest points to, the manifest itself and its digest are not changed.
+// @Description When access control is enabled only admins can update registry annotations.
+// @Router 	/v2/_zot/ext/annotations/{name}/{reference} [patch]
+// @Accept  json
+// @Produce json
+// @Param   name       path    string     true        "repository name"
+// @Param   reference  path    string     true        "tag or digest"
+// @Param   annotations  body  object     true        "annotations to update, null values remove annotations"
+// @Success 200 {object} 	extensions.RegistryAnnotations
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandlePatchRegistryAnnotations(config *config.Config, repoDB repodb.RepoDB, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repo, reference := vars["name"], vars["reference"]
+
+		acCtx, err := localCtx.GetAccessControlContext(req.Context())
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		available, err := localCtx.RepoIsUserAvailable(req.Context(), repo)
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !available || (config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin)) {
+			rsp.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		var patch map[string]*string
+
+		if err := json.NewDecoder(req.Body).Decode(&patch); err != nil {
+			rsp.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		digest, annotations, err := repoDB.PatchRegistryAnnotations(repo, reference, patch)
+		if err != nil {
+			switch {
+			case errors.Is(err, zerr.ErrBadRegistryAnnotation):
+				rsp.WriteHeader(http.StatusBadRequest)
+			case errors.Is(err, zerr.ErrRepoMetaNotFound), errors.Is(err, zerr.ErrManifestMetaNotFound):
+				rsp.WriteHeader(http.StatusNotFound)
+			default:
+				log.Error().Err(err).Str("repository", repo).Str("reference", reference).
+					Msg("failed to update registry annotations")
+				rsp.WriteHeader(http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		log.Info().Str("repository", repo).Str("reference", reference).Str("digest", digest.String()).
+			Str("user", localCtx.GetUsernameFromContext(acCtx)).Msg("registry annotations updated")
+
+		zcommon.WriteJSON(rsp, http.StatusOK, RegistryAnnotations{Digest: digest.String(), Annotations: annotations})
+	}
+}
+
+func getRepoMetaForRequest(rsp http.ResponseWriter, req *http.Request, repoDB repodb.RepoDB, repo string,
+	log log.Logger,
+) (repodb.RepoMetadata, bool) {
+	available, err := localCtx.RepoIsUserAvailable(req.Context(), repo)
+	if err != nil {
+		rsp.WriteHeader(http.StatusInternalServerError)
+
+		return repodb.RepoMetadata{}, false
+	}
+
+	if !available {
+		rsp.WriteHeader(http.StatusForbidden)
+
+		return repodb.RepoMetadata{}, false
+	}
+
+	repoMeta, err := repoDB.GetRepoMeta(repo)
+	if err != nil {
+		if errors.Is(err, zerr.ErrRepoMetaNotFound) {
+			rsp.WriteHeader(http.StatusNotFound)
+
+			return repodb.RepoMetadata{}, false
+		}
+
+		log.Error().Err(err).Str("repository", repo).Msg("failed to get repo metadata")
+		rsp.WriteHeader(http.StatusInternalServerError)
+
+		return repodb.RepoMetadata{}, false
+	}
+
+	return repoMeta, true
+}
@@ -0,0 +1,211 @@
+//go:build search
+// +build search
+
+package extensions_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
+
+	"zotregistry.io/zot/pkg/api"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/extensions"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/test"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+func TestRegistryAnnotationsExtension(t *testing.T) {
+	Convey("Update and search registry annotations", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+		}
+
+		ctlr := api.NewController(conf)
+		ctrlManager := test.NewControllerManager(ctlr)
+
+		ctrlManager.StartAndWait(port)
+
+		defer ctrlManager.StopServer()
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		digest, err := image.Digest()
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(image, baseURL, "test/repo")
+		So(err, ShouldBeNil)
+
+		image, err = test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(image, baseURL, "test/other")
+		So(err, ShouldBeNil)
+
+		annotationsURL := baseURL + constants.FullAnnotationsPrefix + "/test/repo"
+
+		resp, err := resty.R().SetBody(`{"owner": "team-a", "ticket": "JIRA-1"}`).Patch(annotationsURL + "/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var registryAnnotations extensions.RegistryAnnotations
+
+		err = json.Unmarshal(resp.Body(), &registryAnnotations)
+		So(err, ShouldBeNil)
+		So(registryAnnotations.Digest, ShouldEqual, digest.String())
+		So(registryAnnotations.Annotations, ShouldResemble, map[string]string{"owner": "team-a", "ticket": "JIRA-1"})
+
+		resp, err = resty.R().SetBody(`{"ticket": null, "environment": "prod"}`).
+			Patch(annotationsURL + "/" + digest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Get(annotationsURL + "/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		err = json.Unmarshal(resp.Body(), &registryAnnotations)
+		So(err, ShouldBeNil)
+		So(registryAnnotations.Annotations, ShouldResemble, map[string]string{"owner": "team-a", "environment": "prod"})
+
+		resp, err = resty.R().Get(annotationsURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var annotationsList extensions.RegistryAnnotationsList
+
+		err = json.Unmarshal(resp.Body(), &annotationsList)
+		So(err, ShouldBeNil)
+		So(len(annotationsList.Manifests), ShouldEqual, 1)
+		So(annotationsList.Manifests[0].Digest, ShouldEqual, digest.String())
+
+		// the manifest itself is not changed
+		resp, err = resty.R().Head(baseURL + "/v2/test/repo/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.Header().Get("Docker-Content-Digest"), ShouldEqual, digest.String())
+
+		query := `{
+			GlobalSearch(query:"test/", filter:{RegistryAnnotations:["owner=team-a"]}) {
+				Repos { Name NewestImage { RegistryAnnotations { Key Value } } }
+			}
+		}`
+
+		resp, err = resty.R().Get(baseURL + constants.FullSearchPrefix + "?query=" + url.QueryEscape(query))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(string(resp.Body()), ShouldContainSubstring, `"Name":"test/repo"`)
+		So(string(resp.Body()), ShouldContainSubstring, `"Key":"environment","Value":"prod"`)
+		So(string(resp.Body()), ShouldNotContainSubstring, `"Name":"test/other"`)
+
+		resp, err = resty.R().SetBody(`{"": "value"}`).Patch(annotationsURL + "/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetBody(`not json`).Patch(annotationsURL + "/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetBody(`{"owner": "team-b"}`).Patch(annotationsURL + "/2.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().Get(annotationsURL + "/2.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().Get(baseURL + constants.FullAnnotationsPrefix + "/missing/repo")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+}
+
+func TestRegistryAnnotationsHandlers(t *testing.T) {
+	const AnnotationsBaseURL = "http://127.0.0.1:8080/v2/_zot/ext/annotations"
+
+	log := log.NewLogger("debug", "")
+	conf := config.New()
+	mockRepoDB := mocks.RepoDBMock{}
+
+	Convey("Only admins can update registry annotations with access control enabled", t, func() {
+		conf.HTTP.AccessControl = &config.AccessControlConfig{}
+
+		request := httptest.NewRequest(http.MethodPatch, AnnotationsBaseURL+"/repo/1.0", strings.NewReader("{}"))
+		request = mux.SetURLVars(request, map[string]string{"name": "repo", "reference": "1.0"})
+
+		ctx := context.WithValue(request.Context(), localCtx.GetContextKey(),
+			localCtx.AccessControlContext{Username: "user"})
+
+		response := httptest.NewRecorder()
+		extensions.HandlePatchRegistryAnnotations(conf, mockRepoDB, log)(response, request.WithContext(ctx))
+		res := response.Result()
+		So(res.StatusCode, ShouldEqual, http.StatusForbidden)
+		defer res.Body.Close()
+
+		ctx = context.WithValue(request.Context(), localCtx.GetContextKey(),
+			localCtx.AccessControlContext{Username: "admin", IsAdmin: true})
+
+		response = httptest.NewRecorder()
+		extensions.HandlePatchRegistryAnnotations(conf, mockRepoDB, log)(response, request.WithContext(ctx))
+		res = response.Result()
+		So(res.StatusCode, ShouldEqual, http.StatusOK)
+		defer res.Body.Close()
+	})
+
+	Convey("RepoDB errors", t, func() {
+		conf.HTTP.AccessControl = nil
+
+		mockRepoDB.PatchRegistryAnnotationsFn = func(repo, reference string, patch map[string]*string,
+		) (godigest.Digest, map[string]string, error) {
+			return "", nil, ErrTestPin
+		}
+
+		mockRepoDB.GetRepoMetaFn = func(repo string) (repodb.RepoMetadata, error) {
+			return repodb.RepoMetadata{}, ErrTestPin
+		}
+
+		request := httptest.NewRequest(http.MethodPatch, AnnotationsBaseURL+"/repo/1.0", strings.NewReader("{}"))
+		request = mux.SetURLVars(request, map[string]string{"name": "repo", "reference": "1.0"})
+
+		response := httptest.NewRecorder()
+		extensions.HandlePatchRegistryAnnotations(conf, mockRepoDB, log)(response, request)
+		res := response.Result()
+		So(res.StatusCode, ShouldEqual, http.StatusInternalServerError)
+		defer res.Body.Close()
+
+		request = httptest.NewRequest(http.MethodGet, AnnotationsBaseURL+"/repo/1.0", nil)
+		request = mux.SetURLVars(request, map[string]string{"name": "repo", "reference": "1.0"})
+
+		response = httptest.NewRecorder()
+		extensions.HandleGetRegistryAnnotations(mockRepoDB, log)(response, request)
+		res = response.Result()
+		So(res.StatusCode, ShouldEqual, http.StatusInternalServerError)
+		defer res.Body.Close()
+
+		response = httptest.NewRecorder()
+		extensions.HandleListRegistryAnnotations(mockRepoDB, log)(response, request)
+		res = response.Result()
+		So(res.StatusCode, ShouldEqual, http.StatusInternalServerError)
+		defer res.Body.Close()
+	})
+}
@@ -0,0 +1,128 @@
+//go:build search
+// +build search
+
+package extensions
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/audit"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	zreg "zotregistry.io/zot/pkg/regexp"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage"
+)
+
+type AuditSnapshotter = *audit.Snapshotter
+
+func GetAuditSnapshotter(config *config.Config, storeController storage.StoreController, repoDB repodb.RepoDB,
+	cveInfo CveInfo, log log.Logger,
+) AuditSnapshotter {
+	if config.Extensions.Search == nil || !*config.Extensions.Search.Enable ||
+		config.Extensions.Search.AuditSnapshots == nil || repoDB == nil {
+		return nil
+	}
+
+	snapshotter, err := audit.New(*config.Extensions.Search.AuditSnapshots, storeController, repoDB, log)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to set up audit snapshots")
+
+		return nil
+	}
+
+	// the snapshots summarize the scans with the current vulnerability databases
+	if cveInfo != nil {
+		snapshotter.SetScanResults(cveInfo)
+	}
+
+	return snapshotter
+}
+
+func EnableAuditSnapshots(config *config.Config, taskScheduler *scheduler.Scheduler,
+	snapshotter AuditSnapshotter, log log.Logger,
+) {
+	if snapshotter == nil {
+		return
+	}
+
+	log.Info().Str("interval", config.Extensions.Search.AuditSnapshots.Interval.String()).
+		Msg("submitting audit snapshots scheduler")
+	snapshotter.RunPeriodically(taskScheduler)
+}
+
+func SetupAuditSnapshotRoutes(config *config.Config, router *mux.Router, snapshotter AuditSnapshotter,
+	log log.Logger,
+) {
+	if snapshotter == nil {
+		return
+	}
+
+	log.Info().Msg("setting up audit snapshot routes")
+
+	allowedMethods := zcommon.AllowedMethods(http.MethodPost)
+
+	auditRouter := router.PathPrefix(constants.ExtAuditSnapshots).Subrouter()
+	auditRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+	auditRouter.Use(zcommon.AddExtensionSecurityHeaders())
+	auditRouter.HandleFunc(fmt.Sprintf("/{name:%s}", zreg.NameRegexp.String()),
+		HandleAuditSnapshot(config, snapshotter, log)).Methods(allowedMethods...)
+}
+
+// HandleAuditSnapshot godoc
+// @Summary Take an audit snapshot of a repo
+// @Description Push a signed snapshot of the tags of a repo, with the digests they point to, whether these are
+// @Description signed and the summary of their last scan, to the audit repo. When access control is enabled
+// @Description only admins can take snapshots.
+// @Router 	/v2/_zot/ext/audit/snapshots/{name} [post]
+// @Produce json
+// @Param   name     path    string     true        "repository name"
+// @Success 201 {object} 	audit.Result
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleAuditSnapshot(config *config.Config, snapshotter AuditSnapshotter, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		repo := mux.Vars(req)["name"]
+
+		acCtx, err := localCtx.GetAccessControlContext(req.Context())
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		// snapshots are pushed to the audit repo whatever the permissions of the user on it
+		if config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+			rsp.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		result, err := snapshotter.SnapshotRepo(repo)
+		if err != nil {
+			if errors.Is(err, zerr.ErrRepoMetaNotFound) {
+				rsp.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			log.Error().Err(err).Str("repository", repo).Msg("failed to take audit snapshot")
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		zcommon.WriteJSON(rsp, http.StatusCreated, result)
+	}
+}
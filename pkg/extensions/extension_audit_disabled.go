@@ -0,0 +1,36 @@
+//go:build !search
+// +build !search
+
+package extensions
+
+import (
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage"
+)
+
+type AuditSnapshotter interface{}
+
+func GetAuditSnapshotter(config *config.Config, storeController storage.StoreController, repoDB repodb.RepoDB,
+	cveInfo CveInfo, log log.Logger,
+) AuditSnapshotter {
+	return nil
+}
+
+// EnableAuditSnapshots ...
+func EnableAuditSnapshots(config *config.Config, taskScheduler *scheduler.Scheduler,
+	snapshotter AuditSnapshotter, log log.Logger,
+) {
+}
+
+// SetupAuditSnapshotRoutes ...
+func SetupAuditSnapshotRoutes(config *config.Config, router *mux.Router, snapshotter AuditSnapshotter,
+	log log.Logger,
+) {
+	log.Warn().Msg("skipping setting up audit snapshot routes because given zot binary doesn't include " +
+		"this feature, please build a binary that does so")
+}
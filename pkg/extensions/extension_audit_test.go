@@ -0,0 +1,118 @@
+//go:build search
+// +build search
+
+package extensions_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions"
+	"zotregistry.io/zot/pkg/extensions/audit"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/storage"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/local"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+func TestAuditSnapshotHandler(t *testing.T) {
+	const SnapshotsURL = "http://127.0.0.1:8080/v2/_zot/ext/audit/snapshots/"
+
+	log := log.NewLogger("debug", "")
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	repoDB := mocks.RepoDBMock{
+		GetRepoMetaFn: func(repo string) (repodb.RepoMetadata, error) {
+			if repo != "apps/frontend" {
+				return repodb.RepoMetadata{}, zerr.ErrRepoMetaNotFound
+			}
+
+			return repodb.RepoMetadata{
+				Name: repo,
+				Tags: map[string]repodb.Descriptor{"1.0": {Digest: "sha256:1"}},
+			}, nil
+		},
+	}
+
+	post := func(conf *config.Config, snapshotter *audit.Snapshotter, repo string, acCtx any,
+	) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, SnapshotsURL+repo, nil)
+		request = mux.SetURLVars(request, map[string]string{"name": repo})
+
+		if acCtx != nil {
+			request = request.WithContext(context.WithValue(request.Context(), localCtx.GetContextKey(), acCtx))
+		}
+
+		response := httptest.NewRecorder()
+		extensions.HandleAuditSnapshot(conf, snapshotter, log)(response, request)
+
+		return response
+	}
+
+	Convey("Take audit snapshots of repos", t, func() {
+		dir := t.TempDir()
+
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		So(err, ShouldBeNil)
+
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		So(err, ShouldBeNil)
+
+		keyPath := path.Join(dir, "audit.key")
+		err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600)
+		So(err, ShouldBeNil)
+
+		imgStore := local.NewImageStore(dir, false, storageConstants.DefaultGCDelay, false, false,
+			log, metrics, nil, nil)
+
+		snapshotter, err := audit.New(extconf.AuditSnapshotsConfig{Repository: "audit", SigningKey: keyPath},
+			storage.StoreController{DefaultStore: imgStore}, repoDB, log)
+		So(err, ShouldBeNil)
+
+		conf := config.New()
+
+		response := post(conf, snapshotter, "apps/frontend", nil)
+		So(response.Code, ShouldEqual, http.StatusCreated)
+
+		var result audit.Result
+
+		err = json.Unmarshal(response.Body.Bytes(), &result)
+		So(err, ShouldBeNil)
+		So(result.AuditRepository, ShouldEqual, "audit")
+
+		_, digest, _, err := imgStore.GetImageManifest("audit", result.Tag)
+		So(err, ShouldBeNil)
+		So(digest.String(), ShouldEqual, result.Digest)
+
+		So(post(conf, snapshotter, "missing", nil).Code, ShouldEqual, http.StatusNotFound)
+		So(post(conf, snapshotter, "apps/frontend", "bad context").Code, ShouldEqual,
+			http.StatusInternalServerError)
+
+		// only admins can take snapshots with access control enabled
+		conf.HTTP.AccessControl = &config.AccessControlConfig{}
+
+		So(post(conf, snapshotter, "apps/frontend", localCtx.AccessControlContext{Username: "user"}).Code,
+			ShouldEqual, http.StatusForbidden)
+		adminCtx := localCtx.AccessControlContext{Username: "admin", IsAdmin: true}
+		So(post(conf, snapshotter, "apps/frontend", adminCtx).Code, ShouldEqual, http.StatusCreated)
+	})
+}
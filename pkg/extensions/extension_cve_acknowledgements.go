@@ -0,0 +1,291 @@
+//go:build search
+// +build search
+
+package extensions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	zreg "zotregistry.io/zot/pkg/regexp"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+type CVEAcknowledgement struct {
+	Digest         string     `json:"digest"`
+	CVEID          string     `json:"cveId"`
+	Reason         string     `json:"reason"`
+	AcknowledgedBy string     `json:"acknowledgedBy"`
+	AcknowledgedAt time.Time  `json:"acknowledgedAt"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	Expired        bool       `json:"expired"`
+}
+
+type CVEAcknowledgementList struct {
+	Acknowledgements []CVEAcknowledgement `json:"acknowledgements"`
+}
+
+type CVEAcknowledgementRequest struct {
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+func SetupCVEAcknowledgementsRoutes(config *config.Config, router *mux.Router, repoDB repodb.RepoDB,
+	log log.Logger,
+) {
+	if config.Extensions.Search != nil && *config.Extensions.Search.Enable && repoDB != nil {
+		log.Info().Msg("setting up CVE acknowledgements routes")
+
+		allowedMethods := zcommon.AllowedMethods(http.MethodGet, http.MethodPut, http.MethodDelete)
+
+		acknowledgementsRouter := router.PathPrefix(constants.ExtCVEAcknowledgements).Subrouter()
+		acknowledgementsRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+		acknowledgementsRouter.Use(zcommon.AddExtensionSecurityHeaders())
+		acknowledgementsRouter.HandleFunc(fmt.Sprintf("/{name:%s}", zreg.NameRegexp.String()),
+			HandleListCVEAcknowledgements(repoDB, log)).Methods(zcommon.AllowedMethods(http.MethodGet)...)
+		acknowledgementsRouter.HandleFunc(fmt.Sprintf("/{name:%s}/{reference}/{cveid}", zreg.NameRegexp.String()),
+			HandlePutCVEAcknowledgement(config, repoDB, log)).Methods(http.MethodPut)
+		acknowledgementsRouter.HandleFunc(fmt.Sprintf("/{name:%s}/{reference}/{cveid}", zreg.NameRegexp.String()),
+			HandleDeleteCVEAcknowledgement(config, repoDB, log)).Methods(http.MethodDelete)
+	}
+}
+
+// HandleListCVEAcknowledgements godoc
+// @Summary List the CVE acknowledgements of a repo
+// @Description List the CVEs acknowledged for the images of a repo, including the expired acknowledgements.
+// @Description If reference is given only the acknowledgements of the manifest it points to are listed.
+// @Router 	/v2/_zot/ext/cve/acknowledgements/{name} [get]
+// @Produce json
+// @Param   name       path    string     true        "repository name"
+// @Param   reference  query   string     false       "tag or digest"
+// @Success 200 {object} 	extensions.CVEAcknowledgementList
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleListCVEAcknowledgements(repoDB repodb.RepoDB, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		repo := mux.Vars(req)["name"]
+
+		repoMeta, ok := getRepoMetaForRequest(rsp, req, repoDB, repo, log)
+		if !ok {
+			return
+		}
+
+		digests := make([]string, 0, len(repoMeta.CVEAcknowledgements))
+
+		if reference := req.URL.Query().Get("reference"); reference != "" {
+			digest, err := repodb.GetReferenceDigest(repoMeta, reference)
+			if err != nil {
+				rsp.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			digests = append(digests, digest)
+		} else {
+			for digest := range repoMeta.CVEAcknowledgements {
+				digests = append(digests, digest)
+			}
+		}
+
+		now := time.Now()
+		acknowledgementsList := CVEAcknowledgementList{Acknowledgements: []CVEAcknowledgement{}}
+
+		for _, digest := range digests {
+			for cveID, ack := range repoMeta.CVEAcknowledgements[digest] {
+				acknowledgementsList.Acknowledgements = append(acknowledgementsList.Acknowledgements,
+					getCVEAcknowledgement(digest, cveID, ack, now))
+			}
+		}
+
+		sort.Slice(acknowledgementsList.Acknowledgements, func(i, j int) bool {
+			left, right := acknowledgementsList.Acknowledgements[i], acknowledgementsList.Acknowledgements[j]
+
+			if left.Digest != right.Digest {
+				return left.Digest < right.Digest
+			}
+
+			return left.CVEID < right.CVEID
+		})
+
+		zcommon.WriteJSON(rsp, http.StatusOK, acknowledgementsList)
+	}
+}
+
+// HandlePutCVEAcknowledgement godoc
+// @Summary Acknowledge a CVE for an image
+// @Description Acknowledge a CVE found in the manifest a tag or digest points to, acknowledged CVEs are
+// @Description flagged in the search results and not counted in the vulnerability summaries of the image.
+// @Description When access control is enabled only admins can acknowledge CVEs.
+// @Router 	/v2/_zot/ext/cve/acknowledgements/{name}/{reference}/{cveid} [put]
+// @Accept  json
+// @Produce json
+// @Param   name       path    string     true        "repository name"
+// @Param   reference  path    string     true        "tag or digest"
+// @Param   cveid      path    string     true        "CVE id"
+// @Param   acknowledgement  body  extensions.CVEAcknowledgementRequest  true  "reason and optional expiry"
+// @Success 200 {object} 	extensions.CVEAcknowledgement
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandlePutCVEAcknowledgement(config *config.Config, repoDB repodb.RepoDB, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repo, reference, cveID := vars["name"], vars["reference"], vars["cveid"]
+
+		acCtx, ok := authorizeCVEChange(rsp, req, config, repo)
+		if !ok {
+			return
+		}
+
+		var ackRequest CVEAcknowledgementRequest
+
+		if err := json.NewDecoder(req.Body).Decode(&ackRequest); err != nil {
+			rsp.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		now := time.Now()
+
+		ack := repodb.CVEAcknowledgement{
+			Reason:         ackRequest.Reason,
+			AcknowledgedBy: localCtx.GetUsernameFromContext(acCtx),
+			AcknowledgedAt: now,
+		}
+
+		if ackRequest.ExpiresAt != nil {
+			if !ackRequest.ExpiresAt.After(now) {
+				rsp.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			ack.ExpiresAt = *ackRequest.ExpiresAt
+		}
+
+		digest, err := repoDB.SetCVEAcknowledgement(repo, reference, cveID, ack)
+		if err != nil {
+			writeCVEAcknowledgementError(rsp, err, repo, reference, log)
+
+			return
+		}
+
+		log.Info().Str("repository", repo).Str("reference", reference).Str("digest", digest.String()).
+			Str("cve", cveID).Str("user", ack.AcknowledgedBy).Msg("CVE acknowledged")
+
+		zcommon.WriteJSON(rsp, http.StatusOK, getCVEAcknowledgement(digest.String(), cveID, ack, now))
+	}
+}
+
+// HandleDeleteCVEAcknowledgement godoc
+// @Summary Remove the acknowledgement of a CVE for an image
+// @Description Remove the acknowledgement of a CVE found in the manifest a tag or digest points to.
+// @Description When access control is enabled only admins can remove acknowledgements.
+// @Router 	/v2/_zot/ext/cve/acknowledgements/{name}/{reference}/{cveid} [delete]
+// @Param   name       path    string     true        "repository name"
+// @Param   reference  path    string     true        "tag or digest"
+// @Param   cveid      path    string     true        "CVE id"
+// @Success 200 {string} 	string 				"ok"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleDeleteCVEAcknowledgement(config *config.Config, repoDB repodb.RepoDB, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repo, reference, cveID := vars["name"], vars["reference"], vars["cveid"]
+
+		acCtx, ok := authorizeCVEChange(rsp, req, config, repo)
+		if !ok {
+			return
+		}
+
+		digest, err := repoDB.DeleteCVEAcknowledgement(repo, reference, cveID)
+		if err != nil {
+			writeCVEAcknowledgementError(rsp, err, repo, reference, log)
+
+			return
+		}
+
+		log.Info().Str("repository", repo).Str("reference", reference).Str("digest", digest.String()).
+			Str("cve", cveID).Str("user", localCtx.GetUsernameFromContext(acCtx)).Msg("CVE acknowledgement removed")
+
+		rsp.WriteHeader(http.StatusOK)
+	}
+}
+
+// authorizeCVEChange checks the user can read the repo, and is an admin if access control is enabled.
+func authorizeCVEChange(rsp http.ResponseWriter, req *http.Request, config *config.Config, repo string,
+) (*localCtx.AccessControlContext, bool) {
+	acCtx, err := localCtx.GetAccessControlContext(req.Context())
+	if err != nil {
+		rsp.WriteHeader(http.StatusInternalServerError)
+
+		return nil, false
+	}
+
+	available, err := localCtx.RepoIsUserAvailable(req.Context(), repo)
+	if err != nil {
+		rsp.WriteHeader(http.StatusInternalServerError)
+
+		return nil, false
+	}
+
+	if !available || (config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin)) {
+		rsp.WriteHeader(http.StatusForbidden)
+
+		return nil, false
+	}
+
+	return acCtx, true
+}
+
+func writeCVEAcknowledgementError(rsp http.ResponseWriter, err error, repo, reference string, log log.Logger) {
+	switch {
+	case errors.Is(err, zerr.ErrBadCVEAcknowledgement):
+		rsp.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, zerr.ErrRepoMetaNotFound), errors.Is(err, zerr.ErrManifestMetaNotFound),
+		errors.Is(err, zerr.ErrCVEAcknowledgementNotFound):
+		rsp.WriteHeader(http.StatusNotFound)
+	default:
+		log.Error().Err(err).Str("repository", repo).Str("reference", reference).
+			Msg("failed to update CVE acknowledgements")
+		rsp.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func getCVEAcknowledgement(digest, cveID string, ack repodb.CVEAcknowledgement, now time.Time,
+) CVEAcknowledgement {
+	acknowledgement := CVEAcknowledgement{
+		Digest:         digest,
+		CVEID:          cveID,
+		Reason:         ack.Reason,
+		AcknowledgedBy: ack.AcknowledgedBy,
+		AcknowledgedAt: ack.AcknowledgedAt,
+	}
+
+	if !ack.ExpiresAt.IsZero() {
+		expiresAt := ack.ExpiresAt
+
+		acknowledgement.ExpiresAt = &expiresAt
+		acknowledgement.Expired = !expiresAt.After(now)
+	}
+
+	return acknowledgement
+}
@@ -0,0 +1,179 @@
+//go:build search
+// +build search
+
+package extensions_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+func TestCVEAcknowledgementsHandlers(t *testing.T) {
+	const AcknowledgementsBaseURL = "http://127.0.0.1:8080/v2/_zot/ext/cve/acknowledgements"
+
+	log := log.NewLogger("debug", "")
+	digest := godigest.FromString("manifest").String()
+
+	newRepoDB := func() mocks.RepoDBMock {
+		repoMeta := repodb.RepoMetadata{
+			Name:       "repo",
+			Tags:       map[string]repodb.Descriptor{"1.0": {Digest: digest}},
+			Statistics: map[string]repodb.DescriptorStatistics{digest: {}},
+		}
+
+		return mocks.RepoDBMock{
+			GetRepoMetaFn: func(repo string) (repodb.RepoMetadata, error) {
+				return repoMeta, nil
+			},
+			SetCVEAcknowledgementFn: func(repo, reference, cveID string, ack repodb.CVEAcknowledgement,
+			) (godigest.Digest, error) {
+				updated, digest, err := repodb.SetCVEAcknowledgement(repoMeta, reference, cveID, ack)
+				repoMeta = updated
+
+				return godigest.Digest(digest), err
+			},
+			DeleteCVEAcknowledgementFn: func(repo, reference, cveID string) (godigest.Digest, error) {
+				updated, digest, err := repodb.DeleteCVEAcknowledgement(repoMeta, reference, cveID)
+				repoMeta = updated
+
+				return godigest.Digest(digest), err
+			},
+		}
+	}
+
+	put := func(ctx context.Context, conf *config.Config, repoDB repodb.RepoDB, reference, cveID, body string) int {
+		request := httptest.NewRequest(http.MethodPut, AcknowledgementsBaseURL+"/repo/"+reference+"/"+cveID,
+			strings.NewReader(body))
+		request = mux.SetURLVars(request, map[string]string{"name": "repo", "reference": reference, "cveid": cveID})
+
+		response := httptest.NewRecorder()
+		extensions.HandlePutCVEAcknowledgement(conf, repoDB, log)(response, request.WithContext(ctx))
+
+		return response.Code
+	}
+
+	list := func(repoDB repodb.RepoDB, query string) (int, extensions.CVEAcknowledgementList) {
+		request := httptest.NewRequest(http.MethodGet, AcknowledgementsBaseURL+"/repo"+query, nil)
+		request = mux.SetURLVars(request, map[string]string{"name": "repo"})
+
+		response := httptest.NewRecorder()
+		extensions.HandleListCVEAcknowledgements(repoDB, log)(response, request)
+
+		var acknowledgementsList extensions.CVEAcknowledgementList
+
+		_ = json.Unmarshal(response.Body.Bytes(), &acknowledgementsList)
+
+		return response.Code, acknowledgementsList
+	}
+
+	Convey("Acknowledge CVEs and remove the acknowledgements", t, func() {
+		conf := config.New()
+		repoDB := newRepoDB()
+		ctx := context.Background()
+
+		So(put(ctx, conf, repoDB, "1.0", "CVE-1", `{"reason": "not reachable"}`), ShouldEqual, http.StatusOK)
+
+		expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		So(put(ctx, conf, repoDB, digest, "CVE-2", `{"reason": "fix pending", "expiresAt": "`+expiresAt+`"}`),
+			ShouldEqual, http.StatusOK)
+
+		status, acknowledgementsList := list(repoDB, "")
+		So(status, ShouldEqual, http.StatusOK)
+		So(len(acknowledgementsList.Acknowledgements), ShouldEqual, 2)
+		So(acknowledgementsList.Acknowledgements[0].CVEID, ShouldEqual, "CVE-1")
+		So(acknowledgementsList.Acknowledgements[0].Digest, ShouldEqual, digest)
+		So(acknowledgementsList.Acknowledgements[0].Reason, ShouldEqual, "not reachable")
+		So(acknowledgementsList.Acknowledgements[0].ExpiresAt, ShouldBeNil)
+		So(acknowledgementsList.Acknowledgements[1].CVEID, ShouldEqual, "CVE-2")
+		So(acknowledgementsList.Acknowledgements[1].ExpiresAt, ShouldNotBeNil)
+		So(acknowledgementsList.Acknowledgements[1].Expired, ShouldBeFalse)
+
+		status, acknowledgementsList = list(repoDB, "?reference=1.0")
+		So(status, ShouldEqual, http.StatusOK)
+		So(len(acknowledgementsList.Acknowledgements), ShouldEqual, 2)
+
+		status, _ = list(repoDB, "?reference=2.0")
+		So(status, ShouldEqual, http.StatusNotFound)
+
+		// expiry in the past, missing reason, bad body and unknown reference
+		expiresAt = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		So(put(ctx, conf, repoDB, "1.0", "CVE-3", `{"reason": "old", "expiresAt": "`+expiresAt+`"}`),
+			ShouldEqual, http.StatusBadRequest)
+		So(put(ctx, conf, repoDB, "1.0", "CVE-3", `{}`), ShouldEqual, http.StatusBadRequest)
+		So(put(ctx, conf, repoDB, "1.0", "CVE-3", `not json`), ShouldEqual, http.StatusBadRequest)
+		So(put(ctx, conf, repoDB, "2.0", "CVE-3", `{"reason": "reason"}`), ShouldEqual, http.StatusNotFound)
+
+		request := httptest.NewRequest(http.MethodDelete, AcknowledgementsBaseURL+"/repo/1.0/CVE-1", nil)
+		request = mux.SetURLVars(request, map[string]string{"name": "repo", "reference": "1.0", "cveid": "CVE-1"})
+
+		response := httptest.NewRecorder()
+		extensions.HandleDeleteCVEAcknowledgement(conf, repoDB, log)(response, request)
+		So(response.Code, ShouldEqual, http.StatusOK)
+
+		response = httptest.NewRecorder()
+		extensions.HandleDeleteCVEAcknowledgement(conf, repoDB, log)(response, request)
+		So(response.Code, ShouldEqual, http.StatusNotFound)
+
+		status, acknowledgementsList = list(repoDB, "")
+		So(status, ShouldEqual, http.StatusOK)
+		So(len(acknowledgementsList.Acknowledgements), ShouldEqual, 1)
+		So(acknowledgementsList.Acknowledgements[0].CVEID, ShouldEqual, "CVE-2")
+	})
+
+	Convey("Only admins can acknowledge CVEs with access control enabled", t, func() {
+		conf := config.New()
+		conf.HTTP.AccessControl = &config.AccessControlConfig{}
+		repoDB := newRepoDB()
+
+		ctx := context.WithValue(context.Background(), localCtx.GetContextKey(),
+			localCtx.AccessControlContext{Username: "user", ReadGlobPatterns: map[string]bool{"repo": true}})
+		So(put(ctx, conf, repoDB, "1.0", "CVE-1", `{"reason": "reason"}`), ShouldEqual, http.StatusForbidden)
+
+		ctx = context.WithValue(context.Background(), localCtx.GetContextKey(),
+			localCtx.AccessControlContext{Username: "admin", IsAdmin: true})
+		So(put(ctx, conf, repoDB, "1.0", "CVE-1", `{"reason": "reason"}`), ShouldEqual, http.StatusOK)
+
+		_, acknowledgementsList := list(repoDB, "")
+		So(len(acknowledgementsList.Acknowledgements), ShouldEqual, 1)
+		So(acknowledgementsList.Acknowledgements[0].AcknowledgedBy, ShouldEqual, "admin")
+
+		ctx = context.WithValue(context.Background(), localCtx.GetContextKey(), "bad context")
+		So(put(ctx, conf, repoDB, "1.0", "CVE-1", `{"reason": "reason"}`), ShouldEqual,
+			http.StatusInternalServerError)
+	})
+
+	Convey("RepoDB errors", t, func() {
+		conf := config.New()
+		repoDB := mocks.RepoDBMock{
+			GetRepoMetaFn: func(repo string) (repodb.RepoMetadata, error) {
+				return repodb.RepoMetadata{}, ErrTestPin
+			},
+			SetCVEAcknowledgementFn: func(repo, reference, cveID string, ack repodb.CVEAcknowledgement,
+			) (godigest.Digest, error) {
+				return "", ErrTestPin
+			},
+		}
+
+		So(put(context.Background(), conf, repoDB, "1.0", "CVE-1", `{"reason": "reason"}`), ShouldEqual,
+			http.StatusInternalServerError)
+
+		status, _ := list(repoDB, "")
+		So(status, ShouldEqual, http.StatusInternalServerError)
+	})
+}
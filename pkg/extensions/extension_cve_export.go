@@ -0,0 +1,269 @@
+//go:build search
+// +build search
+
+package extensions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/search/cve/export"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	zreg "zotregistry.io/zot/pkg/regexp"
+	"zotregistry.io/zot/pkg/storage"
+)
+
+func SetupCVEExportRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
+	repoDB repodb.RepoDB, cveInfo CveInfo, log log.Logger,
+) {
+	if cveInfo == nil || repoDB == nil {
+		return
+	}
+
+	log.Info().Msg("setting up CVE export routes")
+
+	allowedMethods := zcommon.AllowedMethods(http.MethodGet, http.MethodPost)
+
+	exportRouter := router.PathPrefix(constants.ExtCVEExport).Subrouter()
+	exportRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+	exportRouter.Use(zcommon.AddExtensionSecurityHeaders())
+	exportRouter.HandleFunc(fmt.Sprintf("/{name:%s}/{reference}", zreg.NameRegexp.String()),
+		HandleCVEExport(repoDB, cveInfo, log)).Methods(zcommon.AllowedMethods(http.MethodGet)...)
+	exportRouter.HandleFunc(fmt.Sprintf("/{name:%s}/{reference}/vex", zreg.NameRegexp.String()),
+		HandleAttachVEX(config, storeController, repoDB, cveInfo, log)).Methods(http.MethodPost)
+}
+
+// HandleCVEExport godoc
+// @Summary Export the CVEs of an image
+// @Description Export the CVEs found in the manifest a tag or digest points to, as a SARIF log for code
+// @Description scanning UIs or as a CycloneDX VEX document. Acknowledged CVEs are reported as suppressed in
+// @Description SARIF logs and as not affecting the image in VEX documents.
+// @Router 	/v2/_zot/ext/cve/export/{name}/{reference} [get]
+// @Produce application/sarif+json
+// @Produce application/vnd.cyclonedx+json
+// @Param   name       path    string     true        "repository name"
+// @Param   reference  path    string     true        "tag or digest"
+// @Param   format     query   string     false       "sarif (default) or vex"
+// @Success 200 {object} 	export.SARIFLog
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error"
+// @Failure 503 {string} 	string 				"CVE DB not downloaded yet".
+func HandleCVEExport(repoDB repodb.RepoDB, cveInfo CveInfo, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repo, reference := vars["name"], vars["reference"]
+
+		format := req.URL.Query().Get("format")
+		if format == "" {
+			format = export.FormatSARIF
+		}
+
+		if format != export.FormatSARIF && format != export.FormatVEX {
+			rsp.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		image, cves, ok := getImageCVEsForExport(rsp, req, repoDB, cveInfo, repo, reference, log)
+		if !ok {
+			return
+		}
+
+		if format == export.FormatVEX {
+			writeExportJSON(rsp, http.StatusOK, export.VEXMediaType, export.VEX(image, cves, time.Now()))
+
+			return
+		}
+
+		writeExportJSON(rsp, http.StatusOK, export.SARIFMediaType, export.SARIF(image, cves))
+	}
+}
+
+// HandleAttachVEX godoc
+// @Summary Attach a VEX document to an image
+// @Description Generate the CycloneDX VEX document of the CVEs found in the manifest a tag or digest points
+// @Description to, and push it as an artifact referring to the manifest, so it is listed by the referrers API.
+// @Description When access control is enabled only admins can attach VEX documents.
+// @Router 	/v2/_zot/ext/cve/export/{name}/{reference}/vex [post]
+// @Produce application/vnd.cyclonedx+json
+// @Param   name       path    string     true        "repository name"
+// @Param   reference  path    string     true        "tag or digest"
+// @Success 201 {object} 	export.VEXDocument
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error"
+// @Failure 503 {string} 	string 				"CVE DB not downloaded yet".
+func HandleAttachVEX(config *config.Config, storeController storage.StoreController, repoDB repodb.RepoDB,
+	cveInfo CveInfo, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repo, reference := vars["name"], vars["reference"]
+
+		if _, ok := authorizeCVEChange(rsp, req, config, repo); !ok {
+			return
+		}
+
+		image, cves, ok := getImageCVEsForExport(rsp, req, repoDB, cveInfo, repo, reference, log)
+		if !ok {
+			return
+		}
+
+		vex := export.VEX(image, cves, time.Now())
+
+		vexDigest, err := attachVEX(storeController, repoDB, image, vex, log)
+		if err != nil {
+			log.Error().Err(err).Str("repository", repo).Str("reference", reference).
+				Msg("failed to attach VEX document")
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Info().Str("repository", repo).Str("subject", image.Digest).Str("digest", vexDigest.String()).
+			Msg("VEX document attached")
+
+		rsp.Header().Set(constants.DistContentDigestKey, vexDigest.String())
+		rsp.Header().Set("Location", fmt.Sprintf("/v2/%s/manifests/%s", repo, vexDigest))
+		writeExportJSON(rsp, http.StatusCreated, export.VEXMediaType, vex)
+	}
+}
+
+// getImageCVEsForExport returns the CVEs of the image a tag or digest points to, writing the error
+// response if the user can't read the repo or the CVEs can't be listed.
+func getImageCVEsForExport(rsp http.ResponseWriter, req *http.Request, repoDB repodb.RepoDB, cveInfo CveInfo,
+	repo, reference string, log log.Logger,
+) (export.Image, []cvemodel.CVE, bool) {
+	repoMeta, ok := getRepoMetaForRequest(rsp, req, repoDB, repo, log)
+	if !ok {
+		return export.Image{}, nil, false
+	}
+
+	digest, err := repodb.GetReferenceDigest(repoMeta, reference)
+	if err != nil {
+		rsp.WriteHeader(http.StatusNotFound)
+
+		return export.Image{}, nil, false
+	}
+
+	cves, _, err := cveInfo.GetCVEListForImage(repo, digest, "", cvemodel.PageInput{})
+	if err != nil {
+		switch {
+		case errors.Is(err, zerr.ErrCVEDBNotFound):
+			rsp.WriteHeader(http.StatusServiceUnavailable)
+		case errors.Is(err, zerr.ErrScanNotSupported), errors.Is(err, zerr.ErrImageEncrypted):
+			rsp.WriteHeader(http.StatusBadRequest)
+		default:
+			log.Error().Err(err).Str("repository", repo).Str("reference", reference).
+				Msg("failed to list the CVEs to export")
+			rsp.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return export.Image{}, nil, false
+	}
+
+	return export.Image{Repo: repo, Reference: reference, Digest: digest}, cves, true
+}
+
+// attachVEX pushes the VEX document as an artifact referring to the image and adds it to repodb.
+func attachVEX(storeController storage.StoreController, repoDB repodb.RepoDB, image export.Image,
+	vex export.VEXDocument, log log.Logger,
+) (godigest.Digest, error) {
+	imgStore := storeController.GetImageStore(image.Repo)
+
+	subjectBlob, subjectDigest, subjectMediaType, err := imgStore.GetImageManifest(image.Repo, image.Digest)
+	if err != nil {
+		return "", err
+	}
+
+	vexBlob, err := json.Marshal(vex)
+	if err != nil {
+		return "", err
+	}
+
+	vexBlobDigest := godigest.FromBytes(vexBlob)
+
+	if _, _, err := imgStore.FullBlobUpload(image.Repo, bytes.NewReader(vexBlob), vexBlobDigest); err != nil {
+		return "", err
+	}
+
+	emptyConfig := ispec.DescriptorEmptyJSON
+
+	if _, _, err := imgStore.FullBlobUpload(image.Repo, bytes.NewReader(emptyConfig.Data),
+		emptyConfig.Digest); err != nil {
+		return "", err
+	}
+
+	emptyConfig.Data = nil
+
+	manifest := ispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2}, //nolint:gomnd
+		MediaType:    ispec.MediaTypeImageManifest,
+		ArtifactType: export.VEXMediaType,
+		Config:       emptyConfig,
+		Layers: []ispec.Descriptor{{
+			MediaType: export.VEXMediaType,
+			Digest:    vexBlobDigest,
+			Size:      int64(len(vexBlob)),
+		}},
+		Subject: &ispec.Descriptor{
+			MediaType: subjectMediaType,
+			Digest:    subjectDigest,
+			Size:      int64(len(subjectBlob)),
+		},
+		Annotations: map[string]string{
+			ispec.AnnotationCreated: vex.Metadata.Timestamp.Format(time.RFC3339),
+		},
+	}
+
+	manifestBlob, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+
+	manifestDigest := godigest.FromBytes(manifestBlob)
+
+	if _, _, err := imgStore.PutImageManifest(image.Repo, manifestDigest.String(), ispec.MediaTypeImageManifest,
+		manifestBlob); err != nil {
+		return "", err
+	}
+
+	if err := meta.OnUpdateManifest(image.Repo, manifestDigest.String(), ispec.MediaTypeImageManifest,
+		manifestDigest, manifestBlob, storeController, repoDB, log); err != nil {
+		return "", err
+	}
+
+	return manifestDigest, nil
+}
+
+func writeExportJSON(rsp http.ResponseWriter, status int, mediaType string, document interface{}) {
+	body, err := json.Marshal(document)
+	if err != nil {
+		rsp.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	rsp.Header().Set("Content-Type", mediaType)
+	rsp.WriteHeader(status)
+	_, _ = rsp.Write(body)
+}
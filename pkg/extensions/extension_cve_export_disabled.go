@@ -0,0 +1,21 @@
+//go:build !search
+// +build !search
+
+package extensions
+
+import (
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/storage"
+)
+
+// SetupCVEExportRoutes ...
+func SetupCVEExportRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
+	repoDB repodb.RepoDB, cveInfo CveInfo, log log.Logger,
+) {
+	log.Warn().Msg("skipping setting up CVE export routes because given zot binary doesn't include " +
+		"this feature, please build a binary that does so")
+}
@@ -0,0 +1,216 @@
+//go:build search
+// +build search
+
+package extensions_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/extensions/search/cve/export"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/bolt"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	boltdb_wrapper "zotregistry.io/zot/pkg/meta/repodb/boltdb-wrapper"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/storage"
+	"zotregistry.io/zot/pkg/storage/local"
+	"zotregistry.io/zot/pkg/test"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+func TestCVEExportHandlers(t *testing.T) {
+	log := log.NewLogger("debug", "")
+	digest := godigest.FromString("manifest").String()
+
+	repoDB := mocks.RepoDBMock{
+		GetRepoMetaFn: func(repo string) (repodb.RepoMetadata, error) {
+			return repodb.RepoMetadata{
+				Name:       "repo",
+				Tags:       map[string]repodb.Descriptor{"1.0": {Digest: digest}},
+				Statistics: map[string]repodb.DescriptorStatistics{digest: {}},
+			}, nil
+		},
+	}
+
+	cveInfo := mocks.CveInfoMock{
+		GetCVEListForImageFn: func(repo, reference, searchedCVE string, pageInput cvemodel.PageInput,
+		) ([]cvemodel.CVE, zcommon.PageInfo, error) {
+			return []cvemodel.CVE{
+				{
+					ID:          "CVE-2",
+					Severity:    "MEDIUM",
+					Title:       "Title 2",
+					PackageList: []cvemodel.Package{{Name: "lib", InstalledVersion: "1.0", FixedVersion: "1.1"}},
+				},
+				{
+					ID:              "CVE-1",
+					Severity:        "CRITICAL",
+					Title:           "Title 1",
+					PackageList:     []cvemodel.Package{{Name: "lib", InstalledVersion: "1.0", FixedVersion: "1.2"}},
+					Acknowledgement: &cvemodel.Acknowledgement{Reason: "not reachable"},
+				},
+			}, zcommon.PageInfo{}, nil
+		},
+	}
+
+	get := func(cveInfo extensions.CveInfo, reference, query string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet,
+			constants.FullCVEExportPrefix+"/repo/"+reference+query, nil)
+		request = mux.SetURLVars(request, map[string]string{"name": "repo", "reference": reference})
+
+		response := httptest.NewRecorder()
+		extensions.HandleCVEExport(repoDB, cveInfo, log)(response, request)
+
+		return response
+	}
+
+	Convey("Export the CVEs of an image as SARIF", t, func() {
+		response := get(cveInfo, "1.0", "")
+		So(response.Code, ShouldEqual, http.StatusOK)
+		So(response.Header().Get("Content-Type"), ShouldEqual, export.SARIFMediaType)
+
+		var sarifLog export.SARIFLog
+
+		err := json.Unmarshal(response.Body.Bytes(), &sarifLog)
+		So(err, ShouldBeNil)
+		So(sarifLog.Version, ShouldEqual, "2.1.0")
+		So(len(sarifLog.Runs), ShouldEqual, 1)
+		So(len(sarifLog.Runs[0].Tool.Driver.Rules), ShouldEqual, 2)
+		So(sarifLog.Runs[0].Tool.Driver.Rules[0].ID, ShouldEqual, "CVE-1")
+		So(len(sarifLog.Runs[0].Results), ShouldEqual, 2)
+		So(sarifLog.Runs[0].Results[0].Level, ShouldEqual, "error")
+		So(sarifLog.Runs[0].Results[0].Suppressions, ShouldNotBeEmpty)
+		So(sarifLog.Runs[0].Results[1].Level, ShouldEqual, "warning")
+		So(sarifLog.Runs[0].Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI, ShouldEqual, "repo:1.0")
+	})
+
+	Convey("Export the CVEs of an image as VEX", t, func() {
+		response := get(cveInfo, digest, "?format=vex")
+		So(response.Code, ShouldEqual, http.StatusOK)
+		So(response.Header().Get("Content-Type"), ShouldEqual, export.VEXMediaType)
+
+		var vex export.VEXDocument
+
+		err := json.Unmarshal(response.Body.Bytes(), &vex)
+		So(err, ShouldBeNil)
+		So(vex.BOMFormat, ShouldEqual, "CycloneDX")
+		So(vex.Metadata.Component.Version, ShouldEqual, digest)
+		So(len(vex.Components), ShouldEqual, 1)
+		So(len(vex.Vulnerabilities), ShouldEqual, 2)
+		So(vex.Vulnerabilities[0].Analysis.State, ShouldEqual, "not_affected")
+		So(vex.Vulnerabilities[0].Analysis.Detail, ShouldEqual, "not reachable")
+		So(vex.Vulnerabilities[1].Analysis.State, ShouldEqual, "in_triage")
+		So(vex.Vulnerabilities[1].Affects[0].Ref, ShouldEqual, vex.Components[0].BOMRef)
+	})
+
+	Convey("Export errors", t, func() {
+		So(get(cveInfo, "1.0", "?format=pdf").Code, ShouldEqual, http.StatusBadRequest)
+		So(get(cveInfo, "2.0", "").Code, ShouldEqual, http.StatusNotFound)
+
+		failingCVEInfo := mocks.CveInfoMock{
+			GetCVEListForImageFn: func(repo, reference, searchedCVE string, pageInput cvemodel.PageInput,
+			) ([]cvemodel.CVE, zcommon.PageInfo, error) {
+				return nil, zcommon.PageInfo{}, zerr.ErrCVEDBNotFound
+			},
+		}
+		So(get(failingCVEInfo, "1.0", "").Code, ShouldEqual, http.StatusServiceUnavailable)
+
+		failingCVEInfo.GetCVEListForImageFn = func(repo, reference, searchedCVE string, pageInput cvemodel.PageInput,
+		) ([]cvemodel.CVE, zcommon.PageInfo, error) {
+			return nil, zcommon.PageInfo{}, zerr.ErrScanNotSupported
+		}
+		So(get(failingCVEInfo, "1.0", "").Code, ShouldEqual, http.StatusBadRequest)
+
+		failingCVEInfo.GetCVEListForImageFn = func(repo, reference, searchedCVE string, pageInput cvemodel.PageInput,
+		) ([]cvemodel.CVE, zcommon.PageInfo, error) {
+			return nil, zcommon.PageInfo{}, zerr.ErrBadConfig
+		}
+		So(get(failingCVEInfo, "1.0", "").Code, ShouldEqual, http.StatusInternalServerError)
+	})
+
+	Convey("Attach a VEX document to an image", t, func() {
+		rootDir := t.TempDir()
+
+		imageStore := local.NewImageStore(rootDir, false, 0, false, false, log,
+			monitoring.NewMetricsServer(false, log), nil, nil)
+		storeController := storage.StoreController{DefaultStore: imageStore}
+
+		img, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		err = test.WriteImageToFileSystem(img, "repo", storeController)
+		So(err, ShouldBeNil)
+
+		imgDigest, err := img.Digest()
+		So(err, ShouldBeNil)
+
+		boltDriver, err := bolt.GetBoltDriver(bolt.DBParameters{RootDir: rootDir})
+		So(err, ShouldBeNil)
+
+		boltRepoDB, err := boltdb_wrapper.NewBoltDBWrapper(boltDriver, log)
+		So(err, ShouldBeNil)
+
+		err = repodb.ParseStorage(boltRepoDB, storeController, log)
+		So(err, ShouldBeNil)
+
+		attach := func(ctx context.Context, conf *config.Config) *httptest.ResponseRecorder {
+			request := httptest.NewRequest(http.MethodPost, constants.FullCVEExportPrefix+"/repo/1.0/vex", nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "repo", "reference": "1.0"})
+
+			response := httptest.NewRecorder()
+			extensions.HandleAttachVEX(conf, storeController, boltRepoDB, cveInfo, log)(response,
+				request.WithContext(ctx))
+
+			return response
+		}
+
+		conf := config.New()
+		conf.HTTP.AccessControl = &config.AccessControlConfig{}
+
+		acCtx := localCtx.AccessControlContext{ReadGlobPatterns: map[string]bool{"repo": true}, Username: "user"}
+		ctx := context.WithValue(context.Background(), localCtx.GetContextKey(), acCtx)
+
+		So(attach(ctx, conf).Code, ShouldEqual, http.StatusForbidden)
+
+		response := attach(context.Background(), config.New())
+		So(response.Code, ShouldEqual, http.StatusCreated)
+
+		vexDigest := godigest.Digest(response.Header().Get(constants.DistContentDigestKey))
+		So(vexDigest, ShouldNotBeEmpty)
+
+		referrers, err := imageStore.GetReferrers("repo", imgDigest, []string{export.VEXMediaType})
+		So(err, ShouldBeNil)
+		So(len(referrers.Manifests), ShouldEqual, 1)
+		So(referrers.Manifests[0].Digest, ShouldEqual, vexDigest)
+		So(referrers.Manifests[0].ArtifactType, ShouldEqual, export.VEXMediaType)
+
+		manifestBlob, _, _, err := imageStore.GetImageManifest("repo", vexDigest.String())
+		So(err, ShouldBeNil)
+
+		var manifest ispec.Manifest
+
+		err = json.Unmarshal(manifestBlob, &manifest)
+		So(err, ShouldBeNil)
+		So(manifest.Subject.Digest, ShouldEqual, imgDigest)
+
+		referrersInfo, err := boltRepoDB.GetReferrersInfo("repo", imgDigest, []string{export.VEXMediaType})
+		So(err, ShouldBeNil)
+		So(len(referrersInfo), ShouldEqual, 1)
+	})
+}
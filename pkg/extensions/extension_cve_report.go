@@ -0,0 +1,170 @@
+//go:build search
+// +build search
+
+package extensions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/search/cve/report"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/scheduler"
+)
+
+type CVEReporter = *report.Reporter
+
+func GetCVEReporter(config *config.Config, cveInfo CveInfo, repoDB repodb.RepoDB, log log.Logger) CVEReporter {
+	if cveInfo == nil || repoDB == nil || config.Extensions.Search.CVE.Report == nil {
+		return nil
+	}
+
+	return report.NewReporter(cveInfo, repoDB, config.Storage.RootDirectory,
+		config.Extensions.Search.CVE.Report.Webhook, log)
+}
+
+func EnableCVEReports(config *config.Config, taskScheduler *scheduler.Scheduler, reporter CVEReporter,
+	log log.Logger,
+) {
+	if reporter == nil {
+		return
+	}
+
+	interval := config.Extensions.Search.CVE.Report.Interval
+
+	log.Info().Str("interval", interval.String()).Msg("submitting CVE report scheduler")
+	taskScheduler.SubmitGenerator(&cveReportTaskGenerator{reporter: reporter, log: log}, interval,
+		scheduler.LowPriority)
+}
+
+type cveReportTaskGenerator struct {
+	reporter CVEReporter
+	done     bool
+	log      log.Logger
+}
+
+func (gen *cveReportTaskGenerator) Next() (scheduler.Task, error) {
+	if gen.done {
+		return nil, nil
+	}
+
+	gen.done = true
+
+	return &cveReportTask{reporter: gen.reporter, log: gen.log}, nil
+}
+
+func (gen *cveReportTaskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *cveReportTaskGenerator) Reset() {
+	gen.done = false
+}
+
+type cveReportTask struct {
+	reporter CVEReporter
+	log      log.Logger
+}
+
+func (reportT *cveReportTask) DoWork() error {
+	reportT.log.Info().Msg("generating the CVE report")
+
+	cveReport, err := reportT.reporter.Generate(context.Background())
+	if err != nil {
+		return err
+	}
+
+	reportT.log.Info().Int("namespaces", len(cveReport.Namespaces)).Msg("CVE report generated")
+
+	return nil
+}
+
+func SetupCVEReportRoutes(config *config.Config, router *mux.Router, reporter CVEReporter, log log.Logger) {
+	if reporter == nil {
+		return
+	}
+
+	log.Info().Msg("setting up CVE report routes")
+
+	allowedMethods := zcommon.AllowedMethods(http.MethodGet)
+
+	reportRouter := router.PathPrefix(constants.ExtCVEReport).Subrouter()
+	reportRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+	reportRouter.Use(zcommon.AddExtensionSecurityHeaders())
+	reportRouter.Methods(allowedMethods...).HandlerFunc(HandleCVEReport(config, reporter, log))
+}
+
+// HandleCVEReport godoc
+// @Summary Get the last vulnerability report
+// @Description Get the CVE counts by severity of each namespace, and their change since the previous report,
+// @Description as generated by the last run of the periodic report. Acknowledged CVEs are not counted.
+// @Description When access control is enabled only admins can get the report.
+// @Router 	/v2/_zot/ext/cve/report [get]
+// @Produce json
+// @Produce text/csv
+// @Param   format     query   string     false       "json (default) or csv"
+// @Success 200 {object} 	report.Report
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleCVEReport(config *config.Config, reporter CVEReporter, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		acCtx, err := localCtx.GetAccessControlContext(req.Context())
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+			rsp.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		format := req.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "csv" {
+			rsp.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		cveReport, err := reporter.Last()
+		if err != nil {
+			if errors.Is(err, zerr.ErrCVEReportNotGenerated) {
+				rsp.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if format != "csv" {
+			zcommon.WriteJSON(rsp, http.StatusOK, cveReport)
+
+			return
+		}
+
+		rsp.Header().Set("Content-Type", "text/csv")
+		rsp.Header().Set("Content-Disposition", "attachment; filename=cve-report.csv")
+		rsp.WriteHeader(http.StatusOK)
+
+		if err := report.WriteCSV(rsp, cveReport); err != nil {
+			log.Error().Err(err).Msg("unable to write CVE report")
+		}
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !search
+// +build !search
+
+package extensions
+
+import (
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/scheduler"
+)
+
+type CVEReporter interface{}
+
+func GetCVEReporter(config *config.Config, cveInfo CveInfo, repoDB repodb.RepoDB, log log.Logger) CVEReporter {
+	return nil
+}
+
+// EnableCVEReports ...
+func EnableCVEReports(config *config.Config, taskScheduler *scheduler.Scheduler, reporter CVEReporter,
+	log log.Logger,
+) {
+}
+
+// SetupCVEReportRoutes ...
+func SetupCVEReportRoutes(config *config.Config, router *mux.Router, reporter CVEReporter, log log.Logger) {
+	log.Warn().Msg("skipping setting up CVE report routes because given zot binary doesn't include " +
+		"this feature, please build a binary that does so")
+}
@@ -0,0 +1,98 @@
+//go:build search
+// +build search
+
+package extensions_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/extensions/search/cve/report"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+func TestCVEReportHandler(t *testing.T) {
+	const ReportURL = "http://127.0.0.1:8080/v2/_zot/ext/cve/report"
+
+	log := log.NewLogger("debug", "")
+
+	repoDB := mocks.RepoDBMock{
+		GetMultipleRepoMetaFn: func(ctx context.Context, filter func(repoMeta repodb.RepoMetadata) bool,
+			requestedPage repodb.PageInput,
+		) ([]repodb.RepoMetadata, error) {
+			return []repodb.RepoMetadata{
+				{Name: "team-a/app", Tags: map[string]repodb.Descriptor{"1.0": {Digest: "sha256:1"}}},
+			}, nil
+		},
+	}
+
+	cveInfo := mocks.CveInfoMock{
+		GetCVESummaryForImageMediaFn: func(repo, digest, mediaType string) (cvemodel.ImageCVESummary, error) {
+			return cvemodel.ImageCVESummary{
+				Count: 2, MaxSeverity: "HIGH", CountBySeverity: map[string]int{"HIGH": 2},
+			}, nil
+		},
+	}
+
+	get := func(conf *config.Config, reporter *report.Reporter, query string, acCtx any) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, ReportURL+query, nil)
+
+		if acCtx != nil {
+			request = request.WithContext(context.WithValue(request.Context(), localCtx.GetContextKey(), acCtx))
+		}
+
+		response := httptest.NewRecorder()
+		extensions.HandleCVEReport(conf, reporter, log)(response, request)
+
+		return response
+	}
+
+	Convey("Get the last report as JSON or CSV", t, func() {
+		conf := config.New()
+		reporter := report.NewReporter(cveInfo, repoDB, t.TempDir(), "", log)
+
+		So(get(conf, reporter, "", nil).Code, ShouldEqual, http.StatusNotFound)
+
+		_, err := reporter.Generate(context.Background())
+		So(err, ShouldBeNil)
+
+		response := get(conf, reporter, "", nil)
+		So(response.Code, ShouldEqual, http.StatusOK)
+
+		var cveReport report.Report
+
+		err = json.Unmarshal(response.Body.Bytes(), &cveReport)
+		So(err, ShouldBeNil)
+		So(len(cveReport.Namespaces), ShouldEqual, 1)
+		So(cveReport.Namespaces[0].Namespace, ShouldEqual, "team-a")
+		So(cveReport.Namespaces[0].Counts["HIGH"], ShouldEqual, 2)
+
+		response = get(conf, reporter, "?format=csv", nil)
+		So(response.Code, ShouldEqual, http.StatusOK)
+		So(response.Header().Get("Content-Type"), ShouldEqual, "text/csv")
+		So(strings.Split(response.Body.String(), "\n")[1], ShouldStartWith, "team-a,1,1,0,0,2,")
+
+		So(get(conf, reporter, "?format=xml", nil).Code, ShouldEqual, http.StatusBadRequest)
+		So(get(conf, reporter, "", "bad context").Code, ShouldEqual, http.StatusInternalServerError)
+
+		// only admins can get the report with access control enabled
+		conf.HTTP.AccessControl = &config.AccessControlConfig{}
+
+		So(get(conf, reporter, "", localCtx.AccessControlContext{Username: "user"}).Code,
+			ShouldEqual, http.StatusForbidden)
+		So(get(conf, reporter, "", localCtx.AccessControlContext{Username: "admin", IsAdmin: true}).Code,
+			ShouldEqual, http.StatusOK)
+	})
+}
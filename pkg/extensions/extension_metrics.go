@@ -12,6 +12,10 @@ import (
 	"zotregistry.io/zot/pkg/storage"
 )
 
+func IsBuiltWithMetricsExtension() bool {
+	return true
+}
+
 func EnableMetricsExtension(config *config.Config, log log.Logger, rootDir string) {
 	if config.Extensions.Metrics != nil &&
 		*config.Extensions.Metrics.Enable &&
@@ -11,6 +11,10 @@ import (
 	"zotregistry.io/zot/pkg/storage"
 )
 
+func IsBuiltWithMetricsExtension() bool {
+	return false
+}
+
 // EnableMetricsExtension ...
 func EnableMetricsExtension(config *config.Config, log log.Logger, rootDir string) {
 	log.Warn().Msg("skipping enabling metrics extension because given zot binary doesn't include this feature," +
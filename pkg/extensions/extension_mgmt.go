@@ -6,25 +6,45 @@ package extensions
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"time"
 
+	glob "github.com/bmatcuk/doublestar/v4"
 	"github.com/gorilla/mux"
 	"github.com/opencontainers/go-digest"
 
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/blocklist"
 	"zotregistry.io/zot/pkg/api/config"
 	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/api/roles"
+	"zotregistry.io/zot/pkg/api/tenancy"
 	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/sync"
 	"zotregistry.io/zot/pkg/log"
+	metaCommon "zotregistry.io/zot/pkg/meta/common"
 	"zotregistry.io/zot/pkg/meta/repodb"
 	"zotregistry.io/zot/pkg/meta/signatures"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
 	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage"
+	storageCommon "zotregistry.io/zot/pkg/storage/common"
+	"zotregistry.io/zot/pkg/storage/lease"
 )
 
 const (
-	ConfigResource     = "config"
-	SignaturesResource = "signatures"
+	ConfigResource          = "config"
+	EffectiveConfigResource = "effectiveConfig"
+	SignaturesResource      = "signatures"
+	CacheResource           = "cache"
+	PurgeResource           = "purge"
+	BlocklistResource       = "blocklist"
+	LeaseResource           = "lease"
+	RolesResource           = "roles"
+	SyncResource            = "sync"
+	TenantsResource         = "tenants"
 )
 
 type HTPasswd struct {
@@ -52,6 +72,25 @@ type StrippedConfig struct {
 	} `json:"http" mapstructure:"http"`
 }
 
+// EffectiveConfig is the config zot is running with, including the default values, with its secrets redacted.
+type EffectiveConfig struct {
+	Config *config.Config `json:"config"`
+	// extensions enabled in the config which the binary is built with, and enabled plugins
+	ActiveExtensions []string `json:"activeExtensions"`
+}
+
+// PurgeResult lists the repos a digest was purged from.
+type PurgeResult struct {
+	Digest       string       `json:"digest"`
+	Repositories []PurgedRepo `json:"repositories"`
+}
+
+// PurgedRepo lists the tags and untagged manifests removed from a repo while purging a digest.
+type PurgedRepo struct {
+	Name       string   `json:"name"`
+	References []string `json:"references"`
+}
+
 func IsBuiltWithMGMTExtension() bool {
 	return true
 }
@@ -78,8 +117,14 @@ func (auth Auth) MarshalJSON() ([]byte, error) {
 }
 
 type mgmt struct {
-	config *config.Config
-	log    log.Logger
+	config          *config.Config
+	storeController storage.StoreController
+	repoDB          repodb.RepoDB
+	blocklist       *blocklist.Blocklist
+	leases          *lease.Leases
+	roleBindings    *roles.Bindings
+	tenants         *tenancy.Tenants
+	log             log.Logger
 }
 
 func (mgmt *mgmt) handler() http.Handler {
@@ -100,6 +145,14 @@ func (mgmt *mgmt) handler() http.Handler {
 				w.WriteHeader(http.StatusBadRequest)
 			}
 
+			return
+		case EffectiveConfigResource:
+			if r.Method == http.MethodGet {
+				mgmt.HandleGetEffectiveConfig(w, r)
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+
 			return
 		case SignaturesResource:
 			if r.Method == http.MethodPost {
@@ -108,6 +161,46 @@ func (mgmt *mgmt) handler() http.Handler {
 				w.WriteHeader(http.StatusBadRequest)
 			}
 
+			return
+		case CacheResource:
+			if r.Method == http.MethodPost {
+				mgmt.HandleCacheMaintenance(w, r)
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+
+			return
+		case PurgeResource:
+			if r.Method == http.MethodPost {
+				mgmt.HandlePurgeDigest(w, r)
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+
+			return
+		case BlocklistResource:
+			mgmt.HandleBlocklist(w, r)
+
+			return
+		case LeaseResource:
+			mgmt.HandleLease(w, r)
+
+			return
+		case RolesResource:
+			mgmt.HandleRoles(w, r)
+
+			return
+		case TenantsResource:
+			mgmt.HandleTenants(w, r)
+
+			return
+		case SyncResource:
+			if r.Method == http.MethodGet {
+				mgmt.HandleSyncPreflight(w, r)
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+
 			return
 		default:
 			w.WriteHeader(http.StatusBadRequest)
@@ -117,13 +210,25 @@ func (mgmt *mgmt) handler() http.Handler {
 	})
 }
 
-func SetupMgmtRoutes(config *config.Config, router *mux.Router, log log.Logger) {
+func SetupMgmtRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
+	repoDB repodb.RepoDB, blocklist *blocklist.Blocklist, leases *lease.Leases, roleBindings *roles.Bindings,
+	tenants *tenancy.Tenants, log log.Logger,
+) {
 	if config.Extensions.Mgmt != nil && *config.Extensions.Mgmt.Enable {
 		log.Info().Msg("setting up mgmt routes")
 
-		mgmt := mgmt{config: config, log: log}
+		mgmt := mgmt{
+			config:          config,
+			storeController: storeController,
+			repoDB:          repoDB,
+			blocklist:       blocklist,
+			leases:          leases,
+			roleBindings:    roleBindings,
+			tenants:         tenants,
+			log:             log,
+		}
 
-		allowedMethods := zcommon.AllowedMethods(http.MethodGet, http.MethodPost)
+		allowedMethods := zcommon.AllowedMethods(http.MethodGet, http.MethodPost, http.MethodDelete)
 
 		mgmtRouter := router.PathPrefix(constants.ExtMgmt).Subrouter()
 		mgmtRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
@@ -153,6 +258,38 @@ func (mgmt *mgmt) HandleGetConfig(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(buf)
 }
 
+// mgmtHandler godoc
+// @Summary Get the effective server configuration
+// @Description Get the whole configuration the server is running with, including the default values, with
+// @Description its secrets redacted, and the active extensions, i.e. the ones enabled in the configuration
+// @Description which the binary is built with, and the enabled plugins.
+// @Description When access control is enabled only admins can get the effective configuration.
+// @Router 	/v2/_zot/ext/mgmt [get]
+// @Produce json
+// @Param 	resource 	 query 	 string 		true	"specify resource" Enums(effectiveConfig)
+// @Success 200 {object} 	extensions.EffectiveConfig
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 500 {string} 	string 				"internal server error".
+func (mgmt *mgmt) HandleGetEffectiveConfig(response http.ResponseWriter, request *http.Request) {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if mgmt.config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, EffectiveConfig{
+		Config:           mgmt.config.Sanitize(),
+		ActiveExtensions: GetActiveExtensions(mgmt.config),
+	})
+}
+
 // mgmtHandler godoc
 // @Summary Upload certificates and public keys for verifying signatures
 // @Description Upload certificates and public keys for verifying signatures
@@ -229,6 +366,632 @@ func HandleCertificatesAndPublicKeysUploads(response http.ResponseWriter, reques
 	response.WriteHeader(http.StatusOK)
 }
 
+// mgmtHandler godoc
+// @Summary Check and compact the dedupe cache
+// @Description Check the integrity of the dedupe cache db of every store and compact it,
+// @Description stores using a remote cache are skipped.
+// @Description When access control is enabled only admins can trigger the cache maintenance.
+// @Router 	/v2/_zot/ext/mgmt [post]
+// @Produce json
+// @Param 	resource 	 query 	 string 		true	"specify resource" Enums(cache)
+// @Success 200 {string}    string              "ok"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 500 {string} 	string 				"internal server error".
+func (mgmt *mgmt) HandleCacheMaintenance(response http.ResponseWriter, request *http.Request) {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if mgmt.config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	failed := false
+
+	for _, imgStore := range mgmt.storeController.UniqueImageStores() {
+		if err := imgStore.RunCacheMaintenance(); err != nil {
+			failed = true
+		}
+	}
+
+	mgmt.log.Info().Str("user", localCtx.GetUsernameFromContext(acCtx)).Bool("failed", failed).
+		Msg("mgmt: cache maintenance triggered")
+
+	if failed {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+// mgmtHandler godoc
+// @Summary Check the sync config against the upstream registries
+// @Description Check the credentials file can be read, the content filters compile and each upstream
+// @Description registry can be reached over TLS, accepts the configured credentials and serves its catalog
+// @Description when it is synced periodically, without syncing anything.
+// @Description When access control is enabled only admins can check the sync config.
+// @Router 	/v2/_zot/ext/mgmt [get]
+// @Produce json
+// @Param 	resource 	 query 	 string 		true	"specify resource" Enums(sync)
+// @Success 200 {object}    sync.PreflightReport
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func (mgmt *mgmt) HandleSyncPreflight(response http.ResponseWriter, request *http.Request) {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if mgmt.config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	if !IsBuiltWithSyncExtension() || mgmt.config.Extensions == nil || mgmt.config.Extensions.Sync == nil ||
+		mgmt.config.Extensions.Sync.Enable == nil || !*mgmt.config.Extensions.Sync.Enable {
+		response.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	report := sync.Preflight(mgmt.config.Extensions.Sync, mgmt.log)
+
+	mgmt.log.Info().Str("user", localCtx.GetUsernameFromContext(acCtx)).Bool("ok", report.OK).
+		Msg("mgmt: sync config checked")
+
+	zcommon.WriteJSON(response, http.StatusOK, report)
+}
+
+// mgmtHandler godoc
+// @Summary Purge a digest from all repos
+// @Description Remove a blob or manifest digest from every repo right away instead of waiting for gc,
+// @Description the manifests referencing it are removed along with their tags and the blob is removed
+// @Description from the dedupe cache so it can't be restored from another repo.
+// @Description When access control is enabled only admins can purge digests.
+// @Router 	/v2/_zot/ext/mgmt [post]
+// @Produce json
+// @Param 	resource 	 query 	 string 		true	"specify resource" Enums(purge)
+// @Param 	digest 	 query 	 string 		true	"digest of the blob or manifest to purge"
+// @Success 200 {object}    extensions.PurgeResult
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func (mgmt *mgmt) HandlePurgeDigest(response http.ResponseWriter, request *http.Request) {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if mgmt.config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	purgeDigest, err := digest.Parse(request.URL.Query().Get("digest"))
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	result := PurgeResult{Digest: purgeDigest.String(), Repositories: []PurgedRepo{}}
+
+	failed := false
+
+	for _, imgStore := range mgmt.storeController.UniqueImageStores() {
+		repos, err := imgStore.GetRepositories()
+		if err != nil {
+			mgmt.log.Error().Err(err).Str("rootDir", imgStore.RootDir()).Msg("mgmt: unable to list repos")
+
+			failed = true
+
+			continue
+		}
+
+		for _, repo := range repos {
+			purged, found, err := storageCommon.PurgeDigest(imgStore, repo, purgeDigest, mgmt.log.Logger)
+			if err != nil {
+				failed = true
+			}
+
+			if !found && len(purged) == 0 {
+				continue
+			}
+
+			purgedRepo := PurgedRepo{Name: repo, References: []string{}}
+
+			for _, manifest := range purged {
+				purgedRepo.References = append(purgedRepo.References, manifest.Reference)
+
+				mgmt.purgeRepoMeta(repo, manifest)
+			}
+
+			result.Repositories = append(result.Repositories, purgedRepo)
+		}
+	}
+
+	mgmt.log.Info().Str("user", localCtx.GetUsernameFromContext(acCtx)).Str("digest", purgeDigest.String()).
+		Int("repos", len(result.Repositories)).Bool("failed", failed).Msg("mgmt: digest purged")
+
+	if failed {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if len(result.Repositories) == 0 {
+		response.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, result)
+}
+
+// mgmtHandler godoc
+// @Summary Manage the digest blocklist
+// @Description List, block or unblock digests, blocked digests can't be pushed or pulled and the
+// @Description repos holding a copy when a digest is blocked are recorded as quarantined.
+// @Description Digests blocked in the config file can't be unblocked through the API.
+// @Description When access control is enabled only admins can manage the blocklist.
+// @Router 	/v2/_zot/ext/mgmt [get]
+// @Router 	/v2/_zot/ext/mgmt [post]
+// @Router 	/v2/_zot/ext/mgmt [delete]
+// @Produce json
+// @Param 	resource 	 query 	 string 		true	"specify resource" Enums(blocklist)
+// @Param 	digest 	 query 	 string 		false	"digest to block or unblock"
+// @Param 	reason 	 query 	 string 		false	"why the digest is blocked"
+// @Success 200 {object}    blocklist.EntryList
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func (mgmt *mgmt) HandleBlocklist(response http.ResponseWriter, request *http.Request) {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if mgmt.config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	if mgmt.blocklist == nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if request.Method == http.MethodGet {
+		zcommon.WriteJSON(response, http.StatusOK, blocklist.EntryList{Entries: mgmt.blocklist.List()})
+
+		return
+	}
+
+	blockedDigest, err := digest.Parse(request.URL.Query().Get("digest"))
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	username := localCtx.GetUsernameFromContext(acCtx)
+
+	if request.Method == http.MethodDelete {
+		if err := mgmt.blocklist.Remove(blockedDigest); err != nil {
+			switch {
+			case errors.Is(err, zerr.ErrBlocklistEntryNotFound):
+				response.WriteHeader(http.StatusNotFound)
+			case errors.Is(err, zerr.ErrBlocklistEntryFromConfig):
+				response.WriteHeader(http.StatusBadRequest)
+			default:
+				response.WriteHeader(http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		mgmt.log.Info().Str("user", username).Str("digest", blockedDigest.String()).Msg("mgmt: digest unblocked")
+
+		response.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	entry := blocklist.Entry{
+		Digest:      blockedDigest,
+		Reason:      request.URL.Query().Get("reason"),
+		AddedBy:     username,
+		AddedAt:     time.Now(),
+		Quarantined: mgmt.getReposReferencingDigest(blockedDigest),
+	}
+
+	if err := mgmt.blocklist.Add(entry); err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	mgmt.log.Warn().Str("user", username).Str("digest", blockedDigest.String()).Str("reason", entry.Reason).
+		Strs("quarantined", entry.Quarantined).Msg("mgmt: digest blocked")
+
+	zcommon.WriteJSON(response, http.StatusOK, entry)
+}
+
+// mgmtHandler godoc
+// @Summary Manage storage leases
+// @Description List, acquire, renew or release leases pausing garbage collection, external readers of the storage
+// @Description such as backup agents hold a lease while they read it. Leases expire after their duration unless they are
+// @Description renewed and they can't last longer than the max lease duration of the storage config.
+// @Description When access control is enabled only admins can manage leases.
+// @Router 	/v2/_zot/ext/mgmt [get]
+// @Router 	/v2/_zot/ext/mgmt [post]
+// @Router 	/v2/_zot/ext/mgmt [delete]
+// @Produce json
+// @Param 	resource 	 query 	 string 		true	"specify resource" Enums(lease)
+// @Param 	id 	 query 	 string 		false	"id of the lease to renew or release"
+// @Param 	duration 	 query 	 string 		false	"duration of the lease, e.g. 30m"
+// @Param 	reason 	 query 	 string 		false	"why the lease is acquired"
+// @Success 200 {object}    lease.LeaseList
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func (mgmt *mgmt) HandleLease(response http.ResponseWriter, request *http.Request) {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if mgmt.config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	if mgmt.leases == nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if request.Method == http.MethodGet {
+		zcommon.WriteJSON(response, http.StatusOK, lease.LeaseList{Leases: mgmt.leases.List()})
+
+		return
+	}
+
+	username := localCtx.GetUsernameFromContext(acCtx)
+	leaseID := request.URL.Query().Get("id")
+
+	if request.Method == http.MethodDelete {
+		if err := mgmt.leases.Release(leaseID); err != nil {
+			response.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		mgmt.log.Info().Str("user", username).Str("id", leaseID).Msg("mgmt: storage lease released")
+
+		response.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	var duration time.Duration
+
+	if durationStr := request.URL.Query().Get("duration"); durationStr != "" {
+		duration, err = time.ParseDuration(durationStr)
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	var storageLease lease.Lease
+
+	action := "acquired"
+
+	if leaseID == "" {
+		storageLease, err = mgmt.leases.Acquire(username, request.URL.Query().Get("reason"), duration)
+	} else {
+		action = "renewed"
+		storageLease, err = mgmt.leases.Renew(leaseID, duration)
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, zerr.ErrLeaseNotFound):
+			response.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, zerr.ErrBadLeaseDuration):
+			response.WriteHeader(http.StatusBadRequest)
+		default:
+			response.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	mgmt.log.Info().Str("user", username).Str("id", storageLease.ID).Time("expiresAt", storageLease.ExpiresAt).
+		Msg("mgmt: storage lease " + action)
+
+	zcommon.WriteJSON(response, http.StatusOK, storageLease)
+}
+
+// mgmtHandler godoc
+// @Summary Manage role bindings
+// @Description List, assign or unassign roles (reader, publisher, maintainer or admin) on the repos matching a pattern,
+// @Description the roles assigned through the API are enforced in addition to the access control config.
+// @Description When access control is enabled only admins can manage role bindings.
+// @Router 	/v2/_zot/ext/mgmt [get]
+// @Router 	/v2/_zot/ext/mgmt [post]
+// @Router 	/v2/_zot/ext/mgmt [delete]
+// @Accept  json
+// @Produce json
+// @Param 	resource 	 query 	 string 		true	"specify resource" Enums(roles)
+// @Param 	pattern 	 query 	 string 		false	"repo pattern of the role binding to remove"
+// @Param 	role 	 query 	 string 		false	"role of the role binding to remove"
+// @Param   requestBody		body	roles.Binding		false	"role binding to add"
+// @Success 200 {object}    roles.BindingList
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func (mgmt *mgmt) HandleRoles(response http.ResponseWriter, request *http.Request) {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if mgmt.config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	if mgmt.roleBindings == nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if request.Method == http.MethodGet {
+		zcommon.WriteJSON(response, http.StatusOK, roles.BindingList{Bindings: mgmt.roleBindings.List()})
+
+		return
+	}
+
+	username := localCtx.GetUsernameFromContext(acCtx)
+
+	if request.Method == http.MethodDelete {
+		pattern, role := request.URL.Query().Get("pattern"), request.URL.Query().Get("role")
+
+		if err := mgmt.roleBindings.Remove(pattern, role); err != nil {
+			if errors.Is(err, zerr.ErrRoleBindingNotFound) {
+				response.WriteHeader(http.StatusNotFound)
+			} else {
+				response.WriteHeader(http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		mgmt.log.Info().Str("user", username).Str("pattern", pattern).Str("role", role).
+			Msg("mgmt: role unassigned")
+
+		response.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	var binding roles.Binding
+
+	if err := json.NewDecoder(request.Body).Decode(&binding); err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	binding.AddedBy = username
+	binding.AddedAt = time.Now()
+
+	if err := mgmt.roleBindings.Add(binding); err != nil {
+		if errors.Is(err, zerr.ErrUnknownRole) || errors.Is(err, glob.ErrBadPattern) {
+			response.WriteHeader(http.StatusBadRequest)
+		} else {
+			response.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	mgmt.log.Info().Str("user", username).Str("pattern", binding.Pattern).Str("role", binding.Role).
+		Strs("users", binding.Users).Strs("groups", binding.Groups).Msg("mgmt: role assigned")
+
+	zcommon.WriteJSON(response, http.StatusOK, binding)
+}
+
+// mgmtHandler godoc
+// @Summary Manage tenants
+// @Description List, add, replace or remove the tenants served as virtual registries on their own hosts or path
+// @Description prefixes, the tenants of the config file can't be changed through the API.
+// @Description When access control is enabled only admins can manage tenants.
+// @Router 	/v2/_zot/ext/mgmt [get]
+// @Router 	/v2/_zot/ext/mgmt [post]
+// @Router 	/v2/_zot/ext/mgmt [delete]
+// @Accept  json
+// @Produce json
+// @Param 	resource 	 query 	 string 		true	"specify resource" Enums(tenants)
+// @Param 	name 	 query 	 string 		false	"name of the tenant to remove"
+// @Param   requestBody		body	tenancy.Tenant		false	"tenant to add or replace"
+// @Success 200 {object}    tenancy.TenantList
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func (mgmt *mgmt) HandleTenants(response http.ResponseWriter, request *http.Request) {
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if mgmt.config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+		response.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	if mgmt.tenants == nil {
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if request.Method == http.MethodGet {
+		zcommon.WriteJSON(response, http.StatusOK, tenancy.TenantList{Tenants: mgmt.tenants.List()})
+
+		return
+	}
+
+	username := localCtx.GetUsernameFromContext(acCtx)
+
+	if request.Method == http.MethodDelete {
+		name := request.URL.Query().Get("name")
+
+		if err := mgmt.tenants.Remove(name); err != nil {
+			switch {
+			case errors.Is(err, zerr.ErrTenantNotFound):
+				response.WriteHeader(http.StatusNotFound)
+			case errors.Is(err, zerr.ErrTenantFromConfig):
+				response.WriteHeader(http.StatusBadRequest)
+			default:
+				response.WriteHeader(http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		mgmt.log.Info().Str("user", username).Str("tenant", name).Msg("mgmt: tenant removed")
+
+		response.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	var tenant tenancy.Tenant
+
+	if err := json.NewDecoder(request.Body).Decode(&tenant); err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	tenant.AddedBy = username
+	tenant.AddedAt = time.Now()
+
+	if err := mgmt.tenants.Add(tenant); err != nil {
+		if errors.Is(err, zerr.ErrBadTenant) || errors.Is(err, zerr.ErrTenantFromConfig) {
+			response.WriteHeader(http.StatusBadRequest)
+		} else {
+			response.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	mgmt.log.Info().Str("user", username).Str("tenant", tenant.Name).Strs("hosts", tenant.Hosts).
+		Str("pathPrefix", tenant.PathPrefix).Msg("mgmt: tenant added")
+
+	zcommon.WriteJSON(response, http.StatusOK, tenant)
+}
+
+// getReposReferencingDigest returns the repos holding an image which is or references the digest.
+func (mgmt *mgmt) getReposReferencingDigest(blockedDigest digest.Digest) []string {
+	repos := []string{}
+
+	for _, imgStore := range mgmt.storeController.UniqueImageStores() {
+		storeRepos, err := imgStore.GetRepositories()
+		if err != nil {
+			mgmt.log.Error().Err(err).Str("rootDir", imgStore.RootDir()).Msg("mgmt: unable to list repos")
+
+			continue
+		}
+
+		for _, repo := range storeRepos {
+			if found, _ := storageCommon.RepoReferencesDigest(imgStore, repo, blockedDigest, mgmt.log.Logger); found {
+				repos = append(repos, repo)
+			}
+		}
+	}
+
+	return repos
+}
+
+// purgeRepoMeta removes a purged manifest from repodb, unlike meta.OnDeleteManifest the manifest is not
+// restored in storage if repodb can't be updated, purged content must not come back.
+func (mgmt *mgmt) purgeRepoMeta(repo string, manifest storageCommon.PurgedManifest) {
+	if mgmt.repoDB == nil {
+		return
+	}
+
+	isSignature, signatureType, signedManifestDigest, err := storage.CheckIsImageSignature(repo, manifest.Blob,
+		manifest.Reference)
+	if err != nil {
+		mgmt.log.Error().Err(err).Str("repository", repo).Str("reference", manifest.Reference).
+			Msg("mgmt: can't check if purged image is a signature or not")
+
+		return
+	}
+
+	isAttestation, attestedManifestDigest := storage.CheckIsCosignAttestation(manifest.Reference)
+
+	if isAttestation {
+		err = mgmt.repoDB.DeleteManifestAttestations(repo, attestedManifestDigest, manifest.Digest)
+	} else if isSignature {
+		err = mgmt.repoDB.DeleteSignature(repo, signedManifestDigest, repodb.SignatureMetadata{
+			SignatureDigest: manifest.Digest.String(),
+			SignatureType:   signatureType,
+		})
+	} else {
+		err = mgmt.repoDB.DeleteRepoTag(repo, manifest.Reference)
+
+		if referredDigest, hasSubject := metaCommon.GetReferredSubject(manifest.Blob); err == nil && hasSubject {
+			err = mgmt.repoDB.DeleteReferrer(repo, referredDigest, manifest.Digest)
+		}
+	}
+
+	if err != nil {
+		mgmt.log.Error().Err(err).Str("repository", repo).Str("reference", manifest.Reference).
+			Msg("mgmt: unable to remove purged image from repodb")
+	}
+}
+
 func EnablePeriodicSignaturesVerification(config *config.Config, taskScheduler *scheduler.Scheduler,
 	repoDB repodb.RepoDB, log log.Logger,
 ) {
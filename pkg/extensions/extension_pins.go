@@ -0,0 +1,163 @@
+//go:build search
+// +build search
+
+package extensions
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	zreg "zotregistry.io/zot/pkg/regexp"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+type Pin struct {
+	Reference string    `json:"reference"`
+	Digest    string    `json:"digest"`
+	PinnedBy  string    `json:"pinnedBy"`
+	PinnedAt  time.Time `json:"pinnedAt"`
+}
+
+type PinList struct {
+	Pins []Pin `json:"pins"`
+}
+
+func SetupPinRoutes(config *config.Config, router *mux.Router, repoDB repodb.RepoDB, log log.Logger) {
+	if config.Extensions.Search != nil && *config.Extensions.Search.Enable && repoDB != nil {
+		log.Info().Msg("setting up pin routes")
+
+		allowedMethods := zcommon.AllowedMethods(http.MethodGet, http.MethodPut, http.MethodDelete)
+
+		pinsRouter := router.PathPrefix(constants.ExtPins).Subrouter()
+		pinsRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+		pinsRouter.Use(zcommon.AddExtensionSecurityHeaders())
+		pinsRouter.HandleFunc(fmt.Sprintf("/{name:%s}", zreg.NameRegexp.String()),
+			HandleListPins(repoDB, log)).Methods(zcommon.AllowedMethods(http.MethodGet)...)
+		pinsRouter.HandleFunc(fmt.Sprintf("/{name:%s}/{reference}", zreg.NameRegexp.String()),
+			HandlePin(config, repoDB, log)).Methods(zcommon.AllowedMethods(http.MethodPut, http.MethodDelete)...)
+	}
+}
+
+// HandleListPins godoc
+// @Summary List the pinned references of a repo
+// @Description List the pinned tags and digests of a repo, pinned images are not garbage collected
+// @Router 	/v2/_zot/ext/pins/{name} [get]
+// @Produce json
+// @Param   name     path    string     true        "repository name"
+// @Success 200 {object} 	extensions.PinList
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleListPins(repoDB repodb.RepoDB, log log.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		repo := mux.Vars(req)["name"]
+
+		repoMeta, ok := getRepoMetaForRequest(rsp, req, repoDB, repo, log)
+		if !ok {
+			return
+		}
+
+		pinList := PinList{Pins: make([]Pin, 0, len(repoMeta.Pins))}
+
+		for reference, pin := range repoMeta.Pins {
+			pinList.Pins = append(pinList.Pins, Pin{
+				Reference: reference,
+				Digest:    pin.Digest,
+				PinnedBy:  pin.PinnedBy,
+				PinnedAt:  pin.PinnedAt,
+			})
+		}
+
+		sort.Slice(pinList.Pins, func(i, j int) bool {
+			return pinList.Pins[i].Reference < pinList.Pins[j].Reference
+		})
+
+		zcommon.WriteJSON(rsp, http.StatusOK, pinList)
+	}
+}
+
+// HandlePin godoc
+// @Summary Pin or unpin a reference of a repo
+// @Description Pin a tag or digest of a repo so the image it points to is not garbage collected,
+// @Description a pinned tag keeps protecting the image it pointed to when it was pinned.
+// @Description When access control is enabled only admins can pin and unpin images.
+// @Router 	/v2/_zot/ext/pins/{name}/{reference} [put]
+// @Router 	/v2/_zot/ext/pins/{name}/{reference} [delete]
+// @Produce json
+// @Param   name       path    string     true        "repository name"
+// @Param   reference  path    string     true        "tag or digest"
+// @Success 200 {object} 	extensions.Pin
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandlePin(config *config.Config, repoDB repodb.RepoDB, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repo, reference := vars["name"], vars["reference"]
+
+		acCtx, err := localCtx.GetAccessControlContext(req.Context())
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		available, err := localCtx.RepoIsUserAvailable(req.Context(), repo)
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		// pins change what gc keeps, with access control enabled they are reserved to admins
+		if !available || (config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin)) {
+			rsp.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		pin := Pin{Reference: reference}
+
+		if req.Method == http.MethodDelete {
+			err = repoDB.UnpinImage(repo, reference)
+		} else {
+			var pinInfo repodb.PinInfo
+
+			pinInfo, err = repoDB.PinImage(req.Context(), repo, reference)
+			pin.Digest, pin.PinnedBy, pin.PinnedAt = pinInfo.Digest, pinInfo.PinnedBy, pinInfo.PinnedAt
+		}
+
+		if err != nil {
+			if errors.Is(err, zerr.ErrRepoMetaNotFound) || errors.Is(err, zerr.ErrManifestMetaNotFound) ||
+				errors.Is(err, zerr.ErrPinNotFound) {
+				rsp.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			log.Error().Err(err).Str("repository", repo).Str("reference", reference).Str("method", req.Method).
+				Msg("failed to update image pin")
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Info().Str("repository", repo).Str("reference", reference).Str("digest", pin.Digest).
+			Str("user", localCtx.GetUsernameFromContext(acCtx)).Str("method", req.Method).Msg("image pin updated")
+
+		// a 200 status makes the change show up in the audit log
+		zcommon.WriteJSON(rsp, http.StatusOK, pin)
+	}
+}
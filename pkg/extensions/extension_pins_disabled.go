@@ -0,0 +1,18 @@
+//go:build !search
+// +build !search
+
+package extensions
+
+import (
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+)
+
+// SetupPinRoutes ...
+func SetupPinRoutes(config *config.Config, router *mux.Router, repoDB repodb.RepoDB, log log.Logger) {
+	log.Warn().Msg("skipping setting up pin routes because given zot binary doesn't include this feature," +
+		"please build a binary that does so")
+}
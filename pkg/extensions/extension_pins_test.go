@@ -0,0 +1,182 @@
+//go:build search
+// +build search
+
+package extensions_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
+
+	"zotregistry.io/zot/pkg/api"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/extensions"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/test"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+var ErrTestPin = errors.New("test: pin error")
+
+func TestPinsExtension(t *testing.T) {
+	Convey("Pin and unpin images", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+		}
+
+		ctlr := api.NewController(conf)
+		ctrlManager := test.NewControllerManager(ctlr)
+
+		ctrlManager.StartAndWait(port)
+
+		defer ctrlManager.StopServer()
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		digest, err := image.Digest()
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(image, baseURL, "test/repo")
+		So(err, ShouldBeNil)
+
+		pinsURL := baseURL + constants.FullPinsPrefix + "/test/repo"
+
+		resp, err := resty.R().Put(pinsURL + "/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var pin extensions.Pin
+
+		err = json.Unmarshal(resp.Body(), &pin)
+		So(err, ShouldBeNil)
+		So(pin.Reference, ShouldEqual, "1.0")
+		So(pin.Digest, ShouldEqual, digest.String())
+
+		resp, err = resty.R().Put(pinsURL + "/" + digest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Get(pinsURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var pinList extensions.PinList
+
+		err = json.Unmarshal(resp.Body(), &pinList)
+		So(err, ShouldBeNil)
+		So(len(pinList.Pins), ShouldEqual, 2)
+		So(pinList.Pins[0].Reference, ShouldEqual, "1.0")
+		So(pinList.Pins[1].Reference, ShouldEqual, digest.String())
+
+		pinned, err := ctlr.RepoDB.IsImagePinned("test/repo", digest)
+		So(err, ShouldBeNil)
+		So(pinned, ShouldBeTrue)
+
+		resp, err = resty.R().Delete(pinsURL + "/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Delete(pinsURL + "/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().Put(pinsURL + "/2.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().Put(baseURL + constants.FullPinsPrefix + "/missing/repo/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().Get(baseURL + constants.FullPinsPrefix + "/missing/repo")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+}
+
+func TestPinHandlers(t *testing.T) {
+	const PinsBaseURL = "http://127.0.0.1:8080/v2/_zot/ext/pins"
+
+	log := log.NewLogger("debug", "")
+	conf := config.New()
+	mockRepoDB := mocks.RepoDBMock{}
+
+	Convey("Only admins can pin images with access control enabled", t, func() {
+		conf.HTTP.AccessControl = &config.AccessControlConfig{}
+
+		request := httptest.NewRequest(http.MethodPut, PinsBaseURL+"/repo/1.0", nil)
+		request = mux.SetURLVars(request, map[string]string{"name": "repo", "reference": "1.0"})
+
+		ctx := context.WithValue(request.Context(), localCtx.GetContextKey(),
+			localCtx.AccessControlContext{Username: "user"})
+
+		response := httptest.NewRecorder()
+		extensions.HandlePin(conf, mockRepoDB, log)(response, request.WithContext(ctx))
+		res := response.Result()
+		So(res.StatusCode, ShouldEqual, http.StatusForbidden)
+		defer res.Body.Close()
+
+		ctx = context.WithValue(request.Context(), localCtx.GetContextKey(),
+			localCtx.AccessControlContext{Username: "admin", IsAdmin: true})
+
+		response = httptest.NewRecorder()
+		extensions.HandlePin(conf, mockRepoDB, log)(response, request.WithContext(ctx))
+		res = response.Result()
+		So(res.StatusCode, ShouldEqual, http.StatusOK)
+		defer res.Body.Close()
+	})
+
+	Convey("RepoDB errors", t, func() {
+		conf.HTTP.AccessControl = nil
+
+		mockRepoDB.PinImageFn = func(ctx context.Context, repo, reference string) (repodb.PinInfo, error) {
+			return repodb.PinInfo{}, ErrTestPin
+		}
+
+		mockRepoDB.UnpinImageFn = func(repo, reference string) error {
+			return ErrTestPin
+		}
+
+		mockRepoDB.GetRepoMetaFn = func(repo string) (repodb.RepoMetadata, error) {
+			return repodb.RepoMetadata{}, ErrTestPin
+		}
+
+		for _, method := range []string{http.MethodPut, http.MethodDelete} {
+			request := httptest.NewRequest(method, PinsBaseURL+"/repo/1.0", nil)
+			request = mux.SetURLVars(request, map[string]string{"name": "repo", "reference": "1.0"})
+
+			response := httptest.NewRecorder()
+			extensions.HandlePin(conf, mockRepoDB, log)(response, request)
+			res := response.Result()
+			So(res.StatusCode, ShouldEqual, http.StatusInternalServerError)
+			defer res.Body.Close()
+		}
+
+		request := httptest.NewRequest(http.MethodGet, PinsBaseURL+"/repo", nil)
+		request = mux.SetURLVars(request, map[string]string{"name": "repo"})
+
+		response := httptest.NewRecorder()
+		extensions.HandleListPins(mockRepoDB, log)(response, request)
+		res := response.Result()
+		So(res.StatusCode, ShouldEqual, http.StatusInternalServerError)
+		defer res.Body.Close()
+	})
+}
@@ -0,0 +1,163 @@
+//go:build search
+// +build search
+
+package extensions
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	apiErr "zotregistry.io/zot/pkg/api/errors"
+	"zotregistry.io/zot/pkg/api/promotion"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	zreg "zotregistry.io/zot/pkg/regexp"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/storage"
+)
+
+func SetupPromotionRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
+	repoDB repodb.RepoDB, cveInfo CveInfo, gates *promotion.Gates, log log.Logger,
+) {
+	if config.Extensions.Search != nil && *config.Extensions.Search.Enable && repoDB != nil {
+		log.Info().Msg("setting up promotion routes")
+
+		allowedMethods := zcommon.AllowedMethods(http.MethodPut)
+
+		promotionRouter := router.PathPrefix(constants.ExtPromotion).Subrouter()
+		promotionRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+		promotionRouter.Use(zcommon.AddExtensionSecurityHeaders())
+		promotionRouter.HandleFunc(fmt.Sprintf("/{name:%s}/{tag:%s}", zreg.NameRegexp.String(),
+			zreg.TagRegexp.String()), HandlePromote(config, storeController, repoDB, cveInfo, gates, log)).
+			Methods(allowedMethods...)
+	}
+}
+
+// HandlePromote godoc
+// @Summary Promote an image to a tag
+// @Description Point a tag to an image already pushed to the repo, if the image passes the checks of the
+// @Description promotion gates protecting the tag: it has to be signed, scanned below the severity threshold
+// @Description or referred by a provenance attestation, as each gate requires. Tags protected by a gate can
+// @Description only be created this way. When access control is enabled only admins can promote images.
+// @Router 	/v2/_zot/ext/promotion/{name}/{tag} [put]
+// @Produce json
+// @Param   name     path    string     true        "repository name"
+// @Param   tag      path    string     true        "tag to point to the image"
+// @Param   digest   query   string     true        "digest of the image to promote"
+// @Success 200 {object} 	promotion.Report
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 412 {object} 	promotion.Report	"the image doesn't pass the gates"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandlePromote(config *config.Config, storeController storage.StoreController, repoDB repodb.RepoDB,
+	cveInfo CveInfo, gates *promotion.Gates, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repo, tag := vars["name"], vars["tag"]
+
+		digest, err := godigest.Parse(req.URL.Query().Get("digest"))
+		if err != nil {
+			rsp.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		acCtx, err := localCtx.GetAccessControlContext(req.Context())
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		// promoted tags skip the push permissions, with access control enabled promotions are reserved to admins
+		if config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+			rsp.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		repoMeta, ok := getRepoMetaForRequest(rsp, req, repoDB, repo, log)
+		if !ok {
+			return
+		}
+
+		imgStore := storeController.GetImageStore(repo)
+
+		manifestBlob, _, mediaType, err := imgStore.GetImageManifest(repo, digest.String())
+		if err != nil {
+			if errors.Is(err, zerr.ErrRepoNotFound) || errors.Is(err, zerr.ErrManifestNotFound) {
+				rsp.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			log.Error().Err(err).Str("repository", repo).Str("digest", digest.String()).
+				Msg("failed to get the manifest to promote")
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		var scan promotion.ScanFunc
+
+		if cveInfo != nil {
+			scan = func(repo string, digest godigest.Digest) (string, error) {
+				summary, err := cveInfo.GetCVESummaryForImageMedia(repo, digest.String(), mediaType)
+
+				return summary.MaxSeverity, err
+			}
+		}
+
+		report := promotion.Evaluate(gates.Match(repo, tag), repoMeta, tag, digest, scan)
+
+		username := localCtx.GetUsernameFromContext(acCtx)
+
+		if !report.OK {
+			log.Info().Str("repository", repo).Str("tag", tag).Str("digest", digest.String()).Str("user", username).
+				Interface("checks", report.Checks).Msg("promotion denied by the gates")
+			zcommon.WriteJSON(rsp, http.StatusPreconditionFailed, report)
+
+			return
+		}
+
+		if err := imgStore.TagImageManifest(repo, digest, []string{tag}); err != nil {
+			log.Error().Err(err).Str("repository", repo).Str("tag", tag).Str("digest", digest.String()).
+				Msg("failed to promote image")
+
+			if errors.Is(err, zerr.ErrTagImmutable) {
+				zcommon.WriteJSON(rsp, http.StatusConflict, apiErr.NewErrorList(apiErr.NewError(apiErr.TAG_IMMUTABLE,
+					map[string]string{"name": repo, "tag": tag}).WithMessage(err.Error())))
+
+				return
+			}
+
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if err := meta.OnUpdateManifest(repo, tag, mediaType, digest, manifestBlob, storeController, repoDB,
+			log); err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		log.Info().Str("repository", repo).Str("tag", tag).Str("digest", digest.String()).Str("user", username).
+			Msg("image promoted")
+
+		// a 200 status makes the promotion show up in the audit log
+		zcommon.WriteJSON(rsp, http.StatusOK, report)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !search
+// +build !search
+
+package extensions
+
+import (
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/promotion"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/storage"
+)
+
+// SetupPromotionRoutes ...
+func SetupPromotionRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
+	repoDB repodb.RepoDB, cveInfo CveInfo, gates *promotion.Gates, log log.Logger,
+) {
+	log.Warn().Msg("skipping setting up promotion routes because given zot binary doesn't include " +
+		"this feature, please build a binary that does so")
+}
@@ -0,0 +1,178 @@
+//go:build search
+// +build search
+
+package extensions_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
+
+	"zotregistry.io/zot/pkg/api"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/api/promotion"
+	"zotregistry.io/zot/pkg/extensions"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/log"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/storage"
+	"zotregistry.io/zot/pkg/test"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+func TestPromotionExtension(t *testing.T) {
+	Convey("Promote images through the gates", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+				PromotionGates: []extconf.PromotionGateConfig{
+					{Environment: "prod", Tag: "prod", RequireProvenance: true},
+				},
+			},
+		}
+
+		conf.Storage.TagAliases = []config.TagAliasRule{
+			{Tag: `v(\d+)`, Aliases: []string{"prod"}},
+		}
+
+		ctlr := api.NewController(conf)
+		ctrlManager := test.NewControllerManager(ctlr)
+
+		ctrlManager.StartAndWait(port)
+
+		defer ctrlManager.StopServer()
+
+		// images are pushed by digest, then promoted
+		image, err := test.GetRandomImage("")
+		So(err, ShouldBeNil)
+
+		digest, err := image.Digest()
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(image, baseURL, "test/repo")
+		So(err, ShouldBeNil)
+
+		manifestBlob, err := json.Marshal(image.Manifest)
+		So(err, ShouldBeNil)
+
+		resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/test/repo/manifests/prod")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		// nor through a tag alias
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/test/repo/manifests/v1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().Get(baseURL + "/v2/test/repo/manifests/v1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		promotionURL := baseURL + constants.FullPromotionPrefix + "/test/repo/prod"
+
+		resp, err = resty.R().SetQueryParam("digest", digest.String()).Put(promotionURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusPreconditionFailed)
+
+		var report promotion.Report
+
+		err = json.Unmarshal(resp.Body(), &report)
+		So(err, ShouldBeNil)
+		So(report.OK, ShouldBeFalse)
+		So(report.Digest, ShouldEqual, digest.String())
+		So(len(report.Checks), ShouldEqual, 1)
+		So(report.Checks[0].Environment, ShouldEqual, "prod")
+		So(report.Checks[0].Name, ShouldEqual, promotion.CheckProvenance)
+		So(report.Checks[0].OK, ShouldBeFalse)
+
+		resp, err = resty.R().Get(baseURL + "/v2/test/repo/manifests/prod")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		// a cosign attestation
+		attestation, err := test.GetRandomImage("sha256-" + digest.Encoded() + ".att")
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(attestation, baseURL, "test/repo")
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().SetQueryParam("digest", digest.String()).Put(promotionURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		err = json.Unmarshal(resp.Body(), &report)
+		So(err, ShouldBeNil)
+		So(report.OK, ShouldBeTrue)
+
+		resp, err = resty.R().Get(baseURL + "/v2/test/repo/manifests/prod")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, digest.String())
+
+		repoMeta, err := ctlr.RepoDB.GetRepoMeta("test/repo")
+		So(err, ShouldBeNil)
+		So(repoMeta.Tags["prod"].Digest, ShouldEqual, digest.String())
+
+		// tags not protected by a gate can be pushed and promoted
+		resp, err = resty.R().SetQueryParam("digest", digest.String()).
+			Put(baseURL + constants.FullPromotionPrefix + "/test/repo/dev")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Put(promotionURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetQueryParam("digest", godigest.FromString("missing").String()).Put(promotionURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().SetQueryParam("digest", digest.String()).
+			Put(baseURL + constants.FullPromotionPrefix + "/missing/repo/prod")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+}
+
+func TestPromotionHandlers(t *testing.T) {
+	const PromotionBaseURL = "http://127.0.0.1:8080/v2/_zot/ext/promotion"
+
+	log := log.NewLogger("debug", "")
+	conf := config.New()
+
+	Convey("Only admins can promote images with access control enabled", t, func() {
+		conf.HTTP.AccessControl = &config.AccessControlConfig{}
+
+		digest := godigest.FromString("image")
+
+		request := httptest.NewRequest(http.MethodPut, PromotionBaseURL+"/repo/prod?digest="+digest.String(), nil)
+		request = mux.SetURLVars(request, map[string]string{"name": "repo", "tag": "prod"})
+
+		ctx := context.WithValue(request.Context(), localCtx.GetContextKey(),
+			localCtx.AccessControlContext{Username: "user"})
+
+		response := httptest.NewRecorder()
+		extensions.HandlePromote(conf, storage.StoreController{}, mocks.RepoDBMock{}, nil, nil, log)(response,
+			request.WithContext(ctx))
+		res := response.Result()
+		So(res.StatusCode, ShouldEqual, http.StatusForbidden)
+		defer res.Body.Close()
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/extensions/scrub"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/scheduler"
@@ -16,9 +17,13 @@ import (
 	storageTypes "zotregistry.io/zot/pkg/storage/types"
 )
 
+func IsBuiltWithScrubExtension() bool {
+	return true
+}
+
 // EnableScrubExtension enables scrub extension.
 func EnableScrubExtension(config *config.Config, log log.Logger, storeController storage.StoreController,
-	sch *scheduler.Scheduler,
+	metrics monitoring.MetricServer, sch *scheduler.Scheduler,
 ) {
 	if config.Extensions.Scrub != nil &&
 		*config.Extensions.Scrub.Enable {
@@ -32,6 +37,7 @@ func EnableScrubExtension(config *config.Config, log log.Logger, storeController
 
 		generator := &taskGenerator{
 			imgStore: storeController.DefaultStore,
+			metrics:  metrics,
 			log:      log,
 		}
 		sch.SubmitGenerator(generator, config.Extensions.Scrub.Interval, scheduler.LowPriority)
@@ -40,6 +46,7 @@ func EnableScrubExtension(config *config.Config, log log.Logger, storeController
 			for route := range config.Storage.SubPaths {
 				generator := &taskGenerator{
 					imgStore: storeController.SubStore[route],
+					metrics:  metrics,
 					log:      log,
 				}
 				sch.SubmitGenerator(generator, config.Extensions.Scrub.Interval, scheduler.LowPriority)
@@ -52,6 +59,7 @@ func EnableScrubExtension(config *config.Config, log log.Logger, storeController
 
 type taskGenerator struct {
 	imgStore storageTypes.ImageStore
+	metrics  monitoring.MetricServer
 	log      log.Logger
 	lastRepo string
 	done     bool
@@ -72,7 +80,7 @@ func (gen *taskGenerator) Next() (scheduler.Task, error) {
 
 	gen.lastRepo = repo
 
-	return scrub.NewTask(gen.imgStore, repo, gen.log), nil
+	return scrub.NewTask(gen.imgStore, repo, gen.metrics, gen.log), nil
 }
 
 func (gen *taskGenerator) IsDone() bool {
@@ -5,14 +5,19 @@ package extensions
 
 import (
 	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/scheduler"
 	"zotregistry.io/zot/pkg/storage"
 )
 
+func IsBuiltWithScrubExtension() bool {
+	return false
+}
+
 // EnableScrubExtension ...
 func EnableScrubExtension(config *config.Config, log log.Logger, storeController storage.StoreController,
-	sch *scheduler.Scheduler,
+	metrics monitoring.MetricServer, sch *scheduler.Scheduler,
 ) {
 	log.Warn().Msg("skipping enabling scrub extension because given zot binary doesn't include this feature," +
 		"please build a binary that does so")
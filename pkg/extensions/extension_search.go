@@ -4,21 +4,31 @@
 package extensions
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"path"
+	"strconv"
 	"sync"
 	"time"
 
 	gqlHandler "github.com/99designs/gqlgen/graphql/handler"
 	"github.com/gorilla/mux"
 
+	zerr "zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/api/config"
 	"zotregistry.io/zot/pkg/api/constants"
 	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/extensions/plugins"
 	"zotregistry.io/zot/pkg/extensions/search"
 	cveinfo "zotregistry.io/zot/pkg/extensions/search/cve"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
 	"zotregistry.io/zot/pkg/extensions/search/gql_generated"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/repodb"
+	zreg "zotregistry.io/zot/pkg/regexp"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
 	"zotregistry.io/zot/pkg/scheduler"
 	"zotregistry.io/zot/pkg/storage"
 )
@@ -39,7 +49,7 @@ func IsBuiltWithSearchExtension() bool {
 }
 
 func GetCVEInfo(config *config.Config, storeController storage.StoreController,
-	repoDB repodb.RepoDB, log log.Logger,
+	repoDB repodb.RepoDB, metrics monitoring.MetricServer, log log.Logger,
 ) CveInfo {
 	if config.Extensions.Search == nil || !*config.Extensions.Search.Enable || config.Extensions.Search.CVE == nil {
 		return nil
@@ -47,31 +57,120 @@ func GetCVEInfo(config *config.Config, storeController storage.StoreController,
 
 	dbRepository := config.Extensions.Search.CVE.Trivy.DBRepository
 	javaDBRepository := config.Extensions.Search.CVE.Trivy.JavaDBRepository
+	javaDBPath := config.Extensions.Search.CVE.Trivy.JavaDBPath
 
-	return cveinfo.NewCVEInfo(storeController, repoDB, dbRepository, javaDBRepository, log)
+	maxConcurrentScans := config.Extensions.Search.CVE.MaxConcurrentScans
+
+	cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, dbRepository, javaDBRepository, javaDBPath,
+		maxConcurrentScans, metrics, log)
+
+	if config.CircuitBreaker != nil {
+		cveInfo.Scanner = cveinfo.NewBreakerScanner(cveInfo.Scanner, *config.CircuitBreaker, metrics, log)
+	}
+
+	return cveInfo
+}
+
+// NotifyImageScans sends a scanCompleted event to the notifier plugins each time the scan of an image completes,
+// with the number of vulnerabilities found and their max severity.
+func NotifyImageScans(cveInfo CveInfo, registry *plugins.Registry) {
+	if cveInfo == nil || registry == nil {
+		return
+	}
+
+	cveInfo.OnScanCompleted(func(repo, digest string, cveMap map[string]cvemodel.CVE) {
+		maxSeverity := "NONE"
+
+		for _, cve := range cveMap {
+			if cveInfo.CompareSeverities(maxSeverity, cve.Severity) > 0 {
+				maxSeverity = cve.Severity
+			}
+		}
+
+		registry.Notify(plugins.Event{
+			Type:   plugins.EventScanCompleted,
+			Repo:   repo,
+			Digest: digest,
+			Details: map[string]string{
+				plugins.EventDetailVulnerabilities: strconv.Itoa(len(cveMap)),
+				plugins.EventDetailMaxSeverity:     maxSeverity,
+			},
+		})
+	})
+}
+
+// CancelImageScans cancels the queued and running scans of a deleted image.
+func CancelImageScans(cveInfo CveInfo, repo, digest string) {
+	if cveInfo == nil {
+		return
+	}
+
+	cveInfo.CancelScans(repo, digest)
 }
 
 func EnableSearchExtension(config *config.Config, storeController storage.StoreController,
-	repoDB repodb.RepoDB, taskScheduler *scheduler.Scheduler, cveInfo CveInfo, log log.Logger,
+	repoDB repodb.RepoDB, taskScheduler *scheduler.Scheduler, cveInfo CveInfo, metrics monitoring.MetricServer,
+	log log.Logger,
 ) {
 	if config.Extensions.Search != nil && *config.Extensions.Search.Enable && config.Extensions.Search.CVE != nil {
 		updateInterval := config.Extensions.Search.CVE.UpdateInterval
 
-		downloadTrivyDB(updateInterval, taskScheduler, cveInfo, log)
+		downloadTrivyDB(updateInterval, taskScheduler, cveInfo, metrics, log)
+
+		trivyConfig := config.Extensions.Search.CVE.Trivy
+		if trivyConfig != nil && (trivyConfig.JavaDBRepository != "" || trivyConfig.JavaDBPath != "") {
+			javaDBUpdateInterval := trivyConfig.JavaDBUpdateInterval
+			if javaDBUpdateInterval == 0 {
+				javaDBUpdateInterval = updateInterval
+			}
+
+			downloadTrivyJavaDB(javaDBUpdateInterval, taskScheduler, cveInfo, metrics, log)
+		}
 	} else {
 		log.Info().Msg("CVE config not provided, skipping CVE update")
 	}
 }
 
-func downloadTrivyDB(interval time.Duration, sch *scheduler.Scheduler, cveInfo CveInfo, log log.Logger) {
-	generator := NewTrivyTaskGenerator(interval, cveInfo, log)
+func downloadTrivyDB(interval time.Duration, sch *scheduler.Scheduler, cveInfo CveInfo,
+	metrics monitoring.MetricServer, log log.Logger,
+) {
+	generator := NewTrivyTaskGenerator(interval, cveInfo, metrics, log)
 
 	log.Info().Msg("Submitting CVE DB update scheduler")
 	sch.SubmitGenerator(generator, interval, scheduler.HighPriority)
 }
 
-func NewTrivyTaskGenerator(interval time.Duration, cveInfo CveInfo, log log.Logger) *TrivyTaskGenerator {
-	generator := &TrivyTaskGenerator{interval, cveInfo, log, pending, 0, time.Now(), &sync.Mutex{}}
+func downloadTrivyJavaDB(interval time.Duration, sch *scheduler.Scheduler, cveInfo CveInfo,
+	metrics monitoring.MetricServer, log log.Logger,
+) {
+	generator := NewTrivyJavaDBTaskGenerator(interval, cveInfo, metrics, log)
+
+	log.Info().Msg("Submitting Java DB update scheduler")
+	sch.SubmitGenerator(generator, interval, scheduler.HighPriority)
+}
+
+// NewTrivyTaskGenerator returns a generator of tasks updating the Trivy DB, the DB is unhealthy once it
+// couldn't be updated for a few intervals.
+func NewTrivyTaskGenerator(interval time.Duration, cveInfo CveInfo, metrics monitoring.MetricServer,
+	log log.Logger,
+) *TrivyTaskGenerator {
+	generator := &TrivyTaskGenerator{interval, cveInfo, metrics, log, pending, 0, time.Now(), &sync.Mutex{}, false}
+
+	monitoring.RegisterSubsystem(metrics, monitoring.SubsystemCVEDB, generator.getDBName(),
+		monitoring.MaxAgeIntervals*interval)
+
+	return generator
+}
+
+// NewTrivyJavaDBTaskGenerator returns a generator of tasks updating the Java DB, it is scheduled
+// independently of the generator updating the Trivy DB.
+func NewTrivyJavaDBTaskGenerator(interval time.Duration, cveInfo CveInfo, metrics monitoring.MetricServer,
+	log log.Logger,
+) *TrivyTaskGenerator {
+	generator := &TrivyTaskGenerator{interval, cveInfo, metrics, log, pending, 0, time.Now(), &sync.Mutex{}, true}
+
+	monitoring.RegisterSubsystem(metrics, monitoring.SubsystemCVEDB, generator.getDBName(),
+		monitoring.MaxAgeIntervals*interval)
 
 	return generator
 }
@@ -79,11 +178,13 @@ func NewTrivyTaskGenerator(interval time.Duration, cveInfo CveInfo, log log.Logg
 type TrivyTaskGenerator struct {
 	interval     time.Duration
 	cveInfo      CveInfo
+	metrics      monitoring.MetricServer
 	log          log.Logger
 	status       state
 	waitTime     time.Duration
 	lastTaskTime time.Time
 	lock         *sync.Mutex
+	javaDB       bool
 }
 
 func (gen *TrivyTaskGenerator) Next() (scheduler.Task, error) {
@@ -100,6 +201,15 @@ func (gen *TrivyTaskGenerator) Next() (scheduler.Task, error) {
 	return newTask, nil
 }
 
+// getDBName returns the name of the DB the generator updates, in the health metrics.
+func (gen *TrivyTaskGenerator) getDBName() string {
+	if gen.javaDB {
+		return "javaDB"
+	}
+
+	return "trivyDB"
+}
+
 func (gen *TrivyTaskGenerator) IsDone() bool {
 	gen.lock.Lock()
 	status := gen.status
@@ -129,9 +239,13 @@ func newTrivyTask(interval time.Duration, cveInfo cveinfo.CveInfo,
 }
 
 func (trivyT *trivyTask) DoWork() error {
-	trivyT.log.Info().Msg("updating the CVE database")
+	if trivyT.generator.javaDB {
+		trivyT.log.Info().Msg("updating the Java database")
+	} else {
+		trivyT.log.Info().Msg("updating the CVE database")
+	}
 
-	err := trivyT.cveInfo.UpdateDB()
+	err := trivyT.update()
 	if err != nil {
 		trivyT.generator.lock.Lock()
 		trivyT.generator.status = pending
@@ -151,13 +265,47 @@ func (trivyT *trivyTask) DoWork() error {
 	trivyT.generator.lastTaskTime = time.Now()
 	trivyT.generator.status = done
 	trivyT.generator.lock.Unlock()
-	trivyT.log.Info().Str("DB update completed, next update scheduled after", trivyT.interval.String()).Msg("")
+
+	monitoring.RecordSubsystemSuccess(trivyT.generator.metrics, monitoring.SubsystemCVEDB, trivyT.generator.getDBName())
+
+	if trivyT.generator.javaDB {
+		trivyT.log.Info().Str("Java DB updated, next update scheduled after", trivyT.interval.String()).Msg("")
+	} else {
+		trivyT.log.Info().Str("DB update completed, next update scheduled after", trivyT.interval.String()).Msg("")
+	}
 
 	return nil
 }
 
+func (trivyT *trivyTask) update() error {
+	if trivyT.generator.javaDB {
+		return trivyT.cveInfo.UpdateJavaDB()
+	}
+
+	return trivyT.cveInfo.UpdateDB()
+}
+
+// getBlobScope returns the storage scope of the blobs of a repo: the image store holding the repo if it dedupes
+// blobs, else the repo itself.
+func getBlobScope(config *config.Config, storeController storage.StoreController) func(repo string) string {
+	return func(repo string) string {
+		dedupe := config.Storage.Dedupe
+
+		if subPath, ok := config.Storage.SubPaths[storage.GetRoutePrefix(repo)]; ok {
+			dedupe = subPath.Dedupe
+		}
+
+		rootDir := storeController.GetImageStore(repo).RootDir()
+		if dedupe {
+			return rootDir
+		}
+
+		return path.Join(rootDir, repo)
+	}
+}
+
 func SetupSearchRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
-	repoDB repodb.RepoDB, cveInfo CveInfo, log log.Logger,
+	repoDB repodb.RepoDB, indexing *repodb.IndexingStatus, cveInfo CveInfo, log log.Logger,
 ) {
 	log.Info().Msg("setting up search routes")
 
@@ -169,7 +317,123 @@ func SetupSearchRoutes(config *config.Config, router *mux.Router, storeControlle
 		extRouter := router.PathPrefix(constants.ExtSearch).Subrouter()
 		extRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
 		extRouter.Use(zcommon.AddExtensionSecurityHeaders())
-		extRouter.Methods(allowedMethods...).
-			Handler(gqlHandler.NewDefaultServer(gql_generated.NewExecutableSchema(resConfig)))
+		gqlServer := gqlHandler.NewDefaultServer(gql_generated.NewExecutableSchema(resConfig))
+		search.ApplyQueryLimits(gqlServer, config.Extensions.Search.Limits, log)
+
+		if indexing != nil {
+			gqlServer.Use(search.IndexingProgress{Status: indexing})
+		}
+
+		gqlServer.Use(search.BlobUsage{RepoDB: repoDB, Scope: getBlobScope(config, storeController), Log: log})
+
+		extRouter.Methods(allowedMethods...).Handler(gqlServer)
+
+		digestsAllowedMethods := zcommon.AllowedMethods(http.MethodGet)
+		minLength := search.GetMinDigestPrefixLength(config.Extensions.Search.MinDigestPrefixLength)
+
+		digestsRouter := router.PathPrefix(constants.ExtDigests).Subrouter()
+		digestsRouter.Use(zcommon.ACHeadersHandler(digestsAllowedMethods...))
+		digestsRouter.Use(zcommon.AddExtensionSecurityHeaders())
+		digestsRouter.HandleFunc(fmt.Sprintf("/{name:%s}/{prefix}", zreg.NameRegexp.String()),
+			HandleDigestPrefix(storeController, minLength, log)).Methods(digestsAllowedMethods...)
+
+		if cveInfo != nil {
+			scansAllowedMethods := zcommon.AllowedMethods(http.MethodGet)
+
+			scansRouter := router.PathPrefix(constants.ExtCVEScans).Subrouter()
+			scansRouter.Use(zcommon.ACHeadersHandler(scansAllowedMethods...))
+			scansRouter.Use(zcommon.AddExtensionSecurityHeaders())
+			scansRouter.Methods(scansAllowedMethods...).HandlerFunc(HandleCVEScanQueue(cveInfo, log))
+		}
+	}
+}
+
+type CVEScanQueue struct {
+	Scans []cvemodel.ScanStatus `json:"scans"`
+	// state of the vulnerability databases the images are scanned with
+	Databases []cvemodel.DBStatus `json:"databases"`
+}
+
+// HandleCVEScanQueue godoc
+// @Summary List the CVE scans
+// @Description Get the CVE scans waiting in the scan queue or running, of the repos the user can read,
+// @Description and the state of the vulnerability databases
+// @Router 	/v2/_zot/ext/cve/scans [get]
+// @Produce json
+// @Success 200 {object} 	extensions.CVEScanQueue
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleCVEScanQueue(cveInfo CveInfo, log log.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		scanQueue := CVEScanQueue{Scans: []cvemodel.ScanStatus{}, Databases: cveInfo.GetDBStatus()}
+
+		for _, scan := range cveInfo.GetScanQueue() {
+			available, err := localCtx.RepoIsUserAvailable(req.Context(), scan.Repo)
+			if err != nil {
+				log.Error().Err(err).Str("repository", scan.Repo).Msg("failed to check repo access")
+				rsp.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			if available {
+				scanQueue.Scans = append(scanQueue.Scans, scan)
+			}
+		}
+
+		zcommon.WriteJSON(rsp, http.StatusOK, scanQueue)
+	}
+}
+
+// HandleDigestPrefix godoc
+// @Summary Resolve an abbreviated digest
+// @Description Get the full digest of the manifest whose digest starts with the given prefix,
+// @Description the algorithm can be left out, e.g. "sha256:ab12ef34" or "ab12ef34"
+// @Router 	/v2/_zot/ext/digests/{name}/{prefix} [get]
+// @Produce json
+// @Param   name     path    string     true        "repository name"
+// @Param   prefix   path    string     true        "abbreviated digest"
+// @Success 200 {object} 	search.DigestResolution
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 409 {object} 	search.DigestResolution "prefix matches more than one manifest"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleDigestPrefix(storeController storage.StoreController, minLength int, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repo, prefix := vars["name"], vars["prefix"]
+
+		available, err := localCtx.RepoIsUserAvailable(req.Context(), repo)
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !available {
+			rsp.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		resolution, err := search.ResolveDigestPrefix(storeController.GetImageStore(repo), repo, prefix, minLength)
+		if err != nil {
+			switch {
+			case errors.Is(err, zerr.ErrAmbiguousDigestPrefix):
+				zcommon.WriteJSON(rsp, http.StatusConflict, resolution)
+			case errors.Is(err, zerr.ErrBadDigestPrefix), errors.Is(err, zerr.ErrDigestPrefixTooShort):
+				zcommon.WriteJSON(rsp, http.StatusBadRequest, err.Error())
+			case errors.Is(err, zerr.ErrRepoNotFound), errors.Is(err, zerr.ErrManifestNotFound):
+				rsp.WriteHeader(http.StatusNotFound)
+			default:
+				log.Error().Err(err).Str("repository", repo).Str("prefix", prefix).Msg("failed to resolve digest prefix")
+				rsp.WriteHeader(http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		zcommon.WriteJSON(rsp, http.StatusOK, resolution)
 	}
 }
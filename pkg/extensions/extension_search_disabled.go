@@ -7,6 +7,8 @@ import (
 	"github.com/gorilla/mux"
 
 	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/extensions/plugins"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/repodb"
 	"zotregistry.io/zot/pkg/scheduler"
@@ -16,18 +18,27 @@ import (
 type CveInfo interface{}
 
 func GetCVEInfo(config *config.Config, storeController storage.StoreController,
-	repoDB repodb.RepoDB, log log.Logger,
+	repoDB repodb.RepoDB, metrics monitoring.MetricServer, log log.Logger,
 ) CveInfo {
 	return nil
 }
 
+// NotifyImageScans ...
+func NotifyImageScans(cveInfo CveInfo, registry *plugins.Registry) {
+}
+
+// CancelImageScans ...
+func CancelImageScans(cveInfo CveInfo, repo, digest string) {
+}
+
 func IsBuiltWithSearchExtension() bool {
 	return false
 }
 
 // EnableSearchExtension ...
 func EnableSearchExtension(config *config.Config, storeController storage.StoreController,
-	repoDB repodb.RepoDB, scheduler *scheduler.Scheduler, cveInfo CveInfo, log log.Logger,
+	repoDB repodb.RepoDB, scheduler *scheduler.Scheduler, cveInfo CveInfo, metrics monitoring.MetricServer,
+	log log.Logger,
 ) {
 	log.Warn().Msg("skipping enabling search extension because given zot binary doesn't include this feature," +
 		"please build a binary that does so")
@@ -35,7 +46,7 @@ func EnableSearchExtension(config *config.Config, storeController storage.StoreC
 
 // SetupSearchRoutes ...
 func SetupSearchRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
-	repoDB repodb.RepoDB, cveInfo CveInfo, log log.Logger,
+	repoDB repodb.RepoDB, indexing *repodb.IndexingStatus, cveInfo CveInfo, log log.Logger,
 ) {
 	log.Warn().Msg("skipping setting up search routes because given zot binary doesn't include this feature," +
 		"please build a binary that does so")
@@ -5,7 +5,10 @@ package extensions_test
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -14,10 +17,14 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 
 	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
 	. "zotregistry.io/zot/pkg/extensions"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	cveinfo "zotregistry.io/zot/pkg/extensions/search/cve"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/repodb"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
 	"zotregistry.io/zot/pkg/scheduler"
 	"zotregistry.io/zot/pkg/storage"
 	. "zotregistry.io/zot/pkg/test"
@@ -57,8 +64,8 @@ func TestTrivyDBGenerator(t *testing.T) {
 			},
 		}
 
-		cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", logger)
-		generator := NewTrivyTaskGenerator(time.Minute, cveInfo, logger)
+		cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", "", 1, nil, logger)
+		generator := NewTrivyTaskGenerator(time.Minute, cveInfo, monitoring.NewMetricsServer(false, logger), logger)
 
 		sch.SubmitGenerator(generator, 12000*time.Millisecond, scheduler.HighPriority)
 
@@ -75,3 +82,57 @@ func TestTrivyDBGenerator(t *testing.T) {
 		So(found, ShouldBeTrue)
 	})
 }
+
+func TestCVEScanQueueRoute(t *testing.T) {
+	Convey("List the scans of the repos the user can read", t, func() {
+		cveInfo := mocks.CveInfoMock{
+			GetScanQueueFn: func() []cvemodel.ScanStatus {
+				return []cvemodel.ScanStatus{
+					{Repo: "allowed", Digest: "sha256:1", State: cvemodel.ScanStateRunning},
+					{Repo: "denied", Digest: "sha256:2", State: cvemodel.ScanStateQueued},
+				}
+			},
+			GetDBStatusFn: func() []cvemodel.DBStatus {
+				return []cvemodel.DBStatus{
+					{Name: cvemodel.DBNameTrivy, Source: "ghcr.io/aquasecurity/trivy-db"},
+					{Name: cvemodel.DBNameTrivyJavaDB, Source: "/var/lib/trivy-java-db", Offline: true},
+				}
+			},
+		}
+
+		handler := HandleCVEScanQueue(cveInfo, log.NewLogger("debug", ""))
+
+		request := httptest.NewRequest(http.MethodGet, constants.FullCVEScansPrefix, nil)
+		response := httptest.NewRecorder()
+		handler(response, request)
+		So(response.Code, ShouldEqual, http.StatusOK)
+
+		var scanQueue CVEScanQueue
+
+		err := json.Unmarshal(response.Body.Bytes(), &scanQueue)
+		So(err, ShouldBeNil)
+		So(len(scanQueue.Scans), ShouldEqual, 2)
+		So(len(scanQueue.Databases), ShouldEqual, 2)
+		So(scanQueue.Databases[1].Name, ShouldEqual, cvemodel.DBNameTrivyJavaDB)
+		So(scanQueue.Databases[1].Offline, ShouldBeTrue)
+
+		acCtx := localCtx.AccessControlContext{ReadGlobPatterns: map[string]bool{"allowed": true}}
+		ctx := context.WithValue(context.Background(), localCtx.GetContextKey(), acCtx)
+
+		response = httptest.NewRecorder()
+		handler(response, request.WithContext(ctx))
+		So(response.Code, ShouldEqual, http.StatusOK)
+
+		scanQueue = CVEScanQueue{}
+		err = json.Unmarshal(response.Body.Bytes(), &scanQueue)
+		So(err, ShouldBeNil)
+		So(len(scanQueue.Scans), ShouldEqual, 1)
+		So(scanQueue.Scans[0].Repo, ShouldEqual, "allowed")
+
+		ctx = context.WithValue(context.Background(), localCtx.GetContextKey(), "bad context")
+
+		response = httptest.NewRecorder()
+		handler(response, request.WithContext(ctx))
+		So(response.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+}
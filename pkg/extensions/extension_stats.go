@@ -0,0 +1,198 @@
+//go:build search
+// +build search
+
+package extensions
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/search/convert"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+)
+
+const (
+	defaultStatsDays     = 30
+	defaultStatsTopRepos = 10
+)
+
+// RegistryStats aggregates the content and the activity of the repos the user can read.
+type RegistryStats struct {
+	Repos  int `json:"repos"`
+	Images int `json:"images"`
+	// blobs of the tagged images, each counted once
+	Blobs int   `json:"blobs"`
+	Bytes int64 `json:"bytes"`
+	// one entry for each day of the requested period, oldest first
+	Activity []DailyActivity `json:"activity"`
+	// the repos pulled the most during the requested period
+	TopRepos []RepoActivity `json:"topRepos"`
+}
+
+type DailyActivity struct {
+	Date   string `json:"date"`
+	Pushes int    `json:"pushes"`
+	Pulls  int    `json:"pulls"`
+}
+
+type RepoActivity struct {
+	Name   string `json:"name"`
+	Pushes int    `json:"pushes"`
+	Pulls  int    `json:"pulls"`
+}
+
+func SetupStatsRoutes(config *config.Config, router *mux.Router, repoDB repodb.RepoDB, log log.Logger) {
+	if config.Extensions.Search != nil && *config.Extensions.Search.Enable && repoDB != nil {
+		log.Info().Msg("setting up registry stats routes")
+
+		allowedMethods := zcommon.AllowedMethods(http.MethodGet)
+
+		statsRouter := router.PathPrefix(constants.ExtStats).Subrouter()
+		statsRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+		statsRouter.Use(zcommon.AddExtensionSecurityHeaders())
+		statsRouter.HandleFunc("", HandleGetRegistryStats(repoDB, log)).Methods(allowedMethods...)
+	}
+}
+
+// HandleGetRegistryStats godoc
+// @Summary Get registry statistics
+// @Description Get the number of repos, images and blobs, the size of the blobs, the pushes and pulls of each day
+// @Description and the most pulled repos, counting only the repos the user can read
+// @Router 	/v2/_zot/ext/stats [get]
+// @Produce json
+// @Param   days     query   integer    false       "number of days of activity, 30 by default, 90 at most"
+// @Param   top      query   integer    false       "number of top repos, 10 by default"
+// @Success 200 {object} 	extensions.RegistryStats
+// @Failure 400 {string} 	string 				"bad request"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleGetRegistryStats(repoDB repodb.RepoDB, log log.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		days, ok := getPositiveQueryParam(req, "days", defaultStatsDays)
+		if !ok || days > repodb.MaxDailyStatisticsDays {
+			rsp.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		top, ok := getPositiveQueryParam(req, "top", defaultStatsTopRepos)
+		if !ok {
+			rsp.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		repos, manifestMetaMap, indexDataMap, _, err := repoDB.FilterRepos(req.Context(),
+			func(repoMeta repodb.RepoMetadata) bool { return true }, repodb.PageInput{})
+		if err != nil {
+			log.Error().Err(err).Msg("failed to get the repos to compute registry stats")
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		zcommon.WriteJSON(rsp, http.StatusOK,
+			GetRegistryStats(repos, manifestMetaMap, indexDataMap, time.Now(), days, top))
+	}
+}
+
+// GetRegistryStats aggregates the stats of repos, the activity is reported for the days days up to now.
+func GetRegistryStats(repos []repodb.RepoMetadata, manifestMetaMap map[string]repodb.ManifestMetadata,
+	indexDataMap map[string]repodb.IndexData, now time.Time, days, top int,
+) RegistryStats {
+	stats := RegistryStats{
+		Repos:    len(repos),
+		Activity: make([]DailyActivity, days),
+		TopRepos: []RepoActivity{},
+	}
+
+	dayIndexes := map[string]int{}
+
+	for i := range stats.Activity {
+		date := now.UTC().AddDate(0, 0, i-days+1).Format(repodb.DailyStatisticsDateFormat)
+		stats.Activity[i].Date = date
+		dayIndexes[date] = i
+	}
+
+	blobs := map[string]int64{}
+
+	for _, repoMeta := range repos {
+		// an image tagged more than once is counted once
+		images := map[string]repodb.Descriptor{}
+
+		for _, descriptor := range repoMeta.Tags {
+			images[descriptor.Digest] = descriptor
+		}
+
+		stats.Images += len(images)
+
+		for _, descriptor := range images {
+			for blobDigest, blobSize := range convert.GetImageBlobs(descriptor, manifestMetaMap, indexDataMap) {
+				blobs[blobDigest] = blobSize
+			}
+		}
+
+		repoActivity := RepoActivity{Name: repoMeta.Name}
+
+		for date, statistics := range repoMeta.DailyStatistics {
+			i, ok := dayIndexes[date]
+			if !ok {
+				continue
+			}
+
+			stats.Activity[i].Pushes += statistics.Pushes
+			stats.Activity[i].Pulls += statistics.Pulls
+			repoActivity.Pushes += statistics.Pushes
+			repoActivity.Pulls += statistics.Pulls
+		}
+
+		if repoActivity.Pulls > 0 || repoActivity.Pushes > 0 {
+			stats.TopRepos = append(stats.TopRepos, repoActivity)
+		}
+	}
+
+	stats.Blobs = len(blobs)
+
+	for _, blobSize := range blobs {
+		stats.Bytes += blobSize
+	}
+
+	sort.Slice(stats.TopRepos, func(i, j int) bool {
+		if stats.TopRepos[i].Pulls != stats.TopRepos[j].Pulls {
+			return stats.TopRepos[i].Pulls > stats.TopRepos[j].Pulls
+		}
+
+		if stats.TopRepos[i].Pushes != stats.TopRepos[j].Pushes {
+			return stats.TopRepos[i].Pushes > stats.TopRepos[j].Pushes
+		}
+
+		return stats.TopRepos[i].Name < stats.TopRepos[j].Name
+	})
+
+	if len(stats.TopRepos) > top {
+		stats.TopRepos = stats.TopRepos[:top]
+	}
+
+	return stats
+}
+
+func getPositiveQueryParam(req *http.Request, name string, defaultValue int) (int, bool) {
+	valueStr := req.URL.Query().Get(name)
+	if valueStr == "" {
+		return defaultValue, true
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+
+	return value, true
+}
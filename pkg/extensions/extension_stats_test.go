@@ -0,0 +1,135 @@
+//go:build search
+// +build search
+
+package extensions_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
+
+	"zotregistry.io/zot/pkg/api"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/extensions"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/test"
+)
+
+func TestRegistryStatsExtension(t *testing.T) {
+	Convey("Get registry stats", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+		}
+
+		ctlr := api.NewController(conf)
+		ctrlManager := test.NewControllerManager(ctlr)
+
+		ctrlManager.StartAndWait(port)
+
+		defer ctrlManager.StopServer()
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(image, baseURL, "test/repo")
+		So(err, ShouldBeNil)
+
+		// the same image under another tag
+		image.Reference = "latest"
+
+		err = test.UploadImage(image, baseURL, "test/repo")
+		So(err, ShouldBeNil)
+
+		image, err = test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(image, baseURL, "test/other")
+		So(err, ShouldBeNil)
+
+		for i := 0; i < 2; i++ {
+			resp, err := resty.R().SetHeader("Accept", ispec.MediaTypeImageManifest).
+				Get(baseURL + "/v2/test/other/manifests/1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		}
+
+		resp, err := resty.R().SetQueryParam("days", "7").Get(baseURL + constants.FullStatsPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var stats extensions.RegistryStats
+
+		err = json.Unmarshal(resp.Body(), &stats)
+		So(err, ShouldBeNil)
+		So(stats.Repos, ShouldEqual, 2)
+		So(stats.Images, ShouldEqual, 2)
+		So(stats.Blobs, ShouldBeGreaterThan, 0)
+		So(stats.Bytes, ShouldBeGreaterThan, 0)
+		So(len(stats.Activity), ShouldEqual, 7)
+		So(stats.Activity[6].Date, ShouldEqual, time.Now().UTC().Format(repodb.DailyStatisticsDateFormat))
+		So(stats.Activity[6].Pushes, ShouldEqual, 3)
+		So(stats.Activity[6].Pulls, ShouldEqual, 2)
+		So(stats.TopRepos, ShouldResemble, []extensions.RepoActivity{
+			{Name: "test/other", Pushes: 1, Pulls: 2},
+			{Name: "test/repo", Pushes: 2},
+		})
+
+		resp, err = resty.R().SetQueryParam("top", "1").Get(baseURL + constants.FullStatsPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		err = json.Unmarshal(resp.Body(), &stats)
+		So(err, ShouldBeNil)
+		So(len(stats.Activity), ShouldEqual, 30)
+		So(len(stats.TopRepos), ShouldEqual, 1)
+
+		for _, query := range []map[string]string{{"days": "0"}, {"days": "91"}, {"top": "many"}} {
+			resp, err = resty.R().SetQueryParams(query).Get(baseURL + constants.FullStatsPrefix)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		}
+	})
+
+	Convey("Aggregate repo activity out of the requested period", t, func() {
+		now := time.Date(2023, time.July, 12, 10, 0, 0, 0, time.UTC)
+
+		repos := []repodb.RepoMetadata{
+			{
+				Name: "old",
+				DailyStatistics: map[string]repodb.DailyStatistics{
+					"2023-07-01": {Pushes: 5, Pulls: 5},
+				},
+			},
+			{
+				Name: "new",
+				DailyStatistics: map[string]repodb.DailyStatistics{
+					"2023-07-01": {Pulls: 3},
+					"2023-07-11": {Pulls: 1},
+					"2023-07-12": {Pushes: 1},
+				},
+			},
+		}
+
+		stats := extensions.GetRegistryStats(repos, nil, nil, now, 2, 10)
+		So(stats.Repos, ShouldEqual, 2)
+		So(stats.Images, ShouldEqual, 0)
+		So(stats.Activity, ShouldResemble, []extensions.DailyActivity{
+			{Date: "2023-07-11", Pulls: 1},
+			{Date: "2023-07-12", Pushes: 1},
+		})
+		So(stats.TopRepos, ShouldResemble, []extensions.RepoActivity{{Name: "new", Pushes: 1, Pulls: 1}})
+	})
+}
@@ -4,19 +4,34 @@
 package extensions
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	zerr "zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/extensions/sync"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/repodb"
+	zreg "zotregistry.io/zot/pkg/regexp"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
 	"zotregistry.io/zot/pkg/scheduler"
 	"zotregistry.io/zot/pkg/storage"
 )
 
 func EnableSyncExtension(config *config.Config, repoDB repodb.RepoDB,
-	storeController storage.StoreController, sch *scheduler.Scheduler, log log.Logger,
+	storeController storage.StoreController, sch *scheduler.Scheduler, conflicts *sync.ConflictStore,
+	metrics monitoring.MetricServer, log log.Logger,
 ) (*sync.BaseOnDemand, error) {
 	if config.Extensions.Sync != nil && *config.Extensions.Sync.Enable {
 		onDemand := sync.NewOnDemand(log)
+		downloads := sync.NewDownloadLimiter(config.Extensions.Sync.MaxConcurrentDownloads)
+		prefetcher := sync.NewPrefetcher(config.Extensions.Sync.Registries, storeController, repoDB, log)
 
 		for _, registryConfig := range config.Extensions.Sync.Registries {
 			isPeriodical := len(registryConfig.Content) != 0 && registryConfig.PollInterval != 0
@@ -24,7 +39,7 @@ func EnableSyncExtension(config *config.Config, repoDB repodb.RepoDB,
 
 			if isPeriodical || isOnDemand {
 				service, err := sync.New(registryConfig, config.Extensions.Sync.CredentialsFile,
-					storeController, repoDB, log)
+					storeController, repoDB, conflicts, downloads, prefetcher, metrics, log)
 				if err != nil {
 					return nil, err
 				}
@@ -37,7 +52,7 @@ func EnableSyncExtension(config *config.Config, repoDB repodb.RepoDB,
 
 				if isOnDemand {
 					// onDemand services used in routes.go
-					onDemand.Add(service)
+					onDemand.Add(service, sync.NewUpstreamBreaker(registryConfig, config.CircuitBreaker, metrics, log))
 				}
 			}
 		}
@@ -49,3 +64,102 @@ func EnableSyncExtension(config *config.Config, repoDB repodb.RepoDB,
 
 	return nil, nil //nolint: nilnil
 }
+
+func IsBuiltWithSyncExtension() bool {
+	return true
+}
+
+func SetupPeeringRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
+	conflicts *sync.ConflictStore, log log.Logger,
+) {
+	if isPeeringEnabled(config) {
+		log.Info().Msg("setting up sync peering routes")
+
+		allowedMethods := zcommon.AllowedMethods(http.MethodGet)
+
+		peeringRouter := router.PathPrefix(constants.ExtPeering).Subrouter()
+		peeringRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+		peeringRouter.Use(zcommon.AddExtensionSecurityHeaders())
+		peeringRouter.HandleFunc(fmt.Sprintf("/repos/{name:%s}", zreg.NameRegexp.String()),
+			HandlePeeringRepoState(storeController, log)).Methods(allowedMethods...)
+		peeringRouter.HandleFunc("/conflicts", HandlePeeringConflicts(conflicts, log)).Methods(allowedMethods...)
+	}
+}
+
+// HandlePeeringRepoState godoc
+// @Summary Get the state of a repo
+// @Description Get the number of manifests, the tags and a digest summing up the content of a repo,
+// @Description peered registries compare it with their own before syncing the repo
+// @Router 	/v2/_zot/ext/peering/repos/{name} [get]
+// @Produce json
+// @Param   name     path    string     true        "repository name"
+// @Success 200 {object} 	sync.RepoState
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 404 {string} 	string 				"not found"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandlePeeringRepoState(storeController storage.StoreController, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		repo := mux.Vars(req)["name"]
+
+		available, err := localCtx.RepoIsUserAvailable(req.Context(), repo)
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if !available {
+			rsp.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		state, err := sync.GetRepoState(storeController.GetImageStore(repo), repo)
+		if err != nil {
+			if errors.Is(err, zerr.ErrRepoNotFound) {
+				rsp.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			log.Error().Err(err).Str("repository", repo).Msg("failed to get repo state")
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		zcommon.WriteJSON(rsp, http.StatusOK, state)
+	}
+}
+
+// HandlePeeringConflicts godoc
+// @Summary Get the tags conflicting with peered registries
+// @Description Get the tags which point to different digests on the local registry and on a peer,
+// @Description such tags are not synced until the conflict is resolved
+// @Router 	/v2/_zot/ext/peering/conflicts [get]
+// @Produce json
+// @Success 200 {object} 	sync.ConflictList
+// @Failure 500 {string} 	string 				"internal server error".
+func HandlePeeringConflicts(conflicts *sync.ConflictStore, log log.Logger,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		conflictList := sync.ConflictList{Conflicts: []sync.TagConflict{}}
+
+		for _, conflict := range conflicts.List() {
+			available, err := localCtx.RepoIsUserAvailable(req.Context(), conflict.Repo)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to check repo access")
+				rsp.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			if available {
+				conflictList.Conflicts = append(conflictList.Conflicts, conflict)
+			}
+		}
+
+		zcommon.WriteJSON(rsp, http.StatusOK, conflictList)
+	}
+}
@@ -4,7 +4,10 @@
 package extensions
 
 import (
+	"github.com/gorilla/mux"
+
 	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/extensions/sync"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/repodb"
@@ -12,12 +15,23 @@ import (
 	"zotregistry.io/zot/pkg/storage"
 )
 
+func IsBuiltWithSyncExtension() bool {
+	return false
+}
+
 // EnableSyncExtension ...
 func EnableSyncExtension(config *config.Config, repoDB repodb.RepoDB,
-	storeController storage.StoreController, sch *scheduler.Scheduler, log log.Logger,
+	storeController storage.StoreController, sch *scheduler.Scheduler, conflicts *sync.ConflictStore,
+	metrics monitoring.MetricServer, log log.Logger,
 ) (*sync.BaseOnDemand, error) {
 	log.Warn().Msg("skipping enabling sync extension because given zot binary doesn't include this feature," +
 		"please build a binary that does so")
 
 	return nil, nil //nolint: nilnil
 }
+
+// SetupPeeringRoutes ...
+func SetupPeeringRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
+	conflicts *sync.ConflictStore, log log.Logger,
+) {
+}
@@ -0,0 +1,133 @@
+//go:build telemetry
+// +build telemetry
+
+package extensions
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/telemetry"
+	"zotregistry.io/zot/pkg/log"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage"
+)
+
+func IsBuiltWithTelemetryExtension() bool {
+	return true
+}
+
+// EnableTelemetryExtension periodically posts anonymous usage statistics to the configured endpoint.
+func EnableTelemetryExtension(config *config.Config, storeController storage.StoreController,
+	taskScheduler *scheduler.Scheduler, log log.Logger,
+) {
+	if !isTelemetryEnabled(config) {
+		log.Info().Msg("telemetry not enabled, skipping reporting usage statistics")
+
+		return
+	}
+
+	reporter := telemetry.NewReporter(config, storeController, log)
+	interval := config.Extensions.Telemetry.Interval
+
+	log.Info().Str("endpoint", config.Extensions.Telemetry.Endpoint).Str("interval", interval.String()).
+		Msg("submitting telemetry scheduler")
+	taskScheduler.SubmitGenerator(&telemetryTaskGenerator{reporter: reporter, log: log}, interval,
+		scheduler.LowPriority)
+}
+
+type telemetryTaskGenerator struct {
+	reporter *telemetry.Reporter
+	done     bool
+	log      log.Logger
+}
+
+func (gen *telemetryTaskGenerator) Next() (scheduler.Task, error) {
+	if gen.done {
+		return nil, nil
+	}
+
+	gen.done = true
+
+	return &telemetryTask{reporter: gen.reporter, log: gen.log}, nil
+}
+
+func (gen *telemetryTaskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *telemetryTaskGenerator) Reset() {
+	gen.done = false
+}
+
+type telemetryTask struct {
+	reporter *telemetry.Reporter
+	log      log.Logger
+}
+
+func (telemetryT *telemetryTask) DoWork() error {
+	if err := telemetryT.reporter.Send(context.Background()); err != nil {
+		// reporting usage statistics is best effort, it is retried on the next run
+		telemetryT.log.Warn().Err(err).Msg("telemetry: unable to report usage statistics")
+
+		return nil
+	}
+
+	telemetryT.log.Debug().Msg("telemetry: usage statistics reported")
+
+	return nil
+}
+
+func SetupTelemetryRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
+	log log.Logger,
+) {
+	if !isTelemetryEnabled(config) {
+		return
+	}
+
+	log.Info().Msg("setting up telemetry routes")
+
+	reporter := telemetry.NewReporter(config, storeController, log)
+
+	allowedMethods := zcommon.AllowedMethods(http.MethodGet)
+
+	telemetryRouter := router.PathPrefix(constants.ExtTelemetry).Subrouter()
+	telemetryRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+	telemetryRouter.Use(zcommon.AddExtensionSecurityHeaders())
+	telemetryRouter.Methods(allowedMethods...).HandlerFunc(HandleTelemetry(config, reporter))
+}
+
+// HandleTelemetry godoc
+// @Summary Get the usage statistics reported by telemetry
+// @Description Get the anonymous usage statistics which are periodically posted to the telemetry endpoint,
+// @Description so admins can check exactly what is reported. When access control is enabled only admins can get them.
+// @Router 	/v2/_zot/ext/telemetry [get]
+// @Produce json
+// @Success 200 {object} 	telemetry.Report
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleTelemetry(config *config.Config, reporter *telemetry.Reporter,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		acCtx, err := localCtx.GetAccessControlContext(req.Context())
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if config.HTTP.AccessControl != nil && (acCtx == nil || !acCtx.IsAdmin) {
+			rsp.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		zcommon.WriteJSON(rsp, http.StatusOK, reporter.Generate())
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !telemetry
+// +build !telemetry
+
+package extensions
+
+import (
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage"
+)
+
+func IsBuiltWithTelemetryExtension() bool {
+	return false
+}
+
+// EnableTelemetryExtension ...
+func EnableTelemetryExtension(config *config.Config, storeController storage.StoreController,
+	taskScheduler *scheduler.Scheduler, log log.Logger,
+) {
+	log.Warn().Msg("skipping enabling telemetry extension because given zot binary doesn't include this feature," +
+		"please build a binary that does so")
+}
+
+// SetupTelemetryRoutes ...
+func SetupTelemetryRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
+	log log.Logger,
+) {
+	log.Warn().Msg("skipping setting up telemetry routes because given zot binary doesn't include this feature," +
+		"please build a binary that does so")
+}
@@ -0,0 +1,82 @@
+//go:build telemetry
+// +build telemetry
+
+package extensions_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/extensions/telemetry"
+	"zotregistry.io/zot/pkg/log"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+	"zotregistry.io/zot/pkg/storage"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+func TestTelemetryHandler(t *testing.T) {
+	const TelemetryURL = "http://127.0.0.1:8080/v2/_zot/ext/telemetry"
+
+	log := log.NewLogger("debug", "")
+
+	enable := true
+
+	conf := config.New()
+	conf.Storage.RootDirectory = t.TempDir()
+	conf.Extensions = &extconf.ExtensionConfig{
+		Telemetry: &extconf.TelemetryConfig{BaseConfig: extconf.BaseConfig{Enable: &enable}},
+	}
+
+	storeController := storage.StoreController{
+		DefaultStore: mocks.MockedImageStore{
+			GetRepositoriesFn: func() ([]string, error) {
+				return []string{}, nil
+			},
+		},
+	}
+
+	reporter := telemetry.NewReporter(conf, storeController, log)
+
+	get := func(conf *config.Config, acCtx any) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, TelemetryURL, nil)
+
+		if acCtx != nil {
+			request = request.WithContext(context.WithValue(request.Context(), localCtx.GetContextKey(), acCtx))
+		}
+
+		response := httptest.NewRecorder()
+		extensions.HandleTelemetry(conf, reporter)(response, request)
+
+		return response
+	}
+
+	Convey("Get the report which would be sent", t, func() {
+		response := get(conf, nil)
+		So(response.Code, ShouldEqual, http.StatusOK)
+
+		var report telemetry.Report
+
+		err := json.Unmarshal(response.Body.Bytes(), &report)
+		So(err, ShouldBeNil)
+		So(report.InstanceID, ShouldNotBeEmpty)
+		So(report.Extensions, ShouldResemble, []string{"telemetry"})
+	})
+
+	Convey("Only admins can get the report when access control is enabled", t, func() {
+		acConf := *conf
+		acConf.HTTP.AccessControl = &config.AccessControlConfig{}
+
+		So(get(&acConf, nil).Code, ShouldEqual, http.StatusForbidden)
+		So(get(&acConf, localCtx.AccessControlContext{}).Code, ShouldEqual, http.StatusForbidden)
+		So(get(&acConf, localCtx.AccessControlContext{IsAdmin: true}).Code, ShouldEqual, http.StatusOK)
+		So(get(&acConf, "bad context").Code, ShouldEqual, http.StatusInternalServerError)
+	})
+}
@@ -4,36 +4,122 @@
 package extensions
 
 import (
+	"bytes"
 	"embed"
+	"encoding/json"
+	"fmt"
+	"html"
 	"io/fs"
+	"mime"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"zotregistry.io/zot/pkg/api/config"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/storage"
 )
 
+func IsBuiltWithUIExtension() bool {
+	return true
+}
+
 // content is our static web server content.
 //
 //go:embed build/*
 var content embed.FS
 
+const (
+	// the files under static/ have the hash of their content in their names.
+	uiStaticMaxAge       = 365 * 24 * time.Hour
+	uiDefaultCacheMaxAge = time.Hour
+)
+
+var uiTitleRegexp = regexp.MustCompile(`(?s)<title>.*?</title>`) //nolint:gochecknoglobals
+
 type uiHandler struct {
-	log log.Logger
+	index []byte
+	log   log.Logger
 }
 
 func (uih uiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	buf, _ := content.ReadFile("build/index.html")
+	// the page has to be revalidated so new releases and branding changes are picked up
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	_, err := w.Write(buf)
+	_, err := w.Write(uih.index)
 	if err != nil {
 		uih.log.Error().Err(err).Msg("unable to serve index.html")
 	}
 }
 
+// uiRuntimeConfig is given to the UI in the index page, as window.zotUIConfig.
+type uiRuntimeConfig struct {
+	BasePath string `json:"basePath"`
+	Title    string `json:"title,omitempty"`
+	Logo     string `json:"logo,omitempty"`
+}
+
+// renderUIIndex customizes index.html with the base path and the branding of the UI.
+func renderUIIndex(index []byte, uiConfig *extconf.UIConfig, basePath string, hasLogo bool) ([]byte, error) {
+	runtimeConfig := uiRuntimeConfig{BasePath: basePath, Title: uiConfig.Title}
+
+	if hasLogo {
+		runtimeConfig.Logo = basePath + "/branding/logo"
+	}
+
+	if uiConfig.Title != "" {
+		index = uiTitleRegexp.ReplaceAllLiteral(index, []byte("<title>"+html.EscapeString(uiConfig.Title)+"</title>"))
+	}
+
+	if basePath != "" {
+		// the UI build references its files from the root
+		index = bytes.ReplaceAll(index, []byte(`src="/`), []byte(`src="`+basePath+`/`))
+		index = bytes.ReplaceAll(index, []byte(`href="/`), []byte(`href="`+basePath+`/`))
+	}
+
+	// json escapes <, > and &, so the title can't close the script
+	runtimeConfigBlob, err := json.Marshal(runtimeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	script := []byte(fmt.Sprintf("<script>window.zotUIConfig=%s;</script></head>", runtimeConfigBlob))
+
+	return bytes.Replace(index, []byte("</head>"), script, 1), nil
+}
+
+type uiLogoHandler struct {
+	logo        []byte
+	contentType string
+	maxAge      time.Duration
+}
+
+func (ulh uiLogoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(ulh.maxAge.Seconds())))
+	w.Header().Set("Content-Type", ulh.contentType)
+
+	_, _ = w.Write(ulh.logo)
+}
+
+func addUICacheHeaders(h http.Handler, maxAge time.Duration) http.HandlerFunc { //nolint:varnamelen
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/static/") {
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(uiStaticMaxAge.Seconds())))
+		} else {
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+		}
+
+		h.ServeHTTP(w, r)
+	}
+}
+
 func addUISecurityHeaders(h http.Handler) http.HandlerFunc { //nolint:varnamelen
 	return func(w http.ResponseWriter, r *http.Request) {
 		permissionsPolicy := "microphone=(), geolocation=(), battery=(), camera=(), autoplay=(), gyroscope=(), payment=()"
@@ -61,15 +147,63 @@ func SetupUIRoutes(config *config.Config, router *mux.Router, storeController st
 	log log.Logger,
 ) {
 	if config.Extensions.UI != nil {
+		uiConfig := config.Extensions.UI
+		basePath := strings.TrimSuffix(uiConfig.BasePath, "/")
+
+		cacheMaxAge := uiConfig.CacheMaxAge
+		if cacheMaxAge == 0 {
+			cacheMaxAge = uiDefaultCacheMaxAge
+		}
+
+		uiRouter := router
+
+		if basePath != "" {
+			router.Path(basePath).Handler(http.RedirectHandler(basePath+"/", http.StatusMovedPermanently))
+
+			uiRouter = router.PathPrefix(basePath).Subrouter()
+		}
+
+		var logoHandler *uiLogoHandler
+
+		if uiConfig.LogoPath != "" {
+			logo, err := os.ReadFile(uiConfig.LogoPath)
+			if err != nil {
+				log.Error().Err(err).Str("logoPath", uiConfig.LogoPath).Msg("unable to read the ui logo, using the default one")
+			} else {
+				contentType := mime.TypeByExtension(filepath.Ext(uiConfig.LogoPath))
+				if contentType == "" {
+					contentType = http.DetectContentType(logo)
+				}
+
+				logoHandler = &uiLogoHandler{logo: logo, contentType: contentType, maxAge: cacheMaxAge}
+			}
+		}
+
+		buf, _ := content.ReadFile("build/index.html")
+
+		index, err := renderUIIndex(buf, uiConfig, basePath, logoHandler != nil)
+		if err != nil {
+			log.Error().Err(err).Msg("unable to customize the ui index page")
+
+			index = buf
+		}
+
 		fsub, _ := fs.Sub(content, "build")
-		uih := uiHandler{log: log}
+		uih := uiHandler{index: index, log: log}
+		fileServer := http.StripPrefix(basePath, http.FileServer(http.FS(fsub)))
+
+		if logoHandler != nil {
+			uiRouter.Path("/branding/logo").Handler(addUISecurityHeaders(logoHandler))
+		}
 
-		router.PathPrefix("/login").Handler(addUISecurityHeaders(uih))
-		router.PathPrefix("/home").Handler(addUISecurityHeaders(uih))
-		router.PathPrefix("/explore").Handler(addUISecurityHeaders(uih))
-		router.PathPrefix("/image").Handler(addUISecurityHeaders(uih))
-		router.PathPrefix("/").Handler(addUISecurityHeaders(http.FileServer(http.FS(fsub))))
+		uiRouter.Path("/").Handler(addUISecurityHeaders(uih))
+		uiRouter.Path("/index.html").Handler(addUISecurityHeaders(uih))
+		uiRouter.PathPrefix("/login").Handler(addUISecurityHeaders(uih))
+		uiRouter.PathPrefix("/home").Handler(addUISecurityHeaders(uih))
+		uiRouter.PathPrefix("/explore").Handler(addUISecurityHeaders(uih))
+		uiRouter.PathPrefix("/image").Handler(addUISecurityHeaders(uih))
+		uiRouter.PathPrefix("/").Handler(addUISecurityHeaders(addUICacheHeaders(fileServer, cacheMaxAge)))
 
-		log.Info().Msg("setting up ui routes")
+		log.Info().Str("basePath", basePath+"/").Msg("setting up ui routes")
 	}
 }
@@ -11,6 +11,10 @@ import (
 	"zotregistry.io/zot/pkg/storage"
 )
 
+func IsBuiltWithUIExtension() bool {
+	return false
+}
+
 func SetupUIRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
 	log log.Logger,
 ) {
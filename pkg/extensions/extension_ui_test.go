@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"testing"
 	"time"
 
@@ -104,3 +105,61 @@ func TestUIExtension(t *testing.T) {
 		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
 	})
 }
+
+func TestUIBasePathAndBranding(t *testing.T) {
+	Convey("Verify the UI is served under its base path with custom branding", t, func() {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf.HTTP.Port = port
+
+		logoPath := path.Join(t.TempDir(), "logo.svg")
+		logo := []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`)
+		err := os.WriteFile(logoPath, logo, 0o600)
+		So(err, ShouldBeNil)
+
+		defaultValue := true
+
+		conf.Extensions = &extconf.ExtensionConfig{}
+		conf.Extensions.UI = &extconf.UIConfig{
+			BaseConfig:  extconf.BaseConfig{Enable: &defaultValue},
+			BasePath:    "/registry/",
+			Title:       "ACME <registry>",
+			LogoPath:    logoPath,
+			CacheMaxAge: 10 * time.Minute,
+		}
+		conf.Storage.RootDirectory = t.TempDir()
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := test.NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		resp, err := resty.R().Get(baseURL + "/registry/home")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Cache-Control"), ShouldEqual, "no-cache")
+		So(string(resp.Body()), ShouldContainSubstring, "<title>ACME &lt;registry&gt;</title>")
+		So(string(resp.Body()), ShouldContainSubstring,
+			`window.zotUIConfig={"basePath":"/registry","title":"ACME \u003cregistry\u003e","logo":"/registry/branding/logo"}`)
+		So(string(resp.Body()), ShouldNotContainSubstring, `src="/static/`)
+
+		// redirected to /registry/
+		resp, err = resty.R().Get(baseURL + "/registry")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(string(resp.Body()), ShouldContainSubstring, "window.zotUIConfig")
+
+		resp, err = resty.R().Get(baseURL + "/registry/branding/logo")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Body(), ShouldResemble, logo)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, "image/svg+xml")
+		So(resp.Header().Get("Cache-Control"), ShouldEqual, "public, max-age=600")
+
+		resp, err = resty.R().Get(baseURL + "/home")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+}
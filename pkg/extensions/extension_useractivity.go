@@ -0,0 +1,118 @@
+//go:build userprefs
+// +build userprefs
+
+package extensions
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
+)
+
+const (
+	UserActivityPull   = "pull"
+	UserActivityPush   = "push"
+	UserActivityDelete = "delete"
+)
+
+type UserActivityList struct {
+	Activity []repodb.UserActivity `json:"activity"`
+}
+
+func isUserActivityEnabled(config *config.Config) bool {
+	return config.Extensions != nil && config.Extensions.UserActivity != nil &&
+		config.Extensions.UserActivity.Enable != nil && *config.Extensions.UserActivity.Enable
+}
+
+func SetupUserActivityRoutes(config *config.Config, router *mux.Router, repoDB repodb.RepoDB, log log.Logger) {
+	if isUserActivityEnabled(config) && repoDB != nil {
+		log.Info().Msg("setting up user activity routes")
+
+		allowedMethods := zcommon.AllowedMethods(http.MethodGet)
+
+		activityRouter := router.PathPrefix(constants.ExtUserActivity).Subrouter()
+		activityRouter.Use(zcommon.ACHeadersHandler(allowedMethods...))
+		activityRouter.Use(zcommon.AddExtensionSecurityHeaders())
+		activityRouter.HandleFunc("", HandleUserActivity(repoDB, log)).Methods(allowedMethods...)
+	}
+}
+
+// HandleUserActivity godoc
+// @Summary Get the latest actions of the current user
+// @Description Get the latest actions (pulls, pushes, deletes) done with the credentials of the current user
+// @Router 	/v2/_zot/ext/useractivity [get]
+// @Produce json
+// @Success 200 {object} 	extensions.UserActivityList
+// @Failure 403 {string} 	string 				"forbidden"
+// @Failure 500 {string} 	string 				"internal server error".
+func HandleUserActivity(repoDB repodb.RepoDB, log log.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(rsp http.ResponseWriter, req *http.Request) {
+		acCtx, err := localCtx.GetAccessControlContext(req.Context())
+		if err != nil {
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if localCtx.GetUsernameFromContext(acCtx) == "" {
+			// anonymous users have no activity
+			rsp.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		activity, err := repoDB.GetUserActivity(req.Context())
+		if err != nil {
+			log.Error().Err(err).Msg("failed to get user activity")
+			rsp.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		zcommon.WriteJSON(rsp, http.StatusOK, UserActivityList{Activity: activity})
+	}
+}
+
+// RecordUserActivity stores an action done by the user who made the request, anonymous requests are ignored.
+func RecordUserActivity(config *config.Config, repoDB repodb.RepoDB, request *http.Request,
+	action, repo, reference string, log log.Logger,
+) {
+	if !isUserActivityEnabled(config) || repoDB == nil {
+		return
+	}
+
+	acCtx, err := localCtx.GetAccessControlContext(request.Context())
+	if err != nil || localCtx.GetUsernameFromContext(acCtx) == "" {
+		return
+	}
+
+	activityConfig := config.Extensions.UserActivity
+
+	activity := repodb.UserActivity{
+		Action:    action,
+		Repo:      repo,
+		Reference: reference,
+		Timestamp: time.Now(),
+	}
+
+	if activityConfig.RecordClientAddress {
+		activity.ClientAddress = request.RemoteAddr
+	}
+
+	if activityConfig.RecordUserAgent {
+		activity.UserAgent = request.UserAgent()
+	}
+
+	if err := repoDB.AddUserActivity(request.Context(), activity, activityConfig.MaxEntries); err != nil {
+		log.Error().Err(err).Str("user", acCtx.Username).Str("action", action).Str("repository", repo).
+			Msg("failed to record user activity")
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !userprefs
+// +build !userprefs
+
+package extensions
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+)
+
+const (
+	UserActivityPull   = "pull"
+	UserActivityPush   = "push"
+	UserActivityDelete = "delete"
+)
+
+func SetupUserActivityRoutes(config *config.Config, router *mux.Router, repoDB repodb.RepoDB, log log.Logger) {
+	log.Warn().Msg("user activity extension is disabled because given zot binary doesn't" +
+		"include this feature please build a binary that does so")
+}
+
+// RecordUserActivity ...
+func RecordUserActivity(config *config.Config, repoDB repodb.RepoDB, request *http.Request,
+	action, repo, reference string, log log.Logger,
+) {
+}
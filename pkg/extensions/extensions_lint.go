@@ -9,6 +9,10 @@ import (
 	"zotregistry.io/zot/pkg/log"
 )
 
+func IsBuiltWithLintExtension() bool {
+	return true
+}
+
 func GetLinter(config *config.Config, log log.Logger) *lint.Linter {
 	if config.Extensions == nil {
 		return lint.NewLinter(nil, log)
@@ -9,6 +9,10 @@ import (
 	"zotregistry.io/zot/pkg/log"
 )
 
+func IsBuiltWithLintExtension() bool {
+	return false
+}
+
 func GetLinter(config *config.Config, log log.Logger) *lint.Linter {
 	log.Warn().Msg("lint extension is disabled because given zot binary doesn't" +
 		"include this feature please build a binary that does so")
@@ -13,18 +13,23 @@ import (
 	"testing"
 	"time"
 
+	godigest "github.com/opencontainers/go-digest"
 	. "github.com/smartystreets/goconvey/convey"
 	"gopkg.in/resty.v1"
 
 	"zotregistry.io/zot/pkg/api"
+	"zotregistry.io/zot/pkg/api/blocklist"
 	"zotregistry.io/zot/pkg/api/config"
 	"zotregistry.io/zot/pkg/api/constants"
+	"zotregistry.io/zot/pkg/api/roles"
+	"zotregistry.io/zot/pkg/api/tenancy"
 	"zotregistry.io/zot/pkg/extensions"
 	extconf "zotregistry.io/zot/pkg/extensions/config"
 	syncconf "zotregistry.io/zot/pkg/extensions/config/sync"
 	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/storage"
+	"zotregistry.io/zot/pkg/storage/lease"
 	"zotregistry.io/zot/pkg/storage/local"
 	"zotregistry.io/zot/pkg/test"
 )
@@ -521,8 +526,583 @@ func TestMgmtExtension(t *testing.T) {
 		So(mgmtResp.HTTP.Auth.HTPasswd, ShouldBeNil)
 		So(mgmtResp.HTTP.Auth.LDAP, ShouldBeNil)
 
+		resp, err = resty.R().SetQueryParam("resource", "cache").Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetQueryParam("resource", "cache").Get(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
 		data, _ := os.ReadFile(logFile.Name())
 		So(string(data), ShouldContainSubstring, "setting up mgmt routes")
+		So(string(data), ShouldContainSubstring, "cache maintenance: cache db checked and compacted")
+	})
+
+	Convey("Verify mgmt route for purging a digest", t, func() {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+
+		defaultValue := true
+
+		conf.Extensions = &extconf.ExtensionConfig{}
+		conf.Extensions.Mgmt = &extconf.MgmtConfig{
+			BaseConfig: extconf.BaseConfig{
+				Enable: &defaultValue,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := test.NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		// the same layer is pushed to both repos and deduped
+		err = test.UploadImage(image, baseURL, "infected")
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(image, baseURL, "copy")
+		So(err, ShouldBeNil)
+
+		cleanImage, err := test.GetRandomImage("clean")
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(cleanImage, baseURL, "copy")
+		So(err, ShouldBeNil)
+
+		layerDigest := image.Manifest.Layers[0].Digest
+
+		resp, err := resty.R().SetQueryParams(map[string]string{"resource": "purge", "digest": layerDigest.String()}).
+			Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var purgeResult extensions.PurgeResult
+
+		err = json.Unmarshal(resp.Body(), &purgeResult)
+		So(err, ShouldBeNil)
+		So(purgeResult.Digest, ShouldEqual, layerDigest.String())
+		So(purgeResult.Repositories, ShouldResemble, []extensions.PurgedRepo{
+			{Name: "copy", References: []string{"1.0"}},
+			{Name: "infected", References: []string{"1.0"}},
+		})
+
+		for _, repo := range []string{"infected", "copy"} {
+			resp, err = resty.R().Head(baseURL + "/v2/" + repo + "/manifests/1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+			resp, err = resty.R().Head(baseURL + "/v2/" + repo + "/blobs/" + layerDigest.String())
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		}
+
+		// images not referencing the digest are kept
+		resp, err = resty.R().Head(baseURL + "/v2/copy/manifests/clean")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "purge", "digest": layerDigest.String()}).
+			Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "purge", "digest": "bogus"}).
+			Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "purge", "digest": layerDigest.String()}).
+			Get(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Verify mgmt route for purging a digest behind an immutable tag", t, func() {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		conf.Storage.ImmutableTags = []config.ImmutableTagsRule{
+			{
+				Repositories: []string{"releases"},
+			},
+		}
+
+		defaultValue := true
+
+		conf.Extensions = &extconf.ExtensionConfig{}
+		conf.Extensions.Mgmt = &extconf.MgmtConfig{
+			BaseConfig: extconf.BaseConfig{
+				Enable: &defaultValue,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := test.NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(image, baseURL, "releases")
+		So(err, ShouldBeNil)
+
+		// the tag can't be deleted
+		resp, err := resty.R().Delete(baseURL + "/v2/releases/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusConflict)
+
+		// but the content it points to can be purged
+		layerDigest := image.Manifest.Layers[0].Digest
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "purge", "digest": layerDigest.String()}).
+			Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Head(baseURL + "/v2/releases/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().Head(baseURL + "/v2/releases/blobs/" + layerDigest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("Verify mgmt route for managing the blocklist", t, func() {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+
+		configDigest := godigest.FromString("blocked in config")
+		conf.Storage.Blocklist = []string{configDigest.String()}
+
+		defaultValue := true
+
+		conf.Extensions = &extconf.ExtensionConfig{}
+		conf.Extensions.Mgmt = &extconf.MgmtConfig{
+			BaseConfig: extconf.BaseConfig{
+				Enable: &defaultValue,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := test.NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		err = test.UploadImage(image, baseURL, "infected")
+		So(err, ShouldBeNil)
+
+		layerDigest := image.Manifest.Layers[0].Digest
+
+		resp, err := resty.R().SetQueryParams(map[string]string{
+			"resource": "blocklist",
+			"digest":   layerDigest.String(),
+			"reason":   "malware",
+		}).Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var entry blocklist.Entry
+
+		err = json.Unmarshal(resp.Body(), &entry)
+		So(err, ShouldBeNil)
+		So(entry.Digest, ShouldEqual, layerDigest)
+		So(entry.Reason, ShouldEqual, "malware")
+		So(entry.Quarantined, ShouldResemble, []string{"infected"})
+
+		resp, err = resty.R().Get(baseURL + "/v2/infected/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetQueryParam("resource", "blocklist").Get(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var entryList blocklist.EntryList
+
+		err = json.Unmarshal(resp.Body(), &entryList)
+		So(err, ShouldBeNil)
+		So(len(entryList.Entries), ShouldEqual, 2)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "blocklist", "digest": configDigest.String()}).
+			Delete(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "blocklist", "digest": layerDigest.String()}).
+			Delete(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "blocklist", "digest": layerDigest.String()}).
+			Delete(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().Get(baseURL + "/v2/infected/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "blocklist", "digest": "bogus"}).
+			Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Verify mgmt route for managing storage leases", t, func() {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		conf.Storage.MaxLeaseDuration = time.Hour
+
+		defaultValue := true
+
+		conf.Extensions = &extconf.ExtensionConfig{}
+		conf.Extensions.Mgmt = &extconf.MgmtConfig{
+			BaseConfig: extconf.BaseConfig{
+				Enable: &defaultValue,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := test.NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		So(ctlr.Leases.IsHeld(), ShouldBeFalse)
+
+		resp, err := resty.R().SetQueryParams(map[string]string{
+			"resource": "lease",
+			"duration": "30m",
+			"reason":   "backup",
+		}).Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var storageLease lease.Lease
+
+		err = json.Unmarshal(resp.Body(), &storageLease)
+		So(err, ShouldBeNil)
+		So(storageLease.ID, ShouldNotBeEmpty)
+		So(storageLease.Reason, ShouldEqual, "backup")
+		So(storageLease.ExpiresAt.Sub(storageLease.AcquiredAt), ShouldEqual, 30*time.Minute)
+		So(ctlr.Leases.IsHeld(), ShouldBeTrue)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{
+			"resource": "lease",
+			"id":       storageLease.ID,
+			"duration": "45m",
+		}).Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetQueryParam("resource", "lease").Get(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var leaseList lease.LeaseList
+
+		err = json.Unmarshal(resp.Body(), &leaseList)
+		So(err, ShouldBeNil)
+		So(len(leaseList.Leases), ShouldEqual, 1)
+		So(leaseList.Leases[0].ExpiresAt.After(storageLease.ExpiresAt), ShouldBeTrue)
+
+		for _, duration := range []string{"2h", "-1m", "bogus"} {
+			resp, err = resty.R().SetQueryParams(map[string]string{"resource": "lease", "duration": duration}).
+				Post(baseURL + constants.FullMgmtPrefix)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		}
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "lease", "id": storageLease.ID}).
+			Delete(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(ctlr.Leases.IsHeld(), ShouldBeFalse)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "lease", "id": storageLease.ID}).
+			Delete(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "lease", "id": storageLease.ID}).
+			Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("Verify mgmt route for managing role bindings", t, func() {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+
+		defaultValue := true
+
+		conf.Extensions = &extconf.ExtensionConfig{}
+		conf.Extensions.Mgmt = &extconf.MgmtConfig{
+			BaseConfig: extconf.BaseConfig{
+				Enable: &defaultValue,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := test.NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		resp, err := resty.R().SetQueryParam("resource", "roles").
+			SetBody(`{"pattern": "infra/**", "role": "publisher", "users": ["alice"]}`).
+			Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var binding roles.Binding
+
+		err = json.Unmarshal(resp.Body(), &binding)
+		So(err, ShouldBeNil)
+		So(binding.Role, ShouldEqual, config.PublisherRole)
+		So(binding.Users, ShouldResemble, []string{"alice"})
+
+		for _, body := range []string{`{"pattern": "**", "role": "owner"}`, `{"pattern": "[", "role": "reader"}`, "bogus"} {
+			resp, err = resty.R().SetQueryParam("resource", "roles").SetBody(body).
+				Post(baseURL + constants.FullMgmtPrefix)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		}
+
+		resp, err = resty.R().SetQueryParam("resource", "roles").Get(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var bindingList roles.BindingList
+
+		err = json.Unmarshal(resp.Body(), &bindingList)
+		So(err, ShouldBeNil)
+		So(len(bindingList.Bindings), ShouldEqual, 1)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{
+			"resource": "roles",
+			"pattern":  "infra/**",
+			"role":     config.PublisherRole,
+		}).Delete(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{
+			"resource": "roles",
+			"pattern":  "infra/**",
+			"role":     config.PublisherRole,
+		}).Delete(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("Verify mgmt route for managing tenants", t, func() {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		conf.Tenants = []config.TenantConfig{{Name: "acme", PathPrefix: "/acme"}}
+
+		defaultValue := true
+
+		conf.Extensions = &extconf.ExtensionConfig{}
+		conf.Extensions.Mgmt = &extconf.MgmtConfig{
+			BaseConfig: extconf.BaseConfig{
+				Enable: &defaultValue,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := test.NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		resp, err := resty.R().SetQueryParam("resource", "tenants").
+			SetBody(`{"name": "globex", "pathPrefix": "/globex", "maxRepos": 5}`).
+			Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var tenant tenancy.Tenant
+
+		err = json.Unmarshal(resp.Body(), &tenant)
+		So(err, ShouldBeNil)
+		So(tenant.Name, ShouldEqual, "globex")
+		So(tenant.MaxRepos, ShouldEqual, 5)
+
+		// the tenant is served right away
+		resp, err = resty.R().Get(baseURL + "/globex/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		for _, body := range []string{
+			`{"name": "acme", "pathPrefix": "/acme2"}`,
+			`{"name": "initech", "pathPrefix": "/globex"}`,
+			`{"name": "initech"}`,
+			"bogus",
+		} {
+			resp, err = resty.R().SetQueryParam("resource", "tenants").SetBody(body).
+				Post(baseURL + constants.FullMgmtPrefix)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		}
+
+		resp, err = resty.R().SetQueryParam("resource", "tenants").Get(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var tenantList tenancy.TenantList
+
+		err = json.Unmarshal(resp.Body(), &tenantList)
+		So(err, ShouldBeNil)
+		So(len(tenantList.Tenants), ShouldEqual, 2)
+		So(tenantList.Tenants[0].FromConfig, ShouldBeTrue)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "tenants", "name": "acme"}).
+			Delete(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "tenants", "name": "globex"}).
+			Delete(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetQueryParams(map[string]string{"resource": "tenants", "name": "globex"}).
+			Delete(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().Get(baseURL + "/globex/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("Verify mgmt route for getting the effective config", t, func() {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		conf.Storage.PreReceiveHooks = []config.PreReceiveHookConfig{
+			{URL: "http://127.0.0.1:1", FailOpen: true, Headers: map[string]string{"Authorization": "Bearer secret"}},
+		}
+
+		defaultValue := true
+
+		conf.Extensions = &extconf.ExtensionConfig{}
+		conf.Extensions.Mgmt = &extconf.MgmtConfig{
+			BaseConfig: extconf.BaseConfig{
+				Enable: &defaultValue,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := test.NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		resp, err := resty.R().SetQueryParam("resource", "effectiveConfig").Get(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var effectiveConfig extensions.EffectiveConfig
+
+		err = json.Unmarshal(resp.Body(), &effectiveConfig)
+		So(err, ShouldBeNil)
+		So(effectiveConfig.ActiveExtensions, ShouldContain, "mgmt")
+		So(effectiveConfig.Config.Storage.RootDirectory, ShouldEqual, conf.Storage.RootDirectory)
+		So(effectiveConfig.Config.Storage.GCDelay, ShouldEqual, conf.Storage.GCDelay)
+		So(effectiveConfig.Config.Storage.PreReceiveHooks[0].Headers["Authorization"], ShouldEqual, "******")
+
+		// the running config keeps its secrets
+		So(conf.Storage.PreReceiveHooks[0].Headers["Authorization"], ShouldEqual, "Bearer secret")
+
+		resp, err = resty.R().SetQueryParam("resource", "effectiveConfig").Post(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Verify mgmt route for getting the effective config is only allowed to admins", t, func() {
+		conf := config.New()
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+
+		htpasswdPath := test.MakeHtpasswdFile()
+		defer os.Remove(htpasswdPath)
+
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				"**": config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Users:   []string{"test"},
+							Actions: []string{"read"},
+						},
+					},
+				},
+			},
+			AdminPolicy: config.Policy{
+				Users:   []string{"admin"},
+				Actions: []string{"read", "create", "update", "delete"},
+			},
+		}
+
+		defaultValue := true
+
+		conf.Extensions = &extconf.ExtensionConfig{}
+		conf.Extensions.Mgmt = &extconf.MgmtConfig{
+			BaseConfig: extconf.BaseConfig{
+				Enable: &defaultValue,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := test.NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		resp, err := resty.R().SetBasicAuth("test", "test").SetQueryParam("resource", "effectiveConfig").
+			Get(baseURL + constants.FullMgmtPrefix)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
 	})
 
 	Convey("Verify mgmt route enabled for uploading certificates and public keys", t, func() {
@@ -852,7 +1432,7 @@ func TestAllowedMethodsHeaderMgmt(t *testing.T) {
 
 		resp, _ := resty.R().Options(baseURL + constants.FullMgmtPrefix)
 		So(resp, ShouldNotBeNil)
-		So(resp.Header().Get("Access-Control-Allow-Methods"), ShouldResemble, "GET,POST,OPTIONS")
+		So(resp.Header().Get("Access-Control-Allow-Methods"), ShouldResemble, "GET,POST,DELETE,OPTIONS")
 		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
 	})
 }
@@ -15,7 +15,9 @@ func GetExtensions(config *config.Config) distext.ExtensionList {
 
 	if config.Extensions != nil && config.Extensions.Search != nil {
 		if IsBuiltWithSearchExtension() {
-			endpoints = append(endpoints, constants.FullSearchPrefix)
+			endpoints = append(endpoints, constants.FullSearchPrefix, constants.FullDigestsPrefix,
+				constants.FullPinsPrefix, constants.FullAnnotationsPrefix, constants.FullCVEAcknowledgementsPrefix,
+				constants.FullPromotionPrefix, constants.FullStatsPrefix)
 		}
 
 		if IsBuiltWithUserPrefsExtension() {
@@ -23,10 +25,22 @@ func GetExtensions(config *config.Config) distext.ExtensionList {
 		}
 	}
 
+	if IsBuiltWithUserPrefsExtension() && config.Extensions != nil && config.Extensions.UserActivity != nil {
+		endpoints = append(endpoints, constants.FullUserActivityPrefix)
+	}
+
 	if IsBuiltWithMGMTExtension() && config.Extensions != nil && config.Extensions.Mgmt != nil {
 		endpoints = append(endpoints, constants.FullMgmtPrefix)
 	}
 
+	if IsBuiltWithSyncExtension() && isPeeringEnabled(config) {
+		endpoints = append(endpoints, constants.FullPeeringPrefix)
+	}
+
+	if IsBuiltWithTelemetryExtension() && isTelemetryEnabled(config) {
+		endpoints = append(endpoints, constants.FullTelemetryPrefix)
+	}
+
 	if len(endpoints) > 0 {
 		extensions = append(extensions, distext.Extension{
 			Name:        "_zot",
@@ -40,3 +54,82 @@ func GetExtensions(config *config.Config) distext.ExtensionList {
 
 	return extensionList
 }
+
+func isPeeringEnabled(config *config.Config) bool {
+	if config.Extensions == nil || config.Extensions.Sync == nil ||
+		config.Extensions.Sync.Enable == nil || !*config.Extensions.Sync.Enable {
+		return false
+	}
+
+	for _, registryConfig := range config.Extensions.Sync.Registries {
+		if registryConfig.Peering {
+			return true
+		}
+	}
+
+	return false
+}
+
+// telemetry is opt-in, a missing enable flag means no usage statistics are reported.
+func isTelemetryEnabled(config *config.Config) bool {
+	return config.Extensions != nil && config.Extensions.Telemetry != nil &&
+		config.Extensions.Telemetry.Enable != nil && *config.Extensions.Telemetry.Enable
+}
+
+/*
+GetActiveExtensions returns the names, as in the config, of the extensions which are enabled and which the binary
+is built with, followed by the names of the enabled plugins. The events log is active when it's enabled or, unless
+it's disabled, when plugins are loaded.
+*/
+func GetActiveExtensions(config *config.Config) []string {
+	active := []string{}
+
+	exts := config.Extensions
+	if exts == nil {
+		return active
+	}
+
+	isEnabled := func(enable *bool) bool {
+		return enable != nil && *enable
+	}
+
+	for _, ext := range []struct {
+		name    string
+		enabled bool
+		built   bool
+	}{
+		{"search", exts.Search != nil && isEnabled(exts.Search.Enable), IsBuiltWithSearchExtension()},
+		{"sync", exts.Sync != nil && isEnabled(exts.Sync.Enable), IsBuiltWithSyncExtension()},
+		{"metrics", exts.Metrics != nil && isEnabled(exts.Metrics.Enable), IsBuiltWithMetricsExtension()},
+		{"scrub", exts.Scrub != nil && isEnabled(exts.Scrub.Enable), IsBuiltWithScrubExtension()},
+		{"lint", exts.Lint != nil && isEnabled(exts.Lint.Enable), IsBuiltWithLintExtension()},
+		{"ui", exts.UI != nil && isEnabled(exts.UI.Enable), IsBuiltWithUIExtension()},
+		{"mgmt", exts.Mgmt != nil && isEnabled(exts.Mgmt.Enable), IsBuiltWithMGMTExtension()},
+		{
+			"userActivity", exts.UserActivity != nil && isEnabled(exts.UserActivity.Enable),
+			IsBuiltWithUserPrefsExtension(),
+		},
+		{"telemetry", isTelemetryEnabled(config), IsBuiltWithTelemetryExtension()},
+	} {
+		if ext.enabled && ext.built {
+			active = append(active, ext.name)
+		}
+	}
+
+	keepEvents := len(exts.Plugins) > 0
+	if exts.Events != nil && exts.Events.Enable != nil {
+		keepEvents = *exts.Events.Enable
+	}
+
+	if keepEvents {
+		active = append(active, "events")
+	}
+
+	for _, plugin := range exts.Plugins {
+		if plugin.Enable == nil || *plugin.Enable {
+			active = append(active, "plugin:"+plugin.Name)
+		}
+	}
+
+	return active
+}
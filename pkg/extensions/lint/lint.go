@@ -6,6 +6,7 @@ package lint
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	godigest "github.com/opencontainers/go-digest"
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -31,21 +32,128 @@ func NewLinter(config *config.LintConfig, log log.Logger) *Linter {
 func (linter *Linter) CheckMandatoryAnnotations(repo string, manifestDigest godigest.Digest,
 	imgStore storageTypes.ImageStore,
 ) (bool, error) {
-	if linter.config == nil {
-		return true, nil
+	missingAnnotations, configDigest, err := linter.getMissingMandatoryAnnotations(repo, manifestDigest, imgStore)
+	if err != nil {
+		return false, err
+	}
+
+	// images violating the rules configured to warn are accepted, the violations are returned by Warnings
+	missingAnnotations = linter.filterByAction(missingAnnotations, config.LintActionReject)
+
+	if len(missingAnnotations) > 0 {
+		msg := fmt.Sprintf("\nlinter: manifest %s\nor config %s\nis missing the next annotations: %s",
+			string(manifestDigest), string(configDigest), missingAnnotations)
+		linter.log.Error().Msg(msg)
+
+		return false, fmt.Errorf("%s: %w", msg, zerr.ErrImageLintAnnotations)
+	}
+
+	return true, nil
+}
+
+// Warnings returns the rules configured to warn an image violates, nil if no rule is configured to warn.
+func (linter *Linter) Warnings(repo string, manifestDigest godigest.Digest,
+	imgStore storageTypes.ImageStore,
+) ([]string, error) {
+	if !linter.hasWarnRules() {
+		return nil, nil
+	}
+
+	missingAnnotations, _, err := linter.getMissingMandatoryAnnotations(repo, manifestDigest, imgStore)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []string{}
+
+	for _, annotation := range linter.filterByAction(missingAnnotations, config.LintActionWarn) {
+		warnings = append(warnings, "missing mandatory annotation "+annotation)
+	}
+
+	return warnings, nil
+}
+
+func (linter *Linter) isEnabled() bool {
+	return linter != nil && linter.config != nil && linter.config.Enable != nil && *linter.config.Enable
+}
+
+// getActions returns the action of each mandatory annotation, the rules overriding the action of the lint
+// config for their annotation.
+func (linter *Linter) getActions() map[string]string {
+	if !linter.isEnabled() {
+		return map[string]string{}
+	}
+
+	defaultAction := linter.config.Action
+	if defaultAction == "" {
+		defaultAction = config.LintActionReject
+	}
+
+	actions := make(map[string]string, len(linter.config.MandatoryAnnotations)+len(linter.config.Rules))
+
+	for _, annotation := range linter.config.MandatoryAnnotations {
+		actions[annotation] = defaultAction
 	}
 
-	if (linter.config != nil && !*linter.config.Enable) || len(linter.config.MandatoryAnnotations) == 0 {
-		return true, nil
+	for _, rule := range linter.config.Rules {
+		actions[rule.MandatoryAnnotation] = defaultAction
+
+		if rule.Action != "" {
+			actions[rule.MandatoryAnnotation] = rule.Action
+		}
+	}
+
+	return actions
+}
+
+func (linter *Linter) hasWarnRules() bool {
+	for _, action := range linter.getActions() {
+		if action == config.LintActionWarn {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterByAction returns the annotations whose rules take the action.
+func (linter *Linter) filterByAction(annotations []string, action string) []string {
+	actions := linter.getActions()
+	filtered := []string{}
+
+	for _, annotation := range annotations {
+		if actions[annotation] == action {
+			filtered = append(filtered, annotation)
+		}
+	}
+
+	return filtered
+}
+
+// getMissingMandatoryAnnotations returns the mandatory annotations found neither in the manifest
+// nor in the labels of the config of an image, sorted.
+func (linter *Linter) getMissingMandatoryAnnotations(repo string, manifestDigest godigest.Digest,
+	imgStore storageTypes.ImageStore,
+) ([]string, godigest.Digest, error) {
+	if !linter.isEnabled() {
+		return nil, "", nil
 	}
 
-	mandatoryAnnotationsList := linter.config.MandatoryAnnotations
+	mandatoryAnnotationsList := make([]string, 0, len(linter.config.MandatoryAnnotations))
+
+	for annotation := range linter.getActions() {
+		mandatoryAnnotationsList = append(mandatoryAnnotationsList, annotation)
+	}
+
+	if len(mandatoryAnnotationsList) == 0 {
+		return nil, "", nil
+	}
 
 	content, err := imgStore.GetBlobContent(repo, manifestDigest)
 	if err != nil {
 		linter.log.Error().Err(err).Msg("linter: unable to get image manifest")
 
-		return false, err
+		return nil, "", err
 	}
 
 	var manifest ispec.Manifest
@@ -53,7 +161,7 @@ func (linter *Linter) CheckMandatoryAnnotations(repo string, manifestDigest godi
 	if err := json.Unmarshal(content, &manifest); err != nil {
 		linter.log.Error().Err(err).Msg("linter: couldn't unmarshal manifest JSON")
 
-		return false, err
+		return nil, "", err
 	}
 
 	mandatoryAnnotationsMap := make(map[string]bool)
@@ -70,7 +178,7 @@ func (linter *Linter) CheckMandatoryAnnotations(repo string, manifestDigest godi
 
 	missingAnnotations := getMissingAnnotations(mandatoryAnnotationsMap)
 	if len(missingAnnotations) == 0 {
-		return true, nil
+		return nil, "", nil
 	}
 
 	// if there are mandatory annotations missing in the manifest, get config and check these annotations too
@@ -81,14 +189,14 @@ func (linter *Linter) CheckMandatoryAnnotations(repo string, manifestDigest godi
 		linter.log.Error().Err(err).Msg("linter: couldn't get config JSON " +
 			configDigest.String())
 
-		return false, err
+		return nil, "", err
 	}
 
 	var imageConfig ispec.Image
 	if err := json.Unmarshal(content, &imageConfig); err != nil {
 		linter.log.Error().Err(err).Msg("linter: couldn't unmarshal config JSON " + configDigest.String())
 
-		return false, err
+		return nil, "", err
 	}
 
 	configAnnotations := imageConfig.Config.Labels
@@ -100,15 +208,9 @@ func (linter *Linter) CheckMandatoryAnnotations(repo string, manifestDigest godi
 	}
 
 	missingAnnotations = getMissingAnnotations(mandatoryAnnotationsMap)
-	if len(missingAnnotations) > 0 {
-		msg := fmt.Sprintf("\nlinter: manifest %s\nor config %s\nis missing the next annotations: %s",
-			string(manifestDigest), string(configDigest), missingAnnotations)
-		linter.log.Error().Msg(msg)
+	sort.Strings(missingAnnotations)
 
-		return false, fmt.Errorf("%s: %w", msg, zerr.ErrImageLintAnnotations)
-	}
-
-	return true, nil
+	return missingAnnotations, configDigest, nil
 }
 
 func (linter *Linter) Lint(repo string, manifestDigest godigest.Digest,
@@ -16,3 +16,9 @@ func (linter *Linter) Lint(repo string, manifestDigest godigest.Digest,
 ) (bool, error) {
 	return true, nil
 }
+
+func (linter *Linter) Warnings(repo string, manifestDigest godigest.Digest,
+	imageStore storageTypes.ImageStore,
+) ([]string, error) {
+	return nil, nil
+}
@@ -456,6 +456,90 @@ func TestVerifyMandatoryAnnotations(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
 	})
+
+	Convey("Mandatory annotations only warn", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		enable := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Lint: &extconf.LintConfig{
+				BaseConfig:           extconf.BaseConfig{Enable: &enable},
+				MandatoryAnnotations: []string{"annotation1", "annotation2", "annotation3"},
+				Action:               extconf.LintActionWarn,
+			},
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &enable}},
+		}
+
+		ctlr := api.NewController(conf)
+		dir := t.TempDir()
+
+		test.CopyTestFiles("../../../test/data", dir)
+
+		ctlr.Config.Storage.RootDirectory = dir
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		resp, err := resty.R().Get(baseURL + "/v2/zot-test/manifests/0.0.1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var manifest ispec.Manifest
+		err = json.Unmarshal(resp.Body(), &manifest)
+		So(err, ShouldBeNil)
+
+		manifest.Annotations = map[string]string{"annotation1": "testWarn1"}
+		content, err := json.Marshal(manifest)
+		So(err, ShouldBeNil)
+
+		digest := godigest.FromBytes(content)
+
+		resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
+			SetBody(content).Put(baseURL + "/v2/zot-test/manifests/0.0.1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(resp.Header().Values("Warning"), ShouldResemble, []string{
+			`299 - "missing mandatory annotation annotation2"`,
+			`299 - "missing mandatory annotation annotation3"`,
+		})
+
+		repoMeta, err := ctlr.RepoDB.GetRepoMeta("zot-test")
+		So(err, ShouldBeNil)
+		So(repoMeta.LintViolations[digest.String()], ShouldResemble, []string{
+			"missing mandatory annotation annotation2",
+			"missing mandatory annotation annotation3",
+		})
+
+		// images passing the rules have no violations
+		manifest.Annotations = map[string]string{"annotation1": "a", "annotation2": "b", "annotation3": "c"}
+		content, err = json.Marshal(manifest)
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().SetHeader("Content-Type", "application/vnd.oci.image.manifest.v1+json").
+			SetBody(content).Put(baseURL + "/v2/zot-test/manifests/0.0.1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(resp.Header().Values("Warning"), ShouldBeEmpty)
+
+		repoMeta, err = ctlr.RepoDB.GetRepoMeta("zot-test")
+		So(err, ShouldBeNil)
+		So(repoMeta.LintViolations, ShouldNotContainKey, godigest.FromBytes(content).String())
+
+		// the violations are removed with the manifest
+		So(repoMeta.LintViolations, ShouldContainKey, digest.String())
+
+		resp, err = resty.R().Delete(baseURL + "/v2/zot-test/manifests/" + digest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		repoMeta, err = ctlr.RepoDB.GetRepoMeta("zot-test")
+		So(err, ShouldBeNil)
+		So(repoMeta.LintViolations, ShouldNotContainKey, digest.String())
+	})
 }
 
 func TestVerifyMandatoryAnnotationsFunction(t *testing.T) {
@@ -702,6 +786,78 @@ func TestVerifyMandatoryAnnotationsFunction(t *testing.T) {
 		So(pass, ShouldBeTrue)
 	})
 
+	Convey("Mandatory annotations incomplete in manifest, only warning", t, func() {
+		enable := true
+
+		lintConfig := &extconf.LintConfig{
+			BaseConfig:           extconf.BaseConfig{Enable: &enable},
+			MandatoryAnnotations: []string{"annotation1", "annotation2"},
+			Action:               extconf.LintActionWarn,
+		}
+
+		dir := t.TempDir()
+
+		test.CopyTestFiles("../../../test/data", dir)
+
+		var index ispec.Index
+		buf, err := os.ReadFile(path.Join(dir, "zot-test", "index.json"))
+		So(err, ShouldBeNil)
+		err = json.Unmarshal(buf, &index)
+		So(err, ShouldBeNil)
+
+		manifestDigest := index.Manifests[0].Digest
+
+		linter := lint.NewLinter(lintConfig, log.NewLogger("debug", ""))
+		imgStore := local.NewImageStore(dir, false, 0, false, false,
+			log.NewLogger("debug", ""), monitoring.NewMetricsServer(false, log.NewLogger("debug", "")), linter, nil)
+
+		pass, err := linter.CheckMandatoryAnnotations("zot-test", manifestDigest, imgStore)
+		So(err, ShouldBeNil)
+		So(pass, ShouldBeTrue)
+
+		warnings, err := linter.Warnings("zot-test", manifestDigest, imgStore)
+		So(err, ShouldBeNil)
+		So(warnings, ShouldResemble, []string{
+			"missing mandatory annotation annotation1",
+			"missing mandatory annotation annotation2",
+		})
+
+		warnings, err = linter.Warnings("zot-test", godigest.FromString("missing"), imgStore)
+		So(err, ShouldNotBeNil)
+		So(warnings, ShouldBeNil)
+
+		lintConfig.Action = extconf.LintActionReject
+
+		warnings, err = linter.Warnings("zot-test", manifestDigest, imgStore)
+		So(err, ShouldBeNil)
+		So(warnings, ShouldBeNil)
+
+		// the rules override the action of the config for their annotation
+		lintConfig.Rules = []extconf.LintRule{
+			{MandatoryAnnotation: "annotation2", Action: extconf.LintActionWarn},
+			{MandatoryAnnotation: "annotation3", Action: extconf.LintActionWarn},
+		}
+
+		pass, err = linter.CheckMandatoryAnnotations("zot-test", manifestDigest, imgStore)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "annotation1")
+		So(err.Error(), ShouldNotContainSubstring, "annotation2")
+		So(pass, ShouldBeFalse)
+
+		warnings, err = linter.Warnings("zot-test", manifestDigest, imgStore)
+		So(err, ShouldBeNil)
+		So(warnings, ShouldResemble, []string{
+			"missing mandatory annotation annotation2",
+			"missing mandatory annotation annotation3",
+		})
+
+		lintConfig.MandatoryAnnotations = nil
+
+		pass, err = linter.CheckMandatoryAnnotations("zot-test", manifestDigest, imgStore)
+		So(err, ShouldBeNil)
+		So(pass, ShouldBeTrue)
+	})
+
 	Convey("Cannot unmarshal manifest", t, func() {
 		enable := true
 
@@ -29,3 +29,11 @@ func getDirSize(path string) (int64, error) {
 
 	return size, err
 }
+
+func getVerificationResult(ok bool) string {
+	if ok {
+		return "ok"
+	}
+
+	return "mismatch"
+}
@@ -26,6 +26,30 @@ var (
 		},
 		[]string{"method", "code"},
 	)
+	httpPanics = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_panics_total",
+			Help:      "Total number of http requests whose handler panicked",
+		},
+		[]string{"method", "route"},
+	)
+	httpShedRequests = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_shed_total",
+			Help:      "Total number of http requests rejected while the storage was unhealthy",
+		},
+		[]string{"method"},
+	)
+	tenantHTTPRequests = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "tenant_http_requests_total",
+			Help:      "Total number of http requests served by the virtual registries of the tenants",
+		},
+		[]string{"tenant", "method", "code"},
+	)
 	httpRepoLatency = promauto.NewSummaryVec( //nolint: gochecknoglobals
 		prometheus.SummaryOpts{
 			Namespace: metricsNamespace,
@@ -67,6 +91,14 @@ var (
 		},
 		[]string{"repo"},
 	)
+	syncConflictCounter = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "sync_conflicts_total",
+			Help:      "Total number of times a tag was found pointing to different digests on a peer registry",
+		},
+		[]string{"registry", "repo"},
+	)
 	serverInfo = promauto.NewGaugeVec( //nolint: gochecknoglobals
 		prometheus.GaugeOpts{
 			Namespace: metricsNamespace,
@@ -84,10 +116,188 @@ var (
 		},
 		[]string{"storageName", "lockType"},
 	)
+	blobVerifications = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "storage_blob_verifications_total",
+			Help:      "Total number of blobs verified after garbage collection, by result (ok or mismatch)",
+		},
+		[]string{"storageName", "result"},
+	)
+	storageLeases = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "storage_leases",
+			Help:      "Number of active storage leases pausing garbage collection",
+		},
+		[]string{},
+	)
+	scrubAffectedImages = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "scrub_affected_images",
+			Help:      "Number of images of a repository found corrupted by the last scrub",
+		},
+		[]string{"repo"},
+	)
+	scrubImages = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "scrub_images_total",
+			Help:      "Total number of images scrubbed, by status (ok or affected)",
+		},
+		[]string{"status"},
+	)
+	storageQuotaUsage = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "storage_quota_usage_bytes",
+			Help:      "Bytes used by a repository, as counted by the storage quotas",
+		},
+		[]string{"repo"},
+	)
+	storageQuotaTotalUsage = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "storage_quota_total_usage_bytes",
+			Help:      "Bytes used by all the repositories, as counted by the storage quotas",
+		},
+		[]string{},
+	)
+	breakerState = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "circuit_breaker_state",
+			Help:      "State of the circuit breakers: 0 closed, 1 half-open, 2 open",
+		},
+		[]string{"breaker"},
+	)
+	breakerRejections = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "circuit_breaker_rejections_total",
+			Help:      "Total number of calls rejected by the circuit breakers while their circuit was open",
+		},
+		[]string{"breaker"},
+	)
+	storageHealthScore = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "storage_health_score",
+			Help:      "Health score of the storage between 0 and 1, computed from its recent probes",
+		},
+		[]string{},
+	)
+	storageLeasesExpired = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "storage_leases_expired_total",
+			Help:      "Total number of storage leases which expired without being released",
+		},
+		[]string{},
+	)
+	gcDeferred = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "storage_gc_deferred_total",
+			Help:      "Total number of garbage collections skipped because the storage was leased",
+		},
+		[]string{"storageName"},
+	)
+	storageFsyncLatency = promauto.NewHistogramVec( //nolint: gochecknoglobals
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "storage_fsync_latency_seconds",
+			Help:      "Latency of flushing written files to disk",
+			Buckets:   GetStorageLatencyBuckets(),
+		},
+		[]string{"storageName", "fileType"},
+	)
+	cacheLatency = promauto.NewHistogramVec( //nolint: gochecknoglobals
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_operation_latency_seconds",
+			Help:      "Latency of dedupe cache driver operations",
+			Buckets:   GetStorageLatencyBuckets(),
+		},
+		[]string{"driver", "operation"},
+	)
+	cacheErrors = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_errors_total",
+			Help:      "Total number of failed dedupe cache driver operations",
+		},
+		[]string{"driver", "operation"},
+	)
+	cacheDBSize = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_db_size_bytes",
+			Help:      "Size of the dedupe cache db",
+		},
+		[]string{"storageName"},
+	)
+	cacheBucketEntries = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_bucket_entries",
+			Help:      "Number of entries of the dedupe cache db buckets",
+		},
+		[]string{"storageName", "bucket"},
+	)
+	cacheIntegrityIssues = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_integrity_issues",
+			Help:      "Number of issues found by the last integrity check of the dedupe cache db",
+		},
+		[]string{"storageName"},
+	)
+	cveScans = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cve_scans",
+			Help:      "Number of CVE scans waiting in the scan queue or running",
+		},
+		[]string{"state"},
+	)
+	subsystemLastSuccessAge = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "subsystem_last_success_age_seconds",
+			Help:      "Time since an instance of a background subsystem last succeeded, e.g. a sync registry",
+		},
+		[]string{"subsystem", "instance"},
+	)
+	subsystemHealthy = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "subsystem_healthy",
+			Help:      "Whether a background subsystem is healthy (1) or not (0)",
+		},
+		[]string{"subsystem"},
+	)
+	repoDBErrorRatio = promauto.NewGauge( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "repodb_error_ratio",
+			Help:      "Share of the repodb updates which failed since the previous health check",
+		},
+	)
+	schedulerQueueDepth = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "scheduler_queue_depth",
+			Help:      "Number of tasks waiting in a queue of the scheduler",
+		},
+		[]string{"priority"},
+	)
 )
 
 type metricServer struct {
 	enabled bool
+	health  *health
 	log     log.Logger
 }
 
@@ -102,6 +312,7 @@ func GetStorageLatencyBuckets() []float64 {
 func NewMetricsServer(enabled bool, log log.Logger) MetricServer {
 	return &metricServer{
 		enabled: enabled,
+		health:  newHealth(),
 		log:     log,
 	}
 }
@@ -145,6 +356,24 @@ func IncHTTPConnRequests(ms MetricServer, lvalues ...string) {
 	})
 }
 
+func IncHTTPPanics(ms MetricServer, method, route string) {
+	ms.SendMetric(func() {
+		httpPanics.WithLabelValues(method, route).Inc()
+	})
+}
+
+func IncHTTPShedRequests(ms MetricServer, method string) {
+	ms.SendMetric(func() {
+		httpShedRequests.WithLabelValues(method).Inc()
+	})
+}
+
+func IncTenantHTTPRequests(ms MetricServer, tenant, method, code string) {
+	ms.SendMetric(func() {
+		tenantHTTPRequests.WithLabelValues(tenant, method, code).Inc()
+	})
+}
+
 func ObserveHTTPRepoLatency(ms MetricServer, path string, latency time.Duration) {
 	ms.SendMetric(func() {
 		re := regexp.MustCompile(`\/v2\/(.*?)\/(blobs|tags|manifests)\/(.*)$`)
@@ -187,6 +416,12 @@ func IncUploadCounter(ms MetricServer, repo string) {
 	})
 }
 
+func IncSyncConflictCounter(ms MetricServer, registry, repo string) {
+	ms.SendMetric(func() {
+		syncConflictCounter.WithLabelValues(registry, repo).Inc()
+	})
+}
+
 func SetServerInfo(ms MetricServer, lvalues ...string) {
 	ms.ForceSendMetric(func() {
 		serverInfo.WithLabelValues(lvalues...).Set(0)
@@ -198,3 +433,141 @@ func ObserveStorageLockLatency(ms MetricServer, latency time.Duration, storageNa
 		storageLockLatency.WithLabelValues(storageName, lockType).Observe(latency.Seconds())
 	})
 }
+
+func IncBlobVerifications(ms MetricServer, storageName string, ok bool) {
+	ms.SendMetric(func() {
+		blobVerifications.WithLabelValues(storageName, getVerificationResult(ok)).Inc()
+	})
+}
+
+func SetStorageLeases(ms MetricServer, count int) {
+	ms.ForceSendMetric(func() {
+		storageLeases.WithLabelValues().Set(float64(count))
+	})
+}
+
+func SetScrubAffectedImages(ms MetricServer, repo string, count int) {
+	ms.ForceSendMetric(func() {
+		scrubAffectedImages.WithLabelValues(repo).Set(float64(count))
+	})
+}
+
+func IncScrubbedImages(ms MetricServer, status string) {
+	ms.SendMetric(func() {
+		scrubImages.WithLabelValues(status).Inc()
+	})
+}
+
+func SetStorageQuotaUsage(ms MetricServer, repo string, bytes int64) {
+	ms.ForceSendMetric(func() {
+		storageQuotaUsage.WithLabelValues(repo).Set(float64(bytes))
+	})
+}
+
+func SetStorageQuotaTotalUsage(ms MetricServer, bytes int64) {
+	ms.ForceSendMetric(func() {
+		storageQuotaTotalUsage.WithLabelValues().Set(float64(bytes))
+	})
+}
+
+func SetCircuitBreakerState(ms MetricServer, name string, state int) {
+	ms.ForceSendMetric(func() {
+		breakerState.WithLabelValues(name).Set(float64(state))
+	})
+}
+
+func IncCircuitBreakerRejections(ms MetricServer, name string) {
+	ms.SendMetric(func() {
+		breakerRejections.WithLabelValues(name).Inc()
+	})
+}
+
+func SetStorageHealthScore(ms MetricServer, score float64) {
+	ms.ForceSendMetric(func() {
+		storageHealthScore.WithLabelValues().Set(score)
+	})
+}
+
+func IncStorageLeasesExpired(ms MetricServer) {
+	ms.SendMetric(func() {
+		storageLeasesExpired.WithLabelValues().Inc()
+	})
+}
+
+func IncGCDeferred(ms MetricServer, storageName string) {
+	ms.SendMetric(func() {
+		gcDeferred.WithLabelValues(storageName).Inc()
+	})
+}
+
+func ObserveStorageFsyncLatency(ms MetricServer, latency time.Duration, storageName, fileType string) {
+	ms.SendMetric(func() {
+		storageFsyncLatency.WithLabelValues(storageName, fileType).Observe(latency.Seconds())
+	})
+}
+
+func ObserveCacheLatency(ms MetricServer, latency time.Duration, driver, operation string) {
+	ms.SendMetric(func() {
+		cacheLatency.WithLabelValues(driver, operation).Observe(latency.Seconds())
+	})
+}
+
+func IncCacheErrors(ms MetricServer, driver, operation string) {
+	ms.SendMetric(func() {
+		cacheErrors.WithLabelValues(driver, operation).Inc()
+	})
+}
+
+func SetCacheDBSize(ms MetricServer, storageName string, size int64) {
+	ms.ForceSendMetric(func() {
+		cacheDBSize.WithLabelValues(storageName).Set(float64(size))
+	})
+}
+
+func SetCacheBucketEntries(ms MetricServer, storageName, bucket string, count int) {
+	ms.ForceSendMetric(func() {
+		cacheBucketEntries.WithLabelValues(storageName, bucket).Set(float64(count))
+	})
+}
+
+func SetCacheIntegrityIssues(ms MetricServer, storageName string, issues int) {
+	ms.ForceSendMetric(func() {
+		cacheIntegrityIssues.WithLabelValues(storageName).Set(float64(issues))
+	})
+}
+
+func SetCVEScans(ms MetricServer, queued, running int) {
+	ms.ForceSendMetric(func() {
+		cveScans.WithLabelValues("queued").Set(float64(queued))
+		cveScans.WithLabelValues("running").Set(float64(running))
+	})
+}
+
+func SetSubsystemLastSuccessAge(ms MetricServer, subsystem, instance string, age time.Duration) {
+	ms.ForceSendMetric(func() {
+		subsystemLastSuccessAge.WithLabelValues(subsystem, instance).Set(age.Seconds())
+	})
+}
+
+func SetSubsystemHealthy(ms MetricServer, subsystem string, healthy bool) {
+	ms.ForceSendMetric(func() {
+		value := 0.0
+		if healthy {
+			value = 1
+		}
+
+		subsystemHealthy.WithLabelValues(subsystem).Set(value)
+	})
+}
+
+func SetRepoDBErrorRatio(ms MetricServer, ratio float64) {
+	ms.ForceSendMetric(func() {
+		repoDBErrorRatio.Set(ratio)
+	})
+}
+
+func SetSchedulerQueueDepth(ms MetricServer, priority string, depth int) {
+	ms.ForceSendMetric(func() {
+		schedulerQueueDepth.WithLabelValues(priority).Set(float64(depth))
+	})
+}
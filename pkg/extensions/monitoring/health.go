@@ -0,0 +1,237 @@
+package monitoring
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"zotregistry.io/zot/pkg/scheduler"
+)
+
+// subsystems whose health is exported, see CheckHealth.
+const (
+	SubsystemSync      = "sync"
+	SubsystemCVEDB     = "cveDB"
+	SubsystemRepoDB    = "repoDB"
+	SubsystemScheduler = "scheduler"
+)
+
+const (
+	// HealthCheckInterval is how often the health metrics are updated.
+	HealthCheckInterval = time.Minute
+
+	// MaxAgeIntervals is how many intervals a periodic subsystem can go without succeeding before it's unhealthy.
+	MaxAgeIntervals = 3
+
+	// MaxRepoDBErrorRatio is the share of failed repodb updates since the previous check above which repodb
+	// is unhealthy.
+	MaxRepoDBErrorRatio = 0.1
+)
+
+// SubsystemHealth is the health of a subsystem, or of one of its instances, e.g. a sync registry.
+type SubsystemHealth struct {
+	Subsystem string `json:"subsystem"`
+	Instance  string `json:"instance,omitempty"`
+	Healthy   bool   `json:"healthy"`
+	// time since the instance last succeeded, or since it was registered if it never did
+	LastSuccessAge time.Duration `json:"lastSuccessAge,omitempty"`
+}
+
+/*
+health records when the background subsystems last succeeded, so that a subsystem silently failing, e.g. a sync
+registry unreachable for days, shows in the metrics. Each metric server has its own.
+*/
+type health struct {
+	lock sync.Mutex
+	// last success of each instance of each subsystem, and the age after which it's unhealthy
+	instances map[string]map[string]*instanceHealth
+	// repodb updates since the previous check
+	repoDBUpdates int
+	repoDBErrors  int
+}
+
+type instanceHealth struct {
+	lastSuccess time.Time
+	maxAge      time.Duration
+}
+
+func newHealth() *health {
+	return &health{instances: map[string]map[string]*instanceHealth{}}
+}
+
+func getHealth(ms MetricServer) *health {
+	server, ok := ms.(*metricServer)
+	if !ok || server.health == nil {
+		return nil
+	}
+
+	return server.health
+}
+
+// RegisterSubsystem tracks an instance of a subsystem, it's unhealthy if it doesn't succeed within maxAge.
+func RegisterSubsystem(ms MetricServer, subsystem, instance string, maxAge time.Duration) {
+	health := getHealth(ms)
+	if health == nil {
+		return
+	}
+
+	health.lock.Lock()
+	defer health.lock.Unlock()
+
+	if health.instances[subsystem] == nil {
+		health.instances[subsystem] = map[string]*instanceHealth{}
+	}
+
+	health.instances[subsystem][instance] = &instanceHealth{lastSuccess: time.Now(), maxAge: maxAge}
+}
+
+// RecordSubsystemSuccess records an instance of a subsystem just succeeded, it's ignored if it's not registered.
+func RecordSubsystemSuccess(ms MetricServer, subsystem, instance string) {
+	health := getHealth(ms)
+	if health == nil {
+		return
+	}
+
+	health.lock.Lock()
+	defer health.lock.Unlock()
+
+	if instanceHealth, ok := health.instances[subsystem][instance]; ok {
+		instanceHealth.lastSuccess = time.Now()
+	}
+}
+
+// RecordRepoDBUpdate records the result of a repodb update, for the error ratio of repodb.
+func RecordRepoDBUpdate(ms MetricServer, err error) {
+	health := getHealth(ms)
+	if health == nil {
+		return
+	}
+
+	health.lock.Lock()
+	defer health.lock.Unlock()
+
+	health.repoDBUpdates++
+
+	if err != nil {
+		health.repoDBErrors++
+	}
+}
+
+/*
+CheckHealth updates the health metrics and returns the health of the subsystems, sorted by subsystem and instance:
+  - the registered instances are unhealthy once they didn't succeed for longer than their max age
+  - repodb is unhealthy if more than MaxRepoDBErrorRatio of its updates since the previous check failed
+  - the scheduler is unhealthy if one of its queues is full, tasks can't be added to it anymore
+
+A subsystem is unhealthy if one of its instances is, zot_subsystem_healthy is 0 for it.
+*/
+func CheckHealth(ms MetricServer, sch *scheduler.Scheduler) []SubsystemHealth {
+	health := getHealth(ms)
+	if health == nil {
+		return []SubsystemHealth{}
+	}
+
+	results := []SubsystemHealth{}
+
+	health.lock.Lock()
+
+	for subsystem, instances := range health.instances {
+		for instance, instanceHealth := range instances {
+			age := time.Since(instanceHealth.lastSuccess)
+
+			SetSubsystemLastSuccessAge(ms, subsystem, instance, age)
+
+			results = append(results, SubsystemHealth{
+				Subsystem:      subsystem,
+				Instance:       instance,
+				Healthy:        age <= instanceHealth.maxAge,
+				LastSuccessAge: age,
+			})
+		}
+	}
+
+	var errorRatio float64
+
+	if health.repoDBUpdates > 0 {
+		errorRatio = float64(health.repoDBErrors) / float64(health.repoDBUpdates)
+	}
+
+	health.repoDBUpdates, health.repoDBErrors = 0, 0
+
+	health.lock.Unlock()
+
+	SetRepoDBErrorRatio(ms, errorRatio)
+
+	results = append(results, SubsystemHealth{Subsystem: SubsystemRepoDB, Healthy: errorRatio <= MaxRepoDBErrorRatio})
+
+	if sch != nil {
+		schedulerHealthy := true
+
+		for priority, depth := range sch.GetQueueDepths() {
+			SetSchedulerQueueDepth(ms, priority, depth)
+
+			if depth >= sch.GetQueueCapacity() {
+				schedulerHealthy = false
+			}
+		}
+
+		results = append(results, SubsystemHealth{Subsystem: SubsystemScheduler, Healthy: schedulerHealthy})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Subsystem != results[j].Subsystem {
+			return results[i].Subsystem < results[j].Subsystem
+		}
+
+		return results[i].Instance < results[j].Instance
+	})
+
+	healthy := map[string]bool{}
+
+	for _, result := range results {
+		subsystemHealthy, ok := healthy[result.Subsystem]
+		healthy[result.Subsystem] = result.Healthy && (!ok || subsystemHealthy)
+	}
+
+	for subsystem, subsystemHealthy := range healthy {
+		SetSubsystemHealthy(ms, subsystem, subsystemHealthy)
+	}
+
+	return results
+}
+
+// RunHealthChecksPeriodically updates the health metrics every interval.
+func RunHealthChecksPeriodically(ms MetricServer, interval time.Duration, sch *scheduler.Scheduler) {
+	sch.SubmitGenerator(&healthTaskGenerator{metrics: ms, sch: sch}, interval, scheduler.LowPriority)
+}
+
+type healthTaskGenerator struct {
+	metrics MetricServer
+	sch     *scheduler.Scheduler
+	done    bool
+}
+
+func (gen *healthTaskGenerator) Next() (scheduler.Task, error) {
+	gen.done = true
+
+	return &healthTask{metrics: gen.metrics, sch: gen.sch}, nil
+}
+
+func (gen *healthTaskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *healthTaskGenerator) Reset() {
+	gen.done = false
+}
+
+type healthTask struct {
+	metrics MetricServer
+	sch     *scheduler.Scheduler
+}
+
+func (task *healthTask) DoWork() error {
+	CheckHealth(task.metrics, task.sch)
+
+	return nil
+}
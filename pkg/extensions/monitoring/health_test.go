@@ -0,0 +1,63 @@
+package monitoring_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/scheduler"
+)
+
+var errRepoDB = errors.New("repodb error")
+
+func TestHealth(t *testing.T) {
+	Convey("Check the health of the subsystems", t, func() {
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+		sch := scheduler.NewScheduler(config.New(), log)
+
+		monitoring.RegisterSubsystem(metrics, monitoring.SubsystemSync, "https://registry-1", time.Hour)
+		monitoring.RegisterSubsystem(metrics, monitoring.SubsystemSync, "https://registry-2", time.Millisecond)
+		monitoring.RegisterSubsystem(metrics, monitoring.SubsystemCVEDB, "trivyDB", time.Hour)
+
+		// not registered, ignored
+		monitoring.RecordSubsystemSuccess(metrics, monitoring.SubsystemSync, "https://registry-3")
+
+		monitoring.RecordRepoDBUpdate(metrics, nil)
+		monitoring.RecordRepoDBUpdate(metrics, errRepoDB)
+
+		time.Sleep(10 * time.Millisecond)
+
+		results := monitoring.CheckHealth(metrics, sch)
+		So(results, ShouldHaveLength, 5)
+
+		So(results[0].Subsystem, ShouldEqual, monitoring.SubsystemCVEDB)
+		So(results[0].Healthy, ShouldBeTrue)
+		So(results[1], ShouldResemble, monitoring.SubsystemHealth{Subsystem: monitoring.SubsystemRepoDB})
+		So(results[2], ShouldResemble, monitoring.SubsystemHealth{Subsystem: monitoring.SubsystemScheduler,
+			Healthy: true})
+		So(results[3].Instance, ShouldEqual, "https://registry-1")
+		So(results[3].Healthy, ShouldBeTrue)
+		So(results[4].Instance, ShouldEqual, "https://registry-2")
+		So(results[4].Healthy, ShouldBeFalse)
+		So(results[4].LastSuccessAge, ShouldBeGreaterThanOrEqualTo, 10*time.Millisecond)
+
+		Convey("Subsystems recover once they succeed", func() {
+			monitoring.RecordSubsystemSuccess(metrics, monitoring.SubsystemSync, "https://registry-2")
+			monitoring.RegisterSubsystem(metrics, monitoring.SubsystemSync, "https://registry-2", time.Hour)
+
+			// the repodb updates are counted again after each check
+			results := monitoring.CheckHealth(metrics, nil)
+			So(results, ShouldHaveLength, 4)
+
+			for _, result := range results {
+				So(result.Healthy, ShouldBeTrue)
+			}
+		})
+	})
+}
@@ -18,17 +18,43 @@ import (
 const (
 	metricsNamespace = "zot"
 	// Counters.
-	httpConnRequests = metricsNamespace + ".http.requests"
-	repoDownloads    = metricsNamespace + ".repo.downloads"
-	repoUploads      = metricsNamespace + ".repo.uploads"
+	httpConnRequests  = metricsNamespace + ".http.requests"
+	httpPanics        = metricsNamespace + ".http.panics"
+	httpShedRequests  = metricsNamespace + ".http.requests.shed"
+	breakerRejections = metricsNamespace + ".circuit.breaker.rejections"
+	tenantRequests    = metricsNamespace + ".tenant.http.requests"
+	repoDownloads     = metricsNamespace + ".repo.downloads"
+	repoUploads       = metricsNamespace + ".repo.uploads"
+	syncConflicts     = metricsNamespace + ".sync.conflicts"
+	cacheErrors       = metricsNamespace + ".cache.errors"
+	blobVerifications = metricsNamespace + ".storage.blob.verifications"
+	leasesExpired     = metricsNamespace + ".storage.leases.expired"
+	gcDeferred        = metricsNamespace + ".storage.gc.deferred"
+	scrubImages       = metricsNamespace + ".scrub.images"
 	// Gauge.
-	repoStorageBytes = metricsNamespace + ".repo.storage.bytes"
-	serverInfo       = metricsNamespace + ".info"
+	repoStorageBytes     = metricsNamespace + ".repo.storage.bytes"
+	serverInfo           = metricsNamespace + ".info"
+	cacheDBSizeBytes     = metricsNamespace + ".cache.db.size.bytes"
+	cacheBucketEntries   = metricsNamespace + ".cache.bucket.entries"
+	cacheIntegrityIssues = metricsNamespace + ".cache.integrity.issues"
+	cveScans             = metricsNamespace + ".cve.scans"
+	storageLeases        = metricsNamespace + ".storage.leases"
+	storageHealthScore   = metricsNamespace + ".storage.health.score"
+	storageQuotaUsage    = metricsNamespace + ".storage.quota.usage.bytes"
+	scrubAffectedImages  = metricsNamespace + ".scrub.affected.images"
+	storageQuotaTotal    = metricsNamespace + ".storage.quota.total.usage.bytes"
+	breakerState         = metricsNamespace + ".circuit.breaker.state"
+	subsystemSuccessAge  = metricsNamespace + ".subsystem.last.success.age.seconds"
+	subsystemHealthy     = metricsNamespace + ".subsystem.healthy"
+	repoDBErrorRatio     = metricsNamespace + ".repodb.error.ratio"
+	schedulerQueueDepth  = metricsNamespace + ".scheduler.queue.depth"
 	// Summary.
 	httpRepoLatencySeconds = metricsNamespace + ".http.repo.latency.seconds"
 	// Histogram.
-	httpMethodLatencySeconds  = metricsNamespace + ".http.method.latency.seconds"
-	storageLockLatencySeconds = metricsNamespace + ".storage.lock.latency.seconds"
+	httpMethodLatencySeconds   = metricsNamespace + ".http.method.latency.seconds"
+	storageLockLatencySeconds  = metricsNamespace + ".storage.lock.latency.seconds"
+	storageFsyncLatencySeconds = metricsNamespace + ".storage.fsync.latency.seconds"
+	cacheLatencySeconds        = metricsNamespace + ".cache.operation.latency.seconds"
 
 	metricsScrapeTimeout       = 2 * time.Minute
 	metricsScrapeCheckInterval = 30 * time.Second
@@ -41,6 +67,7 @@ type metricServer struct {
 	cache      *MetricsInfo
 	cacheChan  chan *MetricsInfo
 	bucketsF2S map[float64]string // float64 to string conversion of buckets label
+	health     *health
 	log        log.Logger
 }
 
@@ -198,6 +225,7 @@ func NewMetricsServer(enabled bool, log log.Logger) MetricServer {
 		cacheChan:  make(chan *MetricsInfo),
 		cache:      mi,
 		bucketsF2S: bucketsFloat2String,
+		health:     newHealth(),
 		log:        log,
 	}
 
@@ -209,16 +237,40 @@ func NewMetricsServer(enabled bool, log log.Logger) MetricServer {
 // contains a map with key=CounterName and value=CounterLabels.
 func GetCounters() map[string][]string {
 	return map[string][]string{
-		httpConnRequests: {"method", "code"},
-		repoDownloads:    {"repo"},
-		repoUploads:      {"repo"},
+		httpConnRequests:  {"method", "code"},
+		httpPanics:        {"method", "route"},
+		httpShedRequests:  {"method"},
+		breakerRejections: {"breaker"},
+		tenantRequests:    {"tenant", "method", "code"},
+		repoDownloads:     {"repo"},
+		repoUploads:       {"repo"},
+		syncConflicts:     {"registry", "repo"},
+		cacheErrors:       {"driver", "operation"},
+		blobVerifications: {"storageName", "result"},
+		leasesExpired:     {},
+		gcDeferred:        {"storageName"},
+		scrubImages:       {"status"},
 	}
 }
 
 func GetGauges() map[string][]string {
 	return map[string][]string{
-		repoStorageBytes: {"repo"},
-		serverInfo:       {"commit", "binaryType", "goVersion", "version"},
+		repoStorageBytes:     {"repo"},
+		serverInfo:           {"commit", "binaryType", "goVersion", "version"},
+		cacheDBSizeBytes:     {"storageName"},
+		cacheBucketEntries:   {"storageName", "bucket"},
+		cacheIntegrityIssues: {"storageName"},
+		cveScans:             {"state"},
+		storageLeases:        {},
+		storageHealthScore:   {},
+		storageQuotaUsage:    {"repo"},
+		scrubAffectedImages:  {"repo"},
+		storageQuotaTotal:    {},
+		breakerState:         {"breaker"},
+		subsystemSuccessAge:  {"subsystem", "instance"},
+		subsystemHealthy:     {"subsystem"},
+		repoDBErrorRatio:     {},
+		schedulerQueueDepth:  {"priority"},
 	}
 }
 
@@ -230,8 +282,10 @@ func GetSummaries() map[string][]string {
 
 func GetHistograms() map[string][]string {
 	return map[string][]string{
-		httpMethodLatencySeconds:  {"method"},
-		storageLockLatencySeconds: {"storageName", "lockType"},
+		httpMethodLatencySeconds:   {"method"},
+		storageLockLatencySeconds:  {"storageName", "lockType"},
+		storageFsyncLatencySeconds: {"storageName", "fileType"},
+		cacheLatencySeconds:        {"driver", "operation"},
 	}
 }
 
@@ -472,6 +526,52 @@ func IncDownloadCounter(ms MetricServer, repo string) {
 	ms.SendMetric(dCounter)
 }
 
+func IncHTTPPanics(ms MetricServer, method, route string) {
+	panics := CounterValue{
+		Name:        httpPanics,
+		LabelNames:  []string{"method", "route"},
+		LabelValues: []string{method, route},
+	}
+	ms.SendMetric(panics)
+}
+
+func IncHTTPShedRequests(ms MetricServer, method string) {
+	shed := CounterValue{
+		Name:        httpShedRequests,
+		LabelNames:  []string{"method"},
+		LabelValues: []string{method},
+	}
+	ms.SendMetric(shed)
+}
+
+func IncTenantHTTPRequests(ms MetricServer, tenant, method, code string) {
+	requests := CounterValue{
+		Name:        tenantRequests,
+		LabelNames:  []string{"tenant", "method", "code"},
+		LabelValues: []string{tenant, method, code},
+	}
+	ms.SendMetric(requests)
+}
+
+func IncCircuitBreakerRejections(ms MetricServer, name string) {
+	rejections := CounterValue{
+		Name:        breakerRejections,
+		LabelNames:  []string{"breaker"},
+		LabelValues: []string{name},
+	}
+	ms.SendMetric(rejections)
+}
+
+func SetCircuitBreakerState(ms MetricServer, name string, state int) {
+	breaker := GaugeValue{
+		Name:        breakerState,
+		Value:       float64(state),
+		LabelNames:  []string{"breaker"},
+		LabelValues: []string{name},
+	}
+	ms.ForceSendMetric(breaker)
+}
+
 func IncUploadCounter(ms MetricServer, repo string) {
 	uCounter := CounterValue{
 		Name:        repoUploads,
@@ -481,6 +581,15 @@ func IncUploadCounter(ms MetricServer, repo string) {
 	ms.SendMetric(uCounter)
 }
 
+func IncSyncConflictCounter(ms MetricServer, registry, repo string) {
+	cCounter := CounterValue{
+		Name:        syncConflicts,
+		LabelNames:  []string{"registry", "repo"},
+		LabelValues: []string{registry, repo},
+	}
+	ms.SendMetric(cCounter)
+}
+
 func SetStorageUsage(ms MetricServer, rootDir, repo string) {
 	dir := path.Join(rootDir, repo)
 
@@ -519,13 +628,215 @@ func ObserveStorageLockLatency(ms MetricServer, latency time.Duration, storageNa
 	ms.SendMetric(h)
 }
 
+func IncBlobVerifications(ms MetricServer, storageName string, ok bool) {
+	verifications := CounterValue{
+		Name:        blobVerifications,
+		LabelNames:  []string{"storageName", "result"},
+		LabelValues: []string{storageName, getVerificationResult(ok)},
+	}
+	ms.SendMetric(verifications)
+}
+
+func SetStorageLeases(ms MetricServer, count int) {
+	leases := GaugeValue{
+		Name:        storageLeases,
+		Value:       float64(count),
+		LabelNames:  []string{},
+		LabelValues: []string{},
+	}
+	ms.ForceSendMetric(leases)
+}
+
+func SetScrubAffectedImages(ms MetricServer, repo string, count int) {
+	affected := GaugeValue{
+		Name:        scrubAffectedImages,
+		Value:       float64(count),
+		LabelNames:  []string{"repo"},
+		LabelValues: []string{repo},
+	}
+	ms.ForceSendMetric(affected)
+}
+
+func IncScrubbedImages(ms MetricServer, status string) {
+	scrubbed := CounterValue{
+		Name:        scrubImages,
+		LabelNames:  []string{"status"},
+		LabelValues: []string{status},
+	}
+	ms.SendMetric(scrubbed)
+}
+
+func SetStorageQuotaUsage(ms MetricServer, repo string, bytes int64) {
+	usage := GaugeValue{
+		Name:        storageQuotaUsage,
+		Value:       float64(bytes),
+		LabelNames:  []string{"repo"},
+		LabelValues: []string{repo},
+	}
+	ms.ForceSendMetric(usage)
+}
+
+func SetStorageQuotaTotalUsage(ms MetricServer, bytes int64) {
+	usage := GaugeValue{
+		Name:        storageQuotaTotal,
+		Value:       float64(bytes),
+		LabelNames:  []string{},
+		LabelValues: []string{},
+	}
+	ms.ForceSendMetric(usage)
+}
+
+func SetStorageHealthScore(ms MetricServer, score float64) {
+	health := GaugeValue{
+		Name:        storageHealthScore,
+		Value:       score,
+		LabelNames:  []string{},
+		LabelValues: []string{},
+	}
+	ms.ForceSendMetric(health)
+}
+
+func IncStorageLeasesExpired(ms MetricServer) {
+	expired := CounterValue{
+		Name:        leasesExpired,
+		LabelNames:  []string{},
+		LabelValues: []string{},
+	}
+	ms.SendMetric(expired)
+}
+
+func IncGCDeferred(ms MetricServer, storageName string) {
+	deferred := CounterValue{
+		Name:        gcDeferred,
+		LabelNames:  []string{"storageName"},
+		LabelValues: []string{storageName},
+	}
+	ms.SendMetric(deferred)
+}
+
+func ObserveStorageFsyncLatency(ms MetricServer, latency time.Duration, storageName, fileType string) {
+	h := HistogramValue{
+		Name:        storageFsyncLatencySeconds,
+		Sum:         latency.Seconds(), // convenient temporary store for Histogram latency value
+		LabelNames:  []string{"storageName", "fileType"},
+		LabelValues: []string{storageName, fileType},
+	}
+	ms.SendMetric(h)
+}
+
+func ObserveCacheLatency(ms MetricServer, latency time.Duration, driver, operation string) {
+	h := HistogramValue{
+		Name:        cacheLatencySeconds,
+		Sum:         latency.Seconds(), // convenient temporary store for Histogram latency value
+		LabelNames:  []string{"driver", "operation"},
+		LabelValues: []string{driver, operation},
+	}
+	ms.SendMetric(h)
+}
+
+func IncCacheErrors(ms MetricServer, driver, operation string) {
+	eCounter := CounterValue{
+		Name:        cacheErrors,
+		LabelNames:  []string{"driver", "operation"},
+		LabelValues: []string{driver, operation},
+	}
+	ms.SendMetric(eCounter)
+}
+
+func SetCacheDBSize(ms MetricServer, storageName string, size int64) {
+	dbSize := GaugeValue{
+		Name:        cacheDBSizeBytes,
+		Value:       float64(size),
+		LabelNames:  []string{"storageName"},
+		LabelValues: []string{storageName},
+	}
+	ms.ForceSendMetric(dbSize)
+}
+
+func SetCacheBucketEntries(ms MetricServer, storageName, bucket string, count int) {
+	entries := GaugeValue{
+		Name:        cacheBucketEntries,
+		Value:       float64(count),
+		LabelNames:  []string{"storageName", "bucket"},
+		LabelValues: []string{storageName, bucket},
+	}
+	ms.ForceSendMetric(entries)
+}
+
+func SetCacheIntegrityIssues(ms MetricServer, storageName string, issues int) {
+	integrityIssues := GaugeValue{
+		Name:        cacheIntegrityIssues,
+		Value:       float64(issues),
+		LabelNames:  []string{"storageName"},
+		LabelValues: []string{storageName},
+	}
+	ms.ForceSendMetric(integrityIssues)
+}
+
+func SetCVEScans(ms MetricServer, queued, running int) {
+	for state, count := range map[string]int{"queued": queued, "running": running} {
+		scans := GaugeValue{
+			Name:        cveScans,
+			Value:       float64(count),
+			LabelNames:  []string{"state"},
+			LabelValues: []string{state},
+		}
+		ms.ForceSendMetric(scans)
+	}
+}
+
+func SetSubsystemLastSuccessAge(ms MetricServer, subsystem, instance string, age time.Duration) {
+	successAge := GaugeValue{
+		Name:        subsystemSuccessAge,
+		Value:       age.Seconds(),
+		LabelNames:  []string{"subsystem", "instance"},
+		LabelValues: []string{subsystem, instance},
+	}
+	ms.ForceSendMetric(successAge)
+}
+
+func SetSubsystemHealthy(ms MetricServer, subsystem string, healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+
+	subsystemHealth := GaugeValue{
+		Name:        subsystemHealthy,
+		Value:       value,
+		LabelNames:  []string{"subsystem"},
+		LabelValues: []string{subsystem},
+	}
+	ms.ForceSendMetric(subsystemHealth)
+}
+
+func SetRepoDBErrorRatio(ms MetricServer, ratio float64) {
+	errorRatio := GaugeValue{
+		Name:        repoDBErrorRatio,
+		Value:       ratio,
+		LabelNames:  []string{},
+		LabelValues: []string{},
+	}
+	ms.ForceSendMetric(errorRatio)
+}
+
+func SetSchedulerQueueDepth(ms MetricServer, priority string, depth int) {
+	queueDepth := GaugeValue{
+		Name:        schedulerQueueDepth,
+		Value:       float64(depth),
+		LabelNames:  []string{"priority"},
+		LabelValues: []string{priority},
+	}
+	ms.ForceSendMetric(queueDepth)
+}
+
 func GetMaxIdleScrapeInterval() time.Duration {
 	return metricsScrapeTimeout + metricsScrapeCheckInterval
 }
 
 func GetBuckets(metricName string) []float64 {
 	switch metricName {
-	case storageLockLatencySeconds:
+	case storageLockLatencySeconds, storageFsyncLatencySeconds, cacheLatencySeconds:
 		return GetStorageLatencyBuckets()
 	default:
 		return GetDefaultBuckets()
@@ -60,6 +60,11 @@ func TestExtensionMetrics(t *testing.T) {
 
 		monitoring.ObserveStorageLockLatency(ctlr.Metrics, time.Millisecond, rootDir, "RWLock")
 
+		monitoring.ObserveCacheLatency(ctlr.Metrics, time.Millisecond, "boltdb", "GetBlob")
+		monitoring.IncCacheErrors(ctlr.Metrics, "boltdb", "PutBlob")
+		monitoring.SetCacheDBSize(ctlr.Metrics, rootDir, 32768)
+		monitoring.SetCacheBucketEntries(ctlr.Metrics, rootDir, "blobs", 2)
+
 		resp, err := resty.R().Get(baseURL + "/metrics")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
@@ -72,6 +77,10 @@ func TestExtensionMetrics(t *testing.T) {
 		So(respStr, ShouldContainSubstring, "zot_storage_lock_latency_seconds_bucket")
 		So(respStr, ShouldContainSubstring, "zot_storage_lock_latency_seconds_sum")
 		So(respStr, ShouldContainSubstring, "zot_storage_lock_latency_seconds_bucket")
+		So(respStr, ShouldContainSubstring, "zot_cache_operation_latency_seconds_bucket")
+		So(respStr, ShouldContainSubstring, "zot_cache_errors_total{driver=\"boltdb\",operation=\"PutBlob\"} 1")
+		So(respStr, ShouldContainSubstring, "zot_cache_db_size_bytes")
+		So(respStr, ShouldContainSubstring, "zot_cache_bucket_entries")
 	})
 	Convey("Make a new controller with disabled metrics extension", t, func() {
 		port := test.GetFreePort()
@@ -0,0 +1,427 @@
+package plugins
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage/constants"
+)
+
+const (
+	// EventLogName is the name of the db holding the registry events, in the root directory of the storage.
+	EventLogName = "plugin-events"
+
+	// DefaultEventRetention is the number of events kept for each repo, older events can't be replayed.
+	DefaultEventRetention = 1000
+
+	// DefaultEventMaxAge is how long events are kept.
+	DefaultEventMaxAge = 7 * 24 * time.Hour
+
+	// EventLogCompactionInterval is how often the events older than the max age are dropped.
+	EventLogCompactionInterval = time.Hour
+
+	sequenceKeySize = 8
+)
+
+var (
+	// all the events, by id.
+	eventsBucket = []byte("events") //nolint:gochecknoglobals
+	// a bucket for each repo, with the ids of its events by sequence.
+	reposBucket = []byte("repos") //nolint:gochecknoglobals
+)
+
+/*
+EventLog numbers the registry events and keeps them for a while, so consumers can order the events they
+receive and catch up on the ones they missed, e.g. after a downtime. Each event has an id, increasing with
+every event of the registry, and a sequence, increasing with every event of its repo. Both are persisted in
+a boltdb file, they keep increasing across restarts.
+*/
+type EventLog struct {
+	// events kept for each repo, the oldest ones are dropped first
+	MaxEventsPerRepo uint64
+	// events older than this are dropped when the log is compacted
+	MaxAge time.Duration
+
+	db  *bbolt.DB
+	log log.Logger
+}
+
+// NewEventLog opens the event log stored in rootDir, events logged before a restart are kept.
+func NewEventLog(rootDir string, log log.Logger) (*EventLog, error) {
+	if err := os.MkdirAll(rootDir, constants.DefaultDirPerms); err != nil {
+		log.Error().Err(err).Str("directory", rootDir).Msg("plugins: unable to create directory for event log")
+
+		return nil, err
+	}
+
+	dbPath := path.Join(rootDir, EventLogName+constants.DBExtensionName)
+
+	db, err := bbolt.Open(dbPath, 0o600, &bbolt.Options{ //nolint:gomnd
+		Timeout:      constants.DBCacheLockCheckTimeout,
+		FreelistType: bbolt.FreelistArrayType,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("dbPath", dbPath).Msg("plugins: unable to open event log db")
+
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(eventsBucket); err != nil {
+			return err
+		}
+
+		_, err := tx.CreateBucketIfNotExists(reposBucket)
+
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Str("dbPath", dbPath).Msg("plugins: unable to create event log buckets")
+
+		_ = db.Close()
+
+		return nil, err
+	}
+
+	return &EventLog{
+		MaxEventsPerRepo: DefaultEventRetention,
+		MaxAge:           DefaultEventMaxAge,
+		db:               db,
+		log:              log,
+	}, nil
+}
+
+// Append gives the event the next id and the next sequence of its repo and logs it, the oldest event of the
+// repo is dropped once there are more than MaxEventsPerRepo. The event is returned without an id and a sequence
+// if it can't be logged.
+func (eventLog *EventLog) Append(event Event) (Event, error) {
+	event.ID, event.Sequence = 0, 0
+
+	err := eventLog.db.Update(func(tx *bbolt.Tx) error {
+		events := tx.Bucket(eventsBucket)
+
+		repoEvents, err := tx.Bucket(reposBucket).CreateBucketIfNotExists([]byte(event.Repo))
+		if err != nil {
+			return err
+		}
+
+		if event.ID, err = events.NextSequence(); err != nil {
+			return err
+		}
+
+		if event.Sequence, err = repoEvents.NextSequence(); err != nil {
+			return err
+		}
+
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		if err := events.Put(sequenceKey(event.ID), value); err != nil {
+			return err
+		}
+
+		if err := repoEvents.Put(sequenceKey(event.Sequence), sequenceKey(event.ID)); err != nil {
+			return err
+		}
+
+		if eventLog.MaxEventsPerRepo > 0 && event.Sequence > eventLog.MaxEventsPerRepo {
+			oldestKey := sequenceKey(event.Sequence - eventLog.MaxEventsPerRepo)
+
+			if oldestID := repoEvents.Get(oldestKey); oldestID != nil {
+				if err := events.Delete(oldestID); err != nil {
+					return err
+				}
+			}
+
+			return repoEvents.Delete(oldestKey)
+		}
+
+		return nil
+	})
+	if err != nil {
+		eventLog.log.Error().Err(err).Str("event", event.Type).Str("repository", event.Repo).
+			Str("reference", event.Reference).Msg("plugins: unable to log event")
+
+		event.ID, event.Sequence = 0, 0
+
+		return event, err
+	}
+
+	return event, nil
+}
+
+/*
+Since returns at most limit events of a repo, oldest first, starting with the one with the given sequence.
+It also returns the sequence of the oldest event still logged for the repo and the one of the latest,
+a consumer asking for events older than the oldest one missed some of them. Both are 0 if no event was
+logged for the repo.
+*/
+func (eventLog *EventLog) Since(repo string, from uint64, limit int) ([]Event, uint64, uint64, error) {
+	var (
+		events         = []Event{}
+		oldest, latest uint64
+	)
+
+	err := eventLog.db.View(func(tx *bbolt.Tx) error {
+		repoEvents := tx.Bucket(reposBucket).Bucket([]byte(repo))
+		if repoEvents == nil {
+			return nil
+		}
+
+		latest = repoEvents.Sequence()
+
+		cursor := repoEvents.Cursor()
+
+		if key, _ := cursor.First(); key != nil {
+			oldest = binary.BigEndian.Uint64(key)
+		}
+
+		for key, id := cursor.Seek(sequenceKey(from)); key != nil; key, id = cursor.Next() {
+			if limit > 0 && len(events) >= limit {
+				break
+			}
+
+			var event Event
+
+			if err := json.Unmarshal(tx.Bucket(eventsBucket).Get(id), &event); err != nil {
+				return err
+			}
+
+			events = append(events, event)
+		}
+
+		return nil
+	})
+
+	return events, oldest, latest, err
+}
+
+/*
+Before returns at most limit events of a repo for which match returns true, newest first, starting with the one
+with the given sequence, or with the latest one if it is 0. It also returns the sequence to list the older events
+from, 0 once the oldest event is listed.
+*/
+func (eventLog *EventLog) Before(repo string, from uint64, limit int, match func(Event) (bool, error),
+) ([]Event, uint64, error) {
+	var (
+		events = []Event{}
+		next   uint64
+	)
+
+	err := eventLog.db.View(func(tx *bbolt.Tx) error {
+		repoEvents := tx.Bucket(reposBucket).Bucket([]byte(repo))
+		if repoEvents == nil {
+			return nil
+		}
+
+		cursor := repoEvents.Cursor()
+
+		key, id := cursor.Last()
+
+		if from > 0 {
+			// the first event at or before from
+			if key, id = cursor.Seek(sequenceKey(from)); key == nil {
+				key, id = cursor.Last()
+			} else if binary.BigEndian.Uint64(key) > from {
+				key, id = cursor.Prev()
+			}
+		}
+
+		for ; key != nil; key, id = cursor.Prev() {
+			if limit > 0 && len(events) >= limit {
+				next = binary.BigEndian.Uint64(key)
+
+				break
+			}
+
+			var event Event
+
+			if err := json.Unmarshal(tx.Bucket(eventsBucket).Get(id), &event); err != nil {
+				return err
+			}
+
+			ok, err := match(event)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				events = append(events, event)
+			}
+		}
+
+		return nil
+	})
+
+	return events, next, err
+}
+
+/*
+List returns at most limit events of the registry for which match returns true, oldest first, starting with
+the one with the given id. It also returns the id to list the next events from, the events following the
+returned ones are not listed yet, and the id of the latest event.
+*/
+func (eventLog *EventLog) List(from uint64, limit int, match func(Event) (bool, error),
+) ([]Event, uint64, uint64, error) {
+	var (
+		events       = []Event{}
+		next, latest uint64
+	)
+
+	err := eventLog.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(eventsBucket)
+
+		latest = bucket.Sequence()
+
+		// the next events are the ones logged after the latest one, unless the limit is reached
+		next = latest + 1
+		if from > next {
+			next = from
+		}
+
+		cursor := bucket.Cursor()
+
+		for key, value := cursor.Seek(sequenceKey(from)); key != nil; key, value = cursor.Next() {
+			if limit > 0 && len(events) >= limit {
+				next = binary.BigEndian.Uint64(key)
+
+				break
+			}
+
+			var event Event
+
+			if err := json.Unmarshal(value, &event); err != nil {
+				return err
+			}
+
+			ok, err := match(event)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				events = append(events, event)
+			}
+		}
+
+		return nil
+	})
+
+	return events, next, latest, err
+}
+
+// Compact drops the events older than MaxAge, the space they used is reused by the next events.
+func (eventLog *EventLog) Compact() error {
+	if eventLog.MaxAge <= 0 {
+		return nil
+	}
+
+	cutoff := time.Now().Add(-eventLog.MaxAge)
+
+	var dropped int
+
+	err := eventLog.db.Update(func(tx *bbolt.Tx) error {
+		events := tx.Bucket(eventsBucket)
+		repos := tx.Bucket(reposBucket)
+
+		// events are logged in order, so the old ones are the first ones
+		expired := []Event{}
+		cursor := events.Cursor()
+
+		for key, value := cursor.First(); key != nil; key, value = cursor.Next() {
+			var event Event
+
+			if err := json.Unmarshal(value, &event); err != nil {
+				return err
+			}
+
+			if !event.Timestamp.Before(cutoff) {
+				break
+			}
+
+			expired = append(expired, event)
+		}
+
+		for _, event := range expired {
+			if err := events.Delete(sequenceKey(event.ID)); err != nil {
+				return err
+			}
+
+			// the bucket of the repo is kept even if empty, it holds the sequence of the repo
+			if repoEvents := repos.Bucket([]byte(event.Repo)); repoEvents != nil {
+				if err := repoEvents.Delete(sequenceKey(event.Sequence)); err != nil {
+					return err
+				}
+			}
+		}
+
+		dropped = len(expired)
+
+		return nil
+	})
+	if err != nil {
+		eventLog.log.Error().Err(err).Msg("plugins: unable to compact event log")
+
+		return err
+	}
+
+	if dropped > 0 {
+		eventLog.log.Info().Int("events", dropped).Dur("maxAge", eventLog.MaxAge).
+			Msg("plugins: dropped expired events from the event log")
+	}
+
+	return nil
+}
+
+// RunCompactionPeriodically drops the expired events every interval.
+func (eventLog *EventLog) RunCompactionPeriodically(interval time.Duration, sch *scheduler.Scheduler) {
+	sch.SubmitGenerator(&compactionTaskGenerator{eventLog: eventLog}, interval, scheduler.LowPriority)
+}
+
+// Close closes the db of the event log.
+func (eventLog *EventLog) Close() error {
+	return eventLog.db.Close()
+}
+
+type compactionTaskGenerator struct {
+	eventLog *EventLog
+	done     bool
+}
+
+func (gen *compactionTaskGenerator) Next() (scheduler.Task, error) {
+	gen.done = true
+
+	return &compactionTask{eventLog: gen.eventLog}, nil
+}
+
+func (gen *compactionTaskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *compactionTaskGenerator) Reset() {
+	gen.done = false
+}
+
+type compactionTask struct {
+	eventLog *EventLog
+}
+
+func (task *compactionTask) DoWork() error {
+	return task.eventLog.Compact()
+}
+
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, sequenceKeySize)
+	binary.BigEndian.PutUint64(key, seq)
+
+	return key
+}
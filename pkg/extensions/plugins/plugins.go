@@ -0,0 +1,425 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	goplugin "github.com/hashicorp/go-plugin"
+
+	zerr "zotregistry.io/zot/errors"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/log"
+)
+
+const (
+	EventManifestPushed  = "manifestPushed"
+	EventManifestDeleted = "manifestDeleted"
+	// notified after the push of a signature, along with its manifestPushed event
+	EventSignatureAdded = "signatureAdded"
+	// notified when the vulnerability scan of an image completes
+	EventScanCompleted = "scanCompleted"
+)
+
+// details of the signatureAdded and scanCompleted events.
+const (
+	EventDetailSignatureType   = "signatureType"
+	EventDetailSignedDigest    = "signedDigest"
+	EventDetailVulnerabilities = "vulnerabilities"
+	EventDetailMaxSeverity     = "maxSeverity"
+)
+
+const notifyTimeout = 30 * time.Second
+
+// Plugin is implemented by every third-party extension, it may also implement Notifier and Authorizer
+// to hook into the registry.
+type Plugin interface {
+	// Init is called once at startup with the options of the plugin config.
+	Init(options map[string]interface{}, log log.Logger) error
+}
+
+// Event describes a change of the images of a repo, events of a repo are numbered by increasing Sequence
+// in the order the changes were made. ID numbers the events of all repos, it is only set when events are logged.
+type Event struct {
+	ID        uint64    `json:"id,omitempty"`
+	Sequence  uint64    `json:"sequence"`
+	Type      string    `json:"type"`
+	Repo      string    `json:"repo"`
+	Reference string    `json:"reference"`
+	Digest    string    `json:"digest"`
+	MediaType string    `json:"mediaType"`
+	Username  string    `json:"username,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// e.g. the digest a signature signs, or the number of vulnerabilities found by a scan
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Notifier plugins are notified of image pushes and deletes, signatures and scans, asynchronously so they don't
+// slow down clients.
+// Each notifier receives the events one at a time, in the order of their sequences.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// AuthzRequest describes an action a user wants to do on a repo, see the actions of the access control config.
+type AuthzRequest struct {
+	Username string
+	Groups   []string
+	Action   string
+	Repo     string
+}
+
+type Decision int
+
+const (
+	// Abstain leaves the decision to the access control config and the other plugins.
+	Abstain Decision = iota
+	Allow
+	Deny
+)
+
+// Authorizer plugins are consulted on every request authorized by the access control config.
+type Authorizer interface {
+	Authorize(ctx context.Context, request AuthzRequest) (Decision, error)
+}
+
+type Info struct {
+	Name       string `json:"name"`
+	Path       string `json:"path,omitempty"`
+	Notifier   bool   `json:"notifier"`
+	Authorizer bool   `json:"authorizer"`
+}
+
+type loadedPlugin struct {
+	info   Info
+	plugin Plugin
+	queue  *notifierQueue
+	// the process of the plugin, nil for the plugins which are compiled in
+	client *goplugin.Client
+}
+
+// notifierQueue delivers the events of a notifier in order, a slow notifier doesn't delay the others.
+type notifierQueue struct {
+	name     string
+	notifier Notifier
+	log      log.Logger
+
+	mu      sync.Mutex
+	pending []Event
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+func newNotifierQueue(name string, notifier Notifier, log log.Logger) *notifierQueue {
+	queue := &notifierQueue{
+		name:     name,
+		notifier: notifier,
+		log:      log,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	go queue.run()
+
+	return queue
+}
+
+func (queue *notifierQueue) push(event Event) {
+	queue.mu.Lock()
+	queue.pending = append(queue.pending, event)
+	queue.mu.Unlock()
+
+	select {
+	case queue.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (queue *notifierQueue) run() {
+	for {
+		select {
+		case <-queue.done:
+			return
+		case <-queue.wake:
+		}
+
+		for {
+			queue.mu.Lock()
+			if len(queue.pending) == 0 {
+				queue.mu.Unlock()
+
+				break
+			}
+
+			event := queue.pending[0]
+			queue.pending = queue.pending[1:]
+			queue.mu.Unlock()
+
+			queue.notify(event)
+		}
+	}
+}
+
+func (queue *notifierQueue) notify(event Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	if err := queue.notifier.Notify(ctx, event); err != nil {
+		queue.log.Error().Err(err).Str("plugin", queue.name).Str("event", event.Type).Str("repo", event.Repo).
+			Uint64("sequence", event.Sequence).Msg("plugins: unable to notify plugin")
+	}
+}
+
+// Registry holds the plugins loaded at startup, the plugin processes run until the registry is closed.
+type Registry struct {
+	plugins []loadedPlugin
+	log     log.Logger
+
+	// mu orders the events: sequences are given and events queued to the notifiers under it
+	mu        sync.Mutex
+	sequences map[string]uint64
+	events    *EventLog
+	repoLocks sync.Map
+}
+
+// NewRegistry creates an empty registry of plugins.
+func NewRegistry(log log.Logger) *Registry {
+	return &Registry{log: log, sequences: map[string]uint64{}}
+}
+
+// Load starts and initializes the enabled plugins of the config, each plugin executable runs in its own process.
+func Load(configs []extconf.PluginConfig, log log.Logger) (*Registry, error) {
+	registry := NewRegistry(log)
+
+	for _, pluginConfig := range configs {
+		if pluginConfig.Enable != nil && !*pluginConfig.Enable {
+			log.Info().Str("plugin", pluginConfig.Name).Msg("plugins: plugin disabled, skipping")
+
+			continue
+		}
+
+		client, remote, err := start(pluginConfig.Name, pluginConfig.Path, log)
+		if err != nil {
+			log.Error().Err(err).Str("plugin", pluginConfig.Name).Str("path", pluginConfig.Path).
+				Msg("plugins: unable to start plugin")
+
+			_ = registry.Close()
+
+			return nil, err
+		}
+
+		err = registry.register(pluginConfig.Name, pluginConfig.Path, remote, pluginConfig.Options, client)
+		if err != nil {
+			client.Kill()
+
+			_ = registry.Close()
+
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// Register initializes a plugin which is compiled in instead of being run from an executable.
+func (registry *Registry) Register(name string, extPlugin Plugin, options map[string]interface{}) error {
+	return registry.register(name, "", extPlugin, options, nil)
+}
+
+// List returns the loaded plugins sorted by name.
+func (registry *Registry) List() []Info {
+	infos := []Info{}
+
+	if registry == nil {
+		return infos
+	}
+
+	for _, loaded := range registry.plugins {
+		infos = append(infos, loaded.info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos
+}
+
+// SetEventLog makes the registry number the events with the sequences of the event log and keep them
+// for replay, without it sequences are only kept in memory and restart from 1 with the registry.
+func (registry *Registry) SetEventLog(eventLog *EventLog) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.events = eventLog
+}
+
+// EventLog returns the event log of the registry, nil if events are not kept.
+func (registry *Registry) EventLog() *EventLog {
+	if registry == nil {
+		return nil
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	return registry.events
+}
+
+/*
+LockRepo serializes the changes of a repo which are notified, it returns the func to unlock it.
+Changes made while holding the lock, and notified before releasing it, get sequences in the order they
+were made, even when clients push the same tag concurrently.
+*/
+func (registry *Registry) LockRepo(repo string) func() {
+	if registry == nil {
+		return func() {}
+	}
+
+	repoLock, _ := registry.repoLocks.LoadOrStore(repo, &sync.Mutex{})
+
+	mutex, _ := repoLock.(*sync.Mutex)
+	mutex.Lock()
+
+	return mutex.Unlock
+}
+
+// Notify gives the event the next sequence of its repo and sends it to the notifier plugins in the background,
+// errors are only logged.
+func (registry *Registry) Notify(event Event) {
+	if registry == nil {
+		return
+	}
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if registry.events != nil {
+		// the event is still sent if it can't be logged, without a sequence
+		event, _ = registry.events.Append(event)
+	} else {
+		registry.sequences[event.Repo]++
+		event.Sequence = registry.sequences[event.Repo]
+	}
+
+	for _, loaded := range registry.plugins {
+		if loaded.queue != nil {
+			loaded.queue.push(event)
+		}
+	}
+}
+
+// Close stops notifying the plugins, stops their processes and closes the event log, events not yet sent
+// are dropped.
+func (registry *Registry) Close() error {
+	if registry == nil {
+		return nil
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	for i := range registry.plugins {
+		if registry.plugins[i].queue != nil {
+			close(registry.plugins[i].queue.done)
+			registry.plugins[i].queue = nil
+		}
+
+		if registry.plugins[i].client != nil {
+			registry.plugins[i].client.Kill()
+		}
+	}
+
+	if registry.events != nil {
+		err := registry.events.Close()
+		registry.events = nil
+
+		return err
+	}
+
+	return nil
+}
+
+// Authorize returns whether the request is allowed, given the decision of the access control config:
+// a single plugin denying the request is enough to deny it, otherwise a plugin can allow a request
+// the config doesn't. A plugin failing is considered as denying the request.
+func (registry *Registry) Authorize(ctx context.Context, request AuthzRequest, allowed bool) bool {
+	if registry == nil {
+		return allowed
+	}
+
+	for _, loaded := range registry.plugins {
+		if !loaded.info.Authorizer {
+			continue
+		}
+
+		authorizer, _ := loaded.plugin.(Authorizer)
+
+		decision, err := authorizer.Authorize(ctx, request)
+		if err != nil {
+			registry.log.Error().Err(err).Str("plugin", loaded.info.Name).Str("repo", request.Repo).
+				Msg("plugins: unable to authorize request, denying it")
+
+			return false
+		}
+
+		switch decision {
+		case Deny:
+			return false
+		case Allow:
+			allowed = true
+		case Abstain:
+		}
+	}
+
+	return allowed
+}
+
+func (registry *Registry) register(name, path string, extPlugin Plugin, options map[string]interface{},
+	client *goplugin.Client,
+) error {
+	for _, loaded := range registry.plugins {
+		if loaded.info.Name == name {
+			return fmt.Errorf("%w: %s", zerr.ErrPluginAlreadyLoaded, name)
+		}
+	}
+
+	if err := extPlugin.Init(options, registry.log); err != nil {
+		registry.log.Error().Err(err).Str("plugin", name).Msg("plugins: unable to initialize plugin")
+
+		return err
+	}
+
+	notifier, isNotifier := extPlugin.(Notifier)
+	_, isAuthorizer := extPlugin.(Authorizer)
+
+	// plugin processes are reached through the same client whatever they implement
+	if remote, ok := extPlugin.(*remotePlugin); ok {
+		isNotifier, isAuthorizer = remote.capabilities.Notifier, remote.capabilities.Authorizer
+	}
+
+	loaded := loadedPlugin{
+		info:   Info{Name: name, Path: path, Notifier: isNotifier, Authorizer: isAuthorizer},
+		plugin: extPlugin,
+		client: client,
+	}
+
+	if isNotifier {
+		loaded.queue = newNotifierQueue(name, notifier, registry.log)
+	}
+
+	registry.mu.Lock()
+	registry.plugins = append(registry.plugins, loaded)
+	registry.mu.Unlock()
+
+	registry.log.Info().Str("plugin", name).Bool("notifier", isNotifier).Bool("authorizer", isAuthorizer).
+		Msg("plugins: plugin loaded")
+
+	return nil
+}
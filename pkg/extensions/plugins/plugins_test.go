@@ -0,0 +1,432 @@
+package plugins_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/extensions/plugins"
+	"zotregistry.io/zot/pkg/log"
+)
+
+var ErrTestError = errors.New("test error")
+
+type notifierPlugin struct {
+	options map[string]interface{}
+	events  chan plugins.Event
+	err     error
+}
+
+func (plugin *notifierPlugin) Init(options map[string]interface{}, log log.Logger) error {
+	plugin.options = options
+
+	return plugin.err
+}
+
+func (plugin *notifierPlugin) Notify(ctx context.Context, event plugins.Event) error {
+	plugin.events <- event
+
+	return ErrTestError
+}
+
+type authorizerPlugin struct {
+	decision plugins.Decision
+	err      error
+}
+
+func (plugin *authorizerPlugin) Init(options map[string]interface{}, log log.Logger) error {
+	return nil
+}
+
+func (plugin *authorizerPlugin) Authorize(ctx context.Context, request plugins.AuthzRequest,
+) (plugins.Decision, error) {
+	return plugin.decision, plugin.err
+}
+
+// repoAuthorizer is served by the test binary when it is started as a plugin, it denies the requests on its repo.
+type repoAuthorizer struct {
+	repo string
+}
+
+func (plugin *repoAuthorizer) Init(options map[string]interface{}, log log.Logger) error {
+	repo, _ := options["repo"].(string)
+	plugin.repo = repo
+
+	log.Info().Str("repo", repo).Msg("initialized")
+
+	return nil
+}
+
+func (plugin *repoAuthorizer) Authorize(ctx context.Context, request plugins.AuthzRequest,
+) (plugins.Decision, error) {
+	if request.Repo == plugin.repo {
+		return plugins.Deny, nil
+	}
+
+	return plugins.Abstain, nil
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv(plugins.Handshake.MagicCookieKey) == plugins.Handshake.MagicCookieValue {
+		plugins.Serve(&repoAuthorizer{})
+
+		return
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestPlugins(t *testing.T) {
+	log := log.NewLogger("debug", "")
+
+	Convey("Register plugins", t, func() {
+		registry := plugins.NewRegistry(log)
+
+		notifier := &notifierPlugin{events: make(chan plugins.Event, 1)}
+		err := registry.Register("notifier", notifier, map[string]interface{}{"url": "https://example.com"})
+		So(err, ShouldBeNil)
+		So(notifier.options["url"], ShouldEqual, "https://example.com")
+
+		err = registry.Register("notifier", &notifierPlugin{}, nil)
+		So(errors.Is(err, zerr.ErrPluginAlreadyLoaded), ShouldBeTrue)
+
+		err = registry.Register("broken", &notifierPlugin{err: ErrTestError}, nil)
+		So(errors.Is(err, ErrTestError), ShouldBeTrue)
+
+		err = registry.Register("authorizer", &authorizerPlugin{}, nil)
+		So(err, ShouldBeNil)
+
+		So(registry.List(), ShouldResemble, []plugins.Info{
+			{Name: "authorizer", Authorizer: true},
+			{Name: "notifier", Notifier: true},
+		})
+
+		// notifier errors are only logged
+		registry.Notify(plugins.Event{Type: plugins.EventManifestPushed, Repo: "alpine"})
+
+		event := <-notifier.events
+		So(event.Repo, ShouldEqual, "alpine")
+		So(event.Timestamp.IsZero(), ShouldBeFalse)
+	})
+
+	Convey("Authorize requests", t, func() {
+		request := plugins.AuthzRequest{Username: "bob", Action: "read", Repo: "alpine"}
+
+		authorize := func(allowed bool, authorizers ...*authorizerPlugin) bool {
+			registry := plugins.NewRegistry(log)
+
+			for idx, authorizer := range authorizers {
+				So(registry.Register(string(rune('a'+idx)), authorizer, nil), ShouldBeNil)
+			}
+
+			return registry.Authorize(context.Background(), request, allowed)
+		}
+
+		So(authorize(true), ShouldBeTrue)
+		So(authorize(false), ShouldBeFalse)
+		So(authorize(true, &authorizerPlugin{decision: plugins.Abstain}), ShouldBeTrue)
+		So(authorize(false, &authorizerPlugin{decision: plugins.Allow}), ShouldBeTrue)
+		So(authorize(true, &authorizerPlugin{decision: plugins.Deny}), ShouldBeFalse)
+		So(authorize(true, &authorizerPlugin{decision: plugins.Allow}, &authorizerPlugin{decision: plugins.Deny}),
+			ShouldBeFalse)
+		So(authorize(true, &authorizerPlugin{err: ErrTestError}), ShouldBeFalse)
+	})
+
+	Convey("A nil registry leaves requests as they are", t, func() {
+		var registry *plugins.Registry
+
+		So(registry.Authorize(context.Background(), plugins.AuthzRequest{}, true), ShouldBeTrue)
+		So(registry.Authorize(context.Background(), plugins.AuthzRequest{}, false), ShouldBeFalse)
+		So(registry.List(), ShouldBeEmpty)
+		So(func() { registry.Notify(plugins.Event{}) }, ShouldNotPanic)
+		So(func() { registry.LockRepo("alpine")() }, ShouldNotPanic)
+		So(registry.EventLog(), ShouldBeNil)
+		So(registry.Close(), ShouldBeNil)
+	})
+
+	Convey("Events of a repo are sent in order", t, func() {
+		registry := plugins.NewRegistry(log)
+
+		notifier := &notifierPlugin{events: make(chan plugins.Event, 100)}
+		So(registry.Register("notifier", notifier, nil), ShouldBeNil)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+
+			go func(repo string) {
+				defer wg.Done()
+
+				unlock := registry.LockRepo(repo)
+				defer unlock()
+
+				registry.Notify(plugins.Event{Type: plugins.EventManifestPushed, Repo: repo, Reference: "latest"})
+			}([]string{"alpine", "busybox"}[i%2])
+		}
+
+		wg.Wait()
+
+		sequences := map[string]uint64{}
+
+		for i := 0; i < 50; i++ {
+			event := <-notifier.events
+			So(event.Sequence, ShouldEqual, sequences[event.Repo]+1)
+
+			sequences[event.Repo] = event.Sequence
+		}
+
+		So(sequences, ShouldResemble, map[string]uint64{"alpine": 25, "busybox": 25})
+		So(registry.Close(), ShouldBeNil)
+	})
+
+	Convey("Events are kept in the event log", t, func() {
+		rootDir := t.TempDir()
+
+		eventLog, err := plugins.NewEventLog(rootDir, log)
+		So(err, ShouldBeNil)
+
+		eventLog.MaxEventsPerRepo = 3
+
+		registry := plugins.NewRegistry(log)
+		registry.SetEventLog(eventLog)
+		So(registry.EventLog(), ShouldEqual, eventLog)
+
+		notifier := &notifierPlugin{events: make(chan plugins.Event, 10)}
+		So(registry.Register("notifier", notifier, nil), ShouldBeNil)
+
+		for _, tag := range []string{"1.0", "1.1", "1.2", "1.3"} {
+			registry.Notify(plugins.Event{Type: plugins.EventManifestPushed, Repo: "alpine", Reference: tag})
+		}
+
+		for seq := uint64(1); seq <= 4; seq++ {
+			So((<-notifier.events).Sequence, ShouldEqual, seq)
+		}
+
+		// the oldest event is dropped
+		events, oldest, latest, err := eventLog.Since("alpine", 0, 0)
+		So(err, ShouldBeNil)
+		So(oldest, ShouldEqual, 2)
+		So(latest, ShouldEqual, 4)
+		So(len(events), ShouldEqual, 3)
+		So(events[0].Reference, ShouldEqual, "1.1")
+
+		events, _, _, err = eventLog.Since("alpine", 3, 1)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 1)
+		So(events[0].Sequence, ShouldEqual, 3)
+		So(events[0].Reference, ShouldEqual, "1.2")
+
+		events, oldest, latest, err = eventLog.Since("busybox", 0, 0)
+		So(err, ShouldBeNil)
+		So(events, ShouldBeEmpty)
+		So(oldest, ShouldEqual, 0)
+		So(latest, ShouldEqual, 0)
+
+		// events without a repo can't be logged, they are sent without a sequence
+		registry.Notify(plugins.Event{Type: plugins.EventManifestPushed})
+		So((<-notifier.events).Sequence, ShouldEqual, 0)
+
+		So(registry.Close(), ShouldBeNil)
+
+		// sequences keep increasing after a restart
+		eventLog, err = plugins.NewEventLog(rootDir, log)
+		So(err, ShouldBeNil)
+
+		event, err := eventLog.Append(plugins.Event{Type: plugins.EventManifestDeleted, Repo: "alpine"})
+		So(err, ShouldBeNil)
+		So(event.Sequence, ShouldEqual, 5)
+		So(event.ID, ShouldEqual, 5)
+
+		So(eventLog.Close(), ShouldBeNil)
+	})
+
+	Convey("Events of all repos are listed and expire", t, func() {
+		eventLog, err := plugins.NewEventLog(t.TempDir(), log)
+		So(err, ShouldBeNil)
+
+		defer eventLog.Close()
+
+		eventLog.MaxAge = time.Hour
+		now := time.Now()
+
+		for _, event := range []plugins.Event{
+			{Type: plugins.EventManifestPushed, Repo: "alpine", Reference: "1.0", Timestamp: now.Add(-2 * time.Hour)},
+			{Type: plugins.EventManifestPushed, Repo: "busybox", Reference: "1.0", Timestamp: now.Add(-2 * time.Hour)},
+			{Type: plugins.EventManifestPushed, Repo: "alpine", Reference: "1.1", Timestamp: now},
+			{Type: plugins.EventManifestDeleted, Repo: "alpine", Reference: "1.0", Timestamp: now},
+			{Type: plugins.EventManifestPushed, Repo: "busybox", Reference: "1.1", Timestamp: now},
+		} {
+			_, err := eventLog.Append(event)
+			So(err, ShouldBeNil)
+		}
+
+		all := func(plugins.Event) (bool, error) { return true, nil }
+
+		events, next, latest, err := eventLog.List(0, 0, all)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 5)
+		So(next, ShouldEqual, 6)
+		So(latest, ShouldEqual, 5)
+
+		// pages
+		events, next, _, err = eventLog.List(2, 2, all)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 2)
+		So(events[0].ID, ShouldEqual, 2)
+		So(events[1].ID, ShouldEqual, 3)
+		So(next, ShouldEqual, 4)
+
+		events, next, _, err = eventLog.List(next, 2, all)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 2)
+		So(next, ShouldEqual, 6)
+
+		events, next, _, err = eventLog.List(next, 2, all)
+		So(err, ShouldBeNil)
+		So(events, ShouldBeEmpty)
+		So(next, ShouldEqual, 6)
+
+		// filters
+		events, _, _, err = eventLog.List(0, 0, func(event plugins.Event) (bool, error) {
+			return event.Repo == "alpine" && event.Type == plugins.EventManifestPushed, nil
+		})
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 2)
+		So(events[1].Reference, ShouldEqual, "1.1")
+
+		_, _, _, err = eventLog.List(0, 0, func(event plugins.Event) (bool, error) {
+			return false, ErrTestError
+		})
+		So(err, ShouldEqual, ErrTestError)
+
+		// expired events are dropped, sequences keep increasing
+		So(eventLog.Compact(), ShouldBeNil)
+
+		events, _, _, err = eventLog.List(0, 0, all)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 3)
+		So(events[0].ID, ShouldEqual, 3)
+
+		events, oldest, latest, err := eventLog.Since("busybox", 0, 0)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 1)
+		So(oldest, ShouldEqual, 2)
+		So(latest, ShouldEqual, 2)
+
+		event, err := eventLog.Append(plugins.Event{Type: plugins.EventManifestPushed, Repo: "busybox"})
+		So(err, ShouldBeNil)
+		So(event.Sequence, ShouldEqual, 3)
+		So(event.ID, ShouldEqual, 6)
+
+		eventLog.MaxAge = 0
+		So(eventLog.Compact(), ShouldBeNil)
+	})
+
+	Convey("Events of a repo are listed newest first", t, func() {
+		eventLog, err := plugins.NewEventLog(t.TempDir(), log)
+		So(err, ShouldBeNil)
+
+		defer eventLog.Close()
+
+		for _, event := range []plugins.Event{
+			{Type: plugins.EventManifestPushed, Repo: "alpine", Reference: "1.0"},
+			{Type: plugins.EventManifestPushed, Repo: "busybox", Reference: "1.0"},
+			{Type: plugins.EventSignatureAdded, Repo: "alpine", Reference: "sha256-1.sig"},
+			{Type: plugins.EventScanCompleted, Repo: "alpine", Digest: "sha256:1"},
+			{Type: plugins.EventManifestDeleted, Repo: "alpine", Reference: "1.0"},
+		} {
+			_, err := eventLog.Append(event)
+			So(err, ShouldBeNil)
+		}
+
+		all := func(plugins.Event) (bool, error) { return true, nil }
+
+		events, next, err := eventLog.Before("alpine", 0, 0, all)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 4)
+		So(events[0].Type, ShouldEqual, plugins.EventManifestDeleted)
+		So(events[3].Sequence, ShouldEqual, 1)
+		So(next, ShouldEqual, 0)
+
+		// pages
+		events, next, err = eventLog.Before("alpine", 0, 3, all)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 3)
+		So(events[2].Type, ShouldEqual, plugins.EventSignatureAdded)
+		So(next, ShouldEqual, 1)
+
+		events, next, err = eventLog.Before("alpine", next, 3, all)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 1)
+		So(events[0].Reference, ShouldEqual, "1.0")
+		So(next, ShouldEqual, 0)
+
+		events, _, err = eventLog.Before("alpine", 10, 1, all)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 1)
+		So(events[0].Sequence, ShouldEqual, 4)
+
+		// filters
+		events, _, err = eventLog.Before("alpine", 3, 0, func(event plugins.Event) (bool, error) {
+			return event.Type != plugins.EventSignatureAdded, nil
+		})
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 2)
+		So(events[0].Type, ShouldEqual, plugins.EventScanCompleted)
+
+		_, _, err = eventLog.Before("alpine", 0, 0, func(event plugins.Event) (bool, error) {
+			return false, ErrTestError
+		})
+		So(err, ShouldEqual, ErrTestError)
+
+		events, next, err = eventLog.Before("redis", 0, 0, all)
+		So(err, ShouldBeNil)
+		So(events, ShouldBeEmpty)
+		So(next, ShouldEqual, 0)
+	})
+
+	Convey("Load plugins from the config", t, func() {
+		disable := false
+
+		registry, err := plugins.Load([]extconf.PluginConfig{
+			{BaseConfig: extconf.BaseConfig{Enable: &disable}, Name: "disabled", Path: "/missing"},
+		}, log)
+		So(err, ShouldBeNil)
+		So(registry.List(), ShouldBeEmpty)
+
+		_, err = plugins.Load([]extconf.PluginConfig{
+			{Name: "missing", Path: path.Join(t.TempDir(), "missing")},
+		}, log)
+		So(err, ShouldNotBeNil)
+
+		// the test binary serves repoAuthorizer when started as a plugin
+		registry, err = plugins.Load([]extconf.PluginConfig{
+			{Name: "authorizer", Path: os.Args[0], Options: map[string]interface{}{"repo": "private"}},
+		}, log)
+		So(err, ShouldBeNil)
+
+		So(registry.List(), ShouldResemble, []plugins.Info{
+			{Name: "authorizer", Path: os.Args[0], Authorizer: true},
+		})
+
+		request := plugins.AuthzRequest{Username: "bob", Action: "read", Repo: "private"}
+		So(registry.Authorize(context.Background(), request, true), ShouldBeFalse)
+
+		request.Repo = "public"
+		So(registry.Authorize(context.Background(), request, true), ShouldBeTrue)
+
+		So(registry.Close(), ShouldBeNil)
+
+		// the plugin process is stopped
+		So(registry.Authorize(context.Background(), request, true), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,260 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/rpc"
+	"os"
+	"os/exec"
+
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/rs/zerolog"
+
+	"zotregistry.io/zot/pkg/log"
+)
+
+// pluginKey is the name plugins are served and dispensed with.
+const pluginKey = "plugin"
+
+/*
+Handshake is checked by zot and the plugin executables when a plugin is started, a plugin built against
+a version of this package using another protocol refuses to start instead of misbehaving.
+*/
+//nolint:gochecknoglobals
+var Handshake = goplugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "ZOT_PLUGIN",
+	MagicCookieValue: "zot",
+}
+
+/*
+Serve is called by the main func of a plugin executable to serve extPlugin to zot, it returns once zot
+stops the plugin. Plugins run in their own process so they don't need cgo and can be built with any
+Go version. Their logs, written with the logger given to Init or on their standard error, are added to
+the logs of zot, their standard output is discarded.
+*/
+func Serve(extPlugin Plugin) {
+	goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         goplugin.PluginSet{pluginKey: &rpcPlugin{impl: extPlugin}},
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Level:      hclog.Info,
+			Output:     os.Stderr,
+			JSONFormat: true,
+		}),
+	})
+}
+
+// rpcPlugin serves a Plugin through net/rpc on the plugin side, and dispenses a remotePlugin on zot's side.
+type rpcPlugin struct {
+	impl Plugin
+}
+
+func (p *rpcPlugin) Server(*goplugin.MuxBroker) (interface{}, error) {
+	return &rpcServer{impl: p.impl}, nil
+}
+
+func (p *rpcPlugin) Client(_ *goplugin.MuxBroker, client *rpc.Client) (interface{}, error) {
+	return &remotePlugin{client: client}, nil
+}
+
+type InitArgs struct {
+	// the options are sent as json, gob can't encode the values of their generic maps
+	Options  []byte
+	LogLevel string
+}
+
+type Capabilities struct {
+	Notifier   bool
+	Authorizer bool
+}
+
+// rpcServer runs in the plugin process and calls the plugin implementation.
+type rpcServer struct {
+	impl Plugin
+}
+
+func (server *rpcServer) Init(args InitArgs, _ *struct{}) error {
+	options := map[string]interface{}{}
+
+	if err := json.Unmarshal(args.Options, &options); err != nil {
+		return err
+	}
+
+	level, err := zerolog.ParseLevel(args.LogLevel)
+	if err != nil {
+		level = zerolog.InfoLevel
+	}
+
+	// the standard error of the plugin is forwarded to the logs of zot
+	logger := log.Logger{Logger: zerolog.New(os.Stderr).Level(level).With().Timestamp().Logger()}
+
+	return server.impl.Init(options, logger)
+}
+
+func (server *rpcServer) Capabilities(_ struct{}, capabilities *Capabilities) error {
+	_, capabilities.Notifier = server.impl.(Notifier)
+	_, capabilities.Authorizer = server.impl.(Authorizer)
+
+	return nil
+}
+
+func (server *rpcServer) Notify(event Event, _ *struct{}) error {
+	notifier, ok := server.impl.(Notifier)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	return notifier.Notify(ctx, event)
+}
+
+func (server *rpcServer) Authorize(request AuthzRequest, decision *Decision) error {
+	authorizer, ok := server.impl.(Authorizer)
+	if !ok {
+		*decision = Abstain
+
+		return nil
+	}
+
+	var err error
+
+	*decision, err = authorizer.Authorize(context.Background(), request)
+
+	return err
+}
+
+// remotePlugin runs in zot and calls a plugin running in its own process, it only is a notifier or an
+// authorizer for the registry if the plugin implementation is.
+type remotePlugin struct {
+	client       *rpc.Client
+	capabilities Capabilities
+}
+
+func (remote *remotePlugin) Init(options map[string]interface{}, _ log.Logger) error {
+	encodedOptions, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	return remote.client.Call("Plugin.Init", InitArgs{
+		Options:  encodedOptions,
+		LogLevel: zerolog.GlobalLevel().String(),
+	}, &struct{}{})
+}
+
+func (remote *remotePlugin) Notify(ctx context.Context, event Event) error {
+	return remote.call(ctx, "Plugin.Notify", event, &struct{}{})
+}
+
+func (remote *remotePlugin) Authorize(ctx context.Context, request AuthzRequest) (Decision, error) {
+	var decision Decision
+
+	if err := remote.call(ctx, "Plugin.Authorize", request, &decision); err != nil {
+		return Abstain, err
+	}
+
+	return decision, nil
+}
+
+// call returns when the plugin replies or ctx is done, net/rpc calls can't be canceled.
+func (remote *remotePlugin) call(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	call := remote.client.Go(method, args, reply, make(chan *rpc.Call, 1))
+
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// start runs the plugin executable at path and connects to it.
+func start(name, path string, logger log.Logger) (*goplugin.Client, *remotePlugin, error) {
+	client := goplugin.NewClient(&goplugin.ClientConfig{
+		HandshakeConfig:  Handshake,
+		Plugins:          goplugin.PluginSet{pluginKey: &rpcPlugin{}},
+		Cmd:              exec.Command(path),
+		AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolNetRPC},
+		// the logs of go-plugin in the plugin process
+		Stderr: &pluginOutput{name: name, log: logger},
+		// the logs of the plugin
+		SyncStderr: &pluginOutput{name: name, log: logger},
+		Logger:     hclog.NewNullLogger(),
+	})
+
+	protocol, err := client.Client()
+	if err != nil {
+		client.Kill()
+
+		return nil, nil, err
+	}
+
+	dispensed, err := protocol.Dispense(pluginKey)
+	if err != nil {
+		client.Kill()
+
+		return nil, nil, err
+	}
+
+	remote, _ := dispensed.(*remotePlugin)
+
+	if err := remote.client.Call("Plugin.Capabilities", struct{}{}, &remote.capabilities); err != nil {
+		client.Kill()
+
+		return nil, nil, err
+	}
+
+	return client, remote, nil
+}
+
+// pluginOutput logs the lines written by a plugin on its standard error, with their level when they have one.
+type pluginOutput struct {
+	name string
+	log  log.Logger
+}
+
+func (output *pluginOutput) Write(data []byte) (int, error) {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		output.logLine(bytes.TrimSpace(line))
+	}
+
+	return len(data), nil
+}
+
+func (output *pluginOutput) logLine(line []byte) {
+	if len(line) == 0 {
+		return
+	}
+
+	level := zerolog.InfoLevel
+
+	if json.Valid(line) {
+		var entry struct {
+			Level       string `json:"level"`
+			PluginLevel string `json:"@level"`
+		}
+
+		_ = json.Unmarshal(line, &entry)
+
+		for _, name := range []string{entry.Level, entry.PluginLevel} {
+			if parsed, err := zerolog.ParseLevel(name); err == nil && name != "" {
+				level = parsed
+			}
+		}
+
+		output.log.WithLevel(level).Str("plugin", output.name).RawJSON("output", line).Msg("plugins: plugin output")
+
+		return
+	}
+
+	if bytes.Contains(line, []byte("[DEBUG]")) || bytes.Contains(line, []byte("[TRACE]")) {
+		level = zerolog.DebugLevel
+	}
+
+	output.log.WithLevel(level).Str("plugin", output.name).Bytes("output", line).Msg("plugins: plugin output")
+}
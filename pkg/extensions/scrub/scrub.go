@@ -7,13 +7,16 @@ import (
 	"fmt"
 	"path"
 
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/storage"
 	storageTypes "zotregistry.io/zot/pkg/storage/types"
 )
 
 // Scrub Extension for repo...
-func RunScrubRepo(imgStore storageTypes.ImageStore, repo string, log log.Logger) error {
+func RunScrubRepo(imgStore storageTypes.ImageStore, repo string, metrics monitoring.MetricServer,
+	log log.Logger,
+) error {
 	execMsg := fmt.Sprintf("executing scrub to check manifest/blob integrity for %s", path.Join(imgStore.RootDir(), repo))
 	log.Info().Msg(execMsg)
 
@@ -26,7 +29,11 @@ func RunScrubRepo(imgStore storageTypes.ImageStore, repo string, log log.Logger)
 		return err
 	}
 
+	affected := 0
+
 	for _, result := range results {
+		monitoring.IncScrubbedImages(metrics, result.Status)
+
 		if result.Status == "ok" {
 			log.Info().
 				Str("image", result.ImageName).
@@ -40,9 +47,13 @@ func RunScrubRepo(imgStore storageTypes.ImageStore, repo string, log log.Logger)
 				Str("status", result.Status).
 				Str("error", result.Error).
 				Msg("scrub: blobs/manifest affected")
+
+			affected++
 		}
 	}
 
+	monitoring.SetScrubAffectedImages(metrics, repo, affected)
+
 	log.Info().Msg(fmt.Sprintf("scrub successfully completed for %s", path.Join(imgStore.RootDir(), repo)))
 
 	return nil
@@ -51,13 +62,14 @@ func RunScrubRepo(imgStore storageTypes.ImageStore, repo string, log log.Logger)
 type Task struct {
 	imgStore storageTypes.ImageStore
 	repo     string
+	metrics  monitoring.MetricServer
 	log      log.Logger
 }
 
-func NewTask(imgStore storageTypes.ImageStore, repo string, log log.Logger) *Task {
-	return &Task{imgStore, repo, log}
+func NewTask(imgStore storageTypes.ImageStore, repo string, metrics monitoring.MetricServer, log log.Logger) *Task {
+	return &Task{imgStore, repo, metrics, log}
 }
 
 func (scrubT *Task) DoWork() error {
-	return RunScrubRepo(scrubT.imgStore, scrubT.repo, scrubT.log)
+	return RunScrubRepo(scrubT.imgStore, scrubT.repo, scrubT.metrics, scrubT.log)
 }
@@ -192,7 +192,7 @@ func TestRunScrubRepo(t *testing.T) {
 
 		test.CopyTestFiles("../../../test/data/zot-test", path.Join(dir, repoName))
 
-		err = scrub.RunScrubRepo(imgStore, repoName, log)
+		err = scrub.RunScrubRepo(imgStore, repoName, metrics, log)
 		So(err, ShouldBeNil)
 
 		data, err := os.ReadFile(logFile.Name())
@@ -231,7 +231,7 @@ func TestRunScrubRepo(t *testing.T) {
 			panic(err)
 		}
 
-		err = scrub.RunScrubRepo(imgStore, repoName, log)
+		err = scrub.RunScrubRepo(imgStore, repoName, metrics, log)
 		So(err, ShouldBeNil)
 
 		data, err := os.ReadFile(logFile.Name())
@@ -265,7 +265,7 @@ func TestRunScrubRepo(t *testing.T) {
 
 		So(os.Chmod(path.Join(dir, repoName), 0o000), ShouldBeNil)
 
-		err = scrub.RunScrubRepo(imgStore, repoName, log)
+		err = scrub.RunScrubRepo(imgStore, repoName, metrics, log)
 		So(err, ShouldNotBeNil)
 
 		data, err := os.ReadFile(logFile.Name())
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"zotregistry.io/zot/pkg/extensions/search/convert"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+)
+
+const (
+	uniqueSizeField = "UniqueSize"
+
+	blobUsageExtension = "BlobUsage"
+)
+
+// BlobUsage makes the usage of the blobs of all repos available to the operations asking for the unique size
+// of images. It's computed at most once per operation, and only when the first unique size is resolved.
+type BlobUsage struct {
+	RepoDB repodb.RepoDB
+	// Scope returns the storage scope of a repo, the blobs of the repos in the same scope are shared.
+	Scope func(repo string) string
+	Log   log.Logger
+}
+
+var _ interface {
+	graphql.OperationInterceptor
+	graphql.HandlerExtension
+} = BlobUsage{}
+
+func (usage BlobUsage) ExtensionName() string {
+	return blobUsageExtension
+}
+
+func (usage BlobUsage) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (usage BlobUsage) InterceptOperation(ctx context.Context, next graphql.OperationHandler,
+) graphql.ResponseHandler {
+	opCtx := graphql.GetOperationContext(ctx)
+	if opCtx == nil || opCtx.Operation == nil || !selectsField(opCtx.Operation.SelectionSet, uniqueSizeField) {
+		return next(ctx)
+	}
+
+	loader := convert.NewBlobUsageLoader(func() (*convert.BlobUsage, error) {
+		// the blobs of the repos the user can't read are counted too, they take space all the same
+		repos, manifestMetaMap, indexDataMap, _, err := usage.RepoDB.FilterRepos(context.Background(),
+			func(repoMeta repodb.RepoMetadata) bool { return true }, repodb.PageInput{})
+		if err != nil {
+			usage.Log.Error().Err(err).Msg("failed to get the repos to compute the unique size of images")
+
+			return nil, err
+		}
+
+		return convert.NewBlobUsage(repos, manifestMetaMap, indexDataMap, usage.Scope), nil
+	})
+
+	return next(convert.WithBlobUsage(ctx, loader))
+}
+
+func selectsField(selectionSet ast.SelectionSet, name string) bool {
+	for _, selection := range selectionSet {
+		switch typedSelection := selection.(type) {
+		case *ast.Field:
+			if typedSelection.Name == name || selectsField(typedSelection.SelectionSet, name) {
+				return true
+			}
+		case *ast.InlineFragment:
+			if selectsField(typedSelection.SelectionSet, name) {
+				return true
+			}
+		case *ast.FragmentSpread:
+			if typedSelection.Definition != nil && selectsField(typedSelection.Definition.SelectionSet, name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
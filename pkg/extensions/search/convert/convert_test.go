@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	. "github.com/smartystreets/goconvey/convey"
 
+	"zotregistry.io/zot/pkg/common"
 	"zotregistry.io/zot/pkg/extensions/search/convert"
 	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
 	"zotregistry.io/zot/pkg/extensions/search/gql_generated"
@@ -415,3 +417,272 @@ func TestGetSignaturesInfo(t *testing.T) {
 		So(*signaturesSummary[0].Tool, ShouldEqual, "notation")
 	})
 }
+
+func TestImageSummaryRegistryMetadata(t *testing.T) {
+	Convey("Pins and registry annotations are reported in the image summary", t, func() {
+		ctx := graphql.WithResponseContext(context.Background(),
+			graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+		configBlob, err := json.Marshal(ispec.Image{})
+		So(err, ShouldBeNil)
+
+		digest := godigest.FromString("manifestDigest")
+		repoMeta := repodb.RepoMetadata{
+			Pins: map[string]repodb.PinInfo{"tag": {Digest: digest.String()}},
+			RegistryAnnotations: map[string]map[string]string{
+				digest.String(): {"owner": "team-a"},
+			},
+		}
+		manifestMeta := repodb.ManifestMetadata{
+			ManifestBlob: []byte("{}"),
+			ConfigBlob:   configBlob,
+		}
+
+		imageSummary, _, err := convert.ImageManifest2ImageSummary(ctx, "repo", "tag", digest, true,
+			repoMeta, manifestMeta, mocks.CveInfoMock{})
+		So(err, ShouldBeNil)
+		So(*imageSummary.IsPinned, ShouldBeTrue)
+		So(len(imageSummary.RegistryAnnotations), ShouldEqual, 1)
+		So(*imageSummary.RegistryAnnotations[0].Key, ShouldEqual, "owner")
+		So(*imageSummary.RegistryAnnotations[0].Value, ShouldEqual, "team-a")
+
+		imageSummary, _, err = convert.ImageManifest2ImageSummary(ctx, "repo", "tag", digest, true,
+			repodb.RepoMetadata{}, manifestMeta, mocks.CveInfoMock{})
+		So(err, ShouldBeNil)
+		So(*imageSummary.IsPinned, ShouldBeFalse)
+		So(imageSummary.RegistryAnnotations, ShouldBeEmpty)
+		So(imageSummary.DigestAliases, ShouldBeEmpty)
+	})
+
+	Convey("Digest aliases are reported in the image summary", t, func() {
+		ctx := graphql.WithResponseContext(context.Background(),
+			graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+		configBlob, err := json.Marshal(ispec.Image{})
+		So(err, ShouldBeNil)
+
+		digest := godigest.FromString("manifestDigest")
+		alias := godigest.FromString("dockerManifestDigest")
+		repoMeta := repodb.RepoMetadata{
+			DigestAliases: map[string]string{
+				alias.String():                        digest.String(),
+				godigest.FromString("other").String(): godigest.FromString("otherManifest").String(),
+			},
+		}
+		manifestMeta := repodb.ManifestMetadata{
+			ManifestBlob: []byte("{}"),
+			ConfigBlob:   configBlob,
+		}
+
+		imageSummary, _, err := convert.ImageManifest2ImageSummary(ctx, "repo", "tag", digest, true,
+			repoMeta, manifestMeta, mocks.CveInfoMock{})
+		So(err, ShouldBeNil)
+		So(len(imageSummary.DigestAliases), ShouldEqual, 1)
+		So(*imageSummary.DigestAliases[0], ShouldEqual, alias.String())
+	})
+}
+
+func TestImageSummaryAttestations(t *testing.T) {
+	Convey("Attestations are reported apart from the signatures in the image summary", t, func() {
+		ctx := graphql.WithResponseContext(context.Background(),
+			graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+		configBlob, err := json.Marshal(ispec.Image{})
+		So(err, ShouldBeNil)
+
+		digest := godigest.FromString("manifestDigest")
+		attestationsDigest := godigest.FromString("attestations")
+		repoMeta := repodb.RepoMetadata{
+			Attestations: map[string][]repodb.AttestationInfo{
+				digest.String(): {
+					{
+						ManifestDigest: attestationsDigest.String(),
+						LayerDigest:    godigest.FromString("provenance").String(),
+						PredicateType:  "https://slsa.dev/provenance/v0.2",
+						Tool:           repodb.CosignAttestation,
+					},
+				},
+			},
+		}
+		manifestMeta := repodb.ManifestMetadata{
+			ManifestBlob: []byte("{}"),
+			ConfigBlob:   configBlob,
+		}
+
+		imageSummary, _, err := convert.ImageManifest2ImageSummary(ctx, "repo", "tag", digest, false,
+			repoMeta, manifestMeta, mocks.CveInfoMock{})
+		So(err, ShouldBeNil)
+		So(*imageSummary.IsSigned, ShouldBeFalse)
+		So(imageSummary.SignatureInfo, ShouldBeEmpty)
+		So(len(imageSummary.Attestations), ShouldEqual, 1)
+		So(*imageSummary.Attestations[0].Digest, ShouldEqual, attestationsDigest.String())
+		So(*imageSummary.Attestations[0].PredicateType, ShouldEqual, "https://slsa.dev/provenance/v0.2")
+		So(*imageSummary.Attestations[0].Tool, ShouldEqual, repodb.CosignAttestation)
+
+		imageSummary, _, err = convert.ImageManifest2ImageSummary(ctx, "repo", "tag", digest, false,
+			repodb.RepoMetadata{}, manifestMeta, mocks.CveInfoMock{})
+		So(err, ShouldBeNil)
+		So(imageSummary.Attestations, ShouldBeEmpty)
+	})
+}
+
+func TestImageSummaryEncrypted(t *testing.T) {
+	Convey("Images with encrypted layers are reported as encrypted", t, func() {
+		ctx := graphql.WithResponseContext(context.Background(),
+			graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+		configBlob, err := json.Marshal(ispec.Image{})
+		So(err, ShouldBeNil)
+
+		manifestBlob, err := json.Marshal(ispec.Manifest{
+			Layers: []ispec.Descriptor{
+				{MediaType: ispec.MediaTypeImageLayerGzip, Digest: godigest.FromString("layer1")},
+				{MediaType: common.MediaTypeImageLayerGzipEncrypted, Digest: godigest.FromString("layer2")},
+			},
+		})
+		So(err, ShouldBeNil)
+
+		manifestMeta := repodb.ManifestMetadata{
+			ManifestBlob: manifestBlob,
+			ConfigBlob:   configBlob,
+		}
+
+		imageSummary, _, err := convert.ImageManifest2ImageSummary(ctx, "repo", "tag",
+			godigest.FromBytes(manifestBlob), true, repodb.RepoMetadata{}, manifestMeta, mocks.CveInfoMock{})
+		So(err, ShouldBeNil)
+		So(*imageSummary.IsEncrypted, ShouldBeTrue)
+		So(*imageSummary.Manifests[0].IsEncrypted, ShouldBeTrue)
+
+		manifestMeta.ManifestBlob = []byte("{}")
+
+		imageSummary, _, err = convert.ImageManifest2ImageSummary(ctx, "repo", "tag",
+			godigest.FromString("manifestDigest"), true, repodb.RepoMetadata{}, manifestMeta, mocks.CveInfoMock{})
+		So(err, ShouldBeNil)
+		So(*imageSummary.IsEncrypted, ShouldBeFalse)
+	})
+}
+
+func TestImageSummarySizes(t *testing.T) {
+	Convey("Logical and unique sizes of images sharing layers", t, func() {
+		ctx := graphql.WithResponseContext(context.Background(),
+			graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+		configBlob, err := json.Marshal(ispec.Image{})
+		So(err, ShouldBeNil)
+
+		sharedLayer := ispec.Descriptor{
+			MediaType: ispec.MediaTypeImageLayerGzip, Digest: godigest.FromString("shared"), Size: 100,
+		}
+
+		getManifestMeta := func(name string) (godigest.Digest, repodb.ManifestMetadata) {
+			manifestBlob, err := json.Marshal(ispec.Manifest{
+				Config: ispec.Descriptor{
+					MediaType: ispec.MediaTypeImageConfig, Digest: godigest.FromString(name + "-config"), Size: 10,
+				},
+				Layers: []ispec.Descriptor{
+					sharedLayer,
+					{MediaType: ispec.MediaTypeImageLayerGzip, Digest: godigest.FromString(name), Size: 1000},
+				},
+			})
+			So(err, ShouldBeNil)
+
+			return godigest.FromBytes(manifestBlob), repodb.ManifestMetadata{
+				ManifestBlob: manifestBlob,
+				ConfigBlob:   configBlob,
+			}
+		}
+
+		digest1, manifestMeta1 := getManifestMeta("image1")
+		digest2, manifestMeta2 := getManifestMeta("image2")
+		manifestSize1 := int64(len(manifestMeta1.ManifestBlob))
+
+		repos := []repodb.RepoMetadata{
+			{
+				Name: "repo1",
+				Tags: map[string]repodb.Descriptor{
+					"1.0":    {Digest: digest1.String(), MediaType: ispec.MediaTypeImageManifest},
+					"latest": {Digest: digest1.String(), MediaType: ispec.MediaTypeImageManifest},
+				},
+			},
+			{
+				Name: "repo2",
+				Tags: map[string]repodb.Descriptor{
+					"1.0": {Digest: digest2.String(), MediaType: ispec.MediaTypeImageManifest},
+				},
+			},
+		}
+		manifestMetaMap := map[string]repodb.ManifestMetadata{
+			digest1.String(): manifestMeta1,
+			digest2.String(): manifestMeta2,
+		}
+
+		Convey("Unique sizes are only computed when requested", func() {
+			imageSummary, _, err := convert.ImageManifest2ImageSummary(ctx, "repo1", "1.0", digest1, true,
+				repos[0], manifestMeta1, mocks.CveInfoMock{})
+			So(err, ShouldBeNil)
+			So(*imageSummary.LogicalSize, ShouldEqual, *imageSummary.Size)
+			So(imageSummary.UniqueSize, ShouldBeNil)
+		})
+
+		Convey("Layers shared across repos are reclaimable only if the storage doesn't dedupe them", func() {
+			dedupedCtx := convert.WithBlobUsage(ctx, convert.NewBlobUsageLoader(func() (*convert.BlobUsage, error) {
+				return convert.NewBlobUsage(repos, manifestMetaMap, nil, func(repo string) string { return "/" }), nil
+			}))
+
+			imageSummary, _, err := convert.ImageManifest2ImageSummary(dedupedCtx, "repo1", "1.0", digest1, true,
+				repos[0], manifestMeta1, mocks.CveInfoMock{})
+			So(err, ShouldBeNil)
+			// tagging an image twice doesn't make its blobs shared
+			So(*imageSummary.UniqueSize, ShouldEqual, strconv.FormatInt(manifestSize1+10+1000, 10))
+
+			notDedupedCtx := convert.WithBlobUsage(ctx, convert.NewBlobUsageLoader(func() (*convert.BlobUsage, error) {
+				return convert.NewBlobUsage(repos, manifestMetaMap, nil, func(repo string) string { return repo }), nil
+			}))
+
+			imageSummary, _, err = convert.ImageManifest2ImageSummary(notDedupedCtx, "repo1", "1.0", digest1, true,
+				repos[0], manifestMeta1, mocks.CveInfoMock{})
+			So(err, ShouldBeNil)
+			So(*imageSummary.UniqueSize, ShouldEqual, *imageSummary.Size)
+
+			failingCtx := convert.WithBlobUsage(ctx, convert.NewBlobUsageLoader(func() (*convert.BlobUsage, error) {
+				return nil, ErrTestError
+			}))
+
+			imageSummary, _, err = convert.ImageManifest2ImageSummary(failingCtx, "repo1", "1.0", digest1, true,
+				repos[0], manifestMeta1, mocks.CveInfoMock{})
+			So(err, ShouldBeNil)
+			So(imageSummary.UniqueSize, ShouldBeNil)
+		})
+
+		Convey("Layers shared by the platforms of an index are counted once", func() {
+			indexBlob, err := json.Marshal(ispec.Index{
+				Manifests: []ispec.Descriptor{
+					{MediaType: ispec.MediaTypeImageManifest, Digest: digest1},
+					{MediaType: ispec.MediaTypeImageManifest, Digest: digest2},
+				},
+			})
+			So(err, ShouldBeNil)
+
+			indexDigest := godigest.FromBytes(indexBlob)
+			repoMeta := repodb.RepoMetadata{
+				Name: "repo",
+				Tags: map[string]repodb.Descriptor{
+					"1.0": {Digest: indexDigest.String(), MediaType: ispec.MediaTypeImageIndex},
+				},
+			}
+			indexData := repodb.IndexData{IndexBlob: indexBlob}
+
+			usageCtx := convert.WithBlobUsage(ctx, convert.NewBlobUsageLoader(func() (*convert.BlobUsage, error) {
+				return convert.NewBlobUsage([]repodb.RepoMetadata{repoMeta}, manifestMetaMap,
+					map[string]repodb.IndexData{indexDigest.String(): indexData},
+					func(repo string) string { return "/" }), nil
+			}))
+
+			imageSummary, _, err := convert.ImageIndex2ImageSummary(usageCtx, "repo", "1.0", indexDigest, true,
+				repoMeta, indexData, manifestMetaMap, mocks.CveInfoMock{})
+			So(err, ShouldBeNil)
+
+			size, err := strconv.ParseInt(*imageSummary.Size, 10, 64)
+			So(err, ShouldBeNil)
+
+			logicalSize := size - sharedLayer.Size + int64(len(indexBlob))
+			So(*imageSummary.LogicalSize, ShouldEqual, strconv.FormatInt(logicalSize, 10))
+			So(*imageSummary.UniqueSize, ShouldEqual, *imageSummary.LogicalSize)
+		})
+	})
+}
@@ -158,10 +158,10 @@ func Descriptor2ImageSummary(ctx context.Context, descriptor repodb.Descriptor,
 	indexDataMap map[string]repodb.IndexData, cveInfo cveinfo.CveInfo,
 ) (*gql_generated.ImageSummary, map[string]int64, error) {
 	switch descriptor.MediaType {
-	case ispec.MediaTypeImageManifest:
+	case ispec.MediaTypeImageManifest, common.MediaTypeDockerManifest:
 		return ImageManifest2ImageSummary(ctx, repo, tag, godigest.Digest(descriptor.Digest), skipCVE,
 			repoMeta, manifestMetaMap[descriptor.Digest], cveInfo)
-	case ispec.MediaTypeImageIndex:
+	case ispec.MediaTypeImageIndex, common.MediaTypeDockerManifestList:
 		return ImageIndex2ImageSummary(ctx, repo, tag, godigest.Digest(descriptor.Digest), skipCVE,
 			repoMeta, indexDataMap[descriptor.Digest], manifestMetaMap, cveInfo)
 	default:
@@ -187,6 +187,7 @@ func ImageIndex2ImageSummary(ctx context.Context, repo, tag string, indexDigest
 		indexSize          string
 		totalDownloadCount int
 		maxSeverity        string
+		isEncrypted        bool
 		manifestSummaries  = make([]*gql_generated.ManifestSummary, 0, len(indexContent.Manifests))
 		indexBlobs         = make(map[string]int64, 0)
 
@@ -214,6 +215,8 @@ func ImageIndex2ImageSummary(ctx context.Context, repo, tag string, indexDigest
 
 		totalIndexSize += manifestSize
 
+		isEncrypted = isEncrypted || *manifestSummary.IsEncrypted
+
 		if cvemodel.SeverityValue(*manifestSummary.Vulnerabilities.MaxSeverity) >
 			cvemodel.SeverityValue(maxSeverity) {
 			maxSeverity = *manifestSummary.Vulnerabilities.MaxSeverity
@@ -242,28 +245,53 @@ func ImageIndex2ImageSummary(ctx context.Context, repo, tag string, indexDigest
 
 	indexSize = strconv.FormatInt(totalIndexSize, 10)
 
+	// unlike the size, the logical size counts the layers shared by the platforms once
+	imageBlobs := make(map[string]int64, len(indexBlobs)+1)
+
+	for digest, size := range indexBlobs {
+		imageBlobs[digest] = size
+	}
+
+	imageBlobs[indexDigestStr] = int64(len(indexData.IndexBlob))
+
+	logicalSize, uniqueSize := getImageSizes(ctx, repo, indexDigestStr, imageBlobs)
+
 	annotations := GetAnnotations(indexContent.Annotations, map[string]string{})
 
 	signaturesInfo := GetSignaturesInfo(isSigned, repoMeta, indexDigest)
 
+	isPinned := repodb.IsDigestPinned(repoMeta, indexDigestStr)
+	registryAnnotations := StringMap2Annotations(repoMeta.RegistryAnnotations[indexDigestStr])
+	digestAliases := GetDigestAliases(repoMeta, indexDigestStr)
+	sbomSummary := GetSBOMSummary(repoMeta.SBOMSummaries, indexDigestStr)
+	attestations := GetAttestations(repoMeta.Attestations, indexDigestStr)
+
 	indexSummary := gql_generated.ImageSummary{
-		RepoName:      &repo,
-		Tag:           &tag,
-		Digest:        &indexDigestStr,
-		MediaType:     &indexMediaType,
-		Manifests:     manifestSummaries,
-		LastUpdated:   &indexLastUpdated,
-		IsSigned:      &isSigned,
-		SignatureInfo: signaturesInfo,
-		Size:          &indexSize,
-		DownloadCount: &totalDownloadCount,
-		Description:   &annotations.Description,
-		Title:         &annotations.Title,
-		Documentation: &annotations.Documentation,
-		Licenses:      &annotations.Licenses,
-		Labels:        &annotations.Labels,
-		Source:        &annotations.Source,
-		Vendor:        &annotations.Vendor,
+		RepoName:            &repo,
+		Tag:                 &tag,
+		Digest:              &indexDigestStr,
+		MediaType:           &indexMediaType,
+		Manifests:           manifestSummaries,
+		LastUpdated:         &indexLastUpdated,
+		IsSigned:            &isSigned,
+		SignatureInfo:       signaturesInfo,
+		IsPinned:            &isPinned,
+		RegistryAnnotations: registryAnnotations,
+		DigestAliases:       digestAliases,
+		Sbom:                sbomSummary,
+		Attestations:        attestations,
+		IsEncrypted:         &isEncrypted,
+		Size:                &indexSize,
+		LogicalSize:         logicalSize,
+		UniqueSize:          uniqueSize,
+		DownloadCount:       &totalDownloadCount,
+		Description:         &annotations.Description,
+		Title:               &annotations.Title,
+		Documentation:       &annotations.Documentation,
+		Licenses:            &annotations.Licenses,
+		Labels:              &annotations.Labels,
+		Source:              &annotations.Source,
+		Vendor:              &annotations.Vendor,
 		Vulnerabilities: &gql_generated.ImageVulnerabilitySummary{
 			MaxSeverity: &imageCveSummary.MaxSeverity,
 			Count:       &imageCveSummary.Count,
@@ -332,6 +360,7 @@ func ImageManifest2ImageSummary(ctx context.Context, repo, tag string, digest go
 		configDigest, configSize,
 		manifestContent.Layers)
 	imageSize := strconv.FormatInt(size, 10)
+	logicalSize, uniqueSize := getImageSizes(ctx, repo, manifestDigest, imageBlobsMap)
 
 	annotations := GetAnnotations(manifestContent.Annotations, configContent.Config.Labels)
 
@@ -360,6 +389,13 @@ func ImageManifest2ImageSummary(ctx context.Context, repo, tag string, digest go
 
 	signaturesInfo := GetSignaturesInfo(isSigned, repoMeta, digest)
 
+	isPinned := repodb.IsDigestPinned(repoMeta, manifestDigest)
+	isEncrypted := common.IsImageEncrypted(manifestContent)
+	registryAnnotations := StringMap2Annotations(repoMeta.RegistryAnnotations[manifestDigest])
+	digestAliases := GetDigestAliases(repoMeta, manifestDigest)
+	sbomSummary := GetSBOMSummary(repoMeta.SBOMSummaries, manifestDigest)
+	attestations := GetAttestations(repoMeta.Attestations, manifestDigest)
+
 	imageSummary := gql_generated.ImageSummary{
 		RepoName:  &repoName,
 		Tag:       &tag,
@@ -373,6 +409,7 @@ func ImageManifest2ImageSummary(ctx context.Context, repo, tag string, digest go
 				Size:          &imageSize,
 				IsSigned:      &isSigned,
 				SignatureInfo: signaturesInfo,
+				IsEncrypted:   &isEncrypted,
 				Platform:      &platform,
 				DownloadCount: &downloadCount,
 				Layers:        getLayersSummaries(manifestContent),
@@ -385,19 +422,27 @@ func ImageManifest2ImageSummary(ctx context.Context, repo, tag string, digest go
 				ArtifactType: &artifactType,
 			},
 		},
-		LastUpdated:   &imageLastUpdated,
-		IsSigned:      &isSigned,
-		SignatureInfo: signaturesInfo,
-		Size:          &imageSize,
-		DownloadCount: &downloadCount,
-		Description:   &annotations.Description,
-		Title:         &annotations.Title,
-		Documentation: &annotations.Documentation,
-		Licenses:      &annotations.Licenses,
-		Labels:        &annotations.Labels,
-		Source:        &annotations.Source,
-		Vendor:        &annotations.Vendor,
-		Authors:       &authors,
+		LastUpdated:         &imageLastUpdated,
+		IsSigned:            &isSigned,
+		SignatureInfo:       signaturesInfo,
+		IsPinned:            &isPinned,
+		RegistryAnnotations: registryAnnotations,
+		DigestAliases:       digestAliases,
+		Sbom:                sbomSummary,
+		Attestations:        attestations,
+		IsEncrypted:         &isEncrypted,
+		Size:                &imageSize,
+		LogicalSize:         logicalSize,
+		UniqueSize:          uniqueSize,
+		DownloadCount:       &downloadCount,
+		Description:         &annotations.Description,
+		Title:               &annotations.Title,
+		Documentation:       &annotations.Documentation,
+		Licenses:            &annotations.Licenses,
+		Labels:              &annotations.Labels,
+		Source:              &annotations.Source,
+		Vendor:              &annotations.Vendor,
+		Authors:             &authors,
 		Vulnerabilities: &gql_generated.ImageVulnerabilitySummary{
 			MaxSeverity: &imageCveSummary.MaxSeverity,
 			Count:       &imageCveSummary.Count,
@@ -521,6 +566,8 @@ func ImageManifest2ManifestSummary(ctx context.Context, repo, tag string, descri
 
 	signaturesInfo := GetSignaturesInfo(isSigned, repoMeta, digest)
 
+	isEncrypted := common.IsImageEncrypted(manifestContent)
+
 	manifestSummary := gql_generated.ManifestSummary{
 		Digest:        &manifestDigestStr,
 		ConfigDigest:  &configDigest,
@@ -532,6 +579,7 @@ func ImageManifest2ManifestSummary(ctx context.Context, repo, tag string, descri
 		History:       historyEntries,
 		IsSigned:      &isSigned,
 		SignatureInfo: signaturesInfo,
+		IsEncrypted:   &isEncrypted,
 		Vulnerabilities: &gql_generated.ImageVulnerabilitySummary{
 			MaxSeverity: &imageCveSummary.MaxSeverity,
 			Count:       &imageCveSummary.Count,
@@ -714,6 +762,62 @@ func StringMap2Annotations(strMap map[string]string) []*gql_generated.Annotation
 	return annotations
 }
 
+// GetDigestAliases returns the digests the image with the given digest was pushed with, if it was rewritten.
+func GetDigestAliases(repoMeta repodb.RepoMetadata, digest string) []*string {
+	aliases := repodb.GetDigestAliases(repoMeta, digest)
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	digestAliases := make([]*string, 0, len(aliases))
+
+	for _, alias := range aliases {
+		alias := alias
+
+		digestAliases = append(digestAliases, &alias)
+	}
+
+	return digestAliases
+}
+
+// GetSBOMSummary returns nil if no SBOM referring the image was parsed.
+func GetSBOMSummary(sbomSummaries map[string]repodb.SBOMSummary, digest string) *gql_generated.SBOMSummary {
+	summary, found := sbomSummaries[digest]
+	if !found {
+		return nil
+	}
+
+	licenses := make([]*string, 0, len(summary.Licenses))
+
+	for i := range summary.Licenses {
+		licenses = append(licenses, &summary.Licenses[i])
+	}
+
+	return &gql_generated.SBOMSummary{
+		Digest:       &summary.Digest,
+		Format:       &summary.Format,
+		Licenses:     licenses,
+		PackageCount: &summary.PackageCount,
+	}
+}
+
+// GetAttestations returns the attestations attached to the image, they are not part of its signatures.
+func GetAttestations(attestations map[string][]repodb.AttestationInfo, digest string,
+) []*gql_generated.AttestationSummary {
+	imageAttestations := attestations[digest]
+	results := make([]*gql_generated.AttestationSummary, 0, len(imageAttestations))
+
+	for i := range imageAttestations {
+		results = append(results, &gql_generated.AttestationSummary{
+			Digest:        &imageAttestations[i].ManifestDigest,
+			PredicateType: &imageAttestations[i].PredicateType,
+			Tool:          &imageAttestations[i].Tool,
+		})
+	}
+
+	return results
+}
+
 func GetPreloads(ctx context.Context) map[string]bool {
 	if !graphql.HasOperationContext(ctx) {
 		return map[string]bool{}
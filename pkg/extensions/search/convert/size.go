@@ -0,0 +1,198 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/meta/repodb"
+)
+
+type blobUsageCtxKey struct{}
+
+// BlobUsage tells how many images each blob of the registry is part of, an image being the manifest or the
+// index a tag points to. Blobs are counted by storage scope: the whole image store if it dedupes blobs,
+// else each repo, since each repo then keeps its own copy of the blobs.
+type BlobUsage struct {
+	// map[scope]map[blobDigest]blobOwners
+	blobs map[string]map[string]blobOwners
+	scope func(repo string) string
+}
+
+type blobOwners struct {
+	count int
+	// the first image the blob was found in, repo@digest
+	image string
+}
+
+// BlobUsageLoader builds the blob usage of the registry the first time it's needed by a query.
+type BlobUsageLoader struct {
+	load  func() (*BlobUsage, error)
+	once  sync.Once
+	usage *BlobUsage
+}
+
+func NewBlobUsageLoader(load func() (*BlobUsage, error)) *BlobUsageLoader {
+	return &BlobUsageLoader{load: load}
+}
+
+// Get returns the blob usage, or nil if it couldn't be built.
+func (loader *BlobUsageLoader) Get() *BlobUsage {
+	loader.once.Do(func() {
+		usage, err := loader.load()
+		if err == nil {
+			loader.usage = usage
+		}
+	})
+
+	return loader.usage
+}
+
+// WithBlobUsage returns a copy of ctx carrying the loader of the blob usage, the unique sizes of the images
+// are only computed for the queries carrying it.
+func WithBlobUsage(ctx context.Context, loader *BlobUsageLoader) context.Context {
+	return context.WithValue(ctx, blobUsageCtxKey{}, loader)
+}
+
+func getBlobUsage(ctx context.Context) *BlobUsage {
+	loader, ok := ctx.Value(blobUsageCtxKey{}).(*BlobUsageLoader)
+	if !ok {
+		return nil
+	}
+
+	return loader.Get()
+}
+
+// NewBlobUsage counts the images of the repos each blob is part of, scope returns the storage scope of a repo.
+func NewBlobUsage(repos []repodb.RepoMetadata, manifestMetaMap map[string]repodb.ManifestMetadata,
+	indexDataMap map[string]repodb.IndexData, scope func(repo string) string,
+) *BlobUsage {
+	usage := &BlobUsage{
+		blobs: map[string]map[string]blobOwners{},
+		scope: scope,
+	}
+
+	for _, repoMeta := range repos {
+		scopeBlobs, ok := usage.blobs[scope(repoMeta.Name)]
+		if !ok {
+			scopeBlobs = map[string]blobOwners{}
+			usage.blobs[scope(repoMeta.Name)] = scopeBlobs
+		}
+
+		// an image tagged more than once is counted once
+		images := map[string]repodb.Descriptor{}
+
+		for _, descriptor := range repoMeta.Tags {
+			images[descriptor.Digest] = descriptor
+		}
+
+		for digest, descriptor := range images {
+			image := repoMeta.Name + "@" + digest
+
+			for blobDigest := range GetImageBlobs(descriptor, manifestMetaMap, indexDataMap) {
+				owners := scopeBlobs[blobDigest]
+				if owners.count == 0 {
+					owners.image = image
+				}
+
+				owners.count++
+				scopeBlobs[blobDigest] = owners
+			}
+		}
+	}
+
+	return usage
+}
+
+// UniqueSize returns the size of the blobs of an image which aren't part of any other image in the same storage
+// scope, i.e. the space deleting the image would reclaim.
+func (usage *BlobUsage) UniqueSize(repo, digest string, imageBlobs map[string]int64) int64 {
+	var (
+		size       int64
+		image      = repo + "@" + digest
+		scopeBlobs = usage.blobs[usage.scope(repo)]
+	)
+
+	for blobDigest, blobSize := range imageBlobs {
+		owners := scopeBlobs[blobDigest]
+
+		// untagged images aren't counted, their blobs are unique if no tagged image uses them
+		if owners.count == 0 || (owners.count == 1 && owners.image == image) {
+			size += blobSize
+		}
+	}
+
+	return size
+}
+
+// GetImageBlobs returns the sizes of the blobs of the image a descriptor points to, including the manifests
+// of the image.
+func GetImageBlobs(descriptor repodb.Descriptor, manifestMetaMap map[string]repodb.ManifestMetadata,
+	indexDataMap map[string]repodb.IndexData,
+) map[string]int64 {
+	blobs := map[string]int64{}
+
+	switch descriptor.MediaType {
+	case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
+		addManifestBlobs(blobs, descriptor.Digest, manifestMetaMap[descriptor.Digest])
+	case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
+		indexData, ok := indexDataMap[descriptor.Digest]
+		if !ok {
+			return blobs
+		}
+
+		var indexContent ispec.Index
+
+		if err := json.Unmarshal(indexData.IndexBlob, &indexContent); err != nil {
+			return blobs
+		}
+
+		blobs[descriptor.Digest] = int64(len(indexData.IndexBlob))
+
+		for _, manifest := range indexContent.Manifests {
+			addManifestBlobs(blobs, manifest.Digest.String(), manifestMetaMap[manifest.Digest.String()])
+		}
+	}
+
+	return blobs
+}
+
+func addManifestBlobs(blobs map[string]int64, digest string, manifestMeta repodb.ManifestMetadata) {
+	var manifestContent ispec.Manifest
+
+	if err := json.Unmarshal(manifestMeta.ManifestBlob, &manifestContent); err != nil {
+		return
+	}
+
+	_, manifestBlobs := getImageBlobsInfo(digest, int64(len(manifestMeta.ManifestBlob)),
+		manifestContent.Config.Digest.String(), manifestContent.Config.Size, manifestContent.Layers)
+
+	for blobDigest, blobSize := range manifestBlobs {
+		blobs[blobDigest] = blobSize
+	}
+}
+
+// getImageSizes returns the logical size of an image, the size of its blobs each counted once, and its unique
+// size if the query asked for it.
+func getImageSizes(ctx context.Context, repo, digest string, imageBlobs map[string]int64) (*string, *string) {
+	var logicalSize int64
+
+	for _, blobSize := range imageBlobs {
+		logicalSize += blobSize
+	}
+
+	logicalSizeStr := strconv.FormatInt(logicalSize, 10)
+
+	usage := getBlobUsage(ctx)
+	if usage == nil {
+		return &logicalSizeStr, nil
+	}
+
+	uniqueSizeStr := strconv.FormatInt(usage.UniqueSize(repo, digest, imageBlobs), 10)
+
+	return &logicalSizeStr, &uniqueSizeStr
+}
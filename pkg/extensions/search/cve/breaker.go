@@ -0,0 +1,47 @@
+package cveinfo
+
+import (
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/breaker"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/log"
+)
+
+// breakerScanner stops scanning images while the scans keep failing, e.g. if the trivy db is corrupted, so
+// the search results are returned right away without their vulnerabilities instead of waiting on each scan.
+type breakerScanner struct {
+	Scanner
+	breaker *breaker.Breaker
+}
+
+// NewBreakerScanner wraps a scanner in a circuit breaker, images which can't be scanned, missing images and
+// canceled scans aren't failures of the scanner.
+func NewBreakerScanner(scanner Scanner, config config.CircuitBreakerConfig, metrics monitoring.MetricServer,
+	log log.Logger,
+) Scanner {
+	return breakerScanner{
+		Scanner: scanner,
+		breaker: breaker.New("cve", config, metrics, log, zerr.ErrManifestNotFound, zerr.ErrManifestMetaNotFound,
+			zerr.ErrRepoMetaNotFound, zerr.ErrTagMetaNotFound, zerr.ErrImageEncrypted, zerr.ErrScanNotSupported,
+			zerr.ErrScanCanceled, zerr.ErrCVEDBNotFound),
+	}
+}
+
+func (scanner breakerScanner) ScanImage(image string) (map[string]cvemodel.CVE, error) {
+	var cveMap map[string]cvemodel.CVE
+
+	err := scanner.breaker.Do(func() error {
+		var err error
+
+		cveMap, err = scanner.Scanner.ScanImage(image)
+
+		return err
+	})
+	if err != nil {
+		return map[string]cvemodel.CVE{}, err
+	}
+
+	return cveMap, nil
+}
@@ -2,6 +2,7 @@ package cveinfo
 
 import (
 	"encoding/json"
+	"errors"
 	"sort"
 	"strings"
 	"time"
@@ -9,7 +10,9 @@ import (
 	godigest "github.com/opencontainers/go-digest"
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 
+	zerr "zotregistry.io/zot/errors"
 	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
 	"zotregistry.io/zot/pkg/extensions/search/cve/trivy"
 	"zotregistry.io/zot/pkg/log"
@@ -24,8 +27,14 @@ type CveInfo interface {
 	) ([]cvemodel.CVE, zcommon.PageInfo, error)
 	GetCVESummaryForImage(repo, ref string) (cvemodel.ImageCVESummary, error)
 	GetCVESummaryForImageMedia(repo, digest, mediaType string) (cvemodel.ImageCVESummary, error)
+	GetCachedCVESummary(repo, digest, mediaType string) (cvemodel.ImageCVESummary, bool)
 	CompareSeverities(severity1, severity2 string) int
 	UpdateDB() error
+	UpdateJavaDB() error
+	CancelScans(repo, digest string) int
+	OnScanCompleted(hook cvemodel.ScanCompletedFunc)
+	GetScanQueue() []cvemodel.ScanStatus
+	GetDBStatus() []cvemodel.DBStatus
 }
 
 type Scanner interface {
@@ -34,6 +43,12 @@ type Scanner interface {
 	IsImageMediaScannable(repo, digestStr, mediaType string) (bool, error)
 	CompareSeverities(severity1, severity2 string) int
 	UpdateDB() error
+	UpdateJavaDB() error
+	CancelScans(repo, digest string) int
+	OnScanCompleted(hook cvemodel.ScanCompletedFunc)
+	GetScanQueue() []cvemodel.ScanStatus
+	GetDBStatus() []cvemodel.DBStatus
+	GetCachedResult(digest string) (map[string]cvemodel.CVE, bool)
 }
 
 type BaseCveInfo struct {
@@ -42,16 +57,22 @@ type BaseCveInfo struct {
 	RepoDB  repodb.RepoDB
 }
 
-func NewCVEInfo(storeController storage.StoreController, repoDB repodb.RepoDB,
-	dbRepository, javaDBRepository string, log log.Logger,
+func NewCVEInfo(storeController storage.StoreController, repoDB repodb.RepoDB, dbRepository,
+	javaDBRepository, javaDBPath string, maxConcurrentScans int, metrics monitoring.MetricServer, log log.Logger,
 ) *BaseCveInfo {
-	scanner := trivy.NewScanner(storeController, repoDB, dbRepository, javaDBRepository, log)
+	scanner := trivy.NewScanner(storeController, repoDB, dbRepository, javaDBRepository, javaDBPath,
+		maxConcurrentScans, metrics, log)
 
-	return &BaseCveInfo{
+	cveInfo := &BaseCveInfo{
 		Log:     log,
 		Scanner: scanner,
 		RepoDB:  repoDB,
 	}
+
+	// images are filtered by the summary of their last scan
+	scanner.OnScanCompleted(cveInfo.recordVulnerabilitySummary)
+
+	return cveInfo
 }
 
 func (cveinfo BaseCveInfo) GetImageListForCVE(repo, cveID string) ([]cvemodel.TagInfo, error) {
@@ -67,7 +88,8 @@ func (cveinfo BaseCveInfo) GetImageListForCVE(repo, cveID string) ([]cvemodel.Ta
 
 	for tag, descriptor := range repoMeta.Tags {
 		switch descriptor.MediaType {
-		case ispec.MediaTypeImageManifest, ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageManifest, ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifest,
+			zcommon.MediaTypeDockerManifestList:
 			manifestDigestStr := descriptor.Digest
 
 			manifestDigest := godigest.Digest(manifestDigestStr)
@@ -117,7 +139,7 @@ func (cveinfo BaseCveInfo) GetImageListWithCVEFixed(repo, cveID string) ([]cvemo
 
 	for tag, descriptor := range repoMeta.Tags {
 		switch descriptor.MediaType {
-		case ispec.MediaTypeImageManifest:
+		case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 			manifestDigestStr := descriptor.Digest
 
 			tagInfo, err := getTagInfoForManifest(tag, manifestDigestStr, cveinfo.RepoDB)
@@ -133,7 +155,7 @@ func (cveinfo BaseCveInfo) GetImageListWithCVEFixed(repo, cveID string) ([]cvemo
 			if cveinfo.isManifestVulnerable(repo, tag, manifestDigestStr, cveID) {
 				vulnerableTags = append(vulnerableTags, tagInfo)
 			}
-		case ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 			indexDigestStr := descriptor.Digest
 
 			indexContent, err := getIndexContent(cveinfo.RepoDB, indexDigestStr)
@@ -358,6 +380,25 @@ func (cveinfo BaseCveInfo) GetCVEListForImage(repo, ref string, searchedCVE stri
 
 	cveList, pageInfo := pageFinder.Page()
 
+	acknowledgements := cveinfo.getCVEAcknowledgements(repo, ref)
+
+	for idx := range cveList {
+		ack, ok := acknowledgements[cveList[idx].ID]
+		if !ok {
+			continue
+		}
+
+		cveList[idx].Acknowledgement = &cvemodel.Acknowledgement{
+			Reason:         ack.Reason,
+			AcknowledgedBy: ack.AcknowledgedBy,
+		}
+
+		if !ack.ExpiresAt.IsZero() {
+			expiresAt := ack.ExpiresAt
+			cveList[idx].Acknowledgement.ExpiresAt = &expiresAt
+		}
+	}
+
 	return cveList, pageInfo, nil
 }
 
@@ -366,12 +407,17 @@ func (cveinfo BaseCveInfo) GetCVESummaryForImage(repo, ref string) (cvemodel.Ima
 	// not scannable / error during scan   - max severity ""            - cve count 0   - Errors
 	// scannable no issues found           - max severity "NONE"        - cve count 0   - no Errors
 	// scannable issues found              - max severity from Scanner  - cve count >0  - no Errors
+	// encrypted                           - max severity ""            - cve count 0   - no Errors
 	imageCVESummary := cvemodel.ImageCVESummary{
 		Count:       0,
 		MaxSeverity: "",
 	}
 
 	isValidImage, err := cveinfo.Scanner.IsImageFormatScannable(repo, ref)
+	if errors.Is(err, zerr.ErrImageEncrypted) {
+		return imageCVESummary, nil
+	}
+
 	if !isValidImage {
 		return imageCVESummary, err
 	}
@@ -383,22 +429,7 @@ func (cveinfo BaseCveInfo) GetCVESummaryForImage(repo, ref string) (cvemodel.Ima
 		return imageCVESummary, err
 	}
 
-	imageCVESummary.Count = len(cveMap)
-
-	if imageCVESummary.Count == 0 {
-		imageCVESummary.MaxSeverity = "NONE"
-
-		return imageCVESummary, nil
-	}
-
-	imageCVESummary.MaxSeverity = "UNKNOWN"
-	for _, cve := range cveMap {
-		if cveinfo.Scanner.CompareSeverities(imageCVESummary.MaxSeverity, cve.Severity) > 0 {
-			imageCVESummary.MaxSeverity = cve.Severity
-		}
-	}
-
-	return imageCVESummary, nil
+	return cveinfo.summarizeCVEs(cveMap, cveinfo.getCVEAcknowledgements(repo, ref)), nil
 }
 
 func (cveinfo BaseCveInfo) GetCVESummaryForImageMedia(repo, digest, mediaType string,
@@ -409,6 +440,13 @@ func (cveinfo BaseCveInfo) GetCVESummaryForImageMedia(repo, digest, mediaType st
 	}
 
 	isValidImage, err := cveinfo.Scanner.IsImageMediaScannable(repo, digest, mediaType)
+	if errors.Is(err, zerr.ErrImageEncrypted) {
+		// encrypted images are reported as such in the image summaries, not as scanning errors
+		cveinfo.Log.Debug().Str("image", repo+"@"+digest).Msg("image is encrypted, skipping vulnerability summary")
+
+		return imageCVESummary, nil
+	}
+
 	if !isValidImage {
 		return imageCVESummary, err
 	}
@@ -420,32 +458,173 @@ func (cveinfo BaseCveInfo) GetCVESummaryForImageMedia(repo, digest, mediaType st
 		return imageCVESummary, err
 	}
 
-	imageCVESummary.Count = len(cveMap)
+	return cveinfo.summarizeCVEs(cveMap, cveinfo.getCVEAcknowledgements(repo, digest)), nil
+}
+
+/*
+GetCachedCVESummary summarizes the CVEs of an image scanned with the current vulnerability databases, without
+scanning it, ok is false if the result of its scan isn't cached. The manifests of an index are scanned one by one,
+its result is only known if the results of all its scannable manifests are.
+*/
+func (cveinfo BaseCveInfo) GetCachedCVESummary(repo, digest, mediaType string) (cvemodel.ImageCVESummary, bool) {
+	cveMap := map[string]cvemodel.CVE{}
+
+	switch mediaType {
+	case ispec.MediaTypeImageIndex:
+		indexData, err := cveinfo.RepoDB.GetIndexData(godigest.Digest(digest))
+		if err != nil {
+			return cvemodel.ImageCVESummary{}, false
+		}
 
-	if imageCVESummary.Count == 0 {
-		imageCVESummary.MaxSeverity = "NONE"
+		var indexContent ispec.Index
 
-		return imageCVESummary, nil
+		if err := json.Unmarshal(indexData.IndexBlob, &indexContent); err != nil {
+			return cvemodel.ImageCVESummary{}, false
+		}
+
+		for _, manifest := range indexContent.Manifests {
+			scannable, err := cveinfo.Scanner.IsImageMediaScannable(repo, manifest.Digest.String(), manifest.MediaType)
+			if err != nil || !scannable {
+				continue
+			}
+
+			manifestCVEMap, ok := cveinfo.Scanner.GetCachedResult(manifest.Digest.String())
+			if !ok {
+				return cvemodel.ImageCVESummary{}, false
+			}
+
+			for cveID, cve := range manifestCVEMap {
+				cveMap[cveID] = cve
+			}
+		}
+	default:
+		var ok bool
+
+		cveMap, ok = cveinfo.Scanner.GetCachedResult(digest)
+		if !ok {
+			return cvemodel.ImageCVESummary{}, false
+		}
 	}
 
-	imageCVESummary.MaxSeverity = "UNKNOWN"
-	for _, cve := range cveMap {
+	return cveinfo.summarizeCVEs(cveMap, cveinfo.getCVEAcknowledgements(repo, digest)), true
+}
+
+// summarizeCVEs counts the CVEs of an image and finds their max severity, acknowledged CVEs are left out.
+func (cveinfo BaseCveInfo) summarizeCVEs(cveMap map[string]cvemodel.CVE,
+	acknowledgements map[string]repodb.CVEAcknowledgement,
+) cvemodel.ImageCVESummary {
+	imageCVESummary := cvemodel.ImageCVESummary{
+		Count:       0,
+		MaxSeverity: "NONE",
+	}
+
+	for cveID, cve := range cveMap {
+		if _, acknowledged := acknowledgements[cveID]; acknowledged {
+			continue
+		}
+
+		if imageCVESummary.Count == 0 {
+			imageCVESummary.MaxSeverity = "UNKNOWN"
+			imageCVESummary.CountBySeverity = map[string]int{}
+		}
+
+		imageCVESummary.Count++
+		imageCVESummary.CountBySeverity[cve.Severity]++
+
 		if cveinfo.Scanner.CompareSeverities(imageCVESummary.MaxSeverity, cve.Severity) > 0 {
 			imageCVESummary.MaxSeverity = cve.Severity
 		}
 	}
 
-	return imageCVESummary, nil
+	return imageCVESummary
+}
+
+// recordVulnerabilitySummary records in the repoDB the summary of an image scan when it completes, along with
+// the severity of each CVE found, so the CVEs acknowledged afterwards are left out when filtering images.
+func (cveinfo BaseCveInfo) recordVulnerabilitySummary(repo, digest string, cveMap map[string]cvemodel.CVE) {
+	if cveinfo.RepoDB == nil {
+		return
+	}
+
+	summary := cveinfo.summarizeCVEs(cveMap, cveinfo.getCVEAcknowledgements(repo, digest))
+
+	severities := make(map[string]string, len(cveMap))
+
+	for cveID, cve := range cveMap {
+		severities[cveID] = cve.Severity
+	}
+
+	err := cveinfo.RepoDB.SetVulnerabilitySummary(repo, godigest.Digest(digest), repodb.VulnerabilitySummary{
+		MaxSeverity: summary.MaxSeverity,
+		Count:       summary.Count,
+		Severities:  severities,
+		UpdatedAt:   time.Now(),
+	})
+	if err != nil {
+		// the image may have been deleted while it was scanned
+		cveinfo.Log.Error().Err(err).Str("image", repo+"@"+digest).Msg("unable to record vulnerability summary")
+	}
+}
+
+// getCVEAcknowledgements returns the acknowledgements of the image a tag or digest points to which
+// haven't expired.
+func (cveinfo BaseCveInfo) getCVEAcknowledgements(repo, ref string) map[string]repodb.CVEAcknowledgement {
+	repoMeta, digest, found := cveinfo.getImageRepoMeta(repo, ref)
+	if !found {
+		return map[string]repodb.CVEAcknowledgement{}
+	}
+
+	return repodb.GetActiveCVEAcknowledgements(repoMeta, digest, time.Now())
+}
+
+// getImageRepoMeta returns the metadata of the repo and the digest a tag or digest points to.
+func (cveinfo BaseCveInfo) getImageRepoMeta(repo, ref string) (repodb.RepoMetadata, string, bool) {
+	if cveinfo.RepoDB == nil {
+		return repodb.RepoMetadata{}, "", false
+	}
+
+	repoMeta, err := cveinfo.RepoDB.GetRepoMeta(repo)
+	if err != nil {
+		return repodb.RepoMetadata{}, "", false
+	}
+
+	digest := ref
+
+	if descriptor, ok := repoMeta.Tags[ref]; ok {
+		digest = descriptor.Digest
+	}
+
+	return repoMeta, digest, true
 }
 
 func (cveinfo BaseCveInfo) UpdateDB() error {
 	return cveinfo.Scanner.UpdateDB()
 }
 
+func (cveinfo BaseCveInfo) UpdateJavaDB() error {
+	return cveinfo.Scanner.UpdateJavaDB()
+}
+
 func (cveinfo BaseCveInfo) CompareSeverities(severity1, severity2 string) int {
 	return cveinfo.Scanner.CompareSeverities(severity1, severity2)
 }
 
+func (cveinfo BaseCveInfo) CancelScans(repo, digest string) int {
+	return cveinfo.Scanner.CancelScans(repo, digest)
+}
+
+func (cveinfo BaseCveInfo) OnScanCompleted(hook cvemodel.ScanCompletedFunc) {
+	cveinfo.Scanner.OnScanCompleted(hook)
+}
+
+func (cveinfo BaseCveInfo) GetScanQueue() []cvemodel.ScanStatus {
+	return cveinfo.Scanner.GetScanQueue()
+}
+
+func (cveinfo BaseCveInfo) GetDBStatus() []cvemodel.DBStatus {
+	return cveinfo.Scanner.GetDBStatus()
+}
+
 func GetFixedTags(allTags, vulnerableTags []cvemodel.TagInfo) []cvemodel.TagInfo {
 	sort.Slice(allTags, func(i, j int) bool {
 		return allTags[i].Timestamp.Before(allTags[j].Timestamp)
@@ -458,11 +637,11 @@ func GetFixedTags(allTags, vulnerableTags []cvemodel.TagInfo) []cvemodel.TagInfo
 		vulnerableTagMap[tag.Tag] = tag
 
 		switch tag.Descriptor.MediaType {
-		case ispec.MediaTypeImageManifest:
+		case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 			if tag.Timestamp.Before(earliestVulnerable.Timestamp) {
 				earliestVulnerable = tag
 			}
-		case ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 			for _, manifestDesc := range tag.Manifests {
 				if manifestDesc.Timestamp.Before(earliestVulnerable.Timestamp) {
 					earliestVulnerable = tag
@@ -482,7 +661,7 @@ func GetFixedTags(allTags, vulnerableTags []cvemodel.TagInfo) []cvemodel.TagInfo
 	// newer images which don't
 	for _, tag := range allTags {
 		switch tag.Descriptor.MediaType {
-		case ispec.MediaTypeImageManifest:
+		case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 			if tag.Timestamp.Before(earliestVulnerable.Timestamp) {
 				// The vulnerability did not exist at the time this
 				// image was built
@@ -494,7 +673,7 @@ func GetFixedTags(allTags, vulnerableTags []cvemodel.TagInfo) []cvemodel.TagInfo
 			if _, ok := vulnerableTagMap[tag.Tag]; !ok {
 				fixedTags = append(fixedTags, tag)
 			}
-		case ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 			fixedManifests := []cvemodel.DescriptorInfo{}
 
 			// If the latest update inside the index is before the earliest vulnerability found then
@@ -1,13 +1,19 @@
 package cveinfo
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
+	godigest "github.com/opencontainers/go-digest"
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	. "github.com/smartystreets/goconvey/convey"
 
+	zerr "zotregistry.io/zot/errors"
 	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/test/mocks"
 )
 
 func TestUtils(t *testing.T) {
@@ -63,3 +69,123 @@ func TestUtils(t *testing.T) {
 		})
 	})
 }
+
+func TestRecordVulnerabilitySummary(t *testing.T) {
+	Convey("The summary of a scan is recorded when it completes", t, func() {
+		digest := godigest.FromString("manifest")
+		recorded := map[string]repodb.VulnerabilitySummary{}
+
+		repoDB := mocks.RepoDBMock{
+			GetRepoMetaFn: func(repo string) (repodb.RepoMetadata, error) {
+				return repodb.RepoMetadata{
+					CVEAcknowledgements: map[string]map[string]repodb.CVEAcknowledgement{
+						digest.String(): {"CVE2": {Reason: "not reachable"}},
+					},
+				}, nil
+			},
+			SetVulnerabilitySummaryFn: func(repo string, digest godigest.Digest,
+				summary repodb.VulnerabilitySummary,
+			) error {
+				recorded[repo+"@"+digest.String()] = summary
+
+				return nil
+			},
+		}
+
+		cveInfo := BaseCveInfo{Log: log.NewLogger("debug", ""), RepoDB: repoDB, Scanner: mocks.CveScannerMock{
+			CompareSeveritiesFn: func(severity1, severity2 string) int {
+				return map[string]int{"UNKNOWN": 0, "LOW": 1, "HIGH": 2}[severity2] -
+					map[string]int{"UNKNOWN": 0, "LOW": 1, "HIGH": 2}[severity1]
+			},
+		}}
+
+		cveInfo.recordVulnerabilitySummary("repo", digest.String(), map[string]cvemodel.CVE{
+			"CVE1": {ID: "CVE1", Severity: "LOW"},
+			"CVE2": {ID: "CVE2", Severity: "HIGH"},
+		})
+
+		summary := recorded["repo@"+digest.String()]
+		So(summary.MaxSeverity, ShouldEqual, "LOW")
+		So(summary.Count, ShouldEqual, 1)
+		So(summary.Severities, ShouldResemble, map[string]string{"CVE1": "LOW", "CVE2": "HIGH"})
+		So(summary.UpdatedAt, ShouldNotBeZeroValue)
+
+		Convey("Failing to record it is logged", func() {
+			repoDB.SetVulnerabilitySummaryFn = func(repo string, digest godigest.Digest,
+				summary repodb.VulnerabilitySummary,
+			) error {
+				return zerr.ErrManifestMetaNotFound
+			}
+
+			cveInfo.RepoDB = repoDB
+
+			So(func() { cveInfo.recordVulnerabilitySummary("repo", digest.String(), nil) }, ShouldNotPanic)
+		})
+	})
+}
+
+func TestGetCachedCVESummary(t *testing.T) {
+	Convey("Summarize the cached scans of images", t, func() {
+		scannedDigest := godigest.FromString("scanned")
+		otherDigest := godigest.FromString("other")
+		indexDigest := godigest.FromString("index")
+
+		cached := map[string]map[string]cvemodel.CVE{
+			scannedDigest.String(): {"CVE1": {ID: "CVE1", Severity: "HIGH"}},
+		}
+
+		index := ispec.Index{Manifests: []ispec.Descriptor{
+			{Digest: scannedDigest, MediaType: ispec.MediaTypeImageManifest},
+			{Digest: otherDigest, MediaType: ispec.MediaTypeImageManifest},
+		}}
+
+		indexBlob, err := json.Marshal(index)
+		So(err, ShouldBeNil)
+
+		cveInfo := BaseCveInfo{
+			Log: log.NewLogger("debug", ""),
+			RepoDB: mocks.RepoDBMock{
+				GetIndexDataFn: func(digest godigest.Digest) (repodb.IndexData, error) {
+					if digest != indexDigest {
+						return repodb.IndexData{}, zerr.ErrManifestMetaNotFound
+					}
+
+					return repodb.IndexData{IndexBlob: indexBlob}, nil
+				},
+			},
+			Scanner: mocks.CveScannerMock{
+				GetCachedResultFn: func(digest string) (map[string]cvemodel.CVE, bool) {
+					cveMap, ok := cached[digest]
+
+					return cveMap, ok
+				},
+				CompareSeveritiesFn: func(severity1, severity2 string) int {
+					return map[string]int{"UNKNOWN": 0, "LOW": 1, "HIGH": 2}[severity2] -
+						map[string]int{"UNKNOWN": 0, "LOW": 1, "HIGH": 2}[severity1]
+				},
+			},
+		}
+
+		summary, ok := cveInfo.GetCachedCVESummary("repo", scannedDigest.String(), ispec.MediaTypeImageManifest)
+		So(ok, ShouldBeTrue)
+		So(summary.Count, ShouldEqual, 1)
+		So(summary.MaxSeverity, ShouldEqual, "HIGH")
+
+		_, ok = cveInfo.GetCachedCVESummary("repo", otherDigest.String(), ispec.MediaTypeImageManifest)
+		So(ok, ShouldBeFalse)
+
+		// the result of an index is unknown until all its manifests are scanned
+		_, ok = cveInfo.GetCachedCVESummary("repo", indexDigest.String(), ispec.MediaTypeImageIndex)
+		So(ok, ShouldBeFalse)
+
+		cached[otherDigest.String()] = map[string]cvemodel.CVE{"CVE2": {ID: "CVE2", Severity: "LOW"}}
+
+		summary, ok = cveInfo.GetCachedCVESummary("repo", indexDigest.String(), ispec.MediaTypeImageIndex)
+		So(ok, ShouldBeTrue)
+		So(summary.Count, ShouldEqual, 2)
+		So(summary.MaxSeverity, ShouldEqual, "HIGH")
+
+		_, ok = cveInfo.GetCachedCVESummary("repo", otherDigest.String(), ispec.MediaTypeImageIndex)
+		So(ok, ShouldBeFalse)
+	})
+}
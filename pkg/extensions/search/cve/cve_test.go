@@ -331,7 +331,7 @@ func TestImageFormat(t *testing.T) {
 		err = repodb.ParseStorage(repoDB, storeController, log)
 		So(err, ShouldBeNil)
 
-		cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", log)
+		cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", "", 1, nil, log)
 
 		isValidImage, err := cveInfo.Scanner.IsImageFormatScannable("zot-test", "")
 		So(err, ShouldNotBeNil)
@@ -400,7 +400,7 @@ func TestImageFormat(t *testing.T) {
 			DefaultStore: mocks.MockedImageStore{},
 		}
 
-		cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", log)
+		cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", "", 1, nil, log)
 
 		isScanable, err := cveInfo.Scanner.IsImageFormatScannable("repo", "tag")
 		So(err, ShouldBeNil)
@@ -1201,6 +1201,7 @@ func TestCVEStruct(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(cveSummary.Count, ShouldEqual, 3)
 		So(cveSummary.MaxSeverity, ShouldEqual, "HIGH")
+		So(cveSummary.CountBySeverity, ShouldResemble, map[string]int{"MEDIUM": 1, "HIGH": 1, "LOW": 1})
 
 		cveSummary, err = cveInfo.GetCVESummaryForImage("repo1", "1.0.1")
 		So(err, ShouldBeNil)
@@ -1471,6 +1472,52 @@ func TestCVEStruct(t *testing.T) {
 
 		_, err = cveInfo.GetImageListForCVE("repoIndex", "CVE1")
 		So(err, ShouldBeNil)
+		t.Log("Test acknowledged CVEs")
+
+		cveInfo = cveinfo.BaseCveInfo{Log: log, Scanner: scanner, RepoDB: repoDB}
+
+		_, err = repoDB.SetCVEAcknowledgement("repo1", "1.0.0", "CVE2", repodb.CVEAcknowledgement{
+			Reason:         "not reachable",
+			AcknowledgedBy: "admin",
+			AcknowledgedAt: time.Now(),
+		})
+		So(err, ShouldBeNil)
+
+		_, err = repoDB.SetCVEAcknowledgement("repo1", "1.0.0", "CVE1", repodb.CVEAcknowledgement{
+			Reason:    "expired",
+			ExpiresAt: time.Now().Add(-time.Hour),
+		})
+		So(err, ShouldBeNil)
+
+		// the acknowledged CVE is not counted in the summary, the expired acknowledgement is ignored
+		cveSummary, err = cveInfo.GetCVESummaryForImage("repo1", "1.0.0")
+		So(err, ShouldBeNil)
+		So(cveSummary.Count, ShouldEqual, 2)
+		So(cveSummary.MaxSeverity, ShouldEqual, "MEDIUM")
+
+		cveList, _, err = cveInfo.GetCVEListForImage("repo1", "1.0.0", "", pageInput)
+		So(err, ShouldBeNil)
+		So(len(cveList), ShouldEqual, 3)
+
+		for _, cve := range cveList {
+			if cve.ID == "CVE2" {
+				So(cve.Acknowledgement, ShouldNotBeNil)
+				So(cve.Acknowledgement.Reason, ShouldEqual, "not reachable")
+				So(cve.Acknowledgement.ExpiresAt, ShouldBeNil)
+			} else {
+				So(cve.Acknowledgement, ShouldBeNil)
+			}
+		}
+
+		_, err = repoDB.SetCVEAcknowledgement("repo1", "1.1.0", "CVE3", repodb.CVEAcknowledgement{
+			Reason: "not reachable",
+		})
+		So(err, ShouldBeNil)
+
+		cveSummary, err = cveInfo.GetCVESummaryForImage("repo1", "1.1.0")
+		So(err, ShouldBeNil)
+		So(cveSummary.Count, ShouldEqual, 0)
+		So(cveSummary.MaxSeverity, ShouldEqual, "NONE")
 	})
 }
 
@@ -1598,12 +1645,14 @@ func TestFixedTagsWithIndex(t *testing.T) {
 		err = UploadImage(simpleVulnImg, baseURL, "repo")
 		So(err, ShouldBeNil)
 
-		scanner := trivy.NewScanner(ctlr.StoreController, ctlr.RepoDB, "ghcr.io/project-zot/trivy-db", "", ctlr.Log)
+		scanner := trivy.NewScanner(ctlr.StoreController, ctlr.RepoDB, "ghcr.io/project-zot/trivy-db", "", "", 1, nil,
+			ctlr.Log)
 
 		err = scanner.UpdateDB()
 		So(err, ShouldBeNil)
 
-		cveInfo := cveinfo.NewCVEInfo(ctlr.StoreController, ctlr.RepoDB, "ghcr.io/project-zot/trivy-db", "", ctlr.Log)
+		cveInfo := cveinfo.NewCVEInfo(ctlr.StoreController, ctlr.RepoDB, "ghcr.io/project-zot/trivy-db", "", "", 1, nil,
+			ctlr.Log)
 
 		tagsInfo, err := cveInfo.GetImageListWithCVEFixed("repo", Vulnerability1ID)
 		So(err, ShouldBeNil)
@@ -1665,7 +1714,7 @@ func TestImageListWithCVEFixedErrors(t *testing.T) {
 				return repodb.IndexData{}, zerr.ErrIndexDataNotFount
 			}
 
-			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", log)
+			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", "", 1, nil, log)
 
 			_, err := cveInfo.GetImageListWithCVEFixed("repo", Vulnerability1ID)
 			So(err, ShouldBeNil)
@@ -1686,7 +1735,7 @@ func TestImageListWithCVEFixedErrors(t *testing.T) {
 				return repodb.IndexData{}, zerr.ErrIndexDataNotFount
 			}
 
-			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", log)
+			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", "", 1, nil, log)
 
 			_, err := cveInfo.GetImageListWithCVEFixed("repo", Vulnerability1ID)
 			So(err, ShouldBeNil)
@@ -1707,7 +1756,7 @@ func TestImageListWithCVEFixedErrors(t *testing.T) {
 				return repodb.IndexData{IndexBlob: []byte(`bad index`)}, nil
 			}
 
-			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", log)
+			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", "", 1, nil, log)
 
 			_, err := cveInfo.GetImageListWithCVEFixed("repo", Vulnerability1ID)
 			So(err, ShouldBeNil)
@@ -1725,7 +1774,7 @@ func TestImageListWithCVEFixedErrors(t *testing.T) {
 				}, nil
 			}
 
-			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", log)
+			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", "", 1, nil, log)
 
 			_, err := cveInfo.GetImageListWithCVEFixed("repo", Vulnerability1ID)
 			So(err, ShouldBeNil)
@@ -1757,7 +1806,7 @@ func TestImageListWithCVEFixedErrors(t *testing.T) {
 				return repodb.ManifestData{}, zerr.ErrManifestDataNotFound
 			}
 
-			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", log)
+			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", "", 1, nil, log)
 
 			tagsInfo, err := cveInfo.GetImageListWithCVEFixed("repo", Vulnerability1ID)
 			So(err, ShouldBeNil)
@@ -1776,7 +1825,7 @@ func TestImageListWithCVEFixedErrors(t *testing.T) {
 				}, nil
 			}
 
-			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", log)
+			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", "", 1, nil, log)
 
 			tagsInfo, err := cveInfo.GetImageListWithCVEFixed("repo", Vulnerability1ID)
 			So(err, ShouldBeNil)
@@ -1794,7 +1843,7 @@ func TestGetCVESummaryForImageMediaErrors(t *testing.T) {
 		log := log.NewLogger("debug", "")
 
 		Convey("IsImageMediaScannable returns false", func() {
-			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", log)
+			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", "", 1, nil, log)
 			cveInfo.Scanner = mocks.CveScannerMock{
 				IsImageMediaScannableFn: func(repo, digest, mediaType string) (bool, error) {
 					return false, zerr.ErrScanNotSupported
@@ -1806,7 +1855,7 @@ func TestGetCVESummaryForImageMediaErrors(t *testing.T) {
 		})
 
 		Convey("Scan fails", func() {
-			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", log)
+			cveInfo := cveinfo.NewCVEInfo(storeController, repoDB, "", "", "", 1, nil, log)
 			cveInfo.Scanner = mocks.CveScannerMock{
 				IsImageMediaScannableFn: func(repo, digest, mediaType string) (bool, error) {
 					return true, nil
@@ -0,0 +1,353 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	guuid "github.com/gofrs/uuid"
+
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+)
+
+const (
+	FormatSARIF = "sarif"
+	FormatVEX   = "vex"
+
+	SARIFMediaType = "application/sarif+json"
+	// VEXMediaType is the media type of the VEX documents, and the artifact type of the VEX documents
+	// attached to images as referrers.
+	VEXMediaType = "application/vnd.cyclonedx+json"
+
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+
+	cycloneDXSpecVersion = "1.5"
+
+	toolName = "zot"
+	toolURI  = "https://zotregistry.io"
+)
+
+// Image is the image the CVEs were found in.
+type Image struct {
+	Repo      string
+	Reference string
+	Digest    string
+}
+
+func (image Image) name() string {
+	if image.Reference == "" || image.Reference == image.Digest {
+		return image.Repo + "@" + image.Digest
+	}
+
+	return image.Repo + ":" + image.Reference
+}
+
+//nolint:tagliatelle // SARIF schema
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID               string          `json:"id"`
+	ShortDescription SARIFMessage    `json:"shortDescription"`
+	FullDescription  SARIFMessage    `json:"fullDescription"`
+	Properties       SARIFProperties `json:"properties"`
+}
+
+//nolint:tagliatelle // SARIF schema
+type SARIFProperties struct {
+	// severity score code scanning UIs rank the rules with
+	SecuritySeverity string   `json:"security-severity"`
+	Tags             []string `json:"tags"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   SARIFMessage    `json:"message"`
+	Locations []SARIFLocation `json:"locations"`
+	// set for the CVEs acknowledged for the image
+	Suppressions []SARIFSuppression `json:"suppressions,omitempty"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SARIFSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification"`
+}
+
+// SARIF returns the SARIF log of the CVEs found in an image, each CVE is a rule and each vulnerable package
+// a result located in the image. Acknowledged CVEs are reported as suppressed.
+func SARIF(image Image, cves []cvemodel.CVE) SARIFLog {
+	cves = sortCVEs(cves)
+
+	rules := make([]SARIFRule, 0, len(cves))
+	results := []SARIFResult{}
+	location := SARIFLocation{
+		PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: image.name()}},
+	}
+
+	for ruleIndex, cve := range cves {
+		rules = append(rules, SARIFRule{
+			ID:               cve.ID,
+			ShortDescription: SARIFMessage{Text: firstNonEmpty(cve.Title, cve.ID)},
+			FullDescription:  SARIFMessage{Text: firstNonEmpty(cve.Description, cve.Title, cve.ID)},
+			Properties: SARIFProperties{
+				SecuritySeverity: securitySeverity(cve.Severity),
+				Tags:             []string{"vulnerability", "security", strings.ToUpper(cve.Severity)},
+			},
+		})
+
+		var suppressions []SARIFSuppression
+
+		if cve.Acknowledgement != nil {
+			suppressions = []SARIFSuppression{{Kind: "external", Justification: cve.Acknowledgement.Reason}}
+		}
+
+		for _, pkg := range cve.PackageList {
+			results = append(results, SARIFResult{
+				RuleID:    cve.ID,
+				RuleIndex: ruleIndex,
+				Level:     sarifLevel(cve.Severity),
+				Message: SARIFMessage{
+					Text: fmt.Sprintf("Package: %s\nInstalled Version: %s\nVulnerability: %s\nSeverity: %s\n"+
+						"Fixed Version: %s\nImage: %s", pkg.Name, pkg.InstalledVersion, cve.ID, cve.Severity,
+						pkg.FixedVersion, image.name()),
+				},
+				Locations:    []SARIFLocation{location},
+				Suppressions: suppressions,
+			})
+		}
+	}
+
+	return SARIFLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []SARIFRun{{
+			Tool:    SARIFTool{Driver: SARIFDriver{Name: toolName, InformationURI: toolURI, Rules: rules}},
+			Results: results,
+		}},
+	}
+}
+
+//nolint:tagliatelle // CycloneDX schema
+type VEXDocument struct {
+	BOMFormat       string             `json:"bomFormat"`
+	SpecVersion     string             `json:"specVersion"`
+	SerialNumber    string             `json:"serialNumber"`
+	Version         int                `json:"version"`
+	Metadata        VEXMetadata        `json:"metadata"`
+	Components      []VEXComponent     `json:"components"`
+	Vulnerabilities []VEXVulnerability `json:"vulnerabilities"`
+}
+
+type VEXMetadata struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Tools     []VEXTool    `json:"tools"`
+	Component VEXComponent `json:"component"`
+}
+
+type VEXTool struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+}
+
+//nolint:tagliatelle // CycloneDX schema
+type VEXComponent struct {
+	Type    string `json:"type"`
+	BOMRef  string `json:"bom-ref"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+//nolint:tagliatelle // CycloneDX schema
+type VEXVulnerability struct {
+	BOMRef      string        `json:"bom-ref"`
+	ID          string        `json:"id"`
+	Description string        `json:"description,omitempty"`
+	Ratings     []VEXRating   `json:"ratings"`
+	Affects     []VEXAffects  `json:"affects"`
+	Analysis    VEXAnalysis   `json:"analysis"`
+	Properties  []VEXProperty `json:"properties,omitempty"`
+}
+
+type VEXRating struct {
+	Severity string `json:"severity"`
+}
+
+type VEXAffects struct {
+	Ref string `json:"ref"`
+}
+
+type VEXAnalysis struct {
+	State  string `json:"state"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type VEXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// VEX returns a CycloneDX VEX document of the CVEs found in an image, the vulnerable packages are the
+// components of the image the CVEs affect. Acknowledged CVEs are reported as not affecting the image with
+// the reason they were acknowledged for, the others as being triaged.
+func VEX(image Image, cves []cvemodel.CVE, now time.Time) VEXDocument {
+	cves = sortCVEs(cves)
+
+	imageRef := image.Repo + "@" + image.Digest
+	components := []VEXComponent{}
+	vulnerabilities := make([]VEXVulnerability, 0, len(cves))
+	componentRefs := map[string]bool{}
+
+	for _, cve := range cves {
+		vulnerability := VEXVulnerability{
+			BOMRef:      cve.ID,
+			ID:          cve.ID,
+			Description: firstNonEmpty(cve.Description, cve.Title),
+			Ratings:     []VEXRating{{Severity: vexSeverity(cve.Severity)}},
+			Affects:     []VEXAffects{},
+			Analysis:    VEXAnalysis{State: "in_triage"},
+		}
+
+		if cve.Acknowledgement != nil {
+			vulnerability.Analysis = VEXAnalysis{State: "not_affected", Detail: cve.Acknowledgement.Reason}
+		}
+
+		for _, pkg := range cve.PackageList {
+			componentRef := pkg.Name + "@" + pkg.InstalledVersion
+
+			if !componentRefs[componentRef] {
+				componentRefs[componentRef] = true
+
+				components = append(components, VEXComponent{
+					Type:    "library",
+					BOMRef:  componentRef,
+					Name:    pkg.Name,
+					Version: pkg.InstalledVersion,
+				})
+			}
+
+			vulnerability.Affects = append(vulnerability.Affects, VEXAffects{Ref: componentRef})
+
+			if pkg.FixedVersion != "" && pkg.FixedVersion != "Not Specified" {
+				vulnerability.Properties = append(vulnerability.Properties, VEXProperty{
+					Name:  "zot:fixedVersion:" + pkg.Name,
+					Value: pkg.FixedVersion,
+				})
+			}
+		}
+
+		vulnerabilities = append(vulnerabilities, vulnerability)
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].BOMRef < components[j].BOMRef
+	})
+
+	return VEXDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  cycloneDXSpecVersion,
+		SerialNumber: "urn:uuid:" + guuid.Must(guuid.NewV4()).String(),
+		Version:      1,
+		Metadata: VEXMetadata{
+			Timestamp: now.UTC(),
+			Tools:     []VEXTool{{Vendor: toolName, Name: toolName}},
+			Component: VEXComponent{Type: "container", BOMRef: imageRef, Name: image.Repo, Version: image.Digest},
+		},
+		Components:      components,
+		Vulnerabilities: vulnerabilities,
+	}
+}
+
+func sortCVEs(cves []cvemodel.CVE) []cvemodel.CVE {
+	sorted := append([]cvemodel.CVE{}, cves...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	return sorted
+}
+
+// sarifLevel maps the severity of a CVE to the level of its SARIF results.
+func sarifLevel(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL", "HIGH":
+		return "error"
+	case "MEDIUM":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// securitySeverity maps the severity of a CVE to a CVSS like score, code scanning UIs rank it with.
+func securitySeverity(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return "9.5"
+	case "HIGH":
+		return "8.0"
+	case "MEDIUM":
+		return "5.5"
+	case "LOW":
+		return "2.0"
+	default:
+		return "0.0"
+	}
+}
+
+func vexSeverity(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL", "HIGH", "MEDIUM", "LOW", "NONE":
+		return strings.ToLower(severity)
+	default:
+		return "unknown"
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
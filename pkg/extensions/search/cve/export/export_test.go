@@ -0,0 +1,83 @@
+package export_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.io/zot/pkg/extensions/search/cve/export"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+)
+
+func TestExport(t *testing.T) {
+	image := export.Image{Repo: "repo", Reference: "sha256:123", Digest: "sha256:123"}
+	cves := []cvemodel.CVE{
+		{
+			ID:       "CVE-3",
+			Severity: "LOW",
+			PackageList: []cvemodel.Package{
+				{Name: "lib", InstalledVersion: "1.0", FixedVersion: "Not Specified"},
+				{Name: "other", InstalledVersion: "2.0", FixedVersion: "2.1"},
+			},
+		},
+		{
+			ID:          "CVE-1",
+			Severity:    "HIGH",
+			Description: "description",
+			PackageList: []cvemodel.Package{{Name: "lib", InstalledVersion: "1.0", FixedVersion: "1.1"}},
+		},
+	}
+
+	Convey("SARIF", t, func() {
+		sarifLog := export.SARIF(image, cves)
+		So(len(sarifLog.Runs), ShouldEqual, 1)
+
+		rules := sarifLog.Runs[0].Tool.Driver.Rules
+		So(len(rules), ShouldEqual, 2)
+		So(rules[0].ID, ShouldEqual, "CVE-1")
+		So(rules[0].ShortDescription.Text, ShouldEqual, "CVE-1")
+		So(rules[0].FullDescription.Text, ShouldEqual, "description")
+		So(rules[0].Properties.SecuritySeverity, ShouldEqual, "8.0")
+		So(rules[1].Properties.SecuritySeverity, ShouldEqual, "2.0")
+
+		results := sarifLog.Runs[0].Results
+		So(len(results), ShouldEqual, 3)
+		So(results[0].Level, ShouldEqual, "error")
+		So(results[1].RuleIndex, ShouldEqual, 1)
+		So(results[1].Level, ShouldEqual, "note")
+		So(results[0].Suppressions, ShouldBeEmpty)
+		So(results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI, ShouldEqual, "repo@sha256:123")
+		So(strings.Contains(results[2].Message.Text, "Package: other"), ShouldBeTrue)
+
+		// the CVEs passed in are left unsorted
+		So(cves[0].ID, ShouldEqual, "CVE-3")
+	})
+
+	Convey("VEX", t, func() {
+		now := time.Now()
+
+		vex := export.VEX(image, cves, now)
+		So(vex.SpecVersion, ShouldEqual, "1.5")
+		So(strings.HasPrefix(vex.SerialNumber, "urn:uuid:"), ShouldBeTrue)
+		So(vex.Metadata.Timestamp.Equal(now), ShouldBeTrue)
+		So(vex.Metadata.Component.BOMRef, ShouldEqual, "repo@sha256:123")
+
+		// the components affected by several CVEs are listed once
+		So(len(vex.Components), ShouldEqual, 2)
+		So(vex.Components[0].BOMRef, ShouldEqual, "lib@1.0")
+
+		So(len(vex.Vulnerabilities), ShouldEqual, 2)
+		So(vex.Vulnerabilities[0].ID, ShouldEqual, "CVE-1")
+		So(vex.Vulnerabilities[0].Ratings[0].Severity, ShouldEqual, "high")
+		So(vex.Vulnerabilities[0].Analysis.State, ShouldEqual, "in_triage")
+		So(len(vex.Vulnerabilities[1].Affects), ShouldEqual, 2)
+		So(len(vex.Vulnerabilities[1].Properties), ShouldEqual, 1)
+		So(vex.Vulnerabilities[1].Properties[0].Value, ShouldEqual, "2.1")
+
+		vex = export.VEX(image, []cvemodel.CVE{{ID: "CVE-4", Severity: "SOMETHING"}}, now)
+		So(vex.Vulnerabilities[0].Ratings[0].Severity, ShouldEqual, "unknown")
+		So(vex.Components, ShouldBeEmpty)
+	})
+}
@@ -9,6 +9,8 @@ import (
 type ImageCVESummary struct {
 	Count       int
 	MaxSeverity string
+	// number of CVEs of each severity, not set if the image has no CVEs
+	CountBySeverity map[string]int
 }
 
 //nolint:tagliatelle // graphQL schema
@@ -18,6 +20,15 @@ type CVE struct {
 	Severity    string    `json:"Severity"`
 	Title       string    `json:"Title"`
 	PackageList []Package `json:"PackageList"`
+	// set if the CVE was acknowledged for the image and the acknowledgement didn't expire
+	Acknowledgement *Acknowledgement `json:"Acknowledgement,omitempty"`
+}
+
+//nolint:tagliatelle // graphQL schema
+type Acknowledgement struct {
+	Reason         string     `json:"Reason"`
+	AcknowledgedBy string     `json:"AcknowledgedBy"`
+	ExpiresAt      *time.Time `json:"ExpiresAt,omitempty"`
 }
 
 //nolint:tagliatelle // graphQL schema
@@ -27,6 +38,41 @@ type Package struct {
 	FixedVersion     string `json:"FixedVersion"`
 }
 
+const (
+	ScanStateQueued  = "queued"
+	ScanStateRunning = "running"
+)
+
+// ScanStatus is a scan waiting in the scan queue or being run.
+type ScanStatus struct {
+	Repo      string     `json:"repo"`
+	Digest    string     `json:"digest"`
+	State     string     `json:"state"`
+	QueuedAt  time.Time  `json:"queuedAt"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+}
+
+// ScanCompletedFunc is called with the vulnerabilities found each time the scan of an image completes.
+type ScanCompletedFunc func(repo, digest string, cveMap map[string]CVE)
+
+const (
+	DBNameTrivy       = "trivy-db"
+	DBNameTrivyJavaDB = "trivy-java-db"
+)
+
+// DBStatus is the state of a vulnerability database used by the scanner.
+type DBStatus struct {
+	Name string `json:"name"`
+	// repository the database is downloaded from, or directory it is loaded from if offline
+	Source  string `json:"source"`
+	Offline bool   `json:"offline"`
+	// time the last update succeeded, not set if the database wasn't updated since the registry started
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+	LastAttempt *time.Time `json:"lastAttempt,omitempty"`
+	// error of the last update, if it failed
+	Error string `json:"error,omitempty"`
+}
+
 const (
 	None = iota
 	Low
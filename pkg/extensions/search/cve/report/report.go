@@ -0,0 +1,337 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	zerr "zotregistry.io/zot/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
+	cveinfo "zotregistry.io/zot/pkg/extensions/search/cve"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+// FileName is the file, under the root directory of the default store, where the last report is kept
+// so the trend of the next one can be computed after a restart.
+const FileName = "cve-report.json"
+
+const webhookTimeout = 30 * time.Second
+
+// Severities are the CVE severities counted in the reports, from the highest to the lowest.
+var Severities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "UNKNOWN"} //nolint:gochecknoglobals
+
+// NamespaceReport sums up the CVEs of the images of the repos in a namespace, the namespace of a repo
+// is the first component of its name, repos without one are in the empty namespace.
+type NamespaceReport struct {
+	Namespace    string `json:"namespace"`
+	Repositories int    `json:"repositories"`
+	Images       int    `json:"images"`
+	// images which couldn't be scanned, their CVEs are not counted
+	NotScanned int            `json:"notScanned"`
+	Counts     map[string]int `json:"counts"`
+	Total      int            `json:"total"`
+	// difference with the counts of the previous report
+	Trend      map[string]int `json:"trend"`
+	TotalTrend int            `json:"totalTrend"`
+}
+
+type Report struct {
+	GeneratedAt         time.Time         `json:"generatedAt"`
+	PreviousGeneratedAt *time.Time        `json:"previousGeneratedAt,omitempty"`
+	Namespaces          []NamespaceReport `json:"namespaces"`
+}
+
+// Reporter periodically sums up the CVEs of all the images by namespace, acknowledged CVEs are not
+// counted. The last report is served by the API and optionally posted to a webhook.
+type Reporter struct {
+	cveInfo  cveinfo.CveInfo
+	repoDB   repodb.RepoDB
+	webhook  string
+	client   *http.Client
+	filePath string
+	last     *Report
+	lock     *sync.RWMutex
+	log      log.Logger
+}
+
+// NewReporter creates a reporter, loading the last report saved under rootDir if there is one.
+func NewReporter(cveInfo cveinfo.CveInfo, repoDB repodb.RepoDB, rootDir, webhook string, log log.Logger,
+) *Reporter {
+	reporter := &Reporter{
+		cveInfo:  cveInfo,
+		repoDB:   repoDB,
+		webhook:  webhook,
+		client:   &http.Client{Timeout: webhookTimeout},
+		filePath: path.Join(rootDir, FileName),
+		lock:     &sync.RWMutex{},
+		log:      log,
+	}
+
+	buf, err := os.ReadFile(reporter.filePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Error().Err(err).Str("file", reporter.filePath).Msg("cve report: unable to read last report")
+		}
+
+		return reporter
+	}
+
+	var report Report
+
+	if err := json.Unmarshal(buf, &report); err != nil {
+		// the trend of the next report is computed from scratch
+		log.Error().Err(err).Str("file", reporter.filePath).Msg("cve report: invalid JSON")
+
+		return reporter
+	}
+
+	reporter.last = &report
+
+	return reporter
+}
+
+// Last returns the last generated report.
+func (reporter *Reporter) Last() (Report, error) {
+	reporter.lock.RLock()
+	defer reporter.lock.RUnlock()
+
+	if reporter.last == nil {
+		return Report{}, zerr.ErrCVEReportNotGenerated
+	}
+
+	return *reporter.last, nil
+}
+
+// Generate scans the images of all the repos, saves the report and posts it to the webhook.
+func (reporter *Reporter) Generate(ctx context.Context) (Report, error) {
+	repos, err := reporter.repoDB.GetMultipleRepoMeta(ctx, func(repoMeta repodb.RepoMetadata) bool {
+		return true
+	}, repodb.PageInput{})
+	if err != nil {
+		return Report{}, err
+	}
+
+	namespaces := map[string]*NamespaceReport{}
+
+	for _, repoMeta := range repos {
+		namespace := zcommon.GetRepoNamespace(repoMeta.Name)
+
+		nsReport, ok := namespaces[namespace]
+		if !ok {
+			nsReport = &NamespaceReport{Namespace: namespace, Counts: newCounts(), Trend: newCounts()}
+			namespaces[namespace] = nsReport
+		}
+
+		nsReport.Repositories++
+
+		// images with several tags are counted once
+		scanned := map[string]bool{}
+
+		for _, descriptor := range repoMeta.Tags {
+			if scanned[descriptor.Digest] {
+				continue
+			}
+
+			scanned[descriptor.Digest] = true
+			nsReport.Images++
+
+			summary, err := reporter.cveInfo.GetCVESummaryForImageMedia(repoMeta.Name, descriptor.Digest,
+				descriptor.MediaType)
+			if err != nil || summary.MaxSeverity == "" {
+				nsReport.NotScanned++
+
+				continue
+			}
+
+			for severity, count := range summary.CountBySeverity {
+				if _, ok := nsReport.Counts[severity]; !ok {
+					severity = "UNKNOWN"
+				}
+
+				nsReport.Counts[severity] += count
+			}
+
+			nsReport.Total += summary.Count
+		}
+	}
+
+	report := Report{GeneratedAt: time.Now(), Namespaces: make([]NamespaceReport, 0, len(namespaces))}
+
+	reporter.lock.Lock()
+
+	previous := map[string]NamespaceReport{}
+
+	if reporter.last != nil {
+		previousGeneratedAt := reporter.last.GeneratedAt
+		report.PreviousGeneratedAt = &previousGeneratedAt
+
+		for _, nsReport := range reporter.last.Namespaces {
+			previous[nsReport.Namespace] = nsReport
+		}
+	}
+
+	for _, nsReport := range namespaces {
+		// namespaces which are new since the previous report trend from 0
+		for _, severity := range Severities {
+			nsReport.Trend[severity] = nsReport.Counts[severity] - previous[nsReport.Namespace].Counts[severity]
+		}
+
+		nsReport.TotalTrend = nsReport.Total - previous[nsReport.Namespace].Total
+
+		report.Namespaces = append(report.Namespaces, *nsReport)
+	}
+
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace
+	})
+
+	reporter.last = &report
+
+	reporter.lock.Unlock()
+
+	reporter.save(report)
+
+	if reporter.webhook != "" {
+		if err := reporter.post(ctx, report); err != nil {
+			reporter.log.Error().Err(err).Str("webhook", reporter.webhook).Msg("cve report: unable to post report")
+
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func newCounts() map[string]int {
+	counts := make(map[string]int, len(Severities))
+
+	for _, severity := range Severities {
+		counts[severity] = 0
+	}
+
+	return counts
+}
+
+// save writes the report under the root directory, failures are only logged since the report
+// is still served from memory.
+func (reporter *Reporter) save(report Report) {
+	buf, err := json.Marshal(report)
+	if err != nil {
+		reporter.log.Error().Err(err).Msg("cve report: unable to marshal report")
+
+		return
+	}
+
+	if err := os.MkdirAll(path.Dir(reporter.filePath), storageConstants.DefaultDirPerms); err != nil {
+		reporter.log.Error().Err(err).Str("file", reporter.filePath).Msg("cve report: unable to create report dir")
+
+		return
+	}
+
+	tmpFile := reporter.filePath + ".tmp"
+
+	if err := os.WriteFile(tmpFile, buf, storageConstants.DefaultFilePerms); err != nil {
+		reporter.log.Error().Err(err).Str("file", tmpFile).Msg("cve report: unable to write report")
+
+		return
+	}
+
+	if err := os.Rename(tmpFile, reporter.filePath); err != nil {
+		reporter.log.Error().Err(err).Str("file", reporter.filePath).Msg("cve report: unable to write report")
+	}
+}
+
+func (reporter *Reporter) post(ctx context.Context, report Report) error {
+	buf, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reporter.webhook, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := reporter.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %d", zerr.ErrCVEReportWebhookFailed, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// WriteCSV writes one line per namespace, with the count and the trend of each severity.
+func WriteCSV(writer io.Writer, report Report) error {
+	csvWriter := csv.NewWriter(writer)
+
+	header := []string{"namespace", "repositories", "images", "notScanned"}
+
+	for _, severity := range Severities {
+		header = append(header, strings.ToLower(severity))
+	}
+
+	header = append(header, "total")
+
+	for _, severity := range Severities {
+		header = append(header, strings.ToLower(severity)+"Trend")
+	}
+
+	header = append(header, "totalTrend")
+
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	for _, nsReport := range report.Namespaces {
+		record := []string{
+			nsReport.Namespace,
+			strconv.Itoa(nsReport.Repositories),
+			strconv.Itoa(nsReport.Images),
+			strconv.Itoa(nsReport.NotScanned),
+		}
+
+		for _, severity := range Severities {
+			record = append(record, strconv.Itoa(nsReport.Counts[severity]))
+		}
+
+		record = append(record, strconv.Itoa(nsReport.Total))
+
+		for _, severity := range Severities {
+			record = append(record, strconv.Itoa(nsReport.Trend[severity]))
+		}
+
+		record = append(record, strconv.Itoa(nsReport.TotalTrend))
+
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
@@ -0,0 +1,194 @@
+//go:build search
+// +build search
+
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/extensions/search/cve/report"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+var ErrTestError = errors.New("test error")
+
+func TestReporter(t *testing.T) {
+	log := log.NewLogger("debug", "")
+
+	repos := []repodb.RepoMetadata{
+		{
+			Name: "team-a/app",
+			Tags: map[string]repodb.Descriptor{
+				"1.0":    {Digest: "sha256:1", MediaType: ispec.MediaTypeImageManifest},
+				"latest": {Digest: "sha256:1", MediaType: ispec.MediaTypeImageManifest},
+				"2.0":    {Digest: "sha256:2", MediaType: ispec.MediaTypeImageManifest},
+			},
+		},
+		{
+			Name: "team-a/db",
+			Tags: map[string]repodb.Descriptor{
+				"1.0": {Digest: "sha256:3", MediaType: ispec.MediaTypeImageManifest},
+			},
+		},
+		{
+			Name: "busybox",
+			Tags: map[string]repodb.Descriptor{
+				"1.0": {Digest: "sha256:4", MediaType: ispec.MediaTypeImageManifest},
+			},
+		},
+	}
+
+	summaries := map[string]cvemodel.ImageCVESummary{
+		"sha256:1": {Count: 3, MaxSeverity: "HIGH", CountBySeverity: map[string]int{"HIGH": 1, "LOW": 2}},
+		"sha256:2": {Count: 0, MaxSeverity: "NONE"},
+		"sha256:3": {Count: 1, MaxSeverity: "CRITICAL", CountBySeverity: map[string]int{"CRITICAL": 1}},
+	}
+
+	repoDB := mocks.RepoDBMock{
+		GetMultipleRepoMetaFn: func(ctx context.Context, filter func(repoMeta repodb.RepoMetadata) bool,
+			requestedPage repodb.PageInput,
+		) ([]repodb.RepoMetadata, error) {
+			return repos, nil
+		},
+	}
+
+	cveInfo := mocks.CveInfoMock{
+		GetCVESummaryForImageMediaFn: func(repo, digest, mediaType string) (cvemodel.ImageCVESummary, error) {
+			summary, ok := summaries[digest]
+			if !ok {
+				return cvemodel.ImageCVESummary{}, ErrTestError
+			}
+
+			return summary, nil
+		},
+	}
+
+	Convey("Reports sum up the CVEs by namespace", t, func() {
+		rootDir := t.TempDir()
+		received := make(chan report.Report, 2)
+
+		server := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+			var cveReport report.Report
+
+			_ = json.NewDecoder(req.Body).Decode(&cveReport)
+			received <- cveReport
+		}))
+		defer server.Close()
+
+		reporter := report.NewReporter(cveInfo, repoDB, rootDir, server.URL, log)
+
+		_, err := reporter.Last()
+		So(err, ShouldEqual, zerr.ErrCVEReportNotGenerated)
+
+		cveReport, err := reporter.Generate(context.Background())
+		So(err, ShouldBeNil)
+		So(cveReport.PreviousGeneratedAt, ShouldBeNil)
+		So(len(cveReport.Namespaces), ShouldEqual, 2)
+
+		// busybox has no namespace
+		topLevel, teamA := cveReport.Namespaces[0], cveReport.Namespaces[1]
+
+		So(topLevel.Namespace, ShouldEqual, "")
+		So(topLevel.Images, ShouldEqual, 1)
+		So(topLevel.NotScanned, ShouldEqual, 1)
+		So(topLevel.Total, ShouldEqual, 0)
+
+		So(teamA.Namespace, ShouldEqual, "team-a")
+		So(teamA.Repositories, ShouldEqual, 2)
+		So(teamA.Images, ShouldEqual, 3)
+		So(teamA.NotScanned, ShouldEqual, 0)
+		So(teamA.Counts, ShouldResemble, map[string]int{"CRITICAL": 1, "HIGH": 1, "MEDIUM": 0, "LOW": 2, "UNKNOWN": 0})
+		So(teamA.Total, ShouldEqual, 4)
+		So(teamA.Trend["LOW"], ShouldEqual, 2)
+		So(teamA.TotalTrend, ShouldEqual, 4)
+
+		So((<-received).Namespaces, ShouldResemble, cveReport.Namespaces)
+
+		last, err := reporter.Last()
+		So(err, ShouldBeNil)
+		So(last.GeneratedAt, ShouldEqual, cveReport.GeneratedAt)
+
+		// a CVE is fixed, the trend is computed from the report saved by the previous reporter
+		summaries["sha256:1"] = cvemodel.ImageCVESummary{
+			Count: 2, MaxSeverity: "HIGH", CountBySeverity: map[string]int{"HIGH": 1, "LOW": 1},
+		}
+
+		reporter = report.NewReporter(cveInfo, repoDB, rootDir, server.URL, log)
+
+		cveReport, err = reporter.Generate(context.Background())
+		So(err, ShouldBeNil)
+		So(cveReport.PreviousGeneratedAt, ShouldNotBeNil)
+		So(cveReport.Namespaces[1].Trend["LOW"], ShouldEqual, -1)
+		So(cveReport.Namespaces[1].Trend["HIGH"], ShouldEqual, 0)
+		So(cveReport.Namespaces[1].TotalTrend, ShouldEqual, -1)
+
+		<-received
+
+		var csv bytes.Buffer
+
+		err = report.WriteCSV(&csv, cveReport)
+		So(err, ShouldBeNil)
+
+		lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+		So(len(lines), ShouldEqual, 3)
+		So(lines[0], ShouldEqual, "namespace,repositories,images,notScanned,critical,high,medium,low,unknown,total,"+
+			"criticalTrend,highTrend,mediumTrend,lowTrend,unknownTrend,totalTrend")
+		So(lines[2], ShouldEqual, "team-a,2,3,0,1,1,0,1,0,3,0,0,0,-1,0,-1")
+	})
+
+	Convey("Report errors", t, func() {
+		rootDir := t.TempDir()
+
+		server := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+			_, _ = io.Copy(io.Discard, req.Body)
+			rsp.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		// the report is still kept when the webhook fails
+		reporter := report.NewReporter(cveInfo, repoDB, rootDir, server.URL, log)
+
+		_, err := reporter.Generate(context.Background())
+		So(errors.Is(err, zerr.ErrCVEReportWebhookFailed), ShouldBeTrue)
+
+		_, err = reporter.Last()
+		So(err, ShouldBeNil)
+
+		// an invalid saved report is ignored
+		err = os.WriteFile(path.Join(rootDir, report.FileName), []byte("{"), 0o600)
+		So(err, ShouldBeNil)
+
+		reporter = report.NewReporter(cveInfo, repoDB, rootDir, "", log)
+
+		_, err = reporter.Last()
+		So(err, ShouldEqual, zerr.ErrCVEReportNotGenerated)
+
+		reporter = report.NewReporter(cveInfo, mocks.RepoDBMock{
+			GetMultipleRepoMetaFn: func(ctx context.Context, filter func(repoMeta repodb.RepoMetadata) bool,
+				requestedPage repodb.PageInput,
+			) ([]repodb.RepoMetadata, error) {
+				return nil, ErrTestError
+			},
+		}, rootDir, "", log)
+
+		_, err = reporter.Generate(context.Background())
+		So(err, ShouldEqual, ErrTestError)
+	})
+}
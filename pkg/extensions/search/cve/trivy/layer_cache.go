@@ -0,0 +1,129 @@
+package trivy
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aquasecurity/trivy/pkg/fanal/cache"
+
+	"zotregistry.io/zot/pkg/log"
+)
+
+// layerCacheDir is the directory of the layer caches in the trivy cache directory, with one sub directory
+// per version of the Java DB.
+const layerCacheDir = "layers"
+
+// layerCache holds the results of trivy analyzing the layers of the scanned images, i.e. the packages found
+// in each layer, keyed by layer digest. It is shared by the scans of all the images, so the layers an image
+// has in common with images scanned before, e.g. the layers of their base image, aren't analyzed again and
+// only the vulnerabilities of the packages are looked up in the DB.
+type layerCache struct {
+	cache.FSCache
+	log log.Logger
+}
+
+// Close keeps the cache open when trivy is done with a scan, it is closed when it is replaced.
+func (lc layerCache) Close() error {
+	return nil
+}
+
+func (lc layerCache) MissingBlobs(artifactID string, blobIDs []string) (bool, []string, error) {
+	missingArtifact, missingBlobIDs, err := lc.FSCache.MissingBlobs(artifactID, blobIDs)
+	if err == nil {
+		lc.log.Debug().Str("artifact", artifactID).Int("layers", len(blobIDs)).
+			Int("cachedLayers", len(blobIDs)-len(missingBlobIDs)).Msg("looked up layers in trivy layer cache")
+	}
+
+	return missingArtifact, missingBlobIDs, err
+}
+
+// layerCaches are the layer caches of the trivy cache directories, the packages found in the jar files of
+// a layer are identified with the Java DB, so a layer cache is replaced when the Java DB is updated.
+type layerCaches struct {
+	lock   sync.Mutex
+	caches map[string]*versionedLayerCache
+	log    log.Logger
+}
+
+type versionedLayerCache struct {
+	version string
+	cache   layerCache
+}
+
+func newLayerCaches(log log.Logger) *layerCaches {
+	return &layerCaches{caches: map[string]*versionedLayerCache{}, log: log}
+}
+
+// get returns the layer cache of the trivy cache directory dbDir, for the version of its Java DB.
+func (lcs *layerCaches) get(dbDir string) (cache.Cache, error) {
+	version := javaDBVersion(dbDir)
+
+	lcs.lock.Lock()
+	defer lcs.lock.Unlock()
+
+	if current, ok := lcs.caches[dbDir]; ok {
+		if current.version == version {
+			return current.cache, nil
+		}
+
+		if err := current.cache.FSCache.Close(); err != nil {
+			lcs.log.Warn().Err(err).Str("dbDir", dbDir).Msg("unable to close outdated trivy layer cache")
+		}
+
+		delete(lcs.caches, dbDir)
+	}
+
+	// remove the layer caches of the previous versions, including the ones left over from before a restart
+	cachesDir := path.Join(dbDir, layerCacheDir)
+
+	entries, err := os.ReadDir(cachesDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.Name() != version {
+			_ = os.RemoveAll(path.Join(cachesDir, entry.Name()))
+		}
+	}
+
+	fsCache, err := cache.NewFSCache(path.Join(cachesDir, version))
+	if err != nil {
+		lcs.log.Error().Err(err).Str("dbDir", dbDir).Msg("unable to open trivy layer cache")
+
+		return nil, err
+	}
+
+	current := &versionedLayerCache{version: version, cache: layerCache{FSCache: fsCache, log: lcs.log}}
+	lcs.caches[dbDir] = current
+
+	return current.cache, nil
+}
+
+// javaDBMetadata is the part of the Java DB metadata identifying its version.
+type javaDBMetadata struct {
+	Version   int       `json:"Version"`
+	UpdatedAt time.Time `json:"UpdatedAt"`
+}
+
+// javaDBVersion returns the version of the Java DB in the trivy cache directory dbDir, made of its schema
+// version and of the time it was built, or "none" if there's no Java DB.
+func javaDBVersion(dbDir string) string {
+	content, err := os.ReadFile(path.Join(dbDir, javaDBDir, "metadata.json"))
+	if err != nil {
+		return "none"
+	}
+
+	var metadata javaDBMetadata
+
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return "none"
+	}
+
+	return "v" + strconv.Itoa(metadata.Version) + "-" + metadata.UpdatedAt.UTC().Format("20060102150405")
+}
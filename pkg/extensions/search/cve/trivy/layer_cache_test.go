@@ -0,0 +1,65 @@
+//go:build search
+// +build search
+
+package trivy
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	fanalTypes "github.com/aquasecurity/trivy/pkg/fanal/types"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.io/zot/pkg/log"
+)
+
+func TestLayerCache(t *testing.T) {
+	Convey("Layer caches are kept per Java DB version", t, func() {
+		dbDir := t.TempDir()
+		layerCaches := newLayerCaches(log.NewLogger("debug", ""))
+
+		// left over from a previous run
+		err := os.MkdirAll(path.Join(dbDir, layerCacheDir, "v1-20230101000000"), 0o700)
+		So(err, ShouldBeNil)
+
+		firstCache, err := layerCaches.get(dbDir)
+		So(err, ShouldBeNil)
+		So(javaDBVersion(dbDir), ShouldEqual, "none")
+
+		_, err = os.Stat(path.Join(dbDir, layerCacheDir, "v1-20230101000000"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		// trivy closes the cache at the end of each scan, it is kept open for the next scans
+		So(firstCache.Close(), ShouldBeNil)
+
+		err = firstCache.PutBlob("sha256:layer", fanalTypes.BlobInfo{SchemaVersion: fanalTypes.BlobJSONSchemaVersion})
+		So(err, ShouldBeNil)
+
+		sameCache, err := layerCaches.get(dbDir)
+		So(err, ShouldBeNil)
+
+		_, missingBlobs, err := sameCache.MissingBlobs("sha256:image", []string{"sha256:layer", "sha256:other"})
+		So(err, ShouldBeNil)
+		So(missingBlobs, ShouldResemble, []string{"sha256:other"})
+
+		// the Java DB is updated
+		err = os.MkdirAll(path.Join(dbDir, javaDBDir), 0o700)
+		So(err, ShouldBeNil)
+
+		err = os.WriteFile(path.Join(dbDir, javaDBDir, "metadata.json"),
+			[]byte(`{"Version":1,"UpdatedAt":"2023-06-01T00:00:00Z"}`), 0o600)
+		So(err, ShouldBeNil)
+		So(javaDBVersion(dbDir), ShouldEqual, "v1-20230601000000")
+
+		newCache, err := layerCaches.get(dbDir)
+		So(err, ShouldBeNil)
+
+		_, missingBlobs, err = newCache.MissingBlobs("sha256:image", []string{"sha256:layer"})
+		So(err, ShouldBeNil)
+		So(missingBlobs, ShouldResemble, []string{"sha256:layer"})
+
+		_, err = os.Stat(path.Join(dbDir, layerCacheDir, "none"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+	})
+}
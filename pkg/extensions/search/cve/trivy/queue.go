@@ -0,0 +1,330 @@
+package trivy
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/log"
+)
+
+// scanFunc runs a scan, it calls started once it holds what it needs to run, e.g. its scan slot,
+// the scan is reported as queued until then.
+type scanFunc func(ctx context.Context, repo, digest string, started func()) (map[string]cvemodel.CVE, error)
+
+type scanJob struct {
+	repo     string
+	digest   string
+	queuedAt time.Time
+	// taken by a worker, the scan is completed by the worker
+	taken     bool
+	startedAt time.Time
+	ctx       context.Context //nolint:containedctx // the scan is canceled if its image is deleted
+	cancel    context.CancelFunc
+	done      chan struct{}
+	result    map[string]cvemodel.CVE
+	err       error
+}
+
+// ScanQueue runs the scans with a bounded number of workers, the scans of each repo are queued
+// separately and the workers take them from one repo after the other, so a repo with many images
+// doesn't hold back the scans of the other repos.
+// Scans of the same image are only run once and all the callers waiting on them get the same result.
+type ScanQueue struct {
+	maxWorkers int
+	workers    int
+	scan       scanFunc
+	pending    map[string][]*scanJob // queued scans by repo
+	repos      []string              // repos with queued scans, in the order the workers take them
+	jobs       map[string]*scanJob   // queued and running scans by image
+	onScanned  []cvemodel.ScanCompletedFunc
+	lock       *sync.Mutex
+	metrics    monitoring.MetricServer
+	log        log.Logger
+}
+
+func NewScanQueue(maxWorkers int, scan scanFunc, metrics monitoring.MetricServer, log log.Logger) *ScanQueue {
+	if maxWorkers < 1 {
+		maxWorkers = 1
+	}
+
+	return &ScanQueue{
+		maxWorkers: maxWorkers,
+		scan:       scan,
+		pending:    map[string][]*scanJob{},
+		jobs:       map[string]*scanJob{},
+		lock:       &sync.Mutex{},
+		metrics:    metrics,
+		log:        log,
+	}
+}
+
+// OnScanCompleted adds a func called after each successful scan, canceled and failed scans are ignored.
+func (queue *ScanQueue) OnScanCompleted(hook cvemodel.ScanCompletedFunc) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	queue.onScanned = append(queue.onScanned, hook)
+}
+
+// Scan queues the scan of an image, or joins the one already queued or running, and waits for its result.
+func (queue *ScanQueue) Scan(repo, digest string) (map[string]cvemodel.CVE, error) {
+	job := queue.submit(repo, digest)
+
+	<-job.done
+
+	return job.result, job.err
+}
+
+func (queue *ScanQueue) submit(repo, digest string) *scanJob {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	image := repo + "@" + digest
+
+	if job, ok := queue.jobs[image]; ok {
+		return job
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	job := &scanJob{
+		repo:     repo,
+		digest:   digest,
+		queuedAt: time.Now(),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	queue.jobs[image] = job
+
+	if len(queue.pending[repo]) == 0 {
+		queue.repos = append(queue.repos, repo)
+	}
+
+	queue.pending[repo] = append(queue.pending[repo], job)
+
+	// workers are started on demand and exit once the queue is empty
+	if queue.workers < queue.maxWorkers {
+		queue.workers++
+
+		go queue.work()
+	}
+
+	queue.updateMetrics()
+
+	return job
+}
+
+func (queue *ScanQueue) work() {
+	for {
+		queue.lock.Lock()
+
+		job := queue.next()
+		if job == nil {
+			queue.workers--
+			queue.lock.Unlock()
+
+			return
+		}
+
+		job.taken = true
+
+		queue.lock.Unlock()
+
+		result, err := queue.scan(job.ctx, job.repo, job.digest, func() {
+			queue.start(job)
+		})
+
+		queue.finish(job, result, err)
+	}
+}
+
+func (queue *ScanQueue) start(job *scanJob) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	job.startedAt = time.Now()
+
+	queue.updateMetrics()
+}
+
+// next pops the first scan of the next repo, must be called with the lock held.
+func (queue *ScanQueue) next() *scanJob {
+	if len(queue.repos) == 0 {
+		return nil
+	}
+
+	repo := queue.repos[0]
+	queue.repos = queue.repos[1:]
+
+	jobs := queue.pending[repo]
+	job := jobs[0]
+
+	if len(jobs) == 1 {
+		delete(queue.pending, repo)
+	} else {
+		queue.pending[repo] = jobs[1:]
+		queue.repos = append(queue.repos, repo)
+	}
+
+	return job
+}
+
+func (queue *ScanQueue) finish(job *scanJob, result map[string]cvemodel.CVE, err error) {
+	queue.lock.Lock()
+
+	image := job.repo + "@" + job.digest
+
+	// a canceled scan is already removed and may have been replaced by a new one
+	if queue.jobs[image] == job {
+		delete(queue.jobs, image)
+	}
+
+	onScanned := queue.onScanned
+
+	queue.updateMetrics()
+	queue.lock.Unlock()
+
+	if job.ctx.Err() != nil {
+		result, err = map[string]cvemodel.CVE{}, zerr.ErrScanCanceled
+	}
+
+	job.result, job.err = result, err
+
+	job.cancel()
+	close(job.done)
+
+	if err != nil {
+		return
+	}
+
+	for _, hook := range onScanned {
+		hook(job.repo, job.digest, result)
+	}
+}
+
+// Cancel cancels the queued and running scans of an image, or of all the images of the repo
+// if digest is empty, and returns how many scans were canceled.
+// The callers waiting on them get zerr.ErrScanCanceled.
+func (queue *ScanQueue) Cancel(repo, digest string) int {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	canceled := 0
+
+	for image, job := range queue.jobs {
+		if job.repo != repo || (digest != "" && job.digest != digest) {
+			continue
+		}
+
+		delete(queue.jobs, image)
+		job.cancel()
+
+		canceled++
+
+		// scans taken by a worker are completed by it once trivy returns
+		if job.taken {
+			continue
+		}
+
+		queue.removePending(job)
+
+		job.result, job.err = map[string]cvemodel.CVE{}, zerr.ErrScanCanceled
+		close(job.done)
+	}
+
+	if canceled > 0 {
+		queue.log.Info().Str("repository", repo).Str("digest", digest).Int("canceled", canceled).
+			Msg("canceled image scans")
+
+		queue.updateMetrics()
+	}
+
+	return canceled
+}
+
+// removePending removes a queued scan, must be called with the lock held.
+func (queue *ScanQueue) removePending(job *scanJob) {
+	jobs := queue.pending[job.repo]
+
+	for idx := range jobs {
+		if jobs[idx] == job {
+			jobs = append(jobs[:idx:idx], jobs[idx+1:]...)
+
+			break
+		}
+	}
+
+	if len(jobs) > 0 {
+		queue.pending[job.repo] = jobs
+
+		return
+	}
+
+	delete(queue.pending, job.repo)
+
+	for idx := range queue.repos {
+		if queue.repos[idx] == job.repo {
+			queue.repos = append(queue.repos[:idx:idx], queue.repos[idx+1:]...)
+
+			break
+		}
+	}
+}
+
+// Status returns the queued and running scans, sorted by the time they were queued.
+func (queue *ScanQueue) Status() []cvemodel.ScanStatus {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	statuses := make([]cvemodel.ScanStatus, 0, len(queue.jobs))
+
+	for _, job := range queue.jobs {
+		status := cvemodel.ScanStatus{
+			Repo:     job.repo,
+			Digest:   job.digest,
+			State:    cvemodel.ScanStateQueued,
+			QueuedAt: job.queuedAt,
+		}
+
+		if !job.startedAt.IsZero() {
+			startedAt := job.startedAt
+
+			status.State = cvemodel.ScanStateRunning
+			status.StartedAt = &startedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].QueuedAt.Before(statuses[j].QueuedAt)
+	})
+
+	return statuses
+}
+
+// updateMetrics must be called with the lock held.
+func (queue *ScanQueue) updateMetrics() {
+	if queue.metrics == nil {
+		return
+	}
+
+	queued, running := 0, 0
+
+	for _, job := range queue.jobs {
+		if job.startedAt.IsZero() {
+			queued++
+		} else {
+			running++
+		}
+	}
+
+	monitoring.SetCVEScans(queue.metrics, queued, running)
+}
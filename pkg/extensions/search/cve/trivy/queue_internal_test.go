@@ -0,0 +1,284 @@
+//go:build search
+// +build search
+
+package trivy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/extensions/search/cve/model"
+	"zotregistry.io/zot/pkg/log"
+)
+
+type scanResult struct {
+	image  string
+	cveMap map[string]model.CVE
+	err    error
+}
+
+func startScan(queue *ScanQueue, repo, digest string, results chan<- scanResult) {
+	go func() {
+		cveMap, err := queue.Scan(repo, digest)
+		results <- scanResult{repo + "@" + digest, cveMap, err}
+	}()
+}
+
+func waitForQueue(queue *ScanQueue, count int) []model.ScanStatus {
+	for i := 0; i < 100; i++ {
+		if statuses := queue.Status(); len(statuses) == count {
+			return statuses
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return queue.Status()
+}
+
+func TestScanQueue(t *testing.T) {
+	log := log.NewLogger("debug", "")
+
+	Convey("Scans are taken from one repo after the other", t, func() {
+		started := make(chan string, 10)
+		release := make(chan struct{})
+
+		queue := NewScanQueue(0, func(ctx context.Context, repo, digest string, start func(),
+		) (map[string]model.CVE, error) {
+			start()
+
+			started <- repo + "@" + digest
+			<-release
+
+			return map[string]model.CVE{"CVE-1": {ID: "CVE-1"}}, nil
+		}, nil, log)
+
+		results := make(chan scanResult, 10)
+
+		startScan(queue, "a", "d1", results)
+		So(<-started, ShouldEqual, "a@d1")
+
+		startScan(queue, "a", "d2", results)
+		So(waitForQueue(queue, 2), ShouldHaveLength, 2)
+		startScan(queue, "a", "d3", results)
+		So(waitForQueue(queue, 3), ShouldHaveLength, 3)
+		startScan(queue, "b", "d1", results)
+
+		statuses := waitForQueue(queue, 4)
+		So(statuses, ShouldHaveLength, 4)
+		So(statuses[0].State, ShouldEqual, model.ScanStateRunning)
+		So(statuses[0].StartedAt, ShouldNotBeNil)
+		So(statuses[1].State, ShouldEqual, model.ScanStateQueued)
+
+		// joins the queued scan of the same image
+		job := queue.submit("a", "d2")
+		So(queue.Status(), ShouldHaveLength, 4)
+
+		close(release)
+
+		So(<-started, ShouldEqual, "a@d2")
+		So(<-started, ShouldEqual, "b@d1")
+		So(<-started, ShouldEqual, "a@d3")
+
+		for i := 0; i < 4; i++ {
+			result := <-results
+			So(result.err, ShouldBeNil)
+			So(result.cveMap, ShouldContainKey, "CVE-1")
+		}
+
+		<-job.done
+		So(job.err, ShouldBeNil)
+		So(job.result, ShouldContainKey, "CVE-1")
+
+		So(waitForQueue(queue, 0), ShouldBeEmpty)
+		So(started, ShouldBeEmpty)
+	})
+
+	Convey("Scans are run concurrently up to the limit", t, func() {
+		started := make(chan string, 10)
+		release := make(chan struct{})
+
+		queue := NewScanQueue(2, func(ctx context.Context, repo, digest string, start func(),
+		) (map[string]model.CVE, error) {
+			start()
+
+			started <- repo + "@" + digest
+			<-release
+
+			return map[string]model.CVE{}, nil
+		}, nil, log)
+
+		results := make(chan scanResult, 10)
+
+		startScan(queue, "a", "d1", results)
+		startScan(queue, "a", "d2", results)
+		startScan(queue, "a", "d3", results)
+
+		<-started
+		<-started
+
+		statuses := waitForQueue(queue, 3)
+		So(statuses, ShouldHaveLength, 3)
+
+		running := 0
+
+		for _, status := range statuses {
+			if status.State == model.ScanStateRunning {
+				running++
+			}
+		}
+
+		So(running, ShouldEqual, 2)
+
+		close(release)
+
+		for i := 0; i < 3; i++ {
+			So((<-results).err, ShouldBeNil)
+		}
+	})
+
+	Convey("Scans waiting for their slot are queued", t, func() {
+		slot := make(chan struct{}, 1)
+		started := make(chan string, 10)
+		release := make(chan struct{})
+
+		queue := NewScanQueue(2, func(ctx context.Context, repo, digest string, start func(),
+		) (map[string]model.CVE, error) {
+			slot <- struct{}{}
+			defer func() { <-slot }()
+
+			start()
+			started <- repo + "@" + digest
+			<-release
+
+			return map[string]model.CVE{}, nil
+		}, nil, log)
+
+		results := make(chan scanResult, 10)
+
+		startScan(queue, "a", "d1", results)
+		startScan(queue, "b", "d1", results)
+
+		<-started
+
+		statuses := waitForQueue(queue, 2)
+		So(statuses, ShouldHaveLength, 2)
+
+		running := 0
+
+		for _, status := range statuses {
+			if status.State == model.ScanStateRunning {
+				So(status.StartedAt, ShouldNotBeNil)
+
+				running++
+			} else {
+				So(status.StartedAt, ShouldBeNil)
+			}
+		}
+
+		So(running, ShouldEqual, 1)
+
+		// both scans are taken by a worker, the one waiting for the slot is still canceled
+		So(queue.Cancel("a", "")+queue.Cancel("b", ""), ShouldEqual, 2)
+
+		close(release)
+
+		for i := 0; i < 2; i++ {
+			So((<-results).err, ShouldEqual, zerr.ErrScanCanceled)
+		}
+
+		So(waitForQueue(queue, 0), ShouldBeEmpty)
+	})
+
+	Convey("Completed scans are reported", t, func() {
+		queue := NewScanQueue(1, func(ctx context.Context, repo, digest string, start func(),
+		) (map[string]model.CVE, error) {
+			start()
+
+			if repo == "b" {
+				return nil, zerr.ErrScanNotSupported
+			}
+
+			return map[string]model.CVE{"CVE-1": {ID: "CVE-1"}}, nil
+		}, nil, log)
+
+		scanned := make(chan scanResult, 10)
+
+		queue.OnScanCompleted(func(repo, digest string, cveMap map[string]model.CVE) {
+			scanned <- scanResult{image: repo + "@" + digest, cveMap: cveMap}
+		})
+
+		// every hook is called
+		alsoScanned := make(chan string, 10)
+
+		queue.OnScanCompleted(func(repo, digest string, cveMap map[string]model.CVE) {
+			alsoScanned <- repo + "@" + digest
+		})
+
+		_, err := queue.Scan("b", "d1")
+		So(err, ShouldEqual, zerr.ErrScanNotSupported)
+
+		_, err = queue.Scan("a", "d1")
+		So(err, ShouldBeNil)
+
+		// failed scans aren't reported
+		result := <-scanned
+		So(result.image, ShouldEqual, "a@d1")
+		So(result.cveMap, ShouldContainKey, "CVE-1")
+		So(scanned, ShouldBeEmpty)
+		So(<-alsoScanned, ShouldEqual, "a@d1")
+		So(alsoScanned, ShouldBeEmpty)
+	})
+
+	Convey("Scans of deleted images are canceled", t, func() {
+		started := make(chan string, 10)
+
+		queue := NewScanQueue(1, func(ctx context.Context, repo, digest string, start func(),
+		) (map[string]model.CVE, error) {
+			start()
+
+			started <- repo + "@" + digest
+
+			if repo == "b" {
+				return map[string]model.CVE{}, nil
+			}
+
+			<-ctx.Done()
+
+			return nil, ctx.Err()
+		}, nil, log)
+
+		results := make(chan scanResult, 10)
+
+		startScan(queue, "a", "d1", results)
+		So(<-started, ShouldEqual, "a@d1")
+
+		startScan(queue, "a", "d2", results)
+		So(waitForQueue(queue, 2), ShouldHaveLength, 2)
+		startScan(queue, "b", "d1", results)
+		So(waitForQueue(queue, 3), ShouldHaveLength, 3)
+
+		So(queue.Cancel("a", "d3"), ShouldEqual, 0)
+		So(queue.Cancel("a", ""), ShouldEqual, 2)
+
+		canceled := map[string]error{}
+
+		for i := 0; i < 3; i++ {
+			result := <-results
+			canceled[result.image] = result.err
+		}
+
+		So(canceled["a@d1"], ShouldEqual, zerr.ErrScanCanceled)
+		So(canceled["a@d2"], ShouldEqual, zerr.ErrScanCanceled)
+		So(canceled["b@d1"], ShouldBeNil)
+
+		// the canceled queued scan is never run
+		So(<-started, ShouldEqual, "b@d1")
+		So(waitForQueue(queue, 0), ShouldBeEmpty)
+		So(started, ShouldBeEmpty)
+	})
+}
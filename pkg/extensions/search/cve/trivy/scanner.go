@@ -3,10 +3,13 @@ package trivy
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"sync"
+	"time"
 
 	"github.com/aquasecurity/trivy-db/pkg/metadata"
 	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
@@ -23,12 +26,19 @@ import (
 
 	zerr "zotregistry.io/zot/errors"
 	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/repodb"
 	"zotregistry.io/zot/pkg/storage"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
 )
 
+// javaDBDir is the directory of the Java DB in the trivy cache, javaDBFiles are the files it consists of.
+const javaDBDir = "java-db"
+
+var javaDBFiles = []string{"trivy-java.db", "metadata.json"} //nolint:gochecknoglobals
+
 // getNewScanOptions sets trivy configuration values for our scans and returns them as
 // a trivy Options structure.
 func getNewScanOptions(dir, dbRepository, javaDBRepository string) *flag.Options {
@@ -74,14 +84,23 @@ type Scanner struct {
 	cveController    cveTrivyController
 	storeController  storage.StoreController
 	log              log.Logger
-	dbLock           *sync.Mutex
+	dbLock           *sync.RWMutex
+	scanSlot         *sync.Mutex
 	cache            *CveCache
+	queue            *ScanQueue
 	dbRepository     string
 	javaDBRepository string
+	javaDBPath       string
+	dbStatus         *dbStatus
+	javaDBStatus     *dbStatus
+	layerCaches      *layerCaches
 }
 
-func NewScanner(storeController storage.StoreController,
-	repoDB repodb.RepoDB, dbRepository, javaDBRepository string, log log.Logger,
+// NewScanner returns a scanner using the Trivy DB downloaded from dbRepository, and the Java DB loaded from
+// javaDBPath if set, or downloaded from javaDBRepository otherwise. Images are scanned without the Java DB
+// if neither is set.
+func NewScanner(storeController storage.StoreController, repoDB repodb.RepoDB, dbRepository,
+	javaDBRepository, javaDBPath string, maxConcurrentScans int, metrics monitoring.MetricServer, log log.Logger,
 ) *Scanner {
 	cveController := cveTrivyController{}
 
@@ -111,16 +130,30 @@ func NewScanner(storeController storage.StoreController,
 
 	cveController.SubCveConfig = subCveConfig
 
-	return &Scanner{
+	scanner := &Scanner{
 		log:              log,
 		repoDB:           repoDB,
 		cveController:    cveController,
 		storeController:  storeController,
-		dbLock:           &sync.Mutex{},
+		dbLock:           &sync.RWMutex{},
+		scanSlot:         &sync.Mutex{},
 		cache:            NewCveCache(10000, log), //nolint:gomnd
 		dbRepository:     dbRepository,
 		javaDBRepository: javaDBRepository,
+		javaDBPath:       javaDBPath,
+		dbStatus:         newDBStatus(cvemodel.DBNameTrivy, dbRepository, false),
+		layerCaches:      newLayerCaches(log),
 	}
+
+	if javaDBPath != "" {
+		scanner.javaDBStatus = newDBStatus(cvemodel.DBNameTrivyJavaDB, javaDBPath, true)
+	} else if javaDBRepository != "" {
+		scanner.javaDBStatus = newDBStatus(cvemodel.DBNameTrivyJavaDB, javaDBRepository, false)
+	}
+
+	scanner.queue = NewScanQueue(maxConcurrentScans, scanner.runScan, metrics, log)
+
+	return scanner
 }
 
 func (scanner Scanner) getTrivyOptions(image string) flag.Options {
@@ -155,15 +188,18 @@ func (scanner Scanner) getTrivyOptions(image string) flag.Options {
 	return opts
 }
 
-func (scanner Scanner) runTrivy(opts flag.Options) (types.Report, error) {
-	ctx := context.Background()
-
+func (scanner Scanner) runTrivy(ctx context.Context, opts flag.Options) (types.Report, error) {
 	err := scanner.checkDBPresence()
 	if err != nil {
 		return types.Report{}, err
 	}
 
-	runner, err := artifact.NewRunner(ctx, opts)
+	layerCache, err := scanner.layerCaches.get(opts.CacheDir)
+	if err != nil {
+		return types.Report{}, err
+	}
+
+	runner, err := artifact.NewRunner(ctx, opts, artifact.WithCacheClient(layerCache))
 	if err != nil {
 		return types.Report{}, err
 	}
@@ -212,14 +248,14 @@ func (scanner Scanner) IsImageMediaScannable(repo, digestStr, mediaType string)
 	image := repo + "@" + digestStr
 
 	switch mediaType {
-	case ispec.MediaTypeImageManifest:
+	case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 		ok, err := scanner.isManifestScanable(digestStr)
 		if err != nil {
 			return ok, fmt.Errorf("image '%s' %w", image, err)
 		}
 
 		return ok, nil
-	case ispec.MediaTypeImageIndex:
+	case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 		ok, err := scanner.isIndexScanable(digestStr)
 		if err != nil {
 			return ok, fmt.Errorf("image '%s' %w", image, err)
@@ -250,9 +286,17 @@ func (scanner Scanner) isManifestScanable(digestStr string) (bool, error) {
 		return false, zerr.ErrScanNotSupported
 	}
 
+	// encrypted layers can't be read by the scanner, report them separately from unsupported media types
+	if zcommon.IsImageEncrypted(manifestContent) {
+		scanner.log.Debug().Str("digest", digestStr).Msg("image has encrypted layers, skipping scanning")
+
+		return false, zerr.ErrImageEncrypted
+	}
+
 	for _, imageLayer := range manifestContent.Layers {
 		switch imageLayer.MediaType {
-		case ispec.MediaTypeImageLayerGzip, ispec.MediaTypeImageLayer, string(regTypes.DockerLayer):
+		case ispec.MediaTypeImageLayerGzip, ispec.MediaTypeImageLayerZstd, ispec.MediaTypeImageLayer,
+			string(regTypes.DockerLayer):
 			continue
 		default:
 			scanner.log.Debug().Str("mediaType", imageLayer.MediaType).
@@ -286,9 +330,13 @@ func (scanner Scanner) isIndexScanable(digestStr string) (bool, error) {
 		return true, nil
 	}
 
+	allEncrypted := true
+
 	for _, manifest := range indexContent.Manifests {
 		isScannable, err := scanner.isManifestScanable(manifest.Digest.String())
 		if err != nil {
+			allEncrypted = allEncrypted && errors.Is(err, zerr.ErrImageEncrypted)
+
 			continue
 		}
 
@@ -296,6 +344,12 @@ func (scanner Scanner) isIndexScanable(digestStr string) (bool, error) {
 		if isScannable {
 			return true, nil
 		}
+
+		allEncrypted = false
+	}
+
+	if allEncrypted {
+		return false, zerr.ErrImageEncrypted
 	}
 
 	return false, nil
@@ -335,7 +389,7 @@ func (scanner Scanner) ScanImage(image string) (map[string]cvemodel.CVE, error)
 	)
 
 	switch mediaType {
-	case ispec.MediaTypeImageIndex:
+	case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 		cveIDMap, err = scanner.scanIndex(repo, digest)
 	default:
 		cveIDMap, err = scanner.scanManifest(repo, digest)
@@ -355,15 +409,17 @@ func (scanner Scanner) scanManifest(repo, digest string) (map[string]cvemodel.CV
 		return cachedMap, nil
 	}
 
+	return scanner.queue.Scan(repo, digest)
+}
+
+// runScan is run by the scan queue workers.
+func (scanner Scanner) runScan(ctx context.Context, repo, digest string, started func(),
+) (map[string]cvemodel.CVE, error) {
 	cveidMap := map[string]cvemodel.CVE{}
 	image := repo + "@" + digest
 
-	scanner.dbLock.Lock()
-	opts := scanner.getTrivyOptions(image)
-	report, err := scanner.runTrivy(opts)
-	scanner.dbLock.Unlock()
-
-	if err != nil { //nolint: wsl
+	report, err := scanner.runTrivyScan(ctx, image, started)
+	if err != nil {
 		return cveidMap, err
 	}
 
@@ -426,6 +482,28 @@ func (scanner Scanner) scanManifest(repo, digest string) (map[string]cvemodel.CV
 	return cveidMap, nil
 }
 
+/*
+runTrivyScan runs trivy once the scan holds the scan slot. Trivy keeps the vulnerability db it scans with in
+process wide variables, so there is a single slot whatever the number of workers. The db is only read locked
+during the run, so its updates wait for the running scan, but not for the scans waiting for the slot.
+*/
+func (scanner Scanner) runTrivyScan(ctx context.Context, image string, started func()) (types.Report, error) {
+	scanner.scanSlot.Lock()
+	defer scanner.scanSlot.Unlock()
+
+	// the image may have been deleted while waiting for the slot
+	if err := ctx.Err(); err != nil {
+		return types.Report{}, err
+	}
+
+	scanner.dbLock.RLock()
+	defer scanner.dbLock.RUnlock()
+
+	started()
+
+	return scanner.runTrivy(ctx, scanner.getTrivyOptions(image))
+}
+
 func (scanner Scanner) scanIndex(repo, digest string) (map[string]cvemodel.CVE, error) {
 	indexData, err := scanner.repoDB.GetIndexData(godigest.Digest(digest))
 	if err != nil {
@@ -465,10 +543,47 @@ func (scanner Scanner) UpdateDB() error {
 	scanner.dbLock.Lock()
 	defer scanner.dbLock.Unlock()
 
+	err := scanner.updateDBDirs(scanner.updateDB)
+	scanner.dbStatus.update(err)
+
+	if err != nil {
+		return err
+	}
+
+	scanner.cache.Purge()
+
+	return nil
+}
+
+// UpdateJavaDB downloads the Trivy Java DB under the store root directory, or copies it there from the
+// configured directory. It is updated separately from the Trivy DB, on its own schedule.
+func (scanner Scanner) UpdateJavaDB() error {
+	if scanner.javaDBStatus == nil {
+		return nil
+	}
+
+	// the Java DB updater is also a global in trivy
+	scanner.dbLock.Lock()
+	defer scanner.dbLock.Unlock()
+
+	err := scanner.updateDBDirs(scanner.updateJavaDB)
+	scanner.javaDBStatus.update(err)
+
+	if err != nil {
+		return err
+	}
+
+	scanner.cache.Purge()
+
+	return nil
+}
+
+// updateDBDirs calls update with the trivy cache directory of each store.
+func (scanner Scanner) updateDBDirs(update func(dbDir string) error) error {
 	if scanner.storeController.DefaultStore != nil {
 		dbDir := path.Join(scanner.storeController.DefaultStore.RootDir(), "_trivy")
 
-		err := scanner.updateDB(dbDir)
+		err := update(dbDir)
 		if err != nil {
 			return err
 		}
@@ -478,15 +593,13 @@ func (scanner Scanner) UpdateDB() error {
 		for _, storage := range scanner.storeController.SubStore {
 			dbDir := path.Join(storage.RootDir(), "_trivy")
 
-			err := scanner.updateDB(dbDir)
+			err := update(dbDir)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	scanner.cache.Purge()
-
 	return nil
 }
 
@@ -507,22 +620,84 @@ func (scanner Scanner) updateDB(dbDir string) error {
 		return err
 	}
 
-	if scanner.javaDBRepository != "" {
-		javadb.Init(dbDir, scanner.javaDBRepository, false, false, registryOpts.Insecure)
+	scanner.log.Debug().Str("dbDir", dbDir).Msg("Finished downloading Trivy DB to destination dir")
+
+	return nil
+}
 
-		if err := javadb.Update(); err != nil {
+func (scanner Scanner) updateJavaDB(dbDir string) error {
+	// scans don't update the Java DB, they only require its metadata to be found in the cache
+	if scanner.javaDBPath != "" {
+		err := copyJavaDB(scanner.javaDBPath, path.Join(dbDir, javaDBDir))
+		if err != nil {
 			scanner.log.Error().Err(err).Str("dbDir", dbDir).
-				Str("javaDBRepository", scanner.javaDBRepository).Msg("Error downloading Trivy Java DB to destination dir")
+				Str("javaDBPath", scanner.javaDBPath).Msg("Error loading Trivy Java DB to destination dir")
 
 			return err
 		}
+
+		scanner.log.Debug().Str("dbDir", dbDir).Msg("Finished loading Trivy Java DB to destination dir")
+
+		return nil
 	}
 
-	scanner.log.Debug().Str("dbDir", dbDir).Msg("Finished downloading Trivy DB to destination dir")
+	// trivy only downloads the Java DB if the one in the cache is outdated
+	javadb.Init(dbDir, scanner.javaDBRepository, false, false, false)
+
+	if err := javadb.Update(); err != nil {
+		scanner.log.Error().Err(err).Str("dbDir", dbDir).
+			Str("javaDBRepository", scanner.javaDBRepository).Msg("Error downloading Trivy Java DB to destination dir")
+
+		return err
+	}
+
+	scanner.log.Debug().Str("dbDir", dbDir).Msg("Finished downloading Trivy Java DB to destination dir")
 
 	return nil
 }
 
+// copyJavaDB copies the Java DB files from srcDir to dstDir, each file is replaced at once so running scans
+// keep reading the previous one.
+func copyJavaDB(srcDir, dstDir string) error {
+	if err := os.MkdirAll(dstDir, storageConstants.DefaultDirPerms); err != nil {
+		return err
+	}
+
+	for _, name := range javaDBFiles {
+		if err := copyFile(path.Join(srcDir, name), path.Join(dstDir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	tmpFile, err := os.CreateTemp(path.Dir(dst), path.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := io.Copy(tmpFile, srcFile); err != nil {
+		tmpFile.Close()
+
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), dst)
+}
+
 // checkDBPresence errors if the DB metadata files cannot be accessed.
 func (scanner Scanner) checkDBPresence() error {
 	result := true
@@ -551,6 +726,76 @@ func (scanner Scanner) checkDBPresence() error {
 	return nil
 }
 
+// CancelScans cancels the queued and running scans of an image, or of all the images of the repo
+// if digest is empty, e.g. when they are deleted.
+func (scanner Scanner) CancelScans(repo, digest string) int {
+	return scanner.queue.Cancel(repo, digest)
+}
+
+// OnScanCompleted adds a func called after each image scanned, images whose result is cached aren't scanned.
+func (scanner Scanner) OnScanCompleted(hook cvemodel.ScanCompletedFunc) {
+	scanner.queue.OnScanCompleted(hook)
+}
+
+// GetCachedResult returns the result of the scan of a manifest with the current vulnerability databases, ok is
+// false if it isn't cached, e.g. because it wasn't scanned since the databases were updated.
+func (scanner Scanner) GetCachedResult(digest string) (map[string]cvemodel.CVE, bool) {
+	cveMap := scanner.cache.Get(digest)
+
+	return cveMap, cveMap != nil
+}
+
+// GetScanQueue returns the queued and running scans.
+func (scanner Scanner) GetScanQueue() []cvemodel.ScanStatus {
+	return scanner.queue.Status()
+}
+
+// GetDBStatus returns the state of the Trivy DB, and of the Java DB if it is used.
+func (scanner Scanner) GetDBStatus() []cvemodel.DBStatus {
+	statuses := []cvemodel.DBStatus{scanner.dbStatus.get()}
+
+	if scanner.javaDBStatus != nil {
+		statuses = append(statuses, scanner.javaDBStatus.get())
+	}
+
+	return statuses
+}
+
+// dbStatus tracks the updates of a database, it is shared by the copies of the scanner.
+type dbStatus struct {
+	lock   sync.RWMutex
+	status cvemodel.DBStatus
+}
+
+func newDBStatus(name, source string, offline bool) *dbStatus {
+	return &dbStatus{status: cvemodel.DBStatus{Name: name, Source: source, Offline: offline}}
+}
+
+func (dbs *dbStatus) update(err error) {
+	now := time.Now()
+
+	dbs.lock.Lock()
+	defer dbs.lock.Unlock()
+
+	dbs.status.LastAttempt = &now
+
+	if err != nil {
+		dbs.status.Error = err.Error()
+
+		return
+	}
+
+	dbs.status.Error = ""
+	dbs.status.UpdatedAt = &now
+}
+
+func (dbs *dbStatus) get() cvemodel.DBStatus {
+	dbs.lock.RLock()
+	defer dbs.lock.RUnlock()
+
+	return dbs.status
+}
+
 func (scanner Scanner) CompareSeverities(severity1, severity2 string) int {
 	return dbTypes.CompareSeverityString(severity1, severity2)
 }
@@ -5,6 +5,7 @@ package trivy
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"os"
 	"path"
@@ -102,7 +103,7 @@ func TestMultipleStoragePath(t *testing.T) {
 		repoDB, err := boltdb_wrapper.NewBoltDBWrapper(boltDriver, log)
 		So(err, ShouldBeNil)
 
-		scanner := NewScanner(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", log)
+		scanner := NewScanner(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", "", 1, nil, log)
 
 		So(scanner.storeController.DefaultStore, ShouldNotBeNil)
 		So(scanner.storeController.SubStore, ShouldNotBeNil)
@@ -208,52 +209,58 @@ func TestTrivyLibraryErrors(t *testing.T) {
 		img := "zot-test:0.0.1" //nolint:goconst
 
 		// Download DB fails for missing DB url
-		scanner := NewScanner(storeController, repoDB, "", "", log)
+		scanner := NewScanner(storeController, repoDB, "", "", "", 1, nil, log)
 
 		err = scanner.UpdateDB()
 		So(err, ShouldNotBeNil)
 
 		// Try to scan without the DB being downloaded
 		opts := scanner.getTrivyOptions(img)
-		_, err = scanner.runTrivy(opts)
+		_, err = scanner.runTrivy(context.Background(), opts)
 		So(err, ShouldNotBeNil)
 		So(err, ShouldWrap, zerr.ErrCVEDBNotFound)
 
 		// Download DB fails for invalid Java DB
 		scanner = NewScanner(storeController, repoDB, "ghcr.io/project-zot/trivy-db",
-			"ghcr.io/project-zot/trivy-not-db", log)
+			"ghcr.io/project-zot/trivy-not-db", "", 1, nil, log)
 
-		err = scanner.UpdateDB()
+		err = scanner.UpdateJavaDB()
 		So(err, ShouldNotBeNil)
 
+		dbStatus := scanner.GetDBStatus()
+		So(len(dbStatus), ShouldEqual, 2)
+		So(dbStatus[1].Name, ShouldEqual, model.DBNameTrivyJavaDB)
+		So(dbStatus[1].Error, ShouldNotBeEmpty)
+		So(dbStatus[1].UpdatedAt, ShouldBeNil)
+
 		// Download DB passes for valid Trivy DB url, and missing Trivy Java DB url
 		// Download DB is necessary since DB download on scan is disabled
-		scanner = NewScanner(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", log)
+		scanner = NewScanner(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", "", 1, nil, log)
 
 		err = scanner.UpdateDB()
 		So(err, ShouldBeNil)
 
 		// Scanning image with correct options
 		opts = scanner.getTrivyOptions(img)
-		_, err = scanner.runTrivy(opts)
+		_, err = scanner.runTrivy(context.Background(), opts)
 		So(err, ShouldBeNil)
 
 		// Scanning image with incorrect cache options
 		// to trigger runner initialization errors
 		opts.CacheOptions.CacheBackend = "redis://asdf!$%&!*)("
-		_, err = scanner.runTrivy(opts)
+		_, err = scanner.runTrivy(context.Background(), opts)
 		So(err, ShouldNotBeNil)
 
 		// Scanning image with invalid input to trigger a scanner error
 		opts = scanner.getTrivyOptions("nilnonexisting_image:0.0.1")
-		_, err = scanner.runTrivy(opts)
+		_, err = scanner.runTrivy(context.Background(), opts)
 		So(err, ShouldNotBeNil)
 
 		// Scanning image with incorrect report options
 		// to trigger report filtering errors
 		opts = scanner.getTrivyOptions(img)
 		opts.ReportOptions.IgnorePolicy = "invalid file path"
-		_, err = scanner.runTrivy(opts)
+		_, err = scanner.runTrivy(context.Background(), opts)
 		So(err, ShouldNotBeNil)
 	})
 }
@@ -308,6 +315,11 @@ func TestImageScannable(t *testing.T) {
 				Size:      0,
 				Digest:    godigest.NewDigestFromEncoded(godigest.SHA256, "digest"),
 			},
+			{
+				MediaType: ispec.MediaTypeImageLayerZstd,
+				Size:      0,
+				Digest:    godigest.NewDigestFromEncoded(godigest.SHA256, "zstd-digest"),
+			},
 		},
 	})
 	if err != nil {
@@ -368,6 +380,41 @@ func TestImageScannable(t *testing.T) {
 		panic(err)
 	}
 
+	// Create RepoDB data for manifest with encrypted layers
+	manifestBlobEncryptedLayer, err := json.Marshal(ispec.Manifest{
+		Config: ispec.Descriptor{
+			MediaType: ispec.MediaTypeImageConfig,
+			Size:      0,
+			Digest:    godigest.FromBytes(validConfigBlob),
+		},
+		Layers: []ispec.Descriptor{
+			{
+				MediaType: common.MediaTypeImageLayerGzipEncrypted,
+				Size:      0,
+				Digest:    godigest.NewDigestFromEncoded(godigest.SHA256, "digest"),
+			},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	digestManifestEncryptedLayer := godigest.FromBytes(manifestBlobEncryptedLayer)
+
+	err = repoDB.SetManifestData(digestManifestEncryptedLayer, repodb.ManifestData{
+		ManifestBlob: manifestBlobEncryptedLayer,
+		ConfigBlob:   validConfigBlob,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	err = repoDB.SetRepoReference("repo1", "encrypted-layer", digestManifestEncryptedLayer,
+		ispec.MediaTypeImageManifest)
+	if err != nil {
+		panic(err)
+	}
+
 	// Create RepoDB data for unmarshable manifest
 	unmarshableManifestBlob := []byte("Some string")
 	repoMetaUnmarshable := repodb.ManifestData{
@@ -410,7 +457,7 @@ func TestImageScannable(t *testing.T) {
 	storeController.DefaultStore = store
 
 	scanner := NewScanner(storeController, repoDB, "ghcr.io/project-zot/trivy-db",
-		"ghcr.io/aquasecurity/trivy-java-db", log)
+		"ghcr.io/aquasecurity/trivy-java-db", "", 1, nil, log)
 
 	Convey("Valid image should be scannable", t, func() {
 		result, err := scanner.IsImageFormatScannable("repo1", "valid")
@@ -424,6 +471,12 @@ func TestImageScannable(t *testing.T) {
 		So(result, ShouldBeFalse)
 	})
 
+	Convey("Image with encrypted layers should be unscannable", t, func() {
+		result, err := scanner.IsImageFormatScannable("repo1", "encrypted-layer")
+		So(err, ShouldEqual, zerr.ErrImageEncrypted)
+		So(result, ShouldBeFalse)
+	})
+
 	Convey("Image with unmarshable manifests should be unscannable", t, func() {
 		result, err := scanner.IsImageFormatScannable("repo1", "unmarshable")
 		So(err, ShouldNotBeNil)
@@ -489,24 +542,27 @@ func TestDefaultTrivyDBUrl(t *testing.T) {
 		So(err, ShouldBeNil)
 
 		scanner := NewScanner(storeController, repoDB, "ghcr.io/aquasecurity/trivy-db",
-			"ghcr.io/aquasecurity/trivy-java-db", log)
+			"ghcr.io/aquasecurity/trivy-java-db", "", 1, nil, log)
 
 		// Download DB since DB download on scan is disabled
 		err = scanner.UpdateDB()
 		So(err, ShouldBeNil)
 
+		err = scanner.UpdateJavaDB()
+		So(err, ShouldBeNil)
+
 		// Scanning image
 		img := "zot-test:0.0.1" //nolint:goconst
 
 		opts := scanner.getTrivyOptions(img)
-		_, err = scanner.runTrivy(opts)
+		_, err = scanner.runTrivy(context.Background(), opts)
 		So(err, ShouldBeNil)
 
 		// Scanning image containing a jar file
 		img = "zot-cve-java-test:0.0.1"
 
 		opts = scanner.getTrivyOptions(img)
-		_, err = scanner.runTrivy(opts)
+		_, err = scanner.runTrivy(context.Background(), opts)
 		So(err, ShouldBeNil)
 	})
 }
@@ -520,7 +576,7 @@ func TestIsIndexScanable(t *testing.T) {
 		log := log.NewLogger("debug", "")
 
 		Convey("Find index in cache", func() {
-			scanner := NewScanner(storeController, repoDB, "", "", log)
+			scanner := NewScanner(storeController, repoDB, "", "", "", 1, nil, log)
 
 			scanner.cache.Add("digest", make(map[string]model.CVE))
 
@@ -544,7 +600,7 @@ func TestScanIndexErrors(t *testing.T) {
 				return repodb.IndexData{}, godigest.ErrDigestUnsupported
 			}
 
-			scanner := NewScanner(storeController, repoDB, "", "", log)
+			scanner := NewScanner(storeController, repoDB, "", "", "", 1, nil, log)
 
 			_, err := scanner.scanIndex("repo", "digest")
 			So(err, ShouldNotBeNil)
@@ -557,7 +613,7 @@ func TestScanIndexErrors(t *testing.T) {
 				}, nil
 			}
 
-			scanner := NewScanner(storeController, repoDB, "", "", log)
+			scanner := NewScanner(storeController, repoDB, "", "", "", 1, nil, log)
 
 			_, err := scanner.scanIndex("repo", "digest")
 			So(err, ShouldNotBeNil)
@@ -577,7 +633,7 @@ func TestIsIndexScanableErrors(t *testing.T) {
 			repoDB.GetIndexDataFn = func(indexDigest godigest.Digest) (repodb.IndexData, error) {
 				return repodb.IndexData{}, zerr.ErrManifestDataNotFound
 			}
-			scanner := NewScanner(storeController, repoDB, "", "", log)
+			scanner := NewScanner(storeController, repoDB, "", "", "", 1, nil, log)
 
 			_, err := scanner.isIndexScanable("digest")
 			So(err, ShouldNotBeNil)
@@ -587,7 +643,7 @@ func TestIsIndexScanableErrors(t *testing.T) {
 			repoDB.GetIndexDataFn = func(indexDigest godigest.Digest) (repodb.IndexData, error) {
 				return repodb.IndexData{IndexBlob: []byte(`bad`)}, nil
 			}
-			scanner := NewScanner(storeController, repoDB, "", "", log)
+			scanner := NewScanner(storeController, repoDB, "", "", "", 1, nil, log)
 
 			ok, err := scanner.isIndexScanable("digest")
 			So(err, ShouldNotBeNil)
@@ -618,7 +674,7 @@ func TestIsIndexScanableErrors(t *testing.T) {
 
 				return repodb.ManifestData{}, nil
 			}
-			scanner := NewScanner(storeController, repoDB, "", "", log)
+			scanner := NewScanner(storeController, repoDB, "", "", "", 1, nil, log)
 
 			ok, err := scanner.isIndexScanable("digest")
 			So(err, ShouldBeNil)
@@ -637,7 +693,7 @@ func TestIsIndexScanableErrors(t *testing.T) {
 			repoDB.GetManifestDataFn = func(manifestDigest godigest.Digest) (repodb.ManifestData, error) {
 				return repodb.ManifestData{}, zerr.ErrBadBlob
 			}
-			scanner := NewScanner(storeController, repoDB, "", "", log)
+			scanner := NewScanner(storeController, repoDB, "", "", "", 1, nil, log)
 
 			ok, err := scanner.isIndexScanable("digest")
 			So(err, ShouldBeNil)
@@ -645,3 +701,66 @@ func TestIsIndexScanableErrors(t *testing.T) {
 		})
 	})
 }
+
+func TestOfflineJavaDB(t *testing.T) {
+	Convey("Java DB loaded from a directory", t, func() {
+		rootDir := t.TempDir()
+		javaDBPath := t.TempDir()
+
+		storeController := storage.StoreController{}
+		storeController.DefaultStore = mocks.MockedImageStore{
+			RootDirFn: func() string {
+				return rootDir
+			},
+		}
+
+		log := log.NewLogger("debug", "")
+
+		scanner := NewScanner(storeController, mocks.RepoDBMock{}, "ghcr.io/project-zot/trivy-db",
+			"ghcr.io/aquasecurity/trivy-java-db", javaDBPath, 1, nil, log)
+
+		dbStatus := scanner.GetDBStatus()
+		So(len(dbStatus), ShouldEqual, 2)
+		So(dbStatus[1].Source, ShouldEqual, javaDBPath)
+		So(dbStatus[1].Offline, ShouldBeTrue)
+
+		// the directory doesn't hold a Java DB
+		err := scanner.UpdateJavaDB()
+		So(err, ShouldNotBeNil)
+		So(scanner.GetDBStatus()[1].Error, ShouldNotBeEmpty)
+
+		for _, name := range javaDBFiles {
+			err = os.WriteFile(path.Join(javaDBPath, name), []byte(name), 0o600)
+			So(err, ShouldBeNil)
+		}
+
+		err = scanner.UpdateJavaDB()
+		So(err, ShouldBeNil)
+
+		dbStatus = scanner.GetDBStatus()
+		So(dbStatus[1].Error, ShouldBeEmpty)
+		So(dbStatus[1].UpdatedAt, ShouldNotBeNil)
+
+		// the Trivy DB status is tracked separately
+		So(dbStatus[0].Name, ShouldEqual, model.DBNameTrivy)
+		So(dbStatus[0].LastAttempt, ShouldBeNil)
+
+		for _, name := range javaDBFiles {
+			content, err := os.ReadFile(path.Join(rootDir, "_trivy", javaDBDir, name))
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, name)
+		}
+	})
+
+	Convey("Java DB not used", t, func() {
+		storeController := storage.StoreController{}
+		storeController.DefaultStore = mocks.MockedImageStore{}
+
+		scanner := NewScanner(storeController, mocks.RepoDBMock{}, "ghcr.io/project-zot/trivy-db", "", "", 1, nil,
+			log.NewLogger("debug", ""))
+
+		err := scanner.UpdateJavaDB()
+		So(err, ShouldBeNil)
+		So(len(scanner.GetDBStatus()), ShouldEqual, 1)
+	})
+}
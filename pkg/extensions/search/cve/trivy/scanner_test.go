@@ -64,7 +64,8 @@ func TestScanningByDigest(t *testing.T) {
 		So(err, ShouldBeNil)
 
 		// scan
-		scanner := trivy.NewScanner(ctlr.StoreController, ctlr.RepoDB, "ghcr.io/project-zot/trivy-db", "", ctlr.Log)
+		scanner := trivy.NewScanner(ctlr.StoreController, ctlr.RepoDB, "ghcr.io/project-zot/trivy-db", "", "", 1, nil,
+			ctlr.Log)
 
 		err = scanner.UpdateDB()
 		So(err, ShouldBeNil)
@@ -107,7 +108,7 @@ func TestScannerErrors(t *testing.T) {
 			repoDB.GetIndexDataFn = func(indexDigest godigest.Digest) (repodb.IndexData, error) {
 				return repodb.IndexData{}, zerr.ErrManifestDataNotFound
 			}
-			scanner := trivy.NewScanner(storeController, repoDB, "", "", log)
+			scanner := trivy.NewScanner(storeController, repoDB, "", "", "", 1, nil, log)
 
 			_, err := scanner.ScanImage("repo@" + digest.String())
 			So(err, ShouldNotBeNil)
@@ -174,7 +175,7 @@ func TestVulnerableLayer(t *testing.T) {
 		err = repodb.ParseStorage(repoDB, storeController, log)
 		So(err, ShouldBeNil)
 
-		scanner := trivy.NewScanner(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", log)
+		scanner := trivy.NewScanner(storeController, repoDB, "ghcr.io/project-zot/trivy-db", "", "", 1, nil, log)
 
 		err = scanner.UpdateDB()
 		So(err, ShouldBeNil)
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.io/zot/errors"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+const DefaultMinDigestPrefixLength = 8
+
+// optional algorithm followed by lowercase hex characters, e.g. "sha256:ab12ef" or "ab12ef".
+var digestPrefixRegexp = regexp.MustCompile(`^(?:([a-z0-9]+(?:[.+_-][a-z0-9]+)*):)?([a-f0-9]+)$`)
+
+// DigestResolution is the result of resolving an abbreviated digest, Candidates is only set if the prefix
+// matches more than one manifest.
+type DigestResolution struct {
+	Digest     string   `json:"digest,omitempty"`
+	MediaType  string   `json:"mediaType,omitempty"`
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+// GetMinDigestPrefixLength returns the configured minimum prefix length or the default one.
+func GetMinDigestPrefixLength(minLength int) int {
+	if minLength > 0 {
+		return minLength
+	}
+
+	return DefaultMinDigestPrefixLength
+}
+
+/*
+ResolveDigestPrefix looks up the manifests of a repo whose digest starts with prefix, the same way container
+engines resolve short image IDs. The algorithm can be left out of the prefix, minLength is the minimum number
+of hex characters. If more than one manifest matches, ErrAmbiguousDigestPrefix is returned along with
+a resolution listing all the candidates.
+*/
+func ResolveDigestPrefix(imgStore storageTypes.ImageStore, repo, prefix string, minLength int,
+) (DigestResolution, error) {
+	resolution := DigestResolution{}
+
+	matches := digestPrefixRegexp.FindStringSubmatch(prefix)
+	if matches == nil {
+		return resolution, fmt.Errorf("%w: %s", zerr.ErrBadDigestPrefix, prefix)
+	}
+
+	algorithm, encoded := matches[1], matches[2]
+
+	if len(encoded) < minLength {
+		return resolution, fmt.Errorf("%w: %s has less than %d characters", zerr.ErrDigestPrefixTooShort,
+			prefix, minLength)
+	}
+
+	buf, err := imgStore.GetIndexContent(repo)
+	if err != nil {
+		return resolution, err
+	}
+
+	var index ispec.Index
+	if err := json.Unmarshal(buf, &index); err != nil {
+		return resolution, err
+	}
+
+	// the same manifest can be tagged more than once
+	found := map[godigest.Digest]string{}
+
+	for _, desc := range index.Manifests {
+		if algorithm != "" && desc.Digest.Algorithm().String() != algorithm {
+			continue
+		}
+
+		if strings.HasPrefix(desc.Digest.Encoded(), encoded) {
+			found[desc.Digest] = desc.MediaType
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return resolution, zerr.ErrManifestNotFound
+	case 1:
+		for digest, mediaType := range found {
+			resolution.Digest = digest.String()
+			resolution.MediaType = mediaType
+		}
+
+		return resolution, nil
+	default:
+		for digest := range found {
+			resolution.Candidates = append(resolution.Candidates, digest.String())
+		}
+
+		sort.Strings(resolution.Candidates)
+
+		return resolution, zerr.ErrAmbiguousDigestPrefix
+	}
+}
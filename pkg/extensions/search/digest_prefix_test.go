@@ -0,0 +1,193 @@
+//go:build search
+// +build search
+
+package search_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/constants"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/extensions/search"
+	. "zotregistry.io/zot/pkg/test"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+var ErrTestIndex = errors.New("test: index error")
+
+func TestResolveDigestPrefix(t *testing.T) {
+	Convey("Resolve digest prefixes", t, func() {
+		digest1 := godigest.Digest("sha256:ab12ef0000000000000000000000000000000000000000000000000000000001")
+		digest2 := godigest.Digest("sha256:ab12ef0000000000000000000000000000000000000000000000000000000002")
+		digest3 := godigest.Digest("sha256:cd34560000000000000000000000000000000000000000000000000000000003")
+
+		index := ispec.Index{
+			Manifests: []ispec.Descriptor{
+				{
+					MediaType:   ispec.MediaTypeImageManifest,
+					Digest:      digest1,
+					Annotations: map[string]string{ispec.AnnotationRefName: "1.0"},
+				},
+				{MediaType: ispec.MediaTypeImageManifest, Digest: digest2},
+				{
+					MediaType:   ispec.MediaTypeImageIndex,
+					Digest:      digest3,
+					Annotations: map[string]string{ispec.AnnotationRefName: "2.0"},
+				},
+				{
+					MediaType:   ispec.MediaTypeImageIndex,
+					Digest:      digest3,
+					Annotations: map[string]string{ispec.AnnotationRefName: "latest"},
+				},
+			},
+		}
+
+		indexContent, err := json.Marshal(index)
+		So(err, ShouldBeNil)
+
+		imgStore := mocks.MockedImageStore{
+			GetIndexContentFn: func(repo string) ([]byte, error) {
+				return indexContent, nil
+			},
+		}
+
+		resolution, err := search.ResolveDigestPrefix(imgStore, "repo", "sha256:cd3456", 6)
+		So(err, ShouldBeNil)
+		So(resolution.Digest, ShouldEqual, digest3.String())
+		So(resolution.MediaType, ShouldEqual, ispec.MediaTypeImageIndex)
+		So(resolution.Candidates, ShouldBeEmpty)
+
+		// the algorithm is optional
+		resolution, err = search.ResolveDigestPrefix(imgStore, "repo", digest1.Encoded(), 6)
+		So(err, ShouldBeNil)
+		So(resolution.Digest, ShouldEqual, digest1.String())
+
+		resolution, err = search.ResolveDigestPrefix(imgStore, "repo", "ab12ef", 6)
+		So(errors.Is(err, zerr.ErrAmbiguousDigestPrefix), ShouldBeTrue)
+		So(resolution.Candidates, ShouldResemble, []string{digest1.String(), digest2.String()})
+
+		_, err = search.ResolveDigestPrefix(imgStore, "repo", "sha512:ab12ef", 6)
+		So(errors.Is(err, zerr.ErrManifestNotFound), ShouldBeTrue)
+
+		_, err = search.ResolveDigestPrefix(imgStore, "repo", "ef9999", 6)
+		So(errors.Is(err, zerr.ErrManifestNotFound), ShouldBeTrue)
+
+		_, err = search.ResolveDigestPrefix(imgStore, "repo", "ab12", 6)
+		So(errors.Is(err, zerr.ErrDigestPrefixTooShort), ShouldBeTrue)
+
+		for _, prefix := range []string{"AB12EF", "sha256:", "sha256:xyz123", "ab12ef/", ":ab12ef"} {
+			_, err = search.ResolveDigestPrefix(imgStore, "repo", prefix, 1)
+			So(errors.Is(err, zerr.ErrBadDigestPrefix), ShouldBeTrue)
+		}
+
+		imgStore.GetIndexContentFn = func(repo string) ([]byte, error) {
+			return nil, ErrTestIndex
+		}
+
+		_, err = search.ResolveDigestPrefix(imgStore, "repo", "ab12ef", 6)
+		So(errors.Is(err, ErrTestIndex), ShouldBeTrue)
+
+		imgStore.GetIndexContentFn = func(repo string) ([]byte, error) {
+			return []byte("bad index"), nil
+		}
+
+		_, err = search.ResolveDigestPrefix(imgStore, "repo", "ab12ef", 6)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Default minimum prefix length", t, func() {
+		So(search.GetMinDigestPrefixLength(0), ShouldEqual, search.DefaultMinDigestPrefixLength)
+		So(search.GetMinDigestPrefixLength(12), ShouldEqual, 12)
+	})
+}
+
+func TestDigestPrefixHTTP(t *testing.T) {
+	Convey("Resolve digest prefixes with the digests endpoint", t, func() {
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{
+				BaseConfig:            extconf.BaseConfig{Enable: &defaultVal},
+				MinDigestPrefixLength: 1,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+		ctrlManager := NewControllerManager(ctlr)
+
+		ctrlManager.StartAndWait(port)
+
+		defer ctrlManager.StopServer()
+
+		// with 17 images at least 2 digests start with the same hex character
+		digests := map[string][]godigest.Digest{}
+
+		for i := 0; i < 17; i++ {
+			image, err := GetRandomImage(fmt.Sprintf("tag%d", i))
+			So(err, ShouldBeNil)
+
+			digest, err := image.Digest()
+			So(err, ShouldBeNil)
+
+			err = UploadImage(image, baseURL, "test/repo")
+			So(err, ShouldBeNil)
+
+			digests[digest.Encoded()[:1]] = append(digests[digest.Encoded()[:1]], digest)
+		}
+
+		digestsURL := baseURL + constants.FullDigestsPrefix + "/test/repo/"
+
+		for prefix, matching := range digests {
+			var resolution search.DigestResolution
+
+			if len(matching) == 1 {
+				resp, err := resty.R().Get(digestsURL + matching[0].String()[:len("sha256:")+8])
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, 200)
+
+				err = json.Unmarshal(resp.Body(), &resolution)
+				So(err, ShouldBeNil)
+				So(resolution.Digest, ShouldEqual, matching[0].String())
+				So(resolution.MediaType, ShouldEqual, ispec.MediaTypeImageManifest)
+
+				continue
+			}
+
+			resp, err := resty.R().Get(digestsURL + prefix)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, 409)
+
+			err = json.Unmarshal(resp.Body(), &resolution)
+			So(err, ShouldBeNil)
+			So(resolution.Digest, ShouldBeEmpty)
+			So(len(resolution.Candidates), ShouldEqual, len(matching))
+
+			for _, digest := range matching {
+				So(resolution.Candidates, ShouldContain, digest.String())
+			}
+		}
+
+		resp, err := resty.R().Get(digestsURL + "sha256:XYZ")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, 400)
+
+		resp, err = resty.R().Get(baseURL + constants.FullDigestsPrefix + "/missing/repo/ab12ef")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, 404)
+	})
+}
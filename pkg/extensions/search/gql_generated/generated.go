@@ -48,12 +48,22 @@ type ComplexityRoot struct {
 		Value func(childComplexity int) int
 	}
 
+	AttestationSummary struct {
+		Digest        func(childComplexity int) int
+		PredicateType func(childComplexity int) int
+		Tool          func(childComplexity int) int
+	}
+
 	CVE struct {
-		Description func(childComplexity int) int
-		ID          func(childComplexity int) int
-		PackageList func(childComplexity int) int
-		Severity    func(childComplexity int) int
-		Title       func(childComplexity int) int
+		AcknowledgedBy        func(childComplexity int) int
+		AcknowledgementExpiry func(childComplexity int) int
+		AcknowledgementReason func(childComplexity int) int
+		Description           func(childComplexity int) int
+		ID                    func(childComplexity int) int
+		IsAcknowledged        func(childComplexity int) int
+		PackageList           func(childComplexity int) int
+		Severity              func(childComplexity int) int
+		Title                 func(childComplexity int) int
 	}
 
 	CVEResultForImage struct {
@@ -78,26 +88,34 @@ type ComplexityRoot struct {
 	}
 
 	ImageSummary struct {
-		Authors         func(childComplexity int) int
-		Description     func(childComplexity int) int
-		Digest          func(childComplexity int) int
-		Documentation   func(childComplexity int) int
-		DownloadCount   func(childComplexity int) int
-		IsSigned        func(childComplexity int) int
-		Labels          func(childComplexity int) int
-		LastUpdated     func(childComplexity int) int
-		Licenses        func(childComplexity int) int
-		Manifests       func(childComplexity int) int
-		MediaType       func(childComplexity int) int
-		Referrers       func(childComplexity int) int
-		RepoName        func(childComplexity int) int
-		SignatureInfo   func(childComplexity int) int
-		Size            func(childComplexity int) int
-		Source          func(childComplexity int) int
-		Tag             func(childComplexity int) int
-		Title           func(childComplexity int) int
-		Vendor          func(childComplexity int) int
-		Vulnerabilities func(childComplexity int) int
+		Attestations        func(childComplexity int) int
+		Authors             func(childComplexity int) int
+		Description         func(childComplexity int) int
+		Digest              func(childComplexity int) int
+		DigestAliases       func(childComplexity int) int
+		Documentation       func(childComplexity int) int
+		DownloadCount       func(childComplexity int) int
+		IsEncrypted         func(childComplexity int) int
+		IsPinned            func(childComplexity int) int
+		IsSigned            func(childComplexity int) int
+		Labels              func(childComplexity int) int
+		LastUpdated         func(childComplexity int) int
+		Licenses            func(childComplexity int) int
+		LogicalSize         func(childComplexity int) int
+		Manifests           func(childComplexity int) int
+		MediaType           func(childComplexity int) int
+		Referrers           func(childComplexity int) int
+		RegistryAnnotations func(childComplexity int) int
+		RepoName            func(childComplexity int) int
+		Sbom                func(childComplexity int) int
+		SignatureInfo       func(childComplexity int) int
+		Size                func(childComplexity int) int
+		Source              func(childComplexity int) int
+		Tag                 func(childComplexity int) int
+		Title               func(childComplexity int) int
+		UniqueSize          func(childComplexity int) int
+		Vendor              func(childComplexity int) int
+		Vulnerabilities     func(childComplexity int) int
 	}
 
 	ImageVulnerabilitySummary struct {
@@ -121,6 +139,7 @@ type ComplexityRoot struct {
 		Digest          func(childComplexity int) int
 		DownloadCount   func(childComplexity int) int
 		History         func(childComplexity int) int
+		IsEncrypted     func(childComplexity int) int
 		IsSigned        func(childComplexity int) int
 		LastUpdated     func(childComplexity int) int
 		Layers          func(childComplexity int) int
@@ -170,6 +189,7 @@ type ComplexityRoot struct {
 		ImageListForDigest      func(childComplexity int, id string, requestedPage *PageInput) int
 		ImageListWithCVEFixed   func(childComplexity int, id string, image string, requestedPage *PageInput) int
 		Referrers               func(childComplexity int, repo string, digest string, typeArg []string) int
+		ReferrersGraph          func(childComplexity int, repo string, digest string, maxDepth *int) int
 		RepoListWithNewestImage func(childComplexity int, requestedPage *PageInput) int
 		StarredRepos            func(childComplexity int, requestedPage *PageInput) int
 	}
@@ -182,6 +202,16 @@ type ComplexityRoot struct {
 		Size         func(childComplexity int) int
 	}
 
+	ReferrerGraphNode struct {
+		Annotations  func(childComplexity int) int
+		ArtifactType func(childComplexity int) int
+		Depth        func(childComplexity int) int
+		Digest       func(childComplexity int) int
+		MediaType    func(childComplexity int) int
+		Size         func(childComplexity int) int
+		Subject      func(childComplexity int) int
+	}
+
 	RepoInfo struct {
 		Images  func(childComplexity int) int
 		Summary func(childComplexity int) int
@@ -200,6 +230,13 @@ type ComplexityRoot struct {
 		Vendors       func(childComplexity int) int
 	}
 
+	SBOMSummary struct {
+		Digest       func(childComplexity int) int
+		Format       func(childComplexity int) int
+		Licenses     func(childComplexity int) int
+		PackageCount func(childComplexity int) int
+	}
+
 	SignatureSummary struct {
 		Author    func(childComplexity int) int
 		IsTrusted func(childComplexity int) int
@@ -220,6 +257,7 @@ type QueryResolver interface {
 	BaseImageList(ctx context.Context, image string, digest *string, requestedPage *PageInput) (*PaginatedImagesResult, error)
 	Image(ctx context.Context, image string) (*ImageSummary, error)
 	Referrers(ctx context.Context, repo string, digest string, typeArg []string) ([]*Referrer, error)
+	ReferrersGraph(ctx context.Context, repo string, digest string, maxDepth *int) ([]*ReferrerGraphNode, error)
 	StarredRepos(ctx context.Context, requestedPage *PageInput) (*PaginatedReposResult, error)
 	BookmarkedRepos(ctx context.Context, requestedPage *PageInput) (*PaginatedReposResult, error)
 }
@@ -253,6 +291,48 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.Annotation.Value(childComplexity), true
 
+	case "AttestationSummary.Digest":
+		if e.complexity.AttestationSummary.Digest == nil {
+			break
+		}
+
+		return e.complexity.AttestationSummary.Digest(childComplexity), true
+
+	case "AttestationSummary.PredicateType":
+		if e.complexity.AttestationSummary.PredicateType == nil {
+			break
+		}
+
+		return e.complexity.AttestationSummary.PredicateType(childComplexity), true
+
+	case "AttestationSummary.Tool":
+		if e.complexity.AttestationSummary.Tool == nil {
+			break
+		}
+
+		return e.complexity.AttestationSummary.Tool(childComplexity), true
+
+	case "CVE.AcknowledgedBy":
+		if e.complexity.CVE.AcknowledgedBy == nil {
+			break
+		}
+
+		return e.complexity.CVE.AcknowledgedBy(childComplexity), true
+
+	case "CVE.AcknowledgementExpiry":
+		if e.complexity.CVE.AcknowledgementExpiry == nil {
+			break
+		}
+
+		return e.complexity.CVE.AcknowledgementExpiry(childComplexity), true
+
+	case "CVE.AcknowledgementReason":
+		if e.complexity.CVE.AcknowledgementReason == nil {
+			break
+		}
+
+		return e.complexity.CVE.AcknowledgementReason(childComplexity), true
+
 	case "CVE.Description":
 		if e.complexity.CVE.Description == nil {
 			break
@@ -267,6 +347,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.CVE.ID(childComplexity), true
 
+	case "CVE.IsAcknowledged":
+		if e.complexity.CVE.IsAcknowledged == nil {
+			break
+		}
+
+		return e.complexity.CVE.IsAcknowledged(childComplexity), true
+
 	case "CVE.PackageList":
 		if e.complexity.CVE.PackageList == nil {
 			break
@@ -372,6 +459,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.HistoryDescription.EmptyLayer(childComplexity), true
 
+	case "ImageSummary.Attestations":
+		if e.complexity.ImageSummary.Attestations == nil {
+			break
+		}
+
+		return e.complexity.ImageSummary.Attestations(childComplexity), true
+
 	case "ImageSummary.Authors":
 		if e.complexity.ImageSummary.Authors == nil {
 			break
@@ -393,6 +487,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ImageSummary.Digest(childComplexity), true
 
+	case "ImageSummary.DigestAliases":
+		if e.complexity.ImageSummary.DigestAliases == nil {
+			break
+		}
+
+		return e.complexity.ImageSummary.DigestAliases(childComplexity), true
+
 	case "ImageSummary.Documentation":
 		if e.complexity.ImageSummary.Documentation == nil {
 			break
@@ -407,6 +508,20 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ImageSummary.DownloadCount(childComplexity), true
 
+	case "ImageSummary.IsEncrypted":
+		if e.complexity.ImageSummary.IsEncrypted == nil {
+			break
+		}
+
+		return e.complexity.ImageSummary.IsEncrypted(childComplexity), true
+
+	case "ImageSummary.IsPinned":
+		if e.complexity.ImageSummary.IsPinned == nil {
+			break
+		}
+
+		return e.complexity.ImageSummary.IsPinned(childComplexity), true
+
 	case "ImageSummary.IsSigned":
 		if e.complexity.ImageSummary.IsSigned == nil {
 			break
@@ -435,6 +550,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ImageSummary.Licenses(childComplexity), true
 
+	case "ImageSummary.LogicalSize":
+		if e.complexity.ImageSummary.LogicalSize == nil {
+			break
+		}
+
+		return e.complexity.ImageSummary.LogicalSize(childComplexity), true
+
 	case "ImageSummary.Manifests":
 		if e.complexity.ImageSummary.Manifests == nil {
 			break
@@ -456,6 +578,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ImageSummary.Referrers(childComplexity), true
 
+	case "ImageSummary.RegistryAnnotations":
+		if e.complexity.ImageSummary.RegistryAnnotations == nil {
+			break
+		}
+
+		return e.complexity.ImageSummary.RegistryAnnotations(childComplexity), true
+
 	case "ImageSummary.RepoName":
 		if e.complexity.ImageSummary.RepoName == nil {
 			break
@@ -463,6 +592,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ImageSummary.RepoName(childComplexity), true
 
+	case "ImageSummary.SBOM":
+		if e.complexity.ImageSummary.Sbom == nil {
+			break
+		}
+
+		return e.complexity.ImageSummary.Sbom(childComplexity), true
+
 	case "ImageSummary.SignatureInfo":
 		if e.complexity.ImageSummary.SignatureInfo == nil {
 			break
@@ -498,6 +634,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ImageSummary.Title(childComplexity), true
 
+	case "ImageSummary.UniqueSize":
+		if e.complexity.ImageSummary.UniqueSize == nil {
+			break
+		}
+
+		return e.complexity.ImageSummary.UniqueSize(childComplexity), true
+
 	case "ImageSummary.Vendor":
 		if e.complexity.ImageSummary.Vendor == nil {
 			break
@@ -589,6 +732,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ManifestSummary.History(childComplexity), true
 
+	case "ManifestSummary.IsEncrypted":
+		if e.complexity.ManifestSummary.IsEncrypted == nil {
+			break
+		}
+
+		return e.complexity.ManifestSummary.IsEncrypted(childComplexity), true
+
 	case "ManifestSummary.IsSigned":
 		if e.complexity.ManifestSummary.IsSigned == nil {
 			break
@@ -866,6 +1016,18 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.Query.Referrers(childComplexity, args["repo"].(string), args["digest"].(string), args["type"].([]string)), true
 
+	case "Query.ReferrersGraph":
+		if e.complexity.Query.ReferrersGraph == nil {
+			break
+		}
+
+		args, err := ec.field_Query_ReferrersGraph_args(context.TODO(), rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Query.ReferrersGraph(childComplexity, args["repo"].(string), args["digest"].(string), args["maxDepth"].(*int)), true
+
 	case "Query.RepoListWithNewestImage":
 		if e.complexity.Query.RepoListWithNewestImage == nil {
 			break
@@ -925,6 +1087,55 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.Referrer.Size(childComplexity), true
 
+	case "ReferrerGraphNode.Annotations":
+		if e.complexity.ReferrerGraphNode.Annotations == nil {
+			break
+		}
+
+		return e.complexity.ReferrerGraphNode.Annotations(childComplexity), true
+
+	case "ReferrerGraphNode.ArtifactType":
+		if e.complexity.ReferrerGraphNode.ArtifactType == nil {
+			break
+		}
+
+		return e.complexity.ReferrerGraphNode.ArtifactType(childComplexity), true
+
+	case "ReferrerGraphNode.Depth":
+		if e.complexity.ReferrerGraphNode.Depth == nil {
+			break
+		}
+
+		return e.complexity.ReferrerGraphNode.Depth(childComplexity), true
+
+	case "ReferrerGraphNode.Digest":
+		if e.complexity.ReferrerGraphNode.Digest == nil {
+			break
+		}
+
+		return e.complexity.ReferrerGraphNode.Digest(childComplexity), true
+
+	case "ReferrerGraphNode.MediaType":
+		if e.complexity.ReferrerGraphNode.MediaType == nil {
+			break
+		}
+
+		return e.complexity.ReferrerGraphNode.MediaType(childComplexity), true
+
+	case "ReferrerGraphNode.Size":
+		if e.complexity.ReferrerGraphNode.Size == nil {
+			break
+		}
+
+		return e.complexity.ReferrerGraphNode.Size(childComplexity), true
+
+	case "ReferrerGraphNode.Subject":
+		if e.complexity.ReferrerGraphNode.Subject == nil {
+			break
+		}
+
+		return e.complexity.ReferrerGraphNode.Subject(childComplexity), true
+
 	case "RepoInfo.Images":
 		if e.complexity.RepoInfo.Images == nil {
 			break
@@ -1009,6 +1220,34 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.RepoSummary.Vendors(childComplexity), true
 
+	case "SBOMSummary.Digest":
+		if e.complexity.SBOMSummary.Digest == nil {
+			break
+		}
+
+		return e.complexity.SBOMSummary.Digest(childComplexity), true
+
+	case "SBOMSummary.Format":
+		if e.complexity.SBOMSummary.Format == nil {
+			break
+		}
+
+		return e.complexity.SBOMSummary.Format(childComplexity), true
+
+	case "SBOMSummary.Licenses":
+		if e.complexity.SBOMSummary.Licenses == nil {
+			break
+		}
+
+		return e.complexity.SBOMSummary.Licenses(childComplexity), true
+
+	case "SBOMSummary.PackageCount":
+		if e.complexity.SBOMSummary.PackageCount == nil {
+			break
+		}
+
+		return e.complexity.SBOMSummary.PackageCount(childComplexity), true
+
 	case "SignatureSummary.Author":
 		if e.complexity.SignatureSummary.Author == nil {
 			break
@@ -1140,6 +1379,22 @@ type CVE {
     Information on the packages in which the CVE was found
     """
     PackageList: [PackageInfo]
+    """
+    True if the CVE was acknowledged for this image, acknowledged CVEs are not counted in the vulnerability summaries
+    """
+    IsAcknowledged: Boolean
+    """
+    Why the CVE was acknowledged
+    """
+    AcknowledgementReason: String
+    """
+    The user who acknowledged the CVE
+    """
+    AcknowledgedBy: String
+    """
+    When the acknowledgement expires, not set if it doesn't expire
+    """
+    AcknowledgementExpiry: Time
 }
 
 """
@@ -1227,6 +1482,15 @@ type ImageSummary {
     """
     Size: String
     """
+    Size of the blobs of the image, each counted once even if shared by several of its platforms
+    """
+    LogicalSize: String
+    """
+    Size of the blobs no other tagged image shares with this one in the storage, i.e. the space deleting it would reclaim,
+    blobs are only shared across repos if the storage dedupes them
+    """
+    UniqueSize: String
+    """
     Number of downloads of the manifest of this image
     """
     DownloadCount: Int
@@ -1247,6 +1511,24 @@ type ImageSummary {
     """
     SignatureInfo: [SignatureSummary]
     """
+    True if the image is pinned, pinned images are not garbage collected
+    """
+    IsPinned: Boolean
+    """
+    Annotations set on the registry side, they can be changed without altering the manifest digest
+    """
+    RegistryAnnotations: [Annotation]
+    """
+    Digests the image was pushed with before it was rewritten by the registry (e.g. converted from docker to oci),
+    pulling it by any of them returns this image
+    """
+    DigestAliases: [String]
+    """
+    True if at least one of the image manifests has encrypted layers (ocicrypt),
+    encrypted manifests are not scanned for vulnerabilities
+    """
+    IsEncrypted: Boolean
+    """
     License(s) under which contained software is distributed as an SPDX License Expression
     """
     Licenses: String  #  The value of the annotation if present, 'unknown' otherwise).
@@ -1283,6 +1565,14 @@ type ImageSummary {
     Information about objects that reference this image
     """
     Referrers: [Referrer]
+    """
+    Licenses and package count found in the SBOM attached to the image as a referrer, if there is one
+    """
+    SBOM: SBOMSummary
+    """
+    In-toto attestations attached to the image, e.g. with 'cosign attest', these are not listed in SignatureInfo
+    """
+    Attestations: [AttestationSummary]
 }
 """
 Details about a specific version of an image for a certain operating system and architecture.
@@ -1313,6 +1603,10 @@ type ManifestSummary {
     """
     SignatureInfo: [SignatureSummary]
     """
+    True if the manifest has encrypted layers (ocicrypt), encrypted manifests are not scanned for vulnerabilities
+    """
+    IsEncrypted: Boolean
+    """
     OS and architecture supported by this image
     """
     Platform: Platform
@@ -1458,6 +1752,46 @@ type LayerHistory {
     HistoryDescription: HistoryDescription
 }
 
+"""
+Summary of an SBOM (SPDX or CycloneDX JSON) attached to an image as a referrer
+"""
+type SBOMSummary {
+    """
+    Digest of the SBOM artifact
+    """
+    Digest: String
+    """
+    Format of the SBOM, either SPDX or CycloneDX
+    """
+    Format: String
+    """
+    SPDX license identifiers of the packages listed in the SBOM, license expressions are split in identifiers
+    """
+    Licenses: [String]
+    """
+    Number of packages listed in the SBOM
+    """
+    PackageCount: Int
+}
+
+"""
+Summary of an in-toto attestation attached to an image
+"""
+type AttestationSummary {
+    """
+    Digest of the manifest holding the attestation
+    """
+    Digest: String
+    """
+    Type of the predicate of the attestation, e.g. https://slsa.dev/provenance/v0.2
+    """
+    PredicateType: String
+    """
+    Tool used to attach the attestation, e.g. cosign
+    """
+    Tool: String
+}
+
 """
 Annotation is Key:Value pair representing custom data which is otherwise
 not available in other fields.
@@ -1501,6 +1835,42 @@ type Referrer {
     Annotations:  [Annotation]!
 }
 
+"""
+A referrer found when walking the referrers graph of a subject, e.g. a signature of an SBOM referring an image
+"""
+type ReferrerGraphNode {
+    """
+    Referrer MediaType
+    See https://github.com/opencontainers/artifacts for more details
+    """
+    MediaType:    String
+    """
+    Referrer ArtifactType
+    See https://github.com/opencontainers/artifacts for more details
+    """
+    ArtifactType: String
+    """
+    Total size of the referrer files in bytes
+    """
+    Size:         Int
+    """
+    Digest of the manifest file of the referrer
+    """
+    Digest:       String
+    """
+    A list of annotations associated with this referrer
+    """
+    Annotations:  [Annotation]!
+    """
+    Digest of the manifest this referrer refers
+    """
+    Subject:      String
+    """
+    Distance from the subject of the graph, the direct referrers of the subject have a depth of 1
+    """
+    Depth:        Int
+}
+
 """
 Contains details about the OS and architecture of the image
 """
@@ -1669,6 +2039,25 @@ input Filter {
     Only returns images or repositories that are starred or not starred
     """
     IsStarred: Boolean
+    """
+    Only return images or repositories with all the registry annotations in the list
+    Entries are either "key=value" or "key" to match any value
+    """
+    RegistryAnnotations: [String]
+    """
+    Only return images or repositories which were scanned and have no CVE more severe than the given severity
+    Should be one of NONE, UNKNOWN, LOW, MEDIUM, HIGH, CRITICAL
+    """
+    MaxCVESeverity: String
+    """
+    Only return images or repositories updated after the given time
+    """
+    LastUpdatedAfter: Time
+    """
+    Only return images or repositories without any of the licenses in the list in their SBOM
+    A license also excludes its variants, for example GPL-3.0 excludes GPL-3.0-only and GPL-3.0-or-later
+    """
+    ExcludedLicenses: [String]
 }
 
 """
@@ -1806,6 +2195,20 @@ type Query {
         type: [String!]
     ): [Referrer]!
 
+    """
+    Returns the referrers graph of an image or artifact manifest found in a <repo>: its referrers, the referrers
+    of its referrers and so on, e.g. signatures of SBOMs or attestations referring signatures
+    Each referrer is returned once, up to <maxDepth> levels from the subject
+    """
+    ReferrersGraph(
+        "Repository name"
+        repo: String!,
+        "Digest of the subject of the graph"
+        digest: String!,
+        "Number of levels of referrers to return, 1 returns only the direct referrers. Defaults to and can't exceed 10"
+        maxDepth: Int
+    ): [ReferrerGraphNode]!
+
     """
     Receive RepoSummaries of repos starred by current user
     """
@@ -2112,7 +2515,7 @@ func (ec *executionContext) field_Query_Image_args(ctx context.Context, rawArgs
 	return args, nil
 }
 
-func (ec *executionContext) field_Query_Referrers_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+func (ec *executionContext) field_Query_ReferrersGraph_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
 	var err error
 	args := map[string]interface{}{}
 	var arg0 string
@@ -2133,38 +2536,71 @@ func (ec *executionContext) field_Query_Referrers_args(ctx context.Context, rawA
 		}
 	}
 	args["digest"] = arg1
-	var arg2 []string
-	if tmp, ok := rawArgs["type"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("type"))
-		arg2, err = ec.unmarshalOString2ᚕstringᚄ(ctx, tmp)
+	var arg2 *int
+	if tmp, ok := rawArgs["maxDepth"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("maxDepth"))
+		arg2, err = ec.unmarshalOInt2ᚖint(ctx, tmp)
 		if err != nil {
 			return nil, err
 		}
 	}
-	args["type"] = arg2
+	args["maxDepth"] = arg2
 	return args, nil
 }
 
-func (ec *executionContext) field_Query_RepoListWithNewestImage_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+func (ec *executionContext) field_Query_Referrers_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
 	var err error
 	args := map[string]interface{}{}
-	var arg0 *PageInput
-	if tmp, ok := rawArgs["requestedPage"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("requestedPage"))
-		arg0, err = ec.unmarshalOPageInput2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInput(ctx, tmp)
+	var arg0 string
+	if tmp, ok := rawArgs["repo"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("repo"))
+		arg0, err = ec.unmarshalNString2string(ctx, tmp)
 		if err != nil {
 			return nil, err
 		}
 	}
-	args["requestedPage"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_Query_StarredRepos_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *PageInput
-	if tmp, ok := rawArgs["requestedPage"]; ok {
+	args["repo"] = arg0
+	var arg1 string
+	if tmp, ok := rawArgs["digest"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("digest"))
+		arg1, err = ec.unmarshalNString2string(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["digest"] = arg1
+	var arg2 []string
+	if tmp, ok := rawArgs["type"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("type"))
+		arg2, err = ec.unmarshalOString2ᚕstringᚄ(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["type"] = arg2
+	return args, nil
+}
+
+func (ec *executionContext) field_Query_RepoListWithNewestImage_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *PageInput
+	if tmp, ok := rawArgs["requestedPage"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("requestedPage"))
+		arg0, err = ec.unmarshalOPageInput2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["requestedPage"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_Query_StarredRepos_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *PageInput
+	if tmp, ok := rawArgs["requestedPage"]; ok {
 		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("requestedPage"))
 		arg0, err = ec.unmarshalOPageInput2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInput(ctx, tmp)
 		if err != nil {
@@ -2310,8 +2746,8 @@ func (ec *executionContext) fieldContext_Annotation_Value(ctx context.Context, f
 	return fc, nil
 }
 
-func (ec *executionContext) _CVE_Id(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_CVE_Id(ctx, field)
+func (ec *executionContext) _AttestationSummary_Digest(ctx context.Context, field graphql.CollectedField, obj *AttestationSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_AttestationSummary_Digest(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2324,7 +2760,7 @@ func (ec *executionContext) _CVE_Id(ctx context.Context, field graphql.Collected
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.ID, nil
+		return obj.Digest, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2338,9 +2774,9 @@ func (ec *executionContext) _CVE_Id(ctx context.Context, field graphql.Collected
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_CVE_Id(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_AttestationSummary_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "CVE",
+		Object:     "AttestationSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -2351,8 +2787,8 @@ func (ec *executionContext) fieldContext_CVE_Id(ctx context.Context, field graph
 	return fc, nil
 }
 
-func (ec *executionContext) _CVE_Title(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_CVE_Title(ctx, field)
+func (ec *executionContext) _AttestationSummary_PredicateType(ctx context.Context, field graphql.CollectedField, obj *AttestationSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_AttestationSummary_PredicateType(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2365,7 +2801,7 @@ func (ec *executionContext) _CVE_Title(ctx context.Context, field graphql.Collec
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Title, nil
+		return obj.PredicateType, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2379,9 +2815,9 @@ func (ec *executionContext) _CVE_Title(ctx context.Context, field graphql.Collec
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_CVE_Title(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_AttestationSummary_PredicateType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "CVE",
+		Object:     "AttestationSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -2392,8 +2828,8 @@ func (ec *executionContext) fieldContext_CVE_Title(ctx context.Context, field gr
 	return fc, nil
 }
 
-func (ec *executionContext) _CVE_Description(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_CVE_Description(ctx, field)
+func (ec *executionContext) _AttestationSummary_Tool(ctx context.Context, field graphql.CollectedField, obj *AttestationSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_AttestationSummary_Tool(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2406,7 +2842,7 @@ func (ec *executionContext) _CVE_Description(ctx context.Context, field graphql.
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Description, nil
+		return obj.Tool, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2420,9 +2856,9 @@ func (ec *executionContext) _CVE_Description(ctx context.Context, field graphql.
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_CVE_Description(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_AttestationSummary_Tool(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "CVE",
+		Object:     "AttestationSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -2433,8 +2869,8 @@ func (ec *executionContext) fieldContext_CVE_Description(ctx context.Context, fi
 	return fc, nil
 }
 
-func (ec *executionContext) _CVE_Severity(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_CVE_Severity(ctx, field)
+func (ec *executionContext) _CVE_Id(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVE_Id(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2447,7 +2883,7 @@ func (ec *executionContext) _CVE_Severity(ctx context.Context, field graphql.Col
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Severity, nil
+		return obj.ID, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2461,7 +2897,7 @@ func (ec *executionContext) _CVE_Severity(ctx context.Context, field graphql.Col
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_CVE_Severity(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVE_Id(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "CVE",
 		Field:      field,
@@ -2474,8 +2910,8 @@ func (ec *executionContext) fieldContext_CVE_Severity(ctx context.Context, field
 	return fc, nil
 }
 
-func (ec *executionContext) _CVE_PackageList(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_CVE_PackageList(ctx, field)
+func (ec *executionContext) _CVE_Title(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVE_Title(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2488,7 +2924,7 @@ func (ec *executionContext) _CVE_PackageList(ctx context.Context, field graphql.
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.PackageList, nil
+		return obj.Title, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2497,34 +2933,26 @@ func (ec *executionContext) _CVE_PackageList(ctx context.Context, field graphql.
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*PackageInfo)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOPackageInfo2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPackageInfo(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_CVE_PackageList(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVE_Title(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "CVE",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Name":
-				return ec.fieldContext_PackageInfo_Name(ctx, field)
-			case "InstalledVersion":
-				return ec.fieldContext_PackageInfo_InstalledVersion(ctx, field)
-			case "FixedVersion":
-				return ec.fieldContext_PackageInfo_FixedVersion(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type PackageInfo", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _CVEResultForImage_Tag(ctx context.Context, field graphql.CollectedField, obj *CVEResultForImage) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_CVEResultForImage_Tag(ctx, field)
+func (ec *executionContext) _CVE_Description(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVE_Description(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2537,7 +2965,7 @@ func (ec *executionContext) _CVEResultForImage_Tag(ctx context.Context, field gr
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Tag, nil
+		return obj.Description, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2551,9 +2979,9 @@ func (ec *executionContext) _CVEResultForImage_Tag(ctx context.Context, field gr
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_CVEResultForImage_Tag(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVE_Description(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "CVEResultForImage",
+		Object:     "CVE",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -2564,8 +2992,8 @@ func (ec *executionContext) fieldContext_CVEResultForImage_Tag(ctx context.Conte
 	return fc, nil
 }
 
-func (ec *executionContext) _CVEResultForImage_CVEList(ctx context.Context, field graphql.CollectedField, obj *CVEResultForImage) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_CVEResultForImage_CVEList(ctx, field)
+func (ec *executionContext) _CVE_Severity(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVE_Severity(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2578,7 +3006,7 @@ func (ec *executionContext) _CVEResultForImage_CVEList(ctx context.Context, fiel
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.CVEList, nil
+		return obj.Severity, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2587,38 +3015,26 @@ func (ec *executionContext) _CVEResultForImage_CVEList(ctx context.Context, fiel
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*Cve)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOCVE2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐCve(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_CVEResultForImage_CVEList(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVE_Severity(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "CVEResultForImage",
+		Object:     "CVE",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Id":
-				return ec.fieldContext_CVE_Id(ctx, field)
-			case "Title":
-				return ec.fieldContext_CVE_Title(ctx, field)
-			case "Description":
-				return ec.fieldContext_CVE_Description(ctx, field)
-			case "Severity":
-				return ec.fieldContext_CVE_Severity(ctx, field)
-			case "PackageList":
-				return ec.fieldContext_CVE_PackageList(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type CVE", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _CVEResultForImage_Page(ctx context.Context, field graphql.CollectedField, obj *CVEResultForImage) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_CVEResultForImage_Page(ctx, field)
+func (ec *executionContext) _CVE_PackageList(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVE_PackageList(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2631,7 +3047,7 @@ func (ec *executionContext) _CVEResultForImage_Page(ctx context.Context, field g
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Page, nil
+		return obj.PackageList, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2640,32 +3056,34 @@ func (ec *executionContext) _CVEResultForImage_Page(ctx context.Context, field g
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*PageInfo)
+	res := resTmp.([]*PackageInfo)
 	fc.Result = res
-	return ec.marshalOPageInfo2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInfo(ctx, field.Selections, res)
+	return ec.marshalOPackageInfo2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPackageInfo(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_CVEResultForImage_Page(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVE_PackageList(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "CVEResultForImage",
+		Object:     "CVE",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "TotalCount":
-				return ec.fieldContext_PageInfo_TotalCount(ctx, field)
-			case "ItemCount":
-				return ec.fieldContext_PageInfo_ItemCount(ctx, field)
+			case "Name":
+				return ec.fieldContext_PackageInfo_Name(ctx, field)
+			case "InstalledVersion":
+				return ec.fieldContext_PackageInfo_InstalledVersion(ctx, field)
+			case "FixedVersion":
+				return ec.fieldContext_PackageInfo_FixedVersion(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type PageInfo", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type PackageInfo", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _GlobalSearchResult_Page(ctx context.Context, field graphql.CollectedField, obj *GlobalSearchResult) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_GlobalSearchResult_Page(ctx, field)
+func (ec *executionContext) _CVE_IsAcknowledged(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVE_IsAcknowledged(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2678,7 +3096,7 @@ func (ec *executionContext) _GlobalSearchResult_Page(ctx context.Context, field
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Page, nil
+		return obj.IsAcknowledged, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2687,32 +3105,26 @@ func (ec *executionContext) _GlobalSearchResult_Page(ctx context.Context, field
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*PageInfo)
+	res := resTmp.(*bool)
 	fc.Result = res
-	return ec.marshalOPageInfo2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInfo(ctx, field.Selections, res)
+	return ec.marshalOBoolean2ᚖbool(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_GlobalSearchResult_Page(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVE_IsAcknowledged(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "GlobalSearchResult",
+		Object:     "CVE",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "TotalCount":
-				return ec.fieldContext_PageInfo_TotalCount(ctx, field)
-			case "ItemCount":
-				return ec.fieldContext_PageInfo_ItemCount(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type PageInfo", field.Name)
+			return nil, errors.New("field of type Boolean does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _GlobalSearchResult_Images(ctx context.Context, field graphql.CollectedField, obj *GlobalSearchResult) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_GlobalSearchResult_Images(ctx, field)
+func (ec *executionContext) _CVE_AcknowledgementReason(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVE_AcknowledgementReason(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2725,7 +3137,7 @@ func (ec *executionContext) _GlobalSearchResult_Images(ctx context.Context, fiel
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Images, nil
+		return obj.AcknowledgementReason, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2734,68 +3146,26 @@ func (ec *executionContext) _GlobalSearchResult_Images(ctx context.Context, fiel
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*ImageSummary)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOImageSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐImageSummary(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_GlobalSearchResult_Images(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVE_AcknowledgementReason(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "GlobalSearchResult",
+		Object:     "CVE",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "RepoName":
-				return ec.fieldContext_ImageSummary_RepoName(ctx, field)
-			case "Tag":
-				return ec.fieldContext_ImageSummary_Tag(ctx, field)
-			case "Digest":
-				return ec.fieldContext_ImageSummary_Digest(ctx, field)
-			case "MediaType":
-				return ec.fieldContext_ImageSummary_MediaType(ctx, field)
-			case "Manifests":
-				return ec.fieldContext_ImageSummary_Manifests(ctx, field)
-			case "Size":
-				return ec.fieldContext_ImageSummary_Size(ctx, field)
-			case "DownloadCount":
-				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
-			case "LastUpdated":
-				return ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
-			case "Description":
-				return ec.fieldContext_ImageSummary_Description(ctx, field)
-			case "IsSigned":
-				return ec.fieldContext_ImageSummary_IsSigned(ctx, field)
-			case "SignatureInfo":
-				return ec.fieldContext_ImageSummary_SignatureInfo(ctx, field)
-			case "Licenses":
-				return ec.fieldContext_ImageSummary_Licenses(ctx, field)
-			case "Labels":
-				return ec.fieldContext_ImageSummary_Labels(ctx, field)
-			case "Title":
-				return ec.fieldContext_ImageSummary_Title(ctx, field)
-			case "Source":
-				return ec.fieldContext_ImageSummary_Source(ctx, field)
-			case "Documentation":
-				return ec.fieldContext_ImageSummary_Documentation(ctx, field)
-			case "Vendor":
-				return ec.fieldContext_ImageSummary_Vendor(ctx, field)
-			case "Authors":
-				return ec.fieldContext_ImageSummary_Authors(ctx, field)
-			case "Vulnerabilities":
-				return ec.fieldContext_ImageSummary_Vulnerabilities(ctx, field)
-			case "Referrers":
-				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _GlobalSearchResult_Repos(ctx context.Context, field graphql.CollectedField, obj *GlobalSearchResult) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_GlobalSearchResult_Repos(ctx, field)
+func (ec *executionContext) _CVE_AcknowledgedBy(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVE_AcknowledgedBy(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2808,7 +3178,7 @@ func (ec *executionContext) _GlobalSearchResult_Repos(ctx context.Context, field
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Repos, nil
+		return obj.AcknowledgedBy, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2817,48 +3187,26 @@ func (ec *executionContext) _GlobalSearchResult_Repos(ctx context.Context, field
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*RepoSummary)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalORepoSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoSummary(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_GlobalSearchResult_Repos(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVE_AcknowledgedBy(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "GlobalSearchResult",
+		Object:     "CVE",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Name":
-				return ec.fieldContext_RepoSummary_Name(ctx, field)
-			case "LastUpdated":
-				return ec.fieldContext_RepoSummary_LastUpdated(ctx, field)
-			case "Size":
-				return ec.fieldContext_RepoSummary_Size(ctx, field)
-			case "Platforms":
-				return ec.fieldContext_RepoSummary_Platforms(ctx, field)
-			case "Vendors":
-				return ec.fieldContext_RepoSummary_Vendors(ctx, field)
-			case "NewestImage":
-				return ec.fieldContext_RepoSummary_NewestImage(ctx, field)
-			case "DownloadCount":
-				return ec.fieldContext_RepoSummary_DownloadCount(ctx, field)
-			case "StarCount":
-				return ec.fieldContext_RepoSummary_StarCount(ctx, field)
-			case "IsBookmarked":
-				return ec.fieldContext_RepoSummary_IsBookmarked(ctx, field)
-			case "IsStarred":
-				return ec.fieldContext_RepoSummary_IsStarred(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type RepoSummary", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _GlobalSearchResult_Layers(ctx context.Context, field graphql.CollectedField, obj *GlobalSearchResult) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_GlobalSearchResult_Layers(ctx, field)
+func (ec *executionContext) _CVE_AcknowledgementExpiry(ctx context.Context, field graphql.CollectedField, obj *Cve) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVE_AcknowledgementExpiry(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2871,7 +3219,7 @@ func (ec *executionContext) _GlobalSearchResult_Layers(ctx context.Context, fiel
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Layers, nil
+		return obj.AcknowledgementExpiry, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2880,32 +3228,26 @@ func (ec *executionContext) _GlobalSearchResult_Layers(ctx context.Context, fiel
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*LayerSummary)
+	res := resTmp.(*time.Time)
 	fc.Result = res
-	return ec.marshalOLayerSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerSummary(ctx, field.Selections, res)
+	return ec.marshalOTime2ᚖtimeᚐTime(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_GlobalSearchResult_Layers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVE_AcknowledgementExpiry(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "GlobalSearchResult",
+		Object:     "CVE",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Size":
-				return ec.fieldContext_LayerSummary_Size(ctx, field)
-			case "Digest":
-				return ec.fieldContext_LayerSummary_Digest(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type LayerSummary", field.Name)
+			return nil, errors.New("field of type Time does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _HistoryDescription_Created(ctx context.Context, field graphql.CollectedField, obj *HistoryDescription) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_HistoryDescription_Created(ctx, field)
+func (ec *executionContext) _CVEResultForImage_Tag(ctx context.Context, field graphql.CollectedField, obj *CVEResultForImage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVEResultForImage_Tag(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2918,7 +3260,7 @@ func (ec *executionContext) _HistoryDescription_Created(ctx context.Context, fie
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Created, nil
+		return obj.Tag, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2927,26 +3269,26 @@ func (ec *executionContext) _HistoryDescription_Created(ctx context.Context, fie
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*time.Time)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOTime2ᚖtimeᚐTime(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_HistoryDescription_Created(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVEResultForImage_Tag(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "HistoryDescription",
+		Object:     "CVEResultForImage",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Time does not have child fields")
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _HistoryDescription_CreatedBy(ctx context.Context, field graphql.CollectedField, obj *HistoryDescription) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_HistoryDescription_CreatedBy(ctx, field)
+func (ec *executionContext) _CVEResultForImage_CVEList(ctx context.Context, field graphql.CollectedField, obj *CVEResultForImage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVEResultForImage_CVEList(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -2959,7 +3301,7 @@ func (ec *executionContext) _HistoryDescription_CreatedBy(ctx context.Context, f
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.CreatedBy, nil
+		return obj.CVEList, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -2968,26 +3310,46 @@ func (ec *executionContext) _HistoryDescription_CreatedBy(ctx context.Context, f
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.([]*Cve)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOCVE2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐCve(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_HistoryDescription_CreatedBy(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVEResultForImage_CVEList(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "HistoryDescription",
+		Object:     "CVEResultForImage",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "Id":
+				return ec.fieldContext_CVE_Id(ctx, field)
+			case "Title":
+				return ec.fieldContext_CVE_Title(ctx, field)
+			case "Description":
+				return ec.fieldContext_CVE_Description(ctx, field)
+			case "Severity":
+				return ec.fieldContext_CVE_Severity(ctx, field)
+			case "PackageList":
+				return ec.fieldContext_CVE_PackageList(ctx, field)
+			case "IsAcknowledged":
+				return ec.fieldContext_CVE_IsAcknowledged(ctx, field)
+			case "AcknowledgementReason":
+				return ec.fieldContext_CVE_AcknowledgementReason(ctx, field)
+			case "AcknowledgedBy":
+				return ec.fieldContext_CVE_AcknowledgedBy(ctx, field)
+			case "AcknowledgementExpiry":
+				return ec.fieldContext_CVE_AcknowledgementExpiry(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type CVE", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _HistoryDescription_Author(ctx context.Context, field graphql.CollectedField, obj *HistoryDescription) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_HistoryDescription_Author(ctx, field)
+func (ec *executionContext) _CVEResultForImage_Page(ctx context.Context, field graphql.CollectedField, obj *CVEResultForImage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_CVEResultForImage_Page(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3000,7 +3362,7 @@ func (ec *executionContext) _HistoryDescription_Author(ctx context.Context, fiel
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Author, nil
+		return obj.Page, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3009,26 +3371,32 @@ func (ec *executionContext) _HistoryDescription_Author(ctx context.Context, fiel
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.(*PageInfo)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOPageInfo2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInfo(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_HistoryDescription_Author(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_CVEResultForImage_Page(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "HistoryDescription",
+		Object:     "CVEResultForImage",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "TotalCount":
+				return ec.fieldContext_PageInfo_TotalCount(ctx, field)
+			case "ItemCount":
+				return ec.fieldContext_PageInfo_ItemCount(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PageInfo", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _HistoryDescription_Comment(ctx context.Context, field graphql.CollectedField, obj *HistoryDescription) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_HistoryDescription_Comment(ctx, field)
+func (ec *executionContext) _GlobalSearchResult_Page(ctx context.Context, field graphql.CollectedField, obj *GlobalSearchResult) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_GlobalSearchResult_Page(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3041,7 +3409,7 @@ func (ec *executionContext) _HistoryDescription_Comment(ctx context.Context, fie
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Comment, nil
+		return obj.Page, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3050,26 +3418,32 @@ func (ec *executionContext) _HistoryDescription_Comment(ctx context.Context, fie
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.(*PageInfo)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOPageInfo2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInfo(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_HistoryDescription_Comment(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_GlobalSearchResult_Page(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "HistoryDescription",
+		Object:     "GlobalSearchResult",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "TotalCount":
+				return ec.fieldContext_PageInfo_TotalCount(ctx, field)
+			case "ItemCount":
+				return ec.fieldContext_PageInfo_ItemCount(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PageInfo", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _HistoryDescription_EmptyLayer(ctx context.Context, field graphql.CollectedField, obj *HistoryDescription) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_HistoryDescription_EmptyLayer(ctx, field)
+func (ec *executionContext) _GlobalSearchResult_Images(ctx context.Context, field graphql.CollectedField, obj *GlobalSearchResult) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_GlobalSearchResult_Images(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3082,7 +3456,7 @@ func (ec *executionContext) _HistoryDescription_EmptyLayer(ctx context.Context,
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.EmptyLayer, nil
+		return obj.Images, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3091,26 +3465,84 @@ func (ec *executionContext) _HistoryDescription_EmptyLayer(ctx context.Context,
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*bool)
+	res := resTmp.([]*ImageSummary)
 	fc.Result = res
-	return ec.marshalOBoolean2ᚖbool(ctx, field.Selections, res)
+	return ec.marshalOImageSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐImageSummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_HistoryDescription_EmptyLayer(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_GlobalSearchResult_Images(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "HistoryDescription",
+		Object:     "GlobalSearchResult",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Boolean does not have child fields")
+			switch field.Name {
+			case "RepoName":
+				return ec.fieldContext_ImageSummary_RepoName(ctx, field)
+			case "Tag":
+				return ec.fieldContext_ImageSummary_Tag(ctx, field)
+			case "Digest":
+				return ec.fieldContext_ImageSummary_Digest(ctx, field)
+			case "MediaType":
+				return ec.fieldContext_ImageSummary_MediaType(ctx, field)
+			case "Manifests":
+				return ec.fieldContext_ImageSummary_Manifests(ctx, field)
+			case "Size":
+				return ec.fieldContext_ImageSummary_Size(ctx, field)
+			case "LogicalSize":
+				return ec.fieldContext_ImageSummary_LogicalSize(ctx, field)
+			case "UniqueSize":
+				return ec.fieldContext_ImageSummary_UniqueSize(ctx, field)
+			case "DownloadCount":
+				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
+			case "LastUpdated":
+				return ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
+			case "Description":
+				return ec.fieldContext_ImageSummary_Description(ctx, field)
+			case "IsSigned":
+				return ec.fieldContext_ImageSummary_IsSigned(ctx, field)
+			case "SignatureInfo":
+				return ec.fieldContext_ImageSummary_SignatureInfo(ctx, field)
+			case "IsPinned":
+				return ec.fieldContext_ImageSummary_IsPinned(ctx, field)
+			case "RegistryAnnotations":
+				return ec.fieldContext_ImageSummary_RegistryAnnotations(ctx, field)
+			case "DigestAliases":
+				return ec.fieldContext_ImageSummary_DigestAliases(ctx, field)
+			case "IsEncrypted":
+				return ec.fieldContext_ImageSummary_IsEncrypted(ctx, field)
+			case "Licenses":
+				return ec.fieldContext_ImageSummary_Licenses(ctx, field)
+			case "Labels":
+				return ec.fieldContext_ImageSummary_Labels(ctx, field)
+			case "Title":
+				return ec.fieldContext_ImageSummary_Title(ctx, field)
+			case "Source":
+				return ec.fieldContext_ImageSummary_Source(ctx, field)
+			case "Documentation":
+				return ec.fieldContext_ImageSummary_Documentation(ctx, field)
+			case "Vendor":
+				return ec.fieldContext_ImageSummary_Vendor(ctx, field)
+			case "Authors":
+				return ec.fieldContext_ImageSummary_Authors(ctx, field)
+			case "Vulnerabilities":
+				return ec.fieldContext_ImageSummary_Vulnerabilities(ctx, field)
+			case "Referrers":
+				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
+			case "SBOM":
+				return ec.fieldContext_ImageSummary_SBOM(ctx, field)
+			case "Attestations":
+				return ec.fieldContext_ImageSummary_Attestations(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_RepoName(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_RepoName(ctx, field)
+func (ec *executionContext) _GlobalSearchResult_Repos(ctx context.Context, field graphql.CollectedField, obj *GlobalSearchResult) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_GlobalSearchResult_Repos(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3123,7 +3555,7 @@ func (ec *executionContext) _ImageSummary_RepoName(ctx context.Context, field gr
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.RepoName, nil
+		return obj.Repos, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3132,26 +3564,48 @@ func (ec *executionContext) _ImageSummary_RepoName(ctx context.Context, field gr
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.([]*RepoSummary)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalORepoSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoSummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_RepoName(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_GlobalSearchResult_Repos(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ImageSummary",
+		Object:     "GlobalSearchResult",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "Name":
+				return ec.fieldContext_RepoSummary_Name(ctx, field)
+			case "LastUpdated":
+				return ec.fieldContext_RepoSummary_LastUpdated(ctx, field)
+			case "Size":
+				return ec.fieldContext_RepoSummary_Size(ctx, field)
+			case "Platforms":
+				return ec.fieldContext_RepoSummary_Platforms(ctx, field)
+			case "Vendors":
+				return ec.fieldContext_RepoSummary_Vendors(ctx, field)
+			case "NewestImage":
+				return ec.fieldContext_RepoSummary_NewestImage(ctx, field)
+			case "DownloadCount":
+				return ec.fieldContext_RepoSummary_DownloadCount(ctx, field)
+			case "StarCount":
+				return ec.fieldContext_RepoSummary_StarCount(ctx, field)
+			case "IsBookmarked":
+				return ec.fieldContext_RepoSummary_IsBookmarked(ctx, field)
+			case "IsStarred":
+				return ec.fieldContext_RepoSummary_IsStarred(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type RepoSummary", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Tag(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Tag(ctx, field)
+func (ec *executionContext) _GlobalSearchResult_Layers(ctx context.Context, field graphql.CollectedField, obj *GlobalSearchResult) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_GlobalSearchResult_Layers(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3164,7 +3618,7 @@ func (ec *executionContext) _ImageSummary_Tag(ctx context.Context, field graphql
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Tag, nil
+		return obj.Layers, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3173,26 +3627,32 @@ func (ec *executionContext) _ImageSummary_Tag(ctx context.Context, field graphql
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.([]*LayerSummary)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOLayerSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerSummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Tag(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_GlobalSearchResult_Layers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ImageSummary",
+		Object:     "GlobalSearchResult",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "Size":
+				return ec.fieldContext_LayerSummary_Size(ctx, field)
+			case "Digest":
+				return ec.fieldContext_LayerSummary_Digest(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type LayerSummary", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Digest(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Digest(ctx, field)
+func (ec *executionContext) _HistoryDescription_Created(ctx context.Context, field graphql.CollectedField, obj *HistoryDescription) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_HistoryDescription_Created(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3205,7 +3665,7 @@ func (ec *executionContext) _ImageSummary_Digest(ctx context.Context, field grap
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Digest, nil
+		return obj.Created, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3214,26 +3674,26 @@ func (ec *executionContext) _ImageSummary_Digest(ctx context.Context, field grap
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.(*time.Time)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOTime2ᚖtimeᚐTime(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_HistoryDescription_Created(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ImageSummary",
+		Object:     "HistoryDescription",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			return nil, errors.New("field of type Time does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_MediaType(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_MediaType(ctx, field)
+func (ec *executionContext) _HistoryDescription_CreatedBy(ctx context.Context, field graphql.CollectedField, obj *HistoryDescription) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_HistoryDescription_CreatedBy(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3246,7 +3706,7 @@ func (ec *executionContext) _ImageSummary_MediaType(ctx context.Context, field g
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.MediaType, nil
+		return obj.CreatedBy, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3260,9 +3720,9 @@ func (ec *executionContext) _ImageSummary_MediaType(ctx context.Context, field g
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_MediaType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_HistoryDescription_CreatedBy(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ImageSummary",
+		Object:     "HistoryDescription",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -3273,9 +3733,9 @@ func (ec *executionContext) fieldContext_ImageSummary_MediaType(ctx context.Cont
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Manifests(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Manifests(ctx, field)
-	if err != nil {
+func (ec *executionContext) _HistoryDescription_Author(ctx context.Context, field graphql.CollectedField, obj *HistoryDescription) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_HistoryDescription_Author(ctx, field)
+	if err != nil {
 		return graphql.Null
 	}
 	ctx = graphql.WithFieldContext(ctx, fc)
@@ -3287,7 +3747,7 @@ func (ec *executionContext) _ImageSummary_Manifests(ctx context.Context, field g
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Manifests, nil
+		return obj.Author, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3296,54 +3756,26 @@ func (ec *executionContext) _ImageSummary_Manifests(ctx context.Context, field g
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*ManifestSummary)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOManifestSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐManifestSummary(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Manifests(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_HistoryDescription_Author(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ImageSummary",
+		Object:     "HistoryDescription",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Digest":
-				return ec.fieldContext_ManifestSummary_Digest(ctx, field)
-			case "ConfigDigest":
-				return ec.fieldContext_ManifestSummary_ConfigDigest(ctx, field)
-			case "LastUpdated":
-				return ec.fieldContext_ManifestSummary_LastUpdated(ctx, field)
-			case "Size":
-				return ec.fieldContext_ManifestSummary_Size(ctx, field)
-			case "IsSigned":
-				return ec.fieldContext_ManifestSummary_IsSigned(ctx, field)
-			case "SignatureInfo":
-				return ec.fieldContext_ManifestSummary_SignatureInfo(ctx, field)
-			case "Platform":
-				return ec.fieldContext_ManifestSummary_Platform(ctx, field)
-			case "DownloadCount":
-				return ec.fieldContext_ManifestSummary_DownloadCount(ctx, field)
-			case "Layers":
-				return ec.fieldContext_ManifestSummary_Layers(ctx, field)
-			case "History":
-				return ec.fieldContext_ManifestSummary_History(ctx, field)
-			case "Vulnerabilities":
-				return ec.fieldContext_ManifestSummary_Vulnerabilities(ctx, field)
-			case "Referrers":
-				return ec.fieldContext_ManifestSummary_Referrers(ctx, field)
-			case "ArtifactType":
-				return ec.fieldContext_ManifestSummary_ArtifactType(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type ManifestSummary", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Size(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Size(ctx, field)
+func (ec *executionContext) _HistoryDescription_Comment(ctx context.Context, field graphql.CollectedField, obj *HistoryDescription) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_HistoryDescription_Comment(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3356,7 +3788,7 @@ func (ec *executionContext) _ImageSummary_Size(ctx context.Context, field graphq
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Size, nil
+		return obj.Comment, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3370,9 +3802,9 @@ func (ec *executionContext) _ImageSummary_Size(ctx context.Context, field graphq
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Size(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_HistoryDescription_Comment(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ImageSummary",
+		Object:     "HistoryDescription",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -3383,8 +3815,8 @@ func (ec *executionContext) fieldContext_ImageSummary_Size(ctx context.Context,
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_DownloadCount(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
+func (ec *executionContext) _HistoryDescription_EmptyLayer(ctx context.Context, field graphql.CollectedField, obj *HistoryDescription) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_HistoryDescription_EmptyLayer(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3397,7 +3829,7 @@ func (ec *executionContext) _ImageSummary_DownloadCount(ctx context.Context, fie
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.DownloadCount, nil
+		return obj.EmptyLayer, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3406,26 +3838,26 @@ func (ec *executionContext) _ImageSummary_DownloadCount(ctx context.Context, fie
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*int)
+	res := resTmp.(*bool)
 	fc.Result = res
-	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
+	return ec.marshalOBoolean2ᚖbool(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_DownloadCount(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_HistoryDescription_EmptyLayer(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ImageSummary",
+		Object:     "HistoryDescription",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Int does not have child fields")
+			return nil, errors.New("field of type Boolean does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_LastUpdated(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
+func (ec *executionContext) _ImageSummary_RepoName(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_RepoName(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3438,7 +3870,7 @@ func (ec *executionContext) _ImageSummary_LastUpdated(ctx context.Context, field
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.LastUpdated, nil
+		return obj.RepoName, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3447,26 +3879,26 @@ func (ec *executionContext) _ImageSummary_LastUpdated(ctx context.Context, field
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*time.Time)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOTime2ᚖtimeᚐTime(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_LastUpdated(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_RepoName(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Time does not have child fields")
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Description(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Description(ctx, field)
+func (ec *executionContext) _ImageSummary_Tag(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Tag(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3479,7 +3911,7 @@ func (ec *executionContext) _ImageSummary_Description(ctx context.Context, field
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Description, nil
+		return obj.Tag, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3493,7 +3925,7 @@ func (ec *executionContext) _ImageSummary_Description(ctx context.Context, field
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Description(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Tag(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
@@ -3506,8 +3938,8 @@ func (ec *executionContext) fieldContext_ImageSummary_Description(ctx context.Co
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_IsSigned(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_IsSigned(ctx, field)
+func (ec *executionContext) _ImageSummary_Digest(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Digest(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3520,7 +3952,7 @@ func (ec *executionContext) _ImageSummary_IsSigned(ctx context.Context, field gr
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.IsSigned, nil
+		return obj.Digest, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3529,26 +3961,26 @@ func (ec *executionContext) _ImageSummary_IsSigned(ctx context.Context, field gr
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*bool)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOBoolean2ᚖbool(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_IsSigned(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Boolean does not have child fields")
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_SignatureInfo(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_SignatureInfo(ctx, field)
+func (ec *executionContext) _ImageSummary_MediaType(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_MediaType(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3561,7 +3993,7 @@ func (ec *executionContext) _ImageSummary_SignatureInfo(ctx context.Context, fie
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.SignatureInfo, nil
+		return obj.MediaType, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3570,34 +4002,26 @@ func (ec *executionContext) _ImageSummary_SignatureInfo(ctx context.Context, fie
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*SignatureSummary)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOSignatureSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐSignatureSummary(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_SignatureInfo(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_MediaType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Tool":
-				return ec.fieldContext_SignatureSummary_Tool(ctx, field)
-			case "IsTrusted":
-				return ec.fieldContext_SignatureSummary_IsTrusted(ctx, field)
-			case "Author":
-				return ec.fieldContext_SignatureSummary_Author(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type SignatureSummary", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Licenses(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Licenses(ctx, field)
+func (ec *executionContext) _ImageSummary_Manifests(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Manifests(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3610,7 +4034,7 @@ func (ec *executionContext) _ImageSummary_Licenses(ctx context.Context, field gr
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Licenses, nil
+		return obj.Manifests, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3619,26 +4043,56 @@ func (ec *executionContext) _ImageSummary_Licenses(ctx context.Context, field gr
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.([]*ManifestSummary)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOManifestSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐManifestSummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Licenses(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Manifests(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "Digest":
+				return ec.fieldContext_ManifestSummary_Digest(ctx, field)
+			case "ConfigDigest":
+				return ec.fieldContext_ManifestSummary_ConfigDigest(ctx, field)
+			case "LastUpdated":
+				return ec.fieldContext_ManifestSummary_LastUpdated(ctx, field)
+			case "Size":
+				return ec.fieldContext_ManifestSummary_Size(ctx, field)
+			case "IsSigned":
+				return ec.fieldContext_ManifestSummary_IsSigned(ctx, field)
+			case "SignatureInfo":
+				return ec.fieldContext_ManifestSummary_SignatureInfo(ctx, field)
+			case "IsEncrypted":
+				return ec.fieldContext_ManifestSummary_IsEncrypted(ctx, field)
+			case "Platform":
+				return ec.fieldContext_ManifestSummary_Platform(ctx, field)
+			case "DownloadCount":
+				return ec.fieldContext_ManifestSummary_DownloadCount(ctx, field)
+			case "Layers":
+				return ec.fieldContext_ManifestSummary_Layers(ctx, field)
+			case "History":
+				return ec.fieldContext_ManifestSummary_History(ctx, field)
+			case "Vulnerabilities":
+				return ec.fieldContext_ManifestSummary_Vulnerabilities(ctx, field)
+			case "Referrers":
+				return ec.fieldContext_ManifestSummary_Referrers(ctx, field)
+			case "ArtifactType":
+				return ec.fieldContext_ManifestSummary_ArtifactType(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type ManifestSummary", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Labels(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Labels(ctx, field)
+func (ec *executionContext) _ImageSummary_Size(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Size(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3651,7 +4105,7 @@ func (ec *executionContext) _ImageSummary_Labels(ctx context.Context, field grap
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Labels, nil
+		return obj.Size, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3665,7 +4119,7 @@ func (ec *executionContext) _ImageSummary_Labels(ctx context.Context, field grap
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Labels(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Size(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
@@ -3678,8 +4132,8 @@ func (ec *executionContext) fieldContext_ImageSummary_Labels(ctx context.Context
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Title(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Title(ctx, field)
+func (ec *executionContext) _ImageSummary_LogicalSize(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_LogicalSize(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3692,7 +4146,7 @@ func (ec *executionContext) _ImageSummary_Title(ctx context.Context, field graph
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Title, nil
+		return obj.LogicalSize, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3706,7 +4160,7 @@ func (ec *executionContext) _ImageSummary_Title(ctx context.Context, field graph
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Title(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_LogicalSize(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
@@ -3719,8 +4173,8 @@ func (ec *executionContext) fieldContext_ImageSummary_Title(ctx context.Context,
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Source(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Source(ctx, field)
+func (ec *executionContext) _ImageSummary_UniqueSize(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_UniqueSize(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3733,7 +4187,7 @@ func (ec *executionContext) _ImageSummary_Source(ctx context.Context, field grap
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Source, nil
+		return obj.UniqueSize, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3747,7 +4201,7 @@ func (ec *executionContext) _ImageSummary_Source(ctx context.Context, field grap
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Source(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_UniqueSize(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
@@ -3760,8 +4214,8 @@ func (ec *executionContext) fieldContext_ImageSummary_Source(ctx context.Context
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Documentation(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Documentation(ctx, field)
+func (ec *executionContext) _ImageSummary_DownloadCount(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3774,7 +4228,7 @@ func (ec *executionContext) _ImageSummary_Documentation(ctx context.Context, fie
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Documentation, nil
+		return obj.DownloadCount, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3783,26 +4237,26 @@ func (ec *executionContext) _ImageSummary_Documentation(ctx context.Context, fie
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.(*int)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Documentation(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_DownloadCount(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			return nil, errors.New("field of type Int does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Vendor(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Vendor(ctx, field)
+func (ec *executionContext) _ImageSummary_LastUpdated(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3815,7 +4269,7 @@ func (ec *executionContext) _ImageSummary_Vendor(ctx context.Context, field grap
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Vendor, nil
+		return obj.LastUpdated, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3824,26 +4278,26 @@ func (ec *executionContext) _ImageSummary_Vendor(ctx context.Context, field grap
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.(*time.Time)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOTime2ᚖtimeᚐTime(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Vendor(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_LastUpdated(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			return nil, errors.New("field of type Time does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Authors(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Authors(ctx, field)
+func (ec *executionContext) _ImageSummary_Description(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Description(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3856,7 +4310,7 @@ func (ec *executionContext) _ImageSummary_Authors(ctx context.Context, field gra
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Authors, nil
+		return obj.Description, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3870,7 +4324,7 @@ func (ec *executionContext) _ImageSummary_Authors(ctx context.Context, field gra
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Authors(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Description(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
@@ -3883,8 +4337,8 @@ func (ec *executionContext) fieldContext_ImageSummary_Authors(ctx context.Contex
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Vulnerabilities(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Vulnerabilities(ctx, field)
+func (ec *executionContext) _ImageSummary_IsSigned(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_IsSigned(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3897,7 +4351,7 @@ func (ec *executionContext) _ImageSummary_Vulnerabilities(ctx context.Context, f
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Vulnerabilities, nil
+		return obj.IsSigned, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3906,32 +4360,26 @@ func (ec *executionContext) _ImageSummary_Vulnerabilities(ctx context.Context, f
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*ImageVulnerabilitySummary)
+	res := resTmp.(*bool)
 	fc.Result = res
-	return ec.marshalOImageVulnerabilitySummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐImageVulnerabilitySummary(ctx, field.Selections, res)
+	return ec.marshalOBoolean2ᚖbool(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Vulnerabilities(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_IsSigned(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "MaxSeverity":
-				return ec.fieldContext_ImageVulnerabilitySummary_MaxSeverity(ctx, field)
-			case "Count":
-				return ec.fieldContext_ImageVulnerabilitySummary_Count(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type ImageVulnerabilitySummary", field.Name)
+			return nil, errors.New("field of type Boolean does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageSummary_Referrers(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageSummary_Referrers(ctx, field)
+func (ec *executionContext) _ImageSummary_SignatureInfo(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_SignatureInfo(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3944,7 +4392,7 @@ func (ec *executionContext) _ImageSummary_Referrers(ctx context.Context, field g
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Referrers, nil
+		return obj.SignatureInfo, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3953,12 +4401,12 @@ func (ec *executionContext) _ImageSummary_Referrers(ctx context.Context, field g
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*Referrer)
+	res := resTmp.([]*SignatureSummary)
 	fc.Result = res
-	return ec.marshalOReferrer2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrer(ctx, field.Selections, res)
+	return ec.marshalOSignatureSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐSignatureSummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageSummary_Referrers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_SignatureInfo(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "ImageSummary",
 		Field:      field,
@@ -3966,25 +4414,21 @@ func (ec *executionContext) fieldContext_ImageSummary_Referrers(ctx context.Cont
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "MediaType":
-				return ec.fieldContext_Referrer_MediaType(ctx, field)
-			case "ArtifactType":
-				return ec.fieldContext_Referrer_ArtifactType(ctx, field)
-			case "Size":
-				return ec.fieldContext_Referrer_Size(ctx, field)
-			case "Digest":
-				return ec.fieldContext_Referrer_Digest(ctx, field)
-			case "Annotations":
-				return ec.fieldContext_Referrer_Annotations(ctx, field)
+			case "Tool":
+				return ec.fieldContext_SignatureSummary_Tool(ctx, field)
+			case "IsTrusted":
+				return ec.fieldContext_SignatureSummary_IsTrusted(ctx, field)
+			case "Author":
+				return ec.fieldContext_SignatureSummary_Author(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type Referrer", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type SignatureSummary", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageVulnerabilitySummary_MaxSeverity(ctx context.Context, field graphql.CollectedField, obj *ImageVulnerabilitySummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageVulnerabilitySummary_MaxSeverity(ctx, field)
+func (ec *executionContext) _ImageSummary_IsPinned(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_IsPinned(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -3997,7 +4441,7 @@ func (ec *executionContext) _ImageVulnerabilitySummary_MaxSeverity(ctx context.C
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.MaxSeverity, nil
+		return obj.IsPinned, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4006,26 +4450,26 @@ func (ec *executionContext) _ImageVulnerabilitySummary_MaxSeverity(ctx context.C
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.(*bool)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOBoolean2ᚖbool(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageVulnerabilitySummary_MaxSeverity(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_IsPinned(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ImageVulnerabilitySummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			return nil, errors.New("field of type Boolean does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ImageVulnerabilitySummary_Count(ctx context.Context, field graphql.CollectedField, obj *ImageVulnerabilitySummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ImageVulnerabilitySummary_Count(ctx, field)
+func (ec *executionContext) _ImageSummary_RegistryAnnotations(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_RegistryAnnotations(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4038,7 +4482,7 @@ func (ec *executionContext) _ImageVulnerabilitySummary_Count(ctx context.Context
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Count, nil
+		return obj.RegistryAnnotations, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4047,26 +4491,32 @@ func (ec *executionContext) _ImageVulnerabilitySummary_Count(ctx context.Context
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*int)
+	res := resTmp.([]*Annotation)
 	fc.Result = res
-	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
+	return ec.marshalOAnnotation2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ImageVulnerabilitySummary_Count(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_RegistryAnnotations(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ImageVulnerabilitySummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Int does not have child fields")
+			switch field.Name {
+			case "Key":
+				return ec.fieldContext_Annotation_Key(ctx, field)
+			case "Value":
+				return ec.fieldContext_Annotation_Value(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type Annotation", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _LayerHistory_Layer(ctx context.Context, field graphql.CollectedField, obj *LayerHistory) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_LayerHistory_Layer(ctx, field)
+func (ec *executionContext) _ImageSummary_DigestAliases(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_DigestAliases(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4079,7 +4529,7 @@ func (ec *executionContext) _LayerHistory_Layer(ctx context.Context, field graph
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Layer, nil
+		return obj.DigestAliases, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4088,32 +4538,26 @@ func (ec *executionContext) _LayerHistory_Layer(ctx context.Context, field graph
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*LayerSummary)
+	res := resTmp.([]*string)
 	fc.Result = res
-	return ec.marshalOLayerSummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerSummary(ctx, field.Selections, res)
+	return ec.marshalOString2ᚕᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_LayerHistory_Layer(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_DigestAliases(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "LayerHistory",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Size":
-				return ec.fieldContext_LayerSummary_Size(ctx, field)
-			case "Digest":
-				return ec.fieldContext_LayerSummary_Digest(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type LayerSummary", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _LayerHistory_HistoryDescription(ctx context.Context, field graphql.CollectedField, obj *LayerHistory) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_LayerHistory_HistoryDescription(ctx, field)
+func (ec *executionContext) _ImageSummary_IsEncrypted(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_IsEncrypted(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4126,7 +4570,7 @@ func (ec *executionContext) _LayerHistory_HistoryDescription(ctx context.Context
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.HistoryDescription, nil
+		return obj.IsEncrypted, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4135,38 +4579,26 @@ func (ec *executionContext) _LayerHistory_HistoryDescription(ctx context.Context
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*HistoryDescription)
+	res := resTmp.(*bool)
 	fc.Result = res
-	return ec.marshalOHistoryDescription2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐHistoryDescription(ctx, field.Selections, res)
+	return ec.marshalOBoolean2ᚖbool(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_LayerHistory_HistoryDescription(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_IsEncrypted(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "LayerHistory",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Created":
-				return ec.fieldContext_HistoryDescription_Created(ctx, field)
-			case "CreatedBy":
-				return ec.fieldContext_HistoryDescription_CreatedBy(ctx, field)
-			case "Author":
-				return ec.fieldContext_HistoryDescription_Author(ctx, field)
-			case "Comment":
-				return ec.fieldContext_HistoryDescription_Comment(ctx, field)
-			case "EmptyLayer":
-				return ec.fieldContext_HistoryDescription_EmptyLayer(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type HistoryDescription", field.Name)
+			return nil, errors.New("field of type Boolean does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _LayerSummary_Size(ctx context.Context, field graphql.CollectedField, obj *LayerSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_LayerSummary_Size(ctx, field)
+func (ec *executionContext) _ImageSummary_Licenses(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Licenses(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4179,7 +4611,7 @@ func (ec *executionContext) _LayerSummary_Size(ctx context.Context, field graphq
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Size, nil
+		return obj.Licenses, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4193,9 +4625,9 @@ func (ec *executionContext) _LayerSummary_Size(ctx context.Context, field graphq
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_LayerSummary_Size(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Licenses(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "LayerSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -4206,8 +4638,8 @@ func (ec *executionContext) fieldContext_LayerSummary_Size(ctx context.Context,
 	return fc, nil
 }
 
-func (ec *executionContext) _LayerSummary_Digest(ctx context.Context, field graphql.CollectedField, obj *LayerSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_LayerSummary_Digest(ctx, field)
+func (ec *executionContext) _ImageSummary_Labels(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Labels(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4220,7 +4652,7 @@ func (ec *executionContext) _LayerSummary_Digest(ctx context.Context, field grap
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Digest, nil
+		return obj.Labels, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4234,9 +4666,9 @@ func (ec *executionContext) _LayerSummary_Digest(ctx context.Context, field grap
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_LayerSummary_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Labels(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "LayerSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -4247,8 +4679,8 @@ func (ec *executionContext) fieldContext_LayerSummary_Digest(ctx context.Context
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_Digest(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_Digest(ctx, field)
+func (ec *executionContext) _ImageSummary_Title(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Title(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4261,7 +4693,7 @@ func (ec *executionContext) _ManifestSummary_Digest(ctx context.Context, field g
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Digest, nil
+		return obj.Title, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4275,9 +4707,9 @@ func (ec *executionContext) _ManifestSummary_Digest(ctx context.Context, field g
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Title(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -4288,8 +4720,8 @@ func (ec *executionContext) fieldContext_ManifestSummary_Digest(ctx context.Cont
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_ConfigDigest(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_ConfigDigest(ctx, field)
+func (ec *executionContext) _ImageSummary_Source(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Source(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4302,7 +4734,7 @@ func (ec *executionContext) _ManifestSummary_ConfigDigest(ctx context.Context, f
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.ConfigDigest, nil
+		return obj.Source, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4316,9 +4748,9 @@ func (ec *executionContext) _ManifestSummary_ConfigDigest(ctx context.Context, f
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_ConfigDigest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Source(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -4329,8 +4761,8 @@ func (ec *executionContext) fieldContext_ManifestSummary_ConfigDigest(ctx contex
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_LastUpdated(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_LastUpdated(ctx, field)
+func (ec *executionContext) _ImageSummary_Documentation(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Documentation(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4343,7 +4775,7 @@ func (ec *executionContext) _ManifestSummary_LastUpdated(ctx context.Context, fi
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.LastUpdated, nil
+		return obj.Documentation, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4352,26 +4784,26 @@ func (ec *executionContext) _ManifestSummary_LastUpdated(ctx context.Context, fi
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*time.Time)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOTime2ᚖtimeᚐTime(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_LastUpdated(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Documentation(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Time does not have child fields")
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_Size(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_Size(ctx, field)
+func (ec *executionContext) _ImageSummary_Vendor(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Vendor(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4384,7 +4816,7 @@ func (ec *executionContext) _ManifestSummary_Size(ctx context.Context, field gra
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Size, nil
+		return obj.Vendor, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4398,9 +4830,9 @@ func (ec *executionContext) _ManifestSummary_Size(ctx context.Context, field gra
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_Size(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Vendor(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -4411,8 +4843,8 @@ func (ec *executionContext) fieldContext_ManifestSummary_Size(ctx context.Contex
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_IsSigned(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_IsSigned(ctx, field)
+func (ec *executionContext) _ImageSummary_Authors(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Authors(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4425,7 +4857,7 @@ func (ec *executionContext) _ManifestSummary_IsSigned(ctx context.Context, field
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.IsSigned, nil
+		return obj.Authors, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4434,26 +4866,26 @@ func (ec *executionContext) _ManifestSummary_IsSigned(ctx context.Context, field
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*bool)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOBoolean2ᚖbool(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_IsSigned(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Authors(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Boolean does not have child fields")
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_SignatureInfo(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_SignatureInfo(ctx, field)
+func (ec *executionContext) _ImageSummary_Vulnerabilities(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Vulnerabilities(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4466,7 +4898,7 @@ func (ec *executionContext) _ManifestSummary_SignatureInfo(ctx context.Context,
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.SignatureInfo, nil
+		return obj.Vulnerabilities, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4475,34 +4907,32 @@ func (ec *executionContext) _ManifestSummary_SignatureInfo(ctx context.Context,
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*SignatureSummary)
+	res := resTmp.(*ImageVulnerabilitySummary)
 	fc.Result = res
-	return ec.marshalOSignatureSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐSignatureSummary(ctx, field.Selections, res)
+	return ec.marshalOImageVulnerabilitySummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐImageVulnerabilitySummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_SignatureInfo(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Vulnerabilities(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "Tool":
-				return ec.fieldContext_SignatureSummary_Tool(ctx, field)
-			case "IsTrusted":
-				return ec.fieldContext_SignatureSummary_IsTrusted(ctx, field)
-			case "Author":
-				return ec.fieldContext_SignatureSummary_Author(ctx, field)
+			case "MaxSeverity":
+				return ec.fieldContext_ImageVulnerabilitySummary_MaxSeverity(ctx, field)
+			case "Count":
+				return ec.fieldContext_ImageVulnerabilitySummary_Count(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type SignatureSummary", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type ImageVulnerabilitySummary", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_Platform(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_Platform(ctx, field)
+func (ec *executionContext) _ImageSummary_Referrers(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Referrers(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4515,7 +4945,7 @@ func (ec *executionContext) _ManifestSummary_Platform(ctx context.Context, field
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Platform, nil
+		return obj.Referrers, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4524,32 +4954,38 @@ func (ec *executionContext) _ManifestSummary_Platform(ctx context.Context, field
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*Platform)
+	res := resTmp.([]*Referrer)
 	fc.Result = res
-	return ec.marshalOPlatform2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPlatform(ctx, field.Selections, res)
+	return ec.marshalOReferrer2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrer(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_Platform(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Referrers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "Os":
-				return ec.fieldContext_Platform_Os(ctx, field)
-			case "Arch":
-				return ec.fieldContext_Platform_Arch(ctx, field)
+			case "MediaType":
+				return ec.fieldContext_Referrer_MediaType(ctx, field)
+			case "ArtifactType":
+				return ec.fieldContext_Referrer_ArtifactType(ctx, field)
+			case "Size":
+				return ec.fieldContext_Referrer_Size(ctx, field)
+			case "Digest":
+				return ec.fieldContext_Referrer_Digest(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_Referrer_Annotations(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type Platform", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type Referrer", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_DownloadCount(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_DownloadCount(ctx, field)
+func (ec *executionContext) _ImageSummary_SBOM(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_SBOM(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4562,7 +4998,7 @@ func (ec *executionContext) _ManifestSummary_DownloadCount(ctx context.Context,
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.DownloadCount, nil
+		return obj.Sbom, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4571,26 +5007,36 @@ func (ec *executionContext) _ManifestSummary_DownloadCount(ctx context.Context,
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*int)
+	res := resTmp.(*SBOMSummary)
 	fc.Result = res
-	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
+	return ec.marshalOSBOMSummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐSBOMSummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_DownloadCount(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_SBOM(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Int does not have child fields")
+			switch field.Name {
+			case "Digest":
+				return ec.fieldContext_SBOMSummary_Digest(ctx, field)
+			case "Format":
+				return ec.fieldContext_SBOMSummary_Format(ctx, field)
+			case "Licenses":
+				return ec.fieldContext_SBOMSummary_Licenses(ctx, field)
+			case "PackageCount":
+				return ec.fieldContext_SBOMSummary_PackageCount(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type SBOMSummary", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_Layers(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_Layers(ctx, field)
+func (ec *executionContext) _ImageSummary_Attestations(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Attestations(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4603,7 +5049,7 @@ func (ec *executionContext) _ManifestSummary_Layers(ctx context.Context, field g
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Layers, nil
+		return obj.Attestations, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4612,32 +5058,34 @@ func (ec *executionContext) _ManifestSummary_Layers(ctx context.Context, field g
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*LayerSummary)
+	res := resTmp.([]*AttestationSummary)
 	fc.Result = res
-	return ec.marshalOLayerSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerSummary(ctx, field.Selections, res)
+	return ec.marshalOAttestationSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAttestationSummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_Layers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageSummary_Attestations(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "Size":
-				return ec.fieldContext_LayerSummary_Size(ctx, field)
 			case "Digest":
-				return ec.fieldContext_LayerSummary_Digest(ctx, field)
+				return ec.fieldContext_AttestationSummary_Digest(ctx, field)
+			case "PredicateType":
+				return ec.fieldContext_AttestationSummary_PredicateType(ctx, field)
+			case "Tool":
+				return ec.fieldContext_AttestationSummary_Tool(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type LayerSummary", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type AttestationSummary", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_History(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_History(ctx, field)
+func (ec *executionContext) _ImageVulnerabilitySummary_MaxSeverity(ctx context.Context, field graphql.CollectedField, obj *ImageVulnerabilitySummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageVulnerabilitySummary_MaxSeverity(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4650,7 +5098,7 @@ func (ec *executionContext) _ManifestSummary_History(ctx context.Context, field
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.History, nil
+		return obj.MaxSeverity, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4659,32 +5107,26 @@ func (ec *executionContext) _ManifestSummary_History(ctx context.Context, field
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*LayerHistory)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOLayerHistory2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerHistory(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_History(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageVulnerabilitySummary_MaxSeverity(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageVulnerabilitySummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Layer":
-				return ec.fieldContext_LayerHistory_Layer(ctx, field)
-			case "HistoryDescription":
-				return ec.fieldContext_LayerHistory_HistoryDescription(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type LayerHistory", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_Vulnerabilities(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_Vulnerabilities(ctx, field)
+func (ec *executionContext) _ImageVulnerabilitySummary_Count(ctx context.Context, field graphql.CollectedField, obj *ImageVulnerabilitySummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageVulnerabilitySummary_Count(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4697,7 +5139,7 @@ func (ec *executionContext) _ManifestSummary_Vulnerabilities(ctx context.Context
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Vulnerabilities, nil
+		return obj.Count, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4706,32 +5148,26 @@ func (ec *executionContext) _ManifestSummary_Vulnerabilities(ctx context.Context
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*ImageVulnerabilitySummary)
+	res := resTmp.(*int)
 	fc.Result = res
-	return ec.marshalOImageVulnerabilitySummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐImageVulnerabilitySummary(ctx, field.Selections, res)
+	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_Vulnerabilities(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ImageVulnerabilitySummary_Count(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "ImageVulnerabilitySummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "MaxSeverity":
-				return ec.fieldContext_ImageVulnerabilitySummary_MaxSeverity(ctx, field)
-			case "Count":
-				return ec.fieldContext_ImageVulnerabilitySummary_Count(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type ImageVulnerabilitySummary", field.Name)
+			return nil, errors.New("field of type Int does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_Referrers(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_Referrers(ctx, field)
+func (ec *executionContext) _LayerHistory_Layer(ctx context.Context, field graphql.CollectedField, obj *LayerHistory) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_LayerHistory_Layer(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4744,7 +5180,7 @@ func (ec *executionContext) _ManifestSummary_Referrers(ctx context.Context, fiel
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Referrers, nil
+		return obj.Layer, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4753,38 +5189,32 @@ func (ec *executionContext) _ManifestSummary_Referrers(ctx context.Context, fiel
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.([]*Referrer)
+	res := resTmp.(*LayerSummary)
 	fc.Result = res
-	return ec.marshalOReferrer2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrer(ctx, field.Selections, res)
+	return ec.marshalOLayerSummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerSummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_Referrers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_LayerHistory_Layer(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "LayerHistory",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "MediaType":
-				return ec.fieldContext_Referrer_MediaType(ctx, field)
-			case "ArtifactType":
-				return ec.fieldContext_Referrer_ArtifactType(ctx, field)
 			case "Size":
-				return ec.fieldContext_Referrer_Size(ctx, field)
+				return ec.fieldContext_LayerSummary_Size(ctx, field)
 			case "Digest":
-				return ec.fieldContext_Referrer_Digest(ctx, field)
-			case "Annotations":
-				return ec.fieldContext_Referrer_Annotations(ctx, field)
+				return ec.fieldContext_LayerSummary_Digest(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type Referrer", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type LayerSummary", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_ArtifactType(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_ArtifactType(ctx, field)
+func (ec *executionContext) _LayerHistory_HistoryDescription(ctx context.Context, field graphql.CollectedField, obj *LayerHistory) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_LayerHistory_HistoryDescription(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4797,7 +5227,7 @@ func (ec *executionContext) _ManifestSummary_ArtifactType(ctx context.Context, f
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.ArtifactType, nil
+		return obj.HistoryDescription, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4806,26 +5236,38 @@ func (ec *executionContext) _ManifestSummary_ArtifactType(ctx context.Context, f
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.(*HistoryDescription)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOHistoryDescription2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐHistoryDescription(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_ManifestSummary_ArtifactType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_LayerHistory_HistoryDescription(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "ManifestSummary",
+		Object:     "LayerHistory",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "Created":
+				return ec.fieldContext_HistoryDescription_Created(ctx, field)
+			case "CreatedBy":
+				return ec.fieldContext_HistoryDescription_CreatedBy(ctx, field)
+			case "Author":
+				return ec.fieldContext_HistoryDescription_Author(ctx, field)
+			case "Comment":
+				return ec.fieldContext_HistoryDescription_Comment(ctx, field)
+			case "EmptyLayer":
+				return ec.fieldContext_HistoryDescription_EmptyLayer(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type HistoryDescription", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _PackageInfo_Name(ctx context.Context, field graphql.CollectedField, obj *PackageInfo) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_PackageInfo_Name(ctx, field)
+func (ec *executionContext) _LayerSummary_Size(ctx context.Context, field graphql.CollectedField, obj *LayerSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_LayerSummary_Size(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4838,7 +5280,7 @@ func (ec *executionContext) _PackageInfo_Name(ctx context.Context, field graphql
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Name, nil
+		return obj.Size, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4852,9 +5294,9 @@ func (ec *executionContext) _PackageInfo_Name(ctx context.Context, field graphql
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_PackageInfo_Name(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_LayerSummary_Size(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PackageInfo",
+		Object:     "LayerSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -4865,8 +5307,8 @@ func (ec *executionContext) fieldContext_PackageInfo_Name(ctx context.Context, f
 	return fc, nil
 }
 
-func (ec *executionContext) _PackageInfo_InstalledVersion(ctx context.Context, field graphql.CollectedField, obj *PackageInfo) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_PackageInfo_InstalledVersion(ctx, field)
+func (ec *executionContext) _LayerSummary_Digest(ctx context.Context, field graphql.CollectedField, obj *LayerSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_LayerSummary_Digest(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4879,7 +5321,7 @@ func (ec *executionContext) _PackageInfo_InstalledVersion(ctx context.Context, f
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.InstalledVersion, nil
+		return obj.Digest, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4893,9 +5335,9 @@ func (ec *executionContext) _PackageInfo_InstalledVersion(ctx context.Context, f
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_PackageInfo_InstalledVersion(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_LayerSummary_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PackageInfo",
+		Object:     "LayerSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -4906,8 +5348,8 @@ func (ec *executionContext) fieldContext_PackageInfo_InstalledVersion(ctx contex
 	return fc, nil
 }
 
-func (ec *executionContext) _PackageInfo_FixedVersion(ctx context.Context, field graphql.CollectedField, obj *PackageInfo) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_PackageInfo_FixedVersion(ctx, field)
+func (ec *executionContext) _ManifestSummary_Digest(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_Digest(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4920,7 +5362,7 @@ func (ec *executionContext) _PackageInfo_FixedVersion(ctx context.Context, field
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.FixedVersion, nil
+		return obj.Digest, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -4934,9 +5376,9 @@ func (ec *executionContext) _PackageInfo_FixedVersion(ctx context.Context, field
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_PackageInfo_FixedVersion(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PackageInfo",
+		Object:     "ManifestSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -4947,8 +5389,8 @@ func (ec *executionContext) fieldContext_PackageInfo_FixedVersion(ctx context.Co
 	return fc, nil
 }
 
-func (ec *executionContext) _PageInfo_TotalCount(ctx context.Context, field graphql.CollectedField, obj *PageInfo) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_PageInfo_TotalCount(ctx, field)
+func (ec *executionContext) _ManifestSummary_ConfigDigest(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_ConfigDigest(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -4961,38 +5403,35 @@ func (ec *executionContext) _PageInfo_TotalCount(ctx context.Context, field grap
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.TotalCount, nil
+		return obj.ConfigDigest, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(int)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalNInt2int(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_PageInfo_TotalCount(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_ConfigDigest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PageInfo",
+		Object:     "ManifestSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Int does not have child fields")
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _PageInfo_ItemCount(ctx context.Context, field graphql.CollectedField, obj *PageInfo) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_PageInfo_ItemCount(ctx, field)
+func (ec *executionContext) _ManifestSummary_LastUpdated(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_LastUpdated(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5005,38 +5444,35 @@ func (ec *executionContext) _PageInfo_ItemCount(ctx context.Context, field graph
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.ItemCount, nil
+		return obj.LastUpdated, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(int)
+	res := resTmp.(*time.Time)
 	fc.Result = res
-	return ec.marshalNInt2int(ctx, field.Selections, res)
+	return ec.marshalOTime2ᚖtimeᚐTime(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_PageInfo_ItemCount(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_LastUpdated(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PageInfo",
+		Object:     "ManifestSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Int does not have child fields")
+			return nil, errors.New("field of type Time does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _PaginatedImagesResult_Page(ctx context.Context, field graphql.CollectedField, obj *PaginatedImagesResult) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
+func (ec *executionContext) _ManifestSummary_Size(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_Size(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5049,7 +5485,7 @@ func (ec *executionContext) _PaginatedImagesResult_Page(ctx context.Context, fie
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Page, nil
+		return obj.Size, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -5058,32 +5494,26 @@ func (ec *executionContext) _PaginatedImagesResult_Page(ctx context.Context, fie
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*PageInfo)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalOPageInfo2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInfo(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_PaginatedImagesResult_Page(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_Size(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PaginatedImagesResult",
+		Object:     "ManifestSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "TotalCount":
-				return ec.fieldContext_PageInfo_TotalCount(ctx, field)
-			case "ItemCount":
-				return ec.fieldContext_PageInfo_ItemCount(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type PageInfo", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _PaginatedImagesResult_Results(ctx context.Context, field graphql.CollectedField, obj *PaginatedImagesResult) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+func (ec *executionContext) _ManifestSummary_IsSigned(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_IsSigned(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5096,80 +5526,35 @@ func (ec *executionContext) _PaginatedImagesResult_Results(ctx context.Context,
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Results, nil
+		return obj.IsSigned, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.([]*ImageSummary)
+	res := resTmp.(*bool)
 	fc.Result = res
-	return ec.marshalNImageSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐImageSummaryᚄ(ctx, field.Selections, res)
+	return ec.marshalOBoolean2ᚖbool(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_PaginatedImagesResult_Results(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_IsSigned(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PaginatedImagesResult",
+		Object:     "ManifestSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "RepoName":
-				return ec.fieldContext_ImageSummary_RepoName(ctx, field)
-			case "Tag":
-				return ec.fieldContext_ImageSummary_Tag(ctx, field)
-			case "Digest":
-				return ec.fieldContext_ImageSummary_Digest(ctx, field)
-			case "MediaType":
-				return ec.fieldContext_ImageSummary_MediaType(ctx, field)
-			case "Manifests":
-				return ec.fieldContext_ImageSummary_Manifests(ctx, field)
-			case "Size":
-				return ec.fieldContext_ImageSummary_Size(ctx, field)
-			case "DownloadCount":
-				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
-			case "LastUpdated":
-				return ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
-			case "Description":
-				return ec.fieldContext_ImageSummary_Description(ctx, field)
-			case "IsSigned":
-				return ec.fieldContext_ImageSummary_IsSigned(ctx, field)
-			case "SignatureInfo":
-				return ec.fieldContext_ImageSummary_SignatureInfo(ctx, field)
-			case "Licenses":
-				return ec.fieldContext_ImageSummary_Licenses(ctx, field)
-			case "Labels":
-				return ec.fieldContext_ImageSummary_Labels(ctx, field)
-			case "Title":
-				return ec.fieldContext_ImageSummary_Title(ctx, field)
-			case "Source":
-				return ec.fieldContext_ImageSummary_Source(ctx, field)
-			case "Documentation":
-				return ec.fieldContext_ImageSummary_Documentation(ctx, field)
-			case "Vendor":
-				return ec.fieldContext_ImageSummary_Vendor(ctx, field)
-			case "Authors":
-				return ec.fieldContext_ImageSummary_Authors(ctx, field)
-			case "Vulnerabilities":
-				return ec.fieldContext_ImageSummary_Vulnerabilities(ctx, field)
-			case "Referrers":
-				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
+			return nil, errors.New("field of type Boolean does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _PaginatedReposResult_Page(ctx context.Context, field graphql.CollectedField, obj *PaginatedReposResult) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_PaginatedReposResult_Page(ctx, field)
+func (ec *executionContext) _ManifestSummary_SignatureInfo(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_SignatureInfo(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5182,7 +5567,7 @@ func (ec *executionContext) _PaginatedReposResult_Page(ctx context.Context, fiel
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Page, nil
+		return obj.SignatureInfo, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -5191,32 +5576,34 @@ func (ec *executionContext) _PaginatedReposResult_Page(ctx context.Context, fiel
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*PageInfo)
+	res := resTmp.([]*SignatureSummary)
 	fc.Result = res
-	return ec.marshalOPageInfo2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInfo(ctx, field.Selections, res)
+	return ec.marshalOSignatureSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐSignatureSummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_PaginatedReposResult_Page(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_SignatureInfo(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PaginatedReposResult",
+		Object:     "ManifestSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "TotalCount":
-				return ec.fieldContext_PageInfo_TotalCount(ctx, field)
-			case "ItemCount":
-				return ec.fieldContext_PageInfo_ItemCount(ctx, field)
+			case "Tool":
+				return ec.fieldContext_SignatureSummary_Tool(ctx, field)
+			case "IsTrusted":
+				return ec.fieldContext_SignatureSummary_IsTrusted(ctx, field)
+			case "Author":
+				return ec.fieldContext_SignatureSummary_Author(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type PageInfo", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type SignatureSummary", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _PaginatedReposResult_Results(ctx context.Context, field graphql.CollectedField, obj *PaginatedReposResult) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_PaginatedReposResult_Results(ctx, field)
+func (ec *executionContext) _ManifestSummary_IsEncrypted(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_IsEncrypted(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5229,60 +5616,35 @@ func (ec *executionContext) _PaginatedReposResult_Results(ctx context.Context, f
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Results, nil
+		return obj.IsEncrypted, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.([]*RepoSummary)
+	res := resTmp.(*bool)
 	fc.Result = res
-	return ec.marshalNRepoSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoSummaryᚄ(ctx, field.Selections, res)
+	return ec.marshalOBoolean2ᚖbool(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_PaginatedReposResult_Results(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_IsEncrypted(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PaginatedReposResult",
+		Object:     "ManifestSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Name":
-				return ec.fieldContext_RepoSummary_Name(ctx, field)
-			case "LastUpdated":
-				return ec.fieldContext_RepoSummary_LastUpdated(ctx, field)
-			case "Size":
-				return ec.fieldContext_RepoSummary_Size(ctx, field)
-			case "Platforms":
-				return ec.fieldContext_RepoSummary_Platforms(ctx, field)
-			case "Vendors":
-				return ec.fieldContext_RepoSummary_Vendors(ctx, field)
-			case "NewestImage":
-				return ec.fieldContext_RepoSummary_NewestImage(ctx, field)
-			case "DownloadCount":
-				return ec.fieldContext_RepoSummary_DownloadCount(ctx, field)
-			case "StarCount":
-				return ec.fieldContext_RepoSummary_StarCount(ctx, field)
-			case "IsBookmarked":
-				return ec.fieldContext_RepoSummary_IsBookmarked(ctx, field)
-			case "IsStarred":
-				return ec.fieldContext_RepoSummary_IsStarred(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type RepoSummary", field.Name)
+			return nil, errors.New("field of type Boolean does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Platform_Os(ctx context.Context, field graphql.CollectedField, obj *Platform) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Platform_Os(ctx, field)
+func (ec *executionContext) _ManifestSummary_Platform(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_Platform(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5295,7 +5657,7 @@ func (ec *executionContext) _Platform_Os(ctx context.Context, field graphql.Coll
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Os, nil
+		return obj.Platform, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -5304,26 +5666,32 @@ func (ec *executionContext) _Platform_Os(ctx context.Context, field graphql.Coll
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.(*Platform)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOPlatform2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPlatform(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Platform_Os(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_Platform(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Platform",
+		Object:     "ManifestSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "Os":
+				return ec.fieldContext_Platform_Os(ctx, field)
+			case "Arch":
+				return ec.fieldContext_Platform_Arch(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type Platform", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Platform_Arch(ctx context.Context, field graphql.CollectedField, obj *Platform) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Platform_Arch(ctx, field)
+func (ec *executionContext) _ManifestSummary_DownloadCount(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_DownloadCount(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5336,7 +5704,7 @@ func (ec *executionContext) _Platform_Arch(ctx context.Context, field graphql.Co
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Arch, nil
+		return obj.DownloadCount, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -5345,26 +5713,26 @@ func (ec *executionContext) _Platform_Arch(ctx context.Context, field graphql.Co
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.(*int)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Platform_Arch(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_DownloadCount(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Platform",
+		Object:     "ManifestSummary",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			return nil, errors.New("field of type Int does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_CVEListForImage(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_CVEListForImage(ctx, field)
+func (ec *executionContext) _ManifestSummary_Layers(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_Layers(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5377,57 +5745,41 @@ func (ec *executionContext) _Query_CVEListForImage(ctx context.Context, field gr
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().CVEListForImage(rctx, fc.Args["image"].(string), fc.Args["requestedPage"].(*PageInput), fc.Args["searchedCVE"].(*string))
+		return obj.Layers, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(*CVEResultForImage)
+	res := resTmp.([]*LayerSummary)
 	fc.Result = res
-	return ec.marshalNCVEResultForImage2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐCVEResultForImage(ctx, field.Selections, res)
+	return ec.marshalOLayerSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerSummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_CVEListForImage(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_Layers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "ManifestSummary",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "Tag":
-				return ec.fieldContext_CVEResultForImage_Tag(ctx, field)
-			case "CVEList":
-				return ec.fieldContext_CVEResultForImage_CVEList(ctx, field)
-			case "Page":
-				return ec.fieldContext_CVEResultForImage_Page(ctx, field)
+			case "Size":
+				return ec.fieldContext_LayerSummary_Size(ctx, field)
+			case "Digest":
+				return ec.fieldContext_LayerSummary_Digest(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type CVEResultForImage", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type LayerSummary", field.Name)
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_CVEListForImage_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_ImageListForCVE(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_ImageListForCVE(ctx, field)
+func (ec *executionContext) _ManifestSummary_History(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_History(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5440,55 +5792,41 @@ func (ec *executionContext) _Query_ImageListForCVE(ctx context.Context, field gr
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().ImageListForCve(rctx, fc.Args["id"].(string), fc.Args["requestedPage"].(*PageInput))
+		return obj.History, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(*PaginatedImagesResult)
+	res := resTmp.([]*LayerHistory)
 	fc.Result = res
-	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+	return ec.marshalOLayerHistory2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerHistory(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_ImageListForCVE(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_History(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "ManifestSummary",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "Page":
-				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
-			case "Results":
-				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+			case "Layer":
+				return ec.fieldContext_LayerHistory_Layer(ctx, field)
+			case "HistoryDescription":
+				return ec.fieldContext_LayerHistory_HistoryDescription(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type LayerHistory", field.Name)
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_ImageListForCVE_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_ImageListWithCVEFixed(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_ImageListWithCVEFixed(ctx, field)
+func (ec *executionContext) _ManifestSummary_Vulnerabilities(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_Vulnerabilities(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5501,55 +5839,41 @@ func (ec *executionContext) _Query_ImageListWithCVEFixed(ctx context.Context, fi
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().ImageListWithCVEFixed(rctx, fc.Args["id"].(string), fc.Args["image"].(string), fc.Args["requestedPage"].(*PageInput))
+		return obj.Vulnerabilities, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(*PaginatedImagesResult)
+	res := resTmp.(*ImageVulnerabilitySummary)
 	fc.Result = res
-	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+	return ec.marshalOImageVulnerabilitySummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐImageVulnerabilitySummary(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_ImageListWithCVEFixed(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_Vulnerabilities(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "ManifestSummary",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "Page":
-				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
-			case "Results":
-				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+			case "MaxSeverity":
+				return ec.fieldContext_ImageVulnerabilitySummary_MaxSeverity(ctx, field)
+			case "Count":
+				return ec.fieldContext_ImageVulnerabilitySummary_Count(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type ImageVulnerabilitySummary", field.Name)
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_ImageListWithCVEFixed_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_ImageListForDigest(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_ImageListForDigest(ctx, field)
+func (ec *executionContext) _ManifestSummary_Referrers(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_Referrers(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5562,55 +5886,47 @@ func (ec *executionContext) _Query_ImageListForDigest(ctx context.Context, field
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().ImageListForDigest(rctx, fc.Args["id"].(string), fc.Args["requestedPage"].(*PageInput))
+		return obj.Referrers, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(*PaginatedImagesResult)
+	res := resTmp.([]*Referrer)
 	fc.Result = res
-	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+	return ec.marshalOReferrer2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrer(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_ImageListForDigest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_Referrers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "ManifestSummary",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "Page":
-				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
-			case "Results":
-				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+			case "MediaType":
+				return ec.fieldContext_Referrer_MediaType(ctx, field)
+			case "ArtifactType":
+				return ec.fieldContext_Referrer_ArtifactType(ctx, field)
+			case "Size":
+				return ec.fieldContext_Referrer_Size(ctx, field)
+			case "Digest":
+				return ec.fieldContext_Referrer_Digest(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_Referrer_Annotations(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type Referrer", field.Name)
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_ImageListForDigest_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_RepoListWithNewestImage(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_RepoListWithNewestImage(ctx, field)
+func (ec *executionContext) _ManifestSummary_ArtifactType(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_ArtifactType(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5623,55 +5939,35 @@ func (ec *executionContext) _Query_RepoListWithNewestImage(ctx context.Context,
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().RepoListWithNewestImage(rctx, fc.Args["requestedPage"].(*PageInput))
+		return obj.ArtifactType, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(*PaginatedReposResult)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalNPaginatedReposResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedReposResult(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_RepoListWithNewestImage(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ManifestSummary_ArtifactType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "ManifestSummary",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Page":
-				return ec.fieldContext_PaginatedReposResult_Page(ctx, field)
-			case "Results":
-				return ec.fieldContext_PaginatedReposResult_Results(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type PaginatedReposResult", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_RepoListWithNewestImage_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_ImageList(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_ImageList(ctx, field)
+func (ec *executionContext) _PackageInfo_Name(ctx context.Context, field graphql.CollectedField, obj *PackageInfo) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_PackageInfo_Name(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5684,55 +5980,35 @@ func (ec *executionContext) _Query_ImageList(ctx context.Context, field graphql.
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().ImageList(rctx, fc.Args["repo"].(string), fc.Args["requestedPage"].(*PageInput))
+		return obj.Name, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(*PaginatedImagesResult)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_ImageList(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PackageInfo_Name(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "PackageInfo",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Page":
-				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
-			case "Results":
-				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_ImageList_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_ExpandedRepoInfo(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_ExpandedRepoInfo(ctx, field)
+func (ec *executionContext) _PackageInfo_InstalledVersion(ctx context.Context, field graphql.CollectedField, obj *PackageInfo) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_PackageInfo_InstalledVersion(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5745,55 +6021,35 @@ func (ec *executionContext) _Query_ExpandedRepoInfo(ctx context.Context, field g
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().ExpandedRepoInfo(rctx, fc.Args["repo"].(string))
+		return obj.InstalledVersion, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(*RepoInfo)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalNRepoInfo2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoInfo(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_ExpandedRepoInfo(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PackageInfo_InstalledVersion(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "PackageInfo",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Images":
-				return ec.fieldContext_RepoInfo_Images(ctx, field)
-			case "Summary":
-				return ec.fieldContext_RepoInfo_Summary(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type RepoInfo", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_ExpandedRepoInfo_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_GlobalSearch(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_GlobalSearch(ctx, field)
+func (ec *executionContext) _PackageInfo_FixedVersion(ctx context.Context, field graphql.CollectedField, obj *PackageInfo) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_PackageInfo_FixedVersion(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5806,59 +6062,35 @@ func (ec *executionContext) _Query_GlobalSearch(ctx context.Context, field graph
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().GlobalSearch(rctx, fc.Args["query"].(string), fc.Args["filter"].(*Filter), fc.Args["requestedPage"].(*PageInput))
+		return obj.FixedVersion, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(*GlobalSearchResult)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalNGlobalSearchResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐGlobalSearchResult(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_GlobalSearch(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PackageInfo_FixedVersion(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "PackageInfo",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Page":
-				return ec.fieldContext_GlobalSearchResult_Page(ctx, field)
-			case "Images":
-				return ec.fieldContext_GlobalSearchResult_Images(ctx, field)
-			case "Repos":
-				return ec.fieldContext_GlobalSearchResult_Repos(ctx, field)
-			case "Layers":
-				return ec.fieldContext_GlobalSearchResult_Layers(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type GlobalSearchResult", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_GlobalSearch_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_DerivedImageList(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_DerivedImageList(ctx, field)
+func (ec *executionContext) _PageInfo_TotalCount(ctx context.Context, field graphql.CollectedField, obj *PageInfo) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_PageInfo_TotalCount(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5871,7 +6103,7 @@ func (ec *executionContext) _Query_DerivedImageList(ctx context.Context, field g
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().DerivedImageList(rctx, fc.Args["image"].(string), fc.Args["digest"].(*string), fc.Args["requestedPage"].(*PageInput))
+		return obj.TotalCount, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -5883,43 +6115,26 @@ func (ec *executionContext) _Query_DerivedImageList(ctx context.Context, field g
 		}
 		return graphql.Null
 	}
-	res := resTmp.(*PaginatedImagesResult)
+	res := resTmp.(int)
 	fc.Result = res
-	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+	return ec.marshalNInt2int(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_DerivedImageList(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PageInfo_TotalCount(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "PageInfo",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Page":
-				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
-			case "Results":
-				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+			return nil, errors.New("field of type Int does not have child fields")
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_DerivedImageList_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_BaseImageList(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_BaseImageList(ctx, field)
+func (ec *executionContext) _PageInfo_ItemCount(ctx context.Context, field graphql.CollectedField, obj *PageInfo) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_PageInfo_ItemCount(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5932,7 +6147,7 @@ func (ec *executionContext) _Query_BaseImageList(ctx context.Context, field grap
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().BaseImageList(rctx, fc.Args["image"].(string), fc.Args["digest"].(*string), fc.Args["requestedPage"].(*PageInput))
+		return obj.ItemCount, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -5944,43 +6159,26 @@ func (ec *executionContext) _Query_BaseImageList(ctx context.Context, field grap
 		}
 		return graphql.Null
 	}
-	res := resTmp.(*PaginatedImagesResult)
+	res := resTmp.(int)
 	fc.Result = res
-	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+	return ec.marshalNInt2int(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_BaseImageList(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PageInfo_ItemCount(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "PageInfo",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Page":
-				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
-			case "Results":
-				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+			return nil, errors.New("field of type Int does not have child fields")
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_BaseImageList_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_Image(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_Image(ctx, field)
+func (ec *executionContext) _PaginatedImagesResult_Page(ctx context.Context, field graphql.CollectedField, obj *PaginatedImagesResult) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5993,29 +6191,76 @@ func (ec *executionContext) _Query_Image(ctx context.Context, field graphql.Coll
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().Image(rctx, fc.Args["image"].(string))
+		return obj.Page, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(*ImageSummary)
+	res := resTmp.(*PageInfo)
 	fc.Result = res
-	return ec.marshalNImageSummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐImageSummary(ctx, field.Selections, res)
+	return ec.marshalOPageInfo2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInfo(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_Image(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PaginatedImagesResult_Page(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "PaginatedImagesResult",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "TotalCount":
+				return ec.fieldContext_PageInfo_TotalCount(ctx, field)
+			case "ItemCount":
+				return ec.fieldContext_PageInfo_ItemCount(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PageInfo", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PaginatedImagesResult_Results(ctx context.Context, field graphql.CollectedField, obj *PaginatedImagesResult) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Results, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.([]*ImageSummary)
+	fc.Result = res
+	return ec.marshalNImageSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐImageSummaryᚄ(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_PaginatedImagesResult_Results(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PaginatedImagesResult",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
 			case "RepoName":
@@ -6030,6 +6275,10 @@ func (ec *executionContext) fieldContext_Query_Image(ctx context.Context, field
 				return ec.fieldContext_ImageSummary_Manifests(ctx, field)
 			case "Size":
 				return ec.fieldContext_ImageSummary_Size(ctx, field)
+			case "LogicalSize":
+				return ec.fieldContext_ImageSummary_LogicalSize(ctx, field)
+			case "UniqueSize":
+				return ec.fieldContext_ImageSummary_UniqueSize(ctx, field)
 			case "DownloadCount":
 				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
 			case "LastUpdated":
@@ -6040,6 +6289,14 @@ func (ec *executionContext) fieldContext_Query_Image(ctx context.Context, field
 				return ec.fieldContext_ImageSummary_IsSigned(ctx, field)
 			case "SignatureInfo":
 				return ec.fieldContext_ImageSummary_SignatureInfo(ctx, field)
+			case "IsPinned":
+				return ec.fieldContext_ImageSummary_IsPinned(ctx, field)
+			case "RegistryAnnotations":
+				return ec.fieldContext_ImageSummary_RegistryAnnotations(ctx, field)
+			case "DigestAliases":
+				return ec.fieldContext_ImageSummary_DigestAliases(ctx, field)
+			case "IsEncrypted":
+				return ec.fieldContext_ImageSummary_IsEncrypted(ctx, field)
 			case "Licenses":
 				return ec.fieldContext_ImageSummary_Licenses(ctx, field)
 			case "Labels":
@@ -6058,26 +6315,1373 @@ func (ec *executionContext) fieldContext_Query_Image(ctx context.Context, field
 				return ec.fieldContext_ImageSummary_Vulnerabilities(ctx, field)
 			case "Referrers":
 				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
+			case "SBOM":
+				return ec.fieldContext_ImageSummary_SBOM(ctx, field)
+			case "Attestations":
+				return ec.fieldContext_ImageSummary_Attestations(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
 		},
 	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PaginatedReposResult_Page(ctx context.Context, field graphql.CollectedField, obj *PaginatedReposResult) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_PaginatedReposResult_Page(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
 	defer func() {
 		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
 		}
 	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Page, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*PageInfo)
+	fc.Result = res
+	return ec.marshalOPageInfo2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPageInfo(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_PaginatedReposResult_Page(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PaginatedReposResult",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "TotalCount":
+				return ec.fieldContext_PageInfo_TotalCount(ctx, field)
+			case "ItemCount":
+				return ec.fieldContext_PageInfo_ItemCount(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PageInfo", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PaginatedReposResult_Results(ctx context.Context, field graphql.CollectedField, obj *PaginatedReposResult) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_PaginatedReposResult_Results(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
 	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_Image_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Results, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.([]*RepoSummary)
+	fc.Result = res
+	return ec.marshalNRepoSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoSummaryᚄ(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_PaginatedReposResult_Results(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PaginatedReposResult",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Name":
+				return ec.fieldContext_RepoSummary_Name(ctx, field)
+			case "LastUpdated":
+				return ec.fieldContext_RepoSummary_LastUpdated(ctx, field)
+			case "Size":
+				return ec.fieldContext_RepoSummary_Size(ctx, field)
+			case "Platforms":
+				return ec.fieldContext_RepoSummary_Platforms(ctx, field)
+			case "Vendors":
+				return ec.fieldContext_RepoSummary_Vendors(ctx, field)
+			case "NewestImage":
+				return ec.fieldContext_RepoSummary_NewestImage(ctx, field)
+			case "DownloadCount":
+				return ec.fieldContext_RepoSummary_DownloadCount(ctx, field)
+			case "StarCount":
+				return ec.fieldContext_RepoSummary_StarCount(ctx, field)
+			case "IsBookmarked":
+				return ec.fieldContext_RepoSummary_IsBookmarked(ctx, field)
+			case "IsStarred":
+				return ec.fieldContext_RepoSummary_IsStarred(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type RepoSummary", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Platform_Os(ctx context.Context, field graphql.CollectedField, obj *Platform) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Platform_Os(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Os, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Platform_Os(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Platform",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Platform_Arch(ctx context.Context, field graphql.CollectedField, obj *Platform) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Platform_Arch(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Arch, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Platform_Arch(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Platform",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_CVEListForImage(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_CVEListForImage(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().CVEListForImage(rctx, fc.Args["image"].(string), fc.Args["requestedPage"].(*PageInput), fc.Args["searchedCVE"].(*string))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*CVEResultForImage)
+	fc.Result = res
+	return ec.marshalNCVEResultForImage2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐCVEResultForImage(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_CVEListForImage(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Tag":
+				return ec.fieldContext_CVEResultForImage_Tag(ctx, field)
+			case "CVEList":
+				return ec.fieldContext_CVEResultForImage_CVEList(ctx, field)
+			case "Page":
+				return ec.fieldContext_CVEResultForImage_Page(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type CVEResultForImage", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_CVEListForImage_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_ImageListForCVE(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_ImageListForCVE(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().ImageListForCve(rctx, fc.Args["id"].(string), fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*PaginatedImagesResult)
+	fc.Result = res
+	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_ImageListForCVE(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Page":
+				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
+			case "Results":
+				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_ImageListForCVE_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_ImageListWithCVEFixed(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_ImageListWithCVEFixed(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().ImageListWithCVEFixed(rctx, fc.Args["id"].(string), fc.Args["image"].(string), fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*PaginatedImagesResult)
+	fc.Result = res
+	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_ImageListWithCVEFixed(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Page":
+				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
+			case "Results":
+				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_ImageListWithCVEFixed_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_ImageListForDigest(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_ImageListForDigest(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().ImageListForDigest(rctx, fc.Args["id"].(string), fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*PaginatedImagesResult)
+	fc.Result = res
+	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_ImageListForDigest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Page":
+				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
+			case "Results":
+				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_ImageListForDigest_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_RepoListWithNewestImage(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_RepoListWithNewestImage(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().RepoListWithNewestImage(rctx, fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*PaginatedReposResult)
+	fc.Result = res
+	return ec.marshalNPaginatedReposResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedReposResult(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_RepoListWithNewestImage(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Page":
+				return ec.fieldContext_PaginatedReposResult_Page(ctx, field)
+			case "Results":
+				return ec.fieldContext_PaginatedReposResult_Results(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PaginatedReposResult", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_RepoListWithNewestImage_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_ImageList(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_ImageList(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().ImageList(rctx, fc.Args["repo"].(string), fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*PaginatedImagesResult)
+	fc.Result = res
+	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_ImageList(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Page":
+				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
+			case "Results":
+				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_ImageList_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_ExpandedRepoInfo(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_ExpandedRepoInfo(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().ExpandedRepoInfo(rctx, fc.Args["repo"].(string))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*RepoInfo)
+	fc.Result = res
+	return ec.marshalNRepoInfo2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoInfo(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_ExpandedRepoInfo(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Images":
+				return ec.fieldContext_RepoInfo_Images(ctx, field)
+			case "Summary":
+				return ec.fieldContext_RepoInfo_Summary(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type RepoInfo", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_ExpandedRepoInfo_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_GlobalSearch(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_GlobalSearch(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().GlobalSearch(rctx, fc.Args["query"].(string), fc.Args["filter"].(*Filter), fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*GlobalSearchResult)
+	fc.Result = res
+	return ec.marshalNGlobalSearchResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐGlobalSearchResult(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_GlobalSearch(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Page":
+				return ec.fieldContext_GlobalSearchResult_Page(ctx, field)
+			case "Images":
+				return ec.fieldContext_GlobalSearchResult_Images(ctx, field)
+			case "Repos":
+				return ec.fieldContext_GlobalSearchResult_Repos(ctx, field)
+			case "Layers":
+				return ec.fieldContext_GlobalSearchResult_Layers(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type GlobalSearchResult", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_GlobalSearch_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_DerivedImageList(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_DerivedImageList(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().DerivedImageList(rctx, fc.Args["image"].(string), fc.Args["digest"].(*string), fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*PaginatedImagesResult)
+	fc.Result = res
+	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_DerivedImageList(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Page":
+				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
+			case "Results":
+				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_DerivedImageList_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_BaseImageList(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_BaseImageList(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().BaseImageList(rctx, fc.Args["image"].(string), fc.Args["digest"].(*string), fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*PaginatedImagesResult)
+	fc.Result = res
+	return ec.marshalNPaginatedImagesResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_BaseImageList(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Page":
+				return ec.fieldContext_PaginatedImagesResult_Page(ctx, field)
+			case "Results":
+				return ec.fieldContext_PaginatedImagesResult_Results(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PaginatedImagesResult", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_BaseImageList_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_Image(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_Image(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().Image(rctx, fc.Args["image"].(string))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*ImageSummary)
+	fc.Result = res
+	return ec.marshalNImageSummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐImageSummary(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_Image(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "RepoName":
+				return ec.fieldContext_ImageSummary_RepoName(ctx, field)
+			case "Tag":
+				return ec.fieldContext_ImageSummary_Tag(ctx, field)
+			case "Digest":
+				return ec.fieldContext_ImageSummary_Digest(ctx, field)
+			case "MediaType":
+				return ec.fieldContext_ImageSummary_MediaType(ctx, field)
+			case "Manifests":
+				return ec.fieldContext_ImageSummary_Manifests(ctx, field)
+			case "Size":
+				return ec.fieldContext_ImageSummary_Size(ctx, field)
+			case "LogicalSize":
+				return ec.fieldContext_ImageSummary_LogicalSize(ctx, field)
+			case "UniqueSize":
+				return ec.fieldContext_ImageSummary_UniqueSize(ctx, field)
+			case "DownloadCount":
+				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
+			case "LastUpdated":
+				return ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
+			case "Description":
+				return ec.fieldContext_ImageSummary_Description(ctx, field)
+			case "IsSigned":
+				return ec.fieldContext_ImageSummary_IsSigned(ctx, field)
+			case "SignatureInfo":
+				return ec.fieldContext_ImageSummary_SignatureInfo(ctx, field)
+			case "IsPinned":
+				return ec.fieldContext_ImageSummary_IsPinned(ctx, field)
+			case "RegistryAnnotations":
+				return ec.fieldContext_ImageSummary_RegistryAnnotations(ctx, field)
+			case "DigestAliases":
+				return ec.fieldContext_ImageSummary_DigestAliases(ctx, field)
+			case "IsEncrypted":
+				return ec.fieldContext_ImageSummary_IsEncrypted(ctx, field)
+			case "Licenses":
+				return ec.fieldContext_ImageSummary_Licenses(ctx, field)
+			case "Labels":
+				return ec.fieldContext_ImageSummary_Labels(ctx, field)
+			case "Title":
+				return ec.fieldContext_ImageSummary_Title(ctx, field)
+			case "Source":
+				return ec.fieldContext_ImageSummary_Source(ctx, field)
+			case "Documentation":
+				return ec.fieldContext_ImageSummary_Documentation(ctx, field)
+			case "Vendor":
+				return ec.fieldContext_ImageSummary_Vendor(ctx, field)
+			case "Authors":
+				return ec.fieldContext_ImageSummary_Authors(ctx, field)
+			case "Vulnerabilities":
+				return ec.fieldContext_ImageSummary_Vulnerabilities(ctx, field)
+			case "Referrers":
+				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
+			case "SBOM":
+				return ec.fieldContext_ImageSummary_SBOM(ctx, field)
+			case "Attestations":
+				return ec.fieldContext_ImageSummary_Attestations(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_Image_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_Referrers(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_Referrers(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().Referrers(rctx, fc.Args["repo"].(string), fc.Args["digest"].(string), fc.Args["type"].([]string))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.([]*Referrer)
+	fc.Result = res
+	return ec.marshalNReferrer2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrer(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_Referrers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "MediaType":
+				return ec.fieldContext_Referrer_MediaType(ctx, field)
+			case "ArtifactType":
+				return ec.fieldContext_Referrer_ArtifactType(ctx, field)
+			case "Size":
+				return ec.fieldContext_Referrer_Size(ctx, field)
+			case "Digest":
+				return ec.fieldContext_Referrer_Digest(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_Referrer_Annotations(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type Referrer", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_Referrers_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_ReferrersGraph(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_ReferrersGraph(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().ReferrersGraph(rctx, fc.Args["repo"].(string), fc.Args["digest"].(string), fc.Args["maxDepth"].(*int))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.([]*ReferrerGraphNode)
+	fc.Result = res
+	return ec.marshalNReferrerGraphNode2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrerGraphNode(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_ReferrersGraph(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "MediaType":
+				return ec.fieldContext_ReferrerGraphNode_MediaType(ctx, field)
+			case "ArtifactType":
+				return ec.fieldContext_ReferrerGraphNode_ArtifactType(ctx, field)
+			case "Size":
+				return ec.fieldContext_ReferrerGraphNode_Size(ctx, field)
+			case "Digest":
+				return ec.fieldContext_ReferrerGraphNode_Digest(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_ReferrerGraphNode_Annotations(ctx, field)
+			case "Subject":
+				return ec.fieldContext_ReferrerGraphNode_Subject(ctx, field)
+			case "Depth":
+				return ec.fieldContext_ReferrerGraphNode_Depth(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type ReferrerGraphNode", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_ReferrersGraph_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_StarredRepos(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_StarredRepos(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().StarredRepos(rctx, fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*PaginatedReposResult)
+	fc.Result = res
+	return ec.marshalNPaginatedReposResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedReposResult(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_StarredRepos(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Page":
+				return ec.fieldContext_PaginatedReposResult_Page(ctx, field)
+			case "Results":
+				return ec.fieldContext_PaginatedReposResult_Results(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PaginatedReposResult", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_StarredRepos_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_BookmarkedRepos(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_BookmarkedRepos(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().BookmarkedRepos(rctx, fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*PaginatedReposResult)
+	fc.Result = res
+	return ec.marshalNPaginatedReposResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedReposResult(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_BookmarkedRepos(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Page":
+				return ec.fieldContext_PaginatedReposResult_Page(ctx, field)
+			case "Results":
+				return ec.fieldContext_PaginatedReposResult_Results(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PaginatedReposResult", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_BookmarkedRepos_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query___type(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query___type(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.introspectType(fc.Args["name"].(string))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*introspection.Type)
+	fc.Result = res
+	return ec.marshalO__Type2ᚖgithubᚗcomᚋ99designsᚋgqlgenᚋgraphqlᚋintrospectionᚐType(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query___type(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "kind":
+				return ec.fieldContext___Type_kind(ctx, field)
+			case "name":
+				return ec.fieldContext___Type_name(ctx, field)
+			case "description":
+				return ec.fieldContext___Type_description(ctx, field)
+			case "fields":
+				return ec.fieldContext___Type_fields(ctx, field)
+			case "interfaces":
+				return ec.fieldContext___Type_interfaces(ctx, field)
+			case "possibleTypes":
+				return ec.fieldContext___Type_possibleTypes(ctx, field)
+			case "enumValues":
+				return ec.fieldContext___Type_enumValues(ctx, field)
+			case "inputFields":
+				return ec.fieldContext___Type_inputFields(ctx, field)
+			case "ofType":
+				return ec.fieldContext___Type_ofType(ctx, field)
+			case "specifiedByURL":
+				return ec.fieldContext___Type_specifiedByURL(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type __Type", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query___type_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query___schema(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query___schema(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.introspectSchema()
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*introspection.Schema)
+	fc.Result = res
+	return ec.marshalO__Schema2ᚖgithubᚗcomᚋ99designsᚋgqlgenᚋgraphqlᚋintrospectionᚐSchema(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query___schema(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "description":
+				return ec.fieldContext___Schema_description(ctx, field)
+			case "types":
+				return ec.fieldContext___Schema_types(ctx, field)
+			case "queryType":
+				return ec.fieldContext___Schema_queryType(ctx, field)
+			case "mutationType":
+				return ec.fieldContext___Schema_mutationType(ctx, field)
+			case "subscriptionType":
+				return ec.fieldContext___Schema_subscriptionType(ctx, field)
+			case "directives":
+				return ec.fieldContext___Schema_directives(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type __Schema", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Referrer_MediaType(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Referrer_MediaType(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.MediaType, nil
+	})
+	if err != nil {
 		ec.Error(ctx, err)
-		return
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Referrer_MediaType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Referrer",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_Referrers(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_Referrers(ctx, field)
+func (ec *executionContext) _Referrer_ArtifactType(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Referrer_ArtifactType(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6090,61 +7694,35 @@ func (ec *executionContext) _Query_Referrers(ctx context.Context, field graphql.
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().Referrers(rctx, fc.Args["repo"].(string), fc.Args["digest"].(string), fc.Args["type"].([]string))
+		return obj.ArtifactType, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.([]*Referrer)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalNReferrer2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrer(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_Referrers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_Referrer_ArtifactType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "Referrer",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "MediaType":
-				return ec.fieldContext_Referrer_MediaType(ctx, field)
-			case "ArtifactType":
-				return ec.fieldContext_Referrer_ArtifactType(ctx, field)
-			case "Size":
-				return ec.fieldContext_Referrer_Size(ctx, field)
-			case "Digest":
-				return ec.fieldContext_Referrer_Digest(ctx, field)
-			case "Annotations":
-				return ec.fieldContext_Referrer_Annotations(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type Referrer", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_Referrers_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_StarredRepos(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_StarredRepos(ctx, field)
+func (ec *executionContext) _Referrer_Size(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Referrer_Size(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6157,55 +7735,76 @@ func (ec *executionContext) _Query_StarredRepos(ctx context.Context, field graph
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().StarredRepos(rctx, fc.Args["requestedPage"].(*PageInput))
+		return obj.Size, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.(*PaginatedReposResult)
+	res := resTmp.(*int)
 	fc.Result = res
-	return ec.marshalNPaginatedReposResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedReposResult(ctx, field.Selections, res)
+	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_StarredRepos(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_Referrer_Size(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "Referrer",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Page":
-				return ec.fieldContext_PaginatedReposResult_Page(ctx, field)
-			case "Results":
-				return ec.fieldContext_PaginatedReposResult_Results(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type PaginatedReposResult", field.Name)
+			return nil, errors.New("field of type Int does not have child fields")
 		},
 	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Referrer_Digest(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Referrer_Digest(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
 	defer func() {
 		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
 		}
 	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_StarredRepos_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Digest, nil
+	})
+	if err != nil {
 		ec.Error(ctx, err)
-		return
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Referrer_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Referrer",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_BookmarkedRepos(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_BookmarkedRepos(ctx, field)
+func (ec *executionContext) _Referrer_Annotations(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Referrer_Annotations(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6218,7 +7817,7 @@ func (ec *executionContext) _Query_BookmarkedRepos(ctx context.Context, field gr
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().BookmarkedRepos(rctx, fc.Args["requestedPage"].(*PageInput))
+		return obj.Annotations, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -6230,43 +7829,32 @@ func (ec *executionContext) _Query_BookmarkedRepos(ctx context.Context, field gr
 		}
 		return graphql.Null
 	}
-	res := resTmp.(*PaginatedReposResult)
+	res := resTmp.([]*Annotation)
 	fc.Result = res
-	return ec.marshalNPaginatedReposResult2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedReposResult(ctx, field.Selections, res)
+	return ec.marshalNAnnotation2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_BookmarkedRepos(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_Referrer_Annotations(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "Referrer",
 		Field:      field,
-		IsMethod:   true,
-		IsResolver: true,
+		IsMethod:   false,
+		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "Page":
-				return ec.fieldContext_PaginatedReposResult_Page(ctx, field)
-			case "Results":
-				return ec.fieldContext_PaginatedReposResult_Results(ctx, field)
+			case "Key":
+				return ec.fieldContext_Annotation_Key(ctx, field)
+			case "Value":
+				return ec.fieldContext_Annotation_Value(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type PaginatedReposResult", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type Annotation", field.Name)
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_BookmarkedRepos_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query___type(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query___type(ctx, field)
+func (ec *executionContext) _ReferrerGraphNode_MediaType(ctx context.Context, field graphql.CollectedField, obj *ReferrerGraphNode) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ReferrerGraphNode_MediaType(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6279,7 +7867,7 @@ func (ec *executionContext) _Query___type(ctx context.Context, field graphql.Col
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.introspectType(fc.Args["name"].(string))
+		return obj.MediaType, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -6288,59 +7876,26 @@ func (ec *executionContext) _Query___type(ctx context.Context, field graphql.Col
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*introspection.Type)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalO__Type2ᚖgithubᚗcomᚋ99designsᚋgqlgenᚋgraphqlᚋintrospectionᚐType(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query___type(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ReferrerGraphNode_MediaType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "ReferrerGraphNode",
 		Field:      field,
-		IsMethod:   true,
+		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "kind":
-				return ec.fieldContext___Type_kind(ctx, field)
-			case "name":
-				return ec.fieldContext___Type_name(ctx, field)
-			case "description":
-				return ec.fieldContext___Type_description(ctx, field)
-			case "fields":
-				return ec.fieldContext___Type_fields(ctx, field)
-			case "interfaces":
-				return ec.fieldContext___Type_interfaces(ctx, field)
-			case "possibleTypes":
-				return ec.fieldContext___Type_possibleTypes(ctx, field)
-			case "enumValues":
-				return ec.fieldContext___Type_enumValues(ctx, field)
-			case "inputFields":
-				return ec.fieldContext___Type_inputFields(ctx, field)
-			case "ofType":
-				return ec.fieldContext___Type_ofType(ctx, field)
-			case "specifiedByURL":
-				return ec.fieldContext___Type_specifiedByURL(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type __Type", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ec.Recover(ctx, r)
-			ec.Error(ctx, err)
-		}
-	}()
-	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query___type_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
-		ec.Error(ctx, err)
-		return
-	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query___schema(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query___schema(ctx, field)
+func (ec *executionContext) _ReferrerGraphNode_ArtifactType(ctx context.Context, field graphql.CollectedField, obj *ReferrerGraphNode) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ReferrerGraphNode_ArtifactType(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6353,7 +7908,7 @@ func (ec *executionContext) _Query___schema(ctx context.Context, field graphql.C
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.introspectSchema()
+		return obj.ArtifactType, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -6362,40 +7917,26 @@ func (ec *executionContext) _Query___schema(ctx context.Context, field graphql.C
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*introspection.Schema)
+	res := resTmp.(*string)
 	fc.Result = res
-	return ec.marshalO__Schema2ᚖgithubᚗcomᚋ99designsᚋgqlgenᚋgraphqlᚋintrospectionᚐSchema(ctx, field.Selections, res)
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query___schema(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ReferrerGraphNode_ArtifactType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Query",
+		Object:     "ReferrerGraphNode",
 		Field:      field,
-		IsMethod:   true,
+		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "description":
-				return ec.fieldContext___Schema_description(ctx, field)
-			case "types":
-				return ec.fieldContext___Schema_types(ctx, field)
-			case "queryType":
-				return ec.fieldContext___Schema_queryType(ctx, field)
-			case "mutationType":
-				return ec.fieldContext___Schema_mutationType(ctx, field)
-			case "subscriptionType":
-				return ec.fieldContext___Schema_subscriptionType(ctx, field)
-			case "directives":
-				return ec.fieldContext___Schema_directives(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type __Schema", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Referrer_MediaType(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Referrer_MediaType(ctx, field)
+func (ec *executionContext) _ReferrerGraphNode_Size(ctx context.Context, field graphql.CollectedField, obj *ReferrerGraphNode) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ReferrerGraphNode_Size(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6408,7 +7949,7 @@ func (ec *executionContext) _Referrer_MediaType(ctx context.Context, field graph
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.MediaType, nil
+		return obj.Size, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -6417,26 +7958,26 @@ func (ec *executionContext) _Referrer_MediaType(ctx context.Context, field graph
 	if resTmp == nil {
 		return graphql.Null
 	}
-	res := resTmp.(*string)
+	res := resTmp.(*int)
 	fc.Result = res
-	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Referrer_MediaType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ReferrerGraphNode_Size(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Referrer",
+		Object:     "ReferrerGraphNode",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			return nil, errors.New("field of type Int does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Referrer_ArtifactType(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Referrer_ArtifactType(ctx, field)
+func (ec *executionContext) _ReferrerGraphNode_Digest(ctx context.Context, field graphql.CollectedField, obj *ReferrerGraphNode) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ReferrerGraphNode_Digest(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6449,7 +7990,7 @@ func (ec *executionContext) _Referrer_ArtifactType(ctx context.Context, field gr
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.ArtifactType, nil
+		return obj.Digest, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -6463,9 +8004,9 @@ func (ec *executionContext) _Referrer_ArtifactType(ctx context.Context, field gr
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Referrer_ArtifactType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ReferrerGraphNode_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Referrer",
+		Object:     "ReferrerGraphNode",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -6476,8 +8017,8 @@ func (ec *executionContext) fieldContext_Referrer_ArtifactType(ctx context.Conte
 	return fc, nil
 }
 
-func (ec *executionContext) _Referrer_Size(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Referrer_Size(ctx, field)
+func (ec *executionContext) _ReferrerGraphNode_Annotations(ctx context.Context, field graphql.CollectedField, obj *ReferrerGraphNode) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ReferrerGraphNode_Annotations(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6490,35 +8031,44 @@ func (ec *executionContext) _Referrer_Size(ctx context.Context, field graphql.Co
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Size, nil
+		return obj.Annotations, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
 		return graphql.Null
 	}
-	res := resTmp.(*int)
+	res := resTmp.([]*Annotation)
 	fc.Result = res
-	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
+	return ec.marshalNAnnotation2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Referrer_Size(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ReferrerGraphNode_Annotations(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Referrer",
+		Object:     "ReferrerGraphNode",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type Int does not have child fields")
+			switch field.Name {
+			case "Key":
+				return ec.fieldContext_Annotation_Key(ctx, field)
+			case "Value":
+				return ec.fieldContext_Annotation_Value(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type Annotation", field.Name)
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Referrer_Digest(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Referrer_Digest(ctx, field)
+func (ec *executionContext) _ReferrerGraphNode_Subject(ctx context.Context, field graphql.CollectedField, obj *ReferrerGraphNode) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ReferrerGraphNode_Subject(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6531,7 +8081,7 @@ func (ec *executionContext) _Referrer_Digest(ctx context.Context, field graphql.
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Digest, nil
+		return obj.Subject, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -6545,9 +8095,9 @@ func (ec *executionContext) _Referrer_Digest(ctx context.Context, field graphql.
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Referrer_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ReferrerGraphNode_Subject(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Referrer",
+		Object:     "ReferrerGraphNode",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -6558,8 +8108,8 @@ func (ec *executionContext) fieldContext_Referrer_Digest(ctx context.Context, fi
 	return fc, nil
 }
 
-func (ec *executionContext) _Referrer_Annotations(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Referrer_Annotations(ctx, field)
+func (ec *executionContext) _ReferrerGraphNode_Depth(ctx context.Context, field graphql.CollectedField, obj *ReferrerGraphNode) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ReferrerGraphNode_Depth(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6572,37 +8122,28 @@ func (ec *executionContext) _Referrer_Annotations(ctx context.Context, field gra
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Annotations, nil
+		return obj.Depth, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.([]*Annotation)
+	res := resTmp.(*int)
 	fc.Result = res
-	return ec.marshalNAnnotation2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx, field.Selections, res)
+	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Referrer_Annotations(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_ReferrerGraphNode_Depth(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Referrer",
+		Object:     "ReferrerGraphNode",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Key":
-				return ec.fieldContext_Annotation_Key(ctx, field)
-			case "Value":
-				return ec.fieldContext_Annotation_Value(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type Annotation", field.Name)
+			return nil, errors.New("field of type Int does not have child fields")
 		},
 	}
 	return fc, nil
@@ -6656,6 +8197,10 @@ func (ec *executionContext) fieldContext_RepoInfo_Images(ctx context.Context, fi
 				return ec.fieldContext_ImageSummary_Manifests(ctx, field)
 			case "Size":
 				return ec.fieldContext_ImageSummary_Size(ctx, field)
+			case "LogicalSize":
+				return ec.fieldContext_ImageSummary_LogicalSize(ctx, field)
+			case "UniqueSize":
+				return ec.fieldContext_ImageSummary_UniqueSize(ctx, field)
 			case "DownloadCount":
 				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
 			case "LastUpdated":
@@ -6666,6 +8211,14 @@ func (ec *executionContext) fieldContext_RepoInfo_Images(ctx context.Context, fi
 				return ec.fieldContext_ImageSummary_IsSigned(ctx, field)
 			case "SignatureInfo":
 				return ec.fieldContext_ImageSummary_SignatureInfo(ctx, field)
+			case "IsPinned":
+				return ec.fieldContext_ImageSummary_IsPinned(ctx, field)
+			case "RegistryAnnotations":
+				return ec.fieldContext_ImageSummary_RegistryAnnotations(ctx, field)
+			case "DigestAliases":
+				return ec.fieldContext_ImageSummary_DigestAliases(ctx, field)
+			case "IsEncrypted":
+				return ec.fieldContext_ImageSummary_IsEncrypted(ctx, field)
 			case "Licenses":
 				return ec.fieldContext_ImageSummary_Licenses(ctx, field)
 			case "Labels":
@@ -6684,6 +8237,10 @@ func (ec *executionContext) fieldContext_RepoInfo_Images(ctx context.Context, fi
 				return ec.fieldContext_ImageSummary_Vulnerabilities(ctx, field)
 			case "Referrers":
 				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
+			case "SBOM":
+				return ec.fieldContext_ImageSummary_SBOM(ctx, field)
+			case "Attestations":
+				return ec.fieldContext_ImageSummary_Attestations(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
 		},
@@ -7013,6 +8570,10 @@ func (ec *executionContext) fieldContext_RepoSummary_NewestImage(ctx context.Con
 				return ec.fieldContext_ImageSummary_Manifests(ctx, field)
 			case "Size":
 				return ec.fieldContext_ImageSummary_Size(ctx, field)
+			case "LogicalSize":
+				return ec.fieldContext_ImageSummary_LogicalSize(ctx, field)
+			case "UniqueSize":
+				return ec.fieldContext_ImageSummary_UniqueSize(ctx, field)
 			case "DownloadCount":
 				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
 			case "LastUpdated":
@@ -7023,6 +8584,14 @@ func (ec *executionContext) fieldContext_RepoSummary_NewestImage(ctx context.Con
 				return ec.fieldContext_ImageSummary_IsSigned(ctx, field)
 			case "SignatureInfo":
 				return ec.fieldContext_ImageSummary_SignatureInfo(ctx, field)
+			case "IsPinned":
+				return ec.fieldContext_ImageSummary_IsPinned(ctx, field)
+			case "RegistryAnnotations":
+				return ec.fieldContext_ImageSummary_RegistryAnnotations(ctx, field)
+			case "DigestAliases":
+				return ec.fieldContext_ImageSummary_DigestAliases(ctx, field)
+			case "IsEncrypted":
+				return ec.fieldContext_ImageSummary_IsEncrypted(ctx, field)
 			case "Licenses":
 				return ec.fieldContext_ImageSummary_Licenses(ctx, field)
 			case "Labels":
@@ -7041,6 +8610,10 @@ func (ec *executionContext) fieldContext_RepoSummary_NewestImage(ctx context.Con
 				return ec.fieldContext_ImageSummary_Vulnerabilities(ctx, field)
 			case "Referrers":
 				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
+			case "SBOM":
+				return ec.fieldContext_ImageSummary_SBOM(ctx, field)
+			case "Attestations":
+				return ec.fieldContext_ImageSummary_Attestations(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
 		},
@@ -7212,6 +8785,170 @@ func (ec *executionContext) fieldContext_RepoSummary_IsStarred(ctx context.Conte
 	return fc, nil
 }
 
+func (ec *executionContext) _SBOMSummary_Digest(ctx context.Context, field graphql.CollectedField, obj *SBOMSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_SBOMSummary_Digest(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Digest, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_SBOMSummary_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "SBOMSummary",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _SBOMSummary_Format(ctx context.Context, field graphql.CollectedField, obj *SBOMSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_SBOMSummary_Format(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Format, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_SBOMSummary_Format(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "SBOMSummary",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _SBOMSummary_Licenses(ctx context.Context, field graphql.CollectedField, obj *SBOMSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_SBOMSummary_Licenses(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Licenses, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.([]*string)
+	fc.Result = res
+	return ec.marshalOString2ᚕᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_SBOMSummary_Licenses(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "SBOMSummary",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _SBOMSummary_PackageCount(ctx context.Context, field graphql.CollectedField, obj *SBOMSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_SBOMSummary_PackageCount(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.PackageCount, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*int)
+	fc.Result = res
+	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_SBOMSummary_PackageCount(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "SBOMSummary",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _SignatureSummary_Tool(ctx context.Context, field graphql.CollectedField, obj *SignatureSummary) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_SignatureSummary_Tool(ctx, field)
 	if err != nil {
@@ -9115,7 +10852,7 @@ func (ec *executionContext) unmarshalInputFilter(ctx context.Context, obj interf
 		asMap[k] = v
 	}
 
-	fieldsInOrder := [...]string{"Os", "Arch", "HasToBeSigned", "IsBookmarked", "IsStarred"}
+	fieldsInOrder := [...]string{"Os", "Arch", "HasToBeSigned", "IsBookmarked", "IsStarred", "RegistryAnnotations", "MaxCVESeverity", "LastUpdatedAfter", "ExcludedLicenses"}
 	for _, k := range fieldsInOrder {
 		v, ok := asMap[k]
 		if !ok {
@@ -9167,6 +10904,42 @@ func (ec *executionContext) unmarshalInputFilter(ctx context.Context, obj interf
 				return it, err
 			}
 			it.IsStarred = data
+		case "RegistryAnnotations":
+			var err error
+
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("RegistryAnnotations"))
+			data, err := ec.unmarshalOString2ᚕᚖstring(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.RegistryAnnotations = data
+		case "MaxCVESeverity":
+			var err error
+
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("MaxCVESeverity"))
+			data, err := ec.unmarshalOString2ᚖstring(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.MaxCVESeverity = data
+		case "LastUpdatedAfter":
+			var err error
+
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("LastUpdatedAfter"))
+			data, err := ec.unmarshalOTime2ᚖtimeᚐTime(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.LastUpdatedAfter = data
+		case "ExcludedLicenses":
+			var err error
+
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("ExcludedLicenses"))
+			data, err := ec.unmarshalOString2ᚕᚖstring(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.ExcludedLicenses = data
 		}
 	}
 
@@ -9228,23 +11001,56 @@ func (ec *executionContext) unmarshalInputPageInput(ctx context.Context, obj int
 
 // region    **************************** object.gotpl ****************************
 
-var annotationImplementors = []string{"Annotation"}
+var annotationImplementors = []string{"Annotation"}
+
+func (ec *executionContext) _Annotation(ctx context.Context, sel ast.SelectionSet, obj *Annotation) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, annotationImplementors)
+	out := graphql.NewFieldSet(fields)
+	var invalids uint32
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("Annotation")
+		case "Key":
+
+			out.Values[i] = ec._Annotation_Key(ctx, field, obj)
+
+		case "Value":
+
+			out.Values[i] = ec._Annotation_Value(ctx, field, obj)
+
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch()
+	if invalids > 0 {
+		return graphql.Null
+	}
+	return out
+}
+
+var attestationSummaryImplementors = []string{"AttestationSummary"}
 
-func (ec *executionContext) _Annotation(ctx context.Context, sel ast.SelectionSet, obj *Annotation) graphql.Marshaler {
-	fields := graphql.CollectFields(ec.OperationContext, sel, annotationImplementors)
+func (ec *executionContext) _AttestationSummary(ctx context.Context, sel ast.SelectionSet, obj *AttestationSummary) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, attestationSummaryImplementors)
 	out := graphql.NewFieldSet(fields)
 	var invalids uint32
 	for i, field := range fields {
 		switch field.Name {
 		case "__typename":
-			out.Values[i] = graphql.MarshalString("Annotation")
-		case "Key":
+			out.Values[i] = graphql.MarshalString("AttestationSummary")
+		case "Digest":
 
-			out.Values[i] = ec._Annotation_Key(ctx, field, obj)
+			out.Values[i] = ec._AttestationSummary_Digest(ctx, field, obj)
 
-		case "Value":
+		case "PredicateType":
 
-			out.Values[i] = ec._Annotation_Value(ctx, field, obj)
+			out.Values[i] = ec._AttestationSummary_PredicateType(ctx, field, obj)
+
+		case "Tool":
+
+			out.Values[i] = ec._AttestationSummary_Tool(ctx, field, obj)
 
 		default:
 			panic("unknown field " + strconv.Quote(field.Name))
@@ -9287,6 +11093,22 @@ func (ec *executionContext) _CVE(ctx context.Context, sel ast.SelectionSet, obj
 
 			out.Values[i] = ec._CVE_PackageList(ctx, field, obj)
 
+		case "IsAcknowledged":
+
+			out.Values[i] = ec._CVE_IsAcknowledged(ctx, field, obj)
+
+		case "AcknowledgementReason":
+
+			out.Values[i] = ec._CVE_AcknowledgementReason(ctx, field, obj)
+
+		case "AcknowledgedBy":
+
+			out.Values[i] = ec._CVE_AcknowledgedBy(ctx, field, obj)
+
+		case "AcknowledgementExpiry":
+
+			out.Values[i] = ec._CVE_AcknowledgementExpiry(ctx, field, obj)
+
 		default:
 			panic("unknown field " + strconv.Quote(field.Name))
 		}
@@ -9443,6 +11265,14 @@ func (ec *executionContext) _ImageSummary(ctx context.Context, sel ast.Selection
 
 			out.Values[i] = ec._ImageSummary_Size(ctx, field, obj)
 
+		case "LogicalSize":
+
+			out.Values[i] = ec._ImageSummary_LogicalSize(ctx, field, obj)
+
+		case "UniqueSize":
+
+			out.Values[i] = ec._ImageSummary_UniqueSize(ctx, field, obj)
+
 		case "DownloadCount":
 
 			out.Values[i] = ec._ImageSummary_DownloadCount(ctx, field, obj)
@@ -9463,6 +11293,22 @@ func (ec *executionContext) _ImageSummary(ctx context.Context, sel ast.Selection
 
 			out.Values[i] = ec._ImageSummary_SignatureInfo(ctx, field, obj)
 
+		case "IsPinned":
+
+			out.Values[i] = ec._ImageSummary_IsPinned(ctx, field, obj)
+
+		case "RegistryAnnotations":
+
+			out.Values[i] = ec._ImageSummary_RegistryAnnotations(ctx, field, obj)
+
+		case "DigestAliases":
+
+			out.Values[i] = ec._ImageSummary_DigestAliases(ctx, field, obj)
+
+		case "IsEncrypted":
+
+			out.Values[i] = ec._ImageSummary_IsEncrypted(ctx, field, obj)
+
 		case "Licenses":
 
 			out.Values[i] = ec._ImageSummary_Licenses(ctx, field, obj)
@@ -9499,6 +11345,14 @@ func (ec *executionContext) _ImageSummary(ctx context.Context, sel ast.Selection
 
 			out.Values[i] = ec._ImageSummary_Referrers(ctx, field, obj)
 
+		case "SBOM":
+
+			out.Values[i] = ec._ImageSummary_SBOM(ctx, field, obj)
+
+		case "Attestations":
+
+			out.Values[i] = ec._ImageSummary_Attestations(ctx, field, obj)
+
 		default:
 			panic("unknown field " + strconv.Quote(field.Name))
 		}
@@ -9631,6 +11485,10 @@ func (ec *executionContext) _ManifestSummary(ctx context.Context, sel ast.Select
 
 			out.Values[i] = ec._ManifestSummary_SignatureInfo(ctx, field, obj)
 
+		case "IsEncrypted":
+
+			out.Values[i] = ec._ManifestSummary_IsEncrypted(ctx, field, obj)
+
 		case "Platform":
 
 			out.Values[i] = ec._ManifestSummary_Platform(ctx, field, obj)
@@ -10123,6 +11981,29 @@ func (ec *executionContext) _Query(ctx context.Context, sel ast.SelectionSet) gr
 				return ec.OperationContext.RootResolverMiddleware(ctx, innerFunc)
 			}
 
+			out.Concurrently(i, func() graphql.Marshaler {
+				return rrm(innerCtx)
+			})
+		case "ReferrersGraph":
+			field := field
+
+			innerFunc := func(ctx context.Context) (res graphql.Marshaler) {
+				defer func() {
+					if r := recover(); r != nil {
+						ec.Error(ctx, ec.Recover(ctx, r))
+					}
+				}()
+				res = ec._Query_ReferrersGraph(ctx, field)
+				if res == graphql.Null {
+					atomic.AddUint32(&invalids, 1)
+				}
+				return res
+			}
+
+			rrm := func(ctx context.Context) graphql.Marshaler {
+				return ec.OperationContext.RootResolverMiddleware(ctx, innerFunc)
+			}
+
 			out.Concurrently(i, func() graphql.Marshaler {
 				return rrm(innerCtx)
 			})
@@ -10239,6 +12120,58 @@ func (ec *executionContext) _Referrer(ctx context.Context, sel ast.SelectionSet,
 	return out
 }
 
+var referrerGraphNodeImplementors = []string{"ReferrerGraphNode"}
+
+func (ec *executionContext) _ReferrerGraphNode(ctx context.Context, sel ast.SelectionSet, obj *ReferrerGraphNode) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, referrerGraphNodeImplementors)
+	out := graphql.NewFieldSet(fields)
+	var invalids uint32
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("ReferrerGraphNode")
+		case "MediaType":
+
+			out.Values[i] = ec._ReferrerGraphNode_MediaType(ctx, field, obj)
+
+		case "ArtifactType":
+
+			out.Values[i] = ec._ReferrerGraphNode_ArtifactType(ctx, field, obj)
+
+		case "Size":
+
+			out.Values[i] = ec._ReferrerGraphNode_Size(ctx, field, obj)
+
+		case "Digest":
+
+			out.Values[i] = ec._ReferrerGraphNode_Digest(ctx, field, obj)
+
+		case "Annotations":
+
+			out.Values[i] = ec._ReferrerGraphNode_Annotations(ctx, field, obj)
+
+			if out.Values[i] == graphql.Null {
+				invalids++
+			}
+		case "Subject":
+
+			out.Values[i] = ec._ReferrerGraphNode_Subject(ctx, field, obj)
+
+		case "Depth":
+
+			out.Values[i] = ec._ReferrerGraphNode_Depth(ctx, field, obj)
+
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch()
+	if invalids > 0 {
+		return graphql.Null
+	}
+	return out
+}
+
 var repoInfoImplementors = []string{"RepoInfo"}
 
 func (ec *executionContext) _RepoInfo(ctx context.Context, sel ast.SelectionSet, obj *RepoInfo) graphql.Marshaler {
@@ -10329,6 +12262,43 @@ func (ec *executionContext) _RepoSummary(ctx context.Context, sel ast.SelectionS
 	return out
 }
 
+var sBOMSummaryImplementors = []string{"SBOMSummary"}
+
+func (ec *executionContext) _SBOMSummary(ctx context.Context, sel ast.SelectionSet, obj *SBOMSummary) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, sBOMSummaryImplementors)
+	out := graphql.NewFieldSet(fields)
+	var invalids uint32
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("SBOMSummary")
+		case "Digest":
+
+			out.Values[i] = ec._SBOMSummary_Digest(ctx, field, obj)
+
+		case "Format":
+
+			out.Values[i] = ec._SBOMSummary_Format(ctx, field, obj)
+
+		case "Licenses":
+
+			out.Values[i] = ec._SBOMSummary_Licenses(ctx, field, obj)
+
+		case "PackageCount":
+
+			out.Values[i] = ec._SBOMSummary_PackageCount(ctx, field, obj)
+
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch()
+	if invalids > 0 {
+		return graphql.Null
+	}
+	return out
+}
+
 var signatureSummaryImplementors = []string{"SignatureSummary"}
 
 func (ec *executionContext) _SignatureSummary(ctx context.Context, sel ast.SelectionSet, obj *SignatureSummary) graphql.Marshaler {
@@ -10900,6 +12870,44 @@ func (ec *executionContext) marshalNReferrer2ᚕᚖzotregistryᚗioᚋzotᚋpkg
 	return ret
 }
 
+func (ec *executionContext) marshalNReferrerGraphNode2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrerGraphNode(ctx context.Context, sel ast.SelectionSet, v []*ReferrerGraphNode) graphql.Marshaler {
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalOReferrerGraphNode2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrerGraphNode(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	return ret
+}
+
 func (ec *executionContext) marshalNRepoInfo2zotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoInfo(ctx context.Context, sel ast.SelectionSet, v RepoInfo) graphql.Marshaler {
 	return ec._RepoInfo(ctx, sel, &v)
 }
@@ -11236,6 +13244,47 @@ func (ec *executionContext) marshalN__TypeKind2string(ctx context.Context, sel a
 	return res
 }
 
+func (ec *executionContext) marshalOAnnotation2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx context.Context, sel ast.SelectionSet, v []*Annotation) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalOAnnotation2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	return ret
+}
+
 func (ec *executionContext) marshalOAnnotation2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx context.Context, sel ast.SelectionSet, v *Annotation) graphql.Marshaler {
 	if v == nil {
 		return graphql.Null
@@ -11243,6 +13292,54 @@ func (ec *executionContext) marshalOAnnotation2ᚖzotregistryᚗioᚋzotᚋpkg
 	return ec._Annotation(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalOAttestationSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAttestationSummary(ctx context.Context, sel ast.SelectionSet, v []*AttestationSummary) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalOAttestationSummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAttestationSummary(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	return ret
+}
+
+func (ec *executionContext) marshalOAttestationSummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAttestationSummary(ctx context.Context, sel ast.SelectionSet, v *AttestationSummary) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	return ec._AttestationSummary(ctx, sel, v)
+}
+
 func (ec *executionContext) unmarshalOBoolean2bool(ctx context.Context, v interface{}) (bool, error) {
 	res, err := graphql.UnmarshalBoolean(v)
 	return res, graphql.ErrorOnPath(ctx, err)
@@ -11706,6 +13803,13 @@ func (ec *executionContext) marshalOReferrer2ᚖzotregistryᚗioᚋzotᚋpkgᚋe
 	return ec._Referrer(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalOReferrerGraphNode2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrerGraphNode(ctx context.Context, sel ast.SelectionSet, v *ReferrerGraphNode) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	return ec._ReferrerGraphNode(ctx, sel, v)
+}
+
 func (ec *executionContext) marshalORepoSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoSummary(ctx context.Context, sel ast.SelectionSet, v []*RepoSummary) graphql.Marshaler {
 	if v == nil {
 		return graphql.Null
@@ -11754,6 +13858,13 @@ func (ec *executionContext) marshalORepoSummary2ᚖzotregistryᚗioᚋzotᚋpkg
 	return ec._RepoSummary(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalOSBOMSummary2ᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐSBOMSummary(ctx context.Context, sel ast.SelectionSet, v *SBOMSummary) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	return ec._SBOMSummary(ctx, sel, v)
+}
+
 func (ec *executionContext) marshalOSignatureSummary2ᚕᚖzotregistryᚗioᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐSignatureSummary(ctx context.Context, sel ast.SelectionSet, v []*SignatureSummary) graphql.Marshaler {
 	if v == nil {
 		return graphql.Null
@@ -18,6 +18,16 @@ type Annotation struct {
 	Value *string `json:"Value,omitempty"`
 }
 
+// Summary of an in-toto attestation attached to an image
+type AttestationSummary struct {
+	// Digest of the manifest holding the attestation
+	Digest *string `json:"Digest,omitempty"`
+	// Type of the predicate of the attestation, e.g. https://slsa.dev/provenance/v0.2
+	PredicateType *string `json:"PredicateType,omitempty"`
+	// Tool used to attach the attestation, e.g. cosign
+	Tool *string `json:"Tool,omitempty"`
+}
+
 // Contains various details about the CVE (Common Vulnerabilities and Exposures)
 // and a list of PackageInfo about the affected packages
 type Cve struct {
@@ -31,6 +41,14 @@ type Cve struct {
 	Severity *string `json:"Severity,omitempty"`
 	// Information on the packages in which the CVE was found
 	PackageList []*PackageInfo `json:"PackageList,omitempty"`
+	// True if the CVE was acknowledged for this image, acknowledged CVEs are not counted in the vulnerability summaries
+	IsAcknowledged *bool `json:"IsAcknowledged,omitempty"`
+	// Why the CVE was acknowledged
+	AcknowledgementReason *string `json:"AcknowledgementReason,omitempty"`
+	// The user who acknowledged the CVE
+	AcknowledgedBy *string `json:"AcknowledgedBy,omitempty"`
+	// When the acknowledgement expires, not set if it doesn't expire
+	AcknowledgementExpiry *time.Time `json:"AcknowledgementExpiry,omitempty"`
 }
 
 // Contains the tag of the image and a list of CVEs
@@ -59,6 +77,17 @@ type Filter struct {
 	IsBookmarked *bool `json:"IsBookmarked,omitempty"`
 	// Only returns images or repositories that are starred or not starred
 	IsStarred *bool `json:"IsStarred,omitempty"`
+	// Only return images or repositories with all the registry annotations in the list
+	// Entries are either "key=value" or "key" to match any value
+	RegistryAnnotations []*string `json:"RegistryAnnotations,omitempty"`
+	// Only return images or repositories which were scanned and have no CVE more severe than the given severity
+	// Should be one of NONE, UNKNOWN, LOW, MEDIUM, HIGH, CRITICAL
+	MaxCVESeverity *string `json:"MaxCVESeverity,omitempty"`
+	// Only return images or repositories updated after the given time
+	LastUpdatedAfter *time.Time `json:"LastUpdatedAfter,omitempty"`
+	// Only return images or repositories without any of the licenses in the list in their SBOM
+	// A license also excludes its variants, for example GPL-3.0 excludes GPL-3.0-only and GPL-3.0-or-later
+	ExcludedLicenses []*string `json:"ExcludedLicenses,omitempty"`
 }
 
 // Search results, can contain images, repositories and layers
@@ -103,6 +132,11 @@ type ImageSummary struct {
 	Manifests []*ManifestSummary `json:"Manifests,omitempty"`
 	// Total size of the files associated with all images (manifest, config, layers)
 	Size *string `json:"Size,omitempty"`
+	// Size of the blobs of the image, each counted once even if shared by several of its platforms
+	LogicalSize *string `json:"LogicalSize,omitempty"`
+	// Size of the blobs no other tagged image shares with this one in the storage, i.e. the space deleting it would reclaim,
+	// blobs are only shared across repos if the storage dedupes them
+	UniqueSize *string `json:"UniqueSize,omitempty"`
 	// Number of downloads of the manifest of this image
 	DownloadCount *int `json:"DownloadCount,omitempty"`
 	// Timestamp of the last modification done to the image (from config or the last updated layer)
@@ -113,6 +147,16 @@ type ImageSummary struct {
 	IsSigned *bool `json:"IsSigned,omitempty"`
 	// Info about signature validity
 	SignatureInfo []*SignatureSummary `json:"SignatureInfo,omitempty"`
+	// True if the image is pinned, pinned images are not garbage collected
+	IsPinned *bool `json:"IsPinned,omitempty"`
+	// Annotations set on the registry side, they can be changed without altering the manifest digest
+	RegistryAnnotations []*Annotation `json:"RegistryAnnotations,omitempty"`
+	// Digests the image was pushed with before it was rewritten by the registry (e.g. converted from docker to oci),
+	// pulling it by any of them returns this image
+	DigestAliases []*string `json:"DigestAliases,omitempty"`
+	// True if at least one of the image manifests has encrypted layers (ocicrypt),
+	// encrypted manifests are not scanned for vulnerabilities
+	IsEncrypted *bool `json:"IsEncrypted,omitempty"`
 	// License(s) under which contained software is distributed as an SPDX License Expression
 	Licenses *string `json:"Licenses,omitempty"`
 	// Labels associated with this image
@@ -132,6 +176,10 @@ type ImageSummary struct {
 	Vulnerabilities *ImageVulnerabilitySummary `json:"Vulnerabilities,omitempty"`
 	// Information about objects that reference this image
 	Referrers []*Referrer `json:"Referrers,omitempty"`
+	// Licenses and package count found in the SBOM attached to the image as a referrer, if there is one
+	Sbom *SBOMSummary `json:"SBOM,omitempty"`
+	// In-toto attestations attached to the image, e.g. with 'cosign attest', these are not listed in SignatureInfo
+	Attestations []*AttestationSummary `json:"Attestations,omitempty"`
 }
 
 // Contains summary of vulnerabilities found in a specific image
@@ -172,6 +220,8 @@ type ManifestSummary struct {
 	IsSigned *bool `json:"IsSigned,omitempty"`
 	// Info about signature validity
 	SignatureInfo []*SignatureSummary `json:"SignatureInfo,omitempty"`
+	// True if the manifest has encrypted layers (ocicrypt), encrypted manifests are not scanned for vulnerabilities
+	IsEncrypted *bool `json:"IsEncrypted,omitempty"`
 	// OS and architecture supported by this image
 	Platform *Platform `json:"Platform,omitempty"`
 	// Total numer of image manifest downloads from this repository
@@ -262,6 +312,26 @@ type Referrer struct {
 	Annotations []*Annotation `json:"Annotations"`
 }
 
+// A referrer found when walking the referrers graph of a subject, e.g. a signature of an SBOM referring an image
+type ReferrerGraphNode struct {
+	// Referrer MediaType
+	// See https://github.com/opencontainers/artifacts for more details
+	MediaType *string `json:"MediaType,omitempty"`
+	// Referrer ArtifactType
+	// See https://github.com/opencontainers/artifacts for more details
+	ArtifactType *string `json:"ArtifactType,omitempty"`
+	// Total size of the referrer files in bytes
+	Size *int `json:"Size,omitempty"`
+	// Digest of the manifest file of the referrer
+	Digest *string `json:"Digest,omitempty"`
+	// A list of annotations associated with this referrer
+	Annotations []*Annotation `json:"Annotations"`
+	// Digest of the manifest this referrer refers
+	Subject *string `json:"Subject,omitempty"`
+	// Distance from the subject of the graph, the direct referrers of the subject have a depth of 1
+	Depth *int `json:"Depth,omitempty"`
+}
+
 // Contains details about the repo: both general information on the repo, and the list of images
 type RepoInfo struct {
 	// List of images in the repo
@@ -295,6 +365,18 @@ type RepoSummary struct {
 	IsStarred *bool `json:"IsStarred,omitempty"`
 }
 
+// Summary of an SBOM (SPDX or CycloneDX JSON) attached to an image as a referrer
+type SBOMSummary struct {
+	// Digest of the SBOM artifact
+	Digest *string `json:"Digest,omitempty"`
+	// Format of the SBOM, either SPDX or CycloneDX
+	Format *string `json:"Format,omitempty"`
+	// SPDX license identifiers of the packages listed in the SBOM, license expressions are split in identifiers
+	Licenses []*string `json:"Licenses,omitempty"`
+	// Number of packages listed in the SBOM
+	PackageCount *int `json:"PackageCount,omitempty"`
+}
+
 // Contains details about the signature
 type SignatureSummary struct {
 	// Tool is the tool used for signing image
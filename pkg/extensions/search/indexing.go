@@ -0,0 +1,51 @@
+package search
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"zotregistry.io/zot/pkg/meta/repodb"
+)
+
+const (
+	// IndexingExtension is the key of the response extensions holding the progress of populating repodb.
+	IndexingExtension = "indexing"
+
+	indexingProgressExtension = "IndexingProgress"
+)
+
+// IndexingProgress adds the progress of populating repodb to the extensions of the responses while
+// repodb is populated in the background, the results may then be missing repos which weren't parsed yet.
+type IndexingProgress struct {
+	Status *repodb.IndexingStatus
+}
+
+var _ interface {
+	graphql.ResponseInterceptor
+	graphql.HandlerExtension
+} = IndexingProgress{}
+
+func (indexing IndexingProgress) ExtensionName() string {
+	return indexingProgressExtension
+}
+
+func (indexing IndexingProgress) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (indexing IndexingProgress) InterceptResponse(ctx context.Context, next graphql.ResponseHandler,
+) *graphql.Response {
+	response := next(ctx)
+	if response == nil || !indexing.Status.InProgress() {
+		return response
+	}
+
+	if response.Extensions == nil {
+		response.Extensions = map[string]interface{}{}
+	}
+
+	response.Extensions[IndexingExtension] = indexing.Status.Progress()
+
+	return response
+}
@@ -0,0 +1,181 @@
+package search
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	gqlHandler "github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/log"
+)
+
+const (
+	DefaultMaxQueryDepth      = 20
+	DefaultMaxQueryComplexity = 2000
+
+	ErrCodeDepthLimit      = "DEPTH_LIMIT_EXCEEDED"
+	ErrCodeResultSizeLimit = "RESULT_SIZE_LIMIT_EXCEEDED"
+
+	depthLimitExtension      = "DepthLimit"
+	resultSizeLimitExtension = "ResultSizeLimit"
+)
+
+// GetQueryLimits returns the limits to be applied to graphQL queries, using defaults for unset values.
+func GetQueryLimits(limitsConfig *extconf.QueryLimitsConfig) extconf.QueryLimitsConfig {
+	limits := extconf.QueryLimitsConfig{
+		MaxDepth:      DefaultMaxQueryDepth,
+		MaxComplexity: DefaultMaxQueryComplexity,
+	}
+
+	if limitsConfig == nil {
+		return limits
+	}
+
+	if limitsConfig.MaxDepth > 0 {
+		limits.MaxDepth = limitsConfig.MaxDepth
+	}
+
+	if limitsConfig.MaxComplexity > 0 {
+		limits.MaxComplexity = limitsConfig.MaxComplexity
+	}
+
+	if limitsConfig.MaxResultSize > 0 {
+		limits.MaxResultSize = limitsConfig.MaxResultSize
+	}
+
+	return limits
+}
+
+// ApplyQueryLimits adds the depth, complexity and result size protections to the graphQL server.
+// Operations exceeding the limits are rejected with an error carrying a code in its extensions,
+// so clients can tell them apart from other failures.
+func ApplyQueryLimits(server *gqlHandler.Server, limitsConfig *extconf.QueryLimitsConfig, log log.Logger) {
+	limits := GetQueryLimits(limitsConfig)
+
+	log.Info().Int("maxDepth", limits.MaxDepth).Int("maxComplexity", limits.MaxComplexity).
+		Int("maxResultSize", limits.MaxResultSize).Msg("applying graphQL query limits")
+
+	server.Use(DepthLimit{MaxDepth: limits.MaxDepth, Log: log})
+	server.Use(extension.FixedComplexityLimit(limits.MaxComplexity))
+
+	if limits.MaxResultSize > 0 {
+		server.Use(ResultSizeLimit{MaxResultSize: limits.MaxResultSize, Log: log})
+	}
+}
+
+// DepthLimit rejects operations in which fields are nested deeper than MaxDepth.
+type DepthLimit struct {
+	MaxDepth int
+	Log      log.Logger
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = DepthLimit{}
+
+func (limit DepthLimit) ExtensionName() string {
+	return depthLimitExtension
+}
+
+func (limit DepthLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (limit DepthLimit) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext,
+) *gqlerror.Error {
+	if opCtx.Operation == nil {
+		return nil
+	}
+
+	depth := selectionSetDepth(opCtx.Operation.SelectionSet)
+	if depth > limit.MaxDepth {
+		limit.Log.Warn().Int("depth", depth).Int("maxDepth", limit.MaxDepth).
+			Msg("graphQL query rejected, depth limit exceeded")
+
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, limit.MaxDepth)
+		err.Extensions = map[string]interface{}{
+			"code":     ErrCodeDepthLimit,
+			"depth":    depth,
+			"maxDepth": limit.MaxDepth,
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func selectionSetDepth(selectionSet ast.SelectionSet) int {
+	maxDepth := 0
+
+	for _, selection := range selectionSet {
+		depth := 0
+
+		switch typedSelection := selection.(type) {
+		case *ast.Field:
+			depth = 1 + selectionSetDepth(typedSelection.SelectionSet)
+		case *ast.InlineFragment:
+			depth = selectionSetDepth(typedSelection.SelectionSet)
+		case *ast.FragmentSpread:
+			// cycles between fragments are rejected when the query is validated
+			if typedSelection.Definition != nil {
+				depth = selectionSetDepth(typedSelection.Definition.SelectionSet)
+			}
+		}
+
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+	}
+
+	return maxDepth
+}
+
+// ResultSizeLimit replaces responses larger than MaxResultSize bytes with an error,
+// clients are expected to request smaller pages or fewer fields.
+type ResultSizeLimit struct {
+	MaxResultSize int
+	Log           log.Logger
+}
+
+var _ interface {
+	graphql.ResponseInterceptor
+	graphql.HandlerExtension
+} = ResultSizeLimit{}
+
+func (limit ResultSizeLimit) ExtensionName() string {
+	return resultSizeLimitExtension
+}
+
+func (limit ResultSizeLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (limit ResultSizeLimit) InterceptResponse(ctx context.Context, next graphql.ResponseHandler,
+) *graphql.Response {
+	response := next(ctx)
+	if response == nil || len(response.Data) <= limit.MaxResultSize {
+		return response
+	}
+
+	limit.Log.Warn().Int("size", len(response.Data)).Int("maxResultSize", limit.MaxResultSize).
+		Msg("graphQL response dropped, result size limit exceeded")
+
+	err := gqlerror.Errorf("result has size %d bytes, which exceeds the limit of %d bytes",
+		len(response.Data), limit.MaxResultSize)
+	err.Extensions = map[string]interface{}{
+		"code":          ErrCodeResultSizeLimit,
+		"size":          len(response.Data),
+		"maxResultSize": limit.MaxResultSize,
+	}
+
+	return &graphql.Response{
+		Errors:     gqlerror.List{err},
+		Extensions: response.Extensions,
+	}
+}
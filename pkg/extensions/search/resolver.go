@@ -24,6 +24,7 @@ import (
 	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
 	"zotregistry.io/zot/pkg/extensions/search/gql_generated"
 	"zotregistry.io/zot/pkg/log"
+	metaCommon "zotregistry.io/zot/pkg/meta/common"
 	"zotregistry.io/zot/pkg/meta/repodb"
 	localCtx "zotregistry.io/zot/pkg/requestcontext"
 	"zotregistry.io/zot/pkg/storage"
@@ -32,7 +33,8 @@ import (
 // THIS CODE IS A STARTING POINT ONLY. IT WILL NOT BE UPDATED WITH SCHEMA CHANGES.
 
 const (
-	querySizeLimit = 256
+	querySizeLimit         = 256
+	maxReferrersGraphDepth = 10
 )
 
 // Resolver ...
@@ -181,7 +183,7 @@ func getImageSummary(ctx context.Context, repo, tag string, digest *string, repo
 	)
 
 	switch manifestDescriptor.MediaType {
-	case ispec.MediaTypeImageManifest:
+	case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 		manifestDigest := manifestDescriptor.Digest
 
 		if digest != nil && *digest != manifestDigest {
@@ -198,7 +200,7 @@ func getImageSummary(ctx context.Context, repo, tag string, digest *string, repo
 			ManifestBlob: manifestData.ManifestBlob,
 			ConfigBlob:   manifestData.ConfigBlob,
 		}
-	case ispec.MediaTypeImageIndex:
+	case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 		indexDigest := manifestDescriptor.Digest
 
 		indexData, err := repoDB.GetIndexData(godigest.Digest(indexDigest))
@@ -335,15 +337,24 @@ func getCVEListForImage(
 			)
 		}
 
-		cveids = append(cveids,
-			&gql_generated.Cve{
-				ID:          &vulID,
-				Title:       &title,
-				Description: &desc,
-				Severity:    &severity,
-				PackageList: pkgList,
-			},
-		)
+		cve := &gql_generated.Cve{
+			ID:          &vulID,
+			Title:       &title,
+			Description: &desc,
+			Severity:    &severity,
+			PackageList: pkgList,
+		}
+
+		acknowledged := cveDetail.Acknowledgement != nil
+		cve.IsAcknowledged = &acknowledged
+
+		if acknowledged {
+			cve.AcknowledgementReason = &cveDetail.Acknowledgement.Reason
+			cve.AcknowledgedBy = &cveDetail.Acknowledgement.AcknowledgedBy
+			cve.AcknowledgementExpiry = cveDetail.Acknowledgement.ExpiresAt
+		}
+
+		cveids = append(cveids, cve)
 	}
 
 	return &gql_generated.CVEResultForImage{
@@ -362,11 +373,11 @@ func FilterByTagInfo(tagsInfo []cvemodel.TagInfo) repodb.FilterFunc {
 
 		for _, tagInfo := range tagsInfo {
 			switch tagInfo.Descriptor.MediaType {
-			case ispec.MediaTypeImageManifest:
+			case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 				if tagInfo.Descriptor.Digest.String() == manifestDigest {
 					return true
 				}
-			case ispec.MediaTypeImageIndex:
+			case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 				for _, manifestDesc := range tagInfo.Manifests {
 					if manifestDesc.Digest.String() == manifestDigest {
 						return true
@@ -669,11 +680,15 @@ func globalSearch(ctx context.Context, query string, repoDB repodb.RepoDB, filte
 	localFilter := repodb.Filter{}
 	if filter != nil {
 		localFilter = repodb.Filter{
-			Os:            filter.Os,
-			Arch:          filter.Arch,
-			HasToBeSigned: filter.HasToBeSigned,
-			IsBookmarked:  filter.IsBookmarked,
-			IsStarred:     filter.IsStarred,
+			Os:                  filter.Os,
+			Arch:                filter.Arch,
+			HasToBeSigned:       filter.HasToBeSigned,
+			IsBookmarked:        filter.IsBookmarked,
+			IsStarred:           filter.IsStarred,
+			RegistryAnnotations: filter.RegistryAnnotations,
+			MaxCVESeverity:      filter.MaxCVESeverity,
+			LastUpdatedAfter:    filter.LastUpdatedAfter,
+			ExcludedLicenses:    filter.ExcludedLicenses,
 		}
 	}
 
@@ -1014,6 +1029,18 @@ func checkFilter(filter *gql_generated.Filter) error {
 		}
 	}
 
+	for _, license := range filter.ExcludedLicenses {
+		if license != nil && len(*license) > querySizeLimit {
+			return fmt.Errorf("global-search: max string size limit exeeded for excluded licenses parameter. "+
+				"max=%d current=%d %w", querySizeLimit, len(*license), zerr.ErrInvalidRequestParams)
+		}
+	}
+
+	if filter.MaxCVESeverity != nil && metaCommon.GetCVESeverityRank(strings.TrimSpace(*filter.MaxCVESeverity)) < 0 {
+		return fmt.Errorf("global-search: unknown CVE severity '%s' for max CVE severity parameter %w",
+			*filter.MaxCVESeverity, zerr.ErrInvalidRequestParams)
+	}
+
 	return nil
 }
 
@@ -1066,6 +1093,10 @@ func cleanFilter(filter *gql_generated.Filter) *gql_generated.Filter {
 		filter.Os = deleteEmptyElements(filter.Os)
 	}
 
+	if filter.MaxCVESeverity != nil {
+		*filter.MaxCVESeverity = strings.ToUpper(strings.TrimSpace(*filter.MaxCVESeverity))
+	}
+
 	return filter
 }
 
@@ -1115,7 +1146,7 @@ func expandedRepoInfo(ctx context.Context, repo string, repoDB repodb.RepoDB, cv
 
 	for tag, descriptor := range repoMeta.Tags {
 		switch descriptor.MediaType {
-		case ispec.MediaTypeImageManifest:
+		case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 			digest := descriptor.Digest
 
 			if _, alreadyDownloaded := manifestMetaMap[digest]; alreadyDownloaded {
@@ -1134,7 +1165,7 @@ func expandedRepoInfo(ctx context.Context, repo string, repoDB repodb.RepoDB, cv
 				ManifestBlob: manifestData.ManifestBlob,
 				ConfigBlob:   manifestData.ConfigBlob,
 			}
-		case ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 			digest := descriptor.Digest
 
 			if _, alreadyDownloaded := indexDataMap[digest]; alreadyDownloaded {
@@ -1314,3 +1345,69 @@ func getReferrers(repoDB repodb.RepoDB, repo string, referredDigest string, arti
 
 	return results, nil
 }
+
+// getReferrersGraph walks the referrers of the subject breadth first, up to maxDepth levels.
+// Each referrer is returned once, with the depth it was first found at, so cycles don't loop.
+func getReferrersGraph(repoDB repodb.RepoDB, repo string, subjectDigest string, maxDepth *int,
+	log log.Logger,
+) ([]*gql_generated.ReferrerGraphNode, error) {
+	depthLimit := maxReferrersGraphDepth
+
+	if maxDepth != nil {
+		if *maxDepth < 1 || *maxDepth > maxReferrersGraphDepth {
+			return []*gql_generated.ReferrerGraphNode{}, fmt.Errorf("graphql: maxDepth must be between 1 and %d %w",
+				maxReferrersGraphDepth, zerr.ErrInvalidRequestParams)
+		}
+
+		depthLimit = *maxDepth
+	}
+
+	subject := godigest.Digest(subjectDigest)
+	if err := subject.Validate(); err != nil {
+		log.Error().Err(err).Str("digest", subjectDigest).Msg("graphql: bad referenced digest string from request")
+
+		return []*gql_generated.ReferrerGraphNode{}, fmt.Errorf("graphql: bad digest string from request '%s' %w",
+			subjectDigest, err)
+	}
+
+	// the whole graph is read from the same repo metadata
+	repoMeta, err := repoDB.GetRepoMeta(repo)
+	if err != nil {
+		return []*gql_generated.ReferrerGraphNode{}, err
+	}
+
+	visited := map[string]bool{subject.String(): true}
+	subjects := []string{subject.String()}
+	results := []*gql_generated.ReferrerGraphNode{}
+
+	for depth := 1; depth <= depthLimit && len(subjects) > 0; depth++ {
+		nextSubjects := []string{}
+
+		for _, referredDigest := range subjects {
+			for _, referrer := range repoMeta.Referrers[referredDigest] {
+				if visited[referrer.Digest] {
+					continue
+				}
+
+				visited[referrer.Digest] = true
+				nextSubjects = append(nextSubjects, referrer.Digest)
+
+				referrer, referredDigest, depth := referrer, referredDigest, depth
+
+				results = append(results, &gql_generated.ReferrerGraphNode{
+					MediaType:    &referrer.MediaType,
+					ArtifactType: &referrer.ArtifactType,
+					Digest:       &referrer.Digest,
+					Size:         &referrer.Size,
+					Annotations:  convert.StringMap2Annotations(referrer.Annotations),
+					Subject:      &referredDigest,
+					Depth:        &depth,
+				})
+			}
+		}
+
+		subjects = nextSubjects
+	}
+
+	return results, nil
+}
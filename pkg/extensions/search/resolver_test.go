@@ -15,6 +15,7 @@ import (
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	. "github.com/smartystreets/goconvey/convey"
 
+	zerr "zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/common"
 	cveinfo "zotregistry.io/zot/pkg/extensions/search/cve"
 	cvemodel "zotregistry.io/zot/pkg/extensions/search/cve/model"
@@ -1484,6 +1485,78 @@ func TestGetReferrers(t *testing.T) {
 	})
 }
 
+func TestGetReferrersGraph(t *testing.T) {
+	Convey("getReferrersGraph", t, func() {
+		testLogger := log.NewLogger("debug", "")
+		imageDigest := godigest.FromString("image").String()
+		sbomDigest := godigest.FromString("sbom").String()
+		signatureDigest := godigest.FromString("signature").String()
+		attestationDigest := godigest.FromString("attestation").String()
+
+		// the attestation refers the signature of the SBOM and is referred back by it, which makes a cycle
+		mockedStore := mocks.RepoDBMock{
+			GetRepoMetaFn: func(repo string) (repodb.RepoMetadata, error) {
+				return repodb.RepoMetadata{
+					Name: repo,
+					Referrers: map[string][]repodb.ReferrerInfo{
+						imageDigest:       {{Digest: sbomDigest, ArtifactType: "application/spdx+json"}},
+						sbomDigest:        {{Digest: signatureDigest, ArtifactType: "application/vnd.dev.cosign"}},
+						signatureDigest:   {{Digest: attestationDigest, ArtifactType: "application/vnd.in-toto+json"}},
+						attestationDigest: {{Digest: signatureDigest, ArtifactType: "application/vnd.dev.cosign"}},
+					},
+				}, nil
+			},
+		}
+
+		Convey("Whole graph", func() {
+			referrers, err := getReferrersGraph(mockedStore, "test", imageDigest, nil, testLogger)
+			So(err, ShouldBeNil)
+			So(len(referrers), ShouldEqual, 3)
+			So(*referrers[0].Digest, ShouldEqual, sbomDigest)
+			So(*referrers[0].Subject, ShouldEqual, imageDigest)
+			So(*referrers[0].Depth, ShouldEqual, 1)
+			So(*referrers[1].Digest, ShouldEqual, signatureDigest)
+			So(*referrers[1].Subject, ShouldEqual, sbomDigest)
+			So(*referrers[1].Depth, ShouldEqual, 2)
+			So(*referrers[2].Digest, ShouldEqual, attestationDigest)
+			So(*referrers[2].Subject, ShouldEqual, signatureDigest)
+			So(*referrers[2].Depth, ShouldEqual, 3)
+		})
+
+		Convey("Limited depth", func() {
+			maxDepth := 2
+
+			referrers, err := getReferrersGraph(mockedStore, "test", imageDigest, &maxDepth, testLogger)
+			So(err, ShouldBeNil)
+			So(len(referrers), ShouldEqual, 2)
+
+			maxDepth = 0
+
+			_, err = getReferrersGraph(mockedStore, "test", imageDigest, &maxDepth, testLogger)
+			So(errors.Is(err, zerr.ErrInvalidRequestParams), ShouldBeTrue)
+
+			maxDepth = maxReferrersGraphDepth + 1
+
+			_, err = getReferrersGraph(mockedStore, "test", imageDigest, &maxDepth, testLogger)
+			So(errors.Is(err, zerr.ErrInvalidRequestParams), ShouldBeTrue)
+		})
+
+		Convey("Bad digest", func() {
+			_, err := getReferrersGraph(mockedStore, "test", "bad digest", nil, testLogger)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("GetRepoMeta returns error", func() {
+			_, err := getReferrersGraph(mocks.RepoDBMock{
+				GetRepoMetaFn: func(repo string) (repodb.RepoMetadata, error) {
+					return repodb.RepoMetadata{}, ErrTestError
+				},
+			}, "test", imageDigest, nil, testLogger)
+			So(err, ShouldEqual, ErrTestError)
+		})
+	})
+}
+
 func TestQueryResolverErrors(t *testing.T) {
 	Convey("Errors", t, func() {
 		log := log.NewLogger("debug", "")
@@ -3407,3 +3480,47 @@ func TestFilterFunctions(t *testing.T) {
 		})
 	})
 }
+
+func TestIndexingProgress(t *testing.T) {
+	Convey("Responses carry the progress of populating repodb", t, func() {
+		unblock := make(chan struct{})
+
+		imageStore := mocks.MockedImageStore{
+			GetIndexContentFn: func(repo string) ([]byte, error) {
+				<-unblock
+
+				return nil, ErrTestError
+			},
+			GetRepositoriesFn: func() ([]string, error) {
+				return []string{"repo1"}, nil
+			},
+		}
+		storeController := storage.StoreController{DefaultStore: imageStore}
+
+		status := repodb.ParseStorageInBackground(context.Background(), mocks.RepoDBMock{}, storeController,
+			log.NewLogger("debug", ""))
+
+		indexing := IndexingProgress{Status: status}
+		next := func(ctx context.Context) *graphql.Response {
+			return &graphql.Response{Data: []byte("{}")}
+		}
+
+		response := indexing.InterceptResponse(context.Background(), next)
+		So(response.Extensions, ShouldContainKey, IndexingExtension)
+
+		progress, ok := response.Extensions[IndexingExtension].(repodb.IndexingProgress)
+		So(ok, ShouldBeTrue)
+		So(progress.InProgress, ShouldBeTrue)
+
+		close(unblock)
+		status.Wait()
+
+		response = indexing.InterceptResponse(context.Background(), next)
+		So(response.Extensions, ShouldNotContainKey, IndexingExtension)
+
+		response = indexing.InterceptResponse(context.Background(), func(ctx context.Context) *graphql.Response {
+			return nil
+		})
+		So(response, ShouldBeNil)
+	})
+}
@@ -144,6 +144,18 @@ func (r *queryResolver) Referrers(ctx context.Context, repo string, digest strin
 	return referrers, nil
 }
 
+// ReferrersGraph is the resolver for the ReferrersGraph field.
+func (r *queryResolver) ReferrersGraph(ctx context.Context, repo string, digest string, maxDepth *int) ([]*gql_generated.ReferrerGraphNode, error) {
+	referrers, err := getReferrersGraph(r.repoDB, repo, digest, maxDepth, r.log)
+	if err != nil {
+		r.log.Error().Err(err).Msg("unable to get referrers graph from default store")
+
+		return []*gql_generated.ReferrerGraphNode{}, err
+	}
+
+	return referrers, nil
+}
+
 // StarredRepos is the resolver for the StarredRepos field.
 func (r *queryResolver) StarredRepos(ctx context.Context, requestedPage *gql_generated.PageInput) (*gql_generated.PaginatedReposResult, error) {
 	return getStarredRepos(ctx, r.cveInfo, r.log, requestedPage, r.repoDB)
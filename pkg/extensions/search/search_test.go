@@ -15,6 +15,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -5431,6 +5432,348 @@ func TestRepoDBWhenReadingImages(t *testing.T) {
 	})
 }
 
+func TestRepoDBDownloadsFilter(t *testing.T) {
+	Convey("Pulls filtered from the download counts", t, func() {
+		dir := t.TempDir()
+
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = dir
+		conf.HTTP.TrustedProxies = []string{"127.0.0.0/8", "10.0.0.0/8"}
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+				Downloads: &extconf.DownloadsConfig{
+					DedupWindow:       time.Hour,
+					IgnoredUserAgents: []string{"kube-probe/*"},
+				},
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		config1, layers1, manifest1, err := GetImageComponents(100)
+		So(err, ShouldBeNil)
+
+		err = UploadImage(Image{Manifest: manifest1, Config: config1, Layers: layers1, Reference: "1.0.1"},
+			baseURL, "repo1")
+		So(err, ShouldBeNil)
+
+		getDownloadCount := func(digest string) int {
+			repoMeta, err := ctlr.RepoDB.GetRepoMeta("repo1")
+			So(err, ShouldBeNil)
+
+			return repoMeta.Statistics[digest].DownloadCount
+		}
+
+		resp, err := resty.R().SetHeader("User-Agent", "kube-probe/1.27").Get(baseURL + "/v2/repo1/manifests/1.0.1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		digest := resp.Header().Get(constants.DistContentDigestKey)
+		So(getDownloadCount(digest), ShouldEqual, 0)
+
+		// pulls by tag and by digest from the same client are counted once
+		for _, reference := range []string{"1.0.1", digest, "1.0.1"} {
+			resp, err = resty.R().Get(baseURL + "/v2/repo1/manifests/" + reference)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		}
+
+		So(getDownloadCount(digest), ShouldEqual, 1)
+
+		// HEAD requests are never counted
+		resp, err = resty.R().Head(baseURL + "/v2/repo1/manifests/1.0.1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(getDownloadCount(digest), ShouldEqual, 1)
+
+		Convey("Clients behind trusted proxies", func() {
+			pull := func(forwardedFor string) {
+				resp, err := resty.R().SetHeader("X-Forwarded-For", forwardedFor).
+					Get(baseURL + "/v2/repo1/manifests/1.0.1")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			}
+
+			pull("203.0.113.1, 10.0.0.2")
+			So(getDownloadCount(digest), ShouldEqual, 2)
+
+			// the hops added by the client on the left of the last untrusted hop are ignored
+			pull("198.51.100.7, 203.0.113.1, 10.0.0.3")
+			So(getDownloadCount(digest), ShouldEqual, 2)
+
+			pull("203.0.113.1, 198.51.100.7")
+			So(getDownloadCount(digest), ShouldEqual, 3)
+		})
+
+		Convey("Reload without dedup window", func() {
+			newConf := *conf
+			newConf.Extensions = &extconf.ExtensionConfig{
+				Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+			}
+
+			ctlr.LoadNewConfig(context.Background(), &newConf)
+
+			for i := 0; i < 2; i++ {
+				resp, err = resty.R().Get(baseURL + "/v2/repo1/manifests/1.0.1")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			}
+
+			So(getDownloadCount(digest), ShouldEqual, 3)
+		})
+	})
+}
+
+func TestFreshnessHeaders(t *testing.T) {
+	Convey("Freshness of the images returned in manifest GETs", t, func() {
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}, FreshnessHeaders: true},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		uploadImage := func(repo, tag string, age time.Duration, annotations map[string]string) string {
+			created := time.Now().Add(-age)
+
+			img, err := GetImageWithConfig(ispec.Image{Created: &created})
+			So(err, ShouldBeNil)
+
+			img.Reference = tag
+			img.Manifest.Annotations = annotations
+
+			err = UploadImage(img, baseURL, repo)
+			So(err, ShouldBeNil)
+
+			resp, err := resty.R().Head(baseURL + "/v2/" + repo + "/manifests/" + tag)
+			So(err, ShouldBeNil)
+
+			return resp.Header().Get(constants.DistContentDigestKey)
+		}
+
+		day := 24 * time.Hour
+
+		baseDigest := uploadImage("base", "3.18", 30*day, nil)
+		appDigest := uploadImage("app", "1.0", 5*day+time.Hour, map[string]string{
+			ispec.AnnotationBaseImageName:   "localhost:5000/base:3.18",
+			ispec.AnnotationBaseImageDigest: baseDigest,
+		})
+
+		resp, err := resty.R().Get(baseURL + "/v2/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(constants.ImageCreatedHeader), ShouldNotBeEmpty)
+		So(resp.Header().Get(constants.ImageAgeDaysHeader), ShouldEqual, "5")
+		So(resp.Header().Get(constants.LastScannedHeader), ShouldBeEmpty)
+		So(resp.Header().Get(constants.DaysSinceBaseUpdateHeader), ShouldEqual, "0")
+
+		scannedAt := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+
+		err = ctlr.RepoDB.SetVulnerabilitySummary("app", godigest.Digest(appDigest),
+			repodb.VulnerabilitySummary{UpdatedAt: scannedAt})
+		So(err, ShouldBeNil)
+
+		// the base image tag now points to a newer image
+		uploadImage("base", "3.18", 10*day+time.Hour, nil)
+
+		resp, err = resty.R().Get(baseURL + "/v2/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(constants.LastScannedHeader), ShouldEqual, "2023-06-01T10:00:00Z")
+		So(resp.Header().Get(constants.DaysSinceBaseUpdateHeader), ShouldEqual, "10")
+
+		// the base image isn't stored in the registry
+		uploadImage("other", "1.0", day, map[string]string{
+			ispec.AnnotationBaseImageName:   "docker.io/library/alpine:3.18",
+			ispec.AnnotationBaseImageDigest: baseDigest,
+		})
+
+		resp, err = resty.R().Get(baseURL + "/v2/other/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.Header().Get(constants.ImageAgeDaysHeader), ShouldEqual, "1")
+		So(resp.Header().Get(constants.DaysSinceBaseUpdateHeader), ShouldBeEmpty)
+
+		conf.Extensions.Search.FreshnessHeaders = false
+
+		resp, err = resty.R().Get(baseURL + "/v2/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(constants.ImageCreatedHeader), ShouldBeEmpty)
+	})
+}
+
+func TestRepoDBAsyncUpdates(t *testing.T) {
+	Convey("Repodb updated from the events queue", t, func() {
+		dir := t.TempDir()
+
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = dir
+		conf.Storage.AsyncRepoDBUpdates = true
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		So(ctlr.MetaEvents, ShouldNotBeNil)
+
+		ctlr.MetaEvents.MaxAttempts = 3
+		ctlr.MetaEvents.MinBackoff = time.Millisecond
+		ctlr.MetaEvents.MaxBackoff = 5 * time.Millisecond
+
+		waitForQueue := func() {
+			for i := 0; i < 100 && ctlr.MetaEvents.Len() > 0; i++ {
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			So(ctlr.MetaEvents.Len(), ShouldEqual, 0)
+		}
+
+		config1, layers1, manifest1, err := GetImageComponents(100)
+		So(err, ShouldBeNil)
+
+		err = UploadImage(Image{Manifest: manifest1, Config: config1, Layers: layers1, Reference: "1.0.1"},
+			baseURL, "repo1")
+		So(err, ShouldBeNil)
+
+		waitForQueue()
+
+		repoMeta, err := ctlr.RepoDB.GetRepoMeta("repo1")
+		So(err, ShouldBeNil)
+		So(repoMeta.Tags, ShouldContainKey, "1.0.1")
+
+		Convey("Requests succeed while repodb is failing", func() {
+			var calls atomic.Int32
+
+			ctlr.RepoDB = mocks.RepoDBMock{
+				IncrementImageDownloadsFn: func(repo string, tag string) error {
+					calls.Add(1)
+
+					return ErrTestError
+				},
+				SetManifestDataFn: func(manifestDigest godigest.Digest, mm repodb.ManifestData) error {
+					calls.Add(1)
+
+					return ErrTestError
+				},
+			}
+
+			resp, err := resty.R().Get(baseURL + "/v2/repo1/manifests/1.0.1")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			config2, layers2, manifest2, err := GetImageComponents(200)
+			So(err, ShouldBeNil)
+
+			err = UploadImage(Image{Manifest: manifest2, Config: config2, Layers: layers2, Reference: "1.0.2"},
+				baseURL, "repo1")
+			So(err, ShouldBeNil)
+
+			// the image store isn't rolled back when the update fails
+			resp, err = resty.R().Head(baseURL + "/v2/repo1/manifests/1.0.2")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			// both updates are retried before being dropped
+			waitForQueue()
+			So(calls.Load(), ShouldEqual, 6)
+		})
+	})
+}
+
+func TestRepoDBLazyPopulation(t *testing.T) {
+	Convey("Repodb populated in the background", t, func() {
+		dir := t.TempDir()
+
+		imageStore := local.NewImageStore(dir, false, 0, false, false,
+			log.NewLogger("debug", ""), monitoring.NewMetricsServer(false, log.NewLogger("debug", "")), nil, nil)
+		storeController := storage.StoreController{DefaultStore: imageStore}
+
+		config1, layers1, manifest1, err := GetImageComponents(100)
+		So(err, ShouldBeNil)
+
+		err = WriteImageToFileSystem(Image{Manifest: manifest1, Config: config1, Layers: layers1, Reference: "1.0.1"},
+			"repo1", storeController)
+		So(err, ShouldBeNil)
+
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = dir
+		conf.Storage.LazyRepoDBPopulation = true
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		So(ctlr.RepoDBIndexing, ShouldNotBeNil)
+
+		resp, err := resty.R().Get(baseURL + "/v2/repo1/manifests/1.0.1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		ctlr.RepoDBIndexing.Wait()
+
+		progress := ctlr.RepoDBIndexing.Progress()
+		So(progress.Repos, ShouldEqual, 1)
+		So(progress.ParsedRepos, ShouldEqual, 1)
+
+		query := `
+			{
+				GlobalSearch(query:"repo1:1.0"){
+					Images {
+						RepoName Tag
+					}
+				}
+			}`
+
+		resp, err = resty.R().Get(baseURL + graphqlQueryPrefix + "?query=" + url.QueryEscape(query))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(string(resp.Body()), ShouldNotContainSubstring, `"indexing"`)
+
+		responseStruct := &zcommon.GlobalSearchResultResp{}
+
+		err = json.Unmarshal(resp.Body(), responseStruct)
+		So(err, ShouldBeNil)
+		So(responseStruct.Images, ShouldNotBeEmpty)
+		So(responseStruct.Images[0].Tag, ShouldEqual, "1.0.1")
+	})
+}
+
 func TestRepoDBWhenDeletingImages(t *testing.T) {
 	Convey("Setting up zot repo with test images", t, func() {
 		dir := t.TempDir()
@@ -6592,3 +6935,101 @@ func TestImageSummary(t *testing.T) {
 		}
 	})
 }
+
+func TestQueryLimits(t *testing.T) {
+	Convey("Test graphQL query limits", t, func() {
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+				Limits: &extconf.QueryLimitsConfig{
+					MaxDepth:      3,
+					MaxComplexity: 10,
+					MaxResultSize: 200,
+				},
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		config, layers, manifest, err := GetImageComponents(100)
+		So(err, ShouldBeNil)
+
+		for i := 0; i < 3; i++ {
+			err = PushTestImage(fmt.Sprintf("repo%d", i), "0.0.1", baseURL, manifest, config, layers)
+			So(err, ShouldBeNil)
+		}
+
+		type limitError struct {
+			Message    string                 `json:"message"`
+			Extensions map[string]interface{} `json:"extensions"`
+		}
+
+		type limitResponse struct {
+			Errors []limitError     `json:"errors"`
+			Data   *json.RawMessage `json:"data"`
+		}
+
+		runQuery := func(query string) limitResponse {
+			resp, err := resty.R().Get(baseURL + graphqlQueryPrefix + "?query=" + url.QueryEscape(query))
+			So(err, ShouldBeNil)
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			var response limitResponse
+
+			err = json.Unmarshal(resp.Body(), &response)
+			So(err, ShouldBeNil)
+
+			return response
+		}
+
+		Convey("Query within limits", func() {
+			response := runQuery(`{ ImageList(repo: "repo1") { Results { Tag } } }`)
+			So(response.Errors, ShouldBeEmpty)
+			So(response.Data, ShouldNotBeNil)
+		})
+
+		Convey("Query exceeding the depth limit", func() {
+			response := runQuery(`{ ImageList(repo: "repo1") { Results { Manifests { Digest } } } }`)
+			So(len(response.Errors), ShouldEqual, 1)
+			So(response.Errors[0].Extensions["code"], ShouldEqual, "DEPTH_LIMIT_EXCEEDED")
+			So(response.Errors[0].Extensions["maxDepth"], ShouldEqual, float64(3))
+			So(response.Errors[0].Extensions["depth"], ShouldEqual, float64(4))
+		})
+
+		Convey("Query exceeding the depth limit using fragments", func() {
+			response := runQuery(`{ ImageList(repo: "repo1") { Results { ...Image } } }
+				fragment Image on ImageSummary { Manifests { ... on ManifestSummary { Digest } } }`)
+			So(len(response.Errors), ShouldEqual, 1)
+			So(response.Errors[0].Extensions["code"], ShouldEqual, "DEPTH_LIMIT_EXCEEDED")
+		})
+
+		Convey("Query exceeding the complexity limit", func() {
+			response := runQuery(`{
+				a: ImageList(repo: "repo1") { Results { Tag } }
+				b: ImageList(repo: "repo1") { Results { Tag } }
+				c: ImageList(repo: "repo1") { Results { Tag } }
+				d: ImageList(repo: "repo1") { Results { Tag } }
+			}`)
+			So(len(response.Errors), ShouldEqual, 1)
+			So(response.Errors[0].Extensions["code"], ShouldEqual, "COMPLEXITY_LIMIT_EXCEEDED")
+		})
+
+		Convey("Query exceeding the result size limit", func() {
+			response := runQuery(`{ GlobalSearch(query: "repo") { Repos { Name LastUpdated Size Vendors } } }`)
+			So(len(response.Errors), ShouldEqual, 1)
+			So(response.Errors[0].Extensions["code"], ShouldEqual, "RESULT_SIZE_LIMIT_EXCEEDED")
+			So(response.Data, ShouldBeNil)
+		})
+	})
+}
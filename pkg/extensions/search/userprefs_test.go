@@ -18,9 +18,11 @@ import (
 	"zotregistry.io/zot/pkg/api/config"
 	"zotregistry.io/zot/pkg/api/constants"
 	"zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions"
 	extconf "zotregistry.io/zot/pkg/extensions/config"
 	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
 	"zotregistry.io/zot/pkg/storage"
 	"zotregistry.io/zot/pkg/storage/local"
 	. "zotregistry.io/zot/pkg/test"
@@ -1016,3 +1018,117 @@ func getCredString(username, password string) string {
 
 	return usernameAndHash
 }
+
+func TestUserActivity(t *testing.T) {
+	Convey("Test user activity", t, func(c C) {
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		defaultVal := true
+
+		repo := "repo"
+		tag := "0.0.1"
+
+		firstUser := "alice"
+		firstUserPassword := "deepGoesTheRabbitBurrow"
+		secondUser := "test"
+		secondUserPassword := "test123"
+
+		twoCredTests := fmt.Sprintf("%s\n%s\n\n", getCredString(firstUser, firstUserPassword),
+			getCredString(secondUser, secondUserPassword))
+
+		htpasswdPath := MakeHtpasswdFileFromString(twoCredTests)
+		defer os.Remove(htpasswdPath)
+
+		conf := config.New()
+		conf.Storage.RootDirectory = t.TempDir()
+		conf.HTTP.Port = port
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+			UserActivity: &extconf.UserActivityConfig{
+				BaseConfig:      extconf.BaseConfig{Enable: &defaultVal},
+				MaxEntries:      3,
+				RecordUserAgent: true,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		config, layers, manifest, err := GetImageComponents(100)
+		So(err, ShouldBeNil)
+
+		err = UploadImageWithBasicAuth(
+			Image{
+				Config:    config,
+				Layers:    layers,
+				Manifest:  manifest,
+				Reference: tag,
+			}, baseURL, repo,
+			firstUser, firstUserPassword,
+		)
+		So(err, ShouldBeNil)
+
+		activityURL := baseURL + constants.FullUserActivityPrefix
+
+		getActivity := func(user, password string) []repodb.UserActivity {
+			resp, err := resty.R().SetBasicAuth(user, password).Get(activityURL)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			var activityList extensions.UserActivityList
+
+			err = json.Unmarshal(resp.Body(), &activityList)
+			So(err, ShouldBeNil)
+
+			return activityList.Activity
+		}
+
+		activity := getActivity(firstUser, firstUserPassword)
+		So(len(activity), ShouldEqual, 1)
+		So(activity[0].Action, ShouldEqual, extensions.UserActivityPush)
+		So(activity[0].Repo, ShouldEqual, repo)
+		So(activity[0].Reference, ShouldEqual, tag)
+		So(activity[0].UserAgent, ShouldNotBeEmpty)
+		So(activity[0].ClientAddress, ShouldBeEmpty)
+
+		So(getActivity(secondUser, secondUserPassword), ShouldBeEmpty)
+
+		for i := 0; i < 3; i++ {
+			resp, err := resty.R().SetBasicAuth(secondUser, secondUserPassword).
+				Get(baseURL + "/v2/" + repo + "/manifests/" + tag)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		}
+
+		resp, err := resty.R().SetBasicAuth(firstUser, firstUserPassword).
+			Delete(baseURL + "/v2/" + repo + "/manifests/" + tag)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		// each user only sees their own actions, only the newest maxEntries actions are kept
+		activity = getActivity(firstUser, firstUserPassword)
+		So(len(activity), ShouldEqual, 2)
+		So(activity[0].Action, ShouldEqual, extensions.UserActivityDelete)
+		So(activity[1].Action, ShouldEqual, extensions.UserActivityPush)
+
+		activity = getActivity(secondUser, secondUserPassword)
+		So(len(activity), ShouldEqual, 3)
+
+		for _, action := range activity {
+			So(action.Action, ShouldEqual, extensions.UserActivityPull)
+		}
+
+		// anonymous users are not allowed
+		resp, err = resty.R().Get(activityURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+	})
+}
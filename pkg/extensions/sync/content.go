@@ -71,6 +71,49 @@ func (cm ContentManager) FilterTags(repo string, tags []string) ([]string, error
 	return tags, nil
 }
 
+// isAllowedOnDemand returns whether a local repo may be synced on demand, it isn't if it matches a deny rule
+// or if there are allow rules and it matches none of them.
+func isAllowedOnDemand(rules *syncconf.OnDemandRules, repo string) bool {
+	if rules == nil {
+		return true
+	}
+
+	for _, pattern := range rules.Deny {
+		if matched, _ := glob.Match(pattern, repo); matched {
+			return false
+		}
+	}
+
+	if len(rules.Allow) == 0 {
+		return true
+	}
+
+	for _, pattern := range rules.Allow {
+		if matched, _ := glob.Match(pattern, repo); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsReferrersOnly returns whether only the referrers of the images of an upstream repo are synced.
+func (cm ContentManager) IsReferrersOnly(repo string) bool {
+	content := cm.getContentByUpstreamRepo(repo)
+
+	return content != nil && content.ReferrersOnly
+}
+
+// GetPrefetch returns the prefetch rule of the images of an upstream repo, nil if they aren't prefetched.
+func (cm ContentManager) GetPrefetch(repo string) *syncconf.Prefetch {
+	content := cm.getContentByUpstreamRepo(repo)
+	if content == nil {
+		return nil
+	}
+
+	return content.Prefetch
+}
+
 /*
 GetRepoDestination applies content destination config rule and returns the final repo namespace.
 - used by periodically sync.
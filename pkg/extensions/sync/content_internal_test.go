@@ -87,6 +87,16 @@ func TestContentManager(t *testing.T) {
 		cm := NewContentManager([]syncconf.Content{content}, log.Logger{})
 		So(cm.MatchesContent("repo"), ShouldEqual, false)
 	})
+
+	Convey("Test IsReferrersOnly()", t, func() {
+		cm := NewContentManager([]syncconf.Content{
+			{Prefix: "mirrored/**", ReferrersOnly: true},
+			{Prefix: "zot-fold/**"},
+		}, log.Logger{})
+		So(cm.IsReferrersOnly("mirrored/alpine"), ShouldBeTrue)
+		So(cm.IsReferrersOnly("zot-fold/alpine"), ShouldBeFalse)
+		So(cm.IsReferrersOnly("other/alpine"), ShouldBeFalse)
+	})
 }
 
 func TestGetContentByLocalRepo(t *testing.T) {
@@ -246,3 +256,30 @@ func TestFilterTags(t *testing.T) {
 		}
 	})
 }
+
+func TestOnDemandRules(t *testing.T) {
+	Convey("Test isAllowedOnDemand()", t, func() {
+		So(isAllowedOnDemand(nil, "alpine"), ShouldBeTrue)
+		So(isAllowedOnDemand(&syncconf.OnDemandRules{}, "alpine"), ShouldBeTrue)
+
+		rules := &syncconf.OnDemandRules{
+			Allow: []string{"library/**", "infra/*"},
+			Deny:  []string{"library/alpne", "**/tmp-*"},
+		}
+
+		So(isAllowedOnDemand(rules, "library/alpine"), ShouldBeTrue)
+		So(isAllowedOnDemand(rules, "library/images/ubuntu"), ShouldBeTrue)
+		So(isAllowedOnDemand(rules, "infra/busybox"), ShouldBeTrue)
+		// typo-squatting names can be denied
+		So(isAllowedOnDemand(rules, "library/alpne"), ShouldBeFalse)
+		// deny rules win over allow rules
+		So(isAllowedOnDemand(rules, "library/tmp-test"), ShouldBeFalse)
+		So(isAllowedOnDemand(rules, "infra/dev/busybox"), ShouldBeFalse)
+		So(isAllowedOnDemand(rules, "alpine"), ShouldBeFalse)
+
+		// every repo which isn't denied is allowed without allow rules
+		rules = &syncconf.OnDemandRules{Deny: []string{"alpne"}}
+		So(isAllowedOnDemand(rules, "alpine"), ShouldBeTrue)
+		So(isAllowedOnDemand(rules, "alpne"), ShouldBeFalse)
+	})
+}
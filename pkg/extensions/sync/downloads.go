@@ -0,0 +1,70 @@
+//go:build sync
+// +build sync
+
+package sync
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+
+	syncconf "zotregistry.io/zot/pkg/extensions/config/sync"
+)
+
+// same as the containers/image default, used when a registry config doesn't set maxParallelDownloads.
+const DefaultMaxParallelDownloads = 6
+
+/*
+DownloadLimiter caps the number of layers downloaded at the same time by all sync services.
+
+Each image copy reserves as many slots as the layers it's allowed to download in parallel
+and releases them once the image is synced, so the total number of layers being downloaded
+never exceeds the global cap, no matter how many repos and registries are synced at once.
+A nil DownloadLimiter doesn't limit anything.
+*/
+type DownloadLimiter struct {
+	size      int64
+	semaphore *semaphore.Weighted
+}
+
+// NewDownloadLimiter returns nil if maxConcurrentDownloads is not a positive number.
+func NewDownloadLimiter(maxConcurrentDownloads int) *DownloadLimiter {
+	if maxConcurrentDownloads <= 0 {
+		return nil
+	}
+
+	return &DownloadLimiter{
+		size:      int64(maxConcurrentDownloads),
+		semaphore: semaphore.NewWeighted(int64(maxConcurrentDownloads)),
+	}
+}
+
+/*
+Acquire blocks until parallelDownloads slots are available, it returns the number of layers the image
+copy may download in parallel (lower than parallelDownloads if it exceeds the global cap)
+and a function releasing the slots.
+*/
+func (limiter *DownloadLimiter) Acquire(ctx context.Context, parallelDownloads int) (int, func(), error) {
+	if limiter == nil {
+		return parallelDownloads, func() {}, nil
+	}
+
+	weight := int64(parallelDownloads)
+	if weight > limiter.size {
+		weight = limiter.size
+	}
+
+	if err := limiter.semaphore.Acquire(ctx, weight); err != nil {
+		return 0, nil, err
+	}
+
+	return int(weight), func() { limiter.semaphore.Release(weight) }, nil
+}
+
+func getParallelDownloads(opts syncconf.RegistryConfig) int {
+	if opts.MaxParallelDownloads > 0 {
+		return opts.MaxParallelDownloads
+	}
+
+	return DefaultMaxParallelDownloads
+}
@@ -10,11 +10,13 @@ import (
 )
 
 type Config struct {
-	URL       string
-	Username  string
-	Password  string
-	CertDir   string
-	TLSVerify bool
+	URL      string
+	Username string
+	Password string
+	// if set, used instead of username and password
+	BearerToken string
+	CertDir     string
+	TLSVerify   bool
 }
 
 type Client struct {
@@ -93,6 +95,11 @@ func (httpClient *Client) MakeGetRequest(resultPtr interface{}, mediaType string
 
 	url.RawQuery = url.Query().Encode()
 
+	if httpClient.config.BearerToken != "" {
+		return common.MakeHTTPGetRequestWithToken(httpClient.client, httpClient.config.BearerToken, resultPtr,
+			url.String(), mediaType, httpClient.log)
+	}
+
 	body, mediaType, statusCode, err := common.MakeHTTPGetRequest(httpClient.client, httpClient.config.Username,
 		httpClient.config.Password, resultPtr,
 		url.String(), mediaType, httpClient.log)
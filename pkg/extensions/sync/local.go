@@ -24,6 +24,7 @@ import (
 	"zotregistry.io/zot/pkg/storage"
 	storageCommon "zotregistry.io/zot/pkg/storage/common"
 	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/inflight"
 	"zotregistry.io/zot/pkg/storage/local"
 	storageTypes "zotregistry.io/zot/pkg/storage/types"
 )
@@ -74,6 +75,24 @@ func (registry *LocalRegistry) CanSkipImage(repo, tag string, imageDigest digest
 	return true, nil
 }
 
+func (registry *LocalRegistry) HasImage(repo string, imageDigest digest.Digest) (bool, error) {
+	imageStore := registry.storeController.GetImageStore(repo)
+
+	_, _, _, err := imageStore.GetImageManifest(repo, imageDigest.String())
+	if err != nil {
+		if errors.Is(err, zerr.ErrRepoNotFound) || errors.Is(err, zerr.ErrManifestNotFound) {
+			return false, nil
+		}
+
+		registry.log.Error().Str("errorType", common.TypeOf(err)).Str("repo", repo).
+			Str("digest", imageDigest.String()).Err(err).Msg("couldn't get local image manifest")
+
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (registry *LocalRegistry) GetContext() *types.SystemContext {
 	return registry.tempStorage.GetContext()
 }
@@ -95,6 +114,10 @@ func (registry *LocalRegistry) CommitImage(imageReference types.ImageReference,
 
 	var lockLatency time.Time
 
+	// gc keeps the blobs and manifests copied before the manifest referencing them lands
+	session := registry.storeController.InFlight.NewSession(repo)
+	defer session.Release()
+
 	manifestBlob, manifestDigest, mediaType, err := tempImageStore.GetImageManifest(repo, reference)
 	if err != nil {
 		registry.log.Error().Str("errorType", common.TypeOf(err)).
@@ -107,7 +130,7 @@ func (registry *LocalRegistry) CommitImage(imageReference types.ImageReference,
 	// is image manifest
 	switch mediaType {
 	case ispec.MediaTypeImageManifest:
-		if err := registry.copyManifest(repo, manifestBlob, reference, tempImageStore); err != nil {
+		if err := registry.copyManifest(repo, manifestBlob, reference, tempImageStore, session); err != nil {
 			if errors.Is(err, zerr.ErrImageLintAnnotations) {
 				registry.log.Error().Str("errorType", common.TypeOf(err)).
 					Err(err).Msg("couldn't upload manifest because of missing annotations")
@@ -143,7 +166,7 @@ func (registry *LocalRegistry) CommitImage(imageReference types.ImageReference,
 			}
 
 			if err := registry.copyManifest(repo, manifestBuf, manifest.Digest.String(),
-				tempImageStore); err != nil {
+				tempImageStore, session); err != nil {
 				if errors.Is(err, zerr.ErrImageLintAnnotations) {
 					registry.log.Error().Str("errorType", common.TypeOf(err)).
 						Err(err).Msg("couldn't upload manifest because of missing annotations")
@@ -180,7 +203,7 @@ func (registry *LocalRegistry) CommitImage(imageReference types.ImageReference,
 }
 
 func (registry *LocalRegistry) copyManifest(repo string, manifestContent []byte, reference string,
-	tempImageStore storageTypes.ImageStore,
+	tempImageStore storageTypes.ImageStore, session *inflight.Session,
 ) error {
 	imageStore := registry.storeController.GetImageStore(repo)
 
@@ -196,11 +219,16 @@ func (registry *LocalRegistry) copyManifest(repo string, manifestContent []byte,
 		return err
 	}
 
+	// including the blobs already stored, which may not be referenced yet, and the manifest until its index lands
+	session.Hold(digest.FromBytes(manifestContent), manifest.Config.Digest)
+
 	for _, blob := range manifest.Layers {
 		if storageCommon.IsNonDistributable(blob.MediaType) {
 			continue
 		}
 
+		session.Hold(blob.Digest)
+
 		err = registry.copyBlob(repo, blob.Digest, blob.MediaType, tempImageStore)
 		if err != nil {
 			return err
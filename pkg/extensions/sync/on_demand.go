@@ -6,19 +6,27 @@ package sync
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/containers/common/pkg/retry"
 
 	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/breaker"
 	"zotregistry.io/zot/pkg/common"
+	syncconf "zotregistry.io/zot/pkg/extensions/config/sync"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
 )
 
 type request struct {
 	repo      string
 	reference string
+	// set if the image is synced with the user's credentials, users don't share syncs then
+	username string
 	// used for background retries, at most one background retry per service
 	serviceID    int
 	isBackground bool
@@ -32,6 +40,10 @@ process just the first one, also keep track of all background retrying routines.
 */
 type BaseOnDemand struct {
 	services []Service
+	// circuit breakers of the upstreams of the services, nil if disabled
+	breakers []*breaker.Breaker
+	// at least one service forwards the user's identity
+	forwardsIdentity bool
 	// map[request]chan err
 	requestStore *sync.Map
 	log          log.Logger
@@ -41,16 +53,29 @@ func NewOnDemand(log log.Logger) *BaseOnDemand {
 	return &BaseOnDemand{log: log, requestStore: &sync.Map{}}
 }
 
-func (onDemand *BaseOnDemand) Add(service Service) {
+// Add adds a service syncing on demand, its upstream isn't called while its circuit breaker is open.
+func (onDemand *BaseOnDemand) Add(service Service, serviceBreaker *breaker.Breaker) {
 	onDemand.services = append(onDemand.services, service)
+	onDemand.breakers = append(onDemand.breakers, serviceBreaker)
+
+	if service.ForwardsIdentity() {
+		onDemand.forwardsIdentity = true
+	}
 }
 
-func (onDemand *BaseOnDemand) SyncImage(repo, reference string) error {
+// SyncImage syncs an image from the first registry having it, ctx may carry the identity of the user pulling it.
+func (onDemand *BaseOnDemand) SyncImage(ctx context.Context, repo, reference string) error {
+	identity, _ := localCtx.GetIdentity(ctx)
+
 	req := request{
 		repo:      repo,
 		reference: reference,
 	}
 
+	if onDemand.forwardsIdentity {
+		req.username = identity.Username
+	}
+
 	val, found := onDemand.requestStore.Load(req)
 	if found {
 		onDemand.log.Info().Str("repo", repo).Str("reference", reference).
@@ -73,7 +98,7 @@ func (onDemand *BaseOnDemand) SyncImage(repo, reference string) error {
 	defer onDemand.requestStore.Delete(req)
 	defer close(syncResult)
 
-	go onDemand.syncImage(repo, reference, syncResult)
+	go onDemand.syncImage(identity, req.username, repo, reference, syncResult)
 
 	err, ok := <-syncResult
 	if !ok {
@@ -83,19 +108,31 @@ func (onDemand *BaseOnDemand) SyncImage(repo, reference string) error {
 	return err
 }
 
-func (onDemand *BaseOnDemand) SyncReference(repo string, subjectDigestStr string, referenceType string) error {
+// SyncReference syncs the references of an image, ctx may carry the identity of the user pulling them.
+func (onDemand *BaseOnDemand) SyncReference(ctx context.Context, repo string, subjectDigestStr string,
+	referenceType string,
+) error {
 	var err error
 
-	for _, service := range onDemand.services {
-		err = service.SetNextAvailableURL()
-		if err != nil {
-			return err
-		}
+	identity, _ := localCtx.GetIdentity(ctx)
 
-		err = service.SyncReference(repo, subjectDigestStr, referenceType)
-		if err != nil {
+	for serviceID := range onDemand.services {
+		var service Service
+
+		service, err = onDemand.callService(serviceID, identity, func(service Service) error {
+			return service.SyncReference(repo, subjectDigestStr, referenceType)
+		})
+
+		switch {
+		case errors.Is(err, zerr.ErrCircuitOpen):
+			// the upstream keeps failing, the references are looked up in the next one
 			continue
-		} else {
+		case service == nil:
+			// the upstream couldn't be reached
+			return err
+		case err != nil:
+			continue
+		default:
 			return nil
 		}
 	}
@@ -103,17 +140,29 @@ func (onDemand *BaseOnDemand) SyncReference(repo string, subjectDigestStr string
 	return err
 }
 
-func (onDemand *BaseOnDemand) syncImage(repo, reference string, syncResult chan error) {
+func (onDemand *BaseOnDemand) syncImage(identity localCtx.Identity, username, repo, reference string,
+	syncResult chan error,
+) {
 	var err error
-	for serviceID, service := range onDemand.services {
-		err = service.SetNextAvailableURL()
-		if err != nil {
+	for serviceID := range onDemand.services {
+		var service Service
+
+		service, err = onDemand.callService(serviceID, identity, func(service Service) error {
+			return service.SyncImage(repo, reference)
+		})
+
+		if errors.Is(err, zerr.ErrCircuitOpen) {
+			// the upstream keeps failing, the image is looked up in the next one
+			continue
+		}
+
+		if service == nil {
+			// the upstream couldn't be reached
 			syncResult <- err
 
 			return
 		}
 
-		err = service.SyncImage(repo, reference)
 		if err != nil {
 			if errors.Is(err, zerr.ErrManifestNotFound) ||
 				errors.Is(err, zerr.ErrSyncImageFilteredOut) ||
@@ -124,6 +173,7 @@ func (onDemand *BaseOnDemand) syncImage(repo, reference string, syncResult chan
 			req := request{
 				repo:         repo,
 				reference:    reference,
+				username:     username,
 				serviceID:    serviceID,
 				isBackground: true,
 			}
@@ -137,7 +187,7 @@ func (onDemand *BaseOnDemand) syncImage(repo, reference string, syncResult chan
 
 			if retryOptions.MaxRetry > 0 {
 				// retry in background
-				go func(service Service) {
+				go func(service Service, serviceBreaker *breaker.Breaker) {
 					// remove image after syncing
 					defer func() {
 						onDemand.requestStore.Delete(req)
@@ -151,14 +201,14 @@ func (onDemand *BaseOnDemand) syncImage(repo, reference string, syncResult chan
 					time.Sleep(retryOptions.Delay)
 
 					if err = retry.RetryIfNecessary(context.Background(), func() error {
-						err := service.SyncImage(repo, reference)
-
-						return err
+						return serviceBreaker.Do(func() error {
+							return service.SyncImage(repo, reference)
+						})
 					}, retryOptions); err != nil {
 						onDemand.log.Error().Str("errorType", common.TypeOf(err)).Str("repo", repo).Str("reference", reference).
 							Err(err).Msg("sync routine: error while copying image")
 					}
-				}(service)
+				}(service, onDemand.breakers[serviceID])
 			}
 		} else {
 			break
@@ -167,3 +217,44 @@ func (onDemand *BaseOnDemand) syncImage(repo, reference string, syncResult chan
 
 	syncResult <- err
 }
+
+/*
+callService gets the service syncing with the user's identity and calls fn with it, unless the circuit
+breaker of the service is open. The service is nil if its upstream couldn't be reached.
+*/
+func (onDemand *BaseOnDemand) callService(serviceID int, identity localCtx.Identity, fn func(Service) error,
+) (Service, error) {
+	var userService Service
+
+	err := onDemand.breakers[serviceID].Do(func() error {
+		service := onDemand.services[serviceID]
+
+		if err := service.SetNextAvailableURL(); err != nil {
+			return err
+		}
+
+		var err error
+
+		if userService, err = service.WithIdentity(identity); err != nil {
+			return err
+		}
+
+		return fn(userService)
+	})
+
+	return userService, err
+}
+
+// NewUpstreamBreaker creates the circuit breaker of the upstreams of a registry, or nil if circuit breakers
+// are disabled. Images missing upstream or filtered out aren't failures of the upstream.
+func NewUpstreamBreaker(registryConfig syncconf.RegistryConfig, config *config.CircuitBreakerConfig,
+	metrics monitoring.MetricServer, log log.Logger,
+) *breaker.Breaker {
+	if config == nil {
+		return nil
+	}
+
+	return breaker.New("sync "+strings.Join(registryConfig.URLs, ","), *config, metrics, log,
+		zerr.ErrManifestNotFound, zerr.ErrSyncImageFilteredOut, zerr.ErrSyncImageNotSigned,
+		zerr.ErrSyncReferrerNotFound, zerr.ErrSyncSubjectNotFound)
+}
@@ -3,12 +3,16 @@
 
 package sync
 
+import "context"
+
 type BaseOnDemand struct{}
 
-func (onDemand *BaseOnDemand) SyncImage(repo, reference string) error {
+func (onDemand *BaseOnDemand) SyncImage(ctx context.Context, repo, reference string) error {
 	return nil
 }
 
-func (onDemand *BaseOnDemand) SyncReference(repo string, subjectDigestStr string, referenceType string) error {
+func (onDemand *BaseOnDemand) SyncReference(ctx context.Context, repo string, subjectDigestStr string,
+	referenceType string,
+) error {
 	return nil
 }
@@ -0,0 +1,173 @@
+//go:build sync
+// +build sync
+
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+/*
+RepoState sums up the content of a repo, peered registries exchange it before syncing a repo
+so they can skip the repos which are already in sync without comparing each tag.
+*/
+type RepoState struct {
+	Name string `json:"name"`
+	// number of manifests (tagged or not) found in the repo
+	Height int `json:"height"`
+	// digest computed over all the manifests of the repo and the tags pointing to them
+	Digest string            `json:"digest"`
+	Tags   map[string]string `json:"tags"`
+}
+
+// TagConflict describes a tag pointing to different digests on the local registry and on a peer.
+type TagConflict struct {
+	Registry     string    `json:"registry"`
+	Repo         string    `json:"repo"`
+	Tag          string    `json:"tag"`
+	LocalDigest  string    `json:"localDigest"`
+	RemoteDigest string    `json:"remoteDigest"`
+	DetectedAt   time.Time `json:"detectedAt"`
+}
+
+type ConflictList struct {
+	Conflicts []TagConflict `json:"conflicts"`
+}
+
+// GetRepoState computes the state of a local repo.
+func GetRepoState(imgStore storageTypes.ImageStore, repo string) (RepoState, error) {
+	state := RepoState{
+		Name: repo,
+		Tags: map[string]string{},
+	}
+
+	buf, err := imgStore.GetIndexContent(repo)
+	if err != nil {
+		return state, err
+	}
+
+	var index ispec.Index
+	if err := json.Unmarshal(buf, &index); err != nil {
+		return state, err
+	}
+
+	entries := make([]string, 0, len(index.Manifests))
+
+	for _, desc := range index.Manifests {
+		tag := desc.Annotations[ispec.AnnotationRefName]
+		if tag != "" {
+			state.Tags[tag] = desc.Digest.String()
+		}
+
+		entries = append(entries, fmt.Sprintf("%s %s", desc.Digest.String(), tag))
+	}
+
+	sort.Strings(entries)
+
+	state.Height = len(index.Manifests)
+	state.Digest = godigest.FromString(strings.Join(entries, "\n")).String()
+
+	return state, nil
+}
+
+// ConflictStore keeps the tag conflicts found by all sync services which have peering enabled.
+type ConflictStore struct {
+	// map[registry/repo:tag]TagConflict
+	conflicts map[string]TagConflict
+	lock      *sync.RWMutex
+}
+
+func NewConflictStore() *ConflictStore {
+	return &ConflictStore{
+		conflicts: map[string]TagConflict{},
+		lock:      &sync.RWMutex{},
+	}
+}
+
+func conflictKey(registry, repo, tag string) string {
+	return fmt.Sprintf("%s/%s:%s", registry, repo, tag)
+}
+
+// Add stores a conflict, returns false if the same conflict was already known.
+func (store *ConflictStore) Add(conflict TagConflict) bool {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	key := conflictKey(conflict.Registry, conflict.Repo, conflict.Tag)
+
+	known, found := store.conflicts[key]
+	if found && known.LocalDigest == conflict.LocalDigest && known.RemoteDigest == conflict.RemoteDigest {
+		return false
+	}
+
+	store.conflicts[key] = conflict
+
+	return true
+}
+
+// Remove forgets a conflict, it's called once the tag points to the same digest on both registries.
+func (store *ConflictStore) Remove(registry, repo, tag string) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	delete(store.conflicts, conflictKey(registry, repo, tag))
+}
+
+// List returns all known conflicts sorted by repo and tag.
+func (store *ConflictStore) List() []TagConflict {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	conflicts := make([]TagConflict, 0, len(store.conflicts))
+
+	for _, conflict := range store.conflicts {
+		conflicts = append(conflicts, conflict)
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflictKey(conflicts[i].Repo, conflicts[i].Tag, conflicts[i].Registry) <
+			conflictKey(conflicts[j].Repo, conflicts[j].Tag, conflicts[j].Registry)
+	})
+
+	return conflicts
+}
+
+// peerDigests keeps the digest of each tag as it was the last time the local registry and the peer agreed on it.
+type peerDigests struct {
+	// map[repo:tag]digest
+	digests map[string]godigest.Digest
+	lock    *sync.Mutex
+}
+
+func newPeerDigests() *peerDigests {
+	return &peerDigests{
+		digests: map[string]godigest.Digest{},
+		lock:    &sync.Mutex{},
+	}
+}
+
+func (peer *peerDigests) get(repo, tag string) (godigest.Digest, bool) {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+
+	digest, found := peer.digests[repo+":"+tag]
+
+	return digest, found
+}
+
+func (peer *peerDigests) set(repo, tag string, digest godigest.Digest) {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+
+	peer.digests[repo+":"+tag] = digest
+}
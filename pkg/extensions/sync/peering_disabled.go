@@ -0,0 +1,10 @@
+//go:build !sync
+// +build !sync
+
+package sync
+
+type ConflictStore struct{}
+
+func NewConflictStore() *ConflictStore {
+	return &ConflictStore{}
+}
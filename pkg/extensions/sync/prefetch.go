@@ -0,0 +1,189 @@
+//go:build sync
+// +build sync
+
+package sync
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	syncconf "zotregistry.io/zot/pkg/extensions/config/sync"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/storage"
+)
+
+// Prefetcher reads back the blobs of the newly synced images, so the caches in front of the storage (the page
+// cache of the host, or the caching proxy in front of the s3 bucket) serve the first pull. Images are prefetched
+// one at a time in the background, sync doesn't wait for them.
+type Prefetcher struct {
+	storeController storage.StoreController
+	repoDB          repodb.RepoDB
+	queue           chan prefetchTask
+	log             log.Logger
+}
+
+type prefetchTask struct {
+	repo   string
+	digest digest.Digest
+}
+
+const prefetchQueueSize = 100
+
+// NewPrefetcher returns nil if the content of none of the registries is prefetched.
+func NewPrefetcher(registries []syncconf.RegistryConfig, storeController storage.StoreController,
+	repoDB repodb.RepoDB, log log.Logger,
+) *Prefetcher {
+	if !hasPrefetch(registries) {
+		return nil
+	}
+
+	prefetcher := &Prefetcher{
+		storeController: storeController,
+		repoDB:          repoDB,
+		queue:           make(chan prefetchTask, prefetchQueueSize),
+		log:             log,
+	}
+
+	go prefetcher.run()
+
+	return prefetcher
+}
+
+func hasPrefetch(registries []syncconf.RegistryConfig) bool {
+	for _, registryConfig := range registries {
+		for _, content := range registryConfig.Content {
+			if content.Prefetch != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Prefetch queues the blobs of an image to be read, if its repo is popular enough. Images are dropped while the
+// queue is full, a prefetch is only an optimization.
+func (prefetcher *Prefetcher) Prefetch(repo string, imageDigest digest.Digest, rule *syncconf.Prefetch) {
+	if prefetcher == nil || rule == nil || !prefetcher.isPopular(repo, rule.MinDownloads) {
+		return
+	}
+
+	select {
+	case prefetcher.queue <- prefetchTask{repo: repo, digest: imageDigest}:
+	default:
+		prefetcher.log.Warn().Str("repo", repo).Str("digest", imageDigest.String()).
+			Msg("sync: prefetch queue is full, skipping prefetch of image")
+	}
+}
+
+func (prefetcher *Prefetcher) isPopular(repo string, minDownloads int) bool {
+	if minDownloads <= 0 {
+		return true
+	}
+
+	if prefetcher.repoDB == nil {
+		return false
+	}
+
+	repoMeta, err := prefetcher.repoDB.GetRepoMeta(repo)
+	if err != nil {
+		// the first image of a repo isn't popular yet
+		return false
+	}
+
+	downloads := 0
+
+	for _, statistics := range repoMeta.Statistics {
+		downloads += statistics.DownloadCount
+	}
+
+	return downloads >= minDownloads
+}
+
+func (prefetcher *Prefetcher) run() {
+	for task := range prefetcher.queue {
+		blobs, size, err := prefetcher.prefetchImage(task.repo, task.digest)
+		if err != nil {
+			prefetcher.log.Error().Err(err).Str("repo", task.repo).Str("digest", task.digest.String()).
+				Msg("sync: failed to prefetch image")
+
+			continue
+		}
+
+		prefetcher.log.Debug().Str("repo", task.repo).Str("digest", task.digest.String()).Int("blobs", blobs).
+			Int64("size", size).Msg("sync: prefetched image")
+	}
+}
+
+// prefetchImage reads the config and layers of an image, or of every image of an index, and returns how many
+// blobs and bytes were read.
+func (prefetcher *Prefetcher) prefetchImage(repo string, imageDigest digest.Digest) (int, int64, error) {
+	imgStore := prefetcher.storeController.GetImageStore(repo)
+
+	manifestBlob, _, mediaType, err := imgStore.GetImageManifest(repo, imageDigest.String())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var descriptors []ispec.Descriptor
+
+	switch mediaType {
+	case ispec.MediaTypeImageIndex:
+		var index ispec.Index
+
+		if err := json.Unmarshal(manifestBlob, &index); err != nil {
+			return 0, 0, err
+		}
+
+		var (
+			blobs int
+			size  int64
+		)
+
+		for _, manifest := range index.Manifests {
+			manifestBlobs, manifestSize, err := prefetcher.prefetchImage(repo, manifest.Digest)
+			if err != nil {
+				return blobs, size, err
+			}
+
+			blobs += manifestBlobs
+			size += manifestSize
+		}
+
+		return blobs, size, nil
+	default:
+		var manifest ispec.Manifest
+
+		if err := json.Unmarshal(manifestBlob, &manifest); err != nil {
+			return 0, 0, err
+		}
+
+		descriptors = append(descriptors, manifest.Config)
+		descriptors = append(descriptors, manifest.Layers...)
+	}
+
+	var size int64
+
+	for _, descriptor := range descriptors {
+		blobReader, _, err := imgStore.GetBlob(repo, descriptor.Digest, descriptor.MediaType)
+		if err != nil {
+			return 0, size, err
+		}
+
+		read, err := io.Copy(io.Discard, blobReader)
+
+		blobReader.Close()
+
+		size += read
+
+		if err != nil {
+			return 0, size, err
+		}
+	}
+
+	return len(descriptors), size, nil
+}
@@ -0,0 +1,260 @@
+//go:build sync
+// +build sync
+
+package sync
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/constants"
+	syncconf "zotregistry.io/zot/pkg/extensions/config/sync"
+	client "zotregistry.io/zot/pkg/extensions/sync/httpclient"
+	"zotregistry.io/zot/pkg/log"
+)
+
+const (
+	CheckCredentials  = "credentials"
+	CheckFilters      = "filters"
+	CheckTLS          = "tls"
+	CheckConnectivity = "connectivity"
+	CheckAuth         = "auth"
+	CheckCatalog      = "catalog"
+)
+
+// PreflightReport is the result of checking the sync config against each upstream registry.
+type PreflightReport struct {
+	OK         bool                `json:"ok"`
+	Checks     []PreflightCheck    `json:"checks"`
+	Registries []RegistryPreflight `json:"registries"`
+}
+
+// RegistryPreflight is the result of checking the config of a registry, a registry is usable if its filters
+// are valid and at least one of its urls passes all the checks, as sync falls back to the next url.
+type RegistryPreflight struct {
+	URLs      []string            `json:"urls"`
+	OK        bool                `json:"ok"`
+	Checks    []PreflightCheck    `json:"checks"`
+	Upstreams []UpstreamPreflight `json:"upstreams"`
+}
+
+// UpstreamPreflight is the result of checking one url of a registry.
+type UpstreamPreflight struct {
+	URL    string           `json:"url"`
+	OK     bool             `json:"ok"`
+	Checks []PreflightCheck `json:"checks"`
+}
+
+// PreflightCheck is a single check, the checks following a failed check of an upstream are skipped.
+type PreflightCheck struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+/*
+Preflight checks the sync config without syncing anything: the credentials file can be read, the content
+filters compile, and each upstream url can be reached over TLS, accepts the configured credentials and,
+for registries synced periodically, serves its catalog.
+*/
+func Preflight(config *syncconf.Config, log log.Logger) PreflightReport {
+	report := PreflightReport{OK: true, Checks: []PreflightCheck{}, Registries: []RegistryPreflight{}}
+
+	var credentials syncconf.CredentialsFile
+
+	if config.CredentialsFile != "" {
+		var err error
+
+		credentials, err = getFileCredentials(config.CredentialsFile)
+		report.Checks = append(report.Checks, newCheck(CheckCredentials, err))
+
+		if err != nil {
+			report.OK = false
+		}
+	}
+
+	for _, registryConfig := range config.Registries {
+		registry := preflightRegistry(registryConfig, credentials, log)
+		if !registry.OK {
+			report.OK = false
+		}
+
+		report.Registries = append(report.Registries, registry)
+	}
+
+	return report
+}
+
+func preflightRegistry(config syncconf.RegistryConfig, credentials syncconf.CredentialsFile,
+	log log.Logger,
+) RegistryPreflight {
+	filtersErr := checkContentFilters(config.Content)
+
+	registry := RegistryPreflight{
+		URLs:      config.URLs,
+		Checks:    []PreflightCheck{newCheck(CheckFilters, filtersErr)},
+		Upstreams: []UpstreamPreflight{},
+	}
+
+	isPeriodical := len(config.Content) != 0 && config.PollInterval != 0
+	upstreamOK := false
+
+	for _, upstreamURL := range config.URLs {
+		upstream := preflightUpstream(upstreamURL, config, credentials[StripRegistryTransport(upstreamURL)],
+			isPeriodical, log)
+		upstreamOK = upstreamOK || upstream.OK
+
+		registry.Upstreams = append(registry.Upstreams, upstream)
+	}
+
+	registry.OK = filtersErr == nil && upstreamOK
+
+	return registry
+}
+
+func preflightUpstream(upstreamURL string, config syncconf.RegistryConfig, credentials syncconf.Credentials,
+	isPeriodical bool, log log.Logger,
+) UpstreamPreflight {
+	upstream := UpstreamPreflight{URL: upstreamURL}
+
+	tlsVerify := true
+	if config.TLSVerify != nil {
+		tlsVerify = *config.TLSVerify
+	}
+
+	parsedURL, err := url.Parse(upstreamURL)
+	if err == nil && parsedURL.Host == "" {
+		err = zerr.ErrSyncInvalidUpstreamURL
+	}
+
+	var httpClient *client.Client
+
+	if err == nil {
+		httpClient, err = client.New(client.Config{
+			URL:       upstreamURL,
+			Username:  credentials.Username,
+			Password:  credentials.Password,
+			TLSVerify: tlsVerify,
+			CertDir:   config.CertDir,
+		}, log)
+	}
+
+	if err != nil {
+		upstream.Checks = append(upstream.Checks, newCheck(CheckTLS, err))
+
+		return upstream.skipRemaining(CheckConnectivity, CheckAuth, CheckCatalog)
+	}
+
+	_, _, statusCode, err := httpClient.MakeGetRequest(nil, "", "/v2/")
+
+	switch {
+	case err != nil && statusCode == -1 && isTLSError(err):
+		upstream.Checks = append(upstream.Checks, newCheck(CheckTLS, err))
+
+		return upstream.skipRemaining(CheckConnectivity, CheckAuth, CheckCatalog)
+	case err != nil && statusCode == -1:
+		upstream.Checks = append(upstream.Checks, tlsCheck(parsedURL), newCheck(CheckConnectivity, err))
+
+		return upstream.skipRemaining(CheckAuth, CheckCatalog)
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		upstream.Checks = append(upstream.Checks, tlsCheck(parsedURL), newCheck(CheckConnectivity, nil),
+			newCheck(CheckAuth, fmt.Errorf("%w: upstream registry returned status %d", zerr.ErrUnauthorizedAccess,
+				statusCode)))
+
+		return upstream.skipRemaining(CheckCatalog)
+	case statusCode != http.StatusOK:
+		upstream.Checks = append(upstream.Checks, tlsCheck(parsedURL), newCheck(CheckConnectivity,
+			fmt.Errorf("%w: upstream registry returned status %d", zerr.ErrSyncPingRegistry, statusCode)))
+
+		return upstream.skipRemaining(CheckAuth, CheckCatalog)
+	}
+
+	upstream.Checks = append(upstream.Checks, tlsCheck(parsedURL), newCheck(CheckConnectivity, nil),
+		newCheck(CheckAuth, nil))
+
+	if !isPeriodical {
+		// the catalog is only listed by periodic sync
+		upstream.Checks = append(upstream.Checks, PreflightCheck{Name: CheckCatalog, OK: true, Skipped: true})
+		upstream.OK = true
+
+		return upstream
+	}
+
+	var catalog catalog
+
+	_, _, _, err = httpClient.MakeGetRequest(&catalog, "application/json", //nolint: dogsled
+		constants.RoutePrefix, constants.ExtCatalogPrefix)
+	upstream.Checks = append(upstream.Checks, newCheck(CheckCatalog, err))
+	upstream.OK = err == nil
+
+	return upstream
+}
+
+// skipRemaining marks the checks which can't run after a failed check as skipped.
+func (upstream UpstreamPreflight) skipRemaining(names ...string) UpstreamPreflight {
+	for _, name := range names {
+		upstream.Checks = append(upstream.Checks, PreflightCheck{Name: name, Skipped: true})
+	}
+
+	return upstream
+}
+
+// checkContentFilters checks the prefixes are valid glob patterns and the tag filters valid regexes.
+func checkContentFilters(contents []syncconf.Content) error {
+	for _, content := range contents {
+		if !glob.ValidatePattern(strings.TrimPrefix(content.Prefix, "/")) {
+			return fmt.Errorf("%w: invalid prefix %q", zerr.ErrBadConfig, content.Prefix)
+		}
+
+		if content.Tags != nil && content.Tags.Regex != nil {
+			if _, err := regexp.Compile(*content.Tags.Regex); err != nil {
+				return fmt.Errorf("%w: invalid tags regex %q of prefix %q: %s", zerr.ErrBadConfig,
+					*content.Tags.Regex, content.Prefix, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func tlsCheck(upstreamURL *url.URL) PreflightCheck {
+	if upstreamURL.Scheme != "https" {
+		return PreflightCheck{Name: CheckTLS, OK: true, Skipped: true}
+	}
+
+	return newCheck(CheckTLS, nil)
+}
+
+// isTLSError returns whether a request failed because of the certificates of the upstream registry or
+// the TLS handshake.
+func isTLSError(err error) bool {
+	var (
+		unknownAuthorityErr   x509.UnknownAuthorityError
+		hostnameErr           x509.HostnameError
+		certificateInvalidErr x509.CertificateInvalidError
+		verificationErr       *tls.CertificateVerificationError
+		recordHeaderErr       tls.RecordHeaderError
+	)
+
+	return errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &certificateInvalidErr) || errors.As(err, &verificationErr) ||
+		errors.As(err, &recordHeaderErr)
+}
+
+func newCheck(name string, err error) PreflightCheck {
+	if err != nil {
+		return PreflightCheck{Name: name, Error: err.Error()}
+	}
+
+	return PreflightCheck{Name: name, OK: true}
+}
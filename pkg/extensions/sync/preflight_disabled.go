@@ -0,0 +1,15 @@
+//go:build !sync
+// +build !sync
+
+package sync
+
+import (
+	syncconf "zotregistry.io/zot/pkg/extensions/config/sync"
+	"zotregistry.io/zot/pkg/log"
+)
+
+type PreflightReport struct{}
+
+func Preflight(config *syncconf.Config, log log.Logger) PreflightReport {
+	return PreflightReport{}
+}
@@ -57,6 +57,24 @@ func TestCosign(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(ok, ShouldBeFalse)
 	})
+
+	Convey("cosign tags", t, func() {
+		digest := godigest.FromString("subject")
+		cosignTags := getCosignTagsFromSubjectDigest(digest.String())
+
+		So(cosignTags, ShouldResemble, []string{
+			"sha256-" + digest.Encoded() + ".sig",
+			"sha256-" + digest.Encoded() + ".sbom",
+			"sha256-" + digest.Encoded() + ".att",
+		})
+
+		for _, tag := range cosignTags {
+			So(IsCosignTag(tag), ShouldBeTrue)
+		}
+
+		So(IsCosignTag("sha256-"+digest.Encoded()), ShouldBeFalse)
+		So(IsCosignTag("1.0.att"), ShouldBeFalse)
+	})
 }
 
 func TestOci(t *testing.T) {
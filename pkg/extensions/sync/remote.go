@@ -37,7 +37,7 @@ func NewRemoteRegistry(client *client.Client, logger log.Logger) Remote {
 	registry.client = client
 	clientConfig := client.GetConfig()
 	registry.context = getUpstreamContext(clientConfig.CertDir, clientConfig.Username,
-		clientConfig.Password, clientConfig.TLSVerify)
+		clientConfig.Password, clientConfig.BearerToken, clientConfig.TLSVerify)
 
 	return registry
 }
@@ -141,3 +141,15 @@ func (registry *RemoteRegistry) GetRepoTags(repo string) ([]string, error) {
 
 	return tags, nil
 }
+
+func (registry *RemoteRegistry) GetRepoState(repo string) (RepoState, error) {
+	var state RepoState
+
+	_, _, _, err := registry.client.MakeGetRequest(&state, "application/json", //nolint: dogsled
+		constants.RoutePrefix, constants.ExtPeeringPrefix, "repos", repo)
+	if err != nil {
+		return RepoState{}, err
+	}
+
+	return state, nil
+}
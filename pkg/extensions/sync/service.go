@@ -7,6 +7,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/containers/common/pkg/retry"
 	"github.com/containers/image/v5/copy"
@@ -15,10 +17,12 @@ import (
 	zerr "zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/common"
 	syncconf "zotregistry.io/zot/pkg/extensions/config/sync"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	client "zotregistry.io/zot/pkg/extensions/sync/httpclient"
 	"zotregistry.io/zot/pkg/extensions/sync/references"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/repodb"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
 	"zotregistry.io/zot/pkg/storage"
 )
 
@@ -34,17 +38,34 @@ type BaseService struct {
 	repositories    []string
 	references      references.References
 	client          *client.Client
+	conflicts       *ConflictStore
+	downloads       *DownloadLimiter
+	prefetcher      *Prefetcher
+	peerDigests     *peerDigests
+	metrics         monitoring.MetricServer
 	log             log.Logger
 }
 
 func New(opts syncconf.RegistryConfig, credentialsFilepath string,
-	storeController storage.StoreController, repodb repodb.RepoDB, log log.Logger,
+	storeController storage.StoreController, repodb repodb.RepoDB, conflicts *ConflictStore,
+	downloads *DownloadLimiter, prefetcher *Prefetcher, metrics monitoring.MetricServer, log log.Logger,
 ) (Service, error) {
 	service := &BaseService{}
 
 	service.config = opts
 	service.log = log
 	service.repoDB = repodb
+	service.conflicts = conflicts
+	service.downloads = downloads
+	service.prefetcher = prefetcher
+	service.peerDigests = newPeerDigests()
+	service.metrics = metrics
+
+	// periodic syncs are unhealthy once the catalog of the registry couldn't be synced for a few poll intervals
+	if len(opts.Content) != 0 && opts.PollInterval != 0 {
+		monitoring.RegisterSubsystem(metrics, monitoring.SubsystemSync, strings.Join(opts.URLs, ","),
+			monitoring.MaxAgeIntervals*opts.PollInterval)
+	}
 
 	var err error
 
@@ -144,6 +165,40 @@ func (service *BaseService) GetRetryOptions() *retry.Options {
 	return service.retryOptions
 }
 
+func (service *BaseService) ForwardsIdentity() bool {
+	return service.config.ForwardIdentity
+}
+
+/*
+WithIdentity returns a copy of the service whose upstream requests are authenticated with the user's
+credentials instead of the credentials file, anonymous users stay anonymous upstream.
+If the registry doesn't forward identities the service itself is returned.
+*/
+func (service *BaseService) WithIdentity(identity localCtx.Identity) (Service, error) {
+	if !service.config.ForwardIdentity {
+		return service, nil
+	}
+
+	options := *service.client.GetConfig()
+	options.Username = identity.Username
+	options.Password = identity.Password
+	options.BearerToken = identity.BearerToken
+
+	httpClient, err := client.New(options, service.log)
+	if err != nil {
+		return nil, err
+	}
+
+	userService := *service
+	userService.client = httpClient
+	userService.repositories = []string{}
+	userService.remote = NewRemoteRegistry(httpClient, service.log)
+	userService.references = references.NewReferences(httpClient, service.storeController, service.repoDB,
+		service.log)
+
+	return &userService, nil
+}
+
 func (service *BaseService) getNextRepoFromCatalog(lastRepo string) string {
 	var found bool
 
@@ -199,6 +254,11 @@ func (service *BaseService) GetNextRepo(lastRepo string) (string, error) {
 		matches = service.contentManager.MatchesContent(lastRepo)
 	}
 
+	// the whole catalog was walked, the registry is reachable and its repos are synced
+	if lastRepo == "" {
+		monitoring.RecordSubsystemSuccess(service.metrics, monitoring.SubsystemSync, strings.Join(service.config.URLs, ","))
+	}
+
 	return lastRepo, nil
 }
 
@@ -240,6 +300,13 @@ func (service *BaseService) SyncImage(repo, reference string) error {
 		}
 	}
 
+	if !isAllowedOnDemand(service.config.OnDemandRules, repo) {
+		service.log.Info().Str("remote", remoteURL).Str("repo", repo).Str("reference", reference).
+			Msg("will not sync image, filtered out by on demand rules")
+
+		return zerr.ErrSyncImageFilteredOut
+	}
+
 	service.log.Info().Str("remote", remoteURL).Str("repo", repo).Str("reference", reference).
 		Msg("sync: syncing image")
 
@@ -290,6 +357,12 @@ func (service *BaseService) SyncRepo(repo string) error {
 	// apply content.destination rule
 	localRepo := service.contentManager.GetRepoDestination(repo)
 
+	if service.config.Peering && service.isRepoInSyncWithPeer(localRepo, repo) {
+		service.log.Info().Str("repo", repo).Msg("sync: repo is already in sync with peer, skipping")
+
+		return nil
+	}
+
 	for _, tag := range tags {
 		if references.IsCosignTag(tag) {
 			continue
@@ -302,8 +375,10 @@ func (service *BaseService) SyncRepo(repo string) error {
 
 			return err
 		}, service.retryOptions); err != nil {
-			if errors.Is(err, zerr.ErrSyncImageNotSigned) || errors.Is(err, zerr.ErrMediaTypeNotSupported) {
-				// skip unsigned images or unsupported image mediatype
+			if errors.Is(err, zerr.ErrSyncImageNotSigned) || errors.Is(err, zerr.ErrMediaTypeNotSupported) ||
+				errors.Is(err, zerr.ErrSyncTagConflict) || errors.Is(err, zerr.ErrSyncSubjectNotFound) {
+				// skip unsigned images, unsupported image mediatype, tags conflicting with a peer
+				// or images which aren't present locally when only referrers are synced
 				continue
 			}
 
@@ -367,6 +442,23 @@ func (service *BaseService) syncTag(localRepo, remoteRepo, tag string) (digest.D
 		return "", zerr.ErrMediaTypeNotSupported
 	}
 
+	if service.contentManager.IsReferrersOnly(remoteRepo) {
+		// the image is mirrored by other means, only its referrers are synced once it is present locally
+		present, err := service.local.HasImage(localRepo, manifestDigest)
+		if err != nil {
+			return "", err
+		}
+
+		if !present {
+			service.log.Info().Str("image", remoteImageRef.DockerReference().String()).
+				Msg("skipping image not present locally, only its referrers are synced")
+
+			return "", zerr.ErrSyncSubjectNotFound
+		}
+
+		return manifestDigest, nil
+	}
+
 	if service.config.OnlySigned != nil && *service.config.OnlySigned && !references.IsCosignTag(tag) {
 		signed := service.references.IsSigned(remoteRepo, manifestDigest.String())
 		if !signed {
@@ -378,6 +470,14 @@ func (service *BaseService) syncTag(localRepo, remoteRepo, tag string) (digest.D
 		}
 	}
 
+	_, isDigest := parseReference(tag)
+
+	if service.config.Peering && !isDigest {
+		if err := service.checkPeerConflict(localRepo, tag, manifestDigest); err != nil {
+			return "", err
+		}
+	}
+
 	skipImage, err := service.local.CanSkipImage(localRepo, tag, manifestDigest)
 	if err != nil {
 		service.log.Error().Err(err).Str("errortype", common.TypeOf(err)).
@@ -397,7 +497,18 @@ func (service *BaseService) syncTag(localRepo, remoteRepo, tag string) (digest.D
 		service.log.Info().Str("remote image", remoteImageRef.DockerReference().String()).
 			Str("local image", fmt.Sprintf("%s:%s", localRepo, tag)).Msg("syncing image")
 
+		parallelDownloads, release, err := service.downloads.Acquire(context.Background(),
+			getParallelDownloads(service.config))
+		if err != nil {
+			return "", err
+		}
+
+		copyOptions.MaxParallelDownloads = uint(parallelDownloads)
+
 		_, err = copy.Image(context.Background(), policyContext, localImageRef, remoteImageRef, &copyOptions)
+
+		release()
+
 		if err != nil {
 			service.log.Error().Err(err).Str("errortype", common.TypeOf(err)).
 				Str("remote image", remoteImageRef.DockerReference().String()).
@@ -413,16 +524,103 @@ func (service *BaseService) syncTag(localRepo, remoteRepo, tag string) (digest.D
 
 			return "", err
 		}
+
+		service.prefetcher.Prefetch(localRepo, manifestDigest, service.contentManager.GetPrefetch(remoteRepo))
 	} else {
 		service.log.Info().Str("image", remoteImageRef.DockerReference().String()).
 			Msg("skipping image because it's already synced")
 	}
 
+	if service.config.Peering && !isDigest {
+		service.peerDigests.set(localRepo, tag, manifestDigest)
+	}
+
 	service.log.Info().Str("image", remoteImageRef.DockerReference().String()).Msg("sync: finished syncing image")
 
 	return manifestDigest, nil
 }
 
+// isRepoInSyncWithPeer compares the local repo state with the one exposed by the peer.
+func (service *BaseService) isRepoInSyncWithPeer(localRepo, remoteRepo string) bool {
+	remoteState, err := service.remote.GetRepoState(remoteRepo)
+	if err != nil {
+		// the peer doesn't expose its state, compare each tag instead
+		service.log.Debug().Err(err).Str("repo", remoteRepo).Msg("couldn't get repo state from peer")
+
+		return false
+	}
+
+	localState, err := GetRepoState(service.storeController.GetImageStore(localRepo), localRepo)
+	if err != nil {
+		return false
+	}
+
+	service.log.Debug().Str("repo", localRepo).Int("localHeight", localState.Height).
+		Int("remoteHeight", remoteState.Height).Str("localDigest", localState.Digest).
+		Str("remoteDigest", remoteState.Digest).Msg("sync: comparing repo state with peer")
+
+	if localState.Digest != remoteState.Digest {
+		return false
+	}
+
+	registry := service.client.GetConfig().URL
+
+	for tag, manifestDigest := range localState.Tags {
+		service.peerDigests.set(localRepo, tag, digest.Digest(manifestDigest))
+		service.conflicts.Remove(registry, localRepo, tag)
+	}
+
+	return true
+}
+
+/*
+checkPeerConflict returns ErrSyncTagConflict if the local tag and the peer tag point to different digests
+and the local tag changed since the last time the registries agreed on it, meaning both sides
+were updated independently. In that case the local tag is left untouched and the conflict is reported.
+*/
+func (service *BaseService) checkPeerConflict(localRepo, tag string, remoteDigest digest.Digest) error {
+	registry := service.client.GetConfig().URL
+	imageStore := service.storeController.GetImageStore(localRepo)
+
+	_, localDigest, _, err := imageStore.GetImageManifest(localRepo, tag)
+	if err != nil {
+		if errors.Is(err, zerr.ErrRepoNotFound) || errors.Is(err, zerr.ErrManifestNotFound) {
+			service.conflicts.Remove(registry, localRepo, tag)
+
+			return nil
+		}
+
+		return err
+	}
+
+	lastDigest, found := service.peerDigests.get(localRepo, tag)
+
+	if localDigest == remoteDigest || (found && localDigest == lastDigest) {
+		service.conflicts.Remove(registry, localRepo, tag)
+
+		return nil
+	}
+
+	isNew := service.conflicts.Add(TagConflict{
+		Registry:     registry,
+		Repo:         localRepo,
+		Tag:          tag,
+		LocalDigest:  localDigest.String(),
+		RemoteDigest: remoteDigest.String(),
+		DetectedAt:   time.Now(),
+	})
+
+	if isNew {
+		monitoring.IncSyncConflictCounter(service.metrics, registry, localRepo)
+	}
+
+	service.log.Warn().Str("remote", registry).Str("repo", localRepo).Str("reference", tag).
+		Str("localDigest", localDigest.String()).Str("remoteDigest", remoteDigest.String()).
+		Msg("sync: tag conflicts with peer, skipping")
+
+	return zerr.ErrSyncTagConflict
+}
+
 func (service *BaseService) ResetCatalog() {
 	service.log.Info().Msg("resetting catalog")
 
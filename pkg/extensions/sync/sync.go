@@ -11,6 +11,7 @@ import (
 	"github.com/opencontainers/go-digest"
 
 	"zotregistry.io/zot/pkg/log"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
 	"zotregistry.io/zot/pkg/scheduler"
 )
 
@@ -34,6 +35,10 @@ type Service interface {
 	SetNextAvailableURL() error // used by all sync methods
 	// Returns retry options from registry config.
 	GetRetryOptions() *retry.Options // used by sync on demand to retry in background
+	// Returns whether on demand syncs are made with the credentials of the user pulling the image.
+	ForwardsIdentity() bool // used by sync on demand to not share syncs between users
+	// Returns a service making its upstream requests with the given user's credentials.
+	WithIdentity(identity localCtx.Identity) (Service, error) // used by sync on demand
 }
 
 // Local and remote registries must implement this interface.
@@ -61,6 +66,8 @@ type Remote interface {
 	GetRepoTags(repo string) ([]string, error)
 	// Get manifest content, mediaType, digest given an ImageReference
 	GetManifestContent(imageReference types.ImageReference) ([]byte, string, digest.Digest, error)
+	// Get the state of a repo, only available if the remote is a peered zot
+	GetRepoState(repo string) (RepoState, error)
 }
 
 // Local registry.
@@ -68,6 +75,8 @@ type Local interface {
 	Registry
 	// Check if an image is already synced
 	CanSkipImage(repo, tag string, imageDigest digest.Digest) (bool, error)
+	// Check if an image is present in ImageStore, under any tag or untagged
+	HasImage(repo string, imageDigest digest.Digest) (bool, error)
 	// CommitImage moves a synced repo/ref from temporary oci layout to ImageStore
 	CommitImage(imageReference types.ImageReference, repo, tag string) error
 }
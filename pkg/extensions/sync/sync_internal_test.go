@@ -8,9 +8,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	dockerManifest "github.com/containers/image/v5/manifest"
 	"github.com/containers/image/v5/oci/layout"
@@ -21,12 +24,15 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 
 	"zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/common"
 	"zotregistry.io/zot/pkg/extensions/config"
 	syncconf "zotregistry.io/zot/pkg/extensions/config/sync"
 	"zotregistry.io/zot/pkg/extensions/lint"
 	"zotregistry.io/zot/pkg/extensions/monitoring"
 	client "zotregistry.io/zot/pkg/extensions/sync/httpclient"
 	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	localCtx "zotregistry.io/zot/pkg/requestcontext"
 	"zotregistry.io/zot/pkg/storage"
 	"zotregistry.io/zot/pkg/storage/cache"
 	storageConstants "zotregistry.io/zot/pkg/storage/constants"
@@ -162,12 +168,280 @@ func TestService(t *testing.T) {
 			URLs: []string{"http://localhost"},
 		}
 
-		service, err := New(conf, "", storage.StoreController{}, mocks.RepoDBMock{}, log.Logger{})
+		service, err := New(conf, "", storage.StoreController{}, mocks.RepoDBMock{}, NewConflictStore(), nil, nil,
+			monitoring.NewMetricsServer(false, log.Logger{}), log.Logger{})
 		So(err, ShouldBeNil)
 
 		err = service.SyncRepo("repo")
 		So(err, ShouldNotBeNil)
 	})
+
+	Convey("Images denied on demand are filtered out", t, func() {
+		conf := syncconf.RegistryConfig{
+			URLs:          []string{"http://localhost"},
+			OnDemand:      true,
+			OnDemandRules: &syncconf.OnDemandRules{Deny: []string{"alpne"}},
+		}
+
+		service, err := New(conf, "", storage.StoreController{}, mocks.RepoDBMock{}, NewConflictStore(), nil, nil,
+			monitoring.NewMetricsServer(false, log.Logger{}), log.Logger{})
+		So(err, ShouldBeNil)
+
+		err = service.SyncImage("alpne", "latest")
+		So(err, ShouldEqual, errors.ErrSyncImageFilteredOut)
+	})
+}
+
+func TestForwardIdentity(t *testing.T) {
+	Convey("Forward the user's identity to the upstream registry", t, func() {
+		authHeaders := make(chan string, 1)
+
+		server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if request.URL.Path == "/v2/_catalog" {
+				authHeaders <- request.Header.Get("Authorization")
+			}
+
+			_, _ = response.Write([]byte("{}"))
+		}))
+		defer server.Close()
+
+		conf := syncconf.RegistryConfig{
+			URLs: []string{server.URL},
+		}
+
+		service, err := New(conf, "", storage.StoreController{}, mocks.RepoDBMock{}, NewConflictStore(), nil, nil,
+			monitoring.NewMetricsServer(false, log.Logger{}), log.Logger{})
+		So(err, ShouldBeNil)
+		So(service.ForwardsIdentity(), ShouldBeFalse)
+
+		// not configured, the service credentials are used
+		userService, err := service.WithIdentity(localCtx.Identity{Username: "alice", Password: "secret"})
+		So(err, ShouldBeNil)
+		So(userService, ShouldEqual, service)
+
+		conf.ForwardIdentity = true
+
+		service, err = New(conf, "", storage.StoreController{}, mocks.RepoDBMock{}, NewConflictStore(), nil, nil,
+			monitoring.NewMetricsServer(false, log.Logger{}), log.Logger{})
+		So(err, ShouldBeNil)
+		So(service.ForwardsIdentity(), ShouldBeTrue)
+
+		userService, err = service.WithIdentity(localCtx.Identity{Username: "alice", Password: "secret"})
+		So(err, ShouldBeNil)
+		So(userService, ShouldNotEqual, service)
+
+		_, err = userService.GetNextRepo("")
+		So(err, ShouldBeNil)
+		So(<-authHeaders, ShouldEqual, "Basic YWxpY2U6c2VjcmV0")
+
+		baseService, _ := userService.(*BaseService)
+		So(baseService.remote.GetContext().DockerAuthConfig.Username, ShouldEqual, "alice")
+
+		userService, err = service.WithIdentity(localCtx.Identity{Username: "bob", BearerToken: "token"})
+		So(err, ShouldBeNil)
+
+		_, err = userService.GetNextRepo("")
+		So(err, ShouldBeNil)
+		So(<-authHeaders, ShouldEqual, "Bearer token")
+
+		baseService, _ = userService.(*BaseService)
+		So(baseService.remote.GetContext().DockerBearerRegistryToken, ShouldEqual, "token")
+		So(baseService.remote.GetContext().DockerAuthConfig, ShouldBeNil)
+
+		// anonymous users are anonymous upstream
+		userService, err = service.WithIdentity(localCtx.Identity{})
+		So(err, ShouldBeNil)
+
+		_, err = userService.GetNextRepo("")
+		So(err, ShouldBeNil)
+		So(<-authHeaders, ShouldBeEmpty)
+
+		// users don't share on demand syncs
+		onDemand := NewOnDemand(log.Logger{})
+		onDemand.Add(service, nil)
+		So(onDemand.forwardsIdentity, ShouldBeTrue)
+	})
+}
+
+func TestPeering(t *testing.T) {
+	Convey("Repo state", t, func() {
+		index := ispec.Index{
+			Manifests: []ispec.Descriptor{
+				{
+					Digest:      godigest.FromString("manifest1"),
+					Annotations: map[string]string{ispec.AnnotationRefName: "1.0"},
+				},
+				{
+					Digest: godigest.FromString("referrer"),
+				},
+			},
+		}
+
+		indexBlob, err := json.Marshal(index)
+		So(err, ShouldBeNil)
+
+		imgStore := mocks.MockedImageStore{
+			GetIndexContentFn: func(repo string) ([]byte, error) {
+				return indexBlob, nil
+			},
+		}
+
+		state, err := GetRepoState(imgStore, "repo")
+		So(err, ShouldBeNil)
+		So(state.Name, ShouldEqual, "repo")
+		So(state.Height, ShouldEqual, 2)
+		So(state.Tags, ShouldResemble, map[string]string{"1.0": godigest.FromString("manifest1").String()})
+
+		// order of manifests doesn't matter
+		index.Manifests[0], index.Manifests[1] = index.Manifests[1], index.Manifests[0]
+
+		indexBlob, err = json.Marshal(index)
+		So(err, ShouldBeNil)
+
+		reorderedState, err := GetRepoState(imgStore, "repo")
+		So(err, ShouldBeNil)
+		So(reorderedState.Digest, ShouldEqual, state.Digest)
+
+		// retagging changes the digest
+		index.Manifests[1].Annotations[ispec.AnnotationRefName] = "2.0"
+
+		indexBlob, err = json.Marshal(index)
+		So(err, ShouldBeNil)
+
+		retaggedState, err := GetRepoState(imgStore, "repo")
+		So(err, ShouldBeNil)
+		So(retaggedState.Digest, ShouldNotEqual, state.Digest)
+
+		imgStore.GetIndexContentFn = func(repo string) ([]byte, error) {
+			return []byte("invalid"), nil
+		}
+
+		_, err = GetRepoState(imgStore, "repo")
+		So(err, ShouldNotBeNil)
+
+		imgStore.GetIndexContentFn = func(repo string) ([]byte, error) {
+			return []byte{}, errors.ErrRepoNotFound
+		}
+
+		_, err = GetRepoState(imgStore, "repo")
+		So(err, ShouldEqual, errors.ErrRepoNotFound)
+	})
+
+	Convey("Conflict detection", t, func() {
+		logger := log.NewLogger("debug", "")
+
+		httpClient, err := client.New(client.Config{URL: "http://localhost"}, logger)
+		So(err, ShouldBeNil)
+
+		localDigest := godigest.FromString("local")
+		remoteDigest := godigest.FromString("remote")
+
+		var getManifestErr error
+
+		imgStore := mocks.MockedImageStore{
+			GetImageManifestFn: func(repo, reference string) ([]byte, godigest.Digest, string, error) {
+				return []byte{}, localDigest, ispec.MediaTypeImageManifest, getManifestErr
+			},
+		}
+
+		service := &BaseService{
+			client:          httpClient,
+			storeController: storage.StoreController{DefaultStore: imgStore},
+			conflicts:       NewConflictStore(),
+			peerDigests:     newPeerDigests(),
+			metrics:         monitoring.NewMetricsServer(false, logger),
+			log:             logger,
+		}
+
+		// tag not found locally, nothing to conflict with
+		getManifestErr = errors.ErrManifestNotFound
+
+		err = service.checkPeerConflict("repo", "1.0", remoteDigest)
+		So(err, ShouldBeNil)
+
+		getManifestErr = errors.ErrBadBlob
+
+		err = service.checkPeerConflict("repo", "1.0", remoteDigest)
+		So(err, ShouldEqual, errors.ErrBadBlob)
+
+		// both sides changed the tag since the last sync
+		getManifestErr = nil
+
+		err = service.checkPeerConflict("repo", "1.0", remoteDigest)
+		So(err, ShouldEqual, errors.ErrSyncTagConflict)
+
+		err = service.checkPeerConflict("repo", "1.0", remoteDigest)
+		So(err, ShouldEqual, errors.ErrSyncTagConflict)
+
+		conflicts := service.conflicts.List()
+		So(len(conflicts), ShouldEqual, 1)
+		So(conflicts[0].Registry, ShouldEqual, "http://localhost")
+		So(conflicts[0].Repo, ShouldEqual, "repo")
+		So(conflicts[0].Tag, ShouldEqual, "1.0")
+		So(conflicts[0].LocalDigest, ShouldEqual, localDigest.String())
+		So(conflicts[0].RemoteDigest, ShouldEqual, remoteDigest.String())
+
+		// the local tag didn't change since the last sync, the peer is ahead
+		service.peerDigests.set("repo", "1.0", localDigest)
+
+		err = service.checkPeerConflict("repo", "1.0", remoteDigest)
+		So(err, ShouldBeNil)
+		So(service.conflicts.List(), ShouldBeEmpty)
+
+		// same digest on both sides
+		service.peerDigests = newPeerDigests()
+
+		err = service.checkPeerConflict("repo", "1.0", localDigest)
+		So(err, ShouldBeNil)
+		So(service.conflicts.List(), ShouldBeEmpty)
+	})
+}
+
+func TestDownloadLimiter(t *testing.T) {
+	Convey("no global cap", t, func() {
+		limiter := NewDownloadLimiter(0)
+		So(limiter, ShouldBeNil)
+
+		parallelDownloads, release, err := limiter.Acquire(context.Background(), 8)
+		So(err, ShouldBeNil)
+		So(parallelDownloads, ShouldEqual, 8)
+		release()
+	})
+
+	Convey("default parallel downloads", t, func() {
+		So(getParallelDownloads(syncconf.RegistryConfig{}), ShouldEqual, DefaultMaxParallelDownloads)
+		So(getParallelDownloads(syncconf.RegistryConfig{MaxParallelDownloads: 2}), ShouldEqual, 2)
+	})
+
+	Convey("global cap is shared between image copies", t, func() {
+		limiter := NewDownloadLimiter(4)
+		So(limiter, ShouldNotBeNil)
+
+		// more parallel downloads than the global cap
+		parallelDownloads, release, err := limiter.Acquire(context.Background(), 6)
+		So(err, ShouldBeNil)
+		So(parallelDownloads, ShouldEqual, 4)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		// all slots are taken
+		_, _, err = limiter.Acquire(ctx, 1)
+		So(err, ShouldNotBeNil)
+
+		release()
+
+		parallelDownloads, release, err = limiter.Acquire(context.Background(), 2)
+		So(err, ShouldBeNil)
+		So(parallelDownloads, ShouldEqual, 2)
+
+		otherParallelDownloads, otherRelease, err := limiter.Acquire(context.Background(), 2)
+		So(err, ShouldBeNil)
+		So(otherParallelDownloads, ShouldEqual, 2)
+
+		release()
+		otherRelease()
+	})
 }
 
 func TestLocalRegistry(t *testing.T) {
@@ -279,8 +553,20 @@ func TestLocalRegistry(t *testing.T) {
 			So(ok, ShouldBeFalse)
 			So(err, ShouldBeNil)
 
+			ok, err = registry.HasImage(repoName, indexDigest)
+			So(ok, ShouldBeFalse)
+			So(err, ShouldBeNil)
+
 			err = registry.CommitImage(imageReference, repoName, "1.0")
 			So(err, ShouldBeNil)
+
+			ok, err = registry.HasImage(repoName, indexDigest)
+			So(ok, ShouldBeTrue)
+			So(err, ShouldBeNil)
+
+			ok, err = registry.HasImage(repoName, godigest.FromString("missing"))
+			So(ok, ShouldBeFalse)
+			So(err, ShouldBeNil)
 		})
 
 		Convey("trigger GetImageManifest error in CommitImage()", func() {
@@ -626,6 +912,9 @@ func TestConvertDockerLayersToOCI(t *testing.T) {
 			{
 				MediaType: dockerManifest.DockerV2Schema2LayerMediaType,
 			},
+			{
+				MediaType: dockerManifest.DockerV2Schema2LayerMediaType + "+encrypted",
+			},
 		}
 
 		err := convertDockerLayersToOCI(dockerLayers)
@@ -635,5 +924,204 @@ func TestConvertDockerLayersToOCI(t *testing.T) {
 		So(dockerLayers[1].MediaType, ShouldEqual, ispec.MediaTypeImageLayerNonDistributableGzip) //nolint: staticcheck
 		So(dockerLayers[2].MediaType, ShouldEqual, ispec.MediaTypeImageLayer)
 		So(dockerLayers[3].MediaType, ShouldEqual, ispec.MediaTypeImageLayerGzip)
+		So(dockerLayers[4].MediaType, ShouldEqual, common.MediaTypeImageLayerGzipEncrypted)
+	})
+}
+
+func TestPreflight(t *testing.T) {
+	Convey("Check the sync config against upstream registries", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			username, password, ok := request.BasicAuth()
+			if !ok || username != "user" || password != "pass" {
+				response.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			switch request.URL.Path {
+			case "/v2/":
+				response.WriteHeader(http.StatusOK)
+			case "/v2/_catalog":
+				_, _ = response.Write([]byte(`{"repositories":["alpine"]}`))
+			default:
+				response.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			response.WriteHeader(http.StatusOK)
+		}))
+		defer tlsServer.Close()
+
+		credentialsFile := path.Join(t.TempDir(), "credentials.json")
+		credentials := fmt.Sprintf(`{"%s":{"username":"user","password":"pass"}}`,
+			StripRegistryTransport(server.URL))
+		So(os.WriteFile(credentialsFile, []byte(credentials), 0o600), ShouldBeNil)
+
+		badRegex := "[a-"
+		config := &syncconf.Config{
+			CredentialsFile: credentialsFile,
+			Registries: []syncconf.RegistryConfig{
+				{
+					URLs:         []string{"http://127.0.0.1:1", server.URL},
+					PollInterval: time.Hour,
+					Content:      []syncconf.Content{{Prefix: "**"}},
+				},
+				{
+					URLs:    []string{tlsServer.URL},
+					Content: []syncconf.Content{{Prefix: "alpine", Tags: &syncconf.Tags{Regex: &badRegex}}},
+				},
+			},
+		}
+
+		report := Preflight(config, log.NewLogger("debug", ""))
+		So(report.OK, ShouldBeFalse)
+		So(report.Checks, ShouldResemble, []PreflightCheck{{Name: CheckCredentials, OK: true}})
+		So(report.Registries, ShouldHaveLength, 2)
+
+		// the first url isn't reachable, sync falls back to the second one
+		registry := report.Registries[0]
+		So(registry.OK, ShouldBeTrue)
+		So(registry.Upstreams, ShouldHaveLength, 2)
+		So(registry.Upstreams[0].OK, ShouldBeFalse)
+		So(registry.Upstreams[0].Checks[1].Name, ShouldEqual, CheckConnectivity)
+		So(registry.Upstreams[0].Checks[1].OK, ShouldBeFalse)
+		So(registry.Upstreams[0].Checks[2].Skipped, ShouldBeTrue)
+		So(registry.Upstreams[1].OK, ShouldBeTrue)
+		So(registry.Upstreams[1].Checks[3], ShouldResemble, PreflightCheck{Name: CheckCatalog, OK: true})
+
+		// the certificate of the test server isn't trusted and the tags regex doesn't compile
+		registry = report.Registries[1]
+		So(registry.OK, ShouldBeFalse)
+		So(registry.Checks[0].Name, ShouldEqual, CheckFilters)
+		So(registry.Checks[0].OK, ShouldBeFalse)
+		So(registry.Upstreams[0].Checks[0].Name, ShouldEqual, CheckTLS)
+		So(registry.Upstreams[0].Checks[0].OK, ShouldBeFalse)
+
+		Convey("Without credentials", func() {
+			config.CredentialsFile = ""
+			config.Registries = config.Registries[:1]
+
+			report := Preflight(config, log.NewLogger("debug", ""))
+			So(report.OK, ShouldBeFalse)
+			So(report.Checks, ShouldBeEmpty)
+
+			upstream := report.Registries[0].Upstreams[1]
+			So(upstream.Checks[2].Name, ShouldEqual, CheckAuth)
+			So(upstream.Checks[2].OK, ShouldBeFalse)
+			So(upstream.Checks[3].Skipped, ShouldBeTrue)
+		})
+
+		Convey("Unreadable credentials file", func() {
+			config.CredentialsFile = path.Join(t.TempDir(), "missing.json")
+
+			report := Preflight(config, log.NewLogger("debug", ""))
+			So(report.OK, ShouldBeFalse)
+			So(report.Checks[0].OK, ShouldBeFalse)
+		})
+	})
+}
+
+func TestPrefetcher(t *testing.T) {
+	Convey("prefetcher is only created if some content is prefetched", t, func() {
+		prefetcher := NewPrefetcher([]syncconf.RegistryConfig{
+			{Content: []syncconf.Content{{Prefix: "repo"}}},
+		}, storage.StoreController{}, mocks.RepoDBMock{}, log.NewLogger("debug", ""))
+		So(prefetcher, ShouldBeNil)
+
+		// nothing is queued
+		prefetcher.Prefetch("repo", godigest.FromString("image"), &syncconf.Prefetch{})
+	})
+
+	Convey("prefetch images and indexes", t, func() {
+		dir := t.TempDir()
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		imgStore := local.NewImageStore(dir, false, storageConstants.DefaultGCDelay,
+			false, false, log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		err = test.WriteImageToFileSystem(image, "repo", storeController)
+		So(err, ShouldBeNil)
+
+		imageDigest, err := image.Digest()
+		So(err, ShouldBeNil)
+
+		multiarchImage, err := test.GetRandomMultiarchImage("2.0")
+		So(err, ShouldBeNil)
+
+		err = test.WriteMultiArchImageToFileSystem(multiarchImage, "repo", storeController)
+		So(err, ShouldBeNil)
+
+		indexDigest, err := multiarchImage.Digest()
+		So(err, ShouldBeNil)
+
+		prefetcher := &Prefetcher{storeController: storeController, log: log}
+
+		blobs, size, err := prefetcher.prefetchImage("repo", imageDigest)
+		So(err, ShouldBeNil)
+		So(blobs, ShouldEqual, len(image.Layers)+1)
+
+		expectedSize := image.Manifest.Config.Size
+		for _, layer := range image.Manifest.Layers {
+			expectedSize += layer.Size
+		}
+
+		So(size, ShouldEqual, expectedSize)
+
+		blobs, _, err = prefetcher.prefetchImage("repo", indexDigest)
+		So(err, ShouldBeNil)
+
+		expectedBlobs := 0
+		for _, image := range multiarchImage.Images {
+			expectedBlobs += len(image.Layers) + 1
+		}
+
+		So(blobs, ShouldEqual, expectedBlobs)
+
+		_, _, err = prefetcher.prefetchImage("repo", godigest.FromString("missing"))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("prefetch only the images of popular repos", t, func() {
+		repoDB := mocks.RepoDBMock{
+			GetRepoMetaFn: func(repo string) (repodb.RepoMetadata, error) {
+				if repo != "popular" {
+					return repodb.RepoMetadata{}, errors.ErrRepoMetaNotFound
+				}
+
+				return repodb.RepoMetadata{
+					Statistics: map[string]repodb.DescriptorStatistics{
+						"sha256:1": {DownloadCount: 3},
+						"sha256:2": {DownloadCount: 2},
+					},
+				}, nil
+			},
+		}
+
+		prefetcher := &Prefetcher{repoDB: repoDB, queue: make(chan prefetchTask, 1), log: log.NewLogger("debug", "")}
+
+		So(prefetcher.isPopular("other", 0), ShouldBeTrue)
+		So(prefetcher.isPopular("other", 1), ShouldBeFalse)
+		So(prefetcher.isPopular("popular", 5), ShouldBeTrue)
+		So(prefetcher.isPopular("popular", 6), ShouldBeFalse)
+
+		prefetcher.Prefetch("other", godigest.FromString("image"), &syncconf.Prefetch{MinDownloads: 1})
+		So(len(prefetcher.queue), ShouldEqual, 0)
+
+		prefetcher.Prefetch("popular", godigest.FromString("image"), nil)
+		So(len(prefetcher.queue), ShouldEqual, 0)
+
+		prefetcher.Prefetch("popular", godigest.FromString("image"), &syncconf.Prefetch{MinDownloads: 5})
+		So(len(prefetcher.queue), ShouldEqual, 1)
+
+		// the queue is full
+		prefetcher.Prefetch("popular", godigest.FromString("other"), &syncconf.Prefetch{MinDownloads: 5})
+		So(len(prefetcher.queue), ShouldEqual, 1)
 	})
 }
@@ -5486,6 +5486,134 @@ func TestSyncWithDiffDigest(t *testing.T) {
 	})
 }
 
+func TestPeering(t *testing.T) {
+	Convey("Verify peered registries report conflicting tags instead of overwriting them", t, func() {
+		updateDuration, _ := time.ParseDuration("30m")
+
+		sctlr, srcBaseURL, _, _, _ := makeUpstreamServer(t, false, false)
+
+		defaultVal := true
+
+		// the upstream peers with the downstream too, so that it exposes its repos state
+		sctlr.Config.Extensions.Sync = &syncconf.Config{
+			Enable: &defaultVal,
+			Registries: []syncconf.RegistryConfig{
+				{
+					URLs:    []string{"http://127.0.0.1:1"},
+					Peering: true,
+				},
+			},
+		}
+
+		scm := test.NewControllerManager(sctlr)
+		scm.StartAndWait(sctlr.Config.HTTP.Port)
+		defer scm.StopServer()
+
+		var tlsVerify bool
+
+		syncRegistryConfig := syncconf.RegistryConfig{
+			Content: []syncconf.Content{
+				{
+					Prefix: testImage,
+				},
+			},
+			URLs:         []string{srcBaseURL},
+			PollInterval: updateDuration,
+			TLSVerify:    &tlsVerify,
+			OnDemand:     false,
+			Peering:      true,
+		}
+
+		syncConfig := &syncconf.Config{
+			Enable:     &defaultVal,
+			Registries: []syncconf.RegistryConfig{syncRegistryConfig},
+		}
+
+		dctlr, destBaseURL, destDir, _ := makeDownstreamServer(t, false, syncConfig)
+
+		// both registries start with the same image
+		test.CopyTestFiles("../../../test/data", destDir)
+
+		resp, err := resty.R().Get(srcBaseURL + constants.FullPeeringPrefix + "/repos/" + testImage)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var srcState sync.RepoState
+
+		err = json.Unmarshal(resp.Body(), &srcState)
+		So(err, ShouldBeNil)
+		So(srcState.Name, ShouldEqual, testImage)
+		So(srcState.Height, ShouldBeGreaterThan, 0)
+		So(srcState.Tags, ShouldContainKey, testImageTag)
+
+		localDigest := srcState.Tags[testImageTag]
+
+		resp, err = resty.R().Get(srcBaseURL + constants.FullPeeringPrefix + "/repos/inexistent")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		// then the tag is updated on the upstream while the downstream still has the old one
+		resp, err = resty.R().Get(srcBaseURL + "/v2/" + testImage + "/manifests/" + testImageTag)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var manifest ispec.Manifest
+
+		err = json.Unmarshal(resp.Body(), &manifest)
+		So(err, ShouldBeNil)
+
+		blob := []byte("new layer")
+		manifest.Layers = append(manifest.Layers, ispec.Descriptor{
+			MediaType: ispec.MediaTypeImageLayer,
+			Digest:    pushBlob(srcBaseURL, testImage, blob),
+			Size:      int64(len(blob)),
+		})
+
+		manifestBody, err := json.Marshal(manifest)
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().SetHeader("Content-type", ispec.MediaTypeImageManifest).
+			SetBody(manifestBody).
+			Put(srcBaseURL + "/v2/" + testImage + "/manifests/" + testImageTag)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		remoteDigest := godigest.FromBytes(manifestBody).String()
+
+		dcm := test.NewControllerManager(dctlr)
+		dcm.StartAndWait(dctlr.Config.HTTP.Port)
+		defer dcm.StopServer()
+
+		waitSyncFinish(dctlr.Config.Log.Output)
+
+		// the downstream tag is left untouched
+		resp, err = resty.R().Get(destBaseURL + "/v2/" + testImage + "/manifests/" + testImageTag)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Docker-Content-Digest"), ShouldEqual, localDigest)
+
+		resp, err = resty.R().Get(destBaseURL + constants.FullPeeringPrefix + "/conflicts")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var conflicts sync.ConflictList
+
+		err = json.Unmarshal(resp.Body(), &conflicts)
+		So(err, ShouldBeNil)
+		So(len(conflicts.Conflicts), ShouldEqual, 1)
+		So(conflicts.Conflicts[0].Registry, ShouldEqual, srcBaseURL)
+		So(conflicts.Conflicts[0].Repo, ShouldEqual, testImage)
+		So(conflicts.Conflicts[0].Tag, ShouldEqual, testImageTag)
+		So(conflicts.Conflicts[0].LocalDigest, ShouldEqual, localDigest)
+		So(conflicts.Conflicts[0].RemoteDigest, ShouldEqual, remoteDigest)
+
+		found, err := test.ReadLogFileAndSearchString(dctlr.Config.Log.Output,
+			"sync: tag conflicts with peer, skipping", 15*time.Second)
+		So(err, ShouldBeNil)
+		So(found, ShouldBeTrue)
+	})
+}
+
 func TestSyncSignaturesDiff(t *testing.T) {
 	Convey("Verify sync detects changes in the upstream signatures", t, func() {
 		updateDuration, _ := time.ParseDuration("10s")
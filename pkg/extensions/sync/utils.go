@@ -50,7 +50,7 @@ func getFileCredentials(filepath string) (syncconf.CredentialsFile, error) {
 	return creds, nil
 }
 
-func getUpstreamContext(certDir, username, password string, tlsVerify bool) *types.SystemContext {
+func getUpstreamContext(certDir, username, password, bearerToken string, tlsVerify bool) *types.SystemContext {
 	upstreamCtx := &types.SystemContext{}
 	upstreamCtx.DockerCertPath = certDir
 	upstreamCtx.DockerDaemonCertPath = certDir
@@ -63,7 +63,9 @@ func getUpstreamContext(certDir, username, password string, tlsVerify bool) *typ
 		upstreamCtx.DockerInsecureSkipTLSVerify = types.NewOptionalBool(true)
 	}
 
-	if username != "" && password != "" {
+	if bearerToken != "" {
+		upstreamCtx.DockerBearerRegistryToken = bearerToken
+	} else if username != "" && password != "" {
 		upstreamCtx.DockerAuthConfig = &types.DockerAuthConfig{
 			Username: username,
 			Password: password,
@@ -228,6 +230,9 @@ func convertDockerLayersToOCI(dockerLayers []ispec.Descriptor) error {
 			dockerLayers[idx].MediaType = ispec.MediaTypeImageLayer
 		case manifest.DockerV2Schema2LayerMediaType:
 			dockerLayers[idx].MediaType = ispec.MediaTypeImageLayerGzip
+		case manifest.DockerV2Schema2LayerMediaType + "+encrypted":
+			// layers encrypted with ocicrypt are synced as they are
+			dockerLayers[idx].MediaType = common.MediaTypeImageLayerGzipEncrypted
 		default:
 			return zerr.ErrMediaTypeNotSupported
 		}
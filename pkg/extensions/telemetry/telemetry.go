@@ -0,0 +1,210 @@
+//go:build telemetry
+// +build telemetry
+
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	guuid "github.com/gofrs/uuid"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage"
+	storageCommon "zotregistry.io/zot/pkg/storage/common"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+// InstanceIDFileName is the file, under the root directory of the default store, holding the random id
+// which tells the reports of an instance apart.
+const InstanceIDFileName = "telemetry-id"
+
+const endpointTimeout = 30 * time.Second
+
+// Report holds anonymous usage statistics: no repo, user or host names are ever reported.
+type Report struct {
+	// random id generated on first use, it is not derived from anything identifying the instance
+	InstanceID   string    `json:"instanceID"`
+	Version      string    `json:"version"`
+	Commit       string    `json:"commit"`
+	BinaryType   string    `json:"binaryType"`
+	GoVersion    string    `json:"goVersion"`
+	OS           string    `json:"os"`
+	Arch         string    `json:"arch"`
+	Extensions   []string  `json:"extensions"`
+	Repositories int       `json:"repositories"`
+	Images       int       `json:"images"`
+	GeneratedAt  time.Time `json:"generatedAt"`
+}
+
+// Reporter sums up the usage of the registry and posts it to the configured endpoint.
+type Reporter struct {
+	config          *config.Config
+	storeController storage.StoreController
+	client          *http.Client
+	instanceID      string
+	log             log.Logger
+}
+
+// NewReporter creates a reporter, loading the instance id saved under the root directory or creating it.
+func NewReporter(config *config.Config, storeController storage.StoreController, log log.Logger) *Reporter {
+	return &Reporter{
+		config:          config,
+		storeController: storeController,
+		client:          &http.Client{Timeout: endpointTimeout},
+		instanceID:      getInstanceID(config.Storage.RootDirectory, log),
+		log:             log,
+	}
+}
+
+// Generate sums up the usage of the registry, repos which can't be read are skipped.
+func (reporter *Reporter) Generate() Report {
+	report := Report{
+		InstanceID:  reporter.instanceID,
+		Version:     reporter.config.ReleaseTag,
+		Commit:      reporter.config.Commit,
+		BinaryType:  reporter.config.BinaryType,
+		GoVersion:   reporter.config.GoVersion,
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Extensions:  GetEnabledExtensions(reporter.config),
+		GeneratedAt: time.Now(),
+	}
+
+	for _, imgStore := range reporter.storeController.UniqueImageStores() {
+		repos, err := imgStore.GetRepositories()
+		if err != nil {
+			reporter.log.Error().Err(err).Str("rootDir", imgStore.RootDir()).Msg("telemetry: unable to list repos")
+
+			continue
+		}
+
+		for _, repo := range repos {
+			index, err := storageCommon.GetIndex(imgStore, repo, reporter.log.Logger)
+			if err != nil {
+				continue
+			}
+
+			report.Repositories++
+			report.Images += len(index.Manifests)
+		}
+	}
+
+	return report
+}
+
+// Send generates a report and posts it to the configured endpoint.
+func (reporter *Reporter) Send(ctx context.Context) error {
+	report := reporter.Generate()
+
+	buf, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reporter.config.Extensions.Telemetry.Endpoint,
+		bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := reporter.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %d", zerr.ErrTelemetryEndpointFailed, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetEnabledExtensions returns the sorted names of the extensions enabled in the config.
+func GetEnabledExtensions(config *config.Config) []string {
+	extensions := []string{}
+
+	if config.Extensions == nil {
+		return extensions
+	}
+
+	isEnabled := func(enable *bool) bool {
+		return enable != nil && *enable
+	}
+
+	exts := config.Extensions
+
+	for name, enabled := range map[string]bool{
+		"search":       exts.Search != nil && isEnabled(exts.Search.Enable),
+		"cve":          exts.Search != nil && isEnabled(exts.Search.Enable) && exts.Search.CVE != nil,
+		"sync":         exts.Sync != nil && isEnabled(exts.Sync.Enable),
+		"metrics":      exts.Metrics != nil && isEnabled(exts.Metrics.Enable),
+		"scrub":        exts.Scrub != nil && isEnabled(exts.Scrub.Enable),
+		"lint":         exts.Lint != nil && isEnabled(exts.Lint.Enable),
+		"ui":           exts.UI != nil && isEnabled(exts.UI.Enable),
+		"mgmt":         exts.Mgmt != nil && isEnabled(exts.Mgmt.Enable),
+		"userActivity": exts.UserActivity != nil && isEnabled(exts.UserActivity.Enable),
+		"telemetry":    exts.Telemetry != nil && isEnabled(exts.Telemetry.Enable),
+	} {
+		if enabled {
+			extensions = append(extensions, name)
+		}
+	}
+
+	sort.Strings(extensions)
+
+	return extensions
+}
+
+// getInstanceID returns the instance id saved under rootDir, a new one is created if there is none,
+// if it can't be saved the reports of this run share a temporary id.
+func getInstanceID(rootDir string, log log.Logger) string {
+	filePath := path.Join(rootDir, InstanceIDFileName)
+
+	buf, err := os.ReadFile(filePath)
+	if err == nil && strings.TrimSpace(string(buf)) != "" {
+		return strings.TrimSpace(string(buf))
+	}
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Error().Err(err).Str("file", filePath).Msg("telemetry: unable to read instance id")
+	}
+
+	uuid, err := guuid.NewV4()
+	if err != nil {
+		log.Error().Err(err).Msg("telemetry: unable to generate instance id")
+
+		return ""
+	}
+
+	if err := os.MkdirAll(rootDir, storageConstants.DefaultDirPerms); err != nil {
+		log.Error().Err(err).Str("file", filePath).Msg("telemetry: unable to create instance id dir")
+
+		return uuid.String()
+	}
+
+	if err := os.WriteFile(filePath, []byte(uuid.String()), storageConstants.DefaultFilePerms); err != nil {
+		log.Error().Err(err).Str("file", filePath).Msg("telemetry: unable to save instance id")
+	}
+
+	return uuid.String()
+}
@@ -0,0 +1,152 @@
+//go:build telemetry
+// +build telemetry
+
+package telemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	extconf "zotregistry.io/zot/pkg/extensions/config"
+	"zotregistry.io/zot/pkg/extensions/telemetry"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+var ErrTestError = errors.New("test error")
+
+func TestReporter(t *testing.T) {
+	log := log.NewLogger("debug", "")
+
+	enable := true
+
+	newConfig := func(rootDir, endpoint string) *config.Config {
+		conf := config.New()
+		conf.Storage.RootDirectory = rootDir
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search:    &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &enable}},
+			Telemetry: &extconf.TelemetryConfig{BaseConfig: extconf.BaseConfig{Enable: &enable}, Endpoint: endpoint},
+		}
+
+		return conf
+	}
+
+	indexes := map[string]ispec.Index{
+		"app": {Manifests: []ispec.Descriptor{{Digest: "sha256:1"}, {Digest: "sha256:2"}}},
+		"db":  {Manifests: []ispec.Descriptor{{Digest: "sha256:3"}}},
+	}
+
+	imgStore := mocks.MockedImageStore{
+		RootDirFn: func() string {
+			return "/zot"
+		},
+		GetRepositoriesFn: func() ([]string, error) {
+			return []string{"app", "db", "broken"}, nil
+		},
+		GetIndexContentFn: func(repo string) ([]byte, error) {
+			index, ok := indexes[repo]
+			if !ok {
+				return nil, ErrTestError
+			}
+
+			return json.Marshal(index)
+		},
+	}
+
+	storeController := storage.StoreController{
+		DefaultStore: imgStore,
+		// substores sharing the root dir of another store are only counted once
+		SubStore: map[string]storageTypes.ImageStore{"/a": imgStore},
+	}
+
+	Convey("Generate a report", t, func() {
+		rootDir := t.TempDir()
+		reporter := telemetry.NewReporter(newConfig(rootDir, ""), storeController, log)
+
+		report := reporter.Generate()
+		So(report.InstanceID, ShouldNotBeEmpty)
+		So(report.Extensions, ShouldResemble, []string{"search", "telemetry"})
+		So(report.Repositories, ShouldEqual, 2)
+		So(report.Images, ShouldEqual, 3)
+
+		// the instance id is kept across restarts
+		buf, err := os.ReadFile(path.Join(rootDir, telemetry.InstanceIDFileName))
+		So(err, ShouldBeNil)
+		So(string(buf), ShouldEqual, report.InstanceID)
+
+		reporter = telemetry.NewReporter(newConfig(rootDir, ""), storeController, log)
+		So(reporter.Generate().InstanceID, ShouldEqual, report.InstanceID)
+	})
+
+	Convey("Repos which can't be listed are skipped", t, func() {
+		imgStore := mocks.MockedImageStore{
+			GetRepositoriesFn: func() ([]string, error) {
+				return nil, ErrTestError
+			},
+		}
+
+		reporter := telemetry.NewReporter(newConfig(t.TempDir(), ""),
+			storage.StoreController{DefaultStore: imgStore}, log)
+
+		report := reporter.Generate()
+		So(report.Repositories, ShouldEqual, 0)
+		So(report.Images, ShouldEqual, 0)
+	})
+
+	Convey("Send a report to the endpoint", t, func() {
+		var received telemetry.Report
+
+		status := http.StatusOK
+
+		server := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+			_ = json.NewDecoder(req.Body).Decode(&received)
+
+			rsp.WriteHeader(status)
+		}))
+		defer server.Close()
+
+		reporter := telemetry.NewReporter(newConfig(t.TempDir(), server.URL), storeController, log)
+
+		err := reporter.Send(context.Background())
+		So(err, ShouldBeNil)
+		So(received.InstanceID, ShouldNotBeEmpty)
+		So(received.Images, ShouldEqual, 3)
+
+		status = http.StatusInternalServerError
+
+		err = reporter.Send(context.Background())
+		So(errors.Is(err, zerr.ErrTelemetryEndpointFailed), ShouldBeTrue)
+
+		server.Close()
+
+		err = reporter.Send(context.Background())
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Enabled extensions", t, func() {
+		conf := config.New()
+		So(telemetry.GetEnabledExtensions(conf), ShouldBeEmpty)
+
+		disable := false
+		conf.Extensions = &extconf.ExtensionConfig{
+			Scrub:     &extconf.ScrubConfig{BaseConfig: extconf.BaseConfig{Enable: &enable}},
+			Mgmt:      &extconf.MgmtConfig{BaseConfig: extconf.BaseConfig{Enable: &disable}},
+			Metrics:   &extconf.MetricsConfig{BaseConfig: extconf.BaseConfig{Enable: &enable}},
+			Telemetry: &extconf.TelemetryConfig{},
+		}
+		So(telemetry.GetEnabledExtensions(conf), ShouldResemble, []string{"metrics", "scrub"})
+	})
+}
@@ -0,0 +1,88 @@
+package bolt
+
+import (
+	"sync"
+
+	"go.etcd.io/bbolt"
+)
+
+// Batcher commits the writes made while another write is being committed together, in a single transaction,
+// so concurrent writers share the cost of a commit. Unlike bbolt's Batch, a write made while nothing
+// else is being committed isn't delayed. Like bbolt's Batch, the writes may be run more than once, if
+// another write of their batch fails, so they have to be idempotent.
+type Batcher struct {
+	db         *bbolt.DB
+	lock       sync.Mutex
+	pending    []*batchCall
+	committing bool
+}
+
+type batchCall struct {
+	update func(tx *bbolt.Tx) error
+	err    chan error
+}
+
+func NewBatcher(db *bbolt.DB) *Batcher {
+	return &Batcher{db: db}
+}
+
+// Update runs a write, batched with the other writes waiting to be committed.
+func (batcher *Batcher) Update(update func(tx *bbolt.Tx) error) error {
+	call := &batchCall{update: update, err: make(chan error, 1)}
+
+	batcher.lock.Lock()
+	batcher.pending = append(batcher.pending, call)
+
+	if batcher.committing {
+		batcher.lock.Unlock()
+
+		return <-call.err
+	}
+
+	batcher.committing = true
+	batcher.lock.Unlock()
+
+	// commit the batch of this write, then let another goroutine commit the writes pending meanwhile
+	batcher.commit()
+
+	return <-call.err
+}
+
+func (batcher *Batcher) commit() {
+	batcher.lock.Lock()
+	calls := batcher.pending
+	batcher.pending = nil
+	batcher.lock.Unlock()
+
+	err := batcher.db.Update(func(tx *bbolt.Tx) error {
+		for _, call := range calls {
+			if err := call.update(tx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err == nil || len(calls) == 1 {
+		for _, call := range calls {
+			call.err <- err
+		}
+	} else {
+		// the whole batch was rolled back, the writes are retried one by one so only the failing ones fail
+		for _, call := range calls {
+			call.err <- batcher.db.Update(call.update)
+		}
+	}
+
+	batcher.lock.Lock()
+	defer batcher.lock.Unlock()
+
+	if len(batcher.pending) == 0 {
+		batcher.committing = false
+
+		return
+	}
+
+	go batcher.commit()
+}
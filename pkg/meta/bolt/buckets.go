@@ -9,4 +9,5 @@ const (
 	VersionBucket      = "Version"
 	StarredReposKey    = "StarredReposKey"
 	BookmarkedReposKey = "BookmarkedReposKey"
+	UserActivityKey    = "UserActivityKey"
 )
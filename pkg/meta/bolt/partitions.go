@@ -0,0 +1,204 @@
+package bolt
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// RepoMetadataPartitions is the number of db files the metadata of the repos is spread across, by hash of
+// the repo name. A bolt db allows a single writer at a time, so writes to repos of different partitions,
+// e.g. made by sync and pushes, aren't committed one after the other.
+const RepoMetadataPartitions = 16
+
+// RepoMetadataPartition returns the index of the partition holding the metadata of a repo.
+func RepoMetadataPartition(repo []byte) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write(repo)
+
+	return int(hash.Sum32() % RepoMetadataPartitions)
+}
+
+// RepoMetaPartitions are the db files holding the metadata of the repos, in their RepoMetadata bucket, next
+// to the metadata db.
+type RepoMetaPartitions struct {
+	dbs      []*bbolt.DB
+	batchers []*Batcher
+}
+
+var (
+	openPartitions     = map[*bbolt.DB]*RepoMetaPartitions{}
+	openPartitionsLock sync.Mutex
+)
+
+// GetRepoMetaPartitions returns the partitions of a metadata db, opening and creating their files the first
+// time, a db file can't be opened twice.
+func GetRepoMetaPartitions(boltDB *bbolt.DB) (*RepoMetaPartitions, error) {
+	const perms = 0o600
+
+	openPartitionsLock.Lock()
+	defer openPartitionsLock.Unlock()
+
+	if partitions, ok := openPartitions[boltDB]; ok {
+		return partitions, nil
+	}
+
+	partitions := &RepoMetaPartitions{}
+
+	for index := 0; index < RepoMetadataPartitions; index++ {
+		partitionPath := fmt.Sprintf("%s-%02d.db", strings.TrimSuffix(boltDB.Path(), ".db"), index)
+
+		partitionDB, err := bbolt.Open(partitionPath, perms, &bbolt.Options{Timeout: time.Second * 10})
+		if err != nil {
+			_ = partitions.close()
+
+			return nil, err
+		}
+
+		partitions.dbs = append(partitions.dbs, partitionDB)
+		partitions.batchers = append(partitions.batchers, NewBatcher(partitionDB))
+
+		err = partitionDB.Update(func(tx *bbolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte(RepoMetadataBucket))
+
+			return err
+		})
+		if err != nil {
+			_ = partitions.close()
+
+			return nil, err
+		}
+	}
+
+	openPartitions[boltDB] = partitions
+
+	return partitions, nil
+}
+
+// DB returns the db of the partition holding the metadata of a repo.
+func (partitions *RepoMetaPartitions) DB(repo string) *bbolt.DB {
+	return partitions.dbs[RepoMetadataPartition([]byte(repo))]
+}
+
+// Batcher returns the batcher of the writes to the partition holding the metadata of a repo.
+func (partitions *RepoMetaPartitions) Batcher(repo string) *Batcher {
+	return partitions.batchers[RepoMetadataPartition([]byte(repo))]
+}
+
+// View runs view with the RepoMetadata buckets of all the partitions, each read in its own transaction.
+func (partitions *RepoMetaPartitions) View(view func(buckets RepoMetaBuckets) error) error {
+	buckets := RepoMetaBuckets{}
+
+	for _, partitionDB := range partitions.dbs {
+		tx, err := partitionDB.Begin(false)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = tx.Rollback() }()
+
+		buckets.partitions = append(buckets.partitions, tx.Bucket([]byte(RepoMetadataBucket)))
+	}
+
+	return view(buckets)
+}
+
+func (partitions *RepoMetaPartitions) close() error {
+	var closeErr error
+
+	for _, partitionDB := range partitions.dbs {
+		if err := partitionDB.Close(); err != nil {
+			closeErr = err
+		}
+	}
+
+	return closeErr
+}
+
+// RepoMetaBuckets gives read access to the RepoMetadata buckets of all the partitions as if they were
+// a single bucket.
+type RepoMetaBuckets struct {
+	partitions []*bbolt.Bucket
+}
+
+// Get returns the metadata of a repo, or nil if there's none.
+func (buckets RepoMetaBuckets) Get(repo []byte) []byte {
+	return buckets.partitions[RepoMetadataPartition(repo)].Get(repo)
+}
+
+// Cursor returns a cursor over the metadata of all the repos, sorted by repo name like in a single bucket.
+func (buckets RepoMetaBuckets) Cursor() *RepoMetaCursor {
+	cursor := &RepoMetaCursor{}
+
+	for _, partition := range buckets.partitions {
+		cursor.partitions = append(cursor.partitions, &partitionCursor{cursor: partition.Cursor()})
+	}
+
+	return cursor
+}
+
+// RepoMetaCursor iterates over the partitions at once, merging them.
+type RepoMetaCursor struct {
+	partitions []*partitionCursor
+	current    *partitionCursor
+}
+
+type partitionCursor struct {
+	cursor *bbolt.Cursor
+	key    []byte
+	value  []byte
+}
+
+// First moves to the first repo and returns its name and metadata.
+func (cursor *RepoMetaCursor) First() ([]byte, []byte) {
+	for _, partition := range cursor.partitions {
+		partition.key, partition.value = partition.cursor.First()
+	}
+
+	return cursor.next()
+}
+
+// Next moves to the next repo and returns its name and metadata, or nil at the end.
+func (cursor *RepoMetaCursor) Next() ([]byte, []byte) {
+	if cursor.current == nil {
+		return nil, nil
+	}
+
+	cursor.current.key, cursor.current.value = cursor.current.cursor.Next()
+
+	return cursor.next()
+}
+
+// Seek moves to the first repo named seek or after, and returns its name and metadata.
+func (cursor *RepoMetaCursor) Seek(seek []byte) ([]byte, []byte) {
+	for _, partition := range cursor.partitions {
+		partition.key, partition.value = partition.cursor.Seek(seek)
+	}
+
+	return cursor.next()
+}
+
+func (cursor *RepoMetaCursor) next() ([]byte, []byte) {
+	cursor.current = nil
+
+	for _, partition := range cursor.partitions {
+		if partition.key == nil {
+			continue
+		}
+
+		if cursor.current == nil || bytes.Compare(partition.key, cursor.current.key) < 0 {
+			cursor.current = partition
+		}
+	}
+
+	if cursor.current == nil {
+		return nil, nil
+	}
+
+	return cursor.current.key, cursor.current.value
+}
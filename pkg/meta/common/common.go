@@ -10,6 +10,7 @@ import (
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 
 	zerr "zotregistry.io/zot/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
 	"zotregistry.io/zot/pkg/meta/repodb"
 )
 
@@ -232,9 +233,127 @@ func AcceptedByFilter(filter repodb.Filter, data repodb.FilterData) bool {
 		return false
 	}
 
+	for _, annotation := range filter.RegistryAnnotations {
+		if annotation != nil && !matchesRegistryAnnotation(data.RegistryAnnotations, *annotation) {
+			return false
+		}
+	}
+
+	// images which weren't scanned are left out since their CVEs are not known
+	if filter.MaxCVESeverity != nil && (data.MaxCVESeverity == "" ||
+		GetCVESeverityRank(data.MaxCVESeverity) > GetCVESeverityRank(*filter.MaxCVESeverity)) {
+		return false
+	}
+
+	if filter.LastUpdatedAfter != nil && !data.LastUpdated.After(*filter.LastUpdatedAfter) {
+		return false
+	}
+
+	for _, license := range filter.ExcludedLicenses {
+		if license != nil && matchesLicense(data.Licenses, *license) {
+			return false
+		}
+	}
+
 	return true
 }
 
+// GetImageMaxCVESeverity returns the severity of the most severe CVE found by the last scan of an image which
+// isn't acknowledged now, or an empty string if the image wasn't scanned.
+func GetImageMaxCVESeverity(repoMeta repodb.RepoMetadata, digest string, now time.Time) string {
+	summary, found := repoMeta.VulnerabilitySummaries[digest]
+	if !found {
+		return ""
+	}
+
+	// no CVE was found, or the severities were not recorded
+	if len(summary.Severities) == 0 {
+		return summary.MaxSeverity
+	}
+
+	acknowledgements := repodb.GetActiveCVEAcknowledgements(repoMeta, digest, now)
+	maxSeverity := "NONE"
+
+	for cveID, severity := range summary.Severities {
+		if _, acknowledged := acknowledgements[cveID]; acknowledged {
+			continue
+		}
+
+		if GetCVESeverityRank(severity) < 0 {
+			severity = "UNKNOWN"
+		}
+
+		if GetCVESeverityRank(severity) > GetCVESeverityRank(maxSeverity) {
+			maxSeverity = strings.ToUpper(severity)
+		}
+	}
+
+	return maxSeverity
+}
+
+// GetHigherCVESeverity returns the higher of two CVE severities, an empty severity, of an image which wasn't
+// scanned, is lower than any other.
+func GetHigherCVESeverity(severity1, severity2 string) string {
+	if severity1 == "" || (severity2 != "" && GetCVESeverityRank(severity2) > GetCVESeverityRank(severity1)) {
+		return severity2
+	}
+
+	return severity1
+}
+
+// GetCVESeverityRank orders the CVE severities from NONE, for images without CVEs, to CRITICAL.
+// It returns -1 for unknown severities.
+func GetCVESeverityRank(severity string) int {
+	for rank, cveSeverity := range []string{"NONE", "UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"} {
+		if strings.EqualFold(severity, cveSeverity) {
+			return rank
+		}
+	}
+
+	return -1
+}
+
+// GetRegistryAnnotationsList returns the registry annotations as "key=value" entries used for filtering.
+func GetRegistryAnnotationsList(annotations map[string]string) []string {
+	annotationsList := make([]string, 0, len(annotations))
+
+	for key, value := range annotations {
+		annotationsList = append(annotationsList, key+"="+value)
+	}
+
+	return annotationsList
+}
+
+// matchesRegistryAnnotation checks the "key=value" entries against a "key=value" or "key" criteria.
+func matchesRegistryAnnotation(annotationsList []string, criteria string) bool {
+	for _, annotation := range annotationsList {
+		if annotation == criteria || (!strings.Contains(criteria, "=") && strings.HasPrefix(annotation, criteria+"=")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesLicense checks if one of the licenses is the given license or one of its variants,
+// e.g. "GPL-3.0" matches "GPL-3.0-only", "GPL-3.0-or-later" and "GPL-3.0+".
+func matchesLicense(licenses []string, license string) bool {
+	license = strings.ToLower(strings.TrimSpace(license))
+	if license == "" {
+		return false
+	}
+
+	for _, candidate := range licenses {
+		candidate = strings.ToLower(candidate)
+
+		if candidate == license || candidate == license+"+" || strings.HasPrefix(candidate, license+"-") {
+			return true
+		}
+	}
+
+	return false
+}
+
 func containsString(strSlice []string, str string) bool {
 	for _, val := range strSlice {
 		if strings.EqualFold(val, str) {
@@ -296,6 +415,18 @@ func CheckImageLastUpdated(repoLastUpdated time.Time, isSigned bool, noImageChec
 	return repoLastUpdated, noImageChecked, isSigned
 }
 
+// GetNewestImageCVESeverity returns the CVE severity of the image if it's newer than the images checked
+// before, the same way CheckImageLastUpdated does for the signature, so repos are filtered by their newest image.
+func GetNewestImageCVESeverity(repoLastUpdated time.Time, noImageChecked bool, maxCVESeverity string,
+	imageFilterData repodb.FilterData,
+) string {
+	if noImageChecked || repoLastUpdated.Before(imageFilterData.LastUpdated) {
+		return imageFilterData.MaxCVESeverity
+	}
+
+	return maxCVESeverity
+}
+
 func FilterDataByRepo(foundRepos []repodb.RepoMetadata, manifestMetadataMap map[string]repodb.ManifestMetadata,
 	indexDataMap map[string]repodb.IndexData,
 ) (map[string]repodb.ManifestMetadata, map[string]repodb.IndexData, error) {
@@ -308,9 +439,9 @@ func FilterDataByRepo(foundRepos []repodb.RepoMetadata, manifestMetadataMap map[
 	for _, repoMeta := range foundRepos {
 		for _, descriptor := range repoMeta.Tags {
 			switch descriptor.MediaType {
-			case ispec.MediaTypeImageManifest:
+			case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 				foundManifestMetadataMap[descriptor.Digest] = manifestMetadataMap[descriptor.Digest]
-			case ispec.MediaTypeImageIndex:
+			case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 				indexData := indexDataMap[descriptor.Digest]
 
 				var indexContent ispec.Index
@@ -345,7 +476,7 @@ func FetchDataForRepos(repoDB repodb.RepoDB, foundRepos []repodb.RepoMetadata,
 	for idx := range foundRepos {
 		for _, descriptor := range foundRepos[idx].Tags {
 			switch descriptor.MediaType {
-			case ispec.MediaTypeImageManifest:
+			case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 				manifestData, err := repoDB.GetManifestData(godigest.Digest(descriptor.Digest))
 				if err != nil {
 					return map[string]repodb.ManifestMetadata{}, map[string]repodb.IndexData{}, err
@@ -355,7 +486,7 @@ func FetchDataForRepos(repoDB repodb.RepoDB, foundRepos []repodb.RepoMetadata,
 					ManifestBlob: manifestData.ManifestBlob,
 					ConfigBlob:   manifestData.ConfigBlob,
 				}
-			case ispec.MediaTypeImageIndex:
+			case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 				indexData, err := repoDB.GetIndexData(godigest.Digest(descriptor.Digest))
 				if err != nil {
 					return map[string]repodb.ManifestMetadata{}, map[string]repodb.IndexData{}, err
@@ -30,6 +30,113 @@ func TestUtils(t *testing.T) {
 		So(res, ShouldBeFalse)
 	})
 
+	Convey("AcceptedByFilter with registry annotations", t, func() {
+		owner, env, missing := "owner=team-a", "environment", "ticket"
+		filterData := repodb.FilterData{
+			RegistryAnnotations: common.GetRegistryAnnotationsList(map[string]string{
+				"owner":       "team-a",
+				"environment": "prod",
+			}),
+		}
+
+		So(common.AcceptedByFilter(repodb.Filter{}, filterData), ShouldBeTrue)
+		So(common.AcceptedByFilter(repodb.Filter{RegistryAnnotations: []*string{&owner, &env}}, filterData), ShouldBeTrue)
+		So(common.AcceptedByFilter(repodb.Filter{RegistryAnnotations: []*string{&owner, &missing}}, filterData),
+			ShouldBeFalse)
+
+		otherOwner := "owner=team-b"
+		So(common.AcceptedByFilter(repodb.Filter{RegistryAnnotations: []*string{&otherOwner}}, filterData), ShouldBeFalse)
+
+		// the key has to match entirely
+		prefix := "own"
+		So(common.AcceptedByFilter(repodb.Filter{RegistryAnnotations: []*string{&prefix}}, filterData), ShouldBeFalse)
+	})
+
+	Convey("AcceptedByFilter with CVE severity and update time", t, func() {
+		high, medium, critical := "HIGH", "MEDIUM", "critical"
+		lastUpdated := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+		before, after := lastUpdated.Add(-time.Hour), lastUpdated.Add(time.Hour)
+
+		filterData := repodb.FilterData{MaxCVESeverity: "HIGH", LastUpdated: lastUpdated}
+
+		So(common.AcceptedByFilter(repodb.Filter{MaxCVESeverity: &high}, filterData), ShouldBeTrue)
+		So(common.AcceptedByFilter(repodb.Filter{MaxCVESeverity: &critical}, filterData), ShouldBeTrue)
+		So(common.AcceptedByFilter(repodb.Filter{MaxCVESeverity: &medium}, filterData), ShouldBeFalse)
+		So(common.AcceptedByFilter(repodb.Filter{LastUpdatedAfter: &before}, filterData), ShouldBeTrue)
+		So(common.AcceptedByFilter(repodb.Filter{LastUpdatedAfter: &after}, filterData), ShouldBeFalse)
+		So(common.AcceptedByFilter(repodb.Filter{MaxCVESeverity: &high, LastUpdatedAfter: &before}, filterData),
+			ShouldBeTrue)
+
+		// images which were not scanned are not accepted
+		So(common.AcceptedByFilter(repodb.Filter{MaxCVESeverity: &critical}, repodb.FilterData{}), ShouldBeFalse)
+		So(common.AcceptedByFilter(repodb.Filter{}, repodb.FilterData{}), ShouldBeTrue)
+	})
+
+	Convey("AcceptedByFilter with excluded licenses", t, func() {
+		gpl3, apache, empty := "GPL-3.0", "apache-2.0", ""
+		filterData := repodb.FilterData{Licenses: []string{"MIT", "GPL-3.0-or-later"}}
+
+		So(common.AcceptedByFilter(repodb.Filter{ExcludedLicenses: []*string{&gpl3}}, filterData), ShouldBeFalse)
+		So(common.AcceptedByFilter(repodb.Filter{ExcludedLicenses: []*string{&apache}}, filterData), ShouldBeTrue)
+		So(common.AcceptedByFilter(repodb.Filter{ExcludedLicenses: []*string{&empty}}, filterData), ShouldBeTrue)
+		So(common.AcceptedByFilter(repodb.Filter{ExcludedLicenses: []*string{&gpl3}},
+			repodb.FilterData{Licenses: []string{"LGPL-3.0-only", "GPL-3.0+"}}), ShouldBeFalse)
+		So(common.AcceptedByFilter(repodb.Filter{ExcludedLicenses: []*string{&gpl3}},
+			repodb.FilterData{Licenses: []string{"LGPL-3.0-only"}}), ShouldBeTrue)
+
+		// images without SBOM have no known license
+		So(common.AcceptedByFilter(repodb.Filter{ExcludedLicenses: []*string{&gpl3}}, repodb.FilterData{}), ShouldBeTrue)
+	})
+
+	Convey("GetCVESeverityRank", t, func() {
+		So(common.GetCVESeverityRank("NONE"), ShouldEqual, 0)
+		So(common.GetCVESeverityRank("low"), ShouldBeLessThan, common.GetCVESeverityRank("MEDIUM"))
+		So(common.GetCVESeverityRank("HIGH"), ShouldBeLessThan, common.GetCVESeverityRank("CRITICAL"))
+		So(common.GetCVESeverityRank("SEVERE"), ShouldEqual, -1)
+	})
+
+	Convey("GetImageMaxCVESeverity", t, func() {
+		now := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+		repoMeta := repodb.RepoMetadata{
+			VulnerabilitySummaries: map[string]repodb.VulnerabilitySummary{
+				"scanned":  {MaxSeverity: "HIGH", Severities: map[string]string{"CVE1": "low", "CVE2": "HIGH"}},
+				"clean":    {MaxSeverity: "NONE"},
+				"unranked": {MaxSeverity: "UNKNOWN", Severities: map[string]string{"CVE1": ""}},
+			},
+			CVEAcknowledgements: map[string]map[string]repodb.CVEAcknowledgement{
+				"scanned": {
+					"CVE1": {Reason: "expired", ExpiresAt: now.Add(-time.Hour)},
+					"CVE2": {Reason: "not reachable"},
+				},
+			},
+		}
+
+		// CVEs acknowledged after the scan are left out, expired acknowledgements are ignored
+		So(common.GetImageMaxCVESeverity(repoMeta, "scanned", now), ShouldEqual, "LOW")
+		So(common.GetImageMaxCVESeverity(repoMeta, "clean", now), ShouldEqual, "NONE")
+		So(common.GetImageMaxCVESeverity(repoMeta, "unranked", now), ShouldEqual, "UNKNOWN")
+		So(common.GetImageMaxCVESeverity(repoMeta, "missing", now), ShouldEqual, "")
+	})
+
+	Convey("GetHigherCVESeverity", t, func() {
+		So(common.GetHigherCVESeverity("", "LOW"), ShouldEqual, "LOW")
+		So(common.GetHigherCVESeverity("LOW", ""), ShouldEqual, "LOW")
+		So(common.GetHigherCVESeverity("LOW", "HIGH"), ShouldEqual, "HIGH")
+		So(common.GetHigherCVESeverity("CRITICAL", "NONE"), ShouldEqual, "CRITICAL")
+		So(common.GetHigherCVESeverity("", ""), ShouldEqual, "")
+	})
+
+	Convey("GetNewestImageCVESeverity", t, func() {
+		repoLastUpdated := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+
+		newer := repodb.FilterData{MaxCVESeverity: "LOW", LastUpdated: repoLastUpdated.Add(time.Hour)}
+		older := repodb.FilterData{MaxCVESeverity: "LOW", LastUpdated: repoLastUpdated.Add(-time.Hour)}
+
+		So(common.GetNewestImageCVESeverity(time.Time{}, true, "", older), ShouldEqual, "LOW")
+		So(common.GetNewestImageCVESeverity(repoLastUpdated, false, "HIGH", newer), ShouldEqual, "LOW")
+		So(common.GetNewestImageCVESeverity(repoLastUpdated, false, "HIGH", older), ShouldEqual, "HIGH")
+	})
+
 	Convey("CheckImageLastUpdated", t, func() {
 		Convey("No image checked, it doesn't have time", func() {
 			repoLastUpdated := time.Time{}
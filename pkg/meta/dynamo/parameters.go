@@ -16,6 +16,11 @@ type DBDriverParameters struct {
 func GetDynamoClient(params DBDriverParameters) (*dynamodb.Client, error) {
 	customResolver := aws.EndpointResolverWithOptionsFunc(
 		func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+			// no custom endpoint, e.g. running in AWS, use the endpoint of the region
+			if params.Endpoint == "" {
+				return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+			}
+
 			return aws.Endpoint{
 				PartitionID:   "aws",
 				URL:           params.Endpoint,
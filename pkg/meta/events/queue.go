@@ -0,0 +1,306 @@
+package events
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	"go.etcd.io/bbolt"
+
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage/constants"
+)
+
+const (
+	EventManifestPushed  = "manifestPushed"
+	EventManifestDeleted = "manifestDeleted"
+	EventManifestPulled  = "manifestPulled"
+)
+
+const (
+	// QueueName is the name of the db holding the queued events, in the root directory of the storage.
+	QueueName = "repodb-events"
+
+	DefaultMaxAttempts = 10
+	DefaultMinBackoff  = time.Second
+	DefaultMaxBackoff  = time.Minute
+
+	eventsBucket = "events"
+)
+
+// Event is a change of the images of a repo which repodb hasn't been updated with yet.
+type Event struct {
+	Type      string          `json:"type"`
+	Repo      string          `json:"repo"`
+	Reference string          `json:"reference"`
+	Digest    godigest.Digest `json:"digest"`
+	MediaType string          `json:"mediaType"`
+	Body      []byte          `json:"body"`
+	Attempts  int             `json:"attempts"`
+	Queued    time.Time       `json:"queued"`
+}
+
+// Handler applies an event to repodb, the event is retried later if it returns an error.
+type Handler func(event Event) error
+
+// Queue is a durable FIFO queue of events, persisted in a boltdb file so events queued before a restart
+// are applied once the registry is started again. Events are applied one at a time, in order, a failing
+// event is retried with an exponential backoff and dropped after MaxAttempts.
+type Queue struct {
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+
+	db      *bbolt.DB
+	handler Handler
+	notify  chan struct{}
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+	log     log.Logger
+}
+
+// New opens the queue stored in rootDir, events left from a previous run are kept.
+func New(rootDir string, handler Handler, log log.Logger) (*Queue, error) {
+	if err := os.MkdirAll(rootDir, constants.DefaultDirPerms); err != nil {
+		log.Error().Err(err).Str("directory", rootDir).Msg("events: unable to create directory for queue db")
+
+		return nil, err
+	}
+
+	dbPath := path.Join(rootDir, QueueName+constants.DBExtensionName)
+
+	db, err := bbolt.Open(dbPath, 0o600, &bbolt.Options{ //nolint:gomnd
+		Timeout:      constants.DBCacheLockCheckTimeout,
+		FreelistType: bbolt.FreelistArrayType,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("dbPath", dbPath).Msg("events: unable to open queue db")
+
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(eventsBucket))
+
+		return err
+	}); err != nil {
+		log.Error().Err(err).Str("dbPath", dbPath).Msg("events: unable to create queue bucket")
+
+		_ = db.Close()
+
+		return nil, err
+	}
+
+	return &Queue{
+		MaxAttempts: DefaultMaxAttempts,
+		MinBackoff:  DefaultMinBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+		db:          db,
+		handler:     handler,
+		notify:      make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		log:         log,
+	}, nil
+}
+
+// Enqueue persists an event, it returns once the event is on disk.
+func (queue *Queue) Enqueue(event Event) error {
+	if event.Queued.IsZero() {
+		event.Queued = time.Now()
+	}
+
+	err := queue.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(eventsBucket))
+
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(sequenceKey(seq), value)
+	})
+	if err != nil {
+		queue.log.Error().Err(err).Str("event", event.Type).Str("repository", event.Repo).
+			Str("reference", event.Reference).Msg("events: unable to queue event")
+
+		return err
+	}
+
+	select {
+	case queue.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Len returns the number of events waiting to be applied, including the one being applied.
+func (queue *Queue) Len() int {
+	var count int
+
+	_ = queue.db.View(func(tx *bbolt.Tx) error {
+		count = tx.Bucket([]byte(eventsBucket)).Stats().KeyN
+
+		return nil
+	})
+
+	return count
+}
+
+// Start applies the queued events in the background, until Close is called.
+func (queue *Queue) Start() {
+	queue.wg.Add(1)
+
+	go func() {
+		defer queue.wg.Done()
+
+		queue.run()
+	}()
+}
+
+// Close stops applying events and closes the db, events which weren't applied yet are kept for the next run.
+func (queue *Queue) Close() error {
+	queue.once.Do(func() {
+		close(queue.stop)
+	})
+
+	queue.wg.Wait()
+
+	return queue.db.Close()
+}
+
+func (queue *Queue) run() {
+	for {
+		key, event, found := queue.next()
+		if !found {
+			select {
+			case <-queue.stop:
+				return
+			case <-queue.notify:
+				continue
+			}
+		}
+
+		err := queue.handler(event)
+		if err == nil {
+			queue.remove(key)
+
+			continue
+		}
+
+		event.Attempts++
+
+		if event.Attempts >= queue.MaxAttempts {
+			queue.log.Error().Err(err).Str("event", event.Type).Str("repository", event.Repo).
+				Str("reference", event.Reference).Int("attempts", event.Attempts).
+				Msg("events: dropping event, repodb is out of sync with the storage until the next restart")
+
+			queue.remove(key)
+
+			continue
+		}
+
+		queue.update(key, event)
+
+		backoff := queue.backoff(event.Attempts)
+
+		queue.log.Warn().Err(err).Str("event", event.Type).Str("repository", event.Repo).
+			Str("reference", event.Reference).Int("attempts", event.Attempts).Str("retryIn", backoff.String()).
+			Msg("events: unable to apply event, retrying")
+
+		select {
+		case <-queue.stop:
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (queue *Queue) backoff(attempts int) time.Duration {
+	backoff := queue.MinBackoff
+
+	for i := 1; i < attempts && backoff < queue.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > queue.MaxBackoff {
+		backoff = queue.MaxBackoff
+	}
+
+	return backoff
+}
+
+func (queue *Queue) next() ([]byte, Event, bool) {
+	var (
+		key   []byte
+		event Event
+		found bool
+	)
+
+	_ = queue.db.View(func(tx *bbolt.Tx) error {
+		cursor := tx.Bucket([]byte(eventsBucket)).Cursor()
+
+		for k, value := cursor.First(); k != nil; k, value = cursor.Next() {
+			if err := json.Unmarshal(value, &event); err != nil {
+				queue.log.Error().Err(err).Msg("events: dropping unreadable event")
+
+				key = append([]byte{}, k...)
+
+				return nil
+			}
+
+			key = append([]byte{}, k...)
+			found = true
+
+			return nil
+		}
+
+		return nil
+	})
+
+	if key != nil && !found {
+		queue.remove(key)
+
+		return queue.next()
+	}
+
+	return key, event, found
+}
+
+func (queue *Queue) remove(key []byte) {
+	if err := queue.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(eventsBucket)).Delete(key)
+	}); err != nil {
+		queue.log.Error().Err(err).Msg("events: unable to remove event from queue")
+	}
+}
+
+func (queue *Queue) update(key []byte, event Event) {
+	if err := queue.db.Update(func(tx *bbolt.Tx) error {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket([]byte(eventsBucket)).Put(key, value)
+	}); err != nil {
+		queue.log.Error().Err(err).Msg("events: unable to update event in queue")
+	}
+}
+
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8) //nolint:gomnd
+	binary.BigEndian.PutUint64(key, seq)
+
+	return key
+}
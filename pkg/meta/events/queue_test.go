@@ -0,0 +1,128 @@
+package events_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/events"
+)
+
+var ErrTestError = errors.New("test error")
+
+type recorder struct {
+	lock     sync.Mutex
+	applied  []string
+	failures map[string]int
+}
+
+func (rec *recorder) handle(event events.Event) error {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	if rec.failures[event.Reference] > 0 {
+		rec.failures[event.Reference]--
+
+		return ErrTestError
+	}
+
+	rec.applied = append(rec.applied, event.Reference)
+
+	return nil
+}
+
+func (rec *recorder) getApplied() []string {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	return append([]string{}, rec.applied...)
+}
+
+func waitForQueue(queue *events.Queue) {
+	for i := 0; i < 100 && queue.Len() > 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	logger := log.NewLogger("debug", "")
+
+	Convey("Events are applied in order", t, func() {
+		rec := &recorder{failures: map[string]int{}}
+
+		queue, err := events.New(t.TempDir(), rec.handle, logger)
+		So(err, ShouldBeNil)
+
+		defer queue.Close()
+
+		queue.Start()
+
+		for _, tag := range []string{"1", "2", "3"} {
+			err = queue.Enqueue(events.Event{Type: events.EventManifestPushed, Repo: "repo", Reference: tag})
+			So(err, ShouldBeNil)
+		}
+
+		waitForQueue(queue)
+		So(queue.Len(), ShouldEqual, 0)
+		So(rec.getApplied(), ShouldResemble, []string{"1", "2", "3"})
+	})
+
+	Convey("Failing events are retried, then dropped", t, func() {
+		rec := &recorder{failures: map[string]int{"retried": 2, "dropped": 10}}
+
+		queue, err := events.New(t.TempDir(), rec.handle, logger)
+		So(err, ShouldBeNil)
+
+		defer queue.Close()
+
+		queue.MaxAttempts = 3
+		queue.MinBackoff = time.Millisecond
+		queue.MaxBackoff = 5 * time.Millisecond
+
+		queue.Start()
+
+		for _, tag := range []string{"dropped", "retried", "applied"} {
+			err = queue.Enqueue(events.Event{Type: events.EventManifestPushed, Repo: "repo", Reference: tag})
+			So(err, ShouldBeNil)
+		}
+
+		waitForQueue(queue)
+		So(queue.Len(), ShouldEqual, 0)
+		So(rec.getApplied(), ShouldResemble, []string{"retried", "applied"})
+	})
+
+	Convey("Events are kept across restarts", t, func() {
+		rootDir := t.TempDir()
+		rec := &recorder{failures: map[string]int{}}
+
+		queue, err := events.New(rootDir, rec.handle, logger)
+		So(err, ShouldBeNil)
+
+		err = queue.Enqueue(events.Event{Type: events.EventManifestDeleted, Repo: "repo", Reference: "1"})
+		So(err, ShouldBeNil)
+
+		So(queue.Close(), ShouldBeNil)
+		So(rec.getApplied(), ShouldBeEmpty)
+
+		queue, err = events.New(rootDir, rec.handle, logger)
+		So(err, ShouldBeNil)
+
+		defer queue.Close()
+
+		So(queue.Len(), ShouldEqual, 1)
+
+		queue.Start()
+
+		waitForQueue(queue)
+		So(rec.getApplied(), ShouldResemble, []string{"1"})
+	})
+
+	Convey("Queue in a directory which can't be created", t, func() {
+		_, err := events.New("/proc/zot-events", (&recorder{}).handle, logger)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,38 @@
+package repodb
+
+import (
+	"encoding/json"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	CosignAttestation = "cosign"
+
+	// annotation cosign sets on each attestation layer.
+	cosignPredicateTypeAnnotation = "predicateType"
+)
+
+// GetCosignAttestations returns the attestations held by a manifest pushed by cosign under the
+// sha256-<digest>.att tag, each layer of the manifest is the DSSE envelope of an attestation.
+func GetCosignAttestations(digest godigest.Digest, manifestBlob []byte) ([]AttestationInfo, error) {
+	var manifestContent ispec.Manifest
+
+	if err := json.Unmarshal(manifestBlob, &manifestContent); err != nil {
+		return nil, err
+	}
+
+	attestations := make([]AttestationInfo, 0, len(manifestContent.Layers))
+
+	for _, layer := range manifestContent.Layers {
+		attestations = append(attestations, AttestationInfo{
+			ManifestDigest: digest.String(),
+			LayerDigest:    layer.Digest.String(),
+			PredicateType:  layer.Annotations[cosignPredicateTypeAnnotation],
+			Tool:           CosignAttestation,
+		})
+	}
+
+	return attestations, nil
+}
@@ -23,9 +23,11 @@ import (
 )
 
 type DBWrapper struct {
-	DB      *bbolt.DB
-	Patches []func(DB *bbolt.DB) error
-	Log     log.Logger
+	DB         *bbolt.DB
+	Patches    []func(DB *bbolt.DB) error
+	Log        log.Logger
+	batcher    *bolt.Batcher
+	partitions *bolt.RepoMetaPartitions
 }
 
 func NewBoltDBWrapper(boltDB *bbolt.DB, log log.Logger) (*DBWrapper, error) {
@@ -35,9 +37,12 @@ func NewBoltDBWrapper(boltDB *bbolt.DB, log log.Logger) (*DBWrapper, error) {
 			return err
 		}
 
-		err = versionBuck.Put([]byte(version.DBVersionKey), []byte(version.CurrentVersion))
-		if err != nil {
-			return err
+		// existing dbs keep their version until they are patched
+		if versionBuck.Get([]byte(version.DBVersionKey)) == nil {
+			err = versionBuck.Put([]byte(version.DBVersionKey), []byte(version.CurrentBoltDBVersion))
+			if err != nil {
+				return err
+			}
 		}
 
 		_, err = transaction.CreateBucketIfNotExists([]byte(bolt.ManifestDataBucket))
@@ -50,11 +55,6 @@ func NewBoltDBWrapper(boltDB *bbolt.DB, log log.Logger) (*DBWrapper, error) {
 			return err
 		}
 
-		_, err = transaction.CreateBucketIfNotExists([]byte(bolt.RepoMetadataBucket))
-		if err != nil {
-			return err
-		}
-
 		_, err = transaction.CreateBucketIfNotExists([]byte(bolt.UserDataBucket))
 		if err != nil {
 			return err
@@ -66,15 +66,67 @@ func NewBoltDBWrapper(boltDB *bbolt.DB, log log.Logger) (*DBWrapper, error) {
 		return nil, err
 	}
 
+	partitions, err := bolt.GetRepoMetaPartitions(boltDB)
+	if err != nil {
+		return nil, err
+	}
+
 	return &DBWrapper{
-		DB:      boltDB,
-		Patches: version.GetBoltDBPatches(),
-		Log:     log,
+		DB:         boltDB,
+		Patches:    version.GetBoltDBPatches(),
+		Log:        log,
+		batcher:    bolt.NewBatcher(boltDB),
+		partitions: partitions,
 	}, nil
 }
 
+// update runs the writes made when pushing or syncing images, which are committed together with the
+// concurrent ones, so they have to be idempotent.
+func (bdw *DBWrapper) update(update func(tx *bbolt.Tx) error) error {
+	if bdw.batcher == nil {
+		return bdw.DB.Update(update)
+	}
+
+	return bdw.batcher.Update(update)
+}
+
+// RepoMetaDB returns the db of the partition holding the metadata of a repo, in its RepoMetadata bucket.
+func (bdw *DBWrapper) RepoMetaDB(repo string) *bbolt.DB {
+	return bdw.partitions.DB(repo)
+}
+
+// updateRepo runs the writes of the metadata of a repo made when pushing or syncing images, which are
+// committed together with the concurrent ones of the same partition, so they have to be idempotent.
+func (bdw *DBWrapper) updateRepo(repo string, update func(tx *bbolt.Tx) error) error {
+	return bdw.partitions.Batcher(repo).Update(update)
+}
+
+// getRepoMetaBlob returns the metadata of a repo as stored in its partition, or nil if there's none.
+func (bdw *DBWrapper) getRepoMetaBlob(repo string) ([]byte, error) {
+	var repoMetaBlob []byte
+
+	err := bdw.RepoMetaDB(repo).View(func(tx *bbolt.Tx) error {
+		// the blob is only valid during the transaction
+		repoMetaBlob = append([]byte(nil), tx.Bucket([]byte(bolt.RepoMetadataBucket)).Get([]byte(repo))...)
+
+		return nil
+	})
+
+	return repoMetaBlob, err
+}
+
+// viewAll runs view with a read transaction of the metadata db and the RepoMetadata buckets of all the
+// partitions.
+func (bdw *DBWrapper) viewAll(view func(tx *bbolt.Tx, repoBuck bolt.RepoMetaBuckets) error) error {
+	return bdw.DB.View(func(tx *bbolt.Tx) error {
+		return bdw.partitions.View(func(repoBuck bolt.RepoMetaBuckets) error {
+			return view(tx, repoBuck)
+		})
+	})
+}
+
 func (bdw *DBWrapper) SetManifestData(manifestDigest godigest.Digest, manifestData repodb.ManifestData) error {
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.update(func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.ManifestDataBucket))
 
 		mdBlob, err := json.Marshal(manifestData)
@@ -118,8 +170,29 @@ func (bdw *DBWrapper) GetManifestData(manifestDigest godigest.Digest) (repodb.Ma
 
 func (bdw *DBWrapper) SetManifestMeta(repo string, manifestDigest godigest.Digest, manifestMeta repodb.ManifestMetadata,
 ) error {
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	mdBlob, err := json.Marshal(repodb.ManifestData{
+		ManifestBlob: manifestMeta.ManifestBlob,
+		ConfigBlob:   manifestMeta.ConfigBlob,
+	})
+	if err != nil {
+		return fmt.Errorf("repodb: error while calculating blob for manifest with digest %s %w", manifestDigest, err)
+	}
+
+	err = bdw.update(func(tx *bbolt.Tx) error {
 		dataBuck := tx.Bucket([]byte(bolt.ManifestDataBucket))
+
+		err := dataBuck.Put([]byte(manifestDigest), mdBlob)
+		if err != nil {
+			return fmt.Errorf("repodb: error while setting manifest meta with for digest %s %w", manifestDigest, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
 		repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMeta := repodb.RepoMetadata{
@@ -138,19 +211,6 @@ func (bdw *DBWrapper) SetManifestMeta(repo string, manifestDigest godigest.Diges
 			}
 		}
 
-		mdBlob, err := json.Marshal(repodb.ManifestData{
-			ManifestBlob: manifestMeta.ManifestBlob,
-			ConfigBlob:   manifestMeta.ConfigBlob,
-		})
-		if err != nil {
-			return fmt.Errorf("repodb: error while calculating blob for manifest with digest %s %w", manifestDigest, err)
-		}
-
-		err = dataBuck.Put([]byte(manifestDigest), mdBlob)
-		if err != nil {
-			return fmt.Errorf("repodb: error while setting manifest meta with for digest %s %w", manifestDigest, err)
-		}
-
 		updatedRepoMeta := common.UpdateManifestMeta(repoMeta, manifestDigest, manifestMeta)
 
 		updatedRepoMetaBlob, err := json.Marshal(updatedRepoMeta)
@@ -167,9 +227,13 @@ func (bdw *DBWrapper) SetManifestMeta(repo string, manifestDigest godigest.Diges
 func (bdw *DBWrapper) GetManifestMeta(repo string, manifestDigest godigest.Digest) (repodb.ManifestMetadata, error) {
 	var manifestMetadata repodb.ManifestMetadata
 
-	err := bdw.DB.View(func(tx *bbolt.Tx) error {
+	repoMetaBlob, err := bdw.getRepoMetaBlob(repo)
+	if err != nil {
+		return manifestMetadata, err
+	}
+
+	err = bdw.DB.View(func(tx *bbolt.Tx) error {
 		dataBuck := tx.Bucket([]byte(bolt.ManifestDataBucket))
-		repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		mdBlob := dataBuck.Get([]byte(manifestDigest))
 
@@ -186,7 +250,6 @@ func (bdw *DBWrapper) GetManifestMeta(repo string, manifestDigest godigest.Diges
 
 		var repoMeta repodb.RepoMetadata
 
-		repoMetaBlob := repoBuck.Get([]byte(repo))
 		if len(repoMetaBlob) > 0 {
 			err = json.Unmarshal(repoMetaBlob, &repoMeta)
 			if err != nil {
@@ -212,7 +275,7 @@ func (bdw *DBWrapper) GetManifestMeta(repo string, manifestDigest godigest.Diges
 func (bdw *DBWrapper) SetIndexData(indexDigest godigest.Digest, indexMetadata repodb.IndexData) error {
 	// we make the assumption that the oci layout is consistent and all manifests refferenced inside the
 	// index are present
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.update(func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.IndexDataBucket))
 
 		imBlob, err := json.Marshal(indexMetadata)
@@ -255,7 +318,7 @@ func (bdw *DBWrapper) GetIndexData(indexDigest godigest.Digest) (repodb.IndexDat
 }
 
 func (bdw DBWrapper) SetReferrer(repo string, referredDigest godigest.Digest, referrer repodb.ReferrerInfo) error {
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -317,7 +380,7 @@ func (bdw DBWrapper) SetReferrer(repo string, referredDigest godigest.Digest, re
 func (bdw DBWrapper) DeleteReferrer(repo string, referredDigest godigest.Digest,
 	referrerDigest godigest.Digest,
 ) error {
-	return bdw.DB.Update(func(tx *bbolt.Tx) error {
+	return bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -358,7 +421,7 @@ func (bdw DBWrapper) GetReferrersInfo(repo string, referredDigest godigest.Diges
 ) ([]repodb.ReferrerInfo, error) {
 	referrersInfoResult := []repodb.ReferrerInfo{}
 
-	err := bdw.DB.View(func(tx *bbolt.Tx) error {
+	err := bdw.RepoMetaDB(repo).View(func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -396,7 +459,7 @@ func (bdw *DBWrapper) SetRepoReference(repo string, reference string, manifestDi
 		return err
 	}
 
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -450,7 +513,7 @@ func (bdw *DBWrapper) SetRepoReference(repo string, reference string, manifestDi
 func (bdw *DBWrapper) GetRepoMeta(repo string) (repodb.RepoMetadata, error) {
 	var repoMeta repodb.RepoMetadata
 
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.RepoMetaDB(repo).Update(func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -475,13 +538,15 @@ func (bdw *DBWrapper) GetRepoMeta(repo string) (repodb.RepoMetadata, error) {
 func (bdw *DBWrapper) GetUserRepoMeta(ctx context.Context, repo string) (repodb.RepoMetadata, error) {
 	var repoMeta repodb.RepoMetadata
 
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
-		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+	repoMetaBlob, err := bdw.getRepoMetaBlob(repo)
+	if err != nil {
+		return repoMeta, err
+	}
+
+	err = bdw.DB.Update(func(tx *bbolt.Tx) error {
 		userBookmarks := getUserBookmarks(ctx, tx)
 		userStars := getUserStars(ctx, tx)
 
-		repoMetaBlob := buck.Get([]byte(repo))
-
 		// object not found
 		if repoMetaBlob == nil {
 			return zerr.ErrRepoMetaNotFound
@@ -503,7 +568,7 @@ func (bdw *DBWrapper) GetUserRepoMeta(ctx context.Context, repo string) (repodb.
 }
 
 func (bdw *DBWrapper) SetRepoMeta(repo string, repoMeta repodb.RepoMetadata) error {
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMeta.Name = repo
@@ -520,7 +585,7 @@ func (bdw *DBWrapper) SetRepoMeta(repo string, repoMeta repodb.RepoMetadata) err
 }
 
 func (bdw *DBWrapper) DeleteRepoTag(repo string, tag string) error {
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -552,7 +617,7 @@ func (bdw *DBWrapper) DeleteRepoTag(repo string, tag string) error {
 }
 
 func (bdw *DBWrapper) IncrementRepoStars(repo string) error {
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.RepoMetaDB(repo).Update(func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -581,7 +646,7 @@ func (bdw *DBWrapper) IncrementRepoStars(repo string) error {
 }
 
 func (bdw *DBWrapper) DecrementRepoStars(repo string) error {
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.RepoMetaDB(repo).Update(func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -614,7 +679,7 @@ func (bdw *DBWrapper) DecrementRepoStars(repo string) error {
 func (bdw *DBWrapper) GetRepoStars(repo string) (int, error) {
 	stars := 0
 
-	err := bdw.DB.View(func(tx *bbolt.Tx) error {
+	err := bdw.RepoMetaDB(repo).View(func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		buck.Get([]byte(repo))
@@ -651,9 +716,7 @@ func (bdw *DBWrapper) GetMultipleRepoMeta(ctx context.Context, filter func(repoM
 		return nil, err
 	}
 
-	err = bdw.DB.View(func(tx *bbolt.Tx) error {
-		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
-
+	err = bdw.partitions.View(func(buck bolt.RepoMetaBuckets) error {
 		cursor := buck.Cursor()
 
 		for repoName, repoMetaBlob := cursor.First(); repoName != nil; repoName, repoMetaBlob = cursor.Next() {
@@ -684,7 +747,7 @@ func (bdw *DBWrapper) GetMultipleRepoMeta(ctx context.Context, filter func(repoM
 }
 
 func (bdw *DBWrapper) IncrementImageDownloads(repo string, reference string) error {
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -699,7 +762,8 @@ func (bdw *DBWrapper) IncrementImageDownloads(repo string, reference string) err
 			return err
 		}
 
-		manifestDigest := reference
+		// a manifest pulled by an alias is counted for the digest it's stored with
+		manifestDigest := repodb.GetAliasedDigest(repoMeta, reference)
 
 		if !common.ReferenceIsDigest(reference) {
 			// search digest for tag
@@ -716,6 +780,8 @@ func (bdw *DBWrapper) IncrementImageDownloads(repo string, reference string) err
 		manifestStatistics.DownloadCount++
 		repoMeta.Statistics[manifestDigest] = manifestStatistics
 
+		repoMeta = repodb.CountDailyActivity(repoMeta, time.Now(), 0, 1)
+
 		repoMetaBlob, err = json.Marshal(repoMeta)
 		if err != nil {
 			return err
@@ -728,13 +794,16 @@ func (bdw *DBWrapper) IncrementImageDownloads(repo string, reference string) err
 }
 
 func (bdw *DBWrapper) UpdateSignaturesValidity(repo string, manifestDigest godigest.Digest) error {
-	err := bdw.DB.Update(func(transaction *bbolt.Tx) error {
+	var (
+		blob        []byte
+		signedFound bool
+	)
+
+	err := bdw.DB.View(func(transaction *bbolt.Tx) error {
 		// get ManifestData of signed manifest
 		manifestBuck := transaction.Bucket([]byte(bolt.ManifestDataBucket))
 		mdBlob := manifestBuck.Get([]byte(manifestDigest))
 
-		var blob []byte
-
 		if len(mdBlob) != 0 {
 			var manifestData repodb.ManifestData
 
@@ -763,6 +832,15 @@ func (bdw *DBWrapper) UpdateSignaturesValidity(repo string, manifestDigest godig
 			blob = indexData.IndexBlob
 		}
 
+		signedFound = true
+
+		return nil
+	})
+	if err != nil || !signedFound {
+		return err
+	}
+
+	err = bdw.RepoMetaDB(repo).Update(func(transaction *bbolt.Tx) error {
 		// update signatures with details about validity and author
 		repoBuck := transaction.Bucket([]byte(bolt.RepoMetadataBucket))
 
@@ -826,7 +904,7 @@ func (bdw *DBWrapper) UpdateSignaturesValidity(repo string, manifestDigest godig
 func (bdw *DBWrapper) AddManifestSignature(repo string, signedManifestDigest godigest.Digest,
 	sygMeta repodb.SignatureMetadata,
 ) error {
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -908,7 +986,7 @@ func (bdw *DBWrapper) AddManifestSignature(repo string, signedManifestDigest god
 func (bdw *DBWrapper) DeleteSignature(repo string, signedManifestDigest godigest.Digest,
 	sigMeta repodb.SignatureMetadata,
 ) error {
-	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+	err := bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
 
 		repoMetaBlob := buck.Get([]byte(repo))
@@ -978,11 +1056,10 @@ func (bdw *DBWrapper) SearchRepos(ctx context.Context, searchText string, filter
 			zcommon.PageInfo{}, err
 	}
 
-	err = bdw.DB.View(func(transaction *bbolt.Tx) error {
+	err = bdw.viewAll(func(transaction *bbolt.Tx, repoBuck bolt.RepoMetaBuckets) error {
 		var (
 			manifestMetadataMap = make(map[string]repodb.ManifestMetadata)
 			indexDataMap        = make(map[string]repodb.IndexData)
-			repoBuck            = transaction.Bucket([]byte(bolt.RepoMetadataBucket))
 			indexBuck           = transaction.Bucket([]byte(bolt.IndexDataBucket))
 			manifestBuck        = transaction.Bucket([]byte(bolt.ManifestDataBucket))
 			userBookmarks       = getUserBookmarks(ctx, transaction)
@@ -1016,13 +1093,16 @@ func (bdw *DBWrapper) SearchRepos(ctx context.Context, searchText string, filter
 				repoLastUpdated = time.Time{}
 				osSet           = map[string]bool{}
 				archSet         = map[string]bool{}
+				annotationSet   = map[string]bool{}
+				licenseSet      = map[string]bool{}
 				noImageChecked  = true
 				isSigned        = false
+				maxCVESeverity  = ""
 			)
 
 			for tag, descriptor := range repoMeta.Tags {
 				switch descriptor.MediaType {
-				case ispec.MediaTypeImageManifest:
+				case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 					manifestDigest := descriptor.Digest
 
 					manifestMeta, err := fetchManifestMetaWithCheck(repoMeta, manifestDigest,
@@ -1047,11 +1127,21 @@ func (bdw *DBWrapper) SearchRepos(ctx context.Context, searchText string, filter
 						archSet[arch] = true
 					}
 
+					for _, annotation := range manifestFilterData.RegistryAnnotations {
+						annotationSet[annotation] = true
+					}
+
+					for _, license := range manifestFilterData.Licenses {
+						licenseSet[license] = true
+					}
+
+					maxCVESeverity = common.GetNewestImageCVESeverity(repoLastUpdated, noImageChecked, maxCVESeverity,
+						manifestFilterData)
 					repoLastUpdated, noImageChecked, isSigned = common.CheckImageLastUpdated(repoLastUpdated, isSigned,
 						noImageChecked, manifestFilterData)
 
 					manifestMetadataMap[descriptor.Digest] = manifestMeta
-				case ispec.MediaTypeImageIndex:
+				case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 					indexDigest := descriptor.Digest
 
 					indexData, err := fetchIndexDataWithCheck(indexDigest, indexDataMap, indexBuck)
@@ -1084,8 +1174,18 @@ func (bdw *DBWrapper) SearchRepos(ctx context.Context, searchText string, filter
 						osSet[os] = true
 					}
 
+					for _, annotation := range indexFilterData.RegistryAnnotations {
+						annotationSet[annotation] = true
+					}
+
+					for _, license := range indexFilterData.Licenses {
+						licenseSet[license] = true
+					}
+
 					repoDownloads += indexFilterData.DownloadCount
 
+					maxCVESeverity = common.GetNewestImageCVESeverity(repoLastUpdated, noImageChecked, maxCVESeverity,
+						indexFilterData)
 					repoLastUpdated, noImageChecked, isSigned = common.CheckImageLastUpdated(repoLastUpdated, isSigned,
 						noImageChecked, indexFilterData)
 
@@ -1098,13 +1198,16 @@ func (bdw *DBWrapper) SearchRepos(ctx context.Context, searchText string, filter
 			}
 
 			repoFilterData := repodb.FilterData{
-				OsList:        common.GetMapKeys(osSet),
-				ArchList:      common.GetMapKeys(archSet),
-				LastUpdated:   repoLastUpdated,
-				DownloadCount: repoDownloads,
-				IsSigned:      isSigned,
-				IsBookmarked:  repoMeta.IsBookmarked,
-				IsStarred:     repoMeta.IsStarred,
+				OsList:              common.GetMapKeys(osSet),
+				ArchList:            common.GetMapKeys(archSet),
+				LastUpdated:         repoLastUpdated,
+				DownloadCount:       repoDownloads,
+				IsSigned:            isSigned,
+				RegistryAnnotations: common.GetMapKeys(annotationSet),
+				Licenses:            common.GetMapKeys(licenseSet),
+				MaxCVESeverity:      maxCVESeverity,
+				IsBookmarked:        repoMeta.IsBookmarked,
+				IsStarred:           repoMeta.IsStarred,
 			}
 
 			if !common.AcceptedByFilter(filter, repoFilterData) {
@@ -1206,11 +1309,14 @@ func collectImageManifestFilterData(digest string, repoMeta repodb.RepoMetadata,
 	}
 
 	return repodb.FilterData{
-		DownloadCount: repoMeta.Statistics[digest].DownloadCount,
-		OsList:        osList,
-		ArchList:      archList,
-		LastUpdated:   common.GetImageLastUpdatedTimestamp(configContent),
-		IsSigned:      common.CheckIsSigned(repoMeta.Signatures[digest]),
+		DownloadCount:       repoMeta.Statistics[digest].DownloadCount,
+		OsList:              osList,
+		ArchList:            archList,
+		LastUpdated:         common.GetImageLastUpdatedTimestamp(configContent),
+		IsSigned:            common.CheckIsSigned(repoMeta.Signatures[digest]),
+		RegistryAnnotations: common.GetRegistryAnnotationsList(repoMeta.RegistryAnnotations[digest]),
+		MaxCVESeverity:      common.GetImageMaxCVESeverity(repoMeta, digest, time.Now()),
+		Licenses:            repoMeta.SBOMSummaries[digest].Licenses,
 	}, nil
 }
 
@@ -1231,6 +1337,7 @@ func collectImageIndexFilterInfo(indexDigest string, repoMeta repodb.RepoMetadat
 		firstManifestChecked = false
 		indexOsList          = []string{}
 		indexArchList        = []string{}
+		indexMaxCVESeverity  = common.GetImageMaxCVESeverity(repoMeta, indexDigest, time.Now())
 	)
 
 	for _, manifest := range indexContent.Manifests {
@@ -1253,6 +1360,9 @@ func collectImageIndexFilterInfo(indexDigest string, repoMeta repodb.RepoMetadat
 		indexOsList = append(indexOsList, manifestFilterData.OsList...)
 		indexArchList = append(indexArchList, manifestFilterData.ArchList...)
 
+		// the manifests of an index are scanned one by one
+		indexMaxCVESeverity = common.GetHigherCVESeverity(indexMaxCVESeverity, manifestFilterData.MaxCVESeverity)
+
 		if !firstManifestChecked || indexLastUpdated.Before(manifestFilterData.LastUpdated) {
 			indexLastUpdated = manifestFilterData.LastUpdated
 			firstManifestChecked = true
@@ -1262,11 +1372,14 @@ func collectImageIndexFilterInfo(indexDigest string, repoMeta repodb.RepoMetadat
 	}
 
 	return repodb.FilterData{
-		DownloadCount: repoMeta.Statistics[indexDigest].DownloadCount,
-		LastUpdated:   indexLastUpdated,
-		OsList:        indexOsList,
-		ArchList:      indexArchList,
-		IsSigned:      common.CheckIsSigned(repoMeta.Signatures[indexDigest]),
+		DownloadCount:       repoMeta.Statistics[indexDigest].DownloadCount,
+		LastUpdated:         indexLastUpdated,
+		OsList:              indexOsList,
+		ArchList:            indexArchList,
+		IsSigned:            common.CheckIsSigned(repoMeta.Signatures[indexDigest]),
+		RegistryAnnotations: common.GetRegistryAnnotationsList(repoMeta.RegistryAnnotations[indexDigest]),
+		MaxCVESeverity:      indexMaxCVESeverity,
+		Licenses:            repoMeta.SBOMSummaries[indexDigest].Licenses,
 	}, nil
 }
 
@@ -1309,9 +1422,8 @@ func (bdw *DBWrapper) FilterTags(ctx context.Context, filter repodb.FilterFunc,
 			zcommon.PageInfo{}, err
 	}
 
-	err = bdw.DB.View(func(transaction *bbolt.Tx) error {
+	err = bdw.viewAll(func(transaction *bbolt.Tx, repoBuck bolt.RepoMetaBuckets) error {
 		var (
-			repoBuck      = transaction.Bucket([]byte(bolt.RepoMetadataBucket))
 			indexBuck     = transaction.Bucket([]byte(bolt.IndexDataBucket))
 			manifestBuck  = transaction.Bucket([]byte(bolt.ManifestDataBucket))
 			cursor        = repoBuck.Cursor()
@@ -1340,7 +1452,7 @@ func (bdw *DBWrapper) FilterTags(ctx context.Context, filter repodb.FilterFunc,
 			// take all manifestMetas
 			for tag, descriptor := range repoMeta.Tags {
 				switch descriptor.MediaType {
-				case ispec.MediaTypeImageManifest:
+				case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 					manifestDigest := descriptor.Digest
 
 					manifestMeta, err := fetchManifestMetaWithCheck(repoMeta, manifestDigest, manifestMetadataMap, manifestBuck)
@@ -1352,7 +1464,7 @@ func (bdw *DBWrapper) FilterTags(ctx context.Context, filter repodb.FilterFunc,
 						matchedTags[tag] = descriptor
 						manifestMetadataMap[manifestDigest] = manifestMeta
 					}
-				case ispec.MediaTypeImageIndex:
+				case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 					indexDigest := descriptor.Digest
 
 					indexData, err := fetchIndexDataWithCheck(indexDigest, indexDataMap, indexBuck)
@@ -1446,9 +1558,8 @@ func (bdw *DBWrapper) FilterRepos(ctx context.Context,
 		return []repodb.RepoMetadata{}, map[string]repodb.ManifestMetadata{}, map[string]repodb.IndexData{}, pageInfo, err
 	}
 
-	err = bdw.DB.View(func(tx *bbolt.Tx) error {
+	err = bdw.viewAll(func(tx *bbolt.Tx, buck bolt.RepoMetaBuckets) error {
 		var (
-			buck          = tx.Bucket([]byte(bolt.RepoMetadataBucket))
 			cursor        = buck.Cursor()
 			userBookmarks = getUserBookmarks(ctx, tx)
 			userStars     = getUserStars(ctx, tx)
@@ -1516,9 +1627,8 @@ func (bdw *DBWrapper) SearchTags(ctx context.Context, searchText string, filter
 			fmt.Errorf("repodb: error while parsing search text, invalid format %w", err)
 	}
 
-	err = bdw.DB.View(func(transaction *bbolt.Tx) error {
+	err = bdw.viewAll(func(transaction *bbolt.Tx, repoBuck bolt.RepoMetaBuckets) error {
 		var (
-			repoBuck      = transaction.Bucket([]byte(bolt.RepoMetadataBucket))
 			indexBuck     = transaction.Bucket([]byte(bolt.IndexDataBucket))
 			manifestBuck  = transaction.Bucket([]byte(bolt.ManifestDataBucket))
 			cursor        = repoBuck.Cursor()
@@ -1557,7 +1667,7 @@ func (bdw *DBWrapper) SearchTags(ctx context.Context, searchText string, filter
 				matchedTags[tag] = descriptor
 
 				switch descriptor.MediaType {
-				case ispec.MediaTypeImageManifest:
+				case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 					manifestDigest := descriptor.Digest
 
 					manifestMeta, err := fetchManifestMetaWithCheck(repoMeta, manifestDigest, manifestMetadataMap, manifestBuck)
@@ -1579,7 +1689,7 @@ func (bdw *DBWrapper) SearchTags(ctx context.Context, searchText string, filter
 					}
 
 					manifestMetadataMap[descriptor.Digest] = manifestMeta
-				case ispec.MediaTypeImageIndex:
+				case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 					indexDigest := descriptor.Digest
 
 					indexData, err := fetchIndexDataWithCheck(indexDigest, indexDataMap, indexBuck)
@@ -1613,6 +1723,20 @@ func (bdw *DBWrapper) SearchTags(ctx context.Context, searchText string, filter
 								manifestDigest, err)
 						}
 
+						// annotations set on the index apply to all of its manifests
+						manifestFilterData.RegistryAnnotations = append(manifestFilterData.RegistryAnnotations,
+							common.GetRegistryAnnotationsList(repoMeta.RegistryAnnotations[indexDigest])...)
+
+						// images in an index are scanned together with the index
+						if manifestFilterData.MaxCVESeverity == "" {
+							manifestFilterData.MaxCVESeverity = common.GetImageMaxCVESeverity(repoMeta, indexDigest,
+								time.Now())
+						}
+
+						// the SBOM of the index covers all of its manifests
+						manifestFilterData.Licenses = append(manifestFilterData.Licenses,
+							repoMeta.SBOMSummaries[indexDigest].Licenses...)
+
 						manifestMetadataMap[manifestDigest] = manifestMeta
 
 						if common.AcceptedByFilter(filter, manifestFilterData) {
@@ -1715,38 +1839,37 @@ func (bdw *DBWrapper) ToggleStarRepo(ctx context.Context, repo string) (repodb.T
 			return zerr.ErrCouldNotPersistData
 		}
 
-		repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+		// the stars of the repo are in its partition, they are committed first so the star of the user
+		// isn't kept if they can't be
+		return bdw.RepoMetaDB(repo).Update(func(repoTx *bbolt.Tx) error {
+			repoBuck := repoTx.Bucket([]byte(bolt.RepoMetadataBucket))
 
-		repoMetaBlob := repoBuck.Get([]byte(repo))
-		if repoMetaBlob == nil {
-			return zerr.ErrRepoMetaNotFound
-		}
-
-		var repoMeta repodb.RepoMetadata
+			repoMetaBlob := repoBuck.Get([]byte(repo))
+			if repoMetaBlob == nil {
+				return zerr.ErrRepoMetaNotFound
+			}
 
-		err = json.Unmarshal(repoMetaBlob, &repoMeta)
-		if err != nil {
-			return err
-		}
+			var repoMeta repodb.RepoMetadata
 
-		switch res {
-		case repodb.Added:
-			repoMeta.Stars++
-		case repodb.Removed:
-			repoMeta.Stars--
-		}
+			err := json.Unmarshal(repoMetaBlob, &repoMeta)
+			if err != nil {
+				return err
+			}
 
-		repoMetaBlob, err = json.Marshal(repoMeta)
-		if err != nil {
-			return err
-		}
+			switch res {
+			case repodb.Added:
+				repoMeta.Stars++
+			case repodb.Removed:
+				repoMeta.Stars--
+			}
 
-		err = repoBuck.Put([]byte(repo), repoMetaBlob)
-		if err != nil {
-			return err
-		}
+			repoMetaBlob, err = json.Marshal(repoMeta)
+			if err != nil {
+				return err
+			}
 
-		return nil
+			return repoBuck.Put([]byte(repo), repoMetaBlob)
+		})
 	}); err != nil {
 		return repodb.NotChanged, err
 	}
@@ -1902,6 +2025,412 @@ func (bdw *DBWrapper) GetBookmarkedRepos(ctx context.Context) ([]string, error)
 	return bookmarkedRepos, err
 }
 
+func (bdw *DBWrapper) AddUserActivity(ctx context.Context, activity repodb.UserActivity, maxEntries int) error {
+	acCtx, err := localCtx.GetAccessControlContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	userid := localCtx.GetUsernameFromContext(acCtx)
+	if userid == "" {
+		// empty user is anonymous
+		return zerr.ErrUserDataNotAllowed
+	}
+
+	return bdw.DB.Update(func(tx *bbolt.Tx) error {
+		userdb := tx.Bucket([]byte(bolt.UserDataBucket))
+
+		userBucket, err := userdb.CreateBucketIfNotExists([]byte(userid))
+		if err != nil {
+			// this is a serious failure
+			return zerr.ErrUnableToCreateUserBucket
+		}
+
+		activities := []repodb.UserActivity{}
+
+		mdata := userBucket.Get([]byte(bolt.UserActivityKey))
+		if mdata != nil {
+			if err := json.Unmarshal(mdata, &activities); err != nil {
+				return zerr.ErrInvalidOldUserActivity
+			}
+		}
+
+		activities = repodb.AddActivity(activities, activity, maxEntries)
+
+		repacked, err := json.Marshal(activities)
+		if err != nil {
+			return zerr.ErrCouldNotMarshalUserActivity
+		}
+
+		if err := userBucket.Put([]byte(bolt.UserActivityKey), repacked); err != nil {
+			return zerr.ErrCouldNotPersistData
+		}
+
+		return nil
+	})
+}
+
+func (bdw *DBWrapper) GetUserActivity(ctx context.Context) ([]repodb.UserActivity, error) {
+	activities := []repodb.UserActivity{}
+
+	acCtx, err := localCtx.GetAccessControlContext(ctx)
+	if err != nil {
+		return activities, err
+	}
+
+	userid := localCtx.GetUsernameFromContext(acCtx)
+
+	err = bdw.DB.View(func(tx *bbolt.Tx) error {
+		if userid == "" {
+			return nil
+		}
+
+		userdb := tx.Bucket([]byte(bolt.UserDataBucket))
+		userBucket := userdb.Bucket([]byte(userid))
+
+		if userBucket == nil {
+			return nil
+		}
+
+		mdata := userBucket.Get([]byte(bolt.UserActivityKey))
+		if mdata == nil {
+			return nil
+		}
+
+		if err := json.Unmarshal(mdata, &activities); err != nil {
+			bdw.Log.Info().Str("user", userid).Err(err).Msg("unmarshal error")
+
+			return zerr.ErrInvalidOldUserActivity
+		}
+
+		return nil
+	})
+
+	return activities, err
+}
+
+func (bdw *DBWrapper) PinImage(ctx context.Context, repo string, reference string) (repodb.PinInfo, error) {
+	acCtx, err := localCtx.GetAccessControlContext(ctx)
+	if err != nil {
+		return repodb.PinInfo{}, err
+	}
+
+	var pin repodb.PinInfo
+
+	err = bdw.RepoMetaDB(repo).Update(func(tx *bbolt.Tx) error {
+		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+
+		repoMetaBlob := buck.Get([]byte(repo))
+		if repoMetaBlob == nil {
+			return zerr.ErrRepoMetaNotFound
+		}
+
+		var repoMeta repodb.RepoMetadata
+
+		err := json.Unmarshal(repoMetaBlob, &repoMeta)
+		if err != nil {
+			return err
+		}
+
+		repoMeta, pin, err = repodb.AddPin(repoMeta, reference, localCtx.GetUsernameFromContext(acCtx), time.Now())
+		if err != nil {
+			return err
+		}
+
+		repoMetaBlob, err = json.Marshal(repoMeta)
+		if err != nil {
+			return err
+		}
+
+		return buck.Put([]byte(repo), repoMetaBlob)
+	})
+
+	return pin, err
+}
+
+func (bdw *DBWrapper) UnpinImage(repo string, reference string) error {
+	err := bdw.RepoMetaDB(repo).Update(func(tx *bbolt.Tx) error {
+		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+
+		repoMetaBlob := buck.Get([]byte(repo))
+		if repoMetaBlob == nil {
+			return zerr.ErrRepoMetaNotFound
+		}
+
+		var repoMeta repodb.RepoMetadata
+
+		err := json.Unmarshal(repoMetaBlob, &repoMeta)
+		if err != nil {
+			return err
+		}
+
+		repoMeta, err = repodb.RemovePin(repoMeta, reference)
+		if err != nil {
+			return err
+		}
+
+		repoMetaBlob, err = json.Marshal(repoMeta)
+		if err != nil {
+			return err
+		}
+
+		return buck.Put([]byte(repo), repoMetaBlob)
+	})
+
+	return err
+}
+
+func (bdw *DBWrapper) IsImagePinned(repo string, digest godigest.Digest) (bool, error) {
+	var pinned bool
+
+	err := bdw.RepoMetaDB(repo).View(func(tx *bbolt.Tx) error {
+		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+
+		repoMetaBlob := buck.Get([]byte(repo))
+		if repoMetaBlob == nil {
+			// repos not known by repoDB have no pins
+			return nil
+		}
+
+		var repoMeta repodb.RepoMetadata
+
+		err := json.Unmarshal(repoMetaBlob, &repoMeta)
+		if err != nil {
+			return err
+		}
+
+		pinned = repodb.IsDigestPinned(repoMeta, digest.String())
+
+		return nil
+	})
+
+	return pinned, err
+}
+
+func (bdw *DBWrapper) PatchRegistryAnnotations(repo string, reference string, patch map[string]*string,
+) (godigest.Digest, map[string]string, error) {
+	var (
+		digest      string
+		annotations map[string]string
+	)
+
+	err := bdw.RepoMetaDB(repo).Update(func(tx *bbolt.Tx) error {
+		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+
+		repoMetaBlob := buck.Get([]byte(repo))
+		if repoMetaBlob == nil {
+			return zerr.ErrRepoMetaNotFound
+		}
+
+		var repoMeta repodb.RepoMetadata
+
+		err := json.Unmarshal(repoMetaBlob, &repoMeta)
+		if err != nil {
+			return err
+		}
+
+		repoMeta, digest, annotations, err = repodb.PatchRegistryAnnotations(repoMeta, reference, patch)
+		if err != nil {
+			return err
+		}
+
+		repoMetaBlob, err = json.Marshal(repoMeta)
+		if err != nil {
+			return err
+		}
+
+		return buck.Put([]byte(repo), repoMetaBlob)
+	})
+
+	return godigest.Digest(digest), annotations, err
+}
+
+func (bdw *DBWrapper) SetCVEAcknowledgement(repo string, reference string, cveID string,
+	ack repodb.CVEAcknowledgement,
+) (godigest.Digest, error) {
+	var digest string
+
+	err := bdw.updateRepoMeta(repo, func(repoMeta repodb.RepoMetadata) (repodb.RepoMetadata, error) {
+		var err error
+
+		repoMeta, digest, err = repodb.SetCVEAcknowledgement(repoMeta, reference, cveID, ack)
+
+		return repoMeta, err
+	})
+
+	return godigest.Digest(digest), err
+}
+
+func (bdw *DBWrapper) DeleteCVEAcknowledgement(repo string, reference string, cveID string,
+) (godigest.Digest, error) {
+	var digest string
+
+	err := bdw.updateRepoMeta(repo, func(repoMeta repodb.RepoMetadata) (repodb.RepoMetadata, error) {
+		var err error
+
+		repoMeta, digest, err = repodb.DeleteCVEAcknowledgement(repoMeta, reference, cveID)
+
+		return repoMeta, err
+	})
+
+	return godigest.Digest(digest), err
+}
+
+// SetVulnerabilitySummary stores the vulnerability summary of an image of the repo.
+func (bdw *DBWrapper) SetVulnerabilitySummary(repo string, digest godigest.Digest,
+	summary repodb.VulnerabilitySummary,
+) error {
+	return bdw.updateRepoMeta(repo, func(repoMeta repodb.RepoMetadata) (repodb.RepoMetadata, error) {
+		return repodb.SetVulnerabilitySummary(repoMeta, digest.String(), summary)
+	})
+}
+
+func (bdw *DBWrapper) SetSBOMSummary(repo string, subjectDigest godigest.Digest, summary repodb.SBOMSummary,
+) error {
+	return bdw.updateRepoMeta(repo, func(repoMeta repodb.RepoMetadata) (repodb.RepoMetadata, error) {
+		return repodb.SetSBOMSummary(repoMeta, subjectDigest.String(), summary), nil
+	})
+}
+
+func (bdw *DBWrapper) DeleteSBOMSummary(repo string, subjectDigest, sbomDigest godigest.Digest) error {
+	return bdw.updateRepoMeta(repo, func(repoMeta repodb.RepoMetadata) (repodb.RepoMetadata, error) {
+		return repodb.DeleteSBOMSummary(repoMeta, subjectDigest.String(), sbomDigest.String()), nil
+	})
+}
+
+func (bdw *DBWrapper) SetManifestAttestations(repo string, subjectDigest godigest.Digest,
+	attestations []repodb.AttestationInfo,
+) error {
+	return bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
+		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+
+		// like signatures, attestations may be pushed before anything else in the repo
+		repoMeta := repodb.RepoMetadata{
+			Name:       repo,
+			Tags:       map[string]repodb.Descriptor{},
+			Statistics: map[string]repodb.DescriptorStatistics{},
+			Signatures: map[string]repodb.ManifestSignatures{},
+			Referrers:  map[string][]repodb.ReferrerInfo{},
+		}
+
+		if repoMetaBlob := buck.Get([]byte(repo)); len(repoMetaBlob) != 0 {
+			if err := json.Unmarshal(repoMetaBlob, &repoMeta); err != nil {
+				return err
+			}
+		}
+
+		repoMeta = repodb.SetManifestAttestations(repoMeta, subjectDigest.String(), attestations)
+
+		repoMetaBlob, err := json.Marshal(repoMeta)
+		if err != nil {
+			return err
+		}
+
+		return buck.Put([]byte(repo), repoMetaBlob)
+	})
+}
+
+func (bdw *DBWrapper) DeleteManifestAttestations(repo string, subjectDigest, attestationsDigest godigest.Digest,
+) error {
+	return bdw.updateRepoMeta(repo, func(repoMeta repodb.RepoMetadata) (repodb.RepoMetadata, error) {
+		return repodb.DeleteManifestAttestations(repoMeta, subjectDigest.String(), attestationsDigest.String()), nil
+	})
+}
+
+func (bdw *DBWrapper) SetLintViolations(repo string, manifestDigest godigest.Digest, violations []string) error {
+	return bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
+		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+
+		repoMetaBlob := buck.Get([]byte(repo))
+		if len(repoMetaBlob) == 0 && len(violations) == 0 {
+			return nil
+		}
+
+		// the violations may be recorded before the repo meta is created
+		repoMeta := repodb.RepoMetadata{
+			Name:       repo,
+			Tags:       map[string]repodb.Descriptor{},
+			Statistics: map[string]repodb.DescriptorStatistics{},
+			Signatures: map[string]repodb.ManifestSignatures{},
+			Referrers:  map[string][]repodb.ReferrerInfo{},
+		}
+
+		if len(repoMetaBlob) != 0 {
+			if err := json.Unmarshal(repoMetaBlob, &repoMeta); err != nil {
+				return err
+			}
+		}
+
+		repoMeta = repodb.SetLintViolations(repoMeta, manifestDigest.String(), violations)
+
+		repoMetaBlob, err := json.Marshal(repoMeta)
+		if err != nil {
+			return err
+		}
+
+		return buck.Put([]byte(repo), repoMetaBlob)
+	})
+}
+
+func (bdw *DBWrapper) SetDigestAlias(repo string, alias, manifestDigest godigest.Digest) error {
+	return bdw.updateRepoMeta(repo, func(repoMeta repodb.RepoMetadata) (repodb.RepoMetadata, error) {
+		return repodb.SetDigestAlias(repoMeta, alias.String(), manifestDigest.String()), nil
+	})
+}
+
+func (bdw *DBWrapper) ResolveDigestAlias(repo string, alias godigest.Digest) (godigest.Digest, error) {
+	repoMeta, err := bdw.GetRepoMeta(repo)
+	if err != nil {
+		return "", err
+	}
+
+	manifestDigest, found := repoMeta.DigestAliases[alias.String()]
+	if !found {
+		return "", zerr.ErrManifestMetaNotFound
+	}
+
+	return godigest.Digest(manifestDigest), nil
+}
+
+func (bdw *DBWrapper) IncrementRepoPushes(repo string) error {
+	return bdw.updateRepoMeta(repo, func(repoMeta repodb.RepoMetadata) (repodb.RepoMetadata, error) {
+		return repodb.CountDailyActivity(repoMeta, time.Now(), 1, 0), nil
+	})
+}
+
+// updateRepoMeta applies updateFn to the metadata of an existing repo in a single transaction.
+func (bdw *DBWrapper) updateRepoMeta(repo string,
+	updateFn func(repoMeta repodb.RepoMetadata) (repodb.RepoMetadata, error),
+) error {
+	return bdw.updateRepo(repo, func(tx *bbolt.Tx) error {
+		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+
+		repoMetaBlob := buck.Get([]byte(repo))
+		if repoMetaBlob == nil {
+			return zerr.ErrRepoMetaNotFound
+		}
+
+		var repoMeta repodb.RepoMetadata
+
+		err := json.Unmarshal(repoMetaBlob, &repoMeta)
+		if err != nil {
+			return err
+		}
+
+		repoMeta, err = updateFn(repoMeta)
+		if err != nil {
+			return err
+		}
+
+		repoMetaBlob, err = json.Marshal(repoMeta)
+		if err != nil {
+			return err
+		}
+
+		return buck.Put([]byte(repo), repoMetaBlob)
+	})
+}
+
 func (bdw *DBWrapper) PatchDB() error {
 	var DBVersion string
 
@@ -3,6 +3,10 @@ package bolt_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
@@ -19,6 +23,164 @@ import (
 	"zotregistry.io/zot/pkg/test"
 )
 
+var ErrTestError = errors.New("test error")
+
+// repoMetaBuck stores the metadata of the repos as is, in their partitions.
+type repoMetaBuck struct {
+	boltdbWrapper *boltdb_wrapper.DBWrapper
+}
+
+func (buck repoMetaBuck) Put(repo, repoMetaBlob []byte) error {
+	return buck.boltdbWrapper.RepoMetaDB(string(repo)).Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(bolt.RepoMetadataBucket)).Put(repo, repoMetaBlob)
+	})
+}
+
+func TestBatchedWrites(t *testing.T) {
+	Convey("Concurrent writes are batched", t, func() {
+		boltDriver, err := bolt.GetBoltDriver(bolt.DBParameters{RootDir: t.TempDir()})
+		So(err, ShouldBeNil)
+
+		boltdbWrapper, err := boltdb_wrapper.NewBoltDBWrapper(boltDriver, log.NewLogger("debug", ""))
+		So(err, ShouldBeNil)
+
+		repos := []string{}
+
+		for i := 0; i < 50; i++ {
+			repos = append(repos, fmt.Sprintf("repo%02d", i))
+		}
+
+		var wg sync.WaitGroup
+
+		writeErrs := make(chan error, len(repos))
+
+		for _, repo := range repos {
+			wg.Add(1)
+
+			go func(repo string) {
+				defer wg.Done()
+
+				writeErrs <- boltdbWrapper.SetRepoReference(repo, "tag", digest.FromString(repo),
+					ispec.MediaTypeImageManifest)
+			}(repo)
+		}
+
+		wg.Wait()
+		close(writeErrs)
+
+		for err := range writeErrs {
+			So(err, ShouldBeNil)
+		}
+
+		for _, repo := range repos {
+			repoMeta, err := boltdbWrapper.GetRepoMeta(repo)
+			So(err, ShouldBeNil)
+			So(repoMeta.Tags, ShouldContainKey, "tag")
+		}
+
+		Convey("A failing write doesn't fail the writes batched with it", func() {
+			batcher := bolt.NewBatcher(boltdbWrapper.DB)
+			errs := make(chan error, 20)
+
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+
+				go func(i int) {
+					defer wg.Done()
+
+					errs <- batcher.Update(func(tx *bbolt.Tx) error {
+						if i%2 == 0 {
+							return ErrTestError
+						}
+
+						return tx.Bucket([]byte(bolt.ManifestDataBucket)).Put([]byte(fmt.Sprintf("digest%02d", i)), []byte("{}"))
+					})
+				}(i)
+			}
+
+			wg.Wait()
+			close(errs)
+
+			failed := 0
+
+			for err := range errs {
+				if err != nil {
+					So(err, ShouldEqual, ErrTestError)
+
+					failed++
+				}
+			}
+
+			So(failed, ShouldEqual, 10)
+
+			_, err := boltdbWrapper.GetManifestData("digest01")
+			So(err, ShouldBeNil)
+
+			_, err = boltdbWrapper.GetManifestData("digest02")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestRepoMetaPartitions(t *testing.T) {
+	Convey("The metadata of the repos is spread across the partitions", t, func() {
+		rootDir := t.TempDir()
+
+		boltDriver, err := bolt.GetBoltDriver(bolt.DBParameters{RootDir: rootDir})
+		So(err, ShouldBeNil)
+
+		boltdbWrapper, err := boltdb_wrapper.NewBoltDBWrapper(boltDriver, log.NewLogger("debug", ""))
+		So(err, ShouldBeNil)
+
+		repos := []string{}
+		partitionDBs := map[*bbolt.DB]bool{}
+
+		for i := 0; i < 50; i++ {
+			repo := fmt.Sprintf("repo%02d", i)
+
+			err := boltdbWrapper.SetRepoMeta(repo, repodb.RepoMetadata{Tags: map[string]repodb.Descriptor{}})
+			So(err, ShouldBeNil)
+
+			repos = append(repos, repo)
+			partitionDBs[boltdbWrapper.RepoMetaDB(repo)] = true
+		}
+
+		partitionFiles, err := filepath.Glob(filepath.Join(rootDir, "repo-*.db"))
+		So(err, ShouldBeNil)
+		So(len(partitionFiles), ShouldEqual, bolt.RepoMetadataPartitions)
+		So(len(partitionDBs), ShouldBeGreaterThan, 1)
+
+		// the partitions are the same for every user of the db
+		partitions, err := bolt.GetRepoMetaPartitions(boltDriver)
+		So(err, ShouldBeNil)
+		So(partitions.DB("repo07"), ShouldEqual, boltdbWrapper.RepoMetaDB("repo07"))
+
+		err = partitions.View(func(buckets bolt.RepoMetaBuckets) error {
+			So(buckets.Get([]byte("repo07")), ShouldNotBeNil)
+			So(buckets.Get([]byte("repo50")), ShouldBeNil)
+
+			// iterated like a single bucket, sorted by repo name
+			found := []string{}
+			cursor := buckets.Cursor()
+
+			for repo, _ := cursor.First(); repo != nil; repo, _ = cursor.Next() {
+				found = append(found, string(repo))
+			}
+
+			So(found, ShouldResemble, repos)
+
+			repo, _ := cursor.Seek([]byte("repo25"))
+			So(string(repo), ShouldEqual, "repo25")
+
+			repo, _ = cursor.Next()
+			So(string(repo), ShouldEqual, "repo26")
+
+			return nil
+		})
+		So(err, ShouldBeNil)
+	})
+}
+
 func TestWrapperErrors(t *testing.T) {
 	Convey("Errors", t, func() {
 		ctx := context.Background()
@@ -58,7 +220,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("SetManifestMeta", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 				dataBuck := tx.Bucket([]byte(bolt.ManifestDataBucket))
 
 				err := dataBuck.Put([]byte("digest1"), repoMetaBlob)
@@ -79,7 +241,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("FilterRepos", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				buck := repoMetaBuck{boltdbWrapper}
 				err := buck.Put([]byte("badRepo"), []byte("bad repo"))
 				So(err, ShouldBeNil)
 
@@ -94,7 +256,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("SetReferrer", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo"), []byte("wrong json"))
 			})
@@ -112,7 +274,7 @@ func TestWrapperErrors(t *testing.T) {
 
 			Convey("bad repo meta blob", func() {
 				err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-					repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+					repoBuck := repoMetaBuck{boltdbWrapper}
 
 					return repoBuck.Put([]byte("repo"), []byte("wrong json"))
 				})
@@ -125,7 +287,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("SetRepoReference", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -137,7 +299,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("GetRepoMeta", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -149,7 +311,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("DeleteRepoTag", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -164,7 +326,7 @@ func TestWrapperErrors(t *testing.T) {
 			So(err, ShouldNotBeNil)
 
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -176,7 +338,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("IncrementRepoStars", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -191,7 +353,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("DecrementRepoStars", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -206,7 +368,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("GetRepoStars", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -218,7 +380,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("GetMultipleRepoMeta", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -232,7 +394,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("IncrementImageDownloads", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -245,7 +407,7 @@ func TestWrapperErrors(t *testing.T) {
 			So(err, ShouldNotBeNil)
 
 			err = boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), repoMetaBlob)
 			})
@@ -257,7 +419,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("AddManifestSignature", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -268,7 +430,7 @@ func TestWrapperErrors(t *testing.T) {
 			So(err, ShouldNotBeNil)
 
 			err = boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), repoMetaBlob)
 			})
@@ -281,7 +443,7 @@ func TestWrapperErrors(t *testing.T) {
 
 			//
 			err = boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				repoMeta := repodb.RepoMetadata{
 					Tags: map[string]repodb.Descriptor{},
@@ -333,7 +495,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("DeleteSignature", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -348,7 +510,7 @@ func TestWrapperErrors(t *testing.T) {
 			So(err, ShouldNotBeNil)
 
 			err = boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				repoMeta := repodb.RepoMetadata{
 					Tags: map[string]repodb.Descriptor{},
@@ -386,7 +548,7 @@ func TestWrapperErrors(t *testing.T) {
 
 		Convey("SearchRepos", func() {
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -396,7 +558,7 @@ func TestWrapperErrors(t *testing.T) {
 			So(err, ShouldNotBeNil)
 
 			err = boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 				dataBuck := tx.Bucket([]byte(bolt.ManifestDataBucket))
 
 				err := dataBuck.Put([]byte("dig1"), []byte("wrong json"))
@@ -440,7 +602,7 @@ func TestWrapperErrors(t *testing.T) {
 			So(err, ShouldNotBeNil)
 
 			err = boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 				dataBuck := tx.Bucket([]byte(bolt.ManifestDataBucket))
 
 				manifestMeta := repodb.ManifestMetadata{
@@ -556,7 +718,7 @@ func TestWrapperErrors(t *testing.T) {
 			ctx := context.Background()
 
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 			})
@@ -569,7 +731,7 @@ func TestWrapperErrors(t *testing.T) {
 			So(err, ShouldNotBeNil)
 
 			err = boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 				dataBuck := tx.Bucket([]byte(bolt.ManifestDataBucket))
 
 				manifestMeta := repodb.ManifestMetadata{
@@ -797,7 +959,7 @@ func TestWrapperErrors(t *testing.T) {
 			ctx := context.WithValue(context.Background(), authzCtxKey, acCtx)
 
 			err := boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				err := repoBuck.Put([]byte("repo"), []byte("bad repo"))
 				So(err, ShouldBeNil)
@@ -933,7 +1095,7 @@ func TestWrapperErrors(t *testing.T) {
 			ctx := context.WithValue(context.Background(), authzCtxKey, acCtx)
 
 			err = boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-				repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+				repoBuck := repoMetaBuck{boltdbWrapper}
 
 				err := repoBuck.Put([]byte("repo"), []byte("bad repo"))
 				So(err, ShouldBeNil)
@@ -997,7 +1159,7 @@ func TestWrapperErrors(t *testing.T) {
 				So(err, ShouldBeNil)
 
 				err = boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
-					repoBuck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+					repoBuck := repoMetaBuck{boltdbWrapper}
 
 					return repoBuck.Put([]byte("repo1"), []byte("wrong json"))
 				})
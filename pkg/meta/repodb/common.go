@@ -1,6 +1,9 @@
 package repodb
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	godigest "github.com/opencontainers/go-digest"
@@ -90,3 +93,346 @@ func GetImageDescriptor(repoDB RepoDB, repo, tag string) (Descriptor, error) {
 
 	return imageDescriptor, nil
 }
+
+const (
+	// DailyStatisticsDateFormat is the format of the days the DailyStatistics of repos are keyed by, in UTC.
+	DailyStatisticsDateFormat = "2006-01-02"
+
+	// MaxDailyStatisticsDays is the number of days the DailyStatistics of repos are kept for.
+	MaxDailyStatisticsDays = 90
+)
+
+// CountDailyActivity adds pushes and pulls to the statistics of the day of timestamp in repoMeta, the statistics
+// of the days older than MaxDailyStatisticsDays are dropped.
+func CountDailyActivity(repoMeta RepoMetadata, timestamp time.Time, pushes, pulls int) RepoMetadata {
+	if repoMeta.DailyStatistics == nil {
+		repoMeta.DailyStatistics = map[string]DailyStatistics{}
+	}
+
+	timestamp = timestamp.UTC()
+	day := timestamp.Format(DailyStatisticsDateFormat)
+
+	statistics := repoMeta.DailyStatistics[day]
+	statistics.Pushes += pushes
+	statistics.Pulls += pulls
+	repoMeta.DailyStatistics[day] = statistics
+
+	oldest := timestamp.AddDate(0, 0, -MaxDailyStatisticsDays+1).Format(DailyStatisticsDateFormat)
+
+	for day := range repoMeta.DailyStatistics {
+		// days are formatted to sort chronologically
+		if day < oldest {
+			delete(repoMeta.DailyStatistics, day)
+		}
+	}
+
+	return repoMeta
+}
+
+// AddActivity returns the activity list with the new activity in front, trimmed to maxEntries elements.
+func AddActivity(activities []UserActivity, activity UserActivity, maxEntries int) []UserActivity {
+	activities = append([]UserActivity{activity}, activities...)
+
+	if maxEntries > 0 && len(activities) > maxEntries {
+		activities = activities[:maxEntries]
+	}
+
+	return activities
+}
+
+// GetReferenceDigest returns the digest a tag points to, or the digest itself if it's known in repoMeta.
+// A digest alias is resolved to the digest the manifest is stored with.
+func GetReferenceDigest(repoMeta RepoMetadata, reference string) (string, error) {
+	if _, err := godigest.Parse(reference); err != nil {
+		descriptor, found := repoMeta.Tags[reference]
+		if !found {
+			return "", zerr.ErrManifestMetaNotFound
+		}
+
+		return descriptor.Digest, nil
+	}
+
+	digest := GetAliasedDigest(repoMeta, reference)
+
+	if _, found := repoMeta.Statistics[digest]; !found {
+		return "", zerr.ErrManifestMetaNotFound
+	}
+
+	return digest, nil
+}
+
+/*
+AddPin records a pin of reference in repoMeta, a tag is resolved to the digest it currently points to.
+Pinning an unknown digest or tag returns ErrManifestMetaNotFound.
+*/
+func AddPin(repoMeta RepoMetadata, reference, pinnedBy string, pinnedAt time.Time) (RepoMetadata, PinInfo, error) {
+	digest, err := GetReferenceDigest(repoMeta, reference)
+	if err != nil {
+		return repoMeta, PinInfo{}, err
+	}
+
+	pin := PinInfo{
+		Digest:   digest,
+		PinnedBy: pinnedBy,
+		PinnedAt: pinnedAt,
+	}
+
+	if repoMeta.Pins == nil {
+		repoMeta.Pins = map[string]PinInfo{}
+	}
+
+	repoMeta.Pins[reference] = pin
+
+	return repoMeta, pin, nil
+}
+
+// RemovePin removes the pin of reference from repoMeta, ErrPinNotFound is returned if it's not pinned.
+func RemovePin(repoMeta RepoMetadata, reference string) (RepoMetadata, error) {
+	if _, found := repoMeta.Pins[reference]; !found {
+		return repoMeta, zerr.ErrPinNotFound
+	}
+
+	delete(repoMeta.Pins, reference)
+
+	return repoMeta, nil
+}
+
+// IsDigestPinned returns true if one of the pins of repoMeta points to digest.
+func IsDigestPinned(repoMeta RepoMetadata, digest string) bool {
+	for _, pin := range repoMeta.Pins {
+		if pin.Digest == digest {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+PatchRegistryAnnotations applies patch to the registry annotations of the manifest reference points to,
+keys with a nil value are removed. The annotations are stored by digest so they follow the manifest and not the tag.
+*/
+func PatchRegistryAnnotations(repoMeta RepoMetadata, reference string, patch map[string]*string,
+) (RepoMetadata, string, map[string]string, error) {
+	digest, err := GetReferenceDigest(repoMeta, reference)
+	if err != nil {
+		return repoMeta, "", nil, err
+	}
+
+	annotations := map[string]string{}
+
+	for key, value := range repoMeta.RegistryAnnotations[digest] {
+		annotations[key] = value
+	}
+
+	for key, value := range patch {
+		if strings.TrimSpace(key) == "" || strings.Contains(key, "=") {
+			return repoMeta, "", nil, fmt.Errorf("%w: '%s'", zerr.ErrBadRegistryAnnotation, key)
+		}
+
+		if value == nil {
+			delete(annotations, key)
+
+			continue
+		}
+
+		annotations[key] = *value
+	}
+
+	if repoMeta.RegistryAnnotations == nil {
+		repoMeta.RegistryAnnotations = map[string]map[string]string{}
+	}
+
+	if len(annotations) == 0 {
+		delete(repoMeta.RegistryAnnotations, digest)
+	} else {
+		repoMeta.RegistryAnnotations[digest] = annotations
+	}
+
+	return repoMeta, digest, annotations, nil
+}
+
+/*
+SetCVEAcknowledgement records the acknowledgement of a CVE for the manifest reference points to.
+Like the registry annotations, acknowledgements are stored by digest so they follow the manifest and not the tag.
+*/
+func SetCVEAcknowledgement(repoMeta RepoMetadata, reference, cveID string, ack CVEAcknowledgement,
+) (RepoMetadata, string, error) {
+	digest, err := GetReferenceDigest(repoMeta, reference)
+	if err != nil {
+		return repoMeta, "", err
+	}
+
+	if strings.TrimSpace(cveID) == "" || strings.TrimSpace(ack.Reason) == "" {
+		return repoMeta, "", fmt.Errorf("%w: a CVE id and a reason are required", zerr.ErrBadCVEAcknowledgement)
+	}
+
+	if repoMeta.CVEAcknowledgements == nil {
+		repoMeta.CVEAcknowledgements = map[string]map[string]CVEAcknowledgement{}
+	}
+
+	if repoMeta.CVEAcknowledgements[digest] == nil {
+		repoMeta.CVEAcknowledgements[digest] = map[string]CVEAcknowledgement{}
+	}
+
+	repoMeta.CVEAcknowledgements[digest][cveID] = ack
+
+	return repoMeta, digest, nil
+}
+
+// DeleteCVEAcknowledgement removes the acknowledgement of a CVE for the manifest reference points to.
+func DeleteCVEAcknowledgement(repoMeta RepoMetadata, reference, cveID string) (RepoMetadata, string, error) {
+	digest, err := GetReferenceDigest(repoMeta, reference)
+	if err != nil {
+		return repoMeta, "", err
+	}
+
+	if _, found := repoMeta.CVEAcknowledgements[digest][cveID]; !found {
+		return repoMeta, "", zerr.ErrCVEAcknowledgementNotFound
+	}
+
+	delete(repoMeta.CVEAcknowledgements[digest], cveID)
+
+	if len(repoMeta.CVEAcknowledgements[digest]) == 0 {
+		delete(repoMeta.CVEAcknowledgements, digest)
+	}
+
+	return repoMeta, digest, nil
+}
+
+// GetActiveCVEAcknowledgements returns the acknowledgements of a manifest which haven't expired at the given time.
+func GetActiveCVEAcknowledgements(repoMeta RepoMetadata, digest string, now time.Time,
+) map[string]CVEAcknowledgement {
+	acknowledgements := map[string]CVEAcknowledgement{}
+
+	for cveID, ack := range repoMeta.CVEAcknowledgements[digest] {
+		if ack.ExpiresAt.IsZero() || ack.ExpiresAt.After(now) {
+			acknowledgements[cveID] = ack
+		}
+	}
+
+	return acknowledgements
+}
+
+// SetVulnerabilitySummary records the result of the last CVE scan of a manifest.
+func SetVulnerabilitySummary(repoMeta RepoMetadata, digest string, summary VulnerabilitySummary,
+) (RepoMetadata, error) {
+	if _, found := repoMeta.Statistics[digest]; !found {
+		return repoMeta, zerr.ErrManifestMetaNotFound
+	}
+
+	if repoMeta.VulnerabilitySummaries == nil {
+		repoMeta.VulnerabilitySummaries = map[string]VulnerabilitySummary{}
+	}
+
+	repoMeta.VulnerabilitySummaries[digest] = summary
+
+	return repoMeta, nil
+}
+
+// SetSBOMSummary records the summary of an SBOM referring a manifest, the manifest may not be pushed yet.
+func SetSBOMSummary(repoMeta RepoMetadata, subjectDigest string, summary SBOMSummary) RepoMetadata {
+	if repoMeta.SBOMSummaries == nil {
+		repoMeta.SBOMSummaries = map[string]SBOMSummary{}
+	}
+
+	repoMeta.SBOMSummaries[subjectDigest] = summary
+
+	return repoMeta
+}
+
+// DeleteSBOMSummary removes the summary of an SBOM, unless another SBOM was pushed for the manifest since.
+func DeleteSBOMSummary(repoMeta RepoMetadata, subjectDigest, sbomDigest string) RepoMetadata {
+	if summary, found := repoMeta.SBOMSummaries[subjectDigest]; found && summary.Digest == sbomDigest {
+		delete(repoMeta.SBOMSummaries, subjectDigest)
+	}
+
+	return repoMeta
+}
+
+// SetManifestAttestations records the attestations of a manifest, the manifest may not be pushed yet.
+func SetManifestAttestations(repoMeta RepoMetadata, subjectDigest string, attestations []AttestationInfo,
+) RepoMetadata {
+	if len(attestations) == 0 {
+		delete(repoMeta.Attestations, subjectDigest)
+
+		return repoMeta
+	}
+
+	if repoMeta.Attestations == nil {
+		repoMeta.Attestations = map[string][]AttestationInfo{}
+	}
+
+	repoMeta.Attestations[subjectDigest] = attestations
+
+	return repoMeta
+}
+
+// DeleteManifestAttestations removes the attestations of a manifest, unless they were pushed again since.
+func DeleteManifestAttestations(repoMeta RepoMetadata, subjectDigest, attestationsDigest string) RepoMetadata {
+	attestations, found := repoMeta.Attestations[subjectDigest]
+	if !found || attestations[0].ManifestDigest != attestationsDigest {
+		return repoMeta
+	}
+
+	delete(repoMeta.Attestations, subjectDigest)
+
+	return repoMeta
+}
+
+// SetLintViolations records the lint rules violated by a manifest, the manifest may not be pushed yet.
+func SetLintViolations(repoMeta RepoMetadata, digest string, violations []string) RepoMetadata {
+	if len(violations) == 0 {
+		delete(repoMeta.LintViolations, digest)
+
+		return repoMeta
+	}
+
+	if repoMeta.LintViolations == nil {
+		repoMeta.LintViolations = map[string][]string{}
+	}
+
+	repoMeta.LintViolations[digest] = violations
+
+	return repoMeta
+}
+
+// SetDigestAlias records that the manifest pushed with the alias digest is stored with the given digest.
+func SetDigestAlias(repoMeta RepoMetadata, alias, digest string) RepoMetadata {
+	if alias == digest {
+		return repoMeta
+	}
+
+	if repoMeta.DigestAliases == nil {
+		repoMeta.DigestAliases = map[string]string{}
+	}
+
+	repoMeta.DigestAliases[alias] = digest
+
+	return repoMeta
+}
+
+// GetAliasedDigest returns the digest a manifest pushed with the given digest is stored with, the digest itself
+// if it isn't an alias.
+func GetAliasedDigest(repoMeta RepoMetadata, digest string) string {
+	if aliased, found := repoMeta.DigestAliases[digest]; found {
+		return aliased
+	}
+
+	return digest
+}
+
+// GetDigestAliases returns the sorted digests the manifest with the given digest was pushed with.
+func GetDigestAliases(repoMeta RepoMetadata, digest string) []string {
+	aliases := []string{}
+
+	for alias, aliased := range repoMeta.DigestAliases {
+		if aliased == digest {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	sort.Strings(aliases)
+
+	return aliases
+}
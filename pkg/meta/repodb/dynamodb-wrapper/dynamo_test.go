@@ -316,6 +316,23 @@ func TestWrapperErrors(t *testing.T) {
 			So(err, ShouldNotBeNil)
 		})
 
+		Convey("GetUserActivity GetUserMeta client error", func() {
+			acCtx := localCtx.AccessControlContext{
+				ReadGlobPatterns: map[string]bool{
+					"repo": true,
+				},
+				Username: "username",
+			}
+			authzCtxKey := localCtx.GetContextKey()
+			ctx := context.WithValue(context.Background(), authzCtxKey, acCtx)
+
+			dynamoWrapper.UserDataTablename = badTablename
+
+			activities, err := dynamoWrapper.GetUserActivity(ctx)
+			So(err, ShouldNotBeNil)
+			So(activities, ShouldBeEmpty)
+		})
+
 		Convey("GetUserMeta unmarshal error, bad user data", func() {
 			acCtx := localCtx.AccessControlContext{
 				ReadGlobPatterns: map[string]bool{
@@ -597,7 +597,8 @@ func (dwr *DBWrapper) IncrementImageDownloads(repo string, reference string) err
 		return err
 	}
 
-	descriptorDigest := reference
+	// a manifest pulled by an alias is counted for the digest it's stored with
+	descriptorDigest := repodb.GetAliasedDigest(repoMeta, reference)
 
 	if !common.ReferenceIsDigest(reference) {
 		// search digest for tag
@@ -614,6 +615,8 @@ func (dwr *DBWrapper) IncrementImageDownloads(repo string, reference string) err
 	manifestStatistics.DownloadCount++
 	repoMeta.Statistics[descriptorDigest] = manifestStatistics
 
+	repoMeta = repodb.CountDailyActivity(repoMeta, time.Now(), 0, 1)
+
 	return dwr.SetRepoMeta(repo, repoMeta)
 }
 
@@ -884,13 +887,16 @@ func (dwr *DBWrapper) SearchRepos(ctx context.Context, searchText string, filter
 			repoLastUpdated = time.Time{}
 			osSet           = map[string]bool{}
 			archSet         = map[string]bool{}
+			annotationSet   = map[string]bool{}
+			licenseSet      = map[string]bool{}
 			noImageChecked  = true
 			isSigned        = false
+			maxCVESeverity  = ""
 		)
 
 		for _, descriptor := range repoMeta.Tags {
 			switch descriptor.MediaType {
-			case ispec.MediaTypeImageManifest:
+			case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 				manifestDigest := descriptor.Digest
 
 				manifestMeta, err := dwr.fetchManifestMetaWithCheck(repoMeta.Name, manifestDigest, //nolint:contextcheck
@@ -918,11 +924,21 @@ func (dwr *DBWrapper) SearchRepos(ctx context.Context, searchText string, filter
 					archSet[arch] = true
 				}
 
+				for _, annotation := range manifestFilterData.RegistryAnnotations {
+					annotationSet[annotation] = true
+				}
+
+				for _, license := range manifestFilterData.Licenses {
+					licenseSet[license] = true
+				}
+
+				maxCVESeverity = common.GetNewestImageCVESeverity(repoLastUpdated, noImageChecked, maxCVESeverity,
+					manifestFilterData)
 				repoLastUpdated, noImageChecked, isSigned = common.CheckImageLastUpdated(repoLastUpdated, isSigned,
 					noImageChecked, manifestFilterData)
 
 				manifestMetadataMap[descriptor.Digest] = manifestMeta
-			case ispec.MediaTypeImageIndex:
+			case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 				indexDigest := descriptor.Digest
 
 				indexData, err := dwr.fetchIndexDataWithCheck(indexDigest, indexDataMap) //nolint:contextcheck
@@ -949,8 +965,18 @@ func (dwr *DBWrapper) SearchRepos(ctx context.Context, searchText string, filter
 					osSet[os] = true
 				}
 
+				for _, annotation := range indexFilterData.RegistryAnnotations {
+					annotationSet[annotation] = true
+				}
+
+				for _, license := range indexFilterData.Licenses {
+					licenseSet[license] = true
+				}
+
 				repoDownloads += indexFilterData.DownloadCount
 
+				maxCVESeverity = common.GetNewestImageCVESeverity(repoLastUpdated, noImageChecked, maxCVESeverity,
+					indexFilterData)
 				repoLastUpdated, noImageChecked, isSigned = common.CheckImageLastUpdated(repoLastUpdated, isSigned,
 					noImageChecked, indexFilterData)
 
@@ -963,11 +989,14 @@ func (dwr *DBWrapper) SearchRepos(ctx context.Context, searchText string, filter
 		}
 
 		repoFilterData := repodb.FilterData{
-			OsList:        common.GetMapKeys(osSet),
-			ArchList:      common.GetMapKeys(archSet),
-			LastUpdated:   repoLastUpdated,
-			DownloadCount: repoDownloads,
-			IsSigned:      isSigned,
+			OsList:              common.GetMapKeys(osSet),
+			ArchList:            common.GetMapKeys(archSet),
+			LastUpdated:         repoLastUpdated,
+			DownloadCount:       repoDownloads,
+			IsSigned:            isSigned,
+			RegistryAnnotations: common.GetMapKeys(annotationSet),
+			Licenses:            common.GetMapKeys(licenseSet),
+			MaxCVESeverity:      maxCVESeverity,
 		}
 
 		if !common.AcceptedByFilter(filter, repoFilterData) {
@@ -1052,11 +1081,14 @@ func collectImageManifestFilterData(digest string, repoMeta repodb.RepoMetadata,
 	}
 
 	return repodb.FilterData{
-		DownloadCount: repoMeta.Statistics[digest].DownloadCount,
-		OsList:        osList,
-		ArchList:      archList,
-		LastUpdated:   common.GetImageLastUpdatedTimestamp(configContent),
-		IsSigned:      common.CheckIsSigned(repoMeta.Signatures[digest]),
+		DownloadCount:       repoMeta.Statistics[digest].DownloadCount,
+		OsList:              osList,
+		ArchList:            archList,
+		LastUpdated:         common.GetImageLastUpdatedTimestamp(configContent),
+		IsSigned:            common.CheckIsSigned(repoMeta.Signatures[digest]),
+		RegistryAnnotations: common.GetRegistryAnnotationsList(repoMeta.RegistryAnnotations[digest]),
+		MaxCVESeverity:      common.GetImageMaxCVESeverity(repoMeta, digest, time.Now()),
+		Licenses:            repoMeta.SBOMSummaries[digest].Licenses,
 	}, nil
 }
 
@@ -1096,6 +1128,7 @@ func (dwr *DBWrapper) collectImageIndexFilterInfo(indexDigest string, repoMeta r
 		firstManifestChecked = false
 		indexOsList          = []string{}
 		indexArchList        = []string{}
+		indexMaxCVESeverity  = common.GetImageMaxCVESeverity(repoMeta, indexDigest, time.Now())
 	)
 
 	for _, manifest := range indexContent.Manifests {
@@ -1118,6 +1151,9 @@ func (dwr *DBWrapper) collectImageIndexFilterInfo(indexDigest string, repoMeta r
 		indexOsList = append(indexOsList, manifestFilterData.OsList...)
 		indexArchList = append(indexArchList, manifestFilterData.ArchList...)
 
+		// the manifests of an index are scanned one by one
+		indexMaxCVESeverity = common.GetHigherCVESeverity(indexMaxCVESeverity, manifestFilterData.MaxCVESeverity)
+
 		if !firstManifestChecked || indexLastUpdated.Before(manifestFilterData.LastUpdated) {
 			indexLastUpdated = manifestFilterData.LastUpdated
 			firstManifestChecked = true
@@ -1127,11 +1163,14 @@ func (dwr *DBWrapper) collectImageIndexFilterInfo(indexDigest string, repoMeta r
 	}
 
 	return repodb.FilterData{
-		DownloadCount: repoMeta.Statistics[indexDigest].DownloadCount,
-		LastUpdated:   indexLastUpdated,
-		OsList:        indexOsList,
-		ArchList:      indexArchList,
-		IsSigned:      common.CheckIsSigned(repoMeta.Signatures[indexDigest]),
+		DownloadCount:       repoMeta.Statistics[indexDigest].DownloadCount,
+		LastUpdated:         indexLastUpdated,
+		OsList:              indexOsList,
+		ArchList:            indexArchList,
+		IsSigned:            common.CheckIsSigned(repoMeta.Signatures[indexDigest]),
+		RegistryAnnotations: common.GetRegistryAnnotationsList(repoMeta.RegistryAnnotations[indexDigest]),
+		MaxCVESeverity:      indexMaxCVESeverity,
+		Licenses:            repoMeta.SBOMSummaries[indexDigest].Licenses,
 	}, nil
 }
 
@@ -1185,7 +1224,7 @@ func (dwr *DBWrapper) FilterTags(ctx context.Context, filter repodb.FilterFunc,
 
 		for tag, descriptor := range repoMeta.Tags {
 			switch descriptor.MediaType {
-			case ispec.MediaTypeImageManifest:
+			case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 				manifestDigest := descriptor.Digest
 
 				manifestMeta, err := dwr.fetchManifestMetaWithCheck(repoMeta.Name, manifestDigest, //nolint:contextcheck
@@ -1200,7 +1239,7 @@ func (dwr *DBWrapper) FilterTags(ctx context.Context, filter repodb.FilterFunc,
 					matchedTags[tag] = descriptor
 					manifestMetadataMap[manifestDigest] = manifestMeta
 				}
-			case ispec.MediaTypeImageIndex:
+			case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 				indexDigest := descriptor.Digest
 
 				indexData, err := dwr.fetchIndexDataWithCheck(indexDigest, indexDataMap) //nolint:contextcheck
@@ -1406,7 +1445,7 @@ func (dwr *DBWrapper) SearchTags(ctx context.Context, searchText string, filter
 			matchedTags[tag] = descriptor
 
 			switch descriptor.MediaType {
-			case ispec.MediaTypeImageManifest:
+			case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 				manifestDigest := descriptor.Digest
 
 				manifestMeta, err := dwr.fetchManifestMetaWithCheck(repoMeta.Name, manifestDigest, //nolint:contextcheck
@@ -1431,7 +1470,7 @@ func (dwr *DBWrapper) SearchTags(ctx context.Context, searchText string, filter
 				}
 
 				manifestMetadataMap[descriptor.Digest] = manifestMeta
-			case ispec.MediaTypeImageIndex:
+			case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 				indexDigest := descriptor.Digest
 
 				indexData, err := dwr.fetchIndexDataWithCheck(indexDigest, indexDataMap) //nolint:contextcheck
@@ -1470,6 +1509,20 @@ func (dwr *DBWrapper) SearchTags(ctx context.Context, searchText string, filter
 							fmt.Errorf("%w", err)
 					}
 
+					// annotations set on the index apply to all of its manifests
+					manifestFilterData.RegistryAnnotations = append(manifestFilterData.RegistryAnnotations,
+						common.GetRegistryAnnotationsList(repoMeta.RegistryAnnotations[indexDigest])...)
+
+					// images in an index are scanned together with the index
+					if manifestFilterData.MaxCVESeverity == "" {
+						manifestFilterData.MaxCVESeverity = common.GetImageMaxCVESeverity(repoMeta, indexDigest,
+							time.Now())
+					}
+
+					// the SBOM of the index covers all of its manifests
+					manifestFilterData.Licenses = append(manifestFilterData.Licenses,
+						repoMeta.SBOMSummaries[indexDigest].Licenses...)
+
 					manifestMetadataMap[manifestDigest] = manifestMeta
 
 					if common.AcceptedByFilter(filter, manifestFilterData) {
@@ -1957,6 +2010,251 @@ func (dwr *DBWrapper) GetStarredRepos(ctx context.Context) ([]string, error) {
 	return userMeta.StarredRepos, err
 }
 
+func (dwr *DBWrapper) AddUserActivity(ctx context.Context, activity repodb.UserActivity, maxEntries int) error {
+	userMeta, err := dwr.GetUserMeta(ctx)
+	if err != nil && !errors.Is(err, zerr.ErrUserDataNotFound) {
+		return err
+	}
+
+	userMeta.Activity = repodb.AddActivity(userMeta.Activity, activity, maxEntries)
+
+	return dwr.SetUserMeta(ctx, userMeta)
+}
+
+func (dwr *DBWrapper) GetUserActivity(ctx context.Context) ([]repodb.UserActivity, error) {
+	userMeta, err := dwr.GetUserMeta(ctx)
+	if err != nil {
+		if errors.Is(err, zerr.ErrUserDataNotFound) {
+			return []repodb.UserActivity{}, nil
+		}
+
+		return []repodb.UserActivity{}, err
+	}
+
+	if userMeta.Activity == nil {
+		return []repodb.UserActivity{}, nil
+	}
+
+	return userMeta.Activity, nil
+}
+
+func (dwr *DBWrapper) PinImage(ctx context.Context, repo string, reference string) (repodb.PinInfo, error) {
+	acCtx, err := localCtx.GetAccessControlContext(ctx)
+	if err != nil {
+		return repodb.PinInfo{}, err
+	}
+
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return repodb.PinInfo{}, err
+	}
+
+	repoMeta, pin, err := repodb.AddPin(repoMeta, reference, localCtx.GetUsernameFromContext(acCtx), time.Now())
+	if err != nil {
+		return repodb.PinInfo{}, err
+	}
+
+	return pin, dwr.SetRepoMeta(repo, repoMeta)
+}
+
+func (dwr *DBWrapper) UnpinImage(repo string, reference string) error {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return err
+	}
+
+	repoMeta, err = repodb.RemovePin(repoMeta, reference)
+	if err != nil {
+		return err
+	}
+
+	return dwr.SetRepoMeta(repo, repoMeta)
+}
+
+func (dwr *DBWrapper) PatchRegistryAnnotations(repo string, reference string, patch map[string]*string,
+) (godigest.Digest, map[string]string, error) {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return "", nil, err
+	}
+
+	repoMeta, digest, annotations, err := repodb.PatchRegistryAnnotations(repoMeta, reference, patch)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return godigest.Digest(digest), annotations, dwr.SetRepoMeta(repo, repoMeta)
+}
+
+func (dwr *DBWrapper) SetCVEAcknowledgement(repo string, reference string, cveID string,
+	ack repodb.CVEAcknowledgement,
+) (godigest.Digest, error) {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return "", err
+	}
+
+	repoMeta, digest, err := repodb.SetCVEAcknowledgement(repoMeta, reference, cveID, ack)
+	if err != nil {
+		return "", err
+	}
+
+	return godigest.Digest(digest), dwr.SetRepoMeta(repo, repoMeta)
+}
+
+func (dwr *DBWrapper) DeleteCVEAcknowledgement(repo string, reference string, cveID string,
+) (godigest.Digest, error) {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return "", err
+	}
+
+	repoMeta, digest, err := repodb.DeleteCVEAcknowledgement(repoMeta, reference, cveID)
+	if err != nil {
+		return "", err
+	}
+
+	return godigest.Digest(digest), dwr.SetRepoMeta(repo, repoMeta)
+}
+
+func (dwr *DBWrapper) SetVulnerabilitySummary(repo string, digest godigest.Digest,
+	summary repodb.VulnerabilitySummary,
+) error {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return err
+	}
+
+	repoMeta, err = repodb.SetVulnerabilitySummary(repoMeta, digest.String(), summary)
+	if err != nil {
+		return err
+	}
+
+	return dwr.SetRepoMeta(repo, repoMeta)
+}
+
+func (dwr *DBWrapper) SetSBOMSummary(repo string, subjectDigest godigest.Digest, summary repodb.SBOMSummary,
+) error {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return err
+	}
+
+	return dwr.SetRepoMeta(repo, repodb.SetSBOMSummary(repoMeta, subjectDigest.String(), summary))
+}
+
+func (dwr *DBWrapper) DeleteSBOMSummary(repo string, subjectDigest, sbomDigest godigest.Digest) error {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return err
+	}
+
+	return dwr.SetRepoMeta(repo, repodb.DeleteSBOMSummary(repoMeta, subjectDigest.String(), sbomDigest.String()))
+}
+
+func (dwr *DBWrapper) SetManifestAttestations(repo string, subjectDigest godigest.Digest,
+	attestations []repodb.AttestationInfo,
+) error {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		if !errors.Is(err, zerr.ErrRepoMetaNotFound) {
+			return err
+		}
+
+		// like signatures, attestations may be pushed before anything else in the repo
+		repoMeta = repodb.RepoMetadata{
+			Name:       repo,
+			Tags:       map[string]repodb.Descriptor{},
+			Statistics: map[string]repodb.DescriptorStatistics{},
+			Signatures: map[string]repodb.ManifestSignatures{},
+			Referrers:  map[string][]repodb.ReferrerInfo{},
+		}
+	}
+
+	return dwr.SetRepoMeta(repo, repodb.SetManifestAttestations(repoMeta, subjectDigest.String(), attestations))
+}
+
+func (dwr *DBWrapper) DeleteManifestAttestations(repo string, subjectDigest, attestationsDigest godigest.Digest,
+) error {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return err
+	}
+
+	return dwr.SetRepoMeta(repo, repodb.DeleteManifestAttestations(repoMeta, subjectDigest.String(),
+		attestationsDigest.String()))
+}
+
+func (dwr *DBWrapper) SetLintViolations(repo string, manifestDigest godigest.Digest, violations []string) error {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		if !errors.Is(err, zerr.ErrRepoMetaNotFound) {
+			return err
+		}
+
+		if len(violations) == 0 {
+			return nil
+		}
+
+		// the violations may be recorded before the repo meta is created
+		repoMeta = repodb.RepoMetadata{
+			Name:       repo,
+			Tags:       map[string]repodb.Descriptor{},
+			Statistics: map[string]repodb.DescriptorStatistics{},
+			Signatures: map[string]repodb.ManifestSignatures{},
+			Referrers:  map[string][]repodb.ReferrerInfo{},
+		}
+	}
+
+	return dwr.SetRepoMeta(repo, repodb.SetLintViolations(repoMeta, manifestDigest.String(), violations))
+}
+
+func (dwr *DBWrapper) SetDigestAlias(repo string, alias, manifestDigest godigest.Digest) error {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return err
+	}
+
+	return dwr.SetRepoMeta(repo, repodb.SetDigestAlias(repoMeta, alias.String(), manifestDigest.String()))
+}
+
+func (dwr *DBWrapper) ResolveDigestAlias(repo string, alias godigest.Digest) (godigest.Digest, error) {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return "", err
+	}
+
+	manifestDigest, found := repoMeta.DigestAliases[alias.String()]
+	if !found {
+		return "", zerr.ErrManifestMetaNotFound
+	}
+
+	return godigest.Digest(manifestDigest), nil
+}
+
+func (dwr *DBWrapper) IncrementRepoPushes(repo string) error {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		return err
+	}
+
+	return dwr.SetRepoMeta(repo, repodb.CountDailyActivity(repoMeta, time.Now(), 1, 0))
+}
+
+func (dwr *DBWrapper) IsImagePinned(repo string, digest godigest.Digest) (bool, error) {
+	repoMeta, err := dwr.GetRepoMeta(repo)
+	if err != nil {
+		if errors.Is(err, zerr.ErrRepoMetaNotFound) {
+			// repos not known by repoDB have no pins
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return repodb.IsDigestPinned(repoMeta, digest.String()), nil
+}
+
 func (dwr *DBWrapper) GetUserMeta(ctx context.Context) (repodb.UserData, error) {
 	acCtx, err := localCtx.GetAccessControlContext(ctx)
 	if err != nil {
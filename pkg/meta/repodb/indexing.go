@@ -0,0 +1,175 @@
+package repodb
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage"
+)
+
+const (
+	indexingProgressInterval = 30 * time.Second
+	// a repo is parsed again if its index changed while it was parsed, at most this many times
+	maxRepoParseAttempts = 3
+)
+
+// IndexingProgress is the progress of parsing the storage into repodb in the background.
+type IndexingProgress struct {
+	InProgress  bool      `json:"inProgress"`
+	Repos       int       `json:"repos"`
+	ParsedRepos int       `json:"parsedRepos"`
+	FailedRepos int       `json:"failedRepos"`
+	StartedAt   time.Time `json:"startedAt"`
+}
+
+// IndexingStatus tracks parsing the storage into repodb in the background, meanwhile repodb is missing
+// the repos which weren't parsed yet, except for the images pushed since the registry started.
+type IndexingStatus struct {
+	lock     sync.RWMutex
+	progress IndexingProgress
+	done     chan struct{}
+}
+
+// InProgress returns whether the storage is still being parsed.
+func (status *IndexingStatus) InProgress() bool {
+	if status == nil {
+		return false
+	}
+
+	status.lock.RLock()
+	defer status.lock.RUnlock()
+
+	return status.progress.InProgress
+}
+
+// Progress returns how many repos were parsed so far.
+func (status *IndexingStatus) Progress() IndexingProgress {
+	if status == nil {
+		return IndexingProgress{}
+	}
+
+	status.lock.RLock()
+	defer status.lock.RUnlock()
+
+	return status.progress
+}
+
+// Wait blocks until the storage is parsed, or parsing it is canceled.
+func (status *IndexingStatus) Wait() {
+	if status == nil {
+		return
+	}
+
+	<-status.done
+}
+
+func (status *IndexingStatus) update(update func(progress *IndexingProgress)) {
+	status.lock.Lock()
+	defer status.lock.Unlock()
+
+	update(&status.progress)
+}
+
+// ParseStorageInBackground syncs the repos found in storage with repodb like ParseStorage, but one repo
+// at a time in the background, so the registry can serve requests meanwhile. A repo which fails to be parsed
+// is skipped, parsing stops when ctx is canceled.
+func ParseStorageInBackground(ctx context.Context, repoDB RepoDB, storeController storage.StoreController,
+	log log.Logger,
+) *IndexingStatus {
+	status := &IndexingStatus{
+		progress: IndexingProgress{InProgress: true, StartedAt: time.Now()},
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		defer close(status.done)
+		defer status.update(func(progress *IndexingProgress) {
+			progress.InProgress = false
+		})
+
+		allRepos, err := getAllRepos(storeController)
+		if err != nil {
+			log.Error().Err(err).Msg("load-local-layout: failed to get all repo names, repodb is not populated")
+
+			return
+		}
+
+		status.update(func(progress *IndexingProgress) {
+			progress.Repos = len(allRepos)
+		})
+
+		log.Info().Int("repos", len(allRepos)).Msg("load-local-layout: populating repodb in the background")
+
+		lastReport := time.Now()
+
+		for _, repo := range allRepos {
+			select {
+			case <-ctx.Done():
+				log.Info().Interface("progress", status.Progress()).
+					Msg("load-local-layout: populating repodb canceled")
+
+				return
+			default:
+			}
+
+			err := parseRepoUntilStable(repo, repoDB, storeController, log)
+			if err != nil {
+				log.Error().Err(err).Str("repository", repo).Msg("load-local-layout: failed to sync repo, skipping it")
+			}
+
+			status.update(func(progress *IndexingProgress) {
+				if err != nil {
+					progress.FailedRepos++
+				} else {
+					progress.ParsedRepos++
+				}
+			})
+
+			if time.Since(lastReport) >= indexingProgressInterval {
+				lastReport = time.Now()
+
+				log.Info().Interface("progress", status.Progress()).Msg("load-local-layout: populating repodb")
+			}
+		}
+
+		progress := status.Progress()
+
+		log.Info().Int("parsedRepos", progress.ParsedRepos).Int("failedRepos", progress.FailedRepos).
+			Str("duration", time.Since(progress.StartedAt).String()).Msg("load-local-layout: repodb populated")
+	}()
+
+	return status
+}
+
+// parseRepoUntilStable parses a repo again if an image was pushed or deleted while it was parsed,
+// the tags of a repo being reset before they are parsed.
+func parseRepoUntilStable(repo string, repoDB RepoDB, storeController storage.StoreController,
+	log log.Logger,
+) error {
+	imageStore := storeController.GetImageStore(repo)
+
+	var err error
+
+	for attempt := 0; attempt < maxRepoParseAttempts; attempt++ {
+		indexBefore, indexErr := imageStore.GetIndexContent(repo)
+		if indexErr != nil {
+			return indexErr
+		}
+
+		if err = ParseRepo(repo, repoDB, storeController, log); err != nil {
+			return err
+		}
+
+		indexAfter, indexErr := imageStore.GetIndexContent(repo)
+		if indexErr != nil || bytes.Equal(indexBefore, indexAfter) {
+			return indexErr
+		}
+
+		log.Debug().Str("repository", repo).Msg("load-repo: repo changed while it was parsed, parsing it again")
+	}
+
+	return err
+}
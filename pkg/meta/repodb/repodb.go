@@ -86,6 +86,9 @@ type RepoDB interface { //nolint:interfacebloat
 	// IncrementManifestDownloads adds 1 to the download count of a manifest
 	IncrementImageDownloads(repo string, reference string) error
 
+	// IncrementRepoPushes adds 1 to the push count of the current day of a repo
+	IncrementRepoPushes(repo string) error
+
 	// AddManifestSignature adds signature metadata to a given manifest in the database
 	AddManifestSignature(repo string, signedManifestDigest godigest.Digest, sm SignatureMetadata) error
 
@@ -123,6 +126,64 @@ type RepoDB interface { //nolint:interfacebloat
 	// ToggleBookmarkRepo adds/removes bookmarks on repos
 	ToggleBookmarkRepo(ctx context.Context, reponame string) (ToggleState, error)
 
+	// AddUserActivity records an action of the current user, only the newest maxEntries actions are kept
+	AddUserActivity(ctx context.Context, activity UserActivity, maxEntries int) error
+
+	// GetUserActivity returns the actions recorded for the current user, newest first
+	GetUserActivity(ctx context.Context) ([]UserActivity, error)
+
+	// PinImage pins a reference (tag or digest) of a repo, the pin is recorded along with the current user
+	PinImage(ctx context.Context, repo string, reference string) (PinInfo, error)
+
+	// UnpinImage removes the pin of a reference of a repo
+	UnpinImage(repo string, reference string) error
+
+	// IsImagePinned returns true if a pin of the repo points to the given digest
+	IsImagePinned(repo string, digest godigest.Digest) (bool, error)
+
+	// PatchRegistryAnnotations updates the registry annotations of the manifest a reference (tag or digest)
+	// points to, a nil value removes the annotation. It returns the digest and its updated annotations
+	PatchRegistryAnnotations(repo string, reference string, patch map[string]*string,
+	) (godigest.Digest, map[string]string, error)
+
+	// SetCVEAcknowledgement acknowledges a CVE for the manifest a reference (tag or digest) points to,
+	// replacing any previous acknowledgement of the same CVE. It returns the digest
+	SetCVEAcknowledgement(repo string, reference string, cveID string, ack CVEAcknowledgement,
+	) (godigest.Digest, error)
+
+	// DeleteCVEAcknowledgement removes the acknowledgement of a CVE for the manifest a reference points to
+	DeleteCVEAcknowledgement(repo string, reference string, cveID string) (godigest.Digest, error)
+
+	// SetVulnerabilitySummary records the result of the last CVE scan of a manifest, so images can be
+	// filtered by their CVEs without scanning them again
+	SetVulnerabilitySummary(repo string, digest godigest.Digest, summary VulnerabilitySummary) error
+
+	// SetSBOMSummary records the licenses and packages found in an SBOM referring the given manifest,
+	// replacing the summary of any other SBOM referring it
+	SetSBOMSummary(repo string, subjectDigest godigest.Digest, summary SBOMSummary) error
+
+	// DeleteSBOMSummary removes the summary of the given SBOM, if it's still the one recorded for the manifest
+	DeleteSBOMSummary(repo string, subjectDigest godigest.Digest, sbomDigest godigest.Digest) error
+
+	// SetManifestAttestations records the attestations cosign attached to the given manifest, replacing
+	// the ones which were recorded, cosign pushes all the attestations of a manifest in a single manifest
+	SetManifestAttestations(repo string, subjectDigest godigest.Digest, attestations []AttestationInfo) error
+
+	// DeleteManifestAttestations removes the attestations of the given manifest, if they are still the ones
+	// pushed in the given attestations manifest
+	DeleteManifestAttestations(repo string, subjectDigest godigest.Digest, attestationsDigest godigest.Digest) error
+
+	// SetLintViolations records the lint rules an image pushed in spite of them violates, an empty list clears them.
+	// The repo meta is created if it doesn't exist yet
+	SetLintViolations(repo string, manifestDigest godigest.Digest, violations []string) error
+
+	// SetDigestAlias records that a manifest pushed with the alias digest is stored with another digest, e.g.
+	// after it was converted, so it can still be found by the digest it was pushed with
+	SetDigestAlias(repo string, alias godigest.Digest, manifestDigest godigest.Digest) error
+
+	// ResolveDigestAlias returns the digest a manifest pushed with the alias digest is stored with
+	ResolveDigestAlias(repo string, alias godigest.Digest) (godigest.Digest, error)
+
 	PatchDB() error
 }
 
@@ -160,6 +221,12 @@ type DescriptorStatistics struct {
 	DownloadCount int
 }
 
+// DailyStatistics counts the images pushed to and pulled from a repo in a day.
+type DailyStatistics struct {
+	Pushes int
+	Pulls  int
+}
+
 type ManifestSignatures map[string][]SignatureInfo
 
 type RepoMetadata struct {
@@ -169,6 +236,24 @@ type RepoMetadata struct {
 	Statistics map[string]DescriptorStatistics
 	Signatures map[string]ManifestSignatures
 	Referrers  map[string][]ReferrerInfo
+	// map[reference]PinInfo
+	Pins map[string]PinInfo `json:",omitempty"`
+	// map[manifestDigest]map[key]value, annotations set by users on the registry side
+	RegistryAnnotations map[string]map[string]string `json:",omitempty"`
+	// map[manifestDigest]map[cveID]CVEAcknowledgement
+	CVEAcknowledgements map[string]map[string]CVEAcknowledgement `json:",omitempty"`
+	// map[manifestDigest]VulnerabilitySummary, only set for the images which were scanned
+	VulnerabilitySummaries map[string]VulnerabilitySummary `json:",omitempty"`
+	// map[subjectDigest]SBOMSummary, the summary of the last SBOM pushed as a referrer of the manifest
+	SBOMSummaries map[string]SBOMSummary `json:",omitempty"`
+	// map[subjectDigest]attestations, the attestations attached to the manifest, kept apart from its signatures
+	Attestations map[string][]AttestationInfo `json:",omitempty"`
+	// map[manifestDigest]violations, the lint rules violated by the images accepted with warnings
+	LintViolations map[string][]string `json:",omitempty"`
+	// map[day]DailyStatistics, the pushes and pulls of the repo for each of the last MaxDailyStatisticsDays days
+	DailyStatistics map[string]DailyStatistics `json:",omitempty"`
+	// map[aliasDigest]manifestDigest, the digests manifests were pushed with before they were rewritten
+	DigestAliases map[string]string `json:",omitempty"`
 
 	IsStarred    bool
 	IsBookmarked bool
@@ -176,6 +261,54 @@ type RepoMetadata struct {
 	Stars int
 }
 
+// PinInfo describes a pinned reference, Digest is the manifest the reference pointed to when it was pinned.
+type PinInfo struct {
+	Digest   string
+	PinnedBy string
+	PinnedAt time.Time
+}
+
+// CVEAcknowledgement records that a CVE found in an image was reviewed, it is not counted in the
+// vulnerability summaries of the image until it expires.
+type CVEAcknowledgement struct {
+	Reason         string
+	AcknowledgedBy string
+	AcknowledgedAt time.Time
+	// zero if the acknowledgement doesn't expire
+	ExpiresAt time.Time
+}
+
+// VulnerabilitySummary is the result of a CVE scan, CVEs acknowledged when the scan completed are not counted.
+type VulnerabilitySummary struct {
+	MaxSeverity string
+	Count       int
+	// map[cveID]severity of all the CVEs found, acknowledged or not
+	Severities map[string]string `json:",omitempty"`
+	UpdatedAt  time.Time
+}
+
+// SBOMSummary is what is extracted from an SBOM artifact referring an image.
+type SBOMSummary struct {
+	// digest of the SBOM artifact
+	Digest string
+	// SPDX or CycloneDX
+	Format string
+	// sorted SPDX license identifiers of the packages
+	Licenses     []string
+	PackageCount int
+}
+
+// AttestationInfo describes an in-toto attestation attached to an image, e.g. with 'cosign attest'.
+type AttestationInfo struct {
+	// digest of the manifest holding the attestation
+	ManifestDigest string
+	// digest of the layer holding the DSSE envelope of the attestation
+	LayerDigest string
+	// e.g. https://slsa.dev/provenance/v0.2, empty if the attestation doesn't tell
+	PredicateType string
+	Tool          string
+}
+
 type LayerInfo struct {
 	LayerDigest  string
 	LayerContent []byte
@@ -199,6 +332,17 @@ type UserData struct {
 	// data for each user.
 	StarredRepos    []string
 	BookmarkedRepos []string
+	Activity        []UserActivity
+}
+
+// UserActivity describes an action done by a user, client details are only filled in if configured.
+type UserActivity struct {
+	Action        string
+	Repo          string
+	Reference     string
+	Timestamp     time.Time
+	ClientAddress string `json:",omitempty"`
+	UserAgent     string `json:",omitempty"`
 }
 
 type SortCriteria string
@@ -224,6 +368,13 @@ type Filter struct {
 	HasToBeSigned *bool
 	IsBookmarked  *bool
 	IsStarred     *bool
+	// "key=value" or "key", all of them have to match
+	RegistryAnnotations []*string
+	// images which were scanned and have no CVE more severe than this
+	MaxCVESeverity   *string
+	LastUpdatedAfter *time.Time
+	// images with any of these licenses in their SBOM are excluded
+	ExcludedLicenses []*string
 }
 
 type FilterData struct {
@@ -234,4 +385,10 @@ type FilterData struct {
 	IsSigned      bool
 	IsStarred     bool
 	IsBookmarked  bool
+	// "key=value" entries
+	RegistryAnnotations []string
+	// empty if the image wasn't scanned
+	MaxCVESeverity string
+	// licenses found in the SBOM of the image
+	Licenses []string
 }
@@ -3,6 +3,7 @@ package repodb_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -21,6 +22,7 @@ import (
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	. "github.com/smartystreets/goconvey/convey"
 
+	zerr "zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/bolt"
 	"zotregistry.io/zot/pkg/meta/common"
@@ -41,7 +43,7 @@ const (
 
 func TestBoltDBWrapper(t *testing.T) {
 	Convey("BoltDB Wrapper creation", t, func() {
-		boltDBParams := bolt.DBParameters{}
+		boltDBParams := bolt.DBParameters{RootDir: t.TempDir()}
 		boltDriver, err := bolt.GetBoltDriver(boltDBParams)
 		So(err, ShouldBeNil)
 
@@ -51,27 +53,26 @@ func TestBoltDBWrapper(t *testing.T) {
 		So(repoDB, ShouldNotBeNil)
 		So(err, ShouldBeNil)
 
-		err = os.Chmod("repo.db", 0o200)
+		repoDBPath := path.Join(boltDBParams.RootDir, "repo.db")
+
+		err = os.Chmod(repoDBPath, 0o200)
 		So(err, ShouldBeNil)
 
 		_, err = bolt.GetBoltDriver(boltDBParams)
 		So(err, ShouldNotBeNil)
 
-		err = os.Chmod("repo.db", 0o600)
+		err = os.Chmod(repoDBPath, 0o600)
 		So(err, ShouldBeNil)
-
-		defer os.Remove("repo.db")
 	})
 
 	Convey("BoltDB Wrapper", t, func() {
-		boltDBParams := bolt.DBParameters{}
+		boltDBParams := bolt.DBParameters{RootDir: t.TempDir()}
 		boltDriver, err := bolt.GetBoltDriver(boltDBParams)
 		So(err, ShouldBeNil)
 
 		log := log.NewLogger("debug", "")
 
 		boltdbWrapper, err := boltdb_wrapper.NewBoltDBWrapper(boltDriver, log)
-		defer os.Remove("repo.db")
 		So(boltdbWrapper, ShouldNotBeNil)
 		So(err, ShouldBeNil)
 
@@ -537,6 +538,49 @@ func RunRepoDBTests(t *testing.T, repoDB repodb.RepoDB, preparationFuncs ...func
 			So(err, ShouldNotBeNil)
 		})
 
+		Convey("Test user activity", func() {
+			authzCtxKey := localCtx.GetContextKey()
+
+			// "user1"
+			ctx1 := context.WithValue(context.Background(), authzCtxKey,
+				localCtx.AccessControlContext{Username: "user1"})
+
+			// "user2"
+			ctx2 := context.WithValue(context.Background(), authzCtxKey,
+				localCtx.AccessControlContext{Username: "user2"})
+
+			// anonymous
+			ctx3 := context.WithValue(context.Background(), authzCtxKey,
+				localCtx.AccessControlContext{Username: ""})
+
+			activity, err := repoDB.GetUserActivity(ctx1)
+			So(err, ShouldBeNil)
+			So(activity, ShouldBeEmpty)
+
+			for _, tag := range []string{"tag1", "tag2", "tag3"} {
+				err = repoDB.AddUserActivity(ctx1, repodb.UserActivity{
+					Action:    "pull",
+					Repo:      "repo",
+					Reference: tag,
+					Timestamp: time.Now(),
+				}, 2)
+				So(err, ShouldBeNil)
+			}
+
+			activity, err = repoDB.GetUserActivity(ctx1)
+			So(err, ShouldBeNil)
+			So(len(activity), ShouldEqual, 2)
+			So(activity[0].Reference, ShouldEqual, "tag3")
+			So(activity[1].Reference, ShouldEqual, "tag2")
+
+			activity, err = repoDB.GetUserActivity(ctx2)
+			So(err, ShouldBeNil)
+			So(activity, ShouldBeEmpty)
+
+			err = repoDB.AddUserActivity(ctx3, repodb.UserActivity{Action: "pull", Repo: "repo"}, 2)
+			So(err, ShouldNotBeNil)
+		})
+
 		Convey("Test repo stars for user", func() {
 			var (
 				repo1           = "repo1"
@@ -970,6 +1014,532 @@ func RunRepoDBTests(t *testing.T, repoDB repodb.RepoDB, preparationFuncs ...func
 			So(err, ShouldNotBeNil)
 		})
 
+		Convey("Test daily statistics", func() {
+			manifestDigest := godigest.FromString("manifest")
+
+			err := repoDB.SetRepoReference("repo", "tag", manifestDigest, ispec.MediaTypeImageManifest)
+			So(err, ShouldBeNil)
+
+			err = repoDB.IncrementRepoPushes("repo")
+			So(err, ShouldBeNil)
+
+			err = repoDB.IncrementImageDownloads("repo", "tag")
+			So(err, ShouldBeNil)
+
+			err = repoDB.IncrementImageDownloads("repo", manifestDigest.String())
+			So(err, ShouldBeNil)
+
+			repoMeta, err := repoDB.GetRepoMeta("repo")
+			So(err, ShouldBeNil)
+
+			today := time.Now().UTC().Format(repodb.DailyStatisticsDateFormat)
+			So(repoMeta.DailyStatistics, ShouldResemble, map[string]repodb.DailyStatistics{
+				today: {Pushes: 1, Pulls: 2},
+			})
+
+			err = repoDB.IncrementRepoPushes("missing-repo")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Test image pins", func() {
+			var (
+				repo1 = "repo1"
+				tag1  = "0.0.1"
+			)
+
+			_, manifestBlob, err := generateTestImage()
+			So(err, ShouldBeNil)
+
+			manifestDigest := godigest.FromBytes(manifestBlob)
+			untaggedDigest := godigest.FromString("untagged")
+
+			err = repoDB.SetRepoReference(repo1, tag1, manifestDigest, ispec.MediaTypeImageManifest)
+			So(err, ShouldBeNil)
+
+			err = repoDB.SetRepoReference(repo1, untaggedDigest.String(), untaggedDigest, ispec.MediaTypeImageManifest)
+			So(err, ShouldBeNil)
+
+			ctx := context.WithValue(context.Background(), localCtx.GetContextKey(),
+				localCtx.AccessControlContext{Username: "user1"})
+
+			pinned, err := repoDB.IsImagePinned(repo1, manifestDigest)
+			So(err, ShouldBeNil)
+			So(pinned, ShouldBeFalse)
+
+			pinInfo, err := repoDB.PinImage(ctx, repo1, tag1)
+			So(err, ShouldBeNil)
+			So(pinInfo.Digest, ShouldEqual, manifestDigest.String())
+			So(pinInfo.PinnedBy, ShouldEqual, "user1")
+
+			pinInfo, err = repoDB.PinImage(context.Background(), repo1, untaggedDigest.String())
+			So(err, ShouldBeNil)
+			So(pinInfo.Digest, ShouldEqual, untaggedDigest.String())
+			So(pinInfo.PinnedBy, ShouldBeEmpty)
+
+			// the pin of a tag keeps pointing to the same image when the tag moves
+			err = repoDB.SetRepoReference(repo1, tag1, untaggedDigest, ispec.MediaTypeImageManifest)
+			So(err, ShouldBeNil)
+
+			pinned, err = repoDB.IsImagePinned(repo1, manifestDigest)
+			So(err, ShouldBeNil)
+			So(pinned, ShouldBeTrue)
+
+			repoMeta, err := repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(len(repoMeta.Pins), ShouldEqual, 2)
+			So(repoMeta.Pins[tag1].Digest, ShouldEqual, manifestDigest.String())
+
+			_, err = repoDB.PinImage(ctx, repo1, "missing-tag")
+			So(errors.Is(err, zerr.ErrManifestMetaNotFound), ShouldBeTrue)
+
+			_, err = repoDB.PinImage(ctx, repo1, godigest.FromString("missing").String())
+			So(errors.Is(err, zerr.ErrManifestMetaNotFound), ShouldBeTrue)
+
+			_, err = repoDB.PinImage(ctx, "missing-repo", tag1)
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+
+			err = repoDB.UnpinImage(repo1, tag1)
+			So(err, ShouldBeNil)
+
+			pinned, err = repoDB.IsImagePinned(repo1, manifestDigest)
+			So(err, ShouldBeNil)
+			So(pinned, ShouldBeFalse)
+
+			pinned, err = repoDB.IsImagePinned(repo1, untaggedDigest)
+			So(err, ShouldBeNil)
+			So(pinned, ShouldBeTrue)
+
+			err = repoDB.UnpinImage(repo1, tag1)
+			So(errors.Is(err, zerr.ErrPinNotFound), ShouldBeTrue)
+
+			err = repoDB.UnpinImage("missing-repo", tag1)
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+
+			pinned, err = repoDB.IsImagePinned("missing-repo", manifestDigest)
+			So(err, ShouldBeNil)
+			So(pinned, ShouldBeFalse)
+		})
+
+		Convey("Test registry annotations", func() {
+			var (
+				repo1 = "repo1"
+				repo2 = "repo2"
+				tag1  = "0.0.1"
+				owner = "team-a"
+			)
+
+			configBlob, manifestBlob, err := generateTestImage()
+			So(err, ShouldBeNil)
+
+			manifestDigest := godigest.FromBytes(manifestBlob)
+
+			for _, repo := range []string{repo1, repo2} {
+				err = repoDB.SetRepoReference(repo, tag1, manifestDigest, ispec.MediaTypeImageManifest)
+				So(err, ShouldBeNil)
+
+				err = repoDB.SetManifestMeta(repo, manifestDigest, repodb.ManifestMetadata{
+					ManifestBlob: manifestBlob,
+					ConfigBlob:   configBlob,
+				})
+				So(err, ShouldBeNil)
+			}
+
+			ticket := "JIRA-1"
+
+			digest, annotations, err := repoDB.PatchRegistryAnnotations(repo1, tag1,
+				map[string]*string{"owner": &owner, "ticket": &ticket})
+			So(err, ShouldBeNil)
+			So(digest, ShouldEqual, manifestDigest)
+			So(annotations, ShouldResemble, map[string]string{"owner": owner, "ticket": ticket})
+
+			// a nil value removes the annotation
+			digest, annotations, err = repoDB.PatchRegistryAnnotations(repo1, manifestDigest.String(),
+				map[string]*string{"ticket": nil})
+			So(err, ShouldBeNil)
+			So(digest, ShouldEqual, manifestDigest)
+			So(annotations, ShouldResemble, map[string]string{"owner": owner})
+
+			repoMeta, err := repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.RegistryAnnotations[manifestDigest.String()], ShouldResemble, map[string]string{"owner": owner})
+
+			// the annotations of the same manifest in other repos are not changed
+			repoMeta, err = repoDB.GetRepoMeta(repo2)
+			So(err, ShouldBeNil)
+			So(repoMeta.RegistryAnnotations, ShouldBeEmpty)
+
+			ownerFilter := "owner=" + owner
+			filter := repodb.Filter{RegistryAnnotations: []*string{&ownerFilter}}
+
+			repos, _, _, _, err := repoDB.SearchRepos(context.Background(), "repo", filter, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(len(repos), ShouldEqual, 1)
+			So(repos[0].Name, ShouldEqual, repo1)
+
+			repos, _, _, _, err = repoDB.SearchTags(context.Background(), "repo1:", filter, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(len(repos), ShouldEqual, 1)
+			So(repos[0].Tags, ShouldContainKey, tag1)
+
+			repos, _, _, _, err = repoDB.SearchTags(context.Background(), "repo2:", filter, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(repos, ShouldBeEmpty)
+
+			_, _, err = repoDB.PatchRegistryAnnotations(repo1, tag1, map[string]*string{"": &owner})
+			So(errors.Is(err, zerr.ErrBadRegistryAnnotation), ShouldBeTrue)
+
+			_, _, err = repoDB.PatchRegistryAnnotations(repo1, "missing-tag", map[string]*string{"owner": &owner})
+			So(errors.Is(err, zerr.ErrManifestMetaNotFound), ShouldBeTrue)
+
+			_, _, err = repoDB.PatchRegistryAnnotations("missing-repo", tag1, map[string]*string{"owner": &owner})
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+
+			// removing all annotations removes the entry of the manifest
+			_, annotations, err = repoDB.PatchRegistryAnnotations(repo1, tag1, map[string]*string{"owner": nil})
+			So(err, ShouldBeNil)
+			So(annotations, ShouldBeEmpty)
+
+			repoMeta, err = repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.RegistryAnnotations, ShouldNotContainKey, manifestDigest.String())
+		})
+
+		Convey("Test CVE acknowledgements", func() {
+			var (
+				repo1 = "repo1"
+				tag1  = "0.0.1"
+				cveID = "CVE-2023-0001"
+			)
+
+			manifestDigest := godigest.FromString("fake-manifest")
+
+			err := repoDB.SetRepoReference(repo1, tag1, manifestDigest, ispec.MediaTypeImageManifest)
+			So(err, ShouldBeNil)
+
+			ack := repodb.CVEAcknowledgement{
+				Reason:         "not reachable",
+				AcknowledgedBy: "user1",
+				AcknowledgedAt: time.Now().Round(time.Second),
+				ExpiresAt:      time.Now().Add(time.Hour).Round(time.Second),
+			}
+
+			digest, err := repoDB.SetCVEAcknowledgement(repo1, tag1, cveID, ack)
+			So(err, ShouldBeNil)
+			So(digest, ShouldEqual, manifestDigest)
+
+			repoMeta, err := repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.CVEAcknowledgements[manifestDigest.String()][cveID].Reason, ShouldEqual, ack.Reason)
+
+			acks := repodb.GetActiveCVEAcknowledgements(repoMeta, manifestDigest.String(), time.Now())
+			So(acks, ShouldContainKey, cveID)
+
+			// expired acknowledgements are ignored
+			acks = repodb.GetActiveCVEAcknowledgements(repoMeta, manifestDigest.String(), time.Now().Add(2*time.Hour))
+			So(acks, ShouldBeEmpty)
+
+			_, err = repoDB.SetCVEAcknowledgement(repo1, tag1, cveID, repodb.CVEAcknowledgement{})
+			So(errors.Is(err, zerr.ErrBadCVEAcknowledgement), ShouldBeTrue)
+
+			_, err = repoDB.SetCVEAcknowledgement(repo1, "missing-tag", cveID, ack)
+			So(errors.Is(err, zerr.ErrManifestMetaNotFound), ShouldBeTrue)
+
+			_, err = repoDB.SetCVEAcknowledgement("missing-repo", tag1, cveID, ack)
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+
+			digest, err = repoDB.DeleteCVEAcknowledgement(repo1, manifestDigest.String(), cveID)
+			So(err, ShouldBeNil)
+			So(digest, ShouldEqual, manifestDigest)
+
+			_, err = repoDB.DeleteCVEAcknowledgement(repo1, tag1, cveID)
+			So(errors.Is(err, zerr.ErrCVEAcknowledgementNotFound), ShouldBeTrue)
+
+			_, err = repoDB.DeleteCVEAcknowledgement("missing-repo", tag1, cveID)
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+
+			repoMeta, err = repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.CVEAcknowledgements, ShouldBeEmpty)
+		})
+
+		Convey("Test SetVulnerabilitySummary", func() {
+			var (
+				repo1 = "repo1"
+				repo2 = "repo2"
+				repo3 = "repo3"
+				tag1  = "0.0.1"
+			)
+
+			configBlob, manifestBlob, err := generateTestImage()
+			So(err, ShouldBeNil)
+
+			manifestDigest := godigest.FromBytes(manifestBlob)
+
+			for _, repo := range []string{repo1, repo2, repo3} {
+				err = repoDB.SetRepoReference(repo, tag1, manifestDigest, ispec.MediaTypeImageManifest)
+				So(err, ShouldBeNil)
+
+				err = repoDB.SetManifestMeta(repo, manifestDigest, repodb.ManifestMetadata{
+					ManifestBlob: manifestBlob,
+					ConfigBlob:   configBlob,
+				})
+				So(err, ShouldBeNil)
+			}
+
+			// repo3 was not scanned
+			err = repoDB.SetVulnerabilitySummary(repo1, manifestDigest, repodb.VulnerabilitySummary{
+				MaxSeverity: "LOW", Count: 2, UpdatedAt: time.Now(),
+			})
+			So(err, ShouldBeNil)
+
+			err = repoDB.SetVulnerabilitySummary(repo2, manifestDigest, repodb.VulnerabilitySummary{
+				MaxSeverity: "CRITICAL", Count: 1, UpdatedAt: time.Now(),
+			})
+			So(err, ShouldBeNil)
+
+			repoMeta, err := repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.VulnerabilitySummaries[manifestDigest.String()].MaxSeverity, ShouldEqual, "LOW")
+			So(repoMeta.VulnerabilitySummaries[manifestDigest.String()].Count, ShouldEqual, 2)
+
+			err = repoDB.SetVulnerabilitySummary(repo1, godigest.FromString("missing"), repodb.VulnerabilitySummary{})
+			So(errors.Is(err, zerr.ErrManifestMetaNotFound), ShouldBeTrue)
+
+			err = repoDB.SetVulnerabilitySummary("missing-repo", manifestDigest, repodb.VulnerabilitySummary{})
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+
+			high := "HIGH"
+			filter := repodb.Filter{MaxCVESeverity: &high}
+
+			repos, _, _, _, err := repoDB.SearchRepos(context.Background(), "repo", filter, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(len(repos), ShouldEqual, 1)
+			So(repos[0].Name, ShouldEqual, repo1)
+
+			repos, _, _, _, err = repoDB.SearchTags(context.Background(), "repo1:", filter, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(len(repos), ShouldEqual, 1)
+			So(repos[0].Tags, ShouldContainKey, tag1)
+
+			repos, _, _, _, err = repoDB.SearchTags(context.Background(), "repo2:", filter, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(repos, ShouldBeEmpty)
+
+			repos, _, _, _, err = repoDB.SearchTags(context.Background(), "repo3:", filter, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(repos, ShouldBeEmpty)
+
+			// combined with the update time
+			future := time.Now().Add(time.Hour)
+			filter.LastUpdatedAfter = &future
+
+			repos, _, _, _, err = repoDB.SearchRepos(context.Background(), "repo", filter, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(repos, ShouldBeEmpty)
+		})
+
+		Convey("Test SBOM summaries", func() {
+			var (
+				repo1 = "repo1"
+				tag1  = "0.0.1"
+				tag2  = "0.0.2"
+			)
+
+			configBlob, manifestBlob, err := generateTestImage()
+			So(err, ShouldBeNil)
+
+			manifestDigest1 := godigest.FromBytes(manifestBlob)
+			manifestDigest2 := godigest.FromString("fake-manifest2")
+			sbomDigest := godigest.FromString("sbom")
+
+			for tag, digest := range map[string]godigest.Digest{tag1: manifestDigest1, tag2: manifestDigest2} {
+				err = repoDB.SetRepoReference(repo1, tag, digest, ispec.MediaTypeImageManifest)
+				So(err, ShouldBeNil)
+
+				err = repoDB.SetManifestMeta(repo1, digest, repodb.ManifestMetadata{
+					ManifestBlob: manifestBlob,
+					ConfigBlob:   configBlob,
+				})
+				So(err, ShouldBeNil)
+			}
+
+			err = repoDB.SetSBOMSummary(repo1, manifestDigest1, repodb.SBOMSummary{
+				Digest:       sbomDigest.String(),
+				Format:       repodb.SPDXFormat,
+				Licenses:     []string{"GPL-3.0-only", "MIT"},
+				PackageCount: 2,
+			})
+			So(err, ShouldBeNil)
+
+			repoMeta, err := repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.SBOMSummaries[manifestDigest1.String()].PackageCount, ShouldEqual, 2)
+
+			gpl := "GPL-3.0"
+			filter := repodb.Filter{ExcludedLicenses: []*string{&gpl}}
+
+			repos, _, _, _, err := repoDB.SearchTags(context.Background(), "repo1:", filter, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(len(repos), ShouldEqual, 1)
+			So(repos[0].Tags, ShouldNotContainKey, tag1)
+			So(repos[0].Tags, ShouldContainKey, tag2)
+
+			// the summary of another SBOM is not removed
+			err = repoDB.DeleteSBOMSummary(repo1, manifestDigest1, godigest.FromString("other-sbom"))
+			So(err, ShouldBeNil)
+
+			repoMeta, err = repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.SBOMSummaries, ShouldContainKey, manifestDigest1.String())
+
+			err = repoDB.DeleteSBOMSummary(repo1, manifestDigest1, sbomDigest)
+			So(err, ShouldBeNil)
+
+			repoMeta, err = repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.SBOMSummaries, ShouldBeEmpty)
+
+			err = repoDB.SetSBOMSummary("missing-repo", manifestDigest1, repodb.SBOMSummary{})
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+
+			err = repoDB.DeleteSBOMSummary("missing-repo", manifestDigest1, sbomDigest)
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+		})
+
+		Convey("Test SetLintViolations", func() {
+			manifestDigest := godigest.FromString("manifest")
+
+			err := repoDB.SetRepoReference("repo", "tag", manifestDigest, ispec.MediaTypeImageManifest)
+			So(err, ShouldBeNil)
+
+			violations := []string{"missing mandatory annotation annotation1"}
+
+			err = repoDB.SetLintViolations("repo", manifestDigest, violations)
+			So(err, ShouldBeNil)
+
+			repoMeta, err := repoDB.GetRepoMeta("repo")
+			So(err, ShouldBeNil)
+			So(repoMeta.LintViolations[manifestDigest.String()], ShouldResemble, violations)
+
+			err = repoDB.SetLintViolations("repo", manifestDigest, []string{})
+			So(err, ShouldBeNil)
+
+			repoMeta, err = repoDB.GetRepoMeta("repo")
+			So(err, ShouldBeNil)
+			So(repoMeta.LintViolations, ShouldBeEmpty)
+
+			// the violations can be recorded before the repo meta is created
+			err = repoDB.SetLintViolations("new-repo", manifestDigest, []string{})
+			So(err, ShouldBeNil)
+
+			_, err = repoDB.GetRepoMeta("new-repo")
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+
+			err = repoDB.SetLintViolations("new-repo", manifestDigest, violations)
+			So(err, ShouldBeNil)
+
+			repoMeta, err = repoDB.GetRepoMeta("new-repo")
+			So(err, ShouldBeNil)
+			So(repoMeta.LintViolations[manifestDigest.String()], ShouldResemble, violations)
+		})
+
+		Convey("Test SetDigestAlias", func() {
+			manifestDigest := godigest.FromString("converted-manifest")
+			alias := godigest.FromString("docker-manifest")
+
+			err := repoDB.SetRepoReference("repo", "tag", manifestDigest, ispec.MediaTypeImageManifest)
+			So(err, ShouldBeNil)
+
+			_, err = repoDB.ResolveDigestAlias("repo", alias)
+			So(errors.Is(err, zerr.ErrManifestMetaNotFound), ShouldBeTrue)
+
+			err = repoDB.SetDigestAlias("repo", alias, manifestDigest)
+			So(err, ShouldBeNil)
+
+			aliased, err := repoDB.ResolveDigestAlias("repo", alias)
+			So(err, ShouldBeNil)
+			So(aliased, ShouldEqual, manifestDigest)
+
+			repoMeta, err := repoDB.GetRepoMeta("repo")
+			So(err, ShouldBeNil)
+			So(repodb.GetDigestAliases(repoMeta, manifestDigest.String()), ShouldResemble, []string{alias.String()})
+
+			// pulls by the alias are counted for the manifest
+			err = repoDB.IncrementImageDownloads("repo", alias.String())
+			So(err, ShouldBeNil)
+
+			repoMeta, err = repoDB.GetRepoMeta("repo")
+			So(err, ShouldBeNil)
+			So(repoMeta.Statistics[manifestDigest.String()].DownloadCount, ShouldEqual, 1)
+			So(repoMeta.Statistics, ShouldNotContainKey, alias.String())
+
+			// the alias can be used to pin the manifest
+			pin, err := repoDB.PinImage(context.Background(), "repo", alias.String())
+			So(err, ShouldBeNil)
+			So(pin.Digest, ShouldEqual, manifestDigest.String())
+
+			err = repoDB.SetDigestAlias("missing-repo", alias, manifestDigest)
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+
+			_, err = repoDB.ResolveDigestAlias("missing-repo", alias)
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+		})
+
+		Convey("Test attestations", func() {
+			var (
+				repo1          = "repo1"
+				subjectDigest  = godigest.FromString("subject")
+				manifestDigest = godigest.FromString("attestations")
+			)
+
+			attestations := []repodb.AttestationInfo{
+				{
+					ManifestDigest: manifestDigest.String(),
+					LayerDigest:    godigest.FromString("provenance").String(),
+					PredicateType:  "https://slsa.dev/provenance/v0.2",
+					Tool:           repodb.CosignAttestation,
+				},
+			}
+
+			// the repo meta is created if the attestations are pushed first
+			err := repoDB.SetManifestAttestations(repo1, subjectDigest, attestations)
+			So(err, ShouldBeNil)
+
+			repoMeta, err := repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.Attestations[subjectDigest.String()], ShouldResemble, attestations)
+			So(repoMeta.Signatures[subjectDigest.String()], ShouldBeEmpty)
+
+			// the attestations pushed in another manifest are not removed
+			err = repoDB.DeleteManifestAttestations(repo1, subjectDigest, godigest.FromString("other"))
+			So(err, ShouldBeNil)
+
+			repoMeta, err = repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.Attestations, ShouldContainKey, subjectDigest.String())
+
+			err = repoDB.DeleteManifestAttestations(repo1, subjectDigest, manifestDigest)
+			So(err, ShouldBeNil)
+
+			repoMeta, err = repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.Attestations, ShouldBeEmpty)
+
+			// a manifest without attestations clears them
+			err = repoDB.SetManifestAttestations(repo1, subjectDigest, attestations)
+			So(err, ShouldBeNil)
+
+			err = repoDB.SetManifestAttestations(repo1, subjectDigest, []repodb.AttestationInfo{})
+			So(err, ShouldBeNil)
+
+			repoMeta, err = repoDB.GetRepoMeta(repo1)
+			So(err, ShouldBeNil)
+			So(repoMeta.Attestations, ShouldBeEmpty)
+
+			err = repoDB.DeleteManifestAttestations("missing-repo", subjectDigest, manifestDigest)
+			So(errors.Is(err, zerr.ErrRepoMetaNotFound), ShouldBeTrue)
+		})
+
 		Convey("Test AddImageSignature", func() {
 			var (
 				repo1           = "repo1"
@@ -2885,3 +3455,23 @@ func generateTestImage() ([]byte, []byte, error) {
 
 	return configBlob, manifestBlob, nil
 }
+
+func TestCountDailyActivity(t *testing.T) {
+	Convey("Count the daily activity of a repo", t, func() {
+		now := time.Date(2023, time.July, 12, 23, 30, 0, 0, time.UTC)
+
+		repoMeta := repodb.CountDailyActivity(repodb.RepoMetadata{}, now, 1, 0)
+		repoMeta = repodb.CountDailyActivity(repoMeta, now.Add(time.Hour), 0, 2)
+		So(repoMeta.DailyStatistics, ShouldResemble, map[string]repodb.DailyStatistics{
+			"2023-07-12": {Pushes: 1},
+			"2023-07-13": {Pulls: 2},
+		})
+
+		// the days out of the window are dropped
+		repoMeta = repodb.CountDailyActivity(repoMeta, now.AddDate(0, 0, repodb.MaxDailyStatisticsDays), 1, 1)
+		So(repoMeta.DailyStatistics, ShouldResemble, map[string]repodb.DailyStatistics{
+			"2023-07-13": {Pulls: 2},
+			"2023-10-10": {Pushes: 1, Pulls: 1},
+		})
+	})
+}
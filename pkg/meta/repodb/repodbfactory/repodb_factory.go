@@ -13,10 +13,12 @@ import (
 	boltdb_wrapper "zotregistry.io/zot/pkg/meta/repodb/boltdb-wrapper"
 	dynamodb_wrapper "zotregistry.io/zot/pkg/meta/repodb/dynamodb-wrapper"
 	"zotregistry.io/zot/pkg/meta/signatures"
+	"zotregistry.io/zot/pkg/storage/constants"
 )
 
 func New(storageConfig config.StorageConfig, log log.Logger) (repodb.RepoDB, error) {
-	if storageConfig.RemoteCache {
+	// the redis cache driver only shares the dedupe index, repodb is then kept in a local boltdb
+	if storageConfig.RemoteCache && storageConfig.CacheDriver["name"] != constants.RedisDriverName {
 		dynamoParams := getDynamoParams(storageConfig.CacheDriver, log)
 
 		client, err := dynamo.GetDynamoClient(dynamoParams)
@@ -98,6 +98,27 @@ func TestNew(t *testing.T) {
 		err = os.Chmod(rootDir, 0o777)
 		So(err, ShouldBeNil)
 	})
+
+	Convey("redis cache driver keeps repodb in boltdb", t, func() {
+		rootDir := t.TempDir()
+
+		var storageConfig config.StorageConfig
+
+		storageConfig.RootDirectory = rootDir
+		storageConfig.RemoteCache = true
+		storageConfig.CacheDriver = map[string]interface{}{
+			"name": "redis",
+			"url":  "redis://localhost:6379/0",
+		}
+		log := log.NewLogger("debug", "")
+
+		newRepodb, err := repodbfactory.New(storageConfig, log)
+		So(err, ShouldBeNil)
+		So(newRepodb, ShouldNotBeNil)
+
+		_, err = os.Stat(path.Join(rootDir, "repo.db"))
+		So(err, ShouldBeNil)
+	})
 }
 
 func skipDynamo(t *testing.T) {
@@ -0,0 +1,239 @@
+package repodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.io/zot/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/log"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+const (
+	SPDXFormat      = "SPDX"
+	CycloneDXFormat = "CycloneDX"
+
+	// SBOMs larger than this are not parsed.
+	maxSBOMSize = 64 * 1024 * 1024
+)
+
+// media types and artifact types of the SBOMs which are parsed, cosign uses text/spdx+json.
+var sbomFormats = map[string]string{ //nolint:gochecknoglobals
+	"application/spdx+json":          SPDXFormat,
+	"text/spdx+json":                 SPDXFormat,
+	"application/vnd.cyclonedx+json": CycloneDXFormat,
+}
+
+type spdxDocument struct {
+	SPDXVersion string `json:"spdxVersion"`
+	Packages    []struct {
+		LicenseConcluded string `json:"licenseConcluded"`
+		LicenseDeclared  string `json:"licenseDeclared"`
+	} `json:"packages"`
+}
+
+type cycloneDXDocument struct {
+	BOMFormat  string               `json:"bomFormat"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Licenses []struct {
+		License *struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"license"`
+		Expression string `json:"expression"`
+	} `json:"licenses"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+// GetSBOMSummary returns the subject and the summary of an SBOM pushed as a referrer.
+// isSBOM is false if the manifest doesn't refer another manifest or isn't an SPDX or CycloneDX JSON SBOM.
+func GetSBOMSummary(repo string, digest godigest.Digest, manifestBlob []byte, imageStore storageTypes.ImageStore,
+) (godigest.Digest, SBOMSummary, bool, error) {
+	var manifestContent ispec.Manifest
+
+	if err := json.Unmarshal(manifestBlob, &manifestContent); err != nil {
+		return "", SBOMSummary{}, false, err
+	}
+
+	if manifestContent.Subject == nil {
+		return "", SBOMSummary{}, false, nil
+	}
+
+	artifactFormat := sbomFormats[getMediaTypeWithoutParams(zcommon.GetManifestArtifactType(manifestContent))]
+
+	for _, layer := range manifestContent.Layers {
+		format, ok := sbomFormats[getMediaTypeWithoutParams(layer.MediaType)]
+		if !ok {
+			// tools like oras keep the default layer media type when given the artifact type
+			if artifactFormat == "" || len(manifestContent.Layers) != 1 {
+				continue
+			}
+
+			format = artifactFormat
+		}
+
+		if layer.Size > maxSBOMSize {
+			return "", SBOMSummary{}, true, fmt.Errorf("%w: %s is too large (%d bytes)", zerr.ErrBadSBOM,
+				layer.Digest, layer.Size)
+		}
+
+		sbomBlob, err := imageStore.GetBlobContent(repo, layer.Digest)
+		if err != nil {
+			return "", SBOMSummary{}, true, err
+		}
+
+		summary, err := parseSBOM(format, sbomBlob)
+		if err != nil {
+			return "", SBOMSummary{}, true, err
+		}
+
+		summary.Digest = digest.String()
+
+		return manifestContent.Subject.Digest, summary, true, nil
+	}
+
+	return "", SBOMSummary{}, false, nil
+}
+
+func parseSBOM(format string, sbomBlob []byte) (SBOMSummary, error) {
+	licenses := map[string]bool{}
+	summary := SBOMSummary{Format: format}
+
+	switch format {
+	case SPDXFormat:
+		var document spdxDocument
+
+		if err := json.Unmarshal(sbomBlob, &document); err != nil || document.SPDXVersion == "" {
+			return SBOMSummary{}, fmt.Errorf("%w: not an SPDX JSON document", zerr.ErrBadSBOM)
+		}
+
+		for _, pkg := range document.Packages {
+			license := pkg.LicenseConcluded
+
+			if !isKnownLicense(license) {
+				license = pkg.LicenseDeclared
+			}
+
+			for _, licenseID := range GetLicenseIDs(license) {
+				licenses[licenseID] = true
+			}
+		}
+
+		summary.PackageCount = len(document.Packages)
+	default:
+		var document cycloneDXDocument
+
+		if err := json.Unmarshal(sbomBlob, &document); err != nil || document.BOMFormat != CycloneDXFormat {
+			return SBOMSummary{}, fmt.Errorf("%w: not a CycloneDX JSON document", zerr.ErrBadSBOM)
+		}
+
+		summary.PackageCount = collectCycloneDXLicenses(document.Components, licenses)
+	}
+
+	summary.Licenses = make([]string, 0, len(licenses))
+
+	for license := range licenses {
+		summary.Licenses = append(summary.Licenses, license)
+	}
+
+	sort.Strings(summary.Licenses)
+
+	return summary, nil
+}
+
+// collectCycloneDXLicenses adds the licenses of the components, and of their nested components,
+// and returns the number of components.
+func collectCycloneDXLicenses(components []cycloneDXComponent, licenses map[string]bool) int {
+	count := len(components)
+
+	for _, component := range components {
+		for _, choice := range component.Licenses {
+			if choice.License == nil {
+				for _, licenseID := range GetLicenseIDs(choice.Expression) {
+					licenses[licenseID] = true
+				}
+
+				continue
+			}
+
+			// licenses without an SPDX id are only named
+			license := strings.TrimSpace(choice.License.ID)
+
+			if license == "" {
+				license = strings.TrimSpace(choice.License.Name)
+			}
+
+			if license != "" {
+				licenses[license] = true
+			}
+		}
+
+		count += collectCycloneDXLicenses(component.Components, licenses)
+	}
+
+	return count
+}
+
+// GetLicenseIDs splits an SPDX license expression, e.g. "(MIT OR GPL-2.0-only WITH Classpath-exception-2.0)",
+// in the license identifiers it's made of, exceptions are left out.
+func GetLicenseIDs(expression string) []string {
+	expression = strings.NewReplacer("(", " ", ")", " ").Replace(expression)
+
+	licenseIDs := []string{}
+	isException := false
+
+	for _, token := range strings.Fields(expression) {
+		switch {
+		case strings.EqualFold(token, "AND"), strings.EqualFold(token, "OR"):
+		case strings.EqualFold(token, "WITH"):
+			isException = true
+		case isException:
+			isException = false
+		case isKnownLicense(token):
+			licenseIDs = append(licenseIDs, token)
+		}
+	}
+
+	return licenseIDs
+}
+
+func isKnownLicense(license string) bool {
+	license = strings.TrimSpace(license)
+
+	return license != "" && license != "NOASSERTION" && license != "NONE"
+}
+
+func getMediaTypeWithoutParams(mediaType string) string {
+	return strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0]) //nolint:gomnd
+}
+
+// setSBOMSummary records the summary of an SBOM referrer, invalid SBOMs don't fail the push of the manifest.
+func setSBOMSummary(repo string, digest godigest.Digest, manifestBlob []byte, imageStore storageTypes.ImageStore,
+	repoDB RepoDB, log log.Logger,
+) {
+	subjectDigest, summary, isSBOM, err := GetSBOMSummary(repo, digest, manifestBlob, imageStore)
+	if !isSBOM {
+		return
+	}
+
+	if err != nil {
+		log.Error().Err(err).Str("repository", repo).Str("digest", digest.String()).
+			Msg("repodb: unable to parse SBOM")
+
+		return
+	}
+
+	if err := repoDB.SetSBOMSummary(repo, subjectDigest, summary); err != nil {
+		log.Error().Err(err).Str("repository", repo).Str("digest", digest.String()).
+			Msg("repodb: unable to set SBOM summary")
+	}
+}
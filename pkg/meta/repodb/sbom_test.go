@@ -0,0 +1,173 @@
+package repodb_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+const (
+	spdxSBOM = `{
+		"spdxVersion": "SPDX-2.3",
+		"packages": [
+			{"name": "busybox", "licenseConcluded": "GPL-2.0-only", "licenseDeclared": "NOASSERTION"},
+			{"name": "musl", "licenseConcluded": "NOASSERTION", "licenseDeclared": "MIT"},
+			{"name": "openjdk", "licenseConcluded": "(GPL-2.0-only WITH Classpath-exception-2.0 OR MIT)"},
+			{"name": "unknown", "licenseConcluded": "NONE"}
+		]
+	}`
+	cycloneDXSBOM = `{
+		"bomFormat": "CycloneDX",
+		"components": [
+			{"name": "zlib", "licenses": [{"license": {"id": "Zlib"}}]},
+			{"name": "bash", "licenses": [{"expression": "GPL-3.0-or-later"}], "components": [
+				{"name": "readline", "licenses": [{"license": {"name": "Custom license"}}]}
+			]}
+		]
+	}`
+)
+
+func TestGetSBOMSummary(t *testing.T) {
+	subjectDigest := godigest.FromString("subject")
+	sbomDigest := godigest.FromString("sbom")
+
+	getSBOMManifest := func(artifactType, layerMediaType string, subject bool) []byte {
+		manifest := ispec.Manifest{
+			MediaType:    ispec.MediaTypeImageManifest,
+			ArtifactType: artifactType,
+			Config:       ispec.DescriptorEmptyJSON,
+			Layers: []ispec.Descriptor{
+				{MediaType: layerMediaType, Digest: godigest.FromString("layer"), Size: 10},
+			},
+		}
+
+		if subject {
+			manifest.Subject = &ispec.Descriptor{MediaType: ispec.MediaTypeImageManifest, Digest: subjectDigest}
+		}
+
+		manifestBlob, err := json.Marshal(manifest)
+		So(err, ShouldBeNil)
+
+		return manifestBlob
+	}
+
+	getImageStore := func(sbom string) mocks.MockedImageStore {
+		return mocks.MockedImageStore{
+			GetBlobContentFn: func(repo string, digest godigest.Digest) ([]byte, error) {
+				return []byte(sbom), nil
+			},
+		}
+	}
+
+	Convey("SPDX SBOM", t, func() {
+		manifestBlob := getSBOMManifest("", "application/spdx+json", true)
+
+		subject, summary, isSBOM, err := repodb.GetSBOMSummary("repo", sbomDigest, manifestBlob,
+			getImageStore(spdxSBOM))
+		So(err, ShouldBeNil)
+		So(isSBOM, ShouldBeTrue)
+		So(subject, ShouldEqual, subjectDigest)
+		So(summary, ShouldResemble, repodb.SBOMSummary{
+			Digest:       sbomDigest.String(),
+			Format:       repodb.SPDXFormat,
+			Licenses:     []string{"GPL-2.0-only", "MIT"},
+			PackageCount: 4,
+		})
+	})
+
+	Convey("CycloneDX SBOM attached with the artifact type only", t, func() {
+		manifestBlob := getSBOMManifest("application/vnd.cyclonedx+json", ispec.MediaTypeImageLayer, true)
+
+		_, summary, isSBOM, err := repodb.GetSBOMSummary("repo", sbomDigest, manifestBlob,
+			getImageStore(cycloneDXSBOM))
+		So(err, ShouldBeNil)
+		So(isSBOM, ShouldBeTrue)
+		So(summary.Format, ShouldEqual, repodb.CycloneDXFormat)
+		So(summary.Licenses, ShouldResemble, []string{"Custom license", "GPL-3.0-or-later", "Zlib"})
+		So(summary.PackageCount, ShouldEqual, 3)
+	})
+
+	Convey("Not an SBOM", t, func() {
+		_, _, isSBOM, err := repodb.GetSBOMSummary("repo", sbomDigest,
+			getSBOMManifest("", "application/spdx+json", false), getImageStore(spdxSBOM))
+		So(err, ShouldBeNil)
+		So(isSBOM, ShouldBeFalse)
+
+		_, _, isSBOM, err = repodb.GetSBOMSummary("repo", sbomDigest,
+			getSBOMManifest("application/vnd.example+json", ispec.MediaTypeImageLayer, true), getImageStore(spdxSBOM))
+		So(err, ShouldBeNil)
+		So(isSBOM, ShouldBeFalse)
+
+		_, _, isSBOM, err = repodb.GetSBOMSummary("repo", sbomDigest, []byte("invalid JSON"), getImageStore(spdxSBOM))
+		So(err, ShouldNotBeNil)
+		So(isSBOM, ShouldBeFalse)
+	})
+
+	Convey("Invalid SBOMs", t, func() {
+		manifestBlob := getSBOMManifest("", "application/spdx+json", true)
+
+		_, _, isSBOM, err := repodb.GetSBOMSummary("repo", sbomDigest, manifestBlob, getImageStore(cycloneDXSBOM))
+		So(isSBOM, ShouldBeTrue)
+		So(errors.Is(err, zerr.ErrBadSBOM), ShouldBeTrue)
+
+		manifestBlob = getSBOMManifest("", "application/vnd.cyclonedx+json", true)
+
+		_, _, isSBOM, err = repodb.GetSBOMSummary("repo", sbomDigest, manifestBlob, getImageStore(spdxSBOM))
+		So(isSBOM, ShouldBeTrue)
+		So(errors.Is(err, zerr.ErrBadSBOM), ShouldBeTrue)
+
+		_, _, isSBOM, err = repodb.GetSBOMSummary("repo", sbomDigest, manifestBlob, mocks.MockedImageStore{
+			GetBlobContentFn: func(repo string, digest godigest.Digest) ([]byte, error) {
+				return nil, ErrTestError
+			},
+		})
+		So(isSBOM, ShouldBeTrue)
+		So(err, ShouldEqual, ErrTestError)
+	})
+
+	Convey("SBOMs are summarized when pushed", t, func() {
+		var recorded repodb.SBOMSummary
+
+		repoDB := mocks.RepoDBMock{
+			SetSBOMSummaryFn: func(repo string, subject godigest.Digest, summary repodb.SBOMSummary) error {
+				So(subject, ShouldEqual, subjectDigest)
+				recorded = summary
+
+				return nil
+			},
+		}
+
+		err := repodb.SetImageMetaFromInput("repo", sbomDigest.String(), ispec.MediaTypeImageManifest, sbomDigest,
+			getSBOMManifest("", "application/spdx+json", true), getImageStore(spdxSBOM), repoDB,
+			log.NewLogger("debug", ""))
+		So(err, ShouldBeNil)
+		So(recorded.Licenses, ShouldResemble, []string{"GPL-2.0-only", "MIT"})
+
+		// invalid SBOMs don't fail the push
+		err = repodb.SetImageMetaFromInput("repo", sbomDigest.String(), ispec.MediaTypeImageManifest, sbomDigest,
+			getSBOMManifest("", "application/spdx+json", true), getImageStore("{}"), repoDB,
+			log.NewLogger("debug", ""))
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestGetLicenseIDs(t *testing.T) {
+	Convey("GetLicenseIDs", t, func() {
+		So(repodb.GetLicenseIDs("MIT"), ShouldResemble, []string{"MIT"})
+		So(repodb.GetLicenseIDs("(MIT OR Apache-2.0) AND BSD-3-Clause"), ShouldResemble,
+			[]string{"MIT", "Apache-2.0", "BSD-3-Clause"})
+		So(repodb.GetLicenseIDs("GPL-2.0-only WITH Classpath-exception-2.0"), ShouldResemble,
+			[]string{"GPL-2.0-only"})
+		So(repodb.GetLicenseIDs("NOASSERTION"), ShouldBeEmpty)
+		So(repodb.GetLicenseIDs(""), ShouldBeEmpty)
+	})
+}
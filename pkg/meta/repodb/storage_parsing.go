@@ -13,6 +13,7 @@ import (
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/signatures"
 	"zotregistry.io/zot/pkg/storage"
+	storageCommon "zotregistry.io/zot/pkg/storage/common"
 	storageTypes "zotregistry.io/zot/pkg/storage/types"
 )
 
@@ -118,6 +119,27 @@ func ParseRepo(repo string, repoDB RepoDB, storeController storage.StoreControll
 			continue
 		}
 
+		if isAttestation, attestedManifestDigest := storage.CheckIsCosignAttestation(tag); isAttestation {
+			attestations, err := GetCosignAttestations(descriptor.Digest, descriptorBlob)
+			if err != nil {
+				log.Error().Err(err).Str("repository", repo).Str("tag", tag).
+					Msg("load-repo: failed to parse cosign attestations")
+
+				return err
+			}
+
+			err = repoDB.SetManifestAttestations(repo, attestedManifestDigest, attestations)
+			if err != nil {
+				log.Error().Err(err).Str("repository", repo).Str("tag", tag).
+					Str("manifestDigest", attestedManifestDigest.String()).
+					Msg("load-repo: failed set attestations for attested image")
+
+				return err
+			}
+
+			continue
+		}
+
 		reference := tag
 
 		if tag == "" {
@@ -207,11 +229,11 @@ func getCachedBlob(repo string, descriptor ispec.Descriptor, repoDB RepoDB,
 
 func getCachedBlobFromRepoDB(descriptor ispec.Descriptor, repoDB RepoDB) ([]byte, error) {
 	switch descriptor.MediaType {
-	case ispec.MediaTypeImageManifest:
+	case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 		manifestData, err := repoDB.GetManifestData(descriptor.Digest)
 
 		return manifestData.ManifestBlob, err
-	case ispec.MediaTypeImageIndex:
+	case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 		indexData, err := repoDB.GetIndexData(descriptor.Digest)
 
 		return indexData.IndexBlob, err
@@ -352,12 +374,20 @@ func NewIndexData(repoName string, indexBlob []byte, imageStore storageTypes.Ima
 }
 
 // SetMetadataFromInput tries to set manifest metadata and update repo metadata by adding the current tag
-// (in case the reference is a tag). The function expects image manifests and indexes (multi arch images).
+// (in case the reference is a tag). The function expects image manifests and indexes (multi arch images),
+// docker schema1 manifests have no config to describe the image and aren't added.
 func SetImageMetaFromInput(repo, reference, mediaType string, digest godigest.Digest, descriptorBlob []byte,
 	imageStore storageTypes.ImageStore, repoDB RepoDB, log log.Logger,
 ) error {
+	if zcommon.IsSchema1Manifest(mediaType) {
+		log.Debug().Str("repository", repo).Str("reference", reference).
+			Msg("repodb: skipping docker schema1 manifest")
+
+		return nil
+	}
+
 	switch mediaType {
-	case ispec.MediaTypeImageManifest:
+	case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 		imageData, err := NewManifestData(repo, descriptorBlob, imageStore)
 		if err != nil {
 			return err
@@ -369,7 +399,7 @@ func SetImageMetaFromInput(repo, reference, mediaType string, digest godigest.Di
 
 			return err
 		}
-	case ispec.MediaTypeImageIndex:
+	case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 		indexData := NewIndexData(repo, descriptorBlob, imageStore)
 
 		err := repoDB.SetIndexData(digest, indexData)
@@ -397,6 +427,20 @@ func SetImageMetaFromInput(repo, reference, mediaType string, digest godigest.Di
 		return err
 	}
 
+	// the manifest was rewritten when it was pushed, it can still be found by the digest it was pushed with
+	if originalDigest := storageCommon.GetOriginalDigest(descriptorBlob); originalDigest != "" {
+		err = repoDB.SetDigestAlias(repo, originalDigest, digest)
+		if err != nil {
+			log.Error().Err(err).Msg("repodb: error while putting digest alias")
+
+			return err
+		}
+	}
+
+	if hasSubject && zcommon.IsImageManifest(mediaType) {
+		setSBOMSummary(repo, digest, descriptorBlob, imageStore, repoDB, log)
+	}
+
 	return nil
 }
 
@@ -408,7 +452,7 @@ func GetReferredSubject(descriptorBlob []byte, referrerDigest, mediaType string,
 	)
 
 	switch mediaType {
-	case ispec.MediaTypeImageManifest:
+	case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 		var manifestContent ispec.Manifest
 
 		err := json.Unmarshal(descriptorBlob, &manifestContent)
@@ -426,7 +470,7 @@ func GetReferredSubject(descriptorBlob []byte, referrerDigest, mediaType string,
 			Size:         len(descriptorBlob),
 			Annotations:  manifestContent.Annotations,
 		}
-	case ispec.MediaTypeImageIndex:
+	case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 		var indexContent ispec.Index
 
 		err := json.Unmarshal(descriptorBlob, &indexContent)
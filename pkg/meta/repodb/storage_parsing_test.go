@@ -15,6 +15,7 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 
 	zerr "zotregistry.io/zot/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
 	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/bolt"
@@ -361,6 +362,110 @@ func TestParseStorageErrors(t *testing.T) {
 	})
 }
 
+func TestParseStorageInBackground(t *testing.T) {
+	Convey("Repos are parsed in the background", t, func() {
+		rootDir := t.TempDir()
+		logger := log.NewLogger("debug", "")
+
+		boltDB, err := bolt.GetBoltDriver(bolt.DBParameters{RootDir: rootDir})
+		So(err, ShouldBeNil)
+
+		repoDB, err := bolt_wrapper.NewBoltDBWrapper(boltDB, logger)
+		So(err, ShouldBeNil)
+
+		imageStore := local.NewImageStore(rootDir, false, 0, false, false,
+			logger, monitoring.NewMetricsServer(false, logger), nil, nil)
+		storeController := storage.StoreController{DefaultStore: imageStore}
+
+		for _, repoName := range []string{"repo1", "repo2"} {
+			config, layers, manifest, err := test.GetRandomImageComponents(100)
+			So(err, ShouldBeNil)
+
+			err = test.WriteImageToFileSystem(
+				test.Image{Config: config, Layers: layers, Manifest: manifest, Reference: "tag"},
+				repoName, storeController)
+			So(err, ShouldBeNil)
+		}
+
+		status := repodb.ParseStorageInBackground(context.Background(), repoDB, storeController, logger)
+		status.Wait()
+
+		progress := status.Progress()
+		So(status.InProgress(), ShouldBeFalse)
+		So(progress.Repos, ShouldEqual, 2)
+		So(progress.ParsedRepos, ShouldEqual, 2)
+		So(progress.FailedRepos, ShouldEqual, 0)
+
+		for _, repoName := range []string{"repo1", "repo2"} {
+			repoMeta, err := repoDB.GetRepoMeta(repoName)
+			So(err, ShouldBeNil)
+			So(repoMeta.Tags, ShouldContainKey, "tag")
+		}
+	})
+
+	Convey("Repos failing to be parsed are skipped", t, func() {
+		unblock := make(chan struct{})
+
+		imageStore := mocks.MockedImageStore{
+			GetIndexContentFn: func(repo string) ([]byte, error) {
+				<-unblock
+
+				return nil, ErrTestError
+			},
+			GetRepositoriesFn: func() ([]string, error) {
+				return []string{"repo1", "repo2"}, nil
+			},
+		}
+		storeController := storage.StoreController{DefaultStore: imageStore}
+
+		status := repodb.ParseStorageInBackground(context.Background(), mocks.RepoDBMock{}, storeController,
+			log.NewLogger("debug", ""))
+		So(status.InProgress(), ShouldBeTrue)
+
+		close(unblock)
+		status.Wait()
+
+		progress := status.Progress()
+		So(progress.InProgress, ShouldBeFalse)
+		So(progress.ParsedRepos, ShouldEqual, 0)
+		So(progress.FailedRepos, ShouldEqual, 2)
+	})
+
+	Convey("Parsing stops when canceled", t, func() {
+		imageStore := mocks.MockedImageStore{
+			GetRepositoriesFn: func() ([]string, error) {
+				return []string{"repo1", "repo2"}, nil
+			},
+		}
+		storeController := storage.StoreController{DefaultStore: imageStore}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		status := repodb.ParseStorageInBackground(ctx, mocks.RepoDBMock{}, storeController, log.NewLogger("debug", ""))
+		status.Wait()
+
+		So(status.Progress().Repos, ShouldEqual, 2)
+		So(status.Progress().ParsedRepos+status.Progress().FailedRepos, ShouldEqual, 0)
+	})
+
+	Convey("Listing repos fails", t, func() {
+		imageStore := mocks.MockedImageStore{
+			GetRepositoriesFn: func() ([]string, error) {
+				return nil, ErrTestError
+			},
+		}
+		storeController := storage.StoreController{DefaultStore: imageStore}
+
+		status := repodb.ParseStorageInBackground(context.Background(), mocks.RepoDBMock{}, storeController,
+			log.NewLogger("debug", ""))
+		status.Wait()
+
+		So(status.InProgress(), ShouldBeFalse)
+		So(status.Progress().Repos, ShouldEqual, 0)
+	})
+}
+
 func TestParseStorageWithBoltDB(t *testing.T) {
 	Convey("Boltdb", t, func() {
 		rootDir := t.TempDir()
@@ -615,6 +720,33 @@ func RunParseStorageTests(rootDir string, repoDB repodb.RepoDB) {
 		So(repoMeta.Statistics[manifestDigest.String()].DownloadCount, ShouldEqual, 3)
 		So(repoMeta.Stars, ShouldEqual, 1)
 	})
+
+	Convey("Converted manifests are aliased by their original digest", func() {
+		imageStore := local.NewImageStore(rootDir, false, 0, false, false,
+			log.NewLogger("debug", ""), monitoring.NewMetricsServer(false, log.NewLogger("debug", "")), nil, nil)
+
+		storeController := storage.StoreController{DefaultStore: imageStore}
+
+		originalDigest := godigest.FromString("docker manifest")
+
+		image, err := test.GetRandomImage("converted")
+		So(err, ShouldBeNil)
+
+		image.Manifest.Annotations = map[string]string{zcommon.AnnotationOriginalDigest: originalDigest.String()}
+
+		manifestDigest, err := image.Digest()
+		So(err, ShouldBeNil)
+
+		err = test.WriteImageToFileSystem(image, "converted-repo", storeController)
+		So(err, ShouldBeNil)
+
+		err = repodb.ParseStorage(repoDB, storeController, log.NewLogger("debug", ""))
+		So(err, ShouldBeNil)
+
+		aliased, err := repoDB.ResolveDigestAlias("converted-repo", originalDigest)
+		So(err, ShouldBeNil)
+		So(aliased, ShouldEqual, manifestDigest)
+	})
 }
 
 func TestGetReferredSubject(t *testing.T) {
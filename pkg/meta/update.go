@@ -1,8 +1,11 @@
 package meta
 
 import (
+	"errors"
+
 	godigest "github.com/opencontainers/go-digest"
 
+	zerr "zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/common"
 	"zotregistry.io/zot/pkg/meta/repodb"
@@ -17,63 +20,94 @@ func OnUpdateManifest(repo, reference, mediaType string, digest godigest.Digest,
 ) error {
 	imgStore := storeController.GetImageStore(repo)
 
+	if err := UpdateManifestMeta(repo, reference, mediaType, digest, body, storeController, repoDB,
+		log); err != nil {
+		log.Info().Str("tag", reference).Str("repository", repo).Msg("uploding image meta was unsuccessful for tag in repo")
+
+		if err := imgStore.DeleteImageManifest(repo, reference, false); err != nil {
+			log.Error().Err(err).Str("reference", reference).Str("repository", repo).
+				Msg("couldn't remove image manifest in repo")
+
+			return err
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// UpdateManifestMeta updates repodb after a manifest is added, according to the type of image pushed,
+// without removing the manifest from the image store if it fails.
+func UpdateManifestMeta(repo, reference, mediaType string, digest godigest.Digest, body []byte,
+	storeController storage.StoreController, repoDB repodb.RepoDB, log log.Logger,
+) error {
+	imgStore := storeController.GetImageStore(repo)
+
 	// check if image is a signature
 	isSignature, signatureType, signedManifestDigest, err := storage.CheckIsImageSignature(repo, body, reference)
 	if err != nil {
 		log.Error().Err(err).Msg("can't check if image is a signature or not")
 
-		if err := imgStore.DeleteImageManifest(repo, reference, false); err != nil {
-			log.Error().Err(err).Str("manifest", reference).Str("repository", repo).Msg("couldn't remove image manifest in repo")
+		return err
+	}
+
+	// cosign attestations are recorded apart from both the images and the signatures
+	if isAttestation, attestedManifestDigest := storage.CheckIsCosignAttestation(reference); isAttestation {
+		attestations, err := repodb.GetCosignAttestations(digest, body)
+		if err != nil {
+			log.Error().Err(err).Str("repository", repo).Str("reference", reference).
+				Msg("repodb: can't parse cosign attestations")
 
 			return err
 		}
 
-		return err
+		err = repoDB.SetManifestAttestations(repo, attestedManifestDigest, attestations)
+		if err != nil {
+			log.Error().Err(err).Msg("repodb: error while putting attestations")
+
+			return err
+		}
+
+		return nil
 	}
 
-	metadataSuccessfullySet := true
-
-	if isSignature {
-		layersInfo, errGetLayers := repodb.GetSignatureLayersInfo(repo, reference, digest.String(), signatureType, body,
-			imgStore, log)
-		if errGetLayers != nil {
-			metadataSuccessfullySet = false
-			err = errGetLayers
-		} else {
-			err = repoDB.AddManifestSignature(repo, signedManifestDigest, repodb.SignatureMetadata{
-				SignatureType:   signatureType,
-				SignatureDigest: digest.String(),
-				LayersInfo:      layersInfo,
-			})
-			if err != nil {
-				log.Error().Err(err).Msg("repodb: error while putting repo meta")
-				metadataSuccessfullySet = false
-			} else {
-				err = repoDB.UpdateSignaturesValidity(repo, signedManifestDigest)
-				if err != nil {
-					log.Error().Err(err).Str("repository", repo).Str("reference", reference).Str("digest",
-						signedManifestDigest.String()).Msg("repodb: failed verify signatures validity for signed image")
-					metadataSuccessfullySet = false
-				}
-			}
+	if !isSignature {
+		if err := repodb.SetImageMetaFromInput(repo, reference, mediaType, digest, body, imgStore, repoDB,
+			log); err != nil {
+			return err
 		}
-	} else {
-		err := repodb.SetImageMetaFromInput(repo, reference, mediaType, digest, body,
-			imgStore, repoDB, log)
-		if err != nil {
-			metadataSuccessfullySet = false
+
+		// the push statistics don't keep the image from being pushed
+		if err := repoDB.IncrementRepoPushes(repo); err != nil {
+			log.Error().Err(err).Str("repository", repo).Str("reference", reference).
+				Msg("repodb: failed to count image push")
 		}
+
+		return nil
 	}
 
-	if !metadataSuccessfullySet {
-		log.Info().Str("tag", reference).Str("repository", repo).Msg("uploding image meta was unsuccessful for tag in repo")
+	layersInfo, err := repodb.GetSignatureLayersInfo(repo, reference, digest.String(), signatureType, body,
+		imgStore, log)
+	if err != nil {
+		return err
+	}
 
-		if err := imgStore.DeleteImageManifest(repo, reference, false); err != nil {
-			log.Error().Err(err).Str("reference", reference).Str("repository", repo).
-				Msg("couldn't remove image manifest in repo")
+	err = repoDB.AddManifestSignature(repo, signedManifestDigest, repodb.SignatureMetadata{
+		SignatureType:   signatureType,
+		SignatureDigest: digest.String(),
+		LayersInfo:      layersInfo,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("repodb: error while putting repo meta")
 
-			return err
-		}
+		return err
+	}
+
+	err = repoDB.UpdateSignaturesValidity(repo, signedManifestDigest)
+	if err != nil {
+		log.Error().Err(err).Str("repository", repo).Str("reference", reference).Str("digest",
+			signedManifestDigest.String()).Msg("repodb: failed verify signatures validity for signed image")
 
 		return err
 	}
@@ -86,6 +120,20 @@ func OnUpdateManifest(repo, reference, mediaType string, digest godigest.Digest,
 // consistency between repodb and the image store.
 func OnDeleteManifest(repo, reference, mediaType string, digest godigest.Digest, manifestBlob []byte,
 	storeController storage.StoreController, repoDB repodb.RepoDB, log log.Logger,
+) error {
+	return deleteManifestMeta(repo, reference, mediaType, digest, manifestBlob, storeController, repoDB, true, log)
+}
+
+// DeleteManifestMeta updates repodb after a manifest is deleted, according to the type of image deleted,
+// without restoring the manifest in the image store if it fails.
+func DeleteManifestMeta(repo, reference, mediaType string, digest godigest.Digest, manifestBlob []byte,
+	storeController storage.StoreController, repoDB repodb.RepoDB, log log.Logger,
+) error {
+	return deleteManifestMeta(repo, reference, mediaType, digest, manifestBlob, storeController, repoDB, false, log)
+}
+
+func deleteManifestMeta(repo, reference, mediaType string, digest godigest.Digest, manifestBlob []byte,
+	storeController storage.StoreController, repoDB repodb.RepoDB, restoreStore bool, log log.Logger,
 ) error {
 	imgStore := storeController.GetImageStore(repo)
 
@@ -99,7 +147,15 @@ func OnDeleteManifest(repo, reference, mediaType string, digest godigest.Digest,
 
 	manageRepoMetaSuccessfully := true
 
-	if isSignature {
+	isAttestation, attestedManifestDigest := storage.CheckIsCosignAttestation(reference)
+
+	if isAttestation {
+		err = repoDB.DeleteManifestAttestations(repo, attestedManifestDigest, digest)
+		if err != nil {
+			log.Error().Err(err).Msg("repodb: error while deleting attestations")
+			manageRepoMetaSuccessfully = false
+		}
+	} else if isSignature {
 		err = repoDB.DeleteSignature(repo, signedManifestDigest, repodb.SignatureMetadata{
 			SignatureDigest: digest.String(),
 			SignatureType:   signatureType,
@@ -110,7 +166,7 @@ func OnDeleteManifest(repo, reference, mediaType string, digest godigest.Digest,
 		}
 	} else {
 		err = repoDB.DeleteRepoTag(repo, reference)
-		if err != nil {
+		if err != nil && restoreStore {
 			log.Info().Msg("repodb: restoring image store")
 
 			// restore image store
@@ -118,7 +174,9 @@ func OnDeleteManifest(repo, reference, mediaType string, digest godigest.Digest,
 			if err != nil {
 				log.Error().Err(err).Msg("repodb: error while restoring image store, database is not consistent")
 			}
+		}
 
+		if err != nil {
 			manageRepoMetaSuccessfully = false
 		}
 
@@ -129,6 +187,23 @@ func OnDeleteManifest(repo, reference, mediaType string, digest godigest.Digest,
 
 				return err
 			}
+
+			err = repoDB.DeleteSBOMSummary(repo, refferredDigest, digest)
+			if err != nil {
+				log.Error().Err(err).Msg("repodb: error while deleting SBOM summary")
+
+				return err
+			}
+		}
+
+		// the lint violations are kept as long as the manifest is in the repo, e.g. under another tag
+		_, _, _, getErr := imgStore.GetImageManifest(repo, digest.String())
+		if errors.Is(getErr, zerr.ErrManifestNotFound) {
+			if err := repoDB.SetLintViolations(repo, digest, nil); err != nil {
+				log.Error().Err(err).Msg("repodb: error while deleting lint violations")
+
+				return err
+			}
 		}
 	}
 
@@ -154,7 +229,9 @@ func OnGetManifest(name, reference string, body []byte,
 		return err
 	}
 
-	if !isSignature {
+	isAttestation, _ := storage.CheckIsCosignAttestation(reference)
+
+	if !isSignature && !isAttestation {
 		err := repoDB.IncrementImageDownloads(name, reference)
 		if err != nil {
 			log.Error().Err(err).Str("repository", name).Str("reference", reference).
@@ -3,6 +3,7 @@ package meta_test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -70,6 +71,107 @@ func TestOnUpdateManifest(t *testing.T) {
 		So(repoMeta.Tags, ShouldContainKey, "tag1")
 	})
 
+	Convey("Cosign attestations are recorded apart from the images and the signatures", t, func() {
+		rootDir := t.TempDir()
+		storeController := storage.StoreController{}
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+		storeController.DefaultStore = local.NewImageStore(rootDir, true, 1*time.Second,
+			true, true, log, metrics, nil, nil,
+		)
+
+		params := bolt.DBParameters{
+			RootDir: rootDir,
+		}
+		boltDriver, err := bolt.GetBoltDriver(params)
+		So(err, ShouldBeNil)
+
+		repoDB, err := bolt_wrapper.NewBoltDBWrapper(boltDriver, log)
+		So(err, ShouldBeNil)
+
+		subjectDigest := godigest.FromString("subject")
+		attestationTag := fmt.Sprintf("sha256-%s.att", subjectDigest.Encoded())
+
+		attestationManifest := ispec.Manifest{
+			MediaType: ispec.MediaTypeImageManifest,
+			Layers: []ispec.Descriptor{
+				{
+					MediaType:   "application/vnd.dsse.envelope.v1+json",
+					Digest:      godigest.FromString("provenance"),
+					Annotations: map[string]string{"predicateType": "https://slsa.dev/provenance/v0.2"},
+				},
+				{
+					MediaType:   "application/vnd.dsse.envelope.v1+json",
+					Digest:      godigest.FromString("vuln"),
+					Annotations: map[string]string{"predicateType": "https://cosign.sigstore.dev/attestation/vuln/v1"},
+				},
+			},
+		}
+		attestationManifest.SchemaVersion = 2
+
+		attestationBlob, err := json.Marshal(attestationManifest)
+		So(err, ShouldBeNil)
+
+		attestationDigest := godigest.FromBytes(attestationBlob)
+
+		// the attestations may be pushed before the image
+		err = meta.OnUpdateManifest("repo", attestationTag, ispec.MediaTypeImageManifest, attestationDigest,
+			attestationBlob, storeController, repoDB, log)
+		So(err, ShouldBeNil)
+
+		repoMeta, err := repoDB.GetRepoMeta("repo")
+		So(err, ShouldBeNil)
+		So(repoMeta.Tags, ShouldBeEmpty)
+		So(repoMeta.Signatures, ShouldBeEmpty)
+		So(repoMeta.Attestations[subjectDigest.String()], ShouldResemble, []repodb.AttestationInfo{
+			{
+				ManifestDigest: attestationDigest.String(),
+				LayerDigest:    godigest.FromString("provenance").String(),
+				PredicateType:  "https://slsa.dev/provenance/v0.2",
+				Tool:           repodb.CosignAttestation,
+			},
+			{
+				ManifestDigest: attestationDigest.String(),
+				LayerDigest:    godigest.FromString("vuln").String(),
+				PredicateType:  "https://cosign.sigstore.dev/attestation/vuln/v1",
+				Tool:           repodb.CosignAttestation,
+			},
+		})
+
+		err = meta.OnGetManifest("repo", attestationTag, attestationBlob, storeController, repoDB, log)
+		So(err, ShouldBeNil)
+
+		err = meta.OnDeleteManifest("repo", attestationTag, ispec.MediaTypeImageManifest, attestationDigest,
+			attestationBlob, storeController, repoDB, log)
+		So(err, ShouldBeNil)
+
+		repoMeta, err = repoDB.GetRepoMeta("repo")
+		So(err, ShouldBeNil)
+		So(repoMeta.Attestations, ShouldBeEmpty)
+
+		Convey("Errors are returned", func() {
+			repoDB := mocks.RepoDBMock{
+				SetManifestAttestationsFn: func(repo string, subjectDigest godigest.Digest,
+					attestations []repodb.AttestationInfo,
+				) error {
+					return ErrTestError
+				},
+				DeleteManifestAttestationsFn: func(repo string, subjectDigest, attestationsDigest godigest.Digest,
+				) error {
+					return ErrTestError
+				},
+			}
+
+			err := meta.UpdateManifestMeta("repo", attestationTag, ispec.MediaTypeImageManifest, attestationDigest,
+				attestationBlob, storeController, repoDB, log)
+			So(err, ShouldNotBeNil)
+
+			err = meta.DeleteManifestMeta("repo", attestationTag, ispec.MediaTypeImageManifest, attestationDigest,
+				attestationBlob, storeController, repoDB, log)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
 	Convey("metadataSuccessfullySet is false", t, func() {
 		rootDir := t.TempDir()
 		storeController := storage.StoreController{}
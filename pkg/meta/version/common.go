@@ -6,6 +6,8 @@ const (
 	Version3 = "V3"
 
 	CurrentVersion = Version1
+	// boltdb repo metadata is partitioned since V2
+	CurrentBoltDBVersion = Version2
 )
 
 const (
@@ -3,12 +3,65 @@ package version
 import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"go.etcd.io/bbolt"
+
+	"zotregistry.io/zot/pkg/meta/bolt"
 )
 
 func GetBoltDBPatches() []func(DB *bbolt.DB) error {
-	return []func(DB *bbolt.DB) error{}
+	return []func(DB *bbolt.DB) error{
+		PartitionBoltDBRepoMeta, // V1 to V2
+	}
 }
 
 func GetDynamoDBPatches() []func(client *dynamodb.Client, tableNames map[string]string) error {
 	return []func(client *dynamodb.Client, tableNames map[string]string) error{}
 }
+
+// PartitionBoltDBRepoMeta moves the metadata of the repos from the RepoMetadata bucket of the metadata db to
+// its partitions. The repos are copied before being removed, so it can be run again if it's interrupted.
+func PartitionBoltDBRepoMeta(boltDB *bbolt.DB) error {
+	partitions, err := bolt.GetRepoMetaPartitions(boltDB)
+	if err != nil {
+		return err
+	}
+
+	repos := [][]byte{}
+	blobs := [][]byte{}
+
+	err = boltDB.View(func(tx *bbolt.Tx) error {
+		buck := tx.Bucket([]byte(bolt.RepoMetadataBucket))
+		if buck == nil {
+			return nil
+		}
+
+		return buck.ForEach(func(repo, repoMetaBlob []byte) error {
+			// the keys and values are only valid during the transaction
+			repos = append(repos, append([]byte{}, repo...))
+			blobs = append(blobs, append([]byte{}, repoMetaBlob...))
+
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, repo := range repos {
+		err := partitions.DB(string(repo)).Update(func(tx *bbolt.Tx) error {
+			return tx.Bucket([]byte(bolt.RepoMetadataBucket)).Put(repo, blobs[i])
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return boltDB.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(bolt.RepoMetadataBucket)) != nil {
+			if err := tx.DeleteBucket([]byte(bolt.RepoMetadataBucket)); err != nil {
+				return err
+			}
+		}
+
+		return tx.Bucket([]byte(bolt.VersionBucket)).Put([]byte(DBVersionKey), []byte(Version2))
+	})
+}
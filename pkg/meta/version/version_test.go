@@ -2,6 +2,7 @@ package version_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"os"
 	"testing"
@@ -16,6 +17,7 @@ import (
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/meta/bolt"
 	"zotregistry.io/zot/pkg/meta/dynamo"
+	"zotregistry.io/zot/pkg/meta/repodb"
 	boltdb_wrapper "zotregistry.io/zot/pkg/meta/repodb/boltdb-wrapper"
 	dynamodb_wrapper "zotregistry.io/zot/pkg/meta/repodb/dynamodb-wrapper"
 	"zotregistry.io/zot/pkg/meta/version"
@@ -97,12 +99,115 @@ func TestVersioningBoltDB(t *testing.T) {
 				},
 			}
 
+			err := setBoltDBVersion(boltdbWrapper.DB, version.Version1)
+			So(err, ShouldBeNil)
+
 			err = boltdbWrapper.PatchDB()
 			So(err, ShouldNotBeNil)
 		})
+
+		Convey("new dbs are already patched", func() {
+			So(getBoltDBVersion(boltdbWrapper.DB), ShouldEqual, version.CurrentBoltDBVersion)
+		})
+
+		Convey("repo metadata is moved to the partitions", func() {
+			repoMeta := repodb.RepoMetadata{Name: "repo", Tags: map[string]repodb.Descriptor{}}
+
+			repoMetaBlob, err := json.Marshal(repoMeta)
+			So(err, ShouldBeNil)
+
+			// V1 layout
+			err = boltdbWrapper.DB.Update(func(tx *bbolt.Tx) error {
+				buck, err := tx.CreateBucketIfNotExists([]byte(bolt.RepoMetadataBucket))
+				if err != nil {
+					return err
+				}
+
+				return buck.Put([]byte("repo"), repoMetaBlob)
+			})
+			So(err, ShouldBeNil)
+
+			err = setBoltDBVersion(boltdbWrapper.DB, version.Version1)
+			So(err, ShouldBeNil)
+
+			boltdbWrapper.Patches = version.GetBoltDBPatches()
+
+			err = boltdbWrapper.PatchDB()
+			So(err, ShouldBeNil)
+			So(getBoltDBVersion(boltdbWrapper.DB), ShouldEqual, version.Version2)
+
+			err = boltdbWrapper.DB.View(func(tx *bbolt.Tx) error {
+				So(tx.Bucket([]byte(bolt.RepoMetadataBucket)), ShouldBeNil)
+
+				return nil
+			})
+			So(err, ShouldBeNil)
+
+			err = boltdbWrapper.RepoMetaDB("repo").View(func(tx *bbolt.Tx) error {
+				So(tx.Bucket([]byte(bolt.RepoMetadataBucket)).Get([]byte("repo")), ShouldResemble, repoMetaBlob)
+
+				return nil
+			})
+			So(err, ShouldBeNil)
+
+			repoMeta, err = boltdbWrapper.GetRepoMeta("repo")
+			So(err, ShouldBeNil)
+			So(repoMeta.Name, ShouldEqual, "repo")
+
+			// patching again doesn't change anything
+			err = boltdbWrapper.PatchDB()
+			So(err, ShouldBeNil)
+
+			repos, err := boltdbWrapper.GetMultipleRepoMeta(context.Background(),
+				func(repoMeta repodb.RepoMetadata) bool { return true }, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(len(repos), ShouldEqual, 1)
+		})
+
+		Convey("an interrupted migration can be run again", func() {
+			err := setBoltDBVersion(boltdbWrapper.DB, version.Version1)
+			So(err, ShouldBeNil)
+
+			repoMetaBlob, err := json.Marshal(repodb.RepoMetadata{Name: "repo", Tags: map[string]repodb.Descriptor{}})
+			So(err, ShouldBeNil)
+
+			putRepoMeta := func(tx *bbolt.Tx) error {
+				buck, err := tx.CreateBucketIfNotExists([]byte(bolt.RepoMetadataBucket))
+				if err != nil {
+					return err
+				}
+
+				return buck.Put([]byte("repo"), repoMetaBlob)
+			}
+
+			// the repo was copied to its partition, but not removed from the metadata db
+			So(boltdbWrapper.DB.Update(putRepoMeta), ShouldBeNil)
+			So(boltdbWrapper.RepoMetaDB("repo").Update(putRepoMeta), ShouldBeNil)
+
+			err = version.PartitionBoltDBRepoMeta(boltdbWrapper.DB)
+			So(err, ShouldBeNil)
+
+			repos, err := boltdbWrapper.GetMultipleRepoMeta(context.Background(),
+				func(repoMeta repodb.RepoMetadata) bool { return true }, repodb.PageInput{})
+			So(err, ShouldBeNil)
+			So(len(repos), ShouldEqual, 1)
+			So(getBoltDBVersion(boltdbWrapper.DB), ShouldEqual, version.Version2)
+		})
 	})
 }
 
+func getBoltDBVersion(db *bbolt.DB) string {
+	var vers string
+
+	_ = db.View(func(tx *bbolt.Tx) error {
+		vers = string(tx.Bucket([]byte(bolt.VersionBucket)).Get([]byte(version.DBVersionKey)))
+
+		return nil
+	})
+
+	return vers
+}
+
 func setBoltDBVersion(db *bbolt.DB, vers string) error {
 	err := db.Update(func(tx *bbolt.Tx) error {
 		versionBuck := tx.Bucket([]byte(bolt.VersionBucket))
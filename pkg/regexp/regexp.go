@@ -30,6 +30,12 @@ var (
 	// FullNameRegexp is the format which matches the full string of the
 	// name component of reference.
 	FullNameRegexp = expression(match("^"), NameRegexp, match("$"))
+
+	// TagRegexp is the format of tags, as defined by the distribution spec.
+	TagRegexp = match(`[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}`)
+
+	// FullTagRegexp matches the full string of a tag.
+	FullTagRegexp = expression(match("^"), TagRegexp, match("$"))
 )
 
 // match compiles the string to a regular expression.
@@ -22,11 +22,16 @@ func GetContextKey() *Key {
 type AccessControlContext struct {
 	// read method action
 	ReadGlobPatterns map[string]bool
+	// update method action
+	UpdateGlobPatterns map[string]bool
 	// detectManifestCollision behaviour action
 	DmcGlobPatterns map[string]bool
 	IsAdmin         bool
 	Username        string
 	Groups          []string
+	// Authorize, if set, is given the decision of the access control config for an action on a repo
+	// and returns the final one, e.g. after consulting the authorization plugins.
+	Authorize func(action, repository string, allowed bool) bool
 }
 
 /*
@@ -52,11 +57,24 @@ func GetAccessControlContext(ctx context.Context) (*AccessControlContext, error)
 
 // returns whether or not the user/anonymous who made the request has read permission on 'repository'.
 func (acCtx *AccessControlContext) CanReadRepo(repository string) bool {
+	allowed := true
+
 	if acCtx.ReadGlobPatterns != nil {
-		return acCtx.matchesRepo(acCtx.ReadGlobPatterns, repository)
+		allowed = acCtx.matchesRepo(acCtx.ReadGlobPatterns, repository)
+	}
+
+	return acCtx.authorize("read", repository, allowed)
+}
+
+// returns whether or not the user/anonymous who made the request has update permission on 'repository'.
+func (acCtx *AccessControlContext) CanUpdateRepo(repository string) bool {
+	allowed := true
+
+	if acCtx.UpdateGlobPatterns != nil {
+		allowed = acCtx.matchesRepo(acCtx.UpdateGlobPatterns, repository)
 	}
 
-	return true
+	return acCtx.authorize("update", repository, allowed)
 }
 
 /*
@@ -64,11 +82,21 @@ returns whether or not the user/anonymous who made the request
 has detectManifestCollision permission on 'repository'.
 */
 func (acCtx *AccessControlContext) CanDetectManifestCollision(repository string) bool {
+	allowed := false
+
 	if acCtx.DmcGlobPatterns != nil {
-		return acCtx.matchesRepo(acCtx.DmcGlobPatterns, repository)
+		allowed = acCtx.matchesRepo(acCtx.DmcGlobPatterns, repository)
+	}
+
+	return acCtx.authorize("detectManifestCollision", repository, allowed)
+}
+
+func (acCtx *AccessControlContext) authorize(action, repository string, allowed bool) bool {
+	if acCtx.Authorize == nil {
+		return allowed
 	}
 
-	return false
+	return acCtx.Authorize(action, repository, allowed)
 }
 
 /*
@@ -0,0 +1,32 @@
+package requestcontext
+
+import (
+	"context"
+)
+
+// request-local context key of the caller's identity.
+var identityCtxKey = Key(1) //nolint: gochecknoglobals
+
+// Identity - credentials the user authenticated with, forwarded by sync on demand to upstream registries.
+type Identity struct {
+	Username    string
+	Password    string
+	BearerToken string
+}
+
+// IsAnonymous returns whether the caller didn't provide any credentials.
+func (identity Identity) IsAnonymous() bool {
+	return identity.Password == "" && identity.BearerToken == ""
+}
+
+// WithIdentity returns a copy of ctx carrying the caller's identity.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, &identityCtxKey, identity)
+}
+
+// GetIdentity returns the caller's identity stored in ctx by WithIdentity, if any.
+func GetIdentity(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(&identityCtxKey).(Identity)
+
+	return identity, ok
+}
@@ -0,0 +1,20 @@
+package requestcontext
+
+import (
+	"context"
+)
+
+// request-local context key of the ID of the request.
+var requestIDCtxKey = Key(2) //nolint: gochecknoglobals
+
+// WithRequestID returns a copy of ctx carrying the ID of the request, returned to the client and logged.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, &requestIDCtxKey, requestID)
+}
+
+// GetRequestID returns the ID of the request stored in ctx by WithRequestID, or an empty string.
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(&requestIDCtxKey).(string)
+
+	return requestID
+}
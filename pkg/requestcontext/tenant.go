@@ -0,0 +1,21 @@
+package requestcontext
+
+import (
+	"context"
+)
+
+// request-local context key of the tenant whose virtual registry the request is for.
+var tenantCtxKey = Key(3) //nolint: gochecknoglobals
+
+// WithTenant returns a copy of ctx carrying the name of the tenant the request is for.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, &tenantCtxKey, tenant)
+}
+
+// GetTenant returns the name of the tenant stored in ctx by WithTenant, or an empty string if the request
+// isn't for a virtual registry.
+func GetTenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(&tenantCtxKey).(string)
+
+	return tenant
+}
@@ -243,6 +243,20 @@ func (scheduler *Scheduler) getTasksChannelByPriority(priority Priority) chan Ta
 	return nil
 }
 
+// GetQueueDepths returns the number of tasks waiting in the queue of each priority.
+func (scheduler *Scheduler) GetQueueDepths() map[string]int {
+	return map[string]int{
+		"low":    len(scheduler.tasksQLow),
+		"medium": len(scheduler.tasksQMedium),
+		"high":   len(scheduler.tasksQHigh),
+	}
+}
+
+// GetQueueCapacity returns the number of tasks a queue holds, tasks can't be added to a full queue.
+func (scheduler *Scheduler) GetQueueCapacity() int {
+	return cap(scheduler.tasksQLow)
+}
+
 func (scheduler *Scheduler) SubmitTask(task Task, priority Priority) {
 	// get by priority the channel where the task should be added to
 	tasksQ := scheduler.getTasksChannelByPriority(priority)
@@ -3,6 +3,7 @@ package storage
 import (
 	"zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	zlog "zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/storage/cache"
 	"zotregistry.io/zot/pkg/storage/constants"
@@ -15,14 +16,7 @@ func CreateCacheDatabaseDriver(storageConfig config.StorageConfig, log zlog.Logg
 
 	// local cache
 	if !storageConfig.RemoteCache {
-		params := cache.BoltDBDriverParameters{}
-		params.RootDir = storageConfig.RootDirectory
-		params.Name = constants.BoltdbName
-		params.UseRelPaths = getUseRelPaths(&storageConfig)
-
-		driver, _ := Create("boltdb", params, log)
-
-		return driver
+		return createBoltDBDriver(storageConfig, log)
 	}
 
 	// remote cache
@@ -34,19 +28,48 @@ func CreateCacheDatabaseDriver(storageConfig config.StorageConfig, log zlog.Logg
 			return nil
 		}
 
-		if name != constants.DynamoDBDriverName {
+		var driver cache.Cache
+
+		switch name {
+		case constants.DynamoDBDriverName:
+			dynamoParams := cache.DynamoDBDriverParameters{}
+			dynamoParams.Endpoint, _ = storageConfig.CacheDriver["endpoint"].(string)
+			dynamoParams.Region, _ = storageConfig.CacheDriver["region"].(string)
+			dynamoParams.TableName, _ = storageConfig.CacheDriver["cachetablename"].(string)
+
+			driver, _ = Create("dynamodb", dynamoParams, log)
+		case constants.RedisDriverName:
+			redisParams := cache.RedisDriverParameters{}
+			redisParams.URL, _ = storageConfig.CacheDriver["url"].(string)
+			redisParams.KeyPrefix, _ = storageConfig.CacheDriver["keyprefix"].(string)
+
+			// numbers are decoded as float64 from json and as int from yaml
+			switch poolSize := storageConfig.CacheDriver["poolsize"].(type) {
+			case int:
+				redisParams.PoolSize = poolSize
+			case float64:
+				redisParams.PoolSize = int(poolSize)
+			}
+
+			driver, _ = Create("redis", redisParams, log)
+		default:
 			log.Warn().Str("driver", name).Msg("remote cache driver unsupported!")
 
 			return nil
 		}
 
-		// dynamodb
-		dynamoParams := cache.DynamoDBDriverParameters{}
-		dynamoParams.Endpoint, _ = storageConfig.CacheDriver["endpoint"].(string)
-		dynamoParams.Region, _ = storageConfig.CacheDriver["region"].(string)
-		dynamoParams.TableName, _ = storageConfig.CacheDriver["cachetablename"].(string)
+		// a local boltdb in front of the remote cache speeds up the lookups
+		if localCache, _ := storageConfig.CacheDriver["localcache"].(bool); localCache && driver != nil {
+			localDriver := createBoltDBDriver(storageConfig, log)
+			if localDriver == nil {
+				log.Warn().Str("rootDir", storageConfig.RootDirectory).
+					Msg("unable to create local cache, using only the remote cache")
 
-		driver, _ := Create("dynamodb", dynamoParams, log)
+				return driver
+			}
+
+			return cache.NewChainCache(log, localDriver, driver)
+		}
 
 		return driver
 	}
@@ -54,6 +77,29 @@ func CreateCacheDatabaseDriver(storageConfig config.StorageConfig, log zlog.Logg
 	return nil
 }
 
+func createBoltDBDriver(storageConfig config.StorageConfig, log zlog.Logger) cache.Cache {
+	params := cache.BoltDBDriverParameters{}
+	params.RootDir = storageConfig.RootDirectory
+	params.Name = constants.BoltdbName
+	params.UseRelPaths = getUseRelPaths(&storageConfig)
+
+	driver, _ := Create("boltdb", params, log)
+
+	return driver
+}
+
+// createMetricsCacheDriver returns the cache driver of a store instrumented to report its metrics.
+func createMetricsCacheDriver(storageConfig config.StorageConfig, metrics monitoring.MetricServer,
+	log zlog.Logger,
+) cache.Cache {
+	driver := CreateCacheDatabaseDriver(storageConfig, log)
+	if driver == nil {
+		return nil
+	}
+
+	return cache.NewMetricsCache(driver, storageConfig.RootDirectory, metrics)
+}
+
 func Create(dbtype string, parameters interface{}, log zlog.Logger) (cache.Cache, error) {
 	switch dbtype {
 	case "boltdb":
@@ -64,6 +110,10 @@ func Create(dbtype string, parameters interface{}, log zlog.Logger) (cache.Cache
 		{
 			return cache.NewDynamoDBCache(parameters, log), nil
 		}
+	case "redis":
+		{
+			return cache.NewRedisCache(parameters, log), nil
+		}
 	default:
 		{
 			return nil, errors.ErrBadConfig
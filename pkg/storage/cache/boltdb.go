@@ -5,6 +5,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	godigest "github.com/opencontainers/go-digest"
 	"go.etcd.io/bbolt"
@@ -14,11 +15,20 @@ import (
 	"zotregistry.io/zot/pkg/storage/constants"
 )
 
+// compactTxMaxSize is the maximum size of the transactions used to copy the cache db when compacting it.
+const compactTxMaxSize = 64 * 1024
+
 type BoltDBDriver struct {
 	rootDir     string
+	dbPath      string
+	dbOpts      *bbolt.Options
+	lock        *sync.RWMutex // the db is swapped when compacting it
 	db          *bbolt.DB
 	log         zlog.Logger
 	useRelPaths bool // whether or not to use relative paths, should be true for filesystem and false for s3
+	// number of entries of the buckets, counted when the db is opened and updated with each change
+	entries     map[string]int
+	entriesLock *sync.Mutex
 }
 
 type BoltDBDriverParameters struct {
@@ -53,14 +63,19 @@ func NewBoltDBCache(parameters interface{}, log zlog.Logger) Cache {
 		return nil
 	}
 
+	var entries map[string]int
+
 	if err := cacheDB.Update(func(tx *bbolt.Tx) error {
-		if _, err := tx.CreateBucketIfNotExists([]byte(constants.BlobsCache)); err != nil {
+		root, err := tx.CreateBucketIfNotExists([]byte(constants.BlobsCache))
+		if err != nil {
 			// this is a serious failure
 			log.Error().Err(err).Str("dbPath", dbPath).Msg("unable to create a root bucket")
 
 			return err
 		}
 
+		entries = countEntries(root)
+
 		return nil
 	}); err != nil {
 		// something went wrong
@@ -71,9 +86,52 @@ func NewBoltDBCache(parameters interface{}, log zlog.Logger) Cache {
 
 	return &BoltDBDriver{
 		rootDir:     properParameters.RootDir,
+		dbPath:      dbPath,
+		dbOpts:      dbOpts,
+		lock:        &sync.RWMutex{},
 		db:          cacheDB,
 		useRelPaths: properParameters.UseRelPaths,
 		log:         log,
+		entries:     entries,
+		entriesLock: &sync.Mutex{},
+	}
+}
+
+// countEntries walks the whole db to count the cached digests and their original and duplicate blob paths.
+func countEntries(root *bbolt.Bucket) map[string]int {
+	entries := map[string]int{
+		constants.BlobsCache:       0,
+		constants.OriginalBucket:   0,
+		constants.DuplicatesBucket: 0,
+	}
+
+	_ = root.ForEach(func(digest, _ []byte) error {
+		entries[constants.BlobsCache]++
+
+		bucket := root.Bucket(digest)
+		if bucket == nil {
+			return nil
+		}
+
+		for _, name := range []string{constants.OriginalBucket, constants.DuplicatesBucket} {
+			if child := bucket.Bucket([]byte(name)); child != nil {
+				entries[name] += child.Stats().KeyN
+			}
+		}
+
+		return nil
+	})
+
+	return entries
+}
+
+// addEntries adds the changes of a committed transaction to the number of entries of the buckets.
+func (d *BoltDBDriver) addEntries(changes map[string]int) {
+	d.entriesLock.Lock()
+	defer d.entriesLock.Unlock()
+
+	for name, change := range changes {
+		d.entries[name] += change
 	}
 }
 
@@ -81,6 +139,20 @@ func (d *BoltDBDriver) Name() string {
 	return "boltdb"
 }
 
+func (d *BoltDBDriver) update(fn func(*bbolt.Tx) error) error {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.db.Update(fn)
+}
+
+func (d *BoltDBDriver) view(fn func(*bbolt.Tx) error) error {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.db.View(fn)
+}
+
 func (d *BoltDBDriver) PutBlob(digest godigest.Digest, path string) error {
 	if path == "" {
 		d.log.Error().Err(errors.ErrEmptyValue).Str("digest", digest.String()).Msg("empty path provided")
@@ -97,7 +169,9 @@ func (d *BoltDBDriver) PutBlob(digest godigest.Digest, path string) error {
 		}
 	}
 
-	if err := d.db.Update(func(tx *bbolt.Tx) error {
+	changes := map[string]int{}
+
+	if err := d.update(func(tx *bbolt.Tx) error {
 		root := tx.Bucket([]byte(constants.BlobsCache))
 		if root == nil {
 			// this is a serious failure
@@ -107,6 +181,10 @@ func (d *BoltDBDriver) PutBlob(digest godigest.Digest, path string) error {
 			return err
 		}
 
+		if root.Bucket([]byte(digest.String())) == nil {
+			changes[constants.BlobsCache]++
+		}
+
 		bucket, err := root.CreateBucketIfNotExists([]byte(digest.String()))
 		if err != nil {
 			// this is a serious failure
@@ -124,6 +202,10 @@ func (d *BoltDBDriver) PutBlob(digest godigest.Digest, path string) error {
 			return err
 		}
 
+		if deduped.Get([]byte(path)) == nil {
+			changes[constants.DuplicatesBucket]++
+		}
+
 		if err := deduped.Put([]byte(path), nil); err != nil {
 			d.log.Error().Err(err).Str("bucket", constants.DuplicatesBucket).Str("value", path).Msg("unable to put record")
 
@@ -147,6 +229,8 @@ func (d *BoltDBDriver) PutBlob(digest godigest.Digest, path string) error {
 
 				return err
 			}
+
+			changes[constants.OriginalBucket]++
 		}
 
 		return nil
@@ -154,13 +238,15 @@ func (d *BoltDBDriver) PutBlob(digest godigest.Digest, path string) error {
 		return err
 	}
 
+	d.addEntries(changes)
+
 	return nil
 }
 
 func (d *BoltDBDriver) GetBlob(digest godigest.Digest) (string, error) {
 	var blobPath strings.Builder
 
-	if err := d.db.View(func(tx *bbolt.Tx) error {
+	if err := d.view(func(tx *bbolt.Tx) error {
 		root := tx.Bucket([]byte(constants.BlobsCache))
 		if root == nil {
 			// this is a serious failure
@@ -187,7 +273,7 @@ func (d *BoltDBDriver) GetBlob(digest godigest.Digest) (string, error) {
 }
 
 func (d *BoltDBDriver) HasBlob(digest godigest.Digest, blob string) bool {
-	if err := d.db.View(func(tx *bbolt.Tx) error {
+	if err := d.view(func(tx *bbolt.Tx) error {
 		root := tx.Bucket([]byte(constants.BlobsCache))
 		if root == nil {
 			// this is a serious failure
@@ -226,6 +312,166 @@ func (d *BoltDBDriver) HasBlob(digest godigest.Digest, blob string) bool {
 	return true
 }
 
+// Stats returns the size of the cache db and the number of entries of its buckets: the number of
+// cached digests and the number of original and duplicate blob paths, counted when the db is opened
+// and then updated by each change.
+func (d *BoltDBDriver) Stats() (int64, map[string]int, error) {
+	var size int64
+
+	err := d.view(func(tx *bbolt.Tx) error {
+		size = tx.Size()
+
+		return nil
+	})
+
+	d.entriesLock.Lock()
+	defer d.entriesLock.Unlock()
+
+	entries := make(map[string]int, len(d.entries))
+
+	for name, count := range d.entries {
+		entries[name] = count
+	}
+
+	return size, entries, err
+}
+
+// GetGCCheckpoint returns the last blob swept by the incremental gc of a repo.
+func (d *BoltDBDriver) GetGCCheckpoint(repo string) (godigest.Digest, error) {
+	var checkpoint godigest.Digest
+
+	err := d.view(func(tx *bbolt.Tx) error {
+		// created with the first checkpoint
+		bucket := tx.Bucket([]byte(constants.GCCheckpointsBucket))
+		if bucket == nil {
+			return nil
+		}
+
+		checkpoint = godigest.Digest(bucket.Get([]byte(repo)))
+
+		return nil
+	})
+
+	return checkpoint, err
+}
+
+// PutGCCheckpoint records the last blob swept by the incremental gc of a repo, an empty digest clears it.
+func (d *BoltDBDriver) PutGCCheckpoint(repo string, digest godigest.Digest) error {
+	return d.update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(constants.GCCheckpointsBucket))
+		if err != nil {
+			d.log.Error().Err(err).Str("bucket", constants.GCCheckpointsBucket).Msg("unable to create a bucket")
+
+			return err
+		}
+
+		if digest == "" {
+			return bucket.Delete([]byte(repo))
+		}
+
+		return bucket.Put([]byte(repo), []byte(digest.String()))
+	})
+}
+
+// CheckIntegrity walks all the pages of the cache db looking for corruption, every issue found is logged.
+func (d *BoltDBDriver) CheckIntegrity() (int, error) {
+	var issues int
+
+	err := d.view(func(tx *bbolt.Tx) error {
+		for err := range tx.Check() {
+			d.log.Error().Err(err).Str("dbPath", d.dbPath).Msg("cache db integrity issue")
+
+			issues++
+		}
+
+		return nil
+	})
+
+	return issues, err
+}
+
+// Compact copies the cache db into a new file, leaving out the free pages, and atomically swaps
+// it with the current one. Cache operations wait for the compaction to finish.
+func (d *BoltDBDriver) Compact() (int64, int64, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	sizeBefore, err := fileSize(d.dbPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tmpPath := d.dbPath + ".compact"
+
+	_ = os.Remove(tmpPath)
+
+	if err := d.copyTo(tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+
+		d.log.Error().Err(err).Str("dbPath", d.dbPath).Msg("unable to compact cache db")
+
+		return sizeBefore, sizeBefore, err
+	}
+
+	if err := d.db.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+
+		d.log.Error().Err(err).Str("dbPath", d.dbPath).Msg("unable to close cache db")
+
+		return sizeBefore, sizeBefore, err
+	}
+
+	// on failure keep using the old db
+	renameErr := os.Rename(tmpPath, d.dbPath)
+	if renameErr != nil {
+		_ = os.Remove(tmpPath)
+
+		d.log.Error().Err(renameErr).Str("dbPath", d.dbPath).Msg("unable to replace cache db with the compacted one")
+	}
+
+	cacheDB, err := bbolt.Open(d.dbPath, 0o600, d.dbOpts) //nolint:gomnd
+	if err != nil {
+		// the closed db is kept, cache operations will fail until zot is restarted
+		d.log.Error().Err(err).Str("dbPath", d.dbPath).Msg("unable to reopen cache db")
+
+		return sizeBefore, 0, err
+	}
+
+	d.db = cacheDB
+
+	if renameErr != nil {
+		return sizeBefore, sizeBefore, renameErr
+	}
+
+	sizeAfter, err := fileSize(d.dbPath)
+
+	return sizeBefore, sizeAfter, err
+}
+
+func (d *BoltDBDriver) copyTo(dstPath string) error {
+	dst, err := bbolt.Open(dstPath, 0o600, d.dbOpts) //nolint:gomnd
+	if err != nil {
+		return err
+	}
+
+	if err := bbolt.Compact(dst, d.db, compactTxMaxSize); err != nil {
+		_ = dst.Close()
+
+		return err
+	}
+
+	return dst.Close()
+}
+
+func fileSize(path string) (int64, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return fileInfo.Size(), nil
+}
+
 func (d *BoltDBDriver) getOne(bucket *bbolt.Bucket) []byte {
 	if bucket != nil {
 		cursor := bucket.Cursor()
@@ -247,7 +493,9 @@ func (d *BoltDBDriver) DeleteBlob(digest godigest.Digest, path string) error {
 		}
 	}
 
-	if err := d.db.Update(func(tx *bbolt.Tx) error {
+	changes := map[string]int{}
+
+	if err := d.update(func(tx *bbolt.Tx) error {
 		root := tx.Bucket([]byte(constants.BlobsCache))
 		if root == nil {
 			// this is a serious failure
@@ -267,6 +515,10 @@ func (d *BoltDBDriver) DeleteBlob(digest godigest.Digest, path string) error {
 			return errors.ErrCacheMiss
 		}
 
+		if deduped.Get([]byte(path)) != nil {
+			changes[constants.DuplicatesBucket]--
+		}
+
 		if err := deduped.Delete([]byte(path)); err != nil {
 			d.log.Error().Err(err).Str("digest", digest.String()).Str("bucket", constants.DuplicatesBucket).
 				Str("path", path).Msg("unable to delete")
@@ -278,6 +530,10 @@ func (d *BoltDBDriver) DeleteBlob(digest godigest.Digest, path string) error {
 		if origin != nil {
 			originBlob := d.getOne(origin)
 			if originBlob != nil {
+				if origin.Get([]byte(path)) != nil {
+					changes[constants.OriginalBucket]--
+				}
+
 				if err := origin.Delete([]byte(path)); err != nil {
 					d.log.Error().Err(err).Str("digest", digest.String()).Str("bucket", constants.OriginalBucket).
 						Str("path", path).Msg("unable to delete")
@@ -288,6 +544,10 @@ func (d *BoltDBDriver) DeleteBlob(digest godigest.Digest, path string) error {
 				// move next candidate to origin bucket, next GetKey will return this one and storage will move the content here
 				dedupedBlob := d.getOne(deduped)
 				if dedupedBlob != nil {
+					if origin.Get(dedupedBlob) == nil {
+						changes[constants.OriginalBucket]++
+					}
+
 					if err := origin.Put(dedupedBlob, nil); err != nil {
 						d.log.Error().Err(err).Str("digest", digest.String()).Str("bucket", constants.OriginalBucket).Str("path", path).
 							Msg("unable to put")
@@ -302,6 +562,16 @@ func (d *BoltDBDriver) DeleteBlob(digest godigest.Digest, path string) error {
 		k := d.getOne(origin)
 		if k == nil {
 			d.log.Debug().Str("digest", digest.String()).Str("path", path).Msg("deleting empty bucket")
+
+			changes[constants.BlobsCache]--
+
+			// the stats of a bucket don't tell the changes of the transaction, count its remaining keys
+			_ = deduped.ForEach(func(_, _ []byte) error {
+				changes[constants.DuplicatesBucket]--
+
+				return nil
+			})
+
 			if err := root.DeleteBucket([]byte(digest)); err != nil {
 				d.log.Error().Err(err).Str("digest", digest.String()).Str("bucket", digest.String()).Str("path", path).
 					Msg("unable to delete")
@@ -315,5 +585,7 @@ func (d *BoltDBDriver) DeleteBlob(digest godigest.Digest, path string) error {
 		return err
 	}
 
+	d.addEntries(changes)
+
 	return nil
 }
@@ -1,12 +1,16 @@
 package cache_test
 
 import (
+	"fmt"
+	"os"
 	"path"
 	"testing"
 
+	godigest "github.com/opencontainers/go-digest"
 	. "github.com/smartystreets/goconvey/convey"
 
 	"zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/storage"
 	"zotregistry.io/zot/pkg/storage/cache"
@@ -62,3 +66,206 @@ func TestBoltDBCache(t *testing.T) {
 		So(err, ShouldEqual, errors.ErrEmptyValue)
 	})
 }
+
+func TestMetricsCache(t *testing.T) {
+	Convey("Wrap a cache driver to report metrics", t, func() {
+		dir := t.TempDir()
+
+		log := log.NewLogger("debug", "")
+
+		boltDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{dir, "cache_test", true}, log)
+		So(boltDriver, ShouldNotBeNil)
+
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		cacheDriver := cache.NewMetricsCache(boltDriver, dir, metrics)
+		So(cacheDriver.Name(), ShouldEqual, "boltdb")
+
+		_, err := cacheDriver.GetBlob("key")
+		So(err, ShouldEqual, errors.ErrCacheMiss)
+
+		err = cacheDriver.PutBlob("key", path.Join(dir, "value"))
+		So(err, ShouldBeNil)
+
+		err = cacheDriver.PutBlob("key", path.Join(dir, "duplicate"))
+		So(err, ShouldBeNil)
+
+		err = cacheDriver.PutBlob("key", "")
+		So(err, ShouldEqual, errors.ErrEmptyValue)
+
+		So(cacheDriver.HasBlob("key", "duplicate"), ShouldBeTrue)
+
+		val, err := cacheDriver.GetBlob("key")
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, "value")
+
+		boltStats, ok := boltDriver.(interface {
+			Stats() (int64, map[string]int, error)
+		})
+		So(ok, ShouldBeTrue)
+
+		size, entries, err := boltStats.Stats()
+		So(err, ShouldBeNil)
+		So(size, ShouldBeGreaterThan, 0)
+		So(entries["blobs"], ShouldEqual, 1)
+		So(entries["original"], ShouldEqual, 1)
+		So(entries["duplicates"], ShouldEqual, 2)
+
+		err = cacheDriver.DeleteBlob("key", path.Join(dir, "duplicate"))
+		So(err, ShouldBeNil)
+		So(cacheDriver.HasBlob("key", "duplicate"), ShouldBeFalse)
+
+		_, entries, err = boltStats.Stats()
+		So(err, ShouldBeNil)
+		So(entries["blobs"], ShouldEqual, 1)
+		So(entries["original"], ShouldEqual, 1)
+		So(entries["duplicates"], ShouldEqual, 1)
+
+		err = cacheDriver.DeleteBlob("key", path.Join(dir, "value"))
+		So(err, ShouldBeNil)
+
+		_, entries, err = boltStats.Stats()
+		So(err, ShouldBeNil)
+		So(entries["blobs"], ShouldEqual, 0)
+		So(entries["original"], ShouldEqual, 0)
+		So(entries["duplicates"], ShouldEqual, 0)
+	})
+}
+
+func TestBoltDBCacheMaintenance(t *testing.T) {
+	Convey("Check and compact the cache db", t, func() {
+		dir := t.TempDir()
+
+		log := log.NewLogger("debug", "")
+
+		boltDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{dir, "cache_test", true}, log)
+		So(boltDriver, ShouldNotBeNil)
+
+		cacheDriver := cache.NewMetricsCache(boltDriver, dir, monitoring.NewMetricsServer(false, log))
+
+		maintainer, ok := cacheDriver.(cache.Maintainer)
+		So(ok, ShouldBeTrue)
+
+		for i := 0; i < 1000; i++ {
+			digest := godigest.FromString(fmt.Sprintf("blob-%d", i))
+
+			err := cacheDriver.PutBlob(digest, path.Join(dir, "repo", digest.Encoded()))
+			So(err, ShouldBeNil)
+		}
+
+		for i := 1; i < 1000; i++ {
+			digest := godigest.FromString(fmt.Sprintf("blob-%d", i))
+
+			err := cacheDriver.DeleteBlob(digest, path.Join(dir, "repo", digest.Encoded()))
+			So(err, ShouldBeNil)
+		}
+
+		issues, err := maintainer.CheckIntegrity()
+		So(err, ShouldBeNil)
+		So(issues, ShouldEqual, 0)
+
+		sizeBefore, sizeAfter, err := maintainer.Compact()
+		So(err, ShouldBeNil)
+		So(sizeAfter, ShouldBeLessThan, sizeBefore)
+
+		_, err = os.Stat(path.Join(dir, "cache_test.db.compact"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		// the cache is still usable after the db was swapped
+		digest := godigest.FromString("blob-0")
+		So(cacheDriver.HasBlob(digest, path.Join("repo", digest.Encoded())), ShouldBeTrue)
+
+		err = cacheDriver.PutBlob(godigest.FromString("blob-1"), path.Join(dir, "repo", "blob-1"))
+		So(err, ShouldBeNil)
+
+		issues, err = maintainer.CheckIntegrity()
+		So(err, ShouldBeNil)
+		So(issues, ShouldEqual, 0)
+	})
+
+	Convey("Drivers which can't be maintained", t, func() {
+		log := log.NewLogger("debug", "")
+
+		cacheDriver := cache.NewMetricsCache(nonMaintainableCache{}, "/", monitoring.NewMetricsServer(false, log))
+
+		maintainer, ok := cacheDriver.(cache.Maintainer)
+		So(ok, ShouldBeTrue)
+
+		_, err := maintainer.CheckIntegrity()
+		So(err, ShouldEqual, errors.ErrCacheMaintenanceUnsupported)
+
+		_, _, err = maintainer.Compact()
+		So(err, ShouldEqual, errors.ErrCacheMaintenanceUnsupported)
+
+		checkpointer, ok := cacheDriver.(cache.Checkpointer)
+		So(ok, ShouldBeTrue)
+
+		_, err = checkpointer.GetGCCheckpoint("repo")
+		So(err, ShouldEqual, errors.ErrGCCheckpointUnsupported)
+
+		err = checkpointer.PutGCCheckpoint("repo", godigest.FromString("blob"))
+		So(err, ShouldEqual, errors.ErrGCCheckpointUnsupported)
+	})
+}
+
+func TestBoltDBGCCheckpoints(t *testing.T) {
+	Convey("Record the gc checkpoints of repos", t, func() {
+		dir := t.TempDir()
+
+		log := log.NewLogger("debug", "")
+
+		boltDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{dir, "cache_test", true}, log)
+		So(boltDriver, ShouldNotBeNil)
+
+		cacheDriver := cache.NewMetricsCache(boltDriver, dir, monitoring.NewMetricsServer(false, log))
+
+		checkpointer, ok := cacheDriver.(cache.Checkpointer)
+		So(ok, ShouldBeTrue)
+
+		checkpoint, err := checkpointer.GetGCCheckpoint("repo")
+		So(err, ShouldBeNil)
+		So(checkpoint, ShouldBeEmpty)
+
+		digest := godigest.FromString("blob")
+
+		err = checkpointer.PutGCCheckpoint("repo", digest)
+		So(err, ShouldBeNil)
+
+		checkpoint, err = checkpointer.GetGCCheckpoint("repo")
+		So(err, ShouldBeNil)
+		So(checkpoint, ShouldEqual, digest)
+
+		checkpoint, err = checkpointer.GetGCCheckpoint("other")
+		So(err, ShouldBeNil)
+		So(checkpoint, ShouldBeEmpty)
+
+		err = checkpointer.PutGCCheckpoint("repo", "")
+		So(err, ShouldBeNil)
+
+		checkpoint, err = checkpointer.GetGCCheckpoint("repo")
+		So(err, ShouldBeNil)
+		So(checkpoint, ShouldBeEmpty)
+	})
+}
+
+type nonMaintainableCache struct{}
+
+func (nonMaintainableCache) Name() string {
+	return "nonMaintainable"
+}
+
+func (nonMaintainableCache) GetBlob(digest godigest.Digest) (string, error) {
+	return "", errors.ErrCacheMiss
+}
+
+func (nonMaintainableCache) PutBlob(digest godigest.Digest, path string) error {
+	return nil
+}
+
+func (nonMaintainableCache) HasBlob(digest godigest.Digest, path string) bool {
+	return false
+}
+
+func (nonMaintainableCache) DeleteBlob(digest godigest.Digest, path string) error {
+	return nil
+}
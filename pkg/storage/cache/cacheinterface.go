@@ -20,3 +20,23 @@ type Cache interface {
 	// Delete a blob from the cachedb.
 	DeleteBlob(digest godigest.Digest, path string) error
 }
+
+// Maintainer is implemented by the cache drivers keeping the cache in a local db file which has to be
+// checked and compacted from time to time.
+type Maintainer interface {
+	// Checks the cachedb for corruption, returns the number of issues found.
+	CheckIntegrity() (int, error)
+
+	// Rewrites the cachedb to reclaim the space of deleted entries, returns its size before and after.
+	Compact() (int64, int64, error)
+}
+
+// Checkpointer is implemented by the cache drivers which can record how far the incremental gc of a repo went,
+// so that it resumes from there after a restart.
+type Checkpointer interface {
+	// Returns the last blob swept by gc in the repo, an empty digest if the sweep wasn't started.
+	GetGCCheckpoint(repo string) (godigest.Digest, error)
+
+	// Records the last blob swept by gc in the repo, an empty digest clears it once the sweep is over.
+	PutGCCheckpoint(repo string, digest godigest.Digest) error
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	zlog "zotregistry.io/zot/pkg/log"
+)
+
+// ChainCache chains cache drivers from the fastest to the slowest one, usually a local boltdb
+// in front of a remote cache.
+// Lookups go through the chain until a driver has the blob and populate the faster drivers with it
+// (read-through), updates are applied to the last driver first, which is the source of truth, and
+// then to the faster ones (write-through).
+// The faster drivers are not invalidated when another zot instance updates the last one, so the chain
+// is only for a single zot instance: instances sharing a remote cache would keep stale blob paths.
+type ChainCache struct {
+	caches []Cache
+	log    zlog.Logger
+}
+
+func NewChainCache(log zlog.Logger, caches ...Cache) Cache {
+	return &ChainCache{caches: caches, log: log}
+}
+
+func (c *ChainCache) Name() string {
+	names := make([]string, 0, len(c.caches))
+
+	for _, cache := range c.caches {
+		names = append(names, cache.Name())
+	}
+
+	return strings.Join(names, "+")
+}
+
+func (c *ChainCache) GetBlob(digest godigest.Digest) (string, error) {
+	for idx, cache := range c.caches {
+		path, err := cache.GetBlob(digest)
+		if err != nil {
+			if errors.Is(err, zerr.ErrCacheMiss) {
+				continue
+			}
+
+			return "", err
+		}
+
+		// populate the faster caches so next lookups don't go through the whole chain
+		for _, fasterCache := range c.caches[:idx] {
+			if err := fasterCache.PutBlob(digest, path); err != nil {
+				c.log.Warn().Err(err).Str("driver", fasterCache.Name()).Str("digest", digest.String()).
+					Msg("chain cache: unable to populate cache")
+			}
+		}
+
+		return path, nil
+	}
+
+	return "", zerr.ErrCacheMiss
+}
+
+func (c *ChainCache) PutBlob(digest godigest.Digest, path string) error {
+	for idx := len(c.caches) - 1; idx >= 0; idx-- {
+		if err := c.caches[idx].PutBlob(digest, path); err != nil {
+			// the faster caches are not updated so they don't get ahead of the source of truth
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *ChainCache) HasBlob(digest godigest.Digest, path string) bool {
+	for _, cache := range c.caches {
+		if cache.HasBlob(digest, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *ChainCache) DeleteBlob(digest godigest.Digest, path string) error {
+	found := false
+
+	for idx := len(c.caches) - 1; idx >= 0; idx-- {
+		if err := c.caches[idx].DeleteBlob(digest, path); err != nil {
+			// the faster caches may have not been populated with this blob yet
+			if errors.Is(err, zerr.ErrCacheMiss) {
+				continue
+			}
+
+			return err
+		}
+
+		found = true
+	}
+
+	if !found {
+		return zerr.ErrCacheMiss
+	}
+
+	return nil
+}
+
+// CheckIntegrity checks the first driver of the chain which can be maintained.
+func (c *ChainCache) CheckIntegrity() (int, error) {
+	for _, cache := range c.caches {
+		if maintainer, ok := cache.(Maintainer); ok {
+			return maintainer.CheckIntegrity()
+		}
+	}
+
+	return 0, zerr.ErrCacheMaintenanceUnsupported
+}
+
+// Compact compacts the first driver of the chain which can be maintained.
+func (c *ChainCache) Compact() (int64, int64, error) {
+	for _, cache := range c.caches {
+		if maintainer, ok := cache.(Maintainer); ok {
+			return maintainer.Compact()
+		}
+	}
+
+	return 0, 0, zerr.ErrCacheMaintenanceUnsupported
+}
+
+// GetGCCheckpoint reads the gc checkpoint of a repo from the first driver of the chain which can record them.
+func (c *ChainCache) GetGCCheckpoint(repo string) (godigest.Digest, error) {
+	for _, cache := range c.caches {
+		if checkpointer, ok := cache.(Checkpointer); ok {
+			return checkpointer.GetGCCheckpoint(repo)
+		}
+	}
+
+	return "", zerr.ErrGCCheckpointUnsupported
+}
+
+// PutGCCheckpoint records the gc checkpoint of a repo in the first driver of the chain which can record them.
+func (c *ChainCache) PutGCCheckpoint(repo string, digest godigest.Digest) error {
+	for _, cache := range c.caches {
+		if checkpointer, ok := cache.(Checkpointer); ok {
+			return checkpointer.PutGCCheckpoint(repo, digest)
+		}
+	}
+
+	return zerr.ErrGCCheckpointUnsupported
+}
+
+// Stats reports the statistics of the first driver of the chain which can tell them.
+func (c *ChainCache) Stats() (int64, map[string]int, error) {
+	for _, cache := range c.caches {
+		if reporter, ok := cache.(statsReporter); ok {
+			return reporter.Stats()
+		}
+	}
+
+	return 0, nil, zerr.ErrCacheMaintenanceUnsupported
+}
@@ -0,0 +1,139 @@
+package cache_test
+
+import (
+	"path"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage"
+	"zotregistry.io/zot/pkg/storage/cache"
+	"zotregistry.io/zot/pkg/test/mocks"
+)
+
+func TestChainCache(t *testing.T) {
+	log := log.NewLogger("debug", "")
+
+	Convey("Chain a local cache in front of a shared one", t, func() {
+		dir := t.TempDir()
+
+		localCache, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{dir, "local", false}, log)
+		So(localCache, ShouldNotBeNil)
+
+		sharedCache, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{dir, "shared", false}, log)
+		So(sharedCache, ShouldNotBeNil)
+
+		cacheDriver := cache.NewChainCache(log, localCache, sharedCache)
+		So(cacheDriver.Name(), ShouldEqual, "boltdb+boltdb")
+
+		digest := godigest.FromString("blob")
+		blobPath := path.Join(dir, "repo", "blobs", digest.Encoded())
+
+		_, err := cacheDriver.GetBlob(digest)
+		So(err, ShouldEqual, errors.ErrCacheMiss)
+
+		// written through to both caches
+		err = cacheDriver.PutBlob(digest, blobPath)
+		So(err, ShouldBeNil)
+		So(localCache.HasBlob(digest, blobPath), ShouldBeTrue)
+		So(sharedCache.HasBlob(digest, blobPath), ShouldBeTrue)
+
+		// a blob added by another instance is read through and populated locally
+		otherDigest := godigest.FromString("other blob")
+		otherPath := path.Join(dir, "other", "blobs", otherDigest.Encoded())
+
+		err = sharedCache.PutBlob(otherDigest, otherPath)
+		So(err, ShouldBeNil)
+		So(cacheDriver.HasBlob(otherDigest, otherPath), ShouldBeTrue)
+		So(localCache.HasBlob(otherDigest, otherPath), ShouldBeFalse)
+
+		val, err := cacheDriver.GetBlob(otherDigest)
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, otherPath)
+		So(localCache.HasBlob(otherDigest, otherPath), ShouldBeTrue)
+
+		err = cacheDriver.DeleteBlob(digest, blobPath)
+		So(err, ShouldBeNil)
+		So(cacheDriver.HasBlob(digest, blobPath), ShouldBeFalse)
+
+		err = cacheDriver.DeleteBlob(godigest.FromString("bogus"), blobPath)
+		So(err, ShouldEqual, errors.ErrCacheMiss)
+
+		maintainer, ok := cacheDriver.(cache.Maintainer)
+		So(ok, ShouldBeTrue)
+
+		issues, err := maintainer.CheckIntegrity()
+		So(err, ShouldBeNil)
+		So(issues, ShouldEqual, 0)
+
+		_, _, err = maintainer.Compact()
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Errors of the chained caches", t, func() {
+		localCache := mocks.CacheMock{
+			GetBlobFn: func(digest godigest.Digest) (string, error) {
+				return "", errors.ErrCacheMiss
+			},
+			PutBlobFn: func(digest godigest.Digest, path string) error {
+				return errors.ErrCacheRootBucket
+			},
+			HasBlobFn: func(digest godigest.Digest, path string) bool {
+				return false
+			},
+			DeleteBlobFn: func(digest godigest.Digest, path string) error {
+				return errors.ErrCacheMiss
+			},
+		}
+
+		sharedCache := mocks.CacheMock{
+			GetBlobFn: func(digest godigest.Digest) (string, error) {
+				return "path", nil
+			},
+		}
+
+		cacheDriver := cache.NewChainCache(log, localCache, sharedCache)
+
+		// failing to populate the local cache doesn't fail the lookup
+		val, err := cacheDriver.GetBlob("digest")
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, "path")
+
+		err = cacheDriver.PutBlob("digest", "path")
+		So(err, ShouldEqual, errors.ErrCacheRootBucket)
+
+		err = cacheDriver.DeleteBlob("digest", "path")
+		So(err, ShouldBeNil)
+
+		sharedCache.GetBlobFn = func(digest godigest.Digest) (string, error) {
+			return "", errors.ErrCacheRootBucket
+		}
+
+		sharedCache.DeleteBlobFn = func(digest godigest.Digest, path string) error {
+			return errors.ErrCacheRootBucket
+		}
+
+		cacheDriver = cache.NewChainCache(log, localCache, sharedCache)
+
+		_, err = cacheDriver.GetBlob("digest")
+		So(err, ShouldEqual, errors.ErrCacheRootBucket)
+
+		err = cacheDriver.DeleteBlob("digest", "path")
+		So(err, ShouldEqual, errors.ErrCacheRootBucket)
+
+		// none of the chained caches can be maintained
+		noMaintenanceChain := cache.NewChainCache(log, nonMaintainableCache{})
+
+		maintainer, ok := noMaintenanceChain.(cache.Maintainer)
+		So(ok, ShouldBeTrue)
+
+		_, err = maintainer.CheckIntegrity()
+		So(err, ShouldEqual, errors.ErrCacheMaintenanceUnsupported)
+
+		_, _, err = maintainer.Compact()
+		So(err, ShouldEqual, errors.ErrCacheMaintenanceUnsupported)
+	})
+}
@@ -30,9 +30,9 @@ type Blob struct {
 	BlobPath []string `dynamodbav:"BlobPath,stringset"`
 }
 
-// Use ONLY for tests.
+// NewTable creates the cache table if it doesn't exist yet. The table is billed per request, so an idle
+// deployment doesn't pay for provisioned capacity.
 func (d *DynamoDBDriver) NewTable(tableName string) error {
-	//nolint:gomnd
 	_, err := d.client.CreateTable(context.TODO(), &dynamodb.CreateTableInput{
 		TableName: &tableName,
 		AttributeDefinitions: []types.AttributeDefinition{
@@ -47,10 +47,7 @@ func (d *DynamoDBDriver) NewTable(tableName string) error {
 				KeyType:       types.KeyTypeHash,
 			},
 		},
-		ProvisionedThroughput: &types.ProvisionedThroughput{
-			ReadCapacityUnits:  aws.Int64(10),
-			WriteCapacityUnits: aws.Int64(5),
-		},
+		BillingMode: types.BillingModePayPerRequest,
 	})
 	if err != nil && !strings.Contains(err.Error(), "Table already exists") {
 		return err
@@ -70,6 +67,11 @@ func NewDynamoDBCache(parameters interface{}, log zlog.Logger) Cache {
 	// custom endpoint resolver to point to localhost
 	customResolver := aws.EndpointResolverWithOptionsFunc(
 		func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+			// no custom endpoint, e.g. running in AWS, use the endpoint of the region
+			if properParameters.Endpoint == "" {
+				return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+			}
+
 			return aws.Endpoint{
 				PartitionID:   "aws",
 				URL:           properParameters.Endpoint,
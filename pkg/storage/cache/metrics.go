@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"errors"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+)
+
+// statsReporter is implemented by the cache drivers which can report statistics about their db, they are
+// read after each update so the drivers keep them up to date instead of walking the db.
+type statsReporter interface {
+	Stats() (int64, map[string]int, error)
+}
+
+// MetricsCache wraps a cache driver to report the latency and the errors of its operations and,
+// if the driver can tell them, the size of its db and the number of entries of its buckets.
+type MetricsCache struct {
+	cache       Cache
+	storageName string
+	metrics     monitoring.MetricServer
+}
+
+func NewMetricsCache(cache Cache, storageName string, metrics monitoring.MetricServer) Cache {
+	metricsCache := &MetricsCache{
+		cache:       cache,
+		storageName: storageName,
+		metrics:     metrics,
+	}
+
+	metricsCache.updateStats()
+
+	return metricsCache
+}
+
+func (c *MetricsCache) Name() string {
+	return c.cache.Name()
+}
+
+func (c *MetricsCache) GetBlob(digest godigest.Digest) (string, error) {
+	start := time.Now()
+
+	path, err := c.cache.GetBlob(digest)
+
+	c.observe("GetBlob", start, err)
+
+	return path, err
+}
+
+func (c *MetricsCache) PutBlob(digest godigest.Digest, path string) error {
+	start := time.Now()
+
+	err := c.cache.PutBlob(digest, path)
+
+	c.observe("PutBlob", start, err)
+	c.updateStats()
+
+	return err
+}
+
+func (c *MetricsCache) HasBlob(digest godigest.Digest, path string) bool {
+	start := time.Now()
+
+	found := c.cache.HasBlob(digest, path)
+
+	c.observe("HasBlob", start, nil)
+
+	return found
+}
+
+func (c *MetricsCache) DeleteBlob(digest godigest.Digest, path string) error {
+	start := time.Now()
+
+	err := c.cache.DeleteBlob(digest, path)
+
+	c.observe("DeleteBlob", start, err)
+	c.updateStats()
+
+	return err
+}
+
+func (c *MetricsCache) CheckIntegrity() (int, error) {
+	maintainer, ok := c.cache.(Maintainer)
+	if !ok {
+		return 0, zerr.ErrCacheMaintenanceUnsupported
+	}
+
+	start := time.Now()
+
+	issues, err := maintainer.CheckIntegrity()
+
+	c.observe("CheckIntegrity", start, err)
+
+	if err == nil {
+		monitoring.SetCacheIntegrityIssues(c.metrics, c.storageName, issues)
+	}
+
+	return issues, err
+}
+
+func (c *MetricsCache) Compact() (int64, int64, error) {
+	maintainer, ok := c.cache.(Maintainer)
+	if !ok {
+		return 0, 0, zerr.ErrCacheMaintenanceUnsupported
+	}
+
+	start := time.Now()
+
+	sizeBefore, sizeAfter, err := maintainer.Compact()
+
+	c.observe("Compact", start, err)
+
+	// report the new db size
+	c.updateStats()
+
+	return sizeBefore, sizeAfter, err
+}
+
+func (c *MetricsCache) GetGCCheckpoint(repo string) (godigest.Digest, error) {
+	checkpointer, ok := c.cache.(Checkpointer)
+	if !ok {
+		return "", zerr.ErrGCCheckpointUnsupported
+	}
+
+	start := time.Now()
+
+	checkpoint, err := checkpointer.GetGCCheckpoint(repo)
+
+	c.observe("GetGCCheckpoint", start, err)
+
+	return checkpoint, err
+}
+
+func (c *MetricsCache) PutGCCheckpoint(repo string, digest godigest.Digest) error {
+	checkpointer, ok := c.cache.(Checkpointer)
+	if !ok {
+		return zerr.ErrGCCheckpointUnsupported
+	}
+
+	start := time.Now()
+
+	err := checkpointer.PutGCCheckpoint(repo, digest)
+
+	c.observe("PutGCCheckpoint", start, err)
+
+	return err
+}
+
+func (c *MetricsCache) observe(operation string, start time.Time, err error) {
+	monitoring.ObserveCacheLatency(c.metrics, time.Since(start), c.cache.Name(), operation)
+
+	// cache misses are expected, they don't tell anything about the health of the cache
+	if err != nil && !errors.Is(err, zerr.ErrCacheMiss) {
+		monitoring.IncCacheErrors(c.metrics, c.cache.Name(), operation)
+	}
+}
+
+// updateStats reports the cache db statistics.
+func (c *MetricsCache) updateStats() {
+	reporter, ok := c.cache.(statsReporter)
+	if !ok {
+		return
+	}
+
+	size, entries, err := reporter.Stats()
+	if err != nil {
+		// a chain of remote cache drivers has no statistics to report
+		if !errors.Is(err, zerr.ErrCacheMaintenanceUnsupported) {
+			monitoring.IncCacheErrors(c.metrics, c.cache.Name(), "Stats")
+		}
+
+		return
+	}
+
+	monitoring.SetCacheDBSize(c.metrics, c.storageName, size)
+
+	for bucket, count := range entries {
+		monitoring.SetCacheBucketEntries(c.metrics, c.storageName, bucket, count)
+	}
+}
@@ -0,0 +1,134 @@
+package cache_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage"
+	"zotregistry.io/zot/pkg/storage/cache"
+)
+
+func skipRedis(t *testing.T) {
+	t.Helper()
+
+	if os.Getenv("REDIS_URL") == "" {
+		t.Skip("Skipping testing without redis server")
+	}
+}
+
+func TestRedisParameters(t *testing.T) {
+	Convey("Test redis cache parameters", t, func() {
+		log := log.NewLogger("debug", "")
+
+		So(func() {
+			_ = cache.NewRedisCache("bad params", log)
+		}, ShouldPanic)
+
+		cacheDriver, err := storage.Create("redis", cache.RedisDriverParameters{URL: "bad://url"}, log)
+		So(err, ShouldBeNil)
+		So(cacheDriver, ShouldBeNil)
+
+		// unreachable server
+		cacheDriver, err = storage.Create("redis", cache.RedisDriverParameters{
+			URL:      "redis://127.0.0.1:1/0",
+			PoolSize: 2,
+		}, log)
+		So(err, ShouldBeNil)
+		So(cacheDriver, ShouldNotBeNil)
+		So(cacheDriver.Name(), ShouldEqual, "redis")
+
+		keyDigest := godigest.FromString("key")
+
+		val, err := cacheDriver.GetBlob(keyDigest)
+		So(err, ShouldNotBeNil)
+		So(err, ShouldNotEqual, zerr.ErrCacheMiss)
+		So(val, ShouldBeEmpty)
+
+		err = cacheDriver.PutBlob(keyDigest, "")
+		So(err, ShouldEqual, zerr.ErrEmptyValue)
+
+		err = cacheDriver.PutBlob(keyDigest, "value")
+		So(err, ShouldNotBeNil)
+
+		So(cacheDriver.HasBlob(keyDigest, "value"), ShouldBeFalse)
+
+		err = cacheDriver.DeleteBlob(keyDigest, "value")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestRedis(t *testing.T) {
+	skipRedis(t)
+	Convey("Test redis cache", t, func() {
+		log := log.NewLogger("debug", "")
+		dir := t.TempDir()
+
+		// a prefix per run, the server may be shared with other runs
+		cacheDriver, err := storage.Create("redis", cache.RedisDriverParameters{
+			URL:       os.Getenv("REDIS_URL"),
+			KeyPrefix: path.Base(dir),
+		}, log)
+		So(err, ShouldBeNil)
+		So(cacheDriver, ShouldNotBeNil)
+
+		keyDigest := godigest.FromString("key")
+
+		val, err := cacheDriver.GetBlob(keyDigest)
+		So(err, ShouldEqual, zerr.ErrCacheMiss)
+		So(val, ShouldBeEmpty)
+
+		err = cacheDriver.DeleteBlob(keyDigest, path.Join(dir, "value"))
+		So(err, ShouldEqual, zerr.ErrCacheMiss)
+
+		err = cacheDriver.PutBlob(keyDigest, path.Join(dir, "value"))
+		So(err, ShouldBeNil)
+
+		val, err = cacheDriver.GetBlob(keyDigest)
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, path.Join(dir, "value"))
+
+		So(cacheDriver.HasBlob(keyDigest, path.Join(dir, "value")), ShouldBeTrue)
+		So(cacheDriver.HasBlob(keyDigest, path.Join(dir, "other")), ShouldBeFalse)
+
+		err = cacheDriver.DeleteBlob(keyDigest, path.Join(dir, "value"))
+		So(err, ShouldBeNil)
+
+		So(cacheDriver.HasBlob(keyDigest, path.Join(dir, "value")), ShouldBeFalse)
+
+		_, err = cacheDriver.GetBlob(keyDigest)
+		So(err, ShouldEqual, zerr.ErrCacheMiss)
+
+		// the first path put stays the original until it is deleted
+		err = cacheDriver.PutBlob(keyDigest, path.Join(dir, "value1"))
+		So(err, ShouldBeNil)
+
+		err = cacheDriver.PutBlob(keyDigest, path.Join(dir, "value2"))
+		So(err, ShouldBeNil)
+
+		val, err = cacheDriver.GetBlob(keyDigest)
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, path.Join(dir, "value1"))
+
+		err = cacheDriver.DeleteBlob(keyDigest, path.Join(dir, "value1"))
+		So(err, ShouldBeNil)
+
+		So(cacheDriver.HasBlob(keyDigest, path.Join(dir, "value2")), ShouldBeTrue)
+		So(cacheDriver.HasBlob(keyDigest, path.Join(dir, "value1")), ShouldBeFalse)
+
+		val, err = cacheDriver.GetBlob(keyDigest)
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, path.Join(dir, "value2"))
+
+		err = cacheDriver.DeleteBlob(keyDigest, path.Join(dir, "value2"))
+		So(err, ShouldBeNil)
+
+		_, err = cacheDriver.GetBlob(keyDigest)
+		So(err, ShouldEqual, zerr.ErrCacheMiss)
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"path"
+	"sort"
 	"strings"
+	"time"
 
 	notreg "github.com/notaryproject/notation-go/registry"
 	godigest "github.com/opencontainers/go-digest"
@@ -17,10 +19,63 @@ import (
 	zerr "zotregistry.io/zot/errors"
 	zcommon "zotregistry.io/zot/pkg/common"
 	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage/cache"
 	storageConstants "zotregistry.io/zot/pkg/storage/constants"
 	storageTypes "zotregistry.io/zot/pkg/storage/types"
 )
 
+// maxManifestSize is the biggest blob considered when looking for manifests, see RebuildIndex.
+const maxManifestSize = 4 * 1024 * 1024
+
+// lengths the parts of the referrers tags are truncated to, see GetReferrersTag.
+const (
+	maxReferrersTagAlgorithmLen = 32
+	maxReferrersTagEncodedLen   = 64
+)
+
+// repo issues reported by the storage consistency check.
+const (
+	IssueInvalidRepoName  = "invalid repository name"
+	IssueBadImageLayout   = "missing or invalid oci-layout file"
+	IssueBadIndex         = "missing or invalid index.json"
+	IssueOrphanUploadDir  = "blob upload dir outside of any repo"
+	IssueInvalidRepoFiles = "invalid repo layout"
+)
+
+// CompareRepoNames compares two repo names path segment by path segment, which is the order the image stores
+// walk their repos in, e.g. a/b comes before a-b.
+func CompareRepoNames(name1, name2 string) int {
+	for {
+		segment1, rest1, more1 := strings.Cut(name1, "/")
+		segment2, rest2, more2 := strings.Cut(name2, "/")
+
+		if cmp := strings.Compare(segment1, segment2); cmp != 0 {
+			return cmp
+		}
+
+		switch {
+		case more1 && more2:
+			name1, name2 = rest1, rest2
+		case more1:
+			return 1
+		case more2:
+			return -1
+		default:
+			return 0
+		}
+	}
+}
+
+// IsRepoDirBefore tells if the repos under a dir of an image store, the dir included, all come before last
+// when walking them, see CompareRepoNames.
+func IsRepoDirBefore(dir, last string) bool {
+	if last == "" || dir == last || strings.HasPrefix(last, dir+"/") {
+		return false
+	}
+
+	return CompareRepoNames(dir, last) < 0
+}
+
 func GetTagsByIndex(index ispec.Index) []string {
 	tags := make([]string, 0)
 
@@ -34,6 +89,8 @@ func GetTagsByIndex(index ispec.Index) []string {
 	return tags
 }
 
+// GetManifestDescByReference returns the descriptor of a tag or a digest, manifests converted from docker media
+// types are also found by the digest they were pushed with.
 func GetManifestDescByReference(index ispec.Index, reference string) (ispec.Descriptor, bool) {
 	var manifestDesc ispec.Descriptor
 
@@ -48,6 +105,12 @@ func GetManifestDescByReference(index ispec.Index, reference string) (ispec.Desc
 		}
 	}
 
+	for _, manifest := range index.Manifests {
+		if v, ok := manifest.Annotations[zcommon.AnnotationOriginalDigest]; ok && v == reference {
+			return manifest, true
+		}
+	}
+
 	return manifestDesc, false
 }
 
@@ -69,7 +132,7 @@ func ValidateManifest(imgStore storageTypes.ImageStore, repo, reference, mediaTy
 	}
 
 	switch mediaType {
-	case ispec.MediaTypeImageManifest:
+	case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 		var manifest ispec.Manifest
 		if err := json.Unmarshal(body, &manifest); err != nil {
 			log.Error().Err(err).Msg("unable to unmarshal JSON")
@@ -77,7 +140,7 @@ func ValidateManifest(imgStore storageTypes.ImageStore, repo, reference, mediaTy
 			return "", zerr.ErrBadManifest
 		}
 
-		if manifest.Config.MediaType == ispec.MediaTypeImageConfig {
+		if zcommon.IsImageConfig(manifest.Config.MediaType) {
 			digest, err := validateOCIManifest(imgStore, repo, reference, &manifest, log)
 			if err != nil {
 				log.Error().Err(err).Msg("invalid oci image manifest")
@@ -101,6 +164,43 @@ func ValidateManifest(imgStore storageTypes.ImageStore, repo, reference, mediaTy
 
 			return "", zerr.ErrBadManifest
 		}
+	case zcommon.MediaTypeDockerSchema1Manifest, zcommon.MediaTypeDockerSchema1SignedManifest:
+		digest, err := validateSchema1Manifest(imgStore, repo, body, log)
+		if err != nil {
+			log.Error().Err(err).Msg("invalid docker schema1 manifest")
+
+			return digest, err
+		}
+	}
+
+	return "", nil
+}
+
+// validateSchema1Manifest checks the layers of a docker schema1 manifest exist, it has no config.
+func validateSchema1Manifest(imgStore storageTypes.ImageStore, repo string, body []byte,
+	log zerolog.Logger,
+) (godigest.Digest, error) {
+	var manifest zcommon.Schema1Manifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		log.Error().Err(err).Msg("unable to unmarshal JSON")
+
+		return "", zerr.ErrBadManifest
+	}
+
+	if manifest.SchemaVersion != 1 || len(manifest.FSLayers) == 0 {
+		log.Error().Int("SchemaVersion", manifest.SchemaVersion).Msg("invalid manifest")
+
+		return "", zerr.ErrBadManifest
+	}
+
+	for _, layer := range manifest.Layers() {
+		if err := layer.Validate(); err != nil {
+			return "", zerr.ErrBadManifest
+		}
+
+		if _, err := imgStore.GetBlobContent(repo, layer); err != nil {
+			return layer, zerr.ErrBlobNotFound
+		}
 	}
 
 	return "", nil
@@ -239,6 +339,129 @@ func CheckIfIndexNeedsUpdate(index *ispec.Index, desc *ispec.Descriptor,
 	return updateIndex, oldDgst, nil
 }
 
+// AddTagsToIndex makes the tags point to the manifest with the given digest, which has to be in the index,
+// it returns false if all the tags already did.
+func AddTagsToIndex(imgStore storageTypes.ImageStore, index *ispec.Index, repo string, digest godigest.Digest,
+	tags []string, log zerolog.Logger,
+) (bool, error) {
+	var manifestDesc *ispec.Descriptor
+
+	for _, desc := range index.Manifests {
+		if desc.Digest == digest {
+			desc := desc
+			manifestDesc = &desc
+
+			break
+		}
+	}
+
+	if manifestDesc == nil {
+		return false, zerr.ErrManifestNotFound
+	}
+
+	updated := false
+
+	for _, tag := range tags {
+		desc := ispec.Descriptor{
+			MediaType:   manifestDesc.MediaType,
+			Size:        manifestDesc.Size,
+			Digest:      manifestDesc.Digest,
+			Annotations: map[string]string{ispec.AnnotationRefName: tag},
+		}
+
+		SetTagPushedAt(&desc, time.Now())
+
+		updateIndex, oldDgst, err := CheckIfIndexNeedsUpdate(index, &desc, log)
+		if err != nil {
+			return false, err
+		}
+
+		if !updateIndex {
+			continue
+		}
+
+		if err := UpdateIndexWithPrunedImageManifests(imgStore, index, repo, desc, oldDgst, log); err != nil {
+			return false, err
+		}
+
+		index.Manifests = append(index.Manifests, desc)
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// CheckTagsCanMove returns zerr.ErrTagImmutable if one of the tags is immutable and already points to another
+// manifest than digest, pushing a tag again with the same manifest is allowed. SHOULD lock from outside.
+func CheckTagsCanMove(index ispec.Index, repo string, tags []string, digest godigest.Digest,
+	immutableTags storageTypes.ImmutableTags,
+) error {
+	if immutableTags == nil {
+		return nil
+	}
+
+	for _, desc := range index.Manifests {
+		tag, ok := desc.Annotations[ispec.AnnotationRefName]
+		if !ok || desc.Digest == digest || !zcommon.Contains(tags, tag) {
+			continue
+		}
+
+		if immutableTags.IsImmutable(repo, tag) {
+			return zerr.ErrTagImmutable
+		}
+	}
+
+	return nil
+}
+
+// CheckTagsCanBeDeleted returns zerr.ErrTagImmutable if deleting the reference removes an immutable tag, the
+// reference being the tag itself or the digest of the manifest it points to. SHOULD lock from outside.
+func CheckTagsCanBeDeleted(index ispec.Index, repo, reference string, immutableTags storageTypes.ImmutableTags,
+) error {
+	if immutableTags == nil {
+		return nil
+	}
+
+	for _, desc := range index.Manifests {
+		tag, ok := desc.Annotations[ispec.AnnotationRefName]
+		if !ok || (tag != reference && desc.Digest.String() != reference) {
+			continue
+		}
+
+		if immutableTags.IsImmutable(repo, tag) {
+			return zerr.ErrTagImmutable
+		}
+	}
+
+	return nil
+}
+
+// SetTagPushedAt records in the descriptor of a tag when it was pushed, so that retention policies know the age
+// of the tag rather than the age of its manifest, which can be older.
+func SetTagPushedAt(desc *ispec.Descriptor, pushedAt time.Time) {
+	if desc.Annotations == nil {
+		desc.Annotations = map[string]string{}
+	}
+
+	desc.Annotations[zcommon.AnnotationTagPushedAt] = pushedAt.UTC().Format(time.RFC3339Nano)
+}
+
+// GetTagPushedAt returns when the tag described by desc was pushed, ok is false for the tags pushed before the
+// time was recorded.
+func GetTagPushedAt(desc ispec.Descriptor) (time.Time, bool) {
+	value, ok := desc.Annotations[zcommon.AnnotationTagPushedAt]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	pushedAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return pushedAt, true
+}
+
 // GetIndex returns the contents of index.json.
 func GetIndex(imgStore storageTypes.ImageStore, repo string, log zerolog.Logger) (ispec.Index, error) {
 	var index ispec.Index
@@ -283,6 +506,184 @@ func GetImageIndex(imgStore storageTypes.ImageStore, repo string, digest godiges
 	return imageIndex, nil
 }
 
+// the fields of oci/docker manifests and indexes and of oras artifacts linking them to other manifests.
+type manifestLinks struct {
+	Manifests []ispec.Descriptor `json:"manifests"`
+	Subject   *ispec.Descriptor  `json:"subject,omitempty"`
+}
+
+/*
+GetReachableManifests returns the digests of the manifests gc has to keep in a repo, the ones reachable from
+the tagged and pinned manifests of its index.json, and from its artifacts which are neither images nor indexes:
+  - the manifests of a reachable image index, at any depth of nested indexes
+  - the referrers of a reachable manifest, oci manifests and oras artifacts with a subject, and their own referrers
+
+The untagged images and image indexes of index.json which aren't reachable can be removed.
+*/
+func GetReachableManifests(imgStore storageTypes.ImageStore, repo string, index ispec.Index,
+	pins storageTypes.PinnedImages, log zerolog.Logger,
+) (map[godigest.Digest]bool, error) {
+	links := map[godigest.Digest]manifestLinks{}
+
+	getLinks := func(digest godigest.Digest) (manifestLinks, error) {
+		if digestLinks, ok := links[digest]; ok {
+			return digestLinks, nil
+		}
+
+		var digestLinks manifestLinks
+
+		buf, err := imgStore.GetBlobContent(repo, digest)
+		if err != nil {
+			log.Error().Err(err).Str("repository", repo).Str("digest", digest.String()).
+				Msg("gc: failed to read manifest")
+
+			return digestLinks, err
+		}
+
+		// not a json manifest, it doesn't link to anything
+		if err := json.Unmarshal(buf, &digestLinks); err != nil {
+			log.Debug().Err(err).Str("repository", repo).Str("digest", digest.String()).
+				Msg("gc: invalid manifest JSON")
+		}
+
+		links[digest] = digestLinks
+
+		return digestLinks, nil
+	}
+
+	// referrers are pushed by digest, they are all in index.json
+	referrers := map[godigest.Digest][]ispec.Descriptor{}
+	toVisit := []ispec.Descriptor{}
+
+	for _, desc := range index.Manifests {
+		descLinks, err := getLinks(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		if descLinks.Subject != nil {
+			referrers[descLinks.Subject.Digest] = append(referrers[descLinks.Subject.Digest], desc)
+		}
+
+		_, isRoot := desc.Annotations[ispec.AnnotationRefName]
+
+		if !zcommon.IsImageManifest(desc.MediaType) && !zcommon.IsImageIndex(desc.MediaType) {
+			isRoot = true
+		}
+
+		if !isRoot && pins != nil {
+			isRoot, err = pins.IsImagePinned(repo, desc.Digest)
+			if err != nil {
+				log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
+					Msg("gc: failed to check if manifest is pinned")
+
+				return nil, err
+			}
+		}
+
+		if isRoot {
+			toVisit = append(toVisit, desc)
+		}
+	}
+
+	reachable := map[godigest.Digest]bool{}
+
+	for len(toVisit) > 0 {
+		desc := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+
+		if reachable[desc.Digest] {
+			continue
+		}
+
+		reachable[desc.Digest] = true
+
+		toVisit = append(toVisit, referrers[desc.Digest]...)
+
+		if zcommon.IsImageIndex(desc.MediaType) {
+			descLinks, err := getLinks(desc.Digest)
+			if errors.Is(err, zerr.ErrBlobNotFound) {
+				// a nested index removed with the tag of its parent, it doesn't keep anything
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			toVisit = append(toVisit, descLinks.Manifests...)
+		}
+	}
+
+	return reachable, nil
+}
+
+// the fields of oci/docker manifests and indexes, oras artifacts and docker schema1 manifests listing blobs.
+type manifestBlobs struct {
+	Config    *ispec.Descriptor        `json:"config,omitempty"`
+	Layers    []ispec.Descriptor       `json:"layers"`
+	Manifests []ispec.Descriptor       `json:"manifests"`
+	Blobs     []ispec.Descriptor       `json:"blobs"`
+	FSLayers  []zcommon.Schema1FSLayer `json:"fsLayers"`
+}
+
+/*
+GetReferencedBlobs returns the digests of the blobs referenced by the manifests of index.json: the manifests
+themselves, their configs and layers, the blobs of oras artifacts and, at any depth, the manifests of image
+indexes and their own blobs. Missing manifests are skipped, they don't reference anything.
+*/
+func GetReferencedBlobs(imgStore storageTypes.ImageStore, repo string, index ispec.Index, log zerolog.Logger,
+) (map[godigest.Digest]bool, error) {
+	referenced := map[godigest.Digest]bool{}
+	toVisit := append([]ispec.Descriptor{}, index.Manifests...)
+
+	for len(toVisit) > 0 {
+		desc := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+
+		if referenced[desc.Digest] {
+			continue
+		}
+
+		referenced[desc.Digest] = true
+
+		buf, err := imgStore.GetBlobContent(repo, desc.Digest)
+		if err != nil {
+			if errors.Is(err, zerr.ErrBlobNotFound) {
+				continue
+			}
+
+			log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
+				Msg("gc: failed to read manifest")
+
+			return nil, err
+		}
+
+		var blobs manifestBlobs
+
+		// not a json manifest, it doesn't reference anything
+		if err := json.Unmarshal(buf, &blobs); err != nil {
+			continue
+		}
+
+		if blobs.Config != nil {
+			referenced[blobs.Config.Digest] = true
+		}
+
+		for _, layer := range append(blobs.Layers, blobs.Blobs...) {
+			referenced[layer.Digest] = true
+		}
+
+		for _, layer := range blobs.FSLayers {
+			referenced[layer.BlobSum] = true
+		}
+
+		toVisit = append(toVisit, blobs.Manifests...)
+	}
+
+	return referenced, nil
+}
+
 func GetImageManifest(imgStore storageTypes.ImageStore, repo string, digest godigest.Digest, log zerolog.Logger,
 ) (ispec.Manifest, error) {
 	var manifestContent ispec.Manifest
@@ -352,11 +753,11 @@ manifest indexes else GC can never clean them.
 func UpdateIndexWithPrunedImageManifests(imgStore storageTypes.ImageStore, index *ispec.Index, repo string,
 	desc ispec.Descriptor, oldDgst godigest.Digest, log zerolog.Logger,
 ) error {
-	if (desc.MediaType == ispec.MediaTypeImageIndex) && (oldDgst != "") {
+	if zcommon.IsImageIndex(desc.MediaType) && (oldDgst != "") {
 		otherImgIndexes := []ispec.Descriptor{}
 
 		for _, manifest := range index.Manifests {
-			if manifest.MediaType == ispec.MediaTypeImageIndex {
+			if zcommon.IsImageIndex(manifest.MediaType) {
 				otherImgIndexes = append(otherImgIndexes, manifest)
 			}
 		}
@@ -426,7 +827,7 @@ func PruneImageManifestsFromIndex(imgStore storageTypes.ImageStore, repo string,
 	// for all manifests in the index, skip those that either have a tag or
 	// are used in other imgIndexes
 	for _, outManifest := range outIndex.Manifests {
-		if outManifest.MediaType != ispec.MediaTypeImageManifest {
+		if !zcommon.IsImageManifest(outManifest.MediaType) {
 			prunedManifests = append(prunedManifests, outManifest)
 
 			continue
@@ -462,7 +863,7 @@ func ApplyLinter(imgStore storageTypes.ImageStore, linter Lint, repo string, des
 	pass := true
 
 	// we'll skip anything that's not a image manifest
-	if descriptor.MediaType != ispec.MediaTypeImageManifest {
+	if !zcommon.IsImageManifest(descriptor.MediaType) {
 		return pass, nil
 	}
 
@@ -481,11 +882,18 @@ func ApplyLinter(imgStore storageTypes.ImageStore, linter Lint, repo string, des
 	return pass, nil
 }
 
+// IsCosignTag returns true if the tag is one cosign pushes a signature, an SBOM or attestations of an image under.
+func IsCosignTag(tag string) bool {
+	return strings.HasPrefix(tag, "sha256-") && (strings.HasSuffix(tag, remote.SignatureTagSuffix) ||
+		strings.HasSuffix(tag, remote.SBOMTagSuffix) || strings.HasSuffix(tag, remote.AttestationTagSuffix))
+}
+
 func IsSignature(descriptor ispec.Descriptor) bool {
 	tag := descriptor.Annotations[ispec.AnnotationRefName]
 
 	switch descriptor.MediaType {
-	case ispec.MediaTypeImageManifest:
+	// cosign pushes docker manifests when COSIGN_DOCKER_MEDIA_TYPES is set
+	case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 		// is cosgin signature
 		if strings.HasPrefix(tag, "sha256-") && strings.HasSuffix(tag, remote.SignatureTagSuffix) {
 			return true
@@ -502,6 +910,42 @@ func IsSignature(descriptor ispec.Descriptor) bool {
 	return false
 }
 
+/*
+GetReferrersTagSubject returns the digest of the subject of a referrers tag, e.g. sha256-<hex>, the tag clients
+push an index listing the referrers of a manifest to when the registry doesn't support the referrers API.
+*/
+func GetReferrersTagSubject(tag string) (godigest.Digest, bool) {
+	algorithm, encoded, ok := strings.Cut(tag, "-")
+	if !ok {
+		return "", false
+	}
+
+	digest := godigest.NewDigestFromEncoded(godigest.Algorithm(algorithm), encoded)
+	if err := digest.Validate(); err != nil {
+		return "", false
+	}
+
+	return digest, true
+}
+
+/*
+GetReferrersTag returns the referrers tag of a digest, e.g. sha256-<hex>, the algorithm and the encoded digest are
+truncated to 32 and 64 characters as in the referrers tag schema of the distribution spec.
+*/
+func GetReferrersTag(digest godigest.Digest) string {
+	algorithm := digest.Algorithm().String()
+	if len(algorithm) > maxReferrersTagAlgorithmLen {
+		algorithm = algorithm[:maxReferrersTagAlgorithmLen]
+	}
+
+	encoded := digest.Encoded()
+	if len(encoded) > maxReferrersTagEncodedLen {
+		encoded = encoded[:maxReferrersTagEncodedLen]
+	}
+
+	return algorithm + "-" + encoded
+}
+
 func GetOrasReferrers(imgStore storageTypes.ImageStore, repo string, gdigest godigest.Digest, artifactType string,
 	log zerolog.Logger,
 ) ([]oras.Descriptor, error) {
@@ -598,7 +1042,7 @@ func GetReferrers(imgStore storageTypes.ImageStore, repo string, gdigest godiges
 		}
 
 		switch descriptor.MediaType {
-		case ispec.MediaTypeImageManifest:
+		case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 			var manifestContent ispec.Manifest
 
 			if err := json.Unmarshal(buf, &manifestContent); err != nil {
@@ -625,7 +1069,7 @@ func GetReferrers(imgStore storageTypes.ImageStore, repo string, gdigest godiges
 				Digest:       descriptor.Digest,
 				Annotations:  manifestContent.Annotations,
 			})
-		case ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
 			var indexContent ispec.Index
 
 			if err := json.Unmarshal(buf, &indexContent); err != nil {
@@ -654,6 +1098,9 @@ func GetReferrers(imgStore storageTypes.ImageStore, repo string, gdigest godiges
 		}
 	}
 
+	// referrers listed by clients using the referrers tag schema, which may not be found by their subject
+	result = append(result, getReferrersTagDescriptors(imgStore, repo, index, gdigest, result, artifactTypes, log)...)
+
 	index = ispec.Index{
 		Versioned:   imeta.Versioned{SchemaVersion: storageConstants.SchemaVersion},
 		MediaType:   ispec.MediaTypeImageIndex,
@@ -664,6 +1111,69 @@ func GetReferrers(imgStore storageTypes.ImageStore, repo string, gdigest godiges
 	return index, nil
 }
 
+// getReferrersTagDescriptors returns the manifests of the repo listed in the referrers tag index of a digest,
+// which aren't already in found.
+func getReferrersTagDescriptors(imgStore storageTypes.ImageStore, repo string, index ispec.Index,
+	gdigest godigest.Digest, found []ispec.Descriptor, artifactTypes []string, log zerolog.Logger,
+) []ispec.Descriptor {
+	referrersTag := GetReferrersTag(gdigest)
+
+	var tagDescriptor *ispec.Descriptor
+
+	manifests := map[godigest.Digest]bool{}
+
+	for idx, descriptor := range index.Manifests {
+		manifests[descriptor.Digest] = true
+
+		if descriptor.Annotations[ispec.AnnotationRefName] == referrersTag &&
+			descriptor.MediaType == ispec.MediaTypeImageIndex {
+			tagDescriptor = &index.Manifests[idx]
+		}
+	}
+
+	if tagDescriptor == nil {
+		return nil
+	}
+
+	buf, err := imgStore.GetBlobContent(repo, tagDescriptor.Digest)
+	if err != nil {
+		log.Error().Err(err).Str("repository", repo).Str("tag", referrersTag).Msg("failed to read referrers tag index")
+
+		return nil
+	}
+
+	var tagIndex ispec.Index
+
+	if err := json.Unmarshal(buf, &tagIndex); err != nil {
+		log.Error().Err(err).Str("repository", repo).Str("tag", referrersTag).Msg("invalid JSON")
+
+		return nil
+	}
+
+	for _, descriptor := range found {
+		manifests[descriptor.Digest] = false
+	}
+
+	result := []ispec.Descriptor{}
+
+	for _, descriptor := range tagIndex.Manifests {
+		// referrers deleted since the index was pushed are left out
+		if !manifests[descriptor.Digest] {
+			continue
+		}
+
+		if len(artifactTypes) > 0 && !zcommon.Contains(artifactTypes, descriptor.ArtifactType) {
+			continue
+		}
+
+		manifests[descriptor.Digest] = false
+
+		result = append(result, descriptor)
+	}
+
+	return result
+}
+
 func GetOrasManifestByDigest(imgStore storageTypes.ImageStore, repo string, digest godigest.Digest, log zerolog.Logger,
 ) (oras.Manifest, error) {
 	var artManifest oras.Manifest
@@ -690,9 +1200,72 @@ func GetOrasManifestByDigest(imgStore storageTypes.ImageStore, repo string, dige
 	return artManifest, nil
 }
 
+// RebuildIndex recreates the index of a repo from its blobs, used when index.json is lost.
+// Every manifest or index blob which is not referenced by another index blob is added to the index,
+// the tags can't be recovered since they are only stored in index.json.
+func RebuildIndex(imgStore storageTypes.ImageStore, repo string, blobs map[godigest.Digest]int64,
+	log zerolog.Logger,
+) ispec.Index {
+	index := ispec.Index{Versioned: imeta.Versioned{SchemaVersion: storageConstants.SchemaVersion}}
+
+	descriptors := map[godigest.Digest]ispec.Descriptor{}
+	referenced := map[godigest.Digest]bool{}
+
+	for digest, size := range blobs {
+		// layers can be huge, don't bother reading anything which can't be a manifest
+		// (deduped blobs on object storage are empty, their size is only known after reading them)
+		if size > maxManifestSize {
+			continue
+		}
+
+		buf, err := imgStore.GetBlobContent(repo, digest)
+		if err != nil {
+			log.Warn().Err(err).Str("repository", repo).Str("digest", digest.String()).
+				Msg("unable to read blob while rebuilding index")
+
+			continue
+		}
+
+		var content struct {
+			MediaType    string             `json:"mediaType"`
+			ArtifactType string             `json:"artifactType"`
+			Manifests    []ispec.Descriptor `json:"manifests"`
+		}
+
+		if err := json.Unmarshal(buf, &content); err != nil || !IsSupportedMediaType(content.MediaType) {
+			continue
+		}
+
+		descriptors[digest] = ispec.Descriptor{
+			MediaType:    content.MediaType,
+			ArtifactType: content.ArtifactType,
+			Digest:       digest,
+			Size:         int64(len(buf)),
+		}
+
+		for _, manifest := range content.Manifests {
+			referenced[manifest.Digest] = true
+		}
+	}
+
+	for digest, descriptor := range descriptors {
+		if !referenced[digest] {
+			index.Manifests = append(index.Manifests, descriptor)
+		}
+	}
+
+	// keep the rebuilt index stable between runs
+	sort.Slice(index.Manifests, func(i, j int) bool {
+		return index.Manifests[i].Digest < index.Manifests[j].Digest
+	})
+
+	return index
+}
+
 func IsSupportedMediaType(mediaType string) bool {
-	return mediaType == ispec.MediaTypeImageIndex ||
-		mediaType == ispec.MediaTypeImageManifest ||
+	return zcommon.IsImageIndex(mediaType) ||
+		zcommon.IsImageManifest(mediaType) ||
+		zcommon.IsSchema1Manifest(mediaType) ||
 		mediaType == oras.MediaTypeArtifactManifest
 }
 
@@ -786,3 +1359,271 @@ func (dt *dedupeTask) DoWork() error {
 
 	return err
 }
+
+// RunCacheMaintenance checks the integrity of the dedupe cache db of a store and, if no issues are found,
+// compacts it. Stores without a cache or whose cache driver can't be maintained are skipped.
+func RunCacheMaintenance(cacheDriver cache.Cache, rootDir string, log zerolog.Logger) error {
+	maintainer, ok := cacheDriver.(cache.Maintainer)
+	if !ok {
+		return nil
+	}
+
+	issues, err := maintainer.CheckIntegrity()
+	if err != nil {
+		if errors.Is(err, zerr.ErrCacheMaintenanceUnsupported) {
+			return nil
+		}
+
+		log.Error().Err(err).Str("rootDir", rootDir).Msg("cache maintenance: unable to check cache db integrity")
+
+		return err
+	}
+
+	if issues > 0 {
+		// compacting copies the corrupted pages as they are, the db has to be rebuilt instead
+		log.Error().Err(zerr.ErrCacheCorrupted).Str("rootDir", rootDir).Int("issues", issues).
+			Msg("cache maintenance: skipping compaction")
+
+		return zerr.ErrCacheCorrupted
+	}
+
+	sizeBefore, sizeAfter, err := maintainer.Compact()
+	if err != nil {
+		log.Error().Err(err).Str("rootDir", rootDir).Msg("cache maintenance: unable to compact cache db")
+
+		return err
+	}
+
+	log.Info().Str("rootDir", rootDir).Int64("sizeBefore", sizeBefore).Int64("sizeAfter", sizeAfter).
+		Msg("cache maintenance: cache db checked and compacted")
+
+	return nil
+}
+
+// CacheMaintenanceTaskGenerator generates a single task checking and compacting the dedupe cache db of a store.
+type CacheMaintenanceTaskGenerator struct {
+	ImgStore storageTypes.ImageStore
+	done     bool
+}
+
+func (gen *CacheMaintenanceTaskGenerator) Next() (scheduler.Task, error) {
+	gen.done = true
+
+	return &cacheMaintenanceTask{imgStore: gen.ImgStore}, nil
+}
+
+func (gen *CacheMaintenanceTaskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *CacheMaintenanceTaskGenerator) Reset() {
+	gen.done = false
+}
+
+type cacheMaintenanceTask struct {
+	imgStore storageTypes.ImageStore
+}
+
+func (cmt *cacheMaintenanceTask) DoWork() error {
+	return cmt.imgStore.RunCacheMaintenance()
+}
+
+// PurgedManifest is an index entry removed from a repo while purging a digest,
+// Reference is the tag of the entry or its digest if it is not tagged.
+type PurgedManifest struct {
+	Reference string
+	MediaType string
+	Digest    godigest.Digest
+	Blob      []byte
+}
+
+// PurgeDigest removes a digest from a repo without waiting for gc: the manifests which are or reference
+// the digest (as config, layer, blob, subject or child of an index) are deleted along with all their tags,
+// then the blob itself is deleted, which also removes it from the dedupe cache.
+// It returns the removed index entries and whether the digest was found in the repo.
+func PurgeDigest(imgStore storageTypes.ImageStore, repo string, digest godigest.Digest, log zerolog.Logger,
+) ([]PurgedManifest, bool, error) {
+	index, err := GetIndex(imgStore, repo, log)
+	if err != nil {
+		return nil, false, err
+	}
+
+	purged := []PurgedManifest{}
+	toDelete := []godigest.Digest{}
+	tainted := map[godigest.Digest]bool{}
+
+	for _, desc := range index.Manifests {
+		if _, seen := tainted[desc.Digest]; !seen {
+			tainted[desc.Digest] = manifestReferencesDigest(imgStore, repo, desc.Digest, digest, log)
+
+			if tainted[desc.Digest] {
+				toDelete = append(toDelete, desc.Digest)
+			}
+		}
+
+		if !tainted[desc.Digest] {
+			continue
+		}
+
+		reference, ok := desc.Annotations[ispec.AnnotationRefName]
+		if !ok {
+			reference = desc.Digest.String()
+		}
+
+		// keep the content so callers can update their metadata once the manifest is gone
+		blob, _ := imgStore.GetBlobContent(repo, desc.Digest)
+
+		purged = append(purged, PurgedManifest{
+			Reference: reference,
+			MediaType: desc.MediaType,
+			Digest:    desc.Digest,
+			Blob:      blob,
+		})
+	}
+
+	for _, manifestDigest := range toDelete {
+		// the content is purged because it must go, even from behind immutable tags
+		if err := imgStore.PurgeImageManifest(repo, manifestDigest.String()); err != nil {
+			log.Error().Err(err).Str("repository", repo).Str("digest", manifestDigest.String()).
+				Msg("purge: failed to delete manifest")
+
+			return purged, false, err
+		}
+	}
+
+	found := true
+
+	if err := imgStore.DeleteBlob(repo, digest); err != nil {
+		if !errors.Is(err, zerr.ErrBlobNotFound) {
+			log.Error().Err(err).Str("repository", repo).Str("digest", digest.String()).
+				Msg("purge: failed to delete blob")
+
+			return purged, false, err
+		}
+
+		found = false
+	}
+
+	found = found || len(toDelete) > 0
+
+	if found {
+		log.Info().Str("repository", repo).Str("digest", digest.String()).Int("manifests", len(toDelete)).
+			Msg("purge: digest removed from repo")
+	}
+
+	return purged, found, nil
+}
+
+// RepoReferencesDigest returns true if one of the manifests in the index of a repo is the given digest
+// or references it, directly or through the manifests of an index.
+func RepoReferencesDigest(imgStore storageTypes.ImageStore, repo string, digest godigest.Digest,
+	log zerolog.Logger,
+) (bool, error) {
+	index, err := GetIndex(imgStore, repo, log)
+	if err != nil {
+		return false, err
+	}
+
+	for _, desc := range index.Manifests {
+		if manifestReferencesDigest(imgStore, repo, desc.Digest, digest, log) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GetManifestReferences returns the digests referenced by an image manifest, an image index or an
+// oras artifact manifest: the blobs (config, layers, subject) and the manifests of an index.
+func GetManifestReferences(buf []byte) ([]godigest.Digest, []godigest.Digest, error) {
+	var content struct {
+		Config    *ispec.Descriptor  `json:"config"`
+		Layers    []ispec.Descriptor `json:"layers"`
+		Blobs     []ispec.Descriptor `json:"blobs"`
+		Manifests []ispec.Descriptor `json:"manifests"`
+		Subject   *ispec.Descriptor  `json:"subject"`
+	}
+
+	if err := json.Unmarshal(buf, &content); err != nil {
+		return nil, nil, err
+	}
+
+	blobs := []godigest.Digest{}
+	manifests := []godigest.Digest{}
+
+	if content.Config != nil {
+		blobs = append(blobs, content.Config.Digest)
+	}
+
+	if content.Subject != nil {
+		blobs = append(blobs, content.Subject.Digest)
+	}
+
+	for _, desc := range append(content.Layers, content.Blobs...) {
+		blobs = append(blobs, desc.Digest)
+	}
+
+	for _, desc := range content.Manifests {
+		manifests = append(manifests, desc.Digest)
+	}
+
+	return blobs, manifests, nil
+}
+
+// manifestReferencesDigest returns true if the manifest is the given digest or references it,
+// directly or through the manifests of an index.
+func manifestReferencesDigest(imgStore storageTypes.ImageStore, repo string, manifestDigest,
+	digest godigest.Digest, log zerolog.Logger,
+) bool {
+	if manifestDigest == digest {
+		return true
+	}
+
+	buf, err := imgStore.GetBlobContent(repo, manifestDigest)
+	if err != nil {
+		log.Warn().Err(err).Str("repository", repo).Str("digest", manifestDigest.String()).
+			Msg("unable to read manifest")
+
+		return false
+	}
+
+	blobs, manifests, err := GetManifestReferences(buf)
+	if err != nil {
+		return false
+	}
+
+	for _, blobDigest := range blobs {
+		if blobDigest == digest {
+			return true
+		}
+	}
+
+	for _, childDigest := range manifests {
+		if manifestReferencesDigest(imgStore, repo, childDigest, digest, log) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// layoutVersionFile is the content of the file recording the layout version of a store.
+type layoutVersionFile struct {
+	Version int `json:"version"`
+}
+
+// EncodeLayoutVersion returns the content of the layout version file of a store.
+func EncodeLayoutVersion(version int) ([]byte, error) {
+	return json.Marshal(layoutVersionFile{Version: version})
+}
+
+// DecodeLayoutVersion parses the content of the layout version file of a store.
+func DecodeLayoutVersion(buf []byte) (int, error) {
+	var content layoutVersionFile
+
+	if err := json.Unmarshal(buf, &content); err != nil || content.Version < 0 {
+		return 0, zerr.ErrBadLayoutVersion
+	}
+
+	return content.Version, nil
+}
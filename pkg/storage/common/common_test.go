@@ -3,9 +3,11 @@ package storage_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	godigest "github.com/opencontainers/go-digest"
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -14,6 +16,7 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 
 	"zotregistry.io/zot/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
 	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/storage"
@@ -417,6 +420,269 @@ func TestGetImageIndexErrors(t *testing.T) {
 	})
 }
 
+func TestDockerManifests(t *testing.T) {
+	Convey("Push and gc docker manifests", t, func(c C) {
+		dir := t.TempDir()
+
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+		cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+			RootDir:     dir,
+			Name:        "cache",
+			UseRelPaths: true,
+		}, log)
+		// gc runs after each manifest push, the untagged manifest would be removed before the list is pushed
+		imgStore := local.NewImageStore(dir, false, storageConstants.DefaultGCDelay, true,
+			true, log, metrics, nil, cacheDriver)
+
+		repo := "docker"
+
+		uploadBlob := func(content []byte) godigest.Digest {
+			digest := godigest.FromBytes(content)
+
+			_, _, err := imgStore.FullBlobUpload(repo, bytes.NewReader(content), digest)
+			So(err, ShouldBeNil)
+
+			return digest
+		}
+
+		cblob, _ := test.GetRandomImageConfig()
+		configDigest := uploadBlob(cblob)
+		layerDigest := uploadBlob([]byte("schema2 layer"))
+		schema1LayerDigest := uploadBlob([]byte("schema1 layer"))
+		orphanDigest := uploadBlob([]byte("orphan layer"))
+
+		manifest := ispec.Manifest{
+			MediaType: zcommon.MediaTypeDockerManifest,
+			Config: ispec.Descriptor{
+				MediaType: zcommon.MediaTypeDockerImageConfig,
+				Digest:    configDigest,
+				Size:      int64(len(cblob)),
+			},
+			Layers: []ispec.Descriptor{
+				{
+					MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
+					Digest:    layerDigest,
+					Size:      int64(len("schema2 layer")),
+				},
+			},
+		}
+		manifest.SchemaVersion = 2
+
+		manifestBlob, err := json.Marshal(manifest)
+		So(err, ShouldBeNil)
+
+		manifestDigest := godigest.FromBytes(manifestBlob)
+
+		// only referenced by the manifest list
+		_, _, err = imgStore.PutImageManifest(repo, manifestDigest.String(), zcommon.MediaTypeDockerManifest,
+			manifestBlob)
+		So(err, ShouldBeNil)
+
+		manifestList := ispec.Index{
+			MediaType: zcommon.MediaTypeDockerManifestList,
+			Manifests: []ispec.Descriptor{
+				{
+					MediaType: zcommon.MediaTypeDockerManifest,
+					Digest:    manifestDigest,
+					Size:      int64(len(manifestBlob)),
+				},
+			},
+		}
+		manifestList.SchemaVersion = 2
+
+		manifestListBlob, err := json.Marshal(manifestList)
+		So(err, ShouldBeNil)
+
+		_, _, err = imgStore.PutImageManifest(repo, "list", zcommon.MediaTypeDockerManifestList, manifestListBlob)
+		So(err, ShouldBeNil)
+
+		schema1Blob, err := json.Marshal(zcommon.Schema1Manifest{
+			SchemaVersion: 1,
+			Name:          repo,
+			Tag:           "schema1",
+			FSLayers: []zcommon.Schema1FSLayer{
+				{BlobSum: schema1LayerDigest},
+				{BlobSum: schema1LayerDigest},
+			},
+		})
+		So(err, ShouldBeNil)
+
+		_, _, err = imgStore.PutImageManifest(repo, "schema1", zcommon.MediaTypeDockerSchema1SignedManifest,
+			schema1Blob)
+		So(err, ShouldBeNil)
+
+		_, _, mediaType, err := imgStore.GetImageManifest(repo, "list")
+		So(err, ShouldBeNil)
+		So(mediaType, ShouldEqual, zcommon.MediaTypeDockerManifestList)
+
+		_, _, mediaType, err = imgStore.GetImageManifest(repo, "schema1")
+		So(err, ShouldBeNil)
+		So(mediaType, ShouldEqual, zcommon.MediaTypeDockerSchema1SignedManifest)
+
+		Convey("Schema1 manifest with missing layers", func() {
+			badSchema1Blob, err := json.Marshal(zcommon.Schema1Manifest{
+				SchemaVersion: 1,
+				FSLayers:      []zcommon.Schema1FSLayer{{BlobSum: godigest.FromString("missing")}},
+			})
+			So(err, ShouldBeNil)
+
+			_, _, err = imgStore.PutImageManifest(repo, "bad", zcommon.MediaTypeDockerSchema1Manifest, badSchema1Blob)
+			So(err, ShouldEqual, errors.ErrBlobNotFound)
+
+			_, _, err = imgStore.PutImageManifest(repo, "bad", zcommon.MediaTypeDockerSchema1Manifest,
+				[]byte(`{"schemaVersion": 2}`))
+			So(err, ShouldEqual, errors.ErrBadManifest)
+		})
+
+		Convey("Gc keeps the blobs of docker manifests", func() {
+			imgStore := local.NewImageStore(dir, true, time.Nanosecond, true,
+				true, log, metrics, nil, cacheDriver)
+
+			err := imgStore.RunGCRepo(repo)
+			So(err, ShouldBeNil)
+
+			for _, digest := range []godigest.Digest{manifestDigest, configDigest, layerDigest, schema1LayerDigest} {
+				ok, _, err := imgStore.CheckBlob(repo, digest)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeTrue)
+			}
+
+			ok, _, _ := imgStore.CheckBlob(repo, orphanDigest)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Scrub docker manifests", func() {
+			results, err := storage.CheckRepo(repo, imgStore)
+			So(err, ShouldBeNil)
+			// the manifest is checked through the list and on its own, since it's also in index.json
+			So(len(results), ShouldEqual, 3)
+
+			for _, result := range results {
+				So(result.Status, ShouldEqual, "ok")
+			}
+		})
+	})
+}
+
+func TestConvertDockerManifest(t *testing.T) {
+	Convey("Convert docker manifests to oci", t, func(c C) {
+		dir := t.TempDir()
+
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+		imgStore := local.NewImageStore(dir, false, storageConstants.DefaultGCDelay, false,
+			true, log, metrics, nil, nil)
+
+		repo := "docker"
+
+		cblob, configDigest := test.GetRandomImageConfig()
+		_, _, err := imgStore.FullBlobUpload(repo, bytes.NewReader(cblob), configDigest)
+		So(err, ShouldBeNil)
+
+		layer := []byte("layer")
+		layerDigest := godigest.FromBytes(layer)
+		_, _, err = imgStore.FullBlobUpload(repo, bytes.NewReader(layer), layerDigest)
+		So(err, ShouldBeNil)
+
+		manifest := ispec.Manifest{
+			MediaType: zcommon.MediaTypeDockerManifest,
+			Config: ispec.Descriptor{
+				MediaType: zcommon.MediaTypeDockerImageConfig,
+				Digest:    configDigest,
+				Size:      int64(len(cblob)),
+			},
+			Layers: []ispec.Descriptor{
+				{
+					MediaType: zcommon.MediaTypeDockerLayerGzip,
+					Digest:    layerDigest,
+					Size:      int64(len(layer)),
+				},
+			},
+		}
+		manifest.SchemaVersion = 2
+
+		manifestBlob, err := json.Marshal(manifest)
+		So(err, ShouldBeNil)
+
+		manifestDigest := godigest.FromBytes(manifestBlob)
+
+		converted, mediaType, err := common.ConvertDockerManifest(imgStore, repo, zcommon.MediaTypeDockerManifest,
+			manifestBlob, log.Logger)
+		So(err, ShouldBeNil)
+		So(mediaType, ShouldEqual, ispec.MediaTypeImageManifest)
+		So(common.GetOriginalDigest(converted), ShouldEqual, manifestDigest)
+
+		var convertedManifest ispec.Manifest
+		err = json.Unmarshal(converted, &convertedManifest)
+		So(err, ShouldBeNil)
+		So(convertedManifest.MediaType, ShouldEqual, ispec.MediaTypeImageManifest)
+		So(convertedManifest.Config.MediaType, ShouldEqual, ispec.MediaTypeImageConfig)
+		So(convertedManifest.Config.Digest, ShouldEqual, configDigest)
+		So(convertedManifest.Layers[0].MediaType, ShouldEqual, ispec.MediaTypeImageLayerGzip)
+		So(convertedManifest.Layers[0].Digest, ShouldEqual, layerDigest)
+
+		convertedDigest, _, err := imgStore.PutImageManifest(repo, godigest.FromBytes(converted).String(), mediaType,
+			converted)
+		So(err, ShouldBeNil)
+
+		// the manifest is found by the digest it was pushed with
+		_, digest, mediaType, err := imgStore.GetImageManifest(repo, manifestDigest.String())
+		So(err, ShouldBeNil)
+		So(digest, ShouldEqual, convertedDigest)
+		So(mediaType, ShouldEqual, ispec.MediaTypeImageManifest)
+
+		manifestList := ispec.Index{
+			MediaType: zcommon.MediaTypeDockerManifestList,
+			Manifests: []ispec.Descriptor{
+				{
+					MediaType: zcommon.MediaTypeDockerManifest,
+					Digest:    manifestDigest,
+					Size:      int64(len(manifestBlob)),
+					Platform:  &ispec.Platform{OS: "linux", Architecture: "amd64"},
+				},
+				{
+					MediaType: zcommon.MediaTypeDockerManifest,
+					Digest:    godigest.FromString("missing"),
+					Size:      1,
+				},
+			},
+		}
+		manifestList.SchemaVersion = 2
+
+		manifestListBlob, err := json.Marshal(manifestList)
+		So(err, ShouldBeNil)
+
+		converted, mediaType, err = common.ConvertDockerManifest(imgStore, repo, zcommon.MediaTypeDockerManifestList,
+			manifestListBlob, log.Logger)
+		So(err, ShouldBeNil)
+		So(mediaType, ShouldEqual, ispec.MediaTypeImageIndex)
+		So(common.GetOriginalDigest(converted), ShouldEqual, godigest.FromBytes(manifestListBlob))
+
+		var convertedIndex ispec.Index
+		err = json.Unmarshal(converted, &convertedIndex)
+		So(err, ShouldBeNil)
+		So(convertedIndex.MediaType, ShouldEqual, ispec.MediaTypeImageIndex)
+		So(convertedIndex.Manifests[0].MediaType, ShouldEqual, ispec.MediaTypeImageManifest)
+		So(convertedIndex.Manifests[0].Digest, ShouldEqual, convertedDigest)
+		So(convertedIndex.Manifests[0].Platform.Architecture, ShouldEqual, "amd64")
+		// manifests which weren't pushed are kept as they are
+		So(convertedIndex.Manifests[1].MediaType, ShouldEqual, zcommon.MediaTypeDockerManifest)
+
+		// other manifests aren't converted
+		converted, mediaType, err = common.ConvertDockerManifest(imgStore, repo, ispec.MediaTypeImageManifest,
+			manifestBlob, log.Logger)
+		So(err, ShouldBeNil)
+		So(mediaType, ShouldEqual, ispec.MediaTypeImageManifest)
+		So(converted, ShouldResemble, manifestBlob)
+		So(common.GetOriginalDigest(converted), ShouldBeEmpty)
+
+		_, _, err = common.ConvertDockerManifest(imgStore, repo, zcommon.MediaTypeDockerManifest,
+			[]byte("invalid"), log.Logger)
+		So(err, ShouldEqual, errors.ErrBadManifest)
+	})
+}
+
 func TestIsSignature(t *testing.T) {
 	Convey("Unknown media type", t, func(c C) {
 		isSingature := common.IsSignature(ispec.Descriptor{
@@ -425,3 +691,99 @@ func TestIsSignature(t *testing.T) {
 		So(isSingature, ShouldBeFalse)
 	})
 }
+
+func TestIsCosignTag(t *testing.T) {
+	Convey("Cosign signatures, SBOMs and attestations tags", t, func(c C) {
+		digest := godigest.FromString("subject")
+
+		for _, suffix := range []string{"sig", "sbom", "att"} {
+			So(common.IsCosignTag(fmt.Sprintf("sha256-%s.%s", digest.Encoded(), suffix)), ShouldBeTrue)
+		}
+
+		So(common.IsCosignTag("sha256-"+digest.Encoded()), ShouldBeFalse)
+		So(common.IsCosignTag("1.0.att"), ShouldBeFalse)
+	})
+}
+
+func TestCompareRepoNames(t *testing.T) {
+	Convey("Repo names are compared path segment by path segment", t, func(c C) {
+		So(common.CompareRepoNames("a", "a"), ShouldEqual, 0)
+		So(common.CompareRepoNames("a", "b"), ShouldBeLessThan, 0)
+		So(common.CompareRepoNames("a", "a/b"), ShouldBeLessThan, 0)
+		So(common.CompareRepoNames("a/b", "a-b"), ShouldBeLessThan, 0)
+		So(common.CompareRepoNames("a/b/c", "a/b"), ShouldBeGreaterThan, 0)
+
+		So(common.IsRepoDirBefore("a", ""), ShouldBeFalse)
+		So(common.IsRepoDirBefore("a", "a/b"), ShouldBeFalse)
+		So(common.IsRepoDirBefore("a/b", "a/b"), ShouldBeFalse)
+		So(common.IsRepoDirBefore("a/a", "a/b"), ShouldBeTrue)
+		So(common.IsRepoDirBefore("a", "a-b"), ShouldBeTrue)
+		So(common.IsRepoDirBefore("b", "a/b"), ShouldBeFalse)
+	})
+}
+
+func TestRunCacheMaintenance(t *testing.T) {
+	log := zerolog.New(os.Stdout)
+
+	Convey("Stores without a maintainable cache are skipped", t, func() {
+		err := common.RunCacheMaintenance(nil, "/", log)
+		So(err, ShouldBeNil)
+
+		cacheDriver := mocks.CacheMock{
+			CheckIntegrityFn: func() (int, error) {
+				return 0, errors.ErrCacheMaintenanceUnsupported
+			},
+			CompactFn: func() (int64, int64, error) {
+				return 0, 0, errors.ErrCacheRootBucket
+			},
+		}
+
+		err = common.RunCacheMaintenance(cacheDriver, "/", log)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Integrity check errors", t, func() {
+		compacted := false
+
+		cacheDriver := mocks.CacheMock{
+			CheckIntegrityFn: func() (int, error) {
+				return 0, errors.ErrCacheRootBucket
+			},
+			CompactFn: func() (int64, int64, error) {
+				compacted = true
+
+				return 0, 0, nil
+			},
+		}
+
+		err := common.RunCacheMaintenance(cacheDriver, "/", log)
+		So(err, ShouldEqual, errors.ErrCacheRootBucket)
+		So(compacted, ShouldBeFalse)
+
+		cacheDriver.CheckIntegrityFn = func() (int, error) {
+			return 2, nil
+		}
+
+		err = common.RunCacheMaintenance(cacheDriver, "/", log)
+		So(err, ShouldEqual, errors.ErrCacheCorrupted)
+		So(compacted, ShouldBeFalse)
+	})
+
+	Convey("Compaction", t, func() {
+		cacheDriver := mocks.CacheMock{
+			CompactFn: func() (int64, int64, error) {
+				return 0, 0, errors.ErrCacheRootBucket
+			},
+		}
+
+		err := common.RunCacheMaintenance(cacheDriver, "/", log)
+		So(err, ShouldEqual, errors.ErrCacheRootBucket)
+
+		cacheDriver.CompactFn = func() (int64, int64, error) {
+			return 65536, 32768, nil
+		}
+
+		err = common.RunCacheMaintenance(cacheDriver, "/", log)
+		So(err, ShouldBeNil)
+	})
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/rs/zerolog"
+
+	zerr "zotregistry.io/zot/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+/*
+ConvertDockerManifest converts a docker schema2 manifest or manifest list to the equivalent oci manifest or index,
+other manifests are returned unchanged. The config and layers keep their digests, only their media types change,
+and the digest the manifest was pushed with is kept in the AnnotationOriginalDigest annotation, so it can still be
+pulled by it. The manifests of a list are replaced by the ones they were converted to, when they were.
+*/
+func ConvertDockerManifest(imgStore storageTypes.ImageStore, repo, mediaType string, body []byte,
+	log zerolog.Logger,
+) ([]byte, string, error) {
+	switch mediaType {
+	case zcommon.MediaTypeDockerManifest:
+		return convertDockerImageManifest(body, log)
+	case zcommon.MediaTypeDockerManifestList:
+		return convertDockerManifestList(imgStore, repo, body, log)
+	default:
+		return body, mediaType, nil
+	}
+}
+
+func convertDockerImageManifest(body []byte, log zerolog.Logger) ([]byte, string, error) {
+	var manifest ispec.Manifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		log.Error().Err(err).Msg("convert: unable to unmarshal docker manifest")
+
+		return nil, "", zerr.ErrBadManifest
+	}
+
+	manifest.MediaType = ispec.MediaTypeImageManifest
+
+	if manifest.Config.MediaType == zcommon.MediaTypeDockerImageConfig {
+		manifest.Config.MediaType = ispec.MediaTypeImageConfig
+	}
+
+	for idx, layer := range manifest.Layers {
+		manifest.Layers[idx].MediaType = zcommon.DockerLayerToOCI(layer.MediaType)
+	}
+
+	manifest.Annotations = withOriginalDigest(manifest.Annotations, body)
+
+	converted, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return converted, ispec.MediaTypeImageManifest, nil
+}
+
+func convertDockerManifestList(imgStore storageTypes.ImageStore, repo string, body []byte, log zerolog.Logger,
+) ([]byte, string, error) {
+	var index ispec.Index
+	if err := json.Unmarshal(body, &index); err != nil {
+		log.Error().Err(err).Msg("convert: unable to unmarshal docker manifest list")
+
+		return nil, "", zerr.ErrBadManifest
+	}
+
+	index.MediaType = ispec.MediaTypeImageIndex
+
+	for idx, desc := range index.Manifests {
+		if desc.MediaType != zcommon.MediaTypeDockerManifest {
+			continue
+		}
+
+		// the manifests of a list are pushed before it, by digest, and were converted then
+		manifestBlob, manifestDigest, manifestMediaType, err := imgStore.GetImageManifest(repo, desc.Digest.String())
+		if err != nil {
+			if errors.Is(err, zerr.ErrManifestNotFound) || errors.Is(err, zerr.ErrRepoNotFound) {
+				log.Debug().Str("repository", repo).Str("digest", desc.Digest.String()).
+					Msg("convert: manifest of docker manifest list not found, keeping it as is")
+
+				continue
+			}
+
+			return nil, "", err
+		}
+
+		index.Manifests[idx].MediaType = manifestMediaType
+		index.Manifests[idx].Digest = manifestDigest
+		index.Manifests[idx].Size = int64(len(manifestBlob))
+	}
+
+	index.Annotations = withOriginalDigest(index.Annotations, body)
+
+	converted, err := json.Marshal(index)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return converted, ispec.MediaTypeImageIndex, nil
+}
+
+func withOriginalDigest(annotations map[string]string, body []byte) map[string]string {
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[zcommon.AnnotationOriginalDigest] = godigest.FromBytes(body).String()
+
+	return annotations
+}
+
+// GetOriginalDigest returns the digest a manifest converted from docker media types was pushed with, if any.
+func GetOriginalDigest(body []byte) godigest.Digest {
+	var content struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+
+	if err := json.Unmarshal(body, &content); err != nil {
+		return ""
+	}
+
+	originalDigest, err := godigest.Parse(content.Annotations[zcommon.AnnotationOriginalDigest])
+	if err != nil {
+		return ""
+	}
+
+	return originalDigest
+}
+
+// SetOriginalDigest keeps the digest a converted manifest was pushed with in its descriptor in index.json,
+// for GetManifestDescByReference to find it by this digest.
+func SetOriginalDigest(desc *ispec.Descriptor, body []byte) {
+	originalDigest := GetOriginalDigest(body)
+	if originalDigest == "" {
+		return
+	}
+
+	if desc.Annotations == nil {
+		desc.Annotations = map[string]string{}
+	}
+
+	desc.Annotations[zcommon.AnnotationOriginalDigest] = originalDigest.String()
+}
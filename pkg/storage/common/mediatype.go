@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/opencontainers/umoci/oci/casext/mediatype"
+
+	zcommon "zotregistry.io/zot/pkg/common"
+)
+
+var registerParsersOnce sync.Once //nolint:gochecknoglobals // the umoci parsers are global and registered once
+
+// schema1Blob is how umoci sees a docker schema1 manifest, only its layers are needed to walk it.
+type schema1Blob struct {
+	Layers []ispec.Descriptor
+}
+
+/*
+RegisterMediaTypeParsers lets umoci parse the docker manifests, otherwise they are leaves of the blob graph
+walked by its gc and their configs and layers are removed.
+The docker schema2 types share the layout of the oci ones, schema1 manifests are reduced to their layers.
+*/
+func RegisterMediaTypeParsers() {
+	registerParsersOnce.Do(func() {
+		mediatype.RegisterTarget(zcommon.MediaTypeDockerManifest)
+		mediatype.RegisterParser(zcommon.MediaTypeDockerManifest, mediatype.CustomJSONParser(ispec.Manifest{}))
+		mediatype.RegisterParser(zcommon.MediaTypeDockerManifestList, mediatype.CustomJSONParser(ispec.Index{}))
+		mediatype.RegisterParser(zcommon.MediaTypeDockerImageConfig, mediatype.CustomJSONParser(ispec.Image{}))
+
+		for _, mediaType := range []string{
+			zcommon.MediaTypeDockerSchema1Manifest, zcommon.MediaTypeDockerSchema1SignedManifest,
+		} {
+			mediatype.RegisterTarget(mediaType)
+			mediatype.RegisterParser(mediaType, parseSchema1Manifest)
+		}
+	})
+}
+
+func parseSchema1Manifest(reader io.Reader) (interface{}, error) {
+	blob := schema1Blob{}
+
+	if reader == nil {
+		return blob, nil
+	}
+
+	var manifest zcommon.Schema1Manifest
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return blob, err
+	}
+
+	for _, layer := range manifest.Layers() {
+		blob.Layers = append(blob.Layers, ispec.Descriptor{Digest: layer})
+	}
+
+	return blob, nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// CheckConsistency runs the consistency check on the image stores which have it enabled,
+// repairing the issues found if the store is configured to do so, otherwise only reporting them.
+func CheckConsistency(config *config.Config, storeController StoreController, log log.Logger) error {
+	type storeCheck struct {
+		imgStore storageTypes.ImageStore
+		repair   bool
+	}
+
+	// substores sharing a root directory share the image store too, check it only once
+	checks := map[string]storeCheck{}
+
+	if config.Storage.ConsistencyCheck && storeController.DefaultStore != nil {
+		checks[storeController.DefaultStore.RootDir()] = storeCheck{
+			imgStore: storeController.DefaultStore,
+			repair:   config.Storage.Repair,
+		}
+	}
+
+	for route, storageConfig := range config.Storage.SubPaths {
+		imgStore, ok := storeController.SubStore[route]
+		if !ok || !storageConfig.ConsistencyCheck {
+			continue
+		}
+
+		checks[imgStore.RootDir()] = storeCheck{imgStore: imgStore, repair: storageConfig.Repair}
+	}
+
+	for rootDir, check := range checks {
+		log.Info().Str("rootDir", rootDir).Bool("repair", check.repair).Msg("checking storage consistency")
+
+		issues, err := check.imgStore.CheckConsistency(check.repair)
+		if err != nil {
+			log.Error().Err(err).Str("rootDir", rootDir).Msg("storage consistency check failed")
+
+			return err
+		}
+
+		for _, issue := range issues {
+			if issue.Repaired {
+				log.Info().Str("rootDir", rootDir).Str("repository", issue.Repo).Str("issue", issue.Issue).
+					Msg("storage consistency issue repaired")
+			} else {
+				log.Warn().Str("rootDir", rootDir).Str("repository", issue.Repo).Str("issue", issue.Issue).
+					Msg("storage consistency issue found")
+			}
+		}
+
+		log.Info().Str("rootDir", rootDir).Int("issues", len(issues)).Msg("storage consistency check done")
+	}
+
+	return nil
+}
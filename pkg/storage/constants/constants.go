@@ -15,10 +15,32 @@ const (
 	BlobsCache              = "blobs"
 	DuplicatesBucket        = "duplicates"
 	OriginalBucket          = "original"
+	GCCheckpointsBucket     = "gcCheckpoints"
 	DBExtensionName         = ".db"
 	DBCacheLockCheckTimeout = 10 * time.Second
 	BoltdbName              = "cache"
 	DynamoDBDriverName      = "dynamodb"
+	RedisDriverName         = "redis"
 	DefaultGCDelay          = 1 * time.Hour
 	S3StorageDriverName     = "s3"
+	GCSStorageDriverName    = "gcs"
+	// commit policies of local storage, i.e. when written files are flushed to disk
+	CommitPolicyNone      = "none"     // never, left to the OS
+	CommitPolicyAlways    = "always"   // every blob, manifest and index write
+	CommitPolicyManifest  = "manifest" // manifest and index writes only
+	CommitPolicyPeriodic  = "periodic" // all files written since the last flush, every commit interval
+	DefaultCommitInterval = 5 * time.Second
+	// readahead hints given to the kernel when reading blobs from local storage
+	ReadAheadNormal     = "normal"
+	ReadAheadSequential = "sequential"
+	ReadAheadRandom     = "random"
+	// how local storage dedupes blobs, reflinks fall back to hard links on filesystems without them
+	DedupeStrategyHardLink = "hardlink"
+	DedupeStrategyReflink  = "reflink"
+	// size from which uploads are large blobs, written with direct IO and preallocation if enabled
+	DefaultLargeBlobSize = 64 * 1024 * 1024
+	// file in the root directory of a store recording the version of its layout
+	LayoutVersionFile = ".zot-layout.json"
+	// directory of the root directory holding the blobs shared by all the repos, with the shared blobs layout
+	SharedBlobsDir = "_blobs"
 )
@@ -0,0 +1,786 @@
+/*
+Package gcs implements a storage driver backed by a Google Cloud Storage bucket, so that the object storage
+image store can keep images in gcs the same way as in s3, including dedupe through the cache driver.
+
+The driver talks to the gcs JSON API, blob uploads are mapped to gcs resumable upload sessions, see writer.go.
+*/
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	storagedriver "github.com/docker/distribution/registry/storage/driver"
+	"github.com/docker/distribution/registry/storage/driver/base"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/storage/s3"
+)
+
+const (
+	DriverName      = "gcs"
+	DefaultEndpoint = "https://storage.googleapis.com"
+	// gcs rejects resumable upload chunks which are not a multiple of 256KiB, except for the last one.
+	ChunkGranularity = 256 * 1024
+
+	readWriteScope = "https://www.googleapis.com/auth/devstorage.read_write"
+	// content type of the objects holding the state of incomplete uploads
+	uploadSessionContentType = "application/x-zot-upload-session"
+	sessionURIMetadata       = "session-uri"
+	offsetMetadata           = "offset"
+	defaultContentType       = "application/octet-stream"
+
+	listPageSize   = 1000
+	maxTries       = 5
+	initialBackoff = 100 * time.Millisecond
+)
+
+// DriverParameters configures the driver, requests to Endpoint are sent with Client which authenticates them.
+type DriverParameters struct {
+	Bucket        string
+	RootDirectory string
+	ChunkSize     int64
+	Endpoint      string
+	Client        *http.Client
+}
+
+type driver struct {
+	bucket string
+	// prefix of the object keys, without leading or trailing slash
+	rootDirectory string
+	chunkSize     int64
+	endpoint      string
+	client        *http.Client
+}
+
+type baseEmbed struct {
+	base.Base
+}
+
+// Driver is a storage driver backed by a gcs bucket.
+type Driver struct {
+	baseEmbed
+}
+
+/*
+FromParameters creates a driver from the storage driver config:
+  - bucket: the bucket name, required
+  - rootdirectory: the prefix of the object keys
+  - chunksize: the size of the resumable upload chunks, a multiple of 256KiB, shared with the multipart config
+  - endpoint: the gcs endpoint, e.g. of an emulator
+  - keyfile: a service account key file, by default the application default credentials are used,
+    i.e. the workload identity of the pod on GKE
+  - anonymous: send unauthenticated requests, e.g. to an emulator.
+*/
+func FromParameters(parameters map[string]interface{}) (*Driver, error) {
+	bucket := getStringParameter(parameters, "bucket")
+	if bucket == "" {
+		return nil, fmt.Errorf("%w: gcs storage driver bucket is required", zerr.ErrBadConfig)
+	}
+
+	multipart, err := s3.GetMultipartConfig(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	anonymous, err := getBoolParameter(parameters, "anonymous")
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{}
+
+	if !anonymous {
+		client, err = newClient(getStringParameter(parameters, "keyfile"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return New(DriverParameters{
+		Bucket:        bucket,
+		RootDirectory: getStringParameter(parameters, "rootdirectory"),
+		ChunkSize:     multipart.PartSize,
+		Endpoint:      getStringParameter(parameters, "endpoint"),
+		Client:        client,
+	})
+}
+
+// New creates a driver, the chunk size and endpoint are optional.
+func New(params DriverParameters) (*Driver, error) {
+	if params.Bucket == "" {
+		return nil, fmt.Errorf("%w: gcs storage driver bucket is required", zerr.ErrBadConfig)
+	}
+
+	if params.ChunkSize == 0 {
+		params.ChunkSize = s3.DefaultMultipartPartSize
+	}
+
+	if params.ChunkSize < ChunkGranularity || params.ChunkSize%ChunkGranularity != 0 {
+		return nil, fmt.Errorf("%w: gcs storage driver chunksize must be a multiple of %d bytes",
+			zerr.ErrBadConfig, ChunkGranularity)
+	}
+
+	if params.Endpoint == "" {
+		params.Endpoint = DefaultEndpoint
+	}
+
+	if params.Client == nil {
+		params.Client = http.DefaultClient
+	}
+
+	return &Driver{
+		baseEmbed: baseEmbed{
+			Base: base.Base{
+				StorageDriver: &driver{
+					bucket:        params.Bucket,
+					rootDirectory: strings.Trim(params.RootDirectory, "/"),
+					chunkSize:     params.ChunkSize,
+					endpoint:      strings.TrimSuffix(params.Endpoint, "/"),
+					client:        params.Client,
+				},
+			},
+		},
+	}, nil
+}
+
+func newClient(keyFile string) (*http.Client, error) {
+	ctx := context.Background()
+
+	var (
+		creds *google.Credentials
+		err   error
+	)
+
+	if keyFile != "" {
+		var key []byte
+
+		key, err = os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		creds, err = google.CredentialsFromJSON(ctx, key, readWriteScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, readWriteScope)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return oauth2.NewClient(ctx, creds.TokenSource), nil
+}
+
+func getStringParameter(parameters map[string]interface{}, name string) string {
+	if value, ok := parameters[name]; ok && value != nil {
+		return fmt.Sprint(value)
+	}
+
+	return ""
+}
+
+func getBoolParameter(parameters map[string]interface{}, name string) (bool, error) {
+	switch value := parameters[name].(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return value, nil
+	case string:
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("%w: storage driver %s must be a boolean", zerr.ErrBadConfig, name)
+		}
+
+		return boolValue, nil
+	default:
+		return false, fmt.Errorf("%w: storage driver %s must be a boolean", zerr.ErrBadConfig, name)
+	}
+}
+
+// apiError is a gcs response with an unexpected status.
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (err *apiError) Error() string {
+	return fmt.Sprintf("%s: status %d: %s", zerr.ErrGCSRequestFailed, err.StatusCode, err.Message)
+}
+
+func (err *apiError) Unwrap() error {
+	return zerr.ErrGCSRequestFailed
+}
+
+func newAPIError(resp *http.Response) *apiError {
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096)) //nolint:gomnd
+
+	var errResponse struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	message := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &errResponse); err == nil && errResponse.Error.Message != "" {
+		message = errResponse.Error.Message
+	}
+
+	return &apiError{StatusCode: resp.StatusCode, Message: message}
+}
+
+func isNotFound(err error) bool {
+	var apiErr *apiError
+
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+func isRetryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+/*
+do sends a request and returns its response if it has one of the expected statuses, otherwise the response
+is closed and returned as an *apiError. Requests failing with rate limiting, server or network errors are
+retried with an exponential backoff, which is why the body is given as bytes.
+*/
+func (d *driver) do(ctx context.Context, method, rawURL string, header http.Header, body []byte,
+	expected ...int,
+) (*http.Response, error) {
+	var lastErr error
+
+	for try := 0; try < maxTries; try++ {
+		if try > 0 {
+			select {
+			case <-time.After(initialBackoff << (try - 1)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		for name, values := range header {
+			req.Header[name] = values
+		}
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			lastErr = err
+
+			continue
+		}
+
+		for _, status := range expected {
+			if resp.StatusCode == status {
+				return resp, nil
+			}
+		}
+
+		apiErr := newAPIError(resp)
+		if !isRetryable(resp.StatusCode) {
+			return nil, apiErr
+		}
+
+		lastErr = apiErr
+	}
+
+	return nil, lastErr
+}
+
+func (d *driver) key(filePath string) string {
+	return strings.TrimLeft(path.Join("/", d.rootDirectory, filePath), "/")
+}
+
+// prefix returns the prefix of the keys of the objects under a directory.
+func (d *driver) prefix(dirPath string) string {
+	if key := d.key(dirPath); key != "" {
+		return key + "/"
+	}
+
+	return ""
+}
+
+func (d *driver) path(key string) string {
+	return "/" + strings.TrimLeft(strings.TrimPrefix(key, d.rootDirectory), "/")
+}
+
+func (d *driver) objectsURL() string {
+	return fmt.Sprintf("%s/storage/v1/b/%s/o", d.endpoint, url.PathEscape(d.bucket))
+}
+
+func (d *driver) objectURL(key string) string {
+	return d.objectsURL() + "/" + url.PathEscape(key)
+}
+
+func (d *driver) uploadURL() string {
+	return fmt.Sprintf("%s/upload/storage/v1/b/%s/o", d.endpoint, url.PathEscape(d.bucket))
+}
+
+// object is the gcs object resource, only with the fields used by the driver.
+type object struct {
+	Name        string            `json:"name"`
+	Size        int64             `json:"size,string"`
+	Updated     time.Time         `json:"updated"`
+	ContentType string            `json:"contentType"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+// objectMetadata is the metadata of an uploaded object.
+type objectMetadata struct {
+	Name        string            `json:"name"`
+	ContentType string            `json:"contentType"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type objectList struct {
+	Items         []object `json:"items"`
+	Prefixes      []string `json:"prefixes"`
+	NextPageToken string   `json:"nextPageToken"`
+}
+
+func decodeResponse(resp *http.Response, value interface{}) error {
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(value)
+}
+
+func (d *driver) getObject(ctx context.Context, key string) (object, error) {
+	var obj object
+
+	resp, err := d.do(ctx, http.MethodGet, d.objectURL(key), nil, nil, http.StatusOK)
+	if err != nil {
+		return obj, err
+	}
+
+	err = decodeResponse(resp, &obj)
+
+	return obj, err
+}
+
+// insert uploads an object in a single multipart request.
+func (d *driver) insert(ctx context.Context, key, contentType string, metadata map[string]string,
+	content []byte,
+) error {
+	meta, err := json.Marshal(objectMetadata{Name: key, ContentType: contentType, Metadata: metadata})
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+
+	parts := multipart.NewWriter(&body)
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"application/json; charset=UTF-8", meta},
+		{contentType, content},
+	} {
+		partWriter, err := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return err
+		}
+
+		if _, err := partWriter.Write(part.content); err != nil {
+			return err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return err
+	}
+
+	header := http.Header{"Content-Type": {"multipart/related; boundary=" + parts.Boundary()}}
+
+	resp, err := d.do(ctx, http.MethodPost, d.uploadURL()+"?uploadType=multipart", header, body.Bytes(),
+		http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func (d *driver) list(ctx context.Context, prefix, delimiter, pageToken string, maxResults int,
+) (objectList, error) {
+	var objects objectList
+
+	query := url.Values{}
+	query.Set("prefix", prefix)
+	query.Set("maxResults", strconv.Itoa(maxResults))
+
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+
+	if pageToken != "" {
+		query.Set("pageToken", pageToken)
+	}
+
+	resp, err := d.do(ctx, http.MethodGet, d.objectsURL()+"?"+query.Encode(), nil, nil, http.StatusOK)
+	if err != nil {
+		return objects, err
+	}
+
+	err = decodeResponse(resp, &objects)
+
+	return objects, err
+}
+
+func (d *driver) deleteObject(ctx context.Context, key string) error {
+	resp, err := d.do(ctx, http.MethodDelete, d.objectURL(key), nil, nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func (d *driver) fileInfo(obj object) storagedriver.FileInfo {
+	size := obj.Size
+
+	// the size of an incomplete upload includes the data already sent to its session
+	if obj.ContentType == uploadSessionContentType {
+		offset, _ := strconv.ParseInt(obj.Metadata[offsetMetadata], 10, 64)
+		size += offset
+	}
+
+	return storagedriver.FileInfoInternal{FileInfoFields: storagedriver.FileInfoFields{
+		Path:    d.path(obj.Name),
+		Size:    size,
+		ModTime: obj.Updated,
+	}}
+}
+
+func dirInfo(dirPath string) storagedriver.FileInfo {
+	return storagedriver.FileInfoInternal{FileInfoFields: storagedriver.FileInfoFields{
+		Path:  dirPath,
+		IsDir: true,
+	}}
+}
+
+// Name returns the driver name.
+func (d *driver) Name() string {
+	return DriverName
+}
+
+// GetContent returns the content of a file.
+func (d *driver) GetContent(ctx context.Context, filePath string) ([]byte, error) {
+	reader, err := d.Reader(ctx, filePath, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// PutContent writes a file in a single request.
+func (d *driver) PutContent(ctx context.Context, filePath string, content []byte) error {
+	return d.insert(ctx, d.key(filePath), defaultContentType, nil, content)
+}
+
+// Reader returns a reader of a file starting at offset, the content of incomplete uploads is not readable.
+func (d *driver) Reader(ctx context.Context, filePath string, offset int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, storagedriver.InvalidOffsetError{Path: filePath, Offset: offset, DriverName: DriverName}
+	}
+
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := d.do(ctx, http.MethodGet, d.objectURL(d.key(filePath))+"?alt=media", header, nil,
+		http.StatusOK, http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, storagedriver.PathNotFoundError{Path: filePath, DriverName: DriverName}
+		}
+
+		return nil, err
+	}
+
+	if resp.Header.Get("Content-Type") == uploadSessionContentType {
+		resp.Body.Close()
+
+		return nil, storagedriver.PathNotFoundError{Path: filePath, DriverName: DriverName}
+	}
+
+	// reading from the end of the file
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		resp.Body.Close()
+
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	return resp.Body, nil
+}
+
+// Writer returns a writer of a file, with append set it resumes the incomplete upload of the file.
+func (d *driver) Writer(ctx context.Context, filePath string, append bool) (storagedriver.FileWriter, error) {
+	if !append {
+		return newWriter(ctx, d, filePath), nil
+	}
+
+	return resumeWriter(ctx, d, filePath)
+}
+
+// Stat returns the info of a file, or of a directory if some objects have its path as prefix.
+func (d *driver) Stat(ctx context.Context, filePath string) (storagedriver.FileInfo, error) {
+	if key := d.key(filePath); key != "" {
+		obj, err := d.getObject(ctx, key)
+		if err == nil {
+			return d.fileInfo(obj), nil
+		}
+
+		if !isNotFound(err) {
+			return nil, err
+		}
+	}
+
+	objects, err := d.list(ctx, d.prefix(filePath), "/", "", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objects.Items) == 0 && len(objects.Prefixes) == 0 {
+		return nil, storagedriver.PathNotFoundError{Path: filePath, DriverName: DriverName}
+	}
+
+	return dirInfo(filePath), nil
+}
+
+// List returns the paths of the files and directories directly under a directory.
+func (d *driver) List(ctx context.Context, dirPath string) ([]string, error) {
+	prefix := d.prefix(dirPath)
+	children := []string{}
+	pageToken := ""
+
+	for {
+		objects, err := d.list(ctx, prefix, "/", pageToken, listPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range objects.Items {
+			// placeholder objects created for directories by other tools
+			if strings.HasSuffix(obj.Name, "/") {
+				continue
+			}
+
+			children = append(children, d.path(obj.Name))
+		}
+
+		for _, subPrefix := range objects.Prefixes {
+			children = append(children, d.path(strings.TrimSuffix(subPrefix, "/")))
+		}
+
+		if objects.NextPageToken == "" {
+			break
+		}
+
+		pageToken = objects.NextPageToken
+	}
+
+	if len(children) == 0 && dirPath != "/" {
+		return nil, storagedriver.PathNotFoundError{Path: dirPath, DriverName: DriverName}
+	}
+
+	return children, nil
+}
+
+// Move copies a file with a rewrite, which gcs may split in several calls for large files, then deletes it.
+func (d *driver) Move(ctx context.Context, sourcePath string, destPath string) error {
+	srcKey := d.key(sourcePath)
+	rewriteURL := fmt.Sprintf("%s/rewriteTo/b/%s/o/%s", d.objectURL(srcKey), url.PathEscape(d.bucket),
+		url.PathEscape(d.key(destPath)))
+	rewriteToken := ""
+
+	for {
+		callURL := rewriteURL
+		if rewriteToken != "" {
+			callURL += "?rewriteToken=" + url.QueryEscape(rewriteToken)
+		}
+
+		resp, err := d.do(ctx, http.MethodPost, callURL, nil, nil, http.StatusOK)
+		if err != nil {
+			if isNotFound(err) {
+				return storagedriver.PathNotFoundError{Path: sourcePath, DriverName: DriverName}
+			}
+
+			return err
+		}
+
+		var rewrite struct {
+			Done         bool   `json:"done"`
+			RewriteToken string `json:"rewriteToken"`
+		}
+
+		if err := decodeResponse(resp, &rewrite); err != nil {
+			return err
+		}
+
+		if rewrite.Done {
+			break
+		}
+
+		if rewrite.RewriteToken == "" {
+			return fmt.Errorf("%w: rewrite of %s returned no token", zerr.ErrGCSRequestFailed, sourcePath)
+		}
+
+		rewriteToken = rewrite.RewriteToken
+	}
+
+	return d.deleteObject(ctx, srcKey)
+}
+
+// Delete deletes a file, or a directory with all the objects under it.
+func (d *driver) Delete(ctx context.Context, filePath string) error {
+	deleted := false
+
+	if key := d.key(filePath); key != "" {
+		err := d.deleteObject(ctx, key)
+		if err == nil {
+			deleted = true
+		} else if !isNotFound(err) {
+			return err
+		}
+	}
+
+	prefix := d.prefix(filePath)
+	pageToken := ""
+
+	for {
+		objects, err := d.list(ctx, prefix, "", pageToken, listPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range objects.Items {
+			// deleted concurrently
+			if err := d.deleteObject(ctx, obj.Name); err != nil && !isNotFound(err) {
+				return err
+			}
+
+			deleted = true
+		}
+
+		if objects.NextPageToken == "" {
+			break
+		}
+
+		pageToken = objects.NextPageToken
+	}
+
+	if !deleted {
+		return storagedriver.PathNotFoundError{Path: filePath, DriverName: DriverName}
+	}
+
+	return nil
+}
+
+// URLFor is not supported, blobs are served by zot.
+func (d *driver) URLFor(ctx context.Context, filePath string, options map[string]interface{}) (string, error) {
+	return "", storagedriver.ErrUnsupportedMethod{DriverName: DriverName}
+}
+
+/*
+Walk calls walkFn for the files and directories under a directory, listing all the objects under it page by
+page instead of listing each directory. Directories are walked before the first file under them.
+If walkFn returns ErrSkipDir for a directory the files under it are skipped, for a file the walk stops.
+*/
+func (d *driver) Walk(ctx context.Context, from string, walkFn storagedriver.WalkFn) error {
+	parent := strings.TrimSuffix(from, "/")
+	walked := map[string]bool{}
+	skipped := map[string]bool{}
+	found := false
+	pageToken := ""
+
+	for {
+		objects, err := d.list(ctx, d.prefix(from), "", pageToken, listPageSize)
+		if err != nil {
+			return err
+		}
+
+	objectsLoop:
+		for _, obj := range objects.Items {
+			found = true
+
+			if strings.HasSuffix(obj.Name, "/") {
+				continue
+			}
+
+			info := d.fileInfo(obj)
+			dirs := strings.Split(strings.TrimPrefix(info.Path(), parent+"/"), "/")
+			dirPath := parent
+
+			for _, dir := range dirs[:len(dirs)-1] {
+				dirPath += "/" + dir
+
+				if skipped[dirPath] {
+					continue objectsLoop
+				}
+
+				if walked[dirPath] {
+					continue
+				}
+
+				walked[dirPath] = true
+
+				if err := walkFn(dirInfo(dirPath)); err != nil {
+					if errors.Is(err, storagedriver.ErrSkipDir) {
+						skipped[dirPath] = true
+
+						continue objectsLoop
+					}
+
+					return err
+				}
+			}
+
+			if err := walkFn(info); err != nil {
+				if errors.Is(err, storagedriver.ErrSkipDir) {
+					return nil
+				}
+
+				return err
+			}
+		}
+
+		if objects.NextPageToken == "" {
+			break
+		}
+
+		pageToken = objects.NextPageToken
+	}
+
+	if !found {
+		return storagedriver.PathNotFoundError{Path: from, DriverName: DriverName}
+	}
+
+	return nil
+}
@@ -0,0 +1,819 @@
+package gcs_test
+
+import (
+	"bytes"
+	"context"
+	_ "crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/registry/storage/driver"
+	godigest "github.com/opencontainers/go-digest"
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage"
+	"zotregistry.io/zot/pkg/storage/cache"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/gcs"
+	"zotregistry.io/zot/pkg/storage/s3"
+)
+
+const (
+	testBucket = "zot-test"
+	chunkSize  = gcs.ChunkGranularity
+)
+
+var contentRangeRegexp = regexp.MustCompile(`^bytes (\*|(\d+)-(\d+))/(\*|\d+)$`)
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	metadata    map[string]string
+	updated     time.Time
+}
+
+type fakeSession struct {
+	name string
+	data []byte
+}
+
+// fakeGCS implements the parts of the gcs JSON API used by the driver, on top of an in-memory bucket.
+type fakeGCS struct {
+	server   *httptest.Server
+	lock     sync.Mutex
+	objects  map[string]*fakeObject
+	sessions map[string]*fakeSession
+	// number of requests to answer with a server error
+	failures  int
+	cancelled int
+}
+
+func newFakeGCS() *fakeGCS {
+	fake := &fakeGCS{objects: map[string]*fakeObject{}, sessions: map[string]*fakeSession{}}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serve))
+
+	return fake
+}
+
+func (fake *fakeGCS) object(key string) *fakeObject {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	return fake.objects[key]
+}
+
+func (fake *fakeGCS) serve(response http.ResponseWriter, request *http.Request) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	if fake.failures > 0 {
+		fake.failures--
+		http.Error(response, `{"error":{"message":"backend error"}}`, http.StatusServiceUnavailable)
+
+		return
+	}
+
+	objectsPath := "/storage/v1/b/" + testBucket + "/o"
+	uploadPath := "/upload/storage/v1/b/" + testBucket + "/o"
+	escapedPath := request.URL.EscapedPath()
+
+	switch {
+	case escapedPath == objectsPath && request.Method == http.MethodGet:
+		fake.list(response, request)
+	case escapedPath == uploadPath && request.URL.Query().Get("uploadType") == "multipart":
+		fake.insert(response, request)
+	case escapedPath == uploadPath && request.URL.Query().Get("uploadType") == "resumable":
+		fake.startSession(response, request)
+	case strings.HasPrefix(escapedPath, "/upload/session/"):
+		fake.session(response, request, strings.TrimPrefix(escapedPath, "/upload/session/"))
+	case strings.HasPrefix(escapedPath, objectsPath+"/"):
+		names := strings.Split(strings.TrimPrefix(escapedPath, objectsPath+"/"), "/")
+		key, _ := url.PathUnescape(names[0])
+
+		if len(names) == 6 && names[1] == "rewriteTo" {
+			dst, _ := url.PathUnescape(names[5])
+			fake.rewrite(response, request, key, dst)
+
+			return
+		}
+
+		fake.serveObject(response, request, key)
+	default:
+		http.NotFound(response, request)
+	}
+}
+
+func (fake *fakeGCS) objectResource(key string, obj *fakeObject) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        key,
+		"size":        strconv.Itoa(len(obj.data)),
+		"updated":     obj.updated.Format(time.RFC3339Nano),
+		"contentType": obj.contentType,
+		"metadata":    obj.metadata,
+	}
+}
+
+func (fake *fakeGCS) list(response http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	maxResults, _ := strconv.Atoi(query.Get("maxResults"))
+	start, _ := strconv.Atoi(query.Get("pageToken"))
+
+	keys := []string{}
+
+	for key := range fake.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	items := []map[string]interface{}{}
+	prefixes := []string{}
+	seenPrefixes := map[string]bool{}
+	nextPageToken := ""
+
+	for index := start; index < len(keys); index++ {
+		if len(items)+len(prefixes) == maxResults {
+			nextPageToken = strconv.Itoa(index)
+
+			break
+		}
+
+		key := keys[index]
+
+		if delimiter != "" {
+			if end := strings.Index(key[len(prefix):], delimiter); end >= 0 {
+				subPrefix := key[:len(prefix)+end+1]
+				if !seenPrefixes[subPrefix] {
+					seenPrefixes[subPrefix] = true
+					prefixes = append(prefixes, subPrefix)
+				}
+
+				continue
+			}
+		}
+
+		items = append(items, fake.objectResource(key, fake.objects[key]))
+	}
+
+	writeJSON(response, map[string]interface{}{
+		"items":         items,
+		"prefixes":      prefixes,
+		"nextPageToken": nextPageToken,
+	})
+}
+
+func (fake *fakeGCS) insert(response http.ResponseWriter, request *http.Request) {
+	_, params, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	reader := multipart.NewReader(request.Body, params["boundary"])
+
+	var meta struct {
+		Name        string            `json:"name"`
+		ContentType string            `json:"contentType"`
+		Metadata    map[string]string `json:"metadata"`
+	}
+
+	part, err := reader.NextPart()
+	if err == nil {
+		err = json.NewDecoder(part).Decode(&meta)
+	}
+
+	if err == nil {
+		part, err = reader.NextPart()
+	}
+
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	data, _ := io.ReadAll(part)
+	obj := &fakeObject{data: data, contentType: meta.ContentType, metadata: meta.Metadata, updated: time.Now()}
+	fake.objects[meta.Name] = obj
+
+	writeJSON(response, fake.objectResource(meta.Name, obj))
+}
+
+func (fake *fakeGCS) startSession(response http.ResponseWriter, request *http.Request) {
+	var meta struct {
+		Name string `json:"name"`
+	}
+
+	if err := json.NewDecoder(request.Body).Decode(&meta); err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	sessionID := strconv.Itoa(len(fake.sessions))
+	fake.sessions[sessionID] = &fakeSession{name: meta.Name}
+
+	response.Header().Set("Location", fake.server.URL+"/upload/session/"+sessionID)
+	response.WriteHeader(http.StatusOK)
+}
+
+func (fake *fakeGCS) session(response http.ResponseWriter, request *http.Request, sessionID string) {
+	session, ok := fake.sessions[sessionID]
+	if !ok {
+		http.NotFound(response, request)
+
+		return
+	}
+
+	if request.Method == http.MethodDelete {
+		delete(fake.sessions, sessionID)
+		fake.cancelled++
+
+		response.WriteHeader(499) //nolint:gomnd
+
+		return
+	}
+
+	match := contentRangeRegexp.FindStringSubmatch(request.Header.Get("Content-Range"))
+	if match == nil {
+		http.Error(response, "invalid content range", http.StatusBadRequest)
+
+		return
+	}
+
+	data, _ := io.ReadAll(request.Body)
+
+	if match[1] != "*" {
+		start, _ := strconv.Atoi(match[2])
+		end, _ := strconv.Atoi(match[3])
+
+		if start != len(session.data) || end-start+1 != len(data) {
+			http.Error(response, "invalid chunk range", http.StatusBadRequest)
+
+			return
+		}
+
+		if match[4] == "*" && len(data)%gcs.ChunkGranularity != 0 {
+			http.Error(response, "chunk is not a multiple of 256KiB", http.StatusBadRequest)
+
+			return
+		}
+
+		session.data = append(session.data, data...)
+	}
+
+	if match[4] == "*" {
+		response.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(session.data)-1))
+		response.WriteHeader(http.StatusPermanentRedirect)
+
+		return
+	}
+
+	if total, _ := strconv.Atoi(match[4]); total != len(session.data) {
+		http.Error(response, "invalid total size", http.StatusBadRequest)
+
+		return
+	}
+
+	obj := &fakeObject{data: session.data, contentType: "application/octet-stream", updated: time.Now()}
+	fake.objects[session.name] = obj
+	delete(fake.sessions, sessionID)
+
+	writeJSON(response, fake.objectResource(session.name, obj))
+}
+
+func (fake *fakeGCS) rewrite(response http.ResponseWriter, request *http.Request, src, dst string) {
+	obj, ok := fake.objects[src]
+	if !ok {
+		http.NotFound(response, request)
+
+		return
+	}
+
+	// large objects are rewritten in several calls
+	if request.URL.Query().Get("rewriteToken") == "" {
+		writeJSON(response, map[string]interface{}{"done": false, "rewriteToken": "token"})
+
+		return
+	}
+
+	fake.objects[dst] = &fakeObject{data: obj.data, contentType: obj.contentType, updated: time.Now()}
+
+	writeJSON(response, map[string]interface{}{"done": true})
+}
+
+func (fake *fakeGCS) serveObject(response http.ResponseWriter, request *http.Request, key string) {
+	obj, ok := fake.objects[key]
+	if !ok {
+		http.NotFound(response, request)
+
+		return
+	}
+
+	switch {
+	case request.Method == http.MethodDelete:
+		delete(fake.objects, key)
+		response.WriteHeader(http.StatusNoContent)
+	case request.URL.Query().Get("alt") == "media":
+		response.Header().Set("Content-Type", obj.contentType)
+
+		offset := 0
+		if rangeHeader := request.Header.Get("Range"); rangeHeader != "" {
+			offset, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"))
+			if offset >= len(obj.data) {
+				response.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+
+				return
+			}
+
+			response.WriteHeader(http.StatusPartialContent)
+		}
+
+		_, _ = response.Write(obj.data[offset:])
+	default:
+		writeJSON(response, fake.objectResource(key, obj))
+	}
+}
+
+func writeJSON(response http.ResponseWriter, value interface{}) {
+	response.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(response).Encode(value)
+}
+
+func newDriver(fake *fakeGCS, rootDirectory string) *gcs.Driver {
+	store, err := gcs.New(gcs.DriverParameters{
+		Bucket:        testBucket,
+		RootDirectory: rootDirectory,
+		ChunkSize:     chunkSize,
+		Endpoint:      fake.server.URL,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func TestDriverParameters(t *testing.T) {
+	Convey("Invalid driver parameters are rejected", t, func() {
+		_, err := gcs.New(gcs.DriverParameters{})
+		So(errors.Is(err, zerr.ErrBadConfig), ShouldBeTrue)
+
+		_, err = gcs.New(gcs.DriverParameters{Bucket: testBucket, ChunkSize: gcs.ChunkGranularity + 1})
+		So(errors.Is(err, zerr.ErrBadConfig), ShouldBeTrue)
+
+		_, err = gcs.FromParameters(map[string]interface{}{"name": "gcs"})
+		So(errors.Is(err, zerr.ErrBadConfig), ShouldBeTrue)
+
+		_, err = gcs.FromParameters(map[string]interface{}{"name": "gcs", "bucket": testBucket, "anonymous": "maybe"})
+		So(errors.Is(err, zerr.ErrBadConfig), ShouldBeTrue)
+
+		_, err = gcs.FromParameters(map[string]interface{}{"name": "gcs", "bucket": testBucket, "chunksize": 1024})
+		So(errors.Is(err, zerr.ErrBadConfig), ShouldBeTrue)
+
+		_, err = gcs.FromParameters(map[string]interface{}{
+			"name": "gcs", "bucket": testBucket, "keyfile": path.Join(t.TempDir(), "missing.json"),
+		})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Anonymous driver from the storage driver config", t, func() {
+		fake := newFakeGCS()
+		defer fake.server.Close()
+
+		store, err := gcs.FromParameters(map[string]interface{}{
+			"name":          "gcs",
+			"bucket":        testBucket,
+			"rootdirectory": "/zot",
+			"endpoint":      fake.server.URL,
+			"anonymous":     true,
+		})
+		So(err, ShouldBeNil)
+		So(store.Name(), ShouldEqual, gcs.DriverName)
+
+		err = store.PutContent(context.Background(), "/repo/index.json", []byte("{}"))
+		So(err, ShouldBeNil)
+		So(fake.object("zot/repo/index.json"), ShouldNotBeNil)
+	})
+}
+
+func TestDriver(t *testing.T) {
+	Convey("Driver operations", t, func() {
+		fake := newFakeGCS()
+		defer fake.server.Close()
+
+		ctx := context.Background()
+		store := newDriver(fake, "/zot")
+
+		So(store.PutContent(ctx, "/repo/index.json", []byte("index")), ShouldBeNil)
+		So(store.PutContent(ctx, "/repo/blobs/sha256/a", []byte("blob a")), ShouldBeNil)
+		So(store.PutContent(ctx, "/repo/blobs/sha256/b", []byte{}), ShouldBeNil)
+		So(store.PutContent(ctx, "/other/index.json", []byte("other")), ShouldBeNil)
+
+		Convey("Read files", func() {
+			content, err := store.GetContent(ctx, "/repo/index.json")
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "index")
+
+			reader, err := store.Reader(ctx, "/repo/blobs/sha256/a", 5)
+			So(err, ShouldBeNil)
+			content, err = io.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "a")
+			So(reader.Close(), ShouldBeNil)
+
+			reader, err = store.Reader(ctx, "/repo/blobs/sha256/a", 6)
+			So(err, ShouldBeNil)
+			content, err = io.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(content, ShouldBeEmpty)
+
+			_, err = store.Reader(ctx, "/repo/blobs/sha256/a", -1)
+			So(err, ShouldHaveSameTypeAs, driver.InvalidOffsetError{})
+
+			_, err = store.GetContent(ctx, "/repo/missing")
+			So(err, ShouldHaveSameTypeAs, driver.PathNotFoundError{})
+		})
+
+		Convey("Stat files and directories", func() {
+			info, err := store.Stat(ctx, "/repo/blobs/sha256/a")
+			So(err, ShouldBeNil)
+			So(info.IsDir(), ShouldBeFalse)
+			So(info.Size(), ShouldEqual, 6)
+			So(info.Path(), ShouldEqual, "/repo/blobs/sha256/a")
+
+			info, err = store.Stat(ctx, "/repo/blobs")
+			So(err, ShouldBeNil)
+			So(info.IsDir(), ShouldBeTrue)
+
+			info, err = store.Stat(ctx, "/")
+			So(err, ShouldBeNil)
+			So(info.IsDir(), ShouldBeTrue)
+
+			_, err = store.Stat(ctx, "/missing")
+			So(err, ShouldHaveSameTypeAs, driver.PathNotFoundError{})
+		})
+
+		Convey("List directories", func() {
+			children, err := store.List(ctx, "/")
+			So(err, ShouldBeNil)
+			So(children, ShouldResemble, []string{"/other", "/repo"})
+
+			children, err = store.List(ctx, "/repo")
+			So(err, ShouldBeNil)
+			So(children, ShouldResemble, []string{"/repo/index.json", "/repo/blobs"})
+
+			_, err = store.List(ctx, "/missing")
+			So(err, ShouldHaveSameTypeAs, driver.PathNotFoundError{})
+
+			store = newDriver(fake, "/empty")
+			children, err = store.List(ctx, "/")
+			So(err, ShouldBeNil)
+			So(children, ShouldBeEmpty)
+		})
+
+		Convey("Move files", func() {
+			So(store.Move(ctx, "/repo/blobs/sha256/a", "/repo/blobs/sha256/c"), ShouldBeNil)
+			So(fake.object("zot/repo/blobs/sha256/a"), ShouldBeNil)
+			So(string(fake.object("zot/repo/blobs/sha256/c").data), ShouldEqual, "blob a")
+
+			err := store.Move(ctx, "/repo/blobs/sha256/a", "/repo/blobs/sha256/c")
+			So(err, ShouldHaveSameTypeAs, driver.PathNotFoundError{})
+		})
+
+		Convey("Delete files and directories", func() {
+			So(store.Delete(ctx, "/repo/index.json"), ShouldBeNil)
+			So(fake.object("zot/repo/index.json"), ShouldBeNil)
+
+			So(store.Delete(ctx, "/repo"), ShouldBeNil)
+			So(fake.object("zot/repo/blobs/sha256/a"), ShouldBeNil)
+			So(fake.object("zot/other/index.json"), ShouldNotBeNil)
+
+			err := store.Delete(ctx, "/repo")
+			So(err, ShouldHaveSameTypeAs, driver.PathNotFoundError{})
+		})
+
+		Convey("Walk directories", func() {
+			walked := []string{}
+
+			err := store.Walk(ctx, "/", func(info driver.FileInfo) error {
+				walked = append(walked, fmt.Sprintf("%s %v", info.Path(), info.IsDir()))
+
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(walked, ShouldResemble, []string{
+				"/other true", "/other/index.json false",
+				"/repo true", "/repo/blobs true", "/repo/blobs/sha256 true",
+				"/repo/blobs/sha256/a false", "/repo/blobs/sha256/b false", "/repo/index.json false",
+			})
+
+			walked = []string{}
+
+			err = store.Walk(ctx, "/repo", func(info driver.FileInfo) error {
+				walked = append(walked, info.Path())
+
+				if info.IsDir() {
+					return driver.ErrSkipDir
+				}
+
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(walked, ShouldResemble, []string{"/repo/blobs", "/repo/index.json"})
+
+			walked = []string{}
+
+			err = store.Walk(ctx, "/", func(info driver.FileInfo) error {
+				walked = append(walked, info.Path())
+
+				if !info.IsDir() {
+					return driver.ErrSkipDir
+				}
+
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(walked, ShouldResemble, []string{"/other", "/other/index.json"})
+
+			err = store.Walk(ctx, "/missing", func(info driver.FileInfo) error {
+				return nil
+			})
+			So(err, ShouldHaveSameTypeAs, driver.PathNotFoundError{})
+		})
+
+		Convey("Requests failing with server errors are retried", func() {
+			fake.failures = 2
+
+			content, err := store.GetContent(ctx, "/repo/index.json")
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "index")
+
+			fake.failures = 10
+
+			// the base driver wraps errors without unwrapping them
+			_, err = store.GetContent(ctx, "/repo/index.json")
+			So(err, ShouldHaveSameTypeAs, driver.Error{})
+			So(err.Error(), ShouldContainSubstring, zerr.ErrGCSRequestFailed.Error())
+			So(err.Error(), ShouldContainSubstring, "backend error")
+		})
+
+		Convey("Blob URLs are not supported", func() {
+			_, err := store.URLFor(ctx, "/repo/blobs/sha256/a", nil)
+			So(err, ShouldHaveSameTypeAs, driver.ErrUnsupportedMethod{})
+		})
+	})
+}
+
+func TestResumableUpload(t *testing.T) {
+	Convey("Uploads are resumed from their session objects", t, func() {
+		fake := newFakeGCS()
+		defer fake.server.Close()
+
+		ctx := context.Background()
+		store := newDriver(fake, "")
+		content := bytes.Repeat([]byte("0123456789abcdef"), (2*chunkSize+chunkSize/2)/16)
+
+		writer, err := store.Writer(ctx, "/upload", false)
+		So(err, ShouldBeNil)
+		So(writer.Close(), ShouldBeNil)
+
+		// the empty upload is saved without a session
+		So(fake.object("upload").contentType, ShouldEqual, "application/x-zot-upload-session")
+		So(fake.sessions, ShouldBeEmpty)
+
+		writer, err = store.Writer(ctx, "/upload", true)
+		So(err, ShouldBeNil)
+		So(writer.Size(), ShouldEqual, 0)
+
+		nbytes, err := writer.Write(content[:chunkSize+100])
+		So(err, ShouldBeNil)
+		So(nbytes, ShouldEqual, chunkSize+100)
+		So(writer.Size(), ShouldEqual, chunkSize+100)
+		So(writer.Close(), ShouldBeNil)
+		So(writer.Close(), ShouldNotBeNil)
+
+		// the whole chunk was sent to the session, the rest is saved with it
+		So(fake.sessions, ShouldHaveLength, 1)
+		So(fake.object("upload").data, ShouldHaveLength, 100)
+
+		info, err := store.Stat(ctx, "/upload")
+		So(err, ShouldBeNil)
+		So(info.Size(), ShouldEqual, chunkSize+100)
+
+		_, err = store.Reader(ctx, "/upload", 0)
+		So(err, ShouldHaveSameTypeAs, driver.PathNotFoundError{})
+
+		writer, err = store.Writer(ctx, "/upload", true)
+		So(err, ShouldBeNil)
+		So(writer.Size(), ShouldEqual, chunkSize+100)
+
+		_, err = writer.Write(content[chunkSize+100:])
+		So(err, ShouldBeNil)
+		So(writer.Commit(), ShouldBeNil)
+		So(writer.Close(), ShouldBeNil)
+
+		_, err = writer.Write(content)
+		So(errors.Is(err, zerr.ErrFileWriterDone), ShouldBeTrue)
+
+		uploaded, err := store.GetContent(ctx, "/upload")
+		So(err, ShouldBeNil)
+		So(bytes.Equal(uploaded, content), ShouldBeTrue)
+		So(fake.sessions, ShouldBeEmpty)
+
+		// the upload is done
+		_, err = store.Writer(ctx, "/upload", true)
+		So(err, ShouldHaveSameTypeAs, driver.PathNotFoundError{})
+
+		_, err = store.Writer(ctx, "/missing", true)
+		So(err, ShouldHaveSameTypeAs, driver.PathNotFoundError{})
+	})
+
+	Convey("Uploads of whole chunks finish with an empty chunk", t, func() {
+		fake := newFakeGCS()
+		defer fake.server.Close()
+
+		ctx := context.Background()
+		store := newDriver(fake, "")
+		content := bytes.Repeat([]byte{1}, chunkSize)
+
+		writer, err := store.Writer(ctx, "/upload", false)
+		So(err, ShouldBeNil)
+
+		_, err = writer.Write(content)
+		So(err, ShouldBeNil)
+		So(writer.Commit(), ShouldBeNil)
+
+		So(bytes.Equal(fake.object("upload").data, content), ShouldBeTrue)
+	})
+
+	Convey("Cancelled uploads abort their session", t, func() {
+		fake := newFakeGCS()
+		defer fake.server.Close()
+
+		ctx := context.Background()
+		store := newDriver(fake, "")
+
+		writer, err := store.Writer(ctx, "/upload", false)
+		So(err, ShouldBeNil)
+
+		_, err = writer.Write(bytes.Repeat([]byte{1}, chunkSize+1))
+		So(err, ShouldBeNil)
+		So(writer.Close(), ShouldBeNil)
+
+		writer, err = store.Writer(ctx, "/upload", true)
+		So(err, ShouldBeNil)
+		So(writer.Cancel(), ShouldBeNil)
+		So(writer.Close(), ShouldBeNil)
+
+		So(fake.cancelled, ShouldEqual, 1)
+		So(fake.object("upload"), ShouldBeNil)
+
+		_, err = store.Writer(ctx, "/upload", true)
+		So(err, ShouldHaveSameTypeAs, driver.PathNotFoundError{})
+	})
+
+	Convey("Invalid session objects are rejected", t, func() {
+		fake := newFakeGCS()
+		defer fake.server.Close()
+
+		ctx := context.Background()
+		store := newDriver(fake, "")
+
+		fake.objects["upload"] = &fakeObject{
+			contentType: "application/x-zot-upload-session",
+			metadata:    map[string]string{"offset": "invalid"},
+		}
+
+		_, err := store.Writer(ctx, "/upload", true)
+		So(err.Error(), ShouldContainSubstring, zerr.ErrBadUploadSession.Error())
+	})
+}
+
+func TestObjectStorage(t *testing.T) {
+	Convey("Image store backed by gcs", t, func() {
+		fake := newFakeGCS()
+		defer fake.server.Close()
+
+		store := newDriver(fake, "/zot")
+		cacheDir := t.TempDir()
+
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+			RootDir:     cacheDir,
+			Name:        "gcs_cache",
+			UseRelPaths: false,
+		}, log)
+
+		imgStore := s3.NewImageStore("/zot", cacheDir, false, storageConstants.DefaultGCDelay,
+			true, false, log, metrics, nil, store, s3.MultipartConfig{PartSize: chunkSize}, cacheDriver)
+
+		content := bytes.Repeat([]byte("layer"), chunkSize/2)
+		digest := godigest.FromBytes(content)
+
+		Convey("Chunked uploads are mapped to resumable sessions", func() {
+			upload, err := imgStore.NewBlobUpload("repo")
+			So(err, ShouldBeNil)
+
+			size, err := imgStore.GetBlobUpload("repo", upload)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, 0)
+
+			half := int64(len(content) / 2)
+
+			_, err = imgStore.PutBlobChunk("repo", upload, 0, half-1, bytes.NewReader(content[:half]))
+			So(err, ShouldBeNil)
+
+			_, err = imgStore.PutBlobChunk("repo", upload, 0, half-1, bytes.NewReader(content[:half]))
+			So(errors.Is(err, zerr.ErrBadUploadRange), ShouldBeTrue)
+
+			_, err = imgStore.PutBlobChunk("repo", upload, half, int64(len(content))-1,
+				bytes.NewReader(content[half:]))
+			So(err, ShouldBeNil)
+
+			size, err = imgStore.BlobUploadInfo("repo", upload)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, len(content))
+			So(fake.sessions, ShouldHaveLength, 1)
+
+			err = imgStore.FinishBlobUpload("repo", upload, bytes.NewReader(nil), digest)
+			So(err, ShouldBeNil)
+
+			blob, size, err := imgStore.GetBlob("repo", digest, "application/octet-stream")
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, len(content))
+
+			downloaded, err := io.ReadAll(blob)
+			So(err, ShouldBeNil)
+			So(bytes.Equal(downloaded, content), ShouldBeTrue)
+			So(blob.Close(), ShouldBeNil)
+
+			Convey("Blobs pushed to another repo are deduped", func() {
+				_, _, err := imgStore.FullBlobUpload("other", bytes.NewReader(content), digest)
+				So(err, ShouldBeNil)
+
+				So(fake.object("zot/zot/other/blobs/sha256/"+digest.Encoded()).data, ShouldBeEmpty)
+
+				ok, size, err := imgStore.CheckBlob("other", digest)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeTrue)
+				So(size, ShouldEqual, len(content))
+
+				downloaded, err := imgStore.GetBlobContent("other", digest)
+				So(err, ShouldBeNil)
+				So(bytes.Equal(downloaded, content), ShouldBeTrue)
+			})
+		})
+
+		Convey("Deleted uploads abort their session", func() {
+			upload, err := imgStore.NewBlobUpload("repo")
+			So(err, ShouldBeNil)
+
+			_, err = imgStore.PutBlobChunkStreamed("repo", upload, bytes.NewReader(content))
+			So(err, ShouldBeNil)
+			So(fake.sessions, ShouldHaveLength, 1)
+
+			So(imgStore.DeleteBlobUpload("repo", upload), ShouldBeNil)
+			So(fake.sessions, ShouldBeEmpty)
+
+			_, err = imgStore.GetBlobUpload("repo", upload)
+			So(errors.Is(err, zerr.ErrUploadNotFound), ShouldBeTrue)
+		})
+
+		Convey("Repositories are listed by walking the bucket", func() {
+			_, _, err := imgStore.FullBlobUpload("repo", bytes.NewReader(content), digest)
+			So(err, ShouldBeNil)
+
+			repos, err := imgStore.GetRepositories()
+			So(err, ShouldBeNil)
+			So(repos, ShouldResemble, []string{"repo"})
+		})
+	})
+}
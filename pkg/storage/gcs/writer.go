@@ -0,0 +1,312 @@
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	storagedriver "github.com/docker/distribution/registry/storage/driver"
+
+	zerr "zotregistry.io/zot/errors"
+)
+
+// range of the data persisted by a resumable upload session, returned with the 308 status.
+var sessionRangeRegexp = regexp.MustCompile(`^bytes=0-(\d+)$`)
+
+/*
+writer uploads a file through a gcs resumable upload session, which is started when the first chunk is sent.
+
+Gcs only accepts chunks which are a multiple of 256KiB, except for the last one, so the data written after
+the last whole chunk is buffered. Closing a writer which isn't committed saves the session URI, the offset of
+the session and the buffered data in an object at the path of the file, with the upload session content type,
+which is how the upload is resumed by a writer created with append set, possibly by another zot instance.
+Committing the writer sends the buffered data as the last chunk, the uploaded object replaces the session one.
+*/
+type writer struct {
+	ctx        context.Context //nolint:containedctx // the driver writer interface doesn't take contexts
+	driver     *driver
+	key        string
+	sessionURI string
+	// size of the data already sent to the session
+	offset int64
+	buffer []byte
+
+	closed    bool
+	committed bool
+	cancelled bool
+}
+
+func newWriter(ctx context.Context, d *driver, filePath string) *writer {
+	return &writer{
+		ctx:    ctx,
+		driver: d,
+		key:    d.key(filePath),
+		buffer: make([]byte, 0, d.chunkSize),
+	}
+}
+
+// resumeWriter returns a writer continuing the upload saved in the session object of a file.
+func resumeWriter(ctx context.Context, d *driver, filePath string) (*writer, error) {
+	fileWriter := newWriter(ctx, d, filePath)
+
+	obj, err := d.getObject(ctx, fileWriter.key)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, storagedriver.PathNotFoundError{Path: filePath, DriverName: DriverName}
+		}
+
+		return nil, err
+	}
+
+	// the upload is done or the file was not uploaded in chunks
+	if obj.ContentType != uploadSessionContentType {
+		return nil, storagedriver.PathNotFoundError{Path: filePath, DriverName: DriverName}
+	}
+
+	fileWriter.sessionURI = obj.Metadata[sessionURIMetadata]
+
+	fileWriter.offset, err = strconv.ParseInt(obj.Metadata[offsetMetadata], 10, 64)
+	if err != nil || obj.Size >= d.chunkSize {
+		return nil, fmt.Errorf("%w: %s", zerr.ErrBadUploadSession, filePath)
+	}
+
+	if obj.Size > 0 {
+		resp, err := d.do(ctx, http.MethodGet, d.objectURL(fileWriter.key)+"?alt=media", nil, nil, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+
+		defer resp.Body.Close()
+
+		buffer := fileWriter.buffer[:obj.Size]
+		if _, err := io.ReadFull(resp.Body, buffer); err != nil {
+			return nil, err
+		}
+
+		fileWriter.buffer = buffer
+	}
+
+	return fileWriter, nil
+}
+
+func (w *writer) checkState() error {
+	if w.closed || w.committed || w.cancelled {
+		return zerr.ErrFileWriterDone
+	}
+
+	return nil
+}
+
+// Write buffers data and sends each full buffer to the session as a chunk.
+func (w *writer) Write(data []byte) (int, error) {
+	if err := w.checkState(); err != nil {
+		return 0, err
+	}
+
+	written := 0
+
+	for len(data) > 0 {
+		size := cap(w.buffer) - len(w.buffer)
+		if size > len(data) {
+			size = len(data)
+		}
+
+		w.buffer = append(w.buffer, data[:size]...)
+		data = data[size:]
+		written += size
+
+		if len(w.buffer) == cap(w.buffer) {
+			if err := w.flush(len(w.buffer)); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// Size returns the size of the data written so far, sent to the session or buffered.
+func (w *writer) Size() int64 {
+	return w.offset + int64(len(w.buffer))
+}
+
+// Close sends the whole chunks of the buffer to the session and saves the session with the rest of the buffer.
+func (w *writer) Close() error {
+	if w.closed {
+		return zerr.ErrFileWriterDone
+	}
+
+	w.closed = true
+
+	if w.committed || w.cancelled {
+		return nil
+	}
+
+	if size := len(w.buffer) / ChunkGranularity * ChunkGranularity; size > 0 {
+		if err := w.flush(size); err != nil {
+			return err
+		}
+	}
+
+	return w.driver.insert(w.ctx, w.key, uploadSessionContentType, map[string]string{
+		sessionURIMetadata: w.sessionURI,
+		offsetMetadata:     strconv.FormatInt(w.offset, 10),
+	}, w.buffer)
+}
+
+// Commit finishes the upload, files smaller than a chunk which were never saved are uploaded in one request.
+func (w *writer) Commit() error {
+	if err := w.checkState(); err != nil {
+		return err
+	}
+
+	w.committed = true
+
+	if w.sessionURI == "" {
+		return w.driver.insert(w.ctx, w.key, defaultContentType, nil, w.buffer)
+	}
+
+	total := w.Size()
+
+	_, err := w.driver.putChunk(w.ctx, w.sessionURI, w.buffer, w.offset, total)
+
+	return err
+}
+
+// Cancel aborts the session and deletes the saved upload.
+func (w *writer) Cancel() error {
+	if err := w.checkState(); err != nil {
+		return err
+	}
+
+	w.cancelled = true
+
+	if w.sessionURI != "" {
+		// gcs answers a cancelled session with 499
+		resp, err := w.driver.do(w.ctx, http.MethodDelete, w.sessionURI, nil, nil,
+			http.StatusNoContent, http.StatusOK, 499, http.StatusNotFound) //nolint:gomnd
+		if err != nil {
+			return err
+		}
+
+		resp.Body.Close()
+	}
+
+	if err := w.driver.deleteObject(w.ctx, w.key); err != nil && !isNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// flush sends the first size bytes of the buffer to the session, a multiple of the chunk granularity.
+func (w *writer) flush(size int) error {
+	if w.sessionURI == "" {
+		sessionURI, err := w.driver.startSession(w.ctx, w.key)
+		if err != nil {
+			return err
+		}
+
+		w.sessionURI = sessionURI
+	}
+
+	start := w.offset
+	end := start + int64(size)
+
+	// gcs may persist only part of a chunk, the rest is sent again
+	for w.offset < end {
+		persisted, err := w.driver.putChunk(w.ctx, w.sessionURI, w.buffer[w.offset-start:size], w.offset, -1)
+		if err != nil {
+			return err
+		}
+
+		if persisted <= w.offset || persisted > end {
+			return fmt.Errorf("%w: unexpected persisted size %d", zerr.ErrBadUploadSession, persisted)
+		}
+
+		w.offset = persisted
+	}
+
+	w.buffer = append(w.buffer[:0], w.buffer[size:]...)
+
+	return nil
+}
+
+// startSession starts a resumable upload session and returns its URI.
+func (d *driver) startSession(ctx context.Context, key string) (string, error) {
+	meta, err := json.Marshal(objectMetadata{Name: key, ContentType: defaultContentType})
+	if err != nil {
+		return "", err
+	}
+
+	header := http.Header{"Content-Type": {"application/json; charset=UTF-8"}}
+
+	resp, err := d.do(ctx, http.MethodPost, d.uploadURL()+"?uploadType=resumable", header, meta, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+
+	resp.Body.Close()
+
+	sessionURI := resp.Header.Get("Location")
+	if sessionURI == "" {
+		return "", fmt.Errorf("%w: no session URI returned for %s", zerr.ErrBadUploadSession, key)
+	}
+
+	return sessionURI, nil
+}
+
+/*
+putChunk sends a chunk starting at offset to a session and returns the size of the data persisted by the
+session. total is the size of the file for the last chunk, which finishes the upload, and -1 otherwise.
+*/
+func (d *driver) putChunk(ctx context.Context, sessionURI string, chunk []byte, offset, total int64,
+) (int64, error) {
+	totalRange := "*"
+	if total >= 0 {
+		totalRange = strconv.FormatInt(total, 10)
+	}
+
+	contentRange := fmt.Sprintf("bytes */%s", totalRange)
+	if len(chunk) > 0 {
+		contentRange = fmt.Sprintf("bytes %d-%d/%s", offset, offset+int64(len(chunk))-1, totalRange)
+	}
+
+	resp, err := d.do(ctx, http.MethodPut, sessionURI, http.Header{"Content-Range": {contentRange}}, chunk,
+		http.StatusOK, http.StatusCreated, http.StatusPermanentRedirect)
+	if err != nil {
+		return -1, err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPermanentRedirect {
+		return offset + int64(len(chunk)), nil
+	}
+
+	if total >= 0 {
+		return -1, fmt.Errorf("%w: upload not finished by its last chunk", zerr.ErrBadUploadSession)
+	}
+
+	// nothing persisted yet
+	sessionRange := resp.Header.Get("Range")
+	if sessionRange == "" {
+		return 0, nil
+	}
+
+	match := sessionRangeRegexp.FindStringSubmatch(sessionRange)
+	if match == nil {
+		return -1, fmt.Errorf("%w: invalid session range %q", zerr.ErrBadUploadSession, sessionRange)
+	}
+
+	last, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return -1, err
+	}
+
+	return last + 1, nil
+}
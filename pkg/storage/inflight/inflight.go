@@ -0,0 +1,180 @@
+package inflight
+
+import (
+	"sync"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+)
+
+// DefaultUploadTimeout is how long a finished blob upload is held if the config doesn't set a timeout.
+const DefaultUploadTimeout = 10 * time.Minute
+
+/*
+References registers the content being written to the repos which no manifest references yet, gc doesn't remove
+it whatever its age, so that a short gc delay can't collect the blobs of an image pushed or synced before its
+manifest. Sync sessions hold the digests of the image they copy until it's committed, finished blob uploads are
+held until a manifest referencing them is pushed, or for the upload timeout if none is. References are kept in
+memory only, a restart releases all of them.
+*/
+type References struct {
+	uploadTimeout time.Duration
+	// number of sessions holding each digest of each repo
+	sessions map[reference]int
+	// when the finished uploads of each digest of each repo stop being held
+	uploads map[reference]time.Time
+	// when the expired uploads are next removed
+	nextPrune time.Time
+	lock      *sync.Mutex
+}
+
+type reference struct {
+	repo   string
+	digest godigest.Digest
+}
+
+// New creates an empty set of references, uploads are held for at most uploadTimeout, or DefaultUploadTimeout if
+// it's 0.
+func New(uploadTimeout time.Duration) *References {
+	if uploadTimeout <= 0 {
+		uploadTimeout = DefaultUploadTimeout
+	}
+
+	return &References{
+		uploadTimeout: uploadTimeout,
+		sessions:      map[reference]int{},
+		uploads:       map[reference]time.Time{},
+		lock:          &sync.Mutex{},
+	}
+}
+
+// Session holds digests of a repo until it's released, e.g. the blobs and manifests of an image being synced.
+type Session struct {
+	refs     *References
+	repo     string
+	held     map[godigest.Digest]bool
+	released bool
+}
+
+// NewSession starts a session holding digests of a repo, a nil session is returned if refs is nil.
+func (refs *References) NewSession(repo string) *Session {
+	if refs == nil {
+		return nil
+	}
+
+	return &Session{refs: refs, repo: repo, held: map[godigest.Digest]bool{}}
+}
+
+// Hold adds digests to the session, they're held until the session is released.
+func (session *Session) Hold(digests ...godigest.Digest) {
+	if session == nil {
+		return
+	}
+
+	session.refs.lock.Lock()
+	defer session.refs.lock.Unlock()
+
+	if session.released {
+		return
+	}
+
+	for _, digest := range digests {
+		if session.held[digest] {
+			continue
+		}
+
+		session.held[digest] = true
+		session.refs.sessions[reference{repo: session.repo, digest: digest}]++
+	}
+}
+
+// Release releases the digests held by the session, releasing it again does nothing.
+func (session *Session) Release() {
+	if session == nil {
+		return
+	}
+
+	session.refs.lock.Lock()
+	defer session.refs.lock.Unlock()
+
+	if session.released {
+		return
+	}
+
+	session.released = true
+
+	for digest := range session.held {
+		ref := reference{repo: session.repo, digest: digest}
+
+		if session.refs.sessions[ref]--; session.refs.sessions[ref] <= 0 {
+			delete(session.refs.sessions, ref)
+		}
+	}
+}
+
+// HoldUpload registers a finished blob upload until a manifest referencing it is pushed or the upload timeout.
+func (refs *References) HoldUpload(repo string, digest godigest.Digest) {
+	if refs == nil {
+		return
+	}
+
+	now := time.Now()
+
+	refs.lock.Lock()
+	defer refs.lock.Unlock()
+
+	if now.After(refs.nextPrune) {
+		for ref, expiresAt := range refs.uploads {
+			if now.After(expiresAt) {
+				delete(refs.uploads, ref)
+			}
+		}
+
+		refs.nextPrune = now.Add(refs.uploadTimeout)
+	}
+
+	refs.uploads[reference{repo: repo, digest: digest}] = now.Add(refs.uploadTimeout)
+}
+
+// ReleaseUploads releases the uploads of digests of a repo, once a manifest referencing them is pushed.
+func (refs *References) ReleaseUploads(repo string, digests ...godigest.Digest) {
+	if refs == nil {
+		return
+	}
+
+	refs.lock.Lock()
+	defer refs.lock.Unlock()
+
+	for _, digest := range digests {
+		delete(refs.uploads, reference{repo: repo, digest: digest})
+	}
+}
+
+// IsInFlight returns true if a digest of a repo is held by a session or by an upload which hasn't timed out.
+func (refs *References) IsInFlight(repo string, digest godigest.Digest) bool {
+	if refs == nil {
+		return false
+	}
+
+	ref := reference{repo: repo, digest: digest}
+
+	refs.lock.Lock()
+	defer refs.lock.Unlock()
+
+	if refs.sessions[ref] > 0 {
+		return true
+	}
+
+	expiresAt, ok := refs.uploads[ref]
+	if !ok {
+		return false
+	}
+
+	if time.Now().After(expiresAt) {
+		delete(refs.uploads, ref)
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,72 @@
+package inflight_test
+
+import (
+	"testing"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.io/zot/pkg/storage/inflight"
+)
+
+func TestReferences(t *testing.T) {
+	blobDigest := godigest.FromString("blob")
+	manifestDigest := godigest.FromString("manifest")
+
+	Convey("Sessions hold digests until they're all released", t, func() {
+		refs := inflight.New(0)
+		So(refs.IsInFlight("repo", blobDigest), ShouldBeFalse)
+
+		first := refs.NewSession("repo")
+		first.Hold(blobDigest, manifestDigest)
+		first.Hold(blobDigest)
+
+		second := refs.NewSession("repo")
+		second.Hold(blobDigest)
+
+		So(refs.IsInFlight("repo", blobDigest), ShouldBeTrue)
+		So(refs.IsInFlight("repo", manifestDigest), ShouldBeTrue)
+		So(refs.IsInFlight("other", blobDigest), ShouldBeFalse)
+
+		first.Release()
+		first.Release()
+		So(refs.IsInFlight("repo", blobDigest), ShouldBeTrue)
+		So(refs.IsInFlight("repo", manifestDigest), ShouldBeFalse)
+
+		// released sessions don't hold anything anymore
+		first.Hold(manifestDigest)
+		So(refs.IsInFlight("repo", manifestDigest), ShouldBeFalse)
+
+		second.Release()
+		So(refs.IsInFlight("repo", blobDigest), ShouldBeFalse)
+	})
+
+	Convey("Uploads are held until they're released or time out", t, func() {
+		refs := inflight.New(100 * time.Millisecond)
+
+		refs.HoldUpload("repo", blobDigest)
+		refs.HoldUpload("repo", manifestDigest)
+		So(refs.IsInFlight("repo", blobDigest), ShouldBeTrue)
+		So(refs.IsInFlight("other", blobDigest), ShouldBeFalse)
+
+		refs.ReleaseUploads("repo", blobDigest)
+		So(refs.IsInFlight("repo", blobDigest), ShouldBeFalse)
+		So(refs.IsInFlight("repo", manifestDigest), ShouldBeTrue)
+
+		time.Sleep(200 * time.Millisecond)
+		So(refs.IsInFlight("repo", manifestDigest), ShouldBeFalse)
+	})
+
+	Convey("Nil references hold nothing", t, func() {
+		var refs *inflight.References
+
+		session := refs.NewSession("repo")
+		session.Hold(blobDigest)
+		session.Release()
+
+		refs.HoldUpload("repo", blobDigest)
+		refs.ReleaseUploads("repo", blobDigest)
+		So(refs.IsInFlight("repo", blobDigest), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,207 @@
+package lease
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	guuid "github.com/gofrs/uuid"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+)
+
+const (
+	// DefaultDuration is how long a lease lasts if no duration is requested.
+	DefaultDuration = 10 * time.Minute
+	// DefaultMaxDuration is the longest a lease can be acquired or renewed for if no max duration is configured.
+	DefaultMaxDuration = time.Hour
+)
+
+// Lease is held by an external reader of the storage, e.g. a backup agent, garbage collection is paused
+// until every lease is released or expires.
+type Lease struct {
+	ID         string    `json:"id"`
+	Holder     string    `json:"holder,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+type LeaseList struct {
+	Leases []Lease `json:"leases"`
+}
+
+// Leases holds the active leases, they are kept in memory only so a restart releases all of them.
+type Leases struct {
+	maxDuration time.Duration
+	leases      map[string]Lease
+	lock        *sync.Mutex
+	metrics     monitoring.MetricServer
+	log         log.Logger
+}
+
+// New creates an empty set of leases, leases can't be acquired or renewed for longer than maxDuration.
+func New(maxDuration time.Duration, metrics monitoring.MetricServer, log log.Logger) *Leases {
+	if maxDuration <= 0 {
+		maxDuration = DefaultMaxDuration
+	}
+
+	return &Leases{
+		maxDuration: maxDuration,
+		leases:      map[string]Lease{},
+		lock:        &sync.Mutex{},
+		metrics:     metrics,
+		log:         log,
+	}
+}
+
+// Acquire creates a new lease lasting duration, or DefaultDuration if duration is 0.
+func (ls *Leases) Acquire(holder, reason string, duration time.Duration) (Lease, error) {
+	duration, err := ls.getDuration(duration)
+	if err != nil {
+		return Lease{}, err
+	}
+
+	uuid, err := guuid.NewV4()
+	if err != nil {
+		return Lease{}, err
+	}
+
+	now := time.Now()
+
+	lease := Lease{
+		ID:         uuid.String(),
+		Holder:     holder,
+		Reason:     reason,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(duration),
+	}
+
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	ls.expire()
+
+	ls.leases[lease.ID] = lease
+
+	monitoring.SetStorageLeases(ls.metrics, len(ls.leases))
+
+	return lease, nil
+}
+
+// Renew extends a lease which didn't expire yet so it lasts duration from now.
+func (ls *Leases) Renew(id string, duration time.Duration) (Lease, error) {
+	duration, err := ls.getDuration(duration)
+	if err != nil {
+		return Lease{}, err
+	}
+
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	ls.expire()
+
+	lease, ok := ls.leases[id]
+	if !ok {
+		return Lease{}, zerr.ErrLeaseNotFound
+	}
+
+	lease.ExpiresAt = time.Now().Add(duration)
+	ls.leases[id] = lease
+
+	return lease, nil
+}
+
+// Release removes a lease before it expires.
+func (ls *Leases) Release(id string) error {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	ls.expire()
+
+	if _, ok := ls.leases[id]; !ok {
+		return zerr.ErrLeaseNotFound
+	}
+
+	delete(ls.leases, id)
+
+	monitoring.SetStorageLeases(ls.metrics, len(ls.leases))
+
+	return nil
+}
+
+// List returns the active leases, sorted by expiry time.
+func (ls *Leases) List() []Lease {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	ls.expire()
+
+	leases := make([]Lease, 0, len(ls.leases))
+
+	for _, lease := range ls.leases {
+		leases = append(leases, lease)
+	}
+
+	sort.Slice(leases, func(i, j int) bool {
+		return leases[i].ExpiresAt.Before(leases[j].ExpiresAt)
+	})
+
+	return leases
+}
+
+// IsHeld returns true if at least one lease is active.
+func (ls *Leases) IsHeld() bool {
+	if ls == nil {
+		return false
+	}
+
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	ls.expire()
+
+	return len(ls.leases) > 0
+}
+
+// expire removes the expired leases, the caller must hold the lock.
+func (ls *Leases) expire() {
+	now := time.Now()
+	expired := false
+
+	for id, lease := range ls.leases {
+		if now.Before(lease.ExpiresAt) {
+			continue
+		}
+
+		delete(ls.leases, id)
+
+		expired = true
+
+		ls.log.Warn().Str("id", id).Str("holder", lease.Holder).Time("expiresAt", lease.ExpiresAt).
+			Msg("lease: lease expired without being released")
+		monitoring.IncStorageLeasesExpired(ls.metrics)
+	}
+
+	if expired {
+		monitoring.SetStorageLeases(ls.metrics, len(ls.leases))
+	}
+}
+
+func (ls *Leases) getDuration(duration time.Duration) (time.Duration, error) {
+	if duration == 0 {
+		duration = DefaultDuration
+
+		if duration > ls.maxDuration {
+			duration = ls.maxDuration
+		}
+	}
+
+	if duration < 0 || duration > ls.maxDuration {
+		return 0, zerr.ErrBadLeaseDuration
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,78 @@
+package lease_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage/lease"
+)
+
+func TestLeases(t *testing.T) {
+	log := log.NewLogger("debug", "")
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	Convey("Acquire, renew and release leases", t, func() {
+		leases := lease.New(0, metrics, log)
+		So(leases.IsHeld(), ShouldBeFalse)
+
+		storageLease, err := leases.Acquire("backup", "nightly", 0)
+		So(err, ShouldBeNil)
+		So(storageLease.ID, ShouldNotBeEmpty)
+		So(storageLease.Holder, ShouldEqual, "backup")
+		So(storageLease.ExpiresAt.Sub(storageLease.AcquiredAt), ShouldEqual, lease.DefaultDuration)
+		So(leases.IsHeld(), ShouldBeTrue)
+
+		renewed, err := leases.Renew(storageLease.ID, lease.DefaultMaxDuration)
+		So(err, ShouldBeNil)
+		So(renewed.ExpiresAt.After(storageLease.ExpiresAt), ShouldBeTrue)
+
+		_, err = leases.Renew("unknown", time.Minute)
+		So(errors.Is(err, zerr.ErrLeaseNotFound), ShouldBeTrue)
+
+		_, err = leases.Acquire("backup", "", 2*lease.DefaultMaxDuration)
+		So(errors.Is(err, zerr.ErrBadLeaseDuration), ShouldBeTrue)
+
+		_, err = leases.Acquire("backup", "", -time.Minute)
+		So(errors.Is(err, zerr.ErrBadLeaseDuration), ShouldBeTrue)
+
+		So(len(leases.List()), ShouldEqual, 1)
+
+		So(leases.Release(storageLease.ID), ShouldBeNil)
+		So(errors.Is(leases.Release(storageLease.ID), zerr.ErrLeaseNotFound), ShouldBeTrue)
+		So(leases.IsHeld(), ShouldBeFalse)
+	})
+
+	Convey("Leases expire", t, func() {
+		leases := lease.New(100*time.Millisecond, metrics, log)
+
+		// the default duration is capped by the max duration
+		storageLease, err := leases.Acquire("backup", "", 0)
+		So(err, ShouldBeNil)
+		So(storageLease.ExpiresAt.Sub(storageLease.AcquiredAt), ShouldEqual, 100*time.Millisecond)
+
+		_, err = leases.Acquire("backup", "", 50*time.Millisecond)
+		So(err, ShouldBeNil)
+
+		So(leases.List()[0].ExpiresAt.Before(storageLease.ExpiresAt), ShouldBeTrue)
+
+		time.Sleep(200 * time.Millisecond)
+
+		So(leases.IsHeld(), ShouldBeFalse)
+		So(leases.List(), ShouldBeEmpty)
+
+		_, err = leases.Renew(storageLease.ID, 0)
+		So(errors.Is(err, zerr.ErrLeaseNotFound), ShouldBeTrue)
+	})
+
+	Convey("A nil set of leases is never held", t, func() {
+		var leases *lease.Leases
+
+		So(leases.IsHeld(), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,199 @@
+package local
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// defaultWriteBufferSize is the size of the buffer of io.Copy, used if only direct IO is configured.
+const defaultWriteBufferSize = 32 * 1024
+
+/*
+uploadWriter writes uploaded blob data to a file through a buffer. With direct IO the buffers written once the
+file is larger than directFrom bypass the page cache, which requires the offset and the size of the writes
+to be aligned to directIOAlignment: the data before the first aligned offset and the last partial buffer are
+written through the page cache.
+*/
+type uploadWriter struct {
+	file   *os.File
+	buf    []byte
+	size   int
+	offset int64
+	// offset from which the writes bypass the page cache, -1 if they never do
+	directFrom int64
+	// O_DIRECT is set on the file
+	direct bool
+}
+
+// newUploadWriter returns a writer appending data at offset of file, bufferSize is rounded up to a multiple
+// of directIOAlignment if directFrom is not -1.
+func newUploadWriter(file *os.File, offset int64, bufferSize int, directFrom int64) *uploadWriter {
+	if directFrom >= 0 && !directIOSupported {
+		directFrom = -1
+	}
+
+	if directFrom >= 0 {
+		bufferSize = (bufferSize + directIOAlignment - 1) / directIOAlignment * directIOAlignment
+	}
+
+	return &uploadWriter{
+		file:       file,
+		buf:        alignedBuffer(bufferSize),
+		offset:     offset,
+		directFrom: directFrom,
+	}
+}
+
+// ReadFrom reads data until EOF or an error and writes it, the data read before an error is still written.
+func (writer *uploadWriter) ReadFrom(reader io.Reader) (int64, error) {
+	var total int64
+
+	for {
+		limit := writer.limit()
+
+		n, err := reader.Read(writer.buf[writer.size:limit])
+		writer.size += n
+		total += int64(n)
+
+		if writer.size == limit || err != nil {
+			if flushErr := writer.flush(); flushErr != nil {
+				return total, flushErr
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return total, nil
+		}
+
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// limit returns how much data is buffered before it's written, the first buffer is cut short to align
+// the next writes.
+func (writer *uploadWriter) limit() int {
+	if writer.directFrom < 0 {
+		return len(writer.buf)
+	}
+
+	if misalignment := int(writer.offset % directIOAlignment); misalignment != 0 &&
+		directIOAlignment-misalignment < len(writer.buf) {
+		return directIOAlignment - misalignment
+	}
+
+	return len(writer.buf)
+}
+
+func (writer *uploadWriter) flush() error {
+	if writer.size == 0 {
+		return nil
+	}
+
+	direct := writer.directFrom >= 0 && writer.offset >= writer.directFrom &&
+		writer.offset%directIOAlignment == 0 && writer.size%directIOAlignment == 0
+
+	if err := writer.setDirect(direct); err != nil {
+		return err
+	}
+
+	_, err := writer.file.Write(writer.buf[:writer.size])
+	if err != nil && writer.direct && errors.Is(err, syscall.EINVAL) {
+		// the filesystem doesn't support direct IO, e.g. tmpfs
+		writer.directFrom = -1
+
+		if err := writer.setDirect(false); err != nil {
+			return err
+		}
+
+		_, err = writer.file.Write(writer.buf[:writer.size])
+	}
+
+	if err != nil {
+		return err
+	}
+
+	writer.offset += int64(writer.size)
+	writer.size = 0
+
+	return nil
+}
+
+func (writer *uploadWriter) setDirect(direct bool) error {
+	if direct == writer.direct {
+		return nil
+	}
+
+	if err := setDirectIO(writer.file, direct); err != nil {
+		if direct {
+			// the filesystem doesn't support direct IO, the data is written through the page cache
+			writer.directFrom = -1
+
+			return nil
+		}
+
+		return err
+	}
+
+	writer.direct = direct
+
+	return nil
+}
+
+// Close stops bypassing the page cache, the file is left open.
+func (writer *uploadWriter) Close() error {
+	return writer.setDirect(false)
+}
+
+// copyUpload writes body at offset of an upload file, with the configured IO options.
+func (is *ImageStoreLocal) copyUpload(file *os.File, offset int64, body io.Reader) (int64, error) {
+	if is.ioOptions.WriteBufferSize == 0 && !is.ioOptions.DirectIO {
+		return io.Copy(file, body)
+	}
+
+	bufferSize := is.ioOptions.WriteBufferSize
+	if bufferSize == 0 {
+		bufferSize = defaultWriteBufferSize
+	}
+
+	directFrom := int64(-1)
+	if is.ioOptions.DirectIO {
+		directFrom = is.ioOptions.LargeBlobSize
+	}
+
+	writer := newUploadWriter(file, offset, bufferSize, directFrom)
+
+	n, err := writer.ReadFrom(body)
+
+	if closeErr := writer.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	return n, err
+}
+
+// preallocateUpload reserves the disk space of an upload chunk of known size if the upload is a large blob.
+func (is *ImageStoreLocal) preallocateUpload(file *os.File, from, to int64) {
+	if !is.ioOptions.Preallocate || to+1 < is.ioOptions.LargeBlobSize {
+		return
+	}
+
+	if err := preallocate(file, from, to-from+1); err != nil {
+		// not supported by every filesystem, the chunk is written anyway
+		is.log.Debug().Err(err).Str("file", file.Name()).Msg("unable to preallocate blob upload chunk")
+	}
+}
+
+// adviseReadAhead gives the configured readahead hint for reading a blob to the kernel.
+func (is *ImageStoreLocal) adviseReadAhead(file *os.File) {
+	if is.ioOptions.ReadAhead == "" {
+		return
+	}
+
+	if err := adviseReadAhead(file, is.ioOptions.ReadAhead); err != nil {
+		is.log.Debug().Err(err).Str("file", file.Name()).Msg("unable to set readahead hint")
+	}
+}
@@ -0,0 +1,73 @@
+//go:build linux
+// +build linux
+
+package local
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+const (
+	directIOSupported = true
+
+	// directIOAlignment is the alignment of the memory, the offset and the size of direct IO writes,
+	// the logical block size of most disks.
+	directIOAlignment = 4096
+)
+
+// setDirectIO makes the writes to file bypass the page cache, or not.
+func setDirectIO(file *os.File, enabled bool) error {
+	flags, err := unix.FcntlInt(file.Fd(), unix.F_GETFL, 0)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		flags |= unix.O_DIRECT
+	} else {
+		flags &^= unix.O_DIRECT
+	}
+
+	_, err = unix.FcntlInt(file.Fd(), unix.F_SETFL, flags)
+
+	return err
+}
+
+// alignedBuffer returns a buffer whose memory is aligned as needed by direct IO.
+func alignedBuffer(size int) []byte {
+	buf := make([]byte, size+directIOAlignment)
+
+	shift := 0
+	if misalignment := int(uintptr(unsafe.Pointer(&buf[0])) & (directIOAlignment - 1)); misalignment != 0 {
+		shift = directIOAlignment - misalignment
+	}
+
+	return buf[shift : shift+size]
+}
+
+// preallocate reserves the disk space of length bytes at offset of file, the size of the file is left
+// unchanged since uploads are resumed from it.
+func preallocate(file *os.File, offset, length int64) error {
+	return unix.Fallocate(int(file.Fd()), unix.FALLOC_FL_KEEP_SIZE, offset, length)
+}
+
+// adviseReadAhead tells the kernel how file is going to be read.
+func adviseReadAhead(file *os.File, readAhead string) error {
+	var advice int
+
+	switch readAhead {
+	case storageConstants.ReadAheadSequential:
+		advice = unix.FADV_SEQUENTIAL
+	case storageConstants.ReadAheadRandom:
+		advice = unix.FADV_RANDOM
+	default:
+		advice = unix.FADV_NORMAL
+	}
+
+	return unix.Fadvise(int(file.Fd()), 0, 0, advice)
+}
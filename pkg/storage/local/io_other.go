@@ -0,0 +1,38 @@
+//go:build !linux
+// +build !linux
+
+package local
+
+import (
+	"os"
+
+	zerr "zotregistry.io/zot/errors"
+)
+
+const (
+	directIOSupported = false
+	directIOAlignment = 4096
+)
+
+// setDirectIO fails, direct IO is only supported on linux.
+func setDirectIO(file *os.File, enabled bool) error {
+	if enabled {
+		return zerr.ErrDirectIONotSupported
+	}
+
+	return nil
+}
+
+func alignedBuffer(size int) []byte {
+	return make([]byte, size)
+}
+
+// preallocate does nothing, preallocation is only supported on linux.
+func preallocate(file *os.File, offset, length int64) error {
+	return nil
+}
+
+// adviseReadAhead does nothing, readahead hints are only supported on linux.
+func adviseReadAhead(file *os.File, readAhead string) error {
+	return nil
+}
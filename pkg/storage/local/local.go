@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"math/rand"
 	"os"
 	"path"
 	"path/filepath"
@@ -48,11 +49,59 @@ type ImageStoreLocal struct {
 	cache   cache.Cache
 	gc      bool
 	dedupe  bool
-	commit  bool
 	gcDelay time.Duration
 	log     zerolog.Logger
 	metrics monitoring.MetricServer
 	linter  common.Lint
+	pins    storageTypes.PinnedImages
+	// when written files are flushed to disk
+	commitPolicy string
+	// files written since the last flush, with the periodic commit policy
+	dirtyFiles map[string]bool
+	dirtyLock  *sync.Mutex
+	// safe to use on NFS, see SetNFSMode
+	nfs     bool
+	nfsLock *nfsLock
+	// percentage of the blobs of a repo verified after each gc
+	gcVerifyPercent int
+	// gc is paused while external readers hold a lease
+	leases storageTypes.Leases
+	// content written before the manifests referencing it, kept by gc, see SetInFlight
+	inFlight storageTypes.InFlight
+	// tune how blobs are written and read, see SetIOOptions
+	ioOptions storageTypes.IOOptions
+	// how deduped blobs share their data, see SetDedupeStrategy
+	dedupeStrategy string
+	// blobs are stored once in the shared pool of the root directory, see SetSharedBlobs
+	sharedBlobs bool
+	// tags removed by gc, see SetRetention
+	retention storageTypes.Retention
+	// told about the tags removed by retention, see SetTagRemovals
+	tagRemovals storageTypes.TagRemovals
+	// tags which can't be moved or deleted, see SetImmutableTags
+	immutableTags storageTypes.ImmutableTags
+	// blobs swept by each incremental gc task, see SetGCBatchSize
+	gcBatchSize int
+	// blobs referenced in the repos swept by the incremental gc, accessed under the store lock
+	gcMarks map[string]gcMark
+	// shared blobs linked while gc lists the links to the shared blobs, accessed under the store lock
+	sharedBlobsLinked map[godigest.Digest]bool
+}
+
+// gcMark is the set of blobs referenced by the manifests of a repo, reused by the batches of the incremental gc
+// as long as index.json doesn't change.
+type gcMark struct {
+	indexDigest godigest.Digest
+	referenced  map[godigest.Digest]bool
+}
+
+// WithLogger returns a copy of the store logging its events with logger, e.g. one carrying the fields of the
+// request being served, the copy sharing the locks, the cache and the state of the store.
+func (is *ImageStoreLocal) WithLogger(logger zerolog.Logger) storageTypes.ImageStore {
+	store := *is
+	store.log = logger
+
+	return &store
 }
 
 func (is *ImageStoreLocal) RootDir() string {
@@ -76,19 +125,31 @@ func NewImageStore(rootDir string, gc bool, gcDelay time.Duration, dedupe, commi
 		}
 	}
 
+	// gc walks the docker manifests like the oci ones
+	common.RegisterMediaTypeParsers()
+
 	imgStore := &ImageStoreLocal{
 		rootDir: rootDir,
 		lock:    &sync.RWMutex{},
 		gc:      gc,
 		gcDelay: gcDelay,
 		dedupe:  dedupe,
-		commit:  commit,
 		log:     log.With().Caller().Logger(),
 		metrics: metrics,
 		linter:  linter,
 	}
 
 	imgStore.cache = cacheDriver
+	imgStore.dirtyFiles = map[string]bool{}
+	imgStore.dirtyLock = &sync.Mutex{}
+	imgStore.gcMarks = map[string]gcMark{}
+	imgStore.nfsLock = &nfsLock{path: path.Join(rootDir, nfsLockFile)}
+
+	if commit {
+		imgStore.SetCommitPolicy(storageConstants.CommitPolicyAlways)
+	} else {
+		imgStore.SetCommitPolicy(storageConstants.CommitPolicyNone)
+	}
 
 	if gc {
 		// we use umoci GC to perform garbage-collection, but it uses its own logger
@@ -108,6 +169,22 @@ func NewImageStore(rootDir string, gc bool, gcDelay time.Duration, dedupe, commi
 	return imgStore
 }
 
+// SetPinnedImages sets the source of pinned manifests, which are skipped by gc.
+func (is *ImageStoreLocal) SetPinnedImages(pins storageTypes.PinnedImages) {
+	is.pins = pins
+}
+
+/*
+SetCommitPolicy sets when written files are flushed to disk:
+  - none: never, the OS flushes them in the background
+  - always: blob uploads, manifests and indexes are flushed when written
+  - manifest: manifests and indexes are flushed when written, blobs aren't
+  - periodic: files are flushed by RunCommitPeriodically
+*/
+func (is *ImageStoreLocal) SetCommitPolicy(policy string) {
+	is.commitPolicy = policy
+}
+
 // RLock read-lock.
 func (is *ImageStoreLocal) RLock(lockStart *time.Time) {
 	*lockStart = time.Now()
@@ -129,10 +206,18 @@ func (is *ImageStoreLocal) Lock(lockStart *time.Time) {
 	*lockStart = time.Now()
 
 	is.lock.Lock()
+
+	if is.nfs {
+		is.lockFile()
+	}
 }
 
 // Unlock write-unlock.
 func (is *ImageStoreLocal) Unlock(lockStart *time.Time) {
+	if is.nfs {
+		is.unlockFile()
+	}
+
 	is.lock.Unlock()
 
 	lockEnd := time.Now()
@@ -279,43 +364,194 @@ func (is *ImageStoreLocal) ValidateRepo(name string) (bool, error) {
 	return true, nil
 }
 
-// GetRepositories returns a list of all the repositories under this store.
-func (is *ImageStoreLocal) GetRepositories() ([]string, error) {
+// CheckConsistency looks for repos with missing or invalid layout files and for blob upload dirs left
+// outside of any repo. With repair set the layout files are recreated, index.json being rebuilt from the
+// repo blobs, and the orphan upload dirs are removed, otherwise the issues are only reported.
+func (is *ImageStoreLocal) CheckConsistency(repair bool) ([]storageTypes.RepoIssue, error) {
 	var lockLatency time.Time
 
-	dir := is.rootDir
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
 
-	is.RLock(&lockLatency)
-	defer is.RUnlock(&lockLatency)
+	issues := []storageTypes.RepoIssue{}
 
-	_, err := os.ReadDir(dir)
-	if err != nil {
-		is.log.Error().Err(err).Msg("failure walking storage root-dir")
+	err := filepath.WalkDir(is.rootDir, func(dirPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
 
-		return nil, err
+		if !entry.IsDir() || dirPath == is.rootDir {
+			return nil
+		}
+
+		// blobs and uploads are checked along with the repo containing them
+		if entry.Name() == "blobs" || entry.Name() == storageConstants.BlobUploadDir {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(is.rootDir, dirPath)
+		if err != nil {
+			return nil //nolint:nilerr // ignore paths not relative to root dir
+		}
+
+		repoIssues, err := is.checkRepoConsistency(rel, repair)
+		issues = append(issues, repoIssues...)
+
+		return err
+	})
+
+	return issues, err
+}
+
+func (is *ImageStoreLocal) checkRepoConsistency(repo string, repair bool) ([]storageTypes.RepoIssue, error) {
+	dir := path.Join(is.rootDir, repo)
+	issues := []storageTypes.RepoIssue{}
+
+	if !is.DirExists(path.Join(dir, "blobs")) {
+		uploadDir := path.Join(dir, storageConstants.BlobUploadDir)
+		if !is.DirExists(uploadDir) {
+			return issues, nil
+		}
+
+		issue := storageTypes.RepoIssue{Repo: repo, Issue: common.IssueOrphanUploadDir}
+
+		if repair {
+			if err := os.RemoveAll(uploadDir); err != nil {
+				is.log.Error().Err(err).Str("dir", uploadDir).Msg("unable to remove orphan upload dir")
+
+				return issues, err
+			}
+
+			issue.Repaired = true
+		}
+
+		return append(issues, issue), nil
 	}
 
-	stores := make([]string, 0)
-	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	if !zreg.FullNameRegexp.MatchString(repo) {
+		return append(issues, storageTypes.RepoIssue{Repo: repo, Issue: common.IssueInvalidRepoName}), nil
+	}
+
+	ilPath := path.Join(dir, ispec.ImageLayoutFile)
+
+	var il ispec.ImageLayout
+
+	buf, err := os.ReadFile(ilPath)
+	if err != nil || json.Unmarshal(buf, &il) != nil || il.Version != ispec.ImageLayoutVersion {
+		issue := storageTypes.RepoIssue{Repo: repo, Issue: common.IssueBadImageLayout}
+
+		if repair {
+			buf, err := json.Marshal(ispec.ImageLayout{Version: ispec.ImageLayoutVersion})
+			if err != nil {
+				is.log.Panic().Err(err).Msg("unable to marshal JSON")
+			}
+
+			if err := is.writeFile(ilPath, buf); err != nil {
+				is.log.Error().Err(err).Str("file", ilPath).Msg("unable to write file")
+
+				return issues, err
+			}
+
+			issue.Repaired = true
+		}
+
+		issues = append(issues, issue)
+	}
+
+	indexPath := path.Join(dir, "index.json")
+
+	var index ispec.Index
+
+	buf, err = os.ReadFile(indexPath)
+	if err != nil || json.Unmarshal(buf, &index) != nil {
+		issue := storageTypes.RepoIssue{Repo: repo, Issue: common.IssueBadIndex}
+
+		if repair {
+			blobs, err := is.getRepoBlobs(repo)
+			if err != nil {
+				return issues, err
+			}
+
+			index = common.RebuildIndex(is, repo, blobs, is.log)
+
+			buf, err := json.Marshal(index)
+			if err != nil {
+				is.log.Panic().Err(err).Msg("unable to marshal JSON")
+			}
+
+			if err := is.writeFile(indexPath, buf); err != nil {
+				is.log.Error().Err(err).Str("file", indexPath).Msg("unable to write file")
+
+				return issues, err
+			}
+
+			is.log.Warn().Str("repository", repo).Int("manifests", len(index.Manifests)).
+				Msg("index.json rebuilt from blobs, the images are untagged")
+
+			issue.Repaired = true
+		}
+
+		issues = append(issues, issue)
+	}
+
+	if len(issues) == 0 {
+		if ok, err := is.ValidateRepo(repo); !ok || err != nil {
+			issues = append(issues, storageTypes.RepoIssue{Repo: repo, Issue: common.IssueInvalidRepoFiles})
+		}
+	}
+
+	return issues, nil
+}
+
+// getRepoBlobs returns the digests and sizes of all the blobs of a repo.
+func (is *ImageStoreLocal) getRepoBlobs(repo string) (map[godigest.Digest]int64, error) {
+	blobs := map[godigest.Digest]int64{}
+	blobsDir := path.Join(is.rootDir, repo, "blobs")
+
+	err := filepath.WalkDir(blobsDir, func(blobPath string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !info.IsDir() {
+		if entry.IsDir() {
 			return nil
 		}
 
-		rel, err := filepath.Rel(is.rootDir, path)
+		info, err := entry.Info()
 		if err != nil {
-			return nil //nolint:nilerr // ignore paths not relative to root dir
+			return err
 		}
 
-		if ok, err := is.ValidateRepo(rel); !ok || err != nil {
-			return nil //nolint:nilerr // ignore invalid repos
+		// the size of the shared blob, not of the link
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if info, err = os.Stat(blobPath); err != nil {
+				return nil //nolint:nilerr // a dangling link is a missing blob
+			}
+		}
+
+		digest := godigest.NewDigestFromEncoded(godigest.Algorithm(filepath.Base(filepath.Dir(blobPath))),
+			entry.Name())
+		if digest.Validate() != nil {
+			return nil
 		}
 
-		// is.log.Debug().Str("dir", path).Str("name", info.Name()).Msg("found image store")
-		stores = append(stores, rel)
+		blobs[digest] = info.Size()
+
+		return nil
+	})
+	if err != nil {
+		is.log.Error().Err(err).Str("dir", blobsDir).Msg("unable to walk blobs dir")
+	}
+
+	return blobs, err
+}
+
+// GetRepositories returns a list of all the repositories under this store.
+func (is *ImageStoreLocal) GetRepositories() ([]string, error) {
+	stores := make([]string, 0)
+
+	err := is.WalkRepositories("", func(repo string) error {
+		stores = append(stores, repo)
 
 		return nil
 	})
@@ -323,8 +559,10 @@ func (is *ImageStoreLocal) GetRepositories() ([]string, error) {
 	return stores, err
 }
 
-// GetNextRepository returns next repository under this store.
-func (is *ImageStoreLocal) GetNextRepository(repo string) (string, error) {
+// WalkRepositories calls walkFn for the repositories under this store coming after last, in the order given by
+// CompareRepoNames, without listing them all first. The walk stops at the first error returned by walkFn, which
+// is returned.
+func (is *ImageStoreLocal) WalkRepositories(last string, walkFn func(repo string) error) error {
 	var lockLatency time.Time
 
 	dir := is.rootDir
@@ -336,47 +574,55 @@ func (is *ImageStoreLocal) GetNextRepository(repo string) (string, error) {
 	if err != nil {
 		is.log.Error().Err(err).Msg("failure walking storage root-dir")
 
-		return "", err
+		return err
 	}
 
-	found := false
-	store := ""
-	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !info.IsDir() {
+		if !entry.IsDir() {
 			return nil
 		}
 
 		rel, err := filepath.Rel(is.rootDir, path)
-		if err != nil {
+		if err != nil || rel == "." {
 			return nil //nolint:nilerr // ignore paths not relative to root dir
 		}
 
-		ok, err := is.ValidateRepo(rel)
-		if !ok || err != nil {
-			return nil //nolint:nilerr // ignore invalid repos
+		if rel == storageConstants.SharedBlobsDir || common.IsRepoDirBefore(rel, last) {
+			return filepath.SkipDir
 		}
 
-		if repo == "" && ok && err == nil {
-			store = rel
-
-			return io.EOF
+		// the blobs of a repo can't contain other repos
+		if name := entry.Name(); name == "blobs" || name == storageConstants.BlobUploadDir {
+			if ok, err := is.ValidateRepo(filepath.Dir(rel)); ok && err == nil {
+				return filepath.SkipDir
+			}
 		}
 
-		if found {
-			store = rel
-
-			return io.EOF
+		// the dirs containing last are walked through
+		if last != "" && common.CompareRepoNames(rel, last) <= 0 {
+			return nil
 		}
 
-		if rel == repo {
-			found = true
+		if ok, err := is.ValidateRepo(rel); !ok || err != nil {
+			return nil //nolint:nilerr // ignore invalid repos
 		}
 
-		return nil
+		return walkFn(rel)
+	})
+}
+
+// GetNextRepository returns next repository under this store.
+func (is *ImageStoreLocal) GetNextRepository(repo string) (string, error) {
+	store := ""
+
+	err := is.WalkRepositories(repo, func(rel string) error {
+		store = rel
+
+		return io.EOF
 	})
 
 	return store, err
@@ -481,6 +727,12 @@ func (is *ImageStoreLocal) PutImageManifest(repo, reference, mediaType string, /
 		return "", "", err
 	}
 
+	if !refIsDigest {
+		if err := common.CheckTagsCanMove(index, repo, []string{reference}, mDigest, is.immutableTags); err != nil {
+			return "", "", err
+		}
+	}
+
 	// create a new descriptor
 	desc := ispec.Descriptor{
 		MediaType: mediaType, Size: int64(len(body)), Digest: mDigest,
@@ -488,13 +740,17 @@ func (is *ImageStoreLocal) PutImageManifest(repo, reference, mediaType string, /
 
 	if !refIsDigest {
 		desc.Annotations = map[string]string{ispec.AnnotationRefName: reference}
+
+		common.SetTagPushedAt(&desc, time.Now())
 	}
 
+	common.SetOriginalDigest(&desc, body)
+
 	var subjectDigest godigest.Digest
 
 	artifactType := ""
 
-	if mediaType == ispec.MediaTypeImageManifest {
+	if zcommon.IsImageManifest(mediaType) {
 		var manifest ispec.Manifest
 
 		err := json.Unmarshal(body, &manifest)
@@ -565,7 +821,10 @@ func (is *ImageStoreLocal) PutImageManifest(repo, reference, mediaType string, /
 		return "", "", err
 	}
 
-	if is.gc {
+	// the blobs are referenced by the manifest from now on
+	is.releaseUploads(repo, mediaType, body)
+
+	if is.gc && !is.isLeased() {
 		if err := is.garbageCollect(dir, repo); err != nil {
 			return "", "", err
 		}
@@ -577,8 +836,62 @@ func (is *ImageStoreLocal) PutImageManifest(repo, reference, mediaType string, /
 	return desc.Digest, subjectDigest, nil
 }
 
+// TagImageManifest makes the tags point to a manifest of the repository, index.json is written once
+// so either all or none of the tags are updated.
+func (is *ImageStoreLocal) TagImageManifest(repo string, digest godigest.Digest, tags []string) error {
+	var lockLatency time.Time
+
+	dir := path.Join(is.rootDir, repo)
+	if !is.DirExists(dir) {
+		return zerr.ErrRepoNotFound
+	}
+
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
+
+	index, err := common.GetIndex(is, repo, is.log)
+	if err != nil {
+		return err
+	}
+
+	if err := common.CheckTagsCanMove(index, repo, tags, digest, is.immutableTags); err != nil {
+		return err
+	}
+
+	updated, err := common.AddTagsToIndex(is, &index, repo, digest, tags, is.log)
+	if err != nil || !updated {
+		return err
+	}
+
+	file := path.Join(dir, "index.json")
+
+	buf, err := json.Marshal(index)
+	if err != nil {
+		is.log.Error().Err(err).Str("file", file).Msg("unable to marshal JSON")
+
+		return err
+	}
+
+	if err := is.writeFile(file, buf); err != nil {
+		is.log.Error().Err(err).Str("file", file).Msg("unable to write")
+
+		return err
+	}
+
+	return nil
+}
+
 // DeleteImageManifest deletes the image manifest from the repository.
 func (is *ImageStoreLocal) DeleteImageManifest(repo, reference string, detectCollision bool) error {
+	return is.deleteImageManifest(repo, reference, detectCollision, true)
+}
+
+// PurgeImageManifest deletes the image manifest from the repository even if immutable tags point to it.
+func (is *ImageStoreLocal) PurgeImageManifest(repo, reference string) error {
+	return is.deleteImageManifest(repo, reference, false, false)
+}
+
+func (is *ImageStoreLocal) deleteImageManifest(repo, reference string, detectCollision, checkImmutable bool) error {
 	var lockLatency time.Time
 
 	dir := path.Join(is.rootDir, repo)
@@ -594,6 +907,12 @@ func (is *ImageStoreLocal) DeleteImageManifest(repo, reference string, detectCol
 		return err
 	}
 
+	if checkImmutable {
+		if err := common.CheckTagsCanBeDeleted(index, repo, reference, is.immutableTags); err != nil {
+			return err
+		}
+	}
+
 	manifestDesc, err := common.RemoveManifestDescByReference(&index, reference, detectCollision)
 	if err != nil {
 		return err
@@ -617,7 +936,7 @@ func (is *ImageStoreLocal) DeleteImageManifest(repo, reference string, detectCol
 		return err
 	}
 
-	if is.gc {
+	if is.gc && !is.isLeased() {
 		if err := is.garbageCollect(dir, repo); err != nil {
 			return err
 		}
@@ -725,20 +1044,21 @@ func (is *ImageStoreLocal) PutBlobChunkStreamed(repo, uuid string, body io.Reade
 	}
 
 	defer func() {
-		if is.commit {
-			_ = file.Sync()
+		if is.commitPolicy == storageConstants.CommitPolicyAlways {
+			_ = is.syncFile(file, "blob")
 		}
 
 		_ = file.Close()
 	}()
 
-	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
 		is.log.Error().Err(err).Msg("failed to seek file")
 
 		return -1, err
 	}
 
-	n, err := io.Copy(file, body)
+	n, err := is.copyUpload(file, offset, body)
 
 	return n, err
 }
@@ -774,8 +1094,8 @@ func (is *ImageStoreLocal) PutBlobChunk(repo, uuid string, from, to int64,
 	}
 
 	defer func() {
-		if is.commit {
-			_ = file.Sync()
+		if is.commitPolicy == storageConstants.CommitPolicyAlways {
+			_ = is.syncFile(file, "blob")
 		}
 
 		_ = file.Close()
@@ -787,7 +1107,9 @@ func (is *ImageStoreLocal) PutBlobChunk(repo, uuid string, from, to int64,
 		return -1, err
 	}
 
-	n, err := io.Copy(file, body)
+	is.preallocateUpload(file, from, to)
+
+	n, err := is.copyUpload(file, from, body)
 
 	return n, err
 }
@@ -867,7 +1189,11 @@ func (is *ImageStoreLocal) FinishBlobUpload(repo, uuid string, body io.Reader, d
 
 	dst := is.BlobPath(repo, dstDigest)
 
-	if is.dedupe && fmt.Sprintf("%v", is.cache) != fmt.Sprintf("%v", nil) {
+	if is.sharedBlobs {
+		if err := is.shareBlob(src, dstDigest, dst); err != nil {
+			return err
+		}
+	} else if is.dedupe && fmt.Sprintf("%v", is.cache) != fmt.Sprintf("%v", nil) {
 		err = is.DedupeBlob(src, dstDigest, dst)
 		if err := inject.Error(err); err != nil {
 			is.log.Error().Err(err).Str("src", src).Str("dstDigest", dstDigest.String()).
@@ -884,6 +1210,9 @@ func (is *ImageStoreLocal) FinishBlobUpload(repo, uuid string, body io.Reader, d
 		}
 	}
 
+	is.markDirty(dst)
+	is.holdUpload(repo, dstDigest)
+
 	return nil
 }
 
@@ -915,17 +1244,16 @@ func (is *ImageStoreLocal) FullBlobUpload(repo string, body io.Reader, dstDigest
 	}
 
 	defer func() {
-		if is.commit {
-			_ = blobFile.Sync()
+		if is.commitPolicy == storageConstants.CommitPolicyAlways {
+			_ = is.syncFile(blobFile, "blob")
 		}
 
 		_ = blobFile.Close()
 	}()
 
 	digester := sha256.New()
-	mw := io.MultiWriter(blobFile, digester)
 
-	nbytes, err := io.Copy(mw, body)
+	nbytes, err := is.copyUpload(blobFile, 0, io.TeeReader(body, digester))
 	if err != nil {
 		return "", -1, err
 	}
@@ -948,7 +1276,11 @@ func (is *ImageStoreLocal) FullBlobUpload(repo string, body io.Reader, dstDigest
 	_ = ensureDir(dir, is.log)
 	dst := is.BlobPath(repo, dstDigest)
 
-	if is.dedupe && fmt.Sprintf("%v", is.cache) != fmt.Sprintf("%v", nil) {
+	if is.sharedBlobs {
+		if err := is.shareBlob(src, dstDigest, dst); err != nil {
+			return "", -1, err
+		}
+	} else if is.dedupe && fmt.Sprintf("%v", is.cache) != fmt.Sprintf("%v", nil) {
 		if err := is.DedupeBlob(src, dstDigest, dst); err != nil {
 			is.log.Error().Err(err).Str("src", src).Str("dstDigest", dstDigest.String()).
 				Str("dst", dst).Msg("unable to dedupe blob")
@@ -964,6 +1296,9 @@ func (is *ImageStoreLocal) FullBlobUpload(repo string, body io.Reader, dstDigest
 		}
 	}
 
+	is.markDirty(dst)
+	is.holdUpload(repo, dstDigest)
+
 	return uuid, nbytes, nil
 }
 
@@ -1029,10 +1364,21 @@ retry:
 				return err
 			}
 
-			is.log.Debug().Str("blobPath", dst).Str("dstRecord", dstRecord).Msg("dedupe: creating hard link")
+			if is.nfs {
+				// hard links aren't reliable on NFS, keep the uploaded copy
+				if err := os.Rename(src, dst); err != nil {
+					is.log.Error().Err(err).Str("src", src).Str("dst", dst).Msg("dedupe: unable to rename blob")
+
+					return err
+				}
+
+				return is.cache.PutBlob(dstDigest, dst)
+			}
+
+			is.log.Debug().Str("blobPath", dst).Str("dstRecord", dstRecord).Msg("dedupe: creating link")
 
-			if err := os.Link(dstRecord, dst); err != nil {
-				is.log.Error().Err(err).Str("blobPath", dst).Str("link", dstRecord).Msg("dedupe: unable to hard link")
+			if err := is.linkBlob(dstRecord, dst); err != nil {
+				is.log.Error().Err(err).Str("blobPath", dst).Str("link", dstRecord).Msg("dedupe: unable to link")
 
 				return err
 			}
@@ -1084,7 +1430,7 @@ func (is *ImageStoreLocal) CheckBlob(repo string, digest godigest.Digest) (bool,
 
 	blobPath := is.BlobPath(repo, digest)
 
-	if is.dedupe && fmt.Sprintf("%v", is.cache) != fmt.Sprintf("%v", nil) {
+	if is.sharedBlobs || is.dedupe && fmt.Sprintf("%v", is.cache) != fmt.Sprintf("%v", nil) {
 		is.Lock(&lockLatency)
 		defer is.Unlock(&lockLatency)
 	} else {
@@ -1099,6 +1445,17 @@ func (is *ImageStoreLocal) CheckBlob(repo string, digest godigest.Digest) (bool,
 		return true, binfo.Size(), nil
 	}
 
+	if is.sharedBlobs {
+		is.log.Debug().Err(err).Str("blob", blobPath).Msg("failed to find blob, searching it in shared blobs")
+
+		blobSize, err := is.checkSharedBlob(repo, digest, blobPath)
+		if err != nil {
+			return false, -1, err
+		}
+
+		return true, blobSize, nil
+	}
+
 	is.log.Debug().Err(err).Str("blob", blobPath).Msg("failed to find blob, searching it in cache")
 
 	// Check blobs in cache
@@ -1122,6 +1479,55 @@ func (is *ImageStoreLocal) CheckBlob(repo string, digest godigest.Digest) (bool,
 	return true, blobSize, nil
 }
 
+// MountBlob links a blob of fromRepo in repo, e.g. when it's mounted from another repo, without looking for it in
+// the other repos like CheckBlob does.
+func (is *ImageStoreLocal) MountBlob(repo, fromRepo string, digest godigest.Digest) (int64, error) {
+	var lockLatency time.Time
+
+	if err := digest.Validate(); err != nil {
+		return -1, err
+	}
+
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
+
+	blobPath := is.BlobPath(repo, digest)
+
+	if binfo, err := os.Stat(blobPath); err == nil {
+		return binfo.Size(), nil
+	}
+
+	srcPath := is.BlobPath(fromRepo, digest)
+
+	if _, err := os.Stat(srcPath); err != nil {
+		is.log.Debug().Err(err).Str("blob", srcPath).Msg("failed to find blob to mount")
+
+		return -1, zerr.ErrBlobNotFound
+	}
+
+	// mounted blobs are pushed before their manifest like uploaded ones
+	is.holdUpload(repo, digest)
+
+	if is.sharedBlobs {
+		return is.checkSharedBlob(repo, digest, blobPath)
+	}
+
+	blobSize, err := is.copyBlob(repo, blobPath, srcPath)
+	if err != nil {
+		return -1, err
+	}
+
+	if is.dedupe && fmt.Sprintf("%v", is.cache) != fmt.Sprintf("%v", nil) {
+		if err := is.cache.PutBlob(digest, blobPath); err != nil {
+			is.log.Error().Err(err).Str("blobPath", blobPath).Msg("dedupe: unable to insert blob record")
+
+			return -1, err
+		}
+	}
+
+	return blobSize, nil
+}
+
 func (is *ImageStoreLocal) checkCacheBlob(digest godigest.Digest) (string, error) {
 	if err := digest.Validate(); err != nil {
 		return "", err
@@ -1172,8 +1578,15 @@ func (is *ImageStoreLocal) copyBlob(repo, blobPath, dstRecord string) (int64, er
 
 	_ = ensureDir(filepath.Dir(blobPath), is.log)
 
-	if err := os.Link(dstRecord, blobPath); err != nil {
-		is.log.Error().Err(err).Str("blobPath", blobPath).Str("link", dstRecord).Msg("dedupe: unable to hard link")
+	if is.nfs {
+		// hard links aren't reliable on NFS
+		if err := is.copyFile(dstRecord, blobPath); err != nil {
+			is.log.Error().Err(err).Str("blobPath", blobPath).Str("src", dstRecord).Msg("dedupe: unable to copy blob")
+
+			return -1, zerr.ErrBlobNotFound
+		}
+	} else if err := is.linkBlob(dstRecord, blobPath); err != nil {
+		is.log.Error().Err(err).Str("blobPath", blobPath).Str("link", dstRecord).Msg("dedupe: unable to link")
 
 		return -1, zerr.ErrBlobNotFound
 	}
@@ -1290,6 +1703,8 @@ func (is *ImageStoreLocal) GetBlob(repo string, digest godigest.Digest, mediaTyp
 		return nil, -1, err
 	}
 
+	is.adviseReadAhead(blobReadCloser)
+
 	// The caller function is responsible for calling Close()
 	return blobReadCloser, binfo.Size(), nil
 }
@@ -1397,18 +1812,34 @@ func (is *ImageStoreLocal) GetOrasReferrers(repo string, gdigest godigest.Digest
 }
 
 func (is *ImageStoreLocal) writeFile(filename string, data []byte) error {
-	if !is.commit {
-		return os.WriteFile(filename, data, storageConstants.DefaultFilePerms)
+	// on NFS other instances must not read partially written files
+	if is.nfs {
+		return is.writeFileAtomically(filename, data)
 	}
 
-	fhandle, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, storageConstants.DefaultFilePerms)
-	if err != nil {
-		return err
-	}
+	return is.writeFileContent(filename, data)
+}
+
+func (is *ImageStoreLocal) writeFileContent(filename string, data []byte) error {
+	if is.commitPolicy != storageConstants.CommitPolicyAlways &&
+		is.commitPolicy != storageConstants.CommitPolicyManifest {
+		if err := os.WriteFile(filename, data, storageConstants.DefaultFilePerms); err != nil {
+			return err
+		}
+
+		is.markDirty(filename)
+
+		return nil
+	}
+
+	fhandle, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, storageConstants.DefaultFilePerms)
+	if err != nil {
+		return err
+	}
 
 	_, err = fhandle.Write(data)
 
-	if err1 := inject.Error(fhandle.Sync()); err1 != nil && err == nil {
+	if err1 := inject.Error(is.syncFile(fhandle, "metadata")); err1 != nil && err == nil {
 		err = err1
 		is.log.Error().Err(err).Str("filename", filename).Msg("unable to sync file")
 	}
@@ -1420,6 +1851,112 @@ func (is *ImageStoreLocal) writeFile(filename string, data []byte) error {
 	return err
 }
 
+// syncFile flushes a file to disk, fileType is either blob or metadata (manifests and indexes).
+func (is *ImageStoreLocal) syncFile(file *os.File, fileType string) error {
+	start := time.Now()
+
+	err := file.Sync()
+
+	monitoring.ObserveStorageFsyncLatency(is.metrics, time.Since(start), is.RootDir(), fileType) // histogram
+
+	return err
+}
+
+// markDirty records a written file to be flushed by the next periodic commit.
+func (is *ImageStoreLocal) markDirty(filename string) {
+	if is.commitPolicy != storageConstants.CommitPolicyPeriodic {
+		return
+	}
+
+	is.dirtyLock.Lock()
+	defer is.dirtyLock.Unlock()
+
+	is.dirtyFiles[filename] = true
+}
+
+// Commit flushes to disk the files written since the last commit, with the periodic commit policy.
+func (is *ImageStoreLocal) Commit() error {
+	if is.commitPolicy != storageConstants.CommitPolicyPeriodic {
+		return nil
+	}
+
+	// the map is emptied in place as it's shared with the copies of the store, see WithLogger
+	is.dirtyLock.Lock()
+	dirtyFiles := make([]string, 0, len(is.dirtyFiles))
+
+	for filename := range is.dirtyFiles {
+		dirtyFiles = append(dirtyFiles, filename)
+		delete(is.dirtyFiles, filename)
+	}
+	is.dirtyLock.Unlock()
+
+	var err error
+
+	for _, filename := range dirtyFiles {
+		file, openErr := os.Open(filename)
+		if openErr != nil {
+			// removed since it was written, e.g. by gc
+			if os.IsNotExist(openErr) {
+				continue
+			}
+
+			err = openErr
+
+			continue
+		}
+
+		if syncErr := is.syncFile(file, "periodic"); syncErr != nil {
+			is.log.Error().Err(syncErr).Str("filename", filename).Msg("unable to sync file")
+
+			err = syncErr
+		}
+
+		_ = file.Close()
+	}
+
+	return err
+}
+
+// RunCommitPeriodically flushes written files to disk every interval, with the periodic commit policy.
+func (is *ImageStoreLocal) RunCommitPeriodically(interval time.Duration, sch *scheduler.Scheduler) {
+	if is.commitPolicy != storageConstants.CommitPolicyPeriodic {
+		return
+	}
+
+	generator := &commitTaskGenerator{
+		imgStore: is,
+	}
+
+	sch.SubmitGenerator(generator, interval, scheduler.HighPriority)
+}
+
+type commitTaskGenerator struct {
+	imgStore *ImageStoreLocal
+	done     bool
+}
+
+func (gen *commitTaskGenerator) Next() (scheduler.Task, error) {
+	gen.done = true
+
+	return &commitTask{gen.imgStore}, nil
+}
+
+func (gen *commitTaskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *commitTaskGenerator) Reset() {
+	gen.done = false
+}
+
+type commitTask struct {
+	imgStore *ImageStoreLocal
+}
+
+func (task *commitTask) DoWork() error {
+	return task.imgStore.Commit()
+}
+
 func ValidateHardLink(rootDir string) error {
 	if err := os.MkdirAll(rootDir, storageConstants.DefaultDirPerms); err != nil {
 		return err
@@ -1480,32 +2017,22 @@ func (is *ImageStoreLocal) garbageCollect(dir string, repo string) error {
 		return err
 	}
 
-	referencedByImageIndex := []string{}
 	cosignDescriptors := []ispec.Descriptor{}
 	notationManifests := []extendedManifest{}
+	referrersTagDescriptors := []ispec.Descriptor{}
 
-	/* gather manifests references by multiarch images (to skip gc)
-	gather cosign and notation signatures descriptors */
+	// gather cosign and notation signatures descriptors and referrers tags
 	for _, desc := range index.Manifests {
 		switch desc.MediaType {
-		case ispec.MediaTypeImageIndex:
-			indexImage, err := common.GetImageIndex(is, repo, desc.Digest, is.log)
-			if err != nil {
-				is.log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
-					Msg("gc: failed to read multiarch(index) image")
-
-				return err
+		case ispec.MediaTypeImageIndex, zcommon.MediaTypeDockerManifestList:
+			if _, ok := common.GetReferrersTagSubject(desc.Annotations[ispec.AnnotationRefName]); ok {
+				referrersTagDescriptors = append(referrersTagDescriptors, desc)
 			}
-
-			for _, indexDesc := range indexImage.Manifests {
-				referencedByImageIndex = append(referencedByImageIndex, indexDesc.Digest.String())
-			}
-		case ispec.MediaTypeImageManifest:
+		case ispec.MediaTypeImageManifest, zcommon.MediaTypeDockerManifest:
 			tag, ok := desc.Annotations[ispec.AnnotationRefName]
 			if ok {
 				// gather cosign references
-				if strings.HasPrefix(tag, "sha256-") && (strings.HasSuffix(tag, remote.SignatureTagSuffix) ||
-					strings.HasSuffix(tag, remote.SBOMTagSuffix)) {
+				if common.IsCosignTag(tag) {
 					cosignDescriptors = append(cosignDescriptors, desc)
 
 					continue
@@ -1531,9 +2058,16 @@ func (is *ImageStoreLocal) garbageCollect(dir string, repo string) error {
 		}
 	}
 
+	/* mark the manifests reachable from the tags and image indexes, through nested indexes and referrers,
+	so that a manifest only referenced by an index or by the subject of a referrer isn't removed */
+	reachable, err := common.GetReachableManifests(is, repo, index, is.pins, is.log)
+	if err != nil {
+		return err
+	}
+
 	is.log.Info().Msg("gc: untagged manifests")
 
-	if err := gcUntaggedManifests(is, oci, &index, repo, referencedByImageIndex); err != nil {
+	if err := gcUntaggedManifests(is, oci, &index, repo, reachable); err != nil {
 		return err
 	}
 
@@ -1549,6 +2083,17 @@ func (is *ImageStoreLocal) garbageCollect(dir string, repo string) error {
 		return err
 	}
 
+	is.log.Info().Msg("gc: referrers tags")
+
+	if err := gcReferrersTags(is, oci, &index, repo, referrersTagDescriptors, reachable); err != nil {
+		return err
+	}
+
+	// the blobs are swept in batches by the incremental gc, see gcBlobsBatch
+	if is.gcBatchSize > 0 {
+		return nil
+	}
+
 	is.log.Info().Msg("gc: blobs")
 
 	err = oci.GC(context.Background(), ifOlderThan(is, repo, is.gcDelay))
@@ -1560,68 +2105,69 @@ func (is *ImageStoreLocal) garbageCollect(dir string, repo string) error {
 }
 
 func gcUntaggedManifests(imgStore *ImageStoreLocal, oci casext.Engine, index *ispec.Index, repo string,
-	referencedByImageIndex []string,
+	reachable map[godigest.Digest]bool,
 ) error {
 	for _, desc := range index.Manifests {
-		// skip manifests referenced in image indexex
-		if zcommon.Contains(referencedByImageIndex, desc.Digest.String()) {
+		// skip manifests reachable from tags, pins, image indexes or as referrers, tagged ones are always reachable
+		if reachable[desc.Digest] {
 			continue
 		}
 
-		// remove untagged images
-		if desc.MediaType == ispec.MediaTypeImageManifest {
-			_, ok := desc.Annotations[ispec.AnnotationRefName]
-			if !ok {
-				// check if is indeed an image and not an artifact by checking it's config blob
-				buf, err := imgStore.GetBlobContent(repo, desc.Digest)
-				if err != nil {
-					imgStore.log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
-						Msg("gc: failed to read image manifest")
+		// remove unreachable images and image indexes
+		if !zcommon.IsImageManifest(desc.MediaType) && !zcommon.IsImageIndex(desc.MediaType) {
+			continue
+		}
 
-					return err
-				}
+		if zcommon.IsImageManifest(desc.MediaType) {
+			// check if is indeed an image and not an artifact by checking it's config blob
+			buf, err := imgStore.GetBlobContent(repo, desc.Digest)
+			if err != nil {
+				imgStore.log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
+					Msg("gc: failed to read image manifest")
+
+				return err
+			}
 
-				manifest := ispec.Manifest{}
+			manifest := ispec.Manifest{}
 
-				err = json.Unmarshal(buf, &manifest)
-				if err != nil {
-					return err
-				}
+			err = json.Unmarshal(buf, &manifest)
+			if err != nil {
+				return err
+			}
 
-				// skip manifests which are not of type image
-				if manifest.Config.MediaType != ispec.MediaTypeImageConfig {
-					imgStore.log.Info().Str("config mediaType", manifest.Config.MediaType).
-						Msg("skipping gc untagged manifest, because config blob is not application/vnd.oci.image.config.v1+json")
+			// skip manifests which are not of type image
+			if !zcommon.IsImageConfig(manifest.Config.MediaType) {
+				imgStore.log.Info().Str("config mediaType", manifest.Config.MediaType).
+					Msg("skipping gc untagged manifest, because config blob is not an oci or docker image config")
 
-					continue
-				}
+				continue
+			}
+		}
 
-				// remove manifest if it's older than gc.delay
-				canGC, err := isBlobOlderThan(imgStore, repo, desc.Digest, imgStore.gcDelay)
-				if err != nil {
-					imgStore.log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
-						Str("delay", imgStore.gcDelay.String()).Msg("gc: failed to check if blob is older than delay")
+		// remove manifest if it's older than gc.delay
+		canGC, err := isBlobOlderThan(imgStore, repo, desc.Digest, imgStore.gcDelay)
+		if err != nil {
+			imgStore.log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
+				Str("delay", imgStore.gcDelay.String()).Msg("gc: failed to check if blob is older than delay")
 
-					return err
-				}
+			return err
+		}
 
-				if canGC {
-					imgStore.log.Info().Str("repository", repo).Str("digest", desc.Digest.String()).
-						Msg("gc: removing manifest without tag")
+		if canGC {
+			imgStore.log.Info().Str("repository", repo).Str("digest", desc.Digest.String()).
+				Msg("gc: removing manifest without tag")
 
-					_, err = common.RemoveManifestDescByReference(index, desc.Digest.String(), true)
-					if errors.Is(err, zerr.ErrManifestConflict) {
-						imgStore.log.Info().Str("repository", repo).Str("digest", desc.Digest.String()).
-							Msg("gc: skipping removing manifest due to conflict")
+			_, err = common.RemoveManifestDescByReference(index, desc.Digest.String(), true)
+			if errors.Is(err, zerr.ErrManifestConflict) {
+				imgStore.log.Info().Str("repository", repo).Str("digest", desc.Digest.String()).
+					Msg("gc: skipping removing manifest due to conflict")
 
-						continue
-					}
+				continue
+			}
 
-					err := oci.PutIndex(context.Background(), *index)
-					if err != nil {
-						return err
-					}
-				}
+			err := oci.PutIndex(context.Background(), *index)
+			if err != nil {
+				return err
 			}
 		}
 	}
@@ -1636,20 +2182,18 @@ func gcCosignReferences(imgStore *ImageStoreLocal, oci casext.Engine, index *isp
 		foundSubject := false
 		// check if we can find the manifest which the reference points to
 		for _, desc := range index.Manifests {
-			// signature
-			subject := fmt.Sprintf("sha256-%s.%s", desc.Digest.Encoded(), remote.SignatureTagSuffix)
-			if subject == cosignDesc.Annotations[ispec.AnnotationRefName] {
-				foundSubject = true
-			}
-
-			// sbom
-			subject = fmt.Sprintf("sha256-%s.%s", desc.Digest.Encoded(), remote.SBOMTagSuffix)
-			if subject == cosignDesc.Annotations[ispec.AnnotationRefName] {
-				foundSubject = true
+			// signature, sbom or attestations
+			for _, suffix := range []string{
+				remote.SignatureTagSuffix, remote.SBOMTagSuffix, remote.AttestationTagSuffix,
+			} {
+				subject := fmt.Sprintf("sha256-%s.%s", desc.Digest.Encoded(), suffix)
+				if subject == cosignDesc.Annotations[ispec.AnnotationRefName] {
+					foundSubject = true
+				}
 			}
 		}
 
-		if !foundSubject {
+		if !foundSubject && !imgStore.isLeaseHeld() {
 			// remove manifest
 			imgStore.log.Info().Str("repository", repo).Str("digest", cosignDesc.Digest.String()).
 				Msg("gc: removing cosign reference without subject")
@@ -1667,6 +2211,44 @@ func gcCosignReferences(imgStore *ImageStoreLocal, oci casext.Engine, index *isp
 	return nil
 }
 
+/*
+gcReferrersTags removes the referrers tags whose subject is no longer in the repo, the indexes listing the
+referrers of a manifest which clients push when the referrers API isn't supported, so they don't pile up in
+the tags of the repo. The referrers themselves are removed like any other manifest.
+*/
+func gcReferrersTags(imgStore *ImageStoreLocal, oci casext.Engine, index *ispec.Index, repo string,
+	referrersTagDescriptors []ispec.Descriptor, reachable map[godigest.Digest]bool,
+) error {
+	for _, referrersTagDesc := range referrersTagDescriptors {
+		tag := referrersTagDesc.Annotations[ispec.AnnotationRefName]
+
+		subject, _ := common.GetReferrersTagSubject(tag)
+
+		foundSubject := reachable[subject]
+
+		for _, desc := range index.Manifests {
+			if desc.Digest == subject {
+				foundSubject = true
+			}
+		}
+
+		if !foundSubject && !imgStore.isLeaseHeld() {
+			imgStore.log.Info().Str("repository", repo).Str("tag", tag).Str("digest", referrersTagDesc.Digest.String()).
+				Msg("gc: removing referrers tag without subject")
+
+			// other tags of the same index are kept
+			_, _ = common.RemoveManifestDescByReference(index, tag, false)
+
+			err := oci.PutIndex(context.Background(), *index)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func gcNotationSignatures(imgStore *ImageStoreLocal, oci casext.Engine, index *ispec.Index, repo string,
 	notationManifests []extendedManifest,
 ) error {
@@ -1679,7 +2261,7 @@ func gcNotationSignatures(imgStore *ImageStoreLocal, oci casext.Engine, index *i
 			}
 		}
 
-		if !foundSubject {
+		if !foundSubject && !imgStore.isLeaseHeld() {
 			// remove manifest
 			imgStore.log.Info().Str("repository", repo).Str("digest", notationManifest.Digest.String()).
 				Msg("gc: removing notation signature without subject")
@@ -1705,9 +2287,31 @@ func ifOlderThan(imgStore *ImageStoreLocal, repo string, delay time.Duration) ca
 
 func isBlobOlderThan(imgStore *ImageStoreLocal, repo string, digest godigest.Digest, delay time.Duration,
 ) (bool, error) {
+	// leased since gc started
+	if imgStore.isLeaseHeld() {
+		imgStore.log.Debug().Str("repository", repo).Str("digest", digest.String()).
+			Msg("gc: skipping blob, the storage is leased by an external reader")
+
+		return false, nil
+	}
+
+	// pushed or synced before the manifest referencing it, whatever its age
+	if imgStore.inFlight != nil && imgStore.inFlight.IsInFlight(repo, digest) {
+		imgStore.log.Debug().Str("repository", repo).Str("digest", digest.String()).
+			Msg("gc: skipping in-flight blob")
+
+		return false, nil
+	}
+
 	blobPath := imgStore.BlobPath(repo, digest)
 
-	fileInfo, err := os.Stat(blobPath)
+	stat := os.Stat
+	if imgStore.sharedBlobs {
+		// the age of the link, a shared blob may be linked long after it's been pushed
+		stat = os.Lstat
+	}
+
+	fileInfo, err := stat(blobPath)
 	if err != nil {
 		imgStore.log.Error().Err(err).Str("digest", digest.String()).Str("blobPath", blobPath).
 			Msg("gc: failed to stat blob")
@@ -1724,26 +2328,602 @@ func isBlobOlderThan(imgStore *ImageStoreLocal, repo string, digest godigest.Dig
 	return true, nil
 }
 
-func (is *ImageStoreLocal) gcRepo(repo string) error {
+/*
+gcRepo collects the garbage of a repo. With incremental gc its blobs are then swept in batches, releasing the
+lock between them, by gcRepo itself if sweepBlobs is set, otherwise by the gc tasks.
+*/
+func (is *ImageStoreLocal) gcRepo(repo string, sweepBlobs bool) error {
+	if is.isLeased() {
+		is.log.Info().Str("repository", repo).Msg("gc: skipped, the storage is leased by an external reader")
+
+		return nil
+	}
+
 	dir := path.Join(is.RootDir(), repo)
 
 	var lockLatency time.Time
 
 	is.Lock(&lockLatency)
-	err := is.garbageCollect(dir, repo)
+
+	err := is.applyRetention(dir, repo)
+	if err == nil {
+		err = is.garbageCollect(dir, repo)
+	}
+
 	is.Unlock(&lockLatency)
 
 	if err != nil {
 		return err
 	}
 
+	if is.gcBatchSize > 0 && sweepBlobs {
+		blobs, err := is.getGCBlobs(repo)
+		if err != nil {
+			return err
+		}
+
+		for len(blobs) > 0 {
+			batch := blobs[:is.getGCBatchLen(blobs)]
+			blobs = blobs[len(batch):]
+
+			if err := is.gcBlobsBatch(repo, batch, len(blobs) == 0); err != nil {
+				return err
+			}
+		}
+	}
+
+	if is.gcVerifyPercent > 0 {
+		is.verifyBlobsSample(repo)
+	}
+
 	return nil
 }
 
+// SetGCBatchSize sets the number of blobs swept by each incremental gc task, with 0 the blobs of a repo are
+// swept at once, holding the lock.
+func (is *ImageStoreLocal) SetGCBatchSize(size int) {
+	is.gcBatchSize = size
+}
+
+func (is *ImageStoreLocal) getGCBatchLen(blobs []godigest.Digest) int {
+	if len(blobs) < is.gcBatchSize {
+		return len(blobs)
+	}
+
+	return is.gcBatchSize
+}
+
+/*
+getGCBlobs returns the blobs of a repo the incremental gc has to sweep, sorted by digest, starting after the
+checkpoint recorded in the cache if the previous sweep of the repo didn't complete.
+*/
+func (is *ImageStoreLocal) getGCBlobs(repo string) ([]godigest.Digest, error) {
+	checkpoint := is.getGCCheckpoint(repo)
+
+	var lockLatency time.Time
+
+	is.RLock(&lockLatency)
+	defer is.RUnlock(&lockLatency)
+
+	blobsDir := path.Join(is.rootDir, repo, "blobs")
+
+	// os.ReadDir sorts the entries, so are the digests
+	algorithms, err := os.ReadDir(blobsDir)
+	if err != nil {
+		is.log.Error().Err(err).Str("repository", repo).Msg("gc: unable to list blobs")
+
+		return nil, err
+	}
+
+	blobs := []godigest.Digest{}
+
+	for _, algorithm := range algorithms {
+		if !algorithm.IsDir() {
+			continue
+		}
+
+		entries, err := os.ReadDir(path.Join(blobsDir, algorithm.Name()))
+		if err != nil {
+			is.log.Error().Err(err).Str("repository", repo).Msg("gc: unable to list blobs")
+
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			digest := godigest.NewDigestFromEncoded(godigest.Algorithm(algorithm.Name()), entry.Name())
+
+			if entry.IsDir() || digest.Validate() != nil || digest.String() <= checkpoint.String() {
+				continue
+			}
+
+			blobs = append(blobs, digest)
+		}
+	}
+
+	return blobs, nil
+}
+
+/*
+gcBlobsBatch removes the blobs of a batch which aren't referenced by the manifests of the repo and are older than
+the gc delay, holding the lock for this batch only so that pushes aren't starved. The last blob of the batch is
+recorded as the checkpoint of the repo, cleared after its last batch.
+*/
+func (is *ImageStoreLocal) gcBlobsBatch(repo string, batch []godigest.Digest, last bool) error {
+	if is.isLeased() {
+		is.log.Info().Str("repository", repo).Msg("gc: skipped, the storage is leased by an external reader")
+
+		return nil
+	}
+
+	var lockLatency time.Time
+
+	is.Lock(&lockLatency)
+
+	err := is.sweepBlobs(repo, batch, last)
+
+	is.Unlock(&lockLatency)
+
+	if err != nil {
+		return err
+	}
+
+	if last {
+		is.putGCCheckpoint(repo, "")
+	} else {
+		is.putGCCheckpoint(repo, batch[len(batch)-1])
+	}
+
+	return nil
+}
+
+// sweepBlobs removes the unreferenced blobs of a batch, SHOULD lock from outside.
+func (is *ImageStoreLocal) sweepBlobs(repo string, batch []godigest.Digest, last bool) error {
+	indexContent, err := is.GetIndexContent(repo)
+	if err != nil {
+		return err
+	}
+
+	indexDigest := godigest.FromBytes(indexContent)
+
+	// the referenced blobs are computed again only if manifests were pushed or removed since the previous batch
+	mark, ok := is.gcMarks[repo]
+	if !ok || mark.indexDigest != indexDigest {
+		var index ispec.Index
+
+		if err := json.Unmarshal(indexContent, &index); err != nil {
+			is.log.Error().Err(err).Str("repository", repo).Msg("gc: invalid JSON")
+
+			return err
+		}
+
+		referenced, err := common.GetReferencedBlobs(is, repo, index, is.log)
+		if err != nil {
+			return err
+		}
+
+		mark = gcMark{indexDigest: indexDigest, referenced: referenced}
+
+		is.gcMarks[repo] = mark
+	}
+
+	if last {
+		delete(is.gcMarks, repo)
+	}
+
+	for _, digest := range batch {
+		if mark.referenced[digest] {
+			continue
+		}
+
+		// removed since the blobs were listed
+		if _, err := os.Stat(is.BlobPath(repo, digest)); os.IsNotExist(err) {
+			continue
+		}
+
+		canGC, err := isBlobOlderThan(is, repo, digest, is.gcDelay)
+		if err != nil {
+			return err
+		}
+
+		if !canGC {
+			continue
+		}
+
+		blobPath := is.BlobPath(repo, digest)
+
+		// manifests aren't deduped, they're not in the cache
+		if fmt.Sprintf("%v", is.cache) != fmt.Sprintf("%v", nil) {
+			if err := is.cache.DeleteBlob(digest, blobPath); err != nil && !errors.Is(err, zerr.ErrCacheMiss) {
+				is.log.Error().Err(err).Str("digest", digest.String()).Str("blobPath", blobPath).
+					Msg("gc: unable to remove blob path from cache")
+
+				return err
+			}
+		}
+
+		if err := os.Remove(blobPath); err != nil {
+			is.log.Error().Err(err).Str("blobPath", blobPath).Msg("gc: unable to remove blob")
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getGCCheckpoint returns the checkpoint of the incremental gc of a repo, if its cache driver can record them.
+func (is *ImageStoreLocal) getGCCheckpoint(repo string) godigest.Digest {
+	checkpointer, ok := is.cache.(cache.Checkpointer)
+	if !ok {
+		return ""
+	}
+
+	checkpoint, err := checkpointer.GetGCCheckpoint(repo)
+	if err != nil {
+		if !errors.Is(err, zerr.ErrGCCheckpointUnsupported) {
+			is.log.Warn().Err(err).Str("repository", repo).Msg("gc: unable to read checkpoint, sweeping all blobs")
+		}
+
+		return ""
+	}
+
+	return checkpoint
+}
+
+// putGCCheckpoint records the checkpoint of the incremental gc of a repo, if its cache driver can record them.
+func (is *ImageStoreLocal) putGCCheckpoint(repo string, digest godigest.Digest) {
+	checkpointer, ok := is.cache.(cache.Checkpointer)
+	if !ok {
+		return
+	}
+
+	if err := checkpointer.PutGCCheckpoint(repo, digest); err != nil &&
+		!errors.Is(err, zerr.ErrGCCheckpointUnsupported) {
+		is.log.Warn().Err(err).Str("repository", repo).Str("digest", digest.String()).
+			Msg("gc: unable to record checkpoint")
+	}
+}
+
+// SetIOOptions sets how blobs are written and read, direct IO, preallocation and readahead hints are
+// ignored outside of linux.
+func (is *ImageStoreLocal) SetIOOptions(options storageTypes.IOOptions) {
+	if options.LargeBlobSize == 0 {
+		options.LargeBlobSize = storageConstants.DefaultLargeBlobSize
+	}
+
+	if !directIOSupported && (options.DirectIO || options.Preallocate || options.ReadAhead != "") {
+		is.log.Warn().Str("rootDir", is.rootDir).
+			Msg("direct IO, preallocation and readahead hints are only supported on linux, will be ignored")
+	}
+
+	is.ioOptions = options
+}
+
+// GetLayoutVersion returns the version of the layout of the store, 0 if none was recorded yet.
+func (is *ImageStoreLocal) GetLayoutVersion() (int, error) {
+	var lockLatency time.Time
+
+	is.RLock(&lockLatency)
+	defer is.RUnlock(&lockLatency)
+
+	buf, err := os.ReadFile(path.Join(is.rootDir, storageConstants.LayoutVersionFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+
+		is.log.Error().Err(err).Str("rootDir", is.rootDir).Msg("failed to read layout version")
+
+		return 0, err
+	}
+
+	return common.DecodeLayoutVersion(buf)
+}
+
+// SetLayoutVersion records the version of the layout of the store, the file is replaced atomically so
+// that a crash leaves the previous version recorded.
+func (is *ImageStoreLocal) SetLayoutVersion(version int) error {
+	buf, err := common.EncodeLayoutVersion(version)
+	if err != nil {
+		return err
+	}
+
+	var lockLatency time.Time
+
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
+
+	if err := is.writeFileAtomically(path.Join(is.rootDir, storageConstants.LayoutVersionFile), buf); err != nil {
+		is.log.Error().Err(err).Str("rootDir", is.rootDir).Msg("failed to write layout version")
+
+		return err
+	}
+
+	return nil
+}
+
+// SetImmutableTags sets the immutable tags, pushes moving them to another manifest and deletes removing them
+// are rejected with zerr.ErrTagImmutable.
+func (is *ImageStoreLocal) SetImmutableTags(immutableTags storageTypes.ImmutableTags) {
+	is.immutableTags = immutableTags
+}
+
+// SetLeases sets the storage leases, gc is paused while a lease is held.
+func (is *ImageStoreLocal) SetLeases(leases storageTypes.Leases) {
+	is.leases = leases
+}
+
+// SetInFlight sets the in-flight references, gc doesn't remove the blobs and manifests they hold whatever their
+// age. Finished blob uploads are held until a manifest referencing them is pushed.
+func (is *ImageStoreLocal) SetInFlight(inFlight storageTypes.InFlight) {
+	is.inFlight = inFlight
+}
+
+// holdUpload keeps a finished blob upload from gc until a manifest referencing it is pushed.
+func (is *ImageStoreLocal) holdUpload(repo string, digest godigest.Digest) {
+	if is.inFlight != nil {
+		is.inFlight.HoldUpload(repo, digest)
+	}
+}
+
+// releaseUploads releases the uploads of the config and layers of a pushed image manifest.
+func (is *ImageStoreLocal) releaseUploads(repo, mediaType string, body []byte) {
+	if is.inFlight == nil || !zcommon.IsImageManifest(mediaType) {
+		return
+	}
+
+	var manifest ispec.Manifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return
+	}
+
+	digests := []godigest.Digest{manifest.Config.Digest}
+	for _, layer := range manifest.Layers {
+		digests = append(digests, layer.Digest)
+	}
+
+	is.inFlight.ReleaseUploads(repo, digests...)
+}
+
+// SetRetention sets the retention policies, the tags they expire are removed by gc before its other steps.
+func (is *ImageStoreLocal) SetRetention(retention storageTypes.Retention) {
+	is.retention = retention
+}
+
+// SetTagRemovals sets what is told about the tags removed by the retention policies, e.g. repodb.
+func (is *ImageStoreLocal) SetTagRemovals(removals storageTypes.TagRemovals) {
+	is.tagRemovals = removals
+}
+
+/*
+applyRetention removes the tags of the repo expired by the retention policies, the manifests left without a tag
+are then removed by the gc like any untagged manifest. Immutable tags, tags of pinned manifests, cosign signature
+and sbom tags and referrers tags, which are removed with their subject, are kept. In dry run mode the expired tags are only logged.
+*/
+func (is *ImageStoreLocal) applyRetention(dir, repo string) error {
+	if is.retention == nil {
+		return nil
+	}
+
+	index, err := common.GetIndex(is, repo, is.log)
+	if err != nil {
+		return err
+	}
+
+	tags := []storageTypes.TagInfo{}
+
+	for _, desc := range index.Manifests {
+		tag, ok := desc.Annotations[ispec.AnnotationRefName]
+		if !ok || common.IsCosignTag(tag) {
+			continue
+		}
+
+		if _, ok := common.GetReferrersTagSubject(tag); ok {
+			continue
+		}
+
+		// tags pushed before their push time was recorded are as old as their manifest
+		pushedAt, ok := common.GetTagPushedAt(desc)
+		if !ok {
+			blobPath := is.BlobPath(repo, desc.Digest)
+
+			fileInfo, err := os.Stat(blobPath)
+			if err != nil {
+				is.log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
+					Str("blobPath", blobPath).Msg("gc: failed to stat manifest")
+
+				return err
+			}
+
+			pushedAt = fileInfo.ModTime()
+		}
+
+		tags = append(tags, storageTypes.TagInfo{Tag: tag, Digest: desc.Digest, PushedAt: pushedAt})
+	}
+
+	removed := []storageTypes.TagInfo{}
+
+	for _, tag := range is.retention.GetExpiredTags(repo, tags) {
+		// leased since gc started
+		if is.isLeaseHeld() {
+			is.log.Info().Str("repository", repo).Msg("gc: retention stopped, the storage is leased by an external reader")
+
+			break
+		}
+
+		if is.immutableTags != nil && is.immutableTags.IsImmutable(repo, tag.Tag) {
+			is.log.Info().Str("repository", repo).Str("tag", tag.Tag).Str("digest", tag.Digest.String()).
+				Msg("gc: retention skipping immutable tag")
+
+			continue
+		}
+
+		if is.pins != nil {
+			pinned, err := is.pins.IsImagePinned(repo, tag.Digest)
+			if err != nil {
+				is.log.Error().Err(err).Str("repository", repo).Str("digest", tag.Digest.String()).
+					Msg("gc: failed to check if manifest is pinned")
+
+				return err
+			}
+
+			if pinned {
+				is.log.Info().Str("repository", repo).Str("tag", tag.Tag).Str("digest", tag.Digest.String()).
+					Msg("gc: retention skipping tag of pinned manifest")
+
+				continue
+			}
+		}
+
+		if is.retention.IsDryRun() {
+			is.log.Info().Str("repository", repo).Str("tag", tag.Tag).Str("digest", tag.Digest.String()).
+				Time("pushedAt", tag.PushedAt).Msg("gc: retention would remove tag")
+
+			continue
+		}
+
+		is.log.Info().Str("repository", repo).Str("tag", tag.Tag).Str("digest", tag.Digest.String()).
+			Time("pushedAt", tag.PushedAt).Msg("gc: retention removing tag")
+
+		if _, err := common.RemoveManifestDescByReference(&index, tag.Tag, false); err != nil {
+			return err
+		}
+
+		removed = append(removed, tag)
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	buf, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	if err := is.writeFile(path.Join(dir, "index.json"), buf); err != nil {
+		return err
+	}
+
+	if is.tagRemovals != nil {
+		is.tagRemovals.OnTagsRemoved(repo, removed)
+	}
+
+	return nil
+}
+
+// isLeased returns true if gc has to be skipped because a lease is held, skipped runs are counted.
+func (is *ImageStoreLocal) isLeased() bool {
+	if !is.isLeaseHeld() {
+		return false
+	}
+
+	monitoring.IncGCDeferred(is.metrics, is.rootDir)
+
+	return true
+}
+
+// isLeaseHeld returns true if a lease is held, gc checks it again before each removal since a lease can be
+// acquired while it runs.
+func (is *ImageStoreLocal) isLeaseHeld() bool {
+	return is.leases != nil && is.leases.IsHeld()
+}
+
+// SetGCVerifyPercent sets the percentage of the blobs of a repo, picked at random, verified after each gc.
+func (is *ImageStoreLocal) SetGCVerifyPercent(percent int) {
+	is.gcVerifyPercent = percent
+}
+
+/*
+verifyBlobsSample checks the content of a random sample of the blobs retained by gc matches their digest,
+mismatches are logged and counted, so that a full scrub is amortized over several gc runs.
+*/
+func (is *ImageStoreLocal) verifyBlobsSample(repo string) {
+	blobsDir := path.Join(is.rootDir, repo, "blobs", godigest.SHA256.String())
+
+	entries, err := os.ReadDir(blobsDir)
+	if err != nil {
+		is.log.Error().Err(err).Str("repository", repo).Msg("gc: unable to list blobs to verify")
+
+		return
+	}
+
+	digests := []godigest.Digest{}
+
+	for _, entry := range entries {
+		digest := godigest.NewDigestFromEncoded(godigest.SHA256, entry.Name())
+		if entry.IsDir() || digest.Validate() != nil {
+			continue
+		}
+
+		digests = append(digests, digest)
+	}
+
+	sampleSize := (len(digests)*is.gcVerifyPercent + 99) / 100 //nolint:gomnd
+
+	rand.Shuffle(len(digests), func(i, j int) { //nolint:gosec
+		digests[i], digests[j] = digests[j], digests[i]
+	})
+
+	mismatches := 0
+
+	for _, digest := range digests[:sampleSize] {
+		ok, err := is.verifyBlob(repo, digest)
+		if err != nil {
+			// removed since it was listed
+			if !errors.Is(err, fs.ErrNotExist) {
+				is.log.Error().Err(err).Str("repository", repo).Str("digest", digest.String()).
+					Msg("gc: unable to verify blob")
+			}
+
+			continue
+		}
+
+		if !ok {
+			mismatches++
+
+			is.log.Error().Str("repository", repo).Str("digest", digest.String()).
+				Str("blobPath", is.BlobPath(repo, digest)).Msg("gc: blob content doesn't match its digest")
+		}
+
+		monitoring.IncBlobVerifications(is.metrics, is.RootDir(), ok)
+	}
+
+	is.log.Info().Str("repository", repo).Int("verified", sampleSize).Int("blobs", len(digests)).
+		Int("mismatches", mismatches).Msg("gc: verified a sample of the blobs")
+}
+
+// verifyBlob returns whether the content of a blob matches its digest.
+func (is *ImageStoreLocal) verifyBlob(repo string, digest godigest.Digest) (bool, error) {
+	var lockLatency time.Time
+
+	is.RLock(&lockLatency)
+	defer is.RUnlock(&lockLatency)
+
+	blobFile, err := os.Open(is.BlobPath(repo, digest))
+	if err != nil {
+		return false, err
+	}
+
+	defer blobFile.Close()
+
+	digester := digest.Algorithm().Digester()
+
+	if _, err := io.Copy(digester.Hash(), blobFile); err != nil {
+		return false, err
+	}
+
+	return digester.Digest() == digest, nil
+}
+
 func (is *ImageStoreLocal) RunGCRepo(repo string) error {
+	return is.runGCRepo(repo, true)
+}
+
+func (is *ImageStoreLocal) runGCRepo(repo string, sweepBlobs bool) error {
 	is.log.Info().Msg(fmt.Sprintf("executing GC of orphaned blobs for %s", path.Join(is.RootDir(), repo)))
 
-	if err := is.gcRepo(repo); err != nil {
+	if err := is.gcRepo(repo, sweepBlobs); err != nil {
 		errMessage := fmt.Sprintf("error while running GC for %s", path.Join(is.RootDir(), repo))
 		is.log.Error().Err(err).Msg(errMessage)
 		is.log.Info().Msg(fmt.Sprintf("GC unsuccessfully completed for %s", path.Join(is.RootDir(), repo)))
@@ -1766,10 +2946,22 @@ func (is *ImageStoreLocal) RunGCPeriodically(interval time.Duration, sch *schedu
 type taskGenerator struct {
 	imgStore *ImageStoreLocal
 	lastRepo string
-	done     bool
+	// blobs of lastRepo left to sweep by the incremental gc
+	blobs []godigest.Digest
+	// the shared blobs are swept once all the repos are collected
+	sharedBlobsSwept bool
+	done             bool
 }
 
 func (gen *taskGenerator) Next() (scheduler.Task, error) {
+	// with incremental gc, the blobs of a repo are swept in batches before moving to the next repo
+	if len(gen.blobs) > 0 {
+		batch := gen.blobs[:gen.imgStore.getGCBatchLen(gen.blobs)]
+		gen.blobs = gen.blobs[len(batch):]
+
+		return newGCBlobsTask(gen.imgStore, gen.lastRepo, batch, len(gen.blobs) == 0), nil
+	}
+
 	repo, err := gen.imgStore.GetNextRepository(gen.lastRepo)
 
 	if err != nil && !errors.Is(err, io.EOF) {
@@ -1777,6 +2969,12 @@ func (gen *taskGenerator) Next() (scheduler.Task, error) {
 	}
 
 	if repo == "" {
+		if gen.imgStore.sharedBlobs && !gen.sharedBlobsSwept {
+			gen.sharedBlobsSwept = true
+
+			return &gcSharedBlobsTask{gen.imgStore}, nil
+		}
+
 		gen.done = true
 
 		return nil, nil
@@ -1784,6 +2982,11 @@ func (gen *taskGenerator) Next() (scheduler.Task, error) {
 
 	gen.lastRepo = repo
 
+	if gen.imgStore.gcBatchSize > 0 {
+		// the manifests are still collected if the blobs can't be listed
+		gen.blobs, _ = gen.imgStore.getGCBlobs(repo)
+	}
+
 	return newGCTask(gen.imgStore, repo), nil
 }
 
@@ -1793,6 +2996,8 @@ func (gen *taskGenerator) IsDone() bool {
 
 func (gen *taskGenerator) Reset() {
 	gen.lastRepo = ""
+	gen.blobs = nil
+	gen.sharedBlobsSwept = false
 	gen.done = false
 }
 
@@ -1806,7 +3011,23 @@ func newGCTask(imgStore *ImageStoreLocal, repo string) *gcTask {
 }
 
 func (gcT *gcTask) DoWork() error {
-	return gcT.imgStore.RunGCRepo(gcT.repo)
+	// the blobs are swept by the gc blobs tasks
+	return gcT.imgStore.runGCRepo(gcT.repo, false)
+}
+
+type gcBlobsTask struct {
+	imgStore *ImageStoreLocal
+	repo     string
+	batch    []godigest.Digest
+	last     bool
+}
+
+func newGCBlobsTask(imgStore *ImageStoreLocal, repo string, batch []godigest.Digest, last bool) *gcBlobsTask {
+	return &gcBlobsTask{imgStore, repo, batch, last}
+}
+
+func (gcT *gcBlobsTask) DoWork() error {
+	return gcT.imgStore.gcBlobsBatch(gcT.repo, gcT.batch, gcT.last)
 }
 
 func (is *ImageStoreLocal) GetNextDigestWithBlobPaths(lastDigests []godigest.Digest,
@@ -1868,8 +3089,8 @@ func (is *ImageStoreLocal) dedupeBlobs(digest godigest.Digest, duplicateBlobs []
 	var err error
 	// rebuild from dedupe false to true
 	for _, blobPath := range duplicateBlobs {
-		/* for local storage, because we use hard links, we can assume that any blob can be original
-		so we skip the first one and hard link the rest of them with the first*/
+		/* for local storage, because we use hard links or reflinks, we can assume that any blob can be original
+		so we skip the first one and link the rest of them with the first*/
 		if originalBlob == "" {
 			originalBlob = blobPath
 
@@ -1890,6 +3111,11 @@ func (is *ImageStoreLocal) dedupeBlobs(digest godigest.Digest, duplicateBlobs []
 			continue
 		}
 
+		// reflinked blobs are separate files, the cache records the ones already deduped
+		if is.dedupeStrategy == storageConstants.DedupeStrategyReflink && is.cache.HasBlob(digest, blobPath) {
+			continue
+		}
+
 		binfo, err := os.Stat(blobPath)
 		if err != nil {
 			is.log.Error().Err(err).Str("path", blobPath).Msg("rebuild dedupe: failed to stat blob")
@@ -1918,9 +3144,9 @@ func (is *ImageStoreLocal) dedupeBlobs(digest godigest.Digest, duplicateBlobs []
 
 			tempLinkBlobPath := path.Join(tempLinkBlobDir, uuid.String())
 
-			if err := os.Link(originalBlob, tempLinkBlobPath); err != nil {
+			if err := is.linkBlob(originalBlob, tempLinkBlobPath); err != nil {
 				is.log.Error().Err(err).Str("src", originalBlob).
-					Str("dst", tempLinkBlobPath).Msg("rebuild dedupe: unable to hard link")
+					Str("dst", tempLinkBlobPath).Msg("rebuild dedupe: unable to link")
 
 				return err
 			}
@@ -1960,8 +3186,19 @@ func (is *ImageStoreLocal) RunDedupeForDigest(digest godigest.Digest, dedupe boo
 }
 
 func (is *ImageStoreLocal) RunDedupeBlobs(interval time.Duration, sch *scheduler.Scheduler) {
-	// for local storage no need to undedupe blobs
-	if is.dedupe {
+	// shared blobs aren't deduped, the repos still holding their own blobs are migrated to the shared pool instead
+	if is.sharedBlobs {
+		generator := &sharedBlobsMigrationTaskGenerator{
+			imgStore: is,
+		}
+
+		sch.SubmitGenerator(generator, interval, scheduler.MediumPriority)
+
+		return
+	}
+
+	// for local storage no need to undedupe blobs, blobs are copied on NFS so there's nothing to dedupe
+	if is.dedupe && !is.nfs {
 		generator := &common.DedupeTaskGenerator{
 			ImgStore: is,
 			Dedupe:   is.dedupe,
@@ -1971,3 +3208,16 @@ func (is *ImageStoreLocal) RunDedupeBlobs(interval time.Duration, sch *scheduler
 		sch.SubmitGenerator(generator, interval, scheduler.MediumPriority)
 	}
 }
+
+// RunCacheMaintenance checks the integrity of the dedupe cache db and compacts it.
+func (is *ImageStoreLocal) RunCacheMaintenance() error {
+	return common.RunCacheMaintenance(is.cache, is.rootDir, is.log)
+}
+
+func (is *ImageStoreLocal) RunCacheMaintenancePeriodically(interval time.Duration, sch *scheduler.Scheduler) {
+	generator := &common.CacheMaintenanceTaskGenerator{
+		ImgStore: is,
+	}
+
+	sch.SubmitGenerator(generator, interval, scheduler.LowPriority)
+}
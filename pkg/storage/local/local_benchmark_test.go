@@ -0,0 +1,172 @@
+package local_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/rs/zerolog"
+
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/local"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// The IO options only make a difference on fast disks, e.g. NVMe, set ZOT_BENCH_STORAGE_DIR to a directory
+// on the disk to measure, the temporary directory of the tests is used otherwise:
+//
+//	ZOT_BENCH_STORAGE_DIR=/mnt/nvme/bench go test -run=^$ -bench=. -benchtime=20x ./pkg/storage/local/
+//
+// Readahead hints only matter for blobs which aren't in the page cache, compare them with -benchtime=1x after
+// dropping the caches (echo 3 > /proc/sys/vm/drop_caches).
+
+// benchBlobSize is the size of the blob written and read by each benchmark iteration, a large image layer.
+const benchBlobSize = 256 * 1024 * 1024
+
+var benchIOOptions = map[string]storageTypes.IOOptions{
+	"default":             {},
+	"buffer-1MiB":         {WriteBufferSize: 1024 * 1024},
+	"buffer-4MiB":         {WriteBufferSize: 4 * 1024 * 1024},
+	"direct-buffer-4MiB":  {WriteBufferSize: 4 * 1024 * 1024, DirectIO: true},
+	"direct-preallocated": {WriteBufferSize: 4 * 1024 * 1024, DirectIO: true, Preallocate: true},
+}
+
+func newBenchImageStore(b *testing.B, options storageTypes.IOOptions) storageTypes.ImageStore {
+	b.Helper()
+
+	dir := os.Getenv("ZOT_BENCH_STORAGE_DIR")
+	if dir == "" {
+		dir = b.TempDir()
+	} else {
+		var err error
+
+		dir, err = os.MkdirTemp(dir, "zot-bench")
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Cleanup(func() { _ = os.RemoveAll(dir) })
+	}
+
+	log := log.Logger{Logger: zerolog.New(io.Discard)}
+	metrics := monitoring.NewMetricsServer(false, log)
+	imgStore := local.NewImageStore(dir, false, storageConstants.DefaultGCDelay, false, false, log, metrics,
+		nil, nil)
+	imgStore.SetIOOptions(options)
+
+	return imgStore
+}
+
+func newBenchBlob(b *testing.B) ([]byte, godigest.Digest) {
+	b.Helper()
+
+	content := make([]byte, benchBlobSize)
+	if _, err := rand.Read(content); err != nil {
+		b.Fatal(err)
+	}
+
+	return content, godigest.FromBytes(content)
+}
+
+func BenchmarkPutBlobChunkStreamed(b *testing.B) {
+	content, _ := newBenchBlob(b)
+
+	for name, options := range benchIOOptions {
+		b.Run(name, func(b *testing.B) {
+			imgStore := newBenchImageStore(b, options)
+
+			b.SetBytes(benchBlobSize)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				upload, err := imgStore.NewBlobUpload(repoName)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if _, err := imgStore.PutBlobChunkStreamed(repoName, upload, bytes.NewReader(content)); err != nil {
+					b.Fatal(err)
+				}
+
+				b.StopTimer()
+
+				if err := imgStore.DeleteBlobUpload(repoName, upload); err != nil {
+					b.Fatal(err)
+				}
+
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+func BenchmarkPutBlobChunk(b *testing.B) {
+	content, _ := newBenchBlob(b)
+
+	for name, options := range benchIOOptions {
+		b.Run(name, func(b *testing.B) {
+			imgStore := newBenchImageStore(b, options)
+
+			b.SetBytes(benchBlobSize)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				upload, err := imgStore.NewBlobUpload(repoName)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if _, err := imgStore.PutBlobChunk(repoName, upload, 0, benchBlobSize-1,
+					bytes.NewReader(content)); err != nil {
+					b.Fatal(err)
+				}
+
+				b.StopTimer()
+
+				if err := imgStore.DeleteBlobUpload(repoName, upload); err != nil {
+					b.Fatal(err)
+				}
+
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+func BenchmarkGetBlob(b *testing.B) {
+	content, digest := newBenchBlob(b)
+
+	for _, readAhead := range []string{
+		storageConstants.ReadAheadNormal, storageConstants.ReadAheadSequential, storageConstants.ReadAheadRandom,
+	} {
+		b.Run(readAhead, func(b *testing.B) {
+			imgStore := newBenchImageStore(b, storageTypes.IOOptions{ReadAhead: readAhead})
+
+			if _, _, err := imgStore.FullBlobUpload(repoName, bytes.NewReader(content), digest); err != nil {
+				b.Fatal(err)
+			}
+
+			b.SetBytes(benchBlobSize)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				blob, _, err := imgStore.GetBlob(repoName, digest, ispec.MediaTypeImageLayer)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if _, err := io.Copy(io.Discard, blob); err != nil {
+					b.Fatal(err)
+				}
+
+				_ = blob.Close()
+			}
+		})
+	}
+}
@@ -13,9 +13,11 @@ import (
 	"math/big"
 	"os"
 	"path"
+	"sort"
 	"strings"
 	"syscall"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	godigest "github.com/opencontainers/go-digest"
@@ -27,14 +29,19 @@ import (
 
 	zerr "zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/api/immutabletags"
 	"zotregistry.io/zot/pkg/common"
 	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/scheduler"
 	"zotregistry.io/zot/pkg/storage"
 	"zotregistry.io/zot/pkg/storage/cache"
+	storageCommon "zotregistry.io/zot/pkg/storage/common"
 	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/inflight"
+	"zotregistry.io/zot/pkg/storage/lease"
 	"zotregistry.io/zot/pkg/storage/local"
+	"zotregistry.io/zot/pkg/storage/retention"
 	storageTypes "zotregistry.io/zot/pkg/storage/types"
 	"zotregistry.io/zot/pkg/test"
 	"zotregistry.io/zot/pkg/test/inject"
@@ -2026,292 +2033,578 @@ func TestInjectWriteFile(t *testing.T) {
 	})
 }
 
-func TestGarbageCollect(t *testing.T) {
-	Convey("Repo layout", t, func(c C) {
-		dir := t.TempDir()
-
-		log := log.Logger{Logger: zerolog.New(os.Stdout)}
-		metrics := monitoring.NewMetricsServer(false, log)
+func TestCommitPolicy(t *testing.T) {
+	Convey("Flush written files with the commit policies", t, func() {
+		for _, policy := range []string{
+			storageConstants.CommitPolicyNone, storageConstants.CommitPolicyAlways,
+			storageConstants.CommitPolicyManifest, storageConstants.CommitPolicyPeriodic,
+		} {
+			dir := t.TempDir()
 
-		Convey("Garbage collect with default/long delay", func() {
+			log := log.Logger{Logger: zerolog.New(os.Stdout)}
+			metrics := monitoring.NewMetricsServer(false, log)
 			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
 				RootDir:     dir,
 				Name:        "cache",
 				UseRelPaths: true,
 			}, log)
 			imgStore := local.NewImageStore(dir, true, storageConstants.DefaultGCDelay,
-				true, true, log, metrics, nil, cacheDriver)
-			repoName := "gc-long"
+				true, false, log, metrics, nil, cacheDriver)
+			imgStore.SetCommitPolicy(policy)
 
-			upload, err := imgStore.NewBlobUpload(repoName)
-			So(err, ShouldBeNil)
-			So(upload, ShouldNotBeEmpty)
+			content := []byte("test-data")
+			digest := godigest.FromBytes(content)
 
-			content := []byte("test-data1")
-			buf := bytes.NewBuffer(content)
-			buflen := buf.Len()
-			bdigest := godigest.FromBytes(content)
+			_, _, err := imgStore.FullBlobUpload(repoName, bytes.NewReader(content), digest)
+			So(err, ShouldBeNil)
 
-			blob, err := imgStore.PutBlobChunk(repoName, upload, 0, int64(buflen), buf)
+			upload, err := imgStore.NewBlobUpload(repoName)
 			So(err, ShouldBeNil)
-			So(blob, ShouldEqual, buflen)
 
-			err = imgStore.FinishBlobUpload(repoName, upload, buf, bdigest)
+			_, err = imgStore.PutBlobChunkStreamed(repoName, upload, bytes.NewReader(content))
 			So(err, ShouldBeNil)
 
-			annotationsMap := make(map[string]string)
-			annotationsMap[ispec.AnnotationRefName] = tag
+			localStore, _ := imgStore.(*local.ImageStoreLocal)
+			So(localStore.Commit(), ShouldBeNil)
 
-			cblob, cdigest := test.GetRandomImageConfig()
-			_, clen, err := imgStore.FullBlobUpload(repoName, bytes.NewReader(cblob), cdigest)
+			// files removed before being flushed are skipped
+			_, _, err = imgStore.FullBlobUpload(repoName, bytes.NewReader([]byte("removed")),
+				godigest.FromBytes([]byte("removed")))
 			So(err, ShouldBeNil)
-			So(clen, ShouldEqual, len(cblob))
-			hasBlob, _, err := imgStore.CheckBlob(repoName, cdigest)
+
+			err = os.Remove(imgStore.BlobPath(repoName, godigest.FromBytes([]byte("removed"))))
 			So(err, ShouldBeNil)
-			So(hasBlob, ShouldEqual, true)
 
-			manifest := ispec.Manifest{
-				Config: ispec.Descriptor{
-					MediaType: "application/vnd.oci.image.config.v1+json",
-					Digest:    cdigest,
-					Size:      int64(len(cblob)),
-				},
-				Layers: []ispec.Descriptor{
-					{
-						MediaType: "application/vnd.oci.image.layer.v1.tar",
-						Digest:    bdigest,
-						Size:      int64(buflen),
-					},
-				},
-				Annotations: annotationsMap,
-			}
+			So(localStore.Commit(), ShouldBeNil)
 
-			manifest.SchemaVersion = 2
-			manifestBuf, err := json.Marshal(manifest)
-			So(err, ShouldBeNil)
-			digest := godigest.FromBytes(manifestBuf)
+			taskScheduler := scheduler.NewScheduler(config.New(), log)
+			imgStore.RunCommitPeriodically(time.Second, taskScheduler)
+		}
+	})
+}
 
-			_, _, err = imgStore.PutImageManifest(repoName, tag, ispec.MediaTypeImageManifest, manifestBuf)
-			So(err, ShouldBeNil)
+func TestIOOptions(t *testing.T) {
+	Convey("Write and read blobs with the IO options", t, func() {
+		content := make([]byte, 300001)
+		_, err := rand.Read(content)
+		So(err, ShouldBeNil)
 
-			hasBlob, _, err = imgStore.CheckBlob(repoName, bdigest)
+		digest := godigest.FromBytes(content)
+
+		for _, options := range []storageTypes.IOOptions{
+			{},
+			{WriteBufferSize: 1000},
+			{WriteBufferSize: 10000, DirectIO: true, Preallocate: true, LargeBlobSize: 4096,
+				ReadAhead: storageConstants.ReadAheadSequential},
+			{DirectIO: true, ReadAhead: storageConstants.ReadAheadRandom},
+		} {
+			dir := t.TempDir()
+
+			log := log.Logger{Logger: zerolog.New(os.Stdout)}
+			metrics := monitoring.NewMetricsServer(false, log)
+			imgStore := local.NewImageStore(dir, true, storageConstants.DefaultGCDelay,
+				false, false, log, metrics, nil, nil)
+			imgStore.SetIOOptions(options)
+
+			// uploads in chunks, the first one leaves the next writes unaligned
+			upload, err := imgStore.NewBlobUpload(repoName)
 			So(err, ShouldBeNil)
-			So(hasBlob, ShouldEqual, true)
 
-			err = imgStore.DeleteImageManifest(repoName, digest.String(), false)
+			n, err := imgStore.PutBlobChunkStreamed(repoName, upload, bytes.NewReader(content[:1000]))
 			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1000)
 
-			hasBlob, _, err = imgStore.CheckBlob(repoName, bdigest)
+			n, err = imgStore.PutBlobChunkStreamed(repoName, upload, bytes.NewReader(content[1000:100000]))
 			So(err, ShouldBeNil)
-			So(hasBlob, ShouldEqual, true)
-		})
+			So(n, ShouldEqual, 99000)
 
-		Convey("Garbage collect with short delay", func() {
-			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
-				RootDir:     dir,
-				Name:        "cache",
-				UseRelPaths: true,
-			}, log)
-			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, cacheDriver)
-			repoName := "gc-short"
+			n, err = imgStore.PutBlobChunk(repoName, upload, 100000, int64(len(content))-1,
+				bytes.NewReader(content[100000:]))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, len(content)-100000)
 
-			// upload orphan blob
-			upload, err := imgStore.NewBlobUpload(repoName)
+			err = imgStore.FinishBlobUpload(repoName, upload, bytes.NewReader([]byte{}), digest)
 			So(err, ShouldBeNil)
-			So(upload, ShouldNotBeEmpty)
 
-			content := []byte("test-data1")
-			buf := bytes.NewBuffer(content)
-			buflen := buf.Len()
-			odigest := godigest.FromBytes(content)
+			blob, err := imgStore.GetBlobContent(repoName, digest)
+			So(err, ShouldBeNil)
+			So(blob, ShouldResemble, content)
 
-			blob, err := imgStore.PutBlobChunk(repoName, upload, 0, int64(buflen), buf)
+			_, size, err := imgStore.FullBlobUpload("other", bytes.NewReader(content), digest)
 			So(err, ShouldBeNil)
-			So(blob, ShouldEqual, buflen)
+			So(size, ShouldEqual, len(content))
 
-			err = imgStore.FinishBlobUpload(repoName, upload, buf, odigest)
+			blobReader, size, err := imgStore.GetBlob("other", digest, ispec.MediaTypeImageLayer)
 			So(err, ShouldBeNil)
+			So(size, ShouldEqual, len(content))
 
-			// sleep so orphan blob can be GC'ed
-			time.Sleep(5 * time.Second)
+			blob, err = io.ReadAll(blobReader)
+			So(err, ShouldBeNil)
+			So(blob, ShouldResemble, content)
+			So(blobReader.Close(), ShouldBeNil)
 
-			// upload blob
+			// the data read before an error is kept, so the upload can be resumed
 			upload, err = imgStore.NewBlobUpload(repoName)
 			So(err, ShouldBeNil)
-			So(upload, ShouldNotBeEmpty)
 
-			content = []byte("test-data2")
-			buf = bytes.NewBuffer(content)
-			buflen = buf.Len()
-			bdigest := godigest.FromBytes(content)
+			n, err = imgStore.PutBlobChunkStreamed(repoName, upload,
+				io.MultiReader(bytes.NewReader(content[:5000]), iotest.ErrReader(io.ErrUnexpectedEOF)))
+			So(err, ShouldEqual, io.ErrUnexpectedEOF)
+			So(n, ShouldEqual, 5000)
 
-			blob, err = imgStore.PutBlobChunk(repoName, upload, 0, int64(buflen), buf)
+			size, err = imgStore.BlobUploadInfo(repoName, upload)
 			So(err, ShouldBeNil)
-			So(blob, ShouldEqual, buflen)
+			So(size, ShouldEqual, 5000)
+		}
+	})
+}
 
-			err = imgStore.FinishBlobUpload(repoName, upload, buf, bdigest)
-			So(err, ShouldBeNil)
+func TestTagImageManifest(t *testing.T) {
+	Convey("Update several tags of a manifest at once", t, func() {
+		dir := t.TempDir()
 
-			annotationsMap := make(map[string]string)
-			annotationsMap[ispec.AnnotationRefName] = tag
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+		imgStore := local.NewImageStore(dir, true, storageConstants.DefaultGCDelay,
+			true, true, log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
 
-			cblob, cdigest := test.GetRandomImageConfig()
-			_, clen, err := imgStore.FullBlobUpload(repoName, bytes.NewReader(cblob), cdigest)
-			So(err, ShouldBeNil)
-			So(clen, ShouldEqual, len(cblob))
-			hasBlob, _, err := imgStore.CheckBlob(repoName, cdigest)
-			So(err, ShouldBeNil)
-			So(hasBlob, ShouldEqual, true)
+		oldImage, err := test.GetRandomImage("1.2.2")
+		So(err, ShouldBeNil)
+		So(test.WriteImageToFileSystem(oldImage, repoName, storeController), ShouldBeNil)
 
-			manifest := ispec.Manifest{
-				Config: ispec.Descriptor{
-					MediaType: "application/vnd.oci.image.config.v1+json",
-					Digest:    cdigest,
-					Size:      int64(len(cblob)),
-				},
-				Layers: []ispec.Descriptor{
-					{
-						MediaType: "application/vnd.oci.image.layer.v1.tar",
-						Digest:    bdigest,
-						Size:      int64(buflen),
-					},
-				},
-				Annotations: annotationsMap,
-			}
+		newImage, err := test.GetRandomImage("1.2.3")
+		So(err, ShouldBeNil)
+		So(test.WriteImageToFileSystem(newImage, repoName, storeController), ShouldBeNil)
 
-			manifest.SchemaVersion = 2
-			manifestBuf, err := json.Marshal(manifest)
-			So(err, ShouldBeNil)
-			digest := godigest.FromBytes(manifestBuf)
+		oldDigest, err := oldImage.Digest()
+		So(err, ShouldBeNil)
 
-			_, _, err = imgStore.PutImageManifest(repoName, tag, ispec.MediaTypeImageManifest, manifestBuf)
-			So(err, ShouldBeNil)
+		newDigest, err := newImage.Digest()
+		So(err, ShouldBeNil)
 
-			hasBlob, _, err = imgStore.CheckBlob(repoName, odigest)
-			So(err, ShouldNotBeNil)
-			So(hasBlob, ShouldEqual, false)
+		err = imgStore.TagImageManifest(repoName, oldDigest, []string{"1.2", "1"})
+		So(err, ShouldBeNil)
 
-			hasBlob, _, err = imgStore.CheckBlob(repoName, bdigest)
-			So(err, ShouldBeNil)
-			So(hasBlob, ShouldEqual, true)
+		// existing tags are moved to the new manifest
+		err = imgStore.TagImageManifest(repoName, newDigest, []string{"1.2", "1", "latest"})
+		So(err, ShouldBeNil)
 
-			// sleep so orphan blob can be GC'ed
-			time.Sleep(5 * time.Second)
+		tags, err := imgStore.GetImageTags(repoName)
+		So(err, ShouldBeNil)
+		So(tags, ShouldHaveLength, 5)
 
-			err = imgStore.DeleteImageManifest(repoName, digest.String(), false)
+		for _, tag := range []string{"1.2.3", "1.2", "1", "latest"} {
+			_, digest, _, err := imgStore.GetImageManifest(repoName, tag)
 			So(err, ShouldBeNil)
+			So(digest, ShouldEqual, newDigest)
+		}
 
-			hasBlob, _, err = imgStore.CheckBlob(repoName, bdigest)
-			So(err, ShouldNotBeNil)
-			So(hasBlob, ShouldEqual, false)
-		})
+		_, digest, _, err := imgStore.GetImageManifest(repoName, "1.2.2")
+		So(err, ShouldBeNil)
+		So(digest, ShouldEqual, oldDigest)
 
-		Convey("Garbage collect with dedupe", func() {
-			// garbage-collect is repo-local and dedupe is global and they can interact in strange ways
-			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
-				RootDir:     dir,
-				Name:        "cache",
-				UseRelPaths: true,
-			}, log)
-			imgStore := local.NewImageStore(dir, true, 5*time.Second, true, true, log, metrics, nil, cacheDriver)
+		// tagging again changes nothing
+		err = imgStore.TagImageManifest(repoName, newDigest, []string{"latest"})
+		So(err, ShouldBeNil)
 
-			// first upload an image to the first repo and wait for GC timeout
+		err = imgStore.TagImageManifest(repoName, godigest.FromString("missing"), []string{"latest"})
+		So(err, ShouldEqual, zerr.ErrManifestNotFound)
 
-			repo1Name := "gc1"
+		err = imgStore.TagImageManifest("missing", newDigest, []string{"latest"})
+		So(err, ShouldEqual, zerr.ErrRepoNotFound)
+	})
+}
 
-			// upload blob
-			upload, err := imgStore.NewBlobUpload(repo1Name)
-			So(err, ShouldBeNil)
-			So(upload, ShouldNotBeEmpty)
+func TestNFSMode(t *testing.T) {
+	Convey("Copy blobs and serialize writes with a lock file in the NFS mode", t, func() {
+		dir := t.TempDir()
 
-			content := []byte("test-data")
-			buf := bytes.NewBuffer(content)
-			buflen := buf.Len()
-			bdigest := godigest.FromBytes(content)
-			tdigest := bdigest
+		So(local.IsNFS(dir), ShouldBeFalse)
+		So(local.IsNFS(path.Join(dir, "not", "created", "yet")), ShouldBeFalse)
+		So(local.ValidateNFS(dir), ShouldBeNil)
 
-			blob, err := imgStore.PutBlobChunk(repo1Name, upload, 0, int64(buflen), buf)
-			So(err, ShouldBeNil)
-			So(blob, ShouldEqual, buflen)
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+		cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+			RootDir:     dir,
+			Name:        "cache",
+			UseRelPaths: true,
+		}, log)
+		imgStore := local.NewImageStore(dir, true, storageConstants.DefaultGCDelay,
+			true, true, log, metrics, nil, cacheDriver)
+		imgStore.SetNFSMode(true)
 
-			err = imgStore.FinishBlobUpload(repo1Name, upload, buf, bdigest)
-			So(err, ShouldBeNil)
+		content := []byte("test-data")
+		digest := godigest.FromBytes(content)
 
-			annotationsMap := make(map[string]string)
-			annotationsMap[ispec.AnnotationRefName] = tag
+		_, _, err := imgStore.FullBlobUpload("repo1", bytes.NewReader(content), digest)
+		So(err, ShouldBeNil)
 
-			cblob, cdigest := test.GetRandomImageConfig()
-			_, clen, err := imgStore.FullBlobUpload(repo1Name, bytes.NewReader(cblob), cdigest)
+		_, _, err = imgStore.FullBlobUpload("repo2", bytes.NewReader(content), digest)
+		So(err, ShouldBeNil)
+
+		// the blob is mounted in another repo by copying it
+		ok, size, err := imgStore.CheckBlob("repo3", digest)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		So(size, ShouldEqual, len(content))
+
+		blobInfo1, err := os.Stat(imgStore.BlobPath("repo1", digest))
+		So(err, ShouldBeNil)
+
+		for _, repo := range []string{"repo2", "repo3"} {
+			blobInfo, err := os.Stat(imgStore.BlobPath(repo, digest))
 			So(err, ShouldBeNil)
-			So(clen, ShouldEqual, len(cblob))
-			hasBlob, _, err := imgStore.CheckBlob(repo1Name, cdigest)
+			So(os.SameFile(blobInfo1, blobInfo), ShouldBeFalse)
+
+			blobContent, err := imgStore.GetBlobContent(repo, digest)
 			So(err, ShouldBeNil)
-			So(hasBlob, ShouldEqual, true)
+			So(blobContent, ShouldResemble, content)
+		}
 
-			manifest := ispec.Manifest{
-				Config: ispec.Descriptor{
-					MediaType: "application/vnd.oci.image.config.v1+json",
-					Digest:    cdigest,
-					Size:      int64(len(cblob)),
-				},
-				Layers: []ispec.Descriptor{
-					{
-						MediaType: "application/vnd.oci.image.layer.v1.tar",
-						Digest:    bdigest,
-						Size:      int64(buflen),
-					},
-				},
-				Annotations: annotationsMap,
-			}
+		// index.json is replaced, not written in place
+		_, err = os.Stat(path.Join(dir, "repo1", "index.json"))
+		So(err, ShouldBeNil)
 
-			manifest.SchemaVersion = 2
-			manifestBuf, err := json.Marshal(manifest)
+		_, err = os.Stat(path.Join(dir, ".zot.lock"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		Convey("Stale lock files are removed", func() {
+			err := os.WriteFile(path.Join(dir, ".zot.lock"), []byte("crashed 1"), storageConstants.DefaultFilePerms)
 			So(err, ShouldBeNil)
 
-			_, _, err = imgStore.PutImageManifest(repo1Name, tag, ispec.MediaTypeImageManifest, manifestBuf)
+			staleTime := time.Now().Add(-time.Hour)
+			err = os.Chtimes(path.Join(dir, ".zot.lock"), staleTime, staleTime)
 			So(err, ShouldBeNil)
 
-			hasBlob, _, err = imgStore.CheckBlob(repo1Name, tdigest)
+			err = imgStore.InitRepo("repo4")
 			So(err, ShouldBeNil)
-			So(hasBlob, ShouldEqual, true)
 
-			// sleep so past GC timeout
-			time.Sleep(10 * time.Second)
+			_, err = os.Stat(path.Join(dir, ".zot.lock"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
 
-			hasBlob, _, err = imgStore.CheckBlob(repo1Name, tdigest)
-			So(err, ShouldBeNil)
-			So(hasBlob, ShouldEqual, true)
+		Convey("Lock files taken over by other instances aren't removed", func() {
+			var lockStart time.Time
 
-			// upload another image into a second repo with the same blob contents so dedupe is triggered
+			imgStore.Lock(&lockStart)
 
-			repo2Name := "gc2"
+			content, err := os.ReadFile(path.Join(dir, ".zot.lock"))
+			So(err, ShouldBeNil)
+			So(string(content), ShouldNotBeEmpty)
 
-			upload, err = imgStore.NewBlobUpload(repo2Name)
+			err = os.WriteFile(path.Join(dir, ".zot.lock"), []byte("other 1"), storageConstants.DefaultFilePerms)
 			So(err, ShouldBeNil)
-			So(upload, ShouldNotBeEmpty)
 
-			buf = bytes.NewBuffer(content)
-			buflen = buf.Len()
+			imgStore.Unlock(&lockStart)
 
-			blob, err = imgStore.PutBlobChunk(repo2Name, upload, 0, int64(buflen), buf)
+			content, err = os.ReadFile(path.Join(dir, ".zot.lock"))
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "other 1")
+		})
+	})
+}
+
+func TestReflinkDedupe(t *testing.T) {
+	Convey("Dedupe blobs with reflinks, or hard links if the filesystem doesn't support them", t, func() {
+		dir := t.TempDir()
+
+		reflinkErr := local.ValidateReflink(dir)
+		if reflinkErr != nil {
+			So(errors.Is(reflinkErr, zerr.ErrReflinkNotSupported), ShouldBeTrue)
+		}
+
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+		cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+			RootDir:     dir,
+			Name:        "cache",
+			UseRelPaths: true,
+		}, log)
+		imgStore := local.NewImageStore(dir, true, storageConstants.DefaultGCDelay,
+			true, true, log, metrics, nil, cacheDriver)
+		imgStore.SetDedupeStrategy(storageConstants.DedupeStrategyReflink)
+
+		content := []byte("test-data")
+		digest := godigest.FromBytes(content)
+
+		_, _, err := imgStore.FullBlobUpload("repo1", bytes.NewReader(content), digest)
+		So(err, ShouldBeNil)
+
+		_, _, err = imgStore.FullBlobUpload("repo2", bytes.NewReader(content), digest)
+		So(err, ShouldBeNil)
+
+		ok, size, err := imgStore.CheckBlob("repo3", digest)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		So(size, ShouldEqual, len(content))
+
+		blobInfo1, err := os.Stat(imgStore.BlobPath("repo1", digest))
+		So(err, ShouldBeNil)
+
+		for _, repo := range []string{"repo2", "repo3"} {
+			blobInfo, err := os.Stat(imgStore.BlobPath(repo, digest))
+			So(err, ShouldBeNil)
+			// reflinked blobs are separate files
+			So(os.SameFile(blobInfo1, blobInfo), ShouldEqual, reflinkErr != nil)
+
+			blobContent, err := imgStore.GetBlobContent(repo, digest)
+			So(err, ShouldBeNil)
+			So(blobContent, ShouldResemble, content)
+		}
+
+		// deleting the blob of a repo doesn't affect the others
+		err = imgStore.DeleteBlob("repo1", digest)
+		So(err, ShouldBeNil)
+
+		blobContent, err := imgStore.GetBlobContent("repo2", digest)
+		So(err, ShouldBeNil)
+		So(blobContent, ShouldResemble, content)
+	})
+}
+
+func TestSharedBlobs(t *testing.T) {
+	Convey("Store blobs once in the shared pool and link them in the repos", t, func() {
+		dir := t.TempDir()
+
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+		imgStore := local.NewImageStore(dir, true, 1*time.Second, false, true, log, metrics, nil, nil)
+		imgStore.SetSharedBlobs(true)
+
+		content := []byte("test-data")
+		digest := godigest.FromBytes(content)
+		sharedPath := path.Join(dir, storageConstants.SharedBlobsDir, "sha256", digest.Encoded())
+
+		_, _, err := imgStore.FullBlobUpload("repo1", bytes.NewReader(content), digest)
+		So(err, ShouldBeNil)
+
+		_, _, err = imgStore.FullBlobUpload("repo2", bytes.NewReader(content), digest)
+		So(err, ShouldBeNil)
+
+		// the blob is mounted in another repo by linking it
+		ok, size, err := imgStore.CheckBlob("repo3", digest)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		So(size, ShouldEqual, len(content))
+
+		sharedInfo, err := os.Stat(sharedPath)
+		So(err, ShouldBeNil)
+		So(sharedInfo.Mode().IsRegular(), ShouldBeTrue)
+
+		for _, repo := range []string{"repo1", "repo2", "repo3"} {
+			linkInfo, err := os.Lstat(imgStore.BlobPath(repo, digest))
+			So(err, ShouldBeNil)
+			So(linkInfo.Mode()&os.ModeSymlink, ShouldNotEqual, 0)
+
+			blobContent, err := imgStore.GetBlobContent(repo, digest)
+			So(err, ShouldBeNil)
+			So(blobContent, ShouldResemble, content)
+		}
+
+		// the shared pool isn't a repo
+		repos, err := imgStore.GetRepositories()
+		So(err, ShouldBeNil)
+		So(repos, ShouldResemble, []string{"repo1", "repo2", "repo3"})
+
+		// deleting the blob of a repo only removes its link
+		err = imgStore.DeleteBlob("repo1", digest)
+		So(err, ShouldBeNil)
+
+		_, err = os.Stat(sharedPath)
+		So(err, ShouldBeNil)
+
+		blobContent, err := imgStore.GetBlobContent("repo2", digest)
+		So(err, ShouldBeNil)
+		So(blobContent, ShouldResemble, content)
+
+		Convey("Shared blobs no repo links to are removed by gc", func() {
+			image, err := test.GetRandomImage("1.0")
+			So(err, ShouldBeNil)
+
+			err = test.WriteImageToFileSystem(image, "repo4", storage.StoreController{DefaultStore: imgStore})
+			So(err, ShouldBeNil)
+
+			layerPath := path.Join(dir, storageConstants.SharedBlobsDir, "sha256",
+				image.Manifest.Layers[0].Digest.Encoded())
+
+			time.Sleep(1 * time.Second)
+
+			taskScheduler, cancel := runAndGetScheduler()
+			defer cancel()
+
+			imgStore.RunGCPeriodically(time.Hour, taskScheduler)
+
+			for i := 0; i < 100; i++ {
+				if _, err := os.Stat(sharedPath); os.IsNotExist(err) {
+					break
+				}
+
+				time.Sleep(100 * time.Millisecond)
+			}
+
+			_, err = os.Stat(sharedPath)
+			So(os.IsNotExist(err), ShouldBeTrue)
+
+			_, err = os.Stat(layerPath)
+			So(err, ShouldBeNil)
+
+			_, _, _, err = imgStore.GetImageManifest("repo4", "1.0")
+			So(err, ShouldBeNil)
+		})
+	})
+
+	Convey("Migrate the repos of an existing store to the shared pool", t, func() {
+		dir := t.TempDir()
+
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+		imgStore := local.NewImageStore(dir, false, storageConstants.DefaultGCDelay, false, true,
+			log, metrics, nil, nil)
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		manifestDigest, err := image.Digest()
+		So(err, ShouldBeNil)
+
+		for _, repo := range []string{"migrate1", "migrate2"} {
+			err = test.WriteImageToFileSystem(image, repo, storage.StoreController{DefaultStore: imgStore})
+			So(err, ShouldBeNil)
+		}
+
+		layerDigest := image.Manifest.Layers[0].Digest
+
+		isLink := func(repo string, digest godigest.Digest) bool {
+			linkInfo, err := os.Lstat(imgStore.BlobPath(repo, digest))
+
+			return err == nil && linkInfo.Mode()&os.ModeSymlink != 0
+		}
+
+		So(isLink("migrate1", layerDigest), ShouldBeFalse)
+
+		imgStore = local.NewImageStore(dir, false, storageConstants.DefaultGCDelay, false, true,
+			log, metrics, nil, nil)
+		imgStore.SetSharedBlobs(true)
+
+		taskScheduler, cancel := runAndGetScheduler()
+		defer cancel()
+
+		imgStore.RunDedupeBlobs(time.Duration(0), taskScheduler)
+
+		for i := 0; i < 100 && !isLink("migrate2", image.Manifest.Config.Digest); i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		for _, repo := range []string{"migrate1", "migrate2"} {
+			So(isLink(repo, layerDigest), ShouldBeTrue)
+			So(isLink(repo, image.Manifest.Config.Digest), ShouldBeTrue)
+
+			// manifests stay in the repo
+			So(isLink(repo, manifestDigest), ShouldBeFalse)
+
+			_, _, _, err = imgStore.GetImageManifest(repo, "1.0")
+			So(err, ShouldBeNil)
+
+			blobContent, err := imgStore.GetBlobContent(repo, layerDigest)
+			So(err, ShouldBeNil)
+			So(blobContent, ShouldResemble, image.Layers[0])
+		}
+
+		_, err = os.Stat(path.Join(dir, storageConstants.SharedBlobsDir, "sha256", layerDigest.Encoded()))
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestMountBlob(t *testing.T) {
+	log := log.Logger{Logger: zerolog.New(os.Stdout)}
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	for _, sharedBlobs := range []bool{false, true} {
+		Convey(fmt.Sprintf("Mount a blob from another repo, shared blobs: %t", sharedBlobs), t, func() {
+			dir := t.TempDir()
+
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, false, false, log, metrics, nil, nil)
+			imgStore.SetSharedBlobs(sharedBlobs)
+
+			content := []byte("test-data")
+			digest := godigest.FromBytes(content)
+
+			_, _, err := imgStore.FullBlobUpload("repo1", bytes.NewReader(content), digest)
+			So(err, ShouldBeNil)
+
+			size, err := imgStore.MountBlob("repo2", "repo1", digest)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, len(content))
+
+			blobContent, err := imgStore.GetBlobContent("repo2", digest)
+			So(err, ShouldBeNil)
+			So(blobContent, ShouldResemble, content)
+
+			// mounted again
+			size, err = imgStore.MountBlob("repo2", "repo1", digest)
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, len(content))
+
+			// the other repos aren't looked up
+			_, err = imgStore.MountBlob("repo3", "missing", digest)
+			So(err, ShouldEqual, zerr.ErrBlobNotFound)
+
+			_, err = imgStore.MountBlob("repo3", "repo1", godigest.FromString("missing"))
+			So(err, ShouldEqual, zerr.ErrBlobNotFound)
+
+			_, err = imgStore.MountBlob("repo3", "repo1", "sha256:")
+			So(err, ShouldNotBeNil)
+		})
+	}
+}
+
+func TestGarbageCollect(t *testing.T) {
+	Convey("Repo layout", t, func(c C) {
+		dir := t.TempDir()
+
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		Convey("Garbage collect with default/long delay", func() {
+			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+				RootDir:     dir,
+				Name:        "cache",
+				UseRelPaths: true,
+			}, log)
+			imgStore := local.NewImageStore(dir, true, storageConstants.DefaultGCDelay,
+				true, true, log, metrics, nil, cacheDriver)
+			repoName := "gc-long"
+
+			upload, err := imgStore.NewBlobUpload(repoName)
+			So(err, ShouldBeNil)
+			So(upload, ShouldNotBeEmpty)
+
+			content := []byte("test-data1")
+			buf := bytes.NewBuffer(content)
+			buflen := buf.Len()
+			bdigest := godigest.FromBytes(content)
+
+			blob, err := imgStore.PutBlobChunk(repoName, upload, 0, int64(buflen), buf)
 			So(err, ShouldBeNil)
 			So(blob, ShouldEqual, buflen)
 
-			err = imgStore.FinishBlobUpload(repo2Name, upload, buf, bdigest)
+			err = imgStore.FinishBlobUpload(repoName, upload, buf, bdigest)
 			So(err, ShouldBeNil)
 
-			annotationsMap = make(map[string]string)
+			annotationsMap := make(map[string]string)
 			annotationsMap[ispec.AnnotationRefName] = tag
 
-			cblob, cdigest = test.GetRandomImageConfig()
-			_, clen, err = imgStore.FullBlobUpload(repo2Name, bytes.NewReader(cblob), cdigest)
+			cblob, cdigest := test.GetRandomImageConfig()
+			_, clen, err := imgStore.FullBlobUpload(repoName, bytes.NewReader(cblob), cdigest)
 			So(err, ShouldBeNil)
 			So(clen, ShouldEqual, len(cblob))
-			hasBlob, _, err = imgStore.CheckBlob(repo2Name, cdigest)
+			hasBlob, _, err := imgStore.CheckBlob(repoName, cdigest)
 			So(err, ShouldBeNil)
 			So(hasBlob, ShouldEqual, true)
 
-			manifest = ispec.Manifest{
+			manifest := ispec.Manifest{
 				Config: ispec.Descriptor{
 					MediaType: "application/vnd.oci.image.config.v1+json",
 					Digest:    cdigest,
@@ -2328,46 +2621,83 @@ func TestGarbageCollect(t *testing.T) {
 			}
 
 			manifest.SchemaVersion = 2
-			manifestBuf, err = json.Marshal(manifest)
+			manifestBuf, err := json.Marshal(manifest)
 			So(err, ShouldBeNil)
+			digest := godigest.FromBytes(manifestBuf)
 
-			_, _, err = imgStore.PutImageManifest(repo2Name, tag, ispec.MediaTypeImageManifest, manifestBuf)
+			_, _, err = imgStore.PutImageManifest(repoName, tag, ispec.MediaTypeImageManifest, manifestBuf)
 			So(err, ShouldBeNil)
 
-			hasBlob, _, err = imgStore.CheckBlob(repo2Name, bdigest)
+			hasBlob, _, err = imgStore.CheckBlob(repoName, bdigest)
 			So(err, ShouldBeNil)
 			So(hasBlob, ShouldEqual, true)
 
-			// immediately upload any other image to second repo which should invoke GC inline, but expect layers to persist
+			err = imgStore.DeleteImageManifest(repoName, digest.String(), false)
+			So(err, ShouldBeNil)
 
-			upload, err = imgStore.NewBlobUpload(repo2Name)
+			hasBlob, _, err = imgStore.CheckBlob(repoName, bdigest)
+			So(err, ShouldBeNil)
+			So(hasBlob, ShouldEqual, true)
+		})
+
+		Convey("Garbage collect with short delay", func() {
+			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+				RootDir:     dir,
+				Name:        "cache",
+				UseRelPaths: true,
+			}, log)
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, cacheDriver)
+			repoName := "gc-short"
+
+			// upload orphan blob
+			upload, err := imgStore.NewBlobUpload(repoName)
 			So(err, ShouldBeNil)
 			So(upload, ShouldNotBeEmpty)
 
-			content = []byte("test-data-more")
+			content := []byte("test-data1")
+			buf := bytes.NewBuffer(content)
+			buflen := buf.Len()
+			odigest := godigest.FromBytes(content)
+
+			blob, err := imgStore.PutBlobChunk(repoName, upload, 0, int64(buflen), buf)
+			So(err, ShouldBeNil)
+			So(blob, ShouldEqual, buflen)
+
+			err = imgStore.FinishBlobUpload(repoName, upload, buf, odigest)
+			So(err, ShouldBeNil)
+
+			// sleep so orphan blob can be GC'ed
+			time.Sleep(5 * time.Second)
+
+			// upload blob
+			upload, err = imgStore.NewBlobUpload(repoName)
+			So(err, ShouldBeNil)
+			So(upload, ShouldNotBeEmpty)
+
+			content = []byte("test-data2")
 			buf = bytes.NewBuffer(content)
 			buflen = buf.Len()
-			bdigest = godigest.FromBytes(content)
+			bdigest := godigest.FromBytes(content)
 
-			blob, err = imgStore.PutBlobChunk(repo2Name, upload, 0, int64(buflen), buf)
+			blob, err = imgStore.PutBlobChunk(repoName, upload, 0, int64(buflen), buf)
 			So(err, ShouldBeNil)
 			So(blob, ShouldEqual, buflen)
 
-			err = imgStore.FinishBlobUpload(repo2Name, upload, buf, bdigest)
+			err = imgStore.FinishBlobUpload(repoName, upload, buf, bdigest)
 			So(err, ShouldBeNil)
 
-			annotationsMap = make(map[string]string)
+			annotationsMap := make(map[string]string)
 			annotationsMap[ispec.AnnotationRefName] = tag
 
-			cblob, cdigest = test.GetRandomImageConfig()
-			_, clen, err = imgStore.FullBlobUpload(repo2Name, bytes.NewReader(cblob), cdigest)
+			cblob, cdigest := test.GetRandomImageConfig()
+			_, clen, err := imgStore.FullBlobUpload(repoName, bytes.NewReader(cblob), cdigest)
 			So(err, ShouldBeNil)
 			So(clen, ShouldEqual, len(cblob))
-			hasBlob, _, err = imgStore.CheckBlob(repo2Name, cdigest)
+			hasBlob, _, err := imgStore.CheckBlob(repoName, cdigest)
 			So(err, ShouldBeNil)
 			So(hasBlob, ShouldEqual, true)
 
-			manifest = ispec.Manifest{
+			manifest := ispec.Manifest{
 				Config: ispec.Descriptor{
 					MediaType: "application/vnd.oci.image.config.v1+json",
 					Digest:    cdigest,
@@ -2384,15 +2714,219 @@ func TestGarbageCollect(t *testing.T) {
 			}
 
 			manifest.SchemaVersion = 2
-			manifestBuf, err = json.Marshal(manifest)
+			manifestBuf, err := json.Marshal(manifest)
 			So(err, ShouldBeNil)
 			digest := godigest.FromBytes(manifestBuf)
 
-			_, _, err = imgStore.PutImageManifest(repo2Name, tag, ispec.MediaTypeImageManifest, manifestBuf)
+			_, _, err = imgStore.PutImageManifest(repoName, tag, ispec.MediaTypeImageManifest, manifestBuf)
 			So(err, ShouldBeNil)
 
-			// original blob should exist
-
+			hasBlob, _, err = imgStore.CheckBlob(repoName, odigest)
+			So(err, ShouldNotBeNil)
+			So(hasBlob, ShouldEqual, false)
+
+			hasBlob, _, err = imgStore.CheckBlob(repoName, bdigest)
+			So(err, ShouldBeNil)
+			So(hasBlob, ShouldEqual, true)
+
+			// sleep so orphan blob can be GC'ed
+			time.Sleep(5 * time.Second)
+
+			err = imgStore.DeleteImageManifest(repoName, digest.String(), false)
+			So(err, ShouldBeNil)
+
+			hasBlob, _, err = imgStore.CheckBlob(repoName, bdigest)
+			So(err, ShouldNotBeNil)
+			So(hasBlob, ShouldEqual, false)
+		})
+
+		Convey("Garbage collect with dedupe", func() {
+			// garbage-collect is repo-local and dedupe is global and they can interact in strange ways
+			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+				RootDir:     dir,
+				Name:        "cache",
+				UseRelPaths: true,
+			}, log)
+			imgStore := local.NewImageStore(dir, true, 5*time.Second, true, true, log, metrics, nil, cacheDriver)
+
+			// first upload an image to the first repo and wait for GC timeout
+
+			repo1Name := "gc1"
+
+			// upload blob
+			upload, err := imgStore.NewBlobUpload(repo1Name)
+			So(err, ShouldBeNil)
+			So(upload, ShouldNotBeEmpty)
+
+			content := []byte("test-data")
+			buf := bytes.NewBuffer(content)
+			buflen := buf.Len()
+			bdigest := godigest.FromBytes(content)
+			tdigest := bdigest
+
+			blob, err := imgStore.PutBlobChunk(repo1Name, upload, 0, int64(buflen), buf)
+			So(err, ShouldBeNil)
+			So(blob, ShouldEqual, buflen)
+
+			err = imgStore.FinishBlobUpload(repo1Name, upload, buf, bdigest)
+			So(err, ShouldBeNil)
+
+			annotationsMap := make(map[string]string)
+			annotationsMap[ispec.AnnotationRefName] = tag
+
+			cblob, cdigest := test.GetRandomImageConfig()
+			_, clen, err := imgStore.FullBlobUpload(repo1Name, bytes.NewReader(cblob), cdigest)
+			So(err, ShouldBeNil)
+			So(clen, ShouldEqual, len(cblob))
+			hasBlob, _, err := imgStore.CheckBlob(repo1Name, cdigest)
+			So(err, ShouldBeNil)
+			So(hasBlob, ShouldEqual, true)
+
+			manifest := ispec.Manifest{
+				Config: ispec.Descriptor{
+					MediaType: "application/vnd.oci.image.config.v1+json",
+					Digest:    cdigest,
+					Size:      int64(len(cblob)),
+				},
+				Layers: []ispec.Descriptor{
+					{
+						MediaType: "application/vnd.oci.image.layer.v1.tar",
+						Digest:    bdigest,
+						Size:      int64(buflen),
+					},
+				},
+				Annotations: annotationsMap,
+			}
+
+			manifest.SchemaVersion = 2
+			manifestBuf, err := json.Marshal(manifest)
+			So(err, ShouldBeNil)
+
+			_, _, err = imgStore.PutImageManifest(repo1Name, tag, ispec.MediaTypeImageManifest, manifestBuf)
+			So(err, ShouldBeNil)
+
+			hasBlob, _, err = imgStore.CheckBlob(repo1Name, tdigest)
+			So(err, ShouldBeNil)
+			So(hasBlob, ShouldEqual, true)
+
+			// sleep so past GC timeout
+			time.Sleep(10 * time.Second)
+
+			hasBlob, _, err = imgStore.CheckBlob(repo1Name, tdigest)
+			So(err, ShouldBeNil)
+			So(hasBlob, ShouldEqual, true)
+
+			// upload another image into a second repo with the same blob contents so dedupe is triggered
+
+			repo2Name := "gc2"
+
+			upload, err = imgStore.NewBlobUpload(repo2Name)
+			So(err, ShouldBeNil)
+			So(upload, ShouldNotBeEmpty)
+
+			buf = bytes.NewBuffer(content)
+			buflen = buf.Len()
+
+			blob, err = imgStore.PutBlobChunk(repo2Name, upload, 0, int64(buflen), buf)
+			So(err, ShouldBeNil)
+			So(blob, ShouldEqual, buflen)
+
+			err = imgStore.FinishBlobUpload(repo2Name, upload, buf, bdigest)
+			So(err, ShouldBeNil)
+
+			annotationsMap = make(map[string]string)
+			annotationsMap[ispec.AnnotationRefName] = tag
+
+			cblob, cdigest = test.GetRandomImageConfig()
+			_, clen, err = imgStore.FullBlobUpload(repo2Name, bytes.NewReader(cblob), cdigest)
+			So(err, ShouldBeNil)
+			So(clen, ShouldEqual, len(cblob))
+			hasBlob, _, err = imgStore.CheckBlob(repo2Name, cdigest)
+			So(err, ShouldBeNil)
+			So(hasBlob, ShouldEqual, true)
+
+			manifest = ispec.Manifest{
+				Config: ispec.Descriptor{
+					MediaType: "application/vnd.oci.image.config.v1+json",
+					Digest:    cdigest,
+					Size:      int64(len(cblob)),
+				},
+				Layers: []ispec.Descriptor{
+					{
+						MediaType: "application/vnd.oci.image.layer.v1.tar",
+						Digest:    bdigest,
+						Size:      int64(buflen),
+					},
+				},
+				Annotations: annotationsMap,
+			}
+
+			manifest.SchemaVersion = 2
+			manifestBuf, err = json.Marshal(manifest)
+			So(err, ShouldBeNil)
+
+			_, _, err = imgStore.PutImageManifest(repo2Name, tag, ispec.MediaTypeImageManifest, manifestBuf)
+			So(err, ShouldBeNil)
+
+			hasBlob, _, err = imgStore.CheckBlob(repo2Name, bdigest)
+			So(err, ShouldBeNil)
+			So(hasBlob, ShouldEqual, true)
+
+			// immediately upload any other image to second repo which should invoke GC inline, but expect layers to persist
+
+			upload, err = imgStore.NewBlobUpload(repo2Name)
+			So(err, ShouldBeNil)
+			So(upload, ShouldNotBeEmpty)
+
+			content = []byte("test-data-more")
+			buf = bytes.NewBuffer(content)
+			buflen = buf.Len()
+			bdigest = godigest.FromBytes(content)
+
+			blob, err = imgStore.PutBlobChunk(repo2Name, upload, 0, int64(buflen), buf)
+			So(err, ShouldBeNil)
+			So(blob, ShouldEqual, buflen)
+
+			err = imgStore.FinishBlobUpload(repo2Name, upload, buf, bdigest)
+			So(err, ShouldBeNil)
+
+			annotationsMap = make(map[string]string)
+			annotationsMap[ispec.AnnotationRefName] = tag
+
+			cblob, cdigest = test.GetRandomImageConfig()
+			_, clen, err = imgStore.FullBlobUpload(repo2Name, bytes.NewReader(cblob), cdigest)
+			So(err, ShouldBeNil)
+			So(clen, ShouldEqual, len(cblob))
+			hasBlob, _, err = imgStore.CheckBlob(repo2Name, cdigest)
+			So(err, ShouldBeNil)
+			So(hasBlob, ShouldEqual, true)
+
+			manifest = ispec.Manifest{
+				Config: ispec.Descriptor{
+					MediaType: "application/vnd.oci.image.config.v1+json",
+					Digest:    cdigest,
+					Size:      int64(len(cblob)),
+				},
+				Layers: []ispec.Descriptor{
+					{
+						MediaType: "application/vnd.oci.image.layer.v1.tar",
+						Digest:    bdigest,
+						Size:      int64(buflen),
+					},
+				},
+				Annotations: annotationsMap,
+			}
+
+			manifest.SchemaVersion = 2
+			manifestBuf, err = json.Marshal(manifest)
+			So(err, ShouldBeNil)
+			digest := godigest.FromBytes(manifestBuf)
+
+			_, _, err = imgStore.PutImageManifest(repo2Name, tag, ispec.MediaTypeImageManifest, manifestBuf)
+			So(err, ShouldBeNil)
+
+			// original blob should exist
+
 			hasBlob, _, err = imgStore.CheckBlob(repo2Name, tdigest)
 			So(err, ShouldBeNil)
 			So(hasBlob, ShouldEqual, true)
@@ -2410,71 +2944,619 @@ func TestGarbageCollectForImageStore(t *testing.T) {
 		Convey("Garbage collect error for repo with config removed", func() {
 			logFile, _ := os.CreateTemp("", "zot-log*.txt")
 
-			defer os.Remove(logFile.Name()) // clean up
+			defer os.Remove(logFile.Name()) // clean up
+
+			log := log.NewLogger("debug", logFile.Name())
+			metrics := monitoring.NewMetricsServer(false, log)
+			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+				RootDir:     dir,
+				Name:        "cache",
+				UseRelPaths: true,
+			}, log)
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, cacheDriver)
+			repoName := "gc-all-repos-short"
+
+			test.CopyTestFiles("../../../test/data/zot-test", path.Join(dir, repoName))
+
+			var manifestDigest godigest.Digest
+			manifestDigest, _, _ = test.GetOciLayoutDigests("../../../test/data/zot-test")
+			err := os.Remove(path.Join(dir, repoName, "blobs/sha256", manifestDigest.Encoded()))
+			if err != nil {
+				panic(err)
+			}
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldNotBeNil)
+
+			time.Sleep(500 * time.Millisecond)
+
+			data, err := os.ReadFile(logFile.Name())
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring,
+				fmt.Sprintf("error while running GC for %s", path.Join(imgStore.RootDir(), repoName)))
+		})
+
+		Convey("Garbage collect error - not enough permissions to access index.json", func() {
+			logFile, _ := os.CreateTemp("", "zot-log*.txt")
+
+			defer os.Remove(logFile.Name()) // clean up
+
+			log := log.NewLogger("debug", logFile.Name())
+			metrics := monitoring.NewMetricsServer(false, log)
+			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+				RootDir:     dir,
+				Name:        "cache",
+				UseRelPaths: true,
+			}, log)
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, cacheDriver)
+			repoName := "gc-all-repos-short"
+
+			test.CopyTestFiles("../../../test/data/zot-test", path.Join(dir, repoName))
+
+			So(os.Chmod(path.Join(dir, repoName, "index.json"), 0o000), ShouldBeNil)
+
+			err := imgStore.RunGCRepo(repoName)
+			So(err, ShouldNotBeNil)
+
+			time.Sleep(500 * time.Millisecond)
+
+			data, err := os.ReadFile(logFile.Name())
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring,
+				fmt.Sprintf("error while running GC for %s", path.Join(imgStore.RootDir(), repoName)))
+			So(os.Chmod(path.Join(dir, repoName, "index.json"), 0o755), ShouldBeNil)
+		})
+
+		Convey("Garbage collect verifies a sample of the blobs", func() {
+			logFile, _ := os.CreateTemp("", "zot-log*.txt")
+
+			defer os.Remove(logFile.Name()) // clean up
+
+			log := log.NewLogger("debug", logFile.Name())
+			metrics := monitoring.NewMetricsServer(false, log)
+			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+				RootDir:     dir,
+				Name:        "cache",
+				UseRelPaths: true,
+			}, log)
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, cacheDriver)
+			imgStore.SetGCVerifyPercent(100)
+			repoName := "gc-verify"
+
+			test.CopyTestFiles("../../../test/data/zot-test", path.Join(dir, repoName))
+
+			_, _, layerDigest := test.GetOciLayoutDigests("../../../test/data/zot-test")
+			err := os.WriteFile(path.Join(dir, repoName, "blobs/sha256", layerDigest.Encoded()),
+				[]byte("corrupted"), 0o600)
+			So(err, ShouldBeNil)
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldBeNil)
+
+			time.Sleep(500 * time.Millisecond)
+
+			data, err := os.ReadFile(logFile.Name())
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring, "blob content doesn't match its digest")
+			So(string(data), ShouldContainSubstring, layerDigest.String())
+			So(string(data), ShouldContainSubstring, `"mismatches":1`)
+		})
+
+		Convey("Garbage collect is paused while the storage is leased", func() {
+			logFile, _ := os.CreateTemp("", "zot-log*.txt")
+
+			defer os.Remove(logFile.Name()) // clean up
+
+			log := log.NewLogger("debug", logFile.Name())
+			metrics := monitoring.NewMetricsServer(false, log)
+			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+				RootDir:     dir,
+				Name:        "cache",
+				UseRelPaths: true,
+			}, log)
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, cacheDriver)
+			leases := lease.New(time.Hour, metrics, log)
+			imgStore.SetLeases(leases)
+			repoName := "gc-leased"
+
+			test.CopyTestFiles("../../../test/data/zot-test", path.Join(dir, repoName))
+
+			// gc fails if it runs since the manifest is missing
+			manifestDigest, _, _ := test.GetOciLayoutDigests("../../../test/data/zot-test")
+			err := os.Remove(path.Join(dir, repoName, "blobs/sha256", manifestDigest.Encoded()))
+			So(err, ShouldBeNil)
+
+			storageLease, err := leases.Acquire("backup", "", time.Minute)
+			So(err, ShouldBeNil)
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldBeNil)
+
+			time.Sleep(500 * time.Millisecond)
+
+			data, err := os.ReadFile(logFile.Name())
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring, "gc: skipped, the storage is leased")
+
+			So(leases.Release(storageLease.ID), ShouldBeNil)
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Garbage collect stops removing content once the storage is leased", func() {
+			log := log.NewLogger("debug", "")
+			metrics := monitoring.NewMetricsServer(false, log)
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, false, false, log, metrics, nil, nil)
+			repoName := "gc-leased-while-running"
+
+			image, err := test.GetRandomImage("1.0")
+			So(err, ShouldBeNil)
+
+			err = test.WriteImageToFileSystem(image, repoName, storage.StoreController{DefaultStore: imgStore})
+			So(err, ShouldBeNil)
+
+			orphanContent := "orphan blob"
+			orphanDigest := godigest.FromString(orphanContent)
+
+			_, _, err = imgStore.FullBlobUpload(repoName, strings.NewReader(orphanContent), orphanDigest)
+			So(err, ShouldBeNil)
+
+			time.Sleep(2 * time.Second)
+
+			// the lease is acquired after gc checked it before starting
+			imgStore.SetLeases(&leasedAfterChecks{checks: 1})
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldBeNil)
+
+			found, _, _ := imgStore.CheckBlob(repoName, orphanDigest)
+			So(found, ShouldBeTrue)
+		})
+
+		Convey("Garbage collect keeps the in-flight blobs", func() {
+			log := log.NewLogger("debug", "")
+			metrics := monitoring.NewMetricsServer(false, log)
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, false, false, log, metrics, nil, nil)
+			refs := inflight.New(time.Hour)
+			imgStore.SetInFlight(refs)
+			storeController := storage.StoreController{DefaultStore: imgStore, InFlight: refs}
+			repoName := "gc-in-flight"
+
+			pushBlob := func(content string) godigest.Digest {
+				digest := godigest.FromString(content)
+
+				_, _, err := imgStore.FullBlobUpload(repoName, strings.NewReader(content), digest)
+				So(err, ShouldBeNil)
+
+				return digest
+			}
+
+			// uploaded before the manifest referencing it
+			uploadedDigest := pushBlob("uploaded blob")
+
+			// copied by a sync session
+			syncedDigest := pushBlob("synced blob")
+			refs.ReleaseUploads(repoName, syncedDigest)
+
+			session := refs.NewSession(repoName)
+			session.Hold(syncedDigest)
+
+			orphanDigest := pushBlob("orphan blob")
+			refs.ReleaseUploads(repoName, orphanDigest)
+
+			time.Sleep(2 * time.Second)
+
+			// pushing a manifest runs gc
+			image, err := test.GetRandomImage("1.0")
+			So(err, ShouldBeNil)
+
+			err = test.WriteImageToFileSystem(image, repoName, storeController)
+			So(err, ShouldBeNil)
+
+			for digest, kept := range map[godigest.Digest]bool{
+				uploadedDigest: true,
+				syncedDigest:   true,
+				orphanDigest:   false,
+			} {
+				found, _, _ := imgStore.CheckBlob(repoName, digest)
+				So(found, ShouldEqual, kept)
+			}
+
+			session.Release()
+			refs.ReleaseUploads(repoName, uploadedDigest)
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldBeNil)
+
+			for _, digest := range []godigest.Digest{uploadedDigest, syncedDigest} {
+				found, _, _ := imgStore.CheckBlob(repoName, digest)
+				So(found, ShouldBeFalse)
+			}
+
+			_, _, _, err = imgStore.GetImageManifest(repoName, "1.0")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Garbage collect removes the referrers tags without subject", func() {
+			log := log.NewLogger("debug", "")
+			metrics := monitoring.NewMetricsServer(false, log)
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, nil)
+			storeController := storage.StoreController{DefaultStore: imgStore}
+			repoName := "gc-referrers-tags"
+
+			image, err := test.GetRandomImage("1.0")
+			So(err, ShouldBeNil)
+
+			err = test.WriteImageToFileSystem(image, repoName, storeController)
+			So(err, ShouldBeNil)
+
+			subjectDigest, err := image.Digest()
+			So(err, ShouldBeNil)
+
+			referrer, err := test.GetRandomImage("")
+			So(err, ShouldBeNil)
+
+			subjectBlob, err := json.Marshal(image.Manifest)
+			So(err, ShouldBeNil)
+
+			referrer.Manifest.Subject = &ispec.Descriptor{
+				MediaType: ispec.MediaTypeImageManifest,
+				Digest:    subjectDigest,
+				Size:      int64(len(subjectBlob)),
+			}
+
+			referrerDigest, err := referrer.Digest()
+			So(err, ShouldBeNil)
+
+			referrer.Reference = referrerDigest.String()
+
+			err = test.WriteImageToFileSystem(referrer, repoName, storeController)
+			So(err, ShouldBeNil)
+
+			referrerBlob, err := json.Marshal(referrer.Manifest)
+			So(err, ShouldBeNil)
+
+			// the index pushed by clients to the referrers tag of the subject, and a stale one
+			referrersIndex, err := json.Marshal(ispec.Index{
+				Versioned: imeta.Versioned{SchemaVersion: 2},
+				MediaType: ispec.MediaTypeImageIndex,
+				Manifests: []ispec.Descriptor{{
+					MediaType: ispec.MediaTypeImageManifest,
+					Digest:    referrerDigest,
+					Size:      int64(len(referrerBlob)),
+				}},
+			})
+			So(err, ShouldBeNil)
+
+			subjectTag := "sha256-" + subjectDigest.Encoded()
+			staleTag := "sha256-" + godigest.FromString("removed").Encoded()
+
+			for _, tag := range []string{subjectTag, staleTag} {
+				_, _, err = imgStore.PutImageManifest(repoName, tag, ispec.MediaTypeImageIndex, referrersIndex)
+				So(err, ShouldBeNil)
+			}
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldBeNil)
+
+			tags, err := imgStore.GetImageTags(repoName)
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, []string{"1.0", subjectTag})
+
+			err = imgStore.DeleteImageManifest(repoName, "1.0", false)
+			So(err, ShouldBeNil)
+
+			tags, err = imgStore.GetImageTags(repoName)
+			So(err, ShouldBeNil)
+			So(tags, ShouldBeEmpty)
+		})
+
+		Convey("Garbage collect keeps the manifests reachable through nested indexes and referrers", func() {
+			log := log.NewLogger("debug", "")
+			metrics := monitoring.NewMetricsServer(false, log)
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, nil)
+			storeController := storage.StoreController{DefaultStore: imgStore}
+			repoName := "gc-reachable"
+
+			// pushes an untagged image, optionally referring to subject, and returns its descriptor
+			pushImage := func(subject *ispec.Descriptor) ispec.Descriptor {
+				image, err := test.GetRandomImage("")
+				So(err, ShouldBeNil)
+
+				image.Manifest.Subject = subject
+
+				digest, err := image.Digest()
+				So(err, ShouldBeNil)
+
+				image.Reference = digest.String()
+
+				err = test.WriteImageToFileSystem(image, repoName, storeController)
+				So(err, ShouldBeNil)
+
+				blob, err := json.Marshal(image.Manifest)
+				So(err, ShouldBeNil)
+
+				return ispec.Descriptor{MediaType: ispec.MediaTypeImageManifest, Digest: digest, Size: int64(len(blob))}
+			}
+
+			// pushes an index of manifests to reference and returns its descriptor
+			pushIndex := func(reference string, manifests ...ispec.Descriptor) ispec.Descriptor {
+				blob, err := json.Marshal(ispec.Index{
+					Versioned: imeta.Versioned{SchemaVersion: 2},
+					MediaType: ispec.MediaTypeImageIndex,
+					Manifests: manifests,
+				})
+				So(err, ShouldBeNil)
+
+				digest := godigest.FromBytes(blob)
+
+				if reference == "" {
+					reference = digest.String()
+				}
+
+				_, _, err = imgStore.PutImageManifest(repoName, reference, ispec.MediaTypeImageIndex, blob)
+				So(err, ShouldBeNil)
+
+				return ispec.Descriptor{MediaType: ispec.MediaTypeImageIndex, Digest: digest, Size: int64(len(blob))}
+			}
+
+			// multi -> outer index -> inner index -> image <- referrer <- referrer of the referrer
+			image := pushImage(nil)
+			referrer := pushImage(&image)
+			referrerOfReferrer := pushImage(&referrer)
+			innerIndex := pushIndex("", image)
+			outerIndex := pushIndex("multi", innerIndex)
+
+			// nothing refers to these
+			unreachableImage := pushImage(nil)
+			unreachableIndex := pushIndex("", unreachableImage)
+
+			time.Sleep(1 * time.Second)
+
+			err := imgStore.RunGCRepo(repoName)
+			So(err, ShouldBeNil)
+
+			for _, desc := range []ispec.Descriptor{image, referrer, referrerOfReferrer, innerIndex, outerIndex} {
+				_, _, _, err = imgStore.GetImageManifest(repoName, desc.Digest.String())
+				So(err, ShouldBeNil)
+			}
+
+			for _, desc := range []ispec.Descriptor{unreachableImage, unreachableIndex} {
+				_, _, _, err = imgStore.GetImageManifest(repoName, desc.Digest.String())
+				So(err, ShouldNotBeNil)
+			}
+
+			Convey("Once the tag is removed all its manifests are", func() {
+				err := imgStore.DeleteImageManifest(repoName, "multi", false)
+				So(err, ShouldBeNil)
+
+				err = imgStore.RunGCRepo(repoName)
+				So(err, ShouldBeNil)
+
+				for _, desc := range []ispec.Descriptor{image, referrer, referrerOfReferrer, innerIndex, outerIndex} {
+					_, _, _, err = imgStore.GetImageManifest(repoName, desc.Digest.String())
+					So(err, ShouldNotBeNil)
+				}
+			})
+		})
+
+		Convey("Garbage collect applies the retention policies", func() {
+			logFile, _ := os.CreateTemp("", "zot-log*.txt")
+
+			defer os.Remove(logFile.Name()) // clean up
+
+			log := log.NewLogger("debug", logFile.Name())
+			metrics := monitoring.NewMetricsServer(false, log)
+			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, nil)
+			storeController := storage.StoreController{DefaultStore: imgStore}
+			repoName := "gc-retention"
+
+			// 1.0 was pushed first and latest last
+			digests := map[string]godigest.Digest{}
+
+			for idx, tag := range []string{"1.0", "2.0", "latest"} {
+				image, err := test.GetRandomImage(tag)
+				So(err, ShouldBeNil)
+
+				err = test.WriteImageToFileSystem(image, repoName, storeController)
+				So(err, ShouldBeNil)
+
+				digests[tag], err = image.Digest()
+				So(err, ShouldBeNil)
+
+				pushedAt := time.Now().Add(time.Duration(idx-3) * time.Hour)
+				err = os.Chtimes(imgStore.BlobPath(repoName, digests[tag]), pushedAt, pushedAt)
+				So(err, ShouldBeNil)
+			}
+
+			// the tags are as old as their manifests, a zero time is not recorded, like for older tags
+			setTagsPushedAt := func(pushedAt map[string]time.Time) {
+				indexPath := path.Join(dir, repoName, "index.json")
+
+				buf, err := os.ReadFile(indexPath)
+				So(err, ShouldBeNil)
+
+				var index ispec.Index
+				So(json.Unmarshal(buf, &index), ShouldBeNil)
+
+				for idx, desc := range index.Manifests {
+					tagPushedAt, ok := pushedAt[desc.Annotations[ispec.AnnotationRefName]]
+
+					switch {
+					case !ok:
+					case tagPushedAt.IsZero():
+						delete(index.Manifests[idx].Annotations, common.AnnotationTagPushedAt)
+					default:
+						storageCommon.SetTagPushedAt(&index.Manifests[idx], tagPushedAt)
+					}
+				}
+
+				buf, err = json.Marshal(index)
+				So(err, ShouldBeNil)
+				So(os.WriteFile(indexPath, buf, 0o600), ShouldBeNil)
+			}
+
+			setTagsPushedAt(map[string]time.Time{
+				"1.0":    time.Now().Add(-3 * time.Hour),
+				"2.0":    time.Now().Add(-2 * time.Hour),
+				"latest": time.Now().Add(-1 * time.Hour),
+			})
+
+			policies, err := retention.New(&config.RetentionConfig{
+				DryRun: true,
+				Policies: []config.RetentionPolicy{
+					{Repositories: []string{"other/**"}},
+					{KeepTags: 1, ProtectTags: []string{"latest"}},
+				},
+			})
+			So(err, ShouldBeNil)
+
+			imgStore.SetRetention(policies)
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldBeNil)
+
+			data, err := os.ReadFile(logFile.Name())
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring, "gc: retention would remove tag")
+			So(string(data), ShouldNotContainSubstring, "gc: retention removing tag")
+
+			tags, err := imgStore.GetImageTags(repoName)
+			So(err, ShouldBeNil)
+			So(tags, ShouldHaveLength, 3)
+
+			Convey("Tags are removed without dry run", func() {
+				policies, err := retention.New(&config.RetentionConfig{
+					Policies: []config.RetentionPolicy{{KeepTags: 1, ProtectTags: []string{"latest"}}},
+				})
+				So(err, ShouldBeNil)
+
+				imgStore.SetRetention(policies)
+
+				err = imgStore.RunGCRepo(repoName)
+				So(err, ShouldBeNil)
+
+				tags, err := imgStore.GetImageTags(repoName)
+				So(err, ShouldBeNil)
+				So(tags, ShouldHaveLength, 2)
+				So(tags, ShouldContain, "2.0")
+				So(tags, ShouldContain, "latest")
+
+				// the manifest of the removed tag is removed too
+				ok, _, err := imgStore.CheckBlob(repoName, digests["1.0"])
+				So(err, ShouldNotBeNil)
+				So(ok, ShouldBeFalse)
+			})
+
+			Convey("Tags of pinned manifests are kept", func() {
+				policies, err := retention.New(&config.RetentionConfig{
+					Policies: []config.RetentionPolicy{{MaxAge: 90 * time.Minute}},
+				})
+				So(err, ShouldBeNil)
+
+				imgStore.SetRetention(policies)
+				imgStore.SetPinnedImages(mocks.RepoDBMock{
+					IsImagePinnedFn: func(repo string, digest godigest.Digest) (bool, error) {
+						return digest == digests["2.0"], nil
+					},
+				})
+
+				err = imgStore.RunGCRepo(repoName)
+				So(err, ShouldBeNil)
+
+				tags, err := imgStore.GetImageTags(repoName)
+				So(err, ShouldBeNil)
+				So(tags, ShouldResemble, []string{"2.0", "latest"})
+			})
+
+			Convey("Immutable tags are kept", func() {
+				policies, err := retention.New(&config.RetentionConfig{
+					Policies: []config.RetentionPolicy{{MaxAge: 90 * time.Minute}},
+				})
+				So(err, ShouldBeNil)
+
+				immutableTags, err := immutabletags.New([]config.ImmutableTagsRule{
+					{Repositories: []string{repoName}, Tags: []string{`\d+\.\d+`}},
+				})
+				So(err, ShouldBeNil)
 
-			log := log.NewLogger("debug", logFile.Name())
-			metrics := monitoring.NewMetricsServer(false, log)
-			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
-				RootDir:     dir,
-				Name:        "cache",
-				UseRelPaths: true,
-			}, log)
-			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, cacheDriver)
-			repoName := "gc-all-repos-short"
+				imgStore.SetRetention(policies)
+				imgStore.SetImmutableTags(immutableTags)
 
-			test.CopyTestFiles("../../../test/data/zot-test", path.Join(dir, repoName))
+				err = imgStore.RunGCRepo(repoName)
+				So(err, ShouldBeNil)
 
-			var manifestDigest godigest.Digest
-			manifestDigest, _, _ = test.GetOciLayoutDigests("../../../test/data/zot-test")
-			err := os.Remove(path.Join(dir, repoName, "blobs/sha256", manifestDigest.Encoded()))
-			if err != nil {
-				panic(err)
-			}
+				tags, err := imgStore.GetImageTags(repoName)
+				So(err, ShouldBeNil)
+				So(tags, ShouldResemble, []string{"1.0", "2.0", "latest"})
 
-			err = imgStore.RunGCRepo(repoName)
-			So(err, ShouldNotBeNil)
+				data, err := os.ReadFile(logFile.Name())
+				So(err, ShouldBeNil)
+				So(string(data), ShouldContainSubstring, "gc: retention skipping immutable tag")
+			})
 
-			time.Sleep(500 * time.Millisecond)
+			Convey("The age of the tags is used rather than the age of their manifests", func() {
+				manifest, _, mediaType, err := imgStore.GetImageManifest(repoName, "1.0")
+				So(err, ShouldBeNil)
 
-			data, err := os.ReadFile(logFile.Name())
-			So(err, ShouldBeNil)
-			So(string(data), ShouldContainSubstring,
-				fmt.Sprintf("error while running GC for %s", path.Join(imgStore.RootDir(), repoName)))
-		})
+				// the old manifest of 1.0 is tagged again, and the push time of latest isn't recorded
+				_, _, err = imgStore.PutImageManifest(repoName, "1.1", mediaType, manifest)
+				So(err, ShouldBeNil)
 
-		Convey("Garbage collect error - not enough permissions to access index.json", func() {
-			logFile, _ := os.CreateTemp("", "zot-log*.txt")
+				setTagsPushedAt(map[string]time.Time{"latest": {}})
 
-			defer os.Remove(logFile.Name()) // clean up
+				policies, err := retention.New(&config.RetentionConfig{
+					Policies: []config.RetentionPolicy{{MaxAge: 150 * time.Minute}},
+				})
+				So(err, ShouldBeNil)
 
-			log := log.NewLogger("debug", logFile.Name())
-			metrics := monitoring.NewMetricsServer(false, log)
-			cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
-				RootDir:     dir,
-				Name:        "cache",
-				UseRelPaths: true,
-			}, log)
-			imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, cacheDriver)
-			repoName := "gc-all-repos-short"
+				removals := &tagRemovalsRecorder{}
 
-			test.CopyTestFiles("../../../test/data/zot-test", path.Join(dir, repoName))
+				imgStore.SetRetention(policies)
+				imgStore.SetTagRemovals(removals)
 
-			So(os.Chmod(path.Join(dir, repoName, "index.json"), 0o000), ShouldBeNil)
+				err = imgStore.RunGCRepo(repoName)
+				So(err, ShouldBeNil)
 
-			err := imgStore.RunGCRepo(repoName)
-			So(err, ShouldNotBeNil)
+				tags, err := imgStore.GetImageTags(repoName)
+				So(err, ShouldBeNil)
+				So(tags, ShouldResemble, []string{"2.0", "latest", "1.1"})
 
-			time.Sleep(500 * time.Millisecond)
+				ok, _, err := imgStore.CheckBlob(repoName, digests["1.0"])
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeTrue)
 
-			data, err := os.ReadFile(logFile.Name())
-			So(err, ShouldBeNil)
-			So(string(data), ShouldContainSubstring,
-				fmt.Sprintf("error while running GC for %s", path.Join(imgStore.RootDir(), repoName)))
-			So(os.Chmod(path.Join(dir, repoName, "index.json"), 0o755), ShouldBeNil)
+				// latest is as old as its manifest, which is younger than the max age
+				So(removals.repos, ShouldResemble, []string{repoName})
+				So(removals.tags, ShouldHaveLength, 1)
+				So(removals.tags[0].Tag, ShouldEqual, "1.0")
+			})
 		})
 	})
 }
 
+type tagRemovalsRecorder struct {
+	repos []string
+	tags  []storageTypes.TagInfo
+}
+
+func (recorder *tagRemovalsRecorder) OnTagsRemoved(repo string, tags []storageTypes.TagInfo) {
+	recorder.repos = append(recorder.repos, repo)
+	recorder.tags = append(recorder.tags, tags...)
+}
+
+// leasedAfterChecks is a lease acquired once it has been checked the given number of times.
+type leasedAfterChecks struct {
+	checks int
+}
+
+func (leases *leasedAfterChecks) IsHeld() bool {
+	leases.checks--
+
+	return leases.checks < 0
+}
+
 func TestGarbageCollectErrors(t *testing.T) {
 	Convey("Make image store", t, func(c C) {
 		dir := t.TempDir()
@@ -2691,6 +3773,82 @@ func TestGarbageCollectErrors(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(found, ShouldEqual, true)
 		})
+
+		Convey("Skip pinned untagged manifests", func() {
+			// upload image config blob
+			upload, err = imgStore.NewBlobUpload(repoName)
+			So(err, ShouldBeNil)
+			So(upload, ShouldNotBeEmpty)
+
+			cblob, cdigest := test.GetRandomImageConfig()
+			buf = bytes.NewBuffer(cblob)
+			buflen = buf.Len()
+			blob, err = imgStore.PutBlobChunkStreamed(repoName, upload, buf)
+			So(err, ShouldBeNil)
+			So(blob, ShouldEqual, buflen)
+
+			err = imgStore.FinishBlobUpload(repoName, upload, buf, cdigest)
+			So(err, ShouldBeNil)
+			So(blob, ShouldEqual, buflen)
+
+			// create a manifest
+			manifest := ispec.Manifest{
+				Config: ispec.Descriptor{
+					MediaType: ispec.MediaTypeImageConfig,
+					Digest:    cdigest,
+					Size:      int64(len(cblob)),
+				},
+				Layers: []ispec.Descriptor{
+					{
+						MediaType: ispec.MediaTypeImageLayer,
+						Digest:    bdgst1,
+						Size:      int64(bsize1),
+					},
+				},
+			}
+			manifest.SchemaVersion = 2
+			content, err = json.Marshal(manifest)
+			So(err, ShouldBeNil)
+			digest = godigest.FromBytes(content)
+			So(digest, ShouldNotBeNil)
+
+			_, _, err = imgStore.PutImageManifest(repoName, digest.String(), ispec.MediaTypeImageManifest, content)
+			So(err, ShouldBeNil)
+
+			imgStore.SetPinnedImages(mocks.RepoDBMock{
+				IsImagePinnedFn: func(repo string, manifestDigest godigest.Digest) (bool, error) {
+					return manifestDigest == digest, nil
+				},
+			})
+
+			time.Sleep(500 * time.Millisecond)
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldBeNil)
+
+			// pinned manifest shouldn't be gc'ed
+			found, _, err := imgStore.CheckBlob(repoName, digest)
+			So(err, ShouldBeNil)
+			So(found, ShouldEqual, true)
+
+			imgStore.SetPinnedImages(mocks.RepoDBMock{
+				IsImagePinnedFn: func(repo string, manifestDigest godigest.Digest) (bool, error) {
+					return false, errCache
+				},
+			})
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldNotBeNil)
+
+			imgStore.SetPinnedImages(mocks.RepoDBMock{})
+
+			err = imgStore.RunGCRepo(repoName)
+			So(err, ShouldBeNil)
+
+			// once unpinned the manifest is gc'ed
+			_, _, _, err = imgStore.GetImageManifest(repoName, digest.String())
+			So(err, ShouldNotBeNil)
+		})
 	})
 }
 
@@ -2710,6 +3868,95 @@ func randSeq(n int) string {
 	return string(buf)
 }
 
+func TestIncrementalGarbageCollect(t *testing.T) {
+	Convey("Incremental garbage collect sweeps the blobs in batches", t, func() {
+		dir := t.TempDir()
+
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+		cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+			RootDir:     dir,
+			Name:        "cache",
+			UseRelPaths: true,
+		}, log)
+		imgStore := local.NewImageStore(dir, true, 1*time.Second, true, true, log, metrics, nil, cacheDriver)
+		imgStore.SetGCBatchSize(2)
+
+		storeController := storage.StoreController{DefaultStore: imgStore}
+		repoName := "gc-incremental"
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		err = test.WriteImageToFileSystem(image, repoName, storeController)
+		So(err, ShouldBeNil)
+
+		// blobs which no manifest references, in the order they're swept
+		orphans := []godigest.Digest{}
+
+		for i := 0; i < 5; i++ {
+			content := []byte(fmt.Sprintf("orphan blob %d", i))
+			digest := godigest.FromBytes(content)
+
+			_, _, err := imgStore.FullBlobUpload(repoName, bytes.NewReader(content), digest)
+			So(err, ShouldBeNil)
+
+			orphans = append(orphans, digest)
+		}
+
+		sort.Slice(orphans, func(i, j int) bool { return orphans[i] < orphans[j] })
+
+		blobExists := func(digest godigest.Digest) bool {
+			_, err := os.Stat(imgStore.BlobPath(repoName, digest))
+
+			return err == nil
+		}
+
+		time.Sleep(1 * time.Second)
+
+		// a previous sweep of the repo stopped after the first orphan
+		checkpointer, ok := cacheDriver.(cache.Checkpointer)
+		So(ok, ShouldBeTrue)
+
+		err = checkpointer.PutGCCheckpoint(repoName, orphans[0])
+		So(err, ShouldBeNil)
+
+		err = imgStore.RunGCRepo(repoName)
+		So(err, ShouldBeNil)
+
+		So(blobExists(orphans[0]), ShouldBeTrue)
+
+		for _, orphan := range orphans[1:] {
+			So(blobExists(orphan), ShouldBeFalse)
+		}
+
+		So(blobExists(image.Manifest.Config.Digest), ShouldBeTrue)
+		So(blobExists(image.Manifest.Layers[0].Digest), ShouldBeTrue)
+
+		_, _, _, err = imgStore.GetImageManifest(repoName, "1.0")
+		So(err, ShouldBeNil)
+
+		// the sweep completed
+		checkpoint, err := checkpointer.GetGCCheckpoint(repoName)
+		So(err, ShouldBeNil)
+		So(checkpoint, ShouldBeEmpty)
+
+		Convey("The gc tasks sweep all the blobs once the checkpoint is cleared", func() {
+			taskScheduler, cancel := runAndGetScheduler()
+			defer cancel()
+
+			imgStore.RunGCPeriodically(time.Hour, taskScheduler)
+
+			for i := 0; i < 100 && blobExists(orphans[0]); i++ {
+				time.Sleep(100 * time.Millisecond)
+			}
+
+			So(blobExists(orphans[0]), ShouldBeFalse)
+			So(blobExists(image.Manifest.Layers[0].Digest), ShouldBeTrue)
+		})
+	})
+}
+
 func TestInitRepo(t *testing.T) {
 	Convey("Get error when creating BlobUploadDir subdir on initRepo", t, func() {
 		dir := t.TempDir()
@@ -2894,6 +4141,57 @@ func TestGetRepositories(t *testing.T) {
 		So(repos, ShouldContain, "test-dir-2")
 	})
 
+	Convey("Walk the repos coming after a given one", t, func() {
+		dir := t.TempDir()
+
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+		imgStore := local.NewImageStore(dir, true, storageConstants.DefaultGCDelay,
+			true, true, log, metrics, nil, nil,
+		)
+
+		for _, repo := range []string{"b", "a-c", "a/b", "a"} {
+			err := imgStore.InitRepo(repo)
+			So(err, ShouldBeNil)
+		}
+
+		walk := func(last string, limit int) []string {
+			repos := []string{}
+
+			err := imgStore.WalkRepositories(last, func(repo string) error {
+				repos = append(repos, repo)
+
+				if len(repos) == limit {
+					return io.EOF
+				}
+
+				return nil
+			})
+			if limit > 0 && len(repos) == limit {
+				So(err, ShouldEqual, io.EOF)
+			} else {
+				So(err, ShouldBeNil)
+			}
+
+			return repos
+		}
+
+		So(walk("", 0), ShouldResemble, []string{"a", "a/b", "a-c", "b"})
+		So(walk("a", 0), ShouldResemble, []string{"a/b", "a-c", "b"})
+		So(walk("a/b", 0), ShouldResemble, []string{"a-c", "b"})
+		So(walk("a-b", 0), ShouldResemble, []string{"a-c", "b"})
+		So(walk("b", 0), ShouldResemble, []string{})
+		So(walk("", 2), ShouldResemble, []string{"a", "a/b"})
+
+		repos, err := imgStore.GetRepositories()
+		So(err, ShouldBeNil)
+		So(repos, ShouldResemble, []string{"a", "a/b", "a-c", "b"})
+
+		repo, err := imgStore.GetNextRepository("a/b")
+		So(err, ShouldEqual, io.EOF)
+		So(repo, ShouldEqual, "a-c")
+	})
+
 	Convey("Verify GetRepositories() doesn't return '.' when having an oci layout as root directory ", t, func() {
 		dir := t.TempDir()
 
@@ -2987,6 +4285,34 @@ func TestGetRepositories(t *testing.T) {
 	})
 }
 
+func TestWithLogger(t *testing.T) {
+	Convey("Copies of the store log with their own logger and share its state", t, func() {
+		dir := t.TempDir()
+
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+		imgStore := local.NewImageStore(dir, true, storageConstants.DefaultGCDelay,
+			true, true, log, metrics, nil, nil,
+		)
+
+		var buf bytes.Buffer
+
+		requestStore := imgStore.WithLogger(zerolog.New(&buf).With().Str("requestID", "request-1").Logger())
+
+		err := requestStore.InitRepo("repo")
+		So(err, ShouldBeNil)
+
+		repos, err := imgStore.GetRepositories()
+		So(err, ShouldBeNil)
+		So(repos, ShouldResemble, []string{"repo"})
+
+		_, _, err = requestStore.GetBlob("repo", godigest.FromString("missing"), ispec.MediaTypeImageLayer)
+		So(err, ShouldEqual, zerr.ErrBlobNotFound)
+		So(buf.String(), ShouldContainSubstring, `"requestID":"request-1"`)
+		So(buf.String(), ShouldContainSubstring, "failed to stat blob")
+	})
+}
+
 func TestGetNextRepository(t *testing.T) {
 	dir := t.TempDir()
 	log := log.Logger{Logger: zerolog.New(os.Stdout)}
@@ -3160,3 +4486,49 @@ func isKnownErr(err error) bool {
 
 	return false
 }
+
+func TestLayoutVersion(t *testing.T) {
+	Convey("Record the layout version of the store", t, func() {
+		dir := t.TempDir()
+
+		log := log.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+		imgStore := local.NewImageStore(dir, true, storageConstants.DefaultGCDelay,
+			false, false, log, metrics, nil, nil)
+
+		version, err := imgStore.GetLayoutVersion()
+		So(err, ShouldBeNil)
+		So(version, ShouldEqual, 0)
+
+		err = imgStore.SetLayoutVersion(2)
+		So(err, ShouldBeNil)
+
+		version, err = imgStore.GetLayoutVersion()
+		So(err, ShouldBeNil)
+		So(version, ShouldEqual, 2)
+
+		// the version file isn't a repo
+		repos, err := imgStore.GetRepositories()
+		So(err, ShouldBeNil)
+		So(repos, ShouldBeEmpty)
+
+		err = os.WriteFile(path.Join(dir, storageConstants.LayoutVersionFile), []byte("{"), 0o600)
+		So(err, ShouldBeNil)
+
+		_, err = imgStore.GetLayoutVersion()
+		So(errors.Is(err, zerr.ErrBadLayoutVersion), ShouldBeTrue)
+
+		err = os.Chmod(dir, 0o000)
+		So(err, ShouldBeNil)
+
+		defer func() {
+			_ = os.Chmod(dir, 0o755)
+		}()
+
+		_, err = imgStore.GetLayoutVersion()
+		So(err, ShouldNotBeNil)
+
+		err = imgStore.SetLayoutVersion(3)
+		So(err, ShouldNotBeNil)
+	})
+}
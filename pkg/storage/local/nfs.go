@@ -0,0 +1,319 @@
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	guuid "github.com/gofrs/uuid"
+
+	zerr "zotregistry.io/zot/errors"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+const (
+	// created in the root directory while a zot instance writes to the storage, in the NFS mode.
+	nfsLockFile       = ".zot.lock"
+	nfsLockRetryDelay = 10 * time.Millisecond
+	// lock files older than this are considered left behind by a crashed instance.
+	nfsLockStaleTimeout = 5 * time.Minute
+	// the holder of the lock file touches it this often, so that it doesn't get stale while held.
+	nfsLockRefreshInterval = nfsLockStaleTimeout / 5
+	// errors creating the lock file are logged at most this often while retrying.
+	nfsLockErrorLogInterval = 10 * time.Second
+)
+
+// nfsLock is the lock file shared by all instances using the root directory, its state is shared by
+// the copies of the store.
+type nfsLock struct {
+	path string
+	// identifies the holder of the lock file, written in the file
+	owner string
+	// stops refreshing the lock file, closed on unlock
+	stop chan struct{}
+	done chan struct{}
+}
+
+/*
+SetNFSMode makes the image store safe to use on NFS, possibly shared by several zot instances:
+  - writes are serialized between instances with a lock file, in addition to the in-process lock
+  - index.json, manifests and other metadata files are written to a temp file then renamed,
+    so they're never seen partially written
+  - deduped blobs are copied instead of hard linked, the dedupe cache only records the copies.
+*/
+func (is *ImageStoreLocal) SetNFSMode(enabled bool) {
+	is.nfs = enabled
+}
+
+/*
+lockFile waits until it creates the lock file, which is then refreshed in the background until unlockFile
+so that other instances don't take it over as stale during long writes. The lock is never assumed to be
+held: if the lock file can't be created it keeps retrying, logging the error.
+*/
+func (is *ImageStoreLocal) lockFile() {
+	lock := is.nfsLock
+
+	uuid, err := guuid.NewV4()
+	if err != nil {
+		is.log.Error().Err(err).Msg("unable to generate lock file owner")
+	}
+
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%s %d %s\n", hostname, os.Getpid(), uuid)
+
+	var lastErrorLog time.Time
+
+	for {
+		err := createLockFile(lock.path, owner)
+		if err == nil {
+			break
+		}
+
+		if !os.IsExist(err) {
+			if time.Since(lastErrorLog) > nfsLockErrorLogInterval {
+				is.log.Error().Err(err).Str("lockFile", lock.path).Msg("unable to create lock file, retrying")
+
+				lastErrorLog = time.Now()
+			}
+
+			time.Sleep(nfsLockRetryDelay)
+
+			continue
+		}
+
+		is.removeStaleLockFile(lock.path)
+
+		time.Sleep(nfsLockRetryDelay)
+	}
+
+	lock.owner = owner
+	lock.stop = make(chan struct{})
+	lock.done = make(chan struct{})
+
+	go is.refreshLockFile(lock.path, owner, lock.stop, lock.done)
+}
+
+func createLockFile(lockPath, owner string) error {
+	file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, storageConstants.DefaultFilePerms)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteString(owner)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(lockPath)
+	}
+
+	return err
+}
+
+// removeStaleLockFile removes the lock file if it wasn't refreshed for a while, unless another instance
+// replaced it in the meantime.
+func (is *ImageStoreLocal) removeStaleLockFile(lockPath string) {
+	owner, err := os.ReadFile(lockPath)
+	if err != nil {
+		return
+	}
+
+	fileInfo, err := os.Stat(lockPath)
+	if err != nil || time.Since(fileInfo.ModTime()) <= nfsLockStaleTimeout {
+		return
+	}
+
+	if current, err := os.ReadFile(lockPath); err != nil || !bytes.Equal(current, owner) {
+		return
+	}
+
+	is.log.Warn().Str("lockFile", lockPath).Time("modTime", fileInfo.ModTime()).Str("owner", string(owner)).
+		Msg("removing stale lock file")
+
+	_ = os.Remove(lockPath)
+}
+
+// refreshLockFile touches the lock file until stop is closed, as long as it's still owned by owner.
+func (is *ImageStoreLocal) refreshLockFile(lockPath, owner string, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(nfsLockRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if !ownsLockFile(lockPath, owner) {
+				is.log.Error().Str("lockFile", lockPath).Msg("lock file was taken over by another instance")
+
+				return
+			}
+
+			now := time.Now()
+
+			if err := os.Chtimes(lockPath, now, now); err != nil {
+				is.log.Error().Err(err).Str("lockFile", lockPath).Msg("unable to refresh lock file")
+			}
+		}
+	}
+}
+
+// unlockFile stops refreshing the lock file and removes it, unless another instance took it over.
+func (is *ImageStoreLocal) unlockFile() {
+	lock := is.nfsLock
+
+	if lock.stop != nil {
+		close(lock.stop)
+		<-lock.done
+
+		lock.stop, lock.done = nil, nil
+	}
+
+	if !ownsLockFile(lock.path, lock.owner) {
+		is.log.Error().Str("lockFile", lock.path).Msg("not removing lock file owned by another instance")
+
+		return
+	}
+
+	if err := os.Remove(lock.path); err != nil && !os.IsNotExist(err) {
+		is.log.Error().Err(err).Str("lockFile", lock.path).Msg("unable to remove lock file")
+	}
+}
+
+func ownsLockFile(lockPath, owner string) bool {
+	content, err := os.ReadFile(lockPath)
+
+	return err == nil && string(content) == owner
+}
+
+// writeFileAtomically writes data to a temp file next to filename, then renames it to filename.
+func (is *ImageStoreLocal) writeFileAtomically(filename string, data []byte) error {
+	uuid, err := guuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	tempFilename := path.Join(filepath.Dir(filename), fmt.Sprintf(".%s.%s.tmp", path.Base(filename), uuid))
+
+	if err := is.writeFileContent(tempFilename, data); err != nil {
+		_ = os.Remove(tempFilename)
+
+		return err
+	}
+
+	if err := os.Rename(tempFilename, filename); err != nil {
+		_ = os.Remove(tempFilename)
+
+		return err
+	}
+
+	is.markDirty(filename)
+
+	return nil
+}
+
+// copyFile copies src to a temp file next to dst, then renames it to dst.
+func (is *ImageStoreLocal) copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer srcFile.Close()
+
+	uuid, err := guuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	tempFilename := path.Join(filepath.Dir(dst), fmt.Sprintf(".%s.%s.tmp", path.Base(dst), uuid))
+
+	dstFile, err := os.OpenFile(tempFilename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, storageConstants.DefaultFilePerms)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dstFile, srcFile)
+
+	if err == nil && is.commitPolicy == storageConstants.CommitPolicyAlways {
+		err = is.syncFile(dstFile, "blob")
+	}
+
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tempFilename, dst)
+	}
+
+	if err != nil {
+		_ = os.Remove(tempFilename)
+
+		return err
+	}
+
+	is.markDirty(dst)
+
+	return nil
+}
+
+// ValidateNFS checks the operations the NFS mode relies on work in rootDir: exclusive file creation and rename.
+func ValidateNFS(rootDir string) error {
+	if err := os.MkdirAll(rootDir, storageConstants.DefaultDirPerms); err != nil {
+		return err
+	}
+
+	uuid, err := guuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	checkFile := path.Join(rootDir, fmt.Sprintf("nfscheck-%s.txt", uuid))
+	renamedCheckFile := checkFile + ".renamed"
+
+	defer func() {
+		_ = os.Remove(checkFile)
+		_ = os.Remove(renamedCheckFile)
+	}()
+
+	file, err := os.OpenFile(checkFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, storageConstants.DefaultFilePerms)
+	if err != nil {
+		return err
+	}
+
+	_ = file.Close()
+
+	if file, err := os.OpenFile(checkFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+		storageConstants.DefaultFilePerms); err == nil {
+		_ = file.Close()
+
+		return zerr.ErrNFSExclusiveCreate
+	}
+
+	return os.Rename(checkFile, renamedCheckFile)
+}
+
+// getExistingDir returns dir or its closest existing parent.
+func getExistingDir(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+
+		dir = parent
+	}
+}
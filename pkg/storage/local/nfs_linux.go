@@ -0,0 +1,22 @@
+//go:build linux
+// +build linux
+
+package local
+
+import (
+	"syscall"
+)
+
+// NFS_SUPER_MAGIC from statfs(2).
+const nfsSuperMagic = 0x6969
+
+// IsNFS returns whether dir, or its closest existing parent, is on an NFS filesystem.
+func IsNFS(dir string) bool {
+	var stat syscall.Statfs_t
+
+	if err := syscall.Statfs(getExistingDir(dir), &stat); err != nil {
+		return false
+	}
+
+	return stat.Type == nfsSuperMagic
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package local
+
+// IsNFS returns false, NFS is only detected on linux, elsewhere the NFS mode has to be configured.
+func IsNFS(dir string) bool {
+	return false
+}
@@ -0,0 +1,72 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	guuid "github.com/gofrs/uuid"
+
+	zerr "zotregistry.io/zot/errors"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+/*
+SetDedupeStrategy sets how deduped blobs share their data:
+  - hardlink: all the paths of a blob are hard links to the same file
+  - reflink: each path is a separate file sharing its data blocks with the others (copy on write), on
+    filesystems supporting it, e.g. btrfs, xfs and zfs, otherwise blobs are hard linked.
+
+Reflinked blobs are separate files, so a repo's blobs can be removed, moved or have their permissions changed
+without affecting the other repos. They're ignored in the NFS mode, where blobs are copied.
+*/
+func (is *ImageStoreLocal) SetDedupeStrategy(strategy string) {
+	is.dedupeStrategy = strategy
+}
+
+// linkBlob makes dst a deduped copy of src, following the dedupe strategy.
+func (is *ImageStoreLocal) linkBlob(src, dst string) error {
+	if is.dedupeStrategy == storageConstants.DedupeStrategyReflink {
+		err := reflink(src, dst)
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, zerr.ErrReflinkNotSupported) {
+			return err
+		}
+
+		is.log.Debug().Err(err).Str("src", src).Str("dst", dst).Msg("dedupe: unable to reflink, hard linking blob")
+	}
+
+	return os.Link(src, dst)
+}
+
+// ValidateReflink checks the filesystem of rootDir supports reflinks.
+func ValidateReflink(rootDir string) error {
+	if err := os.MkdirAll(rootDir, storageConstants.DefaultDirPerms); err != nil {
+		return err
+	}
+
+	uuid, err := guuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	checkFile := path.Join(rootDir, fmt.Sprintf("reflinkcheck-%s.txt", uuid))
+	cloneFile := checkFile + ".clone"
+
+	defer func() {
+		_ = os.Remove(checkFile)
+		_ = os.Remove(cloneFile)
+	}()
+
+	err = os.WriteFile(checkFile, []byte("check whether reflinks work on filesystem"),
+		storageConstants.DefaultFilePerms)
+	if err != nil {
+		return err
+	}
+
+	return reflink(checkFile, cloneFile)
+}
@@ -0,0 +1,54 @@
+//go:build linux
+// +build linux
+
+package local
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+
+	zerr "zotregistry.io/zot/errors"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+/*
+reflink creates dst sharing the data blocks of src, with the FICLONE ioctl. copy_file_range isn't used, it
+falls back to copying the data on filesystems which can't share it, without telling.
+It returns ErrReflinkNotSupported if the filesystem doesn't support reflinks, dst is not created.
+*/
+func reflink(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, storageConstants.DefaultFilePerms)
+	if err != nil {
+		return err
+	}
+
+	err = unix.IoctlFileClone(int(dstFile.Fd()), int(srcFile.Fd()))
+
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(dst)
+
+		// not supported by the filesystem, or src and dst are on different filesystems
+		if errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.ENOTTY) || errors.Is(err, unix.EINVAL) ||
+			errors.Is(err, unix.EXDEV) || errors.Is(err, unix.ENOSYS) {
+			return fmt.Errorf("%w: %s", zerr.ErrReflinkNotSupported, err.Error())
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package local
+
+import (
+	zerr "zotregistry.io/zot/errors"
+)
+
+// reflink fails, reflinks are only supported on linux.
+func reflink(src, dst string) error {
+	return zerr.ErrReflinkNotSupported
+}
@@ -0,0 +1,446 @@
+package local
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.io/zot/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/scheduler"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+)
+
+/*
+SetSharedBlobs enables the shared blobs layout: blobs are stored once in the _blobs directory of the root
+directory and the blobs directory of each repo only holds its manifests and symlinks to the shared blobs it uses,
+so blobs don't need to be deduped with hard links. Repos keep the OCI layout, readers follow the symlinks.
+
+Shared blobs no repo links to anymore are removed by gc once all the repos are collected. The repos of an
+existing store are converted by the migration task submitted by RunDedupeBlobs.
+*/
+func (is *ImageStoreLocal) SetSharedBlobs(enabled bool) {
+	is.sharedBlobs = enabled
+}
+
+// sharedBlobPath returns the path of a blob in the shared pool.
+func (is *ImageStoreLocal) sharedBlobPath(digest godigest.Digest) string {
+	return path.Join(is.rootDir, storageConstants.SharedBlobsDir, digest.Algorithm().String(), digest.Encoded())
+}
+
+// shareBlob moves a blob to the shared pool, unless it's already there, and links it at dst, SHOULD lock from outside.
+func (is *ImageStoreLocal) shareBlob(src string, digest godigest.Digest, dst string) error {
+	sharedPath := is.sharedBlobPath(digest)
+
+	if err := ensureDir(filepath.Dir(sharedPath), is.log); err != nil {
+		is.log.Error().Err(err).Str("dir", filepath.Dir(sharedPath)).Msg("shared blobs: unable to create dir")
+
+		return err
+	}
+
+	if _, err := os.Stat(sharedPath); err == nil {
+		// the blob is already shared, the copy isn't needed
+		if err := os.Remove(src); err != nil {
+			is.log.Error().Err(err).Str("src", src).Msg("shared blobs: unable to remove duplicate blob")
+
+			return err
+		}
+	} else {
+		if err := os.Rename(src, sharedPath); err != nil {
+			is.log.Error().Err(err).Str("src", src).Str("dst", sharedPath).Msg("shared blobs: unable to move blob")
+
+			return err
+		}
+
+		is.markDirty(sharedPath)
+	}
+
+	return is.linkSharedBlob(digest, dst)
+}
+
+// linkSharedBlob replaces dst with a relative symlink to a shared blob, SHOULD lock from outside.
+func (is *ImageStoreLocal) linkSharedBlob(digest godigest.Digest, dst string) error {
+	sharedPath := is.sharedBlobPath(digest)
+
+	// relative links keep working if the root directory is moved or mounted elsewhere
+	target, err := filepath.Rel(filepath.Dir(dst), sharedPath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		is.log.Error().Err(err).Str("blobPath", dst).Msg("shared blobs: unable to replace blob")
+
+		return err
+	}
+
+	if err := os.Symlink(target, dst); err != nil {
+		is.log.Error().Err(err).Str("blobPath", dst).Str("target", target).Msg("shared blobs: unable to link blob")
+
+		return err
+	}
+
+	// gc may have listed the links before this one
+	if is.sharedBlobsLinked != nil {
+		is.sharedBlobsLinked[digest] = true
+	}
+
+	return nil
+}
+
+// checkSharedBlob links a shared blob in a repo, e.g. when it's mounted from another repo, SHOULD lock from outside.
+func (is *ImageStoreLocal) checkSharedBlob(repo string, digest godigest.Digest, blobPath string) (int64, error) {
+	sharedPath := is.sharedBlobPath(digest)
+
+	binfo, err := os.Stat(sharedPath)
+	if err != nil {
+		return -1, zerr.ErrBlobNotFound
+	}
+
+	if err := is.initRepo(repo); err != nil {
+		is.log.Error().Err(err).Str("repository", repo).Msg("unable to initialize an empty repo")
+
+		return -1, err
+	}
+
+	_ = ensureDir(filepath.Dir(blobPath), is.log)
+
+	if err := is.linkSharedBlob(digest, blobPath); err != nil {
+		return -1, zerr.ErrBlobNotFound
+	}
+
+	return binfo.Size(), nil
+}
+
+/*
+gcSharedBlobs removes the shared blobs which aren't linked by any repo anymore. The links are listed without
+holding the store lock, so pushes aren't blocked while all the repos are walked, the links made meanwhile are
+recorded and each shared blob found unlinked is checked again under the lock before being removed.
+*/
+func (is *ImageStoreLocal) gcSharedBlobs() error {
+	if is.isLeased() {
+		is.log.Info().Msg("gc: skipped shared blobs, the storage is leased by an external reader")
+
+		return nil
+	}
+
+	var lockLatency time.Time
+
+	is.Lock(&lockLatency)
+
+	if is.sharedBlobsLinked != nil {
+		is.Unlock(&lockLatency)
+
+		is.log.Info().Msg("gc: skipped shared blobs, they are already being collected")
+
+		return nil
+	}
+
+	is.sharedBlobsLinked = map[godigest.Digest]bool{}
+
+	is.Unlock(&lockLatency)
+
+	defer func() {
+		is.Lock(&lockLatency)
+		is.sharedBlobsLinked = nil
+		is.Unlock(&lockLatency)
+	}()
+
+	sharedDir := path.Join(is.rootDir, storageConstants.SharedBlobsDir)
+
+	linked := map[godigest.Digest]bool{}
+
+	err := filepath.WalkDir(is.rootDir, func(blobPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// removed while walking, e.g. a deleted repo
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if entry.IsDir() {
+			if blobPath == sharedDir {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if entry.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		digest := godigest.NewDigestFromEncoded(godigest.Algorithm(filepath.Base(filepath.Dir(blobPath))), entry.Name())
+		if digest.Validate() == nil {
+			linked[digest] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		is.log.Error().Err(err).Msg("gc: unable to list the links to shared blobs")
+
+		return err
+	}
+
+	algorithms, err := os.ReadDir(sharedDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		is.log.Error().Err(err).Str("dir", sharedDir).Msg("gc: unable to list shared blobs")
+
+		return err
+	}
+
+	for _, algorithm := range algorithms {
+		if !algorithm.IsDir() {
+			continue
+		}
+
+		entries, err := os.ReadDir(path.Join(sharedDir, algorithm.Name()))
+		if err != nil {
+			is.log.Error().Err(err).Str("dir", sharedDir).Msg("gc: unable to list shared blobs")
+
+			return err
+		}
+
+		for _, entry := range entries {
+			digest := godigest.NewDigestFromEncoded(godigest.Algorithm(algorithm.Name()), entry.Name())
+
+			if entry.IsDir() || digest.Validate() != nil || linked[digest] {
+				continue
+			}
+
+			// leased since gc started
+			if is.isLeaseHeld() {
+				is.log.Info().Msg("gc: stopped removing shared blobs, the storage is leased by an external reader")
+
+				return nil
+			}
+
+			if err := is.removeSharedBlob(digest); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeSharedBlob removes a shared blob found unlinked by gc, unless it was linked since gc listed the links.
+func (is *ImageStoreLocal) removeSharedBlob(digest godigest.Digest) error {
+	var lockLatency time.Time
+
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
+
+	if is.sharedBlobsLinked[digest] {
+		return nil
+	}
+
+	sharedPath := is.sharedBlobPath(digest)
+
+	is.log.Info().Str("digest", digest.String()).Str("blobPath", sharedPath).Msg("perform GC on shared blob")
+
+	if err := os.Remove(sharedPath); err != nil && !os.IsNotExist(err) {
+		is.log.Error().Err(err).Str("blobPath", sharedPath).Msg("gc: unable to remove shared blob")
+
+		return err
+	}
+
+	return nil
+}
+
+type gcSharedBlobsTask struct {
+	imgStore *ImageStoreLocal
+}
+
+func (gcT *gcSharedBlobsTask) DoWork() error {
+	return gcT.imgStore.gcSharedBlobs()
+}
+
+// getManifestDigests returns the manifests of a repo: the ones of index.json and, at any depth, the manifests
+// of its image indexes, SHOULD lock from outside.
+func (is *ImageStoreLocal) getManifestDigests(repo string) (map[godigest.Digest]bool, error) {
+	indexContent, err := is.GetIndexContent(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var index ispec.Index
+
+	if err := json.Unmarshal(indexContent, &index); err != nil {
+		is.log.Error().Err(err).Str("repository", repo).Msg("shared blobs: invalid JSON")
+
+		return nil, err
+	}
+
+	manifests := map[godigest.Digest]bool{}
+	toVisit := append([]ispec.Descriptor{}, index.Manifests...)
+
+	for len(toVisit) > 0 {
+		desc := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+
+		if manifests[desc.Digest] {
+			continue
+		}
+
+		manifests[desc.Digest] = true
+
+		if !zcommon.IsImageIndex(desc.MediaType) {
+			continue
+		}
+
+		buf, err := is.GetBlobContent(repo, desc.Digest)
+		if errors.Is(err, zerr.ErrBlobNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		var nestedIndex ispec.Index
+
+		if err := json.Unmarshal(buf, &nestedIndex); err != nil {
+			is.log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
+				Msg("shared blobs: invalid JSON")
+
+			return nil, err
+		}
+
+		toVisit = append(toVisit, nestedIndex.Manifests...)
+	}
+
+	return manifests, nil
+}
+
+/*
+migrateRepoToSharedBlobs converts a repo to the shared blobs layout: its blobs which aren't manifests are moved to
+the shared pool, or dropped if the pool already has them, and replaced with links. The links are skipped, so an
+interrupted migration is resumed by running it again.
+*/
+func (is *ImageStoreLocal) migrateRepoToSharedBlobs(repo string) error {
+	var lockLatency time.Time
+
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
+
+	manifests, err := is.getManifestDigests(repo)
+	if err != nil {
+		return err
+	}
+
+	blobsDir := path.Join(is.rootDir, repo, "blobs")
+
+	algorithms, err := os.ReadDir(blobsDir)
+	if err != nil {
+		is.log.Error().Err(err).Str("repository", repo).Msg("shared blobs: unable to list blobs")
+
+		return err
+	}
+
+	migrated := 0
+
+	for _, algorithm := range algorithms {
+		if !algorithm.IsDir() {
+			continue
+		}
+
+		entries, err := os.ReadDir(path.Join(blobsDir, algorithm.Name()))
+		if err != nil {
+			is.log.Error().Err(err).Str("repository", repo).Msg("shared blobs: unable to list blobs")
+
+			return err
+		}
+
+		for _, entry := range entries {
+			digest := godigest.NewDigestFromEncoded(godigest.Algorithm(algorithm.Name()), entry.Name())
+
+			if !entry.Type().IsRegular() || digest.Validate() != nil || manifests[digest] {
+				continue
+			}
+
+			blobPath := is.BlobPath(repo, digest)
+
+			// the blob isn't referenced by the dedupe cache anymore, it's shared through the pool
+			if fmt.Sprintf("%v", is.cache) != fmt.Sprintf("%v", nil) {
+				if err := is.cache.DeleteBlob(digest, blobPath); err != nil && !errors.Is(err, zerr.ErrCacheMiss) {
+					is.log.Error().Err(err).Str("digest", digest.String()).Str("blobPath", blobPath).
+						Msg("shared blobs: unable to remove blob path from cache")
+
+					return err
+				}
+			}
+
+			if err := is.shareBlob(blobPath, digest, blobPath); err != nil {
+				return err
+			}
+
+			migrated++
+		}
+	}
+
+	if migrated > 0 {
+		is.log.Info().Str("repository", repo).Int("blobs", migrated).Msg("shared blobs: migrated repo")
+	}
+
+	return nil
+}
+
+// sharedBlobsMigrationTaskGenerator converts the repos of a store to the shared blobs layout, one task per repo.
+type sharedBlobsMigrationTaskGenerator struct {
+	imgStore *ImageStoreLocal
+	lastRepo string
+	done     bool
+}
+
+func (gen *sharedBlobsMigrationTaskGenerator) Next() (scheduler.Task, error) {
+	repo, err := gen.imgStore.GetNextRepository(gen.lastRepo)
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if repo == "" {
+		gen.done = true
+
+		return nil, nil
+	}
+
+	gen.lastRepo = repo
+
+	return &sharedBlobsMigrationTask{gen.imgStore, repo}, nil
+}
+
+func (gen *sharedBlobsMigrationTaskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *sharedBlobsMigrationTaskGenerator) Reset() {
+	gen.lastRepo = ""
+	gen.done = false
+}
+
+type sharedBlobsMigrationTask struct {
+	imgStore *ImageStoreLocal
+	repo     string
+}
+
+func (task *sharedBlobsMigrationTask) DoWork() error {
+	return task.imgStore.migrateRepoToSharedBlobs(task.repo)
+}
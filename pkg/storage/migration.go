@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/log"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+/*
+Migration changes the on-disk layout of a store to the next layout version.
+
+Migrate returns the changes it made, or with dryRun set the changes it would make without making any.
+The version of a store is only recorded once its migration is done, so a migration interrupted by a crash
+is applied again from the start at the next startup: migrations have to be idempotent, and each of their
+steps has to leave the store usable by the previous layout version until the last one is done,
+e.g. new files are written next to the old ones before the old ones are removed.
+*/
+type Migration struct {
+	// Version is the layout version of the stores the migration was applied to.
+	Version     int
+	Description string
+	Migrate     func(imgStore storageTypes.ImageStore, dryRun bool, log log.Logger) ([]string, error)
+}
+
+// Migrations are the storage layout migrations, in version order, the last one gives the layout version
+// written by this zot.
+var Migrations = []Migration{ //nolint:gochecknoglobals
+	{
+		// stores created before layout versions were recorded only have the version recorded
+		Version:     1,
+		Description: "record the layout version of the store",
+	},
+}
+
+// MigrationReport lists the migrations applied to a store, or pending with a dry run.
+type MigrationReport struct {
+	RootDir string
+	// layout version of the store before migrating it
+	Version    int
+	Migrations []AppliedMigration
+}
+
+// AppliedMigration is a migration applied to a store, with the changes it made.
+type AppliedMigration struct {
+	Version     int
+	Description string
+	Changes     []string
+}
+
+// MigrateLayout migrates the layout of the stores to the version of the last migration, applying the
+// missing migrations in order. Stores with a newer layout version are rejected, they may have been written
+// by a newer zot in a way this one doesn't understand.
+func MigrateLayout(storeController StoreController, migrations []Migration, dryRun bool, log log.Logger,
+) ([]MigrationReport, error) {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			return nil, fmt.Errorf("%w: migration to version %d is not in version order",
+				zerr.ErrBadLayoutVersion, migrations[i].Version)
+		}
+	}
+
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+
+	imgStores := storeController.UniqueImageStores()
+
+	reports := make([]MigrationReport, 0, len(imgStores))
+
+	for _, imgStore := range imgStores {
+		report, err := migrateStore(imgStore, migrations, latest, dryRun, log)
+		if err != nil {
+			return reports, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func migrateStore(imgStore storageTypes.ImageStore, migrations []Migration, latest int, dryRun bool,
+	log log.Logger,
+) (MigrationReport, error) {
+	rootDir := imgStore.RootDir()
+
+	version, err := imgStore.GetLayoutVersion()
+	if err != nil {
+		log.Error().Err(err).Str("rootDir", rootDir).Msg("unable to get storage layout version")
+
+		return MigrationReport{}, err
+	}
+
+	report := MigrationReport{RootDir: rootDir, Version: version, Migrations: []AppliedMigration{}}
+
+	if version > latest {
+		log.Error().Err(zerr.ErrLayoutVersionNotSupported).Str("rootDir", rootDir).Int("version", version).
+			Int("supportedVersion", latest).Msg("storage layout was written by a newer zot")
+
+		return report, fmt.Errorf("%w: %s has layout version %d, this zot supports up to version %d",
+			zerr.ErrLayoutVersionNotSupported, rootDir, version, latest)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			continue
+		}
+
+		log.Info().Str("rootDir", rootDir).Int("version", migration.Version).
+			Str("migration", migration.Description).Bool("dryRun", dryRun).Msg("migrating storage layout")
+
+		var changes []string
+
+		if migration.Migrate != nil {
+			changes, err = migration.Migrate(imgStore, dryRun, log)
+			if err != nil {
+				log.Error().Err(err).Str("rootDir", rootDir).Int("version", migration.Version).
+					Msg("storage layout migration failed")
+
+				return report, err
+			}
+		}
+
+		// the version is recorded once the migration is done, an interrupted migration is applied again
+		if !dryRun {
+			if err := imgStore.SetLayoutVersion(migration.Version); err != nil {
+				return report, err
+			}
+		}
+
+		report.Migrations = append(report.Migrations, AppliedMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Changes:     changes,
+		})
+	}
+
+	return report, nil
+}
+
+// PrintMigrationReports writes the migrations applied to each store, or pending with a dry run.
+func PrintMigrationReports(reports []MigrationReport, dryRun bool, resultWriter io.Writer) {
+	var builder strings.Builder
+
+	for _, report := range reports {
+		fmt.Fprintf(&builder, "%s: layout version %d\n", report.RootDir, report.Version)
+
+		if len(report.Migrations) == 0 {
+			builder.WriteString("  up to date\n")
+
+			continue
+		}
+
+		for _, migration := range report.Migrations {
+			status := "applied"
+			if dryRun {
+				status = "pending"
+			}
+
+			fmt.Fprintf(&builder, "  %s migration to version %d: %s\n", status, migration.Version,
+				migration.Description)
+
+			for _, change := range migration.Changes {
+				fmt.Fprintf(&builder, "    - %s\n", change)
+			}
+		}
+	}
+
+	fmt.Fprint(resultWriter, builder.String())
+}
@@ -0,0 +1,378 @@
+package quota
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// DefaultUsageMaxAge is how long the usage of a repo is trusted before being counted again, so that the
+// content removed by garbage collection stops counting.
+const DefaultUsageMaxAge = 10 * time.Minute
+
+// Usage is the number of bytes used by a repo, or by all of them, and the limit they can't go over.
+type Usage struct {
+	Repo  string `json:"repo,omitempty"`
+	Bytes int64  `json:"bytes"`
+	// 0 means no limit
+	MaxBytes int64 `json:"maxBytes"`
+}
+
+type UsageList struct {
+	Total Usage   `json:"total"`
+	Repos []Usage `json:"repos"`
+}
+
+/*
+Quotas enforce the storage quota config. The bytes used by a repo are the sizes of the manifests, configs and
+layers referenced by its index, a blob referenced by several images of the repo counts once, a blob shared
+with other repos counts in each of them. Uploaded blobs don't count until a manifest references them.
+*/
+type Quotas struct {
+	config          config.StorageQuotaConfig
+	storeController storage.StoreController
+	// the usage of each repo, counted again once older than maxAge or after a push or a delete
+	repos   map[string]repoUsage
+	maxAge  time.Duration
+	lock    *sync.Mutex
+	metrics monitoring.MetricServer
+	log     log.Logger
+}
+
+type repoUsage struct {
+	bytes      int64
+	digests    map[godigest.Digest]bool
+	computedAt time.Time
+}
+
+// manifestReferences are the descriptors of the manifests, indexes and docker manifest lists.
+type manifestReferences struct {
+	Config    *ispec.Descriptor  `json:"config"`
+	Layers    []ispec.Descriptor `json:"layers"`
+	Blobs     []ispec.Descriptor `json:"blobs"`
+	Manifests []ispec.Descriptor `json:"manifests"`
+}
+
+// New creates the quotas of the stores of storeController, usage is counted when first needed.
+func New(quotaConfig config.StorageQuotaConfig, storeController storage.StoreController,
+	metrics monitoring.MetricServer, log log.Logger,
+) *Quotas {
+	return &Quotas{
+		config:          quotaConfig,
+		storeController: storeController,
+		repos:           map[string]repoUsage{},
+		maxAge:          DefaultUsageMaxAge,
+		lock:            &sync.Mutex{},
+		metrics:         metrics,
+		log:             log,
+	}
+}
+
+// CheckUpload returns an error if the repo, or the registry, already uses all the bytes it can.
+func (quotas *Quotas) CheckUpload(repo string) error {
+	return quotas.check(repo, nil)
+}
+
+// CheckManifest returns an error if pushing the manifest would make the repo, or the registry, go over
+// its limit. Only the manifest and the blobs it references which aren't already in the repo count.
+func (quotas *Quotas) CheckManifest(repo string, body []byte) error {
+	return quotas.check(repo, body)
+}
+
+// Invalidate makes the usage of the repo be counted again, it's called whenever the repo changes.
+func (quotas *Quotas) Invalidate(repo string) {
+	quotas.lock.Lock()
+	defer quotas.lock.Unlock()
+
+	delete(quotas.repos, repo)
+}
+
+// RepoUsage returns the bytes used by the repo and its limit.
+func (quotas *Quotas) RepoUsage(repo string) (Usage, error) {
+	usage, err := quotas.getRepoUsage(repo)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	return Usage{Repo: repo, Bytes: usage.bytes, MaxBytes: quotas.config.GetMaxRepoBytes(repo)}, nil
+}
+
+// List returns the usage of every repo, sorted by name, and the total usage of the registry.
+func (quotas *Quotas) List() (UsageList, error) {
+	repos, err := quotas.getRepositories()
+	if err != nil {
+		return UsageList{}, err
+	}
+
+	list := UsageList{Total: Usage{MaxBytes: quotas.config.MaxBytes}, Repos: []Usage{}}
+
+	for _, repo := range repos {
+		usage, err := quotas.RepoUsage(repo)
+		if err != nil {
+			return UsageList{}, err
+		}
+
+		list.Total.Bytes += usage.Bytes
+		list.Repos = append(list.Repos, usage)
+	}
+
+	monitoring.SetStorageQuotaTotalUsage(quotas.metrics, list.Total.Bytes)
+
+	return list, nil
+}
+
+// RunUsageRefreshPeriodically counts the usage of the expired repos every interval, keeping the metrics current.
+func (quotas *Quotas) RunUsageRefreshPeriodically(interval time.Duration, sch *scheduler.Scheduler) {
+	sch.SubmitGenerator(&refreshTaskGenerator{quotas: quotas}, interval, scheduler.LowPriority)
+}
+
+func (quotas *Quotas) check(repo string, body []byte) error {
+	usage, err := quotas.getRepoUsage(repo)
+	if err != nil {
+		return err
+	}
+
+	var added int64
+
+	if body != nil {
+		added = quotas.getAddedBytes(usage, body)
+	}
+
+	if maxRepoBytes := quotas.config.GetMaxRepoBytes(repo); maxRepoBytes > 0 &&
+		exceeds(usage.bytes, added, maxRepoBytes, body == nil) {
+		return zerr.ErrRepoStorageQuotaExceeded
+	}
+
+	if quotas.config.MaxBytes > 0 {
+		list, err := quotas.List()
+		if err != nil {
+			return err
+		}
+
+		if exceeds(list.Total.Bytes, added, quotas.config.MaxBytes, body == nil) {
+			return zerr.ErrStorageQuotaExceeded
+		}
+	}
+
+	return nil
+}
+
+// exceeds returns whether adding bytes to used goes over maxBytes, uploads are rejected once the limit is reached.
+func exceeds(used, added, maxBytes int64, upload bool) bool {
+	if upload {
+		return used >= maxBytes
+	}
+
+	return used+added > maxBytes
+}
+
+// getAddedBytes returns the bytes the manifest and the blobs it references add to the repo.
+func (quotas *Quotas) getAddedBytes(usage repoUsage, body []byte) int64 {
+	added := int64(0)
+
+	if !usage.digests[godigest.FromBytes(body)] {
+		added += int64(len(body))
+	}
+
+	var references manifestReferences
+
+	// invalid manifests are rejected by the storage
+	if err := json.Unmarshal(body, &references); err != nil {
+		return added
+	}
+
+	counted := map[godigest.Digest]bool{}
+
+	for _, desc := range getDescriptors(references) {
+		if usage.digests[desc.Digest] || counted[desc.Digest] {
+			continue
+		}
+
+		counted[desc.Digest] = true
+		added += desc.Size
+	}
+
+	return added
+}
+
+func (quotas *Quotas) getRepoUsage(repo string) (repoUsage, error) {
+	quotas.lock.Lock()
+	usage, ok := quotas.repos[repo]
+	quotas.lock.Unlock()
+
+	if ok && time.Since(usage.computedAt) < quotas.maxAge {
+		return usage, nil
+	}
+
+	usage, err := quotas.countRepoUsage(repo)
+	if err != nil {
+		return repoUsage{}, err
+	}
+
+	quotas.lock.Lock()
+	quotas.repos[repo] = usage
+	quotas.lock.Unlock()
+
+	monitoring.SetStorageQuotaUsage(quotas.metrics, repo, usage.bytes)
+
+	return usage, nil
+}
+
+func (quotas *Quotas) countRepoUsage(repo string) (repoUsage, error) {
+	usage := repoUsage{digests: map[godigest.Digest]bool{}, computedAt: time.Now()}
+
+	imgStore := quotas.storeController.GetImageStore(repo)
+
+	buf, err := imgStore.GetIndexContent(repo)
+	if err != nil {
+		if errors.Is(err, zerr.ErrRepoNotFound) {
+			// a new repo
+			return usage, nil
+		}
+
+		quotas.log.Error().Err(err).Str("repository", repo).Msg("quota: unable to read repository index")
+
+		return repoUsage{}, err
+	}
+
+	var index ispec.Index
+
+	if err := json.Unmarshal(buf, &index); err != nil {
+		quotas.log.Error().Err(err).Str("repository", repo).Msg("quota: unable to unmarshal repository index")
+
+		return repoUsage{}, err
+	}
+
+	for _, desc := range index.Manifests {
+		if err := quotas.countManifest(imgStore, repo, desc, &usage); err != nil {
+			return repoUsage{}, err
+		}
+	}
+
+	return usage, nil
+}
+
+func (quotas *Quotas) countManifest(imgStore storageTypes.ImageStore, repo string, desc ispec.Descriptor,
+	usage *repoUsage,
+) error {
+	if usage.digests[desc.Digest] {
+		return nil
+	}
+
+	usage.digests[desc.Digest] = true
+	usage.bytes += desc.Size
+
+	buf, err := imgStore.GetBlobContent(repo, desc.Digest)
+	if err != nil {
+		if errors.Is(err, zerr.ErrBlobNotFound) {
+			// left for gc and scrub to report
+			return nil
+		}
+
+		quotas.log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
+			Msg("quota: unable to read manifest")
+
+		return err
+	}
+
+	var references manifestReferences
+
+	if err := json.Unmarshal(buf, &references); err != nil {
+		// e.g. docker schema1 manifests, only the manifest itself counts
+		return nil //nolint:nilerr
+	}
+
+	for _, blob := range getDescriptors(manifestReferences{
+		Config: references.Config,
+		Layers: references.Layers,
+		Blobs:  references.Blobs,
+	}) {
+		if !usage.digests[blob.Digest] {
+			usage.digests[blob.Digest] = true
+			usage.bytes += blob.Size
+		}
+	}
+
+	for _, manifest := range references.Manifests {
+		if err := quotas.countManifest(imgStore, repo, manifest, usage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getRepositories returns the repos of all the stores, sorted by name.
+func (quotas *Quotas) getRepositories() ([]string, error) {
+	repos := []string{}
+
+	for _, imgStore := range quotas.storeController.UniqueImageStores() {
+		storeRepos, err := imgStore.GetRepositories()
+		if err != nil {
+			quotas.log.Error().Err(err).Str("rootDir", imgStore.RootDir()).Msg("quota: unable to list repositories")
+
+			return nil, err
+		}
+
+		repos = append(repos, storeRepos...)
+	}
+
+	sort.Strings(repos)
+
+	return repos, nil
+}
+
+func getDescriptors(references manifestReferences) []ispec.Descriptor {
+	descriptors := []ispec.Descriptor{}
+
+	if references.Config != nil {
+		descriptors = append(descriptors, *references.Config)
+	}
+
+	descriptors = append(descriptors, references.Layers...)
+	descriptors = append(descriptors, references.Blobs...)
+
+	return append(descriptors, references.Manifests...)
+}
+
+type refreshTaskGenerator struct {
+	quotas *Quotas
+	done   bool
+}
+
+func (gen *refreshTaskGenerator) Next() (scheduler.Task, error) {
+	gen.done = true
+
+	return &refreshTask{quotas: gen.quotas}, nil
+}
+
+func (gen *refreshTaskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *refreshTaskGenerator) Reset() {
+	gen.done = false
+}
+
+type refreshTask struct {
+	quotas *Quotas
+}
+
+func (task *refreshTask) DoWork() error {
+	_, err := task.quotas.List()
+
+	return err
+}
@@ -0,0 +1,120 @@
+package quota_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/local"
+	"zotregistry.io/zot/pkg/storage/quota"
+	"zotregistry.io/zot/pkg/test"
+)
+
+func TestQuotas(t *testing.T) {
+	log := log.NewLogger("debug", "")
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	Convey("Count the storage used by the repos and enforce the limits", t, func() {
+		imgStore := local.NewImageStore(t.TempDir(), false, storageConstants.DefaultGCDelay, false, false,
+			log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		err = test.WriteImageToFileSystem(image, "repo", storeController)
+		So(err, ShouldBeNil)
+
+		manifestBlob, err := json.Marshal(image.Manifest)
+		So(err, ShouldBeNil)
+
+		imageSize := int64(len(manifestBlob)) + image.Manifest.Config.Size
+		for _, layer := range image.Manifest.Layers {
+			imageSize += layer.Size
+		}
+
+		Convey("Without limits", func() {
+			quotas := quota.New(config.StorageQuotaConfig{}, storeController, metrics, log)
+
+			usage, err := quotas.RepoUsage("repo")
+			So(err, ShouldBeNil)
+			So(usage, ShouldResemble, quota.Usage{Repo: "repo", Bytes: imageSize})
+
+			usage, err = quotas.RepoUsage("new")
+			So(err, ShouldBeNil)
+			So(usage.Bytes, ShouldEqual, 0)
+
+			So(quotas.CheckUpload("repo"), ShouldBeNil)
+			So(quotas.CheckManifest("repo", manifestBlob), ShouldBeNil)
+		})
+
+		Convey("With a repo limit", func() {
+			quotas := quota.New(config.StorageQuotaConfig{
+				MaxRepoBytes: imageSize,
+				Repositories: map[string]int64{"large/**": 0},
+			}, storeController, metrics, log)
+
+			// the repo is full, pushing the same image again doesn't add anything
+			err := quotas.CheckUpload("repo")
+			So(errors.Is(err, zerr.ErrRepoStorageQuotaExceeded), ShouldBeTrue)
+			So(quotas.CheckManifest("repo", manifestBlob), ShouldBeNil)
+
+			// another image only fits in an empty repo
+			other, err := test.GetRandomImage("2.0")
+			So(err, ShouldBeNil)
+
+			otherBlob, err := json.Marshal(other.Manifest)
+			So(err, ShouldBeNil)
+
+			err = quotas.CheckManifest("repo", otherBlob)
+			So(errors.Is(err, zerr.ErrRepoStorageQuotaExceeded), ShouldBeTrue)
+			So(quotas.CheckUpload("other"), ShouldBeNil)
+
+			// the repos matching a pattern have their own limit
+			So(quotas.CheckManifest("large/repo", otherBlob), ShouldBeNil)
+
+			Convey("The usage is counted again after the repo changes", func() {
+				err = test.WriteImageToFileSystem(other, "large/repo", storeController)
+				So(err, ShouldBeNil)
+
+				usage, err := quotas.RepoUsage("large/repo")
+				So(err, ShouldBeNil)
+				So(usage.Bytes, ShouldEqual, 0)
+
+				quotas.Invalidate("large/repo")
+
+				usage, err = quotas.RepoUsage("large/repo")
+				So(err, ShouldBeNil)
+				So(usage.Bytes, ShouldBeGreaterThan, 0)
+				So(usage.MaxBytes, ShouldEqual, 0)
+
+				list, err := quotas.List()
+				So(err, ShouldBeNil)
+				So(list.Repos, ShouldHaveLength, 2)
+				So(list.Repos[0].Repo, ShouldEqual, "large/repo")
+				So(list.Repos[1], ShouldResemble, quota.Usage{Repo: "repo", Bytes: imageSize, MaxBytes: imageSize})
+				So(list.Total.Bytes, ShouldEqual, imageSize+usage.Bytes)
+			})
+		})
+
+		Convey("With a registry limit", func() {
+			quotas := quota.New(config.StorageQuotaConfig{MaxBytes: imageSize}, storeController, metrics, log)
+
+			err := quotas.CheckUpload("other")
+			So(errors.Is(err, zerr.ErrStorageQuotaExceeded), ShouldBeTrue)
+
+			// the image is in another repo, its blobs count again in this one
+			err = quotas.CheckManifest("other", manifestBlob)
+			So(errors.Is(err, zerr.ErrStorageQuotaExceeded), ShouldBeTrue)
+			So(quotas.CheckManifest("repo", manifestBlob), ShouldBeNil)
+		})
+	})
+}
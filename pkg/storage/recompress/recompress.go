@@ -0,0 +1,407 @@
+package recompress
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+	"github.com/klauspost/compress/zstd"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage"
+	storageCommon "zotregistry.io/zot/pkg/storage/common"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// DefaultInterval is the time between two passes over the repos when the config doesn't set one.
+const DefaultInterval = 24 * time.Hour
+
+/*
+Recompressor recompresses the gzip layers of the stored images to zstd, which takes less space. The layers keep
+their uncompressed content, so the configs and their diff ids don't change, but the manifests referencing them
+are rewritten and get a new digest. The tags are moved to the rewritten manifests, and the digest they pointed to
+is kept in the AnnotationOriginalDigest annotation, so images can still be pulled by it. Only the tagged image
+manifests which aren't part of an index, aren't signed and have no referrers are rewritten, since changing their
+digest would break the indexes, the signatures and the referrers. The gzip layers are left to garbage collection.
+*/
+type Recompressor struct {
+	repositories    []string
+	interval        time.Duration
+	storeController storage.StoreController
+	// updated with the rewritten manifests, if search is enabled
+	repoDB repodb.RepoDB
+	log    log.Logger
+}
+
+// New validates the recompression config, repoDB can be nil.
+func New(recompressionConfig config.LayerRecompressionConfig, storeController storage.StoreController,
+	repoDB repodb.RepoDB, log log.Logger,
+) (*Recompressor, error) {
+	for _, pattern := range recompressionConfig.Repositories {
+		if !glob.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("%w: invalid repository pattern %s", zerr.ErrBadLayerRecompression, pattern)
+		}
+	}
+
+	if recompressionConfig.Interval < 0 {
+		return nil, fmt.Errorf("%w: negative interval", zerr.ErrBadLayerRecompression)
+	}
+
+	interval := recompressionConfig.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+
+	return &Recompressor{
+		repositories:    recompressionConfig.Repositories,
+		interval:        interval,
+		storeController: storeController,
+		repoDB:          repoDB,
+		log:             log,
+	}, nil
+}
+
+// RunPeriodically recompresses the images of the matching repos, one repo per task, every interval.
+func (recompressor *Recompressor) RunPeriodically(sch *scheduler.Scheduler) {
+	sch.SubmitGenerator(&taskGenerator{recompressor: recompressor}, recompressor.interval, scheduler.LowPriority)
+}
+
+// RecompressRepo recompresses the gzip layers of the images of a repo, the images which fail are logged and
+// skipped, the last error is returned.
+func (recompressor *Recompressor) RecompressRepo(repo string) error {
+	imgStore := recompressor.storeController.GetImageStore(repo)
+
+	indexBlob, err := imgStore.GetIndexContent(repo)
+	if err != nil {
+		return err
+	}
+
+	var index ispec.Index
+	if err := json.Unmarshal(indexBlob, &index); err != nil {
+		return err
+	}
+
+	tags := map[godigest.Digest][]string{}
+	skipped := map[godigest.Digest]bool{}
+
+	for _, desc := range index.Manifests {
+		tag, ok := desc.Annotations[ispec.AnnotationRefName]
+
+		// cosign signatures, sboms and attestations, and referrers indexes, are tagged after their subject
+		subject, isSubjectTag := storageCommon.GetReferrersTagSubject(strings.TrimSuffix(tag, path.Ext(tag)))
+		if isSubjectTag {
+			skipped[subject] = true
+
+			continue
+		}
+
+		switch {
+		case zcommon.IsImageIndex(desc.MediaType):
+			recompressor.skipIndexManifests(imgStore, repo, desc.Digest, skipped)
+		case ok && desc.MediaType == ispec.MediaTypeImageManifest:
+			tags[desc.Digest] = append(tags[desc.Digest], tag)
+		}
+	}
+
+	digests := make([]godigest.Digest, 0, len(tags))
+
+	for digest := range tags {
+		if !skipped[digest] {
+			digests = append(digests, digest)
+		}
+	}
+
+	sort.Slice(digests, func(i, j int) bool { return digests[i] < digests[j] })
+
+	var lastErr error
+
+	for _, digest := range digests {
+		if err := recompressor.recompressImage(imgStore, repo, digest, tags[digest]); err != nil {
+			recompressor.log.Error().Err(err).Str("repository", repo).Str("digest", digest.String()).
+				Msg("recompress: unable to recompress image")
+
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+func (recompressor *Recompressor) matchesRepo(repo string) bool {
+	if len(recompressor.repositories) == 0 {
+		return true
+	}
+
+	for _, pattern := range recompressor.repositories {
+		// patterns are validated by New
+		if matched, _ := glob.Match(pattern, repo); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipIndexManifests marks the manifests of an index, and of the indexes it references, as not to be rewritten.
+func (recompressor *Recompressor) skipIndexManifests(imgStore storageTypes.ImageStore, repo string,
+	indexDigest godigest.Digest, skipped map[godigest.Digest]bool,
+) {
+	if skipped[indexDigest] {
+		return
+	}
+
+	skipped[indexDigest] = true
+
+	indexBlob, _, _, err := imgStore.GetImageManifest(repo, indexDigest.String())
+	if err != nil {
+		return
+	}
+
+	var index ispec.Index
+	if err := json.Unmarshal(indexBlob, &index); err != nil {
+		return
+	}
+
+	for _, desc := range index.Manifests {
+		if zcommon.IsImageIndex(desc.MediaType) {
+			recompressor.skipIndexManifests(imgStore, repo, desc.Digest, skipped)
+		}
+
+		skipped[desc.Digest] = true
+	}
+}
+
+func (recompressor *Recompressor) recompressImage(imgStore storageTypes.ImageStore, repo string,
+	digest godigest.Digest, tags []string,
+) error {
+	manifestBlob, _, _, err := imgStore.GetImageManifest(repo, digest.String())
+	if err != nil {
+		return err
+	}
+
+	var manifest ispec.Manifest
+	if err := json.Unmarshal(manifestBlob, &manifest); err != nil {
+		return err
+	}
+
+	if manifest.Subject != nil {
+		return nil
+	}
+
+	referrers, err := imgStore.GetReferrers(repo, digest, nil)
+	if err != nil && !errors.Is(err, zerr.ErrManifestNotFound) {
+		return err
+	}
+
+	if len(referrers.Manifests) > 0 {
+		return nil
+	}
+
+	recompressed := false
+
+	for idx, layer := range manifest.Layers {
+		if layer.MediaType != ispec.MediaTypeImageLayerGzip {
+			continue
+		}
+
+		manifest.Layers[idx], err = recompressor.recompressLayer(imgStore, repo, layer)
+		if err != nil {
+			return err
+		}
+
+		recompressed = true
+	}
+
+	if !recompressed {
+		return nil
+	}
+
+	// the digest clients know the image by, even if it was itself converted when pushed
+	if manifest.Annotations == nil {
+		manifest.Annotations = map[string]string{}
+	}
+
+	manifest.Annotations[zcommon.AnnotationOriginalDigest] = digest.String()
+
+	recompressedBlob, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		// the tag may have been pushed again since the index was read
+		_, tagDigest, _, err := imgStore.GetImageManifest(repo, tag)
+		if err != nil || tagDigest != digest {
+			continue
+		}
+
+		recompressedDigest, _, err := imgStore.PutImageManifest(repo, tag, ispec.MediaTypeImageManifest,
+			recompressedBlob)
+		if err != nil {
+			return err
+		}
+
+		recompressor.log.Info().Str("repository", repo).Str("tag", tag).Str("digest", digest.String()).
+			Str("recompressedDigest", recompressedDigest.String()).Msg("recompress: recompressed image layers to zstd")
+
+		if recompressor.repoDB != nil {
+			if err := repodb.SetImageMetaFromInput(repo, tag, ispec.MediaTypeImageManifest, recompressedDigest,
+				recompressedBlob, imgStore, recompressor.repoDB, recompressor.log); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// recompressLayer uploads the zstd recompression of a gzip layer to the repo and returns its descriptor.
+func (recompressor *Recompressor) recompressLayer(imgStore storageTypes.ImageStore, repo string,
+	layer ispec.Descriptor,
+) (ispec.Descriptor, error) {
+	blob, _, err := imgStore.GetBlob(repo, layer.Digest, layer.MediaType)
+	if err != nil {
+		return layer, err
+	}
+
+	defer blob.Close()
+
+	gzipReader, err := gzip.NewReader(blob)
+	if err != nil {
+		return layer, err
+	}
+
+	defer gzipReader.Close()
+
+	uuid, err := imgStore.NewBlobUpload(repo)
+	if err != nil {
+		return layer, err
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	digester := godigest.SHA256.Digester()
+
+	go func() {
+		// the digest is complete once the upload reads the end of the pipe
+		encoder, err := zstd.NewWriter(io.MultiWriter(digester.Hash(), pipeWriter),
+			zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			_ = pipeWriter.CloseWithError(err)
+
+			return
+		}
+
+		if _, err := io.Copy(encoder, gzipReader); err != nil {
+			_ = encoder.Close()
+			_ = pipeWriter.CloseWithError(err)
+
+			return
+		}
+
+		_ = pipeWriter.CloseWithError(encoder.Close())
+	}()
+
+	size, err := imgStore.PutBlobChunkStreamed(repo, uuid, pipeReader)
+	if err != nil {
+		_ = pipeReader.CloseWithError(err)
+		_ = imgStore.DeleteBlobUpload(repo, uuid)
+
+		return layer, err
+	}
+
+	recompressedDigest := digester.Digest()
+
+	if err := imgStore.FinishBlobUpload(repo, uuid, nil, recompressedDigest); err != nil {
+		_ = imgStore.DeleteBlobUpload(repo, uuid)
+
+		return layer, err
+	}
+
+	recompressedLayer := layer
+	recompressedLayer.MediaType = ispec.MediaTypeImageLayerZstd
+	recompressedLayer.Digest = recompressedDigest
+	recompressedLayer.Size = size
+
+	return recompressedLayer, nil
+}
+
+func (recompressor *Recompressor) getRepositories() ([]string, error) {
+	repos, err := recompressor.storeController.GetRepositories()
+	if err != nil {
+		return nil, err
+	}
+
+	matchingRepos := []string{}
+
+	for _, repo := range repos {
+		if recompressor.matchesRepo(repo) {
+			matchingRepos = append(matchingRepos, repo)
+		}
+	}
+
+	return matchingRepos, nil
+}
+
+type taskGenerator struct {
+	recompressor *Recompressor
+	// repos left to recompress in this pass, listed when it starts
+	repos  []string
+	listed bool
+	done   bool
+}
+
+func (gen *taskGenerator) Next() (scheduler.Task, error) {
+	if !gen.listed {
+		repos, err := gen.recompressor.getRepositories()
+		if err != nil {
+			return nil, err
+		}
+
+		gen.repos = repos
+		gen.listed = true
+	}
+
+	if len(gen.repos) == 0 {
+		gen.done = true
+
+		return nil, nil
+	}
+
+	repo := gen.repos[0]
+	gen.repos = gen.repos[1:]
+
+	return &recompressTask{recompressor: gen.recompressor, repo: repo}, nil
+}
+
+func (gen *taskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *taskGenerator) Reset() {
+	gen.repos = nil
+	gen.listed = false
+	gen.done = false
+}
+
+type recompressTask struct {
+	recompressor *Recompressor
+	repo         string
+}
+
+func (task *recompressTask) DoWork() error {
+	return task.recompressor.RecompressRepo(task.repo)
+}
@@ -0,0 +1,146 @@
+package recompress_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	zcommon "zotregistry.io/zot/pkg/common"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/local"
+	"zotregistry.io/zot/pkg/storage/recompress"
+	"zotregistry.io/zot/pkg/test"
+)
+
+// getGzipImage returns an image whose layer is the gzip compression of content.
+func getGzipImage(content []byte, reference string) test.Image {
+	var compressed bytes.Buffer
+
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write(content)
+	So(err, ShouldBeNil)
+	So(writer.Close(), ShouldBeNil)
+
+	image, err := test.GetImageWithComponents(ispec.Image{}, [][]byte{compressed.Bytes()})
+	So(err, ShouldBeNil)
+
+	image.Manifest.Layers[0].MediaType = ispec.MediaTypeImageLayerGzip
+	image.Reference = reference
+
+	return image
+}
+
+func TestRecompressRepo(t *testing.T) {
+	log := log.NewLogger("debug", "")
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	Convey("Recompress the gzip layers of the images to zstd", t, func() {
+		imgStore := local.NewImageStore(t.TempDir(), false, storageConstants.DefaultGCDelay, false, false,
+			log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		content := bytes.Repeat([]byte("uncompressed layer content "), 100)
+
+		image := getGzipImage(content, "1.0")
+		err := test.WriteImageToFileSystem(image, "repo", storeController)
+		So(err, ShouldBeNil)
+
+		_, digest, _, err := imgStore.GetImageManifest("repo", "1.0")
+		So(err, ShouldBeNil)
+
+		// signed images keep their layers
+		signedImage := getGzipImage([]byte("signed layer content"), "signed")
+		err = test.WriteImageToFileSystem(signedImage, "repo", storeController)
+		So(err, ShouldBeNil)
+
+		signature, err := test.GetRandomImage("")
+		So(err, ShouldBeNil)
+
+		signature.Reference, err = test.GetCosignSignatureTagForManifest(signedImage.Manifest)
+		So(err, ShouldBeNil)
+
+		err = test.WriteImageToFileSystem(signature, "repo", storeController)
+		So(err, ShouldBeNil)
+
+		_, signedDigest, _, err := imgStore.GetImageManifest("repo", "signed")
+		So(err, ShouldBeNil)
+
+		recompressor, err := recompress.New(config.LayerRecompressionConfig{}, storeController, nil, log)
+		So(err, ShouldBeNil)
+
+		err = recompressor.RecompressRepo("repo")
+		So(err, ShouldBeNil)
+
+		manifestBlob, recompressedDigest, _, err := imgStore.GetImageManifest("repo", "1.0")
+		So(err, ShouldBeNil)
+		So(recompressedDigest, ShouldNotEqual, digest)
+
+		var manifest ispec.Manifest
+		err = json.Unmarshal(manifestBlob, &manifest)
+		So(err, ShouldBeNil)
+		So(manifest.Config, ShouldResemble, image.Manifest.Config)
+		So(manifest.Layers, ShouldHaveLength, 1)
+		So(manifest.Layers[0].MediaType, ShouldEqual, ispec.MediaTypeImageLayerZstd)
+		So(manifest.Annotations[zcommon.AnnotationOriginalDigest], ShouldEqual, digest.String())
+
+		blob, size, err := imgStore.GetBlob("repo", manifest.Layers[0].Digest, ispec.MediaTypeImageLayerZstd)
+		So(err, ShouldBeNil)
+		So(size, ShouldEqual, manifest.Layers[0].Size)
+
+		compressed, err := io.ReadAll(blob)
+		So(err, ShouldBeNil)
+		So(blob.Close(), ShouldBeNil)
+		So(godigest.FromBytes(compressed), ShouldEqual, manifest.Layers[0].Digest)
+
+		decoder, err := zstd.NewReader(bytes.NewReader(compressed))
+		So(err, ShouldBeNil)
+
+		defer decoder.Close()
+
+		uncompressed, err := io.ReadAll(decoder)
+		So(err, ShouldBeNil)
+		So(uncompressed, ShouldResemble, content)
+
+		// the image can still be pulled by the digest it had
+		_, pulledDigest, _, err := imgStore.GetImageManifest("repo", digest.String())
+		So(err, ShouldBeNil)
+		So(pulledDigest, ShouldEqual, recompressedDigest)
+
+		_, tagDigest, _, err := imgStore.GetImageManifest("repo", "signed")
+		So(err, ShouldBeNil)
+		So(tagDigest, ShouldEqual, signedDigest)
+
+		// recompressed images are left as they are
+		err = recompressor.RecompressRepo("repo")
+		So(err, ShouldBeNil)
+
+		_, tagDigest, _, err = imgStore.GetImageManifest("repo", "1.0")
+		So(err, ShouldBeNil)
+		So(tagDigest, ShouldEqual, recompressedDigest)
+
+		err = recompressor.RecompressRepo("missing")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Reject invalid configs", t, func() {
+		for _, recompressionConfig := range []config.LayerRecompressionConfig{
+			{Repositories: []string{"apps/["}},
+			{Interval: -1},
+		} {
+			_, err := recompress.New(recompressionConfig, storage.StoreController{}, nil, log)
+			So(err, ShouldWrap, zerr.ErrBadLayerRecompression)
+		}
+	})
+}
@@ -0,0 +1,343 @@
+package referrerstags
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+	godigest "github.com/opencontainers/go-digest"
+	imeta "github.com/opencontainers/image-spec/specs-go"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/meta/repodb"
+	"zotregistry.io/zot/pkg/scheduler"
+	"zotregistry.io/zot/pkg/storage"
+	storageCommon "zotregistry.io/zot/pkg/storage/common"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+// DefaultInterval is the time between two passes over the repos when the config doesn't set one.
+const DefaultInterval = time.Hour
+
+/*
+Reconciler keeps the referrers tags of the distribution spec, e.g. sha256-<hex>, in sync with the referrers API.
+Clients which don't use the referrers API push an index listing the referrers of a manifest under its referrers
+tag, the referrers it lists are returned by the referrers API along with the manifests found by their subject.
+Conversely the referrers tag of each manifest having referrers is written with all of them, so that clients pulling
+from mirrors of the repos which don't serve the referrers API can find them. The referrers tags of manifests whose
+referrers are all deleted are removed too.
+*/
+type Reconciler struct {
+	repositories    []string
+	interval        time.Duration
+	storeController storage.StoreController
+	// updated with the referrers tags, if search is enabled
+	repoDB repodb.RepoDB
+	log    log.Logger
+}
+
+// New validates the referrers tags config, repoDB can be nil.
+func New(referrersTagsConfig config.ReferrersTagsConfig, storeController storage.StoreController,
+	repoDB repodb.RepoDB, log log.Logger,
+) (*Reconciler, error) {
+	for _, pattern := range referrersTagsConfig.Repositories {
+		if !glob.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("%w: invalid repository pattern %s", zerr.ErrBadReferrersTags, pattern)
+		}
+	}
+
+	if referrersTagsConfig.Interval < 0 {
+		return nil, fmt.Errorf("%w: negative interval", zerr.ErrBadReferrersTags)
+	}
+
+	interval := referrersTagsConfig.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+
+	return &Reconciler{
+		repositories:    referrersTagsConfig.Repositories,
+		interval:        interval,
+		storeController: storeController,
+		repoDB:          repoDB,
+		log:             log,
+	}, nil
+}
+
+// RunPeriodically reconciles the referrers tags of the matching repos, one repo per task, every interval.
+func (reconciler *Reconciler) RunPeriodically(sch *scheduler.Scheduler) {
+	sch.SubmitGenerator(&taskGenerator{reconciler: reconciler}, reconciler.interval, scheduler.LowPriority)
+}
+
+// ReconcileRepo writes the referrers tags of the manifests of a repo which have referrers, and removes the ones
+// left without referrers. The subjects which fail are logged and skipped, the last error is returned.
+func (reconciler *Reconciler) ReconcileRepo(repo string) error {
+	imgStore := reconciler.storeController.GetImageStore(repo)
+
+	indexBlob, err := imgStore.GetIndexContent(repo)
+	if err != nil {
+		return err
+	}
+
+	var index ispec.Index
+	if err := json.Unmarshal(indexBlob, &index); err != nil {
+		return err
+	}
+
+	// the current referrers tag index of each subject, if any
+	subjects := map[godigest.Digest]*ispec.Descriptor{}
+
+	for idx, desc := range index.Manifests {
+		if subject, ok := storageCommon.GetReferrersTagSubject(desc.Annotations[ispec.AnnotationRefName]); ok {
+			subjects[subject] = &index.Manifests[idx]
+
+			continue
+		}
+
+		subject, err := getSubject(imgStore, repo, desc)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := subjects[subject]; subject != "" && !ok {
+			subjects[subject] = nil
+		}
+	}
+
+	digests := make([]godigest.Digest, 0, len(subjects))
+
+	for digest := range subjects {
+		digests = append(digests, digest)
+	}
+
+	sort.Slice(digests, func(i, j int) bool { return digests[i] < digests[j] })
+
+	var lastErr error
+
+	for _, digest := range digests {
+		if err := reconciler.reconcileSubject(imgStore, repo, digest, subjects[digest]); err != nil {
+			reconciler.log.Error().Err(err).Str("repository", repo).Str("subject", digest.String()).
+				Msg("referrers tags: unable to reconcile referrers tag")
+
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+func (reconciler *Reconciler) matchesRepo(repo string) bool {
+	if len(reconciler.repositories) == 0 {
+		return true
+	}
+
+	for _, pattern := range reconciler.repositories {
+		// patterns are validated by New
+		if matched, _ := glob.Match(pattern, repo); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (reconciler *Reconciler) getRepositories() ([]string, error) {
+	repos, err := reconciler.storeController.GetRepositories()
+	if err != nil {
+		return nil, err
+	}
+
+	matchingRepos := []string{}
+
+	for _, repo := range repos {
+		if reconciler.matchesRepo(repo) {
+			matchingRepos = append(matchingRepos, repo)
+		}
+	}
+
+	return matchingRepos, nil
+}
+
+// getSubject returns the digest of the subject of a manifest or an index, if it has one.
+func getSubject(imgStore storageTypes.ImageStore, repo string, desc ispec.Descriptor) (godigest.Digest, error) {
+	if desc.MediaType != ispec.MediaTypeImageManifest && desc.MediaType != ispec.MediaTypeImageIndex {
+		return "", nil
+	}
+
+	blob, err := imgStore.GetBlobContent(repo, desc.Digest)
+	if err != nil {
+		return "", err
+	}
+
+	// manifests and indexes have the same subject field
+	var manifest ispec.Manifest
+	if err := json.Unmarshal(blob, &manifest); err != nil {
+		return "", err
+	}
+
+	if manifest.Subject == nil {
+		return "", nil
+	}
+
+	return manifest.Subject.Digest, nil
+}
+
+// reconcileSubject makes the referrers tag of a subject list its referrers, tagDesc is its current index, if any.
+func (reconciler *Reconciler) reconcileSubject(imgStore storageTypes.ImageStore, repo string,
+	subject godigest.Digest, tagDesc *ispec.Descriptor,
+) error {
+	// the referrers tags of missing subjects are removed by gc
+	if ok, _, err := imgStore.CheckBlob(repo, subject); err != nil || !ok {
+		return nil //nolint: nilerr
+	}
+
+	// clients may tag something else than an index, e.g. cosign signatures of older versions
+	if tagDesc != nil && tagDesc.MediaType != ispec.MediaTypeImageIndex {
+		return nil
+	}
+
+	referrers, err := imgStore.GetReferrers(repo, subject, nil)
+	if err != nil {
+		return err
+	}
+
+	tag := storageCommon.GetReferrersTag(subject)
+
+	if tagDesc != nil {
+		tagBlob, err := imgStore.GetBlobContent(repo, tagDesc.Digest)
+		if err != nil {
+			return err
+		}
+
+		var tagIndex ispec.Index
+		if err := json.Unmarshal(tagBlob, &tagIndex); err != nil {
+			return err
+		}
+
+		if sameManifests(tagIndex.Manifests, referrers.Manifests) {
+			return nil
+		}
+
+		if len(referrers.Manifests) == 0 {
+			reconciler.log.Info().Str("repository", repo).Str("tag", tag).
+				Msg("referrers tags: removing referrers tag without referrers")
+
+			if err := imgStore.DeleteImageManifest(repo, tag, false); err != nil {
+				return err
+			}
+
+			if reconciler.repoDB != nil {
+				return reconciler.repoDB.DeleteRepoTag(repo, tag)
+			}
+
+			return nil
+		}
+	}
+
+	if len(referrers.Manifests) == 0 {
+		return nil
+	}
+
+	tagIndex := ispec.Index{
+		Versioned: imeta.Versioned{SchemaVersion: storageConstants.SchemaVersion},
+		MediaType: ispec.MediaTypeImageIndex,
+		Manifests: referrers.Manifests,
+	}
+
+	tagBlob, err := json.Marshal(tagIndex)
+	if err != nil {
+		return err
+	}
+
+	digest, _, err := imgStore.PutImageManifest(repo, tag, ispec.MediaTypeImageIndex, tagBlob)
+	if err != nil {
+		return err
+	}
+
+	reconciler.log.Info().Str("repository", repo).Str("tag", tag).Int("referrers", len(referrers.Manifests)).
+		Msg("referrers tags: updated referrers tag")
+
+	if reconciler.repoDB != nil {
+		return repodb.SetImageMetaFromInput(repo, tag, ispec.MediaTypeImageIndex, digest, tagBlob, imgStore,
+			reconciler.repoDB, reconciler.log)
+	}
+
+	return nil
+}
+
+// sameManifests returns true if both lists have the same manifests, in any order.
+func sameManifests(first, second []ispec.Descriptor) bool {
+	if len(first) != len(second) {
+		return false
+	}
+
+	digests := map[godigest.Digest]bool{}
+
+	for _, desc := range first {
+		digests[desc.Digest] = true
+	}
+
+	for _, desc := range second {
+		if !digests[desc.Digest] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type taskGenerator struct {
+	reconciler *Reconciler
+	// repos left to reconcile in this pass, listed when it starts
+	repos  []string
+	listed bool
+	done   bool
+}
+
+func (gen *taskGenerator) Next() (scheduler.Task, error) {
+	if !gen.listed {
+		repos, err := gen.reconciler.getRepositories()
+		if err != nil {
+			return nil, err
+		}
+
+		gen.repos = repos
+		gen.listed = true
+	}
+
+	if len(gen.repos) == 0 {
+		gen.done = true
+
+		return nil, nil
+	}
+
+	repo := gen.repos[0]
+	gen.repos = gen.repos[1:]
+
+	return &reconcileTask{reconciler: gen.reconciler, repo: repo}, nil
+}
+
+func (gen *taskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *taskGenerator) Reset() {
+	gen.repos = nil
+	gen.listed = false
+	gen.done = false
+}
+
+type reconcileTask struct {
+	reconciler *Reconciler
+	repo       string
+}
+
+func (task *reconcileTask) DoWork() error {
+	return task.reconciler.ReconcileRepo(task.repo)
+}
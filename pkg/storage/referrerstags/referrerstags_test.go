@@ -0,0 +1,175 @@
+package referrerstags_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	imeta "github.com/opencontainers/image-spec/specs-go"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/extensions/monitoring"
+	"zotregistry.io/zot/pkg/log"
+	"zotregistry.io/zot/pkg/storage"
+	storageCommon "zotregistry.io/zot/pkg/storage/common"
+	storageConstants "zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/local"
+	"zotregistry.io/zot/pkg/storage/referrerstags"
+	"zotregistry.io/zot/pkg/test"
+)
+
+// writeReferrer writes an untagged artifact, referring to subject if it's set, and returns its descriptor.
+func writeReferrer(storeController storage.StoreController, repo string, subject *ispec.Descriptor,
+) ispec.Descriptor {
+	artifact, err := test.GetRandomImage("")
+	So(err, ShouldBeNil)
+
+	artifact.Manifest.ArtifactType = "application/vnd.example.sbom"
+	artifact.Manifest.Subject = subject
+
+	digest, err := artifact.Digest()
+	So(err, ShouldBeNil)
+
+	artifact.Reference = digest.String()
+
+	err = test.WriteImageToFileSystem(artifact, repo, storeController)
+	So(err, ShouldBeNil)
+
+	manifestBlob, err := json.Marshal(artifact.Manifest)
+	So(err, ShouldBeNil)
+
+	return ispec.Descriptor{
+		MediaType:    ispec.MediaTypeImageManifest,
+		ArtifactType: artifact.Manifest.ArtifactType,
+		Digest:       digest,
+		Size:         int64(len(manifestBlob)),
+	}
+}
+
+func TestReconcileRepo(t *testing.T) {
+	log := log.NewLogger("debug", "")
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	Convey("Reconcile the referrers tags with the referrers", t, func() {
+		imgStore := local.NewImageStore(t.TempDir(), false, storageConstants.DefaultGCDelay, false, false,
+			log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		image, err := test.GetRandomImage("1.0")
+		So(err, ShouldBeNil)
+
+		err = test.WriteImageToFileSystem(image, "repo", storeController)
+		So(err, ShouldBeNil)
+
+		manifestBlob, subjectDigest, _, err := imgStore.GetImageManifest("repo", "1.0")
+		So(err, ShouldBeNil)
+
+		subject := &ispec.Descriptor{
+			MediaType: ispec.MediaTypeImageManifest,
+			Digest:    subjectDigest,
+			Size:      int64(len(manifestBlob)),
+		}
+
+		referrer := writeReferrer(storeController, "repo", subject)
+
+		// a client using the referrers tag schema lists an artifact without subject
+		taggedReferrer := writeReferrer(storeController, "repo", nil)
+
+		tagBlob, err := json.Marshal(ispec.Index{
+			Versioned: imeta.Versioned{SchemaVersion: storageConstants.SchemaVersion},
+			MediaType: ispec.MediaTypeImageIndex,
+			Manifests: []ispec.Descriptor{taggedReferrer},
+		})
+		So(err, ShouldBeNil)
+
+		referrersTag := storageCommon.GetReferrersTag(subjectDigest)
+		So(referrersTag, ShouldEqual, "sha256-"+subjectDigest.Encoded())
+
+		_, _, err = imgStore.PutImageManifest("repo", referrersTag, ispec.MediaTypeImageIndex, tagBlob)
+		So(err, ShouldBeNil)
+
+		referrers, err := imgStore.GetReferrers("repo", subjectDigest, nil)
+		So(err, ShouldBeNil)
+		So(referrers.Manifests, ShouldHaveLength, 2)
+		So(referrers.Manifests[0].Digest, ShouldEqual, referrer.Digest)
+		So(referrers.Manifests[1].Digest, ShouldEqual, taggedReferrer.Digest)
+
+		referrers, err = imgStore.GetReferrers("repo", subjectDigest, []string{"application/vnd.example.other"})
+		So(err, ShouldBeNil)
+		So(referrers.Manifests, ShouldBeEmpty)
+
+		reconciler, err := referrerstags.New(config.ReferrersTagsConfig{}, storeController, nil, log)
+		So(err, ShouldBeNil)
+
+		err = reconciler.ReconcileRepo("repo")
+		So(err, ShouldBeNil)
+
+		getTagIndex := func() ispec.Index {
+			tagBlob, _, _, err := imgStore.GetImageManifest("repo", referrersTag)
+			So(err, ShouldBeNil)
+
+			var tagIndex ispec.Index
+			err = json.Unmarshal(tagBlob, &tagIndex)
+			So(err, ShouldBeNil)
+
+			return tagIndex
+		}
+
+		// the referrers found by their subject are added to the referrers tag
+		tagIndex := getTagIndex()
+		So(tagIndex.Manifests, ShouldHaveLength, 2)
+		So(tagIndex.Manifests[0].Digest, ShouldEqual, referrer.Digest)
+		So(tagIndex.Manifests[1].Digest, ShouldEqual, taggedReferrer.Digest)
+
+		_, tagDigest, _, err := imgStore.GetImageManifest("repo", referrersTag)
+		So(err, ShouldBeNil)
+
+		// reconciled tags are left as they are
+		err = reconciler.ReconcileRepo("repo")
+		So(err, ShouldBeNil)
+
+		_, reconciledDigest, _, err := imgStore.GetImageManifest("repo", referrersTag)
+		So(err, ShouldBeNil)
+		So(reconciledDigest, ShouldEqual, tagDigest)
+
+		// deleted referrers are removed from the referrers tag
+		err = imgStore.DeleteImageManifest("repo", taggedReferrer.Digest.String(), false)
+		So(err, ShouldBeNil)
+
+		err = reconciler.ReconcileRepo("repo")
+		So(err, ShouldBeNil)
+
+		tagIndex = getTagIndex()
+		So(tagIndex.Manifests, ShouldHaveLength, 1)
+		So(tagIndex.Manifests[0].Digest, ShouldEqual, referrer.Digest)
+
+		// the referrers tag is removed along with the last referrer
+		err = imgStore.DeleteImageManifest("repo", referrer.Digest.String(), false)
+		So(err, ShouldBeNil)
+
+		err = reconciler.ReconcileRepo("repo")
+		So(err, ShouldBeNil)
+
+		_, _, _, err = imgStore.GetImageManifest("repo", referrersTag)
+		So(err, ShouldNotBeNil)
+
+		_, _, _, err = imgStore.GetImageManifest("repo", "1.0")
+		So(err, ShouldBeNil)
+
+		err = reconciler.ReconcileRepo("missing")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Reject invalid configs", t, func() {
+		for _, referrersTagsConfig := range []config.ReferrersTagsConfig{
+			{Repositories: []string{"apps/["}},
+			{Interval: -time.Hour},
+		} {
+			_, err := referrerstags.New(referrersTagsConfig, storage.StoreController{}, nil, log)
+			So(err, ShouldWrap, zerr.ErrBadReferrersTags)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package retention
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	glob "github.com/bmatcuk/doublestar/v4"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+type policy struct {
+	repositories []string
+	keepTags     int
+	maxAge       time.Duration
+	protectTags  []*regexp.Regexp
+}
+
+// Policies tell gc which tags to remove, according to the retention config of a store.
+type Policies struct {
+	dryRun   bool
+	policies []policy
+}
+
+// New compiles the policies of the config, it returns nil, which keeps all tags, if the config is nil.
+func New(retentionConfig *config.RetentionConfig) (*Policies, error) {
+	if retentionConfig == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	policies := &Policies{dryRun: retentionConfig.DryRun}
+
+	for idx, policyConfig := range retentionConfig.Policies {
+		if policyConfig.KeepTags < 0 || policyConfig.MaxAge < 0 {
+			return nil, fmt.Errorf("%w: policy %d can't keep a negative number of tags or have a negative max age",
+				zerr.ErrBadRetentionPolicy, idx)
+		}
+
+		for _, pattern := range policyConfig.Repositories {
+			if !glob.ValidatePattern(pattern) {
+				return nil, fmt.Errorf("%w: policy %d has an invalid repository pattern %s", zerr.ErrBadRetentionPolicy,
+					idx, pattern)
+			}
+		}
+
+		compiled := policy{
+			repositories: policyConfig.Repositories,
+			keepTags:     policyConfig.KeepTags,
+			maxAge:       policyConfig.MaxAge,
+		}
+
+		for _, expr := range policyConfig.ProtectTags {
+			// the whole tag has to match
+			tagRegexp, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("%w: policy %d has an invalid protected tag regular expression: %w",
+					zerr.ErrBadRetentionPolicy, idx, err)
+			}
+
+			compiled.protectTags = append(compiled.protectTags, tagRegexp)
+		}
+
+		policies.policies = append(policies.policies, compiled)
+	}
+
+	return policies, nil
+}
+
+/*
+GetExpiredTags returns the tags to remove according to the first policy matching the repo, none if no policy
+matches. The tags which aren't protected are sorted from the most recently pushed, the first KeepTags of them
+are kept, the others are removed if MaxAge is not set or if they were pushed longer than MaxAge ago.
+*/
+func (policies *Policies) GetExpiredTags(repo string, tags []storageTypes.TagInfo) []storageTypes.TagInfo {
+	expired := []storageTypes.TagInfo{}
+
+	if policies == nil {
+		return expired
+	}
+
+	for _, policy := range policies.policies {
+		if !policy.matchesRepo(repo) {
+			continue
+		}
+
+		if policy.keepTags == 0 && policy.maxAge == 0 {
+			return expired
+		}
+
+		candidates := []storageTypes.TagInfo{}
+
+		for _, tag := range tags {
+			if !policy.isProtected(tag.Tag) {
+				candidates = append(candidates, tag)
+			}
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].PushedAt.After(candidates[j].PushedAt)
+		})
+
+		cutoff := time.Now().Add(-policy.maxAge)
+
+		for idx, tag := range candidates {
+			if idx < policy.keepTags {
+				continue
+			}
+
+			if policy.maxAge == 0 || tag.PushedAt.Before(cutoff) {
+				expired = append(expired, tag)
+			}
+		}
+
+		return expired
+	}
+
+	return expired
+}
+
+// IsDryRun returns whether gc only logs the expired tags.
+func (policies *Policies) IsDryRun() bool {
+	return policies != nil && policies.dryRun
+}
+
+func (policy policy) matchesRepo(repo string) bool {
+	if len(policy.repositories) == 0 {
+		return true
+	}
+
+	for _, pattern := range policy.repositories {
+		if matched, err := glob.Match(pattern, repo); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (policy policy) isProtected(tag string) bool {
+	for _, tagRegexp := range policy.protectTags {
+		if tagRegexp.MatchString(tag) {
+			return true
+		}
+	}
+
+	return false
+}
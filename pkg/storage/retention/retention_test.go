@@ -0,0 +1,80 @@
+package retention_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
+	"zotregistry.io/zot/pkg/storage/retention"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
+)
+
+func TestRetention(t *testing.T) {
+	Convey("Invalid policies", t, func() {
+		for _, policy := range []config.RetentionPolicy{
+			{KeepTags: -1},
+			{MaxAge: -time.Hour},
+			{Repositories: []string{"a/[**"}},
+			{ProtectTags: []string{"v("}},
+		} {
+			_, err := retention.New(&config.RetentionConfig{Policies: []config.RetentionPolicy{policy}})
+			So(errors.Is(err, zerr.ErrBadRetentionPolicy), ShouldBeTrue)
+		}
+	})
+
+	Convey("Without config all the tags are kept", t, func() {
+		policies, err := retention.New(nil)
+		So(err, ShouldBeNil)
+		So(policies.IsDryRun(), ShouldBeFalse)
+		So(policies.GetExpiredTags("repo", []storageTypes.TagInfo{{Tag: "1.0"}}), ShouldBeEmpty)
+	})
+
+	Convey("Expired tags", t, func() {
+		now := time.Now()
+		tags := []storageTypes.TagInfo{
+			{Tag: "1.0", PushedAt: now.Add(-72 * time.Hour)},
+			{Tag: "latest", PushedAt: now.Add(-96 * time.Hour)},
+			{Tag: "3.0", PushedAt: now},
+			{Tag: "2.0", PushedAt: now.Add(-48 * time.Hour)},
+			{Tag: "1.1", PushedAt: now.Add(-60 * time.Hour)},
+		}
+
+		policies, err := retention.New(&config.RetentionConfig{
+			DryRun: true,
+			Policies: []config.RetentionPolicy{
+				{Repositories: []string{"keep/**"}},
+				{Repositories: []string{"recent/**"}, KeepTags: 2},
+				{Repositories: []string{"young/**"}, MaxAge: 50 * time.Hour, ProtectTags: []string{"latest"}},
+				{KeepTags: 2, MaxAge: 65 * time.Hour, ProtectTags: []string{"latest", `1\..*`}},
+			},
+		})
+		So(err, ShouldBeNil)
+		So(policies.IsDryRun(), ShouldBeTrue)
+
+		getExpiredTags := func(repo string) []string {
+			expired := []string{}
+
+			for _, tag := range policies.GetExpiredTags(repo, tags) {
+				expired = append(expired, tag.Tag)
+			}
+
+			return expired
+		}
+
+		So(getExpiredTags("keep/repo"), ShouldBeEmpty)
+		So(getExpiredTags("recent/repo"), ShouldResemble, []string{"1.1", "1.0", "latest"})
+		So(getExpiredTags("young/repo"), ShouldResemble, []string{"1.1", "1.0"})
+		So(getExpiredTags("repo"), ShouldBeEmpty)
+
+		// 2.0 is among the 2 most recent tags which aren't protected, but not 1.0
+		policies, err = retention.New(&config.RetentionConfig{
+			Policies: []config.RetentionPolicy{{KeepTags: 2, MaxAge: 65 * time.Hour, ProtectTags: []string{"latest"}}},
+		})
+		So(err, ShouldBeNil)
+		So(getExpiredTags("repo"), ShouldResemble, []string{"1.0"})
+	})
+}
@@ -0,0 +1,293 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/docker/distribution/registry/storage/driver"
+
+	zerr "zotregistry.io/zot/errors"
+)
+
+const (
+	// s3 rejects multipart parts smaller than 5MB, except for the last one.
+	MinMultipartPartSize = 5 * 1024 * 1024
+	// same as the s3 storage driver default chunksize.
+	DefaultMultipartPartSize    = 2 * MinMultipartPartSize
+	DefaultMultipartConcurrency = 4
+
+	partSizeParameter    = "chunksize"
+	concurrencyParameter = "uploadconcurrency"
+)
+
+/*
+MultipartConfig controls how blob upload chunks are handed to the storage driver.
+
+Uploaded data is split in parts of PartSize bytes, which should match the driver chunksize so that
+each write results in whole multipart parts being sent to s3. Up to Concurrency parts are uploaded at
+once when the driver writer is a PartWriter, else they are written one after the other while up to
+Concurrency following parts are read from the client. Either way the transfers overlap instead of the
+whole chunk being buffered before uploading it.
+*/
+type MultipartConfig struct {
+	PartSize    int64
+	Concurrency int
+}
+
+// GetMultipartConfig reads the multipart settings from the storage driver parameters,
+// "chunksize" is shared with the s3 driver and "uploadconcurrency" is only used by zot.
+func GetMultipartConfig(parameters map[string]interface{}) (MultipartConfig, error) {
+	partSize, err := getIntParameter(parameters, partSizeParameter, DefaultMultipartPartSize)
+	if err != nil {
+		return MultipartConfig{}, err
+	}
+
+	if partSize < MinMultipartPartSize {
+		return MultipartConfig{}, fmt.Errorf("%w: storage driver %s must be at least %d bytes",
+			zerr.ErrBadConfig, partSizeParameter, MinMultipartPartSize)
+	}
+
+	concurrency, err := getIntParameter(parameters, concurrencyParameter, DefaultMultipartConcurrency)
+	if err != nil {
+		return MultipartConfig{}, err
+	}
+
+	if concurrency < 1 {
+		return MultipartConfig{}, fmt.Errorf("%w: storage driver %s must be greater than 0",
+			zerr.ErrBadConfig, concurrencyParameter)
+	}
+
+	return MultipartConfig{
+		PartSize:    partSize,
+		Concurrency: int(concurrency),
+	}, nil
+}
+
+func getIntParameter(parameters map[string]interface{}, name string, defaultValue int64) (int64, error) {
+	switch value := parameters[name].(type) {
+	case nil:
+		return defaultValue, nil
+	case int:
+		return int64(value), nil
+	case int64:
+		return value, nil
+	case uint64:
+		return int64(value), nil
+	case float64:
+		return int64(value), nil
+	case string:
+		intValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: storage driver %s must be an integer", zerr.ErrBadConfig, name)
+		}
+
+		return intValue, nil
+	default:
+		return 0, fmt.Errorf("%w: storage driver %s must be an integer", zerr.ErrBadConfig, name)
+	}
+}
+
+func (config MultipartConfig) withDefaults() MultipartConfig {
+	if config.PartSize <= 0 {
+		config.PartSize = DefaultMultipartPartSize
+	}
+
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultMultipartConcurrency
+	}
+
+	return config
+}
+
+/*
+writeParts copies body to the driver writer in parts of config.PartSize bytes, only the last part
+can be smaller. A goroutine reads the next parts from body while the previous ones are written, if the
+writer is a PartWriter up to config.Concurrency parts are uploaded at once, else they are written in order
+and at most config.Concurrency parts wait to be written. Part buffers are reused, and if writing fails
+the reader is stopped before returning so that body isn't read anymore.
+*/
+func writeParts(writer driver.FileWriter, body io.Reader, config MultipartConfig) (int64, error) {
+	if partWriter, ok := writer.(PartWriter); ok {
+		if number, ok := partWriter.NextPart(); ok {
+			return writePartsConcurrently(partWriter, number, body, config)
+		}
+	}
+
+	reader := newPartReader(body, config, 1, false)
+	defer reader.stop()
+
+	var written int64
+
+	for part := range reader.parts {
+		nbytes, err := writer.Write(part.data)
+		written += int64(nbytes)
+
+		if err != nil {
+			return written, err
+		}
+
+		reader.free <- part.data[:cap(part.data)]
+	}
+
+	return written, reader.err()
+}
+
+// writePartsConcurrently uploads the parts of body numbered from number with config.Concurrency workers,
+// the uploads in progress are cancelled as soon as one of them fails.
+func writePartsConcurrently(writer PartWriter, number int, body io.Reader, config MultipartConfig,
+) (int64, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reader := newPartReader(body, config, number, true)
+
+	var (
+		written  int64
+		writeErr error
+		lock     sync.Mutex
+		wg       sync.WaitGroup
+	)
+
+	for i := 0; i < config.Concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// after a failure the parts left are drained so that the reader can be stopped
+			for part := range reader.parts {
+				if ctx.Err() == nil {
+					if err := writer.WritePart(ctx, part.number, part.data); err != nil {
+						lock.Lock()
+						if writeErr == nil {
+							writeErr = err
+						}
+						lock.Unlock()
+
+						cancel()
+						reader.stop()
+					} else {
+						lock.Lock()
+						written += int64(len(part.data))
+						lock.Unlock()
+					}
+				}
+
+				reader.free <- part.data[:cap(part.data)]
+			}
+		}()
+	}
+
+	wg.Wait()
+	reader.stop()
+
+	if writeErr != nil {
+		return written, writeErr
+	}
+
+	return written, reader.err()
+}
+
+type part struct {
+	number int
+	data   []byte
+}
+
+// partReader reads body in parts of config.PartSize bytes from a goroutine, until body ends, reading fails
+// or stop is called.
+type partReader struct {
+	parts chan part
+	free  chan []byte
+
+	done     chan struct{}
+	finished chan struct{}
+	stopOnce sync.Once
+	readErr  error
+}
+
+// newPartReader starts reading body, the parts are numbered from number and if skipEmpty is true an empty
+// body results in no part.
+func newPartReader(body io.Reader, config MultipartConfig, number int, skipEmpty bool) *partReader {
+	maxBuffers := config.Concurrency + 1
+
+	reader := &partReader{
+		parts:    make(chan part, config.Concurrency),
+		free:     make(chan []byte, maxBuffers),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+
+	go reader.read(body, config.PartSize, maxBuffers, number, skipEmpty)
+
+	return reader
+}
+
+func (reader *partReader) read(body io.Reader, partSize int64, maxBuffers, number int, skipEmpty bool) {
+	defer close(reader.finished)
+	defer close(reader.parts)
+
+	allocated := 0
+	sent := false
+
+	for {
+		var buf []byte
+
+		select {
+		case buf = <-reader.free:
+		case <-reader.done:
+			return
+		default:
+			if allocated < maxBuffers {
+				buf = make([]byte, partSize)
+				allocated++
+			} else {
+				select {
+				case buf = <-reader.free:
+				case <-reader.done:
+					return
+				}
+			}
+		}
+
+		nbytes, err := io.ReadFull(body, buf)
+
+		// an empty body still results in a write, so that driver errors are reported
+		if nbytes > 0 || (!sent && !skipEmpty) {
+			select {
+			case reader.parts <- part{number: number, data: buf[:nbytes]}:
+				sent = true
+				number++
+			case <-reader.done:
+				return
+			}
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return
+		}
+
+		if err != nil {
+			reader.readErr = err
+
+			return
+		}
+	}
+}
+
+// stop stops reading and waits for the read in progress to end, the parts already read can still be received.
+func (reader *partReader) stop() {
+	reader.stopOnce.Do(func() { close(reader.done) })
+
+	<-reader.finished
+}
+
+// err returns the error reading body, once all parts have been received.
+func (reader *partReader) err() error {
+	<-reader.finished
+
+	return reader.readErr
+}
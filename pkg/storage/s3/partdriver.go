@@ -0,0 +1,309 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/docker/distribution/registry/storage/driver"
+	s3aws "github.com/docker/distribution/registry/storage/driver/s3-aws"
+
+	zerr "zotregistry.io/zot/errors"
+)
+
+/*
+PartWriter is implemented by the storage driver writers able to upload the parts of their multipart upload
+concurrently, instead of sending them one after the other through Write.
+*/
+type PartWriter interface {
+	driver.FileWriter
+	// NextPart returns the number of the next part to upload, ok is false if the parts must be written through
+	// Write, e.g. when the last uploaded part is too small to be followed by other parts.
+	NextPart() (number int, ok bool)
+	// WritePart uploads data as the part numbered number, it can be called concurrently.
+	WritePart(ctx context.Context, number int, data []byte) error
+}
+
+// partDriver is an s3 storage driver whose writers upload the parts of multipart uploads concurrently,
+// through an s3 client of its own.
+type partDriver struct {
+	driver.StorageDriver
+	client *awss3.S3
+	bucket string
+}
+
+/*
+NewPartDriver wraps the s3 storage driver created from parameters so that its writers are PartWriters.
+The multipart uploads are still created, listed and aborted by the driver, only their parts are uploaded
+and completed through a client built from the same parameters. Drivers using the v2 signature are
+returned unchanged.
+*/
+func NewPartDriver(store driver.StorageDriver, parameters map[string]interface{}) (driver.StorageDriver, error) {
+	if _, ok := store.(*s3aws.Driver); !ok {
+		return store, nil
+	}
+
+	v4Auth, err := getBoolParameter(parameters, "v4auth", true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !v4Auth {
+		return store, nil
+	}
+
+	secure, err := getBoolParameter(parameters, "secure", true)
+	if err != nil {
+		return nil, err
+	}
+
+	skipVerify, err := getBoolParameter(parameters, "skipverify", false)
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := aws.NewConfig().
+		WithRegion(getStringParameter(parameters, "region")).
+		WithDisableSSL(!secure)
+
+	// same credentials chain as the driver
+	metadataSession, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig.WithCredentials(credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.StaticProvider{
+			Value: credentials.Value{
+				AccessKeyID:     getStringParameter(parameters, "accesskey"),
+				SecretAccessKey: getStringParameter(parameters, "secretkey"),
+				SessionToken:    getStringParameter(parameters, "sessiontoken"),
+			},
+		},
+		&credentials.EnvProvider{},
+		&credentials.SharedCredentialsProvider{},
+		&ec2rolecreds.EC2RoleProvider{Client: ec2metadata.New(metadataSession)},
+	}))
+
+	if endpoint := getStringParameter(parameters, "regionendpoint"); endpoint != "" {
+		awsConfig.WithS3ForcePathStyle(true).WithEndpoint(endpoint)
+	}
+
+	if skipVerify {
+		awsConfig.WithHTTPClient(&http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint: gosec
+			},
+		})
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &partDriver{
+		StorageDriver: store,
+		client:        awss3.New(sess),
+		bucket:        getStringParameter(parameters, "bucket"),
+	}, nil
+}
+
+// Writer returns the writer of the driver, able to upload its parts concurrently.
+func (d *partDriver) Writer(ctx context.Context, path string, isAppend bool) (driver.FileWriter, error) {
+	writer, err := d.StorageDriver.Writer(ctx, path, isAppend)
+	if err != nil {
+		return nil, err
+	}
+
+	key := d.StorageDriver.(*s3aws.Driver).S3BucketKey(path)
+
+	// the driver picks the first upload of the key, so do we
+	uploads, err := d.client.ListMultipartUploadsWithContext(ctx, &awss3.ListMultipartUploadsInput{
+		Bucket: aws.String(d.bucket),
+		Prefix: aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, upload := range uploads.Uploads {
+		if aws.StringValue(upload.Key) != key {
+			continue
+		}
+
+		partWriter := &partWriter{
+			FileWriter: writer,
+			driver:     d,
+			key:        key,
+			uploadID:   aws.StringValue(upload.UploadId),
+			size:       writer.Size(),
+		}
+
+		parts, err := partWriter.listParts(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// parts are listed by number, there can be gaps after failed uploads
+		partWriter.nextPart = 1
+
+		if len(parts) > 0 {
+			lastPart := parts[len(parts)-1]
+
+			partWriter.nextPart = int(aws.Int64Value(lastPart.PartNumber)) + 1
+			partWriter.lastPartSize = aws.Int64Value(lastPart.Size)
+		}
+
+		return partWriter, nil
+	}
+
+	// the upload is only found through the driver, e.g. with an s3 compatible store not listing uploads
+	return writer, nil
+}
+
+type partWriter struct {
+	driver.FileWriter
+	driver       *partDriver
+	key          string
+	uploadID     string
+	nextPart     int
+	lastPartSize int64
+
+	lock sync.Mutex
+	// size of the parts uploaded through WritePart
+	written int64
+	// whether the driver writer doesn't know about all the parts
+	uploadedParts bool
+	size          int64
+}
+
+func (w *partWriter) NextPart() (int, bool) {
+	return w.nextPart, w.nextPart == 1 || w.lastPartSize >= MinMultipartPartSize
+}
+
+func (w *partWriter) WritePart(ctx context.Context, number int, data []byte) error {
+	_, err := w.driver.client.UploadPartWithContext(ctx, &awss3.UploadPartInput{
+		Bucket:     aws.String(w.driver.bucket),
+		Key:        aws.String(w.key),
+		UploadId:   aws.String(w.uploadID),
+		PartNumber: aws.Int64(int64(number)),
+		Body:       bytes.NewReader(data),
+	})
+	if err != nil {
+		return err
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.written += int64(len(data))
+	w.uploadedParts = true
+
+	return nil
+}
+
+func (w *partWriter) Size() int64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.uploadedParts {
+		return w.FileWriter.Size()
+	}
+
+	return w.size + w.written
+}
+
+// Commit completes the multipart upload with all its parts, the driver writer only knows about the parts
+// which were uploaded before it was opened.
+func (w *partWriter) Commit() error {
+	w.lock.Lock()
+	uploadedParts := w.uploadedParts
+	w.lock.Unlock()
+
+	if !uploadedParts {
+		return w.FileWriter.Commit()
+	}
+
+	parts, err := w.listParts(context.Background())
+	if err != nil {
+		return err
+	}
+
+	completedParts := make([]*awss3.CompletedPart, 0, len(parts))
+
+	for _, part := range parts {
+		completedParts = append(completedParts, &awss3.CompletedPart{
+			ETag:       part.ETag,
+			PartNumber: part.PartNumber,
+		})
+	}
+
+	sort.Slice(completedParts, func(i, j int) bool {
+		return aws.Int64Value(completedParts[i].PartNumber) < aws.Int64Value(completedParts[j].PartNumber)
+	})
+
+	_, err = w.driver.client.CompleteMultipartUpload(&awss3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(w.driver.bucket),
+		Key:             aws.String(w.key),
+		UploadId:        aws.String(w.uploadID),
+		MultipartUpload: &awss3.CompletedMultipartUpload{Parts: completedParts},
+	})
+
+	return err
+}
+
+func (w *partWriter) listParts(ctx context.Context) ([]*awss3.Part, error) {
+	parts := []*awss3.Part{}
+
+	input := &awss3.ListPartsInput{
+		Bucket:   aws.String(w.driver.bucket),
+		Key:      aws.String(w.key),
+		UploadId: aws.String(w.uploadID),
+	}
+
+	err := w.driver.client.ListPartsPagesWithContext(ctx, input, func(page *awss3.ListPartsOutput, last bool) bool {
+		parts = append(parts, page.Parts...)
+
+		return true
+	})
+
+	return parts, err
+}
+
+func getStringParameter(parameters map[string]interface{}, name string) string {
+	if value, ok := parameters[name]; ok && value != nil {
+		return fmt.Sprint(value)
+	}
+
+	return ""
+}
+
+func getBoolParameter(parameters map[string]interface{}, name string, defaultValue bool) (bool, error) {
+	switch value := parameters[name].(type) {
+	case nil:
+		return defaultValue, nil
+	case bool:
+		return value, nil
+	case string:
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("%w: storage driver %s must be a boolean", zerr.ErrBadConfig, name)
+		}
+
+		return boolValue, nil
+	default:
+		return false, fmt.Errorf("%w: storage driver %s must be a boolean", zerr.ErrBadConfig, name)
+	}
+}
@@ -1,7 +1,6 @@
 package s3
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/json"
@@ -10,6 +9,7 @@ import (
 	"io"
 	"path"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -42,14 +42,26 @@ const (
 
 // ObjectStorage provides the image storage operations.
 type ObjectStorage struct {
-	rootDir string
-	store   driver.StorageDriver
-	lock    *sync.RWMutex
-	log     zerolog.Logger
-	metrics monitoring.MetricServer
-	cache   cache.Cache
-	dedupe  bool
-	linter  common.Lint
+	rootDir   string
+	store     driver.StorageDriver
+	multipart MultipartConfig
+	lock      *sync.RWMutex
+	log       zerolog.Logger
+	metrics   monitoring.MetricServer
+	cache     cache.Cache
+	dedupe    bool
+	linter    common.Lint
+	// tags which can't be moved or deleted, see SetImmutableTags
+	immutableTags storageTypes.ImmutableTags
+}
+
+// WithLogger returns a copy of the store logging its events with logger, e.g. one carrying the fields of the
+// request being served, the copy sharing the locks and the cache of the store.
+func (is *ObjectStorage) WithLogger(logger zerolog.Logger) storageTypes.ImageStore {
+	store := *is
+	store.log = logger
+
+	return &store
 }
 
 func (is *ObjectStorage) RootDir() string {
@@ -67,18 +79,20 @@ func (is *ObjectStorage) DirExists(d string) bool {
 // NewObjectStorage returns a new image store backed by cloud storages.
 // see https://github.com/docker/docker.github.io/tree/master/registry/storage-drivers
 // Use the last argument to properly set a cache database, or it will default to boltDB local storage.
+// Unset multipart values are replaced with defaults.
 func NewImageStore(rootDir string, cacheDir string, gc bool, gcDelay time.Duration, dedupe, commit bool,
 	log zlog.Logger, metrics monitoring.MetricServer, linter common.Lint,
-	store driver.StorageDriver, cacheDriver cache.Cache,
+	store driver.StorageDriver, multipart MultipartConfig, cacheDriver cache.Cache,
 ) storageTypes.ImageStore {
 	imgStore := &ObjectStorage{
-		rootDir: rootDir,
-		store:   store,
-		lock:    &sync.RWMutex{},
-		log:     log.With().Caller().Logger(),
-		metrics: metrics,
-		dedupe:  dedupe,
-		linter:  linter,
+		rootDir:   rootDir,
+		store:     store,
+		multipart: multipart.withDefaults(),
+		lock:      &sync.RWMutex{},
+		log:       log.With().Caller().Logger(),
+		metrics:   metrics,
+		dedupe:    dedupe,
+		linter:    linter,
 	}
 
 	imgStore.cache = cacheDriver
@@ -252,12 +266,26 @@ func (is *ObjectStorage) ValidateRepo(name string) (bool, error) {
 
 // GetRepositories returns a list of all the repositories under this store.
 func (is *ObjectStorage) GetRepositories() ([]string, error) {
+	stores := make([]string, 0)
+
+	err := is.WalkRepositories("", func(repo string) error {
+		stores = append(stores, repo)
+
+		return nil
+	})
+
+	return stores, err
+}
+
+// WalkRepositories calls walkFn for the repositories under this store coming after last, in the order given by
+// CompareRepoNames. The walk stops at the first error returned by walkFn, which is returned. The objects of the
+// store being listed in key order, the repos are sorted before being walked.
+func (is *ObjectStorage) WalkRepositories(last string, walkFn func(repo string) error) error {
 	var lockLatency time.Time
 
 	dir := is.rootDir
 
 	is.RLock(&lockLatency)
-	defer is.RUnlock(&lockLatency)
 
 	stores := make([]string, 0)
 	err := is.store.Walk(context.Background(), dir, func(fileInfo driver.FileInfo) error {
@@ -270,6 +298,22 @@ func (is *ObjectStorage) GetRepositories() ([]string, error) {
 			return nil //nolint:nilerr // ignore paths that are not under root dir
 		}
 
+		if common.IsRepoDirBefore(rel, last) {
+			return driver.ErrSkipDir
+		}
+
+		// the blobs of a repo can't contain other repos
+		if name := path.Base(rel); name == "blobs" || name == storageConstants.BlobUploadDir {
+			if ok, err := is.ValidateRepo(path.Dir(rel)); ok && err == nil {
+				return driver.ErrSkipDir
+			}
+		}
+
+		// the dirs containing last are walked through
+		if last != "" && common.CompareRepoNames(rel, last) <= 0 {
+			return nil
+		}
+
 		if ok, err := is.ValidateRepo(rel); !ok || err != nil {
 			return nil //nolint:nilerr // ignore invalid repos
 		}
@@ -279,13 +323,220 @@ func (is *ObjectStorage) GetRepositories() ([]string, error) {
 		return nil
 	})
 
-	// if the root directory is not yet created then return an empty slice of repositories
+	is.RUnlock(&lockLatency)
+
+	// if the root directory is not yet created then there are no repositories
+	var perr driver.PathNotFoundError
+	if err != nil && !errors.As(err, &perr) {
+		return err
+	}
+
+	sort.Slice(stores, func(i, j int) bool {
+		return common.CompareRepoNames(stores[i], stores[j]) < 0
+	})
+
+	for _, repo := range stores {
+		if err := walkFn(repo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckConsistency looks for repos with missing or invalid layout files and for blob upload dirs left
+// outside of any repo. With repair set the layout files are recreated, index.json being rebuilt from the
+// repo blobs, and the orphan upload dirs are removed, otherwise the issues are only reported.
+func (is *ObjectStorage) CheckConsistency(repair bool) ([]storageTypes.RepoIssue, error) {
+	var lockLatency time.Time
+
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
+
+	issues := []storageTypes.RepoIssue{}
+
+	err := is.store.Walk(context.Background(), is.rootDir, func(fileInfo driver.FileInfo) error {
+		if !fileInfo.IsDir() {
+			return nil
+		}
+
+		// blobs and uploads are checked along with the repo containing them
+		name := path.Base(fileInfo.Path())
+		if name == "blobs" || name == storageConstants.BlobUploadDir {
+			return driver.ErrSkipDir
+		}
+
+		rel, err := filepath.Rel(is.rootDir, fileInfo.Path())
+		if err != nil {
+			return nil //nolint:nilerr // ignore paths that are not under root dir
+		}
+
+		repoIssues, err := is.checkRepoConsistency(rel, repair)
+		issues = append(issues, repoIssues...)
+
+		return err
+	})
+
+	// if the root directory is not yet created then there is nothing to check
 	var perr driver.PathNotFoundError
 	if errors.As(err, &perr) {
-		return stores, nil
+		return issues, nil
 	}
 
-	return stores, err
+	return issues, err
+}
+
+func (is *ObjectStorage) checkRepoConsistency(repo string, repair bool) ([]storageTypes.RepoIssue, error) {
+	dir := path.Join(is.rootDir, repo)
+	issues := []storageTypes.RepoIssue{}
+
+	// object storage has no empty dirs, a repo without any blob is only recognized by its layout files
+	found := map[string]bool{}
+
+	files, err := is.store.List(context.Background(), dir)
+	if err != nil {
+		is.log.Error().Err(err).Str("dir", dir).Msg("unable to read directory")
+
+		return issues, err
+	}
+
+	for _, file := range files {
+		found[path.Base(file)] = true
+	}
+
+	if !found["blobs"] && !found[ispec.ImageLayoutFile] && !found["index.json"] {
+		if !found[storageConstants.BlobUploadDir] {
+			return issues, nil
+		}
+
+		uploadDir := path.Join(dir, storageConstants.BlobUploadDir)
+		issue := storageTypes.RepoIssue{Repo: repo, Issue: common.IssueOrphanUploadDir}
+
+		if repair {
+			if err := is.store.Delete(context.Background(), uploadDir); err != nil {
+				is.log.Error().Err(err).Str("dir", uploadDir).Msg("unable to remove orphan upload dir")
+
+				return issues, err
+			}
+
+			issue.Repaired = true
+		}
+
+		return append(issues, issue), nil
+	}
+
+	if !zreg.FullNameRegexp.MatchString(repo) {
+		return append(issues, storageTypes.RepoIssue{Repo: repo, Issue: common.IssueInvalidRepoName}), nil
+	}
+
+	ilPath := path.Join(dir, ispec.ImageLayoutFile)
+
+	var il ispec.ImageLayout
+
+	buf, err := is.store.GetContent(context.Background(), ilPath)
+	if err != nil || json.Unmarshal(buf, &il) != nil || il.Version != ispec.ImageLayoutVersion {
+		issue := storageTypes.RepoIssue{Repo: repo, Issue: common.IssueBadImageLayout}
+
+		if repair {
+			buf, err := json.Marshal(ispec.ImageLayout{Version: ispec.ImageLayoutVersion})
+			if err != nil {
+				is.log.Error().Err(err).Msg("unable to marshal JSON")
+
+				return issues, err
+			}
+
+			if _, err := writeFile(is.store, ilPath, buf); err != nil {
+				is.log.Error().Err(err).Str("file", ilPath).Msg("unable to write file")
+
+				return issues, err
+			}
+
+			issue.Repaired = true
+		}
+
+		issues = append(issues, issue)
+	}
+
+	indexPath := path.Join(dir, "index.json")
+
+	var index ispec.Index
+
+	buf, err = is.store.GetContent(context.Background(), indexPath)
+	if err != nil || json.Unmarshal(buf, &index) != nil {
+		issue := storageTypes.RepoIssue{Repo: repo, Issue: common.IssueBadIndex}
+
+		if repair {
+			blobs, err := is.getRepoBlobs(repo)
+			if err != nil {
+				return issues, err
+			}
+
+			index = common.RebuildIndex(is, repo, blobs, is.log)
+
+			buf, err := json.Marshal(index)
+			if err != nil {
+				is.log.Error().Err(err).Msg("unable to marshal JSON")
+
+				return issues, err
+			}
+
+			if _, err := writeFile(is.store, indexPath, buf); err != nil {
+				is.log.Error().Err(err).Str("file", indexPath).Msg("unable to write file")
+
+				return issues, err
+			}
+
+			is.log.Warn().Str("repository", repo).Int("manifests", len(index.Manifests)).
+				Msg("index.json rebuilt from blobs, the images are untagged")
+
+			issue.Repaired = true
+		}
+
+		issues = append(issues, issue)
+	}
+
+	if len(issues) == 0 {
+		if ok, err := is.ValidateRepo(repo); !ok || err != nil {
+			issues = append(issues, storageTypes.RepoIssue{Repo: repo, Issue: common.IssueInvalidRepoFiles})
+		}
+	}
+
+	return issues, nil
+}
+
+// getRepoBlobs returns the digests and sizes of all the blobs of a repo, deduped blobs have a 0 size.
+func (is *ObjectStorage) getRepoBlobs(repo string) (map[godigest.Digest]int64, error) {
+	blobs := map[godigest.Digest]int64{}
+	blobsDir := path.Join(is.rootDir, repo, "blobs")
+
+	err := is.store.Walk(context.Background(), blobsDir, func(fileInfo driver.FileInfo) error {
+		if fileInfo.IsDir() {
+			return nil
+		}
+
+		blobPath := fileInfo.Path()
+
+		digest := godigest.NewDigestFromEncoded(godigest.Algorithm(path.Base(path.Dir(blobPath))),
+			path.Base(blobPath))
+		if digest.Validate() != nil {
+			return nil
+		}
+
+		blobs[digest] = fileInfo.Size()
+
+		return nil
+	})
+
+	var perr driver.PathNotFoundError
+	if errors.As(err, &perr) {
+		return blobs, nil
+	}
+
+	if err != nil {
+		is.log.Error().Err(err).Str("dir", blobsDir).Msg("unable to walk blobs dir")
+	}
+
+	return blobs, err
 }
 
 // GetNextRepository returns next repository under this store.
@@ -392,6 +643,12 @@ func (is *ObjectStorage) PutImageManifest(repo, reference, mediaType string, //n
 		return "", "", err
 	}
 
+	if !refIsDigest {
+		if err := common.CheckTagsCanMove(index, repo, []string{reference}, mDigest, is.immutableTags); err != nil {
+			return "", "", err
+		}
+	}
+
 	// create a new descriptor
 	desc := ispec.Descriptor{
 		MediaType: mediaType, Size: int64(len(body)), Digest: mDigest,
@@ -399,13 +656,17 @@ func (is *ObjectStorage) PutImageManifest(repo, reference, mediaType string, //n
 
 	if !refIsDigest {
 		desc.Annotations = map[string]string{ispec.AnnotationRefName: reference}
+
+		common.SetTagPushedAt(&desc, time.Now())
 	}
 
+	common.SetOriginalDigest(&desc, body)
+
 	var subjectDigest godigest.Digest
 
 	artifactType := ""
 
-	if mediaType == ispec.MediaTypeImageManifest {
+	if zcommon.IsImageManifest(mediaType) {
 		var manifest ispec.Manifest
 
 		err := json.Unmarshal(body, &manifest)
@@ -479,8 +740,62 @@ func (is *ObjectStorage) PutImageManifest(repo, reference, mediaType string, //n
 	return desc.Digest, subjectDigest, nil
 }
 
+// TagImageManifest makes the tags point to a manifest of the repository, index.json is written once
+// so either all or none of the tags are updated.
+func (is *ObjectStorage) TagImageManifest(repo string, digest godigest.Digest, tags []string) error {
+	var lockLatency time.Time
+
+	dir := path.Join(is.rootDir, repo)
+	if fi, err := is.store.Stat(context.Background(), dir); err != nil || !fi.IsDir() {
+		return zerr.ErrRepoNotFound
+	}
+
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
+
+	index, err := common.GetIndex(is, repo, is.log)
+	if err != nil {
+		return err
+	}
+
+	if err := common.CheckTagsCanMove(index, repo, tags, digest, is.immutableTags); err != nil {
+		return err
+	}
+
+	updated, err := common.AddTagsToIndex(is, &index, repo, digest, tags, is.log)
+	if err != nil || !updated {
+		return err
+	}
+
+	indexPath := path.Join(dir, "index.json")
+
+	buf, err := json.Marshal(index)
+	if err != nil {
+		is.log.Error().Err(err).Str("file", indexPath).Msg("unable to marshal JSON")
+
+		return err
+	}
+
+	if err = is.store.PutContent(context.Background(), indexPath, buf); err != nil {
+		is.log.Error().Err(err).Str("file", indexPath).Msg("unable to write")
+
+		return err
+	}
+
+	return nil
+}
+
 // DeleteImageManifest deletes the image manifest from the repository.
 func (is *ObjectStorage) DeleteImageManifest(repo, reference string, detectCollisions bool) error {
+	return is.deleteImageManifest(repo, reference, detectCollisions, true)
+}
+
+// PurgeImageManifest deletes the image manifest from the repository even if immutable tags point to it.
+func (is *ObjectStorage) PurgeImageManifest(repo, reference string) error {
+	return is.deleteImageManifest(repo, reference, false, false)
+}
+
+func (is *ObjectStorage) deleteImageManifest(repo, reference string, detectCollisions, checkImmutable bool) error {
 	var lockLatency time.Time
 
 	dir := path.Join(is.rootDir, repo)
@@ -496,6 +811,12 @@ func (is *ObjectStorage) DeleteImageManifest(repo, reference string, detectColli
 		return err
 	}
 
+	if checkImmutable {
+		if err := common.CheckTagsCanBeDeleted(index, repo, reference, is.immutableTags); err != nil {
+			return err
+		}
+	}
+
 	manifestDesc, err := common.RemoveManifestDescByReference(&index, reference, detectCollisions)
 	if err != nil {
 		return err
@@ -572,12 +893,17 @@ func (is *ObjectStorage) NewBlobUpload(repo string) (string, error) {
 
 	blobUploadPath := is.BlobUploadPath(repo, uid)
 
-	// create multipart upload (append false)
-	_, err = is.store.Writer(context.Background(), blobUploadPath, false)
+	// create multipart upload (append false), closing the writer saves the upload for the drivers
+	// which keep it in an object, e.g. gcs
+	writer, err := is.store.Writer(context.Background(), blobUploadPath, false)
 	if err != nil {
 		return "", err
 	}
 
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
 	return uid, nil
 }
 
@@ -619,23 +945,14 @@ func (is *ObjectStorage) PutBlobChunkStreamed(repo, uuid string, body io.Reader)
 
 	defer file.Close()
 
-	buf := new(bytes.Buffer)
-
-	_, err = buf.ReadFrom(body)
-	if err != nil {
-		is.log.Error().Err(err).Msg("failed to read blob")
-
-		return -1, err
-	}
-
-	nbytes, err := file.Write(buf.Bytes())
+	nbytes, err := writeParts(file, body, is.multipart)
 	if err != nil {
 		is.log.Error().Err(err).Msg("failed to append to file")
 
 		return -1, err
 	}
 
-	return int64(nbytes), err
+	return nbytes, err
 }
 
 // PutBlobChunk writes another chunk of data to the specified blob. It returns
@@ -669,23 +986,14 @@ func (is *ObjectStorage) PutBlobChunk(repo, uuid string, from, to int64,
 		return -1, zerr.ErrBadUploadRange
 	}
 
-	buf := new(bytes.Buffer)
-
-	_, err = buf.ReadFrom(body)
-	if err != nil {
-		is.log.Error().Err(err).Msg("failed to read blob")
-
-		return -1, err
-	}
-
-	nbytes, err := file.Write(buf.Bytes())
+	nbytes, err := writeParts(file, body, is.multipart)
 	if err != nil {
 		is.log.Error().Err(err).Msg("failed to append to file")
 
 		return -1, err
 	}
 
-	return int64(nbytes), err
+	return nbytes, err
 }
 
 // BlobUploadInfo returns the current blob size in bytes.
@@ -799,25 +1107,19 @@ func (is *ObjectStorage) FullBlobUpload(repo string, body io.Reader, dstDigest g
 	uuid := u.String()
 	src := is.BlobUploadPath(repo, uuid)
 	digester := sha256.New()
-	buf := new(bytes.Buffer)
 
-	_, err = buf.ReadFrom(body)
+	// the body is streamed to s3 as multipart upload parts, it is never buffered whole in memory
+	fileWriter, err := is.store.Writer(context.Background(), src, false)
 	if err != nil {
-		is.log.Error().Err(err).Msg("failed to read blob")
+		is.log.Error().Err(err).Str("blob", src).Msg("failed to create multipart upload")
 
 		return "", -1, err
 	}
 
-	nbytes, err := writeFile(is.store, src, buf.Bytes())
+	nbytes, err := writeParts(fileWriter, io.TeeReader(body, digester), is.multipart)
 	if err != nil {
 		is.log.Error().Err(err).Msg("failed to write blob")
-
-		return "", -1, err
-	}
-
-	_, err = digester.Write(buf.Bytes())
-	if err != nil {
-		is.log.Error().Err(err).Msg("digester failed to write")
+		is.cancelUpload(fileWriter, src)
 
 		return "", -1, err
 	}
@@ -826,10 +1128,24 @@ func (is *ObjectStorage) FullBlobUpload(repo string, body io.Reader, dstDigest g
 	if srcDigest != dstDigest {
 		is.log.Error().Str("srcDigest", srcDigest.String()).
 			Str("dstDigest", dstDigest.String()).Msg("actual digest not equal to expected digest")
+		is.cancelUpload(fileWriter, src)
 
 		return "", -1, zerr.ErrBadBlobDigest
 	}
 
+	// complete the multipart upload only once the content is known to be valid
+	if err := fileWriter.Commit(); err != nil {
+		is.log.Error().Err(err).Str("blob", src).Msg("failed to commit multipart upload")
+
+		return "", -1, err
+	}
+
+	if err := fileWriter.Close(); err != nil {
+		is.log.Error().Err(err).Msg("failed to close file")
+
+		return "", -1, err
+	}
+
 	var lockLatency time.Time
 
 	is.Lock(&lockLatency)
@@ -853,7 +1169,15 @@ func (is *ObjectStorage) FullBlobUpload(repo string, body io.Reader, dstDigest g
 		}
 	}
 
-	return uuid, int64(nbytes), nil
+	return uuid, nbytes, nil
+}
+
+// cancelUpload aborts the multipart upload of a blob which won't be committed, so that its parts
+// don't linger in the bucket.
+func (is *ObjectStorage) cancelUpload(fileWriter driver.FileWriter, src string) {
+	if err := fileWriter.Cancel(); err != nil {
+		is.log.Error().Err(err).Str("blob", src).Msg("failed to abort multipart upload")
+	}
 }
 
 func (is *ObjectStorage) DedupeBlob(src string, dstDigest godigest.Digest, dst string) error {
@@ -945,6 +1269,106 @@ func (is *ObjectStorage) RunGCRepo(repo string) error {
 func (is *ObjectStorage) RunGCPeriodically(interval time.Duration, sch *scheduler.Scheduler) {
 }
 
+// SetCommitPolicy does nothing, objects are durable once they're written to s3.
+func (is *ObjectStorage) SetCommitPolicy(policy string) {
+}
+
+// RunCommitPeriodically does nothing, objects are durable once they're written to s3.
+func (is *ObjectStorage) RunCommitPeriodically(interval time.Duration, sch *scheduler.Scheduler) {
+}
+
+// SetNFSMode does nothing, s3 doesn't use hard links or local locks.
+func (is *ObjectStorage) SetNFSMode(enabled bool) {
+}
+
+// SetDedupeStrategy does nothing, deduped blobs are recorded in the cache, s3 doesn't link them.
+func (is *ObjectStorage) SetDedupeStrategy(strategy string) {
+}
+
+// SetSharedBlobs does nothing, s3 blobs are shared through the dedupe cache.
+func (is *ObjectStorage) SetSharedBlobs(enabled bool) {
+}
+
+// SetGCVerifyPercent does nothing, gc is not implemented for s3.
+func (is *ObjectStorage) SetGCVerifyPercent(percent int) {
+}
+
+// SetGCBatchSize does nothing, gc is not implemented for s3.
+func (is *ObjectStorage) SetGCBatchSize(size int) {
+}
+
+// SetImmutableTags sets the immutable tags, pushes moving them to another manifest and deletes removing them
+// are rejected with zerr.ErrTagImmutable.
+func (is *ObjectStorage) SetImmutableTags(immutableTags storageTypes.ImmutableTags) {
+	is.immutableTags = immutableTags
+}
+
+// SetLeases does nothing, gc is not implemented for s3.
+func (is *ObjectStorage) SetLeases(leases storageTypes.Leases) {
+}
+
+// SetInFlight does nothing, gc is not implemented for s3.
+func (is *ObjectStorage) SetInFlight(inFlight storageTypes.InFlight) {
+}
+
+// SetIOOptions does nothing, they only tune how local files are written and read.
+func (is *ObjectStorage) SetIOOptions(options storageTypes.IOOptions) {
+}
+
+// GetLayoutVersion returns the version of the layout of the store, 0 if none was recorded yet.
+func (is *ObjectStorage) GetLayoutVersion() (int, error) {
+	var lockLatency time.Time
+
+	is.RLock(&lockLatency)
+	defer is.RUnlock(&lockLatency)
+
+	buf, err := is.store.GetContent(context.Background(), path.Join(is.rootDir, storageConstants.LayoutVersionFile))
+	if err != nil {
+		if errors.As(err, &driver.PathNotFoundError{}) {
+			return 0, nil
+		}
+
+		is.log.Error().Err(err).Str("rootDir", is.rootDir).Msg("failed to read layout version")
+
+		return 0, err
+	}
+
+	return common.DecodeLayoutVersion(buf)
+}
+
+// SetLayoutVersion records the version of the layout of the store, objects are replaced atomically.
+func (is *ObjectStorage) SetLayoutVersion(version int) error {
+	buf, err := common.EncodeLayoutVersion(version)
+	if err != nil {
+		return err
+	}
+
+	var lockLatency time.Time
+
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
+
+	if _, err := writeFile(is.store, path.Join(is.rootDir, storageConstants.LayoutVersionFile), buf); err != nil {
+		is.log.Error().Err(err).Str("rootDir", is.rootDir).Msg("failed to write layout version")
+
+		return err
+	}
+
+	return nil
+}
+
+// SetRetention does nothing, gc is not implemented for s3.
+func (is *ObjectStorage) SetRetention(retention storageTypes.Retention) {
+}
+
+// SetTagRemovals does nothing, gc is not implemented for s3.
+func (is *ObjectStorage) SetTagRemovals(removals storageTypes.TagRemovals) {
+}
+
+// SetPinnedImages does nothing, gc is not implemented for s3.
+func (is *ObjectStorage) SetPinnedImages(pins storageTypes.PinnedImages) {
+}
+
 // DeleteBlobUpload deletes an existing blob upload that is currently in progress.
 func (is *ObjectStorage) DeleteBlobUpload(repo, uuid string) error {
 	blobUploadPath := is.BlobUploadPath(repo, uuid)
@@ -1023,6 +1447,69 @@ func (is *ObjectStorage) CheckBlob(repo string, digest godigest.Digest) (bool, i
 	return true, blobSize, nil
 }
 
+// MountBlob makes a blob of fromRepo available in repo, e.g. when it's mounted from another repo, without looking
+// for it in the other repos like CheckBlob does. Deduped blobs are linked, the others are copied.
+func (is *ObjectStorage) MountBlob(repo, fromRepo string, digest godigest.Digest) (int64, error) {
+	if err := digest.Validate(); err != nil {
+		return -1, err
+	}
+
+	blobPath := is.BlobPath(repo, digest)
+	srcPath := is.BlobPath(fromRepo, digest)
+
+	if !is.dedupe || fmt.Sprintf("%v", is.cache) == fmt.Sprintf("%v", nil) {
+		if binfo, err := is.store.Stat(context.Background(), blobPath); err == nil && binfo.Size() > 0 {
+			return binfo.Size(), nil
+		}
+
+		reader, err := is.store.Reader(context.Background(), srcPath, 0)
+		if err != nil {
+			is.log.Debug().Err(err).Str("blob", srcPath).Msg("failed to find blob to mount")
+
+			return -1, zerr.ErrBlobNotFound
+		}
+		defer reader.Close()
+
+		// the copy is verified against the digest
+		_, blobSize, err := is.FullBlobUpload(repo, reader, digest)
+
+		return blobSize, err
+	}
+
+	var lockLatency time.Time
+
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
+
+	if binfo, err := is.store.Stat(context.Background(), blobPath); err == nil && binfo.Size() > 0 {
+		return binfo.Size(), nil
+	}
+
+	if _, err := is.store.Stat(context.Background(), srcPath); err != nil {
+		is.log.Debug().Err(err).Str("blob", srcPath).Msg("failed to find blob to mount")
+
+		return -1, zerr.ErrBlobNotFound
+	}
+
+	dstRecord, err := is.checkCacheBlob(digest)
+	if err != nil {
+		return -1, zerr.ErrBlobNotFound
+	}
+
+	blobSize, err := is.copyBlob(repo, blobPath, dstRecord)
+	if err != nil {
+		return -1, err
+	}
+
+	if err := is.cache.PutBlob(digest, blobPath); err != nil {
+		is.log.Error().Err(err).Str("blobPath", blobPath).Msg("dedupe: unable to insert blob record")
+
+		return -1, err
+	}
+
+	return blobSize, nil
+}
+
 func (is *ObjectStorage) checkCacheBlob(digest godigest.Digest) (string, error) {
 	if err := digest.Validate(); err != nil {
 		return "", err
@@ -1646,3 +2133,16 @@ func (is *ObjectStorage) RunDedupeBlobs(interval time.Duration, sch *scheduler.S
 
 	sch.SubmitGenerator(generator, interval, scheduler.MediumPriority)
 }
+
+// RunCacheMaintenance checks the integrity of the dedupe cache db and compacts it.
+func (is *ObjectStorage) RunCacheMaintenance() error {
+	return common.RunCacheMaintenance(is.cache, is.rootDir, is.log)
+}
+
+func (is *ObjectStorage) RunCacheMaintenancePeriodically(interval time.Duration, sch *scheduler.Scheduler) {
+	generator := &common.CacheMaintenanceTaskGenerator{
+		ImgStore: is,
+	}
+
+	sch.SubmitGenerator(generator, interval, scheduler.LowPriority)
+}
@@ -11,7 +11,10 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/docker/distribution/registry/storage/driver"
@@ -85,7 +88,7 @@ func createMockStorage(rootDir string, cacheDir string, dedupe bool, store drive
 		}, log)
 	}
 	il := s3.NewImageStore(rootDir, cacheDir, false, storageConstants.DefaultGCDelay,
-		dedupe, false, log, metrics, nil, store, cacheDriver,
+		dedupe, false, log, metrics, nil, store, s3.MultipartConfig{}, cacheDriver,
 	)
 
 	return il
@@ -98,7 +101,7 @@ func createMockStorageWithMockCache(rootDir string, dedupe bool, store driver.St
 	metrics := monitoring.NewMetricsServer(false, log)
 
 	il := s3.NewImageStore(rootDir, "", false, storageConstants.DefaultGCDelay,
-		dedupe, false, log, metrics, nil, store, cacheDriver,
+		dedupe, false, log, metrics, nil, store, s3.MultipartConfig{}, cacheDriver,
 	)
 
 	return il
@@ -160,7 +163,7 @@ func createObjectsStore(rootDir string, cacheDir string, dedupe bool) (
 	}
 
 	il := s3.NewImageStore(rootDir, cacheDir, false, storageConstants.DefaultGCDelay,
-		dedupe, false, log, metrics, nil, store, cacheDriver)
+		dedupe, false, log, metrics, nil, store, s3.MultipartConfig{}, cacheDriver)
 
 	return store, il, err
 }
@@ -195,7 +198,7 @@ func createObjectsStoreDynamo(rootDir string, cacheDir string, dedupe bool, tabl
 	}
 
 	il := s3.NewImageStore(rootDir, cacheDir, false, storageConstants.DefaultGCDelay,
-		dedupe, false, log, metrics, nil, store, cacheDriver)
+		dedupe, false, log, metrics, nil, store, s3.MultipartConfig{}, cacheDriver)
 
 	return store, il, err
 }
@@ -287,6 +290,40 @@ func (f *FileWriterMock) Close() error {
 	return nil
 }
 
+type PartWriterMock struct {
+	FileWriterMock
+	NextPartFn  func() (int, bool)
+	WritePartFn func(context.Context, int, []byte) error
+}
+
+func (f *PartWriterMock) NextPart() (int, bool) {
+	if f != nil && f.NextPartFn != nil {
+		return f.NextPartFn()
+	}
+
+	return 1, true
+}
+
+func (f *PartWriterMock) WritePart(ctx context.Context, number int, data []byte) error {
+	if f != nil && f.WritePartFn != nil {
+		return f.WritePartFn(ctx, number, data)
+	}
+
+	return nil
+}
+
+// countingReader counts the reads of its reader.
+type countingReader struct {
+	reader io.Reader
+	reads  int32
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	atomic.AddInt32(&r.reads, 1)
+
+	return r.reader.Read(p)
+}
+
 type StorageDriverMock struct {
 	NameFn       func() string
 	GetContentFn func(ctx context.Context, path string) ([]byte, error)
@@ -3801,3 +3838,291 @@ func TestInjectDedupe(t *testing.T) {
 		}
 	})
 }
+
+func TestMultipartUpload(t *testing.T) {
+	tdir := t.TempDir()
+	testDir := path.Join("/oci-repo-test", "multipart")
+
+	Convey("Get multipart config from storage driver parameters", t, func() {
+		multipart, err := s3.GetMultipartConfig(map[string]interface{}{"name": "s3"})
+		So(err, ShouldBeNil)
+		So(multipart, ShouldResemble, s3.MultipartConfig{
+			PartSize:    s3.DefaultMultipartPartSize,
+			Concurrency: s3.DefaultMultipartConcurrency,
+		})
+
+		multipart, err = s3.GetMultipartConfig(map[string]interface{}{
+			"chunksize":         float64(8 * 1024 * 1024),
+			"uploadconcurrency": "2",
+		})
+		So(err, ShouldBeNil)
+		So(multipart, ShouldResemble, s3.MultipartConfig{PartSize: 8 * 1024 * 1024, Concurrency: 2})
+
+		for _, parameters := range []map[string]interface{}{
+			{"chunksize": 1024},
+			{"chunksize": "big"},
+			{"uploadconcurrency": 0},
+			{"uploadconcurrency": true},
+		} {
+			_, err = s3.GetMultipartConfig(parameters)
+			So(errors.Is(err, zerr.ErrBadConfig), ShouldBeTrue)
+		}
+	})
+
+	Convey("Blob chunks are written to the storage driver in aligned parts", t, func() {
+		var partSizes []int
+
+		written := new(bytes.Buffer)
+
+		imgStore := createMockStorage(testDir, tdir, false, &StorageDriverMock{
+			WriterFn: func(ctx context.Context, path string, isAppend bool) (driver.FileWriter, error) {
+				return &FileWriterMock{WriteFn: func(b []byte) (int, error) {
+					partSizes = append(partSizes, len(b))
+
+					return written.Write(b)
+				}}, nil
+			},
+		})
+
+		body := make([]byte, 2*s3.DefaultMultipartPartSize+1024)
+		for i := range body {
+			body[i] = byte(i % 251)
+		}
+
+		nbytes, err := imgStore.PutBlobChunkStreamed(testImage, "uuid", bytes.NewReader(body))
+		So(err, ShouldBeNil)
+		So(nbytes, ShouldEqual, len(body))
+		So(partSizes, ShouldResemble, []int{s3.DefaultMultipartPartSize, s3.DefaultMultipartPartSize, 1024})
+		So(bytes.Equal(written.Bytes(), body), ShouldBeTrue)
+
+		partSizes = nil
+		written.Reset()
+
+		nbytes, err = imgStore.PutBlobChunk(testImage, "uuid", int64(fileWriterSize),
+			int64(fileWriterSize+len(body)), bytes.NewReader(body))
+		So(err, ShouldBeNil)
+		So(nbytes, ShouldEqual, len(body))
+		So(partSizes, ShouldResemble, []int{s3.DefaultMultipartPartSize, s3.DefaultMultipartPartSize, 1024})
+		So(bytes.Equal(written.Bytes(), body), ShouldBeTrue)
+	})
+
+	Convey("Full blob uploads are streamed to the storage driver as a multipart upload", t, func() {
+		var partSizes []int
+
+		committed, cancelled := false, false
+		written := new(bytes.Buffer)
+
+		imgStore := createMockStorage(testDir, tdir, false, &StorageDriverMock{
+			WriterFn: func(ctx context.Context, path string, isAppend bool) (driver.FileWriter, error) {
+				return &FileWriterMock{
+					WriteFn: func(b []byte) (int, error) {
+						partSizes = append(partSizes, len(b))
+
+						return written.Write(b)
+					},
+					CommitFn: func() error {
+						committed = true
+
+						return nil
+					},
+					CancelFn: func() error {
+						cancelled = true
+
+						return nil
+					},
+				}, nil
+			},
+		})
+
+		body := make([]byte, s3.DefaultMultipartPartSize+1024)
+		for i := range body {
+			body[i] = byte(i % 251)
+		}
+
+		_, nbytes, err := imgStore.FullBlobUpload(testImage, bytes.NewReader(body), godigest.FromBytes(body))
+		So(err, ShouldBeNil)
+		So(nbytes, ShouldEqual, len(body))
+		So(partSizes, ShouldResemble, []int{s3.DefaultMultipartPartSize, 1024})
+		So(bytes.Equal(written.Bytes(), body), ShouldBeTrue)
+		So(committed, ShouldBeTrue)
+		So(cancelled, ShouldBeFalse)
+
+		committed = false
+
+		_, _, err = imgStore.FullBlobUpload(testImage, bytes.NewReader(body), godigest.FromBytes([]byte("other")))
+		So(errors.Is(err, zerr.ErrBadBlobDigest), ShouldBeTrue)
+		So(committed, ShouldBeFalse)
+		So(cancelled, ShouldBeTrue)
+
+		cancelled = false
+
+		_, _, err = imgStore.FullBlobUpload(testImage, iotest.ErrReader(errCache), godigest.FromBytes(body))
+		So(err, ShouldEqual, errCache)
+		So(committed, ShouldBeFalse)
+		So(cancelled, ShouldBeTrue)
+	})
+
+	Convey("Read and write errors are reported", t, func() {
+		writes := 0
+
+		imgStore := createMockStorage(testDir, tdir, false, &StorageDriverMock{
+			WriterFn: func(ctx context.Context, path string, isAppend bool) (driver.FileWriter, error) {
+				return &FileWriterMock{WriteFn: func(b []byte) (int, error) {
+					writes++
+					if writes > 1 {
+						return 0, errS3
+					}
+
+					return len(b), nil
+				}}, nil
+			},
+		})
+
+		body := io.MultiReader(bytes.NewReader(make([]byte, 1024)), iotest.ErrReader(errCache))
+
+		_, err := imgStore.PutBlobChunkStreamed(testImage, "uuid", body)
+		So(err, ShouldEqual, errCache)
+
+		writes = 0
+
+		_, err = imgStore.PutBlobChunkStreamed(testImage, "uuid",
+			bytes.NewReader(make([]byte, 4*s3.DefaultMultipartPartSize)))
+		So(err, ShouldEqual, errS3)
+		So(writes, ShouldEqual, 2)
+	})
+
+	Convey("Parts are uploaded concurrently by part writers", t, func() {
+		var (
+			lock              sync.Mutex
+			running, maxCalls int
+		)
+
+		uploaded := map[int][]byte{}
+		next := 3
+
+		imgStore := createMockStorage(testDir, tdir, false, &StorageDriverMock{
+			WriterFn: func(ctx context.Context, path string, isAppend bool) (driver.FileWriter, error) {
+				return &PartWriterMock{
+					NextPartFn: func() (int, bool) { return next, true },
+					WritePartFn: func(ctx context.Context, number int, data []byte) error {
+						lock.Lock()
+						running++
+						if running > maxCalls {
+							maxCalls = running
+						}
+						uploaded[number] = append([]byte{}, data...)
+						lock.Unlock()
+
+						time.Sleep(50 * time.Millisecond)
+
+						lock.Lock()
+						running--
+						lock.Unlock()
+
+						return nil
+					},
+				}, nil
+			},
+		})
+
+		body := make([]byte, 6*s3.DefaultMultipartPartSize+1024)
+		for i := range body {
+			body[i] = byte(i % 251)
+		}
+
+		nbytes, err := imgStore.PutBlobChunkStreamed(testImage, "uuid", bytes.NewReader(body))
+		So(err, ShouldBeNil)
+		So(nbytes, ShouldEqual, len(body))
+		So(maxCalls, ShouldBeGreaterThan, 1)
+		So(maxCalls, ShouldBeLessThanOrEqualTo, s3.DefaultMultipartConcurrency)
+		So(len(uploaded), ShouldEqual, 7)
+
+		for i := 0; i < 7; i++ {
+			end := (i + 1) * s3.DefaultMultipartPartSize
+			if end > len(body) {
+				end = len(body)
+			}
+
+			So(bytes.Equal(uploaded[next+i], body[i*s3.DefaultMultipartPartSize:end]), ShouldBeTrue)
+		}
+
+		// parts following a small part are written in order through the driver writer
+		next = 0
+		written := new(bytes.Buffer)
+
+		imgStore = createMockStorage(testDir, tdir, false, &StorageDriverMock{
+			WriterFn: func(ctx context.Context, path string, isAppend bool) (driver.FileWriter, error) {
+				return &PartWriterMock{
+					FileWriterMock: FileWriterMock{WriteFn: written.Write},
+					NextPartFn:     func() (int, bool) { return 2, false },
+					WritePartFn: func(ctx context.Context, number int, data []byte) error {
+						next = number
+
+						return nil
+					},
+				}, nil
+			},
+		})
+
+		nbytes, err = imgStore.PutBlobChunkStreamed(testImage, "uuid", bytes.NewReader(body))
+		So(err, ShouldBeNil)
+		So(nbytes, ShouldEqual, len(body))
+		So(next, ShouldEqual, 0)
+		So(bytes.Equal(written.Bytes(), body), ShouldBeTrue)
+	})
+
+	Convey("Failed uploads cancel the other parts and stop reading the body", t, func() {
+		var cancelled int32
+
+		imgStore := createMockStorage(testDir, tdir, false, &StorageDriverMock{
+			WriterFn: func(ctx context.Context, path string, isAppend bool) (driver.FileWriter, error) {
+				return &PartWriterMock{
+					WritePartFn: func(ctx context.Context, number int, data []byte) error {
+						if number == 2 {
+							return errS3
+						}
+
+						select {
+						case <-ctx.Done():
+							atomic.AddInt32(&cancelled, 1)
+
+							return ctx.Err()
+						case <-time.After(5 * time.Second):
+							return nil
+						}
+					},
+				}, nil
+			},
+		})
+
+		body := &countingReader{reader: bytes.NewReader(make([]byte, 100*s3.DefaultMultipartPartSize))}
+
+		_, err := imgStore.PutBlobChunkStreamed(testImage, "uuid", body)
+		So(err, ShouldEqual, errS3)
+		So(atomic.LoadInt32(&cancelled), ShouldBeGreaterThan, 0)
+
+		reads := atomic.LoadInt32(&body.reads)
+
+		time.Sleep(100 * time.Millisecond)
+		So(atomic.LoadInt32(&body.reads), ShouldEqual, reads)
+
+		// same when writing through the driver writer
+		body = &countingReader{reader: bytes.NewReader(make([]byte, 100*s3.DefaultMultipartPartSize))}
+
+		imgStore = createMockStorage(testDir, tdir, false, &StorageDriverMock{
+			WriterFn: func(ctx context.Context, path string, isAppend bool) (driver.FileWriter, error) {
+				return &FileWriterMock{WriteFn: func(b []byte) (int, error) {
+					return 0, errS3
+				}}, nil
+			},
+		})
+
+		_, err = imgStore.PutBlobChunkStreamed(testImage, "uuid", body)
+		So(err, ShouldEqual, errS3)
+
+		reads = atomic.LoadInt32(&body.reads)
+
+		time.Sleep(100 * time.Millisecond)
+		So(atomic.LoadInt32(&body.reads), ShouldEqual, reads)
+	})
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/opencontainers/umoci/oci/casext"
 
 	"zotregistry.io/zot/errors"
+	zcommon "zotregistry.io/zot/pkg/common"
+	common "zotregistry.io/zot/pkg/storage/common"
 	storageTypes "zotregistry.io/zot/pkg/storage/types"
 )
 
@@ -95,6 +97,8 @@ func CheckRepo(imageName string, imgStore storageTypes.ImageStore) ([]ScrubImage
 
 	ctxUmoci := context.Background()
 
+	common.RegisterMediaTypeParsers()
+
 	oci, err := umoci.OpenLayout(dir)
 	if err != nil {
 		return results, err
@@ -119,7 +123,7 @@ func CheckRepo(imageName string, imgStore storageTypes.ImageStore) ([]ScrubImage
 	listOfManifests := []ispec.Descriptor{}
 
 	for _, manifest := range index.Manifests {
-		if manifest.MediaType == ispec.MediaTypeImageIndex {
+		if zcommon.IsImageIndex(manifest.MediaType) {
 			buf, err := os.ReadFile(path.Join(dir, "blobs", manifest.Digest.Algorithm().String(), manifest.Digest.Encoded()))
 			if err != nil {
 				tagName := manifest.Annotations[ispec.AnnotationRefName]
@@ -129,6 +133,15 @@ func CheckRepo(imageName string, imgStore storageTypes.ImageStore) ([]ScrubImage
 				continue
 			}
 
+			// the manifests of the index are checked by umoci, not the index itself
+			if godigest.FromBytes(buf) != manifest.Digest {
+				tagName := manifest.Annotations[ispec.AnnotationRefName]
+				imgRes := getResult(imageName, tagName, errors.ErrBadBlobDigest)
+				results = append(results, imgRes)
+
+				continue
+			}
+
 			var idx ispec.Index
 			if err := json.Unmarshal(buf, &idx); err != nil {
 				tagName := manifest.Annotations[ispec.AnnotationRefName]
@@ -139,7 +152,7 @@ func CheckRepo(imageName string, imgStore storageTypes.ImageStore) ([]ScrubImage
 			}
 
 			listOfManifests = append(listOfManifests, idx.Manifests...)
-		} else if manifest.MediaType == ispec.MediaTypeImageManifest {
+		} else if zcommon.IsImageManifest(manifest.MediaType) || zcommon.IsSchema1Manifest(manifest.MediaType) {
 			listOfManifests = append(listOfManifests, manifest)
 		}
 	}
@@ -154,9 +167,15 @@ func CheckRepo(imageName string, imgStore storageTypes.ImageStore) ([]ScrubImage
 }
 
 func CheckIntegrity(ctx context.Context, imageName, tagName string, oci casext.Engine, manifest ispec.Descriptor, dir string) ScrubImageResult { //nolint: lll
-	// check manifest and config
-	if _, err := umoci.Stat(ctx, oci, manifest); err != nil {
-		return getResult(imageName, tagName, err)
+	// check manifest and config, schema1 manifests have no config
+	if !zcommon.IsSchema1Manifest(manifest.MediaType) {
+		// umoci only stats oci manifests, the docker ones have the same layout
+		statDesc := manifest
+		statDesc.MediaType = ispec.MediaTypeImageManifest
+
+		if _, err := umoci.Stat(ctx, oci, statDesc); err != nil {
+			return getResult(imageName, tagName, err)
+		}
 	}
 
 	// check layers
@@ -174,7 +193,19 @@ func CheckLayers(imageName, tagName, dir string, manifest ispec.Descriptor) Scru
 	}
 
 	var man ispec.Manifest
-	if err := json.Unmarshal(buf, &man); err != nil {
+
+	if zcommon.IsSchema1Manifest(manifest.MediaType) {
+		var schema1Manifest zcommon.Schema1Manifest
+		if err := json.Unmarshal(buf, &schema1Manifest); err != nil {
+			imageRes = getResult(imageName, tagName, err)
+
+			return imageRes
+		}
+
+		for _, layer := range schema1Manifest.Layers() {
+			man.Layers = append(man.Layers, ispec.Descriptor{Digest: layer})
+		}
+	} else if err := json.Unmarshal(buf, &man); err != nil {
 		imageRes = getResult(imageName, tagName, err)
 
 		return imageRes
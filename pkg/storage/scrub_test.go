@@ -13,6 +13,7 @@ import (
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	. "github.com/smartystreets/goconvey/convey"
 
+	zerr "zotregistry.io/zot/errors"
 	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/storage"
@@ -305,6 +306,23 @@ func TestCheckAllBlobsIntegrity(t *testing.T) {
 			actual = strings.TrimSpace(str)
 			So(actual, ShouldContainSubstring, "IMAGE NAME TAG STATUS ERROR")
 			So(actual, ShouldContainSubstring, "test affected")
+
+			// a valid index which isn't the one with the digest of the blob
+			index.Annotations = map[string]string{"tampered": "true"}
+
+			indexBlob, err = json.Marshal(index)
+			So(err, ShouldBeNil)
+
+			err = os.WriteFile(indexFile, indexBlob, 0o600)
+			So(err, ShouldBeNil)
+
+			res, err = storeCtlr.CheckAllBlobsIntegrity()
+			So(err, ShouldBeNil)
+			So(res.ScrubResults, ShouldContain, storage.ScrubImageResult{
+				ImageName: repoName,
+				Status:    "affected",
+				Error:     zerr.ErrBadBlobDigest.Error(),
+			})
 		})
 
 		Convey("Manifest not found", func() {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/docker/distribution/registry/storage/driver"
 	"github.com/docker/distribution/registry/storage/driver/factory"
 	"github.com/gobwas/glob"
 	notreg "github.com/notaryproject/notation-go/registry"
@@ -18,7 +19,9 @@ import (
 	"zotregistry.io/zot/pkg/log"
 	common "zotregistry.io/zot/pkg/storage/common"
 	"zotregistry.io/zot/pkg/storage/constants"
+	"zotregistry.io/zot/pkg/storage/gcs"
 	"zotregistry.io/zot/pkg/storage/local"
+	"zotregistry.io/zot/pkg/storage/retention"
 	"zotregistry.io/zot/pkg/storage/s3"
 	storageTypes "zotregistry.io/zot/pkg/storage/types"
 )
@@ -35,43 +38,59 @@ func New(config *config.Config, linter common.Lint, metrics monitoring.MetricSer
 		return storeController, errors.ErrImgStoreNotFound
 	}
 
-	// no need to validate hard links work on s3
-	if config.Storage.Dedupe && config.Storage.StorageDriver == nil {
-		err := local.ValidateHardLink(config.Storage.RootDirectory)
-		if err != nil {
-			log.Warn().Msg("input storage root directory filesystem does not supports hardlinking," +
-				"disabling dedupe functionality")
-
-			config.Storage.Dedupe = false
-		}
-	}
-
 	var defaultStore storageTypes.ImageStore
 
 	if config.Storage.StorageDriver == nil {
+		nfs, err := validateLocalStorage(&config.Storage.StorageConfig, log)
+		if err != nil {
+			return storeController, err
+		}
+
 		// false positive lint - linter does not implement Lint method
 		//nolint:typecheck,contextcheck
 		defaultStore = local.NewImageStore(config.Storage.RootDirectory,
 			config.Storage.GC, config.Storage.GCDelay,
 			config.Storage.Dedupe, config.Storage.Commit, log, metrics, linter,
-			CreateCacheDatabaseDriver(config.Storage.StorageConfig, log),
+			createMetricsCacheDriver(config.Storage.StorageConfig, metrics, log),
 		)
+
+		if defaultStore != nil {
+			defaultStore.SetCommitPolicy(config.Storage.GetCommitPolicy())
+			defaultStore.SetNFSMode(nfs)
+			defaultStore.SetDedupeStrategy(config.Storage.GetDedupeStrategy())
+			defaultStore.SetSharedBlobs(config.Storage.SharedBlobs)
+			defaultStore.SetGCVerifyPercent(config.Storage.GCVerifyPercent)
+			defaultStore.SetGCBatchSize(config.Storage.GCBatchSize)
+			defaultStore.SetIOOptions(getIOOptions(config.Storage.StorageConfig))
+
+			if err := setRetention(defaultStore, config.Storage.StorageConfig, log); err != nil {
+				return storeController, err
+			}
+		}
 	} else {
 		storeName := fmt.Sprintf("%v", config.Storage.StorageDriver["name"])
-		if storeName != constants.S3StorageDriverName {
+		if !isObjectStorageDriver(storeName) {
 			log.Fatal().Err(errors.ErrBadConfig).Str("storageDriver", storeName).
 				Msg("unsupported storage driver")
 		}
 		// Init a Storager from connection string.
-		store, err := factory.Create(storeName, config.Storage.StorageDriver)
+		store, err := createStorageDriver(storeName, config.Storage.StorageDriver)
+		if err != nil {
+			log.Error().Err(err).Str("rootDir", config.Storage.RootDirectory).Str("storageDriver", storeName).
+				Msg("unable to create storage driver")
+
+			return storeController, err
+		}
+
+		multipart, err := s3.GetMultipartConfig(config.Storage.StorageDriver)
 		if err != nil {
-			log.Error().Err(err).Str("rootDir", config.Storage.RootDirectory).Msg("unable to create s3 service")
+			log.Error().Err(err).Str("rootDir", config.Storage.RootDirectory).Msg("invalid multipart config")
 
 			return storeController, err
 		}
 
-		/* in the case of s3 config.Storage.RootDirectory is used for caching blobs locally and
-		config.Storage.StorageDriver["rootdirectory"] is the actual rootDir in s3 */
+		/* in the case of s3 and gcs config.Storage.RootDirectory is used for caching blobs locally and
+		config.Storage.StorageDriver["rootdirectory"] is the actual rootDir in the bucket */
 		rootDir := "/"
 		if config.Storage.StorageDriver["rootdirectory"] != nil {
 			rootDir = fmt.Sprintf("%v", config.Storage.StorageDriver["rootdirectory"])
@@ -81,8 +100,8 @@ func New(config *config.Config, linter common.Lint, metrics monitoring.MetricSer
 		//nolint: typecheck,contextcheck
 		defaultStore = s3.NewImageStore(rootDir, config.Storage.RootDirectory,
 			config.Storage.GC, config.Storage.GCDelay, config.Storage.Dedupe,
-			config.Storage.Commit, log, metrics, linter, store,
-			CreateCacheDatabaseDriver(config.Storage.StorageConfig, log))
+			config.Storage.Commit, log, metrics, linter, store, multipart,
+			createMetricsCacheDriver(config.Storage.StorageConfig, metrics, log))
 	}
 
 	storeController.DefaultStore = defaultStore
@@ -106,6 +125,90 @@ func New(config *config.Config, linter common.Lint, metrics monitoring.MetricSer
 	return storeController, nil
 }
 
+/*
+validateLocalStorage checks the filesystem of a local root directory supports the configured features.
+It returns whether the NFS mode is used, configured or detected, in which case blobs are copied instead of
+hard linked. Otherwise dedupe is disabled if hard links don't work, and blobs are hard linked if reflinks
+don't.
+*/
+func validateLocalStorage(storageConfig *config.StorageConfig, log log.Logger) (bool, error) {
+	// blobs are stored once in the shared pool, there's nothing to dedupe
+	if storageConfig.SharedBlobs && storageConfig.Dedupe {
+		log.Info().Str("rootDir", storageConfig.RootDirectory).
+			Msg("using the shared blobs layout, disabling dedupe functionality")
+
+		storageConfig.Dedupe = false
+	}
+
+	nfs := local.IsNFS(storageConfig.RootDirectory)
+	if storageConfig.NFS != nil {
+		nfs = *storageConfig.NFS
+	}
+
+	if nfs {
+		if err := local.ValidateNFS(storageConfig.RootDirectory); err != nil {
+			log.Error().Err(err).Str("rootDir", storageConfig.RootDirectory).
+				Msg("storage root directory doesn't support the operations needed by the NFS mode")
+
+			return false, err
+		}
+
+		log.Info().Str("rootDir", storageConfig.RootDirectory).
+			Msg("using the NFS mode, writes are serialized with a lock file and blobs are copied instead of linked")
+
+		return true, nil
+	}
+
+	if storageConfig.Dedupe {
+		if err := local.ValidateHardLink(storageConfig.RootDirectory); err != nil {
+			log.Warn().Msg("input storage root directory filesystem does not supports hardlinking, " +
+				"disabling dedupe functionality")
+
+			storageConfig.Dedupe = false
+		}
+	}
+
+	if storageConfig.Dedupe && storageConfig.GetDedupeStrategy() == constants.DedupeStrategyReflink {
+		if err := local.ValidateReflink(storageConfig.RootDirectory); err != nil {
+			log.Warn().Err(err).Str("rootDir", storageConfig.RootDirectory).
+				Msg("storage root directory filesystem does not support reflinks, deduping blobs with hard links")
+
+			storageConfig.DedupeStrategy = constants.DedupeStrategyHardLink
+		}
+	}
+
+	return false, nil
+}
+
+// getIOOptions returns the options tuning how the local storage writes and reads blobs.
+func getIOOptions(storageConfig config.StorageConfig) storageTypes.IOOptions {
+	ioConfig := storageConfig.GetIO()
+
+	return storageTypes.IOOptions{
+		WriteBufferSize: ioConfig.WriteBufferSize,
+		DirectIO:        ioConfig.DirectIO,
+		Preallocate:     ioConfig.Preallocate,
+		LargeBlobSize:   ioConfig.LargeBlobSize,
+		ReadAhead:       ioConfig.ReadAhead,
+	}
+}
+
+// setRetention sets the retention policies of the storage config on the store, if there are any.
+func setRetention(imgStore storageTypes.ImageStore, storageConfig config.StorageConfig, log log.Logger) error {
+	policies, err := retention.New(storageConfig.Retention)
+	if err != nil {
+		log.Error().Err(err).Str("rootDir", storageConfig.RootDirectory).Msg("invalid retention policy")
+
+		return err
+	}
+
+	if policies != nil {
+		imgStore.SetRetention(policies)
+	}
+
+	return nil
+}
+
 func getSubStore(cfg *config.Config, subPaths map[string]config.StorageConfig,
 	linter common.Lint, metrics monitoring.MetricServer, log log.Logger,
 ) (map[string]storageTypes.ImageStore, error) {
@@ -115,18 +218,12 @@ func getSubStore(cfg *config.Config, subPaths map[string]config.StorageConfig,
 
 	// creating image store per subpaths
 	for route, storageConfig := range subPaths {
-		// no need to validate hard links work on s3
-		if storageConfig.Dedupe && storageConfig.StorageDriver == nil {
-			err := local.ValidateHardLink(storageConfig.RootDirectory)
+		if storageConfig.StorageDriver == nil {
+			nfs, err := validateLocalStorage(&storageConfig, log)
 			if err != nil {
-				log.Warn().Msg("input storage root directory filesystem does not supports hardlinking, " +
-					"disabling dedupe functionality")
-
-				storageConfig.Dedupe = false
+				return nil, err
 			}
-		}
 
-		if storageConfig.StorageDriver == nil {
 			// Compare if subpath root dir is same as default root dir
 			isSame, _ := config.SameFile(cfg.Storage.RootDirectory, storageConfig.RootDirectory)
 
@@ -154,27 +251,49 @@ func getSubStore(cfg *config.Config, subPaths map[string]config.StorageConfig,
 			if isUnique {
 				imgStoreMap[storageConfig.RootDirectory] = local.NewImageStore(storageConfig.RootDirectory,
 					storageConfig.GC, storageConfig.GCDelay, storageConfig.Dedupe,
-					storageConfig.Commit, log, metrics, linter, CreateCacheDatabaseDriver(storageConfig, log))
+					storageConfig.Commit, log, metrics, linter, createMetricsCacheDriver(storageConfig, metrics, log))
+
+				if imgStoreMap[storageConfig.RootDirectory] != nil {
+					imgStoreMap[storageConfig.RootDirectory].SetCommitPolicy(storageConfig.GetCommitPolicy())
+					imgStoreMap[storageConfig.RootDirectory].SetNFSMode(nfs)
+					imgStoreMap[storageConfig.RootDirectory].SetDedupeStrategy(storageConfig.GetDedupeStrategy())
+					imgStoreMap[storageConfig.RootDirectory].SetSharedBlobs(storageConfig.SharedBlobs)
+					imgStoreMap[storageConfig.RootDirectory].SetGCVerifyPercent(storageConfig.GCVerifyPercent)
+					imgStoreMap[storageConfig.RootDirectory].SetGCBatchSize(storageConfig.GCBatchSize)
+					imgStoreMap[storageConfig.RootDirectory].SetIOOptions(getIOOptions(storageConfig))
+
+					if err := setRetention(imgStoreMap[storageConfig.RootDirectory], storageConfig, log); err != nil {
+						return nil, err
+					}
+				}
 
 				subImageStore[route] = imgStoreMap[storageConfig.RootDirectory]
 			}
 		} else {
 			storeName := fmt.Sprintf("%v", storageConfig.StorageDriver["name"])
-			if storeName != constants.S3StorageDriverName {
+			if !isObjectStorageDriver(storeName) {
 				log.Fatal().Err(errors.ErrBadConfig).Str("storageDriver", storeName).
 					Msg("unsupported storage driver")
 			}
 
 			// Init a Storager from connection string.
-			store, err := factory.Create(storeName, storageConfig.StorageDriver)
+			store, err := createStorageDriver(storeName, storageConfig.StorageDriver)
+			if err != nil {
+				log.Error().Err(err).Str("rootDir", storageConfig.RootDirectory).Str("storageDriver", storeName).
+					Msg("unable to create storage driver")
+
+				return nil, err
+			}
+
+			multipart, err := s3.GetMultipartConfig(storageConfig.StorageDriver)
 			if err != nil {
-				log.Error().Err(err).Str("rootDir", storageConfig.RootDirectory).Msg("Unable to create s3 service")
+				log.Error().Err(err).Str("rootDir", storageConfig.RootDirectory).Msg("invalid multipart config")
 
 				return nil, err
 			}
 
-			/* in the case of s3 c.Config.Storage.RootDirectory is used for caching blobs locally and
-			c.Config.Storage.StorageDriver["rootdirectory"] is the actual rootDir in s3 */
+			/* in the case of s3 and gcs c.Config.Storage.RootDirectory is used for caching blobs locally and
+			c.Config.Storage.StorageDriver["rootdirectory"] is the actual rootDir in the bucket */
 			rootDir := "/"
 			if cfg.Storage.StorageDriver["rootdirectory"] != nil {
 				rootDir = fmt.Sprintf("%v", cfg.Storage.StorageDriver["rootdirectory"])
@@ -184,8 +303,8 @@ func getSubStore(cfg *config.Config, subPaths map[string]config.StorageConfig,
 			//nolint: typecheck
 			subImageStore[route] = s3.NewImageStore(rootDir, storageConfig.RootDirectory,
 				storageConfig.GC, storageConfig.GCDelay,
-				storageConfig.Dedupe, storageConfig.Commit, log, metrics, linter, store,
-				CreateCacheDatabaseDriver(storageConfig, log),
+				storageConfig.Dedupe, storageConfig.Commit, log, metrics, linter, store, multipart,
+				createMetricsCacheDriver(storageConfig, metrics, log),
 			)
 		}
 	}
@@ -193,6 +312,30 @@ func getSubStore(cfg *config.Config, subPaths map[string]config.StorageConfig,
 	return subImageStore, nil
 }
 
+func isObjectStorageDriver(storeName string) bool {
+	return storeName == constants.S3StorageDriverName || storeName == constants.GCSStorageDriverName
+}
+
+// createStorageDriver creates the driver of an object storage image store, the gcs driver is zot's own
+// and isn't registered with the distribution driver factory, the s3 one uploads multipart parts concurrently.
+func createStorageDriver(storeName string, parameters map[string]interface{}) (driver.StorageDriver, error) {
+	if storeName == constants.GCSStorageDriverName {
+		store, err := gcs.FromParameters(parameters)
+		if err != nil {
+			return nil, err
+		}
+
+		return store, nil
+	}
+
+	store, err := factory.Create(storeName, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.NewPartDriver(store, parameters)
+}
+
 func compareImageStore(root1, root2 string) bool {
 	isSameFile, err := config.SameFile(root1, root2)
 	// This error is path error that means either of root directory doesn't exist, in that case do string match
@@ -232,16 +375,28 @@ func CheckIsImageSignature(repoName string, manifestBlob []byte, reference strin
 	// check cosign
 	cosignTagRule := glob.MustCompile("sha256-*.sig")
 
-	if tag := reference; cosignTagRule.Match(reference) {
-		prefixLen := len("sha256-")
-		digestLen := 64
-		signedImageManifestDigestEncoded := tag[prefixLen : prefixLen+digestLen]
+	if cosignTagRule.Match(reference) {
+		return true, CosignType, getCosignTagSubject(reference), nil
+	}
+
+	return false, "", "", nil
+}
 
-		signedImageManifestDigest := godigest.NewDigestFromEncoded(godigest.SHA256,
-			signedImageManifestDigestEncoded)
+// CheckIsCosignAttestation checks if the given reference is the tag under which cosign pushes the
+// attestations of an image, returning the digest of the attested image.
+func CheckIsCosignAttestation(reference string) (bool, godigest.Digest) {
+	cosignTagRule := glob.MustCompile("sha256-*.att")
 
-		return true, CosignType, signedImageManifestDigest, nil
+	if !cosignTagRule.Match(reference) || len(reference) != len("sha256-")+64+len(".att") {
+		return false, ""
 	}
 
-	return false, "", "", nil
+	return true, getCosignTagSubject(reference)
+}
+
+func getCosignTagSubject(tag string) godigest.Digest {
+	prefixLen := len("sha256-")
+	digestLen := 64
+
+	return godigest.NewDigestFromEncoded(godigest.SHA256, tag[prefixLen:prefixLen+digestLen])
 }
@@ -2,8 +2,10 @@ package storage
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"zotregistry.io/zot/pkg/storage/inflight"
 	storageTypes "zotregistry.io/zot/pkg/storage/types"
 )
 
@@ -15,6 +17,8 @@ const (
 type StoreController struct {
 	DefaultStore storageTypes.ImageStore
 	SubStore     map[string]storageTypes.ImageStore
+	// content written before the manifests referencing it, e.g. by sync, see SetInFlight
+	InFlight *inflight.References
 }
 
 func GetRoutePrefix(name string) string {
@@ -45,3 +49,88 @@ func (sc StoreController) GetImageStore(name string) storageTypes.ImageStore {
 
 	return sc.DefaultStore
 }
+
+// SetPinnedImages sets the source of pinned manifests on all image stores, pinned manifests are skipped by gc.
+func (sc StoreController) SetPinnedImages(pins storageTypes.PinnedImages) {
+	for _, imgStore := range sc.UniqueImageStores() {
+		imgStore.SetPinnedImages(pins)
+	}
+}
+
+// SetTagRemovals sets on all image stores what is told about the tags removed by gc.
+func (sc StoreController) SetTagRemovals(removals storageTypes.TagRemovals) {
+	for _, imgStore := range sc.UniqueImageStores() {
+		imgStore.SetTagRemovals(removals)
+	}
+}
+
+// SetImmutableTags sets the immutable tags on all image stores, they reject moving or deleting them.
+func (sc StoreController) SetImmutableTags(immutableTags storageTypes.ImmutableTags) {
+	for _, imgStore := range sc.UniqueImageStores() {
+		imgStore.SetImmutableTags(immutableTags)
+	}
+}
+
+// SetLeases sets the storage leases on all image stores, gc is paused while a lease is held.
+func (sc StoreController) SetLeases(leases storageTypes.Leases) {
+	for _, imgStore := range sc.UniqueImageStores() {
+		imgStore.SetLeases(leases)
+	}
+}
+
+// SetInFlight sets the in-flight references on all image stores, gc doesn't remove the content they hold.
+func (sc StoreController) SetInFlight(inFlight storageTypes.InFlight) {
+	for _, imgStore := range sc.UniqueImageStores() {
+		imgStore.SetInFlight(inFlight)
+	}
+}
+
+// UniqueImageStores returns the default store and the substores, each image store once: substores with the same
+// config share the same image store, the ones with the same root directory are returned once.
+func (sc StoreController) UniqueImageStores() []storageTypes.ImageStore {
+	routes := make([]string, 0, len(sc.SubStore))
+	for route := range sc.SubStore {
+		routes = append(routes, route)
+	}
+
+	// in the same order every time
+	sort.Strings(routes)
+
+	candidates := []storageTypes.ImageStore{sc.DefaultStore}
+	for _, route := range routes {
+		candidates = append(candidates, sc.SubStore[route])
+	}
+
+	imgStores := []storageTypes.ImageStore{}
+	rootDirs := map[string]bool{}
+
+	for _, imgStore := range candidates {
+		if imgStore == nil || rootDirs[imgStore.RootDir()] {
+			continue
+		}
+
+		rootDirs[imgStore.RootDir()] = true
+
+		imgStores = append(imgStores, imgStore)
+	}
+
+	return imgStores
+}
+
+// GetRepositories lists the repos of all image stores, sorted.
+func (sc StoreController) GetRepositories() ([]string, error) {
+	repos := []string{}
+
+	for _, imgStore := range sc.UniqueImageStores() {
+		storeRepos, err := imgStore.GetRepositories()
+		if err != nil {
+			return nil, err
+		}
+
+		repos = append(repos, storeRepos...)
+	}
+
+	sort.Strings(repos)
+
+	return repos, nil
+}
@@ -26,6 +26,8 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 	"gopkg.in/resty.v1"
 
+	zerr "zotregistry.io/zot/errors"
+	"zotregistry.io/zot/pkg/api/config"
 	"zotregistry.io/zot/pkg/extensions/monitoring"
 	"zotregistry.io/zot/pkg/log"
 	"zotregistry.io/zot/pkg/storage"
@@ -88,7 +90,7 @@ func createObjectsStore(rootDir string, cacheDir string) (driver.StorageDriver,
 	}, log)
 
 	il := s3.NewImageStore(rootDir, cacheDir, false, storageConstants.DefaultGCDelay,
-		true, false, log, metrics, nil, store, cacheDriver,
+		true, false, log, metrics, nil, store, s3.MultipartConfig{}, cacheDriver,
 	)
 
 	return store, il, err
@@ -736,7 +738,7 @@ func TestMandatoryAnnotations(t *testing.T) {
 						LintFn: func(repo string, manifestDigest godigest.Digest, imageStore storageTypes.ImageStore) (bool, error) {
 							return false, nil
 						},
-					}, store, nil)
+					}, store, s3.MultipartConfig{}, nil)
 
 				defer cleanupStorage(store, testDir)
 			} else {
@@ -804,7 +806,7 @@ func TestMandatoryAnnotations(t *testing.T) {
 									//nolint: goerr113
 									return false, errors.New("linter error")
 								},
-							}, store, nil)
+							}, store, s3.MultipartConfig{}, nil)
 					} else {
 						cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
 							RootDir:     tdir,
@@ -905,6 +907,319 @@ func TestStorageHandler(t *testing.T) {
 	}
 }
 
+func TestStorageConsistency(t *testing.T) {
+	for _, testcase := range testCases {
+		testcase := testcase
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			var imgStore storageTypes.ImageStore
+			var rootDir string
+			var removeFile func(filePath string) error
+			var writeFile func(filePath string, content []byte) error
+
+			if testcase.storageType == "s3" {
+				skipIt(t)
+
+				uuid, err := guuid.NewV4()
+				if err != nil {
+					panic(err)
+				}
+
+				rootDir = path.Join("/oci-repo-test", uuid.String())
+
+				var store driver.StorageDriver
+				store, imgStore, _ = createObjectsStore(rootDir, t.TempDir())
+				defer cleanupStorage(store, rootDir)
+
+				removeFile = func(filePath string) error {
+					return store.Delete(context.Background(), filePath)
+				}
+				writeFile = func(filePath string, content []byte) error {
+					return store.PutContent(context.Background(), filePath, content)
+				}
+			} else {
+				rootDir = t.TempDir()
+
+				log := log.NewLogger("debug", "")
+				metrics := monitoring.NewMetricsServer(false, log)
+
+				imgStore = local.NewImageStore(rootDir, false, storageConstants.DefaultGCDelay,
+					false, false, log, metrics, nil, nil)
+
+				removeFile = os.Remove
+				writeFile = func(filePath string, content []byte) error {
+					if err := os.MkdirAll(path.Dir(filePath), storageConstants.DefaultDirPerms); err != nil {
+						return err
+					}
+
+					return os.WriteFile(filePath, content, storageConstants.DefaultFilePerms)
+				}
+			}
+
+			Convey("Check and repair storage consistency", t, func() {
+				storeController := storage.StoreController{DefaultStore: imgStore}
+
+				image, err := test.GetRandomImage("1.0")
+				So(err, ShouldBeNil)
+
+				err = test.WriteImageToFileSystem(image, "repo", storeController)
+				So(err, ShouldBeNil)
+
+				multiarchImage, err := test.GetRandomMultiarchImage("2.0")
+				So(err, ShouldBeNil)
+
+				err = test.WriteMultiArchImageToFileSystem(multiarchImage, "multiarch", storeController)
+				So(err, ShouldBeNil)
+
+				issues, err := imgStore.CheckConsistency(false)
+				So(err, ShouldBeNil)
+				So(issues, ShouldBeEmpty)
+
+				err = removeFile(path.Join(rootDir, "repo", ispec.ImageLayoutFile))
+				So(err, ShouldBeNil)
+
+				err = removeFile(path.Join(rootDir, "repo", "index.json"))
+				So(err, ShouldBeNil)
+
+				err = removeFile(path.Join(rootDir, "multiarch", "index.json"))
+				So(err, ShouldBeNil)
+
+				err = writeFile(path.Join(rootDir, "orphan", storageConstants.BlobUploadDir, "upload"), []byte("upload"))
+				So(err, ShouldBeNil)
+
+				// report only
+				issues, err = imgStore.CheckConsistency(false)
+				So(err, ShouldBeNil)
+				So(len(issues), ShouldEqual, 4)
+
+				for _, issue := range issues {
+					So(issue.Repaired, ShouldBeFalse)
+				}
+
+				repos, err := imgStore.GetRepositories()
+				So(err, ShouldBeNil)
+				So(repos, ShouldBeEmpty)
+
+				// repair
+				issues, err = imgStore.CheckConsistency(true)
+				So(err, ShouldBeNil)
+				So(len(issues), ShouldEqual, 4)
+
+				for _, issue := range issues {
+					So(issue.Repaired, ShouldBeTrue)
+				}
+
+				repos, err = imgStore.GetRepositories()
+				So(err, ShouldBeNil)
+				So(repos, ShouldResemble, []string{"multiarch", "repo"})
+
+				// the images are back, without their tags
+				imageDigest, err := image.Digest()
+				So(err, ShouldBeNil)
+
+				_, _, _, err = imgStore.GetImageManifest("repo", imageDigest.String())
+				So(err, ShouldBeNil)
+
+				tags, err := imgStore.GetImageTags("repo")
+				So(err, ShouldBeNil)
+				So(tags, ShouldBeEmpty)
+
+				// only the image index is added to the index, not the manifests it references
+				indexDigest, err := multiarchImage.Digest()
+				So(err, ShouldBeNil)
+
+				indexContent, err := imgStore.GetIndexContent("multiarch")
+				So(err, ShouldBeNil)
+
+				var index ispec.Index
+
+				err = json.Unmarshal(indexContent, &index)
+				So(err, ShouldBeNil)
+				So(len(index.Manifests), ShouldEqual, 1)
+				So(index.Manifests[0].Digest, ShouldEqual, indexDigest)
+				So(index.Manifests[0].MediaType, ShouldEqual, ispec.MediaTypeImageIndex)
+
+				issues, err = imgStore.CheckConsistency(false)
+				So(err, ShouldBeNil)
+				So(issues, ShouldBeEmpty)
+			})
+		})
+	}
+}
+
+func TestCheckConsistency(t *testing.T) {
+	Convey("Only the stores with the consistency check enabled are checked", t, func() {
+		log := log.NewLogger("debug", "")
+		conf := config.New()
+		conf.Storage.ConsistencyCheck = true
+		conf.Storage.SubPaths = map[string]config.StorageConfig{
+			"/a": {RootDirectory: "/a", ConsistencyCheck: true, Repair: true},
+			"/b": {RootDirectory: "/b"},
+		}
+
+		checked := map[string]bool{}
+
+		newStore := func(rootDir string) storageTypes.ImageStore {
+			return mocks.MockedImageStore{
+				RootDirFn: func() string { return rootDir },
+				CheckConsistencyFn: func(repair bool) ([]storageTypes.RepoIssue, error) {
+					checked[rootDir] = repair
+
+					return []storageTypes.RepoIssue{{Repo: "repo", Issue: "issue", Repaired: repair}}, nil
+				},
+			}
+		}
+
+		storeController := storage.StoreController{
+			DefaultStore: newStore("/"),
+			SubStore: map[string]storageTypes.ImageStore{
+				"/a": newStore("/a"),
+				"/b": newStore("/b"),
+			},
+		}
+
+		err := storage.CheckConsistency(conf, storeController, log)
+		So(err, ShouldBeNil)
+		So(checked, ShouldResemble, map[string]bool{"/": false, "/a": true})
+
+		storeController.DefaultStore = mocks.MockedImageStore{
+			CheckConsistencyFn: func(repair bool) ([]storageTypes.RepoIssue, error) {
+				return nil, errors.New("consistency check error")
+			},
+		}
+
+		err = storage.CheckConsistency(conf, storeController, log)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestMigrateLayout(t *testing.T) {
+	Convey("Stores are migrated to the version of the last migration", t, func() {
+		logger := log.NewLogger("debug", "")
+
+		versions := map[string]int{"/": 0, "/a": 1}
+		applied := []string{}
+
+		newStore := func(rootDir string) storageTypes.ImageStore {
+			return mocks.MockedImageStore{
+				RootDirFn:          func() string { return rootDir },
+				GetLayoutVersionFn: func() (int, error) { return versions[rootDir], nil },
+				SetLayoutVersionFn: func(version int) error {
+					versions[rootDir] = version
+
+					return nil
+				},
+			}
+		}
+
+		newMigration := func(version int) storage.Migration {
+			return storage.Migration{
+				Version:     version,
+				Description: fmt.Sprintf("migration %d", version),
+				Migrate: func(imgStore storageTypes.ImageStore, dryRun bool, _ log.Logger) ([]string, error) {
+					change := fmt.Sprintf("%s to %d", imgStore.RootDir(), version)
+
+					if !dryRun {
+						applied = append(applied, change)
+					}
+
+					return []string{change}, nil
+				},
+			}
+		}
+
+		migrations := []storage.Migration{newMigration(1), newMigration(2)}
+
+		storeController := storage.StoreController{
+			DefaultStore: newStore("/"),
+			SubStore: map[string]storageTypes.ImageStore{
+				"/a":  newStore("/a"),
+				"/a2": newStore("/a"),
+			},
+		}
+
+		Convey("Dry run", func() {
+			reports, err := storage.MigrateLayout(storeController, migrations, true, logger)
+			So(err, ShouldBeNil)
+			So(applied, ShouldBeEmpty)
+			So(versions, ShouldResemble, map[string]int{"/": 0, "/a": 1})
+			So(len(reports), ShouldEqual, 2)
+			So(reports[0].RootDir, ShouldEqual, "/")
+			So(len(reports[0].Migrations), ShouldEqual, 2)
+			So(reports[1].RootDir, ShouldEqual, "/a")
+			So(reports[1].Migrations, ShouldResemble, []storage.AppliedMigration{
+				{Version: 2, Description: "migration 2", Changes: []string{"/a to 2"}},
+			})
+
+			output := &bytes.Buffer{}
+			storage.PrintMigrationReports(reports, true, output)
+			So(output.String(), ShouldContainSubstring, "pending migration to version 2: migration 2")
+			So(output.String(), ShouldContainSubstring, "- /a to 2")
+		})
+
+		Convey("Missing migrations are applied in order, once", func() {
+			_, err := storage.MigrateLayout(storeController, migrations, false, logger)
+			So(err, ShouldBeNil)
+			So(applied, ShouldResemble, []string{"/ to 1", "/ to 2", "/a to 2"})
+			So(versions, ShouldResemble, map[string]int{"/": 2, "/a": 2})
+
+			reports, err := storage.MigrateLayout(storeController, migrations, false, logger)
+			So(err, ShouldBeNil)
+			So(len(applied), ShouldEqual, 3)
+			So(reports[0].Migrations, ShouldBeEmpty)
+
+			output := &bytes.Buffer{}
+			storage.PrintMigrationReports(reports, false, output)
+			So(output.String(), ShouldContainSubstring, "up to date")
+		})
+
+		Convey("The version isn't recorded if a migration fails", func() {
+			migrations[1].Migrate = func(imgStore storageTypes.ImageStore, dryRun bool, _ log.Logger,
+			) ([]string, error) {
+				return nil, errors.New("migration error")
+			}
+
+			_, err := storage.MigrateLayout(storeController, migrations, false, logger)
+			So(err, ShouldNotBeNil)
+			So(versions["/"], ShouldEqual, 1)
+		})
+
+		Convey("Stores with a newer layout are rejected", func() {
+			versions["/a"] = 3
+
+			_, err := storage.MigrateLayout(storeController, migrations, false, logger)
+			So(errors.Is(err, zerr.ErrLayoutVersionNotSupported), ShouldBeTrue)
+		})
+
+		Convey("Migrations have to be in version order", func() {
+			_, err := storage.MigrateLayout(storeController, []storage.Migration{newMigration(2), newMigration(1)},
+				false, logger)
+			So(errors.Is(err, zerr.ErrBadLayoutVersion), ShouldBeTrue)
+		})
+
+		Convey("Layout version errors are returned", func() {
+			storeController.DefaultStore = mocks.MockedImageStore{
+				GetLayoutVersionFn: func() (int, error) { return 0, errors.New("layout version error") },
+			}
+
+			_, err := storage.MigrateLayout(storeController, migrations, false, logger)
+			So(err, ShouldNotBeNil)
+
+			storeController.DefaultStore = mocks.MockedImageStore{
+				SetLayoutVersionFn: func(version int) error { return errors.New("layout version error") },
+			}
+
+			_, err = storage.MigrateLayout(storeController, migrations, false, logger)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("The built-in migrations are in version order", t, func() {
+		for i := 1; i < len(storage.Migrations); i++ {
+			So(storage.Migrations[i].Version, ShouldBeGreaterThan, storage.Migrations[i-1].Version)
+		}
+	})
+}
+
 func TestRoutePrefix(t *testing.T) {
 	Convey("Test route prefix", t, func() {
 		routePrefix := storage.GetRoutePrefix("test:latest")
@@ -917,3 +1232,39 @@ func TestRoutePrefix(t *testing.T) {
 		So(routePrefix, ShouldEqual, "/a")
 	})
 }
+
+func TestUniqueImageStores(t *testing.T) {
+	Convey("Image stores shared by substores are returned once", t, func() {
+		newStore := func(rootDir string, repos ...string) storageTypes.ImageStore {
+			return mocks.MockedImageStore{
+				RootDirFn:         func() string { return rootDir },
+				GetRepositoriesFn: func() ([]string, error) { return repos, nil },
+			}
+		}
+
+		sharedStore := newStore("/shared", "b/repo")
+
+		storeController := storage.StoreController{
+			DefaultStore: newStore("/default", "repo"),
+			SubStore: map[string]storageTypes.ImageStore{
+				"/b": sharedStore,
+				"/c": sharedStore,
+				"/a": newStore("/a", "a/repo"),
+			},
+		}
+
+		rootDirs := []string{}
+
+		for _, imgStore := range storeController.UniqueImageStores() {
+			rootDirs = append(rootDirs, imgStore.RootDir())
+		}
+
+		So(rootDirs, ShouldResemble, []string{"/default", "/a", "/shared"})
+
+		repos, err := storeController.GetRepositories()
+		So(err, ShouldBeNil)
+		So(repos, ShouldResemble, []string{"a/repo", "b/repo", "repo"})
+
+		So(storage.StoreController{}.UniqueImageStores(), ShouldBeEmpty)
+	})
+}
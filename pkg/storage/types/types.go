@@ -7,6 +7,7 @@ import (
 	godigest "github.com/opencontainers/go-digest"
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	artifactspec "github.com/oras-project/artifacts-spec/specs-go/v1"
+	"github.com/rs/zerolog"
 
 	"zotregistry.io/zot/pkg/scheduler"
 )
@@ -21,11 +22,14 @@ type ImageStore interface { //nolint:interfacebloat
 	InitRepo(name string) error
 	ValidateRepo(name string) (bool, error)
 	GetRepositories() ([]string, error)
+	WalkRepositories(last string, walkFn func(repo string) error) error
 	GetNextRepository(repo string) (string, error)
 	GetImageTags(repo string) ([]string, error)
 	GetImageManifest(repo, reference string) ([]byte, godigest.Digest, string, error)
 	PutImageManifest(repo, reference, mediaType string, body []byte) (godigest.Digest, godigest.Digest, error)
 	DeleteImageManifest(repo, reference string, detectCollision bool) error
+	PurgeImageManifest(repo, reference string) error
+	TagImageManifest(repo string, digest godigest.Digest, tags []string) error
 	BlobUploadPath(repo, uuid string) string
 	NewBlobUpload(repo string) (string, error)
 	GetBlobUpload(repo, uuid string) (int64, error)
@@ -38,6 +42,7 @@ type ImageStore interface { //nolint:interfacebloat
 	DeleteBlobUpload(repo, uuid string) error
 	BlobPath(repo string, digest godigest.Digest) string
 	CheckBlob(repo string, digest godigest.Digest) (bool, int64, error)
+	MountBlob(repo, fromRepo string, digest godigest.Digest) (int64, error)
 	GetBlob(repo string, digest godigest.Digest, mediaType string) (io.ReadCloser, int64, error)
 	GetBlobPartial(repo string, digest godigest.Digest, mediaType string, from, to int64,
 	) (io.ReadCloser, int64, int64, error)
@@ -51,4 +56,88 @@ type ImageStore interface { //nolint:interfacebloat
 	RunDedupeBlobs(interval time.Duration, sch *scheduler.Scheduler)
 	RunDedupeForDigest(digest godigest.Digest, dedupe bool, duplicateBlobs []string) error
 	GetNextDigestWithBlobPaths(lastDigests []godigest.Digest) (godigest.Digest, []string, error)
+	SetPinnedImages(pins PinnedImages)
+	CheckConsistency(repair bool) ([]RepoIssue, error)
+	RunCacheMaintenance() error
+	RunCacheMaintenancePeriodically(interval time.Duration, sch *scheduler.Scheduler)
+	SetCommitPolicy(policy string)
+	RunCommitPeriodically(interval time.Duration, sch *scheduler.Scheduler)
+	SetNFSMode(enabled bool)
+	SetDedupeStrategy(strategy string)
+	SetSharedBlobs(enabled bool)
+	SetGCVerifyPercent(percent int)
+	SetGCBatchSize(size int)
+	SetLeases(leases Leases)
+	SetInFlight(inFlight InFlight)
+	SetRetention(retention Retention)
+	SetTagRemovals(removals TagRemovals)
+	SetImmutableTags(immutableTags ImmutableTags)
+	SetIOOptions(options IOOptions)
+	GetLayoutVersion() (int, error)
+	SetLayoutVersion(version int) error
+	WithLogger(logger zerolog.Logger) ImageStore
+}
+
+// IOOptions tune how the local storage writes and reads blobs, the zero value keeps the defaults.
+type IOOptions struct {
+	// size of the buffer uploaded data is written to disk through, 0 to write it as it's received
+	WriteBufferSize int
+	// write the data of uploads past LargeBlobSize bypassing the page cache
+	DirectIO bool
+	// reserve the disk space of upload chunks of known size ending past LargeBlobSize before writing them
+	Preallocate   bool
+	LargeBlobSize int64
+	// readahead hint given to the kernel when reading blobs, empty to leave the kernel default
+	ReadAhead string
+}
+
+// PinnedImages tells which manifests are pinned, pinned manifests are never garbage collected.
+type PinnedImages interface {
+	IsImagePinned(repo string, digest godigest.Digest) (bool, error)
+}
+
+// Leases tells whether external readers, e.g. backup agents, hold a lease on the storage, gc is paused while they do.
+type Leases interface {
+	IsHeld() bool
+}
+
+// InFlight tracks the content written to the repos before any manifest references it, gc doesn't remove it.
+type InFlight interface {
+	// HoldUpload holds a finished blob upload until a manifest referencing it is pushed, or a timeout
+	HoldUpload(repo string, digest godigest.Digest)
+	// ReleaseUploads releases the uploads of the blobs referenced by a pushed manifest
+	ReleaseUploads(repo string, digests ...godigest.Digest)
+	IsInFlight(repo string, digest godigest.Digest) bool
+}
+
+// Retention tells which tags of a repo gc removes, according to the retention policy of the repo.
+type Retention interface {
+	// GetExpiredTags returns the tags to remove among the tags of the repo
+	GetExpiredTags(repo string, tags []TagInfo) []TagInfo
+	// IsDryRun returns whether the expired tags are only logged
+	IsDryRun() bool
+}
+
+// TagRemovals is told about the tags removed by gc, e.g. to remove them from repodb.
+type TagRemovals interface {
+	OnTagsRemoved(repo string, tags []TagInfo)
+}
+
+// ImmutableTags tells which tags can't be moved to another manifest or deleted once pushed.
+type ImmutableTags interface {
+	IsImmutable(repo, tag string) bool
+}
+
+// TagInfo is a tag of a repo and when it was pushed.
+type TagInfo struct {
+	Tag      string
+	Digest   godigest.Digest
+	PushedAt time.Time
+}
+
+// RepoIssue is a repo layout problem found by the storage consistency check.
+type RepoIssue struct {
+	Repo     string `json:"repo"`
+	Issue    string `json:"issue"`
+	Repaired bool   `json:"repaired"`
 }
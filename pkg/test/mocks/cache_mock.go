@@ -17,6 +17,12 @@ type CacheMock struct {
 
 	// Delete a blob from the cachedb.
 	DeleteBlobFn func(digest godigest.Digest, path string) error
+
+	// Checks the cachedb for corruption.
+	CheckIntegrityFn func() (int, error)
+
+	// Rewrites the cachedb to reclaim the space of deleted entries.
+	CompactFn func() (int64, int64, error)
 }
 
 func (cacheMock CacheMock) Name() string {
@@ -58,3 +64,19 @@ func (cacheMock CacheMock) DeleteBlob(digest godigest.Digest, path string) error
 
 	return nil
 }
+
+func (cacheMock CacheMock) CheckIntegrity() (int, error) {
+	if cacheMock.CheckIntegrityFn != nil {
+		return cacheMock.CheckIntegrityFn()
+	}
+
+	return 0, nil
+}
+
+func (cacheMock CacheMock) Compact() (int64, int64, error) {
+	if cacheMock.CompactFn != nil {
+		return cacheMock.CompactFn()
+	}
+
+	return 0, 0, nil
+}
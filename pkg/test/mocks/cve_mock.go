@@ -14,8 +14,14 @@ type CveInfoMock struct {
 	) (cvemodel.ImageCVESummary, error)
 	GetCVESummaryForImageMediaFn func(repo string, digest, mediaType string,
 	) (cvemodel.ImageCVESummary, error)
-	CompareSeveritiesFn func(severity1, severity2 string) int
-	UpdateDBFn          func() error
+	CompareSeveritiesFn   func(severity1, severity2 string) int
+	UpdateDBFn            func() error
+	UpdateJavaDBFn        func() error
+	CancelScansFn         func(repo, digest string) int
+	OnScanCompletedFn     func(hook cvemodel.ScanCompletedFunc)
+	GetScanQueueFn        func() []cvemodel.ScanStatus
+	GetDBStatusFn         func() []cvemodel.DBStatus
+	GetCachedCVESummaryFn func(repo, digest, mediaType string) (cvemodel.ImageCVESummary, bool)
 }
 
 func (cveInfo CveInfoMock) GetImageListForCVE(repo, cveID string) ([]cvemodel.TagInfo, error) {
@@ -82,12 +88,64 @@ func (cveInfo CveInfoMock) UpdateDB() error {
 	return nil
 }
 
+func (cveInfo CveInfoMock) UpdateJavaDB() error {
+	if cveInfo.UpdateJavaDBFn != nil {
+		return cveInfo.UpdateJavaDBFn()
+	}
+
+	return nil
+}
+
+func (cveInfo CveInfoMock) CancelScans(repo, digest string) int {
+	if cveInfo.CancelScansFn != nil {
+		return cveInfo.CancelScansFn(repo, digest)
+	}
+
+	return 0
+}
+
+func (cveInfo CveInfoMock) OnScanCompleted(hook cvemodel.ScanCompletedFunc) {
+	if cveInfo.OnScanCompletedFn != nil {
+		cveInfo.OnScanCompletedFn(hook)
+	}
+}
+
+func (cveInfo CveInfoMock) GetScanQueue() []cvemodel.ScanStatus {
+	if cveInfo.GetScanQueueFn != nil {
+		return cveInfo.GetScanQueueFn()
+	}
+
+	return []cvemodel.ScanStatus{}
+}
+
+func (cveInfo CveInfoMock) GetDBStatus() []cvemodel.DBStatus {
+	if cveInfo.GetDBStatusFn != nil {
+		return cveInfo.GetDBStatusFn()
+	}
+
+	return []cvemodel.DBStatus{}
+}
+
+func (cveInfo CveInfoMock) GetCachedCVESummary(repo, digest, mediaType string) (cvemodel.ImageCVESummary, bool) {
+	if cveInfo.GetCachedCVESummaryFn != nil {
+		return cveInfo.GetCachedCVESummaryFn(repo, digest, mediaType)
+	}
+
+	return cvemodel.ImageCVESummary{}, false
+}
+
 type CveScannerMock struct {
 	IsImageFormatScannableFn func(repo string, reference string) (bool, error)
 	IsImageMediaScannableFn  func(repo string, digest, mediaType string) (bool, error)
 	ScanImageFn              func(image string) (map[string]cvemodel.CVE, error)
 	CompareSeveritiesFn      func(severity1, severity2 string) int
 	UpdateDBFn               func() error
+	UpdateJavaDBFn           func() error
+	CancelScansFn            func(repo, digest string) int
+	OnScanCompletedFn        func(hook cvemodel.ScanCompletedFunc)
+	GetScanQueueFn           func() []cvemodel.ScanStatus
+	GetDBStatusFn            func() []cvemodel.DBStatus
+	GetCachedResultFn        func(digest string) (map[string]cvemodel.CVE, bool)
 }
 
 func (scanner CveScannerMock) IsImageFormatScannable(repo string, reference string) (bool, error) {
@@ -129,3 +187,49 @@ func (scanner CveScannerMock) UpdateDB() error {
 
 	return nil
 }
+
+func (scanner CveScannerMock) UpdateJavaDB() error {
+	if scanner.UpdateJavaDBFn != nil {
+		return scanner.UpdateJavaDBFn()
+	}
+
+	return nil
+}
+
+func (scanner CveScannerMock) CancelScans(repo, digest string) int {
+	if scanner.CancelScansFn != nil {
+		return scanner.CancelScansFn(repo, digest)
+	}
+
+	return 0
+}
+
+func (scanner CveScannerMock) OnScanCompleted(hook cvemodel.ScanCompletedFunc) {
+	if scanner.OnScanCompletedFn != nil {
+		scanner.OnScanCompletedFn(hook)
+	}
+}
+
+func (scanner CveScannerMock) GetScanQueue() []cvemodel.ScanStatus {
+	if scanner.GetScanQueueFn != nil {
+		return scanner.GetScanQueueFn()
+	}
+
+	return []cvemodel.ScanStatus{}
+}
+
+func (scanner CveScannerMock) GetDBStatus() []cvemodel.DBStatus {
+	if scanner.GetDBStatusFn != nil {
+		return scanner.GetDBStatusFn()
+	}
+
+	return []cvemodel.DBStatus{}
+}
+
+func (scanner CveScannerMock) GetCachedResult(digest string) (map[string]cvemodel.CVE, bool) {
+	if scanner.GetCachedResultFn != nil {
+		return scanner.GetCachedResultFn(digest)
+	}
+
+	return nil, false
+}
@@ -7,8 +7,10 @@ import (
 	godigest "github.com/opencontainers/go-digest"
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	artifactspec "github.com/oras-project/artifacts-spec/specs-go/v1"
+	"github.com/rs/zerolog"
 
 	"zotregistry.io/zot/pkg/scheduler"
+	storageTypes "zotregistry.io/zot/pkg/storage/types"
 )
 
 type MockedImageStore struct {
@@ -17,12 +19,15 @@ type MockedImageStore struct {
 	InitRepoFn          func(name string) error
 	ValidateRepoFn      func(name string) (bool, error)
 	GetRepositoriesFn   func() ([]string, error)
+	WalkRepositoriesFn  func(last string, walkFn func(repo string) error) error
 	GetNextRepositoryFn func(repo string) (string, error)
 	GetImageTagsFn      func(repo string) ([]string, error)
 	GetImageManifestFn  func(repo string, reference string) ([]byte, godigest.Digest, string, error)
 	PutImageManifestFn  func(repo string, reference string, mediaType string, body []byte) (godigest.Digest,
 		godigest.Digest, error)
 	DeleteImageManifestFn  func(repo string, reference string, detectCollision bool) error
+	PurgeImageManifestFn   func(repo string, reference string) error
+	TagImageManifestFn     func(repo string, digest godigest.Digest, tags []string) error
 	BlobUploadPathFn       func(repo string, uuid string) string
 	NewBlobUploadFn        func(repo string) (string, error)
 	GetBlobUploadFn        func(repo string, uuid string) (int64, error)
@@ -35,6 +40,7 @@ type MockedImageStore struct {
 	DeleteBlobUploadFn     func(repo string, uuid string) error
 	BlobPathFn             func(repo string, digest godigest.Digest) string
 	CheckBlobFn            func(repo string, digest godigest.Digest) (bool, int64, error)
+	MountBlobFn            func(repo, fromRepo string, digest godigest.Digest) (int64, error)
 	GetBlobPartialFn       func(repo string, digest godigest.Digest, mediaType string, from, to int64,
 	) (io.ReadCloser, int64, int64, error)
 	GetBlobFn          func(repo string, digest godigest.Digest, mediaType string) (io.ReadCloser, int64, error)
@@ -44,12 +50,31 @@ type MockedImageStore struct {
 	GetReferrersFn     func(repo string, digest godigest.Digest, artifactTypes []string) (ispec.Index, error)
 	GetOrasReferrersFn func(repo string, digest godigest.Digest, artifactType string,
 	) ([]artifactspec.Descriptor, error)
-	URLForPathFn                 func(path string) (string, error)
-	RunGCRepoFn                  func(repo string) error
-	RunGCPeriodicallyFn          func(interval time.Duration, sch *scheduler.Scheduler)
-	RunDedupeBlobsFn             func(interval time.Duration, sch *scheduler.Scheduler)
-	RunDedupeForDigestFn         func(digest godigest.Digest, dedupe bool, duplicateBlobs []string) error
-	GetNextDigestWithBlobPathsFn func(lastDigests []godigest.Digest) (godigest.Digest, []string, error)
+	URLForPathFn                      func(path string) (string, error)
+	RunGCRepoFn                       func(repo string) error
+	RunGCPeriodicallyFn               func(interval time.Duration, sch *scheduler.Scheduler)
+	RunDedupeBlobsFn                  func(interval time.Duration, sch *scheduler.Scheduler)
+	RunDedupeForDigestFn              func(digest godigest.Digest, dedupe bool, duplicateBlobs []string) error
+	GetNextDigestWithBlobPathsFn      func(lastDigests []godigest.Digest) (godigest.Digest, []string, error)
+	SetPinnedImagesFn                 func(pins storageTypes.PinnedImages)
+	CheckConsistencyFn                func(repair bool) ([]storageTypes.RepoIssue, error)
+	RunCacheMaintenanceFn             func() error
+	RunCacheMaintenancePeriodicallyFn func(interval time.Duration, sch *scheduler.Scheduler)
+	SetCommitPolicyFn                 func(policy string)
+	RunCommitPeriodicallyFn           func(interval time.Duration, sch *scheduler.Scheduler)
+	SetNFSModeFn                      func(enabled bool)
+	SetDedupeStrategyFn               func(strategy string)
+	SetSharedBlobsFn                  func(enabled bool)
+	SetGCVerifyPercentFn              func(percent int)
+	SetGCBatchSizeFn                  func(size int)
+	SetLeasesFn                       func(leases storageTypes.Leases)
+	SetInFlightFn                     func(inFlight storageTypes.InFlight)
+	SetRetentionFn                    func(retention storageTypes.Retention)
+	SetTagRemovalsFn                  func(removals storageTypes.TagRemovals)
+	SetImmutableTagsFn                func(immutableTags storageTypes.ImmutableTags)
+	SetIOOptionsFn                    func(options storageTypes.IOOptions)
+	GetLayoutVersionFn                func() (int, error)
+	SetLayoutVersionFn                func(version int) error
 }
 
 func (is MockedImageStore) Lock(t *time.Time) {
@@ -104,6 +129,14 @@ func (is MockedImageStore) GetRepositories() ([]string, error) {
 	return []string{}, nil
 }
 
+func (is MockedImageStore) WalkRepositories(last string, walkFn func(repo string) error) error {
+	if is.WalkRepositoriesFn != nil {
+		return is.WalkRepositoriesFn(last, walkFn)
+	}
+
+	return nil
+}
+
 func (is MockedImageStore) GetNextRepository(repo string) (string, error) {
 	if is.GetNextRepositoryFn != nil {
 		return is.GetNextRepositoryFn(repo)
@@ -133,6 +166,14 @@ func (is MockedImageStore) PutImageManifest(
 	return "", "", nil
 }
 
+func (is MockedImageStore) TagImageManifest(repo string, digest godigest.Digest, tags []string) error {
+	if is.TagImageManifestFn != nil {
+		return is.TagImageManifestFn(repo, digest, tags)
+	}
+
+	return nil
+}
+
 func (is MockedImageStore) GetImageTags(name string) ([]string, error) {
 	if is.GetImageTagsFn != nil {
 		return is.GetImageTagsFn(name)
@@ -149,6 +190,14 @@ func (is MockedImageStore) DeleteImageManifest(name string, reference string, de
 	return nil
 }
 
+func (is MockedImageStore) PurgeImageManifest(name string, reference string) error {
+	if is.PurgeImageManifestFn != nil {
+		return is.PurgeImageManifestFn(name, reference)
+	}
+
+	return nil
+}
+
 func (is MockedImageStore) NewBlobUpload(repo string) (string, error) {
 	if is.NewBlobUploadFn != nil {
 		return is.NewBlobUploadFn(repo)
@@ -251,6 +300,14 @@ func (is MockedImageStore) CheckBlob(repo string, digest godigest.Digest) (bool,
 	return true, 0, nil
 }
 
+func (is MockedImageStore) MountBlob(repo, fromRepo string, digest godigest.Digest) (int64, error) {
+	if is.MountBlobFn != nil {
+		return is.MountBlobFn(repo, fromRepo, digest)
+	}
+
+	return 0, nil
+}
+
 func (is MockedImageStore) GetBlobPartial(repo string, digest godigest.Digest, mediaType string, from, to int64,
 ) (io.ReadCloser, int64, int64, error) {
 	if is.GetBlobPartialFn != nil {
@@ -360,3 +417,130 @@ func (is MockedImageStore) GetNextDigestWithBlobPaths(lastDigests []godigest.Dig
 
 	return "", []string{}, nil
 }
+
+func (is MockedImageStore) SetPinnedImages(pins storageTypes.PinnedImages) {
+	if is.SetPinnedImagesFn != nil {
+		is.SetPinnedImagesFn(pins)
+	}
+}
+
+func (is MockedImageStore) CheckConsistency(repair bool) ([]storageTypes.RepoIssue, error) {
+	if is.CheckConsistencyFn != nil {
+		return is.CheckConsistencyFn(repair)
+	}
+
+	return []storageTypes.RepoIssue{}, nil
+}
+
+func (is MockedImageStore) RunCacheMaintenance() error {
+	if is.RunCacheMaintenanceFn != nil {
+		return is.RunCacheMaintenanceFn()
+	}
+
+	return nil
+}
+
+func (is MockedImageStore) RunCacheMaintenancePeriodically(interval time.Duration, sch *scheduler.Scheduler) {
+	if is.RunCacheMaintenancePeriodicallyFn != nil {
+		is.RunCacheMaintenancePeriodicallyFn(interval, sch)
+	}
+}
+
+func (is MockedImageStore) SetCommitPolicy(policy string) {
+	if is.SetCommitPolicyFn != nil {
+		is.SetCommitPolicyFn(policy)
+	}
+}
+
+func (is MockedImageStore) RunCommitPeriodically(interval time.Duration, sch *scheduler.Scheduler) {
+	if is.RunCommitPeriodicallyFn != nil {
+		is.RunCommitPeriodicallyFn(interval, sch)
+	}
+}
+
+func (is MockedImageStore) SetNFSMode(enabled bool) {
+	if is.SetNFSModeFn != nil {
+		is.SetNFSModeFn(enabled)
+	}
+}
+
+func (is MockedImageStore) SetDedupeStrategy(strategy string) {
+	if is.SetDedupeStrategyFn != nil {
+		is.SetDedupeStrategyFn(strategy)
+	}
+}
+
+func (is MockedImageStore) SetSharedBlobs(enabled bool) {
+	if is.SetSharedBlobsFn != nil {
+		is.SetSharedBlobsFn(enabled)
+	}
+}
+
+func (is MockedImageStore) SetGCVerifyPercent(percent int) {
+	if is.SetGCVerifyPercentFn != nil {
+		is.SetGCVerifyPercentFn(percent)
+	}
+}
+
+func (is MockedImageStore) SetGCBatchSize(size int) {
+	if is.SetGCBatchSizeFn != nil {
+		is.SetGCBatchSizeFn(size)
+	}
+}
+
+func (is MockedImageStore) SetLeases(leases storageTypes.Leases) {
+	if is.SetLeasesFn != nil {
+		is.SetLeasesFn(leases)
+	}
+}
+
+func (is MockedImageStore) SetImmutableTags(immutableTags storageTypes.ImmutableTags) {
+	if is.SetImmutableTagsFn != nil {
+		is.SetImmutableTagsFn(immutableTags)
+	}
+}
+
+func (is MockedImageStore) SetInFlight(inFlight storageTypes.InFlight) {
+	if is.SetInFlightFn != nil {
+		is.SetInFlightFn(inFlight)
+	}
+}
+
+func (is MockedImageStore) SetRetention(retention storageTypes.Retention) {
+	if is.SetRetentionFn != nil {
+		is.SetRetentionFn(retention)
+	}
+}
+
+func (is MockedImageStore) SetTagRemovals(removals storageTypes.TagRemovals) {
+	if is.SetTagRemovalsFn != nil {
+		is.SetTagRemovalsFn(removals)
+	}
+}
+
+func (is MockedImageStore) SetIOOptions(options storageTypes.IOOptions) {
+	if is.SetIOOptionsFn != nil {
+		is.SetIOOptionsFn(options)
+	}
+}
+
+func (is MockedImageStore) GetLayoutVersion() (int, error) {
+	if is.GetLayoutVersionFn != nil {
+		return is.GetLayoutVersionFn()
+	}
+
+	return 0, nil
+}
+
+func (is MockedImageStore) SetLayoutVersion(version int) error {
+	if is.SetLayoutVersionFn != nil {
+		return is.SetLayoutVersionFn(version)
+	}
+
+	return nil
+}
+
+// WithLogger returns the mock itself, so that the stores of the requests keep its functions.
+func (is MockedImageStore) WithLogger(logger zerolog.Logger) storageTypes.ImageStore {
+	return is
+}
@@ -56,6 +56,8 @@ type RepoDBMock struct {
 
 	IncrementImageDownloadsFn func(repo string, reference string) error
 
+	IncrementRepoPushesFn func(repo string) error
+
 	UpdateSignaturesValidityFn func(repo string, manifestDigest godigest.Digest) error
 
 	AddManifestSignatureFn func(repo string, signedManifestDigest godigest.Digest, sm repodb.SignatureMetadata) error
@@ -95,6 +97,42 @@ type RepoDBMock struct {
 
 	ToggleBookmarkRepoFn func(ctx context.Context, repo string) (repodb.ToggleState, error)
 
+	AddUserActivityFn func(ctx context.Context, activity repodb.UserActivity, maxEntries int) error
+
+	GetUserActivityFn func(ctx context.Context) ([]repodb.UserActivity, error)
+
+	PinImageFn func(ctx context.Context, repo string, reference string) (repodb.PinInfo, error)
+
+	UnpinImageFn func(repo string, reference string) error
+
+	IsImagePinnedFn func(repo string, digest godigest.Digest) (bool, error)
+
+	PatchRegistryAnnotationsFn func(repo string, reference string, patch map[string]*string,
+	) (godigest.Digest, map[string]string, error)
+
+	SetCVEAcknowledgementFn func(repo string, reference string, cveID string, ack repodb.CVEAcknowledgement,
+	) (godigest.Digest, error)
+
+	DeleteCVEAcknowledgementFn func(repo string, reference string, cveID string) (godigest.Digest, error)
+
+	SetVulnerabilitySummaryFn func(repo string, digest godigest.Digest, summary repodb.VulnerabilitySummary) error
+
+	SetSBOMSummaryFn func(repo string, subjectDigest godigest.Digest, summary repodb.SBOMSummary) error
+
+	DeleteSBOMSummaryFn func(repo string, subjectDigest godigest.Digest, sbomDigest godigest.Digest) error
+
+	SetManifestAttestationsFn func(repo string, subjectDigest godigest.Digest,
+		attestations []repodb.AttestationInfo) error
+
+	DeleteManifestAttestationsFn func(repo string, subjectDigest godigest.Digest,
+		attestationsDigest godigest.Digest) error
+
+	SetLintViolationsFn func(repo string, manifestDigest godigest.Digest, violations []string) error
+
+	SetDigestAliasFn func(repo string, alias godigest.Digest, manifestDigest godigest.Digest) error
+
+	ResolveDigestAliasFn func(repo string, alias godigest.Digest) (godigest.Digest, error)
+
 	PatchDBFn func() error
 }
 
@@ -222,6 +260,14 @@ func (sdm RepoDBMock) IncrementImageDownloads(repo string, reference string) err
 	return nil
 }
 
+func (sdm RepoDBMock) IncrementRepoPushes(repo string) error {
+	if sdm.IncrementRepoPushesFn != nil {
+		return sdm.IncrementRepoPushesFn(repo)
+	}
+
+	return nil
+}
+
 func (sdm RepoDBMock) UpdateSignaturesValidity(repo string, manifestDigest godigest.Digest) error {
 	if sdm.UpdateSignaturesValidityFn != nil {
 		return sdm.UpdateSignaturesValidityFn(repo, manifestDigest)
@@ -414,3 +460,141 @@ func (sdm RepoDBMock) ToggleBookmarkRepo(ctx context.Context, repo string) (repo
 
 	return repodb.NotChanged, nil
 }
+
+func (sdm RepoDBMock) AddUserActivity(ctx context.Context, activity repodb.UserActivity, maxEntries int) error {
+	if sdm.AddUserActivityFn != nil {
+		return sdm.AddUserActivityFn(ctx, activity, maxEntries)
+	}
+
+	return nil
+}
+
+func (sdm RepoDBMock) GetUserActivity(ctx context.Context) ([]repodb.UserActivity, error) {
+	if sdm.GetUserActivityFn != nil {
+		return sdm.GetUserActivityFn(ctx)
+	}
+
+	return []repodb.UserActivity{}, nil
+}
+
+func (sdm RepoDBMock) PinImage(ctx context.Context, repo string, reference string) (repodb.PinInfo, error) {
+	if sdm.PinImageFn != nil {
+		return sdm.PinImageFn(ctx, repo, reference)
+	}
+
+	return repodb.PinInfo{}, nil
+}
+
+func (sdm RepoDBMock) UnpinImage(repo string, reference string) error {
+	if sdm.UnpinImageFn != nil {
+		return sdm.UnpinImageFn(repo, reference)
+	}
+
+	return nil
+}
+
+func (sdm RepoDBMock) IsImagePinned(repo string, digest godigest.Digest) (bool, error) {
+	if sdm.IsImagePinnedFn != nil {
+		return sdm.IsImagePinnedFn(repo, digest)
+	}
+
+	return false, nil
+}
+
+func (sdm RepoDBMock) PatchRegistryAnnotations(repo string, reference string, patch map[string]*string,
+) (godigest.Digest, map[string]string, error) {
+	if sdm.PatchRegistryAnnotationsFn != nil {
+		return sdm.PatchRegistryAnnotationsFn(repo, reference, patch)
+	}
+
+	return "", map[string]string{}, nil
+}
+
+func (sdm RepoDBMock) SetCVEAcknowledgement(repo string, reference string, cveID string,
+	ack repodb.CVEAcknowledgement,
+) (godigest.Digest, error) {
+	if sdm.SetCVEAcknowledgementFn != nil {
+		return sdm.SetCVEAcknowledgementFn(repo, reference, cveID, ack)
+	}
+
+	return "", nil
+}
+
+func (sdm RepoDBMock) DeleteCVEAcknowledgement(repo string, reference string, cveID string,
+) (godigest.Digest, error) {
+	if sdm.DeleteCVEAcknowledgementFn != nil {
+		return sdm.DeleteCVEAcknowledgementFn(repo, reference, cveID)
+	}
+
+	return "", nil
+}
+
+func (sdm RepoDBMock) SetVulnerabilitySummary(repo string, digest godigest.Digest,
+	summary repodb.VulnerabilitySummary,
+) error {
+	if sdm.SetVulnerabilitySummaryFn != nil {
+		return sdm.SetVulnerabilitySummaryFn(repo, digest, summary)
+	}
+
+	return nil
+}
+
+func (sdm RepoDBMock) SetSBOMSummary(repo string, subjectDigest godigest.Digest, summary repodb.SBOMSummary,
+) error {
+	if sdm.SetSBOMSummaryFn != nil {
+		return sdm.SetSBOMSummaryFn(repo, subjectDigest, summary)
+	}
+
+	return nil
+}
+
+func (sdm RepoDBMock) DeleteSBOMSummary(repo string, subjectDigest, sbomDigest godigest.Digest) error {
+	if sdm.DeleteSBOMSummaryFn != nil {
+		return sdm.DeleteSBOMSummaryFn(repo, subjectDigest, sbomDigest)
+	}
+
+	return nil
+}
+
+func (sdm RepoDBMock) SetLintViolations(repo string, manifestDigest godigest.Digest, violations []string) error {
+	if sdm.SetLintViolationsFn != nil {
+		return sdm.SetLintViolationsFn(repo, manifestDigest, violations)
+	}
+
+	return nil
+}
+
+func (sdm RepoDBMock) SetDigestAlias(repo string, alias godigest.Digest, manifestDigest godigest.Digest) error {
+	if sdm.SetDigestAliasFn != nil {
+		return sdm.SetDigestAliasFn(repo, alias, manifestDigest)
+	}
+
+	return nil
+}
+
+func (sdm RepoDBMock) ResolveDigestAlias(repo string, alias godigest.Digest) (godigest.Digest, error) {
+	if sdm.ResolveDigestAliasFn != nil {
+		return sdm.ResolveDigestAliasFn(repo, alias)
+	}
+
+	return "", nil
+}
+
+func (sdm RepoDBMock) SetManifestAttestations(repo string, subjectDigest godigest.Digest,
+	attestations []repodb.AttestationInfo,
+) error {
+	if sdm.SetManifestAttestationsFn != nil {
+		return sdm.SetManifestAttestationsFn(repo, subjectDigest, attestations)
+	}
+
+	return nil
+}
+
+func (sdm RepoDBMock) DeleteManifestAttestations(repo string, subjectDigest, attestationsDigest godigest.Digest,
+) error {
+	if sdm.DeleteManifestAttestationsFn != nil {
+		return sdm.DeleteManifestAttestationsFn(repo, subjectDigest, attestationsDigest)
+	}
+
+	return nil
+}
@@ -105,6 +105,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "summary": "List image repositories",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "limit entries for pagination",
+                        "name": "n",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "last repository value for pagination",
+                        "name": "last",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -112,6 +126,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.RepositoryList"
                         }
                     },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "internal server error",
                         "schema": {
@@ -318,7 +338,7 @@ const docTemplate = `{
         },
         "/v2/{name}/blobs/uploads": {
             "post": {
-                "description": "Create a new image blob/layer upload",
+                "description": "Create a new image blob/layer upload, or mount a blob from another repository",
                 "consumes": [
                     "application/json"
                 ],
@@ -333,9 +353,33 @@ const docTemplate = `{
                         "name": "name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "digest of the blob to mount",
+                        "name": "mount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "repository to mount the blob from",
+                        "name": "from",
+                        "in": "query"
                     }
                 ],
                 "responses": {
+                    "201": {
+                        "description": "created",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/v2/{name}/blobs/{digest}"
+                            }
+                        }
+                    },
                     "202": {
                         "description": "accepted",
                         "schema": {
@@ -589,7 +633,8 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "produces": [
-                    "application/vnd.oci.image.layer.v1.tar+gzip"
+                    "application/vnd.oci.image.layer.v1.tar+gzip",
+                    "application/vnd.oci.image.layer.v1.tar+zstd"
                 ],
                 "summary": "Get image blob/layer",
                 "parameters": [